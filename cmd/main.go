@@ -1,6 +1,7 @@
 package main
 
 import (
+	"context"
 	"encoding/json"
 	"flag"
 	"fmt"
@@ -8,13 +9,17 @@ import (
 	"os"
 	"path/filepath"
 	"strings"
+	"time"
 
 	"github.com/gomcpgo/mcp/pkg/handler"
 	"github.com/gomcpgo/mcp/pkg/protocol"
 	"github.com/gomcpgo/mcp/pkg/server"
 	"github.com/gomcpgo/docgen/pkg/config"
+	"github.com/gomcpgo/docgen/pkg/export"
 	docgenHandler "github.com/gomcpgo/docgen/pkg/handler"
+	"github.com/gomcpgo/docgen/pkg/preview"
 	"github.com/gomcpgo/docgen/pkg/types"
+	"github.com/gomcpgo/docgen/pkg/watch"
 	"gopkg.in/yaml.v3"
 )
 
@@ -31,6 +36,7 @@ func main() {
 	versionFlag := flag.Bool("version", false, "Show version information")
 	exportDoc := flag.String("export", "", "Export existing document by ID (format: documentID,format). Example: -export my-doc-123,pdf")
 	styleFile := flag.String("style", "", "Custom style file to use for export (JSON or YAML). Example: -style '/path/to/style.json'")
+	serveAddr := flag.String("serve", "", "Serve HTML previews of documents over HTTP instead of running the MCP server. Example: -serve :8080")
 	flag.Parse()
 
 	if *versionFlag {
@@ -61,6 +67,52 @@ func main() {
 	if err != nil {
 		log.Fatalf("Failed to create docgen handler: %v", err)
 	}
+	docgenHandler.SetVersion(Version)
+
+	// Move section content out of chapter metadata and chapters out of numbered
+	// directories, as written by older versions
+	movedSections, movedChapters, err := docgenHandler.GetManager().MigrateDocuments(context.Background())
+	if err != nil {
+		log.Printf("Failed to migrate documents: %v", err)
+	}
+	if movedSections > 0 {
+		log.Printf("Moved the content of %d section(s) from chapter metadata into section files", movedSections)
+	}
+	if movedChapters > 0 {
+		log.Printf("Moved %d chapter(s) from numbered directories into directories of their own", movedChapters)
+	}
+
+	// Clean up intermediate files left behind by interrupted exports, and
+	// sandbox documents once they expire
+	janitor := export.NewJanitor(cfg)
+	janitor.AddTask(func(now time.Time) { docgenHandler.SweepSandboxes(now) })
+	janitor.Start()
+	defer janitor.Stop()
+
+	// Preview-only mode
+	if *serveAddr != "" {
+		previewServer := preview.NewServer(cfg, docgenHandler.GetStorage(), docgenHandler.ExportDocument)
+		previewServer.SetApproximateRenderer(docgenHandler.RenderApproximateHTML, docgenHandler.GetExporter().PandocAvailable)
+		log.Fatalf("Preview server error: %v", previewServer.ListenAndServe(*serveAddr))
+	}
+
+	// Start the preview server alongside the MCP server if configured
+	if cfg.PreviewAddr != "" {
+		previewServer := preview.NewServer(cfg, docgenHandler.GetStorage(), docgenHandler.ExportDocument)
+		previewServer.SetApproximateRenderer(docgenHandler.RenderApproximateHTML, docgenHandler.GetExporter().PandocAvailable)
+		go func() {
+			if err := previewServer.ListenAndServe(cfg.PreviewAddr); err != nil {
+				log.Printf("Preview server error: %v", err)
+			}
+		}()
+	}
+
+	// Start the watcher if automatic re-export is enabled
+	if cfg.WatchEnabled {
+		watcher := watch.NewWatcher(cfg, docgenHandler.ExportDocument)
+		watcher.Start()
+		defer watcher.Stop()
+	}
 
 	// Create handler registry
 	registry := handler.NewHandlerRegistry()
@@ -344,11 +396,11 @@ Security should be built into your MCP server from the ground up:
 			continue
 		}
 
-		// For this demo, we'll simulate adding content by directly writing to the file
+		// For this demo, we'll simulate adding content by writing the chapter file directly
 		// In a real implementation, you'd use the add_section or update_chapter tools
-		chapterPath := filepath.Join(os.Getenv("DOCGEN_ROOT_DIR"), docID, "chapters", fmt.Sprintf("%02d", i+1), "chapter.md")
-		os.MkdirAll(filepath.Dir(chapterPath), 0755)
-		os.WriteFile(chapterPath, []byte(chapter.content), 0644)
+		if err := h.GetStorage().SaveChapterContent(docID, i+1, chapter.content); err != nil {
+			fmt.Printf("   ⚠️  Failed to write chapter %d: %v\n", i+1, err)
+		}
 	}
 }
 
@@ -407,9 +459,9 @@ func getDocumentStructure(h *docgenHandler.DocGenHandler, docID string) *types.M
 	// For demo purposes, return a mock structure
 	return &types.Manifest{
 		Document: types.Document{
-			ID:     types.DocumentID(docID),
-			Title:  "Sample Document",
-			Author: "Test Author",
+			ID:      types.DocumentID(docID),
+			Title:   "Sample Document",
+			Authors: types.AuthorList{{Name: "Test Author"}},
 			Chapters: []types.Chapter{
 				{Number: 1, Title: "Introduction"},
 				{Number: 2, Title: "Getting Started"},
@@ -4,6 +4,7 @@ go 1.21
 
 require (
 	github.com/gomcpgo/mcp v0.0.0-00010101000000-000000000000
+	github.com/yuin/goldmark v1.7.8
 	gopkg.in/yaml.v3 v3.0.1
 )
 
@@ -0,0 +1,229 @@
+// Package annotate draws callouts (arrows, boxes and numbered steps) on
+// screenshots and other images.
+package annotate
+
+import (
+	"bytes"
+	"fmt"
+	"image"
+	"image/color"
+	"image/draw"
+	_ "image/gif"  // decode GIF sources
+	_ "image/jpeg" // decode JPEG sources
+	"image/png"
+	"math"
+	"strconv"
+
+	"github.com/gomcpgo/docgen/pkg/types"
+)
+
+// defaultColor is a red that stands out on most user interfaces
+var defaultColor = color.RGBA{R: 0xE5, G: 0x39, B: 0x35, A: 0xFF}
+
+// digitGlyphs are 3x5 bitmaps of the digits used for step numbers
+var digitGlyphs = [10][5]string{
+	{"111", "101", "101", "101", "111"},
+	{"010", "110", "010", "010", "111"},
+	{"111", "001", "111", "100", "111"},
+	{"111", "001", "111", "001", "111"},
+	{"101", "101", "111", "001", "001"},
+	{"111", "100", "111", "001", "111"},
+	{"111", "100", "111", "101", "111"},
+	{"111", "001", "001", "001", "001"},
+	{"111", "101", "111", "101", "111"},
+	{"111", "101", "111", "001", "111"},
+}
+
+// Image decodes a PNG, JPEG or GIF image, draws the annotations on it and
+// returns the result as a PNG
+func Image(data []byte, annotations []types.Annotation) ([]byte, error) {
+	src, _, err := image.Decode(bytes.NewReader(data))
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode image (PNG, JPEG and GIF are supported): %w", err)
+	}
+	annotated, err := Draw(src, annotations)
+	if err != nil {
+		return nil, err
+	}
+	var out bytes.Buffer
+	if err := png.Encode(&out, annotated); err != nil {
+		return nil, fmt.Errorf("failed to encode image: %w", err)
+	}
+	return out.Bytes(), nil
+}
+
+// Draw returns a copy of src with the annotations drawn on it, in order. Line
+// widths and step sizes scale with the image.
+func Draw(src image.Image, annotations []types.Annotation) (*image.RGBA, error) {
+	bounds := src.Bounds()
+	img := image.NewRGBA(image.Rect(0, 0, bounds.Dx(), bounds.Dy()))
+	draw.Draw(img, img.Bounds(), src, bounds.Min, draw.Src)
+
+	size := bounds.Dx()
+	if bounds.Dy() < size {
+		size = bounds.Dy()
+	}
+	thickness := maxInt(3, size/150)
+
+	step := 0
+	for i, annotation := range annotations {
+		if err := annotation.Validate(); err != nil {
+			return nil, fmt.Errorf("annotation %d: %w", i+1, err)
+		}
+		points := []image.Point{{annotation.X, annotation.Y}}
+		if annotation.Kind == types.AnnotationArrow {
+			points = append(points, image.Point{annotation.ToX, annotation.ToY})
+		}
+		for _, point := range points {
+			if !point.In(img.Bounds()) {
+				return nil, fmt.Errorf("annotation %d: (%d, %d) is outside the %dx%d image", i+1, point.X, point.Y, bounds.Dx(), bounds.Dy())
+			}
+		}
+
+		c := parseColor(annotation.Color)
+		switch annotation.Kind {
+		case types.AnnotationArrow:
+			drawArrow(img, annotation.X, annotation.Y, annotation.ToX, annotation.ToY, thickness, c)
+		case types.AnnotationBox:
+			x1, y1 := annotation.X+annotation.Width, annotation.Y+annotation.Height
+			drawLine(img, annotation.X, annotation.Y, x1, annotation.Y, thickness, c)
+			drawLine(img, x1, annotation.Y, x1, y1, thickness, c)
+			drawLine(img, x1, y1, annotation.X, y1, thickness, c)
+			drawLine(img, annotation.X, y1, annotation.X, annotation.Y, thickness, c)
+		case types.AnnotationStep:
+			step++
+			if annotation.Number > 0 {
+				step = annotation.Number
+			}
+			drawStep(img, annotation.X, annotation.Y, maxInt(12, size/30), step, c)
+		}
+	}
+	return img, nil
+}
+
+// drawArrow draws a line ending in a filled head at (x1, y1)
+func drawArrow(img *image.RGBA, x0, y0, x1, y1, thickness int, c color.RGBA) {
+	dx, dy := float64(x1-x0), float64(y1-y0)
+	length := math.Hypot(dx, dy)
+	head := math.Min(float64(maxInt(12, 5*thickness)), length)
+	ux, uy := dx/length, dy/length
+
+	// The shaft stops inside the head so its end doesn't show past the tip
+	baseX, baseY := float64(x1)-ux*head, float64(y1)-uy*head
+	shaftX, shaftY := float64(x1)-ux*head/2, float64(y1)-uy*head/2
+	drawLine(img, x0, y0, round(shaftX), round(shaftY), thickness, c)
+
+	half := head * 0.6
+	fillTriangle(img,
+		float64(x1), float64(y1),
+		baseX-uy*half, baseY+ux*half,
+		baseX+uy*half, baseY-ux*half, c)
+}
+
+// drawStep draws a numbered disc with a white rim
+func drawStep(img *image.RGBA, cx, cy, radius, number int, c color.RGBA) {
+	white := color.RGBA{R: 0xFF, G: 0xFF, B: 0xFF, A: 0xFF}
+	fillDisc(img, cx, cy, radius+2, white)
+	fillDisc(img, cx, cy, radius, c)
+
+	digits := strconv.Itoa(number)
+	// Digits are 3x5 cells with a cell between them, scaled to fit the disc
+	scale := maxInt(1, radius/5)
+	for scale > 1 && (4*len(digits)-1)*scale > radius*3/2 {
+		scale--
+	}
+	width, height := (4*len(digits)-1)*scale, 5*scale
+	left, top := cx-width/2, cy-height/2
+	for i, digit := range digits {
+		glyph := digitGlyphs[digit-'0']
+		for row, line := range glyph {
+			for col, bit := range line {
+				if bit != '1' {
+					continue
+				}
+				x := left + (4*i+col)*scale
+				y := top + row*scale
+				draw.Draw(img, image.Rect(x, y, x+scale, y+scale), image.NewUniform(white), image.Point{}, draw.Src)
+			}
+		}
+	}
+}
+
+// drawLine draws a line of the given thickness with round ends
+func drawLine(img *image.RGBA, x0, y0, x1, y1, thickness int, c color.RGBA) {
+	steps := maxInt(absInt(x1-x0), absInt(y1-y0))
+	radius := thickness / 2
+	for i := 0; i <= steps; i++ {
+		t := 0.0
+		if steps > 0 {
+			t = float64(i) / float64(steps)
+		}
+		fillDisc(img, round(float64(x0)+t*float64(x1-x0)), round(float64(y0)+t*float64(y1-y0)), radius, c)
+	}
+}
+
+// fillDisc fills a circle, clipped to the image
+func fillDisc(img *image.RGBA, cx, cy, radius int, c color.RGBA) {
+	for y := cy - radius; y <= cy+radius; y++ {
+		for x := cx - radius; x <= cx+radius; x++ {
+			if (x-cx)*(x-cx)+(y-cy)*(y-cy) <= radius*radius {
+				img.SetRGBA(x, y, c)
+			}
+		}
+	}
+}
+
+// fillTriangle fills the triangle with corners a, b and c, clipped to the image
+func fillTriangle(img *image.RGBA, ax, ay, bx, by, cx, cy float64, c color.RGBA) {
+	minX, maxX := math.Floor(math.Min(ax, math.Min(bx, cx))), math.Ceil(math.Max(ax, math.Max(bx, cx)))
+	minY, maxY := math.Floor(math.Min(ay, math.Min(by, cy))), math.Ceil(math.Max(ay, math.Max(by, cy)))
+	edge := func(x0, y0, x1, y1, px, py float64) float64 {
+		return (x1-x0)*(py-y0) - (y1-y0)*(px-x0)
+	}
+	for y := minY; y <= maxY; y++ {
+		for x := minX; x <= maxX; x++ {
+			d1 := edge(ax, ay, bx, by, x, y)
+			d2 := edge(bx, by, cx, cy, x, y)
+			d3 := edge(cx, cy, ax, ay, x, y)
+			negative := d1 < 0 || d2 < 0 || d3 < 0
+			positive := d1 > 0 || d2 > 0 || d3 > 0
+			if !(negative && positive) {
+				img.SetRGBA(int(x), int(y), c)
+			}
+		}
+	}
+}
+
+// parseColor reads a #RRGGBB or #RGB color, falling back to the default red
+func parseColor(hex string) color.RGBA {
+	if hex == "" {
+		return defaultColor
+	}
+	hex = hex[1:]
+	if len(hex) == 3 {
+		hex = string([]byte{hex[0], hex[0], hex[1], hex[1], hex[2], hex[2]})
+	}
+	value, err := strconv.ParseUint(hex, 16, 32)
+	if err != nil {
+		return defaultColor
+	}
+	return color.RGBA{R: uint8(value >> 16), G: uint8(value >> 8), B: uint8(value), A: 0xFF}
+}
+
+func round(v float64) int {
+	return int(math.Round(v))
+}
+
+func maxInt(a, b int) int {
+	if a > b {
+		return a
+	}
+	return b
+}
+
+func absInt(v int) int {
+	if v < 0 {
+		return -v
+	}
+	return v
+}
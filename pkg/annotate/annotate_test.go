@@ -0,0 +1,101 @@
+package annotate
+
+import (
+	"bytes"
+	"image"
+	"image/color"
+	"image/draw"
+	"image/png"
+	"strings"
+	"testing"
+
+	"github.com/gomcpgo/docgen/pkg/types"
+)
+
+var white = color.RGBA{R: 0xFF, G: 0xFF, B: 0xFF, A: 0xFF}
+
+func blankImage(width, height int) *image.RGBA {
+	img := image.NewRGBA(image.Rect(0, 0, width, height))
+	draw.Draw(img, img.Bounds(), image.NewUniform(white), image.Point{}, draw.Src)
+	return img
+}
+
+func TestDraw(t *testing.T) {
+	src := blankImage(400, 300)
+	blue := color.RGBA{B: 0xFF, A: 0xFF}
+
+	img, err := Draw(src, []types.Annotation{
+		{Kind: types.AnnotationBox, X: 20, Y: 20, Width: 100, Height: 50},
+		{Kind: types.AnnotationArrow, X: 300, Y: 250, ToX: 200, ToY: 150, Color: "#0000FF"},
+		{Kind: types.AnnotationStep, X: 350, Y: 50},
+	})
+	if err != nil {
+		t.Fatalf("Draw() error = %v", err)
+	}
+
+	// Box edges are drawn and its inside is left alone
+	if img.RGBAAt(20, 40) != defaultColor || img.RGBAAt(70, 70) != defaultColor {
+		t.Errorf("Expected the box outline in the default color")
+	}
+	if img.RGBAAt(70, 45) != white {
+		t.Errorf("The inside of the box should be untouched")
+	}
+
+	// The arrow runs from its tail to its tip in its own color
+	if img.RGBAAt(300, 250) != blue || img.RGBAAt(250, 200) != blue || img.RGBAAt(201, 151) != blue {
+		t.Errorf("Expected a blue arrow from (300, 250) to (200, 150)")
+	}
+
+	// The step disc is filled around white digits
+	if img.RGBAAt(350+12, 50) != defaultColor {
+		t.Errorf("Expected the step disc in the default color")
+	}
+	if img.RGBAAt(350, 50) != white {
+		t.Errorf("Expected the step number drawn in white")
+	}
+
+	// The source is copied, not drawn on
+	if src.RGBAAt(20, 40) != white {
+		t.Errorf("Draw() should not change the source image")
+	}
+}
+
+func TestDraw_Errors(t *testing.T) {
+	tests := []struct {
+		annotation types.Annotation
+		wantErr    string
+	}{
+		{types.Annotation{Kind: "circle"}, "invalid annotation kind"},
+		{types.Annotation{Kind: types.AnnotationBox, X: 10, Y: 10}, "positive width and height"},
+		{types.Annotation{Kind: types.AnnotationArrow, X: 10, Y: 10, ToX: 10, ToY: 10}, "to_x and to_y"},
+		{types.Annotation{Kind: types.AnnotationArrow, X: 10, Y: 10, ToX: 500, ToY: 10}, "(500, 10) is outside the 100x100 image"},
+		{types.Annotation{Kind: types.AnnotationStep, X: 10, Y: 10, Color: "red"}, "invalid annotation color"},
+	}
+	for _, tt := range tests {
+		_, err := Draw(blankImage(100, 100), []types.Annotation{tt.annotation})
+		if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+			t.Errorf("Draw(%+v) error = %v, want %q", tt.annotation, err, tt.wantErr)
+		}
+	}
+}
+
+func TestImage(t *testing.T) {
+	var data bytes.Buffer
+	png.Encode(&data, blankImage(200, 100))
+
+	out, err := Image(data.Bytes(), []types.Annotation{{Kind: types.AnnotationStep, X: 100, Y: 50, Number: 12}})
+	if err != nil {
+		t.Fatalf("Image() error = %v", err)
+	}
+	img, err := png.Decode(bytes.NewReader(out))
+	if err != nil {
+		t.Fatalf("Image() did not return a PNG: %v", err)
+	}
+	if img.Bounds().Dx() != 200 || img.Bounds().Dy() != 100 {
+		t.Errorf("Image() size = %v, want 200x100", img.Bounds())
+	}
+
+	if _, err := Image([]byte("<svg/>"), nil); err == nil {
+		t.Error("Expected an error for an unsupported image")
+	}
+}
@@ -5,6 +5,7 @@ import (
 	"os"
 	"path/filepath"
 	"strconv"
+	"strings"
 	"time"
 )
 
@@ -22,23 +23,220 @@ type Config struct {
 	// MaxDocuments is the maximum number of documents allowed
 	MaxDocuments int
 	
+	// MaxSandboxDocuments is the maximum number of sandbox documents, which
+	// don't count toward MaxDocuments, and SandboxTTL how long they are kept
+	MaxSandboxDocuments int
+	SandboxTTL          time.Duration
+	
 	// ExportsDir is the directory for exported documents (within RootDir)
 	ExportsDir string
 	
 	// MaxFileSize is the maximum file size for uploads in bytes
 	MaxFileSize int64
 	
+	// MaxTotalSize and MaxDocumentSize limit the disk space used by the root
+	// directory and by each document, exports included (0 means unlimited)
+	MaxTotalSize    int64
+	MaxDocumentSize int64
+	
+	// StrictMarkdown rejects section content with structural markdown problems
+	// instead of saving it with warnings
+	StrictMarkdown bool
+	
 	// ExportTimeout is the timeout for export operations
 	ExportTimeout time.Duration
+	
+	// PreflightThreshold is the estimated export time above which export_document
+	// returns a preflight summary and waits for confirm=true (0 disables it)
+	PreflightThreshold time.Duration
+	
+	// TempDir holds a working directory for each export's intermediate files
+	TempDir string
+	
+	// EPUBCheckPath is the path to the epubcheck executable (optional tool)
+	EPUBCheckPath string
+	
+	// QPDFPath is the path to the qpdf executable, used to optimize PDF exports (optional tool)
+	QPDFPath string
+	// MSOffCryptoPath is the path to msoffcrypto-tool, used to password-protect
+	// DOCX exports (optional tool)
+	MSOffCryptoPath string
+	
+	// GhostscriptPath is the path to ghostscript, used for PDF/A exports (optional tool)
+	GhostscriptPath string
+	// VeraPDFPath is the path to veraPDF, used to validate PDF/A exports (optional tool)
+	VeraPDFPath string
+	// PDFToTextPath is the path to poppler's pdftotext, used to count the pages
+	// of each chapter in a draft PDF (optional tool)
+	PDFToTextPath string
+	// FCListPath is the path to fontconfig's fc-list, used to check that the
+	// fonts a style names are installed before PDF exports load them (optional tool)
+	FCListPath string
+	// ICCProfilePath is the color profile embedded in PDF/A exports; ghostscript's
+	// built-in sRGB profile when empty
+	ICCProfilePath string
+	
+	// SVGConverterPath is the path to rsvg-convert or inkscape, used to convert
+	// SVG images for PDF exports (optional tool)
+	SVGConverterPath string
+	// SVGFormat is what SVG images are converted to for PDF exports: pdf or png
+	SVGFormat string
+	
+	// WatchEnabled turns on automatic re-export when document content changes
+	WatchEnabled bool
+	
+	// WatchFormat is the export format regenerated by the watcher
+	WatchFormat string
+	
+	// WatchDebounce is how long content must be unchanged before the watcher exports
+	WatchDebounce time.Duration
+	
+	// PreviewAddr is the address of the HTTP preview server (disabled when empty)
+	PreviewAddr string
+	
+	// DefaultHouseStyle is the house style ruleset used when a check names none
+	DefaultHouseStyle string
+	
+	// AllowedRoots are the directories user-supplied file paths (images, reference
+	// documents, stylesheets) must resolve into. Empty means RootDir only.
+	AllowedRoots []string
+	
+	// ClientID identifies the client for usage accounting when the transport
+	// does not supply an identity (such as a single stdio client)
+	ClientID string
+	
+	// QuotaDocuments, QuotaExports and QuotaExportBytes are the default per-client
+	// limits on documents held, exports made and bytes exported (0 means unlimited)
+	QuotaDocuments   int
+	QuotaExports     int
+	QuotaExportBytes int64
+	
+	// AccessControl enforces the roles in access.yaml on every tool call
+	AccessControl bool
+	
+	// Notarize appends a record of every export to the export ledger
+	Notarize bool
+	
+	// HooksEnabled runs the export hooks in hooks.yaml, in the root directory
+	// and in each document's directory
+	HooksEnabled bool
+	
+	// MaxDeletes is how many delete calls each client may make while the
+	// server runs (0 means unlimited)
+	MaxDeletes int
+	
+	// ConfirmDeletes makes delete_document and delete_chapter return a
+	// confirmation token that must be passed back to delete
+	ConfirmDeletes bool
+	
+	// RateLimits caps how often each client may call a tool, by tool name;
+	// the "*" limit applies to each tool not named
+	RateLimits map[string]RateLimit
+	
+	// TimestampURL is an RFC 3161 timestamp authority that anchors ledger records (optional)
+	TimestampURL string
+	
+	// StorageBackend is where documents are kept: "filesystem" (RootDir) or
+	// "s3", in which case RootDir is a local cache of the bucket
+	StorageBackend string
+	
+	// S3 configures the S3-compatible object store used by the "s3" backend
+	S3 S3Config
+}
+
+// RateLimit allows Calls calls to a tool in any period of length Per
+type RateLimit struct {
+	Calls int
+	Per   time.Duration
+}
+
+// rateLimitPeriods are the period units accepted in DOCGEN_RATE_LIMITS
+var rateLimitPeriods = map[string]time.Duration{
+	"s": time.Second,
+	"m": time.Minute,
+	"h": time.Hour,
+}
+
+// ParseRateLimits parses rate limits written as tool=calls/period pairs
+// separated by commas, such as "export_document=10/m,*=120/m". The period is
+// s, m, h or a duration such as 30s.
+func ParseRateLimits(val string) (map[string]RateLimit, error) {
+	limits := make(map[string]RateLimit)
+	for _, entry := range strings.Split(val, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+		tool, limit, ok := strings.Cut(entry, "=")
+		tool = strings.TrimSpace(tool)
+		if !ok || tool == "" {
+			return nil, fmt.Errorf("rate limit %q must be tool=calls/period", entry)
+		}
+		callsStr, periodStr, ok := strings.Cut(strings.TrimSpace(limit), "/")
+		if !ok {
+			return nil, fmt.Errorf("rate limit %q must be tool=calls/period", entry)
+		}
+		calls, err := strconv.Atoi(strings.TrimSpace(callsStr))
+		if err != nil || calls < 1 {
+			return nil, fmt.Errorf("rate limit %q must allow at least one call", entry)
+		}
+		periodStr = strings.TrimSpace(periodStr)
+		per, ok := rateLimitPeriods[periodStr]
+		if !ok {
+			per, err = time.ParseDuration(periodStr)
+			if err != nil || per <= 0 {
+				return nil, fmt.Errorf("rate limit %q has an invalid period: %s", entry, periodStr)
+			}
+		}
+		limits[tool] = RateLimit{Calls: calls, Per: per}
+	}
+	return limits, nil
+}
+
+// S3Config holds the settings for an S3-compatible object store
+type S3Config struct {
+	// Bucket holds the documents; Prefix is prepended to every object key
+	Bucket string
+	Prefix string
+	
+	// Endpoint is the store's base URL (defaults to AWS S3 in Region)
+	Endpoint string
+	Region   string
+	
+	// PathStyle addresses the bucket in the URL path rather than the host
+	// name, as MinIO and most self-hosted stores expect
+	PathStyle bool
+	
+	// AccessKeyID, SecretAccessKey and SessionToken are the request credentials
+	AccessKeyID     string
+	SecretAccessKey string
+	SessionToken    string
 }
 
 // LoadConfig loads configuration from environment variables with defaults
 func LoadConfig() (*Config, error) {
 	cfg := &Config{
-		PandocPath:    "pandoc",
-		MaxDocuments:  100,
-		MaxFileSize:   10 * 1024 * 1024, // 10MB
-		ExportTimeout: 5 * time.Minute,
+		PandocPath:          "pandoc",
+		MaxDocuments:        100,
+		MaxSandboxDocuments: 10,
+		SandboxTTL:          time.Hour,
+		MaxFileSize:         10 * 1024 * 1024, // 10MB
+		ExportTimeout:       5 * time.Minute,
+		PreflightThreshold:  time.Minute,
+		EPUBCheckPath:       "epubcheck",
+		QPDFPath:            "qpdf",
+		MSOffCryptoPath:     "msoffcrypto-tool",
+		GhostscriptPath:     "gs",
+		VeraPDFPath:         "verapdf",
+		PDFToTextPath:       "pdftotext",
+		FCListPath:          "fc-list",
+		SVGConverterPath:    "rsvg-convert",
+		SVGFormat:           "pdf",
+		WatchFormat:         "pdf",
+		WatchDebounce:       2 * time.Second,
+		ClientID:            "local",
+		StorageBackend:      "filesystem",
+		S3:                  S3Config{Region: "us-east-1"},
 	}
 	
 	// DOCGEN_ROOT_DIR (required)
@@ -55,6 +253,59 @@ func LoadConfig() (*Config, error) {
 		cfg.PandocPath = val
 	}
 	
+	// DOCGEN_EPUBCHECK_PATH (optional)
+	if val := os.Getenv("DOCGEN_EPUBCHECK_PATH"); val != "" {
+		cfg.EPUBCheckPath = val
+	}
+	
+	// DOCGEN_QPDF_PATH (optional)
+	if val := os.Getenv("DOCGEN_QPDF_PATH"); val != "" {
+		cfg.QPDFPath = val
+	}
+	
+	// DOCGEN_MSOFFCRYPTO_PATH (optional)
+	if val := os.Getenv("DOCGEN_MSOFFCRYPTO_PATH"); val != "" {
+		cfg.MSOffCryptoPath = val
+	}
+	
+	// DOCGEN_GHOSTSCRIPT_PATH (optional)
+	if val := os.Getenv("DOCGEN_GHOSTSCRIPT_PATH"); val != "" {
+		cfg.GhostscriptPath = val
+	}
+	
+	// DOCGEN_VERAPDF_PATH (optional)
+	if val := os.Getenv("DOCGEN_VERAPDF_PATH"); val != "" {
+		cfg.VeraPDFPath = val
+	}
+	
+	// DOCGEN_PDFTOTEXT_PATH (optional)
+	if val := os.Getenv("DOCGEN_PDFTOTEXT_PATH"); val != "" {
+		cfg.PDFToTextPath = val
+	}
+	
+	// DOCGEN_FCLIST_PATH (optional)
+	if val := os.Getenv("DOCGEN_FCLIST_PATH"); val != "" {
+		cfg.FCListPath = val
+	}
+	
+	// DOCGEN_ICC_PROFILE (optional)
+	if val := os.Getenv("DOCGEN_ICC_PROFILE"); val != "" {
+		cfg.ICCProfilePath = val
+	}
+	
+	// DOCGEN_SVG_CONVERTER (optional)
+	if val := os.Getenv("DOCGEN_SVG_CONVERTER"); val != "" {
+		cfg.SVGConverterPath = val
+	}
+	
+	// DOCGEN_SVG_FORMAT (optional)
+	if val := os.Getenv("DOCGEN_SVG_FORMAT"); val != "" {
+		if val != "pdf" && val != "png" {
+			return nil, fmt.Errorf("invalid DOCGEN_SVG_FORMAT value: %s (must be pdf or png)", val)
+		}
+		cfg.SVGFormat = val
+	}
+	
 	// DOCGEN_CURRENT_STYLE (optional) - replaces DOCGEN_DEFAULT_STYLE
 	if val := os.Getenv("DOCGEN_CURRENT_STYLE"); val != "" {
 		cfg.DefaultStylePath = val
@@ -72,6 +323,30 @@ func LoadConfig() (*Config, error) {
 		cfg.MaxDocuments = maxDocs
 	}
 	
+	// DOCGEN_MAX_SANDBOX_DOCUMENTS (optional)
+	if val := os.Getenv("DOCGEN_MAX_SANDBOX_DOCUMENTS"); val != "" {
+		maxDocs, err := strconv.Atoi(val)
+		if err != nil {
+			return nil, fmt.Errorf("invalid DOCGEN_MAX_SANDBOX_DOCUMENTS value: %s", val)
+		}
+		if maxDocs <= 0 {
+			return nil, fmt.Errorf("DOCGEN_MAX_SANDBOX_DOCUMENTS must be positive")
+		}
+		cfg.MaxSandboxDocuments = maxDocs
+	}
+	
+	// DOCGEN_SANDBOX_TTL_MINUTES (optional)
+	if val := os.Getenv("DOCGEN_SANDBOX_TTL_MINUTES"); val != "" {
+		minutes, err := strconv.Atoi(val)
+		if err != nil {
+			return nil, fmt.Errorf("invalid DOCGEN_SANDBOX_TTL_MINUTES value: %s", val)
+		}
+		if minutes <= 0 {
+			return nil, fmt.Errorf("DOCGEN_SANDBOX_TTL_MINUTES must be positive")
+		}
+		cfg.SandboxTTL = time.Duration(minutes) * time.Minute
+	}
+	
 	
 	// DOCGEN_MAX_FILE_SIZE (optional)
 	if val := os.Getenv("DOCGEN_MAX_FILE_SIZE"); val != "" {
@@ -85,6 +360,24 @@ func LoadConfig() (*Config, error) {
 		cfg.MaxFileSize = maxSize
 	}
 	
+	// DOCGEN_MAX_TOTAL_SIZE (optional)
+	if val := os.Getenv("DOCGEN_MAX_TOTAL_SIZE"); val != "" {
+		maxSize, err := strconv.ParseInt(val, 10, 64)
+		if err != nil || maxSize < 0 {
+			return nil, fmt.Errorf("invalid DOCGEN_MAX_TOTAL_SIZE value: %s", val)
+		}
+		cfg.MaxTotalSize = maxSize
+	}
+	
+	// DOCGEN_MAX_DOCUMENT_SIZE (optional)
+	if val := os.Getenv("DOCGEN_MAX_DOCUMENT_SIZE"); val != "" {
+		maxSize, err := strconv.ParseInt(val, 10, 64)
+		if err != nil || maxSize < 0 {
+			return nil, fmt.Errorf("invalid DOCGEN_MAX_DOCUMENT_SIZE value: %s", val)
+		}
+		cfg.MaxDocumentSize = maxSize
+	}
+	
 	// DOCGEN_EXPORT_TIMEOUT (optional)
 	if val := os.Getenv("DOCGEN_EXPORT_TIMEOUT"); val != "" {
 		timeoutSecs, err := strconv.Atoi(val)
@@ -97,6 +390,190 @@ func LoadConfig() (*Config, error) {
 		cfg.ExportTimeout = time.Duration(timeoutSecs) * time.Second
 	}
 	
+	// DOCGEN_PREFLIGHT_SECONDS (optional, 0 disables the preflight)
+	if val := os.Getenv("DOCGEN_PREFLIGHT_SECONDS"); val != "" {
+		secs, err := strconv.Atoi(val)
+		if err != nil || secs < 0 {
+			return nil, fmt.Errorf("invalid DOCGEN_PREFLIGHT_SECONDS value: %s", val)
+		}
+		cfg.PreflightThreshold = time.Duration(secs) * time.Second
+	}
+	
+	// DOCGEN_TEMP_DIR (optional)
+	cfg.TempDir = filepath.Join(os.TempDir(), "docgen")
+	if val := os.Getenv("DOCGEN_TEMP_DIR"); val != "" {
+		cfg.TempDir = val
+	}
+	
+	// DOCGEN_STRICT_MARKDOWN (optional)
+	if val := os.Getenv("DOCGEN_STRICT_MARKDOWN"); val != "" {
+		strict, err := strconv.ParseBool(val)
+		if err != nil {
+			return nil, fmt.Errorf("invalid DOCGEN_STRICT_MARKDOWN value: %s", val)
+		}
+		cfg.StrictMarkdown = strict
+	}
+	
+	// DOCGEN_WATCH (optional)
+	if val := os.Getenv("DOCGEN_WATCH"); val != "" {
+		enabled, err := strconv.ParseBool(val)
+		if err != nil {
+			return nil, fmt.Errorf("invalid DOCGEN_WATCH value: %s", val)
+		}
+		cfg.WatchEnabled = enabled
+	}
+	
+	// DOCGEN_WATCH_FORMAT (optional)
+	if val := os.Getenv("DOCGEN_WATCH_FORMAT"); val != "" {
+		cfg.WatchFormat = val
+	}
+	
+	// DOCGEN_WATCH_DEBOUNCE_MS (optional)
+	if val := os.Getenv("DOCGEN_WATCH_DEBOUNCE_MS"); val != "" {
+		debounceMs, err := strconv.Atoi(val)
+		if err != nil {
+			return nil, fmt.Errorf("invalid DOCGEN_WATCH_DEBOUNCE_MS value: %s", val)
+		}
+		if debounceMs <= 0 {
+			return nil, fmt.Errorf("DOCGEN_WATCH_DEBOUNCE_MS must be positive")
+		}
+		cfg.WatchDebounce = time.Duration(debounceMs) * time.Millisecond
+	}
+	
+	// DOCGEN_PREVIEW_ADDR (optional)
+	if val := os.Getenv("DOCGEN_PREVIEW_ADDR"); val != "" {
+		cfg.PreviewAddr = val
+	}
+	
+	// DOCGEN_HOUSE_STYLE (optional)
+	if val := os.Getenv("DOCGEN_HOUSE_STYLE"); val != "" {
+		cfg.DefaultHouseStyle = val
+	}
+	
+	// DOCGEN_ALLOWED_ROOTS (optional) - list separated like PATH
+	if val := os.Getenv("DOCGEN_ALLOWED_ROOTS"); val != "" {
+		for _, root := range filepath.SplitList(val) {
+			if root != "" {
+				cfg.AllowedRoots = append(cfg.AllowedRoots, root)
+			}
+		}
+	}
+	
+	// DOCGEN_CLIENT_ID (optional)
+	if val := os.Getenv("DOCGEN_CLIENT_ID"); val != "" {
+		cfg.ClientID = val
+	}
+	
+	// DOCGEN_QUOTA_DOCUMENTS (optional)
+	if val := os.Getenv("DOCGEN_QUOTA_DOCUMENTS"); val != "" {
+		quota, err := strconv.Atoi(val)
+		if err != nil || quota < 0 {
+			return nil, fmt.Errorf("invalid DOCGEN_QUOTA_DOCUMENTS value: %s", val)
+		}
+		cfg.QuotaDocuments = quota
+	}
+	
+	// DOCGEN_QUOTA_EXPORTS (optional)
+	if val := os.Getenv("DOCGEN_QUOTA_EXPORTS"); val != "" {
+		quota, err := strconv.Atoi(val)
+		if err != nil || quota < 0 {
+			return nil, fmt.Errorf("invalid DOCGEN_QUOTA_EXPORTS value: %s", val)
+		}
+		cfg.QuotaExports = quota
+	}
+	
+	// DOCGEN_QUOTA_EXPORT_BYTES (optional)
+	if val := os.Getenv("DOCGEN_QUOTA_EXPORT_BYTES"); val != "" {
+		quota, err := strconv.ParseInt(val, 10, 64)
+		if err != nil || quota < 0 {
+			return nil, fmt.Errorf("invalid DOCGEN_QUOTA_EXPORT_BYTES value: %s", val)
+		}
+		cfg.QuotaExportBytes = quota
+	}
+	
+	// DOCGEN_ACCESS_CONTROL (optional)
+	if val := os.Getenv("DOCGEN_ACCESS_CONTROL"); val != "" {
+		enabled, err := strconv.ParseBool(val)
+		if err != nil {
+			return nil, fmt.Errorf("invalid DOCGEN_ACCESS_CONTROL value: %s", val)
+		}
+		cfg.AccessControl = enabled
+	}
+	
+	// DOCGEN_NOTARIZE (optional)
+	if val := os.Getenv("DOCGEN_NOTARIZE"); val != "" {
+		enabled, err := strconv.ParseBool(val)
+		if err != nil {
+			return nil, fmt.Errorf("invalid DOCGEN_NOTARIZE value: %s", val)
+		}
+		cfg.Notarize = enabled
+	}
+	
+	// DOCGEN_HOOKS (optional)
+	if val := os.Getenv("DOCGEN_HOOKS"); val != "" {
+		enabled, err := strconv.ParseBool(val)
+		if err != nil {
+			return nil, fmt.Errorf("invalid DOCGEN_HOOKS value: %s", val)
+		}
+		cfg.HooksEnabled = enabled
+	}
+	
+	// DOCGEN_MAX_DELETES (optional)
+	if val := os.Getenv("DOCGEN_MAX_DELETES"); val != "" {
+		maxDeletes, err := strconv.Atoi(val)
+		if err != nil || maxDeletes < 0 {
+			return nil, fmt.Errorf("invalid DOCGEN_MAX_DELETES value: %s", val)
+		}
+		cfg.MaxDeletes = maxDeletes
+	}
+	
+	// DOCGEN_CONFIRM_DELETES (optional)
+	if val := os.Getenv("DOCGEN_CONFIRM_DELETES"); val != "" {
+		enabled, err := strconv.ParseBool(val)
+		if err != nil {
+			return nil, fmt.Errorf("invalid DOCGEN_CONFIRM_DELETES value: %s", val)
+		}
+		cfg.ConfirmDeletes = enabled
+	}
+	
+	// DOCGEN_RATE_LIMITS (optional)
+	if val := os.Getenv("DOCGEN_RATE_LIMITS"); val != "" {
+		limits, err := ParseRateLimits(val)
+		if err != nil {
+			return nil, fmt.Errorf("invalid DOCGEN_RATE_LIMITS value: %w", err)
+		}
+		cfg.RateLimits = limits
+	}
+	
+	// DOCGEN_TIMESTAMP_URL (optional)
+	if val := os.Getenv("DOCGEN_TIMESTAMP_URL"); val != "" {
+		cfg.TimestampURL = val
+	}
+	
+	// DOCGEN_STORAGE (optional)
+	if val := os.Getenv("DOCGEN_STORAGE"); val != "" {
+		cfg.StorageBackend = val
+	}
+	
+	// DOCGEN_S3_* (required by the s3 backend); credentials fall back to the
+	// standard AWS variables
+	cfg.S3.Bucket = os.Getenv("DOCGEN_S3_BUCKET")
+	cfg.S3.Prefix = os.Getenv("DOCGEN_S3_PREFIX")
+	cfg.S3.Endpoint = os.Getenv("DOCGEN_S3_ENDPOINT")
+	if val := firstEnv("DOCGEN_S3_REGION", "AWS_REGION"); val != "" {
+		cfg.S3.Region = val
+	}
+	if val := os.Getenv("DOCGEN_S3_PATH_STYLE"); val != "" {
+		pathStyle, err := strconv.ParseBool(val)
+		if err != nil {
+			return nil, fmt.Errorf("invalid DOCGEN_S3_PATH_STYLE value: %s", val)
+		}
+		cfg.S3.PathStyle = pathStyle
+	}
+	cfg.S3.AccessKeyID = firstEnv("DOCGEN_S3_ACCESS_KEY_ID", "AWS_ACCESS_KEY_ID")
+	cfg.S3.SecretAccessKey = firstEnv("DOCGEN_S3_SECRET_ACCESS_KEY", "AWS_SECRET_ACCESS_KEY")
+	cfg.S3.SessionToken = firstEnv("DOCGEN_S3_SESSION_TOKEN", "AWS_SESSION_TOKEN")
+	
 	return cfg, cfg.Validate()
 }
 
@@ -122,17 +599,63 @@ func (c *Config) Validate() error {
 		return fmt.Errorf("export timeout must be positive")
 	}
 	
+	for _, root := range c.AllowedRoots {
+		if !filepath.IsAbs(root) {
+			return fmt.Errorf("allowed root must be an absolute path: %s", root)
+		}
+	}
+	
+	switch c.StorageBackend {
+	case "", "filesystem":
+	case "s3":
+		if c.S3.Bucket == "" {
+			return fmt.Errorf("s3 storage requires a bucket")
+		}
+		if c.S3.AccessKeyID == "" || c.S3.SecretAccessKey == "" {
+			return fmt.Errorf("s3 storage requires an access key ID and secret access key")
+		}
+	default:
+		return fmt.Errorf("unsupported storage backend: %s", c.StorageBackend)
+	}
+	
+	if c.WatchEnabled {
+		switch c.WatchFormat {
+		case "pdf", "docx", "html", "epub", "txt", "ssml", "json":
+		default:
+			return fmt.Errorf("unsupported watch format: %s", c.WatchFormat)
+		}
+		if c.WatchDebounce <= 0 {
+			return fmt.Errorf("watch debounce must be positive")
+		}
+	}
+	
 	return nil
 }
 
+// firstEnv returns the value of the first of the named environment variables that is set
+func firstEnv(names ...string) string {
+	for _, name := range names {
+		if val := os.Getenv(name); val != "" {
+			return val
+		}
+	}
+	return ""
+}
+
 // DocumentPath returns the full path to a document directory
 func (c *Config) DocumentPath(documentID string) string {
 	return filepath.Join(c.RootDir, documentID)
 }
 
-// ChapterPath returns the full path to a chapter directory
-func (c *Config) ChapterPath(documentID string, chapterNumber int) string {
-	return filepath.Join(c.DocumentPath(documentID), "chapters", fmt.Sprintf("%02d", chapterNumber))
+// ChaptersPath returns the full path to the directory holding a document's chapters
+func (c *Config) ChaptersPath(documentID string) string {
+	return filepath.Join(c.DocumentPath(documentID), "chapters")
+}
+
+// ChapterPath returns the full path to a chapter directory, named as in the
+// manifest's chapter order
+func (c *Config) ChapterPath(documentID, chapterDir string) string {
+	return filepath.Join(c.ChaptersPath(documentID), chapterDir)
 }
 
 // AssetsPath returns the full path to the assets directory
@@ -140,6 +663,17 @@ func (c *Config) AssetsPath(documentID string) string {
 	return filepath.Join(c.DocumentPath(documentID), "assets", "images")
 }
 
+// DerivedAssetsPath returns the directory holding images converted from a
+// document's assets, kept so repeated exports don't convert them again
+func (c *Config) DerivedAssetsPath(documentID string) string {
+	return filepath.Join(c.DocumentPath(documentID), "assets", "derived")
+}
+
+// DocumentFontsPath returns the directory holding the font files of a document
+func (c *Config) DocumentFontsPath(documentID string) string {
+	return filepath.Join(c.DocumentPath(documentID), "fonts")
+}
+
 // ManifestPath returns the full path to the manifest file
 func (c *Config) ManifestPath(documentID string) string {
 	return filepath.Join(c.DocumentPath(documentID), "manifest.yaml")
@@ -155,14 +689,34 @@ func (c *Config) PandocConfigPath(documentID string) string {
 	return filepath.Join(c.DocumentPath(documentID), "pandoc-config.yaml")
 }
 
+// StatsPath returns the full path to the writing statistics file
+func (c *Config) StatsPath(documentID string) string {
+	return filepath.Join(c.DocumentPath(documentID), "stats.yaml")
+}
+
+// ExportHistoryPath returns the full path to the registry of a document's exports
+func (c *Config) ExportHistoryPath(documentID string) string {
+	return filepath.Join(c.DocumentPath(documentID), "exports.yaml")
+}
+
+// DocumentHooksPath returns the full path to a document's export hooks
+func (c *Config) DocumentHooksPath(documentID string) string {
+	return filepath.Join(c.DocumentPath(documentID), "hooks.yaml")
+}
+
 // ChapterContentPath returns the full path to a chapter's content file
-func (c *Config) ChapterContentPath(documentID string, chapterNumber int) string {
-	return filepath.Join(c.ChapterPath(documentID, chapterNumber), "chapter.md")
+func (c *Config) ChapterContentPath(documentID, chapterDir string) string {
+	return filepath.Join(c.ChapterPath(documentID, chapterDir), "chapter.md")
 }
 
 // ChapterMetadataPath returns the full path to a chapter's metadata file
-func (c *Config) ChapterMetadataPath(documentID string, chapterNumber int) string {
-	return filepath.Join(c.ChapterPath(documentID, chapterNumber), "metadata.yaml")
+func (c *Config) ChapterMetadataPath(documentID, chapterDir string) string {
+	return filepath.Join(c.ChapterPath(documentID, chapterDir), "metadata.yaml")
+}
+
+// ChapterRevisionsPath returns the full path to the registry of a chapter's revisions
+func (c *Config) ChapterRevisionsPath(documentID, chapterDir string) string {
+	return filepath.Join(c.ChapterPath(documentID, chapterDir), "revisions.yaml")
 }
 
 // ExportPath returns the path for export files
@@ -171,14 +725,59 @@ func (c *Config) ExportPath(documentID, format string) string {
 	return filepath.Join(c.ExportsDir, filename)
 }
 
+// TempPath returns the directory for export working directories, falling back
+// to a docgen directory in the system temp directory
+func (c *Config) TempPath() string {
+	if c.TempDir != "" {
+		return c.TempDir
+	}
+	return filepath.Join(os.TempDir(), "docgen")
+}
+
+// ExportLogsPath returns the directory holding a log for each export run
+func (c *Config) ExportLogsPath() string {
+	return filepath.Join(c.ExportsDir, "logs")
+}
+
+// ExportLogPath returns the directory for the log of one export run
+func (c *Config) ExportLogPath(documentID, timestamp string) string {
+	return filepath.Join(c.ExportLogsPath(), fmt.Sprintf("%s-%s", documentID, timestamp))
+}
+
+// LatestExportPath returns the stable path the watcher writes its most recent export to
+func (c *Config) LatestExportPath(documentID, format string) string {
+	filename := fmt.Sprintf("%s-latest.%s", documentID, format)
+	return filepath.Join(c.ExportsDir, filename)
+}
+
 // SectionsPath returns the full path to a chapter's sections directory
-func (c *Config) SectionsPath(documentID string, chapterNumber int) string {
-	return filepath.Join(c.ChapterPath(documentID, chapterNumber), "sections")
+func (c *Config) SectionsPath(documentID, chapterDir string) string {
+	return filepath.Join(c.ChapterPath(documentID, chapterDir), "sections")
 }
 
 // SectionPath returns the full path to a specific section file
-func (c *Config) SectionPath(documentID string, chapterNumber int, sectionNumber string) string {
-	return filepath.Join(c.SectionsPath(documentID, chapterNumber), fmt.Sprintf("%s.md", sectionNumber))
+func (c *Config) SectionPath(documentID, chapterDir, sectionNumber string) string {
+	return filepath.Join(c.SectionsPath(documentID, chapterDir), fmt.Sprintf("%s.md", sectionNumber))
+}
+
+// UsagePath returns the full path to the per-client usage ledger
+func (c *Config) UsagePath() string {
+	return filepath.Join(c.RootDir, "usage.yaml")
+}
+
+// AccessPolicyPath returns the full path to the role-based access policy
+func (c *Config) AccessPolicyPath() string {
+	return filepath.Join(c.RootDir, "access.yaml")
+}
+
+// HooksPath returns the full path to the export hooks run for every document
+func (c *Config) HooksPath() string {
+	return filepath.Join(c.RootDir, "hooks.yaml")
+}
+
+// ExportLedgerPath returns the full path to the append-only export ledger
+func (c *Config) ExportLedgerPath() string {
+	return filepath.Join(c.RootDir, "export-ledger.jsonl")
 }
 
 // StylesPath returns the full path to the styles directory
@@ -189,4 +788,103 @@ func (c *Config) StylesPath() string {
 // StyleByNamePath returns the full path to a style file by name
 func (c *Config) StyleByNamePath(styleName string) string {
 	return filepath.Join(c.StylesPath(), fmt.Sprintf("%s.yaml", styleName))
-}
\ No newline at end of file
+}
+
+// FontsPath returns the directory holding font files shared by all documents
+func (c *Config) FontsPath() string {
+	return filepath.Join(c.RootDir, "fonts")
+}
+
+// ArchivesPath returns the directory holding document archives
+func (c *Config) ArchivesPath() string {
+	return filepath.Join(c.RootDir, "archives")
+}
+
+// ArchivePath returns the path of a document archive made at the given timestamp
+func (c *Config) ArchivePath(documentID, timestamp string) string {
+	return filepath.Join(c.ArchivesPath(), fmt.Sprintf("%s-%s.zip", documentID, timestamp))
+}
+
+// TemplatesPath returns the full path to the section templates directory
+func (c *Config) TemplatesPath() string {
+	return filepath.Join(c.RootDir, "templates")
+}
+
+// SectionTemplatePath returns the full path to a section template file by name
+func (c *Config) SectionTemplatePath(templateName string) string {
+	return filepath.Join(c.TemplatesPath(), fmt.Sprintf("%s.yaml", templateName))
+}
+
+// HouseStylesPath returns the full path to the house style rulesets directory
+func (c *Config) HouseStylesPath() string {
+	return filepath.Join(c.RootDir, "house-styles")
+}
+
+// HouseStylePath returns the full path to a house style ruleset file by name
+func (c *Config) HouseStylePath(name string) string {
+	return filepath.Join(c.HouseStylesPath(), fmt.Sprintf("%s.yaml", name))
+}
+
+// ResolvePath canonicalizes a user-supplied file path and verifies that it lies
+// within one of the allowed root directories. Relative paths are resolved against
+// baseDir. Symbolic links are followed, so a link inside a root cannot be used to
+// reach a file outside it.
+func (c *Config) ResolvePath(path, baseDir string) (string, error) {
+	if path == "" {
+		return "", fmt.Errorf("path is empty")
+	}
+	if strings.ContainsRune(path, 0) {
+		return "", fmt.Errorf("path contains a null byte")
+	}
+
+	fullPath := path
+	if !filepath.IsAbs(fullPath) {
+		fullPath = filepath.Join(baseDir, fullPath)
+	}
+	resolved, err := canonicalPath(fullPath)
+	if err != nil {
+		return "", fmt.Errorf("failed to resolve path %s: %w", path, err)
+	}
+
+	roots := c.AllowedRoots
+	if len(roots) == 0 {
+		roots = []string{c.RootDir}
+	}
+	for _, root := range roots {
+		canonicalRoot, err := canonicalPath(root)
+		if err != nil {
+			continue
+		}
+		if rel, err := filepath.Rel(canonicalRoot, resolved); err == nil && rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+			return resolved, nil
+		}
+	}
+
+	return "", fmt.Errorf("path %s is outside the allowed directories (%s)", path, strings.Join(roots, ", "))
+}
+
+// canonicalPath returns the absolute path with symbolic links evaluated. Paths
+// that do not exist yet are resolved through their closest existing parent.
+func canonicalPath(path string) (string, error) {
+	abs, err := filepath.Abs(path)
+	if err != nil {
+		return "", err
+	}
+
+	existing, missing := abs, ""
+	for {
+		resolved, err := filepath.EvalSymlinks(existing)
+		if err == nil {
+			return filepath.Join(resolved, missing), nil
+		}
+		if !os.IsNotExist(err) {
+			return "", err
+		}
+		parent := filepath.Dir(existing)
+		if parent == existing {
+			return abs, nil
+		}
+		missing = filepath.Join(filepath.Base(existing), missing)
+		existing = parent
+	}
+}
@@ -2,6 +2,7 @@ package config
 
 import (
 	"os"
+	"path/filepath"
 	"testing"
 	"time"
 )
@@ -147,6 +148,32 @@ func TestConfig_Validate(t *testing.T) {
 			},
 			wantErr: true,
 		},
+		{
+			name: "unsupported watch format",
+			config: &Config{
+				RootDir:       "/tmp/docgen",
+				PandocPath:    "pandoc",
+				MaxDocuments:  100,
+				MaxFileSize:   10 * 1024 * 1024,
+				ExportTimeout: 5 * time.Minute,
+				WatchEnabled:  true,
+				WatchFormat:   "rtf",
+				WatchDebounce: 2 * time.Second,
+			},
+			wantErr: true,
+		},
+		{
+			name: "relative allowed root",
+			config: &Config{
+				RootDir:       "/tmp/docgen",
+				PandocPath:    "pandoc",
+				MaxDocuments:  100,
+				MaxFileSize:   10 * 1024 * 1024,
+				ExportTimeout: 5 * time.Minute,
+				AllowedRoots:  []string{"shared"},
+			},
+			wantErr: true,
+		},
 	}
 
 	for _, tt := range tests {
@@ -180,4 +207,74 @@ func TestConfig_DocumentPath(t *testing.T) {
 			}
 		})
 	}
-}
\ No newline at end of file
+}
+func TestConfig_ResolvePath(t *testing.T) {
+	rootDir := t.TempDir()
+	outsideDir := t.TempDir()
+	docDir := filepath.Join(rootDir, "doc1")
+	if err := os.MkdirAll(docDir, 0755); err != nil {
+		t.Fatalf("Failed to create document dir: %v", err)
+	}
+
+	// A symlink inside the root that points outside it
+	if err := os.Symlink(outsideDir, filepath.Join(rootDir, "escape")); err != nil {
+		t.Fatalf("Failed to create symlink: %v", err)
+	}
+
+	cfg := &Config{RootDir: rootDir}
+
+	tests := []struct {
+		name    string
+		path    string
+		wantErr bool
+	}{
+		{"relative inside", "assets/images/fig.png", false},
+		{"absolute inside", filepath.Join(rootDir, "styles", "custom.css"), false},
+		{"traversal", "../../etc/passwd", true},
+		{"absolute outside", "/etc/passwd", true},
+		{"symlink outside", filepath.Join(rootDir, "escape", "secret.txt"), true},
+		{"sibling prefix", rootDir + "-other/file.txt", true},
+		{"empty", "", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := cfg.ResolvePath(tt.path, docDir)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("Config.ResolvePath(%q) error = %v, wantErr %v", tt.path, err, tt.wantErr)
+			}
+		})
+	}
+
+	// Additional roots replace the default
+	cfg.AllowedRoots = []string{rootDir, outsideDir}
+	if _, err := cfg.ResolvePath(filepath.Join(outsideDir, "logo.png"), docDir); err != nil {
+		t.Errorf("Path in an additional allowed root rejected: %v", err)
+	}
+}
+
+func TestParseRateLimits(t *testing.T) {
+	limits, err := ParseRateLimits("export_document=10/m, *=120/h,add_section = 5/30s,")
+	if err != nil {
+		t.Fatalf("ParseRateLimits() error = %v", err)
+	}
+	want := map[string]RateLimit{
+		"export_document": {Calls: 10, Per: time.Minute},
+		"*":               {Calls: 120, Per: time.Hour},
+		"add_section":     {Calls: 5, Per: 30 * time.Second},
+	}
+	if len(limits) != len(want) {
+		t.Fatalf("ParseRateLimits() = %v, want %v", limits, want)
+	}
+	for tool, limit := range want {
+		if limits[tool] != limit {
+			t.Errorf("Limit for %s = %+v, want %+v", tool, limits[tool], limit)
+		}
+	}
+
+	for _, val := range []string{"export_document", "=10/m", "export_document=10", "export_document=0/m", "export_document=10/fortnight", "export_document=10/-1s"} {
+		if _, err := ParseRateLimits(val); err == nil {
+			t.Errorf("ParseRateLimits(%q) should fail", val)
+		}
+	}
+}
@@ -0,0 +1,62 @@
+package document
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/gomcpgo/docgen/pkg/types"
+)
+
+// SetAbbreviation defines an abbreviation for the document's abbreviations
+// table, replacing any definition of the same term
+func (m *Manager) SetAbbreviation(docID types.DocumentID, abbreviation types.Abbreviation) ([]types.Abbreviation, error) {
+	if err := docID.Validate(); err != nil {
+		return nil, fmt.Errorf("invalid document ID: %w", err)
+	}
+
+	manifest, err := m.storage.LoadManifest(string(docID))
+	if err != nil {
+		return nil, fmt.Errorf("failed to load document manifest: %w", err)
+	}
+
+	if err := manifest.Document.SetAbbreviation(abbreviation); err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	manifest.Document.UpdatedAt = now
+	manifest.UpdatedAt = now
+
+	if err := m.storage.SaveManifest(string(docID), manifest); err != nil {
+		return nil, fmt.Errorf("failed to update manifest: %w", err)
+	}
+
+	return manifest.Document.Abbreviations, nil
+}
+
+// RemoveAbbreviation removes the definition of a term. Definitions written out
+// in the text are not affected.
+func (m *Manager) RemoveAbbreviation(docID types.DocumentID, term string) ([]types.Abbreviation, error) {
+	if err := docID.Validate(); err != nil {
+		return nil, fmt.Errorf("invalid document ID: %w", err)
+	}
+
+	manifest, err := m.storage.LoadManifest(string(docID))
+	if err != nil {
+		return nil, fmt.Errorf("failed to load document manifest: %w", err)
+	}
+
+	if !manifest.Document.RemoveAbbreviation(term) {
+		return nil, fmt.Errorf("abbreviation %s is not defined in document %s", term, docID)
+	}
+
+	now := time.Now()
+	manifest.Document.UpdatedAt = now
+	manifest.UpdatedAt = now
+
+	if err := m.storage.SaveManifest(string(docID), manifest); err != nil {
+		return nil, fmt.Errorf("failed to update manifest: %w", err)
+	}
+
+	return manifest.Document.Abbreviations, nil
+}
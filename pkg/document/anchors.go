@@ -0,0 +1,193 @@
+package document
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/gomcpgo/docgen/pkg/types"
+)
+
+// anchoredBlock is the markup of a figure or table anchored in a section
+type anchoredBlock struct {
+	paragraph int
+	markup    string
+}
+
+// SetFigureAnchor anchors a figure or table, by its ID, after a paragraph of a
+// section in its chapter, or clears its anchor when anchor is nil, and rebuilds
+// the chapter. It reports whether the content already places the figure or
+// table by hand, in which case the anchor has no effect until that is removed.
+func (m *Manager) SetFigureAnchor(docID types.DocumentID, id string, anchor *types.FigureAnchor) (bool, error) {
+	if err := docID.Validate(); err != nil {
+		return false, fmt.Errorf("invalid document ID: %w", err)
+	}
+
+	var chapterNum types.ChapterNumber
+	var err error
+	switch {
+	case strings.HasPrefix(id, "fig-"):
+		var figureID types.FigureID
+		if chapterNum, figureID, err = m.findFigure(docID, types.FigureID(id)); err == nil {
+			id = string(figureID)
+		}
+	case strings.HasPrefix(id, "table-"):
+		chapterNum, _, err = parseTableID(types.TableID(id))
+	default:
+		err = fmt.Errorf("expected a figure ID such as fig-1.2 or a table ID such as table-1.2")
+	}
+	if err != nil {
+		return false, fmt.Errorf("invalid ID %s: %w", id, err)
+	}
+
+	chapter, err := m.storage.LoadChapterMetadata(string(docID), int(chapterNum))
+	if err != nil {
+		return false, fmt.Errorf("failed to load chapter: %w", err)
+	}
+
+	if anchor != nil {
+		if anchor.Paragraph < 0 {
+			return false, fmt.Errorf("paragraph must be 0 or more")
+		}
+		if len(anchor.Section) < 2 || anchor.Section[0] != int(chapterNum) {
+			return false, fmt.Errorf("%s can only be anchored in a section of chapter %d", id, chapterNum)
+		}
+		found := false
+		for _, section := range chapter.Sections {
+			if m.sectionNumbersEqual(section.Number, anchor.Section) {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false, fmt.Errorf("section %s not found in chapter %d", anchor.Section.String(), chapterNum)
+		}
+	}
+
+	var target **types.FigureAnchor
+	for i := range chapter.Figures {
+		if string(chapter.Figures[i].ID) == id {
+			target = &chapter.Figures[i].Anchor
+			chapter.Figures[i].UpdatedAt = time.Now()
+		}
+	}
+	for i := range chapter.Tables {
+		if string(chapter.Tables[i].ID) == id {
+			target = &chapter.Tables[i].Anchor
+			chapter.Tables[i].UpdatedAt = time.Now()
+		}
+	}
+	if target == nil {
+		return false, fmt.Errorf("%s not found in chapter %d", id, chapterNum)
+	}
+	*target = anchor
+	chapter.UpdatedAt = time.Now()
+
+	if err := m.storage.SaveChapterMetadata(string(docID), chapter); err != nil {
+		return false, fmt.Errorf("failed to save chapter metadata: %w", err)
+	}
+	if err := m.RebuildChapterMarkdown(docID, chapterNum); err != nil {
+		return false, fmt.Errorf("failed to rebuild chapter markdown: %w", err)
+	}
+
+	placed := make(map[string]bool)
+	for _, section := range chapter.Sections {
+		if content, err := m.storage.LoadSectionContent(string(docID), int(chapterNum), section.Number); err == nil {
+			for _, match := range anchorPattern.FindAllStringSubmatch(content, -1) {
+				placed[match[1]] = true
+			}
+		}
+	}
+	return placed[id], nil
+}
+
+// anchoredBlocks returns the markup of the chapter's anchored figures and
+// tables that the content doesn't place by hand, by section, in figure and
+// then table order
+func anchoredBlocks(chapter *types.Chapter, placed map[string]bool) map[string][]anchoredBlock {
+	blocks := make(map[string][]anchoredBlock)
+	add := func(id string, anchor *types.FigureAnchor, markup string) {
+		if anchor == nil || placed[id] || len(anchor.Section) < 2 {
+			return
+		}
+		// Anchors follow the chapter when it moves, whatever number they carry
+		key := renumberSection(anchor.Section, chapter.Number, 0).String()
+		blocks[key] = append(blocks[key], anchoredBlock{paragraph: anchor.Paragraph, markup: markup})
+	}
+	for _, figure := range chapter.Figures {
+		add(string(figure.ID), figure.Anchor, figure.Markup())
+	}
+	for _, table := range chapter.Tables {
+		add(string(table.ID), table.Anchor, table.Markup())
+	}
+	return blocks
+}
+
+// placeAnchored inserts blocks into section content after the paragraphs they
+// are anchored to. Blocks anchored past the last paragraph go at the end.
+func placeAnchored(content string, blocks []anchoredBlock) string {
+	sort.SliceStable(blocks, func(i, j int) bool { return blocks[i].paragraph < blocks[j].paragraph })
+	paragraphs := scanParagraphs(content)
+
+	// Inserting from the last block keeps the earlier offsets valid
+	for i := len(blocks) - 1; i >= 0; i-- {
+		after := blocks[i].paragraph
+		if after > len(paragraphs) {
+			after = len(paragraphs)
+		}
+		switch {
+		case len(paragraphs) == 0:
+			content = strings.Trim(blocks[i].markup, "\n") + "\n\n" + strings.TrimLeft(content, "\n")
+		case after == 0:
+			start := paragraphs[0][0]
+			content = content[:start] + strings.Trim(blocks[i].markup, "\n") + "\n\n" + content[start:]
+		default:
+			content = insertParagraph(content, paragraphs[after-1][1], blocks[i].markup)
+		}
+	}
+	return strings.TrimRight(content, "\n")
+}
+
+// shiftAnchorsAfterDeletion keeps anchors on their sections when a section is
+// deleted and the sections after it move up, as renumberSectionsAfterDeletion
+// renumbers them. Anchors in the deleted section are cleared.
+func shiftAnchorsAfterDeletion(chapter *types.Chapter, deleted types.SectionNumber) {
+	shift := func(anchor *types.FigureAnchor) *types.FigureAnchor {
+		if anchor == nil || len(deleted) < 2 || len(anchor.Section) < 2 {
+			return anchor
+		}
+		section := renumberSection(anchor.Section, types.ChapterNumber(deleted[0]), 0)
+		if section.String() == deleted.String() {
+			return nil
+		}
+		level := len(deleted) - 1
+		if len(section) <= level {
+			return anchor
+		}
+		for j := 1; j < level; j++ {
+			if section[j] != deleted[j] {
+				return anchor
+			}
+		}
+		if section[level] > deleted[level] {
+			section[level]--
+		}
+		return &types.FigureAnchor{Section: section, Paragraph: anchor.Paragraph}
+	}
+	for i := range chapter.Figures {
+		chapter.Figures[i].Anchor = shift(chapter.Figures[i].Anchor)
+	}
+	for i := range chapter.Tables {
+		chapter.Tables[i].Anchor = shift(chapter.Tables[i].Anchor)
+	}
+}
+
+// moveAnchor returns an anchor moved with its section to chapterNum, with the
+// section's top-level number shifted by offset
+func moveAnchor(anchor *types.FigureAnchor, chapterNum types.ChapterNumber, offset int) *types.FigureAnchor {
+	if anchor == nil || len(anchor.Section) == 0 {
+		return anchor
+	}
+	return &types.FigureAnchor{Section: renumberSection(anchor.Section, chapterNum, offset), Paragraph: anchor.Paragraph}
+}
@@ -0,0 +1,121 @@
+package document
+
+import (
+	"context"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/gomcpgo/docgen/pkg/types"
+)
+
+func TestManager_SetFigureAnchor(t *testing.T) {
+	manager, tempDir := setupTestManager(t)
+	defer os.RemoveAll(tempDir)
+
+	docID, _ := manager.CreateDocument("Anchors", "Test Author", types.DocumentTypeReport)
+	chapterNum, _ := manager.AddChapter(context.Background(), docID, "Results", nil)
+	manager.AddSection(context.Background(), docID, chapterNum, "Intro", "First.", 1)
+	manager.AddSection(context.Background(), docID, chapterNum, "Findings", "One.\n\n```\ncode\n\nmore\n```\n\nThree.", 1)
+	chart, _ := manager.AddImage(docID, chapterNum, "assets/images/chart.png", "Chart", "here", "", "")
+	mapID, _ := manager.AddImage(docID, chapterNum, "assets/images/map.png", "Map", "top", "", "")
+
+	chapter, _ := manager.storage.LoadChapterMetadata(string(docID), int(chapterNum))
+	chapter.Tables = append(chapter.Tables, types.Table{
+		ID:      types.GenerateTableID(chapterNum, 1),
+		Chapter: chapterNum,
+		Caption: "Totals",
+		Content: "| a | b |\n|---|---|\n| 1 | 2 |",
+	})
+	manager.storage.SaveChapterMetadata(string(docID), chapter)
+
+	// A fenced code block counts as one paragraph; figures are named by ID or
+	// display number
+	for _, tt := range []struct {
+		id        string
+		paragraph int
+	}{{string(chart), 2}, {"table-1.1", 2}, {"fig-1.2", 0}} {
+		placed, err := manager.SetFigureAnchor(docID, tt.id, &types.FigureAnchor{Section: types.SectionNumber{1, 2}, Paragraph: tt.paragraph})
+		if err != nil || placed {
+			t.Fatalf("SetFigureAnchor(%s) = %v, %v", tt.id, placed, err)
+		}
+	}
+
+	content, _ := manager.storage.LoadChapterContent(string(docID), int(chapterNum))
+	want := "## 1.2 Findings\n\n![Map](assets/images/map.png){#" + string(mapID) + "}\n\nOne.\n\n```\ncode\n\nmore\n```\n\n" +
+		"![Chart](assets/images/chart.png){#" + string(chart) + "}\n\n| a | b |\n|---|---|\n| 1 | 2 |\n\nTable: Totals {#table-1.1}\n\nThree."
+	if !strings.Contains(content, want) {
+		t.Errorf("Expected anchored figures and table in:\n%s", content)
+	}
+	if issues, _ := manager.LintFiguresAndTables(docID, chapterNum); len(issues) != 0 {
+		t.Errorf("Expected anchored figures to count as placed, got %+v", issues)
+	}
+
+	// Placing a figure by hand takes precedence over its anchor
+	manager.UpdateSection(context.Background(), docID, chapterNum, types.SectionNumber{1, 1}, "First.\n\n![Chart](assets/images/chart.png){#"+string(chart)+"}")
+	placed, err := manager.SetFigureAnchor(docID, string(chart), &types.FigureAnchor{Section: types.SectionNumber{1, 2}, Paragraph: 9})
+	if err != nil || !placed {
+		t.Errorf("SetFigureAnchor() = %v, %v, want placed by hand", placed, err)
+	}
+	content, _ = manager.storage.LoadChapterContent(string(docID), int(chapterNum))
+	if strings.Count(content, "{#"+string(chart)+"}") != 1 {
+		t.Errorf("Expected the chart once:\n%s", content)
+	}
+
+	// Deleting a section keeps later anchors on their sections
+	manager.SetFigureAnchor(docID, string(chart), nil)
+	manager.DeleteSection(docID, chapterNum, types.SectionNumber{1, 1})
+	chapter, _ = manager.storage.LoadChapterMetadata(string(docID), int(chapterNum))
+	if anchor := chapter.Figures[1].Anchor; anchor == nil || anchor.Section.String() != "1.1" {
+		t.Errorf("Expected the map anchored in section 1.1, got %+v", anchor)
+	}
+	if chapter.Figures[0].Anchor != nil {
+		t.Errorf("Expected the chart's anchor cleared, got %+v", chapter.Figures[0].Anchor)
+	}
+
+	for _, tt := range []struct {
+		id      string
+		section types.SectionNumber
+		wantErr string
+	}{
+		{"fig-1.9", types.SectionNumber{1, 1}, "not found"},
+		{"fig-1.1", types.SectionNumber{1, 7}, "section 1.7 not found"},
+		{"fig-1.1", types.SectionNumber{2, 1}, "only be anchored in a section of chapter 1"},
+		{"lst-1.1", types.SectionNumber{1, 1}, "invalid ID"},
+	} {
+		if _, err := manager.SetFigureAnchor(docID, tt.id, &types.FigureAnchor{Section: tt.section}); err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+			t.Errorf("SetFigureAnchor(%s, %s) error = %v, want %q", tt.id, tt.section.String(), err, tt.wantErr)
+		}
+	}
+}
+
+func TestManager_SplitChapter_MovesAnchoredFigures(t *testing.T) {
+	manager, tempDir := setupTestManager(t)
+	defer os.RemoveAll(tempDir)
+
+	docID, _ := manager.CreateDocument("Anchors", "Test Author", types.DocumentTypeReport)
+	chapterNum, _ := manager.AddChapter(context.Background(), docID, "Everything", nil)
+	manager.AddSection(context.Background(), docID, chapterNum, "Kept", "Kept text.", 1)
+	manager.AddSection(context.Background(), docID, chapterNum, "Moved", "Moved text.", 1)
+	chart, _ := manager.AddImage(docID, chapterNum, "assets/images/chart.png", "Chart", "here", "", "")
+	manager.SetFigureAnchor(docID, string(chart), &types.FigureAnchor{Section: types.SectionNumber{1, 2}, Paragraph: 1})
+
+	result, err := manager.SplitChapter(context.Background(), docID, chapterNum, types.SectionNumber{1, 2}, "")
+	if err != nil {
+		t.Fatalf("SplitChapter() error = %v", err)
+	}
+	if _, renamed := result.IDs[string(chart)]; renamed {
+		t.Errorf("Did not expect the chart's stable ID to change, got %v", result.IDs)
+	}
+	moved, _ := manager.storage.LoadChapterMetadata(string(docID), 2)
+	if len(moved.Figures) != 1 || moved.Figures[0].Anchor == nil || moved.Figures[0].Anchor.Section.String() != "2.1" {
+		t.Fatalf("Unexpected figures in the new chapter: %+v", moved.Figures)
+	}
+	if moved.Figures[0].Label() != "fig-2.1" {
+		t.Errorf("Expected the chart numbered 2.1, got %s", moved.Figures[0].Label())
+	}
+	content, _ := manager.storage.LoadChapterContent(string(docID), 2)
+	if !strings.Contains(content, "Moved text.\n\n![Chart](assets/images/chart.png){#"+string(chart)+"}") {
+		t.Errorf("Expected the figure after the moved text:\n%s", content)
+	}
+}
@@ -0,0 +1,75 @@
+package document
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/gomcpgo/docgen/pkg/annotate"
+	"github.com/gomcpgo/docgen/pkg/types"
+)
+
+// AnnotateImage draws callouts on one of a document's images and saves the
+// result as a new PNG in assets/images, leaving the original untouched. The
+// image is a file name in assets/images or a path relative to the document.
+// The output name defaults to the image's name with -annotated. The new image's
+// path relative to the document is returned, ready for add_image.
+func (m *Manager) AnnotateImage(ctx context.Context, docID types.DocumentID, imagePath string, annotations []types.Annotation, outputName string) (string, error) {
+	if err := docID.Validate(); err != nil {
+		return "", fmt.Errorf("invalid document ID: %w", err)
+	}
+	if imagePath == "" {
+		return "", fmt.Errorf("image path is required")
+	}
+	if len(annotations) == 0 {
+		return "", fmt.Errorf("at least one annotation is required")
+	}
+
+	if outputName == "" {
+		base := filepath.Base(imagePath)
+		outputName = strings.TrimSuffix(base, filepath.Ext(base)) + "-annotated.png"
+	}
+	if outputName != filepath.Base(outputName) || strings.ToLower(filepath.Ext(outputName)) != ".png" {
+		return "", fmt.Errorf("invalid output name %q (must be a .png file name)", outputName)
+	}
+
+	// The image is read from the document's local files
+	if err := m.SyncDocument(ctx, docID); err != nil {
+		return "", err
+	}
+	sourcePath := filepath.Join(m.config.AssetsPath(string(docID)), imagePath)
+	if strings.ContainsAny(imagePath, `/\`) {
+		resolved, err := m.config.ResolvePath(imagePath, m.config.DocumentPath(string(docID)))
+		if err != nil {
+			return "", fmt.Errorf("invalid image path: %w", err)
+		}
+		sourcePath = resolved
+	}
+	if sourcePath == filepath.Join(m.config.AssetsPath(string(docID)), outputName) {
+		return "", fmt.Errorf("output name %s would overwrite the source image", outputName)
+	}
+
+	data, err := os.ReadFile(sourcePath)
+	if err != nil {
+		return "", fmt.Errorf("failed to read image: %w", err)
+	}
+	annotated, err := annotate.Image(data, annotations)
+	if err != nil {
+		return "", err
+	}
+	if m.config.MaxFileSize > 0 && int64(len(annotated)) > m.config.MaxFileSize {
+		return "", fmt.Errorf("annotated image exceeds maximum file size of %d bytes", m.config.MaxFileSize)
+	}
+
+	if err := m.CheckStorageQuota(ctx, docID, int64(len(annotated))); err != nil {
+		return "", err
+	}
+	if _, err := m.storage.SaveAsset(string(docID), outputName, annotated); err != nil {
+		return "", fmt.Errorf("failed to store annotated image: %w", err)
+	}
+
+	// Reference assets relative to the document directory
+	return filepath.ToSlash(filepath.Join("assets", "images", outputName)), nil
+}
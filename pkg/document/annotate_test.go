@@ -0,0 +1,69 @@
+package document
+
+import (
+	"bytes"
+	"context"
+	"image"
+	"image/png"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/gomcpgo/docgen/pkg/types"
+)
+
+func TestManager_AnnotateImage(t *testing.T) {
+	manager, tempDir := setupTestManager(t)
+	defer os.RemoveAll(tempDir)
+
+	docID, err := manager.CreateDocument("Screens", "Test Author", types.DocumentTypeReport)
+	if err != nil {
+		t.Fatalf("Failed to create document: %v", err)
+	}
+	var screenshot bytes.Buffer
+	png.Encode(&screenshot, image.NewRGBA(image.Rect(0, 0, 120, 80)))
+	if _, err := manager.storage.SaveAsset(string(docID), "login.png", screenshot.Bytes()); err != nil {
+		t.Fatalf("Failed to save asset: %v", err)
+	}
+	annotations := []types.Annotation{
+		{Kind: types.AnnotationBox, X: 10, Y: 10, Width: 40, Height: 20},
+		{Kind: types.AnnotationStep, X: 90, Y: 40},
+	}
+
+	imagePath, err := manager.AnnotateImage(context.Background(), docID, "login.png", annotations, "")
+	if err != nil {
+		t.Fatalf("AnnotateImage() error = %v", err)
+	}
+	if imagePath != "assets/images/login-annotated.png" {
+		t.Errorf("AnnotateImage() = %s", imagePath)
+	}
+	data, err := os.ReadFile(filepath.Join(tempDir, string(docID), imagePath))
+	if err != nil {
+		t.Fatalf("Annotated image not saved: %v", err)
+	}
+	if bytes.Equal(data, screenshot.Bytes()) {
+		t.Error("Annotated image should differ from the original")
+	}
+	if original, _ := os.ReadFile(filepath.Join(tempDir, string(docID), "assets", "images", "login.png")); !bytes.Equal(original, screenshot.Bytes()) {
+		t.Error("The original image should be left alone")
+	}
+
+	// Paths relative to the document and output names are accepted
+	if imagePath, err = manager.AnnotateImage(context.Background(), docID, "assets/images/login.png", annotations, "login-step1.png"); err != nil || imagePath != "assets/images/login-step1.png" {
+		t.Errorf("AnnotateImage() with output name = %s, %v", imagePath, err)
+	}
+
+	for _, tt := range []struct {
+		image, output, wantErr string
+	}{
+		{"login.png", "login.png", "overwrite the source"},
+		{"login.png", "../login.png", "invalid output name"},
+		{"login.png", "login.jpg", "invalid output name"},
+		{"missing.png", "", "failed to read image"},
+	} {
+		if _, err := manager.AnnotateImage(context.Background(), docID, tt.image, annotations, tt.output); err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+			t.Errorf("AnnotateImage(%s, %s) error = %v, want %q", tt.image, tt.output, err, tt.wantErr)
+		}
+	}
+}
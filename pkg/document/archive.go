@@ -0,0 +1,311 @@
+package document
+
+import (
+	"archive/zip"
+	"context"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/gomcpgo/docgen/pkg/types"
+	"gopkg.in/yaml.v3"
+)
+
+// archiveTimestamp names archives so that they sort by time
+const archiveTimestamp = "20060102-150405"
+
+// maxArchiveEntries is the most files a restored archive may hold
+const maxArchiveEntries = 10000
+
+// documentIDTimestamp matches the timestamp suffix of a generated document ID
+var documentIDTimestamp = regexp.MustCompile(`-\d+$`)
+
+// ArchiveDocument packages the whole document directory (manifest, chapters,
+// sections, assets, style and pandoc config) into a zip under archives/. The
+// files sit in a folder named after the document, so the archive also unpacks
+// by hand. It returns the archive's path. Archiving stops, leaving no archive,
+// when ctx is done.
+func (m *Manager) ArchiveDocument(ctx context.Context, docID types.DocumentID) (string, error) {
+	if err := docID.Validate(); err != nil {
+		return "", fmt.Errorf("invalid document ID: %w", err)
+	}
+
+	exists, err := m.storage.DocumentExists(string(docID))
+	if err != nil {
+		return "", fmt.Errorf("failed to check document existence: %w", err)
+	}
+	if !exists {
+		return "", fmt.Errorf("document %s not found", docID)
+	}
+	if err := m.SyncDocument(ctx, docID); err != nil {
+		return "", err
+	}
+
+	archivePath := m.config.ArchivePath(string(docID), time.Now().Format(archiveTimestamp))
+	if err := os.MkdirAll(filepath.Dir(archivePath), 0755); err != nil {
+		return "", fmt.Errorf("failed to create archives directory: %w", err)
+	}
+	if err := writeArchive(ctx, archivePath, m.config.DocumentPath(string(docID)), string(docID)); err != nil {
+		os.Remove(archivePath)
+		return "", fmt.Errorf("failed to archive document: %w", err)
+	}
+	return archivePath, nil
+}
+
+// writeArchive zips the files under dir into a folder named prefix, until ctx is done
+func writeArchive(ctx context.Context, archivePath, dir, prefix string) error {
+	file, err := os.Create(archivePath)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	writer := zip.NewWriter(file)
+	err = filepath.WalkDir(dir, func(filePath string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		if !entry.Type().IsRegular() {
+			return nil
+		}
+		rel, err := filepath.Rel(dir, filePath)
+		if err != nil {
+			return err
+		}
+		info, err := entry.Info()
+		if err != nil {
+			return err
+		}
+		header, err := zip.FileInfoHeader(info)
+		if err != nil {
+			return err
+		}
+		header.Name = path.Join(prefix, filepath.ToSlash(rel))
+		header.Method = zip.Deflate
+
+		w, err := writer.CreateHeader(header)
+		if err != nil {
+			return err
+		}
+		in, err := os.Open(filePath)
+		if err != nil {
+			return err
+		}
+		defer in.Close()
+		_, err = io.Copy(w, in)
+		return err
+	})
+	if err != nil {
+		writer.Close()
+		return err
+	}
+	if err := writer.Close(); err != nil {
+		return err
+	}
+	return file.Close()
+}
+
+// RestoreDocument unpacks an archive made by ArchiveDocument as a new document.
+// Relative archive paths are resolved against archives/. The archive must hold
+// a manifest and metadata for every chapter it lists. The document takes newID,
+// or its archived ID when newID is empty; if that ID is taken it gets a fresh
+// one. It returns the ID used.
+func (m *Manager) RestoreDocument(ctx context.Context, archivePath string, newID types.DocumentID) (types.DocumentID, error) {
+	resolved, err := m.config.ResolvePath(archivePath, m.config.ArchivesPath())
+	if err != nil {
+		return "", fmt.Errorf("invalid archive path: %w", err)
+	}
+	reader, err := zip.OpenReader(resolved)
+	if err != nil {
+		return "", fmt.Errorf("failed to open archive: %w", err)
+	}
+	defer reader.Close()
+
+	files, err := m.archiveFiles(reader.File)
+	if err != nil {
+		return "", fmt.Errorf("invalid archive: %w", err)
+	}
+
+	manifestFile, ok := files["manifest.yaml"]
+	if !ok {
+		return "", fmt.Errorf("invalid archive: no manifest.yaml")
+	}
+	var manifest types.Manifest
+	if err := readArchiveYAML(manifestFile, &manifest); err != nil {
+		return "", fmt.Errorf("invalid archive: %w", err)
+	}
+	for _, chapter := range manifest.Document.Chapters {
+		dir, ok := manifest.ChapterDir(chapter.Number)
+		if !ok {
+			return "", fmt.Errorf("invalid archive: chapter %d has no directory in the chapter order", chapter.Number)
+		}
+		metadataPath := fmt.Sprintf("chapters/%s/metadata.yaml", dir)
+		if _, ok := files[metadataPath]; !ok {
+			return "", fmt.Errorf("invalid archive: chapter %d has no %s", chapter.Number, metadataPath)
+		}
+	}
+
+	docID := newID
+	if docID == "" {
+		docID = manifest.Document.ID
+	}
+	if err := docID.Validate(); err != nil {
+		return "", fmt.Errorf("invalid document ID: %w", err)
+	}
+	if err := m.checkDocumentLimit(); err != nil {
+		return "", err
+	}
+	var restoredBytes int64
+	for _, file := range files {
+		restoredBytes += int64(file.UncompressedSize64)
+	}
+	if m.config.MaxDocumentSize > 0 && restoredBytes > m.config.MaxDocumentSize {
+		return "", fmt.Errorf("storage quota exceeded: the archive holds %d bytes, over the %d bytes a document may use", restoredBytes, m.config.MaxDocumentSize)
+	}
+	if err := m.checkTotalQuota(ctx, restoredBytes); err != nil {
+		return "", err
+	}
+	docID, err = m.availableDocumentID(docID)
+	if err != nil {
+		return "", err
+	}
+
+	docDir := m.config.DocumentPath(string(docID))
+	if err := extractArchive(files, docDir); err != nil {
+		os.RemoveAll(docDir)
+		return "", fmt.Errorf("failed to restore document: %w", err)
+	}
+	if err := m.publishDocument(docID); err != nil {
+		m.storage.DeleteDocument(string(docID))
+		return "", err
+	}
+
+	// The manifest records the document's ID
+	restored, err := m.storage.LoadManifest(string(docID))
+	if err == nil {
+		restored.Document.ID = docID
+		err = m.storage.SaveManifest(string(docID), restored)
+	}
+	if err != nil {
+		os.RemoveAll(docDir)
+		return "", fmt.Errorf("failed to update restored manifest: %w", err)
+	}
+
+	return docID, nil
+}
+
+// archiveFiles checks an archive's entries and maps them by their path within
+// the document. Every entry must sit in the same top-level folder, stay inside
+// it, and be no larger than the configured file size limit. A hooks.yaml is
+// dropped: hooks run programs on the server, so only the operator sets them.
+func (m *Manager) archiveFiles(entries []*zip.File) (map[string]*zip.File, error) {
+	if len(entries) > maxArchiveEntries {
+		return nil, fmt.Errorf("too many files (%d, max %d)", len(entries), maxArchiveEntries)
+	}
+
+	files := make(map[string]*zip.File)
+	folder := ""
+	for _, entry := range entries {
+		if strings.HasSuffix(entry.Name, "/") {
+			continue
+		}
+		name := path.Clean(entry.Name)
+		if strings.Contains(entry.Name, "\\") || path.IsAbs(name) || name == ".." || strings.HasPrefix(name, "../") {
+			return nil, fmt.Errorf("unsafe path %s", entry.Name)
+		}
+		top, rel, ok := strings.Cut(name, "/")
+		if !ok {
+			return nil, fmt.Errorf("%s is outside the document folder", entry.Name)
+		}
+		if folder == "" {
+			folder = top
+		} else if top != folder {
+			return nil, fmt.Errorf("more than one document folder (%s and %s)", folder, top)
+		}
+		if !entry.Mode().IsRegular() {
+			return nil, fmt.Errorf("%s is not a regular file", entry.Name)
+		}
+		if m.config.MaxFileSize > 0 && entry.UncompressedSize64 > uint64(m.config.MaxFileSize) {
+			return nil, fmt.Errorf("%s is larger than %d bytes", entry.Name, m.config.MaxFileSize)
+		}
+		if rel == "hooks.yaml" {
+			continue
+		}
+		files[rel] = entry
+	}
+	return files, nil
+}
+
+// readArchiveYAML decodes a YAML file from an archive
+func readArchiveYAML(file *zip.File, data interface{}) error {
+	rc, err := file.Open()
+	if err != nil {
+		return fmt.Errorf("failed to open %s: %w", file.Name, err)
+	}
+	defer rc.Close()
+	if err := yaml.NewDecoder(rc).Decode(data); err != nil {
+		return fmt.Errorf("failed to decode %s: %w", file.Name, err)
+	}
+	return nil
+}
+
+// extractArchive writes archive files to their paths under dir
+func extractArchive(files map[string]*zip.File, dir string) error {
+	for rel, file := range files {
+		target := filepath.Join(dir, filepath.FromSlash(rel))
+		if err := os.MkdirAll(filepath.Dir(target), 0755); err != nil {
+			return err
+		}
+		rc, err := file.Open()
+		if err != nil {
+			return fmt.Errorf("failed to open %s: %w", file.Name, err)
+		}
+		out, err := os.Create(target)
+		if err != nil {
+			rc.Close()
+			return err
+		}
+		// The header's size was checked; don't trust it beyond that
+		_, err = io.Copy(out, io.LimitReader(rc, int64(file.UncompressedSize64)))
+		rc.Close()
+		if closeErr := out.Close(); err == nil {
+			err = closeErr
+		}
+		if err != nil {
+			return fmt.Errorf("failed to extract %s: %w", file.Name, err)
+		}
+	}
+	return nil
+}
+
+// availableDocumentID returns docID if no document has it, or else the same
+// title slug with a fresh timestamp
+func (m *Manager) availableDocumentID(docID types.DocumentID) (types.DocumentID, error) {
+	base := documentIDTimestamp.ReplaceAllString(string(docID), "")
+	candidate := docID
+	for attempt := 0; ; attempt++ {
+		exists, err := m.storage.DocumentExists(string(candidate))
+		if err != nil {
+			return "", fmt.Errorf("failed to check document existence: %w", err)
+		}
+		if !exists {
+			return candidate, nil
+		}
+		candidate = types.DocumentID(fmt.Sprintf("%s-%d", base, time.Now().Unix()))
+		if attempt > 0 {
+			candidate = types.DocumentID(fmt.Sprintf("%s-%d-%d", base, time.Now().Unix(), attempt+1))
+		}
+		if len(candidate) > 50 {
+			return "", fmt.Errorf("no free document ID for %s", docID)
+		}
+	}
+}
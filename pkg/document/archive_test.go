@@ -0,0 +1,138 @@
+package document
+
+import (
+	"archive/zip"
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/gomcpgo/docgen/pkg/types"
+)
+
+func TestManager_ArchiveAndRestoreDocument(t *testing.T) {
+	manager, tempDir := setupTestManager(t)
+	defer os.RemoveAll(tempDir)
+
+	docID, err := manager.CreateDocument("Backup", "Test Author", types.DocumentTypeReport)
+	if err != nil {
+		t.Fatalf("Failed to create document: %v", err)
+	}
+	chapterNum, err := manager.AddChapter(context.Background(), docID, "Kept", nil)
+	if err != nil {
+		t.Fatalf("Failed to add chapter: %v", err)
+	}
+	if _, err := manager.AddSection(context.Background(), docID, chapterNum, "Body", "Archived words.", 1); err != nil {
+		t.Fatalf("Failed to add section: %v", err)
+	}
+	if _, err := manager.storage.SaveAsset(string(docID), "logo.png", []byte("PNG")); err != nil {
+		t.Fatalf("Failed to save asset: %v", err)
+	}
+
+	archivePath, err := manager.ArchiveDocument(context.Background(), docID)
+	if err != nil {
+		t.Fatalf("ArchiveDocument() error = %v", err)
+	}
+	if filepath.Dir(archivePath) != manager.config.ArchivesPath() {
+		t.Errorf("Expected the archive in archives/, got %s", archivePath)
+	}
+
+	// The original still exists, so the restored copy gets a new ID
+	restoredID, err := manager.RestoreDocument(context.Background(), filepath.Base(archivePath), "")
+	if err != nil {
+		t.Fatalf("RestoreDocument() error = %v", err)
+	}
+	if restoredID == docID || !strings.HasPrefix(string(restoredID), "backup-") {
+		t.Errorf("Expected a fresh ID, got %s", restoredID)
+	}
+	manifest, err := manager.GetDocumentStructure(restoredID)
+	if err != nil {
+		t.Fatalf("Failed to load restored document: %v", err)
+	}
+	if manifest.Document.ID != restoredID || len(manifest.Document.Chapters) != 1 {
+		t.Errorf("Unexpected restored manifest %+v", manifest.Document)
+	}
+	content, err := manager.GetSectionContent(restoredID, chapterNum, types.SectionNumber{1, 1})
+	if err != nil || content != "Archived words." {
+		t.Errorf("Unexpected restored section %q, %v", content, err)
+	}
+	if _, err := os.Stat(filepath.Join(manager.config.AssetsPath(string(restoredID)), "logo.png")); err != nil {
+		t.Errorf("Expected the asset to be restored: %v", err)
+	}
+
+	// An explicit ID is used when free
+	namedID, err := manager.RestoreDocument(context.Background(), archivePath, "backup-copy")
+	if err != nil || namedID != "backup-copy" {
+		t.Errorf("Expected backup-copy, got %s, %v", namedID, err)
+	}
+}
+
+func TestManager_RestoreDocumentDropsHooks(t *testing.T) {
+	manager, tempDir := setupTestManager(t)
+	defer os.RemoveAll(tempDir)
+
+	docID, _ := manager.CreateDocument("Hooked", "Test Author", types.DocumentTypeReport)
+	docDir := manager.config.DocumentPath(string(docID))
+	os.WriteFile(manager.config.DocumentHooksPath(string(docID)), []byte("hooks:\n  - event: pre_markdown\n    command: [./run.sh]\n"), 0644)
+	os.WriteFile(filepath.Join(docDir, "run.sh"), []byte("#!/bin/sh\n"), 0755)
+
+	archivePath, err := manager.ArchiveDocument(context.Background(), docID)
+	if err != nil {
+		t.Fatalf("ArchiveDocument() error = %v", err)
+	}
+	restoredID, err := manager.RestoreDocument(context.Background(), archivePath, "hooked-copy")
+	if err != nil {
+		t.Fatalf("RestoreDocument() error = %v", err)
+	}
+	if _, err := os.Stat(manager.config.DocumentHooksPath(string(restoredID))); !os.IsNotExist(err) {
+		t.Errorf("Expected the hooks file dropped on restore, got %v", err)
+	}
+	if hooks, err := manager.storage.LoadDocumentHooks(string(restoredID)); err != nil || len(hooks.Hooks) != 0 {
+		t.Errorf("Expected no hooks for the restored document, got %+v, %v", hooks, err)
+	}
+}
+
+func TestManager_RestoreDocumentRejectsInvalidArchives(t *testing.T) {
+	manager, tempDir := setupTestManager(t)
+	defer os.RemoveAll(tempDir)
+	os.MkdirAll(manager.config.ArchivesPath(), 0755)
+
+	tests := map[string]map[string]string{
+		"escapes.zip": {
+			"doc/manifest.yaml":      "document:\n  id: doc\n",
+			"doc/../../outside.yaml": "x",
+		},
+		"no-manifest.zip": {
+			"doc/style.yaml": "x",
+		},
+		"missing-chapter.zip": {
+			"doc/manifest.yaml": "document:\n  id: doc\n  chapters:\n    - number: 1\n      title: One\n",
+		},
+	}
+	for name, files := range tests {
+		path := filepath.Join(manager.config.ArchivesPath(), name)
+		file, err := os.Create(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		writer := zip.NewWriter(file)
+		for entry, content := range files {
+			w, _ := writer.Create(entry)
+			w.Write([]byte(content))
+		}
+		writer.Close()
+		file.Close()
+
+		if _, err := manager.RestoreDocument(context.Background(), name, ""); err == nil {
+			t.Errorf("%s: expected an error", name)
+		}
+	}
+
+	if docs, _ := manager.storage.ListDocuments(); len(docs) != 0 {
+		t.Errorf("Expected nothing restored, got %v", docs)
+	}
+	if _, err := os.Stat(filepath.Join(tempDir, "outside.yaml")); err == nil {
+		t.Errorf("Archive wrote outside the document")
+	}
+}
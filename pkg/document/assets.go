@@ -0,0 +1,109 @@
+package document
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/gomcpgo/docgen/pkg/types"
+)
+
+// CheckAssets reports images in assets/images that no figure or section refers
+// to, and figures whose image file is missing. With prune the unused images are
+// deleted; figures are never changed.
+func (m *Manager) CheckAssets(ctx context.Context, docID types.DocumentID, prune bool) (*types.AssetReport, error) {
+	if err := docID.Validate(); err != nil {
+		return nil, fmt.Errorf("invalid document ID: %w", err)
+	}
+
+	if err := m.SyncDocument(ctx, docID); err != nil {
+		return nil, err
+	}
+	manifest, err := m.storage.LoadManifest(string(docID))
+	if err != nil {
+		return nil, fmt.Errorf("failed to load manifest: %w", err)
+	}
+
+	report := &types.AssetReport{
+		Orphaned:       []types.OrphanedAsset{},
+		MissingFigures: []types.MissingFigure{},
+	}
+	docDir := m.config.DocumentPath(string(docID))
+	assetsDir := m.config.AssetsPath(string(docID))
+
+	// Files used by figures, and section text that may embed images directly
+	used := make(map[string]bool)
+	var content strings.Builder
+	for _, chapterRef := range manifest.Document.Chapters {
+		chapter, err := m.storage.LoadChapterMetadata(string(docID), int(chapterRef.Number))
+		if err != nil {
+			return nil, fmt.Errorf("failed to load chapter %d metadata: %w", chapterRef.Number, err)
+		}
+		for _, figure := range chapter.Figures {
+			for _, imagePath := range figure.ImagePaths() {
+				resolved, err := m.config.ResolvePath(imagePath, docDir)
+				if err == nil {
+					used[resolved] = true
+					if _, err = os.Stat(resolved); err == nil {
+						continue
+					}
+				}
+				report.MissingFigures = append(report.MissingFigures, types.MissingFigure{
+					FigureID:  figure.ID,
+					Chapter:   chapter.Number,
+					ImagePath: imagePath,
+				})
+			}
+		}
+		for _, section := range chapter.Sections {
+			text, err := m.storage.LoadSectionContent(string(docID), int(chapter.Number), section.Number)
+			if err != nil {
+				continue
+			}
+			content.WriteString(text)
+			content.WriteString("\n")
+		}
+	}
+	sectionText := content.String()
+
+	entries, err := os.ReadDir(assetsDir)
+	if err != nil && !os.IsNotExist(err) {
+		return nil, fmt.Errorf("failed to read assets directory: %w", err)
+	}
+	for _, entry := range entries {
+		if entry.IsDir() {
+			continue
+		}
+		// Compare canonical paths, as figure paths were resolved
+		path, err := m.config.ResolvePath(entry.Name(), assetsDir)
+		if err != nil || used[path] || strings.Contains(sectionText, entry.Name()) {
+			continue
+		}
+		info, err := entry.Info()
+		if err != nil {
+			continue
+		}
+		report.Orphaned = append(report.Orphaned, types.OrphanedAsset{File: entry.Name(), Size: info.Size()})
+		report.OrphanedBytes += info.Size()
+	}
+	sort.Slice(report.Orphaned, func(i, j int) bool {
+		return report.Orphaned[i].File < report.Orphaned[j].File
+	})
+
+	if prune {
+		for _, asset := range report.Orphaned {
+			if err := os.Remove(filepath.Join(assetsDir, asset.File)); err != nil {
+				return nil, fmt.Errorf("failed to remove %s: %w", asset.File, err)
+			}
+		}
+		report.Pruned = true
+		if err := m.publishDocument(docID); err != nil {
+			return nil, err
+		}
+	}
+
+	return report, nil
+}
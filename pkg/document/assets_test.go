@@ -0,0 +1,64 @@
+package document
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/gomcpgo/docgen/pkg/types"
+)
+
+func TestManager_CheckAssets(t *testing.T) {
+	manager, tempDir := setupTestManager(t)
+	defer os.RemoveAll(tempDir)
+
+	docID, err := manager.CreateDocument("Assets", "Test Author", types.DocumentTypeReport)
+	if err != nil {
+		t.Fatalf("Failed to create document: %v", err)
+	}
+	chapterNum, err := manager.AddChapter(context.Background(), docID, "Images", nil)
+	if err != nil {
+		t.Fatalf("Failed to add chapter: %v", err)
+	}
+
+	for name, data := range map[string]string{"used.png": "PNG", "inline.png": "PNG", "stale.png": "12345"} {
+		if _, err := manager.storage.SaveAsset(string(docID), name, []byte(data)); err != nil {
+			t.Fatalf("Failed to save asset: %v", err)
+		}
+	}
+	if _, err := manager.AddImage(docID, chapterNum, "assets/images/used.png", "Used", "here", "", ""); err != nil {
+		t.Fatalf("Failed to add image: %v", err)
+	}
+	gone, err := manager.AddImage(docID, chapterNum, "assets/images/gone.png", "Gone", "here", "", "")
+	if err != nil {
+		t.Fatalf("Failed to add image: %v", err)
+	}
+	if _, err := manager.AddSection(context.Background(), docID, chapterNum, "Inline", "![Inline](assets/images/inline.png)", 1); err != nil {
+		t.Fatalf("Failed to add section: %v", err)
+	}
+
+	report, err := manager.CheckAssets(context.Background(), docID, false)
+	if err != nil {
+		t.Fatalf("CheckAssets() error = %v", err)
+	}
+	if len(report.Orphaned) != 1 || report.Orphaned[0].File != "stale.png" || report.OrphanedBytes != 5 {
+		t.Errorf("Unexpected orphaned assets %+v", report.Orphaned)
+	}
+	if len(report.MissingFigures) != 1 || report.MissingFigures[0].FigureID != gone {
+		t.Errorf("Unexpected missing figures %+v", report.MissingFigures)
+	}
+	if _, err := os.Stat(filepath.Join(manager.config.AssetsPath(string(docID)), "stale.png")); err != nil {
+		t.Errorf("Checking without prune should keep the file")
+	}
+
+	if _, err := manager.CheckAssets(context.Background(), docID, true); err != nil {
+		t.Fatalf("CheckAssets() error = %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(manager.config.AssetsPath(string(docID)), "stale.png")); !os.IsNotExist(err) {
+		t.Errorf("Expected the unused image to be pruned")
+	}
+	if _, err := os.Stat(filepath.Join(manager.config.AssetsPath(string(docID)), "used.png")); err != nil {
+		t.Errorf("Expected the used image to be kept")
+	}
+}
@@ -0,0 +1,73 @@
+package document
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/gomcpgo/docgen/pkg/types"
+)
+
+// AddAuthor appends an author to the document's author list
+func (m *Manager) AddAuthor(docID types.DocumentID, author types.Author) (types.AuthorList, error) {
+	if err := docID.Validate(); err != nil {
+		return nil, fmt.Errorf("invalid document ID: %w", err)
+	}
+	if err := author.Validate(); err != nil {
+		return nil, err
+	}
+
+	manifest, err := m.storage.LoadManifest(string(docID))
+	if err != nil {
+		return nil, fmt.Errorf("failed to load document manifest: %w", err)
+	}
+
+	if manifest.Document.Authors.Index(author.Name) >= 0 {
+		return nil, fmt.Errorf("author %q already exists in document %s", author.Name, docID)
+	}
+
+	now := time.Now()
+	manifest.Document.Authors = append(manifest.Document.Authors, author)
+	manifest.Document.UpdatedAt = now
+	manifest.UpdatedAt = now
+
+	if err := m.storage.SaveManifest(string(docID), manifest); err != nil {
+		return nil, fmt.Errorf("failed to update manifest: %w", err)
+	}
+
+	return manifest.Document.Authors, nil
+}
+
+// RemoveAuthor removes an author by name. A document always keeps at least one author.
+func (m *Manager) RemoveAuthor(docID types.DocumentID, name string) (types.AuthorList, error) {
+	if err := docID.Validate(); err != nil {
+		return nil, fmt.Errorf("invalid document ID: %w", err)
+	}
+	if name == "" {
+		return nil, fmt.Errorf("author name is required")
+	}
+
+	manifest, err := m.storage.LoadManifest(string(docID))
+	if err != nil {
+		return nil, fmt.Errorf("failed to load document manifest: %w", err)
+	}
+
+	index := manifest.Document.Authors.Index(name)
+	if index < 0 {
+		return nil, fmt.Errorf("author %q not found in document %s", name, docID)
+	}
+	if len(manifest.Document.Authors) == 1 {
+		return nil, fmt.Errorf("cannot remove the only author of document %s", docID)
+	}
+
+	now := time.Now()
+	authors := manifest.Document.Authors
+	manifest.Document.Authors = append(authors[:index:index], authors[index+1:]...)
+	manifest.Document.UpdatedAt = now
+	manifest.UpdatedAt = now
+
+	if err := m.storage.SaveManifest(string(docID), manifest); err != nil {
+		return nil, fmt.Errorf("failed to update manifest: %w", err)
+	}
+
+	return manifest.Document.Authors, nil
+}
@@ -0,0 +1,60 @@
+package document
+
+import (
+	"testing"
+
+	"github.com/gomcpgo/docgen/pkg/types"
+)
+
+func TestManager_AddRemoveAuthor(t *testing.T) {
+	manager, _ := setupTestManager(t)
+
+	docID, err := manager.CreateDocument("Test Document", "Test Author", types.DocumentTypeArticle)
+	if err != nil {
+		t.Fatalf("Failed to create document: %v", err)
+	}
+
+	authors, err := manager.AddAuthor(docID, types.Author{
+		Name:        "Second Author",
+		Affiliation: "Example University",
+		Email:       "second@example.com",
+		ORCID:       "0000-0002-1825-0097",
+	})
+	if err != nil {
+		t.Fatalf("AddAuthor() error = %v", err)
+	}
+	if len(authors) != 2 || authors[1].Affiliation != "Example University" {
+		t.Errorf("AddAuthor() authors = %+v", authors)
+	}
+
+	// Duplicate names are rejected
+	if _, err := manager.AddAuthor(docID, types.Author{Name: "second author"}); err == nil {
+		t.Errorf("AddAuthor() should reject duplicate author")
+	}
+
+	// Invalid ORCID is rejected
+	if _, err := manager.AddAuthor(docID, types.Author{Name: "Third", ORCID: "1234"}); err == nil {
+		t.Errorf("AddAuthor() should reject invalid ORCID")
+	}
+
+	authors, err = manager.RemoveAuthor(docID, "Test Author")
+	if err != nil {
+		t.Fatalf("RemoveAuthor() error = %v", err)
+	}
+	if len(authors) != 1 || authors[0].Name != "Second Author" {
+		t.Errorf("RemoveAuthor() authors = %+v", authors)
+	}
+
+	// The last author cannot be removed
+	if _, err := manager.RemoveAuthor(docID, "Second Author"); err == nil {
+		t.Errorf("RemoveAuthor() should not remove the only author")
+	}
+
+	manifest, err := manager.GetDocumentStructure(docID)
+	if err != nil {
+		t.Fatalf("Failed to get document structure: %v", err)
+	}
+	if manifest.Document.Authors.String() != "Second Author" {
+		t.Errorf("Persisted authors = %q", manifest.Document.Authors.String())
+	}
+}
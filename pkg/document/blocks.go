@@ -0,0 +1,121 @@
+package document
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/gomcpgo/docgen/pkg/types"
+)
+
+var (
+	blockListPattern   = regexp.MustCompile(`^([-*+]|\d+[.)])\s`)
+	blockFigurePattern = regexp.MustCompile(`^!\[[^\]]*\]\(`)
+)
+
+// GetSectionBlocks returns a section's content split into numbered blocks, the
+// paragraphs InsertIntoSection counts, so single blocks can be edited without
+// sending the whole section
+func (m *Manager) GetSectionBlocks(docID types.DocumentID, chapterNum types.ChapterNumber, sectionNum types.SectionNumber) ([]types.SectionBlock, error) {
+	if err := docID.Validate(); err != nil {
+		return nil, fmt.Errorf("invalid document ID: %w", err)
+	}
+	content, err := m.storage.LoadSectionContent(string(docID), int(chapterNum), sectionNum)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load section %s: %w", sectionNum.String(), err)
+	}
+
+	blocks := []types.SectionBlock{}
+	for i, bounds := range scanParagraphs(content) {
+		text := content[bounds[0]:bounds[1]]
+		blocks = append(blocks, types.SectionBlock{Number: i + 1, Kind: blockKind(text), Content: text})
+	}
+	return blocks, nil
+}
+
+// UpdateBlock replaces block number block of a section with content, which
+// may hold several blocks of its own. When expectedText is given the block
+// must contain it, so an edit never lands on a block that has since moved.
+func (m *Manager) UpdateBlock(ctx context.Context, docID types.DocumentID, chapterNum types.ChapterNumber, sectionNum types.SectionNumber, block int, content, expectedText string) error {
+	if strings.TrimSpace(content) == "" {
+		return fmt.Errorf("block content is required; use delete_block to remove a block")
+	}
+	current, bounds, err := m.loadBlock(docID, chapterNum, sectionNum, block, expectedText)
+	if err != nil {
+		return err
+	}
+	return m.UpdateSection(ctx, docID, chapterNum, sectionNum, current[:bounds[block-1][0]]+strings.Trim(content, "\n")+current[bounds[block-1][1]:])
+}
+
+// InsertBlock inserts content as a new block of a section after block number
+// after, or at the start of the section when after is 0
+func (m *Manager) InsertBlock(ctx context.Context, docID types.DocumentID, chapterNum types.ChapterNumber, sectionNum types.SectionNumber, after int, content string) error {
+	if after != 0 {
+		if _, _, err := m.loadBlock(docID, chapterNum, sectionNum, after, ""); err != nil {
+			return err
+		}
+	}
+	return m.InsertIntoSection(ctx, docID, chapterNum, sectionNum, content, after, "")
+}
+
+// DeleteBlock removes block number block of a section along with the blank
+// lines that separated it from the next one. A section's only block can't be
+// deleted, as sections always have content.
+func (m *Manager) DeleteBlock(ctx context.Context, docID types.DocumentID, chapterNum types.ChapterNumber, sectionNum types.SectionNumber, block int, expectedText string) error {
+	current, bounds, err := m.loadBlock(docID, chapterNum, sectionNum, block, expectedText)
+	if err != nil {
+		return err
+	}
+	if len(bounds) == 1 {
+		return fmt.Errorf("block 1 is the only block of section %s; replace it with update_block or remove the section with delete_section", sectionNum.String())
+	}
+
+	target := bounds[block-1]
+	if block < len(bounds) {
+		return m.UpdateSection(ctx, docID, chapterNum, sectionNum, current[:target[0]]+current[bounds[block][0]:])
+	}
+	return m.UpdateSection(ctx, docID, chapterNum, sectionNum, current[:bounds[block-2][1]]+current[target[1]:])
+}
+
+// loadBlock loads a section for a block edit, with the bounds of its blocks,
+// checking that the block exists and contains expectedText
+func (m *Manager) loadBlock(docID types.DocumentID, chapterNum types.ChapterNumber, sectionNum types.SectionNumber, block int, expectedText string) (string, [][2]int, error) {
+	if err := docID.Validate(); err != nil {
+		return "", nil, fmt.Errorf("invalid document ID: %w", err)
+	}
+	current, err := m.storage.LoadSectionContent(string(docID), int(chapterNum), sectionNum)
+	if err != nil {
+		return "", nil, fmt.Errorf("failed to load section %s: %w", sectionNum.String(), err)
+	}
+
+	bounds := scanParagraphs(current)
+	if block < 1 || block > len(bounds) {
+		return "", nil, fmt.Errorf("block %d not found: section %s has %d block(s)", block, sectionNum.String(), len(bounds))
+	}
+	if expectedText != "" && !strings.Contains(current[bounds[block-1][0]:bounds[block-1][1]], expectedText) {
+		return "", nil, fmt.Errorf("block %d of section %s doesn't contain %q; call get_section_blocks for the current blocks", block, sectionNum.String(), expectedText)
+	}
+	return current, bounds, nil
+}
+
+// blockKind tells what a block holds from its first line
+func blockKind(block string) types.BlockKind {
+	line, _, _ := strings.Cut(block, "\n")
+	trimmed := strings.TrimSpace(line)
+	switch {
+	case strings.HasPrefix(trimmed, "```") || strings.HasPrefix(trimmed, "~~~"):
+		return types.BlockCode
+	case strings.HasPrefix(trimmed, "#"):
+		return types.BlockHeading
+	case blockListPattern.MatchString(trimmed):
+		return types.BlockList
+	case strings.HasPrefix(trimmed, "|") || strings.HasPrefix(trimmed, "Table:"):
+		return types.BlockTable
+	case strings.HasPrefix(trimmed, ">"):
+		return types.BlockQuote
+	case blockFigurePattern.MatchString(trimmed):
+		return types.BlockFigure
+	}
+	return types.BlockParagraph
+}
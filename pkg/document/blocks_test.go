@@ -0,0 +1,69 @@
+package document
+
+import (
+	"context"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/gomcpgo/docgen/pkg/types"
+)
+
+func TestManager_BlockEdits(t *testing.T) {
+	manager, tempDir := setupTestManager(t)
+	defer os.RemoveAll(tempDir)
+
+	docID, _ := manager.CreateDocument("Report", "Test Author", types.DocumentTypeReport)
+	chapterNum, _ := manager.AddChapter(context.Background(), docID, "Methods", nil)
+	sectionNum, _ := manager.AddSection(context.Background(), docID, chapterNum, "Setup",
+		"Intro.\n\n- one\n- two\n\n```\ncode\n\nmore\n```\n\n| a |\n|---|\n\n> Quoted.\n\n![Chart](assets/images/chart.png)\n\n### Details\n", 1)
+	content := func() string {
+		text, _ := manager.GetSectionContent(docID, chapterNum, sectionNum)
+		return text
+	}
+
+	blocks, err := manager.GetSectionBlocks(docID, chapterNum, sectionNum)
+	if err != nil {
+		t.Fatalf("GetSectionBlocks() error = %v", err)
+	}
+	var kinds []string
+	for _, block := range blocks {
+		kinds = append(kinds, string(block.Kind))
+	}
+	if got := strings.Join(kinds, " "); got != "paragraph list code table quote figure heading" {
+		t.Errorf("Block kinds = %s", got)
+	}
+	if blocks[2].Number != 3 || blocks[2].Content != "```\ncode\n\nmore\n```" {
+		t.Errorf("Unexpected code block %+v", blocks[2])
+	}
+
+	if err := manager.UpdateBlock(context.Background(), docID, chapterNum, sectionNum, 1, "New intro.\n\nSecond.", "Intro"); err != nil {
+		t.Fatalf("UpdateBlock() error = %v", err)
+	}
+	if err := manager.DeleteBlock(context.Background(), docID, chapterNum, sectionNum, 4, "code"); err != nil {
+		t.Fatalf("DeleteBlock() error = %v", err)
+	}
+	if err := manager.DeleteBlock(context.Background(), docID, chapterNum, sectionNum, 7, ""); err != nil {
+		t.Fatalf("DeleteBlock() of the last block error = %v", err)
+	}
+	want := "New intro.\n\nSecond.\n\n- one\n- two\n\n| a |\n|---|\n\n> Quoted.\n\n![Chart](assets/images/chart.png)\n"
+	if got := content(); got != want {
+		t.Errorf("After block edits:\n%q\nwant\n%q", got, want)
+	}
+
+	// Edits refuse blocks that moved or don't exist
+	if err := manager.UpdateBlock(context.Background(), docID, chapterNum, sectionNum, 1, "Text.", "Intro."); err == nil || !strings.Contains(err.Error(), "doesn't contain") {
+		t.Errorf("UpdateBlock() error = %v, want an expected_text mismatch", err)
+	}
+	if err := manager.DeleteBlock(context.Background(), docID, chapterNum, sectionNum, 9, ""); err == nil || !strings.Contains(err.Error(), "has 6 block(s)") {
+		t.Errorf("DeleteBlock() error = %v, want block not found", err)
+	}
+	if err := manager.UpdateBlock(context.Background(), docID, chapterNum, sectionNum, 1, " ", ""); err == nil {
+		t.Error("Expected empty block content to be refused")
+	}
+
+	only, _ := manager.AddSection(context.Background(), docID, chapterNum, "Short", "Only block.", 1)
+	if err := manager.DeleteBlock(context.Background(), docID, chapterNum, only, 1, ""); err == nil || !strings.Contains(err.Error(), "only block") {
+		t.Errorf("DeleteBlock() of the only block error = %v", err)
+	}
+}
@@ -65,6 +65,10 @@ func (m *MockStorage) CreateSectionsDirectory(documentID string, chapterNumber i
 	return nil // No-op for mock
 }
 
+func (m *MockStorage) LoadLegacySectionContent(documentID string, chapterNumber int) (map[string]string, error) {
+	return map[string]string{}, nil // Mock never stores content in metadata
+}
+
 func (m *MockStorage) SaveChapterMetadata(documentID string, chapter *types.Chapter) error {
 	key := documentID + "-" + string(rune(int(chapter.Number)))
 	m.chapters[key] = chapter
@@ -85,10 +89,30 @@ func (m *MockStorage) SaveStyle(documentID string, style *types.Style) error
 func (m *MockStorage) LoadStyle(documentID string) (*types.Style, error)                          { return nil, nil }
 func (m *MockStorage) SaveStyleByName(styleName string, style *types.Style) error                { return nil }
 func (m *MockStorage) LoadStyleByName(styleName string) (*types.Style, error)                    { return nil, nil }
+func (m *MockStorage) LoadStyleFields(styleName string) (map[string]interface{}, error)          { return nil, nil }
 func (m *MockStorage) EnsureDefaultStyle() error                                                  { return nil }
 func (m *MockStorage) SavePandocConfig(documentID string, config *types.PandocConfig) error       { return nil }
 func (m *MockStorage) LoadPandocConfig(documentID string) (*types.PandocConfig, error)            { return nil, nil }
 func (m *MockStorage) LoadChapterContent(documentID string, chapterNumber int) (string, error)    { return "", nil }
+func (m *MockStorage) SaveAsset(documentID string, fileName string, data []byte) (string, error)   { return fileName, nil }
+func (m *MockStorage) SaveSectionTemplate(template *types.SectionTemplate) error                  { return nil }
+func (m *MockStorage) LoadSectionTemplate(templateName string) (*types.SectionTemplate, error)    { return nil, nil }
+func (m *MockStorage) SaveHouseStyle(style *types.HouseStyle) error                               { return nil }
+func (m *MockStorage) LoadHouseStyle(name string) (*types.HouseStyle, error)                      { return nil, nil }
+func (m *MockStorage) SaveWritingStats(documentID string, stats *types.WritingStats) error         { return nil }
+func (m *MockStorage) LoadWritingStats(documentID string) (*types.WritingStats, error)             { return &types.WritingStats{}, nil }
+func (m *MockStorage) SaveExportHistory(documentID string, history *types.ExportHistory) error      { return nil }
+func (m *MockStorage) LoadExportHistory(documentID string) (*types.ExportHistory, error)           { return &types.ExportHistory{}, nil }
+func (m *MockStorage) SaveChapterRevisions(documentID string, chapterNumber int, revisions *types.ChapterRevisions) error { return nil }
+func (m *MockStorage) LoadChapterRevisions(documentID string, chapterNumber int) (*types.ChapterRevisions, error) { return &types.ChapterRevisions{}, nil }
+func (m *MockStorage) SaveUsageLedger(ledger *types.UsageLedger) error                             { return nil }
+func (m *MockStorage) LoadUsageLedger() (*types.UsageLedger, error)                                { return &types.UsageLedger{}, nil }
+func (m *MockStorage) SaveAccessPolicy(policy *types.AccessPolicy) error                           { return nil }
+func (m *MockStorage) LoadAccessPolicy() (*types.AccessPolicy, error)                              { return &types.AccessPolicy{}, nil }
+func (m *MockStorage) LoadHooks() (*types.HookConfig, error)                                       { return &types.HookConfig{}, nil }
+func (m *MockStorage) SaveFont(documentID string, fileName string, data []byte) (string, error)     { return fileName, nil }
+func (m *MockStorage) ListFonts(documentID string) ([]string, error)                                { return []string{}, nil }
+func (m *MockStorage) LoadDocumentHooks(documentID string) (*types.HookConfig, error)              { return &types.HookConfig{}, nil }
 
 func TestRebuildChapterMarkdown_SimpleStructure(t *testing.T) {
 	// Create mock storage and manager
@@ -0,0 +1,170 @@
+package document
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"unicode"
+
+	"github.com/gomcpgo/docgen/pkg/types"
+	"gopkg.in/yaml.v3"
+)
+
+// bibtexEntryPattern matches the start of a BibTeX entry and its key: @book{knuth84,
+var bibtexEntryPattern = regexp.MustCompile(`(?m)^\s*@([A-Za-z]+)\s*[{(]\s*([^,\s{}()]+)\s*,`)
+
+// InsertCitation adds a citation to a section and returns its markup. The key
+// must be in the document's bibliography. The citation goes right after the
+// text given in after, which must appear once in the section, or at the end of
+// the section when after is empty. A citation placed at the end of a sentence
+// goes before its full stop, as pandoc expects.
+func (m *Manager) InsertCitation(ctx context.Context, docID types.DocumentID, chapterNum types.ChapterNumber, sectionNum types.SectionNumber, citation types.Citation, after string) (string, error) {
+	if err := docID.Validate(); err != nil {
+		return "", fmt.Errorf("invalid document ID: %w", err)
+	}
+	if err := citation.Validate(); err != nil {
+		return "", err
+	}
+
+	keys, bibliography, err := m.bibliographyKeys(ctx, docID)
+	if err != nil {
+		return "", err
+	}
+	if !keys[citation.Key] {
+		return "", fmt.Errorf("citation key %s not found in bibliography %s", citation.Key, bibliography)
+	}
+
+	content, err := m.GetSectionContent(docID, chapterNum, sectionNum)
+	if err != nil {
+		return "", err
+	}
+	markup := citation.Markup()
+	updated, err := insertCitation(content, markup, after)
+	if err != nil {
+		return "", err
+	}
+	if err := m.UpdateSection(ctx, docID, chapterNum, sectionNum, updated); err != nil {
+		return "", err
+	}
+
+	return markup, nil
+}
+
+// bibliographyKeys returns the citation keys in a document's bibliography,
+// along with the bibliography as configured
+func (m *Manager) bibliographyKeys(ctx context.Context, docID types.DocumentID) (map[string]bool, string, error) {
+	pandocConfig, err := m.storage.LoadPandocConfig(string(docID))
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to load pandoc config: %w", err)
+	}
+	if pandocConfig.Bibliography == "" {
+		return nil, "", fmt.Errorf("document %s has no bibliography (set one with configure_document)", docID)
+	}
+
+	// The bibliography is read from the document's local files
+	if err := m.SyncDocument(ctx, docID); err != nil {
+		return nil, "", err
+	}
+	path, err := m.config.ResolvePath(pandocConfig.Bibliography, m.config.DocumentPath(string(docID)))
+	if err != nil {
+		return nil, "", fmt.Errorf("invalid bibliography path: %w", err)
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to read bibliography: %w", err)
+	}
+
+	keys, err := parseBibliographyKeys(filepath.Ext(path), data)
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to parse bibliography %s: %w", pandocConfig.Bibliography, err)
+	}
+	return keys, pandocConfig.Bibliography, nil
+}
+
+// parseBibliographyKeys reads the keys of a BibTeX (.bib), CSL JSON (.json) or
+// CSL YAML (.yaml) bibliography
+func parseBibliographyKeys(ext string, data []byte) (map[string]bool, error) {
+	keys := make(map[string]bool)
+	switch strings.ToLower(ext) {
+	case ".bib", ".bibtex":
+		for _, match := range bibtexEntryPattern.FindAllSubmatch(data, -1) {
+			switch strings.ToLower(string(match[1])) {
+			case "string", "preamble", "comment":
+				continue
+			}
+			keys[string(match[2])] = true
+		}
+
+	case ".json":
+		var entries []map[string]interface{}
+		if err := json.Unmarshal(data, &entries); err != nil {
+			return nil, err
+		}
+		for _, entry := range entries {
+			if id, ok := entry["id"]; ok {
+				keys[fmt.Sprint(id)] = true
+			}
+		}
+
+	case ".yaml", ".yml":
+		// Either a list of entries or a references field holding one
+		var document struct {
+			References []map[string]interface{} `yaml:"references"`
+		}
+		if err := yaml.Unmarshal(data, &document); err != nil {
+			var entries []map[string]interface{}
+			if listErr := yaml.Unmarshal(data, &entries); listErr != nil {
+				return nil, err
+			}
+			document.References = entries
+		}
+		for _, entry := range document.References {
+			if id, ok := entry["id"]; ok {
+				keys[fmt.Sprint(id)] = true
+			}
+		}
+
+	default:
+		return nil, fmt.Errorf("unsupported bibliography format %q (use .bib, .json or .yaml)", ext)
+	}
+	return keys, nil
+}
+
+// insertCitation places citation markup in content after the text of after,
+// or at the end of content when after is empty
+func insertCitation(content, markup, after string) (string, error) {
+	after = strings.TrimRightFunc(after, unicode.IsSpace)
+	if after == "" {
+		trimmed := strings.TrimRightFunc(content, unicode.IsSpace)
+		return placeCitation(trimmed, markup) + content[len(trimmed):], nil
+	}
+
+	switch count := strings.Count(content, after); count {
+	case 0:
+		return "", fmt.Errorf("text %q not found in section", after)
+	case 1:
+	default:
+		return "", fmt.Errorf("text %q appears %d times in section; give enough text to pick one", after, count)
+	}
+	end := strings.Index(content, after) + len(after)
+	return placeCitation(content[:end], markup) + content[end:], nil
+}
+
+// placeCitation appends a citation to text, before any sentence-ending punctuation
+func placeCitation(text, markup string) string {
+	if text == "" {
+		return markup
+	}
+	stop := ""
+	if last := text[len(text)-1:]; strings.ContainsAny(last, ".!?") {
+		text, stop = text[:len(text)-1], last
+	}
+	if trimmed := strings.TrimRightFunc(text, unicode.IsSpace); trimmed != "" {
+		text = trimmed + " "
+	}
+	return text + markup + stop
+}
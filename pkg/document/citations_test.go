@@ -0,0 +1,112 @@
+package document
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/gomcpgo/docgen/pkg/types"
+)
+
+func TestParseBibliographyKeys(t *testing.T) {
+	tests := []struct {
+		ext  string
+		data string
+		want []string
+	}{
+		{".bib", "@string{tug = \"TeX Users Group\"}\n@Book{knuth84,\n  title = {The TeXbook}\n}\n  @article( lamport94 , title={LaTeX})\n", []string{"knuth84", "lamport94"}},
+		{".json", `[{"id": "knuth84", "type": "book"}, {"id": 1994}]`, []string{"knuth84", "1994"}},
+		{".yaml", "references:\n- id: knuth84\n  type: book\n", []string{"knuth84"}},
+		{".yml", "- id: lamport94\n", []string{"lamport94"}},
+	}
+	for _, tt := range tests {
+		keys, err := parseBibliographyKeys(tt.ext, []byte(tt.data))
+		if err != nil {
+			t.Errorf("parseBibliographyKeys(%s) error = %v", tt.ext, err)
+			continue
+		}
+		if len(keys) != len(tt.want) {
+			t.Errorf("parseBibliographyKeys(%s) = %v, want %v", tt.ext, keys, tt.want)
+		}
+		for _, key := range tt.want {
+			if !keys[key] {
+				t.Errorf("parseBibliographyKeys(%s) is missing %s", tt.ext, key)
+			}
+		}
+	}
+
+	if _, err := parseBibliographyKeys(".ris", nil); err == nil {
+		t.Error("Expected an error for an unsupported format")
+	}
+}
+
+func TestInsertCitation(t *testing.T) {
+	tests := []struct {
+		content string
+		after   string
+		want    string
+		wantErr string
+	}{
+		{"Macros expand in the mouth.\n", "", "Macros expand in the mouth [@k].\n", ""},
+		{"A list:\n\n- item", "", "A list:\n\n- item [@k]", ""},
+		{"As shown by the TeXbook, macros expand.", "the TeXbook", "As shown by the TeXbook [@k], macros expand.", ""},
+		{"Macros expand. Then they are executed.", "expand. ", "Macros expand [@k]. Then they are executed.", ""},
+		{"Macros expand.", "Tokens", "", "not found"},
+		{"Macros expand. Macros execute.", "Macros", "", "appears 2 times"},
+	}
+	for _, tt := range tests {
+		got, err := insertCitation(tt.content, "[@k]", tt.after)
+		if tt.wantErr != "" {
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("insertCitation(%q, %q) error = %v, want %q", tt.content, tt.after, err, tt.wantErr)
+			}
+			continue
+		}
+		if err != nil || got != tt.want {
+			t.Errorf("insertCitation(%q, %q) = %q, %v, want %q", tt.content, tt.after, got, err, tt.want)
+		}
+	}
+}
+
+func TestManager_InsertCitation(t *testing.T) {
+	manager, tempDir := setupTestManager(t)
+	defer os.RemoveAll(tempDir)
+
+	docID, _ := manager.CreateDocument("Test Document", "Test Author", types.DocumentTypeBook)
+	chapterNum, _ := manager.AddChapter(context.Background(), docID, "Test Chapter", nil)
+	sectionNum, err := manager.AddSection(context.Background(), docID, chapterNum, "Macros", "Macros expand in the mouth.", 1)
+	if err != nil {
+		t.Fatalf("AddSection() error = %v", err)
+	}
+	citation := types.Citation{Key: "knuth84", Locator: "p. 12"}
+
+	// Citations need a bibliography
+	if _, err := manager.InsertCitation(context.Background(), docID, chapterNum, sectionNum, citation, ""); err == nil || !strings.Contains(err.Error(), "no bibliography") {
+		t.Fatalf("InsertCitation() without bibliography error = %v", err)
+	}
+
+	os.WriteFile(filepath.Join(tempDir, string(docID), "references.bib"), []byte("@book{knuth84, title={The TeXbook}}\n"), 0644)
+	if err := manager.ConfigureDocument(docID, nil, &types.PandocConfig{Bibliography: "references.bib"}, nil); err != nil {
+		t.Fatalf("ConfigureDocument() error = %v", err)
+	}
+
+	markup, err := manager.InsertCitation(context.Background(), docID, chapterNum, sectionNum, citation, "")
+	if err != nil {
+		t.Fatalf("InsertCitation() error = %v", err)
+	}
+	if markup != "[@knuth84, p. 12]" {
+		t.Errorf("InsertCitation() markup = %q", markup)
+	}
+	content, _ := manager.GetSectionContent(docID, chapterNum, sectionNum)
+	if content != "Macros expand in the mouth [@knuth84, p. 12]." {
+		t.Errorf("Section content = %q", content)
+	}
+
+	// Unknown keys are rejected and leave the section alone
+	citation.Key = "lamport94"
+	if _, err := manager.InsertCitation(context.Background(), docID, chapterNum, sectionNum, citation, ""); err == nil || !strings.Contains(err.Error(), "not found in bibliography") {
+		t.Errorf("InsertCitation() with unknown key error = %v", err)
+	}
+}
@@ -0,0 +1,209 @@
+package document
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/gomcpgo/docgen/pkg/types"
+)
+
+// consistencyExcerptLength is how much of a drifted paragraph an issue quotes
+const consistencyExcerptLength = 60
+
+// CheckConsistency compares each chapter's chapter.md with what rebuilding it
+// from the section files would write, and reports the drift: content in
+// chapter.md no section holds, sections chapter.md is missing or holds an older
+// version of, and section files the metadata and the sections directory
+// disagree about. chapterNum 0 checks every chapter. Nothing is changed.
+func (m *Manager) CheckConsistency(ctx context.Context, docID types.DocumentID, chapterNum types.ChapterNumber) ([]types.ConsistencyIssue, error) {
+	if err := docID.Validate(); err != nil {
+		return nil, fmt.Errorf("invalid document ID: %w", err)
+	}
+
+	if err := m.SyncDocument(ctx, docID); err != nil {
+		return nil, err
+	}
+	manifest, err := m.storage.LoadManifest(string(docID))
+	if err != nil {
+		return nil, fmt.Errorf("failed to load manifest: %w", err)
+	}
+
+	issues := []types.ConsistencyIssue{}
+	found := chapterNum == 0
+	for _, chapterRef := range manifest.Document.Chapters {
+		if chapterNum != 0 && chapterRef.Number != chapterNum {
+			continue
+		}
+		found = true
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+
+		chapter, err := m.storage.LoadChapterMetadata(string(docID), int(chapterRef.Number))
+		if err != nil {
+			return nil, fmt.Errorf("failed to load chapter %d metadata: %w", chapterRef.Number, err)
+		}
+		dir, _ := manifest.ChapterDir(chapter.Number)
+		chapterIssues, err := m.checkChapterConsistency(docID, chapter, dir)
+		if err != nil {
+			return nil, err
+		}
+		issues = append(issues, chapterIssues...)
+	}
+	if !found {
+		return nil, fmt.Errorf("chapter %d not found", chapterNum)
+	}
+
+	return issues, nil
+}
+
+// checkChapterConsistency compares one chapter's chapter.md with its sections
+func (m *Manager) checkChapterConsistency(docID types.DocumentID, chapter *types.Chapter, dir string) ([]types.ConsistencyIssue, error) {
+	var issues []types.ConsistencyIssue
+	issue := func(section string, line int, problem types.ConsistencyProblem, detail, suggestion string) {
+		issues = append(issues, types.ConsistencyIssue{
+			Chapter:    chapter.Number,
+			Section:    section,
+			Line:       line,
+			Problem:    problem,
+			Detail:     detail,
+			Suggestion: suggestion,
+		})
+	}
+
+	// Section files the metadata and the sections directory disagree about
+	listed := make(map[string]bool)
+	for _, section := range chapter.Sections {
+		listed[section.Number.String()] = true
+		if section.IncludeFile != "" {
+			continue
+		}
+		if _, err := m.storage.LoadSectionContent(string(docID), int(chapter.Number), section.Number); err != nil {
+			issue(section.Number.String(), 0, types.ConsistencyMissingSectionFile,
+				fmt.Sprintf("Section %s (%s) has no section file, so exports leave it out", section.Number.String(), section.Title),
+				"Write its content with update_section, or remove it with delete_section")
+		}
+	}
+	if dir != "" {
+		entries, err := os.ReadDir(m.config.SectionsPath(string(docID), dir))
+		if err != nil && !os.IsNotExist(err) {
+			return nil, fmt.Errorf("failed to read sections of chapter %d: %w", chapter.Number, err)
+		}
+		for _, entry := range entries {
+			number, ok := strings.CutSuffix(entry.Name(), ".md")
+			if entry.IsDir() || !ok || listed[number] {
+				continue
+			}
+			issue(number, 0, types.ConsistencyOrphanSectionFile,
+				fmt.Sprintf("%s is not a section of chapter %d, so exports leave it out", filepath.Join("sections", entry.Name()), chapter.Number),
+				"Add its content to a section with add_section or append_to_section, then remove the file")
+		}
+	}
+
+	compiled, sections, err := m.compileChapter(docID, chapter.Number)
+	if err != nil {
+		return nil, err
+	}
+	stored, err := m.storage.LoadChapterContent(string(docID), int(chapter.Number))
+	if err != nil {
+		issue("", 0, types.ConsistencyMissingChapterFile,
+			fmt.Sprintf("Chapter %d has no chapter.md", chapter.Number),
+			"Rebuild it with check_consistency and rebuild set to true")
+		return issues, nil
+	}
+	if stored == compiled {
+		return issues, nil
+	}
+
+	storedParagraphs := paragraphCounts(stored)
+	compiledParagraphs := paragraphCounts(compiled)
+
+	// Sections whose content chapter.md lacks, in whole or in part
+	headings := make(map[string]string)
+	for _, section := range sections {
+		number := section.number.String()
+		headings[section.heading] = number
+
+		paragraphs := paragraphTexts(section.body)
+		present := 0
+		for _, paragraph := range paragraphs {
+			if storedParagraphs[paragraph] > 0 {
+				present++
+			}
+		}
+		switch {
+		case storedParagraphs[section.heading] == 0 && present == 0:
+			issue(number, 0, types.ConsistencyMissingSection,
+				fmt.Sprintf("Section %s is not in chapter.md", number),
+				"Rebuild chapter.md with check_consistency and rebuild set to true")
+		case present < len(paragraphs):
+			issue(number, 0, types.ConsistencyStaleSection,
+				fmt.Sprintf("chapter.md lacks %d of the %d paragraph(s) in section %s's file", len(paragraphs)-present, len(paragraphs), number),
+				"Rebuild chapter.md with check_consistency and rebuild set to true")
+		}
+	}
+
+	// Runs of paragraphs in chapter.md that rebuilding would drop, under the
+	// section heading they follow
+	section := ""
+	run := -1
+	var runText string
+	flush := func() {
+		if run < 0 {
+			return
+		}
+		issue(section, run, types.ConsistencyExtraContent,
+			fmt.Sprintf("chapter.md has content no section holds: %q", excerpt(runText)),
+			"Move it into a section with update_section or append_to_section, or discard it by rebuilding chapter.md")
+		run = -1
+	}
+	for _, bounds := range scanParagraphs(stored) {
+		text := strings.TrimSpace(stored[bounds[0]:bounds[1]])
+		if number, ok := headings[text]; ok {
+			flush()
+			section = number
+		}
+		if compiledParagraphs[text] > 0 {
+			compiledParagraphs[text]--
+			flush()
+			continue
+		}
+		if run < 0 {
+			run = strings.Count(stored[:bounds[0]], "\n") + 1
+			runText = text
+		}
+	}
+	flush()
+
+	return issues, nil
+}
+
+// paragraphTexts returns content's paragraphs, trimmed
+func paragraphTexts(content string) []string {
+	var paragraphs []string
+	for _, bounds := range scanParagraphs(content) {
+		paragraphs = append(paragraphs, strings.TrimSpace(content[bounds[0]:bounds[1]]))
+	}
+	return paragraphs
+}
+
+// paragraphCounts counts how often each paragraph appears in content
+func paragraphCounts(content string) map[string]int {
+	counts := make(map[string]int)
+	for _, paragraph := range paragraphTexts(content) {
+		counts[paragraph]++
+	}
+	return counts
+}
+
+// excerpt shortens text to its first line, cut to consistencyExcerptLength
+func excerpt(text string) string {
+	line, _, _ := strings.Cut(text, "\n")
+	if len(line) > consistencyExcerptLength {
+		return line[:consistencyExcerptLength] + "..."
+	}
+	return line
+}
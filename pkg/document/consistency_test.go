@@ -0,0 +1,81 @@
+package document
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/gomcpgo/docgen/pkg/types"
+)
+
+func TestManager_CheckConsistency(t *testing.T) {
+	manager, tempDir := setupTestManager(t)
+	defer os.RemoveAll(tempDir)
+
+	docID, _ := manager.CreateDocument("Drift", "Test Author", types.DocumentTypeReport)
+	chapterNum, _ := manager.AddChapter(context.Background(), docID, "Results", nil)
+	manager.AddSection(context.Background(), docID, chapterNum, "Intro", "First.\n\nSecond.", 1)
+	manager.AddSection(context.Background(), docID, chapterNum, "Findings", "Found.", 1)
+	manager.AddSection(context.Background(), docID, chapterNum, "Outlook", "Later.", 1)
+
+	issues, err := manager.CheckConsistency(context.Background(), docID, 0)
+	if err != nil || len(issues) != 0 {
+		t.Fatalf("CheckConsistency() = %+v, %v, want no drift", issues, err)
+	}
+
+	// Edit chapter.md directly: change section 1.1, drop 1.3 and add a paragraph
+	content, _ := manager.storage.LoadChapterContent(string(docID), int(chapterNum))
+	content = strings.Replace(content, "Second.", "Second, edited.", 1)
+	content = strings.Replace(content, "## 1.3 Outlook\n\nLater.\n\n", "", 1)
+	content = strings.Replace(content, "Found.", "Found.\n\nWritten straight into chapter.md.", 1)
+	manager.storage.SaveChapterContent(string(docID), int(chapterNum), content)
+
+	// And leave a section file the metadata doesn't list
+	manifest, _ := manager.storage.LoadManifest(string(docID))
+	dir, _ := manifest.ChapterDir(chapterNum)
+	os.WriteFile(filepath.Join(manager.config.SectionsPath(string(docID), dir), "1.9.md"), []byte("Stray."), 0644)
+
+	issues, err = manager.CheckConsistency(context.Background(), docID, chapterNum)
+	if err != nil {
+		t.Fatalf("CheckConsistency() error = %v", err)
+	}
+	got := make(map[types.ConsistencyProblem][]types.ConsistencyIssue)
+	for _, issue := range issues {
+		got[issue.Problem] = append(got[issue.Problem], issue)
+	}
+	if len(issues) != 5 {
+		t.Errorf("Expected 5 issues, got %+v", issues)
+	}
+	if i := got[types.ConsistencyOrphanSectionFile]; len(i) != 1 || i[0].Section != "1.9" {
+		t.Errorf("Expected orphan section file 1.9, got %+v", i)
+	}
+	if i := got[types.ConsistencyStaleSection]; len(i) != 1 || i[0].Section != "1.1" {
+		t.Errorf("Expected section 1.1 stale, got %+v", i)
+	}
+	if i := got[types.ConsistencyMissingSection]; len(i) != 1 || i[0].Section != "1.3" {
+		t.Errorf("Expected section 1.3 missing, got %+v", i)
+	}
+	extra := got[types.ConsistencyExtraContent]
+	if len(extra) != 2 || extra[0].Section != "1.1" || !strings.Contains(extra[0].Detail, "Second, edited.") ||
+		extra[1].Section != "1.2" || extra[1].Line != 13 {
+		t.Errorf("Unexpected extra content issues: %+v", extra)
+	}
+
+	// A section without a file, and chapter.md gone altogether
+	manager.storage.DeleteSectionFile(string(docID), int(chapterNum), types.SectionNumber{1, 2})
+	os.Remove(manager.config.ChapterContentPath(string(docID), dir))
+	issues, _ = manager.CheckConsistency(context.Background(), docID, chapterNum)
+	problems := make(map[types.ConsistencyProblem]bool)
+	for _, issue := range issues {
+		problems[issue.Problem] = true
+	}
+	if !problems[types.ConsistencyMissingSectionFile] || !problems[types.ConsistencyMissingChapterFile] {
+		t.Errorf("Expected a missing section file and chapter.md, got %+v", issues)
+	}
+
+	if _, err := manager.CheckConsistency(context.Background(), docID, 7); err == nil {
+		t.Error("Expected an error for a missing chapter")
+	}
+}
@@ -0,0 +1,261 @@
+package document
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/base64"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/gomcpgo/docgen/pkg/types"
+)
+
+// ContentImage is an image attached to a multi-part content payload
+type ContentImage struct {
+	Name     string `json:"name"`
+	Data     string `json:"data"` // base64 encoded image bytes
+	MimeType string `json:"mime_type,omitempty"`
+	Caption  string `json:"caption,omitempty"`
+}
+
+// ContentImportResult describes what AddContent created
+type ContentImportResult struct {
+	SectionNumber types.SectionNumber `json:"section_number"`
+	Figures       []types.FigureID    `json:"figures"`
+	Warnings      []string            `json:"warnings,omitempty"`
+}
+
+// inlineImagePattern matches markdown images: ![alt](target)
+var inlineImagePattern = regexp.MustCompile(`!\[([^\]]*)\]\(([^)\s]+)\)`)
+
+// mimeExtensions maps supported image MIME types to file extensions
+var mimeExtensions = map[string]string{
+	"image/png":     ".png",
+	"image/jpeg":    ".jpg",
+	"image/jpg":     ".jpg",
+	"image/gif":     ".gif",
+	"image/svg+xml": ".svg",
+	"image/webp":    ".webp",
+}
+
+// pendingImage is an image of a content payload that has been decoded and
+// checked, and is waiting to be stored under its figure ID
+type pendingImage struct {
+	figureID types.FigureID
+	name     string
+	caption  string
+	ext      string
+	data     []byte
+}
+
+// assetPath is where the image is stored, relative to the document directory
+func (p pendingImage) assetPath() string {
+	return filepath.ToSlash(filepath.Join("assets", "images", string(p.figureID)+p.ext))
+}
+
+// AddContent creates a section from a mixed payload of markdown and base64 images.
+// Images are referenced inline either as ![alt](attachment:name) pointing at an
+// entry in images, or directly as data URIs. Each referenced image is written to
+// the document assets, registered as a figure, and its reference is rewritten to
+// point at the stored asset with the figure ID as anchor. An attachment
+// referenced more than once, or attached twice under different names, is stored
+// as one figure that the later references show without the anchor. Every image
+// is decoded and checked before any is stored, and the images stored are
+// removed again if the section cannot be added.
+func (m *Manager) AddContent(ctx context.Context, docID types.DocumentID, chapterNum types.ChapterNumber, title, content string, level int, images []ContentImage) (*ContentImportResult, error) {
+	if err := docID.Validate(); err != nil {
+		return nil, fmt.Errorf("invalid document ID: %w", err)
+	}
+	if title == "" {
+		return nil, fmt.Errorf("section title is required")
+	}
+	if content == "" {
+		return nil, fmt.Errorf("section content is required")
+	}
+	if level < 1 || level > maxSectionLevel {
+		return nil, fmt.Errorf("section level must be between 1 and %d", maxSectionLevel)
+	}
+
+	attachments := make(map[string]ContentImage, len(images))
+	for _, img := range images {
+		if img.Name == "" {
+			return nil, fmt.Errorf("every attached image needs a name")
+		}
+		attachments[img.Name] = img
+	}
+
+	result := &ContentImportResult{}
+	used := make(map[string]bool)
+	var pending []pendingImage
+	// Attachments already decoded, by the hash of their data
+	attached := make(map[[sha256.Size]byte]pendingImage)
+	var convertErr error
+
+	rewritten := inlineImagePattern.ReplaceAllStringFunc(content, func(match string) string {
+		if convertErr != nil {
+			return match
+		}
+		parts := inlineImagePattern.FindStringSubmatch(match)
+		alt, target := parts[1], parts[2]
+
+		var img ContentImage
+		switch {
+		case strings.HasPrefix(target, "attachment:"):
+			name := strings.TrimPrefix(target, "attachment:")
+			attached, ok := attachments[name]
+			if !ok {
+				convertErr = fmt.Errorf("image reference %q has no matching attachment", name)
+				return match
+			}
+			img = attached
+			used[name] = true
+		case strings.HasPrefix(target, "data:"):
+			parsed, err := parseDataURI(target)
+			if err != nil {
+				convertErr = err
+				return match
+			}
+			img = parsed
+		default:
+			// Regular image links are left untouched
+			return match
+		}
+
+		if img.Caption == "" {
+			img.Caption = alt
+		}
+		if img.Caption == "" {
+			img.Caption = img.Name
+		}
+
+		image, err := m.decodeImage(img)
+		if err != nil {
+			convertErr = err
+			return match
+		}
+		if strings.HasPrefix(target, "attachment:") {
+			hash := sha256.Sum256(image.data)
+			if stored, ok := attached[hash]; ok {
+				return fmt.Sprintf("![%s](%s)", img.Caption, stored.assetPath())
+			}
+			attached[hash] = image
+		}
+		pending = append(pending, image)
+		return fmt.Sprintf("![%s](%s){#%s}", image.caption, image.assetPath(), image.figureID)
+	})
+	if convertErr != nil {
+		return result, convertErr
+	}
+
+	if _, err := m.storage.LoadChapterMetadata(string(docID), int(chapterNum)); err != nil {
+		return result, fmt.Errorf("failed to load chapter: %w", err)
+	}
+	size := int64(len(rewritten))
+	for _, image := range pending {
+		size += int64(len(image.data))
+	}
+	if err := m.CheckStorageQuota(ctx, docID, size); err != nil {
+		return result, err
+	}
+
+	for name := range attachments {
+		if !used[name] {
+			result.Warnings = append(result.Warnings, fmt.Sprintf("attachment %q is not referenced in the content and was ignored", name))
+		}
+	}
+
+	for i, image := range pending {
+		if err := m.storeImage(docID, chapterNum, image); err != nil {
+			m.discardImages(docID, pending[:i+1])
+			return result, err
+		}
+	}
+
+	sectionNum, err := m.AddSection(ctx, docID, chapterNum, title, rewritten, level)
+	if err != nil {
+		m.discardImages(docID, pending)
+		return result, err
+	}
+	for _, image := range pending {
+		result.Figures = append(result.Figures, image.figureID)
+	}
+	result.SectionNumber = sectionNum
+
+	return result, nil
+}
+
+// decodeImage decodes and checks an attached image, and gives it the stable
+// figure ID its asset is named after
+func (m *Manager) decodeImage(img ContentImage) (pendingImage, error) {
+	data, err := base64.StdEncoding.DecodeString(img.Data)
+	if err != nil {
+		return pendingImage{}, fmt.Errorf("image %q is not valid base64: %w", img.Name, err)
+	}
+	if len(data) == 0 {
+		return pendingImage{}, fmt.Errorf("image %q is empty", img.Name)
+	}
+	if m.config.MaxFileSize > 0 && int64(len(data)) > m.config.MaxFileSize {
+		return pendingImage{}, fmt.Errorf("image %q exceeds maximum file size of %d bytes", img.Name, m.config.MaxFileSize)
+	}
+
+	ext := imageExtension(img)
+	if ext == "" {
+		return pendingImage{}, fmt.Errorf("image %q has an unsupported type", img.Name)
+	}
+
+	return pendingImage{
+		figureID: types.NewFigureID(),
+		name:     img.Name,
+		caption:  img.Caption,
+		ext:      ext,
+		data:     data,
+	}, nil
+}
+
+// storeImage writes a decoded image to the document assets and registers its figure
+func (m *Manager) storeImage(docID types.DocumentID, chapterNum types.ChapterNumber, image pendingImage) error {
+	if _, err := m.storage.SaveAsset(string(docID), string(image.figureID)+image.ext, image.data); err != nil {
+		return fmt.Errorf("failed to store image %q: %w", image.name, err)
+	}
+	if _, err := m.addImage(docID, chapterNum, image.figureID, image.assetPath(), image.caption, string(types.PositionHere), "", ""); err != nil {
+		return err
+	}
+	return nil
+}
+
+// discardImages removes the figures and assets of images stored by a content
+// import that failed. Images that were never stored are skipped.
+func (m *Manager) discardImages(docID types.DocumentID, images []pendingImage) {
+	for _, image := range images {
+		m.DeleteImage(docID, image.figureID)
+		os.Remove(filepath.Join(m.config.AssetsPath(string(docID)), string(image.figureID)+image.ext))
+	}
+}
+
+// parseDataURI decodes a data:image/...;base64,... URI into a ContentImage
+func parseDataURI(uri string) (ContentImage, error) {
+	header, data, found := strings.Cut(strings.TrimPrefix(uri, "data:"), ",")
+	if !found || !strings.HasSuffix(header, ";base64") {
+		return ContentImage{}, fmt.Errorf("only base64 encoded data URIs are supported")
+	}
+	return ContentImage{
+		Name:     "inline",
+		MimeType: strings.TrimSuffix(header, ";base64"),
+		Data:     data,
+	}, nil
+}
+
+// imageExtension determines the file extension for an image from its MIME type or name
+func imageExtension(img ContentImage) string {
+	if ext, ok := mimeExtensions[strings.ToLower(img.MimeType)]; ok {
+		return ext
+	}
+	switch ext := strings.ToLower(filepath.Ext(img.Name)); ext {
+	case ".png", ".jpg", ".jpeg", ".gif", ".svg", ".webp":
+		return ext
+	}
+	return ""
+}
@@ -0,0 +1,167 @@
+package document
+
+import (
+	"context"
+	"encoding/base64"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/gomcpgo/docgen/pkg/types"
+)
+
+func TestManager_AddContent(t *testing.T) {
+	manager, tempDir := setupTestManager(t)
+	defer os.RemoveAll(tempDir)
+
+	docID, err := manager.CreateDocument("Test Document", "Test Author", types.DocumentTypeBook)
+	if err != nil {
+		t.Fatalf("Failed to create document: %v", err)
+	}
+	chapterNum, err := manager.AddChapter(context.Background(), docID, "Introduction", nil)
+	if err != nil {
+		t.Fatalf("Failed to add chapter: %v", err)
+	}
+
+	pngData := base64.StdEncoding.EncodeToString([]byte("\x89PNG\r\n\x1a\nfake"))
+	content := "Before.\n\n![Login screen](attachment:shot1)\n\nInline ![Dialog](data:image/png;base64," + pngData + ")\n\n![External](https://example.com/a.png)"
+	images := []ContentImage{
+		{Name: "shot1", Data: pngData, MimeType: "image/png"},
+		{Name: "unused", Data: pngData, MimeType: "image/png"},
+	}
+
+	result, err := manager.AddContent(context.Background(), docID, chapterNum, "Screens", content, 1, images)
+	if err != nil {
+		t.Fatalf("AddContent() error = %v", err)
+	}
+
+	if len(result.Figures) != 2 {
+		t.Fatalf("Expected 2 figures, got %d", len(result.Figures))
+	}
+	login, dialog := string(result.Figures[0]), string(result.Figures[1])
+	if types.FigureID(login).IsNumbered() || login == dialog || result.Figures[1].Validate() != nil {
+		t.Errorf("Expected stable figure IDs, got %v", result.Figures)
+	}
+	if len(result.Warnings) != 1 || !strings.Contains(result.Warnings[0], "unused") {
+		t.Errorf("Expected warning about unused attachment, got %v", result.Warnings)
+	}
+
+	// Images must be materialized into the assets directory
+	for _, name := range []string{login + ".png", dialog + ".png"} {
+		if _, err := os.Stat(filepath.Join(manager.config.AssetsPath(string(docID)), name)); err != nil {
+			t.Errorf("Expected asset %s to exist: %v", name, err)
+		}
+	}
+
+	saved, err := manager.GetSectionContent(docID, chapterNum, result.SectionNumber)
+	if err != nil {
+		t.Fatalf("Failed to load section content: %v", err)
+	}
+	if !strings.Contains(saved, "![Login screen](assets/images/"+login+".png){#"+login+"}") {
+		t.Errorf("Attachment reference not rewritten: %s", saved)
+	}
+	if !strings.Contains(saved, "![Dialog](assets/images/"+dialog+".png){#"+dialog+"}") {
+		t.Errorf("Data URI reference not rewritten: %s", saved)
+	}
+	if !strings.Contains(saved, "![External](https://example.com/a.png)") {
+		t.Errorf("External image reference should be untouched: %s", saved)
+	}
+
+	chapter, err := manager.GetChapter(docID, chapterNum)
+	if err != nil {
+		t.Fatalf("Failed to get chapter: %v", err)
+	}
+	if len(chapter.Figures) != 2 || chapter.Figures[0].ID != result.Figures[0] || chapter.Figures[0].Caption != "Login screen" || chapter.Figures[1].Label() != "fig-1.2" {
+		t.Errorf("Figures not registered correctly: %+v", chapter.Figures)
+	}
+}
+
+func TestManager_AddContent_RepeatedAttachment(t *testing.T) {
+	manager, tempDir := setupTestManager(t)
+	defer os.RemoveAll(tempDir)
+
+	docID, _ := manager.CreateDocument("Test Document", "Test Author", types.DocumentTypeBook)
+	chapterNum, _ := manager.AddChapter(context.Background(), docID, "Introduction", nil)
+
+	// The same attachment twice by name, and a copy of it under another name
+	pngData := base64.StdEncoding.EncodeToString([]byte("\x89PNG\r\n\x1a\nfake"))
+	content := "![Logo](attachment:logo)\n\nAgain ![Logo again](attachment:logo)\n\n![Copy](attachment:copy)"
+	images := []ContentImage{
+		{Name: "logo", Data: pngData, MimeType: "image/png"},
+		{Name: "copy", Data: pngData, MimeType: "image/png"},
+	}
+
+	result, err := manager.AddContent(context.Background(), docID, chapterNum, "Brand", content, 1, images)
+	if err != nil {
+		t.Fatalf("AddContent() error = %v", err)
+	}
+	if len(result.Figures) != 1 || len(result.Warnings) != 0 {
+		t.Fatalf("Expected a single figure and no warnings, got %+v", result)
+	}
+	logo := string(result.Figures[0])
+	if entries, _ := os.ReadDir(manager.config.AssetsPath(string(docID))); len(entries) != 1 {
+		t.Errorf("Expected the image stored once, got %d assets", len(entries))
+	}
+
+	saved, _ := manager.GetSectionContent(docID, chapterNum, result.SectionNumber)
+	path := "assets/images/" + logo + ".png"
+	if !strings.Contains(saved, "![Logo]("+path+"){#"+logo+"}") || !strings.Contains(saved, "![Logo again]("+path+")\n") || !strings.HasSuffix(strings.TrimSpace(saved), "![Copy]("+path+")") {
+		t.Errorf("Expected every reference to the one stored image, anchored once: %s", saved)
+	}
+}
+
+func TestManager_AddContent_MissingAttachment(t *testing.T) {
+	manager, tempDir := setupTestManager(t)
+	defer os.RemoveAll(tempDir)
+
+	docID, err := manager.CreateDocument("Test Document", "Test Author", types.DocumentTypeBook)
+	if err != nil {
+		t.Fatalf("Failed to create document: %v", err)
+	}
+	chapterNum, err := manager.AddChapter(context.Background(), docID, "Introduction", nil)
+	if err != nil {
+		t.Fatalf("Failed to add chapter: %v", err)
+	}
+
+	_, err = manager.AddContent(context.Background(), docID, chapterNum, "Screens", "![x](attachment:missing)", 1, nil)
+	if err == nil {
+		t.Fatal("Expected error for reference without attachment")
+	}
+}
+
+func TestManager_AddContent_NothingStoredOnError(t *testing.T) {
+	manager, tempDir := setupTestManager(t)
+	defer os.RemoveAll(tempDir)
+
+	docID, _ := manager.CreateDocument("Test Document", "Test Author", types.DocumentTypeBook)
+	chapterNum, _ := manager.AddChapter(context.Background(), docID, "Introduction", nil)
+
+	pngData := base64.StdEncoding.EncodeToString([]byte("\x89PNG\r\n\x1a\nfake"))
+	good := ContentImage{Name: "good", Data: pngData, MimeType: "image/png"}
+	tests := []struct {
+		name    string
+		content string
+		level   int
+		images  []ContentImage
+	}{
+		{"bad base64 in a later image", "![a](attachment:good)\n\n![b](attachment:bad)", 1, []ContentImage{good, {Name: "bad", Data: "not base64!", MimeType: "image/png"}}},
+		{"missing attachment", "![a](attachment:good)\n\n![b](attachment:missing)", 1, []ContentImage{good}},
+		{"unsupported type", "![a](attachment:good)\n\n![b](attachment:doc)", 1, []ContentImage{good, {Name: "doc", Data: pngData, MimeType: "application/pdf"}}},
+		{"bad level", "![a](attachment:good)", 7, []ContentImage{good}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := manager.AddContent(context.Background(), docID, chapterNum, "Screens", tt.content, tt.level, tt.images); err == nil {
+				t.Fatal("Expected an error")
+			}
+			chapter, _ := manager.GetChapter(docID, chapterNum)
+			if len(chapter.Figures) != 0 || len(chapter.Sections) != 0 {
+				t.Errorf("Expected no figures or sections, got %+v and %+v", chapter.Figures, chapter.Sections)
+			}
+			if entries, _ := os.ReadDir(manager.config.AssetsPath(string(docID))); len(entries) != 0 {
+				t.Errorf("Expected no assets, got %d", len(entries))
+			}
+		})
+	}
+}
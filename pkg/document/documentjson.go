@@ -0,0 +1,111 @@
+package document
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/gomcpgo/docgen/pkg/types"
+)
+
+// DocumentJSON returns the whole document, with the content of every section
+// and included file, in the stable form external tools consume
+func (m *Manager) DocumentJSON(ctx context.Context, docID types.DocumentID) (*types.DocumentJSON, error) {
+	if err := docID.Validate(); err != nil {
+		return nil, fmt.Errorf("invalid document ID: %w", err)
+	}
+
+	if err := m.SyncDocument(ctx, docID); err != nil {
+		return nil, err
+	}
+	manifest, err := m.storage.LoadManifest(string(docID))
+	if err != nil {
+		return nil, fmt.Errorf("failed to load manifest: %w", err)
+	}
+
+	doc := manifest.Document
+	result := &types.DocumentJSON{
+		SchemaVersion: types.DocumentJSONSchemaVersion,
+		ID:            doc.ID,
+		Title:         doc.Title,
+		Subtitle:      doc.Subtitle,
+		Authors:       doc.Authors,
+		Type:          doc.Type,
+		Language:      doc.Language,
+		Locale:        doc.Locale,
+		Date:          doc.Date,
+		Abstract:      doc.Abstract,
+		Keywords:      doc.Keywords,
+		Tags:          doc.Tags,
+		Parts:         doc.Parts,
+		Abbreviations: doc.Abbreviations,
+		CreatedAt:     doc.CreatedAt,
+		UpdatedAt:     doc.UpdatedAt,
+		Chapters:      []types.ChapterJSON{},
+	}
+	if result.Authors == nil {
+		result.Authors = types.AuthorList{}
+	}
+
+	for _, chapterRef := range doc.Chapters {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		chapter, err := m.storage.LoadChapterMetadata(string(docID), int(chapterRef.Number))
+		if err != nil {
+			return nil, fmt.Errorf("failed to load chapter %d metadata: %w", chapterRef.Number, err)
+		}
+
+		chapterJSON := types.ChapterJSON{
+			Number:      chapter.Number,
+			Title:       chapter.Title,
+			Epigraph:    chapter.Epigraph,
+			Status:      chapter.Status.OrDraft(),
+			FrontMatter: chapter.FrontMatter,
+			Sections:    []types.SectionJSON{},
+			Figures:     chapter.Figures,
+			Tables:      chapter.Tables,
+			Listings:    chapter.Listings,
+			CreatedAt:   chapter.CreatedAt,
+			UpdatedAt:   chapter.UpdatedAt,
+		}
+		if chapterJSON.Figures == nil {
+			chapterJSON.Figures = []types.Figure{}
+		}
+		if chapterJSON.Tables == nil {
+			chapterJSON.Tables = []types.Table{}
+		}
+		if chapterJSON.Listings == nil {
+			chapterJSON.Listings = []types.Listing{}
+		}
+		if chapter.IncludeFile != "" {
+			if chapterJSON.Intro, err = m.readIncludeFile(docID, chapter.IncludeFile); err != nil {
+				return nil, fmt.Errorf("chapter %d: %w", chapter.Number, err)
+			}
+		}
+
+		statuses := chapter.SectionStatuses()
+		for i, section := range chapter.Sections {
+			var content string
+			if section.IncludeFile != "" {
+				content, err = m.readIncludeFile(docID, section.IncludeFile)
+			} else {
+				content, err = m.storage.LoadSectionContent(string(docID), int(chapter.Number), section.Number)
+			}
+			if err != nil {
+				return nil, fmt.Errorf("failed to load section %s: %w", section.Number.String(), err)
+			}
+			chapterJSON.Sections = append(chapterJSON.Sections, types.SectionJSON{
+				Number:      section.Number.String(),
+				Title:       section.Title,
+				Level:       section.Level,
+				Content:     content,
+				Status:      statuses[i].OrDraft(),
+				FrontMatter: section.FrontMatter,
+				UpdatedAt:   section.UpdatedAt,
+			})
+		}
+		result.Chapters = append(result.Chapters, chapterJSON)
+	}
+
+	return result, nil
+}
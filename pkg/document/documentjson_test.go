@@ -0,0 +1,45 @@
+package document
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/gomcpgo/docgen/pkg/types"
+)
+
+func TestManager_DocumentJSON(t *testing.T) {
+	manager, tempDir := setupTestManager(t)
+	defer os.RemoveAll(tempDir)
+
+	docID, _ := manager.CreateDocument("Handbook", "Test Author", types.DocumentTypeReport)
+	chapterNum, _ := manager.AddChapter(context.Background(), docID, "Basics", nil)
+	manager.AddSection(context.Background(), docID, chapterNum, "Start", "Hello.", 1)
+	manager.AddSection(context.Background(), docID, chapterNum, "Detail", "More.", 2)
+	manager.AddChapter(context.Background(), docID, "Empty", nil)
+
+	included := filepath.Join(tempDir, "shared.md")
+	os.WriteFile(included, []byte("Shared text."), 0644)
+	if _, err := manager.SetIncludeFile(docID, chapterNum, nil, included); err != nil {
+		t.Fatalf("SetIncludeFile() error = %v", err)
+	}
+
+	doc, err := manager.DocumentJSON(context.Background(), docID)
+	if err != nil {
+		t.Fatalf("DocumentJSON() error = %v", err)
+	}
+	if doc.SchemaVersion != types.DocumentJSONSchemaVersion || doc.Title != "Handbook" || len(doc.Authors) != 1 || len(doc.Chapters) != 2 {
+		t.Fatalf("Unexpected document %+v", doc)
+	}
+	chapter := doc.Chapters[0]
+	if chapter.Intro != "Shared text." || len(chapter.Sections) != 2 {
+		t.Fatalf("Unexpected chapter %+v", chapter)
+	}
+	if section := chapter.Sections[1]; section.Number != "1.1.1" || section.Level != 2 || section.Content != "More." {
+		t.Errorf("Unexpected section %+v", section)
+	}
+	if empty := doc.Chapters[1]; empty.Sections == nil || empty.Figures == nil || empty.Tables == nil || empty.Listings == nil {
+		t.Errorf("Expected empty lists rather than null in %+v", empty)
+	}
+}
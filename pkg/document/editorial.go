@@ -0,0 +1,94 @@
+package document
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/gomcpgo/docgen/pkg/editorial"
+	"github.com/gomcpgo/docgen/pkg/types"
+)
+
+// SetEditorialRules sets the house-style rules applied when chapters are rebuilt.
+// Passing nil switches normalization off.
+func (m *Manager) SetEditorialRules(docID types.DocumentID, rules *types.EditorialRules) error {
+	if err := docID.Validate(); err != nil {
+		return fmt.Errorf("invalid document ID: %w", err)
+	}
+	if rules != nil {
+		if err := rules.Validate(); err != nil {
+			return err
+		}
+	}
+
+	manifest, err := m.storage.LoadManifest(string(docID))
+	if err != nil {
+		return fmt.Errorf("failed to load manifest: %w", err)
+	}
+
+	now := time.Now()
+	manifest.Document.EditorialRules = rules
+	manifest.Document.UpdatedAt = now
+	manifest.UpdatedAt = now
+
+	if err := m.storage.SaveManifest(string(docID), manifest); err != nil {
+		return fmt.Errorf("failed to update manifest: %w", err)
+	}
+
+	return nil
+}
+
+// EditorialReport lists the changes the document's editorial rules make to each
+// section when chapters are rebuilt. Section files themselves are never modified.
+func (m *Manager) EditorialReport(docID types.DocumentID) ([]types.EditorialChange, error) {
+	if err := docID.Validate(); err != nil {
+		return nil, fmt.Errorf("invalid document ID: %w", err)
+	}
+
+	manifest, err := m.storage.LoadManifest(string(docID))
+	if err != nil {
+		return nil, fmt.Errorf("failed to load manifest: %w", err)
+	}
+
+	changes := []types.EditorialChange{}
+	if !manifest.Document.EditorialRules.Enabled() {
+		return changes, nil
+	}
+
+	for _, chapterRef := range manifest.Document.Chapters {
+		chapter, err := m.storage.LoadChapterMetadata(string(docID), int(chapterRef.Number))
+		if err != nil {
+			return nil, fmt.Errorf("failed to load chapter %d metadata: %w", chapterRef.Number, err)
+		}
+
+		for _, section := range chapter.Sections {
+			content, err := m.storage.LoadSectionContent(string(docID), int(chapter.Number), section.Number)
+			if err != nil {
+				continue
+			}
+			_, sectionChanges := normalizeSectionContent(content, manifest.Document.EditorialRules, chapter.Number, section.Number)
+			changes = append(changes, sectionChanges...)
+		}
+	}
+
+	return changes, nil
+}
+
+// normalizeSectionContent applies editorial rules to section content and reports the
+// changes against the section they were made in
+func normalizeSectionContent(content string, rules *types.EditorialRules, chapterNum types.ChapterNumber, sectionNum types.SectionNumber) (string, []types.EditorialChange) {
+	normalized, edits := editorial.Normalize(content, rules)
+
+	changes := make([]types.EditorialChange, 0, len(edits))
+	for _, edit := range edits {
+		changes = append(changes, types.EditorialChange{
+			Chapter:     chapterNum,
+			Section:     sectionNum.String(),
+			Line:        edit.Line,
+			Rule:        edit.Rule,
+			Original:    edit.Original,
+			Replacement: edit.Replacement,
+		})
+	}
+
+	return normalized, changes
+}
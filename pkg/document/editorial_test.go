@@ -0,0 +1,77 @@
+package document
+
+import (
+	"context"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/gomcpgo/docgen/pkg/types"
+)
+
+func TestManager_EditorialRules(t *testing.T) {
+	manager, tempDir := setupTestManager(t)
+	defer os.RemoveAll(tempDir)
+
+	docID, err := manager.CreateDocument("Test Document", "Test Author", types.DocumentTypeReport)
+	if err != nil {
+		t.Fatalf("Failed to create document: %v", err)
+	}
+	chapterNum, err := manager.AddChapter(context.Background(), docID, "Results", nil)
+	if err != nil {
+		t.Fatalf("Failed to add chapter: %v", err)
+	}
+	sectionNum, err := manager.AddSection(context.Background(), docID, chapterNum, "Findings", "We ran 3 trials and saw 40 percent growth.", 1)
+	if err != nil {
+		t.Fatalf("Failed to add section: %v", err)
+	}
+
+	// Without rules nothing is reported
+	changes, err := manager.EditorialReport(docID)
+	if err != nil {
+		t.Fatalf("EditorialReport() error = %v", err)
+	}
+	if len(changes) != 0 {
+		t.Errorf("Expected no changes without rules, got %+v", changes)
+	}
+
+	if err := manager.SetEditorialRules(docID, &types.EditorialRules{PercentStyle: "bogus"}); err == nil {
+		t.Errorf("Expected an error for an invalid percent style")
+	}
+
+	rules := &types.EditorialRules{SpellOutNumbers: true, PercentStyle: types.PercentStyleSymbol}
+	if err := manager.SetEditorialRules(docID, rules); err != nil {
+		t.Fatalf("SetEditorialRules() error = %v", err)
+	}
+
+	changes, err = manager.EditorialReport(docID)
+	if err != nil {
+		t.Fatalf("EditorialReport() error = %v", err)
+	}
+	if len(changes) != 2 {
+		t.Fatalf("Expected 2 changes, got %+v", changes)
+	}
+	if changes[0].Chapter != chapterNum || changes[0].Section != sectionNum.String() {
+		t.Errorf("Change should reference chapter %d section %s, got %+v", chapterNum, sectionNum.String(), changes[0])
+	}
+
+	// Rebuilding normalizes the compiled chapter but not the section file
+	if err := manager.RebuildChapterMarkdown(docID, chapterNum); err != nil {
+		t.Fatalf("RebuildChapterMarkdown() error = %v", err)
+	}
+	compiled, err := manager.storage.LoadChapterContent(string(docID), int(chapterNum))
+	if err != nil {
+		t.Fatalf("Failed to load chapter content: %v", err)
+	}
+	if !strings.Contains(compiled, "We ran three trials and saw 40% growth.") {
+		t.Errorf("Compiled chapter should be normalized, got:\n%s", compiled)
+	}
+
+	original, err := manager.GetSectionContent(docID, chapterNum, sectionNum)
+	if err != nil {
+		t.Fatalf("Failed to get section content: %v", err)
+	}
+	if !strings.Contains(original, "3 trials") {
+		t.Errorf("Section file should keep the author's text, got %q", original)
+	}
+}
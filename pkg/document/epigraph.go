@@ -0,0 +1,42 @@
+package document
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/gomcpgo/docgen/pkg/types"
+)
+
+// SetEpigraph sets the quotation that opens a chapter, or removes it when
+// epigraph is nil or has no text
+func (m *Manager) SetEpigraph(docID types.DocumentID, chapterNum types.ChapterNumber, epigraph *types.Epigraph) error {
+	if err := docID.Validate(); err != nil {
+		return fmt.Errorf("invalid document ID: %w", err)
+	}
+
+	chapter, err := m.storage.LoadChapterMetadata(string(docID), int(chapterNum))
+	if err != nil {
+		return fmt.Errorf("failed to load chapter: %w", err)
+	}
+
+	if epigraph != nil {
+		epigraph = &types.Epigraph{
+			Text:        strings.TrimSpace(epigraph.Text),
+			Attribution: strings.TrimSpace(epigraph.Attribution),
+		}
+		if epigraph.Text == "" {
+			if epigraph.Attribution != "" {
+				return fmt.Errorf("an epigraph needs its text as well as its attribution")
+			}
+			epigraph = nil
+		}
+	}
+	chapter.Epigraph = epigraph
+	chapter.UpdatedAt = time.Now()
+
+	if err := m.storage.SaveChapterMetadata(string(docID), chapter); err != nil {
+		return fmt.Errorf("failed to save chapter metadata: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,38 @@
+package document
+
+import (
+	"context"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/gomcpgo/docgen/pkg/types"
+)
+
+func TestManager_SetEpigraph(t *testing.T) {
+	manager, tempDir := setupTestManager(t)
+	defer os.RemoveAll(tempDir)
+
+	docID, _ := manager.CreateDocument("Novel", "Test Author", types.DocumentTypeBook)
+	chapterNum, _ := manager.AddChapter(context.Background(), docID, "Beginnings", nil)
+
+	if err := manager.SetEpigraph(docID, chapterNum, &types.Epigraph{Text: " All happy families are alike. \n", Attribution: "Leo Tolstoy"}); err != nil {
+		t.Fatalf("SetEpigraph() error = %v", err)
+	}
+	manifest, _ := manager.GetDocumentStructure(docID)
+	epigraph := manifest.Document.Chapters[0].Epigraph
+	if epigraph == nil || epigraph.Text != "All happy families are alike." || epigraph.Attribution != "Leo Tolstoy" {
+		t.Errorf("Unexpected epigraph %+v", epigraph)
+	}
+
+	if err := manager.SetEpigraph(docID, chapterNum, &types.Epigraph{Attribution: "Nobody"}); err == nil || !strings.Contains(err.Error(), "needs its text") {
+		t.Errorf("SetEpigraph() error = %v, want the text to be required", err)
+	}
+	if err := manager.SetEpigraph(docID, chapterNum, &types.Epigraph{}); err != nil {
+		t.Fatalf("SetEpigraph() removing error = %v", err)
+	}
+	chapter, _ := manager.storage.LoadChapterMetadata(string(docID), int(chapterNum))
+	if chapter.Epigraph != nil {
+		t.Errorf("Expected the epigraph removed, got %+v", chapter.Epigraph)
+	}
+}
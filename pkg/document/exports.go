@@ -0,0 +1,167 @@
+package document
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gomcpgo/docgen/pkg/types"
+)
+
+// exportHistoryMu serializes changes to export histories, since the watcher
+// exports in the background while clients export and prune
+var exportHistoryMu sync.Mutex
+
+// RecordExport adds an export to the document's export history, numbering it
+// after the last one recorded
+func (m *Manager) RecordExport(docID types.DocumentID, entry types.ExportEntry) (*types.ExportEntry, error) {
+	if err := docID.Validate(); err != nil {
+		return nil, fmt.Errorf("invalid document ID: %w", err)
+	}
+
+	exportHistoryMu.Lock()
+	defer exportHistoryMu.Unlock()
+
+	history, err := m.storage.LoadExportHistory(string(docID))
+	if err != nil {
+		return nil, fmt.Errorf("failed to load export history: %w", err)
+	}
+	history.LastID++
+	entry.ID = history.LastID
+	history.Exports = append(history.Exports, entry)
+	if err := m.storage.SaveExportHistory(string(docID), history); err != nil {
+		return nil, fmt.Errorf("failed to save export history: %w", err)
+	}
+	return &entry, nil
+}
+
+// ListExports returns the document's export history, newest first, with each
+// successful export marked available while its output file still holds it
+func (m *Manager) ListExports(docID types.DocumentID) ([]types.ExportEntry, error) {
+	if err := docID.Validate(); err != nil {
+		return nil, fmt.Errorf("invalid document ID: %w", err)
+	}
+
+	history, err := m.storage.LoadExportHistory(string(docID))
+	if err != nil {
+		return nil, fmt.Errorf("failed to load export history: %w", err)
+	}
+	return m.markAvailableExports(history.Exports), nil
+}
+
+// DeleteExports removes exports from the document's export history: those with
+// the given IDs and, unless before is zero, those made before it. Output files
+// still holding a removed export are deleted with their compressed copies. It
+// returns the removed exports, marked available when their files were deleted,
+// and the bytes freed.
+func (m *Manager) DeleteExports(docID types.DocumentID, ids []int, before time.Time) ([]types.ExportEntry, int64, error) {
+	if err := docID.Validate(); err != nil {
+		return nil, 0, fmt.Errorf("invalid document ID: %w", err)
+	}
+	if len(ids) == 0 && before.IsZero() {
+		return nil, 0, fmt.Errorf("export IDs or a date to delete exports before are required")
+	}
+
+	exportHistoryMu.Lock()
+	defer exportHistoryMu.Unlock()
+
+	history, err := m.storage.LoadExportHistory(string(docID))
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to load export history: %w", err)
+	}
+
+	selected := make(map[int]bool)
+	for _, id := range ids {
+		selected[id] = true
+	}
+	found := make(map[int]bool)
+	var kept, removed []types.ExportEntry
+	for _, entry := range m.markAvailableExports(history.Exports) {
+		if selected[entry.ID] || (!before.IsZero() && entry.Time.Before(before)) {
+			found[entry.ID] = true
+			removed = append(removed, entry)
+		} else {
+			kept = append(kept, entry)
+		}
+	}
+	for _, id := range ids {
+		if !found[id] {
+			return nil, 0, fmt.Errorf("export %d not found", id)
+		}
+	}
+
+	var freed int64
+	for _, entry := range removed {
+		if !entry.Available {
+			continue
+		}
+		for _, path := range []string{entry.OutputPath, entry.CompressedPath} {
+			bytes, err := m.deleteExportFile(path)
+			if err != nil {
+				return nil, 0, err
+			}
+			freed += bytes
+		}
+	}
+
+	// The history is kept oldest first
+	sort.Slice(kept, func(i, j int) bool { return kept[i].ID < kept[j].ID })
+	for i := range kept {
+		kept[i].Available = false
+	}
+	history.Exports = kept
+	if err := m.storage.SaveExportHistory(string(docID), history); err != nil {
+		return nil, 0, fmt.Errorf("failed to save export history: %w", err)
+	}
+	return removed, freed, nil
+}
+
+// markAvailableExports returns exports newest first, marking those whose
+// output file exists and was written by no later export
+func (m *Manager) markAvailableExports(exports []types.ExportEntry) []types.ExportEntry {
+	result := make([]types.ExportEntry, len(exports))
+	copy(result, exports)
+	sort.Slice(result, func(i, j int) bool { return result[i].ID > result[j].ID })
+
+	overwritten := make(map[string]bool)
+	for i := range result {
+		entry := &result[i]
+		if !entry.Success || entry.OutputPath == "" {
+			continue
+		}
+		if !overwritten[entry.OutputPath] {
+			if _, err := os.Stat(entry.OutputPath); err == nil {
+				entry.Available = true
+			}
+		}
+		overwritten[entry.OutputPath] = true
+	}
+	return result
+}
+
+// deleteExportFile deletes a file in the exports directory, returning its
+// size. Paths outside the exports directory are left alone.
+func (m *Manager) deleteExportFile(path string) (int64, error) {
+	if path == "" {
+		return 0, nil
+	}
+	rel, err := filepath.Rel(m.config.ExportsDir, path)
+	if err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		return 0, nil
+	}
+	info, err := os.Stat(path)
+	if os.IsNotExist(err) {
+		return 0, nil
+	}
+	if err != nil {
+		return 0, fmt.Errorf("failed to delete %s: %w", path, err)
+	}
+	if err := os.Remove(path); err != nil {
+		return 0, fmt.Errorf("failed to delete %s: %w", path, err)
+	}
+	return info.Size(), nil
+}
@@ -0,0 +1,95 @@
+package document
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/gomcpgo/docgen/pkg/types"
+)
+
+func TestManager_ExportHistory(t *testing.T) {
+	manager, tempDir := setupTestManager(t)
+	defer os.RemoveAll(tempDir)
+	manager.config.ExportsDir = filepath.Join(tempDir, "exports")
+	os.MkdirAll(manager.config.ExportsDir, 0755)
+
+	docID, _ := manager.CreateDocument("Report", "Test Author", types.DocumentTypeReport)
+	pdfPath := filepath.Join(manager.config.ExportsDir, "report.pdf")
+	htmlPath := filepath.Join(manager.config.ExportsDir, "report.html")
+	outsidePath := filepath.Join(tempDir, "report.docx")
+	os.WriteFile(pdfPath, []byte("pdf"), 0644)
+	os.WriteFile(htmlPath, []byte("<html>"), 0644)
+	os.WriteFile(outsidePath, []byte("docx"), 0644)
+
+	old := time.Now().Add(-10 * 24 * time.Hour)
+	for _, entry := range []types.ExportEntry{
+		{Time: old, Format: types.ExportFormatPDF, OutputPath: pdfPath, Success: true},
+		{Time: old, Format: types.ExportFormatDOCX, OutputPath: outsidePath, Success: true},
+		{Time: time.Now(), Format: types.ExportFormatPDF, Success: false, Error: "pandoc failed"},
+		{Time: time.Now(), Format: types.ExportFormatPDF, OutputPath: pdfPath, Success: true},
+		{Time: time.Now(), Format: types.ExportFormatHTML, OutputPath: htmlPath, Success: true},
+	} {
+		if _, err := manager.RecordExport(docID, entry); err != nil {
+			t.Fatalf("RecordExport() error = %v", err)
+		}
+	}
+
+	exports, err := manager.ListExports(docID)
+	if err != nil {
+		t.Fatalf("ListExports() error = %v", err)
+	}
+	available := make(map[int]bool)
+	for _, entry := range exports {
+		available[entry.ID] = entry.Available
+	}
+	if len(exports) != 5 || exports[0].ID != 5 {
+		t.Fatalf("Expected 5 exports newest first, got %+v", exports)
+	}
+	// The first PDF was overwritten by the fourth export and the third failed
+	if available[1] || !available[2] || available[3] || !available[4] || !available[5] {
+		t.Errorf("Unexpected availability: %v", available)
+	}
+
+	// Deleting old exports removes only files in the exports directory that
+	// still hold them
+	deleted, freed, err := manager.DeleteExports(docID, nil, time.Now().Add(-24*time.Hour))
+	if err != nil {
+		t.Fatalf("DeleteExports() error = %v", err)
+	}
+	if len(deleted) != 2 || freed != 0 {
+		t.Errorf("DeleteExports() = %+v, %d", deleted, freed)
+	}
+	if _, err := os.Stat(pdfPath); err != nil {
+		t.Errorf("Expected the later PDF export to be kept: %v", err)
+	}
+	if _, err := os.Stat(outsidePath); err != nil {
+		t.Errorf("Expected files outside the exports directory to be kept: %v", err)
+	}
+
+	deleted, freed, err = manager.DeleteExports(docID, []int{5}, time.Time{})
+	if err != nil {
+		t.Fatalf("DeleteExports() error = %v", err)
+	}
+	if len(deleted) != 1 || freed != int64(len("<html>")) {
+		t.Errorf("DeleteExports() = %+v, %d", deleted, freed)
+	}
+	if _, err := os.Stat(htmlPath); !os.IsNotExist(err) {
+		t.Errorf("Expected the HTML export to be deleted, got %v", err)
+	}
+
+	if _, _, err := manager.DeleteExports(docID, []int{5}, time.Time{}); err == nil {
+		t.Error("Expected an error deleting an export twice")
+	}
+
+	// Numbering continues after deleted exports
+	entry, _ := manager.RecordExport(docID, types.ExportEntry{Time: time.Now(), Format: types.ExportFormatEPUB, Success: true})
+	if entry.ID != 6 {
+		t.Errorf("Expected the next export to be numbered 6, got %d", entry.ID)
+	}
+	exports, _ = manager.ListExports(docID)
+	if len(exports) != 3 {
+		t.Errorf("Expected 3 exports left, got %+v", exports)
+	}
+}
@@ -0,0 +1,63 @@
+package document
+
+import (
+	"fmt"
+
+	"github.com/gomcpgo/docgen/pkg/types"
+)
+
+// Limits on the layout of a composite figure
+const (
+	minSubFigures  = 2
+	maxSubFigures  = 12
+	maxGridColumns = 4
+	// DefaultGridColumns lays sub-figures out two by two
+	DefaultGridColumns = 2
+)
+
+// AddFigureGrid adds a composite figure to a chapter: images laid out in a
+// grid, each with a sub-caption, under one numbered caption. It returns the
+// figure ID and the markup that places the figure in a section.
+func (m *Manager) AddFigureGrid(docID types.DocumentID, chapterNum types.ChapterNumber, caption string, subFigures []types.SubFigure, columns int, position string) (types.FigureID, string, error) {
+	if err := docID.Validate(); err != nil {
+		return "", "", fmt.Errorf("invalid document ID: %w", err)
+	}
+	if caption == "" {
+		return "", "", fmt.Errorf("caption is required")
+	}
+	if len(subFigures) < minSubFigures || len(subFigures) > maxSubFigures {
+		return "", "", fmt.Errorf("a figure grid needs between %d and %d images, got %d", minSubFigures, maxSubFigures, len(subFigures))
+	}
+	if columns == 0 {
+		columns = DefaultGridColumns
+	}
+	if columns < 1 || columns > maxGridColumns {
+		return "", "", fmt.Errorf("columns must be between 1 and %d", maxGridColumns)
+	}
+	if err := validateImagePosition(position); err != nil {
+		return "", "", err
+	}
+
+	// Only files inside the allowed directories may be referenced
+	for i, sub := range subFigures {
+		if sub.ImagePath == "" {
+			return "", "", fmt.Errorf("image %d has no image path", i+1)
+		}
+		if _, err := m.config.ResolvePath(sub.ImagePath, m.config.DocumentPath(string(docID))); err != nil {
+			return "", "", fmt.Errorf("invalid path for image %d: %w", i+1, err)
+		}
+	}
+
+	figureID, err := m.registerFigure(docID, chapterNum, types.Figure{
+		Caption:    caption,
+		Position:   types.ImagePosition(position),
+		Alignment:  types.AlignCenter,
+		SubFigures: subFigures,
+		Columns:    columns,
+	})
+	if err != nil {
+		return "", "", err
+	}
+
+	return figureID, types.Figure{ID: figureID, SubFigures: subFigures}.Markup(), nil
+}
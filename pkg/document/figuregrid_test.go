@@ -0,0 +1,61 @@
+package document
+
+import (
+	"context"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/gomcpgo/docgen/pkg/types"
+)
+
+func TestManager_AddFigureGrid(t *testing.T) {
+	manager, tempDir := setupTestManager(t)
+	defer os.RemoveAll(tempDir)
+
+	docID, _ := manager.CreateDocument("Report", "Test Author", types.DocumentTypeReport)
+	chapterNum, _ := manager.AddChapter(context.Background(), docID, "Results", nil)
+	manager.AddImage(docID, chapterNum, "assets/images/plot.png", "A plot", "here", "", "")
+	subFigures := []types.SubFigure{
+		{ImagePath: "assets/images/a.png", Caption: "Wild type"},
+		{ImagePath: "assets/images/b.png", Caption: "Mutant"},
+	}
+
+	figureID, markup, err := manager.AddFigureGrid(docID, chapterNum, "Growth of both strains", subFigures, 0, "here")
+	if err != nil {
+		t.Fatalf("AddFigureGrid() error = %v", err)
+	}
+	if figureID.IsNumbered() || markup != "::: {#"+string(figureID)+" .figure-grid}\n:::" {
+		t.Errorf("AddFigureGrid() = %s, %q", figureID, markup)
+	}
+	chapter, _ := manager.storage.LoadChapterMetadata(string(docID), int(chapterNum))
+	figure := chapter.Figures[1]
+	if figure.Columns != DefaultGridColumns || len(figure.SubFigures) != 2 || figure.ImagePath != "" || figure.Label() != "fig-1.2" {
+		t.Errorf("Unexpected figure: %+v", figure)
+	}
+
+	// The grid counts as placed once its markup is in the content
+	if _, err := manager.AddSection(context.Background(), docID, chapterNum, "Growth", "Both strains grew.\n\n"+markup, 1); err != nil {
+		t.Fatalf("AddSection() error = %v", err)
+	}
+	issues, _ := manager.LintFiguresAndTables(docID, chapterNum)
+	for _, issue := range issues {
+		if issue.ID == string(figureID) {
+			t.Errorf("Placed grid reported as %s", issue.Problem)
+		}
+	}
+
+	for _, tt := range []struct {
+		subFigures []types.SubFigure
+		columns    int
+		wantErr    string
+	}{
+		{subFigures[:1], 2, "between 2 and 12 images"},
+		{subFigures, 5, "columns must be between 1 and 4"},
+		{[]types.SubFigure{{ImagePath: "a.png"}, {}}, 2, "image 2 has no image path"},
+	} {
+		if _, _, err := manager.AddFigureGrid(docID, chapterNum, "Caption", tt.subFigures, tt.columns, "here"); err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+			t.Errorf("AddFigureGrid() error = %v, want %q", err, tt.wantErr)
+		}
+	}
+}
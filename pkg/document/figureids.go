@@ -0,0 +1,58 @@
+package document
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/gomcpgo/docgen/pkg/types"
+)
+
+// MigrateFigureIDs gives every figure still identified by its number (fig-1.2)
+// a stable ID, renaming the images imported for it and rewriting its anchors
+// and cross-references in every section. Figures keep their display numbers.
+// It returns the old and new ID of every migrated figure.
+func (m *Manager) MigrateFigureIDs(ctx context.Context, docID types.DocumentID) (map[string]string, error) {
+	if err := docID.Validate(); err != nil {
+		return nil, fmt.Errorf("invalid document ID: %w", err)
+	}
+	if err := m.SyncDocument(ctx, docID); err != nil {
+		return nil, err
+	}
+
+	manifest, err := m.storage.LoadManifest(string(docID))
+	if err != nil {
+		return nil, fmt.Errorf("failed to load manifest: %w", err)
+	}
+
+	r := newRestructure(docID, 0)
+	for _, chapterRef := range manifest.Document.Chapters {
+		chapter, err := m.storage.LoadChapterMetadata(string(docID), int(chapterRef.Number))
+		if err != nil {
+			return nil, fmt.Errorf("failed to load chapter %d: %w", chapterRef.Number, err)
+		}
+		migrated := false
+		for i := range chapter.Figures {
+			if chapter.Figures[i].ID.IsNumbered() {
+				r.replaceFigureID(m, &chapter.Figures[i], types.NewFigureID())
+				chapter.Figures[i].UpdatedAt = time.Now()
+				migrated = true
+			}
+		}
+		if !migrated {
+			continue
+		}
+		chapter.UpdatedAt = time.Now()
+		if err := m.storage.SaveChapterMetadata(string(docID), chapter); err != nil {
+			return nil, fmt.Errorf("failed to save chapter %d metadata: %w", chapter.Number, err)
+		}
+	}
+
+	if len(r.result.IDs) == 0 {
+		return r.result.IDs, nil
+	}
+	if err := m.finishRestructure(r, manifest); err != nil {
+		return nil, err
+	}
+	return r.result.IDs, nil
+}
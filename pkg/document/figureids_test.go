@@ -0,0 +1,89 @@
+package document
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/gomcpgo/docgen/pkg/types"
+)
+
+func TestManager_StableFigureIDs(t *testing.T) {
+	manager, tempDir := setupTestManager(t)
+	defer os.RemoveAll(tempDir)
+
+	docID, _ := manager.CreateDocument("Stable", "Test Author", types.DocumentTypeReport)
+	chapterNum, _ := manager.AddChapter(context.Background(), docID, "Results", nil)
+	manager.AddSection(context.Background(), docID, chapterNum, "Findings", "Text.", 1)
+	first, _ := manager.AddImage(docID, chapterNum, "assets/images/first.png", "First", "here", "", "")
+	second, _ := manager.AddImage(docID, chapterNum, "assets/images/second.png", "Second", "here", "", "")
+	if first.IsNumbered() || second.IsNumbered() || first == second {
+		t.Fatalf("Expected distinct stable IDs, got %s and %s", first, second)
+	}
+
+	// A figure is found by its display number as well as its ID
+	if err := manager.UpdateImageCaption(docID, "fig-1.2", "Second, captioned"); err != nil {
+		t.Fatalf("UpdateImageCaption() error = %v", err)
+	}
+	manager.UpdateSection(context.Background(), docID, chapterNum, types.SectionNumber{1, 1}, "See @"+string(second)+".")
+
+	// Deleting the first figure renumbers the second without renaming it
+	if err := manager.DeleteImage(docID, first); err != nil {
+		t.Fatalf("DeleteImage() error = %v", err)
+	}
+	chapter, _ := manager.storage.LoadChapterMetadata(string(docID), int(chapterNum))
+	if len(chapter.Figures) != 1 || chapter.Figures[0].ID != second || chapter.Figures[0].Label() != "fig-1.1" || chapter.Figures[0].Caption != "Second, captioned" {
+		t.Fatalf("Unexpected figures %+v", chapter.Figures)
+	}
+	if content, _ := manager.GetSectionContent(docID, chapterNum, types.SectionNumber{1, 1}); content != "See @"+string(second)+"." {
+		t.Errorf("Expected the reference left alone, got %q", content)
+	}
+
+	if err := manager.DeleteImage(docID, "fig-1.2"); err == nil {
+		t.Error("Expected an error for a display number no figure has")
+	}
+}
+
+func TestManager_MigrateFigureIDs(t *testing.T) {
+	manager, tempDir := setupTestManager(t)
+	defer os.RemoveAll(tempDir)
+
+	docID, _ := manager.CreateDocument("Migrate", "Test Author", types.DocumentTypeReport)
+	chapterNum, _ := manager.AddChapter(context.Background(), docID, "Results", nil)
+	manager.AddSection(context.Background(), docID, chapterNum, "Findings", "Text.", 1)
+	manager.storage.SaveAsset(string(docID), "fig-1.1.png", []byte("PNG"))
+	old := addNumberedImage(t, manager, docID, chapterNum, "assets/images/fig-1.1.png", "Chart")
+	stable, _ := manager.AddImage(docID, chapterNum, "assets/images/map.png", "Map", "here", "", "")
+	manager.UpdateSection(context.Background(), docID, chapterNum, types.SectionNumber{1, 1}, "![Chart](assets/images/fig-1.1.png){#fig-1.1}\n\nSee @fig-1.1 and @"+string(stable)+".")
+
+	ids, err := manager.MigrateFigureIDs(context.Background(), docID)
+	if err != nil {
+		t.Fatalf("MigrateFigureIDs() error = %v", err)
+	}
+	migrated := types.FigureID(ids[string(old)])
+	if len(ids) != 1 || migrated.IsNumbered() || migrated.Validate() != nil {
+		t.Fatalf("Expected fig-1.1 given a stable ID, got %v", ids)
+	}
+
+	chapter, _ := manager.storage.LoadChapterMetadata(string(docID), int(chapterNum))
+	if chapter.Figures[0].ID != migrated || chapter.Figures[0].Label() != "fig-1.1" || chapter.Figures[1].ID != stable {
+		t.Errorf("Unexpected figures %+v", chapter.Figures)
+	}
+	if chapter.Figures[0].ImagePath != "assets/images/"+string(migrated)+".png" {
+		t.Errorf("Expected the imported image renamed, got %s", chapter.Figures[0].ImagePath)
+	}
+	if _, err := os.Stat(filepath.Join(manager.config.AssetsPath(string(docID)), string(migrated)+".png")); err != nil {
+		t.Errorf("Expected the asset renamed: %v", err)
+	}
+	content, _ := manager.GetSectionContent(docID, chapterNum, types.SectionNumber{1, 1})
+	if !strings.Contains(content, "{#"+string(migrated)+"}") || !strings.Contains(content, "See @"+string(migrated)+" and @"+string(stable)+".") {
+		t.Errorf("Expected the anchor and reference migrated, got %q", content)
+	}
+
+	// Migrating again has nothing to do
+	if ids, err := manager.MigrateFigureIDs(context.Background(), docID); err != nil || len(ids) != 0 {
+		t.Errorf("MigrateFigureIDs() = %v, %v, want nothing migrated", ids, err)
+	}
+}
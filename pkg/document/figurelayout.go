@@ -0,0 +1,132 @@
+package document
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/gomcpgo/docgen/pkg/types"
+)
+
+var (
+	// figureImagePattern matches an inline image with a figure anchor, capturing
+	// the image, the figure ID and the rest of its attributes
+	figureImagePattern = regexp.MustCompile(`(!\[[^\]]*\]\([^)]*\))\{#(` + types.FigureIDPattern + `)([^}]*)\}`)
+	// attributeTokenPattern matches one attribute of a pandoc attribute list,
+	// keeping quoted values whole
+	attributeTokenPattern = regexp.MustCompile(`[^\s=]+="[^"]*"|\S+`)
+)
+
+// UpdateImageProperties changes the width, alignment and position of an image
+// figure, leaving those not given as they are, and rebuilds the chapter so its
+// markdown carries them
+func (m *Manager) UpdateImageProperties(docID types.DocumentID, figureID types.FigureID, properties types.ImageProperties) (*types.Figure, error) {
+	if err := docID.Validate(); err != nil {
+		return nil, fmt.Errorf("invalid document ID: %w", err)
+	}
+	if properties == (types.ImageProperties{}) {
+		return nil, fmt.Errorf("at least one of width, alignment or position is required")
+	}
+	if properties.Width != "" && properties.Width != "auto" {
+		if err := types.ValidateImageWidth(properties.Width); err != nil {
+			return nil, err
+		}
+	}
+	if properties.Alignment != "" {
+		if err := validateImageAlignment(string(properties.Alignment)); err != nil {
+			return nil, err
+		}
+	}
+	if properties.Position != "" {
+		if err := validateImagePosition(string(properties.Position)); err != nil {
+			return nil, err
+		}
+	}
+
+	chapterNum, figureID, err := m.findFigure(docID, figureID)
+	if err != nil {
+		return nil, err
+	}
+	chapter, err := m.storage.LoadChapterMetadata(string(docID), int(chapterNum))
+	if err != nil {
+		return nil, fmt.Errorf("failed to load chapter: %w", err)
+	}
+
+	var figure *types.Figure
+	for i := range chapter.Figures {
+		if chapter.Figures[i].ID == figureID {
+			figure = &chapter.Figures[i]
+			break
+		}
+	}
+	if figure == nil {
+		return nil, fmt.Errorf("figure %s not found", figureID)
+	}
+	if figure.IsGrid() && (properties.Width != "" || properties.Alignment != "") {
+		return nil, fmt.Errorf("figure %s is a figure grid; only its position can be changed", figureID)
+	}
+
+	switch properties.Width {
+	case "":
+	case "auto":
+		figure.Width = ""
+	default:
+		figure.Width = properties.Width
+	}
+	if properties.Alignment != "" {
+		figure.Alignment = properties.Alignment
+	}
+	if properties.Position != "" {
+		figure.Position = properties.Position
+	}
+	now := time.Now()
+	figure.UpdatedAt = now
+	chapter.UpdatedAt = now
+
+	if err := m.storage.SaveChapterMetadata(string(docID), chapter); err != nil {
+		return nil, fmt.Errorf("failed to save chapter metadata: %w", err)
+	}
+	if err := m.RebuildChapterMarkdown(docID, chapterNum); err != nil {
+		return nil, fmt.Errorf("failed to rebuild chapter markdown: %w", err)
+	}
+
+	updated := *figure
+	return &updated, nil
+}
+
+// applyFigureLayout gives each anchored image of a registered figure the
+// width and alignment attributes of its metadata. An alignment class written
+// by hand gives way to the metadata; a width only does when the figure has one.
+func applyFigureLayout(content string, figures []types.Figure) string {
+	byID := make(map[string]types.Figure)
+	for _, figure := range figures {
+		if !figure.IsGrid() {
+			byID[string(figure.ID)] = figure
+		}
+	}
+	if len(byID) == 0 {
+		return content
+	}
+
+	return figureImagePattern.ReplaceAllStringFunc(content, func(match string) string {
+		parts := figureImagePattern.FindStringSubmatch(match)
+		figure, ok := byID[parts[2]]
+		if !ok {
+			return match
+		}
+
+		attributes := []string{"#" + parts[2]}
+		for _, token := range attributeTokenPattern.FindAllString(parts[3], -1) {
+			switch {
+			case token == "."+types.FigureAlignLeftClass || token == "."+types.FigureAlignRightClass:
+				continue
+			case strings.HasPrefix(token, "width=") && figure.Width != "":
+				continue
+			}
+			attributes = append(attributes, token)
+		}
+		attributes = append(attributes, figure.LayoutAttributes()...)
+		return parts[1] + "{" + strings.Join(attributes, " ") + "}"
+	})
+}
@@ -0,0 +1,62 @@
+package document
+
+import (
+	"context"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/gomcpgo/docgen/pkg/types"
+)
+
+func TestManager_ImageProperties(t *testing.T) {
+	manager, tempDir := setupTestManager(t)
+	defer os.RemoveAll(tempDir)
+
+	docID, _ := manager.CreateDocument("Report", "Test Author", types.DocumentTypeReport)
+	chapterNum, _ := manager.AddChapter(context.Background(), docID, "Results", nil)
+	figureID, err := manager.AddImage(docID, chapterNum, "assets/images/plot.png", "A plot", "here", "60%", "left")
+	if err != nil {
+		t.Fatalf("AddImage() error = %v", err)
+	}
+	if _, err := manager.AddSection(context.Background(), docID, chapterNum, "Plot", "See the plot.\n\n![A plot](assets/images/plot.png){#"+string(figureID)+" width=30% .shadow}", 1); err != nil {
+		t.Fatalf("AddSection() error = %v", err)
+	}
+
+	content, _ := manager.storage.LoadChapterContent(string(docID), int(chapterNum))
+	if !strings.Contains(content, "![A plot](assets/images/plot.png){#"+string(figureID)+" .shadow .align-left width=60%}") {
+		t.Errorf("Expected the figure's width and alignment in the chapter:\n%s", content)
+	}
+
+	// Only the properties given change
+	// The figure's display number names it as well as its ID does
+	figure, err := manager.UpdateImageProperties(docID, "fig-1.1", types.ImageProperties{Width: "auto", Alignment: types.AlignRight})
+	if err != nil {
+		t.Fatalf("UpdateImageProperties() error = %v", err)
+	}
+	if figure.Width != "" || figure.Alignment != types.AlignRight || figure.Position != types.PositionHere {
+		t.Errorf("Unexpected figure: %+v", figure)
+	}
+	content, _ = manager.storage.LoadChapterContent(string(docID), int(chapterNum))
+	if !strings.Contains(content, "{#"+string(figureID)+" width=30% .shadow .align-right}") {
+		t.Errorf("Expected the hand-written width to stay without a figure width:\n%s", content)
+	}
+
+	for _, tt := range []struct {
+		properties types.ImageProperties
+		wantErr    string
+	}{
+		{types.ImageProperties{}, "at least one of"},
+		{types.ImageProperties{Width: "150%"}, "at most 100%"},
+		{types.ImageProperties{Width: "wide"}, "invalid width"},
+		{types.ImageProperties{Alignment: "justify"}, "invalid alignment"},
+		{types.ImageProperties{Position: "side"}, "invalid position"},
+	} {
+		if _, err := manager.UpdateImageProperties(docID, figureID, tt.properties); err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+			t.Errorf("UpdateImageProperties(%+v) error = %v, want %q", tt.properties, err, tt.wantErr)
+		}
+	}
+	if _, err := manager.AddImage(docID, chapterNum, "assets/images/plot.png", "A plot", "here", "", "middle"); err == nil {
+		t.Errorf("AddImage() should reject an invalid alignment")
+	}
+}
@@ -0,0 +1,93 @@
+package document
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/gomcpgo/docgen/pkg/types"
+)
+
+// AddFont copies a font file into a document's fonts folder, or with an empty
+// document ID into the fonts folder shared by all documents, for styles to
+// name in font_file. The source must lie within the allowed directories; a
+// font of the same name is replaced. It returns the font's file name.
+func (m *Manager) AddFont(ctx context.Context, docID types.DocumentID, sourcePath string) (string, error) {
+	baseDir := m.config.RootDir
+	if docID != "" {
+		if err := docID.Validate(); err != nil {
+			return "", fmt.Errorf("invalid document ID: %w", err)
+		}
+		if _, err := m.storage.LoadManifest(string(docID)); err != nil {
+			return "", fmt.Errorf("failed to load document: %w", err)
+		}
+		baseDir = m.config.DocumentPath(string(docID))
+	}
+
+	resolved, err := m.config.ResolvePath(sourcePath, baseDir)
+	if err != nil {
+		return "", fmt.Errorf("invalid font path: %w", err)
+	}
+	name := filepath.Base(resolved)
+	if err := types.ValidateFontFileName(name); err != nil {
+		return "", err
+	}
+	info, err := os.Stat(resolved)
+	if err != nil || !info.Mode().IsRegular() {
+		return "", fmt.Errorf("font file not found: %s", sourcePath)
+	}
+	if m.config.MaxFileSize > 0 && info.Size() > m.config.MaxFileSize {
+		return "", fmt.Errorf("font file %s is larger than %d bytes", sourcePath, m.config.MaxFileSize)
+	}
+
+	data, err := os.ReadFile(resolved)
+	if err != nil {
+		return "", fmt.Errorf("failed to read font file: %w", err)
+	}
+	// Shared fonts belong to no document, so count only toward the total
+	if docID != "" {
+		err = m.CheckStorageQuota(ctx, docID, int64(len(data)))
+	} else {
+		err = m.checkTotalQuota(ctx, int64(len(data)))
+	}
+	if err != nil {
+		return "", err
+	}
+	if _, err := m.storage.SaveFont(string(docID), name, data); err != nil {
+		return "", err
+	}
+	return name, nil
+}
+
+// ListFonts returns the fonts a document can use: its own, then the shared
+// ones it doesn't have a font of the same name for. With an empty document ID
+// only the shared fonts are listed.
+func (m *Manager) ListFonts(docID types.DocumentID) ([]types.BundledFont, error) {
+	fonts := []types.BundledFont{}
+	own := make(map[string]bool)
+	if docID != "" {
+		if err := docID.Validate(); err != nil {
+			return nil, fmt.Errorf("invalid document ID: %w", err)
+		}
+		names, err := m.storage.ListFonts(string(docID))
+		if err != nil {
+			return nil, err
+		}
+		for _, name := range names {
+			fonts = append(fonts, types.BundledFont{Name: name})
+			own[name] = true
+		}
+	}
+
+	shared, err := m.storage.ListFonts("")
+	if err != nil {
+		return nil, err
+	}
+	for _, name := range shared {
+		if !own[name] {
+			fonts = append(fonts, types.BundledFont{Name: name, Shared: true})
+		}
+	}
+	return fonts, nil
+}
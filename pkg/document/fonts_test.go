@@ -0,0 +1,71 @@
+package document
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/gomcpgo/docgen/pkg/types"
+)
+
+func TestManager_AddFont(t *testing.T) {
+	manager, tempDir := setupTestManager(t)
+	defer os.RemoveAll(tempDir)
+
+	docID, _ := manager.CreateDocument("Type Specimen", "Test Author", types.DocumentTypeBook)
+	source := filepath.Join(tempDir, "incoming")
+	os.MkdirAll(source, 0755)
+	for _, name := range []string{"Serif-Regular.otf", "Mono.ttf", "Serif.woff2"} {
+		os.WriteFile(filepath.Join(source, name), []byte("font"), 0644)
+	}
+
+	if name, err := manager.AddFont(context.Background(), docID, filepath.Join(source, "Serif-Regular.otf")); err != nil || name != "Serif-Regular.otf" {
+		t.Fatalf("AddFont() = %q, %v", name, err)
+	}
+	if _, err := os.Stat(filepath.Join(manager.config.DocumentFontsPath(string(docID)), "Serif-Regular.otf")); err != nil {
+		t.Errorf("Expected the font in the document's fonts folder: %v", err)
+	}
+	for _, name := range []string{"Serif-Regular.otf", "Mono.ttf"} {
+		if _, err := manager.AddFont(context.Background(), "", filepath.Join(source, name)); err != nil {
+			t.Fatalf("AddFont() shared error = %v", err)
+		}
+	}
+
+	// The document's own font hides the shared one of the same name
+	fonts, err := manager.ListFonts(docID)
+	if err != nil {
+		t.Fatalf("ListFonts() error = %v", err)
+	}
+	if len(fonts) != 2 || fonts[0] != (types.BundledFont{Name: "Serif-Regular.otf"}) || fonts[1] != (types.BundledFont{Name: "Mono.ttf", Shared: true}) {
+		t.Errorf("Unexpected fonts %+v", fonts)
+	}
+	if shared, _ := manager.ListFonts(""); len(shared) != 2 || !shared[0].Shared {
+		t.Errorf("Expected only the shared fonts, got %+v", shared)
+	}
+
+	if _, err := manager.AddFont(context.Background(), docID, filepath.Join(source, "Serif.woff2")); err == nil {
+		t.Error("Expected an error for a font format exports can't load")
+	}
+	if _, err := manager.AddFont(context.Background(), docID, filepath.Join(source, "Missing.otf")); err == nil {
+		t.Error("Expected an error for a missing font file")
+	}
+	if _, err := manager.AddFont(context.Background(), docID, "/etc/fonts/Serif.otf"); err == nil {
+		t.Error("Expected an error for a font outside the allowed directories")
+	}
+	if _, err := manager.AddFont(context.Background(), "missing-doc", filepath.Join(source, "Mono.ttf")); err == nil {
+		t.Error("Expected an error for a missing document")
+	}
+
+	// Fonts count toward the document's quota, shared ones toward the total
+	manager.config.MaxDocumentSize = 1
+	if _, err := manager.AddFont(context.Background(), docID, filepath.Join(source, "Mono.ttf")); err == nil || !strings.Contains(err.Error(), "storage quota exceeded") {
+		t.Errorf("Expected a quota error for a document font, got %v", err)
+	}
+	manager.config.MaxDocumentSize = 0
+	manager.config.MaxTotalSize = 1
+	if _, err := manager.AddFont(context.Background(), "", filepath.Join(source, "Mono.ttf")); err == nil || !strings.Contains(err.Error(), "storage quota exceeded") {
+		t.Errorf("Expected a quota error for a shared font, got %v", err)
+	}
+}
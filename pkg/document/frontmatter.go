@@ -0,0 +1,71 @@
+package document
+
+import (
+	"fmt"
+	"regexp"
+	"time"
+
+	"github.com/gomcpgo/docgen/pkg/types"
+)
+
+// frontMatterKeyPattern matches the keys front matter may use
+var frontMatterKeyPattern = regexp.MustCompile(`^[A-Za-z][A-Za-z0-9_-]*$`)
+
+// SetFrontMatter sets front matter on a chapter, or on one of its sections
+// when sectionNum is given. Keys in values are added or replaced and a nil
+// value removes its key; with replace the front matter becomes values. It
+// returns the front matter as saved.
+func (m *Manager) SetFrontMatter(docID types.DocumentID, chapterNum types.ChapterNumber, sectionNum types.SectionNumber, values map[string]interface{}, replace bool) (types.FrontMatter, error) {
+	if err := docID.Validate(); err != nil {
+		return nil, fmt.Errorf("invalid document ID: %w", err)
+	}
+	for key := range values {
+		if !frontMatterKeyPattern.MatchString(key) {
+			return nil, fmt.Errorf("invalid front matter key %q: use letters, digits, - and _, starting with a letter", key)
+		}
+	}
+
+	chapter, err := m.storage.LoadChapterMetadata(string(docID), int(chapterNum))
+	if err != nil {
+		return nil, fmt.Errorf("failed to load chapter: %w", err)
+	}
+
+	target := &chapter.FrontMatter
+	if sectionNum != nil {
+		target = nil
+		for i := range chapter.Sections {
+			if m.sectionNumbersEqual(chapter.Sections[i].Number, sectionNum) {
+				target = &chapter.Sections[i].FrontMatter
+				chapter.Sections[i].UpdatedAt = time.Now()
+				break
+			}
+		}
+		if target == nil {
+			return nil, fmt.Errorf("section %s not found", sectionNum.String())
+		}
+	}
+
+	frontMatter := types.FrontMatter{}
+	if !replace {
+		for key, value := range *target {
+			frontMatter[key] = value
+		}
+	}
+	for key, value := range values {
+		if value == nil {
+			delete(frontMatter, key)
+		} else {
+			frontMatter[key] = value
+		}
+	}
+	if len(frontMatter) == 0 {
+		frontMatter = nil
+	}
+	*target = frontMatter
+	chapter.UpdatedAt = time.Now()
+
+	if err := m.storage.SaveChapterMetadata(string(docID), chapter); err != nil {
+		return nil, fmt.Errorf("failed to save chapter metadata: %w", err)
+	}
+	return frontMatter, nil
+}
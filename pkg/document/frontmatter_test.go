@@ -0,0 +1,58 @@
+package document
+
+import (
+	"context"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/gomcpgo/docgen/pkg/types"
+)
+
+func TestManager_SetFrontMatter(t *testing.T) {
+	manager, tempDir := setupTestManager(t)
+	defer os.RemoveAll(tempDir)
+
+	docID, _ := manager.CreateDocument("Handbook", "Test Author", types.DocumentTypeReport)
+	chapterNum, _ := manager.AddChapter(context.Background(), docID, "Basics", nil)
+	manager.AddSection(context.Background(), docID, chapterNum, "Start", "Hello.", 1)
+	manager.AddSection(context.Background(), docID, chapterNum, "Detail", "More.", 1)
+	other, _ := manager.AddChapter(context.Background(), docID, "Other", nil)
+
+	if _, err := manager.SetFrontMatter(docID, chapterNum, nil, map[string]interface{}{"status": "review", "owner": "Sam"}, false); err != nil {
+		t.Fatalf("SetFrontMatter() chapter error = %v", err)
+	}
+	frontMatter, err := manager.SetFrontMatter(docID, chapterNum, nil, map[string]interface{}{"owner": nil, "due": "2026-11-01"}, false)
+	if err != nil || len(frontMatter) != 2 || frontMatter["status"] != "review" || frontMatter["due"] != "2026-11-01" {
+		t.Errorf("SetFrontMatter() merge = %v, %v", frontMatter, err)
+	}
+	manager.SetFrontMatter(docID, chapterNum, types.SectionNumber{1, 2}, map[string]interface{}{"status": "draft", "tags": []interface{}{"legal", "urgent"}}, false)
+	manager.SetFrontMatter(docID, other, nil, map[string]interface{}{"status": "draft"}, false)
+
+	// Front matter is kept with the chapter metadata
+	chapter, _ := manager.storage.LoadChapterMetadata(string(docID), int(chapterNum))
+	if chapter.Sections[1].FrontMatter["status"] != "draft" || chapter.FrontMatter["status"] != "review" {
+		t.Errorf("Unexpected stored front matter %+v / %+v", chapter.FrontMatter, chapter.Sections[1].FrontMatter)
+	}
+
+	manifest, _ := manager.GetDocumentStructure(docID)
+	manifest, _ = ShapeStructure(manifest, types.StructureOptions{Sections: true, FrontMatter: map[string]string{"tags": "urgent"}})
+	if len(manifest.Document.Chapters) != 1 || len(manifest.Document.Chapters[0].Sections) != 1 || manifest.Document.Chapters[0].Sections[0].Title != "Detail" {
+		t.Errorf("Expected only section 1.2 to match, got %+v", manifest.Document.Chapters)
+	}
+	manifest, _ = manager.GetDocumentStructure(docID)
+	manifest, _ = ShapeStructure(manifest, types.StructureOptions{Sections: true, FrontMatter: map[string]string{"status": "draft"}})
+	if len(manifest.Document.Chapters) != 2 || len(manifest.Document.Chapters[0].Sections) != 1 {
+		t.Errorf("Expected section 1.2 and chapter 2 to match, got %+v", manifest.Document.Chapters)
+	}
+
+	if frontMatter, _ := manager.SetFrontMatter(docID, chapterNum, nil, map[string]interface{}{}, true); frontMatter != nil {
+		t.Errorf("Expected replacing with nothing to clear the front matter, got %v", frontMatter)
+	}
+	if _, err := manager.SetFrontMatter(docID, chapterNum, nil, map[string]interface{}{"bad key": "x"}, false); err == nil || !strings.Contains(err.Error(), "invalid front matter key") {
+		t.Errorf("SetFrontMatter() error = %v, want an invalid key", err)
+	}
+	if _, err := manager.SetFrontMatter(docID, chapterNum, types.SectionNumber{1, 9}, map[string]interface{}{"status": "x"}, false); err == nil {
+		t.Error("Expected an error for a missing section")
+	}
+}
@@ -0,0 +1,121 @@
+package document
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/gomcpgo/docgen/pkg/types"
+)
+
+// StaleChapterAge is how far a chapter's last change may lag behind the most
+// recently changed chapter before the chapter counts as stale
+const StaleChapterAge = 90 * 24 * time.Hour
+
+// Health score penalties: each kind of problem costs points per occurrence, up
+// to a cap, so that no single kind can take the whole score
+const (
+	validationErrorPenalty = 10
+	validationErrorCap     = 40
+	todoPenalty            = 2
+	todoCap                = 20
+	emptySectionPenalty    = 5
+	emptySectionCap        = 20
+	staleChapterPenalty    = 3
+	staleChapterCap        = 15
+)
+
+// Health scores a document from its validation errors, unresolved TODOs, empty
+// sections and chapters, and chapters left behind by the rest of the document.
+// Validation is run by the caller, which passes the number of errors it found.
+func (m *Manager) Health(docID types.DocumentID, validationErrors int) (*types.DocumentHealth, error) {
+	if err := docID.Validate(); err != nil {
+		return nil, fmt.Errorf("invalid document ID: %w", err)
+	}
+
+	manifest, err := m.storage.LoadManifest(string(docID))
+	if err != nil {
+		return nil, fmt.Errorf("failed to load manifest: %w", err)
+	}
+
+	health := &types.DocumentHealth{ValidationErrors: validationErrors}
+	var latest time.Time
+	for i, chapterRef := range manifest.Document.Chapters {
+		chapter, err := m.storage.LoadChapterMetadata(string(docID), int(chapterRef.Number))
+		if err != nil {
+			return nil, fmt.Errorf("failed to load chapter %d metadata: %w", chapterRef.Number, err)
+		}
+		manifest.Document.Chapters[i] = *chapter
+		if chapter.UpdatedAt.After(latest) {
+			latest = chapter.UpdatedAt
+		}
+
+		if len(chapter.Sections) == 0 && chapter.IncludeFile == "" {
+			health.EmptyChapters = append(health.EmptyChapters, chapter.Number)
+			continue
+		}
+		for _, section := range chapter.Sections {
+			// Included files are checked by validation
+			if section.IncludeFile != "" {
+				continue
+			}
+			content, err := m.storage.LoadSectionContent(string(docID), int(chapter.Number), section.Number)
+			if err != nil || strings.TrimSpace(content) == "" {
+				health.EmptySections = append(health.EmptySections, section.Number.String())
+			}
+		}
+	}
+	health.Todos = len(manifest.Todos())
+	for _, chapter := range manifest.Document.Chapters {
+		if latest.Sub(chapter.UpdatedAt) > StaleChapterAge {
+			health.StaleChapters = append(health.StaleChapters, chapter.Number)
+		}
+	}
+
+	scoreHealth(health)
+	return health, nil
+}
+
+// scoreHealth sets a health report's score and suggestions from its findings
+func scoreHealth(health *types.DocumentHealth) {
+	empty := len(health.EmptySections) + len(health.EmptyChapters)
+	penalty := capPenalty(health.ValidationErrors, validationErrorPenalty, validationErrorCap) +
+		capPenalty(health.Todos, todoPenalty, todoCap) +
+		capPenalty(empty, emptySectionPenalty, emptySectionCap) +
+		capPenalty(len(health.StaleChapters), staleChapterPenalty, staleChapterCap)
+	health.Score = 100 - penalty
+
+	health.Suggestions = nil
+	if health.ValidationErrors > 0 {
+		health.Suggestions = append(health.Suggestions, fmt.Sprintf("Fix %d validation error(s) reported by validate_document before exporting", health.ValidationErrors))
+	}
+	if health.Todos > 0 {
+		health.Suggestions = append(health.Suggestions, fmt.Sprintf("Resolve %d TODO(s) listed by list_todos", health.Todos))
+	}
+	if len(health.EmptySections) > 0 {
+		health.Suggestions = append(health.Suggestions, fmt.Sprintf("Write or delete empty sections %s", strings.Join(health.EmptySections, ", ")))
+	}
+	if len(health.EmptyChapters) > 0 {
+		health.Suggestions = append(health.Suggestions, fmt.Sprintf("Add sections to or delete empty chapters %s", joinChapterNumbers(health.EmptyChapters)))
+	}
+	if len(health.StaleChapters) > 0 {
+		health.Suggestions = append(health.Suggestions, fmt.Sprintf("Review chapters %s, which have not changed in %d days while the rest of the document has", joinChapterNumbers(health.StaleChapters), int(StaleChapterAge.Hours()/24)))
+	}
+}
+
+// capPenalty returns count times each, but no more than limit
+func capPenalty(count, each, limit int) int {
+	if count*each > limit {
+		return limit
+	}
+	return count * each
+}
+
+// joinChapterNumbers lists chapter numbers separated by commas
+func joinChapterNumbers(numbers []types.ChapterNumber) string {
+	parts := make([]string, len(numbers))
+	for i, number := range numbers {
+		parts[i] = number.String()
+	}
+	return strings.Join(parts, ", ")
+}
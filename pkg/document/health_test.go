@@ -0,0 +1,62 @@
+package document
+
+import (
+	"context"
+	"os"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/gomcpgo/docgen/pkg/types"
+)
+
+func TestManager_Health(t *testing.T) {
+	manager, tempDir := setupTestManager(t)
+	defer os.RemoveAll(tempDir)
+
+	docID, _ := manager.CreateDocument("Report", "Test Author", types.DocumentTypeReport)
+	intro, _ := manager.AddChapter(context.Background(), docID, "Introduction", nil)
+	manager.AddSection(context.Background(), docID, intro, "Background", "Some background.", 1)
+
+	health, err := manager.Health(docID, 0)
+	if err != nil {
+		t.Fatalf("Health() error = %v", err)
+	}
+	if health.Score != 100 || len(health.Suggestions) != 0 {
+		t.Errorf("A clean document should score 100, got %+v", health)
+	}
+
+	// An empty section, an empty chapter, an uncaptioned image and a chapter
+	// left behind by the rest of the document
+	manager.AddSection(context.Background(), docID, intro, "Scope", "  \n", 1)
+	manager.AddImage(docID, intro, "assets/images/plot.png", "", "here", "", "")
+	manager.AddChapter(context.Background(), docID, "Results", nil)
+	chapter, _ := manager.storage.LoadChapterMetadata(string(docID), int(intro))
+	chapter.UpdatedAt = time.Now().Add(-StaleChapterAge - time.Hour)
+	manager.storage.SaveChapterMetadata(string(docID), chapter)
+
+	health, err = manager.Health(docID, 1)
+	if err != nil {
+		t.Fatalf("Health() error = %v", err)
+	}
+	if health.ValidationErrors != 1 || health.Todos != 1 {
+		t.Errorf("Unexpected counts: %+v", health)
+	}
+	if !reflect.DeepEqual(health.EmptySections, []string{"1.2"}) || !reflect.DeepEqual(health.EmptyChapters, []types.ChapterNumber{2}) {
+		t.Errorf("Unexpected empty sections %v and chapters %v", health.EmptySections, health.EmptyChapters)
+	}
+	if !reflect.DeepEqual(health.StaleChapters, []types.ChapterNumber{1}) {
+		t.Errorf("StaleChapters = %v, want [1]", health.StaleChapters)
+	}
+	// 10 for the validation error, 2 for the TODO, 2*5 for the empty section
+	// and chapter and 3 for the stale chapter
+	if health.Score != 75 || len(health.Suggestions) != 5 {
+		t.Errorf("Score = %d with %d suggestions, want 75 with 5", health.Score, len(health.Suggestions))
+	}
+
+	// Penalties are capped per kind
+	health, _ = manager.Health(docID, 20)
+	if health.Score != 45 {
+		t.Errorf("Score = %d, want 45 with validation errors capped at 40", health.Score)
+	}
+}
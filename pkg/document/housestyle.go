@@ -0,0 +1,114 @@
+package document
+
+import (
+	"fmt"
+
+	"github.com/gomcpgo/docgen/pkg/editorial"
+	"github.com/gomcpgo/docgen/pkg/types"
+)
+
+// SaveHouseStyle validates and stores a house style ruleset. Saving under an
+// existing name replaces the previous ruleset.
+func (m *Manager) SaveHouseStyle(style *types.HouseStyle) error {
+	if err := types.ValidateHouseStyleName(style.Name); err != nil {
+		return fmt.Errorf("invalid house style name: %w", err)
+	}
+	if len(style.Rules) == 0 {
+		return fmt.Errorf("house style must have at least one rule")
+	}
+	if _, err := editorial.Compile(style); err != nil {
+		return fmt.Errorf("invalid house style: %w", err)
+	}
+
+	if err := m.storage.SaveHouseStyle(style); err != nil {
+		return fmt.Errorf("failed to save house style: %w", err)
+	}
+	return nil
+}
+
+// CheckHouseStyle checks every section of a document against a house style
+// ruleset. An empty name uses the configured default ruleset. With fix set, matches
+// of rules marked as safe to fix are corrected in the section files and the
+// affected chapters are rebuilt; section titles are only reported.
+func (m *Manager) CheckHouseStyle(docID types.DocumentID, name string, fix bool) ([]types.HouseStyleFinding, error) {
+	if err := docID.Validate(); err != nil {
+		return nil, fmt.Errorf("invalid document ID: %w", err)
+	}
+	if name == "" {
+		name = m.config.DefaultHouseStyle
+	}
+	if name == "" {
+		return nil, fmt.Errorf("no house style given and no default house style configured")
+	}
+	if err := types.ValidateHouseStyleName(name); err != nil {
+		return nil, fmt.Errorf("invalid house style name: %w", err)
+	}
+
+	style, err := m.storage.LoadHouseStyle(name)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load house style: %w", err)
+	}
+	ruleset, err := editorial.Compile(style)
+	if err != nil {
+		return nil, fmt.Errorf("invalid house style: %w", err)
+	}
+
+	manifest, err := m.storage.LoadManifest(string(docID))
+	if err != nil {
+		return nil, fmt.Errorf("failed to load manifest: %w", err)
+	}
+
+	findings := []types.HouseStyleFinding{}
+	for _, chapterRef := range manifest.Document.Chapters {
+		chapter, err := m.storage.LoadChapterMetadata(string(docID), int(chapterRef.Number))
+		if err != nil {
+			return nil, fmt.Errorf("failed to load chapter %d metadata: %w", chapterRef.Number, err)
+		}
+
+		chapterFixed := false
+		for _, section := range chapter.Sections {
+			for _, finding := range ruleset.CheckHeading(section.Title) {
+				findings = append(findings, houseStyleFinding(finding, chapter.Number, section.Number))
+			}
+
+			content, err := m.storage.LoadSectionContent(string(docID), int(chapter.Number), section.Number)
+			if err != nil {
+				continue
+			}
+			fixed, sectionFindings := ruleset.Check(content, fix)
+			for _, finding := range sectionFindings {
+				findings = append(findings, houseStyleFinding(finding, chapter.Number, section.Number))
+			}
+
+			if fixed != content {
+				if err := m.storage.SaveSectionContent(string(docID), int(chapter.Number), section.Number, fixed); err != nil {
+					return nil, fmt.Errorf("failed to save fixed section %s: %w", section.Number.String(), err)
+				}
+				chapterFixed = true
+			}
+		}
+
+		if chapterFixed {
+			if err := m.RebuildChapterMarkdown(docID, chapter.Number); err != nil {
+				return nil, fmt.Errorf("failed to rebuild chapter %d: %w", chapter.Number, err)
+			}
+		}
+	}
+
+	return findings, nil
+}
+
+// houseStyleFinding places an engine finding in its chapter and section
+func houseStyleFinding(finding editorial.Finding, chapterNum types.ChapterNumber, sectionNum types.SectionNumber) types.HouseStyleFinding {
+	return types.HouseStyleFinding{
+		Rule:       finding.Rule,
+		Severity:   finding.Severity,
+		Message:    finding.Message,
+		Chapter:    chapterNum,
+		Section:    sectionNum.String(),
+		Line:       finding.Line,
+		Match:      finding.Match,
+		Suggestion: finding.Suggestion,
+		Fixed:      finding.Fixed,
+	}
+}
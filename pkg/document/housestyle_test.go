@@ -0,0 +1,98 @@
+package document
+
+import (
+	"context"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/gomcpgo/docgen/pkg/types"
+)
+
+func TestManager_CheckHouseStyle(t *testing.T) {
+	manager, tempDir := setupTestManager(t)
+	defer os.RemoveAll(tempDir)
+
+	docID, err := manager.CreateDocument("Test Document", "Test Author", types.DocumentTypeReport)
+	if err != nil {
+		t.Fatalf("Failed to create document: %v", err)
+	}
+	chapterNum, err := manager.AddChapter(context.Background(), docID, "Results", nil)
+	if err != nil {
+		t.Fatalf("Failed to add chapter: %v", err)
+	}
+	sectionNum, err := manager.AddSection(context.Background(), docID, chapterNum, "Findings:", "Send the the report by e-mail.", 1)
+	if err != nil {
+		t.Fatalf("Failed to add section: %v", err)
+	}
+
+	// No ruleset given and no default configured
+	if _, err := manager.CheckHouseStyle(docID, "", false); err == nil {
+		t.Errorf("Expected an error without a house style")
+	}
+	if _, err := manager.CheckHouseStyle(docID, "missing", false); err == nil {
+		t.Errorf("Expected an error for an unknown house style")
+	}
+
+	if err := manager.SaveHouseStyle(&types.HouseStyle{Name: "acme", Rules: []types.HouseStyleRule{{ID: "bad", Pattern: "("}}}); err == nil {
+		t.Errorf("Expected an error for an invalid rule")
+	}
+
+	replacement := "email"
+	style := &types.HouseStyle{
+		Name: "acme",
+		Rules: []types.HouseStyleRule{
+			{ID: "email", Pattern: `\be-mail\b`, Replacement: &replacement, Severity: types.SeverityError, Fix: true},
+			{ID: "doubles", Builtin: "repeated_words"},
+			{ID: "headings", Builtin: "heading_punctuation", Fix: true},
+		},
+	}
+	if err := manager.SaveHouseStyle(style); err != nil {
+		t.Fatalf("SaveHouseStyle() error = %v", err)
+	}
+
+	findings, err := manager.CheckHouseStyle(docID, "acme", false)
+	if err != nil {
+		t.Fatalf("CheckHouseStyle() error = %v", err)
+	}
+	if len(findings) != 3 {
+		t.Fatalf("Expected 3 findings, got %+v", findings)
+	}
+	for _, finding := range findings {
+		if finding.Chapter != chapterNum || finding.Section != sectionNum.String() {
+			t.Errorf("Finding should reference chapter %d section %s, got %+v", chapterNum, sectionNum.String(), finding)
+		}
+	}
+
+	// The configured default is used when no name is given
+	manager.config.DefaultHouseStyle = "acme"
+	findings, err = manager.CheckHouseStyle(docID, "", true)
+	if err != nil {
+		t.Fatalf("CheckHouseStyle() with fix error = %v", err)
+	}
+	fixed := 0
+	for _, finding := range findings {
+		if finding.Fixed {
+			fixed++
+		}
+	}
+	if fixed != 1 {
+		t.Errorf("Expected only the email finding to be fixed, got %+v", findings)
+	}
+
+	content, err := manager.GetSectionContent(docID, chapterNum, sectionNum)
+	if err != nil {
+		t.Fatalf("Failed to get section content: %v", err)
+	}
+	if !strings.Contains(content, "the the report by email.") {
+		t.Errorf("Section should have only safe fixes applied, got %q", content)
+	}
+
+	compiled, err := manager.storage.LoadChapterContent(string(docID), int(chapterNum))
+	if err != nil {
+		t.Fatalf("Failed to load chapter content: %v", err)
+	}
+	if !strings.Contains(compiled, "by email.") {
+		t.Errorf("Chapter should be rebuilt after fixing, got:\n%s", compiled)
+	}
+}
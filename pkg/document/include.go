@@ -0,0 +1,143 @@
+package document
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/gomcpgo/docgen/pkg/types"
+)
+
+// SetIncludeFile makes a chapter, or one of its sections when sectionNum is
+// given, include a markdown file kept outside the document. The path is
+// resolved against the document directory and must lie within the allowed
+// directories. An empty path stops including a file. It returns the resolved
+// path of the included file.
+func (m *Manager) SetIncludeFile(docID types.DocumentID, chapterNum types.ChapterNumber, sectionNum types.SectionNumber, path string) (string, error) {
+	if err := docID.Validate(); err != nil {
+		return "", fmt.Errorf("invalid document ID: %w", err)
+	}
+
+	resolved := ""
+	if path != "" {
+		var err error
+		resolved, err = m.resolveIncludeFile(docID, path)
+		if err != nil {
+			return "", err
+		}
+	}
+
+	chapter, err := m.storage.LoadChapterMetadata(string(docID), int(chapterNum))
+	if err != nil {
+		return "", fmt.Errorf("failed to load chapter: %w", err)
+	}
+
+	now := time.Now()
+	if len(sectionNum) == 0 {
+		chapter.IncludeFile = path
+	} else {
+		found := false
+		for i, section := range chapter.Sections {
+			if m.sectionNumbersEqual(section.Number, sectionNum) {
+				chapter.Sections[i].IncludeFile = path
+				chapter.Sections[i].UpdatedAt = now
+				found = true
+				break
+			}
+		}
+		if !found {
+			return "", fmt.Errorf("section %s not found in chapter %d", sectionNum.String(), chapterNum)
+		}
+	}
+	chapter.UpdatedAt = now
+
+	if err := m.storage.SaveChapterMetadata(string(docID), chapter); err != nil {
+		return "", fmt.Errorf("failed to save chapter metadata: %w", err)
+	}
+	if err := m.RebuildChapterMarkdown(docID, chapterNum); err != nil {
+		return "", fmt.Errorf("failed to rebuild chapter markdown: %w", err)
+	}
+
+	return resolved, nil
+}
+
+// resolveIncludeFile resolves the path of an included file and checks that it
+// is a file small enough to include
+func (m *Manager) resolveIncludeFile(docID types.DocumentID, path string) (string, error) {
+	resolved, err := m.config.ResolvePath(path, m.config.DocumentPath(string(docID)))
+	if err != nil {
+		return "", fmt.Errorf("invalid include path: %w", err)
+	}
+	if m.isServerFile(docID, resolved) {
+		return "", fmt.Errorf("invalid include path: %s belongs to another document or to the server", path)
+	}
+	info, err := os.Stat(resolved)
+	if err != nil {
+		return "", fmt.Errorf("included file not found: %s", path)
+	}
+	if !info.Mode().IsRegular() {
+		return "", fmt.Errorf("included path is not a file: %s", path)
+	}
+	if m.config.MaxFileSize > 0 && info.Size() > m.config.MaxFileSize {
+		return "", fmt.Errorf("included file %s is larger than %d bytes", path, m.config.MaxFileSize)
+	}
+	return resolved, nil
+}
+
+// readIncludeFile reads the current content of an included file
+func (m *Manager) readIncludeFile(docID types.DocumentID, path string) (string, error) {
+	resolved, err := m.resolveIncludeFile(docID, path)
+	if err != nil {
+		return "", err
+	}
+	data, err := os.ReadFile(resolved)
+	if err != nil {
+		return "", fmt.Errorf("failed to read included file %s: %w", path, err)
+	}
+	return string(data), nil
+}
+
+// isServerFile reports whether a resolved path lies where a document may not
+// include from: the server's own files in the root directory, archives,
+// exports and other documents. Access to those is granted per document, so an
+// include would hand them to anyone who can edit this one.
+func (m *Manager) isServerFile(docID types.DocumentID, resolved string) bool {
+	for _, dir := range []string{m.config.ExportsDir, m.config.ArchivesPath()} {
+		if within(dir, resolved) {
+			return true
+		}
+	}
+	for _, file := range []string{m.config.UsagePath(), m.config.AccessPolicyPath(), m.config.HooksPath(), m.config.ExportLedgerPath()} {
+		if canonical, err := filepath.EvalSymlinks(file); err == nil && canonical == resolved {
+			return true
+		}
+	}
+
+	root, err := filepath.EvalSymlinks(m.config.RootDir)
+	if err != nil || !within(root, resolved) {
+		return false
+	}
+	rel, _ := filepath.Rel(root, resolved)
+	top, _, nested := strings.Cut(rel, string(filepath.Separator))
+	if !nested || top == string(docID) {
+		return false
+	}
+	_, err = os.Stat(m.config.ManifestPath(top))
+	return err == nil
+}
+
+// within reports whether path lies inside dir, with dir's symbolic links
+// evaluated as path's already are
+func within(dir, path string) bool {
+	if dir == "" {
+		return false
+	}
+	dir, err := filepath.EvalSymlinks(dir)
+	if err != nil {
+		return false
+	}
+	rel, err := filepath.Rel(dir, path)
+	return err == nil && rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator))
+}
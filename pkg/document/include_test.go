@@ -0,0 +1,99 @@
+package document
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/gomcpgo/docgen/pkg/types"
+)
+
+func TestManager_SetIncludeFile(t *testing.T) {
+	manager, tempDir := setupTestManager(t)
+	defer os.RemoveAll(tempDir)
+
+	docID, _ := manager.CreateDocument("Guide", "Test Author", types.DocumentTypeBook)
+	chapterNum, _ := manager.AddChapter(context.Background(), docID, "Setup", nil)
+	manager.AddSection(context.Background(), docID, chapterNum, "Install", "Run the installer.", 1)
+
+	sharedDir := filepath.Join(tempDir, "shared")
+	os.MkdirAll(sharedDir, 0755)
+	os.WriteFile(filepath.Join(sharedDir, "intro.md"), []byte("Shared introduction."), 0644)
+	os.WriteFile(filepath.Join(sharedDir, "install.md"), []byte("Shared install steps."), 0644)
+
+	if _, err := manager.SetIncludeFile(docID, chapterNum, nil, "../shared/intro.md"); err != nil {
+		t.Fatalf("SetIncludeFile() chapter error = %v", err)
+	}
+	resolved, err := manager.SetIncludeFile(docID, chapterNum, types.SectionNumber{1, 1}, filepath.Join(sharedDir, "install.md"))
+	if err != nil {
+		t.Fatalf("SetIncludeFile() section error = %v", err)
+	}
+	if filepath.Base(resolved) != "install.md" {
+		t.Errorf("Resolved path = %s", resolved)
+	}
+
+	content, err := manager.storage.LoadChapterContent(string(docID), int(chapterNum))
+	if err != nil {
+		t.Fatalf("Failed to load chapter content: %v", err)
+	}
+	if !strings.Contains(content, "Shared introduction.") || !strings.Contains(content, "Shared install steps.") {
+		t.Errorf("Included files missing from chapter:\n%s", content)
+	}
+	if strings.Contains(content, "Run the installer.") {
+		t.Errorf("Section content should be replaced by its included file:\n%s", content)
+	}
+
+	// Changes to an included file show up the next time the chapter is built
+	os.WriteFile(filepath.Join(sharedDir, "intro.md"), []byte("Revised introduction."), 0644)
+	manager.RebuildChapterMarkdown(docID, chapterNum)
+	content, _ = manager.storage.LoadChapterContent(string(docID), int(chapterNum))
+	if !strings.Contains(content, "Revised introduction.") {
+		t.Errorf("Chapter should pick up the revised included file:\n%s", content)
+	}
+
+	// Clearing the section include brings back its own content
+	if _, err := manager.SetIncludeFile(docID, chapterNum, types.SectionNumber{1, 1}, ""); err != nil {
+		t.Fatalf("SetIncludeFile() clear error = %v", err)
+	}
+	content, _ = manager.storage.LoadChapterContent(string(docID), int(chapterNum))
+	if !strings.Contains(content, "Run the installer.") {
+		t.Errorf("Section content should be back after clearing the include:\n%s", content)
+	}
+
+	if _, err := manager.SetIncludeFile(docID, chapterNum, nil, "../shared/missing.md"); err == nil {
+		t.Error("Expected an error for a missing file")
+	}
+	if _, err := manager.SetIncludeFile(docID, chapterNum, nil, "/etc/hostname"); err == nil {
+		t.Error("Expected an error for a file outside the allowed directories")
+	}
+	if _, err := manager.SetIncludeFile(docID, chapterNum, types.SectionNumber{1, 9}, "../shared/intro.md"); err == nil {
+		t.Error("Expected an error for a missing section")
+	}
+
+	// Other documents and the server's own files can't be included
+	otherID, _ := manager.CreateDocument("Private", "Test Author", types.DocumentTypeBook)
+	otherChapter, _ := manager.AddChapter(context.Background(), otherID, "Secrets", nil)
+	manager.AddSection(context.Background(), otherID, otherChapter, "Secret", "Confidential.", 1)
+	otherManifest, _ := manager.storage.LoadManifest(string(otherID))
+	otherDir, _ := otherManifest.ChapterDir(otherChapter)
+	os.WriteFile(manager.config.AccessPolicyPath(), []byte("roles: {}\n"), 0644)
+	os.MkdirAll(manager.config.ArchivesPath(), 0755)
+	os.WriteFile(filepath.Join(manager.config.ArchivesPath(), "notes.md"), []byte("Archived."), 0644)
+	for _, path := range []string{
+		"../" + string(otherID) + "/chapters/" + otherDir + "/sections/1.1.md",
+		"../access.yaml",
+		"../archives/notes.md",
+	} {
+		if _, err := manager.SetIncludeFile(docID, chapterNum, nil, path); err == nil || !strings.Contains(err.Error(), "belongs to another document or to the server") {
+			t.Errorf("Expected %s to be refused, got %v", path, err)
+		}
+	}
+
+	// The document's own files can be
+	os.WriteFile(filepath.Join(manager.config.DocumentPath(string(docID)), "notes.md"), []byte("Own notes."), 0644)
+	if _, err := manager.SetIncludeFile(docID, chapterNum, nil, "notes.md"); err != nil {
+		t.Errorf("SetIncludeFile() own file error = %v", err)
+	}
+}
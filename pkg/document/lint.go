@@ -0,0 +1,166 @@
+package document
+
+import (
+	"fmt"
+	"path"
+	"regexp"
+	"strings"
+
+	"github.com/gomcpgo/docgen/pkg/types"
+)
+
+var (
+	// anchorPattern matches figure, table and listing anchors: {#fig-3f9a2c7d},
+	// {#table-1.2 width=50%} or {#lst-1.2 .python}
+	anchorPattern = regexp.MustCompile(`\{#(` + types.FigureIDPattern + `|(?:table|lst)-\d+\.\d+)[^}]*\}`)
+	// crossReferencePattern matches cross-references: @fig-3f9a2c7d, @table-1.2 or @lst-1.2
+	crossReferencePattern = regexp.MustCompile(`@(` + types.FigureIDPattern + `|(?:table|lst)-\d+\.\d+)\b`)
+)
+
+// LintFiguresAndTables checks that every figure, table and code listing
+// registered in a chapter's metadata appears in its content, as an anchor or
+// the rendered image or table, and that every anchor and cross-reference in
+// the content has a registered figure, table or listing behind it. Figures
+// named by their display number rather than their stable ID are reported too.
+// With chapterNum 0 every chapter is checked.
+func (m *Manager) LintFiguresAndTables(docID types.DocumentID, chapterNum types.ChapterNumber) ([]types.ContentLintIssue, error) {
+	if err := docID.Validate(); err != nil {
+		return nil, fmt.Errorf("invalid document ID: %w", err)
+	}
+
+	manifest, err := m.storage.LoadManifest(string(docID))
+	if err != nil {
+		return nil, fmt.Errorf("failed to load manifest: %w", err)
+	}
+
+	// Every registered ID in the document, with the chapter that owns it, and
+	// the stable figure IDs by display number
+	var chapters []*types.Chapter
+	owners := make(map[string]types.ChapterNumber)
+	aliases := make(map[string]string)
+	found := chapterNum == 0
+	for _, chapterRef := range manifest.Document.Chapters {
+		chapter, err := m.storage.LoadChapterMetadata(string(docID), int(chapterRef.Number))
+		if err != nil {
+			return nil, fmt.Errorf("failed to load chapter %d metadata: %w", chapterRef.Number, err)
+		}
+		for _, figure := range chapter.Figures {
+			owners[string(figure.ID)] = chapter.Number
+			if !figure.ID.IsNumbered() {
+				aliases[string(figure.Label())] = string(figure.ID)
+			}
+		}
+		for _, table := range chapter.Tables {
+			owners[string(table.ID)] = chapter.Number
+		}
+		for _, listing := range chapter.Listings {
+			owners[string(listing.ID)] = chapter.Number
+		}
+		if chapterNum == 0 || chapter.Number == chapterNum {
+			chapters = append(chapters, chapter)
+			found = true
+		}
+	}
+	if !found {
+		return nil, fmt.Errorf("chapter %d not found", chapterNum)
+	}
+
+	issues := []types.ContentLintIssue{}
+	for _, chapter := range chapters {
+		issues = append(issues, m.lintChapter(docID, chapter, owners, aliases)...)
+	}
+	return issues, nil
+}
+
+// lintChapter compares one chapter's figures and tables with its section content
+func (m *Manager) lintChapter(docID types.DocumentID, chapter *types.Chapter, owners map[string]types.ChapterNumber, aliases map[string]string) []types.ContentLintIssue {
+	var issues []types.ContentLintIssue
+	issue := func(section, id string, problem types.ContentLintProblem, suggestion string) {
+		issues = append(issues, types.ContentLintIssue{
+			Chapter:    chapter.Number,
+			Section:    section,
+			ID:         id,
+			Problem:    problem,
+			Suggestion: suggestion,
+		})
+	}
+
+	// What the content shows: anchors, image files and text, by section
+	anchors := make(map[string]bool)
+	images := make(map[string]bool)
+	var content strings.Builder
+	for _, section := range chapter.Sections {
+		text, err := m.storage.LoadSectionContent(string(docID), int(chapter.Number), section.Number)
+		if err != nil {
+			continue
+		}
+		content.WriteString(text)
+		content.WriteString("\n")
+		sectionNum := section.Number.String()
+
+		for _, match := range anchorPattern.FindAllStringSubmatch(text, -1) {
+			id := match[1]
+			anchors[id] = true
+			owner, ok := owners[id]
+			if stable, aliased := aliases[id]; !ok && aliased {
+				issue(sectionNum, id, types.LintDisplayAlias, fmt.Sprintf("Replace {#%s} with {#%s}: %s is the figure's display number, which changes when figures are deleted or chapters move", id, stable, id))
+				id = stable
+				anchors[id] = true
+				owner, ok = owners[id]
+			}
+			switch {
+			case !ok && strings.HasPrefix(id, "fig-"):
+				issue(sectionNum, id, types.LintNotRegistered, fmt.Sprintf("Register the image with add_image so it is numbered as a figure, or remove the {#%s} anchor", id))
+			case !ok && strings.HasPrefix(id, "lst-"):
+				issue(sectionNum, id, types.LintNotRegistered, fmt.Sprintf("Register the code with add_listing so it is numbered as a listing, or remove the {#%s} anchor", id))
+			case !ok:
+				issue(sectionNum, id, types.LintNotRegistered, fmt.Sprintf("Register the table in the chapter metadata, or remove the {#%s} anchor", id))
+			case owner != chapter.Number:
+				issue(sectionNum, id, types.LintWrongChapter, fmt.Sprintf("%s is registered in chapter %d; move this section there or use an ID from chapter %d", id, owner, chapter.Number))
+			}
+		}
+		for _, match := range crossReferencePattern.FindAllStringSubmatch(text, -1) {
+			_, ok := owners[match[1]]
+			if stable, aliased := aliases[match[1]]; !ok && aliased {
+				issue(sectionNum, match[1], types.LintDisplayAlias, fmt.Sprintf("Replace @%s with @%s: %s is the figure's display number, which changes when figures are deleted or chapters move", match[1], stable, match[1]))
+			} else if !ok {
+				issue(sectionNum, match[1], types.LintUnknownReference, fmt.Sprintf("Nothing is registered as %s; fix or remove the @%s reference", match[1], match[1]))
+			}
+		}
+		for _, match := range inlineImagePattern.FindAllStringSubmatch(text, -1) {
+			images[path.Base(match[2])] = true
+		}
+	}
+	text := content.String()
+
+	// Figures and tables anchored to a section are placed when the chapter is built
+	sections := make(map[string]bool)
+	for _, section := range chapter.Sections {
+		sections[section.Number.String()] = true
+	}
+	isAnchored := func(anchor *types.FigureAnchor) bool {
+		return anchor != nil && len(anchor.Section) > 1 && sections[renumberSection(anchor.Section, chapter.Number, 0).String()]
+	}
+
+	for _, figure := range chapter.Figures {
+		if anchors[string(figure.ID)] || isAnchored(figure.Anchor) || (!figure.IsGrid() && images[path.Base(figure.ImagePath)]) {
+			continue
+		}
+		issue("", string(figure.ID), types.LintNotInContent, fmt.Sprintf("Insert %s where the figure belongs, anchor it with set_figure_anchor, or delete it with delete_image", figure.Markup()))
+	}
+	for _, table := range chapter.Tables {
+		tableContent := strings.TrimSpace(table.Content)
+		if anchors[string(table.ID)] || isAnchored(table.Anchor) || (tableContent != "" && strings.Contains(text, tableContent)) {
+			continue
+		}
+		issue("", string(table.ID), types.LintNotInContent, fmt.Sprintf("Insert the table followed by \"Table: %s {#%s}\" where it belongs, anchor it with set_figure_anchor, or remove it from the chapter", table.Caption, table.ID))
+	}
+	for _, listing := range chapter.Listings {
+		if anchors[string(listing.ID)] {
+			continue
+		}
+		issue("", string(listing.ID), types.LintNotInContent, fmt.Sprintf("Insert the code as a fenced block opening with %s where the listing belongs, or remove it from the chapter", strings.SplitN(listing.Markup(""), "\n", 2)[0]))
+	}
+
+	return issues
+}
@@ -0,0 +1,84 @@
+package document
+
+import (
+	"context"
+	"os"
+	"testing"
+
+	"github.com/gomcpgo/docgen/pkg/types"
+)
+
+func TestManager_LintFiguresAndTables(t *testing.T) {
+	manager, tempDir := setupTestManager(t)
+	defer os.RemoveAll(tempDir)
+
+	docID, err := manager.CreateDocument("Lint", "Test Author", types.DocumentTypeReport)
+	if err != nil {
+		t.Fatalf("Failed to create document: %v", err)
+	}
+	chapterNum, err := manager.AddChapter(context.Background(), docID, "Results", nil)
+	if err != nil {
+		t.Fatalf("Failed to add chapter: %v", err)
+	}
+	otherNum, err := manager.AddChapter(context.Background(), docID, "Appendix", nil)
+	if err != nil {
+		t.Fatalf("Failed to add chapter: %v", err)
+	}
+
+	// The chart is shown by its image, the unused figure is never shown
+	chart, _ := manager.AddImage(docID, chapterNum, "assets/images/chart.png", "Chart", "here", "", "")
+	unused, _ := manager.AddImage(docID, chapterNum, "assets/images/unused.png", "Unused", "here", "", "")
+	extra, _ := manager.AddImage(docID, otherNum, "assets/images/extra.png", "Extra", "here", "", "")
+
+	// table-1.1 is shown by its content
+	chapter, _ := manager.storage.LoadChapterMetadata(string(docID), int(chapterNum))
+	chapter.Tables = append(chapter.Tables, types.Table{
+		ID:      types.GenerateTableID(chapterNum, 1),
+		Chapter: chapterNum,
+		Caption: "Totals",
+		Content: "| a | b |\n|---|---|\n| 1 | 2 |",
+	})
+	manager.storage.SaveChapterMetadata(string(docID), chapter)
+
+	content := "![Chart](assets/images/chart.png)\n\n| a | b |\n|---|---|\n| 1 | 2 |\n\n" +
+		"![Ghost](ghost.png){#fig-1.9}\n\nSee @table-4.4, @" + string(chart) + " and @fig-1.1.\n\n![Extra](extra.png){#" + string(extra) + "}"
+	if _, err := manager.AddSection(context.Background(), docID, chapterNum, "Findings", content, 1); err != nil {
+		t.Fatalf("Failed to add section: %v", err)
+	}
+
+	issues, err := manager.LintFiguresAndTables(docID, chapterNum)
+	if err != nil {
+		t.Fatalf("LintFiguresAndTables() error = %v", err)
+	}
+	want := map[string]types.ContentLintProblem{
+		"fig-1.9":      types.LintNotRegistered,
+		string(extra):  types.LintWrongChapter,
+		"table-4.4":    types.LintUnknownReference,
+		"fig-1.1":      types.LintDisplayAlias,
+		string(unused): types.LintNotInContent,
+	}
+	if len(issues) != len(want) {
+		t.Fatalf("Expected %d issues, got %+v", len(want), issues)
+	}
+	for _, issue := range issues {
+		if want[issue.ID] != issue.Problem || issue.Suggestion == "" {
+			t.Errorf("Unexpected issue %+v", issue)
+		}
+		if issue.Problem != types.LintNotInContent && issue.Section != "1.1" {
+			t.Errorf("Expected the issue located in section 1.1, got %+v", issue)
+		}
+	}
+
+	// Checking every chapter also reports the appendix figure that is never shown
+	all, err := manager.LintFiguresAndTables(docID, 0)
+	if err != nil {
+		t.Fatalf("LintFiguresAndTables() error = %v", err)
+	}
+	if len(all) != len(want)+1 || all[len(all)-1].ID != string(extra) || all[len(all)-1].Problem != types.LintNotInContent {
+		t.Errorf("Unexpected issues for the whole document %+v", all)
+	}
+
+	if _, err := manager.LintFiguresAndTables(docID, 9); err == nil {
+		t.Error("Expected an error for a missing chapter")
+	}
+}
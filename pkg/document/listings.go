@@ -0,0 +1,51 @@
+package document
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/gomcpgo/docgen/pkg/types"
+)
+
+// AddListing adds a captioned code listing to a chapter, numbered as the next
+// listing in the chapter. It returns the listing ID and the fenced code block
+// that places the listing in a section; exports number and caption the block
+// from the listing's metadata.
+func (m *Manager) AddListing(docID types.DocumentID, chapterNum types.ChapterNumber, caption, language, code string) (types.ListingID, string, error) {
+	if err := docID.Validate(); err != nil {
+		return "", "", fmt.Errorf("invalid document ID: %w", err)
+	}
+	if caption == "" {
+		return "", "", fmt.Errorf("caption is required")
+	}
+	if language != "" {
+		if err := types.ValidateListingLanguage(language); err != nil {
+			return "", "", err
+		}
+	}
+
+	chapter, err := m.storage.LoadChapterMetadata(string(docID), int(chapterNum))
+	if err != nil {
+		return "", "", fmt.Errorf("failed to load chapter: %w", err)
+	}
+
+	now := time.Now()
+	sequence := len(chapter.Listings) + 1
+	listing := types.Listing{
+		ID:        types.GenerateListingID(chapterNum, sequence),
+		Chapter:   chapterNum,
+		Sequence:  sequence,
+		Caption:   caption,
+		Language:  language,
+		CreatedAt: now,
+		UpdatedAt: now,
+	}
+	chapter.Listings = append(chapter.Listings, listing)
+	chapter.UpdatedAt = now
+
+	if err := m.storage.SaveChapterMetadata(string(docID), chapter); err != nil {
+		return "", "", fmt.Errorf("failed to save chapter metadata: %w", err)
+	}
+
+	return listing.ID, listing.Markup(code), nil
+}
@@ -0,0 +1,86 @@
+package document
+
+import (
+	"context"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/gomcpgo/docgen/pkg/types"
+)
+
+func TestManager_AddListing(t *testing.T) {
+	manager, tempDir := setupTestManager(t)
+	defer os.RemoveAll(tempDir)
+
+	docID, _ := manager.CreateDocument("Manual", "Test Author", types.DocumentTypeBook)
+	chapterNum, _ := manager.AddChapter(context.Background(), docID, "Setup", nil)
+	manager.AddListing(docID, chapterNum, "Installing the tool", "bash", "make install")
+
+	listingID, markup, err := manager.AddListing(docID, chapterNum, "Reading a fence", "markdown", "```go\nfmt.Println()\n```")
+	if err != nil {
+		t.Fatalf("AddListing() error = %v", err)
+	}
+	if listingID != "lst-1.2" || markup != "````{#lst-1.2 .markdown}\n```go\nfmt.Println()\n```\n````" {
+		t.Errorf("AddListing() = %s, %q", listingID, markup)
+	}
+	chapter, _ := manager.storage.LoadChapterMetadata(string(docID), int(chapterNum))
+	if len(chapter.Listings) != 2 || chapter.Listings[1].Caption != "Reading a fence" || chapter.Listings[1].Sequence != 2 {
+		t.Errorf("Unexpected listings: %+v", chapter.Listings)
+	}
+
+	// A listing counts as placed once its block is in the content
+	if _, err := manager.AddSection(context.Background(), docID, chapterNum, "Fences", "See @lst-1.2 and @lst-1.3.\n\n"+markup, 1); err != nil {
+		t.Fatalf("AddSection() error = %v", err)
+	}
+	issues, _ := manager.LintFiguresAndTables(docID, chapterNum)
+	problems := make(map[string]types.ContentLintProblem)
+	for _, issue := range issues {
+		problems[issue.ID] = issue.Problem
+	}
+	if _, ok := problems["lst-1.2"]; ok {
+		t.Errorf("Placed listing reported as %s", problems["lst-1.2"])
+	}
+	if problems["lst-1.1"] != types.LintNotInContent || problems["lst-1.3"] != types.LintUnknownReference {
+		t.Errorf("Unexpected lint issues: %+v", issues)
+	}
+
+	for _, tt := range []struct {
+		caption, language, wantErr string
+	}{
+		{"", "go", "caption is required"},
+		{"Caption", "c plus plus", "invalid listing language"},
+	} {
+		if _, _, err := manager.AddListing(docID, chapterNum, tt.caption, tt.language, "code"); err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+			t.Errorf("AddListing() error = %v, want %q", err, tt.wantErr)
+		}
+	}
+}
+
+func TestManager_SplitChapter_MovesListings(t *testing.T) {
+	manager, tempDir := setupTestManager(t)
+	defer os.RemoveAll(tempDir)
+
+	docID, _ := manager.CreateDocument("Manual", "Test Author", types.DocumentTypeBook)
+	chapterNum, _ := manager.AddChapter(context.Background(), docID, "Everything", nil)
+	_, first, _ := manager.AddListing(docID, chapterNum, "First", "go", "a := 1")
+	_, second, _ := manager.AddListing(docID, chapterNum, "Second", "go", "b := 2")
+	manager.AddSection(context.Background(), docID, chapterNum, "Kept", first, 1)
+	manager.AddSection(context.Background(), docID, chapterNum, "Moved", "As @lst-1.2 shows:\n\n"+second, 1)
+
+	result, err := manager.SplitChapter(context.Background(), docID, chapterNum, types.SectionNumber{1, 2}, "")
+	if err != nil {
+		t.Fatalf("SplitChapter() error = %v", err)
+	}
+	if result.IDs["lst-1.2"] != "lst-2.1" {
+		t.Errorf("Expected lst-1.2 renamed to lst-2.1, got %v", result.IDs)
+	}
+	moved, _ := manager.storage.LoadChapterMetadata(string(docID), 2)
+	if len(moved.Listings) != 1 || moved.Listings[0].ID != "lst-2.1" || moved.Listings[0].Caption != "Second" {
+		t.Errorf("Unexpected listings in the new chapter: %+v", moved.Listings)
+	}
+	content, _ := manager.storage.LoadSectionContent(string(docID), 2, types.SectionNumber{2, 1})
+	if !strings.Contains(content, "As @lst-2.1 shows") || !strings.Contains(content, "{#lst-2.1 .go}") {
+		t.Errorf("Expected references to the renamed listing:\n%s", content)
+	}
+}
@@ -0,0 +1,76 @@
+package document
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/gomcpgo/docgen/pkg/types"
+)
+
+// LockDocument makes a document read-only, for a finalized manuscript or
+// while its files are edited directly on disk
+func (m *Manager) LockDocument(docID types.DocumentID, reason string) (*types.DocumentLock, error) {
+	if err := docID.Validate(); err != nil {
+		return nil, fmt.Errorf("invalid document ID: %w", err)
+	}
+
+	manifest, err := m.storage.LoadManifest(string(docID))
+	if err != nil {
+		return nil, fmt.Errorf("failed to load manifest: %w", err)
+	}
+	if manifest.Document.Lock != nil {
+		return nil, fmt.Errorf("document %s is already locked", docID)
+	}
+
+	now := time.Now()
+	manifest.Document.Lock = &types.DocumentLock{LockedAt: now, Reason: reason}
+	manifest.UpdatedAt = now
+
+	if err := m.storage.SaveManifest(string(docID), manifest); err != nil {
+		return nil, fmt.Errorf("failed to update manifest: %w", err)
+	}
+	return manifest.Document.Lock, nil
+}
+
+// UnlockDocument makes a locked document editable again
+func (m *Manager) UnlockDocument(docID types.DocumentID) error {
+	if err := docID.Validate(); err != nil {
+		return fmt.Errorf("invalid document ID: %w", err)
+	}
+
+	manifest, err := m.storage.LoadManifest(string(docID))
+	if err != nil {
+		return fmt.Errorf("failed to load manifest: %w", err)
+	}
+	if manifest.Document.Lock == nil {
+		return fmt.Errorf("document %s is not locked", docID)
+	}
+
+	manifest.Document.Lock = nil
+	manifest.UpdatedAt = time.Now()
+
+	if err := m.storage.SaveManifest(string(docID), manifest); err != nil {
+		return fmt.Errorf("failed to update manifest: %w", err)
+	}
+	return nil
+}
+
+// CheckUnlocked returns an error when a document is locked
+func (m *Manager) CheckUnlocked(docID types.DocumentID) error {
+	if err := docID.Validate(); err != nil {
+		return nil
+	}
+	manifest, err := m.storage.LoadManifest(string(docID))
+	if err != nil || manifest == nil {
+		// Missing documents are reported by the tools themselves
+		return nil
+	}
+	lock := manifest.Document.Lock
+	if lock == nil {
+		return nil
+	}
+	if lock.Reason != "" {
+		return fmt.Errorf("document %s is locked (%s) and read-only; unlock it with unlock_document to change it", docID, lock.Reason)
+	}
+	return fmt.Errorf("document %s is locked and read-only; unlock it with unlock_document to change it", docID)
+}
@@ -0,0 +1,44 @@
+package document
+
+import (
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/gomcpgo/docgen/pkg/types"
+)
+
+func TestManager_LockDocument(t *testing.T) {
+	manager, tempDir := setupTestManager(t)
+	defer os.RemoveAll(tempDir)
+
+	docID, _ := manager.CreateDocument("Final Draft", "Test Author", types.DocumentTypeBook)
+	if err := manager.CheckUnlocked(docID); err != nil {
+		t.Errorf("CheckUnlocked() error = %v on a new document", err)
+	}
+
+	lock, err := manager.LockDocument(docID, "sent to the printer")
+	if err != nil {
+		t.Fatalf("LockDocument() error = %v", err)
+	}
+	if lock.Reason != "sent to the printer" || lock.LockedAt.IsZero() {
+		t.Errorf("Unexpected lock %+v", lock)
+	}
+	err = manager.CheckUnlocked(docID)
+	if err == nil || !strings.Contains(err.Error(), "locked (sent to the printer)") {
+		t.Errorf("CheckUnlocked() error = %v, want the lock reason", err)
+	}
+	if _, err := manager.LockDocument(docID, ""); err == nil || !strings.Contains(err.Error(), "already locked") {
+		t.Errorf("LockDocument() error = %v on a locked document", err)
+	}
+
+	if err := manager.UnlockDocument(docID); err != nil {
+		t.Fatalf("UnlockDocument() error = %v", err)
+	}
+	if err := manager.CheckUnlocked(docID); err != nil {
+		t.Errorf("CheckUnlocked() error = %v after unlocking", err)
+	}
+	if err := manager.UnlockDocument(docID); err == nil || !strings.Contains(err.Error(), "not locked") {
+		t.Errorf("UnlockDocument() error = %v on an unlocked document", err)
+	}
+}
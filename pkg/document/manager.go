@@ -1,9 +1,9 @@
 package document
 
 import (
+	"context"
 	"fmt"
 	"regexp"
-	"strconv"
 	"strings"
 	"time"
 
@@ -28,12 +28,20 @@ func NewManager(cfg *config.Config, stor storage.Storage) *Manager {
 
 // CreateDocument creates a new document with the given parameters
 func (m *Manager) CreateDocument(title, author string, docType types.DocumentType) (types.DocumentID, error) {
+	return m.CreateDocumentWithMetadata(title, author, docType, types.DocumentMetadata{})
+}
+
+// CreateDocumentWithMetadata creates a new document with optional bibliographic metadata
+func (m *Manager) CreateDocumentWithMetadata(title, author string, docType types.DocumentType, metadata types.DocumentMetadata) (types.DocumentID, error) {
 	if title == "" {
 		return "", fmt.Errorf("document title is required")
 	}
 	if author == "" {
 		return "", fmt.Errorf("document author is required")
 	}
+	if err := metadata.Validate(); err != nil {
+		return "", err
+	}
 
 	// Generate a unique document ID
 	docID := types.DocumentID(generateDocumentID(title))
@@ -62,11 +70,13 @@ func (m *Manager) CreateDocument(title, author string, docType types.DocumentTyp
 	doc := &types.Document{
 		ID:        docID,
 		Title:     title,
-		Author:    author,
+		Authors:   types.AuthorList{{Name: author}},
 		Type:      docType,
 		CreatedAt: now,
 		UpdatedAt: now,
 		Chapters:  []types.Chapter{},
+
+		DocumentMetadata: metadata,
 	}
 
 	if err := m.storage.CreateDocumentStructure(doc); err != nil {
@@ -106,6 +116,12 @@ func (m *Manager) GetDocumentStructure(docID types.DocumentID) (*types.Manifest,
 		manifest.Document.Chapters[i].Sections = chapterMetadata.Sections
 		manifest.Document.Chapters[i].Figures = chapterMetadata.Figures
 		manifest.Document.Chapters[i].Tables = chapterMetadata.Tables
+		manifest.Document.Chapters[i].Listings = chapterMetadata.Listings
+		manifest.Document.Chapters[i].PandocOptions = chapterMetadata.PandocOptions
+		manifest.Document.Chapters[i].IncludeFile = chapterMetadata.IncludeFile
+		manifest.Document.Chapters[i].FrontMatter = chapterMetadata.FrontMatter
+		manifest.Document.Chapters[i].Status = chapterMetadata.Status
+		manifest.Document.Chapters[i].Epigraph = chapterMetadata.Epigraph
 	}
 
 	return manifest, nil
@@ -132,8 +148,10 @@ func (m *Manager) DeleteDocument(docID types.DocumentID) error {
 	return nil
 }
 
-// AddChapter adds a new chapter to the document
-func (m *Manager) AddChapter(docID types.DocumentID, title string, position *int) (types.ChapterNumber, error) {
+// AddChapter adds a new chapter to the document, at the end or at position.
+// Chapters from position on move up a number, and their section numbers and
+// figure and table IDs, along with references to them, change to match.
+func (m *Manager) AddChapter(ctx context.Context, docID types.DocumentID, title string, position *int) (types.ChapterNumber, error) {
 	if err := docID.Validate(); err != nil {
 		return 0, fmt.Errorf("invalid document ID: %w", err)
 	}
@@ -147,17 +165,34 @@ func (m *Manager) AddChapter(docID types.DocumentID, title string, position *int
 		return 0, fmt.Errorf("failed to load document manifest: %w", err)
 	}
 
-	// Determine chapter number
-	var chapterNum types.ChapterNumber
-	if position != nil {
-		// Insert at specific position (renumber subsequent chapters)
+	// Determine chapter number; a position after the last chapter adds at the end
+	chapterNum := types.ChapterNumber(len(manifest.Document.Chapters) + 1)
+	var r *restructure
+	if position != nil && *position != int(chapterNum) {
+		if *position < 1 || *position > int(chapterNum) {
+			return 0, fmt.Errorf("position must be between 1 and %d", chapterNum)
+		}
+		if err := m.SyncDocument(ctx, docID); err != nil {
+			return 0, err
+		}
+
+		// Insert at specific position: later chapters move up a number and are
+		// relabelled, with their sections, figures and tables, to match
 		chapterNum = types.ChapterNumber(*position)
+		r = newRestructure(docID, chapterNum)
 		if err := m.renumberChapters(string(docID), manifest, int(chapterNum), 1); err != nil {
 			return 0, fmt.Errorf("failed to renumber chapters: %w", err)
 		}
-	} else {
-		// Add at the end
-		chapterNum = types.ChapterNumber(len(manifest.Document.Chapters) + 1)
+		for i := range manifest.Document.Chapters {
+			later := &manifest.Document.Chapters[i]
+			if later.Number >= chapterNum {
+				later.Number++
+				if err := m.relabelChapter(r, manifest, later.Number); err != nil {
+					return 0, err
+				}
+			}
+		}
+		manifest.Document.InsertChapterInParts(chapterNum)
 	}
 
 	// Create chapter
@@ -173,13 +208,24 @@ func (m *Manager) AddChapter(docID types.DocumentID, title string, position *int
 		UpdatedAt: now,
 	}
 
-	// Create chapter structure
+	// Create chapter structure in a directory of its own
+	if err := m.placeChapter(string(docID), manifest, chapterNum); err != nil {
+		return 0, err
+	}
 	if err := m.storage.CreateChapterStructure(string(docID), chapter); err != nil {
 		return 0, fmt.Errorf("failed to create chapter structure: %w", err)
 	}
 
 	// Update manifest
 	manifest.Document.Chapters = append(manifest.Document.Chapters, *chapter)
+	if r != nil {
+		// Put the chapter in order, update references to renamed figures and
+		// tables and rebuild the chapters whose numbers changed
+		if err := m.finishRestructure(r, manifest); err != nil {
+			return 0, err
+		}
+		return chapterNum, nil
+	}
 	manifest.ChapterCounts[chapterNum] = types.ChapterCount{
 		Sections: 0,
 		Figures:  0,
@@ -269,6 +315,28 @@ func (m *Manager) UpdateChapterMetadata(docID types.DocumentID, chapterNum types
 	return nil
 }
 
+// ConfigureChapter sets chapter-specific export options. Passing nil clears them.
+func (m *Manager) ConfigureChapter(docID types.DocumentID, chapterNum types.ChapterNumber, options *types.ChapterPandocOptions) error {
+	if err := docID.Validate(); err != nil {
+		return fmt.Errorf("invalid document ID: %w", err)
+	}
+
+	// Load current chapter
+	chapter, err := m.storage.LoadChapterMetadata(string(docID), int(chapterNum))
+	if err != nil {
+		return fmt.Errorf("failed to load chapter metadata: %w", err)
+	}
+
+	chapter.PandocOptions = options
+	chapter.UpdatedAt = time.Now()
+
+	if err := m.storage.SaveChapterMetadata(string(docID), chapter); err != nil {
+		return fmt.Errorf("failed to save chapter metadata: %w", err)
+	}
+
+	return nil
+}
+
 // DeleteChapter removes a chapter and renumbers subsequent chapters
 func (m *Manager) DeleteChapter(docID types.DocumentID, chapterNum types.ChapterNumber) error {
 	if err := docID.Validate(); err != nil {
@@ -310,6 +378,7 @@ func (m *Manager) DeleteChapter(docID types.DocumentID, chapterNum types.Chapter
 		}
 	}
 	manifest.Document.Chapters = updatedChapters
+	manifest.Document.RemoveChapterFromParts(chapterNum)
 
 	// Update chapter counts
 	updatedCounts := make(map[types.ChapterNumber]types.ChapterCount)
@@ -332,8 +401,8 @@ func (m *Manager) DeleteChapter(docID types.DocumentID, chapterNum types.Chapter
 	return nil
 }
 
-// ConfigureDocument updates document configuration (style, pandoc options)
-func (m *Manager) ConfigureDocument(docID types.DocumentID, styleUpdates *types.Style, pandocOptions *types.PandocConfig) error {
+// ConfigureDocument updates document configuration (style, pandoc options, metadata)
+func (m *Manager) ConfigureDocument(docID types.DocumentID, styleUpdates *types.Style, pandocOptions *types.PandocConfig, metadataUpdates *types.DocumentMetadataUpdate) error {
 	if err := docID.Validate(); err != nil {
 		return fmt.Errorf("invalid document ID: %w", err)
 	}
@@ -348,23 +417,90 @@ func (m *Manager) ConfigureDocument(docID types.DocumentID, styleUpdates *types.
 
 	// Update style if provided
 	if styleUpdates != nil {
+		if err := styleUpdates.NumberingStyle.Validate(); err != nil {
+			return err
+		}
+		if err := styleUpdates.Typography.Validate(); err != nil {
+			return err
+		}
+		if err := styleUpdates.ChapterHeading.Validate(); err != nil {
+			return err
+		}
+		if err := styleUpdates.Slides.Validate(); err != nil {
+			return err
+		}
+
+		// Template files are read at export time and must stay inside the allowed directories
+		docDir := m.config.DocumentPath(string(docID))
+		if styleUpdates.ReferenceDocx != "" {
+			if _, err := m.config.ResolvePath(styleUpdates.ReferenceDocx, docDir); err != nil {
+				return fmt.Errorf("invalid reference_docx: %w", err)
+			}
+		}
+		if styleUpdates.StyleCSS != "" {
+			if _, err := m.config.ResolvePath(styleUpdates.StyleCSS, docDir); err != nil {
+				return fmt.Errorf("invalid style_css: %w", err)
+			}
+		}
+		if styleUpdates.Slides.ReferencePPTX != "" {
+			if _, err := m.config.ResolvePath(styleUpdates.Slides.ReferencePPTX, docDir); err != nil {
+				return fmt.Errorf("invalid reference_pptx: %w", err)
+			}
+		}
 		if err := m.storage.SaveStyle(string(docID), styleUpdates); err != nil {
 			return fmt.Errorf("failed to save style: %w", err)
 		}
+
+		// Chapter markdown carries the chapter and section numbers
+		manifest, err := m.storage.LoadManifest(string(docID))
+		if err != nil {
+			return fmt.Errorf("failed to load manifest: %w", err)
+		}
+		for _, chapter := range manifest.Document.Chapters {
+			if err := m.RebuildChapterMarkdown(docID, chapter.Number); err != nil {
+				return fmt.Errorf("failed to rebuild chapter %d markdown: %w", chapter.Number, err)
+			}
+		}
 	}
 
 	// Update pandoc config if provided
 	if pandocOptions != nil {
+		// The markdown profile is configured separately and kept unless replaced
+		if pandocOptions.Markdown == nil {
+			if existing, err := m.storage.LoadPandocConfig(string(docID)); err == nil {
+				pandocOptions.Markdown = existing.Markdown
+			}
+		}
 		if err := m.storage.SavePandocConfig(string(docID), pandocOptions); err != nil {
 			return fmt.Errorf("failed to save pandoc config: %w", err)
 		}
 	}
 
+	// Update document metadata if provided
+	if metadataUpdates != nil {
+		manifest, err := m.storage.LoadManifest(string(docID))
+		if err != nil {
+			return fmt.Errorf("failed to load manifest: %w", err)
+		}
+
+		manifest.Document.DocumentMetadata.Merge(*metadataUpdates)
+		if err := manifest.Document.DocumentMetadata.Validate(); err != nil {
+			return err
+		}
+		now := time.Now()
+		manifest.Document.UpdatedAt = now
+		manifest.UpdatedAt = now
+
+		if err := m.storage.SaveManifest(string(docID), manifest); err != nil {
+			return fmt.Errorf("failed to update manifest: %w", err)
+		}
+	}
+
 	return nil
 }
 
 // AddSection adds a new section to a chapter
-func (m *Manager) AddSection(docID types.DocumentID, chapterNum types.ChapterNumber, title, content string, level int) (types.SectionNumber, error) {
+func (m *Manager) AddSection(ctx context.Context, docID types.DocumentID, chapterNum types.ChapterNumber, title, content string, level int) (types.SectionNumber, error) {
 	if err := docID.Validate(); err != nil {
 		return nil, fmt.Errorf("invalid document ID: %w", err)
 	}
@@ -384,6 +520,10 @@ func (m *Manager) AddSection(docID types.DocumentID, chapterNum types.ChapterNum
 		return nil, fmt.Errorf("failed to load chapter: %w", err)
 	}
 
+	if err := m.CheckStorageQuota(ctx, docID, int64(len(content))); err != nil {
+		return nil, err
+	}
+
 	// Generate next section number
 	sectionNum, err := m.generateNextSectionNumber(chapter, level)
 	if err != nil {
@@ -395,7 +535,6 @@ func (m *Manager) AddSection(docID types.DocumentID, chapterNum types.ChapterNum
 	section := types.Section{
 		Number:    sectionNum,
 		Title:     title,
-		Content:   content,
 		Level:     level,
 		CreatedAt: now,
 		UpdatedAt: now,
@@ -406,8 +545,7 @@ func (m *Manager) AddSection(docID types.DocumentID, chapterNum types.ChapterNum
 		return nil, fmt.Errorf("failed to save section content: %w", err)
 	}
 
-	// Add section to chapter metadata (without content)
-	section.Content = "" // Don't store content in metadata
+	// Add section to chapter metadata
 	chapter.Sections = append(chapter.Sections, section)
 	chapter.UpdatedAt = now
 
@@ -424,6 +562,85 @@ func (m *Manager) AddSection(docID types.DocumentID, chapterNum types.ChapterNum
 	return sectionNum, nil
 }
 
+// AddSections adds sections to the end of a chapter in order, numbering each
+// as AddSection would after the ones before it. Every section is checked
+// before anything is written, and the chapter metadata is saved and its
+// markdown rebuilt once, so a failure leaves the chapter as it was. It returns
+// the new section numbers.
+func (m *Manager) AddSections(ctx context.Context, docID types.DocumentID, chapterNum types.ChapterNumber, sections []types.NewSection) ([]types.SectionNumber, error) {
+	if err := docID.Validate(); err != nil {
+		return nil, fmt.Errorf("invalid document ID: %w", err)
+	}
+	if len(sections) == 0 {
+		return nil, fmt.Errorf("no sections to add")
+	}
+
+	var size int64
+	for i, section := range sections {
+		if section.Title == "" {
+			return nil, fmt.Errorf("section %d has no title", i+1)
+		}
+		if section.Content == "" {
+			return nil, fmt.Errorf("section %d (%q) has no content", i+1, section.Title)
+		}
+		if section.Level < 1 || section.Level > maxSectionLevel {
+			return nil, fmt.Errorf("section %d (%q): section level must be between 1 and %d", i+1, section.Title, maxSectionLevel)
+		}
+		size += int64(len(section.Content))
+	}
+
+	chapter, err := m.storage.LoadChapterMetadata(string(docID), int(chapterNum))
+	if err != nil {
+		return nil, fmt.Errorf("failed to load chapter: %w", err)
+	}
+	if err := m.CheckStorageQuota(ctx, docID, size); err != nil {
+		return nil, err
+	}
+
+	// Each section is numbered after the ones added before it
+	now := time.Now()
+	numbers := make([]types.SectionNumber, 0, len(sections))
+	for _, section := range sections {
+		sectionNum, err := m.generateNextSectionNumber(chapter, section.Level)
+		if err != nil {
+			return nil, fmt.Errorf("failed to generate section number for %q: %w", section.Title, err)
+		}
+		chapter.Sections = append(chapter.Sections, types.Section{
+			Number:    sectionNum,
+			Title:     section.Title,
+			Level:     section.Level,
+			CreatedAt: now,
+			UpdatedAt: now,
+		})
+		numbers = append(numbers, sectionNum)
+	}
+
+	// Section files written before a failure are removed again
+	removeWritten := func(written []types.SectionNumber) {
+		for _, sectionNum := range written {
+			m.storage.DeleteSectionFile(string(docID), int(chapterNum), sectionNum)
+		}
+	}
+	for i, sectionNum := range numbers {
+		if err := m.storage.SaveSectionContent(string(docID), int(chapterNum), sectionNum, sections[i].Content); err != nil {
+			removeWritten(numbers[:i])
+			return nil, fmt.Errorf("failed to save section %s content: %w", sectionNum.String(), err)
+		}
+	}
+
+	chapter.UpdatedAt = now
+	if err := m.storage.SaveChapterMetadata(string(docID), chapter); err != nil {
+		removeWritten(numbers)
+		return nil, fmt.Errorf("failed to save chapter metadata: %w", err)
+	}
+
+	if err := m.RebuildChapterMarkdown(docID, chapterNum); err != nil {
+		return nil, fmt.Errorf("failed to rebuild chapter markdown: %w", err)
+	}
+
+	return numbers, nil
+}
+
 // generateNextSectionNumber generates the next section number for the given level
 func (m *Manager) generateNextSectionNumber(chapter *types.Chapter, level int) (types.SectionNumber, error) {
 	chapterNum := int(chapter.Number)
@@ -496,7 +713,7 @@ func (m *Manager) generateNextSectionNumber(chapter *types.Chapter, level int) (
 }
 
 // UpdateSection updates the content of an existing section
-func (m *Manager) UpdateSection(docID types.DocumentID, chapterNum types.ChapterNumber, sectionNum types.SectionNumber, content string) error {
+func (m *Manager) UpdateSection(ctx context.Context, docID types.DocumentID, chapterNum types.ChapterNumber, sectionNum types.SectionNumber, content string) error {
 	if err := docID.Validate(); err != nil {
 		return fmt.Errorf("invalid document ID: %w", err)
 	}
@@ -514,6 +731,12 @@ func (m *Manager) UpdateSection(docID types.DocumentID, chapterNum types.Chapter
 	found := false
 	for i, section := range chapter.Sections {
 		if m.sectionNumbersEqual(section.Number, sectionNum) {
+			// Only growth counts toward the storage quota
+			previous, _ := m.storage.LoadSectionContent(string(docID), int(chapterNum), sectionNum)
+			if err := m.CheckStorageQuota(ctx, docID, int64(len(content)-len(previous))); err != nil {
+				return err
+			}
+
 			// Update section content in individual file
 			if err := m.storage.SaveSectionContent(string(docID), int(chapterNum), sectionNum, content); err != nil {
 				return fmt.Errorf("failed to save section content: %w", err)
@@ -592,6 +815,7 @@ func (m *Manager) DeleteSection(docID types.DocumentID, chapterNum types.Chapter
 
 	// Renumber sections if needed (sections with same level and deeper)
 	m.renumberSectionsAfterDeletion(chapter, sectionNum)
+	shiftAnchorsAfterDeletion(chapter, sectionNum)
 
 	// Save updated chapter metadata
 	if err := m.storage.SaveChapterMetadata(string(docID), chapter); err != nil {
@@ -637,81 +861,155 @@ func (m *Manager) renumberSectionsAfterDeletion(chapter *types.Chapter, deletedS
 	}
 }
 
-// MoveChapter moves a chapter from one position to another and renumbers accordingly
-func (m *Manager) MoveChapter(docID types.DocumentID, fromPos, toPos types.ChapterNumber) error {
+// MoveChapter moves a chapter from one position to another. Every chapter whose
+// number changes is relabelled: its section numbers and figure and table IDs
+// follow the new number, and references to the renamed IDs are updated. Chapter
+// directories stay where they are; only the chapter order changes. Parts keep
+// their chapter ranges.
+func (m *Manager) MoveChapter(ctx context.Context, docID types.DocumentID, fromPos, toPos types.ChapterNumber) (*RestructureResult, error) {
 	if err := docID.Validate(); err != nil {
-		return fmt.Errorf("invalid document ID: %w", err)
+		return nil, fmt.Errorf("invalid document ID: %w", err)
+	}
+
+	if err := m.SyncDocument(ctx, docID); err != nil {
+		return nil, err
 	}
 
 	// Load the document manifest
 	manifest, err := m.storage.LoadManifest(string(docID))
 	if err != nil {
-		return fmt.Errorf("failed to load document manifest: %w", err)
+		return nil, fmt.Errorf("failed to load document manifest: %w", err)
 	}
 
 	// Validate positions
-	if int(fromPos) > len(manifest.Document.Chapters) || int(toPos) > len(manifest.Document.Chapters) {
-		return fmt.Errorf("invalid position: document has %d chapters", len(manifest.Document.Chapters))
+	count := len(manifest.Document.Chapters)
+	if fromPos < 1 || toPos < 1 || int(fromPos) > count || int(toPos) > count {
+		return nil, fmt.Errorf("invalid position: document has %d chapters", count)
 	}
 
-	// Convert to 0-based indexing
-	fromIdx := int(fromPos) - 1
-	toIdx := int(toPos) - 1
-
-	if fromIdx == toIdx {
-		return nil // No movement needed
+	r := newRestructure(docID, toPos)
+	if fromPos == toPos {
+		return r.result, nil // No movement needed
 	}
 
-	// Store the chapter to move
-	chapterToMove := manifest.Document.Chapters[fromIdx]
-
-	// Remove the chapter from its current position
-	manifest.Document.Chapters = append(manifest.Document.Chapters[:fromIdx], manifest.Document.Chapters[fromIdx+1:]...)
-
-	// Insert the chapter at the new position
-	if toIdx >= len(manifest.Document.Chapters) {
-		// Insert at the end
-		manifest.Document.Chapters = append(manifest.Document.Chapters, chapterToMove)
-	} else {
-		// Insert at the specified position
-		manifest.Document.Chapters = append(manifest.Document.Chapters[:toIdx], append([]types.Chapter{chapterToMove}, manifest.Document.Chapters[toIdx:]...)...)
+	// Work out the new order, then move the chapters and their directories to it
+	manifest.EnsureChapterOrder()
+	var newOrder []int
+	for i := 0; i < count; i++ {
+		if i != int(fromPos)-1 {
+			newOrder = append(newOrder, i)
+		}
+	}
+	newOrder = append(newOrder[:toPos-1], append([]int{int(fromPos) - 1}, newOrder[toPos-1:]...)...)
+	chapters := make([]types.Chapter, count)
+	dirs := make([]string, count)
+	for i, old := range newOrder {
+		chapters[i] = manifest.Document.Chapters[old]
+		dirs[i] = manifest.ChapterOrder[old]
+	}
+	manifest.Document.Chapters = chapters
+	manifest.ChapterOrder = dirs
+	if err := m.storage.SaveManifest(string(docID), manifest); err != nil {
+		return nil, fmt.Errorf("failed to save chapter order: %w", err)
 	}
 
-	// Renumber all chapters and update filesystem
-	// For move operation, we need to renumber all chapters from position 1
-	if err := m.renumberChapters(string(docID), manifest, 0, 0); err != nil {
-		return fmt.Errorf("failed to renumber chapters: %w", err)
+	// Relabel the chapters between the two positions, which all changed number
+	for i := range manifest.Document.Chapters {
+		chapter := &manifest.Document.Chapters[i]
+		newNum := types.ChapterNumber(i + 1)
+		if chapter.Number == newNum {
+			continue
+		}
+		chapter.Number = newNum
+		if err := m.relabelChapter(r, manifest, newNum); err != nil {
+			return nil, err
+		}
 	}
 
-	// Update manifest
-	manifest.UpdatedAt = time.Now()
-	if err := m.storage.SaveManifest(string(docID), manifest); err != nil {
-		return fmt.Errorf("failed to save manifest: %w", err)
+	if err := m.finishRestructure(r, manifest); err != nil {
+		return nil, err
 	}
+	return r.result, nil
+}
 
-	return nil
+// AddImage adds a new image figure to a chapter. An empty width keeps the
+// image's natural size and an empty alignment centers it.
+func (m *Manager) AddImage(docID types.DocumentID, chapterNum types.ChapterNumber, imagePath, caption, position, width, alignment string) (types.FigureID, error) {
+	return m.addImage(docID, chapterNum, types.NewFigureID(), imagePath, caption, position, width, alignment)
 }
 
-// AddImage adds a new image figure to a chapter
-func (m *Manager) AddImage(docID types.DocumentID, chapterNum types.ChapterNumber, imagePath, caption, position string) (types.FigureID, error) {
+// addImage adds an image figure with the given stable ID, such as one an
+// imported image's asset was named after
+func (m *Manager) addImage(docID types.DocumentID, chapterNum types.ChapterNumber, figureID types.FigureID, imagePath, caption, position, width, alignment string) (types.FigureID, error) {
 	if err := docID.Validate(); err != nil {
 		return "", fmt.Errorf("invalid document ID: %w", err)
 	}
 	if imagePath == "" {
 		return "", fmt.Errorf("image path is required")
 	}
+
+	// Only files inside the allowed directories may be referenced
+	if _, err := m.config.ResolvePath(imagePath, m.config.DocumentPath(string(docID))); err != nil {
+		return "", fmt.Errorf("invalid image path: %w", err)
+	}
+
+	// Images added without a caption get a placeholder flagged as a TODO
+	captionTODO := false
 	if caption == "" {
-		return "", fmt.Errorf("caption is required")
+		caption = types.PlaceholderCaption(imagePath)
+		captionTODO = true
+	}
+
+	if err := validateImagePosition(position); err != nil {
+		return "", err
+	}
+	if width != "" {
+		if err := types.ValidateImageWidth(width); err != nil {
+			return "", err
+		}
+	}
+	if alignment == "" {
+		alignment = string(types.AlignCenter)
+	}
+	if err := validateImageAlignment(alignment); err != nil {
+		return "", err
 	}
 
-	// Validate position
+	return m.registerFigure(docID, chapterNum, types.Figure{
+		ID:        figureID,
+		Caption:   caption,
+		ImagePath: imagePath,
+		Position:  types.ImagePosition(position),
+		Alignment: types.ImageAlignment(alignment),
+		Width:     width,
+
+		CaptionTODO: captionTODO,
+	})
+}
+
+// validateImagePosition checks a figure's placement
+func validateImagePosition(position string) error {
 	validPositions := map[string]bool{
 		"here": true, "top": true, "bottom": true, "page": true, "float": true,
 	}
 	if !validPositions[position] {
-		return "", fmt.Errorf("invalid position: %s (must be one of: here, top, bottom, page, float)", position)
+		return fmt.Errorf("invalid position: %s (must be one of: here, top, bottom, page, float)", position)
 	}
+	return nil
+}
+
+// validateImageAlignment checks a figure's alignment
+func validateImageAlignment(alignment string) error {
+	switch types.ImageAlignment(alignment) {
+	case types.AlignLeft, types.AlignCenter, types.AlignRight:
+		return nil
+	}
+	return fmt.Errorf("invalid alignment: %s (must be one of: left, center, right)", alignment)
+}
 
+// registerFigure numbers a figure as the next one in its chapter and saves it
+// in the chapter metadata. Figures without an ID get a new stable one.
+func (m *Manager) registerFigure(docID types.DocumentID, chapterNum types.ChapterNumber, figure types.Figure) (types.FigureID, error) {
 	// Load current chapter
 	chapter, err := m.storage.LoadChapterMetadata(string(docID), int(chapterNum))
 	if err != nil {
@@ -721,23 +1019,17 @@ func (m *Manager) AddImage(docID types.DocumentID, chapterNum types.ChapterNumbe
 	// Generate next figure sequence number for this chapter
 	sequence := len(chapter.Figures) + 1
 
-	// Generate figure ID
-	figureID := types.FigureID(fmt.Sprintf("fig-%d.%d", chapterNum, sequence))
+	// The ID stays the same whatever number the figure later has
+	if figure.ID == "" {
+		figure.ID = types.NewFigureID()
+	}
+	figureID := figure.ID
 
-	// Create new figure
 	now := time.Now()
-	figure := types.Figure{
-		ID:        figureID,
-		Chapter:   chapterNum,
-		Sequence:  sequence,
-		Caption:   caption,
-		ImagePath: imagePath,
-		Position:  types.ImagePosition(position),
-		Alignment: types.AlignCenter, // Default alignment
-		Width:     "",                // Will be determined automatically
-		CreatedAt: now,
-		UpdatedAt: now,
-	}
+	figure.Chapter = chapterNum
+	figure.Sequence = sequence
+	figure.CreatedAt = now
+	figure.UpdatedAt = now
 
 	// Add figure to chapter
 	chapter.Figures = append(chapter.Figures, figure)
@@ -760,10 +1052,10 @@ func (m *Manager) UpdateImageCaption(docID types.DocumentID, figureID types.Figu
 		return fmt.Errorf("caption is required")
 	}
 
-	// Parse figure ID to get chapter number
-	chapterNum, err := m.parseFigureIDChapter(figureID)
+	// Find the chapter holding the figure
+	chapterNum, figureID, err := m.findFigure(docID, figureID)
 	if err != nil {
-		return fmt.Errorf("invalid figure ID: %w", err)
+		return err
 	}
 
 	// Load current chapter
@@ -777,6 +1069,7 @@ func (m *Manager) UpdateImageCaption(docID types.DocumentID, figureID types.Figu
 	for i, figure := range chapter.Figures {
 		if figure.ID == figureID {
 			chapter.Figures[i].Caption = newCaption
+			chapter.Figures[i].CaptionTODO = false
 			chapter.Figures[i].UpdatedAt = time.Now()
 			chapter.UpdatedAt = time.Now()
 			found = true
@@ -802,10 +1095,10 @@ func (m *Manager) DeleteImage(docID types.DocumentID, figureID types.FigureID) e
 		return fmt.Errorf("invalid document ID: %w", err)
 	}
 
-	// Parse figure ID to get chapter number
-	chapterNum, err := m.parseFigureIDChapter(figureID)
+	// Find the chapter holding the figure
+	chapterNum, figureID, err := m.findFigure(docID, figureID)
 	if err != nil {
-		return fmt.Errorf("invalid figure ID: %w", err)
+		return err
 	}
 
 	// Load current chapter
@@ -836,8 +1129,10 @@ func (m *Manager) DeleteImage(docID types.DocumentID, figureID types.FigureID) e
 	for i := range chapter.Figures {
 		if chapter.Figures[i].Sequence > deletedSequence {
 			chapter.Figures[i].Sequence--
-			// Update the figure ID to reflect new sequence
-			chapter.Figures[i].ID = types.FigureID(fmt.Sprintf("fig-%d.%d", chapterNum, chapter.Figures[i].Sequence))
+			// Numbered IDs follow the new sequence; stable IDs stay as they are
+			if chapter.Figures[i].ID.IsNumbered() {
+				chapter.Figures[i].ID = chapter.Figures[i].Label()
+			}
 		}
 	}
 
@@ -849,24 +1144,46 @@ func (m *Manager) DeleteImage(docID types.DocumentID, figureID types.FigureID) e
 	return nil
 }
 
-// parseFigureIDChapter extracts the chapter number from a figure ID (e.g., "fig-1.2" -> 1)
-func (m *Manager) parseFigureIDChapter(figureID types.FigureID) (types.ChapterNumber, error) {
-	idStr := string(figureID)
-	if !strings.HasPrefix(idStr, "fig-") {
-		return 0, fmt.Errorf("figure ID must start with 'fig-'")
+// findFigure returns the chapter holding a figure and the figure's ID, given
+// its ID or its display number (fig-1.2 for the second figure of chapter 1)
+func (m *Manager) findFigure(docID types.DocumentID, figureID types.FigureID) (types.ChapterNumber, types.FigureID, error) {
+	if err := figureID.Validate(); err != nil {
+		return 0, "", fmt.Errorf("invalid figure ID: %w", err)
 	}
 
-	parts := strings.Split(idStr[4:], ".")
-	if len(parts) != 2 {
-		return 0, fmt.Errorf("figure ID must be in format 'fig-X.Y'")
+	// A display number names its chapter; a stable ID may be in any of them
+	var chapters []types.ChapterNumber
+	if chapterNum, _, err := parseFigureID(figureID); err == nil {
+		chapters = append(chapters, chapterNum)
+	} else {
+		manifest, err := m.storage.LoadManifest(string(docID))
+		if err != nil {
+			return 0, "", fmt.Errorf("failed to load manifest: %w", err)
+		}
+		for _, chapter := range manifest.Document.Chapters {
+			chapters = append(chapters, chapter.Number)
+		}
 	}
 
-	chapterNum, err := strconv.Atoi(parts[0])
-	if err != nil {
-		return 0, fmt.Errorf("invalid chapter number in figure ID: %w", err)
+	for _, chapterNum := range chapters {
+		chapter, err := m.storage.LoadChapterMetadata(string(docID), int(chapterNum))
+		if err != nil {
+			continue
+		}
+		var aliased types.FigureID
+		for _, figure := range chapter.Figures {
+			if figure.ID == figureID {
+				return chapterNum, figure.ID, nil
+			}
+			if figure.Label() == figureID {
+				aliased = figure.ID
+			}
+		}
+		if aliased != "" {
+			return chapterNum, aliased, nil
+		}
 	}
-
-	return types.ChapterNumber(chapterNum), nil
+	return 0, "", fmt.Errorf("figure %s not found", figureID)
 }
 
 // RebuildChapterMarkdown compiles all section files into chapter.md
@@ -875,42 +1192,123 @@ func (m *Manager) RebuildChapterMarkdown(docID types.DocumentID, chapterNum type
 		return fmt.Errorf("invalid document ID: %w", err)
 	}
 
+	content, _, err := m.compileChapter(docID, chapterNum)
+	if err != nil {
+		return err
+	}
+
+	// Save compiled content to chapter.md
+	if err := m.storage.SaveChapterContent(string(docID), int(chapterNum), content); err != nil {
+		return fmt.Errorf("failed to save compiled chapter content: %w", err)
+	}
+
+	return nil
+}
+
+// compiledSection is a section's heading and content as compileChapter writes them
+type compiledSection struct {
+	number  types.SectionNumber
+	heading string
+	body    string
+}
+
+// compileChapter compiles a chapter's section files into the markdown
+// RebuildChapterMarkdown writes to chapter.md, returning the sections it wrote
+// alongside. Sections without a file are left out.
+func (m *Manager) compileChapter(docID types.DocumentID, chapterNum types.ChapterNumber) (string, []compiledSection, error) {
 	// Load chapter metadata to get section order
 	chapter, err := m.storage.LoadChapterMetadata(string(docID), int(chapterNum))
 	if err != nil {
-		return fmt.Errorf("failed to load chapter metadata: %w", err)
+		return "", nil, fmt.Errorf("failed to load chapter metadata: %w", err)
+	}
+
+	// Load editorial rules; a missing manifest just means no normalization
+	var rules *types.EditorialRules
+	if manifest, err := m.storage.LoadManifest(string(docID)); err == nil && manifest != nil {
+		rules = manifest.Document.EditorialRules
+	}
+
+	// Chapter and section numbers are written in the document's numbering style,
+	// and the chapter heading in its chapter heading style
+	var numbering types.NumberingStyle
+	var chapterHeading types.ChapterHeadingStyle
+	if style, err := m.storage.LoadStyle(string(docID)); err == nil && style != nil {
+		numbering = style.NumberingStyle
+		chapterHeading = style.ChapterHeading
 	}
 
 	// Build chapter markdown content
 	var content strings.Builder
 	
 	// Add chapter title as main heading
-	content.WriteString(fmt.Sprintf("# Chapter %d: %s\n\n", chapterNum, chapter.Title))
+	content.WriteString(fmt.Sprintf("# %s\n\n", chapterHeading.Heading(chapterNum, chapter.Title, numbering)))
 	
-	// Process sections in order
-	for _, section := range chapter.Sections {
-		// Load section content
-		sectionContent, err := m.storage.LoadSectionContent(string(docID), int(chapterNum), section.Number)
+	// An included file opens the chapter; validation reports it when missing
+	if chapter.IncludeFile != "" {
+		if included, err := m.readIncludeFile(docID, chapter.IncludeFile); err == nil {
+			content.WriteString(included)
+			content.WriteString("\n\n")
+		}
+	}
+	
+	// Load each section's content, or the file included in its place, noting
+	// the figures and tables the content places by hand
+	contents := make([]*string, len(chapter.Sections))
+	placed := make(map[string]bool)
+	for i, section := range chapter.Sections {
+		var sectionContent string
+		if section.IncludeFile != "" {
+			sectionContent, err = m.readIncludeFile(docID, section.IncludeFile)
+		} else {
+			sectionContent, err = m.storage.LoadSectionContent(string(docID), int(chapterNum), section.Number)
+		}
 		if err != nil {
 			// If section file doesn't exist, skip it but log the issue
 			continue
 		}
+		contents[i] = &sectionContent
+		for _, match := range anchorPattern.FindAllStringSubmatch(sectionContent, -1) {
+			placed[match[1]] = true
+		}
+	}
+	anchored := anchoredBlocks(chapter, placed)
+	
+	// Process sections in order
+	var sections []compiledSection
+	for i, section := range chapter.Sections {
+		if contents[i] == nil {
+			continue
+		}
+		sectionContent := *contents[i]
 		
 		// Generate markdown header based on section level
 		headerLevel := strings.Repeat("#", section.Level+1) // +1 because chapter is already #
-		content.WriteString(fmt.Sprintf("%s %s %s\n\n", headerLevel, section.Number.String(), section.Title))
+		heading := fmt.Sprintf("%s %s", headerLevel, section.Title)
+		if label := numbering.SectionLabel(section.Number); label != "" {
+			heading = fmt.Sprintf("%s %s %s", headerLevel, label, section.Title)
+		}
+		content.WriteString(heading + "\n\n")
 		
+		// Apply house-style normalization to the compiled output only
+		if rules.Enabled() {
+			sectionContent, _ = normalizeSectionContent(sectionContent, rules, chapterNum, section.Number)
+		}
+
+		// Anchored figures and tables go where their anchors say
+		if blocks := anchored[section.Number.String()]; len(blocks) > 0 {
+			sectionContent = placeAnchored(sectionContent, blocks)
+		}
+
+		// Figures are sized and aligned as their metadata says
+		sectionContent = applyFigureLayout(sectionContent, chapter.Figures)
+
 		// Add section content
 		content.WriteString(sectionContent)
 		content.WriteString("\n\n")
+		sections = append(sections, compiledSection{number: section.Number, heading: heading, body: sectionContent})
 	}
 	
-	// Save compiled content to chapter.md
-	if err := m.storage.SaveChapterContent(string(docID), int(chapterNum), content.String()); err != nil {
-		return fmt.Errorf("failed to save compiled chapter content: %w", err)
-	}
-	
-	return nil
+	return content.String(), sections, nil
 }
 
 // checkDocumentLimit checks if the document count is within limits
@@ -920,7 +1318,15 @@ func (m *Manager) checkDocumentLimit() error {
 		return fmt.Errorf("failed to list documents: %w", err)
 	}
 
-	if len(docs) >= m.config.MaxDocuments {
+	// Sandbox documents have a limit of their own
+	count := 0
+	for _, id := range docs {
+		if manifest, err := m.storage.LoadManifest(id); err == nil && manifest.Document.IsSandbox() {
+			continue
+		}
+		count++
+	}
+	if count >= m.config.MaxDocuments {
 		return fmt.Errorf("maximum number of documents (%d) reached", m.config.MaxDocuments)
 	}
 
@@ -1,6 +1,7 @@
 package document
 
 import (
+	"context"
 	"os"
 	"testing"
 
@@ -26,6 +27,26 @@ func setupTestManager(t *testing.T) (*Manager, string) {
 	return manager, tempDir
 }
 
+// addNumberedImage adds an image figure identified by its number (fig-1.2),
+// as in documents created before figures had stable IDs
+func addNumberedImage(t *testing.T, manager *Manager, docID types.DocumentID, chapterNum types.ChapterNumber, imagePath, caption string) types.FigureID {
+	figureID, err := manager.AddImage(docID, chapterNum, imagePath, caption, "here", "", "")
+	if err != nil {
+		t.Fatalf("Failed to add image: %v", err)
+	}
+	chapter, _ := manager.storage.LoadChapterMetadata(string(docID), int(chapterNum))
+	for i := range chapter.Figures {
+		if chapter.Figures[i].ID == figureID {
+			chapter.Figures[i].ID = chapter.Figures[i].Label()
+			figureID = chapter.Figures[i].ID
+		}
+	}
+	if err := manager.storage.SaveChapterMetadata(string(docID), chapter); err != nil {
+		t.Fatalf("Failed to save chapter: %v", err)
+	}
+	return figureID
+}
+
 func TestManager_CreateDocument(t *testing.T) {
 	manager, tempDir := setupTestManager(t)
 	defer os.RemoveAll(tempDir)
@@ -97,12 +118,12 @@ func TestManager_GetDocumentStructure(t *testing.T) {
 	}
 
 	// Add some chapters
-	_, err = manager.AddChapter(docID, "Introduction", nil)
+	_, err = manager.AddChapter(context.Background(), docID, "Introduction", nil)
 	if err != nil {
 		t.Fatalf("Failed to add chapter: %v", err)
 	}
 
-	_, err = manager.AddChapter(docID, "Methods", nil)
+	_, err = manager.AddChapter(context.Background(), docID, "Methods", nil)
 	if err != nil {
 		t.Fatalf("Failed to add chapter: %v", err)
 	}
@@ -164,7 +185,7 @@ func TestManager_AddChapter(t *testing.T) {
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			chapterNum, err := manager.AddChapter(docID, tt.title, tt.position)
+			chapterNum, err := manager.AddChapter(context.Background(), docID, tt.title, tt.position)
 			if (err != nil) != tt.wantErr {
 				t.Errorf("Manager.AddChapter() error = %v, wantErr %v", err, tt.wantErr)
 				return
@@ -198,7 +219,7 @@ func TestManager_GetChapter(t *testing.T) {
 		t.Fatalf("Failed to create document: %v", err)
 	}
 
-	chapterNum, err := manager.AddChapter(docID, "Introduction", nil)
+	chapterNum, err := manager.AddChapter(context.Background(), docID, "Introduction", nil)
 	if err != nil {
 		t.Fatalf("Failed to add chapter: %v", err)
 	}
@@ -269,7 +290,7 @@ func TestManager_UpdateChapterMetadata(t *testing.T) {
 		t.Fatalf("Failed to create document: %v", err)
 	}
 
-	chapterNum, err := manager.AddChapter(docID, "Introduction", nil)
+	chapterNum, err := manager.AddChapter(context.Background(), docID, "Introduction", nil)
 	if err != nil {
 		t.Fatalf("Failed to add chapter: %v", err)
 	}
@@ -289,4 +310,124 @@ func TestManager_UpdateChapterMetadata(t *testing.T) {
 	if chapter.Title != "New Introduction Title" {
 		t.Errorf("Expected updated title 'New Introduction Title', got %s", chapter.Title)
 	}
-}
\ No newline at end of file
+}
+func TestManager_ConfigureChapter(t *testing.T) {
+	manager, tempDir := setupTestManager(t)
+	defer os.RemoveAll(tempDir)
+
+	docID, err := manager.CreateDocument("Test Document", "Test Author", types.DocumentTypeBook)
+	if err != nil {
+		t.Fatalf("Failed to create document: %v", err)
+	}
+
+	chapterNum, err := manager.AddChapter(context.Background(), docID, "Appendix", nil)
+	if err != nil {
+		t.Fatalf("Failed to add chapter: %v", err)
+	}
+
+	options := &types.ChapterPandocOptions{
+		Variables:    map[string]string{"geometry": "margin=0.5in"},
+		ClassOptions: []string{"openany"},
+		Landscape:    true,
+	}
+	if err := manager.ConfigureChapter(docID, chapterNum, options); err != nil {
+		t.Fatalf("ConfigureChapter() error = %v", err)
+	}
+
+	// The options should be visible in the document structure
+	manifest, err := manager.GetDocumentStructure(docID)
+	if err != nil {
+		t.Fatalf("Failed to get document structure: %v", err)
+	}
+	got := manifest.Document.Chapters[0].PandocOptions
+	if got == nil || !got.Landscape || got.Variables["geometry"] != "margin=0.5in" || len(got.ClassOptions) != 1 {
+		t.Errorf("Expected chapter pandoc options to be stored, got %+v", got)
+	}
+
+	// Clearing the options
+	if err := manager.ConfigureChapter(docID, chapterNum, nil); err != nil {
+		t.Fatalf("ConfigureChapter() clear error = %v", err)
+	}
+	chapter, err := manager.GetChapter(docID, chapterNum)
+	if err != nil {
+		t.Fatalf("Failed to get chapter: %v", err)
+	}
+	if chapter.PandocOptions != nil {
+		t.Errorf("Expected chapter pandoc options to be cleared, got %+v", chapter.PandocOptions)
+	}
+}
+
+func TestManager_RejectsPathsOutsideRoot(t *testing.T) {
+	manager, tempDir := setupTestManager(t)
+	defer os.RemoveAll(tempDir)
+
+	docID, err := manager.CreateDocument("Test Document", "Test Author", types.DocumentTypeBook)
+	if err != nil {
+		t.Fatalf("Failed to create document: %v", err)
+	}
+	chapterNum, err := manager.AddChapter(context.Background(), docID, "Introduction", nil)
+	if err != nil {
+		t.Fatalf("Failed to add chapter: %v", err)
+	}
+
+	if _, err := manager.AddImage(docID, chapterNum, "/etc/passwd", "Secrets", "here", "", ""); err == nil {
+		t.Errorf("AddImage() should reject an absolute path outside the root")
+	}
+	if _, err := manager.AddImage(docID, chapterNum, "../../../etc/passwd", "Secrets", "here", "", ""); err == nil {
+		t.Errorf("AddImage() should reject a traversal path")
+	}
+	if _, err := manager.AddImage(docID, chapterNum, "assets/images/diagram.png", "Diagram", "here", "", ""); err != nil {
+		t.Errorf("AddImage() with a path inside the document error = %v", err)
+	}
+
+	style := types.DefaultStyle()
+	style.ReferenceDocx = "/etc/passwd"
+	if err := manager.ConfigureDocument(docID, &style, nil, nil); err == nil {
+		t.Errorf("ConfigureDocument() should reject a reference document outside the root")
+	}
+	style.ReferenceDocx = ""
+	style.StyleCSS = "../../outside.css"
+	if err := manager.ConfigureDocument(docID, &style, nil, nil); err == nil {
+		t.Errorf("ConfigureDocument() should reject a stylesheet outside the root")
+	}
+	style.StyleCSS = "custom.css"
+	if err := manager.ConfigureDocument(docID, &style, nil, nil); err != nil {
+		t.Errorf("ConfigureDocument() with a stylesheet inside the document error = %v", err)
+	}
+}
+
+func TestManager_ConfigureMarkdown(t *testing.T) {
+	manager, tempDir := setupTestManager(t)
+	defer os.RemoveAll(tempDir)
+
+	docID, err := manager.CreateDocument("Flavor", "Test Author", types.DocumentTypeReport)
+	if err != nil {
+		t.Fatalf("Failed to create document: %v", err)
+	}
+
+	if _, err := manager.ConfigureMarkdown(docID, map[string]bool{"raw_html": false}); err != nil {
+		t.Fatalf("ConfigureMarkdown() error = %v", err)
+	}
+	profile, err := manager.ConfigureMarkdown(docID, map[string]bool{"footnotes": false})
+	if err != nil {
+		t.Fatalf("ConfigureMarkdown() error = %v", err)
+	}
+	if got := profile.PandocFormat(); got != "markdown-footnotes-raw_html" {
+		t.Errorf("PandocFormat() = %q, want earlier settings to be kept", got)
+	}
+	if _, err := manager.ConfigureMarkdown(docID, map[string]bool{"Raw-TeX": true}); err == nil {
+		t.Errorf("Expected an error for an invalid extension name")
+	}
+
+	// Replacing the export settings keeps the profile
+	if err := manager.ConfigureDocument(docID, nil, &types.PandocConfig{PDFEngine: "xelatex"}, nil); err != nil {
+		t.Fatalf("ConfigureDocument() error = %v", err)
+	}
+	pandocConfig, err := manager.storage.LoadPandocConfig(string(docID))
+	if err != nil {
+		t.Fatalf("LoadPandocConfig() error = %v", err)
+	}
+	if pandocConfig.Markdown.PandocFormat() != "markdown-footnotes-raw_html" {
+		t.Errorf("Expected the markdown profile to survive ConfigureDocument, got %+v", pandocConfig.Markdown)
+	}
+}
@@ -0,0 +1,37 @@
+package document
+
+import (
+	"fmt"
+
+	"github.com/gomcpgo/docgen/pkg/types"
+)
+
+// ConfigureMarkdown turns markdown reader extensions on or off for a document,
+// keeping the settings of extensions not mentioned, and returns the resulting profile
+func (m *Manager) ConfigureMarkdown(docID types.DocumentID, settings map[string]bool) (*types.MarkdownProfile, error) {
+	if err := docID.Validate(); err != nil {
+		return nil, fmt.Errorf("invalid document ID: %w", err)
+	}
+
+	pandocConfig, err := m.storage.LoadPandocConfig(string(docID))
+	if err != nil {
+		return nil, fmt.Errorf("failed to load pandoc config: %w", err)
+	}
+
+	profile := &types.MarkdownProfile{}
+	if pandocConfig.Markdown != nil {
+		*profile = *pandocConfig.Markdown
+	}
+	for extension, enabled := range settings {
+		if err := profile.Set(extension, enabled); err != nil {
+			return nil, err
+		}
+	}
+	pandocConfig.Markdown = profile
+
+	if err := m.storage.SavePandocConfig(string(docID), pandocConfig); err != nil {
+		return nil, fmt.Errorf("failed to save pandoc config: %w", err)
+	}
+
+	return profile, nil
+}
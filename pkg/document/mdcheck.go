@@ -0,0 +1,163 @@
+package document
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/gomcpgo/docgen/pkg/types"
+)
+
+var (
+	// atxHeadingPattern matches an ATX heading, capturing its hashes
+	atxHeadingPattern = regexp.MustCompile(`^(#{1,6})(?:\s|$)`)
+	// imageTargetPattern matches an inline image, capturing everything between the
+	// parentheses, even nothing at all
+	imageTargetPattern = regexp.MustCompile(`!\[[^\]]*\]\(([^)]*)\)`)
+	// tableDelimiterPattern matches the row separating a pipe table's header from its body
+	tableDelimiterPattern = regexp.MustCompile(`^\|?(\s*:?-+:?\s*\|)*\s*:?-+:?\s*\|?$`)
+)
+
+// CheckSectionMarkdown looks for structural problems in content meant for a
+// section at level: code fences left open, malformed pipe tables, headings that
+// skip a level and images whose path is missing or leads nowhere. Tables are
+// only checked when the document's markdown profile has pipe tables on.
+func (m *Manager) CheckSectionMarkdown(docID types.DocumentID, level int, content string) ([]types.MarkdownIssue, error) {
+	if err := docID.Validate(); err != nil {
+		return nil, fmt.Errorf("invalid document ID: %w", err)
+	}
+
+	var profile *types.MarkdownProfile
+	if pandocConfig, err := m.storage.LoadPandocConfig(string(docID)); err == nil {
+		profile = pandocConfig.Markdown
+	}
+
+	issues := []types.MarkdownIssue{}
+	issue := func(line int, problem types.MarkdownProblem, format string, args ...interface{}) {
+		issues = append(issues, types.MarkdownIssue{Line: line, Problem: problem, Message: fmt.Sprintf(format, args...)})
+	}
+
+	// The section's own heading has one more # than its level
+	previousHeading := level + 1
+	var fence string
+	fenceLine := 0
+	var table []string
+	tableLine := 0
+	checkTable := func() {
+		if len(table) > 1 && profile.Enabled("pipe_tables") {
+			checkPipeTable(table, tableLine, issue)
+		}
+		table = nil
+	}
+
+	for i, line := range strings.Split(content, "\n") {
+		lineNum := i + 1
+		trimmed := strings.TrimSpace(line)
+
+		if fence != "" {
+			if strings.HasPrefix(trimmed, fence) && strings.Trim(trimmed, fence[:1]) == "" {
+				fence = ""
+			}
+			continue
+		}
+		if strings.HasPrefix(trimmed, "```") || strings.HasPrefix(trimmed, "~~~") {
+			checkTable()
+			fence = trimmed[:len(trimmed)-len(strings.TrimLeft(trimmed, trimmed[:1]))]
+			fenceLine = lineNum
+			continue
+		}
+
+		if strings.HasPrefix(trimmed, "|") {
+			if table == nil {
+				tableLine = lineNum
+			}
+			table = append(table, trimmed)
+		} else {
+			checkTable()
+		}
+
+		if match := atxHeadingPattern.FindStringSubmatch(trimmed); match != nil {
+			headingLevel := len(match[1])
+			if headingLevel > previousHeading+1 {
+				issue(lineNum, types.MarkdownHeadingJump, "heading level jumps from H%d to H%d; use H%d", previousHeading, headingLevel, previousHeading+1)
+			}
+			previousHeading = headingLevel
+		}
+
+		for _, match := range imageTargetPattern.FindAllStringSubmatch(line, -1) {
+			if problem := m.checkImagePath(docID, match[1]); problem != "" {
+				issue(lineNum, types.MarkdownMissingImage, "%s", problem)
+			}
+		}
+	}
+	checkTable()
+
+	if fence != "" {
+		issue(fenceLine, types.MarkdownUnclosedFence, "code fence %s is never closed; add a closing %s", fence, fence)
+	}
+	return issues, nil
+}
+
+// checkPipeTable checks that a pipe table starting at line has a delimiter row
+// and that every row has as many cells as its header
+func checkPipeTable(rows []string, line int, issue func(int, types.MarkdownProblem, string, ...interface{})) {
+	if !tableDelimiterPattern.MatchString(rows[1]) {
+		issue(line+1, types.MarkdownMalformedTable, "table has no delimiter row such as | --- | --- | under its header")
+		return
+	}
+	columns := countTableCells(rows[0])
+	for i, row := range rows[1:] {
+		if cells := countTableCells(row); cells != columns {
+			issue(line+1+i, types.MarkdownMalformedTable, "table row has %d cells but the header has %d", cells, columns)
+		}
+	}
+}
+
+// countTableCells counts the cells of a pipe table row, ignoring escaped pipes
+// and the pipes that open and close the row
+func countTableCells(row string) int {
+	row = strings.TrimPrefix(row, "|")
+	if strings.HasSuffix(row, "|") && !strings.HasSuffix(row, `\|`) {
+		row = strings.TrimSuffix(row, "|")
+	}
+	cells := 1
+	for i := 0; i < len(row); i++ {
+		switch row[i] {
+		case '\\':
+			i++
+		case '|':
+			cells++
+		}
+	}
+	return cells
+}
+
+// checkImagePath describes what is wrong with the target of an inline image,
+// or returns "" when it is fine. Targets that aren't local files aren't checked.
+func (m *Manager) checkImagePath(docID types.DocumentID, target string) string {
+	target = strings.TrimSpace(target)
+	if strings.HasPrefix(target, "<") {
+		if end := strings.Index(target, ">"); end > 0 {
+			target = target[1:end]
+		}
+	} else if fields := strings.Fields(target); len(fields) > 0 {
+		target = fields[0]
+	}
+	if target == "" {
+		return "image has no path"
+	}
+	if strings.Contains(target, "://") || strings.HasPrefix(target, "data:") {
+		return ""
+	}
+
+	path := target
+	if !filepath.IsAbs(path) {
+		path = filepath.Join(m.config.DocumentPath(string(docID)), path)
+	}
+	if _, err := os.Stat(path); err != nil {
+		return fmt.Sprintf("image %s not found; add it with add_image or fix the path", target)
+	}
+	return ""
+}
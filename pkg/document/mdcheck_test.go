@@ -0,0 +1,90 @@
+package document
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/gomcpgo/docgen/pkg/types"
+)
+
+func TestManager_CheckSectionMarkdown(t *testing.T) {
+	manager, tempDir := setupTestManager(t)
+	defer os.RemoveAll(tempDir)
+
+	docID, _ := manager.CreateDocument("Checks", "Test Author", types.DocumentTypeBook)
+	imagePath := filepath.Join(tempDir, string(docID), "assets", "images", "chart.png")
+	os.MkdirAll(filepath.Dir(imagePath), 0755)
+	os.WriteFile(imagePath, []byte("PNG"), 0644)
+
+	tests := []struct {
+		name    string
+		level   int
+		content string
+		want    []types.MarkdownIssue
+	}{
+		{
+			name:    "clean",
+			level:   1,
+			content: "Intro.\n\n### Detail\n\n| A | B |\n|---|:-:|\n| 1 | 2 |\n\n![Chart](assets/images/chart.png \"Chart\")\n\n```go\n# not a heading\n| not | a table\n```",
+		},
+		{
+			name:    "unclosed fence",
+			level:   1,
+			content: "Text.\n\n````\ncode\n```\nmore",
+			want:    []types.MarkdownIssue{{Line: 3, Problem: types.MarkdownUnclosedFence}},
+		},
+		{
+			name:    "table without delimiter",
+			level:   1,
+			content: "| A | B |\n| 1 | 2 |",
+			want:    []types.MarkdownIssue{{Line: 2, Problem: types.MarkdownMalformedTable}},
+		},
+		{
+			name:    "ragged table",
+			level:   1,
+			content: "| A | B |\n|---|---|\n| 1 | 2 | 3 |\n| a \\| b | c |",
+			want:    []types.MarkdownIssue{{Line: 3, Problem: types.MarkdownMalformedTable}},
+		},
+		{
+			name:    "heading jump",
+			level:   2,
+			content: "#### Fine\n\n###### Too deep",
+			want:    []types.MarkdownIssue{{Line: 3, Problem: types.MarkdownHeadingJump}},
+		},
+		{
+			name:    "missing images",
+			level:   1,
+			content: "![Empty]() and ![Gone](assets/images/gone.png) but ![Web](https://example.com/a.png)",
+			want: []types.MarkdownIssue{
+				{Line: 1, Problem: types.MarkdownMissingImage},
+				{Line: 1, Problem: types.MarkdownMissingImage},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			issues, err := manager.CheckSectionMarkdown(docID, tt.level, tt.content)
+			if err != nil {
+				t.Fatalf("CheckSectionMarkdown() error = %v", err)
+			}
+			if len(issues) != len(tt.want) {
+				t.Fatalf("CheckSectionMarkdown() = %+v, want %d issue(s)", issues, len(tt.want))
+			}
+			for i, want := range tt.want {
+				if issues[i].Line != want.Line || issues[i].Problem != want.Problem || issues[i].Message == "" {
+					t.Errorf("Issue %d = %+v, want line %d %s", i, issues[i], want.Line, want.Problem)
+				}
+			}
+		})
+	}
+
+	// Tables are left alone when pipe tables are off
+	if _, err := manager.ConfigureMarkdown(docID, map[string]bool{"pipe_tables": false}); err != nil {
+		t.Fatalf("ConfigureMarkdown() error = %v", err)
+	}
+	issues, _ := manager.CheckSectionMarkdown(docID, 1, "| A | B |\n| 1 | 2 |")
+	if len(issues) != 0 {
+		t.Errorf("Expected no table issues with pipe tables off, got %+v", issues)
+	}
+}
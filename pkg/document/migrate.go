@@ -0,0 +1,164 @@
+package document
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"os"
+
+	"github.com/gomcpgo/docgen/pkg/types"
+)
+
+// MigrateSectionContent moves section bodies that older versions kept in chapter
+// metadata into section files, and rewrites the metadata without them. When a
+// section already has a file, the file wins, as it is what exports are built
+// from. It returns the number of sections whose content was moved.
+func (m *Manager) MigrateSectionContent(docID types.DocumentID) (int, error) {
+	if err := docID.Validate(); err != nil {
+		return 0, fmt.Errorf("invalid document ID: %w", err)
+	}
+
+	manifest, err := m.storage.LoadManifest(string(docID))
+	if err != nil {
+		return 0, fmt.Errorf("failed to load manifest: %w", err)
+	}
+
+	moved := 0
+	for _, chapterRef := range manifest.Document.Chapters {
+		chapterNum := int(chapterRef.Number)
+		legacy, err := m.storage.LoadLegacySectionContent(string(docID), chapterNum)
+		if err != nil {
+			return moved, fmt.Errorf("failed to read chapter %d metadata: %w", chapterNum, err)
+		}
+		if len(legacy) == 0 {
+			continue
+		}
+
+		chapter, err := m.storage.LoadChapterMetadata(string(docID), chapterNum)
+		if err != nil {
+			return moved, fmt.Errorf("failed to load chapter %d metadata: %w", chapterNum, err)
+		}
+		for _, section := range chapter.Sections {
+			content, ok := legacy[section.Number.String()]
+			if !ok {
+				continue
+			}
+			if _, err := m.storage.LoadSectionContent(string(docID), chapterNum, section.Number); err == nil {
+				continue
+			}
+			if err := m.storage.SaveSectionContent(string(docID), chapterNum, section.Number, content); err != nil {
+				return moved, fmt.Errorf("failed to save section %s content: %w", section.Number.String(), err)
+			}
+			moved++
+		}
+
+		// Saving drops the content from metadata, which no longer has a field for it
+		if err := m.storage.SaveChapterMetadata(string(docID), chapter); err != nil {
+			return moved, fmt.Errorf("failed to save chapter %d metadata: %w", chapterNum, err)
+		}
+		if err := m.RebuildChapterMarkdown(docID, chapterRef.Number); err != nil {
+			return moved, fmt.Errorf("failed to rebuild chapter %d markdown: %w", chapterNum, err)
+		}
+	}
+
+	return moved, nil
+}
+
+// MigrateChapterDirectories moves the chapters of a document from directories
+// named after their number, which older versions renamed whenever chapters were
+// renumbered, into directories of their own, and records the chapter order. A
+// migration that stopped part way is finished on the next run. It returns the
+// number of chapters moved. Each chapter moves to a directory named after its
+// numbered one, so a chapter moved by a run that stopped before saving the
+// chapter order is recognized as migrated.
+func (m *Manager) MigrateChapterDirectories(ctx context.Context, docID types.DocumentID) (int, error) {
+	if err := docID.Validate(); err != nil {
+		return 0, fmt.Errorf("invalid document ID: %w", err)
+	}
+
+	manifest, err := m.storage.LoadManifest(string(docID))
+	if err != nil {
+		return 0, fmt.Errorf("failed to load manifest: %w", err)
+	}
+	legacy := manifest.ChapterOrder == nil
+	for _, dir := range manifest.ChapterOrder {
+		legacy = legacy || types.IsLegacyChapterDir(dir)
+	}
+	if !legacy || len(manifest.Document.Chapters) == 0 {
+		return 0, nil
+	}
+	if err := m.SyncDocument(ctx, docID); err != nil {
+		return 0, err
+	}
+
+	manifest.EnsureChapterOrder()
+	moved := 0
+	for i, dir := range manifest.ChapterOrder {
+		if !types.IsLegacyChapterDir(dir) {
+			continue
+		}
+		newDir := migratedChapterDir(dir)
+		newPath := m.config.ChapterPath(string(docID), newDir)
+		if err := os.Rename(m.config.ChapterPath(string(docID), dir), newPath); err != nil {
+			if os.IsNotExist(err) {
+				if _, statErr := os.Stat(newPath); statErr == nil {
+					manifest.ChapterOrder[i] = newDir
+				}
+				continue
+			}
+			// The chapters already moved are only found through the saved order
+			err = fmt.Errorf("failed to move chapter %d: %w", i+1, err)
+			if saveErr := m.storage.SaveManifest(string(docID), manifest); saveErr != nil {
+				return moved, fmt.Errorf("%w; failed to save chapter order: %v", err, saveErr)
+			}
+			return moved, err
+		}
+		manifest.ChapterOrder[i] = newDir
+		moved++
+	}
+
+	if err := m.storage.SaveManifest(string(docID), manifest); err != nil {
+		return moved, fmt.Errorf("failed to save chapter order: %w", err)
+	}
+	if err := m.publishDocument(docID); err != nil {
+		return moved, err
+	}
+	return moved, nil
+}
+
+// migratedChapterDir returns the directory a chapter in a numbered directory
+// moves to. It is derived from the numbered directory rather than random, so
+// that a migration that stopped part way finds where it moved the chapter.
+func migratedChapterDir(legacyDir string) string {
+	sum := sha256.Sum256([]byte("chapter-" + legacyDir))
+	return "ch-" + hex.EncodeToString(sum[:4])
+}
+
+// MigrateDocuments runs MigrateSectionContent and MigrateChapterDirectories on
+// every document. A document that fails to migrate doesn't stop the others; the
+// failures are returned together. It returns the number of sections and the
+// number of chapters moved.
+func (m *Manager) MigrateDocuments(ctx context.Context) (int, int, error) {
+	documentIDs, err := m.storage.ListDocuments()
+	if err != nil {
+		return 0, 0, fmt.Errorf("failed to list documents: %w", err)
+	}
+
+	sections, chapters := 0, 0
+	var failures []error
+	for _, documentID := range documentIDs {
+		moved, err := m.MigrateSectionContent(types.DocumentID(documentID))
+		sections += moved
+		if err != nil {
+			failures = append(failures, fmt.Errorf("document %s: %w", documentID, err))
+		}
+		moved, err = m.MigrateChapterDirectories(ctx, types.DocumentID(documentID))
+		chapters += moved
+		if err != nil {
+			failures = append(failures, fmt.Errorf("document %s: %w", documentID, err))
+		}
+	}
+	return sections, chapters, errors.Join(failures...)
+}
@@ -0,0 +1,169 @@
+package document
+
+import (
+	"context"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/gomcpgo/docgen/pkg/types"
+)
+
+func TestManager_MigrateSectionContent(t *testing.T) {
+	manager, tempDir := setupTestManager(t)
+	defer os.RemoveAll(tempDir)
+
+	docID, err := manager.CreateDocument("Legacy", "Test Author", types.DocumentTypeReport)
+	if err != nil {
+		t.Fatalf("Failed to create document: %v", err)
+	}
+	chapterNum, err := manager.AddChapter(context.Background(), docID, "Old", nil)
+	if err != nil {
+		t.Fatalf("Failed to add chapter: %v", err)
+	}
+	manager.AddSection(context.Background(), docID, chapterNum, "Kept", "File content.", 1)
+	manager.AddSection(context.Background(), docID, chapterNum, "Moved", "placeholder", 1)
+
+	// Rewrite the metadata as older versions stored it, with bodies inline and
+	// one section file missing
+	manifest, _ := manager.storage.LoadManifest(string(docID))
+	dir, _ := manifest.ChapterDir(chapterNum)
+	metadataPath := manager.config.ChapterMetadataPath(string(docID), dir)
+	metadata, err := os.ReadFile(metadataPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	legacy := strings.Replace(string(metadata), "title: Kept\n", "title: Kept\n      content: Stale metadata content.\n", 1)
+	legacy = strings.Replace(legacy, "title: Moved\n", "title: Moved\n      content: Metadata content.\n", 1)
+	if legacy == string(metadata) {
+		t.Fatalf("Unexpected metadata layout:\n%s", metadata)
+	}
+	os.WriteFile(metadataPath, []byte(legacy), 0644)
+	os.Remove(manager.config.SectionPath(string(docID), dir, "1.2"))
+
+	moved, _, err := manager.MigrateDocuments(context.Background())
+	if err != nil {
+		t.Fatalf("MigrateDocuments() error = %v", err)
+	}
+	if moved != 1 {
+		t.Errorf("Expected 1 section moved, got %d", moved)
+	}
+
+	if content, _ := manager.storage.LoadSectionContent(string(docID), int(chapterNum), types.SectionNumber{1, 1}); content != "File content." {
+		t.Errorf("Existing section file should win, got %q", content)
+	}
+	if content, _ := manager.storage.LoadSectionContent(string(docID), int(chapterNum), types.SectionNumber{1, 2}); content != "Metadata content." {
+		t.Errorf("Expected the metadata content in the section file, got %q", content)
+	}
+	if legacy, _ := manager.storage.LoadLegacySectionContent(string(docID), int(chapterNum)); len(legacy) != 0 {
+		t.Errorf("Expected metadata without content, got %v", legacy)
+	}
+	chapterMD, _ := manager.storage.LoadChapterContent(string(docID), int(chapterNum))
+	if !strings.Contains(chapterMD, "Metadata content.") {
+		t.Errorf("Expected the chapter to be rebuilt, got:\n%s", chapterMD)
+	}
+
+	// Running again finds nothing to do
+	if moved, _, err := manager.MigrateDocuments(context.Background()); err != nil || moved != 0 {
+		t.Errorf("Expected nothing to migrate, got %d, %v", moved, err)
+	}
+}
+
+func TestManager_MigrateChapterDirectories(t *testing.T) {
+	manager, tempDir := setupTestManager(t)
+	defer os.RemoveAll(tempDir)
+
+	docID, _ := manager.CreateDocument("Legacy", "Test Author", types.DocumentTypeBook)
+	for _, title := range []string{"One", "Two"} {
+		chapterNum, _ := manager.AddChapter(context.Background(), docID, title, nil)
+		manager.AddSection(context.Background(), docID, chapterNum, "Intro", title+" content.", 1)
+	}
+
+	// Lay the document out as older versions did, in numbered directories
+	manifest, _ := manager.storage.LoadManifest(string(docID))
+	for i, dir := range manifest.ChapterOrder {
+		legacyDir := types.LegacyChapterDir(types.ChapterNumber(i + 1))
+		if err := os.Rename(manager.config.ChapterPath(string(docID), dir), manager.config.ChapterPath(string(docID), legacyDir)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	manifest.ChapterOrder = nil
+	manager.storage.SaveManifest(string(docID), manifest)
+
+	// Legacy documents still work before they are migrated
+	if content, err := manager.storage.LoadSectionContent(string(docID), 2, types.SectionNumber{2, 1}); err != nil || content != "Two content." {
+		t.Fatalf("Legacy section = %q, %v", content, err)
+	}
+
+	moved, err := manager.MigrateChapterDirectories(context.Background(), docID)
+	if err != nil || moved != 2 {
+		t.Fatalf("MigrateChapterDirectories() = %d, %v, want 2", moved, err)
+	}
+	manifest, _ = manager.storage.LoadManifest(string(docID))
+	if len(manifest.ChapterOrder) != 2 || types.IsLegacyChapterDir(manifest.ChapterOrder[1]) {
+		t.Fatalf("Expected new directories in the chapter order, got %v", manifest.ChapterOrder)
+	}
+	if _, err := os.Stat(manager.config.ChapterPath(string(docID), "02")); !os.IsNotExist(err) {
+		t.Errorf("Numbered directory should be gone, stat error = %v", err)
+	}
+	if content, err := manager.storage.LoadSectionContent(string(docID), 2, types.SectionNumber{2, 1}); err != nil || content != "Two content." {
+		t.Errorf("Migrated section = %q, %v", content, err)
+	}
+
+	// Running again finds nothing to do
+	if moved, err := manager.MigrateChapterDirectories(context.Background(), docID); err != nil || moved != 0 {
+		t.Errorf("Expected nothing to migrate, got %d, %v", moved, err)
+	}
+}
+
+func TestManager_MigrateChapterDirectories_Resumes(t *testing.T) {
+	manager, tempDir := setupTestManager(t)
+	defer os.RemoveAll(tempDir)
+
+	docID, _ := manager.CreateDocument("Legacy", "Test Author", types.DocumentTypeBook)
+	for _, title := range []string{"One", "Two"} {
+		chapterNum, _ := manager.AddChapter(context.Background(), docID, title, nil)
+		manager.AddSection(context.Background(), docID, chapterNum, "Intro", title+" content.", 1)
+	}
+
+	// A migration that stopped after the first chapter left the second in its
+	// numbered directory
+	manifest, _ := manager.storage.LoadManifest(string(docID))
+	if err := os.Rename(manager.config.ChapterPath(string(docID), manifest.ChapterOrder[1]), manager.config.ChapterPath(string(docID), "02")); err != nil {
+		t.Fatal(err)
+	}
+	first := manifest.ChapterOrder[0]
+	manifest.ChapterOrder[1] = "02"
+	manager.storage.SaveManifest(string(docID), manifest)
+
+	moved, err := manager.MigrateChapterDirectories(context.Background(), docID)
+	if err != nil || moved != 1 {
+		t.Fatalf("MigrateChapterDirectories() = %d, %v, want 1", moved, err)
+	}
+	manifest, _ = manager.storage.LoadManifest(string(docID))
+	if manifest.ChapterOrder[0] != first || types.IsLegacyChapterDir(manifest.ChapterOrder[1]) {
+		t.Errorf("Expected the second chapter moved, got %v", manifest.ChapterOrder)
+	}
+	for chapterNum, want := range map[int]string{1: "One content.", 2: "Two content."} {
+		if content, err := manager.storage.LoadSectionContent(string(docID), chapterNum, types.SectionNumber{chapterNum, 1}); err != nil || content != want {
+			t.Errorf("Section %d.1 = %q, %v", chapterNum, content, err)
+		}
+	}
+
+	// A migration that stopped after moving a chapter but before saving the
+	// order finds the chapter where it was moved
+	manifest, _ = manager.storage.LoadManifest(string(docID))
+	second := manifest.ChapterOrder[1]
+	manifest.ChapterOrder[1] = "02"
+	manager.storage.SaveManifest(string(docID), manifest)
+	if moved, err := manager.MigrateChapterDirectories(context.Background(), docID); err != nil || moved != 0 {
+		t.Fatalf("MigrateChapterDirectories() = %d, %v, want 0", moved, err)
+	}
+	manifest, _ = manager.storage.LoadManifest(string(docID))
+	if manifest.ChapterOrder[1] != second {
+		t.Errorf("Expected the moved chapter found in %s, got %v", second, manifest.ChapterOrder)
+	}
+	if content, err := manager.storage.LoadSectionContent(string(docID), 2, types.SectionNumber{2, 1}); err != nil || content != "Two content." {
+		t.Errorf("Section 2.1 = %q, %v", content, err)
+	}
+}
@@ -2,74 +2,69 @@ package document
 
 import (
 	"fmt"
-	"os"
-	"path/filepath"
 	"strconv"
 
 	"github.com/gomcpgo/docgen/pkg/types"
 )
 
-// renumberChapters renumbers chapters starting from a given position with an offset
-// offset: +1 to shift up (insert), -1 to shift down (delete)
+// renumberChapters shifts the chapters from a given position by an offset in the
+// document's chapter order: +1 opens a slot for a chapter inserted there and -1
+// closes the slot of the chapter removed just before it. Chapter directories keep
+// their names, so only the manifest changes. It is saved straight away, so that
+// storage finds the chapters under their new numbers.
 func (m *Manager) renumberChapters(docID string, manifest *types.Manifest, startFrom int, offset int) error {
 	if offset == 0 {
 		return nil // No change needed
 	}
 
-	// Get all chapters that need renumbering
-	var toRename []types.ChapterNumber
-	for _, chapter := range manifest.Document.Chapters {
-		if int(chapter.Number) >= startFrom {
-			toRename = append(toRename, chapter.Number)
-		}
+	manifest.EnsureChapterOrder()
+	order := manifest.ChapterOrder
+	index := startFrom - 1
+	if index < 0 {
+		index = 0
+	}
+	if index > len(order) {
+		index = len(order)
 	}
 
-	// Rename chapter directories in the correct order to avoid conflicts
 	if offset > 0 {
-		// Renaming upward (insert): start from highest number
-		for i := len(toRename) - 1; i >= 0; i-- {
-			oldNum := int(toRename[i])
-			newNum := oldNum + offset
-			
-			if err := m.renameChapterDirectory(docID, oldNum, newNum); err != nil {
-				return fmt.Errorf("failed to rename chapter %d to %d: %w", oldNum, newNum, err)
-			}
-		}
+		// Insert: later chapters move up, leaving empty slots for the new ones
+		shifted := append([]string{}, order[:index]...)
+		shifted = append(shifted, make([]string, offset)...)
+		order = append(shifted, order[index:]...)
 	} else {
-		// Renaming downward (delete): start from lowest number
-		for _, chapterNum := range toRename {
-			oldNum := int(chapterNum)
-			newNum := oldNum + offset
-			
-			if err := m.renameChapterDirectory(docID, oldNum, newNum); err != nil {
-				return fmt.Errorf("failed to rename chapter %d to %d: %w", oldNum, newNum, err)
-			}
+		// Delete: later chapters move down over the removed ones
+		from := index + offset
+		if from < 0 {
+			from = 0
 		}
+		order = append(append([]string{}, order[:from]...), order[index:]...)
 	}
+	manifest.ChapterOrder = order
 
+	if err := m.storage.SaveManifest(docID, manifest); err != nil {
+		return fmt.Errorf("failed to save chapter order: %w", err)
+	}
 	return nil
 }
 
-// renameChapterDirectory renames a chapter directory from old number to new number
-func (m *Manager) renameChapterDirectory(docID string, oldNum, newNum int) error {
-	oldPath := m.config.ChapterPath(docID, oldNum)
-	newPath := m.config.ChapterPath(docID, newNum)
-
-	// Check if old directory exists
-	if _, err := os.Stat(oldPath); os.IsNotExist(err) {
-		return nil // Directory doesn't exist, nothing to rename
+// placeChapter gives a new chapter a directory of its own at its number in the
+// chapter order, in the slot renumberChapters opened for it or after the last
+// chapter, and saves the order so that storage creates the chapter there
+func (m *Manager) placeChapter(docID string, manifest *types.Manifest, chapterNum types.ChapterNumber) error {
+	manifest.EnsureChapterOrder()
+	index := int(chapterNum) - 1
+	for len(manifest.ChapterOrder) <= index {
+		manifest.ChapterOrder = append(manifest.ChapterOrder, "")
 	}
-
-	// Create parent directory for new path if needed
-	if err := os.MkdirAll(filepath.Dir(newPath), 0755); err != nil {
-		return fmt.Errorf("failed to create parent directory: %w", err)
+	if manifest.ChapterOrder[index] != "" {
+		return fmt.Errorf("chapter %d already has a directory", chapterNum)
 	}
+	manifest.ChapterOrder[index] = types.NewChapterDir()
 
-	// Rename the directory
-	if err := os.Rename(oldPath, newPath); err != nil {
-		return fmt.Errorf("failed to rename directory: %w", err)
+	if err := m.storage.SaveManifest(docID, manifest); err != nil {
+		return fmt.Errorf("failed to save chapter order: %w", err)
 	}
-
 	return nil
 }
 
@@ -95,7 +90,7 @@ func generateTableSequence(existing []types.Table) int {
 	return maxSequence + 1
 }
 
-// renumberFigures updates figure IDs when chapters are renumbered
+// renumberFigures updates numbered figure IDs when chapters are renumbered
 func renumberFigures(figures []types.Figure, chapterNum types.ChapterNumber, offset int) []types.Figure {
 	var updated []types.Figure
 	
@@ -104,7 +99,9 @@ func renumberFigures(figures []types.Figure, chapterNum types.ChapterNumber, off
 			// Update the figure's chapter number
 			newChapter := types.ChapterNumber(int(fig.Chapter) + offset)
 			fig.Chapter = newChapter
-			fig.ID = types.GenerateFigureID(newChapter, fig.Sequence)
+			if fig.ID.IsNumbered() {
+				fig.ID = types.GenerateFigureID(newChapter, fig.Sequence)
+			}
 		}
 		updated = append(updated, fig)
 	}
@@ -1,8 +1,10 @@
 package document
 
 import (
+	"context"
 	"fmt"
 	"os"
+	"path/filepath"
 	"testing"
 
 	"github.com/gomcpgo/docgen/pkg/types"
@@ -20,7 +22,7 @@ func TestRenumberChapters(t *testing.T) {
 
 	// Add chapters 1, 2, 3
 	for i := 1; i <= 3; i++ {
-		_, err := manager.AddChapter(docID, fmt.Sprintf("Chapter %d", i), nil)
+		_, err := manager.AddChapter(context.Background(), docID, fmt.Sprintf("Chapter %d", i), nil)
 		if err != nil {
 			t.Fatalf("Failed to add chapter %d: %v", i, err)
 		}
@@ -58,6 +60,127 @@ func TestRenumberChapters(t *testing.T) {
 	}
 }
 
+func TestChapterDirectoriesAreStable(t *testing.T) {
+	manager, tempDir := setupTestManager(t)
+	defer os.RemoveAll(tempDir)
+
+	docID, _ := manager.CreateDocument("Test Document", "Test Author", types.DocumentTypeBook)
+	for _, title := range []string{"One", "Two", "Three"} {
+		if _, err := manager.AddChapter(context.Background(), docID, title, nil); err != nil {
+			t.Fatalf("Failed to add chapter %s: %v", title, err)
+		}
+	}
+	manifest, _ := manager.storage.LoadManifest(string(docID))
+	dirs := append([]string{}, manifest.ChapterOrder...)
+	if len(dirs) != 3 || dirs[0] == dirs[1] || types.IsLegacyChapterDir(dirs[0]) {
+		t.Fatalf("Expected a directory of its own for each chapter, got %v", dirs)
+	}
+
+	// Removing a chapter only changes the order; the other directories stay put
+	if err := manager.DeleteChapter(docID, 1); err != nil {
+		t.Fatalf("DeleteChapter() error = %v", err)
+	}
+	manifest, _ = manager.storage.LoadManifest(string(docID))
+	if len(manifest.ChapterOrder) != 2 || manifest.ChapterOrder[0] != dirs[1] || manifest.ChapterOrder[1] != dirs[2] {
+		t.Errorf("Expected order %v after deleting chapter 1, got %v", dirs[1:], manifest.ChapterOrder)
+	}
+	if _, err := os.Stat(filepath.Join(tempDir, string(docID), "chapters", dirs[0])); !os.IsNotExist(err) {
+		t.Errorf("Deleted chapter's directory should be gone, stat error = %v", err)
+	}
+	chapter, err := manager.storage.LoadChapterMetadata(string(docID), 1)
+	if err != nil || chapter.Title != "Two" {
+		t.Errorf("Chapter 1 should be read from the second chapter's directory, got %+v, %v", chapter, err)
+	}
+
+	// A chapter inserted in the middle gets a new directory in its slot
+	position := 2
+	if _, err := manager.AddChapter(context.Background(), docID, "Inserted", &position); err != nil {
+		t.Fatalf("AddChapter() at position error = %v", err)
+	}
+	manifest, _ = manager.storage.LoadManifest(string(docID))
+	if len(manifest.ChapterOrder) != 3 || manifest.ChapterOrder[0] != dirs[1] || manifest.ChapterOrder[2] != dirs[2] {
+		t.Fatalf("Expected the new chapter between the others, got %v", manifest.ChapterOrder)
+	}
+	if exists, _ := manager.storage.ChapterExists(string(docID), 4); exists {
+		t.Error("Chapter 4 should not exist")
+	}
+}
+
+func TestAddChapter_InsertInMiddle(t *testing.T) {
+	manager, tempDir := setupTestManager(t)
+	defer os.RemoveAll(tempDir)
+
+	docID, _ := manager.CreateDocument("Test Document", "Test Author", types.DocumentTypeBook)
+	for _, title := range []string{"One", "Two", "Three"} {
+		chapterNum, err := manager.AddChapter(context.Background(), docID, title, nil)
+		if err != nil {
+			t.Fatalf("Failed to add chapter %s: %v", title, err)
+		}
+		manager.AddSection(context.Background(), docID, chapterNum, title+" section", fmt.Sprintf("Text of %s.", title), 1)
+		addNumberedImage(t, manager, docID, chapterNum, fmt.Sprintf("assets/images/%s.png", title), title+" figure")
+	}
+	manager.UpdateSection(context.Background(), docID, 1, types.SectionNumber{1, 1}, "See @fig-2.1 and @fig-3.1.")
+
+	position := 2
+	chapterNum, err := manager.AddChapter(context.Background(), docID, "Inserted", &position)
+	if err != nil {
+		t.Fatalf("AddChapter() at position error = %v", err)
+	}
+	if chapterNum != 2 {
+		t.Errorf("AddChapter() = %d, want 2", chapterNum)
+	}
+
+	manifest, err := manager.GetDocumentStructure(docID)
+	if err != nil {
+		t.Fatalf("GetDocumentStructure() error = %v", err)
+	}
+	var titles []string
+	for i, chapter := range manifest.Document.Chapters {
+		titles = append(titles, chapter.Title)
+		if chapter.Number != types.ChapterNumber(i+1) {
+			t.Errorf("Chapter %q is number %d at index %d", chapter.Title, chapter.Number, i)
+		}
+	}
+	if fmt.Sprint(titles) != "[One Inserted Two Three]" {
+		t.Errorf("Chapters = %v", titles)
+	}
+
+	// The chapters that moved carry their new number everywhere
+	for chapterNum, title := range map[types.ChapterNumber]string{3: "Two", 4: "Three"} {
+		chapter, err := manager.GetChapter(docID, chapterNum)
+		if err != nil {
+			t.Fatalf("GetChapter(%d) error = %v", chapterNum, err)
+		}
+		if chapter.Number != chapterNum || chapter.Title != title {
+			t.Errorf("Chapter %d is %d %q", chapterNum, chapter.Number, chapter.Title)
+		}
+		if len(chapter.Sections) != 1 || chapter.Sections[0].Number.String() != fmt.Sprintf("%d.1", chapterNum) {
+			t.Errorf("Chapter %d sections = %+v", chapterNum, chapter.Sections)
+		}
+		if len(chapter.Figures) != 1 || chapter.Figures[0].ID != types.GenerateFigureID(chapterNum, 1) {
+			t.Errorf("Chapter %d figures = %+v", chapterNum, chapter.Figures)
+		}
+		content, err := manager.GetSectionContent(docID, chapterNum, types.SectionNumber{int(chapterNum), 1})
+		if err != nil || content != fmt.Sprintf("Text of %s.", title) {
+			t.Errorf("Section %d.1 content = %q, %v", chapterNum, content, err)
+		}
+		if count := manifest.ChapterCounts[chapterNum]; count.Sections != 1 || count.Figures != 1 {
+			t.Errorf("Chapter %d counts = %+v", chapterNum, count)
+		}
+	}
+
+	// References to the renamed figures follow them
+	content, _ := manager.GetSectionContent(docID, 1, types.SectionNumber{1, 1})
+	if content != "See @fig-3.1 and @fig-4.1." {
+		t.Errorf("References were not updated: %q", content)
+	}
+
+	position = 7
+	if _, err := manager.AddChapter(context.Background(), docID, "Too far", &position); err == nil {
+		t.Error("Expected an error for a position past the end")
+	}
+}
+
 func TestGenerateFigureSequence(t *testing.T) {
 	tests := []struct {
 		name     string
@@ -0,0 +1,105 @@
+package document
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/gomcpgo/docgen/pkg/types"
+)
+
+// maxSectionLevel is the deepest section level a chapter can hold
+const maxSectionLevel = 6
+
+// BuildOutline adds the chapters of an outline, with their nested sections, to
+// the end of a document. The whole outline is checked before anything is
+// created, so a mistake in it leaves the document as it was. It returns the
+// numbers of the new chapters and how many sections were added.
+func (m *Manager) BuildOutline(ctx context.Context, docID types.DocumentID, outline []types.OutlineChapter) ([]types.ChapterNumber, int, error) {
+	if err := docID.Validate(); err != nil {
+		return nil, 0, fmt.Errorf("invalid document ID: %w", err)
+	}
+	if len(outline) == 0 {
+		return nil, 0, fmt.Errorf("outline has no chapters")
+	}
+
+	var size int64
+	for i, chapter := range outline {
+		if chapter.Title == "" {
+			return nil, 0, fmt.Errorf("chapter %d of the outline has no title", i+1)
+		}
+		chapterSize, err := checkOutlineSections(chapter.Sections, 1, fmt.Sprintf("chapter %q", chapter.Title))
+		if err != nil {
+			return nil, 0, err
+		}
+		size += chapterSize
+	}
+	if err := m.CheckStorageQuota(ctx, docID, size); err != nil {
+		return nil, 0, err
+	}
+
+	chapters := make([]types.ChapterNumber, 0, len(outline))
+	sections := 0
+	for _, chapter := range outline {
+		chapterNum, err := m.AddChapter(ctx, docID, chapter.Title, nil)
+		if err != nil {
+			return chapters, sections, fmt.Errorf("failed to add chapter %q: %w", chapter.Title, err)
+		}
+		chapters = append(chapters, chapterNum)
+
+		added, err := m.addOutlineSections(ctx, docID, chapterNum, chapter.Sections, 1)
+		sections += added
+		if err != nil {
+			return chapters, sections, err
+		}
+	}
+
+	return chapters, sections, nil
+}
+
+// checkOutlineSections checks the sections of an outline at level and below,
+// returning the size of the content they will be given
+func checkOutlineSections(sections []types.OutlineSection, level int, parent string) (int64, error) {
+	if len(sections) > 0 && level > maxSectionLevel {
+		return 0, fmt.Errorf("sections of %s are nested deeper than %d levels", parent, maxSectionLevel)
+	}
+	var size int64
+	for i, section := range sections {
+		if section.Title == "" {
+			return 0, fmt.Errorf("section %d of %s has no title", i+1, parent)
+		}
+		size += int64(len(outlineSectionContent(section)))
+		nested, err := checkOutlineSections(section.Sections, level+1, fmt.Sprintf("section %q", section.Title))
+		if err != nil {
+			return 0, err
+		}
+		size += nested
+	}
+	return size, nil
+}
+
+// addOutlineSections adds the sections of an outline to a chapter in order,
+// each followed by its own sections, and returns how many were added
+func (m *Manager) addOutlineSections(ctx context.Context, docID types.DocumentID, chapterNum types.ChapterNumber, sections []types.OutlineSection, level int) (int, error) {
+	added := 0
+	for _, section := range sections {
+		if _, err := m.AddSection(ctx, docID, chapterNum, section.Title, outlineSectionContent(section), level); err != nil {
+			return added, fmt.Errorf("failed to add section %q to chapter %d: %w", section.Title, chapterNum, err)
+		}
+		added++
+
+		nested, err := m.addOutlineSections(ctx, docID, chapterNum, section.Sections, level+1)
+		added += nested
+		if err != nil {
+			return added, err
+		}
+	}
+	return added, nil
+}
+
+// outlineSectionContent returns the content an outline section is created with
+func outlineSectionContent(section types.OutlineSection) string {
+	if section.Content != "" {
+		return section.Content
+	}
+	return types.PlaceholderSectionContent(section.Title)
+}
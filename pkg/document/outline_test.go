@@ -0,0 +1,93 @@
+package document
+
+import (
+	"context"
+	"os"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/gomcpgo/docgen/pkg/types"
+)
+
+func TestManager_BuildOutline(t *testing.T) {
+	manager, tempDir := setupTestManager(t)
+	defer os.RemoveAll(tempDir)
+
+	docID, _ := manager.CreateDocument("Handbook", "Test Author", types.DocumentTypeBook)
+	manager.AddChapter(context.Background(), docID, "Preface", nil)
+
+	outline := []types.OutlineChapter{
+		{
+			Title: "Getting Started",
+			Sections: []types.OutlineSection{
+				{Title: "Install", Content: "Run the installer.", Sections: []types.OutlineSection{
+					{Title: "Linux"},
+					{Title: "macOS"},
+				}},
+				{Title: "Configure"},
+			},
+		},
+		{Title: "Reference"},
+	}
+
+	chapters, sections, err := manager.BuildOutline(context.Background(), docID, outline)
+	if err != nil {
+		t.Fatalf("BuildOutline() error = %v", err)
+	}
+	if !reflect.DeepEqual(chapters, []types.ChapterNumber{2, 3}) || sections != 4 {
+		t.Errorf("BuildOutline() = %v, %d; want [2 3], 4", chapters, sections)
+	}
+
+	chapter, err := manager.GetChapter(docID, 2)
+	if err != nil {
+		t.Fatalf("GetChapter() error = %v", err)
+	}
+	var numbers []string
+	for _, section := range chapter.Sections {
+		numbers = append(numbers, section.Number.String())
+	}
+	if !reflect.DeepEqual(numbers, []string{"2.1", "2.1.1", "2.1.2", "2.2"}) {
+		t.Errorf("Section numbers = %v", numbers)
+	}
+	if !strings.Contains(chapter.Content, "Run the installer.") || !strings.Contains(chapter.Content, types.PlaceholderSectionContent("Linux")) {
+		t.Errorf("Unexpected chapter content:\n%s", chapter.Content)
+	}
+}
+
+func TestManager_BuildOutline_Invalid(t *testing.T) {
+	manager, tempDir := setupTestManager(t)
+	defer os.RemoveAll(tempDir)
+
+	docID, _ := manager.CreateDocument("Handbook", "Test Author", types.DocumentTypeBook)
+
+	deep := []types.OutlineSection{{Title: "Too deep"}}
+	for i := 0; i < maxSectionLevel; i++ {
+		deep = []types.OutlineSection{{Title: "Level", Sections: deep}}
+	}
+
+	tests := []struct {
+		name    string
+		outline []types.OutlineChapter
+		want    string
+	}{
+		{"empty", nil, "no chapters"},
+		{"chapter without title", []types.OutlineChapter{{Title: "One"}, {}}, "chapter 2 of the outline has no title"},
+		{"section without title", []types.OutlineChapter{{Title: "One", Sections: []types.OutlineSection{{Title: "A"}, {Content: "text"}}}}, `section 2 of chapter "One" has no title`},
+		{"nested too deep", []types.OutlineChapter{{Title: "One", Sections: deep}}, "deeper than 6 levels"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, _, err := manager.BuildOutline(context.Background(), docID, tt.outline)
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("BuildOutline() error = %v, want %q", err, tt.want)
+			}
+		})
+	}
+
+	// Nothing is created from an invalid outline
+	manifest, _ := manager.GetDocumentStructure(docID)
+	if len(manifest.Document.Chapters) != 0 {
+		t.Errorf("Invalid outlines should not add chapters, got %d", len(manifest.Document.Chapters))
+	}
+}
@@ -0,0 +1,64 @@
+package document
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/gomcpgo/docgen/pkg/types"
+)
+
+// SetPart groups a run of chapters into a named part, replacing any part that
+// starts at the same chapter
+func (m *Manager) SetPart(docID types.DocumentID, part types.Part) ([]types.Part, error) {
+	if err := docID.Validate(); err != nil {
+		return nil, fmt.Errorf("invalid document ID: %w", err)
+	}
+
+	manifest, err := m.storage.LoadManifest(string(docID))
+	if err != nil {
+		return nil, fmt.Errorf("failed to load document manifest: %w", err)
+	}
+
+	if int(part.LastChapter) > len(manifest.Document.Chapters) {
+		return nil, fmt.Errorf("chapter %d not found in document %s", part.LastChapter, docID)
+	}
+	if err := manifest.Document.SetPart(part); err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	manifest.Document.UpdatedAt = now
+	manifest.UpdatedAt = now
+
+	if err := m.storage.SaveManifest(string(docID), manifest); err != nil {
+		return nil, fmt.Errorf("failed to update manifest: %w", err)
+	}
+
+	return manifest.Document.Parts, nil
+}
+
+// RemovePart removes the part that starts at a chapter. The chapters themselves are kept.
+func (m *Manager) RemovePart(docID types.DocumentID, firstChapter types.ChapterNumber) ([]types.Part, error) {
+	if err := docID.Validate(); err != nil {
+		return nil, fmt.Errorf("invalid document ID: %w", err)
+	}
+
+	manifest, err := m.storage.LoadManifest(string(docID))
+	if err != nil {
+		return nil, fmt.Errorf("failed to load document manifest: %w", err)
+	}
+
+	if !manifest.Document.RemovePart(firstChapter) {
+		return nil, fmt.Errorf("no part starts at chapter %d in document %s", firstChapter, docID)
+	}
+
+	now := time.Now()
+	manifest.Document.UpdatedAt = now
+	manifest.UpdatedAt = now
+
+	if err := m.storage.SaveManifest(string(docID), manifest); err != nil {
+		return nil, fmt.Errorf("failed to update manifest: %w", err)
+	}
+
+	return manifest.Document.Parts, nil
+}
@@ -0,0 +1,224 @@
+package document
+
+import (
+	"fmt"
+	"math"
+	"strings"
+	"time"
+	"unicode"
+
+	"github.com/gomcpgo/docgen/pkg/types"
+)
+
+// progressWindowDays is the number of recent days used to estimate writing pace
+const progressWindowDays = 7
+
+// SetWritingTarget sets the word count goal used for progress projections.
+// Passing nil removes the target.
+func (m *Manager) SetWritingTarget(docID types.DocumentID, target *types.WritingTarget) error {
+	if err := docID.Validate(); err != nil {
+		return fmt.Errorf("invalid document ID: %w", err)
+	}
+	if target != nil {
+		if err := target.Validate(); err != nil {
+			return err
+		}
+	}
+
+	manifest, err := m.storage.LoadManifest(string(docID))
+	if err != nil {
+		return fmt.Errorf("failed to load manifest: %w", err)
+	}
+
+	now := time.Now()
+	manifest.Document.WritingTarget = target
+	manifest.Document.UpdatedAt = now
+	manifest.UpdatedAt = now
+
+	if err := m.storage.SaveManifest(string(docID), manifest); err != nil {
+		return fmt.Errorf("failed to update manifest: %w", err)
+	}
+
+	return nil
+}
+
+// WordCount counts the words in every section of a document. Fenced code
+// blocks and markdown punctuation are not counted.
+func (m *Manager) WordCount(docID types.DocumentID) (int, error) {
+	if err := docID.Validate(); err != nil {
+		return 0, fmt.Errorf("invalid document ID: %w", err)
+	}
+
+	manifest, err := m.storage.LoadManifest(string(docID))
+	if err != nil {
+		return 0, fmt.Errorf("failed to load manifest: %w", err)
+	}
+	if manifest == nil {
+		return 0, nil
+	}
+
+	total := 0
+	for _, chapterRef := range manifest.Document.Chapters {
+		chapter, err := m.storage.LoadChapterMetadata(string(docID), int(chapterRef.Number))
+		if err != nil {
+			return 0, fmt.Errorf("failed to load chapter %d metadata: %w", chapterRef.Number, err)
+		}
+		for _, section := range chapter.Sections {
+			total += countWords(section.Title)
+			content, err := m.storage.LoadSectionContent(string(docID), int(chapter.Number), section.Number)
+			if err != nil {
+				continue
+			}
+			total += countWords(content)
+		}
+	}
+
+	return total, nil
+}
+
+// RecordWordCount stores today's word count snapshot for the document. It
+// counts every section, so callers record once per change rather than once
+// per chapter rebuilt.
+func (m *Manager) RecordWordCount(docID types.DocumentID) error {
+	words, err := m.WordCount(docID)
+	if err != nil {
+		return err
+	}
+
+	stats, err := m.storage.LoadWritingStats(string(docID))
+	if err != nil {
+		return fmt.Errorf("failed to load writing stats: %w", err)
+	}
+	stats.Record(time.Now().Format(types.DateLayout), words)
+	if err := m.storage.SaveWritingStats(string(docID), stats); err != nil {
+		return fmt.Errorf("failed to save writing stats: %w", err)
+	}
+	return nil
+}
+
+// WritingProgress reports the words added on each of the last days (all days
+// when days is zero or less), the recent pace and, when the document has a
+// target, the projected completion date. Today counts the current words, which
+// are not recorded: reading progress doesn't change the document.
+func (m *Manager) WritingProgress(docID types.DocumentID, days int) (*types.WritingProgress, error) {
+	words, err := m.WordCount(docID)
+	if err != nil {
+		return nil, err
+	}
+
+	manifest, err := m.storage.LoadManifest(string(docID))
+	if err != nil {
+		return nil, fmt.Errorf("failed to load manifest: %w", err)
+	}
+	stats, err := m.storage.LoadWritingStats(string(docID))
+	if err != nil {
+		return nil, fmt.Errorf("failed to load writing stats: %w", err)
+	}
+
+	now := time.Now()
+	stats.Record(now.Format(types.DateLayout), words)
+
+	return computeProgress(stats, manifest.Document.WritingTarget, days, now), nil
+}
+
+// computeProgress derives daily progress and projections from the snapshots. The
+// first snapshot counts as written on its day.
+func computeProgress(stats *types.WritingStats, target *types.WritingTarget, days int, now time.Time) *types.WritingProgress {
+	today := truncateToDate(now)
+	progress := &types.WritingProgress{Days: []types.DailyProgress{}}
+
+	previous := 0
+	for _, snapshot := range stats.Snapshots {
+		progress.Days = append(progress.Days, types.DailyProgress{
+			Date:  snapshot.Date,
+			Words: snapshot.Words,
+			Added: snapshot.Words - previous,
+		})
+		previous = snapshot.Words
+	}
+	progress.CurrentWords = previous
+
+	// Pace is the growth since the last snapshot before the window, spread over
+	// the days the document has existed within it
+	windowStart := today.AddDate(0, 0, -progressWindowDays)
+	baseline, baselineDate := 0, windowStart
+	if len(stats.Snapshots) > 0 {
+		if first, err := time.Parse(types.DateLayout, stats.Snapshots[0].Date); err == nil && first.After(windowStart) {
+			// Count the first day itself as a writing day
+			baselineDate = first.AddDate(0, 0, -1)
+		}
+	}
+	for _, snapshot := range stats.Snapshots {
+		date, err := time.Parse(types.DateLayout, snapshot.Date)
+		if err != nil || date.After(windowStart) {
+			break
+		}
+		baseline = snapshot.Words
+	}
+	if elapsed := int(today.Sub(baselineDate).Hours() / 24); elapsed > 0 {
+		progress.AveragePerDay = math.Round(float64(progress.CurrentWords-baseline)/float64(elapsed)*10) / 10
+	}
+
+	if days > 0 && len(progress.Days) > days {
+		progress.Days = progress.Days[len(progress.Days)-days:]
+	}
+
+	if target == nil || target.Words <= 0 {
+		return progress
+	}
+
+	progress.TargetWords = target.Words
+	progress.RemainingWords = max(target.Words-progress.CurrentWords, 0)
+	progress.PercentComplete = math.Round(math.Min(float64(progress.CurrentWords)/float64(target.Words), 1)*1000) / 10
+
+	if progress.RemainingWords == 0 {
+		progress.ProjectedCompletion = today.Format(types.DateLayout)
+	} else if progress.AveragePerDay > 0 {
+		daysNeeded := int(math.Ceil(float64(progress.RemainingWords) / progress.AveragePerDay))
+		progress.ProjectedCompletion = today.AddDate(0, 0, daysNeeded).Format(types.DateLayout)
+	}
+
+	if deadline, err := time.Parse(types.DateLayout, target.Deadline); err == nil {
+		progress.Deadline = target.Deadline
+		// Today still counts as a writing day
+		daysLeft := int(deadline.Sub(today).Hours()/24) + 1
+		if progress.RemainingWords > 0 && daysLeft > 0 {
+			progress.RequiredPerDay = int(math.Ceil(float64(progress.RemainingWords) / float64(daysLeft)))
+		}
+		onTrack := progress.RemainingWords == 0 ||
+			(progress.ProjectedCompletion != "" && progress.ProjectedCompletion <= target.Deadline)
+		progress.OnTrack = &onTrack
+	}
+
+	return progress
+}
+
+// truncateToDate drops the time of day, keeping the calendar date in UTC so that
+// it compares with parsed snapshot dates
+func truncateToDate(t time.Time) time.Time {
+	year, month, day := t.Date()
+	return time.Date(year, month, day, 0, 0, 0, 0, time.UTC)
+}
+
+// countWords counts the words in markdown text outside fenced code blocks. Tokens
+// made only of markup such as "#", "-" or "|" are not words.
+func countWords(text string) int {
+	count := 0
+	inFence := false
+	for _, line := range strings.Split(text, "\n") {
+		trimmed := strings.TrimSpace(line)
+		if strings.HasPrefix(trimmed, "```") || strings.HasPrefix(trimmed, "~~~") {
+			inFence = !inFence
+			continue
+		}
+		if inFence {
+			continue
+		}
+		for _, field := range strings.Fields(line) {
+			if strings.IndexFunc(field, func(r rune) bool { return unicode.IsLetter(r) || unicode.IsDigit(r) }) >= 0 {
+				count++
+			}
+		}
+	}
+	return count
+}
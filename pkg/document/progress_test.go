@@ -0,0 +1,155 @@
+package document
+
+import (
+	"context"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/gomcpgo/docgen/pkg/types"
+)
+
+func TestCountWords(t *testing.T) {
+	tests := []struct {
+		name string
+		text string
+		want int
+	}{
+		{"plain", "The quick brown fox.", 4},
+		{"markup", "## Heading\n\n- one item\n- two items", 5},
+		{"code fence", "Before.\n\n```go\nfunc main() {}\n```\n\nAfter.", 2},
+		{"table pipes", "| a | b |\n|---|---|", 2},
+		{"empty", "", 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := countWords(tt.text); got != tt.want {
+				t.Errorf("countWords(%q) = %d, want %d", tt.text, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestComputeProgress(t *testing.T) {
+	now := time.Date(2024, 11, 10, 15, 0, 0, 0, time.UTC)
+	stats := &types.WritingStats{Snapshots: []types.WordCountSnapshot{
+		{Date: "2024-11-01", Words: 1000},
+		{Date: "2024-11-03", Words: 1500},
+		{Date: "2024-11-08", Words: 2900},
+		{Date: "2024-11-10", Words: 3100},
+	}}
+	target := &types.WritingTarget{Words: 10000, Deadline: "2024-11-30"}
+
+	progress := computeProgress(stats, target, 3, now)
+
+	if progress.CurrentWords != 3100 {
+		t.Errorf("CurrentWords = %d, want 3100", progress.CurrentWords)
+	}
+	if len(progress.Days) != 3 || progress.Days[0].Date != "2024-11-03" {
+		t.Fatalf("Expected the last 3 days, got %+v", progress.Days)
+	}
+	if progress.Days[1].Added != 1400 || progress.Days[2].Added != 200 {
+		t.Errorf("Unexpected words added per day: %+v", progress.Days)
+	}
+
+	// 1600 words since the 2024-11-03 snapshot over seven days
+	if progress.AveragePerDay != 228.6 {
+		t.Errorf("AveragePerDay = %v, want 228.6", progress.AveragePerDay)
+	}
+	if progress.RemainingWords != 6900 || progress.PercentComplete != 31 {
+		t.Errorf("Unexpected remaining %d / percent %v", progress.RemainingWords, progress.PercentComplete)
+	}
+	// ceil(6900 / 228.6) = 31 days
+	if progress.ProjectedCompletion != "2024-12-11" {
+		t.Errorf("ProjectedCompletion = %q, want 2024-12-11", progress.ProjectedCompletion)
+	}
+	// 6900 words over 21 days including today
+	if progress.RequiredPerDay != 329 {
+		t.Errorf("RequiredPerDay = %d, want 329", progress.RequiredPerDay)
+	}
+	if progress.OnTrack == nil || *progress.OnTrack {
+		t.Errorf("Expected the document to be behind schedule")
+	}
+}
+
+func TestComputeProgress_NewDocument(t *testing.T) {
+	now := time.Date(2024, 11, 2, 9, 0, 0, 0, time.UTC)
+	stats := &types.WritingStats{Snapshots: []types.WordCountSnapshot{
+		{Date: "2024-11-01", Words: 800},
+		{Date: "2024-11-02", Words: 1200},
+	}}
+
+	progress := computeProgress(stats, &types.WritingTarget{Words: 1000}, 0, now)
+
+	if progress.Days[0].Added != 800 {
+		t.Errorf("The first snapshot should count as written on its day, got %+v", progress.Days[0])
+	}
+	if progress.AveragePerDay != 600 {
+		t.Errorf("AveragePerDay = %v, want 600", progress.AveragePerDay)
+	}
+	if progress.RemainingWords != 0 || progress.PercentComplete != 100 || progress.ProjectedCompletion != "2024-11-02" {
+		t.Errorf("A finished target should be complete today, got %+v", progress)
+	}
+	if progress.OnTrack != nil {
+		t.Errorf("OnTrack should be unset without a deadline")
+	}
+}
+
+func TestManager_WritingProgress(t *testing.T) {
+	manager, tempDir := setupTestManager(t)
+	defer os.RemoveAll(tempDir)
+
+	docID, err := manager.CreateDocument("Test Document", "Test Author", types.DocumentTypeBook)
+	if err != nil {
+		t.Fatalf("Failed to create document: %v", err)
+	}
+	chapterNum, err := manager.AddChapter(context.Background(), docID, "Opening", nil)
+	if err != nil {
+		t.Fatalf("Failed to add chapter: %v", err)
+	}
+	if _, err := manager.AddSection(context.Background(), docID, chapterNum, "Scene", "It was a dark and stormy night.", 1); err != nil {
+		t.Fatalf("Failed to add section: %v", err)
+	}
+
+	if err := manager.SetWritingTarget(docID, &types.WritingTarget{Words: 100, Deadline: "30/11/2024"}); err == nil {
+		t.Errorf("Expected an error for an invalid deadline")
+	}
+	if err := manager.SetWritingTarget(docID, &types.WritingTarget{Words: 100}); err != nil {
+		t.Fatalf("SetWritingTarget() error = %v", err)
+	}
+
+	// Reading progress counts today's words without recording them
+	progress, err := manager.WritingProgress(docID, 30)
+	if err != nil {
+		t.Fatalf("WritingProgress() error = %v", err)
+	}
+	if progress.CurrentWords != 8 || len(progress.Days) != 1 {
+		t.Errorf("Unexpected progress before recording %+v", progress)
+	}
+	if stats, _ := manager.storage.LoadWritingStats(string(docID)); len(stats.Snapshots) != 0 {
+		t.Errorf("WritingProgress() should not record a snapshot, got %+v", stats.Snapshots)
+	}
+
+	if err := manager.RecordWordCount(docID); err != nil {
+		t.Fatalf("RecordWordCount() error = %v", err)
+	}
+	stats, err := manager.storage.LoadWritingStats(string(docID))
+	if err != nil {
+		t.Fatalf("Failed to load writing stats: %v", err)
+	}
+	if len(stats.Snapshots) != 1 || stats.Snapshots[0].Words != 8 {
+		t.Errorf("Expected one snapshot of 8 words, got %+v", stats.Snapshots)
+	}
+
+	progress, err = manager.WritingProgress(docID, 30)
+	if err != nil {
+		t.Fatalf("WritingProgress() error = %v", err)
+	}
+	if progress.CurrentWords != 8 || progress.TargetWords != 100 || progress.RemainingWords != 92 {
+		t.Errorf("Unexpected progress %+v", progress)
+	}
+	if len(progress.Days) != 1 || progress.Days[0].Added != 8 {
+		t.Errorf("Expected a single day with 8 words added, got %+v", progress.Days)
+	}
+}
@@ -0,0 +1,101 @@
+package document
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"sort"
+
+	"github.com/gomcpgo/docgen/pkg/types"
+)
+
+// StorageUsage reports the disk space used by each document, with its exports,
+// and by the root directory as a whole, against the configured quotas. Measuring
+// stops when ctx is done.
+func (m *Manager) StorageUsage(ctx context.Context) (*types.StorageUsage, error) {
+	documentIDs, err := m.storage.ListDocuments()
+	if err != nil {
+		return nil, fmt.Errorf("failed to list documents: %w", err)
+	}
+
+	usage := &types.StorageUsage{
+		MaxTotalBytes:    m.config.MaxTotalSize,
+		MaxDocumentBytes: m.config.MaxDocumentSize,
+		Documents:        []types.DocumentUsage{},
+	}
+	var documentsBytes int64
+	for _, docID := range documentIDs {
+		size, err := m.DocumentSize(ctx, types.DocumentID(docID), 1)
+		if err != nil {
+			return nil, fmt.Errorf("failed to measure document %s: %w", docID, err)
+		}
+		document := types.DocumentUsage{
+			DocumentID:   types.DocumentID(docID),
+			ContentBytes: size.ChaptersBytes + size.SnapshotsBytes + size.OtherBytes,
+			AssetsBytes:  size.AssetsBytes,
+			ExportsBytes: size.ExportsBytes,
+			TotalBytes:   size.TotalBytes,
+			OverQuota:    m.config.MaxDocumentSize > 0 && size.TotalBytes > m.config.MaxDocumentSize,
+		}
+		if manifest, err := m.storage.LoadManifest(docID); err == nil {
+			document.Title = manifest.Document.Title
+		}
+		usage.Documents = append(usage.Documents, document)
+		documentsBytes += size.TotalBytes
+	}
+	sort.SliceStable(usage.Documents, func(i, j int) bool {
+		return usage.Documents[i].TotalBytes > usage.Documents[j].TotalBytes
+	})
+
+	usage.TotalBytes, err = m.rootBytes(ctx)
+	if err != nil {
+		return nil, err
+	}
+	usage.SharedBytes = usage.TotalBytes - documentsBytes
+	return usage, nil
+}
+
+// CheckStorageQuota fails if writing adding more bytes to a document would take
+// it or the root directory over its quota. Exports can't be sized in advance,
+// so they count once written: a document already over its quota can't export.
+func (m *Manager) CheckStorageQuota(ctx context.Context, docID types.DocumentID, adding int64) error {
+	if m.config.MaxDocumentSize > 0 {
+		size, err := m.DocumentSize(ctx, docID, 1)
+		if err != nil {
+			return fmt.Errorf("failed to check storage quota: %w", err)
+		}
+		if size.TotalBytes+adding > m.config.MaxDocumentSize {
+			return fmt.Errorf("storage quota exceeded: document %s uses %d of its %d bytes and this change needs %d more; remove old exports or unused assets (check_assets with prune) to free space, and see get_storage_usage",
+				docID, size.TotalBytes, m.config.MaxDocumentSize, adding)
+		}
+	}
+	return m.checkTotalQuota(ctx, adding)
+}
+
+// checkTotalQuota fails if adding more bytes would take the root directory over its quota
+func (m *Manager) checkTotalQuota(ctx context.Context, adding int64) error {
+	if m.config.MaxTotalSize <= 0 {
+		return nil
+	}
+	total, err := m.rootBytes(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to check storage quota: %w", err)
+	}
+	if total+adding > m.config.MaxTotalSize {
+		return fmt.Errorf("storage quota exceeded: all documents use %d of %d bytes and this change needs %d more; delete or archive documents to free space, and see get_storage_usage",
+			total, m.config.MaxTotalSize, adding)
+	}
+	return nil
+}
+
+// rootBytes returns the disk space used by everything under the root directory
+func (m *Manager) rootBytes(ctx context.Context) (int64, error) {
+	var total int64
+	err := walkFiles(ctx, m.config.RootDir, func(path string, bytes int64) {
+		total += bytes
+	})
+	if err != nil && !os.IsNotExist(err) {
+		return 0, fmt.Errorf("failed to measure root directory: %w", err)
+	}
+	return total, nil
+}
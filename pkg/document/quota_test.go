@@ -0,0 +1,64 @@
+package document
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/gomcpgo/docgen/pkg/types"
+)
+
+func TestManager_StorageUsageAndQuotas(t *testing.T) {
+	manager, tempDir := setupTestManager(t)
+	defer os.RemoveAll(tempDir)
+	manager.config.ExportsDir = filepath.Join(tempDir, "exports")
+
+	docID, _ := manager.CreateDocument("Quota Book", "Test Author", types.DocumentTypeBook)
+	chapterNum, _ := manager.AddChapter(context.Background(), docID, "Only", nil)
+	sectionNum, err := manager.AddSection(context.Background(), docID, chapterNum, "Body", strings.Repeat("word ", 100), 1)
+	if err != nil {
+		t.Fatalf("AddSection() error = %v", err)
+	}
+	os.MkdirAll(manager.config.ExportsDir, 0755)
+	os.WriteFile(manager.config.ExportPath(string(docID), "pdf"), make([]byte, 300), 0644)
+
+	usage, err := manager.StorageUsage(context.Background())
+	if err != nil {
+		t.Fatalf("StorageUsage() error = %v", err)
+	}
+	if len(usage.Documents) != 1 {
+		t.Fatalf("Expected one document, got %+v", usage.Documents)
+	}
+	document := usage.Documents[0]
+	if document.Title != "Quota Book" || document.ExportsBytes != 300 || document.ContentBytes < 500 || document.OverQuota {
+		t.Errorf("Unexpected document usage %+v", document)
+	}
+	if usage.TotalBytes < document.TotalBytes || usage.SharedBytes != usage.TotalBytes-document.TotalBytes {
+		t.Errorf("Unexpected totals %+v", usage)
+	}
+
+	// A per-document quota stops growth but lets content shrink
+	manager.config.MaxDocumentSize = document.TotalBytes + 50
+	if _, err := manager.AddSection(context.Background(), docID, chapterNum, "More", strings.Repeat("more ", 20), 1); err == nil || !strings.Contains(err.Error(), "storage quota exceeded") {
+		t.Errorf("AddSection() over quota error = %v", err)
+	}
+	if err := manager.UpdateSection(context.Background(), docID, chapterNum, sectionNum, "Short now."); err != nil {
+		t.Errorf("UpdateSection() that shrinks content error = %v", err)
+	}
+
+	// So does a quota on the root directory
+	manager.config.MaxDocumentSize = 0
+	manager.config.MaxTotalSize = usage.TotalBytes
+	if _, err := manager.AddSection(context.Background(), docID, chapterNum, "More", strings.Repeat("more ", 200), 1); err == nil || !strings.Contains(err.Error(), "all documents use") {
+		t.Errorf("AddSection() over total quota error = %v", err)
+	}
+
+	// Measuring the quota stops with the request
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, err := manager.AddSection(ctx, docID, chapterNum, "More", "Text.", 1); err == nil || !strings.Contains(err.Error(), "context canceled") {
+		t.Errorf("AddSection() with a canceled request error = %v", err)
+	}
+}
@@ -0,0 +1,149 @@
+package document
+
+import (
+	"context"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/gomcpgo/docgen/pkg/types"
+)
+
+// RenameDocument retitles a document and optionally moves it to a new ID. A
+// move renames the document directory, points paths inside the document's
+// files at the new directory and records the new ID in the manifest. It
+// returns the document's ID after the change.
+func (m *Manager) RenameDocument(ctx context.Context, docID types.DocumentID, options types.RenameOptions) (types.DocumentID, error) {
+	if err := docID.Validate(); err != nil {
+		return "", fmt.Errorf("invalid document ID: %w", err)
+	}
+	if options.NewID != "" && options.DeriveID {
+		return "", fmt.Errorf("a new document ID cannot be both given and derived")
+	}
+	if options.Title == "" && options.Author == "" && options.NewID == "" && !options.DeriveID {
+		return "", fmt.Errorf("nothing to change: give a title, author or new document ID")
+	}
+
+	manifest, err := m.storage.LoadManifest(string(docID))
+	if err != nil {
+		return "", fmt.Errorf("failed to load document manifest: %w", err)
+	}
+
+	newID := options.NewID
+	if options.DeriveID {
+		title := options.Title
+		if title == "" {
+			title = manifest.Document.Title
+		}
+		newID = types.DocumentID(generateDocumentID(title))
+	}
+	if newID == docID {
+		newID = ""
+	}
+	if newID != "" {
+		if err := newID.Validate(); err != nil {
+			return "", fmt.Errorf("invalid new document ID: %w", err)
+		}
+		exists, err := m.storage.DocumentExists(string(newID))
+		if err != nil {
+			return "", fmt.Errorf("failed to check document existence: %w", err)
+		}
+		if exists {
+			return "", fmt.Errorf("document %s already exists", newID)
+		}
+	}
+
+	if options.Title != "" || options.Author != "" {
+		if options.Title != "" {
+			manifest.Document.Title = options.Title
+		}
+		if options.Author != "" {
+			if len(manifest.Document.Authors) == 0 {
+				manifest.Document.Authors = types.AuthorList{{Name: options.Author}}
+			} else {
+				manifest.Document.Authors[0].Name = options.Author
+			}
+		}
+		now := time.Now()
+		manifest.Document.UpdatedAt = now
+		manifest.UpdatedAt = now
+		if err := m.storage.SaveManifest(string(docID), manifest); err != nil {
+			return "", fmt.Errorf("failed to update manifest: %w", err)
+		}
+	}
+
+	if newID == "" {
+		return docID, nil
+	}
+	if err := m.moveDocument(ctx, docID, newID); err != nil {
+		return "", err
+	}
+	return newID, nil
+}
+
+// moveDocument moves a document's directory from docID to newID and records
+// the new ID in its manifest
+func (m *Manager) moveDocument(ctx context.Context, docID, newID types.DocumentID) error {
+	if err := m.SyncDocument(ctx, docID); err != nil {
+		return err
+	}
+	oldDir := m.config.DocumentPath(string(docID))
+	newDir := m.config.DocumentPath(string(newID))
+	if err := os.Rename(oldDir, newDir); err != nil {
+		return fmt.Errorf("failed to rename document directory: %w", err)
+	}
+	if err := rewriteDocumentPaths(newDir, oldDir); err != nil {
+		os.Rename(newDir, oldDir)
+		return fmt.Errorf("failed to update paths in document: %w", err)
+	}
+	if err := m.publishDocument(newID); err != nil {
+		os.Rename(newDir, oldDir)
+		return err
+	}
+
+	// The manifest records the document's ID
+	manifest, err := m.storage.LoadManifest(string(newID))
+	if err == nil {
+		manifest.Document.ID = newID
+		err = m.storage.SaveManifest(string(newID), manifest)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to update moved manifest: %w", err)
+	}
+
+	// Storage that keeps documents elsewhere still holds them under the old ID
+	if err := m.storage.DeleteDocument(string(docID)); err != nil {
+		return fmt.Errorf("failed to remove document %s after the move: %w", docID, err)
+	}
+	return nil
+}
+
+// rewriteDocumentPaths points absolute paths into oldDir, such as image paths
+// given as full paths, at dir instead. Only markdown and YAML files are read.
+func rewriteDocumentPaths(dir, oldDir string) error {
+	oldPrefix := oldDir + string(filepath.Separator)
+	newPrefix := dir + string(filepath.Separator)
+	return filepath.WalkDir(dir, func(path string, entry fs.DirEntry, err error) error {
+		if err != nil || entry.IsDir() {
+			return err
+		}
+		if ext := filepath.Ext(path); ext != ".md" && ext != ".yaml" {
+			return nil
+		}
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return err
+		}
+		if !strings.Contains(string(data), oldPrefix) {
+			return nil
+		}
+		info, err := entry.Info()
+		if err != nil {
+			return err
+		}
+		return os.WriteFile(path, []byte(strings.ReplaceAll(string(data), oldPrefix, newPrefix)), info.Mode())
+	})
+}
@@ -0,0 +1,77 @@
+package document
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/gomcpgo/docgen/pkg/types"
+)
+
+func TestManager_RenameDocument(t *testing.T) {
+	manager, tempDir := setupTestManager(t)
+	defer os.RemoveAll(tempDir)
+
+	docID, _ := manager.CreateDocument("Draft Title", "First Author", types.DocumentTypeBook)
+	chapterNum, _ := manager.AddChapter(context.Background(), docID, "Opening", nil)
+	oldImage := filepath.Join(manager.config.AssetsPath(string(docID)), "map.png")
+	manager.AddImage(docID, chapterNum, oldImage, "A map", "here", "", "")
+	manager.AddSection(context.Background(), docID, chapterNum, "Start", "![Map]("+oldImage+")", 1)
+
+	// Retitling keeps the ID
+	renamed, err := manager.RenameDocument(context.Background(), docID, types.RenameOptions{Title: "Final Title", Author: "Second Author"})
+	if err != nil {
+		t.Fatalf("RenameDocument() error = %v", err)
+	}
+	manifest, _ := manager.GetDocumentStructure(docID)
+	if renamed != docID || manifest.Document.Title != "Final Title" || manifest.Document.Authors[0].Name != "Second Author" {
+		t.Errorf("Unexpected rename: %s %+v", renamed, manifest.Document)
+	}
+
+	// Moving renames the directory and the paths that point into it
+	newID, err := manager.RenameDocument(context.Background(), docID, types.RenameOptions{NewID: "final-title"})
+	if err != nil {
+		t.Fatalf("RenameDocument() error = %v", err)
+	}
+	if newID != "final-title" {
+		t.Errorf("RenameDocument() = %s, want final-title", newID)
+	}
+	if exists, _ := manager.storage.DocumentExists(string(docID)); exists {
+		t.Errorf("Document %s should no longer exist", docID)
+	}
+	manifest, err = manager.GetDocumentStructure(newID)
+	if err != nil || manifest.Document.ID != newID || manifest.Document.Title != "Final Title" {
+		t.Fatalf("Unexpected moved manifest: %v %+v", err, manifest)
+	}
+	newImage := filepath.Join(manager.config.AssetsPath(string(newID)), "map.png")
+	if path := manifest.Document.Chapters[0].Figures[0].ImagePath; path != newImage {
+		t.Errorf("Figure path = %s, want %s", path, newImage)
+	}
+	content, _ := manager.GetSectionContent(newID, chapterNum, types.SectionNumber{1, 1})
+	if !strings.Contains(content, newImage) || strings.Contains(content, string(docID)) {
+		t.Errorf("Content still points at the old directory:\n%s", content)
+	}
+
+	// A derived ID comes from the title
+	derived, err := manager.RenameDocument(context.Background(), newID, types.RenameOptions{Title: "Second Edition", DeriveID: true})
+	if err != nil || !strings.HasPrefix(string(derived), "second-edition-") {
+		t.Errorf("RenameDocument() = %s, %v, want a second-edition ID", derived, err)
+	}
+
+	other, _ := manager.CreateDocument("Other", "Author", types.DocumentTypeReport)
+	for _, tt := range []struct {
+		options types.RenameOptions
+		wantErr string
+	}{
+		{types.RenameOptions{}, "nothing to change"},
+		{types.RenameOptions{NewID: other}, "already exists"},
+		{types.RenameOptions{NewID: "bad id"}, "invalid new document ID"},
+		{types.RenameOptions{NewID: "x", DeriveID: true}, "both given and derived"},
+	} {
+		if _, err := manager.RenameDocument(context.Background(), derived, tt.options); err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+			t.Errorf("RenameDocument(%+v) error = %v, want %q", tt.options, err, tt.wantErr)
+		}
+	}
+}
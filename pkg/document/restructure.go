@@ -0,0 +1,615 @@
+package document
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/gomcpgo/docgen/pkg/types"
+)
+
+// referencePattern matches figure, table and listing IDs in section content,
+// both as anchors ({#fig-1.2}) and cross-references (@fig-1.2) or asset names
+var referencePattern = regexp.MustCompile(`\b(?:` + types.FigureIDPattern + `|(?:table|lst)-\d+\.\d+)\b`)
+
+// RestructureResult describes how a merge or split renumbered a document
+type RestructureResult struct {
+	// Chapter is the merged chapter or the newly created one
+	Chapter types.ChapterNumber `json:"chapter"`
+	// Sections maps the old numbers of the moved sections to their new numbers
+	Sections map[string]string `json:"sections"`
+	// IDs maps every renamed figure, table and listing ID to its new ID.
+	// References in section content have already been updated.
+	IDs map[string]string `json:"ids"`
+}
+
+// restructure collects the renames made while merging, splitting or inserting
+// chapters
+type restructure struct {
+	docID  types.DocumentID
+	result *RestructureResult
+	assets [][2]string // old and new asset paths, renamed at the end
+}
+
+func newRestructure(docID types.DocumentID, chapter types.ChapterNumber) *restructure {
+	return &restructure{
+		docID: docID,
+		result: &RestructureResult{
+			Chapter:  chapter,
+			Sections: make(map[string]string),
+			IDs:      make(map[string]string),
+		},
+	}
+}
+
+// MergeChapters appends the sections, figures and tables of chapter from to chapter
+// into and removes chapter from. The moved top-level sections are numbered after
+// into's own, and later chapters move up to close the gap.
+func (m *Manager) MergeChapters(ctx context.Context, docID types.DocumentID, into, from types.ChapterNumber) (*RestructureResult, error) {
+	if err := docID.Validate(); err != nil {
+		return nil, fmt.Errorf("invalid document ID: %w", err)
+	}
+	if into == from {
+		return nil, fmt.Errorf("cannot merge chapter %d into itself", into)
+	}
+
+	if err := m.SyncDocument(ctx, docID); err != nil {
+		return nil, err
+	}
+
+	manifest, err := m.storage.LoadManifest(string(docID))
+	if err != nil {
+		return nil, fmt.Errorf("failed to load manifest: %w", err)
+	}
+	for _, chapterNum := range []types.ChapterNumber{into, from} {
+		exists, err := m.storage.ChapterExists(string(docID), int(chapterNum))
+		if err != nil {
+			return nil, fmt.Errorf("failed to check chapter existence: %w", err)
+		}
+		if !exists {
+			return nil, fmt.Errorf("chapter %d not found in document %s", chapterNum, docID)
+		}
+	}
+
+	target, err := m.storage.LoadChapterMetadata(string(docID), int(into))
+	if err != nil {
+		return nil, fmt.Errorf("failed to load chapter %d: %w", into, err)
+	}
+	source, err := m.storage.LoadChapterMetadata(string(docID), int(from))
+	if err != nil {
+		return nil, fmt.Errorf("failed to load chapter %d: %w", from, err)
+	}
+
+	// The target moves up a place when an earlier chapter is merged into it
+	final := into
+	if into > from {
+		final--
+	}
+	r := newRestructure(docID, final)
+
+	// Top-level sections of the source continue after the target's
+	offset := 0
+	for _, section := range target.Sections {
+		if len(section.Number) == 2 && section.Number[1] > offset {
+			offset = section.Number[1]
+		}
+	}
+	for _, section := range source.Sections {
+		content, err := m.storage.LoadSectionContent(string(docID), int(from), section.Number)
+		if err != nil {
+			return nil, fmt.Errorf("failed to load section %s: %w", section.Number.String(), err)
+		}
+
+		oldNumber := section.Number.String()
+		section.Number = renumberSection(section.Number, into, offset)
+		if err := m.storage.SaveSectionContent(string(docID), int(into), section.Number, content); err != nil {
+			return nil, fmt.Errorf("failed to save section content: %w", err)
+		}
+		target.Sections = append(target.Sections, section)
+		r.result.Sections[oldNumber] = renumberSection(section.Number, final, 0).String()
+	}
+
+	for _, figure := range source.Figures {
+		figure.Sequence = len(target.Figures) + 1
+		figure.Anchor = moveAnchor(figure.Anchor, final, offset)
+		r.renameFigure(m, &figure, final)
+		target.Figures = append(target.Figures, figure)
+	}
+	for _, table := range source.Tables {
+		table.Sequence = len(target.Tables) + 1
+		table.Anchor = moveAnchor(table.Anchor, final, offset)
+		r.renameTable(&table, final)
+		target.Tables = append(target.Tables, table)
+	}
+	for _, listing := range source.Listings {
+		listing.Sequence = len(target.Listings) + 1
+		r.renameListing(&listing, final)
+		target.Listings = append(target.Listings, listing)
+	}
+
+	target.UpdatedAt = time.Now()
+	if err := m.storage.SaveChapterMetadata(string(docID), target); err != nil {
+		return nil, fmt.Errorf("failed to save chapter metadata: %w", err)
+	}
+
+	// Remove the source and close the gap it leaves
+	if err := m.storage.DeleteChapter(string(docID), int(from)); err != nil {
+		return nil, fmt.Errorf("failed to delete chapter: %w", err)
+	}
+	var chapters []types.Chapter
+	for _, chapter := range manifest.Document.Chapters {
+		if chapter.Number != from {
+			chapters = append(chapters, chapter)
+		}
+	}
+	manifest.Document.Chapters = chapters
+	manifest.Document.RemoveChapterFromParts(from)
+	if err := m.renumberChapters(string(docID), manifest, int(from)+1, -1); err != nil {
+		return nil, fmt.Errorf("failed to renumber chapters: %w", err)
+	}
+	for i := range manifest.Document.Chapters {
+		chapter := &manifest.Document.Chapters[i]
+		if chapter.Number > from {
+			chapter.Number--
+			if err := m.relabelChapter(r, manifest, chapter.Number); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	if err := m.finishRestructure(r, manifest); err != nil {
+		return nil, err
+	}
+	return r.result, nil
+}
+
+// SplitChapter moves the top-level section at and every section after it into a
+// new chapter inserted directly after chapterNum. Figures, tables and listings
+// referenced only by the moved sections move with them. An empty title reuses the title of
+// the section the chapter is split at.
+func (m *Manager) SplitChapter(ctx context.Context, docID types.DocumentID, chapterNum types.ChapterNumber, at types.SectionNumber, title string) (*RestructureResult, error) {
+	if err := docID.Validate(); err != nil {
+		return nil, fmt.Errorf("invalid document ID: %w", err)
+	}
+	if len(at) != 2 {
+		return nil, fmt.Errorf("a chapter can only be split at a top-level section such as %d.2", chapterNum)
+	}
+
+	if err := m.SyncDocument(ctx, docID); err != nil {
+		return nil, err
+	}
+
+	manifest, err := m.storage.LoadManifest(string(docID))
+	if err != nil {
+		return nil, fmt.Errorf("failed to load manifest: %w", err)
+	}
+	exists, err := m.storage.ChapterExists(string(docID), int(chapterNum))
+	if err != nil {
+		return nil, fmt.Errorf("failed to check chapter existence: %w", err)
+	}
+	if !exists {
+		return nil, fmt.Errorf("chapter %d not found in document %s", chapterNum, docID)
+	}
+
+	chapter, err := m.storage.LoadChapterMetadata(string(docID), int(chapterNum))
+	if err != nil {
+		return nil, fmt.Errorf("failed to load chapter: %w", err)
+	}
+
+	splitIndex := -1
+	for i, section := range chapter.Sections {
+		if m.sectionNumbersEqual(section.Number, at) {
+			splitIndex = i
+			break
+		}
+	}
+	if splitIndex == -1 {
+		return nil, fmt.Errorf("section %s not found in chapter %d", at.String(), chapterNum)
+	}
+	if splitIndex == 0 {
+		return nil, fmt.Errorf("cannot split chapter %d at its first section", chapterNum)
+	}
+	if title == "" {
+		title = chapter.Sections[splitIndex].Title
+	}
+
+	newNum := chapterNum + 1
+	r := newRestructure(docID, newNum)
+
+	// Make room for the new chapter
+	if err := m.renumberChapters(string(docID), manifest, int(newNum), 1); err != nil {
+		return nil, fmt.Errorf("failed to renumber chapters: %w", err)
+	}
+	for i := range manifest.Document.Chapters {
+		later := &manifest.Document.Chapters[i]
+		if later.Number > chapterNum {
+			later.Number++
+			if err := m.relabelChapter(r, manifest, later.Number); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	now := time.Now()
+	newChapter := &types.Chapter{
+		Number:    newNum,
+		Title:     title,
+		Sections:  []types.Section{},
+		Figures:   []types.Figure{},
+		Tables:    []types.Table{},
+		CreatedAt: now,
+		UpdatedAt: now,
+	}
+	if err := m.placeChapter(string(docID), manifest, newNum); err != nil {
+		return nil, err
+	}
+	if err := m.storage.CreateChapterStructure(string(docID), newChapter); err != nil {
+		return nil, fmt.Errorf("failed to create chapter structure: %w", err)
+	}
+
+	// Move the sections, noting which figures and tables each half refers to
+	keptRefs := make(map[string]bool)
+	movedRefs := make(map[string]bool)
+	for i, section := range chapter.Sections {
+		content, err := m.storage.LoadSectionContent(string(docID), int(chapterNum), section.Number)
+		if err != nil {
+			return nil, fmt.Errorf("failed to load section %s: %w", section.Number.String(), err)
+		}
+		refs := keptRefs
+		if i >= splitIndex {
+			refs = movedRefs
+		}
+		for _, id := range referencePattern.FindAllString(content, -1) {
+			refs[id] = true
+		}
+		if i < splitIndex {
+			continue
+		}
+
+		oldNumber := section.Number
+		section.Number = renumberSection(section.Number, newNum, -(at[1] - 1))
+		if err := m.storage.SaveSectionContent(string(docID), int(newNum), section.Number, content); err != nil {
+			return nil, fmt.Errorf("failed to save section content: %w", err)
+		}
+		if err := m.storage.DeleteSectionFile(string(docID), int(chapterNum), oldNumber); err != nil {
+			return nil, fmt.Errorf("failed to delete section file: %w", err)
+		}
+		newChapter.Sections = append(newChapter.Sections, section)
+		r.result.Sections[oldNumber.String()] = section.Number.String()
+	}
+	chapter.Sections = chapter.Sections[:splitIndex]
+
+	// Anchored figures and tables belong to the half holding their section
+	for _, figure := range chapter.Figures {
+		if figure.Anchor != nil && len(figure.Anchor.Section) > 1 {
+			anchoredRefs(figure.Anchor, at, keptRefs, movedRefs)[string(figure.ID)] = true
+		}
+	}
+	for _, table := range chapter.Tables {
+		if table.Anchor != nil && len(table.Anchor.Section) > 1 {
+			anchoredRefs(table.Anchor, at, keptRefs, movedRefs)[string(table.ID)] = true
+		}
+	}
+
+	// Figures, tables and listings move when only the moved sections refer to them
+	var keptFigures []types.Figure
+	for _, figure := range chapter.Figures {
+		id := string(figure.ID)
+		if movedRefs[id] && !keptRefs[id] {
+			figure.Sequence = len(newChapter.Figures) + 1
+			figure.Anchor = moveAnchor(figure.Anchor, newNum, -(at[1] - 1))
+			r.renameFigure(m, &figure, newNum)
+			newChapter.Figures = append(newChapter.Figures, figure)
+		} else {
+			figure.Sequence = len(keptFigures) + 1
+			r.renameFigure(m, &figure, chapterNum)
+			keptFigures = append(keptFigures, figure)
+		}
+	}
+	chapter.Figures = keptFigures
+
+	var keptTables []types.Table
+	for _, table := range chapter.Tables {
+		id := string(table.ID)
+		if movedRefs[id] && !keptRefs[id] {
+			table.Sequence = len(newChapter.Tables) + 1
+			table.Anchor = moveAnchor(table.Anchor, newNum, -(at[1] - 1))
+			r.renameTable(&table, newNum)
+			newChapter.Tables = append(newChapter.Tables, table)
+		} else {
+			table.Sequence = len(keptTables) + 1
+			r.renameTable(&table, chapterNum)
+			keptTables = append(keptTables, table)
+		}
+	}
+	chapter.Tables = keptTables
+
+	var keptListings []types.Listing
+	for _, listing := range chapter.Listings {
+		id := string(listing.ID)
+		if movedRefs[id] && !keptRefs[id] {
+			listing.Sequence = len(newChapter.Listings) + 1
+			r.renameListing(&listing, newNum)
+			newChapter.Listings = append(newChapter.Listings, listing)
+		} else {
+			listing.Sequence = len(keptListings) + 1
+			r.renameListing(&listing, chapterNum)
+			keptListings = append(keptListings, listing)
+		}
+	}
+	chapter.Listings = keptListings
+
+	chapter.UpdatedAt = now
+	if err := m.storage.SaveChapterMetadata(string(docID), chapter); err != nil {
+		return nil, fmt.Errorf("failed to save chapter metadata: %w", err)
+	}
+	if err := m.storage.SaveChapterMetadata(string(docID), newChapter); err != nil {
+		return nil, fmt.Errorf("failed to save chapter metadata: %w", err)
+	}
+
+	// Insert the new chapter after the one it was split from
+	var chapters []types.Chapter
+	for _, existing := range manifest.Document.Chapters {
+		chapters = append(chapters, existing)
+		if existing.Number == chapterNum {
+			chapters = append(chapters, types.Chapter{
+				Number:    newNum,
+				Title:     title,
+				CreatedAt: now,
+				UpdatedAt: now,
+			})
+		}
+	}
+	manifest.Document.Chapters = chapters
+
+	// The new chapter stays in the part of the chapter it was split from
+	manifest.Document.InsertChapterInParts(newNum)
+	for i := range manifest.Document.Parts {
+		if manifest.Document.Parts[i].LastChapter == chapterNum {
+			manifest.Document.Parts[i].LastChapter = newNum
+		}
+	}
+
+	if err := m.finishRestructure(r, manifest); err != nil {
+		return nil, err
+	}
+	return r.result, nil
+}
+
+// BalancedSplitPoint returns the top-level section at which splitting a chapter
+// leaves the two halves closest in word count
+func (m *Manager) BalancedSplitPoint(docID types.DocumentID, chapterNum types.ChapterNumber) (types.SectionNumber, error) {
+	if err := docID.Validate(); err != nil {
+		return nil, fmt.Errorf("invalid document ID: %w", err)
+	}
+
+	chapter, err := m.storage.LoadChapterMetadata(string(docID), int(chapterNum))
+	if err != nil {
+		return nil, fmt.Errorf("failed to load chapter: %w", err)
+	}
+
+	// Words before each section, and in the whole chapter
+	before := make([]int, len(chapter.Sections))
+	total := 0
+	for i, section := range chapter.Sections {
+		before[i] = total
+		total += countWords(section.Title)
+		content, err := m.storage.LoadSectionContent(string(docID), int(chapterNum), section.Number)
+		if err != nil {
+			return nil, fmt.Errorf("failed to load section %s: %w", section.Number.String(), err)
+		}
+		total += countWords(content)
+	}
+
+	var best types.SectionNumber
+	bestDiff := 0
+	for i, section := range chapter.Sections {
+		if i == 0 || len(section.Number) != 2 {
+			continue
+		}
+		diff := before[i] - (total - before[i])
+		if diff < 0 {
+			diff = -diff
+		}
+		if best == nil || diff < bestDiff {
+			best, bestDiff = section.Number, diff
+		}
+	}
+	if best == nil {
+		return nil, fmt.Errorf("chapter %d has no top-level section after its first to split at", chapterNum)
+	}
+	return best, nil
+}
+
+// relabelChapter updates a chapter that moved in the chapter order so that its
+// metadata, section files, figure IDs and table IDs carry its new number
+func (m *Manager) relabelChapter(r *restructure, manifest *types.Manifest, chapterNum types.ChapterNumber) error {
+	docID := string(r.docID)
+	chapter, err := m.storage.LoadChapterMetadata(docID, int(chapterNum))
+	if err != nil {
+		return fmt.Errorf("failed to load chapter %d: %w", chapterNum, err)
+	}
+	chapter.Number = chapterNum
+	dir, _ := manifest.ChapterDir(chapterNum)
+
+	for i := range chapter.Sections {
+		section := &chapter.Sections[i]
+		if len(section.Number) == 0 || section.Number[0] == int(chapterNum) {
+			continue
+		}
+		newNumber := renumberSection(section.Number, chapterNum, 0)
+		oldPath := m.config.SectionPath(docID, dir, section.Number.String())
+		newPath := m.config.SectionPath(docID, dir, newNumber.String())
+		if err := os.Rename(oldPath, newPath); err != nil && !os.IsNotExist(err) {
+			return fmt.Errorf("failed to rename section %s: %w", section.Number.String(), err)
+		}
+		section.Number = newNumber
+	}
+	if err := m.publishDocument(r.docID); err != nil {
+		return err
+	}
+
+	for i := range chapter.Figures {
+		r.renameFigure(m, &chapter.Figures[i], chapterNum)
+	}
+	for i := range chapter.Tables {
+		r.renameTable(&chapter.Tables[i], chapterNum)
+	}
+	for i := range chapter.Listings {
+		r.renameListing(&chapter.Listings[i], chapterNum)
+	}
+
+	if err := m.storage.SaveChapterMetadata(docID, chapter); err != nil {
+		return fmt.Errorf("failed to save chapter %d metadata: %w", chapterNum, err)
+	}
+	return nil
+}
+
+// renameFigure moves a figure to a chapter. A numbered ID is renumbered for
+// the figure's chapter and sequence; stable IDs stay as they are.
+func (r *restructure) renameFigure(m *Manager, figure *types.Figure, chapterNum types.ChapterNumber) {
+	figure.Chapter = chapterNum
+	if figure.ID.IsNumbered() {
+		r.replaceFigureID(m, figure, types.GenerateFigureID(chapterNum, figure.Sequence))
+	}
+}
+
+// replaceFigureID gives a figure a new ID, recording the rename. Images
+// imported into the assets directory are named after their figure and are
+// renamed with it.
+func (r *restructure) replaceFigureID(m *Manager, figure *types.Figure, newID types.FigureID) {
+	if figure.ID == newID {
+		return
+	}
+	r.result.IDs[string(figure.ID)] = string(newID)
+
+	dir, file := path.Split(figure.ImagePath)
+	if dir == "assets/images/" && strings.HasPrefix(file, string(figure.ID)+".") {
+		newFile := string(newID) + strings.TrimPrefix(file, string(figure.ID))
+		r.assets = append(r.assets, [2]string{
+			filepath.Join(m.config.AssetsPath(string(r.docID)), file),
+			filepath.Join(m.config.AssetsPath(string(r.docID)), newFile),
+		})
+		figure.ImagePath = dir + newFile
+	}
+	figure.ID = newID
+}
+
+// renameTable gives a table the ID for its chapter and sequence, recording the rename
+func (r *restructure) renameTable(table *types.Table, chapterNum types.ChapterNumber) {
+	table.Chapter = chapterNum
+	newID := types.GenerateTableID(chapterNum, table.Sequence)
+	if table.ID != newID {
+		r.result.IDs[string(table.ID)] = string(newID)
+		table.ID = newID
+	}
+}
+
+// renameListing gives a code listing the ID for its chapter and sequence,
+// recording the rename
+func (r *restructure) renameListing(listing *types.Listing, chapterNum types.ChapterNumber) {
+	listing.Chapter = chapterNum
+	newID := types.GenerateListingID(chapterNum, listing.Sequence)
+	if listing.ID != newID {
+		r.result.IDs[string(listing.ID)] = string(newID)
+		listing.ID = newID
+	}
+}
+
+// finishRestructure renames assets, rewrites figure and table references in every
+// section, recounts the chapters, saves the manifest and rebuilds every chapter
+func (m *Manager) finishRestructure(r *restructure, manifest *types.Manifest) error {
+	docID := string(r.docID)
+
+	// Rename in two steps so that a new name may be another asset's old name
+	for _, rename := range r.assets {
+		if err := os.Rename(rename[0], rename[0]+".renaming"); err != nil && !os.IsNotExist(err) {
+			return fmt.Errorf("failed to rename asset %s: %w", rename[0], err)
+		}
+	}
+	for _, rename := range r.assets {
+		if err := os.Rename(rename[0]+".renaming", rename[1]); err != nil && !os.IsNotExist(err) {
+			return fmt.Errorf("failed to rename asset %s: %w", rename[0], err)
+		}
+	}
+	if err := m.publishDocument(r.docID); err != nil {
+		return err
+	}
+
+	counts := make(map[types.ChapterNumber]types.ChapterCount)
+	for _, chapterRef := range manifest.Document.Chapters {
+		chapter, err := m.storage.LoadChapterMetadata(docID, int(chapterRef.Number))
+		if err != nil {
+			return fmt.Errorf("failed to load chapter %d: %w", chapterRef.Number, err)
+		}
+		counts[chapter.Number] = types.ChapterCount{
+			Sections: len(chapter.Sections),
+			Figures:  len(chapter.Figures),
+			Tables:   len(chapter.Tables),
+		}
+		if len(r.result.IDs) == 0 {
+			continue
+		}
+
+		for _, section := range chapter.Sections {
+			content, err := m.storage.LoadSectionContent(docID, int(chapter.Number), section.Number)
+			if err != nil {
+				continue
+			}
+			updated := referencePattern.ReplaceAllStringFunc(content, func(id string) string {
+				if newID, ok := r.result.IDs[id]; ok {
+					return newID
+				}
+				return id
+			})
+			if updated != content {
+				if err := m.storage.SaveSectionContent(docID, int(chapter.Number), section.Number, updated); err != nil {
+					return fmt.Errorf("failed to update references in section %s: %w", section.Number.String(), err)
+				}
+			}
+		}
+	}
+
+	sort.Slice(manifest.Document.Chapters, func(i, j int) bool {
+		return manifest.Document.Chapters[i].Number < manifest.Document.Chapters[j].Number
+	})
+	manifest.ChapterCounts = counts
+	manifest.UpdatedAt = time.Now()
+	if err := m.storage.SaveManifest(docID, manifest); err != nil {
+		return fmt.Errorf("failed to update manifest: %w", err)
+	}
+
+	for _, chapter := range manifest.Document.Chapters {
+		if err := m.RebuildChapterMarkdown(r.docID, chapter.Number); err != nil {
+			return fmt.Errorf("failed to rebuild chapter %d markdown: %w", chapter.Number, err)
+		}
+	}
+	return nil
+}
+
+// anchoredRefs returns the references of the half of a split chapter that
+// holds an anchor's section: the moved half from the split point on
+func anchoredRefs(anchor *types.FigureAnchor, at types.SectionNumber, keptRefs, movedRefs map[string]bool) map[string]bool {
+	if anchor.Section[1] >= at[1] {
+		return movedRefs
+	}
+	return keptRefs
+}
+
+// renumberSection returns a section number moved to chapterNum with its top-level
+// number shifted by offset
+func renumberSection(number types.SectionNumber, chapterNum types.ChapterNumber, offset int) types.SectionNumber {
+	renumbered := make(types.SectionNumber, len(number))
+	copy(renumbered, number)
+	renumbered[0] = int(chapterNum)
+	if len(renumbered) > 1 {
+		renumbered[1] += offset
+	}
+	return renumbered
+}
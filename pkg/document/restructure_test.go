@@ -0,0 +1,344 @@
+package document
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/gomcpgo/docgen/pkg/types"
+)
+
+// setupRestructureDocument creates three chapters with two sections each. Chapter 2
+// has an imported figure referenced from its second section and chapter 3 refers
+// to it as well.
+func setupRestructureDocument(t *testing.T, manager *Manager) types.DocumentID {
+	docID, err := manager.CreateDocument("Restructure", "Test Author", types.DocumentTypeBook)
+	if err != nil {
+		t.Fatalf("Failed to create document: %v", err)
+	}
+	for _, title := range []string{"One", "Two", "Three"} {
+		chapterNum, err := manager.AddChapter(context.Background(), docID, title, nil)
+		if err != nil {
+			t.Fatalf("Failed to add chapter: %v", err)
+		}
+		for _, section := range []string{"First", "Second"} {
+			if _, err := manager.AddSection(context.Background(), docID, chapterNum, title+" "+section, title+" "+section+" text.", 1); err != nil {
+				t.Fatalf("Failed to add section: %v", err)
+			}
+		}
+	}
+
+	if _, err := manager.storage.SaveAsset(string(docID), "fig-2.1.png", []byte("PNG")); err != nil {
+		t.Fatalf("Failed to save asset: %v", err)
+	}
+	// The figure is still identified by its number, so restructuring renames it
+	addNumberedImage(t, manager, docID, 2, "assets/images/fig-2.1.png", "Chart")
+	if err := manager.UpdateSection(context.Background(), docID, 2, types.SectionNumber{2, 2}, "![Chart](assets/images/fig-2.1.png){#fig-2.1}"); err != nil {
+		t.Fatalf("Failed to update section: %v", err)
+	}
+	if err := manager.UpdateSection(context.Background(), docID, 3, types.SectionNumber{3, 1}, "As shown in @fig-2.1."); err != nil {
+		t.Fatalf("Failed to update section: %v", err)
+	}
+	return docID
+}
+
+func TestManager_MergeChapters(t *testing.T) {
+	manager, tempDir := setupTestManager(t)
+	defer os.RemoveAll(tempDir)
+	docID := setupRestructureDocument(t, manager)
+
+	// Merge chapter 2 into chapter 1
+	result, err := manager.MergeChapters(context.Background(), docID, 1, 2)
+	if err != nil {
+		t.Fatalf("MergeChapters() error = %v", err)
+	}
+	if result.Chapter != 1 || result.Sections["2.1"] != "1.3" || result.Sections["2.2"] != "1.4" {
+		t.Errorf("Unexpected result %+v", result)
+	}
+	if result.IDs["fig-2.1"] != "fig-1.1" {
+		t.Errorf("Expected fig-2.1 to become fig-1.1, got %v", result.IDs)
+	}
+
+	manifest, err := manager.GetDocumentStructure(docID)
+	if err != nil {
+		t.Fatalf("GetDocumentStructure() error = %v", err)
+	}
+	if len(manifest.Document.Chapters) != 2 || manifest.Document.Chapters[1].Title != "Three" {
+		t.Fatalf("Expected chapters One and Three, got %+v", manifest.Document.Chapters)
+	}
+	if counts := manifest.ChapterCounts[1]; counts.Sections != 4 || counts.Figures != 1 {
+		t.Errorf("Expected 4 sections and 1 figure in chapter 1, got %+v", counts)
+	}
+
+	merged, err := manager.GetChapter(docID, 1)
+	if err != nil {
+		t.Fatalf("GetChapter() error = %v", err)
+	}
+	if len(merged.Sections) != 4 || merged.Sections[3].Title != "Two Second" {
+		t.Errorf("Unexpected merged sections %+v", merged.Sections)
+	}
+	if merged.Figures[0].ImagePath != "assets/images/fig-1.1.png" {
+		t.Errorf("Expected the asset to be renamed, got %s", merged.Figures[0].ImagePath)
+	}
+	if _, err := os.Stat(filepath.Join(manager.config.AssetsPath(string(docID)), "fig-1.1.png")); err != nil {
+		t.Errorf("Renamed asset missing: %v", err)
+	}
+	if !strings.Contains(merged.Content, "## 1.4 Two Second") || !strings.Contains(merged.Content, "{#fig-1.1}") {
+		t.Errorf("Unexpected chapter markdown:\n%s", merged.Content)
+	}
+
+	// The old chapter 3 is now chapter 2 with its sections and reference updated
+	content, err := manager.GetSectionContent(docID, 2, types.SectionNumber{2, 1})
+	if err != nil {
+		t.Fatalf("GetSectionContent() error = %v", err)
+	}
+	if content != "As shown in @fig-1.1." {
+		t.Errorf("Expected the reference to be updated, got %q", content)
+	}
+
+	if _, err := manager.MergeChapters(context.Background(), docID, 1, 1); err == nil {
+		t.Error("Expected an error merging a chapter into itself")
+	}
+}
+
+func TestManager_MergeIntoLaterChapter(t *testing.T) {
+	manager, tempDir := setupTestManager(t)
+	defer os.RemoveAll(tempDir)
+	docID := setupRestructureDocument(t, manager)
+
+	// Merging chapter 2 into chapter 3 leaves the result as chapter 2
+	result, err := manager.MergeChapters(context.Background(), docID, 3, 2)
+	if err != nil {
+		t.Fatalf("MergeChapters() error = %v", err)
+	}
+	if result.Chapter != 2 || result.Sections["2.1"] != "2.3" {
+		t.Errorf("Unexpected result %+v", result)
+	}
+	// The figure lands on the same ID
+	if _, renamed := result.IDs["fig-2.1"]; renamed {
+		t.Errorf("Did not expect fig-2.1 to be renamed, got %v", result.IDs)
+	}
+
+	merged, err := manager.GetChapter(docID, 2)
+	if err != nil {
+		t.Fatalf("GetChapter() error = %v", err)
+	}
+	if merged.Title != "Three" || len(merged.Sections) != 4 {
+		t.Fatalf("Unexpected merged chapter %+v", merged)
+	}
+	for i, want := range []string{"2.1", "2.2", "2.3", "2.4"} {
+		if got := merged.Sections[i].Number.String(); got != want {
+			t.Errorf("Section %d numbered %s, want %s", i, got, want)
+		}
+	}
+	if content, _ := manager.GetSectionContent(docID, 2, types.SectionNumber{2, 1}); content != "As shown in @fig-2.1." {
+		t.Errorf("Unexpected section content %q", content)
+	}
+}
+
+func TestManager_SplitChapter(t *testing.T) {
+	manager, tempDir := setupTestManager(t)
+	defer os.RemoveAll(tempDir)
+	docID := setupRestructureDocument(t, manager)
+
+	if _, err := manager.SplitChapter(context.Background(), docID, 2, types.SectionNumber{2, 1}, ""); err == nil {
+		t.Error("Expected an error splitting at the first section")
+	}
+	if _, err := manager.SplitChapter(context.Background(), docID, 2, types.SectionNumber{2, 9}, ""); err == nil {
+		t.Error("Expected an error for a missing section")
+	}
+
+	result, err := manager.SplitChapter(context.Background(), docID, 2, types.SectionNumber{2, 2}, "")
+	if err != nil {
+		t.Fatalf("SplitChapter() error = %v", err)
+	}
+	if result.Chapter != 3 || result.Sections["2.2"] != "3.1" || result.IDs["fig-2.1"] != "fig-3.1" {
+		t.Errorf("Unexpected result %+v", result)
+	}
+
+	manifest, err := manager.GetDocumentStructure(docID)
+	if err != nil {
+		t.Fatalf("GetDocumentStructure() error = %v", err)
+	}
+	var titles []string
+	for _, chapter := range manifest.Document.Chapters {
+		titles = append(titles, chapter.Title)
+	}
+	if strings.Join(titles, ",") != "One,Two,Two Second,Three" {
+		t.Fatalf("Unexpected chapters %v", titles)
+	}
+
+	split, err := manager.GetChapter(docID, 3)
+	if err != nil {
+		t.Fatalf("GetChapter() error = %v", err)
+	}
+	if len(split.Sections) != 1 || len(split.Figures) != 1 || split.Figures[0].ID != "fig-3.1" {
+		t.Errorf("Unexpected new chapter %+v", split)
+	}
+	if !strings.Contains(split.Content, "{#fig-3.1}") {
+		t.Errorf("Unexpected chapter markdown:\n%s", split.Content)
+	}
+
+	// The old chapter 3 moved to 4 and its reference follows the figure
+	content, err := manager.GetSectionContent(docID, 4, types.SectionNumber{4, 1})
+	if err != nil {
+		t.Fatalf("GetSectionContent() error = %v", err)
+	}
+	if content != "As shown in @fig-3.1." {
+		t.Errorf("Expected the reference to be updated, got %q", content)
+	}
+}
+
+func TestManager_MoveChapter(t *testing.T) {
+	tests := []struct {
+		name   string
+		from   types.ChapterNumber
+		to     types.ChapterNumber
+		titles []string
+		figure types.ChapterNumber // chapter holding the figure after the move
+		refers types.ChapterNumber // chapter referring to it after the move
+	}{
+		{"forward", 1, 3, []string{"Two", "Three", "One"}, 1, 2},
+		{"backward", 3, 1, []string{"Three", "One", "Two"}, 3, 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			manager, tempDir := setupTestManager(t)
+			defer os.RemoveAll(tempDir)
+			docID := setupRestructureDocument(t, manager)
+
+			result, err := manager.MoveChapter(context.Background(), docID, tt.from, tt.to)
+			if err != nil {
+				t.Fatalf("MoveChapter() error = %v", err)
+			}
+			figureID := string(types.GenerateFigureID(tt.figure, 1))
+			if figureID != "fig-2.1" && result.IDs["fig-2.1"] != figureID {
+				t.Errorf("Expected fig-2.1 to become %s, got %v", figureID, result.IDs)
+			}
+
+			manifest, err := manager.GetDocumentStructure(docID)
+			if err != nil {
+				t.Fatalf("GetDocumentStructure() error = %v", err)
+			}
+			for i, chapter := range manifest.Document.Chapters {
+				if chapter.Number != types.ChapterNumber(i+1) || chapter.Title != tt.titles[i] {
+					t.Errorf("Chapter %d is %d %q, want %q", i+1, chapter.Number, chapter.Title, tt.titles[i])
+				}
+			}
+
+			// Every chapter reads its own content under its new number
+			for i, title := range tt.titles {
+				chapterNum := types.ChapterNumber(i + 1)
+				chapter, err := manager.GetChapter(docID, chapterNum)
+				if err != nil {
+					t.Fatalf("GetChapter(%d) error = %v", chapterNum, err)
+				}
+				if len(chapter.Sections) != 2 || chapter.Sections[0].Number.String() != fmt.Sprintf("%d.1", chapterNum) || chapter.Sections[0].Title != title+" First" {
+					t.Errorf("Chapter %d sections = %+v", chapterNum, chapter.Sections)
+				}
+				if !strings.Contains(chapter.Content, title) {
+					t.Errorf("Chapter %d markdown should be rebuilt from %s:\n%s", chapterNum, title, chapter.Content)
+				}
+			}
+
+			moved, _ := manager.GetChapter(docID, tt.figure)
+			if len(moved.Figures) != 1 || string(moved.Figures[0].ID) != figureID || moved.Figures[0].ImagePath != "assets/images/"+figureID+".png" {
+				t.Errorf("Unexpected figures %+v", moved.Figures)
+			}
+			if _, err := os.Stat(filepath.Join(manager.config.AssetsPath(string(docID)), figureID+".png")); err != nil {
+				t.Errorf("Renamed asset missing: %v", err)
+			}
+			content, err := manager.GetSectionContent(docID, tt.refers, types.SectionNumber{int(tt.refers), 1})
+			if err != nil || content != "As shown in @"+figureID+"." {
+				t.Errorf("Expected the reference to be updated, got %q, %v", content, err)
+			}
+		})
+	}
+
+	manager, tempDir := setupTestManager(t)
+	defer os.RemoveAll(tempDir)
+	docID := setupRestructureDocument(t, manager)
+	if _, err := manager.MoveChapter(context.Background(), docID, 1, 4); err == nil {
+		t.Error("Expected an error moving past the last chapter")
+	}
+}
+
+func TestManager_RestructureKeepsParts(t *testing.T) {
+	manager, tempDir := setupTestManager(t)
+	defer os.RemoveAll(tempDir)
+	docID := setupRestructureDocument(t, manager)
+
+	if _, err := manager.SetPart(docID, types.Part{Title: "Early", FirstChapter: 1, LastChapter: 2}); err != nil {
+		t.Fatalf("SetPart() error = %v", err)
+	}
+	if _, err := manager.SetPart(docID, types.Part{Title: "Late", FirstChapter: 3, LastChapter: 3}); err != nil {
+		t.Fatalf("SetPart() error = %v", err)
+	}
+	if _, err := manager.SetPart(docID, types.Part{Title: "Missing", FirstChapter: 4, LastChapter: 5}); err == nil {
+		t.Error("Expected an error for chapters that do not exist")
+	}
+
+	// The chapter split off the end of a part stays in it
+	if _, err := manager.SplitChapter(context.Background(), docID, 2, types.SectionNumber{2, 2}, ""); err != nil {
+		t.Fatalf("SplitChapter() error = %v", err)
+	}
+	manifest, err := manager.GetDocumentStructure(docID)
+	if err != nil {
+		t.Fatalf("GetDocumentStructure() error = %v", err)
+	}
+	parts := manifest.Document.Parts
+	if len(parts) != 2 || parts[0].LastChapter != 3 || parts[1].FirstChapter != 4 || parts[1].LastChapter != 4 {
+		t.Fatalf("Unexpected parts after split %+v", parts)
+	}
+
+	// Merging away the only chapter of a part removes the part
+	if _, err := manager.MergeChapters(context.Background(), docID, 3, 4); err != nil {
+		t.Fatalf("MergeChapters() error = %v", err)
+	}
+	manifest, err = manager.GetDocumentStructure(docID)
+	if err != nil {
+		t.Fatalf("GetDocumentStructure() error = %v", err)
+	}
+	if parts := manifest.Document.Parts; len(parts) != 1 || parts[0].Title != "Early" || parts[0].LastChapter != 3 {
+		t.Errorf("Unexpected parts after merge %+v", parts)
+	}
+}
+
+func TestManager_BalancedSplitPoint(t *testing.T) {
+	manager, tempDir := setupTestManager(t)
+	defer os.RemoveAll(tempDir)
+
+	docID, err := manager.CreateDocument("Balanced", "Test Author", types.DocumentTypeBook)
+	if err != nil {
+		t.Fatalf("Failed to create document: %v", err)
+	}
+	chapterNum, err := manager.AddChapter(context.Background(), docID, "Long", nil)
+	if err != nil {
+		t.Fatalf("Failed to add chapter: %v", err)
+	}
+	if _, err := manager.BalancedSplitPoint(docID, chapterNum); err == nil {
+		t.Error("Expected an error for a chapter without sections")
+	}
+
+	// 40 words, then 10, 30 and 20: the halves are most even before the third section
+	for _, words := range []int{40, 10, 30, 20} {
+		content := strings.TrimSpace(strings.Repeat("word ", words))
+		if _, err := manager.AddSection(context.Background(), docID, chapterNum, "S", content, 1); err != nil {
+			t.Fatalf("Failed to add section: %v", err)
+		}
+	}
+	if _, err := manager.AddSection(context.Background(), docID, chapterNum, "Nested", "Nested text.", 2); err != nil {
+		t.Fatalf("Failed to add section: %v", err)
+	}
+
+	at, err := manager.BalancedSplitPoint(docID, chapterNum)
+	if err != nil {
+		t.Fatalf("BalancedSplitPoint() error = %v", err)
+	}
+	if at.String() != "1.3" {
+		t.Errorf("BalancedSplitPoint() = %s, want 1.3", at.String())
+	}
+}
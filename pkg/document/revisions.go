@@ -0,0 +1,94 @@
+package document
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gomcpgo/docgen/pkg/types"
+)
+
+// chapterRevisionsMu serializes changes to chapter revision registries
+var chapterRevisionsMu sync.Mutex
+
+// SnapshotChapter records the chapter's compiled markdown as a new revision,
+// dropping the oldest once the chapter has MaxChapterRevisions. When the
+// chapter hasn't changed since its latest revision, that revision is returned
+// instead and the returned flag is false.
+func (m *Manager) SnapshotChapter(docID types.DocumentID, chapterNum types.ChapterNumber, label string) (*types.ChapterRevision, bool, error) {
+	if err := m.RebuildChapterMarkdown(docID, chapterNum); err != nil {
+		return nil, false, fmt.Errorf("failed to rebuild chapter: %w", err)
+	}
+	content, err := m.storage.LoadChapterContent(string(docID), int(chapterNum))
+	if err != nil {
+		return nil, false, fmt.Errorf("failed to load chapter content: %w", err)
+	}
+
+	chapterRevisionsMu.Lock()
+	defer chapterRevisionsMu.Unlock()
+
+	revisions, err := m.storage.LoadChapterRevisions(string(docID), int(chapterNum))
+	if err != nil {
+		return nil, false, fmt.Errorf("failed to load chapter revisions: %w", err)
+	}
+	if latest, ok := revisions.Find(0); ok && latest.Content == content {
+		return latest, false, nil
+	}
+
+	revisions.LastID++
+	revisions.Revisions = append(revisions.Revisions, types.ChapterRevision{
+		ID:        revisions.LastID,
+		Label:     strings.TrimSpace(label),
+		CreatedAt: time.Now(),
+		Words:     countWords(content),
+		Content:   content,
+	})
+	if excess := len(revisions.Revisions) - types.MaxChapterRevisions; excess > 0 {
+		revisions.Revisions = revisions.Revisions[excess:]
+	}
+	if err := m.storage.SaveChapterRevisions(string(docID), int(chapterNum), revisions); err != nil {
+		return nil, false, fmt.Errorf("failed to save chapter revisions: %w", err)
+	}
+
+	revision := revisions.Revisions[len(revisions.Revisions)-1]
+	return &revision, true, nil
+}
+
+// ListChapterRevisions returns the chapter's revisions, newest first
+func (m *Manager) ListChapterRevisions(docID types.DocumentID, chapterNum types.ChapterNumber) ([]types.ChapterRevision, error) {
+	if err := docID.Validate(); err != nil {
+		return nil, fmt.Errorf("invalid document ID: %w", err)
+	}
+
+	revisions, err := m.storage.LoadChapterRevisions(string(docID), int(chapterNum))
+	if err != nil {
+		return nil, fmt.Errorf("failed to load chapter revisions: %w", err)
+	}
+	list := make([]types.ChapterRevision, 0, len(revisions.Revisions))
+	for i := len(revisions.Revisions) - 1; i >= 0; i-- {
+		list = append(list, revisions.Revisions[i])
+	}
+	return list, nil
+}
+
+// GetChapterRevision returns one of the chapter's revisions, or its latest
+// when id is 0
+func (m *Manager) GetChapterRevision(docID types.DocumentID, chapterNum types.ChapterNumber, id int) (*types.ChapterRevision, error) {
+	if err := docID.Validate(); err != nil {
+		return nil, fmt.Errorf("invalid document ID: %w", err)
+	}
+
+	revisions, err := m.storage.LoadChapterRevisions(string(docID), int(chapterNum))
+	if err != nil {
+		return nil, fmt.Errorf("failed to load chapter revisions: %w", err)
+	}
+	if len(revisions.Revisions) == 0 {
+		return nil, fmt.Errorf("chapter %d has no revisions; take one with snapshot_chapter", chapterNum)
+	}
+	revision, ok := revisions.Find(id)
+	if !ok {
+		return nil, fmt.Errorf("revision %d of chapter %d not found", id, chapterNum)
+	}
+	return revision, nil
+}
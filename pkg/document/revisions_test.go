@@ -0,0 +1,60 @@
+package document
+
+import (
+	"context"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/gomcpgo/docgen/pkg/types"
+)
+
+func TestManager_SnapshotChapter(t *testing.T) {
+	manager, tempDir := setupTestManager(t)
+	defer os.RemoveAll(tempDir)
+
+	docID, _ := manager.CreateDocument("Report", "Test Author", types.DocumentTypeBook)
+	chapterNum, _ := manager.AddChapter(context.Background(), docID, "Findings", nil)
+	manager.AddSection(context.Background(), docID, chapterNum, "Results", "First draft.", 1)
+
+	if _, err := manager.GetChapterRevision(docID, chapterNum, 0); err == nil || !strings.Contains(err.Error(), "no revisions") {
+		t.Errorf("GetChapterRevision() error = %v, want no revisions", err)
+	}
+
+	first, created, err := manager.SnapshotChapter(docID, chapterNum, " before edit ")
+	if err != nil || !created {
+		t.Fatalf("SnapshotChapter() = %v, %v", created, err)
+	}
+	if first.ID != 1 || first.Label != "before edit" || !strings.Contains(first.Content, "First draft.") {
+		t.Errorf("Unexpected revision %+v", first)
+	}
+	if again, created, _ := manager.SnapshotChapter(docID, chapterNum, ""); created || again.ID != 1 {
+		t.Errorf("Expected an unchanged chapter to keep its revision, got %+v", again)
+	}
+
+	manager.UpdateSection(context.Background(), docID, chapterNum, types.SectionNumber{1, 1}, "Second draft.")
+	second, created, _ := manager.SnapshotChapter(docID, chapterNum, "")
+	if !created || second.ID != 2 {
+		t.Errorf("Expected revision 2, got %+v", second)
+	}
+
+	revisions, _ := manager.ListChapterRevisions(docID, chapterNum)
+	if len(revisions) != 2 || revisions[0].ID != 2 {
+		t.Errorf("Expected the revisions newest first, got %+v", revisions)
+	}
+	if revision, err := manager.GetChapterRevision(docID, chapterNum, 1); err != nil || !strings.Contains(revision.Content, "First draft.") {
+		t.Errorf("GetChapterRevision(1) = %+v, %v", revision, err)
+	}
+	if _, err := manager.GetChapterRevision(docID, chapterNum, 9); err == nil {
+		t.Error("Expected an error for a missing revision")
+	}
+
+	for i := 0; i < types.MaxChapterRevisions; i++ {
+		manager.UpdateSection(context.Background(), docID, chapterNum, types.SectionNumber{1, 1}, strings.Repeat("More. ", i+1))
+		manager.SnapshotChapter(docID, chapterNum, "")
+	}
+	revisions, _ = manager.ListChapterRevisions(docID, chapterNum)
+	if len(revisions) != types.MaxChapterRevisions || revisions[len(revisions)-1].ID != 3 {
+		t.Errorf("Expected the oldest revisions dropped, got %d from %d", len(revisions), revisions[len(revisions)-1].ID)
+	}
+}
@@ -0,0 +1,115 @@
+package document
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"time"
+
+	"github.com/gomcpgo/docgen/pkg/types"
+)
+
+// SandboxIDPrefix starts the ID of every sandbox document
+const SandboxIDPrefix = "sandbox-"
+
+// DefaultSandboxTTL is how long sandbox documents are kept when the
+// configuration doesn't say
+const DefaultSandboxTTL = time.Hour
+
+// MaxSandboxTTL is the longest lifetime a sandbox document may be given
+const MaxSandboxTTL = 24 * time.Hour
+
+// CreateSandboxDocument creates a scratch document that is deleted once ttl
+// passes, or the configured time when ttl is 0. Each sandbox gets an ID of its
+// own, so that sandboxes created at the same time with the same title don't
+// collide. It returns the document's ID and when it expires.
+func (m *Manager) CreateSandboxDocument(title, author string, docType types.DocumentType, ttl time.Duration) (types.DocumentID, time.Time, error) {
+	if title == "" {
+		return "", time.Time{}, fmt.Errorf("document title is required")
+	}
+	if author == "" {
+		return "", time.Time{}, fmt.Errorf("document author is required")
+	}
+	if ttl < 0 || ttl > MaxSandboxTTL {
+		return "", time.Time{}, fmt.Errorf("sandbox lifetime must be positive and at most %d hours", int(MaxSandboxTTL.Hours()))
+	}
+	if ttl == 0 {
+		ttl = m.config.SandboxTTL
+	}
+	if ttl == 0 {
+		ttl = DefaultSandboxTTL
+	}
+
+	sandboxes, err := m.sandboxDocuments()
+	if err != nil {
+		return "", time.Time{}, err
+	}
+	if m.config.MaxSandboxDocuments > 0 && len(sandboxes) >= m.config.MaxSandboxDocuments {
+		return "", time.Time{}, fmt.Errorf("maximum number of sandbox documents (%d) reached", m.config.MaxSandboxDocuments)
+	}
+
+	docID, err := newSandboxID()
+	if err != nil {
+		return "", time.Time{}, err
+	}
+
+	now := time.Now()
+	expiresAt := now.Add(ttl)
+	doc := &types.Document{
+		ID:        docID,
+		Title:     title,
+		Authors:   types.AuthorList{{Name: author}},
+		Type:      docType,
+		CreatedAt: now,
+		UpdatedAt: now,
+		Chapters:  []types.Chapter{},
+		ExpiresAt: &expiresAt,
+	}
+	if err := m.storage.CreateDocumentStructure(doc); err != nil {
+		return "", time.Time{}, fmt.Errorf("failed to create document structure: %w", err)
+	}
+
+	return docID, expiresAt, nil
+}
+
+// ExpiredSandboxes lists the sandbox documents due to be deleted at now
+func (m *Manager) ExpiredSandboxes(now time.Time) ([]types.DocumentID, error) {
+	sandboxes, err := m.sandboxDocuments()
+	if err != nil {
+		return nil, err
+	}
+	var expired []types.DocumentID
+	for _, doc := range sandboxes {
+		if doc.Expired(now) {
+			expired = append(expired, doc.ID)
+		}
+	}
+	return expired, nil
+}
+
+// sandboxDocuments loads the documents of every sandbox. Documents whose
+// manifest can't be loaded are skipped.
+func (m *Manager) sandboxDocuments() ([]types.Document, error) {
+	docs, err := m.storage.ListDocuments()
+	if err != nil {
+		return nil, fmt.Errorf("failed to list documents: %w", err)
+	}
+	var sandboxes []types.Document
+	for _, id := range docs {
+		manifest, err := m.storage.LoadManifest(id)
+		if err != nil || !manifest.Document.IsSandbox() {
+			continue
+		}
+		sandboxes = append(sandboxes, manifest.Document)
+	}
+	return sandboxes, nil
+}
+
+// newSandboxID returns a random sandbox document ID
+func newSandboxID() (types.DocumentID, error) {
+	var id [6]byte
+	if _, err := rand.Read(id[:]); err != nil {
+		return "", fmt.Errorf("failed to generate sandbox ID: %w", err)
+	}
+	return types.DocumentID(SandboxIDPrefix + hex.EncodeToString(id[:])), nil
+}
@@ -0,0 +1,56 @@
+package document
+
+import (
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gomcpgo/docgen/pkg/types"
+)
+
+func TestManager_CreateSandboxDocument(t *testing.T) {
+	manager, tempDir := setupTestManager(t)
+	defer os.RemoveAll(tempDir)
+	manager.config.MaxDocuments = 1
+	manager.config.MaxSandboxDocuments = 2
+
+	if _, err := manager.CreateDocument("Real Work", "Test Author", types.DocumentTypeBook); err != nil {
+		t.Fatalf("CreateDocument() error = %v", err)
+	}
+
+	// Sandboxes with the same title get IDs of their own, beyond the document limit
+	first, expiresAt, err := manager.CreateSandboxDocument("Try", "Test Author", types.DocumentTypeReport, 0)
+	if err != nil {
+		t.Fatalf("CreateSandboxDocument() error = %v", err)
+	}
+	second, _, err := manager.CreateSandboxDocument("Try", "Test Author", types.DocumentTypeReport, 2*time.Hour)
+	if err != nil {
+		t.Fatalf("CreateSandboxDocument() error = %v", err)
+	}
+	if first == second || !strings.HasPrefix(string(first), SandboxIDPrefix) {
+		t.Errorf("Unexpected sandbox IDs %s and %s", first, second)
+	}
+	if until := time.Until(expiresAt); until <= 0 || until > DefaultSandboxTTL {
+		t.Errorf("Expected the default lifetime, expires in %v", until)
+	}
+	manifest, _ := manager.storage.LoadManifest(string(first))
+	if !manifest.Document.IsSandbox() {
+		t.Errorf("Sandbox manifest should record its expiry")
+	}
+
+	if _, _, err := manager.CreateSandboxDocument("Try", "Test Author", types.DocumentTypeReport, 0); err == nil || !strings.Contains(err.Error(), "maximum number of sandbox documents") {
+		t.Errorf("Expected the sandbox limit, got %v", err)
+	}
+	if _, _, err := manager.CreateSandboxDocument("Try", "Test Author", types.DocumentTypeReport, 48*time.Hour); err == nil {
+		t.Errorf("Expected lifetimes over %v to be refused", MaxSandboxTTL)
+	}
+
+	expired, err := manager.ExpiredSandboxes(time.Now().Add(90 * time.Minute))
+	if err != nil {
+		t.Fatalf("ExpiredSandboxes() error = %v", err)
+	}
+	if len(expired) != 1 || expired[0] != first {
+		t.Errorf("ExpiredSandboxes() = %v, want [%s]", expired, first)
+	}
+}
@@ -0,0 +1,131 @@
+package document
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/gomcpgo/docgen/pkg/types"
+)
+
+// AppendToSection adds content to the end of a section, as a paragraph of its
+// own, without sending the rest of the section
+func (m *Manager) AppendToSection(ctx context.Context, docID types.DocumentID, chapterNum types.ChapterNumber, sectionNum types.SectionNumber, content string) error {
+	current, err := m.loadSectionForEdit(docID, chapterNum, sectionNum, content)
+	if err != nil {
+		return err
+	}
+	paragraphs := scanParagraphs(current)
+	if len(paragraphs) == 0 {
+		return m.UpdateSection(ctx, docID, chapterNum, sectionNum, content)
+	}
+	return m.UpdateSection(ctx, docID, chapterNum, sectionNum, insertParagraph(current, paragraphs[len(paragraphs)-1][1], content))
+}
+
+// InsertIntoSection adds content to a section after one of its paragraphs: the
+// paragraph containing afterText when it is given, which must appear in only
+// one paragraph, or else paragraph afterParagraph, counting from 1. An
+// afterParagraph of 0 inserts the content at the start of the section.
+func (m *Manager) InsertIntoSection(ctx context.Context, docID types.DocumentID, chapterNum types.ChapterNumber, sectionNum types.SectionNumber, content string, afterParagraph int, afterText string) error {
+	current, err := m.loadSectionForEdit(docID, chapterNum, sectionNum, content)
+	if err != nil {
+		return err
+	}
+	paragraphs := scanParagraphs(current)
+
+	if afterText != "" {
+		afterParagraph = 0
+		for i, p := range paragraphs {
+			if !strings.Contains(current[p[0]:p[1]], afterText) {
+				continue
+			}
+			if afterParagraph != 0 {
+				return fmt.Errorf("text %q appears in more than one paragraph (%d and %d); give more of it", afterText, afterParagraph, i+1)
+			}
+			afterParagraph = i + 1
+		}
+		if afterParagraph == 0 {
+			return fmt.Errorf("text %q not found in section %s", afterText, sectionNum.String())
+		}
+	}
+
+	if afterParagraph < 0 || afterParagraph > len(paragraphs) {
+		return fmt.Errorf("paragraph %d not found: section %s has %d paragraph(s)", afterParagraph, sectionNum.String(), len(paragraphs))
+	}
+	if afterParagraph == 0 {
+		if len(paragraphs) == 0 {
+			return m.UpdateSection(ctx, docID, chapterNum, sectionNum, content)
+		}
+		start := paragraphs[0][0]
+		return m.UpdateSection(ctx, docID, chapterNum, sectionNum, current[:start]+strings.Trim(content, "\n")+"\n\n"+current[start:])
+	}
+	return m.UpdateSection(ctx, docID, chapterNum, sectionNum, insertParagraph(current, paragraphs[afterParagraph-1][1], content))
+}
+
+// InsertPageBreak starts a new page in a section's exports after one of its
+// paragraphs, found as InsertIntoSection finds them, or at the end of the
+// section when afterParagraph is negative and afterText is empty
+func (m *Manager) InsertPageBreak(ctx context.Context, docID types.DocumentID, chapterNum types.ChapterNumber, sectionNum types.SectionNumber, afterParagraph int, afterText string) error {
+	if afterParagraph < 0 && afterText == "" {
+		return m.AppendToSection(ctx, docID, chapterNum, sectionNum, types.PageBreakMarker)
+	}
+	return m.InsertIntoSection(ctx, docID, chapterNum, sectionNum, types.PageBreakMarker, afterParagraph, afterText)
+}
+
+// loadSectionForEdit loads the content of a section that content is about to
+// be added to
+func (m *Manager) loadSectionForEdit(docID types.DocumentID, chapterNum types.ChapterNumber, sectionNum types.SectionNumber, content string) (string, error) {
+	if err := docID.Validate(); err != nil {
+		return "", fmt.Errorf("invalid document ID: %w", err)
+	}
+	if strings.TrimSpace(content) == "" {
+		return "", fmt.Errorf("content to add is required")
+	}
+	current, err := m.storage.LoadSectionContent(string(docID), int(chapterNum), sectionNum)
+	if err != nil {
+		return "", fmt.Errorf("failed to load section %s: %w", sectionNum.String(), err)
+	}
+	return current, nil
+}
+
+// insertParagraph inserts content as a paragraph of its own at offset, the end
+// of a paragraph, keeping everything around it as it was
+func insertParagraph(current string, offset int, content string) string {
+	return current[:offset] + "\n\n" + strings.Trim(content, "\n") + current[offset:]
+}
+
+// scanParagraphs finds the blocks of content separated by blank lines, as start
+// and end offsets. Fenced code blocks count as one paragraph, blank lines and all.
+func scanParagraphs(content string) [][2]int {
+	var paragraphs [][2]int
+	start, end := -1, -1
+	fence := ""
+	offset := 0
+	for _, line := range strings.SplitAfter(content, "\n") {
+		lineStart := offset
+		offset += len(line)
+		text := strings.TrimRight(line, "\r\n")
+		trimmed := strings.TrimSpace(text)
+
+		if fence == "" && trimmed == "" {
+			if start >= 0 {
+				paragraphs = append(paragraphs, [2]int{start, end})
+				start = -1
+			}
+			continue
+		}
+		if fence != "" && strings.HasPrefix(trimmed, fence) {
+			fence = ""
+		} else if fence == "" && (strings.HasPrefix(trimmed, "```") || strings.HasPrefix(trimmed, "~~~")) {
+			fence = trimmed[:3]
+		}
+		if start < 0 {
+			start = lineStart
+		}
+		end = lineStart + len(text)
+	}
+	if start >= 0 {
+		paragraphs = append(paragraphs, [2]int{start, end})
+	}
+	return paragraphs
+}
@@ -0,0 +1,87 @@
+package document
+
+import (
+	"context"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/gomcpgo/docgen/pkg/types"
+)
+
+func TestManager_AppendAndInsertIntoSection(t *testing.T) {
+	manager, tempDir := setupTestManager(t)
+	defer os.RemoveAll(tempDir)
+
+	docID, _ := manager.CreateDocument("Report", "Test Author", types.DocumentTypeReport)
+	chapterNum, _ := manager.AddChapter(context.Background(), docID, "Methods", nil)
+	sectionNum, _ := manager.AddSection(context.Background(), docID, chapterNum, "Setup", "First paragraph.\n\n```\ncode\n\nmore code\n```\n", 1)
+	content := func() string {
+		text, _ := manager.GetSectionContent(docID, chapterNum, sectionNum)
+		return text
+	}
+
+	if err := manager.AppendToSection(context.Background(), docID, chapterNum, sectionNum, "Last paragraph."); err != nil {
+		t.Fatalf("AppendToSection(context.Background()) error = %v", err)
+	}
+	want := "First paragraph.\n\n```\ncode\n\nmore code\n```\n\nLast paragraph.\n"
+	if got := content(); got != want {
+		t.Errorf("After append:\n%q\nwant\n%q", got, want)
+	}
+
+	// The code block is one paragraph, so the second paragraph ends with it
+	if err := manager.InsertIntoSection(context.Background(), docID, chapterNum, sectionNum, "After the code.", 2, ""); err != nil {
+		t.Fatalf("InsertIntoSection(context.Background()) error = %v", err)
+	}
+	if err := manager.InsertIntoSection(context.Background(), docID, chapterNum, sectionNum, "Second paragraph.", 0, "First"); err != nil {
+		t.Fatalf("InsertIntoSection(context.Background()) error = %v", err)
+	}
+	if err := manager.InsertIntoSection(context.Background(), docID, chapterNum, sectionNum, "Opening.", 0, ""); err != nil {
+		t.Fatalf("InsertIntoSection(context.Background()) error = %v", err)
+	}
+	want = "Opening.\n\nFirst paragraph.\n\nSecond paragraph.\n\n```\ncode\n\nmore code\n```\n\nAfter the code.\n\nLast paragraph.\n"
+	if got := content(); got != want {
+		t.Errorf("After inserts:\n%q\nwant\n%q", got, want)
+	}
+
+	for _, tt := range []struct {
+		afterParagraph int
+		afterText      string
+		wantErr        string
+	}{
+		{9, "", "paragraph 9 not found"},
+		{0, "paragraph.", "more than one paragraph"},
+		{0, "missing", "not found"},
+	} {
+		if err := manager.InsertIntoSection(context.Background(), docID, chapterNum, sectionNum, "Text.", tt.afterParagraph, tt.afterText); err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+			t.Errorf("InsertIntoSection(%d, %q) error = %v, want %q", tt.afterParagraph, tt.afterText, err, tt.wantErr)
+		}
+	}
+	if err := manager.AppendToSection(context.Background(), docID, chapterNum, sectionNum, " \n"); err == nil {
+		t.Errorf("Expected blank content to be refused")
+	}
+}
+
+func TestManager_InsertPageBreak(t *testing.T) {
+	manager, tempDir := setupTestManager(t)
+	defer os.RemoveAll(tempDir)
+
+	docID, _ := manager.CreateDocument("Report", "Test Author", types.DocumentTypeReport)
+	chapterNum, _ := manager.AddChapter(context.Background(), docID, "Methods", nil)
+	sectionNum, _ := manager.AddSection(context.Background(), docID, chapterNum, "Setup", "First paragraph.\n\nSecond paragraph.\n", 1)
+
+	if err := manager.InsertPageBreak(context.Background(), docID, chapterNum, sectionNum, -1, ""); err != nil {
+		t.Fatalf("InsertPageBreak(context.Background()) error = %v", err)
+	}
+	if err := manager.InsertPageBreak(context.Background(), docID, chapterNum, sectionNum, 0, "First"); err != nil {
+		t.Fatalf("InsertPageBreak(context.Background()) error = %v", err)
+	}
+	want := "First paragraph.\n\n\\pagebreak\n\nSecond paragraph.\n\n\\pagebreak\n"
+	if got, _ := manager.GetSectionContent(docID, chapterNum, sectionNum); got != want {
+		t.Errorf("After page breaks:\n%q\nwant\n%q", got, want)
+	}
+
+	if err := manager.InsertPageBreak(context.Background(), docID, chapterNum, sectionNum, 9, ""); err == nil {
+		t.Error("Expected an error for a missing paragraph")
+	}
+}
@@ -1,6 +1,9 @@
 package document
 
 import (
+	"context"
+	"os"
+	"strings"
 	"testing"
 	"time"
 
@@ -258,4 +261,124 @@ func sectionsEqual(a, b types.SectionNumber) bool {
 		}
 	}
 	return true
-}
\ No newline at end of file
+}
+func TestRebuildChapterMarkdown_NumberingStyle(t *testing.T) {
+	manager, tempDir := setupTestManager(t)
+	defer os.RemoveAll(tempDir)
+
+	docID, _ := manager.CreateDocument("Test Document", "Test Author", types.DocumentTypeBook)
+	manager.AddChapter(context.Background(), docID, "Introduction", nil)
+	chapterNum, _ := manager.AddChapter(context.Background(), docID, "Methods", nil)
+	manager.AddSection(context.Background(), docID, chapterNum, "Setup", "Setup text.", 1)
+	manager.AddSection(context.Background(), docID, chapterNum, "Tools", "Tools text.", 2)
+
+	style := types.DefaultStyle()
+	style.NumberingStyle.ChapterFormat = types.NumberFormatRoman
+	style.NumberingStyle.SectionDepth = 1
+	if err := manager.ConfigureDocument(docID, &style, nil, nil); err != nil {
+		t.Fatalf("ConfigureDocument() error = %v", err)
+	}
+
+	// Configuring the style rebuilds the chapters with the new numbers
+	content, err := manager.storage.LoadChapterContent(string(docID), 2)
+	if err != nil {
+		t.Fatalf("Failed to read chapter markdown: %v", err)
+	}
+	for _, want := range []string{"# Chapter II: Methods\n", "## II.1 Setup\n", "### Tools\n"} {
+		if !strings.Contains(string(content), want) {
+			t.Errorf("Chapter markdown is missing %q:\n%s", want, content)
+		}
+	}
+
+	style.NumberingStyle.ChapterFormat = "greek"
+	if err := manager.ConfigureDocument(docID, &style, nil, nil); err == nil {
+		t.Error("Expected an invalid chapter format to be rejected")
+	}
+}
+
+func TestRebuildChapterMarkdown_ChapterHeading(t *testing.T) {
+	manager, tempDir := setupTestManager(t)
+	defer os.RemoveAll(tempDir)
+
+	docID, _ := manager.CreateDocument("Test Document", "Test Author", types.DocumentTypeBook)
+	manager.AddChapter(context.Background(), docID, "Introduction", nil)
+	manager.AddChapter(context.Background(), docID, "Methods", nil)
+
+	prefix := "%d."
+	style := types.DefaultStyle()
+	style.ChapterHeading = types.ChapterHeadingStyle{Prefix: &prefix, Unnumbered: []string{"Introduction"}, Case: types.HeadingCaseUpper}
+	if err := manager.ConfigureDocument(docID, &style, nil, nil); err != nil {
+		t.Fatalf("ConfigureDocument() error = %v", err)
+	}
+
+	for chapter, want := range map[int]string{1: "# INTRODUCTION\n", 2: "# 2. METHODS\n"} {
+		content, err := manager.storage.LoadChapterContent(string(docID), chapter)
+		if err != nil {
+			t.Fatalf("Failed to read chapter markdown: %v", err)
+		}
+		if !strings.HasPrefix(string(content), want) {
+			t.Errorf("Chapter %d markdown should start with %q:\n%s", chapter, want, content)
+		}
+	}
+
+	style.ChapterHeading.Case = "small-caps"
+	if err := manager.ConfigureDocument(docID, &style, nil, nil); err == nil {
+		t.Error("Expected an invalid heading case to be rejected")
+	}
+}
+
+func TestManager_AddSections(t *testing.T) {
+	manager, tempDir := setupTestManager(t)
+	defer os.RemoveAll(tempDir)
+
+	docID, _ := manager.CreateDocument("Bulk", "Test Author", types.DocumentTypeReport)
+	chapterNum, _ := manager.AddChapter(context.Background(), docID, "Guide", nil)
+	manager.AddSection(context.Background(), docID, chapterNum, "Existing", "Already here.", 1)
+
+	numbers, err := manager.AddSections(context.Background(), docID, chapterNum, []types.NewSection{
+		{Title: "Setup", Content: "Install it.", Level: 1},
+		{Title: "Requirements", Content: "A computer.", Level: 2},
+		{Title: "Packages", Content: "Some packages.", Level: 3},
+		{Title: "Download", Content: "Get it.", Level: 2},
+		{Title: "Usage", Content: "Run it.", Level: 1},
+	})
+	if err != nil {
+		t.Fatalf("AddSections() error = %v", err)
+	}
+	var got []string
+	for _, number := range numbers {
+		got = append(got, number.String())
+	}
+	if strings.Join(got, ",") != "1.2,1.2.1,1.2.1.1,1.2.2,1.3" {
+		t.Errorf("AddSections(context.Background()) = %v", got)
+	}
+
+	chapter, err := manager.GetChapter(docID, chapterNum)
+	if err != nil {
+		t.Fatalf("GetChapter() error = %v", err)
+	}
+	if len(chapter.Sections) != 6 || chapter.Sections[3].Title != "Packages" {
+		t.Errorf("Unexpected sections %+v", chapter.Sections)
+	}
+	if !strings.Contains(chapter.Content, "#### 1.2.1.1 Packages\n\nSome packages.") || !strings.Contains(chapter.Content, "## 1.3 Usage\n\nRun it.") {
+		t.Errorf("Unexpected chapter markdown:\n%s", chapter.Content)
+	}
+
+	// A bad section keeps all of them from being added
+	for _, sections := range [][]types.NewSection{
+		nil,
+		{{Title: "Fine", Content: "Text.", Level: 1}, {Title: "", Content: "Text.", Level: 1}},
+		{{Title: "Fine", Content: "Text.", Level: 1}, {Title: "Empty", Level: 1}},
+		{{Title: "Fine", Content: "Text.", Level: 1}, {Title: "Deep", Content: "Text.", Level: 7}},
+	} {
+		if _, err := manager.AddSections(context.Background(), docID, chapterNum, sections); err == nil {
+			t.Errorf("Expected an error adding %+v", sections)
+		}
+	}
+	if chapter, _ := manager.GetChapter(docID, chapterNum); len(chapter.Sections) != 6 {
+		t.Errorf("Expected no sections added by the failed calls, got %d", len(chapter.Sections))
+	}
+	if _, err := manager.AddSections(context.Background(), docID, 9, []types.NewSection{{Title: "Lost", Content: "Text.", Level: 1}}); err == nil {
+		t.Error("Expected an error for a missing chapter")
+	}
+}
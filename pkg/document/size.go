@@ -0,0 +1,159 @@
+package document
+
+import (
+	"context"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/gomcpgo/docgen/pkg/types"
+)
+
+// DefaultLargestFiles is the number of largest files a size report lists
+const DefaultLargestFiles = 10
+
+// exportLogSuffix matches the timestamp that follows the document ID in an export log directory
+var exportLogSuffix = regexp.MustCompile(`^-\d{8}T\d{6}\.\d{3}Z$`)
+
+// exportFileSuffix matches what follows the document ID in its exports, latest
+// exports and chapter previews
+var exportFileSuffix = regexp.MustCompile(`^(-latest|-chapter-\d+-preview)?\.[A-Za-z0-9]+$`)
+
+// DocumentSize reports the disk space a document uses, split into chapters,
+// assets, exports, word count snapshots and everything else, with the largest
+// files first. Measuring stops when ctx is done.
+func (m *Manager) DocumentSize(ctx context.Context, docID types.DocumentID, largest int) (*types.DocumentSize, error) {
+	if err := docID.Validate(); err != nil {
+		return nil, fmt.Errorf("invalid document ID: %w", err)
+	}
+
+	if err := m.SyncDocument(ctx, docID); err != nil {
+		return nil, err
+	}
+	docDir := m.config.DocumentPath(string(docID))
+	if _, err := os.Stat(docDir); err != nil {
+		return nil, fmt.Errorf("failed to read document: %w", err)
+	}
+	if largest <= 0 {
+		largest = DefaultLargestFiles
+	}
+
+	size := &types.DocumentSize{
+		Chapters:     []types.ChapterSize{},
+		LargestFiles: []types.FileSize{},
+	}
+	manifest, err := m.storage.LoadManifest(string(docID))
+	if err != nil {
+		return nil, fmt.Errorf("failed to load manifest: %w", err)
+	}
+	chapterNumbers := make(map[string]types.ChapterNumber)
+	for _, chapter := range manifest.Document.Chapters {
+		if dir, ok := manifest.ChapterDir(chapter.Number); ok {
+			chapterNumbers[dir] = chapter.Number
+		}
+	}
+	chapters := make(map[types.ChapterNumber]int64)
+	var files []types.FileSize
+
+	record := func(path string, bytes int64) {
+		size.TotalBytes += bytes
+		rel, err := filepath.Rel(m.config.RootDir, path)
+		if err != nil {
+			rel = path
+		}
+		files = append(files, types.FileSize{Path: filepath.ToSlash(rel), Bytes: bytes})
+	}
+
+	err = walkFiles(ctx, docDir, func(path string, bytes int64) {
+		rel, _ := filepath.Rel(docDir, path)
+		parts := strings.Split(filepath.ToSlash(rel), "/")
+		switch {
+		case parts[0] == "chapters":
+			size.ChaptersBytes += bytes
+			if number, ok := chapterNumbers[parts[1]]; ok && len(parts) > 2 {
+				chapters[number] += bytes
+			}
+		case parts[0] == "assets":
+			size.AssetsBytes += bytes
+		case rel == filepath.Base(m.config.StatsPath(string(docID))):
+			size.SnapshotsBytes += bytes
+		default:
+			size.OtherBytes += bytes
+		}
+		record(path, bytes)
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to measure document: %w", err)
+	}
+
+	// Exports sit in a shared directory, named after the document
+	exportDirs := []struct {
+		dir    string
+		suffix *regexp.Regexp
+	}{
+		{m.config.ExportsDir, exportFileSuffix},
+		{m.config.ExportLogsPath(), exportLogSuffix},
+	}
+	for _, exports := range exportDirs {
+		entries, err := os.ReadDir(exports.dir)
+		if err != nil && !os.IsNotExist(err) {
+			return nil, fmt.Errorf("failed to read exports: %w", err)
+		}
+		for _, entry := range entries {
+			name := entry.Name()
+			// Another document's ID may start with this one's, so match the whole name
+			if !strings.HasPrefix(name, string(docID)) || !exports.suffix.MatchString(strings.TrimPrefix(name, string(docID))) {
+				continue
+			}
+			if err := walkFiles(ctx, filepath.Join(exports.dir, name), func(path string, bytes int64) {
+				size.ExportsBytes += bytes
+				record(path, bytes)
+			}); err != nil {
+				return nil, fmt.Errorf("failed to measure exports: %w", err)
+			}
+		}
+	}
+
+	for number, bytes := range chapters {
+		size.Chapters = append(size.Chapters, types.ChapterSize{Chapter: number, Bytes: bytes})
+	}
+	sort.Slice(size.Chapters, func(i, j int) bool {
+		return size.Chapters[i].Chapter < size.Chapters[j].Chapter
+	})
+
+	sort.SliceStable(files, func(i, j int) bool {
+		return files[i].Bytes > files[j].Bytes
+	})
+	if len(files) > largest {
+		files = files[:largest]
+	}
+	size.LargestFiles = append(size.LargestFiles, files...)
+
+	return size, nil
+}
+
+// walkFiles calls visit with the size of every regular file under root, which
+// may itself be a file, until ctx is done
+func walkFiles(ctx context.Context, root string, visit func(path string, bytes int64)) error {
+	return filepath.WalkDir(root, func(path string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		if !entry.Type().IsRegular() {
+			return nil
+		}
+		info, err := entry.Info()
+		if err != nil {
+			return err
+		}
+		visit(path, info.Size())
+		return nil
+	})
+}
@@ -0,0 +1,75 @@
+package document
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/gomcpgo/docgen/pkg/types"
+)
+
+func TestManager_DocumentSize(t *testing.T) {
+	manager, tempDir := setupTestManager(t)
+	defer os.RemoveAll(tempDir)
+
+	docID, err := manager.CreateDocument("Sizes", "Test Author", types.DocumentTypeReport)
+	if err != nil {
+		t.Fatalf("Failed to create document: %v", err)
+	}
+	chapterNum, err := manager.AddChapter(context.Background(), docID, "Text", nil)
+	if err != nil {
+		t.Fatalf("Failed to add chapter: %v", err)
+	}
+	if _, err := manager.AddSection(context.Background(), docID, chapterNum, "Body", "Some words here.", 1); err != nil {
+		t.Fatalf("Failed to add section: %v", err)
+	}
+	if err := manager.RecordWordCount(docID); err != nil {
+		t.Fatalf("Failed to record word count: %v", err)
+	}
+	if _, err := manager.storage.SaveAsset(string(docID), "big.png", make([]byte, 5000)); err != nil {
+		t.Fatalf("Failed to save asset: %v", err)
+	}
+
+	// Exports of this document, and of one whose ID extends it
+	manager.config.ExportsDir = filepath.Join(tempDir, "exports")
+	os.MkdirAll(manager.config.ExportsDir, 0755)
+	os.WriteFile(manager.config.ExportPath(string(docID), "pdf"), make([]byte, 1000), 0644)
+	os.WriteFile(manager.config.ExportPath(string(docID)+"-2", "pdf"), make([]byte, 9000), 0644)
+	logDir := manager.config.ExportLogPath(string(docID), "20260101T120000.000Z")
+	os.MkdirAll(logDir, 0755)
+	os.WriteFile(filepath.Join(logDir, "stderr.txt"), make([]byte, 20), 0644)
+
+	size, err := manager.DocumentSize(context.Background(), docID, 2)
+	if err != nil {
+		t.Fatalf("DocumentSize() error = %v", err)
+	}
+	if size.AssetsBytes != 5000 || size.ExportsBytes != 1020 {
+		t.Errorf("Unexpected assets %d or exports %d", size.AssetsBytes, size.ExportsBytes)
+	}
+	if size.ChaptersBytes == 0 || len(size.Chapters) != 1 || size.Chapters[0].Bytes != size.ChaptersBytes {
+		t.Errorf("Unexpected chapters %d %+v", size.ChaptersBytes, size.Chapters)
+	}
+	if size.SnapshotsBytes == 0 || size.OtherBytes == 0 {
+		t.Errorf("Expected snapshots and manifest to be counted, got %+v", size)
+	}
+	total := size.ChaptersBytes + size.AssetsBytes + size.ExportsBytes + size.SnapshotsBytes + size.OtherBytes
+	if size.TotalBytes != total {
+		t.Errorf("TotalBytes = %d, want %d", size.TotalBytes, total)
+	}
+	if len(size.LargestFiles) != 2 || size.LargestFiles[0].Path != string(docID)+"/assets/images/big.png" || size.LargestFiles[1].Bytes != 1000 {
+		t.Errorf("Unexpected largest files %+v", size.LargestFiles)
+	}
+
+	if _, err := manager.DocumentSize(context.Background(), "missing", 0); err == nil {
+		t.Error("Expected an error for a missing document")
+	}
+
+	// A scan stops once its context is cancelled
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, err := manager.DocumentSize(ctx, docID, 0); !errors.Is(err, context.Canceled) {
+		t.Errorf("DocumentSize() error = %v, want a cancellation", err)
+	}
+}
@@ -0,0 +1,49 @@
+package document
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/gomcpgo/docgen/pkg/types"
+)
+
+// SetStatus sets the workflow status of a chapter, or of one of its sections
+// when sectionNum is given. An empty status clears a section's own status, so
+// it takes its parent's again, and resets a chapter to draft.
+func (m *Manager) SetStatus(docID types.DocumentID, chapterNum types.ChapterNumber, sectionNum types.SectionNumber, status types.WorkflowStatus) error {
+	if err := docID.Validate(); err != nil {
+		return fmt.Errorf("invalid document ID: %w", err)
+	}
+	if status != "" {
+		if err := status.Validate(); err != nil {
+			return err
+		}
+	}
+
+	chapter, err := m.storage.LoadChapterMetadata(string(docID), int(chapterNum))
+	if err != nil {
+		return fmt.Errorf("failed to load chapter: %w", err)
+	}
+
+	target := &chapter.Status
+	if sectionNum != nil {
+		target = nil
+		for i := range chapter.Sections {
+			if m.sectionNumbersEqual(chapter.Sections[i].Number, sectionNum) {
+				target = &chapter.Sections[i].Status
+				chapter.Sections[i].UpdatedAt = time.Now()
+				break
+			}
+		}
+		if target == nil {
+			return fmt.Errorf("section %s not found", sectionNum.String())
+		}
+	}
+	*target = status
+	chapter.UpdatedAt = time.Now()
+
+	if err := m.storage.SaveChapterMetadata(string(docID), chapter); err != nil {
+		return fmt.Errorf("failed to save chapter metadata: %w", err)
+	}
+	return nil
+}
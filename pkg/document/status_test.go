@@ -0,0 +1,72 @@
+package document
+
+import (
+	"context"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/gomcpgo/docgen/pkg/types"
+)
+
+func TestManager_SetStatus(t *testing.T) {
+	manager, tempDir := setupTestManager(t)
+	defer os.RemoveAll(tempDir)
+
+	docID, _ := manager.CreateDocument("Handbook", "Test Author", types.DocumentTypeReport)
+	chapterNum, _ := manager.AddChapter(context.Background(), docID, "Basics", nil)
+	manager.AddSection(context.Background(), docID, chapterNum, "Start", "Hello.", 1)
+	manager.AddSection(context.Background(), docID, chapterNum, "Detail", "More.", 1)
+	manager.AddSection(context.Background(), docID, chapterNum, "Fine print", "Small.", 2)
+	other, _ := manager.AddChapter(context.Background(), docID, "Other", nil)
+	manager.AddSection(context.Background(), docID, other, "Elsewhere", "Away.", 1)
+
+	if err := manager.SetStatus(docID, chapterNum, nil, types.StatusInReview); err != nil {
+		t.Fatalf("SetStatus() chapter error = %v", err)
+	}
+	if err := manager.SetStatus(docID, chapterNum, types.SectionNumber{1, 2}, types.StatusApproved); err != nil {
+		t.Fatalf("SetStatus() section error = %v", err)
+	}
+
+	// Sections without a status take their parent's, then the chapter's
+	chapter, _ := manager.storage.LoadChapterMetadata(string(docID), int(chapterNum))
+	want := []types.WorkflowStatus{types.StatusInReview, types.StatusApproved, types.StatusApproved}
+	for i, status := range chapter.SectionStatuses() {
+		if status != want[i] {
+			t.Errorf("Section %s status = %q, want %q", chapter.Sections[i].Number.String(), status, want[i])
+		}
+	}
+
+	manifest, _ := manager.GetDocumentStructure(docID)
+	manifest, _ = ShapeStructure(manifest, types.StructureOptions{Sections: true, Status: []types.WorkflowStatus{types.StatusApproved}})
+	if len(manifest.Document.Chapters) != 1 || len(manifest.Document.Chapters[0].Sections) != 2 || manifest.Document.Chapters[0].Status != types.StatusInReview {
+		t.Errorf("Expected sections 1.2 and 1.2.1 to be approved, got %+v", manifest.Document.Chapters)
+	}
+	manifest, _ = manager.GetDocumentStructure(docID)
+	manifest, _ = ShapeStructure(manifest, types.StructureOptions{Sections: true, Status: []types.WorkflowStatus{types.StatusDraft}})
+	if len(manifest.Document.Chapters) != 1 || manifest.Document.Chapters[0].Number != other || len(manifest.Document.Chapters[0].Sections) != 1 {
+		t.Errorf("Expected chapter 2 to be draft, got %+v", manifest.Document.Chapters)
+	}
+
+	doc, err := manager.DocumentJSON(context.Background(), docID)
+	if err != nil {
+		t.Fatalf("DocumentJSON() error = %v", err)
+	}
+	if doc.Chapters[0].Status != types.StatusInReview || doc.Chapters[0].Sections[2].Status != types.StatusApproved || doc.Chapters[1].Sections[0].Status != types.StatusDraft {
+		t.Errorf("Unexpected statuses in %+v", doc.Chapters)
+	}
+
+	// Clearing a section's status gives it its parent's again
+	manager.SetStatus(docID, chapterNum, types.SectionNumber{1, 2}, "")
+	chapter, _ = manager.storage.LoadChapterMetadata(string(docID), int(chapterNum))
+	if status := chapter.SectionStatuses()[2]; status != types.StatusInReview {
+		t.Errorf("Expected section 1.2.1 to be in review, got %q", status)
+	}
+
+	if err := manager.SetStatus(docID, chapterNum, nil, "done"); err == nil || !strings.Contains(err.Error(), "invalid status") {
+		t.Errorf("SetStatus() error = %v, want an invalid status", err)
+	}
+	if err := manager.SetStatus(docID, chapterNum, types.SectionNumber{1, 9}, types.StatusFinal); err == nil {
+		t.Error("Expected an error for a missing section")
+	}
+}
@@ -0,0 +1,159 @@
+package document
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/gomcpgo/docgen/pkg/types"
+)
+
+// compactOmittedFields are left out of compact structure responses: timestamps
+// and bookkeeping that says nothing about the document's content
+var compactOmittedFields = map[string]bool{
+	"created_at":     true,
+	"updated_at":     true,
+	"chapter_counts": true,
+	"chapter_order":  true,
+}
+
+// ShapeStructure trims a manifest loaded with GetDocumentStructure to the
+// chapters, section levels and lists the options ask for. The manifest is
+// changed in place and returned.
+func ShapeStructure(manifest *types.Manifest, options types.StructureOptions) (*types.Manifest, error) {
+	if options.Depth < 0 {
+		return nil, fmt.Errorf("depth cannot be negative")
+	}
+
+	if len(options.Chapters) > 0 {
+		byNumber := make(map[types.ChapterNumber]types.Chapter)
+		for _, chapter := range manifest.Document.Chapters {
+			byNumber[chapter.Number] = chapter
+		}
+		selected := make([]types.Chapter, 0, len(options.Chapters))
+		counts := make(map[types.ChapterNumber]types.ChapterCount)
+		for _, number := range options.Chapters {
+			chapter, ok := byNumber[number]
+			if !ok {
+				return nil, fmt.Errorf("chapter %d not found", number)
+			}
+			selected = append(selected, chapter)
+			if count, ok := manifest.ChapterCounts[number]; ok {
+				counts[number] = count
+			}
+		}
+		manifest.Document.Chapters = selected
+		manifest.ChapterCounts = counts
+	}
+
+	// Sections are kept by their status, nested sections taking their parent's
+	// and top-level ones their chapter's when unset. Chapters are kept when
+	// their own status is wanted or any of their sections are.
+	if len(options.Status) > 0 {
+		wanted := make(map[types.WorkflowStatus]bool)
+		for _, status := range options.Status {
+			wanted[status.OrDraft()] = true
+		}
+		var matching []types.Chapter
+		for _, chapter := range manifest.Document.Chapters {
+			var sections []types.Section
+			for i, status := range chapter.SectionStatuses() {
+				if wanted[status.OrDraft()] {
+					sections = append(sections, chapter.Sections[i])
+				}
+			}
+			if wanted[chapter.Status.OrDraft()] || len(sections) > 0 {
+				chapter.Sections = sections
+				matching = append(matching, chapter)
+			}
+		}
+		manifest.Document.Chapters = matching
+	}
+
+	// Chapters whose front matter matches keep all their sections; others only
+	// the matching ones, and are left out when none match
+	if len(options.FrontMatter) > 0 {
+		var matching []types.Chapter
+		for _, chapter := range manifest.Document.Chapters {
+			if chapter.FrontMatter.Matches(options.FrontMatter) {
+				matching = append(matching, chapter)
+				continue
+			}
+			var sections []types.Section
+			for _, section := range chapter.Sections {
+				if section.FrontMatter.Matches(options.FrontMatter) {
+					sections = append(sections, section)
+				}
+			}
+			if len(sections) > 0 {
+				chapter.Sections = sections
+				matching = append(matching, chapter)
+			}
+		}
+		manifest.Document.Chapters = matching
+	}
+
+	for i := range manifest.Document.Chapters {
+		chapter := &manifest.Document.Chapters[i]
+		if !options.Sections || options.Depth == 1 {
+			chapter.Sections = nil
+		} else if options.Depth > 1 {
+			var sections []types.Section
+			for _, section := range chapter.Sections {
+				if section.Level < options.Depth {
+					sections = append(sections, section)
+				}
+			}
+			chapter.Sections = sections
+		}
+		if !options.Figures {
+			chapter.Figures = nil
+		}
+		if !options.Tables {
+			chapter.Tables = nil
+		}
+		if !options.Listings {
+			chapter.Listings = nil
+		}
+	}
+
+	return manifest, nil
+}
+
+// CompactStructure returns a manifest as JSON-style fields without timestamps,
+// chapter counts, chapter directories or empty values
+func CompactStructure(manifest *types.Manifest) (map[string]interface{}, error) {
+	data, err := json.Marshal(manifest)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode structure: %w", err)
+	}
+	var fields map[string]interface{}
+	if err := json.Unmarshal(data, &fields); err != nil {
+		return nil, fmt.Errorf("failed to encode structure: %w", err)
+	}
+	compactFields(fields)
+	return fields, nil
+}
+
+// compactFields removes omitted and empty fields from nested JSON objects
+func compactFields(value interface{}) bool {
+	switch v := value.(type) {
+	case nil:
+		return false
+	case string:
+		return v != ""
+	case []interface{}:
+		for _, item := range v {
+			compactFields(item)
+		}
+		return len(v) > 0
+	case map[string]interface{}:
+		for key, field := range v {
+			if compactOmittedFields[key] || !compactFields(field) {
+				delete(v, key)
+			}
+		}
+		return len(v) > 0
+	default:
+		return true
+	}
+}
@@ -0,0 +1,97 @@
+package document
+
+import (
+	"testing"
+
+	"github.com/gomcpgo/docgen/pkg/types"
+)
+
+func structureManifest() *types.Manifest {
+	return &types.Manifest{
+		Document: types.Document{
+			Title: "Book",
+			Chapters: []types.Chapter{
+				{
+					Number: 1,
+					Title:  "One",
+					Sections: []types.Section{
+						{Number: types.SectionNumber{1, 1}, Title: "Intro", Level: 1},
+						{Number: types.SectionNumber{1, 1, 1}, Title: "Detail", Level: 2},
+					},
+					Figures: []types.Figure{{ID: "fig-1.1", Caption: "A figure"}},
+					Tables:  []types.Table{{ID: "table-1.1", Caption: "A table"}},
+				},
+				{Number: 2, Title: "Two"},
+				{Number: 3, Title: "Three"},
+			},
+		},
+		ChapterCounts: map[types.ChapterNumber]types.ChapterCount{
+			1: {Sections: 2, Figures: 1, Tables: 1},
+			2: {},
+			3: {},
+		},
+	}
+}
+
+func TestShapeStructure(t *testing.T) {
+	all := types.StructureOptions{Sections: true, Figures: true, Tables: true, Listings: true}
+	manifest, err := ShapeStructure(structureManifest(), all)
+	if err != nil {
+		t.Fatalf("ShapeStructure() error = %v", err)
+	}
+	if len(manifest.Document.Chapters) != 3 || len(manifest.Document.Chapters[0].Sections) != 2 {
+		t.Errorf("All options should keep the whole manifest, got %+v", manifest.Document.Chapters)
+	}
+
+	options := all
+	options.Depth = 2
+	options.Chapters = []types.ChapterNumber{1, 3}
+	manifest, _ = ShapeStructure(structureManifest(), options)
+	chapters := manifest.Document.Chapters
+	if len(chapters) != 2 || chapters[1].Number != 3 || len(manifest.ChapterCounts) != 2 {
+		t.Errorf("Expected chapters 1 and 3, got %+v", chapters)
+	}
+	if len(chapters[0].Sections) != 1 || chapters[0].Sections[0].Title != "Intro" {
+		t.Errorf("Depth 2 should keep top-level sections only, got %+v", chapters[0].Sections)
+	}
+
+	manifest, _ = ShapeStructure(structureManifest(), types.StructureOptions{Tables: true})
+	chapter := manifest.Document.Chapters[0]
+	if chapter.Sections != nil || chapter.Figures != nil || len(chapter.Tables) != 1 {
+		t.Errorf("Only tables should be kept, got %+v", chapter)
+	}
+
+	options = all
+	options.Depth = 1
+	manifest, _ = ShapeStructure(structureManifest(), options)
+	if manifest.Document.Chapters[0].Sections != nil {
+		t.Errorf("Depth 1 should list chapters only")
+	}
+
+	if _, err := ShapeStructure(structureManifest(), types.StructureOptions{Chapters: []types.ChapterNumber{9}}); err == nil {
+		t.Error("Expected an error for a missing chapter")
+	}
+	if _, err := ShapeStructure(structureManifest(), types.StructureOptions{Depth: -1}); err == nil {
+		t.Error("Expected an error for a negative depth")
+	}
+}
+
+func TestCompactStructure(t *testing.T) {
+	fields, err := CompactStructure(structureManifest())
+	if err != nil {
+		t.Fatalf("CompactStructure() error = %v", err)
+	}
+	for _, key := range []string{"created_at", "updated_at", "chapter_counts"} {
+		if _, ok := fields[key]; ok {
+			t.Errorf("Compact structure should leave out %s", key)
+		}
+	}
+	document := fields["document"].(map[string]interface{})
+	chapters := document["chapters"].([]interface{})
+	if _, ok := chapters[1].(map[string]interface{})["sections"]; ok {
+		t.Errorf("Empty sections should be left out, got %v", chapters[1])
+	}
+	if title := chapters[0].(map[string]interface{})["title"]; title != "One" {
+		t.Errorf("Chapter title = %v, want One", title)
+	}
+}
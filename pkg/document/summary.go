@@ -0,0 +1,110 @@
+package document
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/gomcpgo/docgen/pkg/types"
+)
+
+// SummarizeStructure returns each chapter's title, word count, section
+// headings and an excerpt of its opening: its first paragraph, or its first
+// ExcerptWords words. It reads the content but returns only the summary, so a
+// client can decide where to edit without fetching whole chapters.
+func (m *Manager) SummarizeStructure(docID types.DocumentID, options types.SummaryOptions) (*types.StructureSummary, error) {
+	if options.Depth < 0 {
+		return nil, fmt.Errorf("depth cannot be negative")
+	}
+	if options.ExcerptWords < 0 {
+		return nil, fmt.Errorf("excerpt_words cannot be negative")
+	}
+
+	manifest, err := m.GetDocumentStructure(docID)
+	if err != nil {
+		return nil, err
+	}
+	manifest, err = ShapeStructure(manifest, types.StructureOptions{Chapters: options.Chapters, Sections: true})
+	if err != nil {
+		return nil, err
+	}
+
+	summary := &types.StructureSummary{
+		Title:    manifest.Document.Title,
+		Chapters: []types.ChapterSummary{},
+	}
+	for _, chapter := range manifest.Document.Chapters {
+		entry := types.ChapterSummary{
+			Number: chapter.Number,
+			Title:  chapter.Title,
+		}
+
+		// The chapter's own include file comes first, as it does when the
+		// chapter is built
+		var prose []string
+		contents := make([]string, 0, len(chapter.Sections)+1)
+		if chapter.IncludeFile != "" {
+			if included, err := m.readIncludeFile(docID, chapter.IncludeFile); err == nil {
+				contents = append(contents, included)
+			}
+		}
+		for _, section := range chapter.Sections {
+			var content string
+			if section.IncludeFile != "" {
+				content, err = m.readIncludeFile(docID, section.IncludeFile)
+			} else {
+				content, err = m.storage.LoadSectionContent(string(docID), int(chapter.Number), section.Number)
+			}
+			if err != nil {
+				continue
+			}
+			contents = append(contents, content)
+
+			if options.Depth == 0 || section.Level < options.Depth {
+				entry.Sections = append(entry.Sections, types.TOCSection{
+					Number: section.Number,
+					Title:  section.Title,
+					Level:  section.Level,
+				})
+			}
+		}
+		for _, content := range contents {
+			entry.Words += countWords(content)
+			for _, bounds := range scanParagraphs(content) {
+				if block := content[bounds[0]:bounds[1]]; isProse(block) {
+					prose = append(prose, strings.Join(strings.Fields(block), " "))
+				}
+			}
+		}
+
+		entry.Excerpt = summaryExcerpt(prose, options.ExcerptWords)
+		summary.Chapters = append(summary.Chapters, entry)
+	}
+
+	return summary, nil
+}
+
+// isProse tells whether a block is a paragraph of text, rather than code, a
+// list, a figure or markup such as page breaks, fenced divs and raw HTML
+func isProse(block string) bool {
+	if blockKind(block) != types.BlockParagraph {
+		return false
+	}
+	trimmed := strings.TrimSpace(block)
+	return !strings.HasPrefix(trimmed, "<") && !strings.HasPrefix(trimmed, ":::") && !strings.HasPrefix(trimmed, "\\")
+}
+
+// summaryExcerpt returns the first paragraph, or with words set the first
+// words across paragraphs, ending in an ellipsis when cut short
+func summaryExcerpt(prose []string, words int) string {
+	if len(prose) == 0 {
+		return ""
+	}
+	if words == 0 {
+		return prose[0]
+	}
+	fields := strings.Fields(strings.Join(prose, " "))
+	if len(fields) <= words {
+		return strings.Join(fields, " ")
+	}
+	return strings.Join(fields[:words], " ") + "…"
+}
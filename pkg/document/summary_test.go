@@ -0,0 +1,52 @@
+package document
+
+import (
+	"context"
+	"os"
+	"testing"
+
+	"github.com/gomcpgo/docgen/pkg/types"
+)
+
+func TestManager_SummarizeStructure(t *testing.T) {
+	manager, tempDir := setupTestManager(t)
+	defer os.RemoveAll(tempDir)
+
+	docID, _ := manager.CreateDocument("Field Guide", "Test Author", types.DocumentTypeBook)
+	first, _ := manager.AddChapter(context.Background(), docID, "Birds", nil)
+	second, _ := manager.AddChapter(context.Background(), docID, "Trees", nil)
+	manager.AddSection(context.Background(), docID, first, "Songbirds", "```\ncode here\n```\n\nSongbirds sing\nat dawn.\n\nThey nest in hedges.", 1)
+	manager.AddSection(context.Background(), docID, first, "Finches", "Finches eat seeds.", 2)
+	manager.AddSection(context.Background(), docID, second, "Oaks", "- acorns\n- leaves", 1)
+
+	summary, err := manager.SummarizeStructure(docID, types.SummaryOptions{})
+	if err != nil {
+		t.Fatalf("SummarizeStructure() error = %v", err)
+	}
+	if len(summary.Chapters) != 2 {
+		t.Fatalf("Expected 2 chapters, got %d", len(summary.Chapters))
+	}
+	birds := summary.Chapters[0]
+	if birds.Excerpt != "Songbirds sing at dawn." {
+		t.Errorf("Expected the first paragraph as the excerpt, got %q", birds.Excerpt)
+	}
+	if birds.Words != 11 || len(birds.Sections) != 2 || birds.Sections[1].Title != "Finches" {
+		t.Errorf("Unexpected chapter summary %+v", birds)
+	}
+	if summary.Chapters[1].Excerpt != "" {
+		t.Errorf("Expected no excerpt for a chapter without paragraphs, got %q", summary.Chapters[1].Excerpt)
+	}
+
+	// An excerpt by words runs across paragraphs
+	summary, _ = manager.SummarizeStructure(docID, types.SummaryOptions{Chapters: []types.ChapterNumber{first}, Depth: 2, ExcerptWords: 6})
+	if len(summary.Chapters) != 1 || summary.Chapters[0].Excerpt != "Songbirds sing at dawn. They nest…" || len(summary.Chapters[0].Sections) != 1 {
+		t.Errorf("Unexpected summary %+v", summary.Chapters)
+	}
+
+	if _, err := manager.SummarizeStructure(docID, types.SummaryOptions{ExcerptWords: -1}); err == nil {
+		t.Error("Expected an error for negative excerpt_words")
+	}
+	if _, err := manager.SummarizeStructure(docID, types.SummaryOptions{Chapters: []types.ChapterNumber{9}}); err == nil {
+		t.Error("Expected an error for a missing chapter")
+	}
+}
@@ -0,0 +1,37 @@
+package document
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/gomcpgo/docgen/pkg/storage"
+	"github.com/gomcpgo/docgen/pkg/types"
+)
+
+// SyncDocument brings the local files of a document up to date when storage
+// keeps documents elsewhere, such as in an object store. Anything that reads a
+// document's files directly rather than through storage, exports included,
+// syncs first. Syncing stops when ctx is done.
+func (m *Manager) SyncDocument(ctx context.Context, docID types.DocumentID) error {
+	syncer, ok := m.storage.(storage.Syncer)
+	if !ok {
+		return nil
+	}
+	if err := syncer.SyncDocument(ctx, string(docID)); err != nil {
+		return fmt.Errorf("failed to sync document: %w", err)
+	}
+	return nil
+}
+
+// publishDocument stores changes made directly to a document's local files,
+// such as renamed section files, when storage keeps documents elsewhere
+func (m *Manager) publishDocument(docID types.DocumentID) error {
+	syncer, ok := m.storage.(storage.Syncer)
+	if !ok {
+		return nil
+	}
+	if err := syncer.PublishDocument(string(docID)); err != nil {
+		return fmt.Errorf("failed to publish document: %w", err)
+	}
+	return nil
+}
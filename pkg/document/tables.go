@@ -0,0 +1,213 @@
+package document
+
+import (
+	"encoding/csv"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/gomcpgo/docgen/pkg/types"
+)
+
+// ParseDelimitedTable reads CSV or TSV data into table cells, the first row
+// being the header. The delimiter is a single character or "tab"; when it is
+// empty, tabs, semicolons or commas are detected from the first line. Short
+// rows are padded with empty cells.
+func ParseDelimitedTable(data, delimiter string) (*types.TableData, error) {
+	data = strings.Trim(strings.ReplaceAll(data, "\r\n", "\n"), "\n")
+	if strings.TrimSpace(data) == "" {
+		return nil, fmt.Errorf("data is empty")
+	}
+
+	var comma rune
+	switch {
+	case delimiter == "tab" || delimiter == "\t":
+		comma = '\t'
+	case delimiter == "":
+		firstLine := strings.SplitN(data, "\n", 2)[0]
+		switch {
+		case strings.Contains(firstLine, "\t"):
+			comma = '\t'
+		case strings.Count(firstLine, ";") > strings.Count(firstLine, ","):
+			comma = ';'
+		default:
+			comma = ','
+		}
+	case len([]rune(delimiter)) == 1 && delimiter != "\"" && delimiter != "\n":
+		comma = []rune(delimiter)[0]
+	default:
+		return nil, fmt.Errorf("invalid delimiter %q: use a single character such as ',' or ';', or 'tab'", delimiter)
+	}
+
+	reader := csv.NewReader(strings.NewReader(data))
+	reader.Comma = comma
+	reader.FieldsPerRecord = -1
+	reader.LazyQuotes = true
+	records, err := reader.ReadAll()
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse data: %w", err)
+	}
+
+	table := &types.TableData{Header: records[0], Rows: records[1:]}
+	table.Normalize()
+	return table, nil
+}
+
+// AddTable registers a table built from cells in the chapter of a section and
+// anchors it after a paragraph of the section, or after its last paragraph
+// when afterParagraph is negative. The table's markdown is written from the
+// cells, so the section content stays free of it and the cells can be
+// changed with UpdateTableData.
+func (m *Manager) AddTable(docID types.DocumentID, sectionNum types.SectionNumber, afterParagraph int, caption, format string, data *types.TableData) (*types.Table, error) {
+	if err := docID.Validate(); err != nil {
+		return nil, fmt.Errorf("invalid document ID: %w", err)
+	}
+	if strings.TrimSpace(caption) == "" {
+		return nil, fmt.Errorf("caption is required")
+	}
+	if format == "" {
+		format = types.TableFormatMarkdown
+	}
+	if err := types.ValidateTableFormat(format); err != nil {
+		return nil, err
+	}
+	data.Normalize()
+	if err := data.Validate(); err != nil {
+		return nil, err
+	}
+	if len(sectionNum) < 2 {
+		return nil, fmt.Errorf("a section number such as 1.2 is required")
+	}
+
+	chapterNum := types.ChapterNumber(sectionNum[0])
+	chapter, err := m.storage.LoadChapterMetadata(string(docID), int(chapterNum))
+	if err != nil {
+		return nil, fmt.Errorf("failed to load chapter: %w", err)
+	}
+	found := false
+	for _, section := range chapter.Sections {
+		if m.sectionNumbersEqual(section.Number, sectionNum) {
+			found = true
+			break
+		}
+	}
+	if !found {
+		return nil, fmt.Errorf("section %s not found in chapter %d", sectionNum.String(), chapterNum)
+	}
+
+	if afterParagraph < 0 {
+		content, err := m.storage.LoadSectionContent(string(docID), int(chapterNum), sectionNum)
+		if err != nil {
+			return nil, fmt.Errorf("failed to load section content: %w", err)
+		}
+		afterParagraph = len(scanParagraphs(content))
+	}
+
+	now := time.Now()
+	sequence := len(chapter.Tables) + 1
+	table := types.Table{
+		ID:        types.GenerateTableID(chapterNum, sequence),
+		Chapter:   chapterNum,
+		Sequence:  sequence,
+		Caption:   strings.TrimSpace(caption),
+		Content:   data.Markdown(format),
+		Format:    format,
+		CreatedAt: now,
+		UpdatedAt: now,
+		Anchor:    &types.FigureAnchor{Section: sectionNum, Paragraph: afterParagraph},
+		Data:      data,
+	}
+	chapter.Tables = append(chapter.Tables, table)
+	chapter.UpdatedAt = now
+
+	if err := m.storage.SaveChapterMetadata(string(docID), chapter); err != nil {
+		return nil, fmt.Errorf("failed to save chapter metadata: %w", err)
+	}
+	if err := m.RebuildChapterMarkdown(docID, chapterNum); err != nil {
+		return nil, fmt.Errorf("failed to rebuild chapter markdown: %w", err)
+	}
+	return &table, nil
+}
+
+// UpdateTableData adds columns and rows to a table built from cells and sets
+// cell values, then rewrites the table's markdown and rebuilds its chapter
+func (m *Manager) UpdateTableData(docID types.DocumentID, tableID types.TableID, update types.TableUpdate) (*types.Table, error) {
+	if err := docID.Validate(); err != nil {
+		return nil, fmt.Errorf("invalid document ID: %w", err)
+	}
+	if len(update.AddColumns) == 0 && len(update.AddRows) == 0 && len(update.Cells) == 0 {
+		return nil, fmt.Errorf("nothing to update: give cells, add_rows or add_columns")
+	}
+	chapterNum, _, err := parseTableID(tableID)
+	if err != nil {
+		return nil, fmt.Errorf("invalid table ID %s: %w", tableID, err)
+	}
+
+	chapter, err := m.storage.LoadChapterMetadata(string(docID), int(chapterNum))
+	if err != nil {
+		return nil, fmt.Errorf("failed to load chapter: %w", err)
+	}
+	var table *types.Table
+	for i := range chapter.Tables {
+		if chapter.Tables[i].ID == tableID {
+			table = &chapter.Tables[i]
+			break
+		}
+	}
+	if table == nil {
+		return nil, fmt.Errorf("table %s not found in chapter %d", tableID, chapterNum)
+	}
+	if table.Data == nil {
+		return nil, fmt.Errorf("table %s has no cell data; only tables from create_table_from_csv can be updated cell by cell", tableID)
+	}
+
+	data := table.Data
+	for _, column := range update.AddColumns {
+		if len(column.Values) > len(data.Rows) {
+			return nil, fmt.Errorf("column %q has %d values for %d rows", column.Header, len(column.Values), len(data.Rows))
+		}
+		data.Header = append(data.Header, column.Header)
+		for i := range data.Rows {
+			value := ""
+			if i < len(column.Values) {
+				value = column.Values[i]
+			}
+			data.Rows[i] = append(data.Rows[i], value)
+		}
+	}
+	for _, row := range update.AddRows {
+		if len(row) > data.Columns() {
+			return nil, fmt.Errorf("row with %d cells added to a table with %d columns", len(row), data.Columns())
+		}
+		data.Rows = append(data.Rows, append([]string{}, row...))
+	}
+	data.Normalize()
+
+	for _, cell := range update.Cells {
+		if cell.Row < 0 || cell.Row > len(data.Rows) {
+			return nil, fmt.Errorf("row %d out of range: the table has the header row 0 and rows 1-%d", cell.Row, len(data.Rows))
+		}
+		if cell.Column < 1 || cell.Column > data.Columns() {
+			return nil, fmt.Errorf("column %d out of range: the table has columns 1-%d", cell.Column, data.Columns())
+		}
+		value := strings.TrimSpace(cell.Value)
+		if cell.Row == 0 {
+			data.Header[cell.Column-1] = value
+		} else {
+			data.Rows[cell.Row-1][cell.Column-1] = value
+		}
+	}
+
+	now := time.Now()
+	table.Content = data.Markdown(table.Format)
+	table.UpdatedAt = now
+	chapter.UpdatedAt = now
+
+	if err := m.storage.SaveChapterMetadata(string(docID), chapter); err != nil {
+		return nil, fmt.Errorf("failed to save chapter metadata: %w", err)
+	}
+	if err := m.RebuildChapterMarkdown(docID, chapterNum); err != nil {
+		return nil, fmt.Errorf("failed to rebuild chapter markdown: %w", err)
+	}
+	return table, nil
+}
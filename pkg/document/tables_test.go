@@ -0,0 +1,90 @@
+package document
+
+import (
+	"context"
+	"os"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/gomcpgo/docgen/pkg/types"
+)
+
+func TestParseDelimitedTable(t *testing.T) {
+	tests := []struct {
+		name      string
+		data      string
+		delimiter string
+		header    []string
+		rows      [][]string
+	}{
+		{"csv", "Name, Score\nAda,\"1,5\"\nGrace\n", "", []string{"Name", "Score"}, [][]string{{"Ada", "1,5"}, {"Grace", ""}}},
+		{"tsv", "Name\tScore\r\nAda\t3\r\n", "", []string{"Name", "Score"}, [][]string{{"Ada", "3"}}},
+		{"semicolons", "Name;Score\nAda;2,5", "", []string{"Name", "Score"}, [][]string{{"Ada", "2,5"}}},
+		{"explicit", "Name|Score\nAda|4", "|", []string{"Name", "Score"}, [][]string{{"Ada", "4"}}},
+	}
+	for _, tt := range tests {
+		table, err := ParseDelimitedTable(tt.data, tt.delimiter)
+		if err != nil {
+			t.Fatalf("%s: ParseDelimitedTable() error = %v", tt.name, err)
+		}
+		if !reflect.DeepEqual(table.Header, tt.header) || !reflect.DeepEqual(table.Rows, tt.rows) {
+			t.Errorf("%s: got %q %q, want %q %q", tt.name, table.Header, table.Rows, tt.header, tt.rows)
+		}
+	}
+
+	if _, err := ParseDelimitedTable(" \n", ""); err == nil {
+		t.Error("Expected an error for empty data")
+	}
+	if _, err := ParseDelimitedTable("a,b", "::"); err == nil {
+		t.Error("Expected an error for an invalid delimiter")
+	}
+}
+
+func TestManager_AddTable(t *testing.T) {
+	manager, tempDir := setupTestManager(t)
+	defer os.RemoveAll(tempDir)
+
+	docID, _ := manager.CreateDocument("Report", "Test Author", types.DocumentTypeBook)
+	chapterNum, _ := manager.AddChapter(context.Background(), docID, "Findings", nil)
+	manager.AddSection(context.Background(), docID, chapterNum, "Results", "First.\n\nSecond.", 1)
+
+	data, _ := ParseDelimitedTable("Name,Score\nAda,3\nGrace,5", "")
+	table, err := manager.AddTable(docID, types.SectionNumber{1, 1}, 1, "Scores", "", data)
+	if err != nil {
+		t.Fatalf("AddTable() error = %v", err)
+	}
+	if table.ID != "table-1.1" || table.Format != types.TableFormatMarkdown || table.Anchor.Paragraph != 1 {
+		t.Errorf("Unexpected table %+v", table)
+	}
+	content, _ := manager.storage.LoadChapterContent(string(docID), int(chapterNum))
+	if !strings.Contains(content, "First.\n\n| Name | Score |\n|---|---|\n| Ada | 3 |\n| Grace | 5 |\n\nTable: Scores {#table-1.1}\n\nSecond.") {
+		t.Errorf("Expected the table after the first paragraph:\n%s", content)
+	}
+
+	table, err = manager.UpdateTableData(docID, table.ID, types.TableUpdate{
+		AddColumns: []types.TableColumn{{Header: "Rank", Values: []string{"2"}}},
+		AddRows:    [][]string{{"Linus", "4"}},
+		Cells:      []types.TableCell{{Row: 0, Column: 2, Value: "Points"}, {Row: 2, Column: 3, Value: "1"}},
+	})
+	if err != nil {
+		t.Fatalf("UpdateTableData() error = %v", err)
+	}
+	if want := "| Name | Points | Rank |\n|---|---|---|\n| Ada | 3 | 2 |\n| Grace | 5 | 1 |\n| Linus | 4 |  |"; table.Content != want {
+		t.Errorf("Content =\n%s\nwant:\n%s", table.Content, want)
+	}
+	content, _ = manager.storage.LoadChapterContent(string(docID), int(chapterNum))
+	if !strings.Contains(content, "| Linus | 4 |  |") {
+		t.Errorf("Expected the chapter rebuilt with the updated table:\n%s", content)
+	}
+
+	if _, err := manager.UpdateTableData(docID, table.ID, types.TableUpdate{Cells: []types.TableCell{{Row: 9, Column: 1}}}); err == nil || !strings.Contains(err.Error(), "row 9 out of range") {
+		t.Errorf("UpdateTableData() error = %v, want row out of range", err)
+	}
+	if _, err := manager.UpdateTableData(docID, "table-1.7", types.TableUpdate{AddRows: [][]string{{"x"}}}); err == nil {
+		t.Error("Expected an error for a missing table")
+	}
+	if _, err := manager.AddTable(docID, types.SectionNumber{1, 4}, -1, "Missing", "", data); err == nil {
+		t.Error("Expected an error for a missing section")
+	}
+}
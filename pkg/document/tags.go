@@ -0,0 +1,74 @@
+package document
+
+import (
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/gomcpgo/docgen/pkg/types"
+)
+
+// TagDocument adds tags to a document. Tags it already has are ignored. It
+// returns the document's tags, sorted.
+func (m *Manager) TagDocument(docID types.DocumentID, tags []string) ([]string, error) {
+	return m.updateTags(docID, tags, func(current map[string]bool, tag string) error {
+		current[tag] = true
+		return nil
+	})
+}
+
+// UntagDocument removes tags from a document. It returns the document's
+// remaining tags, sorted.
+func (m *Manager) UntagDocument(docID types.DocumentID, tags []string) ([]string, error) {
+	return m.updateTags(docID, tags, func(current map[string]bool, tag string) error {
+		if !current[tag] {
+			return fmt.Errorf("document %s has no tag %q", docID, tag)
+		}
+		delete(current, tag)
+		return nil
+	})
+}
+
+// updateTags applies change to each normalized tag and saves the result
+func (m *Manager) updateTags(docID types.DocumentID, tags []string, change func(current map[string]bool, tag string) error) ([]string, error) {
+	if err := docID.Validate(); err != nil {
+		return nil, fmt.Errorf("invalid document ID: %w", err)
+	}
+	if len(tags) == 0 {
+		return nil, fmt.Errorf("at least one tag is required")
+	}
+
+	manifest, err := m.storage.LoadManifest(string(docID))
+	if err != nil {
+		return nil, fmt.Errorf("failed to load document manifest: %w", err)
+	}
+
+	current := make(map[string]bool)
+	for _, tag := range manifest.Document.Tags {
+		current[tag] = true
+	}
+	for _, tag := range tags {
+		normalized, err := types.NormalizeTag(tag)
+		if err != nil {
+			return nil, err
+		}
+		if err := change(current, normalized); err != nil {
+			return nil, err
+		}
+	}
+
+	manifest.Document.Tags = nil
+	for tag := range current {
+		manifest.Document.Tags = append(manifest.Document.Tags, tag)
+	}
+	sort.Strings(manifest.Document.Tags)
+
+	now := time.Now()
+	manifest.Document.UpdatedAt = now
+	manifest.UpdatedAt = now
+	if err := m.storage.SaveManifest(string(docID), manifest); err != nil {
+		return nil, fmt.Errorf("failed to update manifest: %w", err)
+	}
+
+	return manifest.Document.Tags, nil
+}
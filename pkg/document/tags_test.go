@@ -0,0 +1,55 @@
+package document
+
+import (
+	"os"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/gomcpgo/docgen/pkg/types"
+)
+
+func TestManager_TagDocument(t *testing.T) {
+	manager, tempDir := setupTestManager(t)
+	defer os.RemoveAll(tempDir)
+
+	docID, _ := manager.CreateDocument("Quarterly Report", "Test Author", types.DocumentTypeReport)
+
+	tags, err := manager.TagDocument(docID, []string{" Client:Acme ", "q3", "q3"})
+	if err != nil {
+		t.Fatalf("TagDocument() error = %v", err)
+	}
+	if !reflect.DeepEqual(tags, []string{"client:acme", "q3"}) {
+		t.Errorf("TagDocument() = %v", tags)
+	}
+	manifest, _ := manager.GetDocumentStructure(docID)
+	if !manifest.Document.HasTags([]string{"q3", "client:acme"}) || manifest.Document.HasTags([]string{"q4"}) {
+		t.Errorf("Unexpected tags in manifest: %v", manifest.Document.Tags)
+	}
+
+	tags, err = manager.UntagDocument(docID, []string{"Q3"})
+	if err != nil || !reflect.DeepEqual(tags, []string{"client:acme"}) {
+		t.Errorf("UntagDocument() = %v, %v", tags, err)
+	}
+
+	for _, tt := range []struct {
+		tags    []string
+		remove  bool
+		wantErr string
+	}{
+		{nil, false, "at least one tag"},
+		{[]string{"two words"}, false, "invalid tag"},
+		{[]string{"  "}, false, "tag cannot be empty"},
+		{[]string{"q4"}, true, "has no tag"},
+	} {
+		var err error
+		if tt.remove {
+			_, err = manager.UntagDocument(docID, tt.tags)
+		} else {
+			_, err = manager.TagDocument(docID, tt.tags)
+		}
+		if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+			t.Errorf("tags %v: error = %v, want %q", tt.tags, err, tt.wantErr)
+		}
+	}
+}
@@ -0,0 +1,116 @@
+package document
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/gomcpgo/docgen/pkg/types"
+)
+
+// templateVariablePattern matches {{variable}} placeholders, allowing inner spaces
+var templateVariablePattern = regexp.MustCompile(`\{\{\s*([A-Za-z_][A-Za-z0-9_]*)\s*\}\}`)
+
+// SaveSectionTemplate stores a reusable section scaffold. Saving under an existing
+// name replaces the previous template.
+func (m *Manager) SaveSectionTemplate(name, description, title, content string, level int) (*types.SectionTemplate, error) {
+	if err := types.ValidateTemplateName(name); err != nil {
+		return nil, fmt.Errorf("invalid template name: %w", err)
+	}
+	if title == "" {
+		return nil, fmt.Errorf("template title is required")
+	}
+	if content == "" {
+		return nil, fmt.Errorf("template content is required")
+	}
+	if level < 1 || level > 6 {
+		return nil, fmt.Errorf("section level must be between 1 and 6")
+	}
+
+	now := time.Now()
+	template := &types.SectionTemplate{
+		Name:        name,
+		Description: description,
+		Title:       title,
+		Content:     content,
+		Level:       level,
+		Variables:   extractTemplateVariables(title + "\n" + content),
+		CreatedAt:   now,
+		UpdatedAt:   now,
+	}
+
+	// Keep the original creation time when replacing a template
+	if existing, err := m.storage.LoadSectionTemplate(name); err == nil && existing != nil {
+		template.CreatedAt = existing.CreatedAt
+	}
+
+	if err := m.storage.SaveSectionTemplate(template); err != nil {
+		return nil, fmt.Errorf("failed to save section template: %w", err)
+	}
+
+	return template, nil
+}
+
+// ApplySectionTemplate instantiates a section template into a new section of a chapter.
+// Every variable used by the template must be supplied in values. A non-zero level
+// overrides the template's default level.
+func (m *Manager) ApplySectionTemplate(ctx context.Context, docID types.DocumentID, chapterNum types.ChapterNumber, name string, values map[string]string, level int) (types.SectionNumber, error) {
+	if err := docID.Validate(); err != nil {
+		return nil, fmt.Errorf("invalid document ID: %w", err)
+	}
+	if err := types.ValidateTemplateName(name); err != nil {
+		return nil, fmt.Errorf("invalid template name: %w", err)
+	}
+
+	template, err := m.storage.LoadSectionTemplate(name)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load section template: %w", err)
+	}
+
+	var missing []string
+	for _, variable := range template.Variables {
+		if _, ok := values[variable]; !ok {
+			missing = append(missing, variable)
+		}
+	}
+	if len(missing) > 0 {
+		return nil, fmt.Errorf("missing values for template variables: %s", strings.Join(missing, ", "))
+	}
+
+	if level == 0 {
+		level = template.Level
+	}
+
+	title := substituteTemplateVariables(template.Title, values)
+	content := substituteTemplateVariables(template.Content, values)
+
+	return m.AddSection(ctx, docID, chapterNum, title, content, level)
+}
+
+// extractTemplateVariables returns the sorted, unique variable names used in text
+func extractTemplateVariables(text string) []string {
+	seen := make(map[string]bool)
+	variables := []string{}
+	for _, match := range templateVariablePattern.FindAllStringSubmatch(text, -1) {
+		if !seen[match[1]] {
+			seen[match[1]] = true
+			variables = append(variables, match[1])
+		}
+	}
+	sort.Strings(variables)
+	return variables
+}
+
+// substituteTemplateVariables replaces {{variable}} placeholders with supplied values
+func substituteTemplateVariables(text string, values map[string]string) string {
+	return templateVariablePattern.ReplaceAllStringFunc(text, func(match string) string {
+		name := templateVariablePattern.FindStringSubmatch(match)[1]
+		if value, ok := values[name]; ok {
+			return value
+		}
+		return match
+	})
+}
@@ -0,0 +1,67 @@
+package document
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/gomcpgo/docgen/pkg/types"
+)
+
+func TestManager_SectionTemplates(t *testing.T) {
+	manager, _ := setupTestManager(t)
+
+	docID, err := manager.CreateDocument("Test Document", "Test Author", types.DocumentTypeReport)
+	if err != nil {
+		t.Fatalf("Failed to create document: %v", err)
+	}
+	chapterNum, err := manager.AddChapter(context.Background(), docID, "Test Chapter", nil)
+	if err != nil {
+		t.Fatalf("Failed to add chapter: %v", err)
+	}
+
+	template, err := manager.SaveSectionTemplate(
+		"executive-summary",
+		"Standard executive summary",
+		"Executive Summary: {{ project }}",
+		"This report covers {{project}} for {{quarter}}.",
+		2,
+	)
+	if err != nil {
+		t.Fatalf("SaveSectionTemplate() error = %v", err)
+	}
+	if strings.Join(template.Variables, ",") != "project,quarter" {
+		t.Errorf("SaveSectionTemplate() variables = %v, want [project quarter]", template.Variables)
+	}
+
+	// Missing values are reported by name
+	_, err = manager.ApplySectionTemplate(context.Background(), docID, chapterNum, "executive-summary", map[string]string{"project": "Apollo"}, 0)
+	if err == nil || !strings.Contains(err.Error(), "quarter") {
+		t.Errorf("ApplySectionTemplate() should report missing variable, got %v", err)
+	}
+
+	sectionNum, err := manager.ApplySectionTemplate(context.Background(), docID, chapterNum, "executive-summary", map[string]string{
+		"project": "Apollo",
+		"quarter": "Q3",
+	}, 0)
+	if err != nil {
+		t.Fatalf("ApplySectionTemplate() error = %v", err)
+	}
+
+	chapter, err := manager.GetChapter(docID, chapterNum)
+	if err != nil {
+		t.Fatalf("Failed to get chapter: %v", err)
+	}
+	section := chapter.Sections[0]
+	if section.Title != "Executive Summary: Apollo" || section.Level != 2 {
+		t.Errorf("Applied section = %q (level %d)", section.Title, section.Level)
+	}
+
+	content, err := manager.GetSectionContent(docID, chapterNum, sectionNum)
+	if err != nil {
+		t.Fatalf("Failed to get section content: %v", err)
+	}
+	if content != "This report covers Apollo for Q3." {
+		t.Errorf("Applied content = %q", content)
+	}
+}
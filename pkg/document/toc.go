@@ -0,0 +1,52 @@
+package document
+
+import (
+	"fmt"
+
+	"github.com/gomcpgo/docgen/pkg/types"
+)
+
+// DefaultTOCDepth includes chapters and two levels of sections
+const DefaultTOCDepth = 3
+
+// TableOfContents returns a document's chapters and sections with figure and
+// table counts. Depth counts heading levels as toc_depth does: 1 lists only the
+// chapters, 2 adds top-level sections and so on.
+func (m *Manager) TableOfContents(docID types.DocumentID, depth int) (*types.TableOfContents, error) {
+	if depth < 1 {
+		return nil, fmt.Errorf("depth must be at least 1")
+	}
+
+	manifest, err := m.GetDocumentStructure(docID)
+	if err != nil {
+		return nil, err
+	}
+
+	toc := &types.TableOfContents{
+		Title:    manifest.Document.Title,
+		Chapters: []types.TOCChapter{},
+	}
+	for _, chapter := range manifest.Document.Chapters {
+		entry := types.TOCChapter{
+			Number:  chapter.Number,
+			Title:   chapter.Title,
+			Figures: len(chapter.Figures),
+			Tables:  len(chapter.Tables),
+		}
+		if number, part := manifest.Document.PartContaining(chapter.Number); part != nil && part.FirstChapter == chapter.Number {
+			entry.Part = fmt.Sprintf("Part %s: %s", types.RomanNumeral(number), part.Title)
+		}
+		for _, section := range chapter.Sections {
+			if section.Level < depth {
+				entry.Sections = append(entry.Sections, types.TOCSection{
+					Number: section.Number,
+					Title:  section.Title,
+					Level:  section.Level,
+				})
+			}
+		}
+		toc.Chapters = append(toc.Chapters, entry)
+	}
+
+	return toc, nil
+}
@@ -0,0 +1,54 @@
+package document
+
+import (
+	"context"
+	"os"
+	"testing"
+
+	"github.com/gomcpgo/docgen/pkg/types"
+)
+
+func TestManager_TableOfContents(t *testing.T) {
+	manager, tempDir := setupTestManager(t)
+	defer os.RemoveAll(tempDir)
+
+	docID, _ := manager.CreateDocument("Field Guide", "Test Author", types.DocumentTypeBook)
+	first, _ := manager.AddChapter(context.Background(), docID, "Birds", nil)
+	second, _ := manager.AddChapter(context.Background(), docID, "Trees", nil)
+	manager.AddSection(context.Background(), docID, first, "Songbirds", "Text", 1)
+	manager.AddSection(context.Background(), docID, first, "Finches", "Text", 2)
+	manager.AddSection(context.Background(), docID, first, "Goldfinch", "Text", 3)
+	manager.AddSection(context.Background(), docID, second, "Oaks", "Text", 1)
+	manager.AddImage(docID, first, "robin.png", "A robin", "here", "", "")
+	manager.AddImage(docID, first, "wren.png", "A wren", "here", "", "")
+	if _, err := manager.SetPart(docID, types.Part{Title: "Living Things", FirstChapter: first, LastChapter: second}); err != nil {
+		t.Fatalf("SetPart() error = %v", err)
+	}
+
+	toc, err := manager.TableOfContents(docID, DefaultTOCDepth)
+	if err != nil {
+		t.Fatalf("TableOfContents() error = %v", err)
+	}
+	want := "Field Guide\n" +
+		"Part I: Living Things\n" +
+		"1 Birds (2 figures)\n" +
+		"  1.1 Songbirds\n" +
+		"    1.1.1 Finches\n" +
+		"2 Trees\n" +
+		"  2.1 Oaks\n"
+	if got := toc.Text(); got != want {
+		t.Errorf("TableOfContents().Text() =\n%s\nwant\n%s", got, want)
+	}
+
+	// Depth 1 lists the chapters alone
+	toc, _ = manager.TableOfContents(docID, 1)
+	for _, chapter := range toc.Chapters {
+		if len(chapter.Sections) != 0 {
+			t.Errorf("Chapter %d should have no sections at depth 1: %v", chapter.Number, chapter.Sections)
+		}
+	}
+
+	if _, err := manager.TableOfContents(docID, 0); err == nil {
+		t.Error("Expected an error for depth 0")
+	}
+}
@@ -0,0 +1,16 @@
+package document
+
+import (
+	"github.com/gomcpgo/docgen/pkg/types"
+)
+
+// ListTodos returns the outstanding drafting items in a document, such as
+// figures that were added with a placeholder caption
+func (m *Manager) ListTodos(docID types.DocumentID) ([]types.TodoItem, error) {
+	manifest, err := m.GetDocumentStructure(docID)
+	if err != nil {
+		return nil, err
+	}
+
+	return manifest.Todos(), nil
+}
@@ -0,0 +1,61 @@
+package document
+
+import (
+	"context"
+	"testing"
+
+	"github.com/gomcpgo/docgen/pkg/types"
+)
+
+func TestManager_ListTodos_PlaceholderCaption(t *testing.T) {
+	manager, _ := setupTestManager(t)
+
+	docID, err := manager.CreateDocument("Test Document", "Test Author", types.DocumentTypeBook)
+	if err != nil {
+		t.Fatalf("Failed to create document: %v", err)
+	}
+	chapterNum, err := manager.AddChapter(context.Background(), docID, "Test Chapter", nil)
+	if err != nil {
+		t.Fatalf("Failed to add chapter: %v", err)
+	}
+
+	// An image without a caption is accepted with a placeholder
+	figureID, err := manager.AddImage(docID, chapterNum, "assets/images/diagram.png", "", "here", "", "")
+	if err != nil {
+		t.Fatalf("AddImage() without caption error = %v", err)
+	}
+	if _, err := manager.AddImage(docID, chapterNum, "assets/images/photo.png", "A photo", "here", "", ""); err != nil {
+		t.Fatalf("AddImage() error = %v", err)
+	}
+
+	todos, err := manager.ListTodos(docID)
+	if err != nil {
+		t.Fatalf("ListTodos() error = %v", err)
+	}
+	if len(todos) != 1 {
+		t.Fatalf("ListTodos() returned %d items, want 1", len(todos))
+	}
+	if todos[0].Kind != types.TodoKindFigureCaption || todos[0].ItemID != string(figureID) {
+		t.Errorf("ListTodos() item = %+v", todos[0])
+	}
+
+	chapter, err := manager.GetChapter(docID, chapterNum)
+	if err != nil {
+		t.Fatalf("Failed to get chapter: %v", err)
+	}
+	if chapter.Figures[0].Caption != "TODO: caption for diagram.png" {
+		t.Errorf("Placeholder caption = %q", chapter.Figures[0].Caption)
+	}
+
+	// Setting a real caption resolves the TODO
+	if err := manager.UpdateImageCaption(docID, figureID, "System diagram"); err != nil {
+		t.Fatalf("UpdateImageCaption() error = %v", err)
+	}
+	todos, err = manager.ListTodos(docID)
+	if err != nil {
+		t.Fatalf("ListTodos() error = %v", err)
+	}
+	if len(todos) != 0 {
+		t.Errorf("ListTodos() after caption update returned %d items, want 0", len(todos))
+	}
+}
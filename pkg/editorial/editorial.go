@@ -0,0 +1,229 @@
+// Package editorial checks markdown text against house style rules. It provides the
+// document editorial rules (spelling out small numbers, spacing units and making
+// percentages consistent) and a rule engine for organization-defined rulesets.
+package editorial
+
+import (
+	"regexp"
+	"strings"
+
+	"github.com/gomcpgo/docgen/pkg/types"
+)
+
+// Rule names used in change reports
+const (
+	RuleSpellOutNumbers = "spell_out_numbers"
+	RuleUnitSpacing     = "unit_spacing"
+	RulePercentStyle    = "percent_style"
+)
+
+// unitSpace is the narrow no-break space placed between a number and its unit
+const unitSpace = "\u202f"
+
+// units lists the unit symbols recognized after a number, longest first where they share a prefix
+const units = `°C|°F|µm|µs|kHz|MHz|GHz|Hz|kg|mg|km|cm|mm|nm|ms|min|kW|MW|mW|mV|mA|kJ|kPa|MPa|Pa|mL|KB|MB|GB|TB|kB|g|m|s|h|V|A|W|J|N|L|K`
+
+// Change describes a single edit made to a line of text
+type Change struct {
+	Line        int
+	Rule        string
+	Original    string
+	Replacement string
+}
+
+var (
+	// protectedPattern matches inline spans that must never be rewritten:
+	// code, math, link targets, attributes, HTML tags and bare URLs
+	protectedPattern = regexp.MustCompile("`[^`]*`|\\$[^$]*\\$|\\]\\([^)]*\\)|\\{[^}]*\\}|<[^>]*>|https?://\\S+")
+
+	fencePattern    = regexp.MustCompile("^\\s{0,3}(`{3,}|~{3,})")
+	listItemPattern = regexp.MustCompile(`^\s*\d+[.)]\s`)
+
+	percentSymbolPattern = regexp.MustCompile(`(\d+(?:[.,]\d+)?)(?:\s?%|\s+(?:percent|per cent|pct)\b)`)
+	unitPattern          = regexp.MustCompile(`(\d+(?:[.,]\d+)?)(?: |\x{00A0}|\x{2009}|\x{202F})?(` + units + `)\b`)
+	unitWordPattern      = regexp.MustCompile(`^(` + units + `)[.,;:!?]*$`)
+	numberPattern        = regexp.MustCompile(`\d+(?:[.,]\d+)*`)
+
+	// numberReferenceWords precede numbers that label something and stay numerals
+	numberReferenceWords = map[string]bool{
+		"chapter": true, "chapters": true, "section": true, "sections": true,
+		"figure": true, "fig.": true, "table": true, "tables": true,
+		"page": true, "pages": true, "p.": true, "pp.": true, "step": true,
+		"version": true, "level": true, "equation": true, "eq.": true,
+		"appendix": true, "part": true, "no.": true, "item": true, "line": true,
+	}
+
+	smallNumberWords = []string{"zero", "one", "two", "three", "four", "five", "six", "seven", "eight", "nine"}
+)
+
+// Normalize applies the enabled rules to markdown text and returns the rewritten
+// text with the list of changes. Code blocks, tables, headings markup, inline code,
+// math and link targets are left untouched.
+func Normalize(text string, rules *types.EditorialRules) (string, []Change) {
+	if !rules.Enabled() {
+		return text, nil
+	}
+
+	// Percentages and units are handled first so that the numbers they contain
+	// are not spelled out
+	var ruleset Ruleset
+	if rules.PercentStyle != "" {
+		style := rules.PercentStyle
+		ruleset.add(RulePercentStyle, func(text string, lineStart bool) (string, []edit) {
+			return normalizePercent(text, style)
+		})
+	}
+	if rules.UnitSpacing {
+		ruleset.add(RuleUnitSpacing, func(text string, lineStart bool) (string, []edit) {
+			return spaceUnits(text)
+		})
+	}
+	if rules.SpellOutNumbers {
+		ruleset.add(RuleSpellOutNumbers, spellOutNumbers)
+	}
+
+	normalized, findings := ruleset.Check(text, true)
+
+	changes := make([]Change, 0, len(findings))
+	for _, finding := range findings {
+		changes = append(changes, Change{
+			Line:        finding.Line,
+			Rule:        finding.Rule,
+			Original:    finding.Match,
+			Replacement: finding.Suggestion,
+		})
+	}
+	return normalized, changes
+}
+
+// normalizePercent rewrites percentages in the given style
+func normalizePercent(text string, style types.PercentStyle) (string, []edit) {
+	var edits []edit
+	text = percentSymbolPattern.ReplaceAllStringFunc(text, func(match string) string {
+		number := percentSymbolPattern.FindStringSubmatch(match)[1]
+		replacement := number + "%"
+		if style == types.PercentStyleWord {
+			replacement = number + " percent"
+		}
+		if replacement != match {
+			edits = append(edits, edit{original: match, replacement: replacement})
+		}
+		return replacement
+	})
+	return text, edits
+}
+
+// spaceUnits puts a narrow no-break space between numbers and their units. Single
+// letter units written without a space ("1990s", "4K") are too ambiguous to touch.
+func spaceUnits(text string) (string, []edit) {
+	var edits []edit
+	var out strings.Builder
+	last := 0
+
+	for _, loc := range unitPattern.FindAllStringSubmatchIndex(text, -1) {
+		start, end := loc[0], loc[1]
+		if start > 0 && (isWordByte(text[start-1]) || text[start-1] == '.' || text[start-1] == ',') {
+			continue
+		}
+		number, unit := text[loc[2]:loc[3]], text[loc[4]:loc[5]]
+		if loc[3] == loc[4] && len(unit) == 1 {
+			continue
+		}
+
+		replacement := number + unitSpace + unit
+		if replacement == text[start:end] {
+			continue
+		}
+		out.WriteString(text[last:start])
+		out.WriteString(replacement)
+		last = end
+		edits = append(edits, edit{original: text[start:end], replacement: replacement})
+	}
+	out.WriteString(text[last:])
+
+	return out.String(), edits
+}
+
+// spellOutNumbers replaces standalone whole numbers under ten with words. Numbers
+// that are part of a larger token, carry a unit or percentage, or label a chapter,
+// figure, page and so on are left as numerals.
+func spellOutNumbers(text string, lineStart bool) (string, []edit) {
+	var edits []edit
+	var out strings.Builder
+	last := 0
+
+	for _, loc := range numberPattern.FindAllStringIndex(text, -1) {
+		start, end := loc[0], loc[1]
+		number := text[start:end]
+		if len(number) != 1 || !isStandaloneNumber(text, start, end) {
+			continue
+		}
+
+		word := smallNumberWords[number[0]-'0']
+		if isSentenceStart(text[:start], lineStart) {
+			word = strings.ToUpper(word[:1]) + word[1:]
+		}
+
+		out.WriteString(text[last:start])
+		out.WriteString(word)
+		last = end
+		edits = append(edits, edit{original: number, replacement: word})
+	}
+	out.WriteString(text[last:])
+
+	return out.String(), edits
+}
+
+// isStandaloneNumber reports whether the number at text[start:end] reads as a plain count
+func isStandaloneNumber(text string, start, end int) bool {
+	// Part of an identifier, amount, range, time or other token; non-ASCII
+	// neighbours include no-break spaces, quotes and degree signs
+	if start > 0 {
+		prev := text[start-1]
+		if isWordByte(prev) || prev >= 0x80 || strings.IndexByte("$#+-/:.,@^_*\\'\"", prev) >= 0 {
+			return false
+		}
+	}
+	if end < len(text) {
+		next := text[end]
+		if isWordByte(next) || next >= 0x80 || strings.IndexByte("%/:-^_*'", next) >= 0 {
+			return false
+		}
+		// A decimal point or thousands separator followed by more digits
+		if (next == '.' || next == ',') && end+1 < len(text) && text[end+1] >= '0' && text[end+1] <= '9' {
+			return false
+		}
+	}
+
+	// Labels such as "Figure 3" or "page 7"
+	before := strings.Fields(text[:start])
+	if len(before) > 0 && numberReferenceWords[strings.ToLower(before[len(before)-1])] {
+		return false
+	}
+
+	// Numbers followed by a unit or "percent" are measurements
+	after := strings.Fields(text[end:])
+	if len(after) > 0 {
+		nextWord := strings.TrimRight(after[0], ".,;:!?")
+		if strings.EqualFold(nextWord, "percent") || unitWordPattern.MatchString(after[0]) {
+			return false
+		}
+	}
+
+	return true
+}
+
+// isSentenceStart reports whether the text before a number ends a sentence
+func isSentenceStart(before string, lineStart bool) bool {
+	trimmed := strings.TrimRight(before, " ")
+	trimmed = strings.TrimLeft(trimmed, "#>*_ ")
+	if trimmed == "" {
+		return lineStart
+	}
+	return strings.HasSuffix(trimmed, ".") || strings.HasSuffix(trimmed, "!") || strings.HasSuffix(trimmed, "?")
+}
+
+// isWordByte reports whether b is an ASCII letter or digit
+func isWordByte(b byte) bool {
+	return (b >= 'a' && b <= 'z') || (b >= 'A' && b <= 'Z') || (b >= '0' && b <= '9')
+}
@@ -0,0 +1,91 @@
+package editorial
+
+import (
+	"testing"
+
+	"github.com/gomcpgo/docgen/pkg/types"
+)
+
+func TestNormalize_SpellOutNumbers(t *testing.T) {
+	rules := &types.EditorialRules{SpellOutNumbers: true}
+
+	tests := []struct {
+		name  string
+		input string
+		want  string
+	}{
+		{"small number", "We ran 3 trials.", "We ran three trials."},
+		{"sentence start", "5 samples failed. 2 passed.", "Five samples failed. Two passed."},
+		{"ten and above", "We ran 12 trials.", "We ran 12 trials."},
+		{"decimal", "A ratio of 2.5 was found.", "A ratio of 2.5 was found."},
+		{"reference label", "See Figure 3 and Chapter 2.", "See Figure 3 and Chapter 2."},
+		{"with unit", "It weighed 4 kg.", "It weighed 4 kg."},
+		{"with percent", "Only 7% replied.", "Only 7% replied."},
+		{"identifier", "Use v2 or 3D models.", "Use v2 or 3D models."},
+		{"list marker", "1. Add 2 eggs", "1. Add two eggs"},
+		{"inline code", "Set `retries = 3` for 3 hosts.", "Set `retries = 3` for three hosts."},
+		{"time", "Meet at 9:30.", "Meet at 9:30."},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, _ := Normalize(tt.input, rules)
+			if got != tt.want {
+				t.Errorf("Normalize(%q) = %q, want %q", tt.input, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestNormalize_UnitSpacing(t *testing.T) {
+	rules := &types.EditorialRules{UnitSpacing: true}
+
+	got, changes := Normalize("A 5 kg load over 12km in the 1990s at 25°C.", rules)
+	want := "A 5\u202fkg load over 12\u202fkm in the 1990s at 25\u202f°C."
+	if got != want {
+		t.Errorf("Normalize() = %q, want %q", got, want)
+	}
+	if len(changes) != 3 {
+		t.Errorf("Expected 3 changes, got %d: %+v", len(changes), changes)
+	}
+
+	// Already normalized text is left alone
+	if _, changes := Normalize(want, rules); len(changes) != 0 {
+		t.Errorf("Expected no changes for normalized text, got %+v", changes)
+	}
+}
+
+func TestNormalize_PercentStyle(t *testing.T) {
+	symbol := &types.EditorialRules{PercentStyle: types.PercentStyleSymbol}
+	got, _ := Normalize("Growth was 12 percent, then 4 %, then 3.5 per cent.", symbol)
+	if want := "Growth was 12%, then 4%, then 3.5%."; got != want {
+		t.Errorf("symbol style = %q, want %q", got, want)
+	}
+
+	word := &types.EditorialRules{PercentStyle: types.PercentStyleWord, SpellOutNumbers: true}
+	got, _ = Normalize("Growth was 12% and 4%.", word)
+	if want := "Growth was 12 percent and 4 percent."; got != want {
+		t.Errorf("word style = %q, want %q", got, want)
+	}
+}
+
+func TestNormalize_SkipsCodeAndTables(t *testing.T) {
+	rules := &types.EditorialRules{SpellOutNumbers: true, UnitSpacing: true, PercentStyle: types.PercentStyleSymbol}
+	input := "```go\nx := 3 // 5 kg\n```\n\n| a | 4 percent |\n|---|---|\n\nThere were 2 tables."
+
+	got, changes := Normalize(input, rules)
+	want := "```go\nx := 3 // 5 kg\n```\n\n| a | 4 percent |\n|---|---|\n\nThere were two tables."
+	if got != want {
+		t.Errorf("Normalize() = %q, want %q", got, want)
+	}
+	if len(changes) != 1 || changes[0].Line != 8 || changes[0].Rule != RuleSpellOutNumbers {
+		t.Errorf("Expected a single spell-out change on line 8, got %+v", changes)
+	}
+}
+
+func TestNormalize_Disabled(t *testing.T) {
+	input := "We ran 3 trials at 5 kg and 10 percent."
+	if got, changes := Normalize(input, nil); got != input || changes != nil {
+		t.Errorf("Normalize() with no rules should not change text")
+	}
+}
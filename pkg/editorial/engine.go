@@ -0,0 +1,401 @@
+package editorial
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/gomcpgo/docgen/pkg/types"
+)
+
+// Block kinds a house style rule can be limited to
+const (
+	BlockHeading    = "heading"
+	BlockParagraph  = "paragraph"
+	BlockListItem   = "list_item"
+	BlockBlockquote = "blockquote"
+)
+
+// Built-in house style checks
+const (
+	BuiltinSpellOutNumbers    = "spell_out_numbers"
+	BuiltinUnitSpacing        = "unit_spacing"
+	BuiltinPercentStyle       = "percent_style"
+	BuiltinRepeatedWords      = "repeated_words"
+	BuiltinMaxSentenceWords   = "max_sentence_words"
+	BuiltinHeadingPunctuation = "heading_punctuation"
+)
+
+// defaultMaxSentenceWords is used when max_sentence_words has no "max" option
+const defaultMaxSentenceWords = 35
+
+var (
+	headingLinePattern    = regexp.MustCompile(`^#{1,6}\s`)
+	blockquoteLinePattern = regexp.MustCompile(`^\s{0,3}>`)
+	bulletItemPattern     = regexp.MustCompile(`^\s*[-*+]\s`)
+	wordPattern           = regexp.MustCompile(`[\p{L}\p{N}']+`)
+	sentenceEndPattern    = regexp.MustCompile(`[.!?](\s|$)`)
+)
+
+// edit is a single match of a rule within a prose segment. An empty replacement
+// means the rule only reports the match.
+type edit struct {
+	original    string
+	replacement string
+}
+
+// checkFunc checks a prose segment and returns the text with the rule's fixes
+// applied along with every match. lineStart is true when the segment starts its line.
+type checkFunc func(text string, lineStart bool) (string, []edit)
+
+// rule is a compiled house style rule
+type rule struct {
+	id       string
+	message  string
+	severity types.HouseStyleSeverity
+	fix      bool
+	blocks   map[string]bool // empty means every block kind
+	check    checkFunc
+}
+
+// Finding is a rule match within checked text
+type Finding struct {
+	Rule       string
+	Severity   types.HouseStyleSeverity
+	Message    string
+	Line       int // 1-based line in the checked text
+	Match      string
+	Suggestion string
+	Fixed      bool
+}
+
+// Ruleset is a compiled set of rules applied in order
+type Ruleset struct {
+	rules []rule
+}
+
+// add appends an always-fixing rule, used for the document editorial rules
+func (rs *Ruleset) add(id string, check checkFunc) {
+	rs.rules = append(rs.rules, rule{id: id, severity: types.SeverityInfo, fix: true, check: check})
+}
+
+// Compile validates a house style and compiles its rules
+func Compile(style *types.HouseStyle) (*Ruleset, error) {
+	ruleset := &Ruleset{}
+	seen := make(map[string]bool)
+
+	for i, def := range style.Rules {
+		if def.ID == "" {
+			return nil, fmt.Errorf("rule %d has no id", i+1)
+		}
+		if seen[def.ID] {
+			return nil, fmt.Errorf("duplicate rule id %q", def.ID)
+		}
+		seen[def.ID] = true
+
+		compiled := rule{
+			id:       def.ID,
+			message:  def.Message,
+			severity: def.Severity,
+			fix:      def.Fix,
+			blocks:   make(map[string]bool),
+		}
+
+		switch compiled.severity {
+		case "":
+			compiled.severity = types.SeverityWarning
+		case types.SeverityError, types.SeverityWarning, types.SeverityInfo:
+		default:
+			return nil, fmt.Errorf("rule %q: invalid severity %q", def.ID, def.Severity)
+		}
+
+		for _, block := range def.Blocks {
+			switch block {
+			case BlockHeading, BlockParagraph, BlockListItem, BlockBlockquote:
+				compiled.blocks[block] = true
+			default:
+				return nil, fmt.Errorf("rule %q: unknown block kind %q", def.ID, block)
+			}
+		}
+
+		var err error
+		switch {
+		case def.Pattern != "" && def.Builtin != "":
+			return nil, fmt.Errorf("rule %q: pattern and builtin cannot be combined", def.ID)
+		case def.Pattern != "":
+			compiled.check, err = regexCheck(def)
+		case def.Builtin != "":
+			compiled.check, err = builtinCheck(def, compiled.blocks)
+		default:
+			return nil, fmt.Errorf("rule %q needs a pattern or a builtin", def.ID)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("rule %q: %w", def.ID, err)
+		}
+
+		if compiled.message == "" {
+			compiled.message = def.ID
+		}
+		ruleset.rules = append(ruleset.rules, compiled)
+	}
+
+	return ruleset, nil
+}
+
+// Check runs every rule over markdown text. With fix set, matches of rules marked
+// as safe to fix are replaced; all other matches are only reported. Code blocks,
+// tables, inline code, math and link targets are never checked.
+func (rs *Ruleset) Check(text string, fix bool) (string, []Finding) {
+	var findings []Finding
+	lines := strings.Split(text, "\n")
+	fence := ""
+
+	for i, line := range lines {
+		// Skip fenced code and raw blocks
+		if match := fencePattern.FindStringSubmatch(line); match != nil {
+			if fence == "" {
+				fence = match[1]
+			} else if strings.Trim(strings.TrimSpace(line), fence[:1]) == "" && len(strings.TrimSpace(line)) >= len(fence) {
+				fence = ""
+			}
+			continue
+		}
+		if fence != "" {
+			continue
+		}
+
+		// Tables and indented code are not prose
+		trimmed := strings.TrimSpace(line)
+		if trimmed == "" || strings.HasPrefix(trimmed, "|") || strings.HasPrefix(line, "    ") || strings.HasPrefix(line, "\t") {
+			continue
+		}
+
+		lines[i], findings = rs.checkLine(line, i+1, classifyLine(line), fix, findings)
+	}
+
+	return strings.Join(lines, "\n"), findings
+}
+
+// CheckHeading runs the rules that apply to headings over a heading's text, such
+// as a section title. Nothing is fixed.
+func (rs *Ruleset) CheckHeading(title string) []Finding {
+	_, findings := rs.checkLine(title, 0, BlockHeading, false, nil)
+	return findings
+}
+
+// checkLine applies each rule in turn to a single line
+func (rs *Ruleset) checkLine(line string, lineNumber int, block string, fix bool, findings []Finding) (string, []Finding) {
+	for _, r := range rs.rules {
+		if len(r.blocks) > 0 && !r.blocks[block] {
+			continue
+		}
+
+		fixed, edits := applyToProse(line, r.check)
+		applied := fix && r.fix
+		for _, e := range edits {
+			findings = append(findings, Finding{
+				Rule:       r.id,
+				Severity:   r.severity,
+				Message:    r.message,
+				Line:       lineNumber,
+				Match:      e.original,
+				Suggestion: e.replacement,
+				Fixed:      applied && e.replacement != "",
+			})
+		}
+		if applied {
+			line = fixed
+		}
+	}
+	return line, findings
+}
+
+// applyToProse runs a check over the unprotected segments of a line. List markers
+// such as "3. " are structure, not prose, and are kept as they are.
+func applyToProse(line string, check checkFunc) (string, []edit) {
+	var edits []edit
+	var out strings.Builder
+
+	prefix := listItemPattern.FindString(line)
+	out.WriteString(prefix)
+	rest := line[len(prefix):]
+
+	last := 0
+	for _, span := range protectedPattern.FindAllStringIndex(rest, -1) {
+		text, segmentEdits := check(rest[last:span[0]], last == 0 && prefix == "")
+		out.WriteString(text)
+		edits = append(edits, segmentEdits...)
+		out.WriteString(rest[span[0]:span[1]])
+		last = span[1]
+	}
+	text, segmentEdits := check(rest[last:], last == 0 && prefix == "")
+	out.WriteString(text)
+	edits = append(edits, segmentEdits...)
+
+	return out.String(), edits
+}
+
+// classifyLine returns the block kind of a markdown line
+func classifyLine(line string) string {
+	switch {
+	case headingLinePattern.MatchString(line):
+		return BlockHeading
+	case blockquoteLinePattern.MatchString(line):
+		return BlockBlockquote
+	case listItemPattern.MatchString(line), bulletItemPattern.MatchString(line):
+		return BlockListItem
+	default:
+		return BlockParagraph
+	}
+}
+
+// regexCheck builds the check for a pattern rule
+func regexCheck(def types.HouseStyleRule) (checkFunc, error) {
+	pattern := def.Pattern
+	if def.IgnoreCase {
+		pattern = "(?i)" + pattern
+	}
+	re, err := regexp.Compile(pattern)
+	if err != nil {
+		return nil, fmt.Errorf("invalid pattern: %w", err)
+	}
+	if def.Fix && def.Replacement == nil {
+		return nil, fmt.Errorf("fix requires a replacement")
+	}
+
+	return func(text string, lineStart bool) (string, []edit) {
+		var edits []edit
+		var out strings.Builder
+		last := 0
+		for _, loc := range re.FindAllStringSubmatchIndex(text, -1) {
+			if loc[0] == loc[1] {
+				continue
+			}
+			e := edit{original: text[loc[0]:loc[1]]}
+			out.WriteString(text[last:loc[0]])
+			if def.Replacement != nil {
+				e.replacement = string(re.ExpandString(nil, *def.Replacement, text, loc))
+				out.WriteString(e.replacement)
+			} else {
+				out.WriteString(e.original)
+			}
+			last = loc[1]
+			edits = append(edits, e)
+		}
+		out.WriteString(text[last:])
+		return out.String(), edits
+	}, nil
+}
+
+// builtinCheck builds the check for a built-in rule
+func builtinCheck(def types.HouseStyleRule, blocks map[string]bool) (checkFunc, error) {
+	switch def.Builtin {
+	case BuiltinSpellOutNumbers:
+		return spellOutNumbers, nil
+
+	case BuiltinUnitSpacing:
+		return func(text string, lineStart bool) (string, []edit) {
+			return spaceUnits(text)
+		}, nil
+
+	case BuiltinPercentStyle:
+		style := types.PercentStyle(def.Options["style"])
+		if err := (&types.EditorialRules{PercentStyle: style}).Validate(); err != nil || style == "" {
+			return nil, fmt.Errorf("percent_style needs a style option of %q or %q", types.PercentStyleSymbol, types.PercentStyleWord)
+		}
+		return func(text string, lineStart bool) (string, []edit) {
+			return normalizePercent(text, style)
+		}, nil
+
+	case BuiltinRepeatedWords:
+		return repeatedWords, nil
+
+	case BuiltinMaxSentenceWords:
+		max := defaultMaxSentenceWords
+		if value, ok := def.Options["max"]; ok {
+			parsed, err := strconv.Atoi(value)
+			if err != nil || parsed <= 0 {
+				return nil, fmt.Errorf("max_sentence_words needs a positive max option")
+			}
+			max = parsed
+		}
+		return func(text string, lineStart bool) (string, []edit) {
+			return text, longSentences(text, max)
+		}, nil
+
+	case BuiltinHeadingPunctuation:
+		// Only meaningful for headings
+		if len(blocks) == 0 {
+			blocks[BlockHeading] = true
+		}
+		return headingPunctuation, nil
+
+	default:
+		return nil, fmt.Errorf("unknown builtin %q", def.Builtin)
+	}
+}
+
+// repeatedWords finds accidentally doubled words such as "the the"
+func repeatedWords(text string, lineStart bool) (string, []edit) {
+	var edits []edit
+	var out strings.Builder
+	last := 0
+
+	words := wordPattern.FindAllStringIndex(text, -1)
+	for i := 1; i < len(words); i++ {
+		prev, cur := words[i-1], words[i]
+		if strings.TrimSpace(text[prev[1]:cur[0]]) != "" {
+			continue
+		}
+		if !strings.EqualFold(text[prev[0]:prev[1]], text[cur[0]:cur[1]]) || isNumeric(text[cur[0]:cur[1]]) {
+			continue
+		}
+		out.WriteString(text[last:prev[1]])
+		last = cur[1]
+		edits = append(edits, edit{original: text[prev[0]:cur[1]], replacement: text[prev[0]:prev[1]]})
+	}
+	out.WriteString(text[last:])
+
+	return out.String(), edits
+}
+
+// longSentences reports sentences with more than max words
+func longSentences(text string, max int) []edit {
+	var edits []edit
+	start := 0
+	ends := sentenceEndPattern.FindAllStringIndex(text, -1)
+	ends = append(ends, []int{len(text), len(text)})
+
+	for _, end := range ends {
+		if end[0] < start {
+			continue
+		}
+		sentence := strings.TrimSpace(text[start:min(end[0]+1, len(text))])
+		if count := len(wordPattern.FindAllString(sentence, -1)); count > max {
+			edits = append(edits, edit{original: sentence})
+		}
+		start = end[1]
+	}
+	return edits
+}
+
+// headingPunctuation flags headings that end with a full stop or colon
+func headingPunctuation(text string, lineStart bool) (string, []edit) {
+	trimmed := strings.TrimRight(text, " \t")
+	if !strings.HasSuffix(trimmed, ".") && !strings.HasSuffix(trimmed, ":") {
+		return text, nil
+	}
+	// An ellipsis is deliberate
+	if strings.HasSuffix(trimmed, "...") {
+		return text, nil
+	}
+	fixed := strings.TrimRight(trimmed, ".:")
+	return fixed + text[len(trimmed):], []edit{{original: trimmed, replacement: fixed}}
+}
+
+// isNumeric reports whether a word is made of digits only
+func isNumeric(word string) bool {
+	_, err := strconv.Atoi(word)
+	return err == nil
+}
@@ -0,0 +1,134 @@
+package editorial
+
+import (
+	"testing"
+
+	"github.com/gomcpgo/docgen/pkg/types"
+)
+
+func stringPtr(s string) *string {
+	return &s
+}
+
+func TestCompile_Errors(t *testing.T) {
+	tests := []struct {
+		name string
+		rule types.HouseStyleRule
+	}{
+		{"missing id", types.HouseStyleRule{Pattern: "foo"}},
+		{"no check", types.HouseStyleRule{ID: "empty"}},
+		{"pattern and builtin", types.HouseStyleRule{ID: "both", Pattern: "foo", Builtin: BuiltinRepeatedWords}},
+		{"invalid pattern", types.HouseStyleRule{ID: "bad", Pattern: "("}},
+		{"fix without replacement", types.HouseStyleRule{ID: "nofix", Pattern: "foo", Fix: true}},
+		{"unknown builtin", types.HouseStyleRule{ID: "unknown", Builtin: "oxford_comma"}},
+		{"invalid severity", types.HouseStyleRule{ID: "sev", Pattern: "foo", Severity: "fatal"}},
+		{"unknown block", types.HouseStyleRule{ID: "block", Pattern: "foo", Blocks: []string{"table"}}},
+		{"percent without style", types.HouseStyleRule{ID: "pct", Builtin: BuiltinPercentStyle}},
+		{"invalid max", types.HouseStyleRule{ID: "long", Builtin: BuiltinMaxSentenceWords, Options: map[string]string{"max": "zero"}}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := Compile(&types.HouseStyle{Name: "test", Rules: []types.HouseStyleRule{tt.rule}}); err == nil {
+				t.Errorf("Compile() expected an error")
+			}
+		})
+	}
+
+	duplicate := &types.HouseStyle{Name: "test", Rules: []types.HouseStyleRule{
+		{ID: "same", Pattern: "foo"},
+		{ID: "same", Pattern: "bar"},
+	}}
+	if _, err := Compile(duplicate); err == nil {
+		t.Errorf("Compile() expected an error for duplicate rule ids")
+	}
+}
+
+func TestRuleset_CheckPattern(t *testing.T) {
+	ruleset, err := Compile(&types.HouseStyle{Name: "test", Rules: []types.HouseStyleRule{
+		{ID: "email", Pattern: `\be-mail\b`, IgnoreCase: true, Replacement: stringPtr("email"), Fix: true},
+		{ID: "utilize", Pattern: `\butiliz(e|es|ed)\b`, Replacement: stringPtr("us$1"), Severity: types.SeverityInfo},
+	}})
+	if err != nil {
+		t.Fatalf("Compile() error = %v", err)
+	}
+
+	input := "Send an E-mail.\n\n```\ne-mail = true\n```\n\nWe utilized `e-mail` tools."
+
+	// Reporting leaves the text untouched
+	text, findings := ruleset.Check(input, false)
+	if text != input {
+		t.Errorf("Check() without fix changed the text to %q", text)
+	}
+	if len(findings) != 2 {
+		t.Fatalf("Expected 2 findings, got %+v", findings)
+	}
+	if findings[0].Rule != "email" || findings[0].Line != 1 || findings[0].Severity != types.SeverityWarning || findings[0].Fixed {
+		t.Errorf("Unexpected first finding %+v", findings[0])
+	}
+	if findings[1].Rule != "utilize" || findings[1].Line != 7 || findings[1].Suggestion != "used" {
+		t.Errorf("Unexpected second finding %+v", findings[1])
+	}
+
+	// Only rules marked fix are applied
+	text, findings = ruleset.Check(input, true)
+	want := "Send an email.\n\n```\ne-mail = true\n```\n\nWe utilized `e-mail` tools."
+	if text != want {
+		t.Errorf("Check() with fix = %q, want %q", text, want)
+	}
+	if !findings[0].Fixed || findings[1].Fixed {
+		t.Errorf("Only the email finding should be fixed, got %+v", findings)
+	}
+}
+
+func TestRuleset_Builtins(t *testing.T) {
+	ruleset, err := Compile(&types.HouseStyle{Name: "test", Rules: []types.HouseStyleRule{
+		{ID: "doubles", Builtin: BuiltinRepeatedWords, Fix: true},
+		{ID: "long", Builtin: BuiltinMaxSentenceWords, Options: map[string]string{"max": "5"}},
+		{ID: "headings", Builtin: BuiltinHeadingPunctuation, Fix: true},
+		{ID: "percent", Builtin: BuiltinPercentStyle, Options: map[string]string{"style": "symbol"}, Fix: true},
+	}})
+	if err != nil {
+		t.Fatalf("Compile() error = %v", err)
+	}
+
+	input := "## Overview:\n\nThe the result was 20 percent. This sentence has far too many words in it."
+	text, findings := ruleset.Check(input, true)
+
+	want := "## Overview\n\nThe result was 20%. This sentence has far too many words in it."
+	if text != want {
+		t.Errorf("Check() = %q, want %q", text, want)
+	}
+
+	counts := make(map[string]int)
+	for _, finding := range findings {
+		counts[finding.Rule]++
+	}
+	for rule, count := range map[string]int{"doubles": 1, "long": 1, "headings": 1, "percent": 1} {
+		if counts[rule] != count {
+			t.Errorf("Expected %d %s findings, got %d (%+v)", count, rule, counts[rule], findings)
+		}
+	}
+
+	// Heading punctuation only applies to headings
+	if findings := ruleset.CheckHeading("Results:"); len(findings) != 1 || findings[0].Suggestion != "Results" {
+		t.Errorf("CheckHeading() = %+v", findings)
+	}
+	if _, findings := ruleset.Check("It ends here.", false); len(findings) != 0 {
+		t.Errorf("Paragraphs should not be checked for heading punctuation, got %+v", findings)
+	}
+}
+
+func TestRuleset_Blocks(t *testing.T) {
+	ruleset, err := Compile(&types.HouseStyle{Name: "test", Rules: []types.HouseStyleRule{
+		{ID: "quotes-only", Pattern: "very", Blocks: []string{BlockBlockquote}},
+	}})
+	if err != nil {
+		t.Fatalf("Compile() error = %v", err)
+	}
+
+	_, findings := ruleset.Check("A very long day.\n\n> A very short quote.\n\n- A very short item", false)
+	if len(findings) != 1 || findings[0].Line != 3 {
+		t.Errorf("Expected one blockquote finding on line 3, got %+v", findings)
+	}
+}
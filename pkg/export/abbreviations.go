@@ -0,0 +1,188 @@
+package export
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+	"unicode"
+
+	"github.com/gomcpgo/docgen/pkg/types"
+)
+
+var (
+	// abbreviationPattern matches a word with at least two capitals and no
+	// lower-case letters, such as API, HTTP2 or the plural APIs
+	abbreviationPattern = regexp.MustCompile(`\b[A-Z][A-Z0-9]*[A-Z][A-Z0-9]*s?\b`)
+	// abbreviationDefinitionPattern matches an abbreviation in parentheses: (API)
+	abbreviationDefinitionPattern = regexp.MustCompile(`\(([A-Z][A-Z0-9]*[A-Z][A-Z0-9]*)s?\)`)
+	// definitionWordPattern matches the words of a written-out definition
+	definitionWordPattern = regexp.MustCompile(`[A-Za-z][A-Za-z']*`)
+	// romanNumeralPattern matches Roman numerals, which look like abbreviations
+	romanNumeralPattern = regexp.MustCompile(`^M{0,3}(CM|CD|D?C{0,3})(XC|XL|L?X{0,3})(IX|IV|V?I{0,3})$`)
+
+	// Text that never holds abbreviations: code, link targets and attributes
+	fencedCodePattern = regexp.MustCompile("(?ms)^(```|~~~).*?^(```|~~~)[ \\t]*$")
+	inlineCodePattern = regexp.MustCompile("`[^`\n]*`")
+	linkTargetPattern = regexp.MustCompile(`\]\([^)]*\)`)
+	attributesPattern = regexp.MustCompile(`\{[^}\n]*\}`)
+	bareURLPattern    = regexp.MustCompile(`https?://\S+`)
+)
+
+// notAbbreviations are capitalised words that are not abbreviations
+var notAbbreviations = map[string]bool{
+	"TODO":  true,
+	"FIXME": true,
+	"NOTE":  true,
+	"OK":    true,
+}
+
+// definitionStopWords may appear in a written-out definition without a letter
+// in the abbreviation, as in "Department of Energy (DOE)"
+var definitionStopWords = map[string]bool{
+	"a": true, "an": true, "and": true, "at": true, "by": true, "for": true,
+	"in": true, "of": true, "on": true, "or": true, "the": true, "to": true,
+	"with": true,
+}
+
+// Abbreviations collects the abbreviations in a document: those defined for
+// it and those written out in the text, plus any used without a definition
+func (e *Exporter) Abbreviations(documentID string, manifest *types.Manifest) *types.AbbreviationReport {
+	var contents []string
+	for _, chapter := range manifest.Document.Chapters {
+		if content, err := e.loadChapterContent(documentID, manifest, chapter.Number); err == nil {
+			contents = append(contents, content)
+		}
+	}
+	return collectAbbreviations(&manifest.Document, contents)
+}
+
+// collectAbbreviations finds the abbreviations used in content and what they
+// stand for. A definition set on the document wins over one in the text, and
+// the first "Long Form (LF)" in the text wins over later ones.
+func collectAbbreviations(doc *types.Document, contents []string) *types.AbbreviationReport {
+	definitions := make(map[string]string)
+	for _, abbreviation := range doc.Abbreviations {
+		definitions[abbreviation.Term] = abbreviation.Definition
+	}
+
+	used := make(map[string]bool)
+	for _, content := range contents {
+		text := abbreviationText(content)
+		for _, match := range abbreviationDefinitionPattern.FindAllStringSubmatchIndex(text, -1) {
+			term := text[match[2]:match[3]]
+			if _, ok := definitions[term]; ok {
+				continue
+			}
+			if definition := definitionBefore(text[:match[0]], term); definition != "" {
+				definitions[term] = definition
+			}
+		}
+		for _, word := range abbreviationPattern.FindAllString(text, -1) {
+			used[singularAbbreviation(word)] = true
+		}
+	}
+
+	report := &types.AbbreviationReport{
+		Abbreviations: []types.Abbreviation{},
+		Undefined:     []string{},
+	}
+	for term, definition := range definitions {
+		report.Abbreviations = append(report.Abbreviations, types.Abbreviation{Term: term, Definition: definition})
+	}
+	sort.Slice(report.Abbreviations, func(i, j int) bool {
+		return strings.ToLower(report.Abbreviations[i].Term) < strings.ToLower(report.Abbreviations[j].Term)
+	})
+	for term := range used {
+		if _, ok := definitions[term]; ok || notAbbreviations[term] || romanNumeralPattern.MatchString(term) {
+			continue
+		}
+		report.Undefined = append(report.Undefined, term)
+	}
+	sort.Strings(report.Undefined)
+	return report
+}
+
+// abbreviationText blanks out the parts of markdown that are not prose
+func abbreviationText(content string) string {
+	for _, pattern := range []*regexp.Regexp{fencedCodePattern, inlineCodePattern, linkTargetPattern, attributesPattern, bareURLPattern} {
+		content = pattern.ReplaceAllStringFunc(content, func(match string) string {
+			if strings.HasPrefix(match, "](") {
+				return "]"
+			}
+			return " "
+		})
+	}
+	return content
+}
+
+// singularAbbreviation drops the plural s from an abbreviation: APIs is API
+func singularAbbreviation(word string) string {
+	if strings.HasSuffix(word, "s") {
+		return strings.TrimSuffix(word, "s")
+	}
+	return word
+}
+
+// definitionBefore returns the written-out form of term that ends text, such as
+// "Application Programming Interface" before "(API)". Each capital of the term
+// must start a word, or be a capital inside one (JavaScript Object Notation for
+// JSON); small words like "of" may be skipped. It returns "" if the words
+// before the parenthesis don't spell the term.
+func definitionBefore(text, term string) string {
+	var letters []rune
+	for _, r := range term {
+		if unicode.IsUpper(r) {
+			letters = append(letters, r)
+		}
+	}
+	// Definitions don't run across sentences or lines
+	if cut := strings.LastIndexAny(text, ".!?:;\n"); cut >= 0 {
+		text = text[cut+1:]
+	}
+	words := definitionWordPattern.FindAllStringIndex(text, -1)
+
+	remaining := len(letters)
+	for i := len(words) - 1; i >= 0 && remaining > 0; i-- {
+		word := text[words[i][0]:words[i][1]]
+		capitals := []rune{unicode.ToUpper(rune(word[0]))}
+		for _, r := range word[1:] {
+			if unicode.IsUpper(r) {
+				capitals = append(capitals, r)
+			}
+		}
+
+		switch {
+		case len(capitals) > 1 && len(capitals) <= remaining && string(capitals) == string(letters[remaining-len(capitals):remaining]):
+			remaining -= len(capitals)
+		case capitals[0] == letters[remaining-1]:
+			remaining--
+		case definitionStopWords[strings.ToLower(word)]:
+			continue
+		default:
+			return ""
+		}
+		if remaining == 0 {
+			return strings.TrimSpace(text[words[i][0]:])
+		}
+	}
+	return ""
+}
+
+// abbreviationsTable renders the abbreviations table that opens the document
+func abbreviationsTable(abbreviations []types.Abbreviation) string {
+	if len(abbreviations) == 0 {
+		return ""
+	}
+	cell := strings.NewReplacer("|", `\|`, "\n", " ")
+
+	var table strings.Builder
+	table.WriteString("# Abbreviations {.unnumbered}\n\n")
+	table.WriteString("| Abbreviation | Definition |\n")
+	table.WriteString("|:-------------|:-----------|\n")
+	for _, abbreviation := range abbreviations {
+		fmt.Fprintf(&table, "| %s | %s |\n", cell.Replace(abbreviation.Term), cell.Replace(abbreviation.Definition))
+	}
+	table.WriteString("\n")
+	return table.String()
+}
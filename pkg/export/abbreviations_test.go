@@ -0,0 +1,121 @@
+package export
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/gomcpgo/docgen/pkg/types"
+)
+
+func TestDefinitionBefore(t *testing.T) {
+	tests := []struct {
+		text string
+		term string
+		want string
+	}{
+		{"We call the Application Programming Interface ", "API", "Application Programming Interface"},
+		{"funded by the Department of Energy ", "DOE", "Department of Energy"},
+		{"data is sent as JavaScript Object Notation ", "JSON", "JavaScript Object Notation"},
+		{"as in peer-to-peer ", "PP", "peer-to-peer"},
+		{"The results. Application ", "API", ""},
+		{"something unrelated entirely ", "API", ""},
+	}
+	for _, tt := range tests {
+		if got := definitionBefore(tt.text, tt.term); got != tt.want {
+			t.Errorf("definitionBefore(%q, %q) = %q, want %q", tt.text, tt.term, got, tt.want)
+		}
+	}
+}
+
+func TestCollectAbbreviations(t *testing.T) {
+	doc := &types.Document{
+		Abbreviations: []types.Abbreviation{{Term: "NASA", Definition: "National Aeronautics and Space Administration"}},
+	}
+	contents := []string{
+		"The Application Programming Interface (API) is documented. Each API call and both APIs use HTTP.\n\n" +
+			"```\nGET /FOO HTTP/1.1\n```\n\nSee `CONST_VALUE` and [the spec](https://example.com/RFC).",
+		"NASA built it in Part II. TODO: check the National Aeronautics and Space Administration (NASA) wording. " +
+			"Use the Secure Sockets Layer (SSL) and Secure Sockets Layer (SSL) again. {#fig-1.1 width=50%}",
+	}
+
+	report := collectAbbreviations(doc, contents)
+
+	var terms []string
+	for _, abbreviation := range report.Abbreviations {
+		terms = append(terms, abbreviation.Term+"="+abbreviation.Definition)
+	}
+	want := "API=Application Programming Interface,NASA=National Aeronautics and Space Administration,SSL=Secure Sockets Layer"
+	if strings.Join(terms, ",") != want {
+		t.Errorf("Abbreviations = %v, want %s", terms, want)
+	}
+	if strings.Join(report.Undefined, ",") != "HTTP" {
+		t.Errorf("Undefined = %v, want [HTTP]", report.Undefined)
+	}
+}
+
+func TestAbbreviationsTable(t *testing.T) {
+	if table := abbreviationsTable(nil); table != "" {
+		t.Errorf("abbreviationsTable(nil) = %q, want empty", table)
+	}
+	table := abbreviationsTable([]types.Abbreviation{{Term: "I/O", Definition: "input | output"}})
+	if !strings.HasPrefix(table, "# Abbreviations {.unnumbered}\n") || !strings.Contains(table, `| I/O | input \| output |`) {
+		t.Errorf("abbreviationsTable() = %q", table)
+	}
+}
+
+func TestExporter_Abbreviations(t *testing.T) {
+	exporter, tempDir := setupTestExporter(t)
+	defer os.RemoveAll(tempDir)
+
+	_, manifest, _, _ := createTestDocument(t, tempDir)
+	contents := []string{
+		"# Introduction\n\nThe Portable Document Format (PDF) is the target.",
+		"# Methods\n\nWe export each PDF and check it with OCR.",
+	}
+	for i, content := range contents {
+		chapterPath := filepath.Join(tempDir, "test-doc", "chapters", fmt.Sprintf("%02d", i+1))
+		os.MkdirAll(chapterPath, 0755)
+		os.WriteFile(filepath.Join(chapterPath, "chapter.md"), []byte(content), 0644)
+	}
+	os.WriteFile(filepath.Join(tempDir, "test-doc", "manifest.yaml"), []byte("id: test-doc\n"), 0644)
+
+	// The table opens the export, before the first chapter
+	markdown, err := exporter.GenerateMarkdown("test-doc", manifest, &types.ExportOptions{Format: types.ExportFormatHTML, Abbreviations: true})
+	if err != nil {
+		t.Fatalf("GenerateMarkdown() error = %v", err)
+	}
+	table := strings.Index(markdown, "# Abbreviations")
+	if table == -1 || table > strings.Index(markdown, "# Introduction") || !strings.Contains(markdown, "| PDF | Portable Document Format |") {
+		t.Errorf("Abbreviations table missing or misplaced:\n%s", markdown)
+	}
+
+	// Only the exported chapters contribute
+	markdown, err = exporter.GenerateMarkdown("test-doc", manifest, &types.ExportOptions{Format: types.ExportFormatHTML, Abbreviations: true, Chapters: []types.ChapterNumber{2}})
+	if err != nil {
+		t.Fatalf("GenerateMarkdown() error = %v", err)
+	}
+	if strings.Contains(markdown, "# Abbreviations") {
+		t.Errorf("Chapter 2 defines no abbreviations, got:\n%s", markdown)
+	}
+
+	// Without the option there is no table
+	markdown, _ = exporter.GenerateMarkdown("test-doc", manifest, &types.ExportOptions{Format: types.ExportFormatHTML})
+	if strings.Contains(markdown, "# Abbreviations") {
+		t.Errorf("Abbreviations table should be opt-in")
+	}
+
+	// Validation warns about abbreviations that are never defined
+	report := exporter.ValidateDocument("test-doc", manifest)
+	var warned []string
+	for _, warning := range report.Warnings {
+		if strings.HasPrefix(warning, "Abbreviation ") {
+			warned = append(warned, warning)
+		}
+	}
+	if len(warned) != 1 || !strings.Contains(warned[0], "OCR") {
+		t.Errorf("Expected one warning for OCR, got %v", warned)
+	}
+}
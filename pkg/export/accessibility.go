@@ -0,0 +1,176 @@
+package export
+
+import (
+	"fmt"
+	"math"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/gomcpgo/docgen/pkg/types"
+)
+
+// Minimum contrast ratios with the page for body and large text (WCAG 2 level AA)
+const (
+	minTextContrast    = 4.5
+	minHeadingContrast = 3.0
+)
+
+// pageBackground is the color every export is laid out on
+const pageBackground = "#FFFFFF"
+
+var (
+	// headingLinePattern matches an ATX heading, capturing its hashes and text
+	headingLinePattern = regexp.MustCompile(`^(#{1,6})\s+(.*)$`)
+	// emptyAltPattern matches an image without alt text, capturing its path
+	emptyAltPattern = regexp.MustCompile(`!\[\s*\]\(([^)\s]*)`)
+)
+
+// ValidateAccessibility adds accessibility checks to a validation report:
+// figures without a caption to describe them, images without alt text, headings
+// that skip a level, text colors with too little contrast with the page and a
+// missing document language
+func (e *Exporter) ValidateAccessibility(documentID string, manifest *types.Manifest, style *types.Style, report *types.ValidationReport) {
+	warn := func(format string, args ...interface{}) {
+		report.Warnings = append(report.Warnings, "Accessibility: "+fmt.Sprintf(format, args...))
+	}
+
+	if manifest.Document.Language == "" {
+		warn("document language is not set; screen readers need it to pick a voice (set it with configure_document)")
+	}
+
+	for _, chapter := range manifest.Document.Chapters {
+		for _, figure := range chapter.Figures {
+			if strings.TrimSpace(figure.Caption) == "" || figure.CaptionTODO {
+				warn("figure %s has no caption to describe it", figure.ID)
+			}
+		}
+
+		content, err := e.loadChapterContent(documentID, manifest, chapter.Number)
+		if err != nil {
+			continue
+		}
+		previous := 0
+		inFence := false
+		for _, line := range strings.Split(content, "\n") {
+			trimmed := strings.TrimSpace(line)
+			if strings.HasPrefix(trimmed, "```") || strings.HasPrefix(trimmed, "~~~") {
+				inFence = !inFence
+				continue
+			}
+			if inFence {
+				continue
+			}
+			if match := headingLinePattern.FindStringSubmatch(trimmed); match != nil {
+				level := len(match[1])
+				if previous > 0 && level > previous+1 {
+					warn("chapter %d: heading %q skips from level %d to %d", chapter.Number, match[2], previous, level)
+				}
+				previous = level
+			}
+			for _, match := range emptyAltPattern.FindAllStringSubmatch(line, -1) {
+				warn("chapter %d: image %s has no alt text", chapter.Number, match[1])
+			}
+		}
+	}
+
+	if style != nil {
+		checkContrast := func(name, color string, minimum float64) {
+			if color == "" {
+				return
+			}
+			ratio, ok := contrastRatio(color, pageBackground)
+			if ok && ratio < minimum {
+				warn("%s %s has a contrast of %.1f:1 with the white page; at least %.1f:1 is needed", name, color, ratio, minimum)
+			}
+		}
+		checkContrast("body color", style.Body.Color, minTextContrast)
+		checkContrast("heading color", style.Heading.Color, minHeadingContrast)
+		checkContrast("monospace color", style.Monospace.Color, minTextContrast)
+		checkContrast("link color", style.LinkColor, minTextContrast)
+	}
+}
+
+// contrastRatio returns the WCAG contrast ratio of two #RRGGBB or #RGB colors
+func contrastRatio(foreground, background string) (float64, bool) {
+	fg, ok := relativeLuminance(foreground)
+	if !ok {
+		return 0, false
+	}
+	bg, ok := relativeLuminance(background)
+	if !ok {
+		return 0, false
+	}
+	lighter, darker := math.Max(fg, bg), math.Min(fg, bg)
+	return (lighter + 0.05) / (darker + 0.05), true
+}
+
+// relativeLuminance returns the WCAG relative luminance of a #RRGGBB or #RGB color
+func relativeLuminance(color string) (float64, bool) {
+	hex := strings.TrimPrefix(color, "#")
+	if len(hex) == 3 {
+		hex = string([]byte{hex[0], hex[0], hex[1], hex[1], hex[2], hex[2]})
+	}
+	if len(hex) != 6 {
+		return 0, false
+	}
+	value, err := strconv.ParseUint(hex, 16, 32)
+	if err != nil {
+		return 0, false
+	}
+
+	channel := func(shift uint) float64 {
+		c := float64((value>>shift)&0xFF) / 255
+		if c <= 0.03928 {
+			return c / 12.92
+		}
+		return math.Pow((c+0.055)/1.055, 2.4)
+	}
+	return 0.2126*channel(16) + 0.7152*channel(8) + 0.0722*channel(0), true
+}
+
+// generateAccessibilityHeader creates the LaTeX preamble that tags an accessible
+// PDF export for screen readers. Tagging needs LaTeX's tagpdf package.
+func generateAccessibilityHeader(options *types.ExportOptions) string {
+	if !options.Accessible {
+		return ""
+	}
+	return "\n% Accessibility: tagged PDF showing the document title\n" +
+		"\\usepackage{tagpdf}\n" +
+		"\\tagpdfsetup{activate-all, interwordspace=true}\n" +
+		"\\AtBeginDocument{\\hypersetup{pdfdisplaydoctitle=true}}\n"
+}
+
+// accessibleHTMLBeforeBody is the skip link opening the body of an accessible
+// HTML export, ahead of the title block and table of contents
+const accessibleHTMLBeforeBody = `<a class="skip-link" href="#main-content">Skip to main content</a>
+`
+
+// accessibleHTMLCSS keeps the skip link out of sight until it has keyboard
+// focus and makes the focused element visible
+const accessibleHTMLCSS = `/* Accessibility */
+.skip-link {
+  position: absolute;
+  left: -10000px;
+}
+.skip-link:focus {
+  position: static;
+  display: inline-block;
+  padding: 0.5em;
+  background: #ffffff;
+  color: #000000;
+}
+a:focus, [tabindex]:focus {
+  outline: 2px solid #1a5fb4;
+  outline-offset: 2px;
+}
+`
+
+// mainLandmark opens or closes the main landmark around the chapters of an
+// accessible HTML export
+func mainLandmark(open bool) string {
+	if open {
+		return "```{=html}\n<main id=\"main-content\">\n```\n\n"
+	}
+	return "\n\n```{=html}\n</main>\n```\n"
+}
@@ -0,0 +1,121 @@
+package export
+
+import (
+	"math"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/gomcpgo/docgen/pkg/types"
+)
+
+func TestContrastRatio(t *testing.T) {
+	tests := []struct {
+		foreground string
+		want       float64
+	}{
+		{"#000000", 21},
+		{"#000", 21},
+		{"#FFFFFF", 1},
+		{"#777777", 4.48},
+	}
+	for _, tt := range tests {
+		got, ok := contrastRatio(tt.foreground, pageBackground)
+		if !ok || math.Abs(got-tt.want) > 0.01 {
+			t.Errorf("contrastRatio(%s) = %.2f, %v, want %.2f", tt.foreground, got, ok, tt.want)
+		}
+	}
+
+	if _, ok := contrastRatio("navy", pageBackground); ok {
+		t.Errorf("contrastRatio() should not accept named colors")
+	}
+}
+
+func TestExporter_ValidateAccessibility(t *testing.T) {
+	exporter, tempDir := setupTestExporter(t)
+	defer os.RemoveAll(tempDir)
+
+	_, manifest, style, _ := createTestDocument(t, tempDir)
+	manifest.Document.Chapters[0].Figures = []types.Figure{
+		{ID: "fig-1.1", Caption: "A diagram"},
+		{ID: "fig-1.2", Caption: "TODO: describe", CaptionTODO: true},
+	}
+	style.Body.Color = "#999999"
+	style.Heading.Color = "#000000"
+
+	chapters := map[string]string{
+		"01": "# Introduction\n\n### Too deep\n\n![](images/chart.png)\n\n```\n#### not a heading\n```\n",
+		"02": "# Methods\n\n## Data\n\n![A described chart](images/chart.png)\n",
+	}
+	for dir, content := range chapters {
+		path := filepath.Join(tempDir, "test-doc", "chapters", dir)
+		os.MkdirAll(path, 0755)
+		os.WriteFile(filepath.Join(path, "chapter.md"), []byte(content), 0644)
+	}
+
+	report := &types.ValidationReport{Valid: true}
+	exporter.ValidateAccessibility("test-doc", manifest, style, report)
+
+	if !report.Valid {
+		t.Errorf("Accessibility problems should only warn, got errors %v", report.Errors)
+	}
+	warnings := strings.Join(report.Warnings, "\n")
+	for _, want := range []string{
+		"document language is not set",
+		"figure fig-1.2 has no caption",
+		`chapter 1: heading "Too deep" skips from level 1 to 3`,
+		"chapter 1: image images/chart.png has no alt text",
+		"body color #999999",
+	} {
+		if !strings.Contains(warnings, want) {
+			t.Errorf("Warnings should contain %q, got:\n%s", want, warnings)
+		}
+	}
+	for _, unwanted := range []string{"fig-1.1", "chapter 2", "heading color", "not a heading"} {
+		if strings.Contains(warnings, unwanted) {
+			t.Errorf("Warnings should not contain %q, got:\n%s", unwanted, warnings)
+		}
+	}
+}
+
+func TestExporter_GenerateMarkdown_AccessibleHTML(t *testing.T) {
+	exporter, tempDir := setupTestExporter(t)
+	defer os.RemoveAll(tempDir)
+
+	doc, manifest, _, _ := createTestDocument(t, tempDir)
+	for i, dir := range []string{"01", "02"} {
+		path := filepath.Join(tempDir, "test-doc", "chapters", dir)
+		os.MkdirAll(path, 0755)
+		os.WriteFile(filepath.Join(path, "chapter.md"), []byte(doc.Chapters[i].Content), 0644)
+	}
+
+	markdown, err := exporter.GenerateMarkdown("test-doc", manifest, &types.ExportOptions{Format: types.ExportFormatHTML, Accessible: true})
+	if err != nil {
+		t.Fatalf("GenerateMarkdown() error = %v", err)
+	}
+	open := strings.Index(markdown, `<main id="main-content">`)
+	end := strings.Index(markdown, "</main>")
+	chapter := strings.Index(markdown, "# Introduction")
+	if open < 0 || end < 0 || !(open < chapter && chapter < end) {
+		t.Errorf("Accessible HTML should wrap the chapters in a main landmark, got:\n%s", markdown)
+	}
+
+	markdown, err = exporter.GenerateMarkdown("test-doc", manifest, &types.ExportOptions{Format: types.ExportFormatPDF, Accessible: true})
+	if err != nil {
+		t.Fatalf("GenerateMarkdown() error = %v", err)
+	}
+	if strings.Contains(markdown, "<main") {
+		t.Errorf("PDF exports should not get a main landmark")
+	}
+}
+
+func TestGenerateAccessibilityHeader(t *testing.T) {
+	if header := generateAccessibilityHeader(&types.ExportOptions{}); header != "" {
+		t.Errorf("generateAccessibilityHeader() = %q without accessible, want empty", header)
+	}
+	header := generateAccessibilityHeader(&types.ExportOptions{Accessible: true})
+	if !strings.Contains(header, `\usepackage{tagpdf}`) || !strings.Contains(header, "pdfdisplaydoctitle") {
+		t.Errorf("generateAccessibilityHeader() = %q", header)
+	}
+}
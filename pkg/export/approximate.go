@@ -0,0 +1,200 @@
+package export
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"html/template"
+	"os/exec"
+	"strings"
+
+	"github.com/gomcpgo/docgen/pkg/types"
+	"github.com/yuin/goldmark"
+	"github.com/yuin/goldmark/extension"
+	"github.com/yuin/goldmark/parser"
+	"github.com/yuin/goldmark/renderer"
+	"github.com/yuin/goldmark/renderer/html"
+)
+
+// ApproximateNotice is shown on HTML rendered by the built-in renderer instead of pandoc
+const ApproximateNotice = "Approximate preview rendered without pandoc. Citations, cross-references, math and pandoc-specific markdown may look different in exported documents."
+
+// approximateMarkdown returns a converter with the extensions closest to pandoc
+// reading markdown with the given profile
+func approximateMarkdown(profile *types.MarkdownProfile) goldmark.Markdown {
+	extensions := []goldmark.Extender{
+		extension.Strikethrough,
+		extension.Linkify,
+		extension.DefinitionList,
+	}
+	if profile.Enabled("pipe_tables") {
+		extensions = append(extensions, extension.Table)
+	}
+	if profile.Enabled("task_lists") {
+		extensions = append(extensions, extension.TaskList)
+	}
+	if profile.Enabled("footnotes") {
+		extensions = append(extensions, extension.Footnote)
+	}
+	if profile.Enabled("smart") {
+		extensions = append(extensions, extension.Typographer)
+	}
+
+	var rendererOptions []renderer.Option
+	if profile.Enabled("raw_html") {
+		// pandoc passes raw HTML through as well
+		rendererOptions = append(rendererOptions, html.WithUnsafe())
+	}
+
+	return goldmark.New(
+		goldmark.WithExtensions(extensions...),
+		goldmark.WithParserOptions(
+			parser.WithAutoHeadingID(),
+			parser.WithAttribute(),
+		),
+		goldmark.WithRendererOptions(rendererOptions...),
+	)
+}
+
+var approximateTemplate = template.Must(template.New("approximate").Parse(`<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<title>{{.Title}}</title>
+<style>
+body { font-family: Georgia, serif; margin: 2em auto; max-width: 45em; padding: 0 1em; line-height: 1.5; color: #222; }
+.docgen-approximate { font-family: sans-serif; font-size: 0.9em; background: #fff4d6; border: 1px solid #e0b84c; padding: 0.6em 1em; margin-bottom: 2em; }
+table { border-collapse: collapse; }
+th, td { border: 1px solid #ccc; padding: 0.3em 0.6em; }
+img { max-width: 100%; }
+pre { background: #f5f5f5; padding: 0.8em; overflow-x: auto; }
+</style>
+</head>
+<body>
+<div class="docgen-approximate" role="note">{{.Notice}}</div>
+{{.Body}}
+</body>
+</html>
+`))
+
+// RenderApproximateHTML renders markdown to a standalone HTML page with the
+// built-in renderer. The page carries a notice that it only approximates the
+// pandoc output.
+func RenderApproximateHTML(title, markdown string, profile *types.MarkdownProfile) ([]byte, error) {
+	body, err := renderApproximateFragment(markdown, profile)
+	if err != nil {
+		return nil, err
+	}
+
+	var page bytes.Buffer
+	if err := approximateTemplate.Execute(&page, map[string]interface{}{
+		"Title":  title,
+		"Notice": ApproximateNotice,
+		"Body":   template.HTML(body),
+	}); err != nil {
+		return nil, fmt.Errorf("failed to render preview page: %w", err)
+	}
+	return page.Bytes(), nil
+}
+
+// renderApproximateFragment renders markdown to an HTML fragment with the built-in renderer
+func renderApproximateFragment(markdown string, profile *types.MarkdownProfile) (string, error) {
+	// Raw HTML blocks are unwrapped and raw blocks for other formats dropped, as
+	// pandoc does; goldmark would otherwise show them as code
+	markdown = scanRawBlocks(markdown, func(rawFormat string, line int, lines []string) []string {
+		if !acceptsRawFormat(types.ExportFormatHTML, rawFormat) || len(lines) < 2 {
+			return nil
+		}
+		return lines[1 : len(lines)-1]
+	})
+	markdown = rawInlinePattern.ReplaceAllStringFunc(markdown, func(match string) string {
+		parts := rawInlinePattern.FindStringSubmatch(match)
+		if acceptsRawFormat(types.ExportFormatHTML, parts[2]) {
+			return parts[1]
+		}
+		return ""
+	})
+
+	var body bytes.Buffer
+	if err := approximateMarkdown(profile).Convert([]byte(markdown), &body); err != nil {
+		return "", fmt.Errorf("failed to render markdown: %w", err)
+	}
+	return body.String(), nil
+}
+
+// PandocAvailable reports whether the configured pandoc can be found
+func (e *Exporter) PandocAvailable() bool {
+	_, err := findPandocPath(e.config.PandocPath)
+	return err == nil
+}
+
+// RenderChapterHTML renders a chapter as an HTML fragment with pandoc, or with the
+// built-in renderer when pandoc is not installed. The returned flag reports that
+// the built-in renderer was used and the result is approximate.
+func (e *Exporter) RenderChapterHTML(ctx context.Context, documentID string, manifest *types.Manifest, chapterNum types.ChapterNumber, profile *types.MarkdownProfile, rebuildFunc ChapterRebuildFunc) (string, bool, error) {
+	// Rebuild chapter markdown from section files to ensure it's current
+	if rebuildFunc != nil {
+		if err := rebuildFunc(types.DocumentID(documentID), chapterNum); err != nil {
+			return "", false, fmt.Errorf("failed to rebuild chapter %d markdown: %w", chapterNum, err)
+		}
+	}
+
+	chapterContent, err := e.loadChapterContent(documentID, manifest, chapterNum)
+	if err != nil {
+		return "", false, fmt.Errorf("failed to load chapter content: %w", err)
+	}
+	return e.renderChapterFragment(ctx, documentID, manifest, chapterNum, chapterContent, profile)
+}
+
+// renderChapterFragment renders a chapter's markdown as an HTML fragment with
+// pandoc, or with the built-in renderer when pandoc is not installed
+func (e *Exporter) renderChapterFragment(ctx context.Context, documentID string, manifest *types.Manifest, chapterNum types.ChapterNumber, chapterContent string, profile *types.MarkdownProfile) (string, bool, error) {
+	chapterContent = e.expandFigureGrids(documentID, manifest, chapterNum, chapterContent, types.ExportFormatHTML)
+
+	pandocPath, err := findPandocPath(e.config.PandocPath)
+	if err != nil {
+		fragment, err := renderApproximateFragment(chapterContent, profile)
+		return fragment, true, err
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, e.config.ExportTimeout)
+	defer cancel()
+
+	cmd := exec.CommandContext(ctx, pandocPath, "--from", profile.PandocFormat(), "--to", "html")
+	cmd.Stdin = strings.NewReader(stripRawBlocks(chapterContent, types.ExportFormatHTML))
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	output, err := cmd.Output()
+	if err != nil {
+		return "", false, fmt.Errorf("pandoc execution failed: %w: %s", err, strings.TrimSpace(stderr.String()))
+	}
+	return string(output), false, nil
+}
+
+// RenderApproximateDocument renders every chapter of a document into one HTML
+// page with the built-in renderer
+func (e *Exporter) RenderApproximateDocument(ctx context.Context, documentID string, manifest *types.Manifest, profile *types.MarkdownProfile, rebuildFunc ChapterRebuildFunc) ([]byte, error) {
+	var content strings.Builder
+	for _, chapter := range manifest.Document.Chapters {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		if rebuildFunc != nil {
+			if err := rebuildFunc(types.DocumentID(documentID), chapter.Number); err != nil {
+				return nil, fmt.Errorf("failed to rebuild chapter %d markdown: %w", chapter.Number, err)
+			}
+		}
+		chapterContent, err := e.loadChapterContent(documentID, manifest, chapter.Number)
+		if err != nil {
+			return nil, fmt.Errorf("failed to load chapter %d content: %w", chapter.Number, err)
+		}
+		content.WriteString(chapterContent)
+		content.WriteString("\n\n")
+	}
+
+	title := manifest.Document.Title
+	if title == "" {
+		title = documentID
+	}
+	return RenderApproximateHTML(title, content.String(), profile)
+}
@@ -0,0 +1,93 @@
+package export
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/gomcpgo/docgen/pkg/types"
+)
+
+func TestRenderApproximateHTML(t *testing.T) {
+	page, err := RenderApproximateHTML("Field <Notes>", rawBlockContent+"\n| A | B |\n|---|---|\n| 1 | 2 |\n\nSee note.[^1]\n\n[^1]: A footnote.\n", nil)
+	if err != nil {
+		t.Fatalf("RenderApproximateHTML() error = %v", err)
+	}
+	html := string(page)
+
+	for _, want := range []string{
+		"<title>Field &lt;Notes&gt;</title>",
+		ApproximateNotice,
+		`<h1 id="results">Results</h1>`,
+		`<div class="note">Note</div>`,
+		"<table>",
+		"A footnote.",
+	} {
+		if !strings.Contains(html, want) {
+			t.Errorf("Expected %q in the rendered page:\n%s", want, html)
+		}
+	}
+	for _, unwanted := range []string{"\\begin{landscape}", "\\hfill", "{=html}"} {
+		if strings.Contains(html, unwanted) {
+			t.Errorf("Did not expect %q in the rendered page", unwanted)
+		}
+	}
+	// Raw-block syntax shown inside a code block is left alone
+	if !strings.Contains(html, "\\example") {
+		t.Errorf("Code block content should be kept")
+	}
+}
+
+func TestRenderApproximateHTML_MarkdownProfile(t *testing.T) {
+	profile := &types.MarkdownProfile{}
+	profile.Set("pipe_tables", false)
+	profile.Set("raw_html", false)
+
+	page, err := RenderApproximateHTML("Profile", "| A | B |\n|---|---|\n| 1 | 2 |\n\n<span class=\"x\">raw</span>\n", profile)
+	if err != nil {
+		t.Fatalf("RenderApproximateHTML() error = %v", err)
+	}
+	html := string(page)
+	if strings.Contains(html, "<table>") {
+		t.Errorf("Pipe tables should not be rendered when the extension is off")
+	}
+	if strings.Contains(html, `<span class="x">`) {
+		t.Errorf("Raw HTML should not pass through when the extension is off")
+	}
+}
+
+func TestExporter_RenderChapterHTMLWithoutPandoc(t *testing.T) {
+	exporter, tempDir := setupTestExporter(t)
+	defer os.RemoveAll(tempDir)
+	exporter.config.PandocPath = filepath.Join(tempDir, "missing", "pandoc")
+
+	if exporter.PandocAvailable() {
+		t.Fatal("PandocAvailable() = true for a missing pandoc")
+	}
+
+	contentPath := exporter.config.ChapterContentPath("test-doc", "01")
+	os.MkdirAll(filepath.Dir(contentPath), 0755)
+	os.WriteFile(contentPath, []byte("# Introduction\n\nSome *emphasis*."), 0644)
+
+	html, approximate, err := exporter.RenderChapterHTML(context.Background(), "test-doc", &types.Manifest{}, 1, nil, nil)
+	if err != nil {
+		t.Fatalf("RenderChapterHTML() error = %v", err)
+	}
+	if !approximate {
+		t.Error("Expected an approximate rendering without pandoc")
+	}
+	if !strings.Contains(html, "<em>emphasis</em>") {
+		t.Errorf("Unexpected HTML: %s", html)
+	}
+
+	manifest := &types.Manifest{Document: types.Document{Title: "Test Document", Chapters: []types.Chapter{{Number: 1}}}}
+	page, err := exporter.RenderApproximateDocument(context.Background(), "test-doc", manifest, nil, nil)
+	if err != nil {
+		t.Fatalf("RenderApproximateDocument() error = %v", err)
+	}
+	if !strings.Contains(string(page), "<title>Test Document</title>") || !strings.Contains(string(page), "Introduction</h1>") {
+		t.Errorf("Unexpected page: %s", page)
+	}
+}
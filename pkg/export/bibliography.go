@@ -0,0 +1,101 @@
+package export
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/gomcpgo/docgen/pkg/types"
+)
+
+// referencesTitle heads reference lists, at the end of the document or of each chapter
+const referencesTitle = "References"
+
+// chapterBibliographyMarker ends each chapter when chapters get reference lists
+// of their own. The filter replaces it with the chapter's references.
+const chapterBibliographyMarker = "::: {.chapter-bibliography}\n:::\n\n"
+
+// chapterBibliographyFilter runs citeproc on each chapter separately, like
+// biblatex's refsection, and puts the chapter's reference list where its
+// marker is. It needs pandoc 2.19.1 or later for pandoc.utils.citeproc.
+const chapterBibliographyFilter = `-- Generated by docgen: one reference list per chapter
+function Pandoc(doc)
+  local title = "` + referencesTitle + `"
+  local meta = {}
+  for key, value in pairs(doc.meta) do
+    if key == "reference-section-title" then
+      title = pandoc.utils.stringify(value)
+    else
+      meta[key] = value
+    end
+  end
+
+  local blocks, chapter, count = pandoc.List(), pandoc.List(), 0
+  for _, block in ipairs(doc.blocks) do
+    if block.t == "Div" and block.classes:includes("chapter-bibliography") then
+      count = count + 1
+      local cited = pandoc.utils.citeproc(pandoc.Pandoc(chapter, meta))
+      for _, resolved in ipairs(cited.blocks) do
+        if resolved.t == "Div" and resolved.identifier == "refs" then
+          if #resolved.content > 0 then
+            resolved.identifier = "refs-" .. count
+            blocks:insert(pandoc.Header(2, title, pandoc.Attr("", {"unnumbered"})))
+            blocks:insert(resolved)
+          end
+        else
+          blocks:insert(resolved)
+        end
+      end
+      chapter = pandoc.List()
+    else
+      chapter:insert(block)
+    end
+  end
+  blocks:extend(chapter)
+  doc.blocks = blocks
+  return doc
+end
+`
+
+// bibliographyArgs returns the pandoc arguments that resolve citations against
+// the document's bibliography, or nil when it has none. Per-chapter lists use a
+// Lua filter written next to inputFile instead of --citeproc.
+func (e *Exporter) bibliographyArgs(documentID, inputFile string, pandocConfig *types.PandocConfig) []string {
+	if pandocConfig.Bibliography == "" {
+		return nil
+	}
+	documentDir := e.config.DocumentPath(documentID)
+	bibliography, err := e.config.ResolvePath(pandocConfig.Bibliography, documentDir)
+	if err != nil {
+		log.Printf("[DOCGEN BIBLIOGRAPHY] Ignoring bibliography: %v", err)
+		return nil
+	}
+	if _, err := os.Stat(bibliography); err != nil {
+		log.Printf("[DOCGEN BIBLIOGRAPHY] Bibliography not found: %s", bibliography)
+		return nil
+	}
+
+	args := []string{"--bibliography", bibliography}
+
+	// A citation style given as a CSL file; names like "apa" keep pandoc's default style
+	if strings.HasSuffix(strings.ToLower(pandocConfig.CitationStyle), ".csl") {
+		if csl, err := e.config.ResolvePath(pandocConfig.CitationStyle, documentDir); err != nil {
+			log.Printf("[DOCGEN BIBLIOGRAPHY] Ignoring citation style: %v", err)
+		} else {
+			args = append(args, "--csl", csl)
+		}
+	}
+
+	if pandocConfig.BibliographyScope == types.BibliographyScopeChapter {
+		filterFile := filepath.Join(filepath.Dir(inputFile), fmt.Sprintf("%s-chapter-bibliography.lua", documentID))
+		if err := os.WriteFile(filterFile, []byte(chapterBibliographyFilter), 0644); err != nil {
+			log.Printf("[DOCGEN BIBLIOGRAPHY] Failed to write chapter bibliography filter: %v", err)
+			return nil
+		}
+		return append(args, "--lua-filter", filterFile)
+	}
+
+	return append(args, "--citeproc", "-M", "reference-section-title="+referencesTitle)
+}
@@ -0,0 +1,92 @@
+package export
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/gomcpgo/docgen/pkg/types"
+)
+
+func TestExporter_BibliographyArgs(t *testing.T) {
+	exporter, tempDir := setupTestExporter(t)
+	defer os.RemoveAll(tempDir)
+
+	_, manifest, style, pandocConfig := createTestDocument(t, tempDir)
+	docDir := filepath.Join(tempDir, "test-doc")
+	os.MkdirAll(docDir, 0755)
+	os.WriteFile(filepath.Join(docDir, "references.bib"), []byte("@book{knuth84, title={The TeXbook}}\n"), 0644)
+	workDir := t.TempDir()
+	inputFile := filepath.Join(workDir, "test-doc-input.md")
+	options := &types.ExportOptions{Format: types.ExportFormatHTML}
+
+	// No bibliography, no citeproc
+	args := strings.Join(exporter.GeneratePandocCommand(context.Background(), "test-doc", inputFile, "out.html", manifest, style, pandocConfig, options, "").Args, " ")
+	if strings.Contains(args, "--citeproc") || strings.Contains(args, "--bibliography") {
+		t.Errorf("Citations should only be resolved with a bibliography: %s", args)
+	}
+
+	// One reference list for the whole document
+	pandocConfig.Bibliography = "references.bib"
+	pandocConfig.CitationStyle = "apa"
+	args = strings.Join(exporter.GeneratePandocCommand(context.Background(), "test-doc", inputFile, "out.html", manifest, style, pandocConfig, options, "").Args, " ")
+	if !strings.Contains(args, "--bibliography "+filepath.Join(docDir, "references.bib")) || !strings.Contains(args, "--citeproc") {
+		t.Errorf("Expected citeproc with the document's bibliography: %s", args)
+	}
+	if strings.Contains(args, "--csl") || strings.Contains(args, "--lua-filter") {
+		t.Errorf("A named style keeps pandoc's default and document scope needs no filter: %s", args)
+	}
+
+	// A reference list per chapter resolves citations in a filter instead
+	pandocConfig.BibliographyScope = types.BibliographyScopeChapter
+	pandocConfig.CitationStyle = "styles/chicago.csl"
+	args = strings.Join(exporter.GeneratePandocCommand(context.Background(), "test-doc", inputFile, "out.html", manifest, style, pandocConfig, options, "").Args, " ")
+	filterFile := filepath.Join(workDir, "test-doc-chapter-bibliography.lua")
+	if strings.Contains(args, "--citeproc") || !strings.Contains(args, "--lua-filter "+filterFile) {
+		t.Errorf("Expected the chapter bibliography filter instead of --citeproc: %s", args)
+	}
+	if !strings.Contains(args, "--csl "+filepath.Join(docDir, "styles", "chicago.csl")) {
+		t.Errorf("Expected the CSL file relative to the document: %s", args)
+	}
+	if filter, err := os.ReadFile(filterFile); err != nil || !strings.Contains(string(filter), "pandoc.utils.citeproc") {
+		t.Errorf("Expected the filter next to the input file, got error %v", err)
+	}
+
+	// A missing bibliography is skipped rather than failing the export
+	pandocConfig.Bibliography = "missing.bib"
+	args = strings.Join(exporter.GeneratePandocCommand(context.Background(), "test-doc", inputFile, "out.html", manifest, style, pandocConfig, options, "").Args, " ")
+	if strings.Contains(args, "--bibliography") {
+		t.Errorf("Missing bibliography should be skipped: %s", args)
+	}
+}
+
+func TestGenerateMarkdown_ChapterBibliographies(t *testing.T) {
+	exporter, tempDir := setupTestExporter(t)
+	defer os.RemoveAll(tempDir)
+
+	_, manifest, _, _ := createTestDocument(t, tempDir)
+	for i, content := range []string{"# Introduction\n\nAs shown [@knuth84].", "# Methods\n\nSee [@lamport94]."} {
+		chapterPath := filepath.Join(tempDir, "test-doc", "chapters", fmt.Sprintf("%02d", i+1))
+		os.MkdirAll(chapterPath, 0755)
+		os.WriteFile(filepath.Join(chapterPath, "chapter.md"), []byte(content), 0644)
+	}
+
+	markdown, err := exporter.GenerateMarkdown("test-doc", manifest, &types.ExportOptions{Format: types.ExportFormatHTML, ChapterBibliographies: true})
+	if err != nil {
+		t.Fatalf("GenerateMarkdown() error = %v", err)
+	}
+	if strings.Count(markdown, chapterBibliographyMarker) != 2 {
+		t.Fatalf("Expected a marker after each chapter:\n%s", markdown)
+	}
+	if strings.Index(markdown, chapterBibliographyMarker) > strings.Index(markdown, "# Methods") {
+		t.Errorf("The first chapter's marker should come before the second chapter:\n%s", markdown)
+	}
+
+	markdown, _ = exporter.GenerateMarkdown("test-doc", manifest, &types.ExportOptions{Format: types.ExportFormatHTML})
+	if strings.Contains(markdown, "chapter-bibliography") {
+		t.Errorf("Markers should only be written for chapter bibliographies:\n%s", markdown)
+	}
+}
@@ -0,0 +1,115 @@
+package export
+
+import (
+	"log"
+
+	"github.com/gomcpgo/docgen/pkg/types"
+)
+
+// mergeChapterPandocOptions combines the document-level pandoc config with the
+// per-chapter options of the chapters being exported. Document-level variables and
+// markdown extensions take precedence; among chapters, the first chapter to set one
+// wins. The returned config is a copy and is never nil.
+func mergeChapterPandocOptions(pandocConfig *types.PandocConfig, manifest *types.Manifest, chapters []types.ChapterNumber) *types.PandocConfig {
+	merged := &types.PandocConfig{}
+	if pandocConfig != nil {
+		*merged = *pandocConfig
+	}
+	merged.Variables = make(map[string]string)
+	merged.ClassOptions = nil
+	if pandocConfig != nil {
+		for key, value := range pandocConfig.Variables {
+			merged.Variables[key] = value
+		}
+		merged.ClassOptions = append(merged.ClassOptions, pandocConfig.ClassOptions...)
+	}
+	var markdown *types.MarkdownProfile
+	if merged.Markdown != nil {
+		markdown = &types.MarkdownProfile{}
+		*markdown = *merged.Markdown
+		markdown.Extensions = nil
+		for extension, enabled := range merged.Markdown.Extensions {
+			markdown.Set(extension, enabled)
+		}
+	}
+
+	for _, chapter := range exportedChapters(manifest, chapters) {
+		if chapter.PandocOptions == nil {
+			continue
+		}
+		for key, value := range chapter.PandocOptions.Variables {
+			if existing, ok := merged.Variables[key]; ok {
+				if existing != value {
+					log.Printf("[DOCGEN] Chapter %d variable %s=%s ignored, already set to %s\n", chapter.Number, key, value, existing)
+				}
+				continue
+			}
+			merged.Variables[key] = value
+		}
+		for _, option := range chapter.PandocOptions.ClassOptions {
+			if !contains(merged.ClassOptions, option) {
+				merged.ClassOptions = append(merged.ClassOptions, option)
+			}
+		}
+		for extension, enabled := range chapter.PandocOptions.Markdown {
+			if existing, ok := markdown.Setting(extension); ok {
+				if existing != enabled {
+					log.Printf("[DOCGEN] Chapter %d markdown extension %s ignored, already turned %s\n", chapter.Number, extension, onOff(existing))
+				}
+				continue
+			}
+			if markdown == nil {
+				markdown = &types.MarkdownProfile{}
+			}
+			markdown.Set(extension, enabled)
+		}
+	}
+	merged.Markdown = markdown
+
+	return merged
+}
+
+// onOff describes a switch setting
+func onOff(enabled bool) string {
+	if enabled {
+		return "on"
+	}
+	return "off"
+}
+
+// exportedChapters returns the manifest chapters included in an export, in export order.
+// An empty selection means every chapter.
+func exportedChapters(manifest *types.Manifest, chapters []types.ChapterNumber) []types.Chapter {
+	if len(chapters) == 0 {
+		return manifest.Document.Chapters
+	}
+
+	var result []types.Chapter
+	for _, chapterNum := range chapters {
+		for _, chapter := range manifest.Document.Chapters {
+			if chapter.Number == chapterNum {
+				result = append(result, chapter)
+				break
+			}
+		}
+	}
+	return result
+}
+
+// hasLandscapeChapters reports whether any exported chapter is set in landscape
+func hasLandscapeChapters(manifest *types.Manifest, chapters []types.ChapterNumber) bool {
+	for _, chapter := range exportedChapters(manifest, chapters) {
+		if chapter.PandocOptions != nil && chapter.PandocOptions.Landscape {
+			return true
+		}
+	}
+	return false
+}
+
+// generateChapterLayoutHeader creates the LaTeX preamble needed by per-chapter layout options
+func generateChapterLayoutHeader(manifest *types.Manifest, chapters []types.ChapterNumber) string {
+	if !hasLandscapeChapters(manifest, chapters) {
+		return ""
+	}
+	return "% Landscape chapters\n\\usepackage{pdflscape}\n"
+}
@@ -0,0 +1,123 @@
+package export
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/gomcpgo/docgen/pkg/types"
+)
+
+func TestMergeChapterPandocOptions(t *testing.T) {
+	manifest := &types.Manifest{
+		Document: types.Document{
+			Chapters: []types.Chapter{
+				{Number: 1, PandocOptions: &types.ChapterPandocOptions{
+					Variables:    map[string]string{"geometry": "margin=0.5in", "fontsize": "10pt"},
+					ClassOptions: []string{"openany"},
+				}},
+				{Number: 2, PandocOptions: &types.ChapterPandocOptions{
+					Variables:    map[string]string{"linestretch": "1.0"},
+					ClassOptions: []string{"openany", "twoside"},
+				}},
+			},
+		},
+	}
+	pandocConfig := &types.PandocConfig{
+		TOC:       true,
+		Variables: map[string]string{"fontsize": "12pt"},
+	}
+
+	merged := mergeChapterPandocOptions(pandocConfig, manifest, nil)
+
+	if merged.Variables["fontsize"] != "12pt" {
+		t.Errorf("Document-level variable should take precedence, got %s", merged.Variables["fontsize"])
+	}
+	if merged.Variables["geometry"] != "margin=0.5in" || merged.Variables["linestretch"] != "1.0" {
+		t.Errorf("Chapter variables should be merged, got %v", merged.Variables)
+	}
+	if strings.Join(merged.ClassOptions, ",") != "openany,twoside" {
+		t.Errorf("Expected deduplicated class options, got %v", merged.ClassOptions)
+	}
+	if !merged.TOC {
+		t.Errorf("Document-level settings should be preserved")
+	}
+	if len(pandocConfig.Variables) != 1 {
+		t.Errorf("Document-level config should not be modified, got %v", pandocConfig.Variables)
+	}
+
+	// Only the exported chapters contribute options
+	merged = mergeChapterPandocOptions(nil, manifest, []types.ChapterNumber{2})
+	if _, ok := merged.Variables["geometry"]; ok {
+		t.Errorf("Options of chapters not being exported should be ignored")
+	}
+}
+
+func TestMergeChapterPandocOptions_Markdown(t *testing.T) {
+	manifest := &types.Manifest{
+		Document: types.Document{
+			Chapters: []types.Chapter{
+				{Number: 1, PandocOptions: &types.ChapterPandocOptions{Markdown: map[string]bool{"definition_lists": true, "smart": true}}},
+				{Number: 2, PandocOptions: &types.ChapterPandocOptions{Markdown: map[string]bool{"definition_lists": false, "mark": true}}},
+			},
+		},
+	}
+	profile := &types.MarkdownProfile{}
+	profile.Set("smart", false)
+	pandocConfig := &types.PandocConfig{Bibliography: "refs.bib", Markdown: profile}
+
+	merged := mergeChapterPandocOptions(pandocConfig, manifest, nil)
+	if got := merged.Markdown.PandocFormat(); got != "markdown-smart+definition_lists+mark" {
+		t.Errorf("PandocFormat() = %q, want the document's smart setting and chapter 1's definition_lists", got)
+	}
+	if merged.Bibliography != "refs.bib" {
+		t.Errorf("Document settings without chapter options should be kept, got %+v", merged)
+	}
+	if len(profile.Extensions) != 0 {
+		t.Errorf("The document profile should not change, got %v", profile.Extensions)
+	}
+
+	// Only the exported chapters count
+	merged = mergeChapterPandocOptions(nil, manifest, []types.ChapterNumber{2})
+	if got := merged.Markdown.PandocFormat(); got != "markdown-definition_lists+mark" {
+		t.Errorf("PandocFormat() = %q, want chapter 2's extensions", got)
+	}
+}
+
+func TestExporter_GenerateMarkdown_LandscapeChapter(t *testing.T) {
+	exporter, tempDir := setupTestExporter(t)
+	defer os.RemoveAll(tempDir)
+
+	doc, manifest, style, _ := createTestDocument(t, tempDir)
+	manifest.Document.Chapters[1].PandocOptions = &types.ChapterPandocOptions{Landscape: true}
+
+	for _, chapter := range doc.Chapters {
+		chapterPath := filepath.Join(tempDir, "test-doc", "chapters", fmt.Sprintf("%02d", chapter.Number))
+		os.MkdirAll(chapterPath, 0755)
+		os.WriteFile(filepath.Join(chapterPath, "chapter.md"), []byte(chapter.Content), 0644)
+	}
+
+	markdown, err := exporter.GenerateMarkdown("test-doc", manifest, &types.ExportOptions{Format: types.ExportFormatPDF})
+	if err != nil {
+		t.Fatalf("GenerateMarkdown() error = %v", err)
+	}
+
+	begin := strings.Index(markdown, "\\begin{landscape}")
+	if begin == -1 || begin < strings.Index(markdown, "# Introduction") || begin > strings.Index(markdown, "# Methods") {
+		t.Errorf("Landscape environment should wrap only the Methods chapter, got:\n%s", markdown)
+	}
+
+	html, err := exporter.GenerateMarkdown("test-doc", manifest, &types.ExportOptions{Format: types.ExportFormatHTML})
+	if err != nil {
+		t.Fatalf("GenerateMarkdown() error = %v", err)
+	}
+	if strings.Contains(html, "landscape") {
+		t.Errorf("Landscape environment should only be emitted for PDF")
+	}
+
+	if !strings.Contains(generateLaTeXHeader(style, manifest)+generateChapterLayoutHeader(manifest, nil), "pdflscape") {
+		t.Errorf("LaTeX header should load pdflscape for landscape chapters")
+	}
+}
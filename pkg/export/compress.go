@@ -0,0 +1,153 @@
+package export
+
+import (
+	"archive/zip"
+	"compress/gzip"
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+
+	"github.com/gomcpgo/docgen/pkg/types"
+)
+
+// qpdfWarningsExit is qpdf's exit status when it wrote its output but had
+// warnings about the input
+const qpdfWarningsExit = 3
+
+// finishExport converts a finished export to PDF/A, optimizes and
+// password-protects it and writes its compressed copy, as the export options ask
+func (e *Exporter) finishExport(ctx context.Context, result *types.ExportResult, options *types.ExportOptions) error {
+	// PDF/A comes first, as ghostscript rewrites the whole PDF
+	if options.PDFA && options.Format == types.ExportFormatPDF {
+		if err := e.convertPDFA(ctx, result); err != nil {
+			return err
+		}
+	}
+
+	if options.OptimizePDF && options.Format == types.ExportFormatPDF {
+		optimized, err := e.optimizePDF(ctx, result.OutputPath)
+		if err != nil {
+			return err
+		}
+		if optimized {
+			result.Optimized = true
+		} else {
+			result.Warnings = append(result.Warnings, fmt.Sprintf("qpdf not found (%s); the PDF was not optimized", e.config.QPDFPath))
+		}
+	}
+
+	if options.Protection != nil {
+		if err := e.protectExport(ctx, result.OutputPath, options); err != nil {
+			return err
+		}
+		result.Protected = true
+	}
+
+	var compressedPath string
+	var err error
+	switch options.Compression {
+	case types.CompressionGzip:
+		compressedPath, err = gzipFile(result.OutputPath)
+	case types.CompressionZip:
+		compressedPath, err = zipFile(result.OutputPath)
+	default:
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to compress export: %w", err)
+	}
+	info, err := os.Stat(compressedPath)
+	if err != nil {
+		return fmt.Errorf("failed to compress export: %w", err)
+	}
+	result.CompressedPath = compressedPath
+	result.CompressedBytes = info.Size()
+	return nil
+}
+
+// optimizePDF linearizes a PDF and compresses its streams with qpdf, in place.
+// It reports false, without an error, when qpdf isn't installed.
+func (e *Exporter) optimizePDF(ctx context.Context, path string) (bool, error) {
+	qpdfPath, err := exec.LookPath(e.config.QPDFPath)
+	if err != nil {
+		return false, nil
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, e.config.ExportTimeout)
+	defer cancel()
+
+	optimized := path + ".optimized"
+	cmd := exec.CommandContext(ctx, qpdfPath, "--linearize", "--object-streams=generate", "--compress-streams=y", "--recompress-flate", path, optimized)
+	output, err := cmd.CombinedOutput()
+	if exitErr, ok := err.(*exec.ExitError); ok && exitErr.ExitCode() == qpdfWarningsExit {
+		err = nil
+	}
+	if err != nil {
+		os.Remove(optimized)
+		return false, fmt.Errorf("failed to optimize PDF: %w: %s", err, strings.TrimSpace(string(output)))
+	}
+	if err := os.Rename(optimized, path); err != nil {
+		os.Remove(optimized)
+		return false, fmt.Errorf("failed to replace PDF with its optimized copy: %w", err)
+	}
+	return true, nil
+}
+
+// gzipFile writes a gzip copy of a file next to it and returns its path
+func gzipFile(path string) (string, error) {
+	target := path + ".gz"
+	return target, writeCompressed(path, target, func(out io.Writer, in *os.File) error {
+		writer, err := gzip.NewWriterLevel(out, gzip.BestCompression)
+		if err != nil {
+			return err
+		}
+		writer.Name = filepath.Base(path)
+		if _, err := io.Copy(writer, in); err != nil {
+			return err
+		}
+		return writer.Close()
+	})
+}
+
+// zipFile writes a zip holding a file next to it and returns its path
+func zipFile(path string) (string, error) {
+	target := path + ".zip"
+	return target, writeCompressed(path, target, func(out io.Writer, in *os.File) error {
+		writer := zip.NewWriter(out)
+		entry, err := writer.CreateHeader(&zip.FileHeader{Name: filepath.Base(path), Method: zip.Deflate})
+		if err != nil {
+			return err
+		}
+		if _, err := io.Copy(entry, in); err != nil {
+			return err
+		}
+		return writer.Close()
+	})
+}
+
+// writeCompressed runs compress from the source file to the target file,
+// removing the target if it fails
+func writeCompressed(source, target string, compress func(out io.Writer, in *os.File) error) error {
+	in, err := os.Open(source)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+
+	out, err := os.Create(target)
+	if err != nil {
+		return err
+	}
+	err = compress(out, in)
+	if closeErr := out.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		os.Remove(target)
+	}
+	return err
+}
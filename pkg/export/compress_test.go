@@ -0,0 +1,108 @@
+package export
+
+import (
+	"archive/zip"
+	"compress/gzip"
+	"context"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/gomcpgo/docgen/pkg/types"
+)
+
+// warningQPDF copies its input to its output and exits as qpdf does when the
+// input had warnings
+const warningQPDF = `#!/bin/sh
+for last; do :; done
+cp "$5" "$last"
+echo linearized >> "$last"
+exit 3
+`
+
+func TestExporter_FinishExportCompression(t *testing.T) {
+	exporter, tempDir := setupTestExporter(t)
+	defer os.RemoveAll(tempDir)
+
+	output := filepath.Join(tempDir, "doc.html")
+	content := strings.Repeat("<p>Compressible text.</p>\n", 200)
+	if err := os.WriteFile(output, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	result := &types.ExportResult{OutputPath: output}
+	if err := exporter.finishExport(context.Background(), result, &types.ExportOptions{Format: types.ExportFormatHTML, Compression: types.CompressionGzip}); err != nil {
+		t.Fatalf("finishExport() error = %v", err)
+	}
+	if result.CompressedPath != output+".gz" || result.CompressedBytes == 0 || result.CompressedBytes >= int64(len(content)) {
+		t.Errorf("Unexpected gzip result %+v", result)
+	}
+	file, _ := os.Open(result.CompressedPath)
+	reader, err := gzip.NewReader(file)
+	if err != nil {
+		t.Fatalf("Invalid gzip: %v", err)
+	}
+	data, _ := io.ReadAll(reader)
+	file.Close()
+	if string(data) != content || reader.Name != "doc.html" {
+		t.Errorf("gzip copy does not hold the export (name %q)", reader.Name)
+	}
+
+	result = &types.ExportResult{OutputPath: output}
+	if err := exporter.finishExport(context.Background(), result, &types.ExportOptions{Format: types.ExportFormatHTML, Compression: types.CompressionZip}); err != nil {
+		t.Fatalf("finishExport() error = %v", err)
+	}
+	archive, err := zip.OpenReader(result.CompressedPath)
+	if err != nil {
+		t.Fatalf("Invalid zip: %v", err)
+	}
+	defer archive.Close()
+	if len(archive.File) != 1 || archive.File[0].Name != "doc.html" {
+		t.Errorf("Expected the zip to hold doc.html, got %v", archive.File)
+	}
+
+	// The export itself is kept
+	if data, _ := os.ReadFile(output); string(data) != content {
+		t.Errorf("Compression changed the export")
+	}
+}
+
+func TestExporter_FinishExportOptimizePDF(t *testing.T) {
+	exporter, tempDir := setupTestExporter(t)
+	defer os.RemoveAll(tempDir)
+
+	output := filepath.Join(tempDir, "doc.pdf")
+	if err := os.WriteFile(output, []byte("%PDF-1.7\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	options := &types.ExportOptions{Format: types.ExportFormatPDF, OptimizePDF: true}
+
+	// Without qpdf the export is kept as it is, with a warning
+	exporter.config.QPDFPath = filepath.Join(tempDir, "missing", "qpdf")
+	result := &types.ExportResult{OutputPath: output}
+	if err := exporter.finishExport(context.Background(), result, options); err != nil {
+		t.Fatalf("finishExport() error = %v", err)
+	}
+	if result.Optimized || len(result.Warnings) != 1 || !strings.Contains(result.Warnings[0], "qpdf not found") {
+		t.Errorf("Expected a qpdf warning, got %+v", result)
+	}
+
+	// qpdf's warnings exit status still counts as optimized
+	exporter.config.QPDFPath = filepath.Join(tempDir, "qpdf")
+	if err := os.WriteFile(exporter.config.QPDFPath, []byte(warningQPDF), 0755); err != nil {
+		t.Fatal(err)
+	}
+	result = &types.ExportResult{OutputPath: output}
+	if err := exporter.finishExport(context.Background(), result, options); err != nil {
+		t.Fatalf("finishExport() error = %v", err)
+	}
+	data, _ := os.ReadFile(output)
+	if !result.Optimized || !strings.Contains(string(data), "linearized") {
+		t.Errorf("Expected the optimized PDF in place, got %+v and %q", result, data)
+	}
+	if _, err := os.Stat(output + ".optimized"); !os.IsNotExist(err) {
+		t.Errorf("The temporary optimized file should be gone, stat error = %v", err)
+	}
+}
@@ -0,0 +1,294 @@
+package export
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"html/template"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/gomcpgo/docgen/pkg/types"
+)
+
+// htmlTagPattern matches an HTML start or end tag, capturing the slash of an
+// end tag, the element name and the slash of a self-closing tag
+var htmlTagPattern = regexp.MustCompile(`<(/?)([a-zA-Z][a-zA-Z0-9-]*)[^>]*?(/?)>`)
+
+// voidElements are HTML elements without end tags
+var voidElements = map[string]bool{
+	"area": true, "base": true, "br": true, "col": true, "embed": true, "hr": true, "img": true,
+	"input": true, "link": true, "meta": true, "source": true, "track": true, "wbr": true,
+}
+
+// diffKind is whether a block is in both renderings, or only the revision's or
+// the current one
+type diffKind int
+
+const (
+	diffEqual diffKind = iota
+	diffRemoved
+	diffAdded
+)
+
+// diffBlock is one rendered block in a chapter diff
+type diffBlock struct {
+	kind diffKind
+	html string
+}
+
+var diffTemplate = template.Must(template.New("diff").Parse(`<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<title>{{.Title}}</title>
+<style>
+body { font-family: Georgia, serif; margin: 2em auto; max-width: {{if .SideBySide}}90em{{else}}45em{{end}}; padding: 0 1em; line-height: 1.5; color: #222; }
+.docgen-diff-summary, .docgen-approximate { font-family: sans-serif; font-size: 0.9em; padding: 0.6em 1em; margin-bottom: 1em; }
+.docgen-diff-summary { background: #f5f5f5; border: 1px solid #ccc; }
+.docgen-approximate { background: #fff4d6; border: 1px solid #e0b84c; }
+.diff-added, .diff-removed { display: block; text-decoration: none; }
+.diff-added { background: #e6ffed; border-left: 4px solid #2da44e; padding-left: 0.6em; }
+.diff-removed { background: #ffebe9; border-left: 4px solid #cf222e; padding-left: 0.6em; }
+.diff-removed > * { text-decoration: line-through; }
+.diff-row { display: grid; grid-template-columns: 1fr 1fr; column-gap: 2em; }
+.diff-side { min-width: 0; }
+.diff-heading { font-family: sans-serif; font-size: 0.9em; color: #555; border-bottom: 1px solid #ccc; }
+table { border-collapse: collapse; }
+th, td { border: 1px solid #ccc; padding: 0.3em 0.6em; }
+img { max-width: 100%; }
+pre { background: #f5f5f5; padding: 0.8em; overflow-x: auto; }
+</style>
+</head>
+<body>
+<div class="docgen-diff-summary" role="note">{{.Summary}}</div>
+{{if .Notice}}<div class="docgen-approximate" role="note">{{.Notice}}</div>
+{{end}}{{.Body}}
+</body>
+</html>
+`))
+
+// DiffChapter renders the chapter's current content and one of its revisions
+// to HTML and writes a page showing the rendered blocks that were added and
+// removed since the revision: marked in one column for inline diffs, or the
+// revision and the current content next to each other for side-by-side diffs
+func (e *Exporter) DiffChapter(ctx context.Context, documentID string, manifest *types.Manifest, chapterNum types.ChapterNumber, revision *types.ChapterRevision, mode types.DiffMode, profile *types.MarkdownProfile, rebuildFunc ChapterRebuildFunc) (*types.ChapterDiff, error) {
+	if err := mode.Validate(); err != nil {
+		return nil, err
+	}
+	if rebuildFunc != nil {
+		if err := rebuildFunc(types.DocumentID(documentID), chapterNum); err != nil {
+			return nil, fmt.Errorf("failed to rebuild chapter %d markdown: %w", chapterNum, err)
+		}
+	}
+	current, err := e.loadChapterContent(documentID, manifest, chapterNum)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load chapter content: %w", err)
+	}
+
+	// Both renderings point at the document's images, since the page is
+	// written to the exports directory
+	documentDir := e.config.DocumentPath(documentID)
+	currentHTML, approximate, err := e.renderChapterFragment(ctx, documentID, manifest, chapterNum, absoluteImagePaths(current, documentDir), profile)
+	if err != nil {
+		return nil, fmt.Errorf("failed to render chapter: %w", err)
+	}
+	revisionHTML, _, err := e.renderChapterFragment(ctx, documentID, manifest, chapterNum, absoluteImagePaths(revision.Content, documentDir), profile)
+	if err != nil {
+		return nil, fmt.Errorf("failed to render revision %d: %w", revision.ID, err)
+	}
+
+	blocks := diffHTMLBlocks(splitHTMLBlocks(revisionHTML), splitHTMLBlocks(currentHTML))
+	result := &types.ChapterDiff{Mode: mode, Revision: revision.ID, Approximate: approximate}
+	for _, block := range blocks {
+		switch block.kind {
+		case diffAdded:
+			result.Added++
+		case diffRemoved:
+			result.Removed++
+		default:
+			result.Unchanged++
+		}
+	}
+
+	title := fmt.Sprintf("Chapter %d", chapterNum)
+	for _, chapter := range manifest.Document.Chapters {
+		if chapter.Number == chapterNum && chapter.Title != "" {
+			title += ": " + chapter.Title
+			break
+		}
+	}
+	since := fmt.Sprintf("revision %d", revision.ID)
+	if revision.Label != "" {
+		since += fmt.Sprintf(" (%s)", revision.Label)
+	}
+	summary := fmt.Sprintf("%s: changes since %s of %s: %d block(s) added, %d removed, %d unchanged",
+		title, since, revision.CreatedAt.Format("2006-01-02 15:04"), result.Added, result.Removed, result.Unchanged)
+	notice := ""
+	if approximate {
+		notice = ApproximateNotice
+	}
+	body := inlineDiffHTML(blocks)
+	if mode == types.DiffModeSideBySide {
+		body = sideBySideDiffHTML(blocks, revision.ID)
+	}
+
+	var page bytes.Buffer
+	if err := diffTemplate.Execute(&page, map[string]interface{}{
+		"Title":      title,
+		"Summary":    summary,
+		"Notice":     notice,
+		"SideBySide": mode == types.DiffModeSideBySide,
+		"Body":       template.HTML(body),
+	}); err != nil {
+		return nil, fmt.Errorf("failed to render diff page: %w", err)
+	}
+
+	result.OutputPath = filepath.Join(e.config.ExportsDir, fmt.Sprintf("%s-chapter-%d-diff.html", documentID, chapterNum))
+	if err := os.MkdirAll(filepath.Dir(result.OutputPath), 0755); err != nil {
+		return nil, fmt.Errorf("failed to create output directory: %w", err)
+	}
+	if err := os.WriteFile(result.OutputPath, page.Bytes(), 0644); err != nil {
+		return nil, fmt.Errorf("failed to write diff: %w", err)
+	}
+	return result, nil
+}
+
+// splitHTMLBlocks splits an HTML fragment into its top-level elements, such as
+// paragraphs, headings, lists and tables, whatever lines they are wrapped over
+func splitHTMLBlocks(fragment string) []string {
+	var blocks []string
+	var current []string
+	depth := 0
+	for _, line := range strings.Split(fragment, "\n") {
+		if strings.TrimSpace(line) == "" && depth == 0 {
+			continue
+		}
+		current = append(current, line)
+		for _, tag := range htmlTagPattern.FindAllStringSubmatch(line, -1) {
+			name := strings.ToLower(tag[2])
+			switch {
+			case voidElements[name] || tag[3] == "/":
+			case tag[1] == "/":
+				depth--
+			default:
+				depth++
+			}
+		}
+		if depth <= 0 {
+			blocks = append(blocks, strings.Join(current, "\n"))
+			current = nil
+			depth = 0
+		}
+	}
+	if len(current) > 0 {
+		blocks = append(blocks, strings.Join(current, "\n"))
+	}
+	return blocks
+}
+
+// diffHTMLBlocks compares the revision's blocks with the current ones by their
+// longest common subsequence, ignoring differences in whitespace
+func diffHTMLBlocks(old, new []string) []diffBlock {
+	normalize := func(blocks []string) []string {
+		normalized := make([]string, len(blocks))
+		for i, block := range blocks {
+			normalized[i] = strings.Join(strings.Fields(block), " ")
+		}
+		return normalized
+	}
+	a, b := normalize(old), normalize(new)
+
+	// common[i][j] is the length of the longest common subsequence of a[i:] and b[j:]
+	common := make([][]int, len(a)+1)
+	for i := range common {
+		common[i] = make([]int, len(b)+1)
+	}
+	for i := len(a) - 1; i >= 0; i-- {
+		for j := len(b) - 1; j >= 0; j-- {
+			if a[i] == b[j] {
+				common[i][j] = common[i+1][j+1] + 1
+			} else if common[i+1][j] >= common[i][j+1] {
+				common[i][j] = common[i+1][j]
+			} else {
+				common[i][j] = common[i][j+1]
+			}
+		}
+	}
+
+	var blocks []diffBlock
+	i, j := 0, 0
+	for i < len(a) || j < len(b) {
+		switch {
+		case i < len(a) && j < len(b) && a[i] == b[j]:
+			blocks = append(blocks, diffBlock{diffEqual, new[j]})
+			i++
+			j++
+		case j < len(b) && (i == len(a) || common[i][j+1] > common[i+1][j]):
+			blocks = append(blocks, diffBlock{diffAdded, new[j]})
+			j++
+		default:
+			blocks = append(blocks, diffBlock{diffRemoved, old[i]})
+			i++
+		}
+	}
+	return blocks
+}
+
+// inlineDiffHTML writes the blocks in one column, removed blocks before the
+// blocks that replaced them
+func inlineDiffHTML(blocks []diffBlock) string {
+	var body strings.Builder
+	for _, block := range blocks {
+		switch block.kind {
+		case diffAdded:
+			body.WriteString("<ins class=\"diff-added\">\n" + block.html + "\n</ins>\n")
+		case diffRemoved:
+			body.WriteString("<del class=\"diff-removed\">\n" + block.html + "\n</del>\n")
+		default:
+			body.WriteString(block.html + "\n")
+		}
+	}
+	return body.String()
+}
+
+// sideBySideDiffHTML writes the revision's blocks on the left and the current
+// ones on the right, lining up each run of removed blocks with the added
+// blocks that replaced it
+func sideBySideDiffHTML(blocks []diffBlock, revision int) string {
+	var body strings.Builder
+	row := func(left, right string) {
+		body.WriteString("<div class=\"diff-row\">\n<div class=\"diff-side\">\n" + left + "\n</div>\n<div class=\"diff-side\">\n" + right + "\n</div>\n</div>\n")
+	}
+	row(fmt.Sprintf("<p class=\"diff-heading\">Revision %d</p>", revision), "<p class=\"diff-heading\">Current</p>")
+
+	var removed, added []string
+	flush := func() {
+		for k := 0; k < len(removed) || k < len(added); k++ {
+			left, right := "", ""
+			if k < len(removed) {
+				left = "<div class=\"diff-removed\">\n" + removed[k] + "\n</div>"
+			}
+			if k < len(added) {
+				right = "<div class=\"diff-added\">\n" + added[k] + "\n</div>"
+			}
+			row(left, right)
+		}
+		removed, added = nil, nil
+	}
+	for _, block := range blocks {
+		switch block.kind {
+		case diffRemoved:
+			removed = append(removed, block.html)
+		case diffAdded:
+			added = append(added, block.html)
+		default:
+			flush()
+			row(block.html, block.html)
+		}
+	}
+	flush()
+	return body.String()
+}
@@ -0,0 +1,84 @@
+package export
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gomcpgo/docgen/pkg/types"
+)
+
+func TestSplitHTMLBlocks(t *testing.T) {
+	fragment := "<h1 id=\"intro\">Intro</h1>\n<p>A paragraph wrapped\nover two lines.</p>\n\n<ul>\n<li>One</li>\n<li>Two<br />\nlines</li>\n</ul>\n<hr />\n<p><img src=\"a.png\" alt=\"A\"></p>"
+	want := []string{
+		"<h1 id=\"intro\">Intro</h1>",
+		"<p>A paragraph wrapped\nover two lines.</p>",
+		"<ul>\n<li>One</li>\n<li>Two<br />\nlines</li>\n</ul>",
+		"<hr />",
+		"<p><img src=\"a.png\" alt=\"A\"></p>",
+	}
+	if got := splitHTMLBlocks(fragment); !reflect.DeepEqual(got, want) {
+		t.Errorf("splitHTMLBlocks() = %q, want %q", got, want)
+	}
+}
+
+func TestDiffHTMLBlocks(t *testing.T) {
+	old := []string{"<h1>Title</h1>", "<p>Kept.</p>", "<p>Old\ntext.</p>", "<p>Dropped.</p>"}
+	new := []string{"<h1>Title</h1>", "<p>Kept.</p>", "<p>New text.</p>", "<p>Old text.</p>"}
+
+	blocks := diffHTMLBlocks(old, new)
+	want := []diffBlock{
+		{diffEqual, "<h1>Title</h1>"},
+		{diffEqual, "<p>Kept.</p>"},
+		{diffAdded, "<p>New text.</p>"},
+		{diffEqual, "<p>Old text.</p>"},
+		{diffRemoved, "<p>Dropped.</p>"},
+	}
+	if !reflect.DeepEqual(blocks, want) {
+		t.Errorf("diffHTMLBlocks() = %v, want %v", blocks, want)
+	}
+
+	side := sideBySideDiffHTML([]diffBlock{{diffRemoved, "<p>Old.</p>"}, {diffAdded, "<p>New.</p>"}}, 3)
+	if !strings.Contains(side, "<div class=\"diff-removed\">\n<p>Old.</p>\n</div>\n</div>\n<div class=\"diff-side\">\n<div class=\"diff-added\">\n<p>New.</p>") {
+		t.Errorf("Expected the replaced block next to its replacement:\n%s", side)
+	}
+}
+
+func TestExporter_DiffChapter(t *testing.T) {
+	exporter, tempDir := setupTestExporter(t)
+	defer os.RemoveAll(tempDir)
+	exporter.config.PandocPath = filepath.Join(tempDir, "missing", "pandoc")
+
+	contentPath := exporter.config.ChapterContentPath("test-doc", "01")
+	os.MkdirAll(filepath.Dir(contentPath), 0755)
+	os.WriteFile(contentPath, []byte("# Chapter 1: Introduction\n\nFirst paragraph.\n\nA new paragraph."), 0644)
+	manifest := &types.Manifest{Document: types.Document{Chapters: []types.Chapter{{Number: 1, Title: "Introduction"}}}}
+	revision := &types.ChapterRevision{ID: 2, Label: "before edit", CreatedAt: time.Now(), Content: "# Chapter 1: Introduction\n\nFirst paragraph.\n\nAn old paragraph."}
+
+	for _, mode := range []types.DiffMode{types.DiffModeInline, types.DiffModeSideBySide} {
+		diff, err := exporter.DiffChapter(context.Background(), "test-doc", manifest, 1, revision, mode, nil, nil)
+		if err != nil {
+			t.Fatalf("DiffChapter(%s) error = %v", mode, err)
+		}
+		if diff.Added != 1 || diff.Removed != 1 || diff.Unchanged != 2 || !diff.Approximate || diff.Revision != 2 {
+			t.Errorf("Unexpected diff %+v", diff)
+		}
+		page, err := os.ReadFile(diff.OutputPath)
+		if err != nil {
+			t.Fatalf("Failed to read the diff page: %v", err)
+		}
+		for _, want := range []string{"<title>Chapter 1: Introduction</title>", "changes since revision 2 (before edit)", "An old paragraph.", "A new paragraph.", ApproximateNotice} {
+			if !strings.Contains(string(page), want) {
+				t.Errorf("Expected %q in the %s diff:\n%s", want, mode, page)
+			}
+		}
+	}
+
+	if _, err := exporter.DiffChapter(context.Background(), "test-doc", manifest, 1, revision, "split", nil, nil); err == nil {
+		t.Error("Expected an error for an invalid mode")
+	}
+}
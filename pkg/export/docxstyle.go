@@ -0,0 +1,313 @@
+package export
+
+import (
+	"archive/zip"
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/gomcpgo/docgen/pkg/types"
+)
+
+// Text defaults of the generated reference document, in points
+const (
+	defaultDocxBodySize    = 12.0
+	defaultDocxHeadingStep = 4.0 / 3.0
+)
+
+var (
+	// cssLengthPattern matches a length with its unit, capturing both
+	cssLengthPattern = regexp.MustCompile(`^(\d+(?:\.\d+)?)(in|cm|mm|pt|px)$`)
+	// rgbColorPattern matches an rgb() color, capturing its channels
+	rgbColorPattern = regexp.MustCompile(`^rgb\(\s*(\d+)\s*,\s*(\d+)\s*,\s*(\d+)\s*\)$`)
+)
+
+// referenceDocParts are the parts of the minimal DOCX the generated reference
+// document is built on, apart from its styles and document parts. pandoc
+// takes any part a reference document lacks, such as numbering, from its own.
+var referenceDocParts = map[string]string{
+	"[Content_Types].xml": `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<Types xmlns="http://schemas.openxmlformats.org/package/2006/content-types">` +
+		`<Default Extension="rels" ContentType="application/vnd.openxmlformats-package.relationships+xml"/>` +
+		`<Default Extension="xml" ContentType="application/xml"/>` +
+		`<Override PartName="/word/document.xml" ContentType="application/vnd.openxmlformats-officedocument.wordprocessingml.document.main+xml"/>` +
+		`<Override PartName="/word/styles.xml" ContentType="application/vnd.openxmlformats-officedocument.wordprocessingml.styles+xml"/>` +
+		`<Override PartName="/word/settings.xml" ContentType="application/vnd.openxmlformats-officedocument.wordprocessingml.settings+xml"/>` +
+		`</Types>`,
+	"_rels/.rels": `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships">` +
+		`<Relationship Id="rId1" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/officeDocument" Target="word/document.xml"/>` +
+		`</Relationships>`,
+	"word/_rels/document.xml.rels": `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships">` +
+		`<Relationship Id="rId1" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/styles" Target="styles.xml"/>` +
+		`<Relationship Id="rId2" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/settings" Target="settings.xml"/>` +
+		`</Relationships>`,
+	"word/settings.xml": `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<w:settings xmlns:w="http://schemas.openxmlformats.org/wordprocessingml/2006/main">` +
+		`<w:zoom w:percent="100"/><w:defaultTabStop w:val="720"/><w:characterSpacingControl w:val="doNotCompress"/><w:compat/>` +
+		`</w:settings>`,
+}
+
+// styledReferenceDoc writes a DOCX reference document whose styles and page
+// setup follow a style: fonts, sizes and colors for body text, headings, code
+// and links, line spacing and margins. pandoc takes its paragraph and character
+// styles from the reference document, so DOCX exports match the style without
+// one crafted in Word.
+func styledReferenceDoc(style *types.Style, outputPath string) error {
+	parts := map[string]string{
+		"word/styles.xml":   docxStylesXML(style),
+		"word/document.xml": docxDocumentXML(style.Margins),
+	}
+	for name, content := range referenceDocParts {
+		parts[name] = content
+	}
+
+	names := make([]string, 0, len(parts))
+	for name := range parts {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var output bytes.Buffer
+	writer := zip.NewWriter(&output)
+	for _, name := range names {
+		w, err := writer.Create(name)
+		if err != nil {
+			return fmt.Errorf("failed to write %s: %w", name, err)
+		}
+		if _, err := io.WriteString(w, parts[name]); err != nil {
+			return fmt.Errorf("failed to write %s: %w", name, err)
+		}
+	}
+	if err := writer.Close(); err != nil {
+		return fmt.Errorf("failed to write reference document: %w", err)
+	}
+	return os.WriteFile(outputPath, output.Bytes(), 0644)
+}
+
+// docxDocumentXML returns an empty document part whose section properties give
+// the page size and margins exports are laid out with
+func docxDocumentXML(margins types.Margins) string {
+	margin := func(length string) int {
+		if twips, ok := lengthToTwips(length); ok {
+			return twips
+		}
+		return 1440
+	}
+	return fmt.Sprintf(`<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<w:document xmlns:w="http://schemas.openxmlformats.org/wordprocessingml/2006/main" xmlns:r="http://schemas.openxmlformats.org/officeDocument/2006/relationships"><w:body><w:p/>`+
+		`<w:sectPr><w:pgSz w:w="12240" w:h="15840"/><w:pgMar w:top="%d" w:right="%d" w:bottom="%d" w:left="%d" w:header="720" w:footer="720" w:gutter="0"/></w:sectPr>`+
+		`</w:body></w:document>`,
+		margin(margins.Top), margin(margins.Right), margin(margins.Bottom), margin(margins.Left))
+}
+
+// docxStylesXML returns a styles part with the paragraph and character styles
+// pandoc writes, in the fonts, sizes and colors of a style
+func docxStylesXML(style *types.Style) string {
+	bodySize := defaultDocxBodySize
+	if size, ok := lengthToPoints(style.Body.FontSize); ok {
+		bodySize = size
+	}
+	headingSize := bodySize * defaultDocxHeadingStep
+	if size, ok := lengthToPoints(style.Heading.FontSize); ok {
+		headingSize = size
+	}
+	codeSize := bodySize * 11 / 12
+	if size, ok := lengthToPoints(style.Monospace.FontSize); ok {
+		codeSize = size
+	}
+
+	lineSpacing := ""
+	if spacing, err := strconv.ParseFloat(style.LineSpacing, 64); err == nil && spacing > 0 {
+		lineSpacing = fmt.Sprintf(` w:line="%d" w:lineRule="auto"`, int(spacing*240+0.5))
+	}
+
+	body := runProperties(style.Body.FontFamily, style.Body.Color, bodySize, false, false)
+	heading := func(size float64) string {
+		return runProperties(style.Heading.FontFamily, style.Heading.Color, size, true, false)
+	}
+	code := runProperties(style.Monospace.FontFamily, style.Monospace.Color, codeSize, false, false)
+	epigraphSize := bodySize
+	if size, ok := lengthToPoints(style.Epigraph.FontSize); ok {
+		epigraphSize = size
+	}
+	epigraph := runProperties(style.Epigraph.FontFamily, style.Epigraph.Color, epigraphSize, false, true)
+
+	var xml strings.Builder
+	xml.WriteString(`<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<w:styles xmlns:w="http://schemas.openxmlformats.org/wordprocessingml/2006/main">`)
+	xml.WriteString(`<w:docDefaults><w:rPrDefault>` + body + `</w:rPrDefault>`)
+	xml.WriteString(`<w:pPrDefault><w:pPr><w:spacing w:after="0"` + lineSpacing + `/></w:pPr></w:pPrDefault></w:docDefaults>`)
+
+	paragraph := func(id, name, basedOn, pPr, rPr string) {
+		xml.WriteString(`<w:style w:type="paragraph" w:styleId="` + id + `"`)
+		if id == "Normal" {
+			xml.WriteString(` w:default="1"`)
+		}
+		xml.WriteString(`><w:name w:val="` + name + `"/>`)
+		if basedOn != "" {
+			xml.WriteString(`<w:basedOn w:val="` + basedOn + `"/><w:next w:val="BodyText"/>`)
+		}
+		xml.WriteString(`<w:qFormat/>`)
+		if pPr != "" {
+			xml.WriteString(`<w:pPr>` + pPr + `</w:pPr>`)
+		}
+		xml.WriteString(rPr + `</w:style>`)
+	}
+	character := func(id, name, rPr string) {
+		if id == "DefaultParagraphFont" {
+			xml.WriteString(`<w:style w:type="character" w:default="1" w:styleId="` + id + `"><w:name w:val="` + name + `"/><w:uiPriority w:val="1"/><w:semiHidden/>`)
+		} else {
+			xml.WriteString(`<w:style w:type="character" w:styleId="` + id + `"><w:name w:val="` + name + `"/><w:basedOn w:val="DefaultParagraphFont"/>`)
+		}
+		xml.WriteString(rPr + `</w:style>`)
+	}
+
+	paragraph("Normal", "Normal", "", "", "")
+	paragraph("BodyText", "Body Text", "Normal", `<w:spacing w:before="180" w:after="180"/>`, "")
+	paragraph("FirstParagraph", "First Paragraph", "BodyText", "", "")
+	paragraph("Compact", "Compact", "BodyText", `<w:spacing w:before="36" w:after="36"/>`, "")
+	paragraph("Title", "Title", "Normal", `<w:keepNext/><w:keepLines/><w:spacing w:before="480" w:after="240"/><w:jc w:val="center"/>`, heading(headingSize*1.5))
+	paragraph("Subtitle", "Subtitle", "Title", `<w:spacing w:before="240" w:after="240"/>`, heading(headingSize*1.125))
+	paragraph("Author", "Author", "Normal", `<w:keepNext/><w:keepLines/><w:jc w:val="center"/>`, "")
+	paragraph("Date", "Date", "Normal", `<w:keepNext/><w:keepLines/><w:jc w:val="center"/>`, "")
+	paragraph("Abstract", "Abstract", "Normal", `<w:keepNext/><w:keepLines/><w:spacing w:before="300" w:after="300"/>`, "")
+	for level := 1; level <= 6; level++ {
+		paragraph(fmt.Sprintf("Heading%d", level), fmt.Sprintf("heading %d", level), "BodyText",
+			fmt.Sprintf(`<w:keepNext/><w:keepLines/><w:spacing w:before="%d" w:after="0"/><w:outlineLvl w:val="%d"/>`, 480-(level-1)*40, level-1),
+			heading(headingLevelSize(level, headingSize, bodySize)))
+	}
+	paragraph("BlockText", "Block Text", "BodyText", `<w:spacing w:before="100" w:after="100"/><w:ind w:left="480" w:right="480"/>`, "")
+	paragraph("Epigraph", "Epigraph", "Normal", `<w:spacing w:before="120" w:after="60"/><w:ind w:left="4320"/><w:jc w:val="right"/>`, epigraph)
+	paragraph("EpigraphAttribution", "Epigraph Attribution", "Epigraph", `<w:spacing w:before="0" w:after="360"/>`, `<w:rPr><w:i w:val="0"/><w:iCs w:val="0"/></w:rPr>`)
+	paragraph("KeepTogether", "Keep Together", "BodyText", `<w:keepNext/><w:keepLines/>`, "")
+	paragraph("Caption", "caption", "Normal", `<w:spacing w:before="0" w:after="120"/>`, `<w:rPr><w:i/></w:rPr>`)
+	paragraph("TableCaption", "Table Caption", "Caption", `<w:keepNext/>`, "")
+	paragraph("ImageCaption", "Image Caption", "Caption", "", "")
+	paragraph("ListingCaption", "Listing Caption", "Caption", `<w:keepNext/>`, "")
+	paragraph("Figure", "Figure", "Normal", "", "")
+	paragraph("CaptionedFigure", "Captioned Figure", "Figure", `<w:keepNext/>`, "")
+	paragraph("SourceCode", "Source Code", "Normal", `<w:wordWrap w:val="off"/>`, code)
+	paragraph("FootnoteText", "footnote text", "Normal", "", fmt.Sprintf(`<w:rPr><w:sz w:val="%d"/></w:rPr>`, halfPoints(bodySize*5/6)))
+	paragraph("TOCHeading", "TOC Heading", "Heading1", `<w:outlineLvl w:val="9"/>`, "")
+	paragraph("Header", "header", "Normal", `<w:tabs><w:tab w:val="center" w:pos="4680"/><w:tab w:val="right" w:pos="9360"/></w:tabs>`, "")
+	paragraph("Footer", "footer", "Normal", `<w:tabs><w:tab w:val="center" w:pos="4680"/><w:tab w:val="right" w:pos="9360"/></w:tabs>`, "")
+
+	character("DefaultParagraphFont", "Default Paragraph Font", "")
+	character("VerbatimChar", "Verbatim Char", code)
+	character("FootnoteReference", "footnote reference", `<w:rPr><w:vertAlign w:val="superscript"/></w:rPr>`)
+	link := `<w:rPr><w:color w:val="4F81BD"/></w:rPr>`
+	if color, ok := wordColor(style.LinkColor); ok {
+		link = `<w:rPr><w:color w:val="` + color + `"/></w:rPr>`
+	}
+	character("Hyperlink", "Hyperlink", link)
+
+	xml.WriteString(`<w:style w:type="table" w:default="1" w:styleId="Table"><w:name w:val="Table"/><w:tblPr><w:tblInd w:w="0" w:type="dxa"/>` +
+		`<w:tblCellMar><w:top w:w="0" w:type="dxa"/><w:left w:w="108" w:type="dxa"/><w:bottom w:w="0" w:type="dxa"/><w:right w:w="108" w:type="dxa"/></w:tblCellMar></w:tblPr></w:style>`)
+	xml.WriteString(`</w:styles>`)
+	return xml.String()
+}
+
+// headingLevelSize steps heading sizes down from the top level's size to the
+// body size, which headings from level 4 on share
+func headingLevelSize(level int, headingSize, bodySize float64) float64 {
+	if level >= 4 {
+		return bodySize
+	}
+	return bodySize + (headingSize-bodySize)*float64(4-level)/3
+}
+
+// runProperties returns a w:rPr element with a font, color and size, leaving
+// out what isn't set
+func runProperties(fontFamily, color string, size float64, bold, italic bool) string {
+	var rPr strings.Builder
+	rPr.WriteString("<w:rPr>")
+	if fontFamily != "" {
+		font := xmlEscape(fontFamily)
+		rPr.WriteString(`<w:rFonts w:ascii="` + font + `" w:hAnsi="` + font + `" w:cs="` + font + `"/>`)
+	}
+	if bold {
+		rPr.WriteString("<w:b/><w:bCs/>")
+	}
+	if italic {
+		rPr.WriteString("<w:i/><w:iCs/>")
+	}
+	if wordHex, ok := wordColor(color); ok {
+		rPr.WriteString(`<w:color w:val="` + wordHex + `"/>`)
+	}
+	rPr.WriteString(fmt.Sprintf(`<w:sz w:val="%d"/><w:szCs w:val="%d"/>`, halfPoints(size), halfPoints(size)))
+	rPr.WriteString("</w:rPr>")
+	return rPr.String()
+}
+
+// halfPoints converts a size in points to the half-points Word sizes text in
+func halfPoints(points float64) int {
+	return int(points*2 + 0.5)
+}
+
+// lengthToPoints converts a length such as 12pt or 0.5in to points
+func lengthToPoints(length string) (float64, bool) {
+	match := cssLengthPattern.FindStringSubmatch(strings.TrimSpace(length))
+	if match == nil {
+		return 0, false
+	}
+	value, err := strconv.ParseFloat(match[1], 64)
+	if err != nil {
+		return 0, false
+	}
+	switch match[2] {
+	case "in":
+		return value * 72, true
+	case "cm":
+		return value * 72 / 2.54, true
+	case "mm":
+		return value * 72 / 25.4, true
+	case "px":
+		return value * 0.75, true
+	}
+	return value, true
+}
+
+// lengthToTwips converts a length to the twentieths of a point Word measures
+// pages in
+func lengthToTwips(length string) (int, bool) {
+	points, ok := lengthToPoints(length)
+	if !ok {
+		return 0, false
+	}
+	return int(points*20 + 0.5), true
+}
+
+// wordColor converts a #RRGGBB, #RGB or rgb() color to the RRGGBB Word uses
+func wordColor(color string) (string, bool) {
+	color = strings.TrimSpace(color)
+	if match := rgbColorPattern.FindStringSubmatch(color); match != nil {
+		var hex strings.Builder
+		for _, channel := range match[1:] {
+			value, err := strconv.Atoi(channel)
+			if err != nil || value > 255 {
+				return "", false
+			}
+			hex.WriteString(fmt.Sprintf("%02X", value))
+		}
+		return hex.String(), true
+	}
+
+	hex := strings.TrimPrefix(color, "#")
+	if len(hex) == 3 {
+		hex = string([]byte{hex[0], hex[0], hex[1], hex[1], hex[2], hex[2]})
+	}
+	if len(hex) != 6 || !strings.HasPrefix(color, "#") {
+		return "", false
+	}
+	if _, err := strconv.ParseUint(hex, 16, 32); err != nil {
+		return "", false
+	}
+	return strings.ToUpper(hex), true
+}
@@ -0,0 +1,109 @@
+package export
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/gomcpgo/docgen/pkg/types"
+)
+
+func TestStyledReferenceDoc(t *testing.T) {
+	style := &types.Style{
+		Body:        types.TextStyle{FontFamily: "Georgia", FontSize: "11pt", Color: "#333"},
+		Heading:     types.TextStyle{FontFamily: "Open Sans", FontSize: "20pt", Color: "rgb(0, 77, 101)"},
+		Monospace:   types.TextStyle{FontFamily: "Fira Code"},
+		LinkColor:   "#1a5fb4",
+		LineSpacing: "1.5",
+		Margins:     types.Margins{Top: "1in", Bottom: "2.54cm", Left: "0.75in", Right: "54pt"},
+	}
+	output := filepath.Join(t.TempDir(), "styles.docx")
+	if err := styledReferenceDoc(style, output); err != nil {
+		t.Fatalf("styledReferenceDoc() error = %v", err)
+	}
+	parts := readZip(t, output)
+
+	for _, name := range []string{"[Content_Types].xml", "_rels/.rels", "word/_rels/document.xml.rels", "word/settings.xml"} {
+		if _, ok := parts[name]; !ok {
+			t.Errorf("Expected part %s in the reference document", name)
+		}
+	}
+
+	styles := parts["word/styles.xml"]
+	for _, want := range []string{
+		// Body text in the document defaults
+		`<w:rPrDefault><w:rPr><w:rFonts w:ascii="Georgia" w:hAnsi="Georgia" w:cs="Georgia"/><w:color w:val="333333"/><w:sz w:val="22"/>`,
+		`<w:spacing w:after="0" w:line="360" w:lineRule="auto"/>`,
+		// Headings step down from the heading size to the body size
+		`w:styleId="Heading1"><w:name w:val="heading 1"/>`,
+		`<w:rFonts w:ascii="Open Sans" w:hAnsi="Open Sans" w:cs="Open Sans"/><w:b/><w:bCs/><w:color w:val="004D65"/><w:sz w:val="40"/>`,
+		`<w:color w:val="004D65"/><w:sz w:val="22"/>`,
+		`w:styleId="VerbatimChar"><w:name w:val="Verbatim Char"/><w:basedOn w:val="DefaultParagraphFont"/><w:rPr><w:rFonts w:ascii="Fira Code"`,
+		`w:styleId="Hyperlink"><w:name w:val="Hyperlink"/><w:basedOn w:val="DefaultParagraphFont"/><w:rPr><w:color w:val="1A5FB4"/>`,
+	} {
+		if !strings.Contains(styles, want) {
+			t.Errorf("Expected %q in the styles:\n%s", want, styles)
+		}
+	}
+
+	document := parts["word/document.xml"]
+	if !strings.Contains(document, `<w:pgMar w:top="1440" w:right="1080" w:bottom="1440" w:left="1080"`) {
+		t.Errorf("Expected the style's margins in the page setup:\n%s", document)
+	}
+}
+
+func TestHeadingLevelSize(t *testing.T) {
+	for level, want := range map[int]float64{1: 18, 2: 16, 3: 14, 4: 12, 6: 12} {
+		if got := headingLevelSize(level, 18, 12); got != want {
+			t.Errorf("headingLevelSize(%d) = %v, want %v", level, got, want)
+		}
+	}
+}
+
+func TestWordColor(t *testing.T) {
+	tests := map[string]string{
+		"#004d65":          "004D65",
+		"#abc":             "AABBCC",
+		"rgb(255, 0, 128)": "FF0080",
+		"blue":             "",
+		"#12345":           "",
+		"rgb(300, 0, 0)":   "",
+	}
+	for color, want := range tests {
+		got, ok := wordColor(color)
+		if got != want || ok != (want != "") {
+			t.Errorf("wordColor(%q) = %q, %v; want %q", color, got, ok, want)
+		}
+	}
+}
+
+func TestExporter_GeneratePandocCommand_StyledReferenceDoc(t *testing.T) {
+	exporter, tempDir := setupTestExporter(t)
+	defer os.RemoveAll(tempDir)
+
+	_, manifest, style, pandocConfig := createTestDocument(t, tempDir)
+	workDir := t.TempDir()
+	inputFile := filepath.Join(workDir, "test-doc-input.md")
+	options := &types.ExportOptions{Format: types.ExportFormatDOCX}
+
+	args := strings.Join(exporter.GeneratePandocCommand(context.Background(), "test-doc", inputFile, "out.docx", manifest, style, pandocConfig, options, "").Args, " ")
+	styledDoc := filepath.Join(workDir, "test-doc-styles.docx")
+	if !strings.Contains(args, "--reference-doc "+styledDoc) {
+		t.Errorf("Expected the reference document generated from the style: %s", args)
+	}
+	if _, err := os.Stat(styledDoc); err != nil {
+		t.Errorf("Expected the generated reference document: %v", err)
+	}
+
+	// A custom reference document is used as it is
+	docDir := filepath.Join(tempDir, "test-doc")
+	os.MkdirAll(docDir, 0755)
+	writeZip(t, filepath.Join(docDir, "custom.docx"), map[string]string{"word/document.xml": "<w:document/>"})
+	style.ReferenceDocx = "custom.docx"
+	args = strings.Join(exporter.GeneratePandocCommand(context.Background(), "test-doc", inputFile, "out.docx", manifest, style, pandocConfig, options, "").Args, " ")
+	if !strings.Contains(args, "--reference-doc "+filepath.Join(docDir, "custom.docx")) {
+		t.Errorf("Expected the custom reference document: %s", args)
+	}
+}
@@ -0,0 +1,113 @@
+package export
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/gomcpgo/docgen/pkg/types"
+)
+
+// addEpigraph puts the chapter's epigraph under its heading, in the markup
+// each format styles: a LaTeX environment for PDF, custom paragraph styles for
+// DOCX, and an .epigraph div for HTML, EPUB and the rest
+func addEpigraph(content string, chapter *types.Chapter, format types.ExportFormat) string {
+	if chapter.Epigraph == nil || chapter.Epigraph.Text == "" {
+		return content
+	}
+	text := strings.TrimSpace(chapter.Epigraph.Text)
+	attribution := ""
+	if chapter.Epigraph.Attribution != "" {
+		attribution = "— " + strings.TrimSpace(chapter.Epigraph.Attribution)
+	}
+
+	var block strings.Builder
+	switch format {
+	case types.ExportFormatPDF:
+		block.WriteString("```{=latex}\n\\begin{docgenepigraph}\n```\n\n" + text + "\n\n")
+		if attribution != "" {
+			block.WriteString("```{=latex}\n\\docgenattribution\n```\n\n" + attribution + "\n\n")
+		}
+		block.WriteString("```{=latex}\n\\end{docgenepigraph}\n```")
+	case types.ExportFormatDOCX:
+		block.WriteString("::: {custom-style=\"Epigraph\"}\n" + text + "\n:::")
+		if attribution != "" {
+			block.WriteString("\n\n::: {custom-style=\"Epigraph Attribution\"}\n" + attribution + "\n:::")
+		}
+	default:
+		block.WriteString(":::: {.epigraph}\n" + text + "\n")
+		if attribution != "" {
+			block.WriteString("\n::: {.epigraph-attribution}\n" + attribution + "\n:::\n")
+		}
+		block.WriteString("::::")
+	}
+	return insertUnderChapterHeading(content, chapter, block.String())
+}
+
+// hasEpigraphs reports whether any exported chapter has an epigraph
+func hasEpigraphs(manifest *types.Manifest, chapters []types.ChapterNumber) bool {
+	for _, chapter := range exportedChapters(manifest, chapters) {
+		if chapter.Epigraph != nil && chapter.Epigraph.Text != "" {
+			return true
+		}
+	}
+	return false
+}
+
+// generateEpigraphHeader defines the environment PDF epigraphs are set in:
+// right-aligned in a narrower column, in italics or the style's epigraph text
+// style
+func generateEpigraphHeader(style *types.Style, manifest *types.Manifest, chapters []types.ChapterNumber) string {
+	if !hasEpigraphs(manifest, chapters) {
+		return ""
+	}
+
+	font := "\\small"
+	var epigraph types.TextStyle
+	if style != nil {
+		epigraph = style.Epigraph
+	}
+	if size, ok := lengthToPoints(epigraph.FontSize); ok {
+		font = fmt.Sprintf("\\fontsize{%g}{%g}\\selectfont", size, size*1.2)
+	}
+	if epigraph.FontFamily != "" && needsXeLaTeX(style) {
+		font += "\\fontspec" + fontspecFont(epigraph)
+	}
+
+	var header strings.Builder
+	header.WriteString("\n% Chapter epigraphs\n")
+	if epigraph.Color != "" {
+		header.WriteString("\\usepackage{xcolor}\n")
+		font += fmt.Sprintf("\\color[HTML]{%s}", convertColorToHex(epigraph.Color))
+	}
+	header.WriteString(fmt.Sprintf("\\newenvironment{docgenepigraph}{\\begin{flushright}\\begin{minipage}{0.6\\textwidth}\\raggedleft\\itshape%s}{\\end{minipage}\\end{flushright}\\bigskip}\n", font))
+	header.WriteString("\\newcommand{\\docgenattribution}{\\par\\smallskip\\upshape}\n")
+	return header.String()
+}
+
+// epigraphCSS styles .epigraph divs in HTML exports
+func epigraphCSS(epigraph types.TextStyle) string {
+	var css strings.Builder
+	css.WriteString(".epigraph {\n")
+	css.WriteString("    max-width: 60%;\n")
+	css.WriteString("    margin: 0 0 2em auto;\n")
+	css.WriteString("    text-align: right;\n")
+	css.WriteString("    font-style: italic;\n")
+	if epigraph.FontFamily != "" {
+		css.WriteString(fmt.Sprintf("    font-family: '%s', serif;\n", epigraph.FontFamily))
+	}
+	if epigraph.FontSize != "" {
+		css.WriteString(fmt.Sprintf("    font-size: %s;\n", epigraph.FontSize))
+	}
+	if epigraph.Color != "" {
+		css.WriteString(fmt.Sprintf("    color: %s;\n", epigraph.Color))
+	}
+	css.WriteString("}\n\n")
+	css.WriteString(".epigraph p {\n")
+	css.WriteString("    text-align: right;\n")
+	css.WriteString("    margin-bottom: 0.5em;\n")
+	css.WriteString("}\n\n")
+	css.WriteString(".epigraph-attribution {\n")
+	css.WriteString("    font-style: normal;\n")
+	css.WriteString("}\n\n")
+	return css.String()
+}
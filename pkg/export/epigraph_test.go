@@ -0,0 +1,67 @@
+package export
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/gomcpgo/docgen/pkg/types"
+)
+
+func TestAddEpigraph(t *testing.T) {
+	chapter := &types.Chapter{
+		Number:   1,
+		Title:    "Beginnings",
+		Epigraph: &types.Epigraph{Text: "All happy families are *alike*.", Attribution: "Leo Tolstoy"},
+	}
+	content := "# Chapter 1: Beginnings\n\nIt began."
+
+	tests := []struct {
+		format types.ExportFormat
+		want   string
+	}{
+		{types.ExportFormatPDF, "# Chapter 1: Beginnings\n\n```{=latex}\n\\begin{docgenepigraph}\n```\n\nAll happy families are *alike*.\n\n" +
+			"```{=latex}\n\\docgenattribution\n```\n\n— Leo Tolstoy\n\n```{=latex}\n\\end{docgenepigraph}\n```\n\nIt began."},
+		{types.ExportFormatDOCX, "# Chapter 1: Beginnings\n\n::: {custom-style=\"Epigraph\"}\nAll happy families are *alike*.\n:::\n\n" +
+			"::: {custom-style=\"Epigraph Attribution\"}\n— Leo Tolstoy\n:::\n\nIt began."},
+		{types.ExportFormatHTML, "# Chapter 1: Beginnings\n\n:::: {.epigraph}\nAll happy families are *alike*.\n\n" +
+			"::: {.epigraph-attribution}\n— Leo Tolstoy\n:::\n::::\n\nIt began."},
+	}
+	for _, tt := range tests {
+		if got := addEpigraph(content, chapter, tt.format); got != tt.want {
+			t.Errorf("addEpigraph(%s) =\n%s\nwant:\n%s", tt.format, got, tt.want)
+		}
+	}
+
+	chapter.Epigraph = nil
+	if got := addEpigraph(content, chapter, types.ExportFormatHTML); got != content {
+		t.Errorf("Expected content without an epigraph unchanged, got:\n%s", got)
+	}
+}
+
+func TestEpigraphStyles(t *testing.T) {
+	manifest := &types.Manifest{Document: types.Document{Chapters: []types.Chapter{
+		{Number: 1, Title: "One"},
+		{Number: 2, Title: "Two", Epigraph: &types.Epigraph{Text: "Quoted."}},
+	}}}
+	style := &types.Style{Epigraph: types.TextStyle{FontSize: "10pt", Color: "#555555"}}
+
+	if header := generateEpigraphHeader(style, manifest, []types.ChapterNumber{1}); header != "" {
+		t.Errorf("Expected no header without exported epigraphs, got:\n%s", header)
+	}
+	header := generateEpigraphHeader(style, manifest, nil)
+	if !strings.Contains(header, "\\newenvironment{docgenepigraph}") || !strings.Contains(header, "\\fontsize{10}{12}\\selectfont\\color[HTML]{555555}") {
+		t.Errorf("Unexpected epigraph header:\n%s", header)
+	}
+
+	if css := generateHTMLCSS(style, manifest); !strings.Contains(css, ".epigraph {\n    max-width: 60%;") || !strings.Contains(css, "    color: #555555;") {
+		t.Errorf("Expected epigraph rules in the CSS:\n%s", css)
+	}
+
+	styles := docxStylesXML(style)
+	if !strings.Contains(styles, `w:styleId="Epigraph"><w:name w:val="Epigraph"/>`) || !strings.Contains(styles, `<w:i/><w:iCs/><w:color w:val="555555"/><w:sz w:val="20"/>`) {
+		t.Errorf("Expected an italic Epigraph paragraph style:\n%s", styles)
+	}
+	if !strings.Contains(styles, `w:styleId="EpigraphAttribution"><w:name w:val="Epigraph Attribution"/><w:basedOn w:val="Epigraph"/>`) {
+		t.Errorf("Expected an Epigraph Attribution paragraph style:\n%s", styles)
+	}
+}
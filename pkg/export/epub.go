@@ -0,0 +1,126 @@
+package export
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+
+	"github.com/gomcpgo/docgen/pkg/types"
+)
+
+// generateEPUBAccessibilityMetadata writes the schema.org accessibility metadata
+// pandoc emits into the EPUB package document
+func generateEPUBAccessibilityMetadata(doc *types.Document) string {
+	hasFigures := false
+	allFiguresDescribed := true
+	for _, chapter := range doc.Chapters {
+		for _, figure := range chapter.Figures {
+			hasFigures = true
+			if figure.Caption == "" || figure.CaptionTODO {
+				allFiguresDescribed = false
+			}
+		}
+	}
+
+	accessModes := []string{"textual"}
+	features := []string{"structuralNavigation", "tableOfContents", "readingOrder"}
+	if hasFigures {
+		accessModes = append(accessModes, "visual")
+		if allFiguresDescribed {
+			features = append(features, "alternativeText")
+		}
+	}
+
+	summary := "This publication provides a navigable table of contents and structured headings."
+	if hasFigures {
+		if allFiguresDescribed {
+			summary += " All figures have text descriptions from their captions."
+		} else {
+			summary += " Some figures do not yet have text descriptions."
+		}
+	}
+
+	var yaml strings.Builder
+	yaml.WriteString(fmt.Sprintf("accessModes: [%s]\n", strings.Join(accessModes, ", ")))
+	if !hasFigures || allFiguresDescribed {
+		yaml.WriteString("accessModeSufficient: [textual]\n")
+	}
+	yaml.WriteString(fmt.Sprintf("accessibilityFeatures: [%s]\n", strings.Join(features, ", ")))
+	yaml.WriteString("accessibilityHazards: [none]\n")
+	yaml.WriteString(fmt.Sprintf("accessibilitySummary: %q\n", summary))
+
+	return yaml.String()
+}
+
+// ValidateEPUB adds EPUB accessibility checks to a validation report and runs
+// epubcheck against the last EPUB export when it is available
+func (e *Exporter) ValidateEPUB(ctx context.Context, documentID string, manifest *types.Manifest, report *types.ValidationReport) {
+	checkEPUBAccessibility(manifest, report)
+	e.runEPUBCheck(ctx, documentID, report)
+}
+
+// checkEPUBAccessibility reports document issues that make an EPUB less accessible
+func checkEPUBAccessibility(manifest *types.Manifest, report *types.ValidationReport) {
+	if manifest.Document.Language == "" {
+		report.Warnings = append(report.Warnings, "EPUB: document language is not set; reading systems need it to choose a voice and hyphenation")
+	}
+	for _, chapter := range manifest.Document.Chapters {
+		for _, figure := range chapter.Figures {
+			if figure.Caption == "" || figure.CaptionTODO {
+				report.Warnings = append(report.Warnings, fmt.Sprintf("EPUB: figure %s has no text description for its alt text", figure.ID))
+			}
+		}
+	}
+}
+
+// runEPUBCheck runs epubcheck against the document's last EPUB export, if both
+// epubcheck and the export are available, and adds its findings to the report
+func (e *Exporter) runEPUBCheck(ctx context.Context, documentID string, report *types.ValidationReport) {
+	epubPath := e.config.ExportPath(documentID, string(types.ExportFormatEPUB))
+	if _, err := os.Stat(epubPath); os.IsNotExist(err) {
+		report.Warnings = append(report.Warnings, "EPUB: no EPUB export found; export the document as epub to run epubcheck")
+		return
+	}
+
+	checkPath, err := exec.LookPath(e.config.EPUBCheckPath)
+	if err != nil {
+		report.Warnings = append(report.Warnings, fmt.Sprintf("EPUB: epubcheck not found (%s); skipping conformance check", e.config.EPUBCheckPath))
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, e.config.ExportTimeout)
+	defer cancel()
+
+	// epubcheck exits non-zero when it finds errors, so the output is parsed regardless
+	output, _ := exec.CommandContext(ctx, checkPath, epubPath).CombinedOutput()
+	if ctx.Err() != nil {
+		report.Warnings = append(report.Warnings, fmt.Sprintf("EPUB: epubcheck timed out after %v", e.config.ExportTimeout))
+		return
+	}
+
+	errs, warnings := parseEPUBCheckOutput(string(output))
+	if len(errs) > 0 {
+		report.Errors = append(report.Errors, errs...)
+		report.Valid = false
+	}
+	report.Warnings = append(report.Warnings, warnings...)
+}
+
+// parseEPUBCheckOutput splits epubcheck messages into errors and warnings
+func parseEPUBCheckOutput(output string) ([]string, []string) {
+	var errs, warnings []string
+	scanner := bufio.NewScanner(strings.NewReader(output))
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		switch {
+		case strings.HasPrefix(line, "FATAL"), strings.HasPrefix(line, "ERROR"):
+			errs = append(errs, "epubcheck: "+line)
+		case strings.HasPrefix(line, "WARNING"):
+			warnings = append(warnings, "epubcheck: "+line)
+		}
+	}
+	return errs, warnings
+}
@@ -0,0 +1,71 @@
+package export
+
+import (
+	"context"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/gomcpgo/docgen/pkg/types"
+)
+
+func TestGenerateEPUBAccessibilityMetadata(t *testing.T) {
+	doc := &types.Document{
+		Title: "Test Document",
+		Chapters: []types.Chapter{
+			{Number: 1, Figures: []types.Figure{{ID: "fig-1.1", Caption: "A diagram"}}},
+		},
+	}
+
+	yaml := generateEPUBAccessibilityMetadata(doc)
+	for _, want := range []string{
+		"accessModes: [textual, visual]",
+		"accessModeSufficient: [textual]",
+		"alternativeText",
+		"accessibilityHazards: [none]",
+	} {
+		if !strings.Contains(yaml, want) {
+			t.Errorf("Accessibility metadata should contain %q, got:\n%s", want, yaml)
+		}
+	}
+
+	// A placeholder caption is not a sufficient text alternative
+	doc.Chapters[0].Figures[0].CaptionTODO = true
+	yaml = generateEPUBAccessibilityMetadata(doc)
+	if strings.Contains(yaml, "accessModeSufficient") || strings.Contains(yaml, "alternativeText") {
+		t.Errorf("Undescribed figures should not claim textual sufficiency, got:\n%s", yaml)
+	}
+}
+
+func TestParseEPUBCheckOutput(t *testing.T) {
+	output := `Validating using EPUB version 3.3 rules.
+ERROR(RSC-005): book.epub/EPUB/text/ch001.xhtml(12,7): Error while parsing file
+WARNING(ACC-011): book.epub/EPUB/nav.xhtml(3,1): Missing landmark
+Check finished with errors`
+
+	errs, warnings := parseEPUBCheckOutput(output)
+	if len(errs) != 1 || !strings.Contains(errs[0], "RSC-005") {
+		t.Errorf("parseEPUBCheckOutput() errors = %v", errs)
+	}
+	if len(warnings) != 1 || !strings.Contains(warnings[0], "ACC-011") {
+		t.Errorf("parseEPUBCheckOutput() warnings = %v", warnings)
+	}
+}
+
+func TestExporter_ValidateEPUB(t *testing.T) {
+	exporter, tempDir := setupTestExporter(t)
+	defer os.RemoveAll(tempDir)
+
+	_, manifest, _, _ := createTestDocument(t, tempDir)
+	report := &types.ValidationReport{Valid: true}
+
+	exporter.ValidateEPUB(context.Background(), "test-doc", manifest, report)
+
+	if !report.Valid {
+		t.Errorf("Missing EPUB export should only warn, got errors %v", report.Errors)
+	}
+	warnings := strings.Join(report.Warnings, "\n")
+	if !strings.Contains(warnings, "language is not set") || !strings.Contains(warnings, "no EPUB export found") {
+		t.Errorf("ValidateEPUB() warnings = %v", report.Warnings)
+	}
+}
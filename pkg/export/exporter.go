@@ -3,7 +3,6 @@ package export
 import (
 	"context"
 	"fmt"
-	"io"
 	"log"
 	"os"
 	"os/exec"
@@ -32,124 +31,297 @@ func NewExporter(cfg *config.Config) *Exporter {
 type ChapterRebuildFunc func(docID types.DocumentID, chapterNum types.ChapterNumber) error
 
 // ExportDocument exports a document to the specified format
-func (e *Exporter) ExportDocument(documentID string, manifest *types.Manifest, style *types.Style, pandocConfig *types.PandocConfig, options *types.ExportOptions, rebuildFunc ChapterRebuildFunc) (string, error) {
+func (e *Exporter) ExportDocument(ctx context.Context, documentID string, manifest *types.Manifest, style *types.Style, pandocConfig *types.PandocConfig, options *types.ExportOptions, rebuildFunc ChapterRebuildFunc) (string, error) {
+	result, err := e.ExportDocumentResult(ctx, documentID, manifest, style, pandocConfig, options, rebuildFunc)
+	if err != nil {
+		return "", err
+	}
+	return result.OutputPath, nil
+}
+
+// ExportDocumentResult exports a document like ExportDocument and also reports
+// the PDF engine used. A PDF export that fails for engine-specific reasons is
+// retried once with a better-suited engine.
+func (e *Exporter) ExportDocumentResult(ctx context.Context, documentID string, manifest *types.Manifest, style *types.Style, pandocConfig *types.PandocConfig, options *types.ExportOptions, rebuildFunc ChapterRebuildFunc) (*types.ExportResult, error) {
 	// Rebuild all chapter markdown files from section files to ensure they're current
 	if rebuildFunc != nil {
 		for _, chapter := range manifest.Document.Chapters {
+			if err := ctx.Err(); err != nil {
+				return nil, fmt.Errorf("export cancelled: %w", err)
+			}
 			if err := rebuildFunc(types.DocumentID(documentID), chapter.Number); err != nil {
-				return "", fmt.Errorf("failed to rebuild chapter %d markdown: %w", chapter.Number, err)
+				return nil, fmt.Errorf("failed to rebuild chapter %d markdown: %w", chapter.Number, err)
 			}
 		}
 	}
 
+	hookContext := types.HookContext{
+		Event:        types.HookPreMarkdown,
+		DocumentID:   types.DocumentID(documentID),
+		Format:       options.Format,
+		DocumentPath: e.config.DocumentPath(documentID),
+	}
+	if err := e.runHooks(ctx, options.Hooks, hookContext); err != nil {
+		return nil, err
+	}
+
+	// Reading-order formats and JSON are rendered directly without pandoc
+	if options.Format == types.ExportFormatText || options.Format == types.ExportFormatSSML || options.Format == types.ExportFormatJSON {
+		var outputFile string
+		var err error
+		if options.Format == types.ExportFormatJSON {
+			outputFile, err = e.exportJSON(documentID, options)
+		} else {
+			outputFile, err = e.exportReadingOrder(documentID, manifest, options)
+		}
+		if err != nil {
+			return nil, err
+		}
+		result := &types.ExportResult{OutputPath: outputFile}
+		if err := e.finishExport(ctx, result, options); err != nil {
+			return nil, err
+		}
+		hookContext.Event, hookContext.OutputPath = types.HookPostExport, outputFile
+		if err := e.runHooks(ctx, options.Hooks, hookContext); err != nil {
+			return nil, err
+		}
+		return result, nil
+	}
+
 	// Validate document first
 	report := e.ValidateDocument(documentID, manifest)
 	if !report.Valid {
-		return "", fmt.Errorf("document validation failed: %v", report.Errors)
+		return nil, fmt.Errorf("document validation failed: %v", report.Errors)
 	}
 
+	// Chapter reference lists and figure numbers are set up at chapter boundaries
+	chapterOptions := *options
+	if pandocConfig != nil && pandocConfig.Bibliography != "" && pandocConfig.BibliographyScope == types.BibliographyScopeChapter {
+		chapterOptions.ChapterBibliographies = true
+	}
+	if style != nil {
+		chapterOptions.Numbering = &style.NumberingStyle
+		chapterOptions.Typography = &style.Typography
+	}
+	options = &chapterOptions
+
 	// Generate combined markdown
 	markdown, err := e.GenerateMarkdown(documentID, manifest, options)
 	if err != nil {
-		return "", fmt.Errorf("failed to generate markdown: %w", err)
+		return nil, fmt.Errorf("failed to generate markdown: %w", err)
+	}
+
+	// Fragments point their images where the embedding site serves them
+	if options.Fragment != nil {
+		markdown = rebaseImagePaths(markdown, options.Fragment.ImageBaseURL)
+	}
+
+	// reveal.js decks are written to the exports directory and read the
+	// document's images from where they are
+	if options.Format == types.ExportFormatRevealJS {
+		markdown = absoluteImagePaths(markdown, e.config.DocumentPath(documentID))
+	}
+
+	// LaTeX can't include SVG images, so PDF exports use converted copies
+	var svgWarnings []string
+	if options.Format == types.ExportFormatPDF {
+		markdown, svgWarnings = e.convertSVGImages(ctx, documentID, markdown)
+	}
+
+	// Bundled fonts are found, and for PDF, fonts that aren't installed are
+	// replaced before LaTeX tries to load them
+	style, fontWarnings := e.prepareFonts(ctx, documentID, style, options.Format)
+
+	// Intermediate files live in a working directory of their own, so concurrent
+	// exports of the same document don't collide
+	workDir, err := e.newWorkDir(documentID)
+	if err != nil {
+		return nil, err
 	}
+	defer os.RemoveAll(workDir)
 
 	// Create temporary input file
-	tempInputFile := filepath.Join(os.TempDir(), fmt.Sprintf("%s-input.md", documentID))
+	tempInputFile := filepath.Join(workDir, fmt.Sprintf("%s-input.md", documentID))
 	if err := os.WriteFile(tempInputFile, []byte(markdown), 0644); err != nil {
-		return "", fmt.Errorf("failed to write temporary input file: %w", err)
+		return nil, fmt.Errorf("failed to write temporary input file: %w", err)
+	}
+
+	// Hooks may rewrite the markdown pandoc reads
+	hookContext.Event, hookContext.MarkdownPath = types.HookPostMarkdown, tempInputFile
+	if err := e.runHooks(ctx, options.Hooks, hookContext); err != nil {
+		return nil, err
+	}
+
+	// Bundle the source for the PDF to carry
+	if options.EmbedSource && options.Format == types.ExportFormatPDF {
+		if err := e.writeSourceBundle(documentID, tempInputFile); err != nil {
+			return nil, err
+		}
 	}
-	defer os.Remove(tempInputFile)
 
 	// Generate output file path
-	outputFile := e.config.ExportPath(documentID, string(options.Format))
+	outputFile := e.config.ExportPath(documentID, options.Format.Extension())
+	if options.Fragment != nil {
+		outputFile = e.config.ExportPath(documentID, options.Fragment.Extension())
+	}
 	if err := os.MkdirAll(filepath.Dir(outputFile), 0755); err != nil {
-		return "", fmt.Errorf("failed to create output directory: %w", err)
+		return nil, fmt.Errorf("failed to create output directory: %w", err)
 	}
 
-	// Create temporary CSS file for HTML export if needed
+	// Create temporary CSS file for HTML export if needed; fragments are
+	// styled by the page they are embedded in
 	var tempCSSFile string
-	if options.Format == types.ExportFormatHTML && style != nil {
+	if options.Format == types.ExportFormatHTML && style != nil && options.Fragment == nil {
 		cssContent := generateHTMLCSS(style, manifest)
 		if cssContent != "" {
-			tempCSSFile = filepath.Join(os.TempDir(), fmt.Sprintf("%s-style.css", documentID))
+			tempCSSFile = filepath.Join(workDir, fmt.Sprintf("%s-style.css", documentID))
 			if err := os.WriteFile(tempCSSFile, []byte(cssContent), 0644); err != nil {
-				return "", fmt.Errorf("failed to create temporary CSS file: %w", err)
+				return nil, fmt.Errorf("failed to create temporary CSS file: %w", err)
 			}
-			defer os.Remove(tempCSSFile)
 			log.Printf("[DOCGEN HTML] Created temporary CSS file: %s", tempCSSFile)
 		}
 	}
 
+	// Merge chapter-level pandoc options into the document config
+	pandocConfig = mergeChapterPandocOptions(pandocConfig, manifest, options.Chapters)
+
 	// Generate pandoc command
-	cmd := e.GeneratePandocCommand(documentID, tempInputFile, outputFile, manifest, style, pandocConfig, options, tempCSSFile)
+	cmd := e.GeneratePandocCommand(ctx, documentID, tempInputFile, outputFile, manifest, style, pandocConfig, options, tempCSSFile)
+
+	stderr, runErr := e.runPandoc(ctx, documentID, options.Format, cmd, tempInputFile, outputFile)
+	result := &types.ExportResult{OutputPath: outputFile, PDFEngine: pdfEngineArg(cmd.Args), Warnings: append(svgWarnings, fontWarnings...)}
+
+	// Retry once with another engine when the failure is down to the engine
+	if runErr != nil && options.Format == types.ExportFormatPDF {
+		if reason := engineFailure(stderr); reason != "" {
+			if fallback := fallbackEngine(result.PDFEngine, engineAvailable); fallback != "" {
+				retryConfig := types.PandocConfig{}
+				if pandocConfig != nil {
+					retryConfig = *pandocConfig
+				}
+				retryConfig.PDFEngine = fallback
+				retryCmd := e.GeneratePandocCommand(ctx, documentID, tempInputFile, outputFile, manifest, style, &retryConfig, options, tempCSSFile)
+				// The language may rule the fallback out, in which case a retry would fail the same way
+				if engine := pdfEngineArg(retryCmd.Args); engine != result.PDFEngine {
+					log.Printf("[DOCGEN PDF ENGINE] %s failed (%s), retrying with %s", result.PDFEngine, reason, engine)
+					if _, retryErr := e.runPandoc(ctx, documentID, options.Format, retryCmd, tempInputFile, outputFile); retryErr != nil {
+						return nil, fmt.Errorf("%w (retrying with %s also failed: %v)", runErr, engine, retryErr)
+					}
+					result.FallbackFrom = result.PDFEngine
+					result.FallbackReason = reason
+					result.PDFEngine = engine
+					runErr = nil
+				}
+			}
+		}
+	}
+	if runErr != nil {
+		return nil, runErr
+	}
 
-	// Command is ready for execution
+	// Verify output file was created
+	if _, err := os.Stat(outputFile); os.IsNotExist(err) {
+		return nil, fmt.Errorf("output file was not created: %s", outputFile)
+	}
 
+	// Confluence's storage format is written from pandoc's HTML
+	if options.Fragment != nil && options.Fragment.Confluence {
+		fragment, err := os.ReadFile(outputFile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read fragment: %w", err)
+		}
+		if err := os.WriteFile(outputFile, []byte(confluenceStorageFormat(string(fragment))), 0644); err != nil {
+			return nil, fmt.Errorf("failed to write Confluence storage format: %w", err)
+		}
+	}
+
+	if err := e.finishExport(ctx, result, options); err != nil {
+		return nil, err
+	}
+	hookContext.Event, hookContext.MarkdownPath, hookContext.OutputPath = types.HookPostExport, "", outputFile
+	if err := e.runHooks(ctx, options.Hooks, hookContext); err != nil {
+		return nil, err
+	}
+
+	// Accessible exports report what still keeps the document from being accessible
+	if options.Accessible {
+		accessibility := &types.ValidationReport{}
+		e.ValidateAccessibility(documentID, manifest, style, accessibility)
+		result.Warnings = append(result.Warnings, accessibility.Warnings...)
+	}
+
+	return result, nil
+}
+
+// runPandoc runs a pandoc command with the export timeout and records it in the
+// export log. It returns pandoc's standard error for diagnosing failures.
+func (e *Exporter) runPandoc(ctx context.Context, documentID string, format types.ExportFormat, cmd *exec.Cmd, inputFile, outputFile string) (string, error) {
 	// Execute pandoc command with timeout
-	ctx, cancel := context.WithTimeout(context.Background(), e.config.ExportTimeout)
+	ctx, cancel := context.WithTimeout(ctx, e.config.ExportTimeout)
 	defer cancel()
 
-	// Capture stderr for better error reporting
-	cmd.Stderr = nil // We'll capture it manually
-	stderrPipe, err := cmd.StderrPipe()
-	if err != nil {
-		return "", fmt.Errorf("failed to create stderr pipe: %w", err)
-	}
+	// Capture pandoc's output for the export log
+	var stdout, stderr strings.Builder
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
 
 	cmd.SysProcAttr = nil // Ensure clean execution
+	started := time.Now()
 	if err := cmd.Start(); err != nil {
-		return "", fmt.Errorf("failed to start pandoc: %w", err)
+		err = fmt.Errorf("failed to start pandoc: %w", err)
+		e.saveExportLog(documentID, format, cmd, inputFile, started, "", "", err, outputFile)
+		return "", err
 	}
 
-	// Read stderr in background
-	stderrCh := make(chan string, 1)
-	go func() {
-		stderrBytes, err := io.ReadAll(stderrPipe)
-		if err == nil {
-			stderrCh <- string(stderrBytes)
-		} else {
-			stderrCh <- ""
-		}
-	}()
-
 	// Wait for completion with timeout
 	done := make(chan error, 1)
 	go func() {
 		done <- cmd.Wait()
 	}()
 
+	var runErr error
 	select {
 	case err := <-done:
-		stderr := <-stderrCh
 		if err != nil {
-			if stderr != "" {
-				return "", fmt.Errorf("pandoc execution failed: %w. Stderr: %s", err, stderr)
+			if stderr.Len() > 0 {
+				runErr = fmt.Errorf("pandoc execution failed: %w. Stderr: %s", err, stderr.String())
+			} else {
+				runErr = fmt.Errorf("pandoc execution failed: %w", err)
 			}
-			return "", fmt.Errorf("pandoc execution failed: %w", err)
 		}
 	case <-ctx.Done():
 		cmd.Process.Kill()
-		return "", fmt.Errorf("pandoc execution timed out after %v", e.config.ExportTimeout)
-	}
-
-	// Verify output file was created
-	if _, err := os.Stat(outputFile); os.IsNotExist(err) {
-		return "", fmt.Errorf("output file was not created: %s", outputFile)
+		<-done
+		if ctx.Err() == context.DeadlineExceeded {
+			runErr = fmt.Errorf("pandoc execution timed out after %v", e.config.ExportTimeout)
+		} else {
+			runErr = fmt.Errorf("pandoc execution cancelled: %w", ctx.Err())
+		}
 	}
-
-	return outputFile, nil
+	e.saveExportLog(documentID, format, cmd, inputFile, started, stdout.String(), stderr.String(), runErr, outputFile)
+	return stderr.String(), runErr
 }
 
 // GenerateMarkdown combines all chapters into a single markdown document
 func (e *Exporter) GenerateMarkdown(documentID string, manifest *types.Manifest, options *types.ExportOptions) (string, error) {
 	var content strings.Builder
 
-	// Add YAML metadata header
-	yaml := generateYAMLMetadata(&manifest.Document, nil)
-	content.WriteString("---\n")
-	content.WriteString(yaml)
-	content.WriteString("---\n\n")
+	// Add YAML metadata header; a volume has one for all its documents
+	if options.Volume == nil {
+		yaml := generateYAMLMetadata(&manifest.Document, nil)
+		content.WriteString("---\n")
+		content.WriteString(yaml)
+		if options.Format == types.ExportFormatEPUB {
+			content.WriteString(generateEPUBAccessibilityMetadata(&manifest.Document))
+		}
+		content.WriteString("---\n\n")
+	}
+
+	// Accessible HTML marks the document body as the page's main landmark
+	accessibleHTML := options.Accessible && options.Format == types.ExportFormatHTML
+	if accessibleHTML {
+		content.WriteString(mainLandmark(true))
+	}
 
 	// Determine which chapters to include
 	chaptersToInclude := options.Chapters
@@ -160,7 +332,22 @@ func (e *Exporter) GenerateMarkdown(documentID string, manifest *types.Manifest,
 		}
 	}
 
+	// The abbreviations table covers the exported chapters
+	if options.Abbreviations {
+		var contents []string
+		for _, chapterNum := range chaptersToInclude {
+			if chapterContent, err := e.loadChapterContent(documentID, manifest, chapterNum); err == nil {
+				contents = append(contents, chapterContent)
+			}
+		}
+		content.WriteString(abbreviationsTable(collectAbbreviations(&manifest.Document, contents).Abbreviations))
+	}
+	if options.ListOfListings {
+		content.WriteString(listOfListings(manifest, options.Chapters, options.Format, options.Numbering))
+	}
+
 	// Process each chapter
+	currentPart := 0
 	for _, chapterNum := range chaptersToInclude {
 		// Find the chapter in manifest
 		var chapter *types.Chapter
@@ -176,25 +363,83 @@ func (e *Exporter) GenerateMarkdown(documentID string, manifest *types.Manifest,
 		}
 
 		// Read chapter content
-		chapterContent, err := e.loadChapterContent(documentID, int(chapterNum))
+		chapterContent, err := e.loadChapterContent(documentID, manifest, chapterNum)
 		if err != nil {
 			return "", fmt.Errorf("failed to load chapter %d content: %w", chapterNum, err)
 		}
+		if options.ApprovedOnly {
+			var exported bool
+			if chapterContent, exported = filterApproved(chapterContent, chapter); !exported {
+				continue
+			}
+		}
+		if options.Volume != nil {
+			chapterContent = renumberHeadings(chapterContent, chapter, chapterNum+types.ChapterNumber(options.Volume.ChapterOffset), options.Numbering, options.Volume.ChapterHeading)
+		}
+		chapterContent = addEpigraph(chapterContent, chapter, options.Format)
+		if options.StatusStamps {
+			chapterContent = addStatusStamp(chapterContent, chapter)
+		}
+
+		// Drop raw blocks meant for other output formats
+		chapterContent = stripRawBlocks(chapterContent, options.Format)
+		chapterContent = translateLayoutMarkers(chapterContent, options.Format)
+		chapterContent = resolveFigureAnchors(manifest, chapterContent)
+		chapterContent = e.expandFigureGrids(documentID, manifest, chapterNum, chapterContent, options.Format)
+		chapterContent = e.placeFigures(documentID, manifest, chapterNum, chapterContent, options.Format)
+		chapterContent = formatListings(manifest, chapterNum, chapterContent, options.Format, options.Numbering)
+		chapterContent = formatFigureReferences(manifest, chapterContent, options.Format, options.Numbering, options.Volume)
+		if options.Format == types.ExportFormatPDF {
+			chapterContent = adjustFloatPlacements(chapterContent, options.FloatPlacement)
+		}
+		if len(options.FrontMatterBadges) > 0 && (options.Format == types.ExportFormatHTML || options.Format == types.ExportFormatEPUB) {
+			chapterContent = addFrontMatterBadges(chapterContent, chapter, options.FrontMatterBadges)
+		}
+		chapterContent = applyTypography(chapterContent, options.Typography)
+
+		// Open the chapter's part before its first exported chapter. In a volume
+		// each document is a part of its own.
+		if partNumber, part := manifest.Document.PartContaining(chapterNum); part != nil && partNumber != currentPart && options.Volume == nil {
+			content.WriteString(partHeading(options.Format, partNumber, part.Title))
+			currentPart = partNumber
+		}
 
 		// Add chapter to combined content
-		content.WriteString(fmt.Sprintf("\\newpage\n\n"))
+		content.WriteString(chapterBreak(options.Format))
+		if options.Numbering != nil {
+			content.WriteString(numberingMarker(options.Format, options.Numbering, manifest, chapterNum, options.Volume))
+		}
+		landscape := options.Format == types.ExportFormatPDF && chapter.PandocOptions != nil && chapter.PandocOptions.Landscape
+		if landscape {
+			content.WriteString("```{=latex}\n\\begin{landscape}\n```\n\n")
+		}
+		if options.PageMarkers {
+			chapterContent = addPageMarker(chapterContent, chapterNum)
+		}
 		content.WriteString(chapterContent)
 		content.WriteString("\n\n")
+		if landscape {
+			content.WriteString("```{=latex}\n\\end{landscape}\n```\n\n")
+		}
+		if options.ChapterBibliographies {
+			content.WriteString(chapterBibliographyMarker)
+		}
+	}
+	if accessibleHTML {
+		content.WriteString(mainLandmark(false))
 	}
 
 	return content.String(), nil
 }
 
-// GeneratePandocCommand creates the pandoc command with all necessary options
-func (e *Exporter) GeneratePandocCommand(documentID, inputFile, outputFile string, manifest *types.Manifest, style *types.Style, pandocConfig *types.PandocConfig, options *types.ExportOptions, tempCSSFile string) *exec.Cmd {
+// GeneratePandocCommand creates the pandoc command with all necessary options.
+// Generated headers, stylesheets and reference documents are written next to
+// inputFile, in the export's working directory.
+func (e *Exporter) GeneratePandocCommand(ctx context.Context, documentID, inputFile, outputFile string, manifest *types.Manifest, style *types.Style, pandocConfig *types.PandocConfig, options *types.ExportOptions, tempCSSFile string) *exec.Cmd {
 	args := []string{
 		inputFile,
 		"-o", outputFile,
+		"--from", readerFormat(pandocConfig.Markdown, style),
 	}
 
 	// Add format-specific options
@@ -214,28 +459,33 @@ func (e *Exporter) GeneratePandocCommand(documentID, inputFile, outputFile strin
 		}
 		
 		// Determine PDF engine based on style
-		pdfEngine := determinePDFEngine(style, pandocConfig)
+		language := manifest.Document.Language
+		pdfEngine := determinePDFEngine(style, pandocConfig, language)
 		log.Printf("[DOCGEN PDF] Using PDF engine: %s\n", pdfEngine)
 		args = append(args, "--pdf-engine", pdfEngine)
 		
-		if style != nil {
-			// Generate and include LaTeX header for advanced styling
-			latexHeader := generateLaTeXHeader(style, manifest)
-			log.Printf("[DOCGEN PDF] Generated LaTeX header (%d chars):\n%s\n", len(latexHeader), latexHeader)
-			if latexHeader != "" {
-				// Create temporary LaTeX header file
-				tempHeaderFile := filepath.Join(os.TempDir(), fmt.Sprintf("%s-header.tex", documentID))
-				log.Printf("[DOCGEN PDF] Writing LaTeX header to: %s\n", tempHeaderFile)
-				if err := os.WriteFile(tempHeaderFile, []byte(latexHeader), 0644); err == nil {
-					args = append(args, "-H", tempHeaderFile)
-				}
+		// Generate and include LaTeX header for advanced styling and non-Latin scripts
+		latexHeader := generateLaTeXHeader(style, manifest) + generateLanguageHeader(language) + generateLocaleHeader(&manifest.Document) + generateChapterLayoutHeader(manifest, options.Chapters) + generateFigureGridHeader(manifest, options.Chapters) + generateMarkingsHeader(options) + generateNumberingHeader(options) + generateListingsHeader(manifest, options.Chapters) + generateEpigraphHeader(style, manifest, options.Chapters) + generateFloatHeader(options) + generateAccessibilityHeader(options)
+		if options.EmbedSource {
+			latexHeader += generateSourceHeader(documentID, inputFile)
+		}
+		log.Printf("[DOCGEN PDF] Generated LaTeX header (%d chars):\n%s\n", len(latexHeader), latexHeader)
+		if latexHeader != "" {
+			// Create temporary LaTeX header file
+			tempHeaderFile := filepath.Join(filepath.Dir(inputFile), fmt.Sprintf("%s-header.tex", documentID))
+			log.Printf("[DOCGEN PDF] Writing LaTeX header to: %s\n", tempHeaderFile)
+			if err := os.WriteFile(tempHeaderFile, []byte(latexHeader), 0644); err == nil {
+				args = append(args, "-H", tempHeaderFile)
 			}
-			
+		}
+		
+		if style != nil {
 			// Add basic font and margin settings
 			if style.Body.FontSize != "" {
 				args = append(args, "-V", fmt.Sprintf("fontsize=%s", style.Body.FontSize))
 			}
-			if style.Body.FontFamily != "" {
+			// Bundled fonts are loaded from their files by the header instead
+			if style.Body.FontFamily != "" && !filepath.IsAbs(style.Body.FontFile) {
 				args = append(args, "-V", fmt.Sprintf("mainfont=%s", style.Body.FontFamily))
 			}
 			if style.Margins.Top != "" {
@@ -249,17 +499,14 @@ func (e *Exporter) GeneratePandocCommand(documentID, inputFile, outputFile strin
 		useReferenceDoc := false
 		
 		if style != nil && style.ReferenceDocx != "" {
-			// Resolve reference document path
-			if filepath.IsAbs(style.ReferenceDocx) {
-				referenceDoc = style.ReferenceDocx
-			} else {
-				// Relative to document directory
-				docDir := filepath.Dir(e.config.ManifestPath(documentID))
-				referenceDoc = filepath.Join(docDir, style.ReferenceDocx)
-			}
+			// Resolve reference document path, relative to the document directory
+			resolved, err := e.config.ResolvePath(style.ReferenceDocx, e.config.DocumentPath(documentID))
+			referenceDoc = resolved
 			
-			// Check if the reference document exists
-			if _, err := os.Stat(referenceDoc); err == nil {
+			// Check if the reference document is allowed and exists
+			if err != nil {
+				log.Printf("[DOCGEN DOCX] Ignoring reference document: %v", err)
+			} else if _, err := os.Stat(referenceDoc); err == nil {
 				useReferenceDoc = true
 				log.Printf("[DOCGEN DOCX] Using custom reference document: %s", referenceDoc)
 			} else {
@@ -267,6 +514,18 @@ func (e *Exporter) GeneratePandocCommand(documentID, inputFile, outputFile strin
 			}
 		}
 		
+		// Without a custom reference document, one is generated from the style
+		if !useReferenceDoc && style != nil {
+			styledDoc := filepath.Join(filepath.Dir(inputFile), fmt.Sprintf("%s-styles.docx", documentID))
+			if err := styledReferenceDoc(style, styledDoc); err != nil {
+				log.Printf("[DOCGEN DOCX] Failed to generate reference document from style: %v", err)
+			} else {
+				referenceDoc = styledDoc
+				useReferenceDoc = true
+				log.Printf("[DOCGEN DOCX] Using reference document generated from style: %s", referenceDoc)
+			}
+		}
+		
 		if !useReferenceDoc && style != nil {
 			// Apply Typography settings using Pandoc variables for DOCX
 			// Note: This provides basic font support, but full styling requires a custom reference.docx
@@ -305,42 +564,163 @@ func (e *Exporter) GeneratePandocCommand(documentID, inputFile, outputFile strin
 			}
 		}
 		
+		// The style's headers and footers live in the reference document's
+		// header and footer parts and settings
+		if style != nil && style.HeaderFooter.IsSet() {
+			base := ""
+			if useReferenceDoc {
+				base = referenceDoc
+			}
+			headerFooterDoc := filepath.Join(filepath.Dir(inputFile), fmt.Sprintf("%s-headers.docx", documentID))
+			pandocPath, _ := findPandocPath(e.config.PandocPath)
+			if err := headerFooterReferenceDoc(pandocPath, base, headerFooterDoc, style.HeaderFooter, CreateTemplateVariables(manifest)); err != nil {
+				log.Printf("[DOCGEN DOCX] Failed to add headers and footers: %v", err)
+			} else {
+				referenceDoc = headerFooterDoc
+				useReferenceDoc = true
+			}
+		}
+
+		// Review markings live in the reference document's header, footer and
+		// page setup; a watermark and banner replace the default header and footer
+		if hasMarkings(options) {
+			base := ""
+			if useReferenceDoc {
+				base = referenceDoc
+			}
+			markedDoc := filepath.Join(filepath.Dir(inputFile), fmt.Sprintf("%s-reference.docx", documentID))
+			pandocPath, _ := findPandocPath(e.config.PandocPath)
+			if err := markedReferenceDoc(pandocPath, base, markedDoc, options); err != nil {
+				log.Printf("[DOCGEN DOCX] Failed to add review markings: %v", err)
+			} else {
+				referenceDoc = markedDoc
+				useReferenceDoc = true
+			}
+		}
+
 		// Only add reference document if one exists and is specified
 		if useReferenceDoc {
 			args = append(args, "--reference-doc", referenceDoc)
 		}
 		
 	case types.ExportFormatHTML:
+		// Fragments are the body alone, without stylesheets or embedded images
+		if options.Fragment != nil {
+			args = append(args, fragmentArgs(options.Fragment)...)
+			break
+		}
+
 		args = append(args, "--standalone")
 		args = append(args, "--embed-resources") // Embed CSS and other resources directly in HTML
 		
+		// Chapter opening pages are told apart in print CSS by their sections
+		if style != nil && style.HeaderFooter.SuppressChapterStart {
+			args = append(args, "--section-divs")
+		}
+		
 		// Use custom CSS if specified
+		// Resolve CSS file path, relative to the document directory
+		var cssFile string
 		if style != nil && style.StyleCSS != "" {
-			// Resolve CSS file path
-			var cssFile string
-			if filepath.IsAbs(style.StyleCSS) {
-				cssFile = style.StyleCSS
-			} else {
-				// Relative to document directory
-				docDir := filepath.Dir(e.config.ManifestPath(documentID))
-				cssFile = filepath.Join(docDir, style.StyleCSS)
+			resolved, err := e.config.ResolvePath(style.StyleCSS, e.config.DocumentPath(documentID))
+			if err != nil {
+				log.Printf("[DOCGEN HTML] Ignoring custom CSS file: %v", err)
 			}
+			cssFile = resolved
+		}
+		
+		if cssFile != "" {
 			args = append(args, "--css", cssFile)
 		} else if tempCSSFile != "" {
 			// Use the temporary CSS file created in the main export function
 			args = append(args, "--css", tempCSSFile)
 			log.Printf("[DOCGEN HTML] Using temporary CSS file: %s", tempCSSFile)
 		}
+
+		// Bundled fonts are declared alongside the document's own CSS and
+		// embedded with it
+		if fontCSS := fontFaceCSS(style, func(path string) string { return path }); fontCSS != "" {
+			fontCSSFile := filepath.Join(filepath.Dir(inputFile), fmt.Sprintf("%s-fonts.css", documentID))
+			if err := os.WriteFile(fontCSSFile, []byte(fontCSS), 0644); err == nil {
+				args = append(args, "--css", fontCSSFile)
+			}
+		}
+
+		// The HTML layout and theme are layered over the document's own CSS
+		if style != nil {
+			if themeCSS := generateHTMLThemeCSS(style.HTML); themeCSS != "" {
+				themeCSSFile := filepath.Join(filepath.Dir(inputFile), fmt.Sprintf("%s-theme.css", documentID))
+				if err := os.WriteFile(themeCSSFile, []byte(themeCSS), 0644); err == nil {
+					args = append(args, "--css", themeCSSFile)
+				}
+			}
+		}
+
+		// Figure alignment is layered over the document's own CSS
+		if hasAlignedFigures(manifest, options.Chapters) {
+			figuresCSSFile := filepath.Join(filepath.Dir(inputFile), fmt.Sprintf("%s-figures.css", documentID))
+			if err := os.WriteFile(figuresCSSFile, []byte(figureAlignmentCSS), 0644); err == nil {
+				args = append(args, "--css", figuresCSSFile)
+			}
+		}
+
+		// Caption numbers are layered over the document's own CSS
+		if numberingCSS := generateNumberingCSS(options, exportLocale(&manifest.Document)); numberingCSS != "" {
+			numberingCSSFile := filepath.Join(filepath.Dir(inputFile), fmt.Sprintf("%s-numbering.css", documentID))
+			if err := os.WriteFile(numberingCSSFile, []byte(numberingCSS), 0644); err == nil {
+				args = append(args, "--css", numberingCSSFile)
+			}
+		}
+
+		// Review markings are layered over the document's own CSS
+		if markingsCSS := generateMarkingsCSS(options); markingsCSS != "" {
+			markingsCSSFile := filepath.Join(filepath.Dir(inputFile), fmt.Sprintf("%s-markings.css", documentID))
+			if err := os.WriteFile(markingsCSSFile, []byte(markingsCSS), 0644); err == nil {
+				args = append(args, "--css", markingsCSSFile)
+			}
+		}
+
+		// Accessible exports open with a skip link to the main landmark
+		if options.Accessible {
+			accessibilityCSSFile := filepath.Join(filepath.Dir(inputFile), fmt.Sprintf("%s-accessibility.css", documentID))
+			if err := os.WriteFile(accessibilityCSSFile, []byte(accessibleHTMLCSS), 0644); err == nil {
+				args = append(args, "--css", accessibilityCSSFile)
+			}
+			beforeBodyFile := filepath.Join(filepath.Dir(inputFile), fmt.Sprintf("%s-before-body.html", documentID))
+			if err := os.WriteFile(beforeBodyFile, []byte(accessibleHTMLBeforeBody), 0644); err == nil {
+				args = append(args, "--include-before-body", beforeBodyFile)
+			}
+		}
+
+	case types.ExportFormatRevealJS, types.ExportFormatPPTX:
+		args = append(args, e.slideArgs(documentID, inputFile, style, options)...)
+
+	case types.ExportFormatEPUB:
+		// A visible table of contents alongside the navigation document and landmarks
+		// that pandoc always generates for EPUB3
+		args = append(args, "--toc")
+		args = append(args, e.epubFontArgs(ctx, documentID, inputFile, style)...)
+		if manifest.Document.Language == "" {
+			// EPUB requires a language; pandoc falls back to en-US
+			log.Printf("[DOCGEN EPUB] Document language not set, pandoc will default to en-US")
+		}
 	}
 
-	// Add table of contents if enabled
-	if pandocConfig.TOC {
+	// Resolve relative image references (assets/images/...) against the document directory
+	args = append(args, "--resource-path", e.config.DocumentPath(documentID))
+
+	// Add table of contents if enabled, or for the HTML sidebar to hold
+	sidebarTOC := options.Format == types.ExportFormatHTML && style != nil && style.HTML.SidebarTOC
+	if pandocConfig.TOC || sidebarTOC {
 		args = append(args, "--toc")
 		if pandocConfig.TOCDepth > 0 {
 			args = append(args, "--toc-depth", fmt.Sprintf("%d", pandocConfig.TOCDepth))
 		}
 	}
 
+	// Resolve citations against the bibliography
+	args = append(args, e.bibliographyArgs(documentID, inputFile, pandocConfig)...)
+
 	// Add any additional arguments
 	args = append(args, pandocConfig.Args...)
 
@@ -349,6 +729,11 @@ func (e *Exporter) GeneratePandocCommand(documentID, inputFile, outputFile strin
 		args = append(args, "-V", fmt.Sprintf("%s=%s", key, value))
 	}
 
+	// Add document class options
+	for _, option := range pandocConfig.ClassOptions {
+		args = append(args, "-V", fmt.Sprintf("classoption=%s", option))
+	}
+
 	// Resolve pandoc path
 	pandocPath, err := findPandocPath(e.config.PandocPath)
 	if err != nil {
@@ -372,13 +757,37 @@ func (e *Exporter) ValidateDocument(documentID string, manifest *types.Manifest)
 
 	// Check that all chapters have content files
 	for _, chapter := range manifest.Document.Chapters {
-		chapterPath := e.config.ChapterContentPath(documentID, int(chapter.Number))
+		chapterPath, err := e.chapterContentPath(documentID, manifest, chapter.Number)
+		if err != nil {
+			report.Errors = append(report.Errors, fmt.Sprintf("Chapter %d has no directory in the chapter order", chapter.Number))
+			report.Valid = false
+			continue
+		}
 		if _, err := os.Stat(chapterPath); os.IsNotExist(err) {
 			report.Errors = append(report.Errors, fmt.Sprintf("Chapter %d content file not found: %s", chapter.Number, chapterPath))
 			report.Valid = false
 		}
 	}
 
+	// Check that files included from outside the document can be read
+	for _, chapter := range manifest.Document.Chapters {
+		if chapter.IncludeFile != "" {
+			if err := e.checkIncludeFile(documentID, chapter.IncludeFile); err != nil {
+				report.Errors = append(report.Errors, fmt.Sprintf("Chapter %d include: %v", chapter.Number, err))
+				report.Valid = false
+			}
+		}
+		for _, section := range chapter.Sections {
+			if section.IncludeFile == "" {
+				continue
+			}
+			if err := e.checkIncludeFile(documentID, section.IncludeFile); err != nil {
+				report.Errors = append(report.Errors, fmt.Sprintf("Section %s include: %v", section.Number.String(), err))
+				report.Valid = false
+			}
+		}
+	}
+
 	// Check that required files exist
 	manifestPath := e.config.ManifestPath(documentID)
 	if _, err := os.Stat(manifestPath); os.IsNotExist(err) {
@@ -396,18 +805,71 @@ func (e *Exporter) ValidateDocument(documentID string, manifest *types.Manifest)
 	// Add warnings for missing figures or broken references
 	for _, chapter := range manifest.Document.Chapters {
 		for _, figure := range chapter.Figures {
-			imagePath := filepath.Join(e.config.AssetsPath(documentID), filepath.Base(figure.ImagePath))
-			if _, err := os.Stat(imagePath); os.IsNotExist(err) {
-				report.Warnings = append(report.Warnings, fmt.Sprintf("Figure image not found: %s", imagePath))
+			for _, figureImage := range figure.ImagePaths() {
+				imagePath := filepath.Join(e.config.AssetsPath(documentID), filepath.Base(figureImage))
+				if _, err := os.Stat(imagePath); os.IsNotExist(err) {
+					report.Warnings = append(report.Warnings, fmt.Sprintf("Figure image not found: %s", imagePath))
+				}
 			}
 		}
 	}
 
+	// List raw blocks, which are only included in matching export formats
+	for _, chapter := range manifest.Document.Chapters {
+		if chapterContent, err := e.loadChapterContent(documentID, manifest, chapter.Number); err == nil {
+			checkRawBlocks(chapter.Number, chapterContent, report)
+		}
+	}
+
+	// Placeholder captions are reported so they are not forgotten before publishing
+	for _, todo := range manifest.Todos() {
+		report.Warnings = append(report.Warnings, todo.Description)
+	}
+
+	// Abbreviations should be defined once, for readers and the abbreviations table
+	for _, term := range e.Abbreviations(documentID, manifest).Undefined {
+		report.Warnings = append(report.Warnings, fmt.Sprintf("Abbreviation %s is used but never defined; write it out at first use as \"Long Form (%s)\" or define it with set_abbreviation", term, term))
+	}
+
+	// Exports fall back to English for locales docgen has no words for
+	if locale := manifest.Document.Locale; locale != "" && lookupLocale(locale) == nil {
+		report.Warnings = append(report.Warnings, fmt.Sprintf("Locale %s is not supported; dates, caption labels and contents headings are exported in English (supported: de, en, es, fr, it, nl, pt)", locale))
+	}
+
 	return report
 }
 
-// PreviewChapter generates a preview of a single chapter
-func (e *Exporter) PreviewChapter(documentID string, chapterNum types.ChapterNumber, format types.ExportFormat, rebuildFunc ChapterRebuildFunc) (string, error) {
+// checkIncludeFile checks that a file included in a document lies within the
+// allowed directories and exists
+func (e *Exporter) checkIncludeFile(documentID, path string) error {
+	resolved, err := e.config.ResolvePath(path, e.config.DocumentPath(documentID))
+	if err != nil {
+		return err
+	}
+	if _, err := os.Stat(resolved); err != nil {
+		return fmt.Errorf("included file not found: %s", path)
+	}
+	return nil
+}
+
+// ValidateDocumentStrict validates a document and additionally treats outstanding
+// TODO items, such as placeholder captions, as errors
+func (e *Exporter) ValidateDocumentStrict(documentID string, manifest *types.Manifest) *types.ValidationReport {
+	report := e.ValidateDocument(documentID, manifest)
+
+	for _, todo := range manifest.Todos() {
+		report.Errors = append(report.Errors, fmt.Sprintf("Unresolved TODO: %s", todo.Description))
+		report.Valid = false
+	}
+
+	return report
+}
+
+// PreviewChapter renders a single chapter as a standalone HTML page or PDF
+// the way exports render it: citations are resolved against the document's
+// bibliography, with the reference list after the chapter, notes are set with
+// it, and the style is applied unless it is nil
+func (e *Exporter) PreviewChapter(ctx context.Context, documentID string, manifest *types.Manifest, chapterNum types.ChapterNumber, format types.ExportFormat, style *types.Style, pandocConfig *types.PandocConfig, rebuildFunc ChapterRebuildFunc) (string, error) {
 	// Rebuild chapter markdown from section files to ensure it's current
 	if rebuildFunc != nil {
 		if err := rebuildFunc(types.DocumentID(documentID), chapterNum); err != nil {
@@ -415,18 +877,34 @@ func (e *Exporter) PreviewChapter(documentID string, chapterNum types.ChapterNum
 		}
 	}
 
-	// Create a minimal manifest with just one chapter
-	chapterContent, err := e.loadChapterContent(documentID, int(chapterNum))
+	options := &types.ExportOptions{Format: format, Chapters: []types.ChapterNumber{chapterNum}}
+	if style != nil {
+		options.Numbering = &style.NumberingStyle
+		options.Typography = &style.Typography
+	}
+	chapterContent, err := e.GenerateMarkdown(documentID, manifest, options)
 	if err != nil {
 		return "", fmt.Errorf("failed to load chapter content: %w", err)
 	}
+	if format == types.ExportFormatPDF {
+		var svgWarnings []string
+		chapterContent, svgWarnings = e.convertSVGImages(ctx, documentID, chapterContent)
+		for _, warning := range svgWarnings {
+			log.Printf("[DOCGEN SVG] %s", warning)
+		}
+	}
+	style, _ = e.prepareFonts(ctx, documentID, style, format)
 
-	// Create temporary input file
-	tempInputFile := filepath.Join(os.TempDir(), fmt.Sprintf("%s-chapter-%d-preview.md", documentID, chapterNum))
+	workDir, err := e.newWorkDir(documentID)
+	if err != nil {
+		return "", err
+	}
+	defer os.RemoveAll(workDir)
+
+	tempInputFile := filepath.Join(workDir, fmt.Sprintf("%s-chapter-%d-preview.md", documentID, chapterNum))
 	if err := os.WriteFile(tempInputFile, []byte(chapterContent), 0644); err != nil {
 		return "", fmt.Errorf("failed to write temporary input file: %w", err)
 	}
-	defer os.Remove(tempInputFile)
 
 	// Generate output file path
 	outputFile := filepath.Join(e.config.ExportsDir, fmt.Sprintf("%s-chapter-%d-preview.%s", documentID, chapterNum, format))
@@ -434,45 +912,49 @@ func (e *Exporter) PreviewChapter(documentID string, chapterNum types.ChapterNum
 		return "", fmt.Errorf("failed to create output directory: %w", err)
 	}
 
-	// Create simple pandoc command for preview
-	args := []string{
-		tempInputFile,
-		"-o", outputFile,
-		"--standalone",
-	}
-
-	if format == types.ExportFormatPDF {
-		args = append(args, "--pdf-engine", "pdflatex")
-	}
-
-	// Resolve pandoc path
-	pandocPath, err := findPandocPath(e.config.PandocPath)
-	if err != nil {
-		return "", fmt.Errorf("pandoc not found: %w", err)
+	var tempCSSFile string
+	if format == types.ExportFormatHTML && style != nil {
+		if cssContent := generateHTMLCSS(style, manifest); cssContent != "" {
+			tempCSSFile = filepath.Join(workDir, fmt.Sprintf("%s-style.css", documentID))
+			if err := os.WriteFile(tempCSSFile, []byte(cssContent), 0644); err != nil {
+				return "", fmt.Errorf("failed to create temporary CSS file: %w", err)
+			}
+		}
 	}
 
-	cmd := exec.Command(pandocPath, args...)
-
-	// Execute with timeout
-	ctx, cancel := context.WithTimeout(context.Background(), e.config.ExportTimeout)
-	defer cancel()
+	// A single chapter has no table of contents, and its citations are listed
+	// after it whatever the scope of the document's reference lists
+	previewConfig := mergeChapterPandocOptions(pandocConfig, manifest, options.Chapters)
+	previewConfig.TOC = false
+	previewConfig.BibliographyScope = types.BibliographyScopeDocument
 
-	if err := cmd.Run(); err != nil {
-		return "", fmt.Errorf("pandoc execution failed: %w", err)
+	cmd := e.GeneratePandocCommand(ctx, documentID, tempInputFile, outputFile, manifest, style, previewConfig, options, tempCSSFile)
+	if _, err := e.runPandoc(ctx, documentID, format, cmd, tempInputFile, outputFile); err != nil {
+		return "", err
 	}
-
-	select {
-	case <-ctx.Done():
-		return "", fmt.Errorf("pandoc execution timed out")
-	default:
+	if _, err := os.Stat(outputFile); err != nil {
+		return "", fmt.Errorf("output file was not created: %s", outputFile)
 	}
 
 	return outputFile, nil
 }
 
+// chapterContentPath returns the path to a chapter's content file, in the
+// directory the manifest's chapter order gives the chapter
+func (e *Exporter) chapterContentPath(documentID string, manifest *types.Manifest, chapterNumber types.ChapterNumber) (string, error) {
+	dir, ok := manifest.ChapterDir(chapterNumber)
+	if !ok {
+		return "", fmt.Errorf("chapter %d not found in document %s", chapterNumber, documentID)
+	}
+	return e.config.ChapterContentPath(documentID, dir), nil
+}
+
 // loadChapterContent loads the content of a specific chapter
-func (e *Exporter) loadChapterContent(documentID string, chapterNumber int) (string, error) {
-	contentPath := e.config.ChapterContentPath(documentID, chapterNumber)
+func (e *Exporter) loadChapterContent(documentID string, manifest *types.Manifest, chapterNumber types.ChapterNumber) (string, error) {
+	contentPath, err := e.chapterContentPath(documentID, manifest, chapterNumber)
+	if err != nil {
+		return "", err
+	}
 	content, err := os.ReadFile(contentPath)
 	if err != nil {
 		return "", fmt.Errorf("failed to read chapter content: %w", err)
@@ -485,8 +967,48 @@ func generateYAMLMetadata(doc *types.Document, style *types.Style) string {
 	var yaml strings.Builder
 
 	yaml.WriteString(fmt.Sprintf("title: %q\n", doc.Title))
-	yaml.WriteString(fmt.Sprintf("author: %q\n", doc.Author))
-	yaml.WriteString(fmt.Sprintf("date: %q\n", time.Now().Format("2006-01-02")))
+	if doc.Subtitle != "" {
+		yaml.WriteString(fmt.Sprintf("subtitle: %q\n", doc.Subtitle))
+	}
+
+	// Pandoc renders an author list as separate names on the title page
+	// and joins them in the DOCX core properties
+	switch len(doc.Authors) {
+	case 0:
+	case 1:
+		yaml.WriteString(fmt.Sprintf("author: %q\n", doc.Authors[0].Name))
+	default:
+		yaml.WriteString("author:\n")
+		for _, name := range doc.Authors.Names() {
+			yaml.WriteString(fmt.Sprintf("  - %q\n", name))
+		}
+	}
+	if len(doc.Authors) > 0 {
+		yaml.WriteString(generateAuthorDetails(doc.Authors))
+	}
+	yaml.WriteString(fmt.Sprintf("date: %q\n", documentDate(doc)))
+	if lookupLocale(doc.Locale) != nil {
+		// Pandoc's contents heading, in every format it writes one
+		yaml.WriteString(fmt.Sprintf("toc-title: %q\n", exportLocale(doc).Contents))
+	}
+
+	// Language drives hyphenation and babel/polyglossia selection in PDF output
+	if doc.Language != "" {
+		yaml.WriteString(fmt.Sprintf("lang: %q\n", doc.Language))
+	}
+	if isRTLLanguage(doc.Language) {
+		// Sets dir="rtl" in HTML and loads bidi in the LaTeX template
+		yaml.WriteString("dir: rtl\n")
+	}
+	if len(doc.Keywords) > 0 {
+		yaml.WriteString("keywords:\n")
+		for _, keyword := range doc.Keywords {
+			yaml.WriteString(fmt.Sprintf("  - %q\n", keyword))
+		}
+	}
+	if doc.Abstract != "" {
+		yaml.WriteString(fmt.Sprintf("abstract: %q\n", doc.Abstract))
+	}
 
 	// Document class based on type
 	switch doc.Type {
@@ -516,6 +1038,45 @@ func generateYAMLMetadata(doc *types.Document, style *types.Style) string {
 	return yaml.String()
 }
 
+// documentDate returns the explicit document date, or today's date when none is set.
+// With a locale, ISO dates are written out in its format: "1er mai 2024".
+func documentDate(doc *types.Document) string {
+	locale := lookupLocale(doc.Locale)
+	if doc.Date == "" {
+		if locale != nil {
+			return locale.formatDate(time.Now())
+		}
+		return time.Now().Format("2006-01-02")
+	}
+	if locale != nil {
+		if date, err := time.Parse("2006-01-02", doc.Date); err == nil {
+			return locale.formatDate(date)
+		}
+	}
+	return doc.Date
+}
+
+// generateAuthorDetails writes the structured author list for templates that use it
+func generateAuthorDetails(authors types.AuthorList) string {
+	var yaml strings.Builder
+
+	yaml.WriteString("authors:\n")
+	for _, author := range authors {
+		yaml.WriteString(fmt.Sprintf("  - name: %q\n", author.Name))
+		if author.Affiliation != "" {
+			yaml.WriteString(fmt.Sprintf("    affiliation: %q\n", author.Affiliation))
+		}
+		if author.Email != "" {
+			yaml.WriteString(fmt.Sprintf("    email: %q\n", author.Email))
+		}
+		if author.ORCID != "" {
+			yaml.WriteString(fmt.Sprintf("    orcid: %q\n", author.ORCID))
+		}
+	}
+
+	return yaml.String()
+}
+
 // findPandocPath finds the pandoc executable using system commands
 func findPandocPath(configPath string) (string, error) {
 	// If config path is an absolute path, use it directly
@@ -566,18 +1127,18 @@ func generateLaTeXHeader(style *types.Style, manifest *types.Manifest) string {
 		
 		// Set main font (body text)
 		if style.Body.FontFamily != "" {
-			header.WriteString(fmt.Sprintf("\\setmainfont{%s}\n", style.Body.FontFamily))
+			header.WriteString(fmt.Sprintf("\\setmainfont%s\n", fontspecFont(style.Body)))
 		}
 		
 		// Set heading font
 		if style.Heading.FontFamily != "" && style.Heading.FontFamily != style.Body.FontFamily {
-			header.WriteString(fmt.Sprintf("\\newfontfamily\\headingfont{%s}\n", style.Heading.FontFamily))
+			header.WriteString(fmt.Sprintf("\\newfontfamily\\headingfont%s\n", fontspecFont(style.Heading)))
 		}
 		
 		// Set monospace font
 		if style.Monospace.FontFamily != "" {
-			header.WriteString(fmt.Sprintf("\\newfontfamily\\monospacefont{%s}\n", style.Monospace.FontFamily))
-			header.WriteString("\\setmonofont{" + style.Monospace.FontFamily + "}\n")
+			header.WriteString(fmt.Sprintf("\\newfontfamily\\monospacefont%s\n", fontspecFont(style.Monospace)))
+			header.WriteString("\\setmonofont" + fontspecFont(style.Monospace) + "\n")
 		}
 	}
 
@@ -626,31 +1187,13 @@ func generateLaTeXHeader(style *types.Style, manifest *types.Manifest) string {
 	}
 
 	// Header/Footer setup
-	if style.HeaderFooter.HeaderTemplate != "" || style.HeaderFooter.FooterTemplate != "" {
-		header.WriteString("\n% Header/Footer\n")
-		header.WriteString("\\usepackage{fancyhdr}\n")
-		header.WriteString("\\usepackage{lastpage}\n")
-		header.WriteString("\\pagestyle{fancy}\n")
-		header.WriteString("\\fancyhf{}\n")
-
-		// Process templates for LaTeX
-		vars := CreateTemplateVariables(manifest)
-		
-		if style.HeaderFooter.HeaderTemplate != "" {
-			headerContent := ProcessTemplateForPDF(style.HeaderFooter.HeaderTemplate, vars)
-			header.WriteString(fmt.Sprintf("\\fancyhead[C]{%s}\n", headerContent))
-		}
-		
-		if style.HeaderFooter.FooterTemplate != "" {
-			footerContent := ProcessTemplateForPDF(style.HeaderFooter.FooterTemplate, vars)
-			header.WriteString(fmt.Sprintf("\\fancyfoot[C]{%s}\n", footerContent))
-		}
-	}
+	header.WriteString(generateHeaderFooterHeader(style.HeaderFooter, CreateTemplateVariables(manifest)))
 
 	// Line spacing
 	if style.LineSpacing != "" && style.LineSpacing != "1" {
 		header.WriteString(fmt.Sprintf("\n%% Line spacing\n\\linespread{%s}\n", style.LineSpacing))
 	}
+	header.WriteString(generateTypographyHeader(style.Typography))
 
 	// Add any custom LaTeX header content
 	if style.LaTeXHeader != "" {
@@ -676,6 +1219,14 @@ func needsXeLaTeX(style *types.Style) bool {
 	log.Printf("[DOCGEN FONT CHECK] Heading font: '%s'\n", style.Heading.FontFamily)
 	log.Printf("[DOCGEN FONT CHECK] Monospace font: '%s'\n", style.Monospace.FontFamily)
 	
+	// Fonts bundled as files are loaded with fontspec
+	for _, font := range styleFonts(style) {
+		if font.text.FontFile != "" {
+			log.Printf("[DOCGEN FONT CHECK] %s font is loaded from %s, needs XeLaTeX\n", font.role, font.text.FontFile)
+			return true
+		}
+	}
+
 	// Check if any custom fonts are specified
 	if !contains(defaultFonts, style.Body.FontFamily) {
 		log.Printf("[DOCGEN FONT CHECK] Body font '%s' is custom, needs XeLaTeX\n", style.Body.FontFamily)
@@ -689,6 +1240,10 @@ func needsXeLaTeX(style *types.Style) bool {
 		log.Printf("[DOCGEN FONT CHECK] Monospace font '%s' is custom, needs XeLaTeX\n", style.Monospace.FontFamily)
 		return true
 	}
+	if !contains(defaultFonts, style.Epigraph.FontFamily) {
+		log.Printf("[DOCGEN FONT CHECK] Epigraph font '%s' is custom, needs XeLaTeX\n", style.Epigraph.FontFamily)
+		return true
+	}
 	
 	log.Printf("[DOCGEN FONT CHECK] All fonts are defaults, using pdflatex\n")
 	return false
@@ -735,8 +1290,18 @@ func contains(slice []string, item string) bool {
 	return false
 }
 
-// determinePDFEngine determines which PDF engine to use based on style
-func determinePDFEngine(style *types.Style, pandocConfig *types.PandocConfig) string {
+// determinePDFEngine determines which PDF engine to use based on style and document language
+func determinePDFEngine(style *types.Style, pandocConfig *types.PandocConfig, language string) string {
+	// RTL and CJK scripts cannot be typeset by pdflatex
+	if script := lookupLanguageScript(language); script != nil {
+		if pandocConfig != nil && (pandocConfig.PDFEngine == "xelatex" || (pandocConfig.PDFEngine == "lualatex" && !script.CJK)) {
+			log.Printf("[DOCGEN PDF ENGINE] Using user-specified engine: %s\n", pandocConfig.PDFEngine)
+			return pandocConfig.PDFEngine
+		}
+		log.Printf("[DOCGEN PDF ENGINE] Using XeLaTeX for language %s\n", language)
+		return "xelatex"
+	}
+
 	// User override takes precedence
 	if pandocConfig != nil && pandocConfig.PDFEngine != "" {
 		log.Printf("[DOCGEN PDF ENGINE] Using user-specified engine: %s\n", pandocConfig.PDFEngine)
@@ -917,6 +1482,9 @@ func generateHTMLCSS(style *types.Style, manifest *types.Manifest) string {
 	css.WriteString("    line-height: inherit;\n")
 	css.WriteString("}\n\n")
 	
+	css.WriteString(epigraphCSS(style.Epigraph))
+	css.WriteString(keepTogetherCSS())
+
 	// Table of Contents styling
 	css.WriteString("#TOC {\n")
 	css.WriteString("    background: #f8f9fa;\n")
@@ -961,6 +1529,16 @@ func generateHTMLCSS(style *types.Style, manifest *types.Manifest) string {
 	}
 	css.WriteString("}\n\n")
 	
+	// Wide images, code and tables shrink or scroll instead of widening the page
+	css.WriteString("img {\n")
+	css.WriteString("    max-width: 100%;\n")
+	css.WriteString("    height: auto;\n")
+	css.WriteString("}\n\n")
+	
+	css.WriteString("pre {\n")
+	css.WriteString("    overflow-x: auto;\n")
+	css.WriteString("}\n\n")
+	
 	// Responsive design for smaller screens
 	css.WriteString("@media screen and (max-width: 768px) {\n")
 	css.WriteString("    body {\n")
@@ -984,6 +1562,15 @@ func generateHTMLCSS(style *types.Style, manifest *types.Manifest) string {
 	css.WriteString("        padding: 1rem;\n")
 	css.WriteString("        margin: 1rem 0;\n")
 	css.WriteString("    }\n")
+	css.WriteString("    \n")
+	css.WriteString("    table {\n")
+	css.WriteString("        display: block;\n")
+	css.WriteString("        overflow-x: auto;\n")
+	css.WriteString("    }\n")
+	css.WriteString("    \n")
+	css.WriteString("    p {\n")
+	css.WriteString("        text-align: left;\n")
+	css.WriteString("    }\n")
 	css.WriteString("}\n\n")
 
 	// Print styles
@@ -1005,25 +1592,7 @@ func generateHTMLCSS(style *types.Style, manifest *types.Manifest) string {
 	css.WriteString("    }\n")
 	
 	// Print-specific headers/footers (if templates are specified)
-	if style.HeaderFooter.HeaderTemplate != "" || style.HeaderFooter.FooterTemplate != "" {
-		vars := CreateTemplateVariables(manifest)
-		
-		if style.HeaderFooter.HeaderTemplate != "" {
-			headerContent := ProcessTemplateForHTML(style.HeaderFooter.HeaderTemplate, vars)
-			css.WriteString("    \n")
-			css.WriteString("    @page {\n")
-			css.WriteString(fmt.Sprintf("        @top-center { content: '%s'; }\n", headerContent))
-			css.WriteString("    }\n")
-		}
-		
-		if style.HeaderFooter.FooterTemplate != "" {
-			footerContent := ProcessTemplateForHTML(style.HeaderFooter.FooterTemplate, vars)
-			css.WriteString("    \n") 
-			css.WriteString("    @page {\n")
-			css.WriteString(fmt.Sprintf("        @bottom-center { content: '%s'; }\n", footerContent))
-			css.WriteString("    }\n")
-		}
-	}
+	css.WriteString(generateHeaderFooterCSS(style.HeaderFooter, CreateTemplateVariables(manifest)))
 	
 	css.WriteString("}\n")
 
@@ -1,6 +1,8 @@
 package export
 
 import (
+	"context"
+	"fmt"
 	"os"
 	"path/filepath"
 	"strings"
@@ -30,10 +32,10 @@ func setupTestExporter(t *testing.T) (*Exporter, string) {
 
 func createTestDocument(t *testing.T, tempDir string) (*types.Document, *types.Manifest, *types.Style, *types.PandocConfig) {
 	doc := &types.Document{
-		ID:     "test-doc",
-		Title:  "Test Document",
-		Author: "Test Author",
-		Type:   types.DocumentTypeBook,
+		ID:      "test-doc",
+		Title:   "Test Document",
+		Authors: types.AuthorList{{Name: "Test Author"}},
+		Type:    types.DocumentTypeBook,
 		Chapters: []types.Chapter{
 			{
 				Number:  1,
@@ -126,7 +128,7 @@ func TestExporter_GeneratePandocCommand(t *testing.T) {
 	inputFile := filepath.Join(tempDir, "input.md")
 	outputFile := filepath.Join(tempDir, "output.pdf")
 
-	cmd := exporter.GeneratePandocCommand("test-doc", inputFile, outputFile, manifest, style, pandocConfig, options, "")
+	cmd := exporter.GeneratePandocCommand(context.Background(), "test-doc", inputFile, outputFile, manifest, style, pandocConfig, options, "")
 
 	// Check basic command structure (path might be full path to pandoc)
 	if !strings.Contains(cmd.Path, "pandoc") {
@@ -210,9 +212,9 @@ func TestExporter_ValidateDocument(t *testing.T) {
 
 func TestGenerateYAMLMetadata(t *testing.T) {
 	doc := &types.Document{
-		Title:  "Test Document",
-		Author: "Test Author",
-		Type:   types.DocumentTypeBook,
+		Title:   "Test Document",
+		Authors: types.AuthorList{{Name: "Test Author"}},
+		Type:    types.DocumentTypeBook,
 	}
 
 	style := &types.Style{
@@ -235,4 +237,207 @@ func TestGenerateYAMLMetadata(t *testing.T) {
 	if !strings.Contains(yaml, "documentclass: book") {
 		t.Errorf("YAML should contain document class")
 	}
-}
\ No newline at end of file
+}
+func TestGenerateYAMLMetadata_MultipleAuthors(t *testing.T) {
+	doc := &types.Document{
+		Title: "Test Document",
+		Authors: types.AuthorList{
+			{Name: "First Author", Affiliation: "Example University"},
+			{Name: "Second Author", ORCID: "0000-0002-1825-0097"},
+		},
+		Type: types.DocumentTypeArticle,
+	}
+
+	yaml := generateYAMLMetadata(doc, nil)
+
+	if !strings.Contains(yaml, "author:\n  - \"First Author\"\n  - \"Second Author\"\n") {
+		t.Errorf("YAML should list all authors, got:\n%s", yaml)
+	}
+
+	if !strings.Contains(yaml, `affiliation: "Example University"`) {
+		t.Errorf("YAML should contain author affiliation")
+	}
+
+	if !strings.Contains(yaml, `orcid: "0000-0002-1825-0097"`) {
+		t.Errorf("YAML should contain author ORCID")
+	}
+}
+
+func TestExporter_ValidateDocumentStrict_PlaceholderCaption(t *testing.T) {
+	exporter, tempDir := setupTestExporter(t)
+	defer os.RemoveAll(tempDir)
+
+	_, manifest, _, _ := createTestDocument(t, tempDir)
+	manifest.Document.Chapters[0].Figures = []types.Figure{
+		{ID: "fig-1.1", Caption: "TODO: caption for diagram.png", ImagePath: "diagram.png", CaptionTODO: true},
+	}
+
+	report := exporter.ValidateDocument("test-doc", manifest)
+	for _, e := range report.Errors {
+		if strings.Contains(e, "fig-1.1") {
+			t.Errorf("Non-strict validation should not fail on placeholder captions")
+		}
+	}
+
+	strict := exporter.ValidateDocumentStrict("test-doc", manifest)
+	if strict.Valid {
+		t.Errorf("Strict validation should fail on placeholder captions")
+	}
+}
+
+func TestExporter_ValidateDocument_IncludeFile(t *testing.T) {
+	exporter, tempDir := setupTestExporter(t)
+	defer os.RemoveAll(tempDir)
+
+	_, manifest, _, _ := createTestDocument(t, tempDir)
+	for _, chapter := range manifest.Document.Chapters {
+		chapterPath := filepath.Join(tempDir, "test-doc", "chapters", fmt.Sprintf("%02d", chapter.Number))
+		os.MkdirAll(chapterPath, 0755)
+		os.WriteFile(filepath.Join(chapterPath, "chapter.md"), []byte(chapter.Content), 0644)
+	}
+	os.WriteFile(filepath.Join(tempDir, "shared.md"), []byte("Shared text."), 0644)
+	manifest.Document.Chapters[0].IncludeFile = "../shared.md"
+	manifest.Document.Chapters[1].Sections = []types.Section{
+		{Number: types.SectionNumber{2, 1}, Title: "Setup", IncludeFile: "../missing.md"},
+	}
+
+	report := exporter.ValidateDocument("test-doc", manifest)
+	for _, e := range report.Errors {
+		if strings.Contains(e, "Chapter 1 include") {
+			t.Errorf("An included file that exists should not be reported: %s", e)
+		}
+	}
+	found := false
+	for _, e := range report.Errors {
+		if strings.Contains(e, "Section 2.1 include") && strings.Contains(e, "missing.md") {
+			found = true
+		}
+	}
+	if !found || report.Valid {
+		t.Errorf("Expected the missing included file to be reported, got %v", report.Errors)
+	}
+}
+
+func TestGenerateYAMLMetadata_DocumentMetadata(t *testing.T) {
+	doc := &types.Document{
+		Title:   "Test Document",
+		Authors: types.AuthorList{{Name: "Test Author"}},
+		Type:    types.DocumentTypeArticle,
+		DocumentMetadata: types.DocumentMetadata{
+			Subtitle: "A Subtitle",
+			Keywords: []string{"alpha", "beta"},
+			Abstract: "Short abstract.",
+			Language: "de-DE",
+			Date:     "2024-05-01",
+		},
+	}
+
+	yaml := generateYAMLMetadata(doc, nil)
+
+	expected := []string{
+		`subtitle: "A Subtitle"`,
+		`date: "2024-05-01"`,
+		`lang: "de-DE"`,
+		"keywords:\n  - \"alpha\"\n  - \"beta\"\n",
+		`abstract: "Short abstract."`,
+	}
+	for _, want := range expected {
+		if !strings.Contains(yaml, want) {
+			t.Errorf("YAML should contain %q, got:\n%s", want, yaml)
+		}
+	}
+}
+
+func TestExporter_GenerateMarkdown_Parts(t *testing.T) {
+	exporter, tempDir := setupTestExporter(t)
+	defer os.RemoveAll(tempDir)
+
+	doc, manifest, _, _ := createTestDocument(t, tempDir)
+	for _, chapter := range doc.Chapters {
+		chapterPath := filepath.Join(tempDir, "test-doc", "chapters", fmt.Sprintf("%02d", chapter.Number))
+		os.MkdirAll(chapterPath, 0755)
+		os.WriteFile(filepath.Join(chapterPath, "chapter.md"), []byte(chapter.Content), 0644)
+	}
+	manifest.Document.Parts = []types.Part{
+		{Title: "Background", FirstChapter: 1, LastChapter: 1},
+		{Title: "Methods & Results", FirstChapter: 2, LastChapter: 2},
+	}
+
+	markdown, err := exporter.GenerateMarkdown("test-doc", manifest, &types.ExportOptions{Format: types.ExportFormatPDF})
+	if err != nil {
+		t.Fatalf("GenerateMarkdown() error = %v", err)
+	}
+	if !strings.Contains(markdown, `\part{Background}`) || !strings.Contains(markdown, `\part{Methods \& Results}`) {
+		t.Errorf("PDF markdown should open each part with \\part, got:\n%s", markdown)
+	}
+
+	// A range starting inside the second part still gets its heading
+	markdown, err = exporter.GenerateMarkdown("test-doc", manifest, &types.ExportOptions{
+		Format:   types.ExportFormatHTML,
+		Chapters: []types.ChapterNumber{2},
+	})
+	if err != nil {
+		t.Fatalf("GenerateMarkdown() error = %v", err)
+	}
+	if !strings.Contains(markdown, "# Part II: Methods & Results {.part .unnumbered}") {
+		t.Errorf("HTML markdown should contain the part heading, got:\n%s", markdown)
+	}
+	if strings.Contains(markdown, "Background") {
+		t.Errorf("Parts without exported chapters should be left out")
+	}
+}
+
+// previewPandoc stands in for pandoc: it records its arguments and writes the output
+const previewPandoc = `#!/bin/sh
+dir=$(dirname "$0")
+echo "$@" > "$dir/args"
+while [ $# -gt 0 ]; do
+  if [ "$1" = "-o" ]; then out="$2"; fi
+  shift
+done
+echo preview > "$out"
+`
+
+func TestExporter_PreviewChapter(t *testing.T) {
+	exporter, tempDir := setupTestExporter(t)
+	defer os.RemoveAll(tempDir)
+
+	exporter.config.PandocPath = filepath.Join(tempDir, "bin", "pandoc")
+	os.MkdirAll(filepath.Dir(exporter.config.PandocPath), 0755)
+	os.WriteFile(exporter.config.PandocPath, []byte(previewPandoc), 0755)
+
+	doc, manifest, style, pandocConfig := createTestDocument(t, tempDir)
+	chapterPath := filepath.Join(tempDir, "test-doc", "chapters", "02")
+	os.MkdirAll(chapterPath, 0755)
+	os.WriteFile(filepath.Join(chapterPath, "chapter.md"), []byte(doc.Chapters[1].Content+" As shown [@knuth84].[^1]\n\n[^1]: A note."), 0644)
+	os.WriteFile(filepath.Join(tempDir, "test-doc", "refs.bib"), []byte("@book{knuth84, title={The TeXbook}}\n"), 0644)
+	pandocConfig.Bibliography = "refs.bib"
+	pandocConfig.BibliographyScope = types.BibliographyScopeChapter
+
+	outputPath, err := exporter.PreviewChapter(context.Background(), "test-doc", manifest, 2, types.ExportFormatHTML, style, pandocConfig, nil)
+	if err != nil {
+		t.Fatalf("PreviewChapter() error = %v", err)
+	}
+	if filepath.Base(outputPath) != "test-doc-chapter-2-preview.html" {
+		t.Errorf("Unexpected preview path %s", outputPath)
+	}
+	args, _ := os.ReadFile(filepath.Join(tempDir, "bin", "args"))
+	// The whole document's bibliography is cited against, and the style applied
+	for _, want := range []string{"--bibliography " + filepath.Join(tempDir, "test-doc", "refs.bib"), "--citeproc", "--css", "--standalone"} {
+		if !strings.Contains(string(args), want) {
+			t.Errorf("Expected %q in the preview arguments: %s", want, args)
+		}
+	}
+	if strings.Contains(string(args), "--toc") || strings.Contains(string(args), "--lua-filter") {
+		t.Errorf("Unexpected preview arguments: %s", args)
+	}
+
+	// Unstyled PDF previews keep pandoc's defaults
+	if _, err := exporter.PreviewChapter(context.Background(), "test-doc", manifest, 2, types.ExportFormatPDF, nil, pandocConfig, nil); err != nil {
+		t.Fatalf("PreviewChapter() error = %v", err)
+	}
+	args, _ = os.ReadFile(filepath.Join(tempDir, "bin", "args"))
+	if strings.Contains(string(args), "mainfont=") || !strings.Contains(string(args), "--pdf-engine pdflatex") {
+		t.Errorf("Expected an unstyled PDF preview: %s", args)
+	}
+}
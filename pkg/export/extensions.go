@@ -0,0 +1,72 @@
+package export
+
+import (
+	"context"
+	"fmt"
+	"os/exec"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/gomcpgo/docgen/pkg/types"
+)
+
+// PandocMarkdownExtensions asks the configured pandoc which extensions its
+// markdown reader supports. The result maps each name to whether pandoc turns
+// it on by default.
+func (e *Exporter) PandocMarkdownExtensions() (map[string]bool, error) {
+	pandocPath, err := findPandocPath(e.config.PandocPath)
+	if err != nil {
+		return nil, err
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	output, err := exec.CommandContext(ctx, pandocPath, "--list-extensions=markdown").Output()
+	if err != nil {
+		return nil, fmt.Errorf("failed to run pandoc --list-extensions: %w", err)
+	}
+
+	// Each line is an extension name after + when it is on by default or - when off
+	extensions := make(map[string]bool)
+	for _, line := range strings.Split(string(output), "\n") {
+		line = strings.TrimSpace(line)
+		if len(line) < 2 || (line[0] != '+' && line[0] != '-') {
+			continue
+		}
+		extensions[line[1:]] = line[0] == '+'
+	}
+	if len(extensions) == 0 {
+		return nil, fmt.Errorf("pandoc --list-extensions printed no extensions")
+	}
+	return extensions, nil
+}
+
+// CheckMarkdownExtensions returns an error naming the extensions the installed
+// pandoc's markdown reader doesn't support. When pandoc can't be asked, only the
+// extensions of the markdown profile's own fields are accepted.
+func (e *Exporter) CheckMarkdownExtensions(names []string) error {
+	supported, probeErr := e.PandocMarkdownExtensions()
+	if probeErr != nil {
+		supported = make(map[string]bool)
+		for _, name := range types.MarkdownExtensions {
+			supported[name] = true
+		}
+	}
+
+	var unknown []string
+	for _, name := range names {
+		if _, ok := supported[name]; !ok {
+			unknown = append(unknown, name)
+		}
+	}
+	if len(unknown) == 0 {
+		return nil
+	}
+	sort.Strings(unknown)
+	if probeErr != nil {
+		return fmt.Errorf("cannot check markdown extensions %s against pandoc: %v", strings.Join(unknown, ", "), probeErr)
+	}
+	return fmt.Errorf("pandoc's markdown reader does not support %s (see pandoc --list-extensions=markdown)", strings.Join(unknown, ", "))
+}
@@ -0,0 +1,46 @@
+package export
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// extensionsPandoc lists a few markdown extensions the way pandoc does
+const extensionsPandoc = `#!/bin/sh
+printf '+definition_lists\n-mark\n+smart\n'
+`
+
+func TestExporter_CheckMarkdownExtensions(t *testing.T) {
+	exporter, tempDir := setupTestExporter(t)
+	defer os.RemoveAll(tempDir)
+	exporter.config.PandocPath = filepath.Join(tempDir, "pandoc")
+	if err := os.WriteFile(exporter.config.PandocPath, []byte(extensionsPandoc), 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	extensions, err := exporter.PandocMarkdownExtensions()
+	if err != nil {
+		t.Fatalf("PandocMarkdownExtensions() error = %v", err)
+	}
+	if len(extensions) != 3 || !extensions["smart"] || extensions["mark"] {
+		t.Errorf("Unexpected extensions %v", extensions)
+	}
+
+	if err := exporter.CheckMarkdownExtensions([]string{"mark", "definition_lists"}); err != nil {
+		t.Errorf("CheckMarkdownExtensions() error = %v", err)
+	}
+	if err := exporter.CheckMarkdownExtensions([]string{"smart", "wikilinks", "emoji"}); err == nil || !strings.Contains(err.Error(), "does not support emoji, wikilinks") {
+		t.Errorf("CheckMarkdownExtensions() error = %v, want the unsupported extensions named", err)
+	}
+
+	// Without pandoc, only the profile's own extensions are accepted
+	exporter.config.PandocPath = filepath.Join(tempDir, "missing-pandoc")
+	if err := exporter.CheckMarkdownExtensions([]string{"task_lists"}); err != nil {
+		t.Errorf("CheckMarkdownExtensions() error = %v", err)
+	}
+	if err := exporter.CheckMarkdownExtensions([]string{"mark"}); err == nil || !strings.Contains(err.Error(), "cannot check") {
+		t.Errorf("CheckMarkdownExtensions() error = %v, want it to say pandoc couldn't be asked", err)
+	}
+}
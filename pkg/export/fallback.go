@@ -0,0 +1,67 @@
+package export
+
+import (
+	"os/exec"
+	"strings"
+)
+
+// engineFailures maps telltale pandoc/LaTeX errors to the reason they are
+// reported with. Each means the document needs a different PDF engine rather
+// than a change to its content.
+var engineFailures = []struct {
+	pattern string
+	reason  string
+}{
+	{"fontspec Error", "fontspec requires XeLaTeX or LuaLaTeX"},
+	{"requires either XeTeX or LuaTeX", "fontspec requires XeLaTeX or LuaLaTeX"},
+	{"not set up for use with LaTeX", "Unicode characters pdflatex cannot typeset"},
+	{"Package inputenc Error", "Unicode characters pdflatex cannot typeset"},
+	{"Please select a different --pdf-engine", "PDF engine is not installed"},
+	{"luaotfload", "LuaLaTeX font loading failed"},
+	{"xdvipdfmx:fatal", "XeLaTeX output driver failed"},
+}
+
+// engineFallbacks lists, for each PDF engine, the engines to try instead in order of preference
+var engineFallbacks = map[string][]string{
+	"pdflatex": {"xelatex", "lualatex", "tectonic"},
+	"xelatex":  {"lualatex", "tectonic"},
+	"lualatex": {"xelatex", "tectonic"},
+	"tectonic": {"xelatex", "lualatex"},
+}
+
+// engineFailure returns why a failed PDF export is down to its engine, or ""
+// when the failure has another cause
+func engineFailure(stderr string) string {
+	for _, failure := range engineFailures {
+		if strings.Contains(stderr, failure.pattern) {
+			return failure.reason
+		}
+	}
+	return ""
+}
+
+// fallbackEngine returns the first installed engine to use instead of current, or ""
+func fallbackEngine(current string, available func(engine string) bool) string {
+	for _, engine := range engineFallbacks[current] {
+		if available(engine) {
+			return engine
+		}
+	}
+	return ""
+}
+
+// engineAvailable reports whether a PDF engine is on the PATH
+func engineAvailable(engine string) bool {
+	_, err := exec.LookPath(engine)
+	return err == nil
+}
+
+// pdfEngineArg returns the --pdf-engine value of a pandoc command, or ""
+func pdfEngineArg(args []string) string {
+	for i := 1; i < len(args); i++ {
+		if args[i-1] == "--pdf-engine" {
+			return args[i]
+		}
+	}
+	return ""
+}
@@ -0,0 +1,79 @@
+package export
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/gomcpgo/docgen/pkg/types"
+)
+
+// fontspecPandoc is a pandoc stand-in that fails under pdflatex the way fontspec does
+const fontspecPandoc = `#!/bin/sh
+for arg in "$@"; do
+  if [ "$arg" = "pdflatex" ]; then
+    echo "! Fatal Package fontspec Error: The fontspec package requires either XeTeX or LuaTeX." >&2
+    exit 43
+  fi
+done
+while [ $# -gt 0 ]; do
+  if [ "$1" = "-o" ]; then : > "$2"; fi
+  shift
+done
+`
+
+func TestFallbackEngine(t *testing.T) {
+	if reason := engineFailure("! Fatal Package fontspec Error: The fontspec package requires either XeTeX or LuaTeX."); reason == "" {
+		t.Error("Expected a fontspec failure to be engine-specific")
+	}
+	if reason := engineFailure("! Undefined control sequence."); reason != "" {
+		t.Errorf("Did not expect a content error to be engine-specific, got %q", reason)
+	}
+
+	installed := map[string]bool{"lualatex": true}
+	available := func(engine string) bool { return installed[engine] }
+	if engine := fallbackEngine("pdflatex", available); engine != "lualatex" {
+		t.Errorf("Expected lualatex, got %q", engine)
+	}
+	if engine := fallbackEngine("lualatex", available); engine != "" {
+		t.Errorf("Expected no fallback, got %q", engine)
+	}
+}
+
+func TestExporter_ExportDocumentEngineFallback(t *testing.T) {
+	exporter, tempDir := setupTestExporter(t)
+	defer os.RemoveAll(tempDir)
+
+	binDir := filepath.Join(tempDir, "bin")
+	os.MkdirAll(binDir, 0755)
+	exporter.config.PandocPath = filepath.Join(binDir, "pandoc")
+	if err := os.WriteFile(exporter.config.PandocPath, []byte(fontspecPandoc), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(binDir, "xelatex"), []byte("#!/bin/sh\n"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("PATH", binDir)
+
+	doc, manifest, style, _ := createTestDocument(t, tempDir)
+	for _, chapter := range doc.Chapters {
+		chapterPath := filepath.Join(tempDir, "test-doc", "chapters", fmt.Sprintf("%02d", chapter.Number))
+		os.MkdirAll(chapterPath, 0755)
+		os.WriteFile(filepath.Join(chapterPath, "chapter.md"), []byte(chapter.Content), 0644)
+	}
+	os.WriteFile(filepath.Join(tempDir, "test-doc", "manifest.yaml"), []byte("document: {}\n"), 0644)
+
+	pandocConfig := &types.PandocConfig{PDFEngine: "pdflatex"}
+	result, err := exporter.ExportDocumentResult(context.Background(), "test-doc", manifest, style, pandocConfig, &types.ExportOptions{Format: types.ExportFormatPDF}, nil)
+	if err != nil {
+		t.Fatalf("ExportDocumentResult() error = %v", err)
+	}
+	if result.PDFEngine != "xelatex" || result.FallbackFrom != "pdflatex" || result.FallbackReason == "" {
+		t.Errorf("Unexpected result %+v", result)
+	}
+	if pandocConfig.PDFEngine != "pdflatex" {
+		t.Errorf("The document's pandoc config should be left alone")
+	}
+}
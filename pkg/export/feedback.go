@@ -0,0 +1,568 @@
+package export
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"os/exec"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/gomcpgo/docgen/pkg/types"
+)
+
+// maxFeedbackContext is the longest paragraph context kept for a feedback item
+const maxFeedbackContext = 300
+
+// docxHeadingStylePattern matches the style IDs of Word's heading paragraphs
+var docxHeadingStylePattern = regexp.MustCompile(`(?i)^heading\s*([1-6])$`)
+
+// feedbackHeadingPattern matches an ATX heading in pandoc's markdown, with the
+// attributes pandoc adds after its text
+var feedbackHeadingPattern = regexp.MustCompile(`^(#{1,6})\s+(.*?)(?:\s+\{[^}]*\})?\s*$`)
+
+// docxParagraph is a paragraph of a DOCX body with its tracked changes
+type docxParagraph struct {
+	// level is the heading level, or 0 for other paragraphs
+	level int
+	// original is the text before the changes, accepted the text after them
+	original string
+	accepted string
+	changes  []types.FeedbackItem
+}
+
+// docxComment is a comment from word/comments.xml
+type docxComment struct {
+	id     string
+	author string
+	date   string
+	text   string
+}
+
+// headingLocator follows the headings of an exported document to tell which
+// chapter and section the text after them belongs to
+type headingLocator struct {
+	chapters []types.Chapter
+	chapter  *types.Chapter
+	section  int
+	matched  bool
+}
+
+func newHeadingLocator(manifest *types.Manifest) *headingLocator {
+	return &headingLocator{chapters: manifest.Document.Chapters, section: -1}
+}
+
+// heading moves to the chapter or section whose title ends the heading's
+// text, whatever label or numbering the export put before it, and reports
+// whether it did. Chapter headings that match no chapter leave every chapter;
+// other headings that match no section are part of the current section.
+func (l *headingLocator) heading(level int, text string) bool {
+	text = normalizeHeadingText(text)
+	if level == 1 {
+		l.chapter, l.section = nil, -1
+		best := 0
+		for i := range l.chapters {
+			title := normalizeHeadingText(l.chapters[i].Title)
+			if title != "" && len(title) > best && strings.HasSuffix(text, title) {
+				l.chapter, best = &l.chapters[i], len(title)
+			}
+		}
+		if l.chapter != nil {
+			l.matched = true
+		}
+		return l.chapter != nil
+	}
+	if l.chapter == nil {
+		return false
+	}
+
+	// Sections come in order, so titles used twice match the next one first
+	sections := l.chapter.Sections
+	for k := 1; k <= len(sections); k++ {
+		i := (l.section + k) % len(sections)
+		if i < 0 {
+			i += len(sections)
+		}
+		title := normalizeHeadingText(sections[i].Title)
+		if sections[i].Level+1 == level && title != "" && strings.HasSuffix(text, title) {
+			l.section = i
+			return true
+		}
+	}
+	return false
+}
+
+// location returns the current chapter and section number
+func (l *headingLocator) location() (types.ChapterNumber, string) {
+	if l.chapter == nil {
+		return 0, ""
+	}
+	if l.section < 0 {
+		return l.chapter.Number, ""
+	}
+	return l.chapter.Number, l.chapter.Sections[l.section].Number.String()
+}
+
+// currentSection returns the current section, or nil in a chapter's opening
+func (l *headingLocator) currentSection() *types.Section {
+	if l.chapter == nil || l.section < 0 {
+		return nil
+	}
+	return &l.chapter.Sections[l.section]
+}
+
+// normalizeHeadingText lowercases a heading and collapses its whitespace and
+// markdown escapes, so headings compare the same in Word and in pandoc's output
+func normalizeHeadingText(text string) string {
+	text = strings.ReplaceAll(text, "\\", "")
+	return strings.ToLower(strings.Join(strings.Fields(text), " "))
+}
+
+// ReadDOCXFeedback reads the tracked changes and comments of a DOCX exported
+// from the document and edited by a reviewer, placing each in the chapter and
+// section whose heading it follows. When pandoc is installed, every section
+// with tracked changes is also proposed as it reads with the changes accepted.
+func (e *Exporter) ReadDOCXFeedback(ctx context.Context, docxPath string, manifest *types.Manifest, profile *types.MarkdownProfile) (*types.DocxFeedback, error) {
+	archive, err := zip.OpenReader(docxPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open DOCX: %w", err)
+	}
+	defer archive.Close()
+
+	documentXML, err := readZipFile(&archive.Reader, "word/document.xml", e.config.MaxFileSize)
+	if err != nil {
+		return nil, err
+	}
+	if documentXML == nil {
+		return nil, fmt.Errorf("%s is not a DOCX: it has no word/document.xml", docxPath)
+	}
+	paragraphs, commentParagraphs, commentedText, err := parseDOCXBody(documentXML)
+	if err != nil {
+		return nil, err
+	}
+
+	var comments []docxComment
+	commentsXML, err := readZipFile(&archive.Reader, "word/comments.xml", e.config.MaxFileSize)
+	if err != nil {
+		return nil, err
+	}
+	if commentsXML != nil {
+		if comments, err = parseDOCXComments(commentsXML); err != nil {
+			return nil, err
+		}
+	}
+
+	feedback := &types.DocxFeedback{Path: docxPath, Items: []types.FeedbackItem{}}
+	if coreXML, err := readZipFile(&archive.Reader, "docProps/core.xml", e.config.MaxFileSize); err == nil && coreXML != nil {
+		if title := docxCoreTitle(coreXML); title != "" && normalizeHeadingText(title) != normalizeHeadingText(manifest.Document.Title) {
+			feedback.Warnings = append(feedback.Warnings, fmt.Sprintf("the DOCX is titled %q, not %q; it may not have been exported from this document", title, manifest.Document.Title))
+		}
+	}
+
+	// Comments are listed after the changes of the paragraph they start in,
+	// and those on no paragraph at the end
+	commentsByParagraph := make(map[int][]docxComment)
+	var loose []docxComment
+	for _, comment := range comments {
+		comment.text = strings.TrimSpace(comment.text)
+		if i, ok := commentParagraphs[comment.id]; ok && i >= 0 {
+			commentsByParagraph[i] = append(commentsByParagraph[i], comment)
+		} else {
+			loose = append(loose, comment)
+		}
+	}
+
+	locator := newHeadingLocator(manifest)
+	changed := make(map[string]int)
+	for i, paragraph := range paragraphs {
+		if paragraph.level > 0 {
+			locator.heading(paragraph.level, paragraph.original)
+		}
+		chapter, section := locator.location()
+		paragraphContext := truncateFeedbackContext(paragraph.accepted)
+		for _, change := range paragraph.changes {
+			change.Chapter, change.Section, change.Context = chapter, section, paragraphContext
+			feedback.Items = append(feedback.Items, change)
+			if section != "" {
+				changed[section]++
+			}
+		}
+		for _, comment := range commentsByParagraph[i] {
+			feedback.Items = append(feedback.Items, types.FeedbackItem{
+				Kind:    types.FeedbackComment,
+				Author:  comment.author,
+				Date:    comment.date,
+				Chapter: chapter,
+				Section: section,
+				Text:    collapseFeedbackText(commentedText[comment.id]),
+				Comment: comment.text,
+				Context: paragraphContext,
+			})
+		}
+	}
+	for _, comment := range loose {
+		feedback.Items = append(feedback.Items, types.FeedbackItem{Kind: types.FeedbackComment, Author: comment.author, Date: comment.date, Comment: comment.text})
+	}
+	for _, item := range feedback.Items {
+		if item.Chapter == 0 {
+			feedback.Unmapped++
+		}
+	}
+	if !locator.matched && len(paragraphs) > 0 {
+		feedback.Warnings = append(feedback.Warnings, "no heading in the DOCX matches a chapter of the document, so its feedback could not be placed")
+	}
+
+	if len(changed) == 0 {
+		return feedback, nil
+	}
+	pandocPath, err := findPandocPath(e.config.PandocPath)
+	if err != nil {
+		feedback.Warnings = append(feedback.Warnings, "pandoc is not installed, so no section updates are proposed")
+		return feedback, nil
+	}
+	sections, err := e.acceptedSections(ctx, pandocPath, docxPath, manifest, profile)
+	if err != nil {
+		feedback.Warnings = append(feedback.Warnings, fmt.Sprintf("no section updates are proposed: %v", err))
+		return feedback, nil
+	}
+	for _, proposal := range sections {
+		if changes := changed[proposal.Section]; changes > 0 {
+			proposal.Changes = changes
+			feedback.Proposals = append(feedback.Proposals, proposal)
+		}
+	}
+	return feedback, nil
+}
+
+// acceptedSections converts the DOCX to markdown with pandoc, accepting its
+// tracked changes, and splits it at the headings of the document's sections
+func (e *Exporter) acceptedSections(ctx context.Context, pandocPath, docxPath string, manifest *types.Manifest, profile *types.MarkdownProfile) ([]types.SectionProposal, error) {
+	ctx, cancel := context.WithTimeout(ctx, e.config.ExportTimeout)
+	defer cancel()
+
+	cmd := exec.CommandContext(ctx, pandocPath, docxPath, "--from", "docx", "--to", profile.PandocFormat(), "--track-changes=accept", "--wrap=none")
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	output, err := cmd.Output()
+	if err != nil {
+		return nil, fmt.Errorf("pandoc execution failed: %w: %s", err, strings.TrimSpace(stderr.String()))
+	}
+
+	var proposals []types.SectionProposal
+	var lines []string
+	current := -1
+	flush := func() {
+		if current >= 0 {
+			proposals[current].Content = strings.TrimSpace(strings.Join(lines, "\n"))
+		}
+		lines = nil
+	}
+
+	locator := newHeadingLocator(manifest)
+	fence := ""
+	for _, line := range strings.Split(string(output), "\n") {
+		trimmed := strings.TrimSpace(line)
+		if fence != "" {
+			if strings.HasPrefix(trimmed, fence) {
+				fence = ""
+			}
+			lines = append(lines, line)
+			continue
+		}
+		if strings.HasPrefix(trimmed, "```") || strings.HasPrefix(trimmed, "~~~") {
+			fence = trimmed[:3]
+			lines = append(lines, line)
+			continue
+		}
+
+		match := feedbackHeadingPattern.FindStringSubmatch(line)
+		if match == nil || !(locator.heading(len(match[1]), match[2]) || len(match[1]) == 1) {
+			lines = append(lines, line)
+			continue
+		}
+		flush()
+		current = -1
+		if section := locator.currentSection(); section != nil {
+			chapter, number := locator.location()
+			proposals = append(proposals, types.SectionProposal{Chapter: chapter, Section: number, Title: section.Title})
+			current = len(proposals) - 1
+		}
+	}
+	flush()
+	return proposals, nil
+}
+
+// parseDOCXBody reads the paragraphs of word/document.xml with their tracked
+// changes, and for each comment the paragraph it starts in and the text it is on
+func parseDOCXBody(data []byte) ([]docxParagraph, map[string]int, map[string]string, error) {
+	decoder := xml.NewDecoder(bytes.NewReader(data))
+	var paragraphs []docxParagraph
+	commentParagraphs := make(map[string]int)
+	commentedText := make(map[string]string)
+	open := make(map[string]*strings.Builder)
+
+	current := -1
+	depth := 0
+	skip := 0
+	inText := false
+	var change *types.FeedbackItem
+	var changeText strings.Builder
+
+	write := func(text string, deleted bool) {
+		if current < 0 {
+			return
+		}
+		if change != nil {
+			changeText.WriteString(text)
+			deleted = change.Kind == types.FeedbackDeletion
+		}
+		paragraph := &paragraphs[current]
+		if change == nil || deleted {
+			paragraph.original += text
+		}
+		if !deleted {
+			paragraph.accepted += text
+			for _, anchored := range open {
+				anchored.WriteString(text)
+			}
+		}
+	}
+
+	for {
+		token, err := decoder.Token()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, nil, nil, fmt.Errorf("failed to parse word/document.xml: %w", err)
+		}
+
+		switch t := token.(type) {
+		case xml.StartElement:
+			// Formatting changes hold the properties as they were, which
+			// aren't the paragraph's
+			if skip > 0 || strings.HasSuffix(t.Name.Local, "PrChange") {
+				skip++
+				continue
+			}
+			switch t.Name.Local {
+			case "p":
+				depth++
+				if depth == 1 {
+					paragraphs = append(paragraphs, docxParagraph{})
+					current = len(paragraphs) - 1
+				}
+			case "pStyle":
+				if match := docxHeadingStylePattern.FindStringSubmatch(xmlAttr(t, "val")); match != nil && current >= 0 && depth == 1 {
+					paragraphs[current].level, _ = strconv.Atoi(match[1])
+				}
+			case "ins", "moveTo", "del", "moveFrom":
+				kind := types.FeedbackInsertion
+				if t.Name.Local == "del" || t.Name.Local == "moveFrom" {
+					kind = types.FeedbackDeletion
+				}
+				change = &types.FeedbackItem{Kind: kind, Author: xmlAttr(t, "author"), Date: xmlAttr(t, "date")}
+				changeText.Reset()
+			case "t", "delText":
+				inText = true
+			case "tab", "br", "cr":
+				write(" ", false)
+			case "commentRangeStart":
+				id := xmlAttr(t, "id")
+				open[id] = &strings.Builder{}
+				commentParagraphs[id] = current
+			case "commentRangeEnd":
+				id := xmlAttr(t, "id")
+				if anchored, ok := open[id]; ok {
+					commentedText[id] = anchored.String()
+					delete(open, id)
+				}
+			case "commentReference":
+				if id := xmlAttr(t, "id"); id != "" {
+					if _, ok := commentParagraphs[id]; !ok {
+						commentParagraphs[id] = current
+					}
+				}
+			}
+		case xml.EndElement:
+			if skip > 0 {
+				skip--
+				continue
+			}
+			switch t.Name.Local {
+			case "p":
+				depth--
+			case "t", "delText":
+				inText = false
+			case "ins", "moveTo", "del", "moveFrom":
+				// Changes to a paragraph mark alone have no text to report
+				if change != nil && current >= 0 {
+					if text := collapseFeedbackText(changeText.String()); text != "" {
+						change.Text = text
+						paragraphs[current].changes = append(paragraphs[current].changes, *change)
+					}
+				}
+				change = nil
+			}
+		case xml.CharData:
+			if inText && skip == 0 {
+				write(string(t), false)
+			}
+		}
+	}
+
+	// Comments still open at the end of the body run to its end
+	for id, anchored := range open {
+		commentedText[id] = anchored.String()
+	}
+	for i := range paragraphs {
+		paragraphs[i].original = collapseFeedbackText(paragraphs[i].original)
+		paragraphs[i].accepted = collapseFeedbackText(paragraphs[i].accepted)
+	}
+	return paragraphs, commentParagraphs, commentedText, nil
+}
+
+// parseDOCXComments reads the comments of word/comments.xml in order of their IDs
+func parseDOCXComments(data []byte) ([]docxComment, error) {
+	decoder := xml.NewDecoder(bytes.NewReader(data))
+	var comments []docxComment
+	var current *docxComment
+	var text strings.Builder
+	inText := false
+
+	for {
+		token, err := decoder.Token()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse word/comments.xml: %w", err)
+		}
+
+		switch t := token.(type) {
+		case xml.StartElement:
+			switch t.Name.Local {
+			case "comment":
+				current = &docxComment{id: xmlAttr(t, "id"), author: xmlAttr(t, "author"), date: xmlAttr(t, "date")}
+				text.Reset()
+			case "t":
+				inText = true
+			case "tab", "br":
+				text.WriteString(" ")
+			}
+		case xml.EndElement:
+			switch t.Name.Local {
+			case "t":
+				inText = false
+			case "p":
+				text.WriteString("\n")
+			case "comment":
+				if current != nil {
+					current.text = text.String()
+					comments = append(comments, *current)
+					current = nil
+				}
+			}
+		case xml.CharData:
+			if inText && current != nil {
+				text.Write(t)
+			}
+		}
+	}
+
+	sort.SliceStable(comments, func(i, j int) bool {
+		a, errA := strconv.Atoi(comments[i].id)
+		b, errB := strconv.Atoi(comments[j].id)
+		return errA == nil && errB == nil && a < b
+	})
+	return comments, nil
+}
+
+// docxCoreTitle returns the title in a DOCX's docProps/core.xml
+func docxCoreTitle(data []byte) string {
+	decoder := xml.NewDecoder(bytes.NewReader(data))
+	inTitle := false
+	var title strings.Builder
+	for {
+		token, err := decoder.Token()
+		if err != nil {
+			break
+		}
+		switch t := token.(type) {
+		case xml.StartElement:
+			inTitle = t.Name.Local == "title"
+		case xml.EndElement:
+			if t.Name.Local == "title" {
+				return strings.TrimSpace(title.String())
+			}
+			inTitle = false
+		case xml.CharData:
+			if inTitle {
+				title.Write(t)
+			}
+		}
+	}
+	return ""
+}
+
+// readZipFile returns the content of a file in a zip archive, or nil when the
+// archive has no such file. A file larger than maxSize is refused, whatever
+// size the archive claims for it, unless maxSize is zero or less.
+func readZipFile(archive *zip.Reader, name string, maxSize int64) ([]byte, error) {
+	for _, file := range archive.File {
+		if file.Name != name {
+			continue
+		}
+		if maxSize > 0 && file.UncompressedSize64 > uint64(maxSize) {
+			return nil, fmt.Errorf("%s is larger than %d bytes", name, maxSize)
+		}
+		reader, err := file.Open()
+		if err != nil {
+			return nil, fmt.Errorf("failed to open %s: %w", name, err)
+		}
+		defer reader.Close()
+		var content io.Reader = reader
+		if maxSize > 0 {
+			content = io.LimitReader(reader, maxSize+1)
+		}
+		data, err := io.ReadAll(content)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read %s: %w", name, err)
+		}
+		if maxSize > 0 && int64(len(data)) > maxSize {
+			return nil, fmt.Errorf("%s is larger than %d bytes", name, maxSize)
+		}
+		return data, nil
+	}
+	return nil, nil
+}
+
+// xmlAttr returns the value of an element's attribute, whatever its namespace
+func xmlAttr(element xml.StartElement, name string) string {
+	for _, attr := range element.Attr {
+		if attr.Name.Local == name {
+			return attr.Value
+		}
+	}
+	return ""
+}
+
+// collapseFeedbackText trims text and collapses its runs of whitespace
+func collapseFeedbackText(text string) string {
+	return strings.Join(strings.Fields(text), " ")
+}
+
+// truncateFeedbackContext shortens a paragraph kept as context to
+// maxFeedbackContext characters
+func truncateFeedbackContext(text string) string {
+	runes := []rune(text)
+	if len(runes) <= maxFeedbackContext {
+		return text
+	}
+	return strings.TrimSpace(string(runes[:maxFeedbackContext])) + "…"
+}
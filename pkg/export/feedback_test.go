@@ -0,0 +1,156 @@
+package export
+
+import (
+	"archive/zip"
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/gomcpgo/docgen/pkg/types"
+)
+
+// writeTestDOCX writes a DOCX with the given body and comments
+func writeTestDOCX(t *testing.T, path, body, comments string) {
+	t.Helper()
+	file, err := os.Create(path)
+	if err != nil {
+		t.Fatalf("Failed to create DOCX: %v", err)
+	}
+	defer file.Close()
+
+	const ns = `xmlns:w="http://schemas.openxmlformats.org/wordprocessingml/2006/main"`
+	parts := map[string]string{
+		"word/document.xml": `<?xml version="1.0" encoding="UTF-8"?><w:document ` + ns + `><w:body>` + body + `</w:body></w:document>`,
+		"docProps/core.xml": `<?xml version="1.0" encoding="UTF-8"?><cp:coreProperties xmlns:cp="http://schemas.openxmlformats.org/package/2006/metadata/core-properties" xmlns:dc="http://purl.org/dc/elements/1.1/"><dc:title>Test Document</dc:title></cp:coreProperties>`,
+	}
+	if comments != "" {
+		parts["word/comments.xml"] = `<?xml version="1.0" encoding="UTF-8"?><w:comments ` + ns + `>` + comments + `</w:comments>`
+	}
+	writer := zip.NewWriter(file)
+	for name, content := range parts {
+		part, err := writer.Create(name)
+		if err != nil {
+			t.Fatalf("Failed to add %s: %v", name, err)
+		}
+		part.Write([]byte(content))
+	}
+	if err := writer.Close(); err != nil {
+		t.Fatalf("Failed to write DOCX: %v", err)
+	}
+}
+
+func TestExporter_ReadDOCXFeedback(t *testing.T) {
+	exporter, tempDir := setupTestExporter(t)
+	defer os.RemoveAll(tempDir)
+	exporter.config.PandocPath = filepath.Join(tempDir, "missing", "pandoc")
+
+	heading := func(level, text string) string {
+		return `<w:p><w:pPr><w:pStyle w:val="Heading` + level + `"/></w:pPr><w:r><w:t>` + text + `</w:t></w:r></w:p>`
+	}
+	body := `<w:p><w:pPr><w:pStyle w:val="Title"/></w:pPr><w:r><w:t>Test Document</w:t></w:r></w:p>` +
+		heading("1", "Chapter 1: Introduction") +
+		`<w:p><w:r><w:t xml:space="preserve">The opening </w:t></w:r><w:ins w:id="1" w:author="Ada" w:date="2026-10-01T10:00:00Z"><w:r><w:t>short </w:t></w:r></w:ins><w:r><w:t>paragraph.</w:t></w:r></w:p>` +
+		heading("2", "1.1 Background") +
+		`<w:p><w:commentRangeStart w:id="0"/><w:r><w:t>Prior work</w:t></w:r><w:commentRangeEnd w:id="0"/><w:r><w:commentReference w:id="0"/></w:r>` +
+		`<w:r><w:t xml:space="preserve"> is </w:t></w:r><w:del w:id="2" w:author="Grace"><w:r><w:delText>very </w:delText></w:r></w:del><w:r><w:t>limited.</w:t></w:r></w:p>` +
+		heading("3", "Notes") +
+		`<w:p><w:pPr><w:rPr><w:ins w:id="3" w:author="Ada"/></w:rPr></w:pPr><w:r><w:rPr><w:rPrChange w:id="4" w:author="Ada"><w:rPr><w:b/></w:rPr></w:rPrChange></w:rPr><w:t>Still background.</w:t></w:r><w:ins w:id="5" w:author="Ada"><w:r><w:t xml:space="preserve"> Added.</w:t></w:r></w:ins></w:p>` +
+		heading("1", "References") +
+		`<w:p><w:ins w:id="6" w:author="Ada"><w:r><w:t>Smith 2020.</w:t></w:r></w:ins></w:p>`
+	comments := `<w:comment w:id="0" w:author="Grace" w:date="2026-10-02T09:00:00Z"><w:p><w:r><w:t>Which work?</w:t></w:r></w:p><w:p><w:r><w:t>Add citations.</w:t></w:r></w:p></w:comment>` +
+		`<w:comment w:id="9" w:author="Ada"><w:p><w:r><w:t>General remark.</w:t></w:r></w:p></w:comment>`
+	docxPath := filepath.Join(tempDir, "reviewed.docx")
+	writeTestDOCX(t, docxPath, body, comments)
+
+	manifest := &types.Manifest{Document: types.Document{Title: "Test Document", Chapters: []types.Chapter{{
+		Number:   1,
+		Title:    "Introduction",
+		Sections: []types.Section{{Number: types.SectionNumber{1, 1}, Title: "Background", Level: 1}},
+	}}}}
+
+	feedback, err := exporter.ReadDOCXFeedback(context.Background(), docxPath, manifest, nil)
+	if err != nil {
+		t.Fatalf("ReadDOCXFeedback() error = %v", err)
+	}
+	want := []types.FeedbackItem{
+		{Kind: types.FeedbackInsertion, Author: "Ada", Date: "2026-10-01T10:00:00Z", Chapter: 1, Text: "short", Context: "The opening short paragraph."},
+		{Kind: types.FeedbackDeletion, Author: "Grace", Chapter: 1, Section: "1.1", Text: "very", Context: "Prior work is limited."},
+		{Kind: types.FeedbackComment, Author: "Grace", Date: "2026-10-02T09:00:00Z", Chapter: 1, Section: "1.1", Text: "Prior work", Comment: "Which work?\nAdd citations.", Context: "Prior work is limited."},
+		{Kind: types.FeedbackInsertion, Author: "Ada", Chapter: 1, Section: "1.1", Text: "Added.", Context: "Still background. Added."},
+		{Kind: types.FeedbackInsertion, Author: "Ada", Text: "Smith 2020.", Context: "Smith 2020."},
+		{Kind: types.FeedbackComment, Author: "Ada", Comment: "General remark."},
+	}
+	if len(feedback.Items) != len(want) {
+		t.Fatalf("Expected %d items, got %+v", len(want), feedback.Items)
+	}
+	for i := range want {
+		if feedback.Items[i] != want[i] {
+			t.Errorf("Item %d = %+v, want %+v", i, feedback.Items[i], want[i])
+		}
+	}
+	if feedback.Unmapped != 2 {
+		t.Errorf("Unmapped = %d, want 2", feedback.Unmapped)
+	}
+	if len(feedback.Proposals) != 0 || len(feedback.Warnings) != 1 || !strings.Contains(feedback.Warnings[0], "pandoc is not installed") {
+		t.Errorf("Expected only a warning that no updates are proposed without pandoc, got %+v %q", feedback.Proposals, feedback.Warnings)
+	}
+
+	other := *manifest
+	other.Document.Title = "Another Document"
+	other.Document.Chapters = []types.Chapter{{Number: 1, Title: "Results"}}
+	feedback, err = exporter.ReadDOCXFeedback(context.Background(), docxPath, &other, nil)
+	if err != nil {
+		t.Fatalf("ReadDOCXFeedback() error = %v", err)
+	}
+	if feedback.Unmapped != len(feedback.Items) || len(feedback.Warnings) < 2 {
+		t.Errorf("Expected unplaced feedback and warnings for another document, got %d of %d unmapped, %q", feedback.Unmapped, len(feedback.Items), feedback.Warnings)
+	}
+
+	// Parts larger than the maximum file size aren't read
+	exporter.config.MaxFileSize = int64(len(body)) / 2
+	if _, err := exporter.ReadDOCXFeedback(context.Background(), docxPath, manifest, nil); err == nil || !strings.Contains(err.Error(), "word/document.xml is larger than") {
+		t.Errorf("Expected an error for an oversized document.xml, got %v", err)
+	}
+
+	os.WriteFile(filepath.Join(tempDir, "notes.docx"), []byte("not a zip"), 0644)
+	if _, err := exporter.ReadDOCXFeedback(context.Background(), filepath.Join(tempDir, "notes.docx"), manifest, nil); err == nil {
+		t.Error("Expected an error for a file that isn't a DOCX")
+	}
+}
+
+func TestExporter_AcceptedSections(t *testing.T) {
+	exporter, tempDir := setupTestExporter(t)
+	defer os.RemoveAll(tempDir)
+
+	// A stand-in for pandoc that prints the markdown of an accepted DOCX
+	pandoc := filepath.Join(tempDir, "pandoc")
+	markdown := "# Chapter 1: Introduction {#chapter-1-introduction}\n\nOpening.\n\n## 1.1 Background {#background}\n\nPrior work is limited.\n\n### Notes\n\n```\n## not a heading\n```\n\n## 1.2 Method\n\nSteps.\n\n# Appendix\n\nExtra.\n"
+	os.WriteFile(pandoc, []byte("#!/bin/sh\ncat <<'EOF'\n"+markdown+"EOF\n"), 0755)
+
+	manifest := &types.Manifest{Document: types.Document{Chapters: []types.Chapter{{
+		Number: 1,
+		Title:  "Introduction",
+		Sections: []types.Section{
+			{Number: types.SectionNumber{1, 1}, Title: "Background", Level: 1},
+			{Number: types.SectionNumber{1, 2}, Title: "Method", Level: 1},
+		},
+	}}}}
+	proposals, err := exporter.acceptedSections(context.Background(), pandoc, "reviewed.docx", manifest, nil)
+	if err != nil {
+		t.Fatalf("acceptedSections() error = %v", err)
+	}
+	want := []types.SectionProposal{
+		{Chapter: 1, Section: "1.1", Title: "Background", Content: "Prior work is limited.\n\n### Notes\n\n```\n## not a heading\n```"},
+		{Chapter: 1, Section: "1.2", Title: "Method", Content: "Steps."},
+	}
+	if len(proposals) != len(want) {
+		t.Fatalf("Expected %d proposals, got %+v", len(want), proposals)
+	}
+	for i := range want {
+		if proposals[i] != want[i] {
+			t.Errorf("Proposal %d = %+v, want %+v", i, proposals[i], want[i])
+		}
+	}
+}
@@ -0,0 +1,148 @@
+package export
+
+import (
+	"fmt"
+	"html"
+	"regexp"
+	"strings"
+
+	"github.com/gomcpgo/docgen/pkg/types"
+)
+
+// figureGridPattern matches the fenced div that places a composite figure:
+// ::: {#fig-3f9a2c7d .figure-grid} followed by a closing :::
+var figureGridPattern = regexp.MustCompile(`(?m)^:::+[ \t]*\{#(` + types.FigureIDPattern + `)[ \t]+\.` + types.FigureGridClass + `\}[ \t]*\n:::+[ \t]*$`)
+
+// latexFloatPlacements maps figure positions to LaTeX float placements
+var latexFloatPlacements = map[types.ImagePosition]string{
+	types.PositionTop:    "t",
+	types.PositionBottom: "b",
+	types.PositionPage:   "p",
+}
+
+// expandFigureGrids replaces the composite figures placed in a chapter with
+// subfigure environments in PDF exports, a CSS grid in HTML and EPUB exports
+// and a run of sub-captioned images in other formats. Divs naming figures the
+// chapter doesn't have are left alone.
+func (e *Exporter) expandFigureGrids(documentID string, manifest *types.Manifest, chapterNum types.ChapterNumber, content string, format types.ExportFormat) string {
+	figures := make(map[string]types.Figure)
+	for _, chapter := range manifest.Document.Chapters {
+		if chapter.Number != chapterNum {
+			continue
+		}
+		for _, figure := range chapter.Figures {
+			if figure.IsGrid() {
+				figures[string(figure.ID)] = figure
+			}
+		}
+	}
+	if len(figures) == 0 {
+		return content
+	}
+
+	return figureGridPattern.ReplaceAllStringFunc(content, func(match string) string {
+		figure, ok := figures[figureGridPattern.FindStringSubmatch(match)[1]]
+		if !ok {
+			return match
+		}
+		paths := make([]string, len(figure.SubFigures))
+		for i, sub := range figure.SubFigures {
+			paths[i] = sub.ImagePath
+			if resolved, err := e.config.ResolvePath(sub.ImagePath, e.config.DocumentPath(documentID)); err == nil {
+				paths[i] = resolved
+			}
+		}
+
+		switch format {
+		case types.ExportFormatPDF:
+			return latexFigureGrid(figure, paths)
+		case types.ExportFormatHTML, types.ExportFormatEPUB, types.ExportFormatRevealJS:
+			return htmlFigureGrid(figure, paths)
+		default:
+			return markdownFigureGrid(figure, paths)
+		}
+	})
+}
+
+// subFigureLabel is the (a), (b), ... that opens a sub-caption
+func subFigureLabel(index int) string {
+	return fmt.Sprintf("(%c)", 'a'+index)
+}
+
+// latexFigureGrid writes a composite figure as subfigure environments, which
+// LaTeX labels (a), (b), ... itself
+func latexFigureGrid(figure types.Figure, paths []string) string {
+	placement, ok := latexFloatPlacements[figure.Position]
+	if !ok {
+		placement = "htbp"
+	}
+	width := 0.96 / float64(figure.Columns)
+
+	var latex strings.Builder
+	latex.WriteString("```{=latex}\n")
+	latex.WriteString(fmt.Sprintf("\\begin{figure}[%s]\n\\centering\n", placement))
+	for i, sub := range figure.SubFigures {
+		latex.WriteString(fmt.Sprintf("\\begin{subfigure}[t]{%.2f\\linewidth}\n\\centering\n", width))
+		latex.WriteString(fmt.Sprintf("\\includegraphics[width=\\linewidth]{%s}\n", paths[i]))
+		latex.WriteString(fmt.Sprintf("\\caption{%s}\n", latexSpecialChars.Replace(sub.Caption)))
+		latex.WriteString("\\end{subfigure}")
+		if (i+1)%figure.Columns == 0 || i == len(figure.SubFigures)-1 {
+			latex.WriteString("\n\\par\\medskip\n")
+		} else {
+			latex.WriteString("\\hfill\n")
+		}
+	}
+	latex.WriteString(fmt.Sprintf("\\caption{%s}\n\\label{%s}\n", latexSpecialChars.Replace(figure.Caption), figure.ID))
+	latex.WriteString("\\end{figure}\n```")
+	return latex.String()
+}
+
+// htmlFigureGrid writes a composite figure as a CSS grid of sub-figures. The
+// figure keeps a single figcaption, so caption numbering counts it once.
+func htmlFigureGrid(figure types.Figure, paths []string) string {
+	var out strings.Builder
+	out.WriteString("```{=html}\n")
+	out.WriteString(fmt.Sprintf("<figure id=\"%s\" class=\"%s\">\n", figure.ID, types.FigureGridClass))
+	out.WriteString(fmt.Sprintf("<div style=\"display: grid; grid-template-columns: repeat(%d, 1fr); gap: 1em; align-items: start;\">\n", figure.Columns))
+	for i, sub := range figure.SubFigures {
+		caption := strings.TrimSpace(subFigureLabel(i) + " " + sub.Caption)
+		out.WriteString(fmt.Sprintf("<div class=\"subfigure\"><img src=\"%s\" alt=\"%s\" style=\"width: 100%%;\"><p class=\"subcaption\" style=\"text-align: center;\">%s</p></div>\n",
+			html.EscapeString(paths[i]), html.EscapeString(sub.Caption), html.EscapeString(caption)))
+	}
+	out.WriteString("</div>\n")
+	out.WriteString(fmt.Sprintf("<figcaption>%s</figcaption>\n", html.EscapeString(figure.Caption)))
+	out.WriteString("</figure>\n```")
+	return out.String()
+}
+
+// markdownFigureGrid writes a composite figure as its sub-captioned images
+// followed by the caption, for formats without a grid layout
+func markdownFigureGrid(figure types.Figure, paths []string) string {
+	var out strings.Builder
+	out.WriteString(fmt.Sprintf("::: {#%s}\n", figure.ID))
+	for i, sub := range figure.SubFigures {
+		out.WriteString(fmt.Sprintf("![%s](%s)\n\n", strings.TrimSpace(subFigureLabel(i)+" "+sub.Caption), paths[i]))
+	}
+	out.WriteString(figure.Caption + "\n:::")
+	return out.String()
+}
+
+// hasFigureGrids reports whether any exported chapter has a composite figure
+func hasFigureGrids(manifest *types.Manifest, chapters []types.ChapterNumber) bool {
+	for _, chapter := range exportedChapters(manifest, chapters) {
+		for _, figure := range chapter.Figures {
+			if figure.IsGrid() {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// generateFigureGridHeader loads the subfigure environment for composite figures
+func generateFigureGridHeader(manifest *types.Manifest, chapters []types.ChapterNumber) string {
+	if !hasFigureGrids(manifest, chapters) {
+		return ""
+	}
+	return "% Composite figures\n\\usepackage{subcaption}\n"
+}
@@ -0,0 +1,88 @@
+package export
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/gomcpgo/docgen/pkg/types"
+)
+
+func TestExporter_ExpandFigureGrids(t *testing.T) {
+	exporter, tempDir := setupTestExporter(t)
+	defer os.RemoveAll(tempDir)
+
+	_, manifest, _, _ := createTestDocument(t, tempDir)
+	manifest.Document.Chapters[0].Figures = []types.Figure{{
+		ID:       "fig-1.1",
+		Caption:  "Growth & decay",
+		Position: types.PositionTop,
+		Columns:  2,
+		SubFigures: []types.SubFigure{
+			{ImagePath: "assets/images/a.png", Caption: "Wild type"},
+			{ImagePath: "assets/images/b.png", Caption: "Mutant"},
+			{ImagePath: "assets/images/c.png"},
+		},
+	}}
+	content := "Before.\n\n::: {#fig-1.1 .figure-grid}\n:::\n\n::: {#fig-1.9 .figure-grid}\n:::\n\nAfter."
+	imageA := filepath.Join(tempDir, "test-doc", "assets", "images", "a.png")
+
+	latex := exporter.expandFigureGrids("test-doc", manifest, 1, content, types.ExportFormatPDF)
+	for _, want := range []string{
+		"\\begin{figure}[t]",
+		"\\begin{subfigure}[t]{0.48\\linewidth}",
+		"\\includegraphics[width=\\linewidth]{" + imageA + "}",
+		"\\caption{Wild type}\n\\end{subfigure}\\hfill",
+		"\\caption{Mutant}\n\\end{subfigure}\n\\par\\medskip",
+		"\\caption{Growth \\& decay}\n\\label{fig-1.1}",
+	} {
+		if !strings.Contains(latex, want) {
+			t.Errorf("PDF grid is missing %q:\n%s", want, latex)
+		}
+	}
+	if !strings.Contains(latex, "::: {#fig-1.9 .figure-grid}") || !strings.Contains(latex, "Before.") || !strings.Contains(latex, "After.") {
+		t.Errorf("Unknown grids and surrounding text should be left alone:\n%s", latex)
+	}
+
+	html := exporter.expandFigureGrids("test-doc", manifest, 1, content, types.ExportFormatHTML)
+	for _, want := range []string{
+		`<figure id="fig-1.1" class="figure-grid">`,
+		"grid-template-columns: repeat(2, 1fr)",
+		`<p class="subcaption" style="text-align: center;">(b) Mutant</p>`,
+		`<p class="subcaption" style="text-align: center;">(c)</p>`,
+		"<figcaption>Growth &amp; decay</figcaption>",
+	} {
+		if !strings.Contains(html, want) {
+			t.Errorf("HTML grid is missing %q:\n%s", want, html)
+		}
+	}
+	if strings.Count(html, "<figcaption>") != 1 {
+		t.Errorf("The grid should have one figcaption for caption numbering:\n%s", html)
+	}
+
+	docx := exporter.expandFigureGrids("test-doc", manifest, 1, content, types.ExportFormatDOCX)
+	if !strings.Contains(docx, "::: {#fig-1.1}\n![(a) Wild type]("+imageA+")") || !strings.Contains(docx, "Growth & decay\n:::") {
+		t.Errorf("Other formats should get sub-captioned images:\n%s", docx)
+	}
+
+	// Chapters without grids are returned unchanged
+	if got := exporter.expandFigureGrids("test-doc", manifest, 2, content, types.ExportFormatPDF); got != content {
+		t.Errorf("Chapter 2 content changed:\n%s", got)
+	}
+}
+
+func TestGenerateFigureGridHeader(t *testing.T) {
+	_, manifest, _, _ := createTestDocument(t, "")
+	if header := generateFigureGridHeader(manifest, nil); header != "" {
+		t.Errorf("No header expected without composite figures, got %q", header)
+	}
+
+	manifest.Document.Chapters[1].Figures = []types.Figure{{ID: "fig-2.1", SubFigures: []types.SubFigure{{ImagePath: "a.png"}, {ImagePath: "b.png"}}}}
+	if header := generateFigureGridHeader(manifest, nil); !strings.Contains(header, "\\usepackage{subcaption}") {
+		t.Errorf("Expected subcaption to be loaded, got %q", header)
+	}
+	if header := generateFigureGridHeader(manifest, []types.ChapterNumber{1}); header != "" {
+		t.Errorf("Chapters without composite figures need no header, got %q", header)
+	}
+}
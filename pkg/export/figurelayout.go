@@ -0,0 +1,148 @@
+package export
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/gomcpgo/docgen/pkg/types"
+)
+
+var (
+	// anchoredImagePattern matches an inline image with a figure anchor,
+	// capturing its caption, path, figure ID and other attributes
+	anchoredImagePattern = regexp.MustCompile(`!\[([^\]]*)\]\(([^)\s]*)[^)]*\)\{#(` + types.FigureIDPattern + `)([^}]*)\}`)
+	// widthAttributePattern matches the width attribute of an image, capturing its value
+	widthAttributePattern = regexp.MustCompile(`(?:^|\s)width="?([^"\s}]+)"?`)
+)
+
+// latexAlignments are the commands that align a figure's contents in LaTeX
+var latexAlignments = map[types.ImageAlignment]string{
+	types.AlignLeft:   "\\raggedright",
+	types.AlignCenter: "\\centering",
+	types.AlignRight:  "\\raggedleft",
+}
+
+// figureAlignmentCSS aligns the figures whose images carry an alignment class,
+// along with their captions
+var figureAlignmentCSS = fmt.Sprintf(`/* Figure alignment */
+img.%[1]s, img.%[2]s {
+    display: block;
+}
+
+img.%[1]s {
+    margin-left: 0;
+    margin-right: auto;
+}
+
+img.%[2]s {
+    margin-left: auto;
+    margin-right: 0;
+}
+
+figure:has(img.%[1]s) {
+    text-align: left;
+}
+
+figure:has(img.%[2]s) {
+    text-align: right;
+}
+`, types.FigureAlignLeftClass, types.FigureAlignRightClass)
+
+// placeFigures writes the image figures placed in a chapter that are aligned
+// or positioned as LaTeX figure environments in PDF exports, since pandoc
+// centers every figure and places it with the default float placement. The
+// width comes from the image's attributes. Other formats take the width and
+// alignment from the attributes written when the chapter was built.
+func (e *Exporter) placeFigures(documentID string, manifest *types.Manifest, chapterNum types.ChapterNumber, content string, format types.ExportFormat) string {
+	if format != types.ExportFormatPDF {
+		return content
+	}
+
+	figures := make(map[string]types.Figure)
+	for _, chapter := range manifest.Document.Chapters {
+		if chapter.Number != chapterNum {
+			continue
+		}
+		for _, figure := range chapter.Figures {
+			if !figure.IsGrid() && needsLaTeXPlacement(figure) {
+				figures[string(figure.ID)] = figure
+			}
+		}
+	}
+	if len(figures) == 0 {
+		return content
+	}
+
+	return anchoredImagePattern.ReplaceAllStringFunc(content, func(match string) string {
+		parts := anchoredImagePattern.FindStringSubmatch(match)
+		figure, ok := figures[parts[3]]
+		if !ok {
+			return match
+		}
+		path := parts[2]
+		if resolved, err := e.config.ResolvePath(path, e.config.DocumentPath(documentID)); err == nil {
+			path = resolved
+		}
+		width := ""
+		if widthMatch := widthAttributePattern.FindStringSubmatch(parts[4]); widthMatch != nil {
+			width = widthMatch[1]
+		}
+		return latexFigure(figure, parts[1], path, width)
+	})
+}
+
+// needsLaTeXPlacement reports whether a figure is aligned or positioned in a
+// way pandoc's own LaTeX figures can't express
+func needsLaTeXPlacement(figure types.Figure) bool {
+	_, positioned := latexFloatPlacements[figure.Position]
+	return positioned || figure.Alignment == types.AlignLeft || figure.Alignment == types.AlignRight
+}
+
+// latexFigure writes an image figure as a figure environment with its
+// placement, alignment and width
+func latexFigure(figure types.Figure, caption, path, width string) string {
+	placement, ok := latexFloatPlacements[figure.Position]
+	if !ok {
+		placement = "htbp"
+	}
+	alignment, ok := latexAlignments[figure.Alignment]
+	if !ok {
+		alignment = latexAlignments[types.AlignCenter]
+	}
+	options := ""
+	if width != "" {
+		options = "[width=" + latexWidth(width) + "]"
+	}
+
+	var latex strings.Builder
+	latex.WriteString("```{=latex}\n")
+	latex.WriteString(fmt.Sprintf("\\begin{figure}[%s]\n%s\n", placement, alignment))
+	latex.WriteString(fmt.Sprintf("\\includegraphics%s{%s}\n", options, path))
+	latex.WriteString(fmt.Sprintf("\\caption{%s}\n\\label{%s}\n", latexSpecialChars.Replace(caption), figure.ID))
+	latex.WriteString("\\end{figure}\n```")
+	return latex.String()
+}
+
+// latexWidth converts an image width to LaTeX: a percentage is a share of the
+// line width, and lengths are kept as they are
+func latexWidth(width string) string {
+	if percent, err := strconv.ParseFloat(strings.TrimSuffix(width, "%"), 64); err == nil && strings.HasSuffix(width, "%") {
+		return fmt.Sprintf("%.2f\\linewidth", percent/100)
+	}
+	return width
+}
+
+// hasAlignedFigures reports whether any exported chapter has a figure that
+// isn't centered
+func hasAlignedFigures(manifest *types.Manifest, chapters []types.ChapterNumber) bool {
+	for _, chapter := range exportedChapters(manifest, chapters) {
+		for _, figure := range chapter.Figures {
+			if figure.Alignment == types.AlignLeft || figure.Alignment == types.AlignRight {
+				return true
+			}
+		}
+	}
+	return false
+}
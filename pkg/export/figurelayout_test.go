@@ -0,0 +1,51 @@
+package export
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/gomcpgo/docgen/pkg/types"
+)
+
+func TestExporter_PlaceFigures(t *testing.T) {
+	exporter, tempDir := setupTestExporter(t)
+	defer os.RemoveAll(tempDir)
+
+	_, manifest, _, _ := createTestDocument(t, tempDir)
+	manifest.Document.Chapters[0].Figures = []types.Figure{
+		{ID: "fig-1.1", ImagePath: "assets/images/a.png", Position: types.PositionTop, Alignment: types.AlignRight, Width: "60%"},
+		{ID: "fig-1.2", ImagePath: "assets/images/b.png", Position: types.PositionHere, Alignment: types.AlignCenter},
+	}
+	content := "![Growth & decay](assets/images/a.png){#fig-1.1 .align-right width=60%}\n\n![Plain](assets/images/b.png){#fig-1.2}"
+	imageA := filepath.Join(tempDir, "test-doc", "assets", "images", "a.png")
+
+	latex := exporter.placeFigures("test-doc", manifest, 1, content, types.ExportFormatPDF)
+	for _, want := range []string{
+		"\\begin{figure}[t]\n\\raggedleft\n",
+		"\\includegraphics[width=0.60\\linewidth]{" + imageA + "}",
+		"\\caption{Growth \\& decay}\n\\label{fig-1.1}",
+		// Centered figures in the default position are left to pandoc
+		"![Plain](assets/images/b.png){#fig-1.2}",
+	} {
+		if !strings.Contains(latex, want) {
+			t.Errorf("Expected %q in:\n%s", want, latex)
+		}
+	}
+
+	if html := exporter.placeFigures("test-doc", manifest, 1, content, types.ExportFormatHTML); html != content {
+		t.Errorf("Expected HTML content unchanged, got:\n%s", html)
+	}
+	if !hasAlignedFigures(manifest, nil) {
+		t.Errorf("Expected an aligned figure")
+	}
+}
+
+func TestLaTeXWidth(t *testing.T) {
+	for width, want := range map[string]string{"50%": "0.50\\linewidth", "100%": "1.00\\linewidth", "10cm": "10cm"} {
+		if got := latexWidth(width); got != want {
+			t.Errorf("latexWidth(%q) = %q, want %q", width, got, want)
+		}
+	}
+}
@@ -0,0 +1,115 @@
+package export
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+
+	"github.com/gomcpgo/docgen/pkg/types"
+)
+
+var (
+	// figureReferencePattern matches a cross-reference to a figure by its
+	// stable ID or display number: @fig-3f9a2c7d or @fig-1.2
+	figureReferencePattern = regexp.MustCompile(`@(` + types.FigureIDPattern + `)\b`)
+	// figureAliasAnchorPattern matches a figure anchor written with a display
+	// number, {#fig-1.2 or {#fig-1.2 width=50%}
+	figureAliasAnchorPattern = regexp.MustCompile(`\{#(fig-\d+\.\d+)([\s}])`)
+)
+
+// figureNumbers maps every figure's ID, and the display number it is also
+// known by, to the number it is exported with: 1.2 or II.2 when figures are
+// numbered by chapter, 7 when they run through the document. In a volume the
+// numbers continue from the documents before.
+func figureNumbers(manifest *types.Manifest, numbering *types.NumberingStyle, volume *types.VolumePlacement) map[string]string {
+	if numbering == nil {
+		numbering = &types.NumberingStyle{}
+	}
+	chapterOffset, figures := 0, 0
+	if volume != nil {
+		chapterOffset, figures = volume.ChapterOffset, volume.FigureOffset
+	}
+
+	numbers := make(map[string]string)
+	for _, chapter := range manifest.Document.Chapters {
+		for _, figure := range chapter.Figures {
+			number := fmt.Sprintf("%s.%d", numbering.ChapterLabel(chapter.Number+types.ChapterNumber(chapterOffset)), figure.Sequence)
+			if numbering.FigureNumbering == types.CounterContinuous {
+				number = strconv.Itoa(figures + figure.Sequence)
+			}
+			if _, ok := numbers[string(figure.Label())]; !ok {
+				numbers[string(figure.Label())] = number
+			}
+			numbers[string(figure.ID)] = number
+		}
+		figures += len(chapter.Figures)
+	}
+	return numbers
+}
+
+// figureAliases maps the display numbers of the figures with stable IDs to
+// their IDs. A figure still identified by its number keeps it.
+func figureAliases(manifest *types.Manifest) map[string]types.FigureID {
+	aliases := make(map[string]types.FigureID)
+	for _, chapter := range manifest.Document.Chapters {
+		for _, figure := range chapter.Figures {
+			if !figure.ID.IsNumbered() {
+				aliases[string(figure.Label())] = figure.ID
+			}
+		}
+	}
+	for _, chapter := range manifest.Document.Chapters {
+		for _, figure := range chapter.Figures {
+			delete(aliases, string(figure.ID))
+		}
+	}
+	return aliases
+}
+
+// resolveFigureAnchors rewrites the figure anchors written with a display
+// number to the figure's stable ID, so the figure is laid out and referenced
+// under its ID. Anchors no figure has the number of are left alone.
+func resolveFigureAnchors(manifest *types.Manifest, content string) string {
+	aliases := figureAliases(manifest)
+	if len(aliases) == 0 {
+		return content
+	}
+	return figureAliasAnchorPattern.ReplaceAllStringFunc(content, func(match string) string {
+		parts := figureAliasAnchorPattern.FindStringSubmatch(match)
+		id, ok := aliases[parts[1]]
+		if !ok {
+			return match
+		}
+		return "{#" + string(id) + parts[2]
+	})
+}
+
+// formatFigureReferences writes the cross-references to figures with the
+// numbers the figures are exported with, labelled in the document locale. PDF
+// exports refer to the figure's LaTeX label, so the number matches its
+// caption; other formats link to the figure. References by display number
+// lead to the figure that has it, and references to unknown figures are left
+// alone.
+func formatFigureReferences(manifest *types.Manifest, content string, format types.ExportFormat, numbering *types.NumberingStyle, volume *types.VolumePlacement) string {
+	numbers := figureNumbers(manifest, numbering, volume)
+	if len(numbers) == 0 {
+		return content
+	}
+	aliases := figureAliases(manifest)
+	label := exportLocale(&manifest.Document).Figure
+
+	return figureReferencePattern.ReplaceAllStringFunc(content, func(match string) string {
+		id := match[1:]
+		number, ok := numbers[id]
+		if !ok {
+			return match
+		}
+		if stable, aliased := aliases[id]; aliased {
+			id = string(stable)
+		}
+		if format == types.ExportFormatPDF {
+			return fmt.Sprintf("`%s~\\ref{%s}`{=latex}", label, id)
+		}
+		return fmt.Sprintf("[%s %s](#%s)", label, number, id)
+	})
+}
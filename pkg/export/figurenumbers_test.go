@@ -0,0 +1,58 @@
+package export
+
+import (
+	"testing"
+
+	"github.com/gomcpgo/docgen/pkg/types"
+)
+
+// figureNumbersManifest has a figure with a stable ID in chapters 1 and 2 and
+// one still identified by its number in chapter 2
+func figureNumbersManifest() *types.Manifest {
+	return &types.Manifest{Document: types.Document{Chapters: []types.Chapter{
+		{Number: 1, Figures: []types.Figure{{ID: "fig-0000000a", Chapter: 1, Sequence: 1}}},
+		{Number: 2, Figures: []types.Figure{
+			{ID: "fig-0000000b", Chapter: 2, Sequence: 1},
+			{ID: "fig-2.2", Chapter: 2, Sequence: 2},
+		}},
+	}}}
+}
+
+func TestFormatFigureReferences(t *testing.T) {
+	manifest := figureNumbersManifest()
+	content := "See @fig-0000000b, @fig-1.1, @fig-2.2 and @fig-9.9."
+
+	got := formatFigureReferences(manifest, content, types.ExportFormatHTML, nil, nil)
+	want := "See [Figure 2.1](#fig-0000000b), [Figure 1.1](#fig-0000000a), [Figure 2.2](#fig-2.2) and @fig-9.9."
+	if got != want {
+		t.Errorf("formatFigureReferences() = %q, want %q", got, want)
+	}
+
+	// Numbers follow the numbering style, and continue through a volume
+	numbering := &types.NumberingStyle{FigureNumbering: types.CounterContinuous}
+	if got := formatFigureReferences(manifest, "@fig-0000000b", types.ExportFormatDOCX, numbering, &types.VolumePlacement{FigureOffset: 4}); got != "[Figure 6](#fig-0000000b)" {
+		t.Errorf("Expected the continuous number, got %q", got)
+	}
+	roman := &types.NumberingStyle{ChapterFormat: types.NumberFormatRoman}
+	if got := formatFigureReferences(manifest, "@fig-2.2", types.ExportFormatHTML, roman, nil); got != "[Figure II.2](#fig-2.2)" {
+		t.Errorf("Expected the chapter in roman numerals, got %q", got)
+	}
+
+	// PDF exports leave the number to LaTeX
+	if got := formatFigureReferences(manifest, "@fig-1.1", types.ExportFormatPDF, nil, nil); got != "`Figure~\\ref{fig-0000000a}`{=latex}" {
+		t.Errorf("Unexpected PDF reference %q", got)
+	}
+
+	manifest.Document.Locale = "de"
+	if got := formatFigureReferences(manifest, "@fig-0000000a", types.ExportFormatHTML, nil, nil); got != "[Abbildung 1.1](#fig-0000000a)" {
+		t.Errorf("Expected the German label, got %q", got)
+	}
+}
+
+func TestResolveFigureAnchors(t *testing.T) {
+	content := "![A](a.png){#fig-1.1 width=50%}\n\n![B](b.png){#fig-2.2}\n\n![C](c.png){#fig-3.1}"
+	want := "![A](a.png){#fig-0000000a width=50%}\n\n![B](b.png){#fig-2.2}\n\n![C](c.png){#fig-3.1}"
+	if got := resolveFigureAnchors(figureNumbersManifest(), content); got != want {
+		t.Errorf("resolveFigureAnchors() =\n%s\nwant\n%s", got, want)
+	}
+}
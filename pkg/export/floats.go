@@ -0,0 +1,78 @@
+package export
+
+import (
+	"regexp"
+	"strings"
+
+	"github.com/gomcpgo/docgen/pkg/types"
+)
+
+// floatBeginPattern matches the opening of a LaTeX figure or table float
+// written in raw LaTeX, with its placement specifier if it has one
+var floatBeginPattern = regexp.MustCompile(`\\begin\{(figure|table)\}(\[[htbpH!]*\])?`)
+
+// balancedFloatParameters relax LaTeX's limits on how much of a page floats
+// may take. The defaults push large figures to the end of the chapter and
+// leave text pages half empty.
+var balancedFloatParameters = []string{
+	"\\renewcommand{\\topfraction}{0.9}",
+	"\\renewcommand{\\bottomfraction}{0.8}",
+	"\\renewcommand{\\textfraction}{0.07}",
+	"\\renewcommand{\\floatpagefraction}{0.7}",
+	"\\renewcommand{\\dbltopfraction}{0.9}",
+	"\\renewcommand{\\dblfloatpagefraction}{0.7}",
+	"\\setcounter{topnumber}{3}",
+	"\\setcounter{bottomnumber}{2}",
+	"\\setcounter{totalnumber}{5}",
+	"\\setcounter{dbltopnumber}{2}",
+}
+
+// generateFloatHeader creates the LaTeX preamble for the float placement of an export
+func generateFloatHeader(options *types.ExportOptions) string {
+	var header strings.Builder
+	switch options.FloatPlacement {
+	case types.FloatPlacementBalanced:
+		header.WriteString("\n% Float placement: balanced\n")
+		for _, parameter := range balancedFloatParameters {
+			header.WriteString(parameter + "\n")
+		}
+		// Pandoc places figures with htbp; ! lets them ignore the limits above
+		// when nothing else fits
+		header.WriteString("\\makeatletter\n\\def\\fps@figure{!htbp}\n\\def\\fps@table{!htbp}\n\\makeatother\n")
+	case types.FloatPlacementHere:
+		header.WriteString("\n% Float placement: here\n")
+		header.WriteString("\\usepackage{float}\n")
+		header.WriteString("\\floatplacement{figure}{H}\n")
+		header.WriteString("\\floatplacement{table}{H}\n")
+	}
+	return header.String()
+}
+
+// adjustFloatPlacements rewrites the placement of floats written in raw LaTeX,
+// such as composite figures, to match the export's float placement. Balanced
+// placement lets each float fall back to a page of floats, so a float that
+// asks for the top of a page can't hold back every float after it. Two-column
+// floats are left alone, as they can't be placed here.
+func adjustFloatPlacements(content string, placement types.FloatPlacement) string {
+	if placement == types.FloatPlacementDefault {
+		return content
+	}
+	return floatBeginPattern.ReplaceAllStringFunc(content, func(match string) string {
+		parts := floatBeginPattern.FindStringSubmatch(match)
+		begin := "\\begin{" + parts[1] + "}"
+		if placement == types.FloatPlacementHere {
+			return begin + "[H]"
+		}
+		spec := strings.Trim(parts[2], "[]")
+		if spec == "" || strings.Contains(spec, "H") {
+			return match
+		}
+		if !strings.Contains(spec, "!") {
+			spec = "!" + spec
+		}
+		if !strings.Contains(spec, "p") {
+			spec += "p"
+		}
+		return begin + "[" + spec + "]"
+	})
+}
@@ -0,0 +1,44 @@
+package export
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/gomcpgo/docgen/pkg/types"
+)
+
+func TestGenerateFloatHeader(t *testing.T) {
+	if header := generateFloatHeader(&types.ExportOptions{}); header != "" {
+		t.Errorf("No header expected for the default placement, got %q", header)
+	}
+
+	balanced := generateFloatHeader(&types.ExportOptions{FloatPlacement: types.FloatPlacementBalanced})
+	for _, want := range []string{"\\renewcommand{\\textfraction}{0.07}", "\\def\\fps@figure{!htbp}"} {
+		if !strings.Contains(balanced, want) {
+			t.Errorf("Balanced header is missing %q:\n%s", want, balanced)
+		}
+	}
+
+	here := generateFloatHeader(&types.ExportOptions{FloatPlacement: types.FloatPlacementHere})
+	if !strings.Contains(here, "\\usepackage{float}") || !strings.Contains(here, "\\floatplacement{figure}{H}") {
+		t.Errorf("Here header should place floats with H:\n%s", here)
+	}
+}
+
+func TestAdjustFloatPlacements(t *testing.T) {
+	content := "\\begin{figure}[t]\n\\end{figure}\n\\begin{table}\n\\end{table}\n\\begin{figure*}[t]\n\\end{figure*}"
+
+	if got := adjustFloatPlacements(content, types.FloatPlacementDefault); got != content {
+		t.Errorf("Default placement changed the content:\n%s", got)
+	}
+
+	balanced := adjustFloatPlacements(content, types.FloatPlacementBalanced)
+	if !strings.Contains(balanced, "\\begin{figure}[!tp]") || !strings.Contains(balanced, "\\begin{table}\n") {
+		t.Errorf("Balanced placement should add ! and p to explicit placements only:\n%s", balanced)
+	}
+
+	here := adjustFloatPlacements(content, types.FloatPlacementHere)
+	if !strings.Contains(here, "\\begin{figure}[H]") || !strings.Contains(here, "\\begin{table}[H]") || !strings.Contains(here, "\\begin{figure*}[t]") {
+		t.Errorf("Here placement should use H for single-column floats:\n%s", here)
+	}
+}
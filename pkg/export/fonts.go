@@ -0,0 +1,418 @@
+package export
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/gomcpgo/docgen/pkg/types"
+)
+
+// builtinFontFallbacks are free look-alikes of fonts styles often name but
+// many systems lack, tried after a style's own font_fallbacks
+var builtinFontFallbacks = map[string][]string{
+	"times new roman": {"TeX Gyre Termes", "Liberation Serif", "Nimbus Roman", "Tinos"},
+	"times":           {"TeX Gyre Termes", "Nimbus Roman", "Liberation Serif"},
+	"arial":           {"Liberation Sans", "TeX Gyre Heros", "Nimbus Sans", "Arimo"},
+	"helvetica":       {"TeX Gyre Heros", "Nimbus Sans", "Liberation Sans"},
+	"courier new":     {"Liberation Mono", "TeX Gyre Cursor", "Nimbus Mono PS", "Cousine"},
+	"courier":         {"TeX Gyre Cursor", "Nimbus Mono PS", "Liberation Mono"},
+	"georgia":         {"Gelasio", "DejaVu Serif"},
+	"calibri":         {"Carlito"},
+	"cambria":         {"Caladea"},
+	"palatino":        {"TeX Gyre Pagella", "P052"},
+	"book antiqua":    {"TeX Gyre Pagella", "P052"},
+	"garamond":        {"EB Garamond"},
+	"consolas":        {"Inconsolata", "DejaVu Sans Mono"},
+}
+
+// fontFileExtensions are the files fontspec loads by name rather than through
+// fontconfig, which can't be checked against the installed families
+var fontFileExtensions = map[string]bool{".otf": true, ".ttf": true, ".ttc": true, ".pfb": true}
+
+// commonFontWords say little about what a font looks like, so they don't make
+// one font like another
+var commonFontWords = map[string]bool{"new": true, "pro": true, "std": true, "the": true, "font": true}
+
+// styleFont is a text style of a style that names a font, with its part
+type styleFont struct {
+	role string
+	text *types.TextStyle
+}
+
+// styleFonts returns the text styles whose fonts exports load
+func styleFonts(style *types.Style) []styleFont {
+	return []styleFont{
+		{"body", &style.Body},
+		{"heading", &style.Heading},
+		{"monospace", &style.Monospace},
+		{"epigraph", &style.Epigraph},
+	}
+}
+
+// prepareFonts readies a style's fonts for an export. Bundled font files are
+// found for every format, and for PDF, fonts that aren't installed are
+// replaced. It returns a copy of the style and warnings about the fonts it
+// couldn't use.
+func (e *Exporter) prepareFonts(ctx context.Context, documentID string, style *types.Style, format types.ExportFormat) (*types.Style, []string) {
+	style, warnings := e.bundleFonts(documentID, style)
+	if format == types.ExportFormatPDF {
+		var missing []string
+		style, missing = e.ResolveFonts(ctx, style)
+		warnings = append(warnings, missing...)
+	}
+	return style, warnings
+}
+
+// bundleFonts finds the font files a style names, in the document's fonts
+// folder before the shared one. The returned copy of the style has each
+// font_file replaced by the file's full path, and where the style names no
+// family for the font, the family named after the file. A font file that
+// can't be found is dropped with a warning, leaving its family to stand in.
+func (e *Exporter) bundleFonts(documentID string, style *types.Style) (*types.Style, []string) {
+	if style == nil {
+		return style, nil
+	}
+
+	resolved := *style
+	var warnings []string
+	for _, font := range styleFonts(&resolved) {
+		file := font.text.FontFile
+		if file == "" || filepath.IsAbs(file) {
+			continue
+		}
+		path, err := e.fontFilePath(documentID, file)
+		if err != nil {
+			instead := "the default font"
+			if font.text.FontFamily != "" {
+				instead = fmt.Sprintf("font %q", font.text.FontFamily)
+			}
+			warnings = append(warnings, fmt.Sprintf("Font file %q (%s): %v; using %s instead", file, font.role, err, instead))
+			font.text.FontFile = ""
+			continue
+		}
+		font.text.FontFile = path
+		if font.text.FontFamily == "" {
+			font.text.FontFamily = fontFileFamily(path)
+		}
+	}
+	return &resolved, warnings
+}
+
+// fontFilePath returns the full path of a font file in the document's fonts
+// folder or the shared one
+func (e *Exporter) fontFilePath(documentID, name string) (string, error) {
+	if err := types.ValidateFontFileName(name); err != nil {
+		return "", err
+	}
+	for _, dir := range []string{e.config.DocumentFontsPath(documentID), e.config.FontsPath()} {
+		path, err := filepath.Abs(filepath.Join(dir, name))
+		if err != nil {
+			continue
+		}
+		if info, err := os.Stat(path); err == nil && info.Mode().IsRegular() {
+			return path, nil
+		}
+	}
+	return "", fmt.Errorf("not found in the document's or the shared fonts folder")
+}
+
+// fontFace is one file of a bundled font
+type fontFace struct {
+	path   string
+	bold   bool
+	italic bool
+}
+
+// name is what the face's file is named after, and its fontspec option
+func (f fontFace) name() string {
+	switch {
+	case f.bold && f.italic:
+		return "BoldItalic"
+	case f.bold:
+		return "Bold"
+	case f.italic:
+		return "Italic"
+	}
+	return "Regular"
+}
+
+// fontFilePrefix returns what the files of a font's faces are named after:
+// Name- for Name-Regular.otf, Name.otf and Name-Roman.otf alike
+func fontFilePrefix(path string) string {
+	stem := strings.TrimSuffix(filepath.Base(path), filepath.Ext(path))
+	for _, regular := range []string{"-Regular", "-Roman", "-Book"} {
+		if strings.HasSuffix(stem, regular) {
+			return strings.TrimSuffix(stem, regular) + "-"
+		}
+	}
+	return stem + "-"
+}
+
+// fontFileFamily names a font given only by its file after the file
+func fontFileFamily(path string) string {
+	return strings.TrimSuffix(fontFilePrefix(path), "-")
+}
+
+// fontFaces returns the files of a bundled font: the regular file, then the
+// bold, italic and bold italic files named like it that exist beside it
+func fontFaces(path string) []fontFace {
+	faces := []fontFace{{path: path}}
+	prefix := filepath.Join(filepath.Dir(path), fontFilePrefix(path))
+	for _, face := range []fontFace{{bold: true}, {italic: true}, {bold: true, italic: true}} {
+		face.path = prefix + face.name() + filepath.Ext(path)
+		if _, err := os.Stat(face.path); err == nil {
+			faces = append(faces, face)
+		}
+	}
+	return faces
+}
+
+// fontspecFont returns the arguments of a fontspec font command for a text
+// style: its family, or its bundled file with the folder to load it from and
+// its other faces
+func fontspecFont(text types.TextStyle) string {
+	if !filepath.IsAbs(text.FontFile) {
+		return "{" + text.FontFamily + "}"
+	}
+	options := []string{"Path=" + filepath.ToSlash(filepath.Dir(text.FontFile)) + "/"}
+	for _, face := range fontFaces(text.FontFile)[1:] {
+		options = append(options, fmt.Sprintf("%sFont=%s", face.name(), filepath.Base(face.path)))
+	}
+	return fmt.Sprintf("{%s}[%s]", filepath.Base(text.FontFile), strings.Join(options, ","))
+}
+
+// fontFaceCSS declares a style's bundled fonts in CSS, src giving the URL each
+// file is loaded from
+func fontFaceCSS(style *types.Style, src func(path string) string) string {
+	if style == nil {
+		return ""
+	}
+	var css strings.Builder
+	declared := make(map[string]bool)
+	for _, font := range styleFonts(style) {
+		if !filepath.IsAbs(font.text.FontFile) || declared[font.text.FontFile] {
+			continue
+		}
+		declared[font.text.FontFile] = true
+		for _, face := range fontFaces(font.text.FontFile) {
+			format, weight, fontStyle := "opentype", "normal", "normal"
+			if strings.EqualFold(filepath.Ext(face.path), ".ttf") {
+				format = "truetype"
+			}
+			if face.bold {
+				weight = "bold"
+			}
+			if face.italic {
+				fontStyle = "italic"
+			}
+			css.WriteString(fmt.Sprintf("@font-face {\n    font-family: '%s';\n    src: url('%s') format('%s');\n    font-weight: %s;\n    font-style: %s;\n}\n\n", font.text.FontFamily, src(face.path), format, weight, fontStyle))
+		}
+	}
+	return css.String()
+}
+
+// epubFontArgs embeds a style's bundled fonts in an EPUB. Pandoc keeps
+// embedded fonts in the book's fonts folder, beside the folder of its
+// stylesheets, and uses its own stylesheet only when it is given none, so the
+// fonts are declared and applied in a copy of it.
+func (e *Exporter) epubFontArgs(ctx context.Context, documentID, inputFile string, style *types.Style) []string {
+	fontCSS := fontFaceCSS(style, func(path string) string { return "../fonts/" + filepath.Base(path) })
+	if fontCSS == "" {
+		return nil
+	}
+
+	var args []string
+	for _, font := range styleFonts(style) {
+		if filepath.IsAbs(font.text.FontFile) {
+			for _, face := range fontFaces(font.text.FontFile) {
+				args = append(args, "--epub-embed-font", face.path)
+			}
+		}
+	}
+
+	var css strings.Builder
+	if pandocPath, err := findPandocPath(e.config.PandocPath); err == nil {
+		if defaultCSS, err := exec.CommandContext(ctx, pandocPath, "--print-default-data-file", "epub.css").Output(); err == nil {
+			css.Write(defaultCSS)
+			css.WriteString("\n")
+		}
+	}
+	css.WriteString(fontCSS)
+	for _, rule := range []struct {
+		selector string
+		text     types.TextStyle
+	}{{"body", style.Body}, {"h1, h2, h3, h4, h5, h6", style.Heading}, {"code, pre", style.Monospace}, {".epigraph", style.Epigraph}} {
+		if filepath.IsAbs(rule.text.FontFile) {
+			css.WriteString(fmt.Sprintf("%s {\n    font-family: '%s';\n}\n\n", rule.selector, rule.text.FontFamily))
+		}
+	}
+
+	cssFile := filepath.Join(filepath.Dir(inputFile), fmt.Sprintf("%s-epub.css", documentID))
+	if err := os.WriteFile(cssFile, []byte(css.String()), 0644); err != nil {
+		log.Printf("[DOCGEN EPUB] Failed to write font stylesheet: %v", err)
+		return nil
+	}
+	return append(args, "--css", cssFile)
+}
+
+// installedFonts returns the font families fontconfig knows, keyed by their
+// lowercase name. It returns nil when fc-list can't be run, in which case
+// fonts go unchecked.
+func (e *Exporter) installedFonts(ctx context.Context) map[string]string {
+	if e.config.FCListPath == "" || !engineAvailable(e.config.FCListPath) {
+		log.Printf("[DOCGEN FONTS] fc-list not found, fonts are not checked")
+		return nil
+	}
+	output, err := exec.CommandContext(ctx, e.config.FCListPath, ":", "family").Output()
+	if err != nil {
+		log.Printf("[DOCGEN FONTS] fc-list failed, fonts are not checked: %v", err)
+		return nil
+	}
+
+	// Each line lists a font's family names, localized ones included
+	families := make(map[string]string)
+	for _, line := range strings.Split(string(output), "\n") {
+		for _, family := range strings.Split(line, ",") {
+			family = strings.TrimSpace(strings.ReplaceAll(family, "\\-", "-"))
+			if family != "" {
+				families[strings.ToLower(family)] = family
+			}
+		}
+	}
+	return families
+}
+
+// ResolveFonts checks that the fonts a PDF export of the style loads are
+// installed, leaving those loaded from bundled files alone. Each missing font
+// is replaced by the first installed font among its fallbacks, the style's
+// font_fallbacks before the built-in ones, or by LaTeX's default font when
+// none is, so xelatex doesn't stop partway through the export on a fontspec
+// error. The style is left alone; a copy with the replacements is returned
+// along with a warning for each missing font, naming installed fonts like it.
+// Without fc-list the style is returned unchecked.
+func (e *Exporter) ResolveFonts(ctx context.Context, style *types.Style) (*types.Style, []string) {
+	if style == nil || !needsXeLaTeX(style) {
+		return style, nil
+	}
+	installed := e.installedFonts(ctx)
+	if installed == nil {
+		return style, nil
+	}
+
+	resolved := *style
+	var warnings []string
+	replacements := make(map[string]string)
+	for _, font := range styleFonts(&resolved) {
+		family := font.text.FontFamily
+		if family == "" || font.text.FontFile != "" || fontFileExtensions[strings.ToLower(filepath.Ext(family))] || installed[strings.ToLower(family)] != "" {
+			continue
+		}
+		if replacement, ok := replacements[family]; ok {
+			font.text.FontFamily = replacement
+			continue
+		}
+
+		replacement := fontFallback(family, style.FontFallbacks, installed)
+		if replacement != "" {
+			warnings = append(warnings, fmt.Sprintf("Font %q (%s) is not installed; using %q instead", family, font.role, replacement))
+		} else {
+			warning := fmt.Sprintf("Font %q (%s) is not installed and has no installed fallback; using the default font instead", family, font.role)
+			if suggestions := suggestFonts(family, font.role == "monospace", installed); len(suggestions) > 0 {
+				warning += fmt.Sprintf(". Installed fonts like it: %s (add one to the style's font_fallbacks)", strings.Join(suggestions, ", "))
+			}
+			warnings = append(warnings, warning)
+		}
+		log.Printf("[DOCGEN FONTS] %s", warnings[len(warnings)-1])
+		replacements[family] = replacement
+		font.text.FontFamily = replacement
+	}
+	return &resolved, warnings
+}
+
+// ValidateFonts adds a warning to a validation report for each font of the
+// style a PDF export of the document would have to replace
+func (e *Exporter) ValidateFonts(ctx context.Context, documentID string, style *types.Style, report *types.ValidationReport) {
+	_, warnings := e.prepareFonts(ctx, documentID, style, types.ExportFormatPDF)
+	report.Warnings = append(report.Warnings, warnings...)
+}
+
+// fontFallback returns the first installed fallback for a font, or ""
+func fontFallback(family string, fallbacks map[string][]string, installed map[string]string) string {
+	candidates := append([]string{}, fallbacks[family]...)
+	for name, names := range fallbacks {
+		if strings.EqualFold(name, family) && name != family {
+			candidates = append(candidates, names...)
+		}
+	}
+	candidates = append(candidates, builtinFontFallbacks[strings.ToLower(family)]...)
+	for _, candidate := range candidates {
+		if name, ok := installed[strings.ToLower(candidate)]; ok {
+			return name
+		}
+	}
+	return ""
+}
+
+// suggestFonts returns up to three installed fonts like a missing one: those
+// sharing a word of its name, or else installed fonts of its kind (monospace,
+// sans serif or serif, going by its name)
+func suggestFonts(family string, monospace bool, installed map[string]string) []string {
+	scores := make(map[string]int)
+	for _, word := range strings.Fields(strings.ToLower(family)) {
+		if len(word) < 3 || commonFontWords[word] {
+			continue
+		}
+		for lower, name := range installed {
+			if containsWord(lower, word) {
+				scores[name]++
+			}
+		}
+	}
+
+	if len(scores) == 0 {
+		lowerFamily := strings.ToLower(family)
+		kind := "serif"
+		switch {
+		case monospace || strings.Contains(lowerFamily, "mono") || strings.Contains(lowerFamily, "courier"):
+			kind = "mono"
+		case strings.Contains(lowerFamily, "sans"):
+			kind = "sans"
+		}
+		for lower, name := range installed {
+			if containsWord(lower, kind) && (kind != "serif" || !containsWord(lower, "sans")) {
+				scores[name] = 1
+			}
+		}
+	}
+
+	suggestions := make([]string, 0, len(scores))
+	for name := range scores {
+		suggestions = append(suggestions, name)
+	}
+	sort.Slice(suggestions, func(i, j int) bool {
+		if scores[suggestions[i]] != scores[suggestions[j]] {
+			return scores[suggestions[i]] > scores[suggestions[j]]
+		}
+		return suggestions[i] < suggestions[j]
+	})
+	if len(suggestions) > 3 {
+		suggestions = suggestions[:3]
+	}
+	return suggestions
+}
+
+// containsWord reports whether a lowercase font name has word as one of its words
+func containsWord(name, word string) bool {
+	for _, field := range strings.Fields(name) {
+		if field == word {
+			return true
+		}
+	}
+	return false
+}
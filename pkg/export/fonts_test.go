@@ -0,0 +1,191 @@
+package export
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/gomcpgo/docgen/pkg/types"
+)
+
+// installedFontList stands in for fc-list, listing a few installed families
+const installedFontList = `#!/bin/sh
+printf 'Liberation Serif\nDejaVu Sans,DejaVu Sans Condensed\nDejaVu Sans Mono\nFira Code\nOpen Sans\n'
+`
+
+// writeFontList points the exporter's fc-list at the fake one
+func writeFontList(t *testing.T, exporter *Exporter, tempDir string) {
+	exporter.config.FCListPath = filepath.Join(tempDir, "fc-list")
+	if err := os.WriteFile(exporter.config.FCListPath, []byte(installedFontList), 0755); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestExporter_ResolveFonts(t *testing.T) {
+	exporter, tempDir := setupTestExporter(t)
+	defer os.RemoveAll(tempDir)
+
+	style := &types.Style{
+		Body:          types.TextStyle{FontFamily: "Times New Roman"},
+		Heading:       types.TextStyle{FontFamily: "Helvetica Neue"},
+		Monospace:     types.TextStyle{FontFamily: "Source Code Pro"},
+		Epigraph:      types.TextStyle{FontFamily: "fira code"},
+		FontFallbacks: map[string][]string{"Helvetica Neue": {"Inter", "Open Sans"}},
+	}
+
+	// Without fc-list fonts go unchecked
+	exporter.config.FCListPath = filepath.Join(tempDir, "missing")
+	if resolved, warnings := exporter.ResolveFonts(context.Background(), style); resolved != style || len(warnings) != 0 {
+		t.Errorf("Expected the style unchecked without fc-list, got %v", warnings)
+	}
+
+	writeFontList(t, exporter, tempDir)
+	resolved, warnings := exporter.ResolveFonts(context.Background(), style)
+	if resolved.Body.FontFamily != "Liberation Serif" || resolved.Heading.FontFamily != "Open Sans" || resolved.Monospace.FontFamily != "" || resolved.Epigraph.FontFamily != "fira code" {
+		t.Errorf("Unexpected fonts %+v %+v %+v %+v", resolved.Body, resolved.Heading, resolved.Monospace, resolved.Epigraph)
+	}
+	if style.Body.FontFamily != "Times New Roman" {
+		t.Error("The style should be left alone")
+	}
+	if len(warnings) != 3 || !strings.Contains(warnings[2], `Font "Source Code Pro" (monospace) is not installed`) || !strings.Contains(warnings[2], "Installed fonts like it: Fira Code") {
+		t.Errorf("Unexpected warnings %v", warnings)
+	}
+	if header := generateLaTeXHeader(resolved, &types.Manifest{}); !strings.Contains(header, "\\setmainfont{Liberation Serif}") || strings.Contains(header, "\\setmonofont") {
+		t.Errorf("Expected the header to load the replacements:\n%s", header)
+	}
+
+	// Fonts pdflatex exports don't load aren't checked
+	plain := &types.Style{Body: types.TextStyle{FontFamily: "Times New Roman"}}
+	if resolved, warnings := exporter.ResolveFonts(context.Background(), plain); resolved != plain || len(warnings) != 0 {
+		t.Errorf("Expected no checks for a pdflatex style, got %v", warnings)
+	}
+}
+
+func TestSuggestFonts(t *testing.T) {
+	installed := map[string]string{"liberation serif": "Liberation Serif", "dejavu sans": "DejaVu Sans", "dejavu sans mono": "DejaVu Sans Mono", "open sans": "Open Sans"}
+	tests := []struct {
+		family    string
+		monospace bool
+		want      string
+	}{
+		{"Open Sans Light", false, "Open Sans, DejaVu Sans, DejaVu Sans Mono"},
+		{"Minion", false, "Liberation Serif"},
+		{"Menlo", true, "DejaVu Sans Mono"},
+		{"Gill Sans", false, "DejaVu Sans, DejaVu Sans Mono, Open Sans"},
+		{"Times New Roman", false, "Liberation Serif"},
+	}
+	for _, tt := range tests {
+		if got := strings.Join(suggestFonts(tt.family, tt.monospace, installed), ", "); got != tt.want {
+			t.Errorf("suggestFonts(%q) = %q, want %q", tt.family, got, tt.want)
+		}
+	}
+}
+
+func TestExporter_ExportDocumentFontFallback(t *testing.T) {
+	exporter, tempDir := setupTestExporter(t)
+	defer os.RemoveAll(tempDir)
+	writeFontList(t, exporter, tempDir)
+	exporter.config.PandocPath = filepath.Join(tempDir, "pandoc")
+	os.WriteFile(exporter.config.PandocPath, []byte(draftPandoc), 0755)
+
+	doc, manifest, style, pandocConfig := createTestDocument(t, tempDir)
+	for _, chapter := range doc.Chapters {
+		chapterPath := filepath.Join(tempDir, "test-doc", "chapters", fmt.Sprintf("%02d", chapter.Number))
+		os.MkdirAll(chapterPath, 0755)
+		os.WriteFile(filepath.Join(chapterPath, "chapter.md"), []byte(chapter.Content), 0644)
+	}
+	os.WriteFile(filepath.Join(tempDir, "test-doc", "manifest.yaml"), []byte("document: {}\n"), 0644)
+	style.Body.FontFamily = "Garamond"
+
+	result, err := exporter.ExportDocumentResult(context.Background(), "test-doc", manifest, style, pandocConfig, &types.ExportOptions{Format: types.ExportFormatPDF}, nil)
+	if err != nil {
+		t.Fatalf("ExportDocumentResult() error = %v", err)
+	}
+	if len(result.Warnings) != 1 || !strings.Contains(result.Warnings[0], `Font "Garamond" (body) is not installed`) {
+		t.Errorf("Expected a warning for the missing font, got %v", result.Warnings)
+	}
+	if result.PDFEngine != "pdflatex" {
+		t.Errorf("Expected pdflatex once the missing font is replaced by the default, got %s", result.PDFEngine)
+	}
+}
+
+func TestExporter_BundleFonts(t *testing.T) {
+	exporter, tempDir := setupTestExporter(t)
+	defer os.RemoveAll(tempDir)
+
+	documentFonts := exporter.config.DocumentFontsPath("test-doc")
+	sharedFonts := exporter.config.FontsPath()
+	os.MkdirAll(documentFonts, 0755)
+	os.MkdirAll(sharedFonts, 0755)
+	for _, path := range []string{
+		filepath.Join(documentFonts, "Serif-Regular.otf"),
+		filepath.Join(documentFonts, "Serif-Bold.otf"),
+		filepath.Join(sharedFonts, "Serif-Regular.otf"),
+		filepath.Join(sharedFonts, "Mono.ttf"),
+	} {
+		os.WriteFile(path, []byte("font"), 0644)
+	}
+
+	style := &types.Style{
+		Body:      types.TextStyle{FontFile: "Serif-Regular.otf"},
+		Heading:   types.TextStyle{FontFamily: "Georgia", FontFile: "Missing.otf"},
+		Monospace: types.TextStyle{FontFamily: "Code", FontFile: "Mono.ttf"},
+	}
+	bundled, warnings := exporter.bundleFonts("test-doc", style)
+	if bundled.Body.FontFile != filepath.Join(documentFonts, "Serif-Regular.otf") || bundled.Body.FontFamily != "Serif" {
+		t.Errorf("Expected the document's font named after its file, got %+v", bundled.Body)
+	}
+	if bundled.Monospace.FontFile != filepath.Join(sharedFonts, "Mono.ttf") {
+		t.Errorf("Expected the shared font, got %+v", bundled.Monospace)
+	}
+	if bundled.Heading.FontFile != "" || len(warnings) != 1 || !strings.Contains(warnings[0], `Font file "Missing.otf" (heading)`) || !strings.Contains(warnings[0], `using font "Georgia" instead`) {
+		t.Errorf("Expected the missing file dropped with a warning, got %+v %v", bundled.Heading, warnings)
+	}
+	if style.Body.FontFile != "Serif-Regular.otf" {
+		t.Error("The style should be left alone")
+	}
+
+	// Bundled fonts are loaded from their files with their other faces
+	header := generateLaTeXHeader(bundled, &types.Manifest{})
+	if !strings.Contains(header, "\\setmainfont{Serif-Regular.otf}[Path="+filepath.ToSlash(documentFonts)+"/,BoldFont=Serif-Bold.otf]") || !strings.Contains(header, "\\setmonofont{Mono.ttf}[Path="+filepath.ToSlash(sharedFonts)+"/]") {
+		t.Errorf("Expected fontspec to load the files:\n%s", header)
+	}
+	css := fontFaceCSS(bundled, func(path string) string { return "../fonts/" + filepath.Base(path) })
+	if !strings.Contains(css, "font-family: 'Serif';\n    src: url('../fonts/Serif-Bold.otf') format('opentype');\n    font-weight: bold;") || !strings.Contains(css, "url('../fonts/Mono.ttf') format('truetype')") {
+		t.Errorf("Unexpected font faces:\n%s", css)
+	}
+
+	// EPUB exports embed every face
+	exporter.config.PandocPath = filepath.Join(tempDir, "missing-pandoc")
+	cmd := exporter.GeneratePandocCommand(context.Background(), "test-doc", filepath.Join(tempDir, "input.md"), filepath.Join(tempDir, "out.epub"), &types.Manifest{}, bundled, &types.PandocConfig{}, &types.ExportOptions{Format: types.ExportFormatEPUB}, "")
+	args := strings.Join(cmd.Args, " ")
+	for _, want := range []string{"--epub-embed-font " + filepath.Join(documentFonts, "Serif-Bold.otf"), "--epub-embed-font " + filepath.Join(sharedFonts, "Mono.ttf"), "--css " + filepath.Join(tempDir, "test-doc-epub.css")} {
+		if !strings.Contains(args, want) {
+			t.Errorf("Expected %q in %s", want, args)
+		}
+	}
+	epubCSS, _ := os.ReadFile(filepath.Join(tempDir, "test-doc-epub.css"))
+	if !strings.Contains(string(epubCSS), "code, pre {\n    font-family: 'Code';\n}") {
+		t.Errorf("Expected the stylesheet to apply the fonts:\n%s", epubCSS)
+	}
+}
+
+func TestFontFaces(t *testing.T) {
+	dir := t.TempDir()
+	for _, name := range []string{"Sans.ttf", "Sans-Italic.ttf", "Sans-BoldItalic.ttf"} {
+		os.WriteFile(filepath.Join(dir, name), []byte("font"), 0644)
+	}
+	var names []string
+	for _, face := range fontFaces(filepath.Join(dir, "Sans.ttf")) {
+		names = append(names, face.name()+"="+filepath.Base(face.path))
+	}
+	if got := strings.Join(names, ", "); got != "Regular=Sans.ttf, Italic=Sans-Italic.ttf, BoldItalic=Sans-BoldItalic.ttf" {
+		t.Errorf("fontFaces() = %s", got)
+	}
+	if family := fontFileFamily("/fonts/Source-Roman.otf"); family != "Source" {
+		t.Errorf("fontFileFamily() = %q", family)
+	}
+}
@@ -0,0 +1,119 @@
+package export
+
+import (
+	"fmt"
+	"html"
+	"path"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/gomcpgo/docgen/pkg/types"
+)
+
+var (
+	// confluenceFigurePattern matches a figure pandoc writes, capturing its
+	// image and caption
+	confluenceFigurePattern = regexp.MustCompile(`(?s)<figure[^>]*>\s*(.*?)\s*<figcaption[^>]*>(.*?)</figcaption>\s*</figure>`)
+	// confluenceCodePattern matches a code block pandoc writes without
+	// highlighting, capturing its classes and code
+	confluenceCodePattern = regexp.MustCompile(`(?s)<pre(?:\s+class="([^"]*)")?[^>]*>\s*<code[^>]*>(.*?)</code>\s*</pre>`)
+	// confluenceImagePattern matches an image tag
+	confluenceImagePattern = regexp.MustCompile(`<img\s[^>]*?/?>`)
+	// confluenceAttrPattern matches an attribute of a tag
+	confluenceAttrPattern = regexp.MustCompile(`\b(src|alt|title|width|height)="([^"]*)"`)
+	// confluenceVoidPattern matches void elements, which XHTML closes
+	confluenceVoidPattern = regexp.MustCompile(`<(br|hr|col|wbr)\b(\s[^>]*?)??\s*/?>`)
+	// confluenceSectionPattern matches the section elements pandoc wraps
+	// footnotes and sections in
+	confluenceSectionPattern = regexp.MustCompile(`<(/?)section\b`)
+)
+
+// fragmentArgs returns the pandoc arguments for a body-only HTML fragment.
+// Confluence's code macro highlights code itself, so its code is left plain.
+func fragmentArgs(fragment *types.HTMLFragment) []string {
+	args := []string{"--to", "html5"}
+	if fragment.HeadingOffset != 0 {
+		args = append(args, fmt.Sprintf("--shift-heading-level-by=%d", fragment.HeadingOffset))
+	}
+	if fragment.Confluence {
+		args = append(args, "--no-highlight")
+	}
+	return args
+}
+
+// rebaseImagePaths points the relative image paths of a document's markdown
+// at the base URL its images are served from; images in assets/images are
+// expected there by file name alone
+func rebaseImagePaths(markdown, baseURL string) string {
+	baseURL = strings.TrimRight(baseURL, "/")
+	if baseURL == "" {
+		return markdown
+	}
+	for _, pattern := range []*regexp.Regexp{markdownImagePathPattern, htmlImagePathPattern} {
+		markdown = pattern.ReplaceAllStringFunc(markdown, func(match string) string {
+			parts := pattern.FindStringSubmatch(match)
+			imagePath := parts[2]
+			if strings.Contains(imagePath, ":") || filepath.IsAbs(imagePath) || strings.HasPrefix(imagePath, "#") {
+				return match
+			}
+			imagePath = strings.TrimPrefix(filepath.ToSlash(filepath.Clean(imagePath)), "assets/images/")
+			return parts[1] + baseURL + "/" + imagePath
+		})
+	}
+	return markdown
+}
+
+// confluenceStorageFormat turns an HTML fragment written by pandoc into
+// Confluence's XHTML storage format: images become image macros, attached to
+// the page by file name when they have a relative path, code blocks become
+// code macros and figures paragraphs with their caption
+func confluenceStorageFormat(fragment string) string {
+	fragment = confluenceFigurePattern.ReplaceAllString(fragment, "<p>$1</p>\n<p><em>$2</em></p>")
+
+	fragment = confluenceImagePattern.ReplaceAllStringFunc(fragment, func(match string) string {
+		attrs := make(map[string]string)
+		for _, attr := range confluenceAttrPattern.FindAllStringSubmatch(match, -1) {
+			attrs[attr[1]] = attr[2]
+		}
+		src := attrs["src"]
+		var image strings.Builder
+		image.WriteString("<ac:image")
+		if attrs["alt"] != "" {
+			image.WriteString(` ac:alt="` + attrs["alt"] + `"`)
+		}
+		if attrs["title"] != "" {
+			image.WriteString(` ac:title="` + attrs["title"] + `"`)
+		}
+		for _, dimension := range []string{"width", "height"} {
+			if value := attrs[dimension]; value != "" && strings.Trim(value, "0123456789") == "" {
+				image.WriteString(` ac:` + dimension + `="` + value + `"`)
+			}
+		}
+		image.WriteString(">")
+		if strings.Contains(src, ":") || strings.HasPrefix(src, "/") {
+			image.WriteString(`<ri:url ri:value="` + src + `" />`)
+		} else {
+			image.WriteString(`<ri:attachment ri:filename="` + path.Base(src) + `" />`)
+		}
+		image.WriteString("</ac:image>")
+		return image.String()
+	})
+
+	fragment = confluenceVoidPattern.ReplaceAllString(fragment, "<$1$2 />")
+	fragment = confluenceSectionPattern.ReplaceAllString(fragment, "<${1}div")
+
+	// Code goes last, since its text is no longer escaped in the macro
+	fragment = confluenceCodePattern.ReplaceAllStringFunc(fragment, func(match string) string {
+		parts := confluenceCodePattern.FindStringSubmatch(match)
+		var macro strings.Builder
+		macro.WriteString(`<ac:structured-macro ac:name="code">`)
+		if classes := strings.Fields(parts[1]); len(classes) > 0 {
+			macro.WriteString(`<ac:parameter ac:name="language">` + html.EscapeString(classes[0]) + `</ac:parameter>`)
+		}
+		code := strings.ReplaceAll(html.UnescapeString(parts[2]), "]]>", "]]]]><![CDATA[>")
+		macro.WriteString(`<ac:plain-text-body><![CDATA[` + code + `]]></ac:plain-text-body></ac:structured-macro>`)
+		return macro.String()
+	})
+	return fragment
+}
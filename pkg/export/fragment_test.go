@@ -0,0 +1,112 @@
+package export
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/gomcpgo/docgen/pkg/types"
+)
+
+// fragmentPandoc stands in for pandoc: it copies its input next to the output
+// and writes a fragment with a figure and a code block
+const fragmentPandoc = `#!/bin/sh
+input="$1"
+while [ $# -gt 0 ]; do
+  if [ "$1" = "-o" ]; then out="$2"; fi
+  shift
+done
+dir=$(dirname "$out")
+cp "$input" "$dir/input.md"
+cat > "$out" <<'EOF'
+<h2 id="introduction">Introduction</h2>
+<p>Text<br>more.</p>
+<figure>
+<img src="https://cdn.example.com/report/plot.png" alt="Plot" />
+<figcaption aria-hidden="true">Plot</figcaption>
+</figure>
+<pre class="python"><code>if a &lt; b:
+    print(&quot;&lt;br&gt;&quot;)</code></pre>
+EOF
+`
+
+func TestRebaseImagePaths(t *testing.T) {
+	markdown := "![Plot](assets/images/plot.png){width=50%}\n\n![Logo](https://example.com/logo.png)\n\n<img src=\"diagrams/flow.svg\" alt=\"Flow\">"
+	want := "![Plot](https://cdn.example.com/report/plot.png){width=50%}\n\n![Logo](https://example.com/logo.png)\n\n<img src=\"https://cdn.example.com/report/diagrams/flow.svg\" alt=\"Flow\">"
+	if got := rebaseImagePaths(markdown, "https://cdn.example.com/report/"); got != want {
+		t.Errorf("rebaseImagePaths() =\n%s\nwant:\n%s", got, want)
+	}
+	if got := rebaseImagePaths(markdown, ""); got != markdown {
+		t.Errorf("Expected no change without a base URL, got:\n%s", got)
+	}
+}
+
+func TestConfluenceStorageFormat(t *testing.T) {
+	fragment := "<p>One<br>two<br/></p>\n<hr>\n<figure>\n<img src=\"assets/images/plot.png\" alt=\"A plot\" width=\"300\" style=\"width:50%\" />\n<figcaption aria-hidden=\"true\">A plot</figcaption>\n</figure>\n" +
+		"<p><img src=\"https://example.com/logo.png\" alt=\"Logo\" /></p>\n<pre class=\"go\"><code>x := a &lt; b &amp;&amp; c\n// ]]&gt; &lt;br&gt;</code></pre>\n<pre><code>plain</code></pre>\n" +
+		"<section id=\"footnotes\" class=\"footnotes\"><hr /><ol><li>Note</li></ol></section>"
+	want := "<p>One<br />two<br /></p>\n<hr />\n<p><ac:image ac:alt=\"A plot\" ac:width=\"300\"><ri:attachment ri:filename=\"plot.png\" /></ac:image></p>\n<p><em>A plot</em></p>\n" +
+		"<p><ac:image ac:alt=\"Logo\"><ri:url ri:value=\"https://example.com/logo.png\" /></ac:image></p>\n" +
+		"<ac:structured-macro ac:name=\"code\"><ac:parameter ac:name=\"language\">go</ac:parameter><ac:plain-text-body><![CDATA[x := a < b && c\n// ]]]]><![CDATA[> <br>]]></ac:plain-text-body></ac:structured-macro>\n" +
+		"<ac:structured-macro ac:name=\"code\"><ac:plain-text-body><![CDATA[plain]]></ac:plain-text-body></ac:structured-macro>\n" +
+		"<div id=\"footnotes\" class=\"footnotes\"><hr /><ol><li>Note</li></ol></div>"
+	if got := confluenceStorageFormat(fragment); got != want {
+		t.Errorf("confluenceStorageFormat() =\n%s\nwant:\n%s", got, want)
+	}
+}
+
+func TestExporter_ExportFragment(t *testing.T) {
+	exporter, tempDir := setupTestExporter(t)
+	defer os.RemoveAll(tempDir)
+
+	exporter.config.PandocPath = filepath.Join(tempDir, "bin", "pandoc")
+	os.MkdirAll(filepath.Dir(exporter.config.PandocPath), 0755)
+	if err := os.WriteFile(exporter.config.PandocPath, []byte(fragmentPandoc), 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	doc, manifest, style, pandocConfig := createTestDocument(t, tempDir)
+	for _, chapter := range doc.Chapters {
+		chapterPath := filepath.Join(tempDir, "test-doc", "chapters", fmt.Sprintf("%02d", chapter.Number))
+		os.MkdirAll(chapterPath, 0755)
+		os.WriteFile(filepath.Join(chapterPath, "chapter.md"), []byte(chapter.Content+"\n\n![Plot](assets/images/plot.png)"), 0644)
+	}
+	os.WriteFile(filepath.Join(tempDir, "test-doc", "manifest.yaml"), []byte("document: {}\n"), 0644)
+
+	fragment := &types.HTMLFragment{HeadingOffset: 1, ImageBaseURL: "https://cdn.example.com/report"}
+	options := &types.ExportOptions{Format: types.ExportFormatHTML, Fragment: fragment}
+	args := strings.Join(exporter.GeneratePandocCommand(context.Background(), "test-doc", "input.md", "out.html", manifest, style, pandocConfig, options, "style.css").Args, " ")
+	if !strings.Contains(args, "--to html5 --shift-heading-level-by=1") || strings.Contains(args, "--standalone") || strings.Contains(args, "--css") || strings.Contains(args, "--no-highlight") {
+		t.Errorf("Unexpected fragment arguments: %s", args)
+	}
+
+	result, err := exporter.ExportDocumentResult(context.Background(), "test-doc", manifest, style, pandocConfig, options, nil)
+	if err != nil {
+		t.Fatalf("ExportDocumentResult() error = %v", err)
+	}
+	if filepath.Base(result.OutputPath) != "test-doc.fragment.html" {
+		t.Errorf("Expected the fragment apart from full HTML exports, got %s", result.OutputPath)
+	}
+	input, _ := os.ReadFile(filepath.Join(filepath.Dir(result.OutputPath), "input.md"))
+	if !strings.Contains(string(input), "![Plot](https://cdn.example.com/report/plot.png)") {
+		t.Errorf("Expected the images rebased in pandoc's input:\n%s", input)
+	}
+
+	fragment.Confluence = true
+	result, err = exporter.ExportDocumentResult(context.Background(), "test-doc", manifest, style, pandocConfig, options, nil)
+	if err != nil {
+		t.Fatalf("ExportDocumentResult() error = %v", err)
+	}
+	if filepath.Base(result.OutputPath) != "test-doc.confluence.xml" {
+		t.Errorf("Unexpected Confluence output path %s", result.OutputPath)
+	}
+	storage, _ := os.ReadFile(result.OutputPath)
+	for _, want := range []string{"<p>Text<br />more.</p>", `<ri:url ri:value="https://cdn.example.com/report/plot.png" />`, "<![CDATA[if a < b:\n    print(\"<br>\")]]>"} {
+		if !strings.Contains(string(storage), want) {
+			t.Errorf("Expected %q in the storage format:\n%s", want, storage)
+		}
+	}
+}
@@ -0,0 +1,123 @@
+package export
+
+import (
+	"fmt"
+	"html"
+	"regexp"
+	"strings"
+
+	"github.com/gomcpgo/docgen/pkg/types"
+)
+
+// frontMatterHeadingPattern matches an ATX heading line
+var frontMatterHeadingPattern = regexp.MustCompile(`^(#{1,6})\s+(.+?)\s*$`)
+
+// frontMatterBadgeStyle keeps badges readable without a stylesheet
+const frontMatterBadgeStyle = "display: inline-block; margin-right: 0.4em; padding: 0.1em 0.6em; border-radius: 0.8em; background: #e8eef7; color: #24364f; font-size: 0.8em;"
+
+// addFrontMatterBadges puts the chapter's and its sections' front matter for
+// keys under their headings, as raw HTML that other formats drop
+func addFrontMatterBadges(content string, chapter *types.Chapter, keys []string) string {
+	lines := strings.Split(content, "\n")
+	headings := chapterHeadings(lines, chapter)
+	output := make([]string, 0, len(lines))
+
+	for i, line := range lines {
+		output = append(output, line)
+		section, ok := headings[i]
+		if !ok {
+			continue
+		}
+		frontMatter := chapter.FrontMatter
+		if section >= 0 {
+			frontMatter = chapter.Sections[section].FrontMatter
+		}
+		if badges := frontMatterBadges(frontMatter, keys); badges != "" {
+			output = append(output, "", "```{=html}", badges, "```")
+		}
+	}
+	return strings.Join(output, "\n")
+}
+
+// chapterHeadings finds the chapter's heading and its sections' headings in
+// its content lines, outside code blocks. It maps the line of each to the
+// index of its section, or -1 for the chapter heading.
+func chapterHeadings(lines []string, chapter *types.Chapter) map[int]int {
+	headings := make(map[int]int)
+	chapterDone := false
+	next := 0
+	fence := ""
+
+	for i, line := range lines {
+		trimmed := strings.TrimSpace(line)
+		if fence != "" {
+			if strings.HasPrefix(trimmed, fence) {
+				fence = ""
+			}
+			continue
+		}
+		if strings.HasPrefix(trimmed, "```") || strings.HasPrefix(trimmed, "~~~") {
+			fence = trimmed[:3]
+			continue
+		}
+		match := frontMatterHeadingPattern.FindStringSubmatch(line)
+		if match == nil {
+			continue
+		}
+
+		level := len(match[1])
+		switch {
+		case level == 1 && !chapterDone:
+			chapterDone = true
+			headings[i] = -1
+		case level > 1:
+			// Sections come in order, but those without a file have no heading
+			for j := next; j < len(chapter.Sections); j++ {
+				section := chapter.Sections[j]
+				if section.Level+1 == level && strings.HasSuffix(match[2], section.Title) {
+					headings[i] = j
+					next = j + 1
+					break
+				}
+			}
+		}
+	}
+	return headings
+}
+
+// insertUnderChapterHeading puts a block under the chapter's heading, or at
+// the start of content that has none
+func insertUnderChapterHeading(content string, chapter *types.Chapter, block string) string {
+	lines := strings.Split(content, "\n")
+	for i, section := range chapterHeadings(lines, chapter) {
+		if section < 0 {
+			return strings.Join(lines[:i+1], "\n") + "\n\n" + block + "\n" + strings.Join(lines[i+1:], "\n")
+		}
+	}
+	return block + "\n\n" + content
+}
+
+// frontMatterBadges renders the front matter values of keys as a row of badges
+func frontMatterBadges(frontMatter types.FrontMatter, keys []string) string {
+	var badges []string
+	for _, key := range keys {
+		value, ok := frontMatter[key]
+		if !ok {
+			continue
+		}
+		text := fmt.Sprint(value)
+		if items, isList := value.([]interface{}); isList {
+			parts := make([]string, len(items))
+			for i, item := range items {
+				parts[i] = fmt.Sprint(item)
+			}
+			text = strings.Join(parts, ", ")
+		}
+		badges = append(badges, fmt.Sprintf(`<span class="docgen-badge docgen-badge-%s" style="%s">%s: %s</span>`,
+			html.EscapeString(key), frontMatterBadgeStyle, html.EscapeString(key), html.EscapeString(text)))
+	}
+	if len(badges) == 0 {
+		return ""
+	}
+	return `<p class="docgen-front-matter">` + strings.Join(badges, " ") + `</p>`
+}
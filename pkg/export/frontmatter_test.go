@@ -0,0 +1,32 @@
+package export
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/gomcpgo/docgen/pkg/types"
+)
+
+func TestAddFrontMatterBadges(t *testing.T) {
+	chapter := &types.Chapter{
+		Number:      1,
+		Title:       "Basics",
+		FrontMatter: types.FrontMatter{"status": "review"},
+		Sections: []types.Section{
+			{Number: types.SectionNumber{1, 1}, Title: "Missing", Level: 1, FrontMatter: types.FrontMatter{"status": "draft"}},
+			{Number: types.SectionNumber{1, 2}, Title: "Start", Level: 1, FrontMatter: types.FrontMatter{"status": "<draft>", "tags": []interface{}{"a", "b"}, "owner": "Sam"}},
+		},
+	}
+	content := "# Chapter 1: Basics\n\n```\n## 1.2 Start\n```\n\n## 1.2 Start\n\nHello."
+
+	got := addFrontMatterBadges(content, chapter, []string{"status", "tags"})
+	if !strings.Contains(got, "# Chapter 1: Basics\n\n```{=html}\n<p class=\"docgen-front-matter\"><span class=\"docgen-badge docgen-badge-status\"") {
+		t.Errorf("Expected the chapter's badge under its heading:\n%s", got)
+	}
+	if strings.Count(got, "```{=html}") != 2 {
+		t.Errorf("Expected badges for the chapter and the one section heading, not the code block:\n%s", got)
+	}
+	if !strings.Contains(got, ">status: &lt;draft&gt;</span> <span") || !strings.Contains(got, ">tags: a, b</span>") || strings.Contains(got, "owner") {
+		t.Errorf("Unexpected section badges:\n%s", got)
+	}
+}
@@ -0,0 +1,322 @@
+package export
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/gomcpgo/docgen/pkg/types"
+)
+
+// generateHeaderFooterHeader creates the LaTeX preamble for a style's headers and
+// footers with fancyhdr. The first page is told apart by a flag cleared after
+// it is shipped out, odd and even pages by the page counter, so that both work
+// in one-sided documents. Chapter opening pages and the title page use the
+// plain page style, which is redefined when they need their own headers.
+func generateHeaderFooterHeader(hf types.HeaderFooter, vars TemplateVariables) string {
+	if !hf.IsSet() {
+		return ""
+	}
+
+	var header strings.Builder
+	header.WriteString("\n% Header/Footer\n")
+	header.WriteString("\\usepackage{fancyhdr}\n")
+	header.WriteString("\\usepackage{lastpage}\n")
+	header.WriteString("\\pagestyle{fancy}\n")
+	header.WriteString("\\fancyhf{}\n")
+
+	process := func(template string) string {
+		return ProcessTemplateForPDF(template, vars)
+	}
+	oddEven := func(odd, even string) string {
+		if odd == even {
+			return odd
+		}
+		return fmt.Sprintf("\\ifodd\\value{page}%s\\else %s\\fi", odd, even)
+	}
+	firstPage := func(first, other string) string {
+		if hf.FirstPage == nil || first == other {
+			return other
+		}
+		return fmt.Sprintf("\\ifdocgenfirstpage %s\\else %s\\fi", first, other)
+	}
+	writeSlots := func(head, foot string) {
+		if head != "" {
+			header.WriteString(fmt.Sprintf("\\fancyhead[C]{%s}\n", head))
+		}
+		if foot != "" {
+			header.WriteString(fmt.Sprintf("\\fancyfoot[C]{%s}\n", foot))
+		}
+	}
+
+	var first types.PageTemplates
+	if hf.FirstPage != nil {
+		first = *hf.FirstPage
+		header.WriteString("\\newif\\ifdocgenfirstpage\n")
+		header.WriteString("\\docgenfirstpagetrue\n")
+		header.WriteString("\\AddToHook{shipout/after}{\\global\\docgenfirstpagefalse}\n")
+	}
+
+	// Without templates for the other pages, they keep LaTeX's plain page number
+	odd, even := hf.Odd(), hf.Even()
+	if hf.HeaderTemplate == "" && hf.FooterTemplate == "" && hf.OddPage == nil && hf.EvenPage == nil {
+		odd.Footer, even.Footer = "{page}", "{page}"
+		header.WriteString("\\renewcommand{\\headrulewidth}{0pt}\n")
+	}
+	writeSlots(
+		firstPage(process(first.Header), oddEven(process(odd.Header), process(even.Header))),
+		firstPage(process(first.Footer), oddEven(process(odd.Footer), process(even.Footer))),
+	)
+
+	if hf.FirstPage != nil || hf.SuppressChapterStart {
+		// Chapter opening pages keep LaTeX's plain page number unless suppressed
+		chapterFoot := "\\thepage"
+		if hf.SuppressChapterStart {
+			chapterFoot = ""
+		}
+		header.WriteString("\\fancypagestyle{plain}{%\n")
+		header.WriteString("\\fancyhf{}\n")
+		header.WriteString("\\renewcommand{\\headrulewidth}{0pt}\n")
+		writeSlots(firstPage(process(first.Header), ""), firstPage(process(first.Footer), chapterFoot))
+		header.WriteString("}\n")
+	}
+
+	return header.String()
+}
+
+// generateHeaderFooterCSS creates the print CSS for a style's headers and
+// footers: @page rules for every page, :first, :left and :right pages, and,
+// when chapter opening pages are suppressed, the first page of each chapter.
+// Chapters are told apart by the sections pandoc wraps them in with
+// --section-divs.
+func generateHeaderFooterCSS(hf types.HeaderFooter, vars TemplateVariables) string {
+	if !hf.IsSet() {
+		return ""
+	}
+
+	var css strings.Builder
+	pageRule := func(selector string, templates types.PageTemplates, always bool) {
+		head := ProcessTemplateForHTML(templates.Header, vars)
+		foot := ProcessTemplateForHTML(templates.Footer, vars)
+		if !always && head == "" && foot == "" {
+			return
+		}
+		css.WriteString("    \n")
+		css.WriteString(fmt.Sprintf("    @page%s {\n", selector))
+		if head != "" || always {
+			css.WriteString(fmt.Sprintf("        @top-center { content: %s; }\n", pageMarginContent(head)))
+		}
+		if foot != "" || always {
+			css.WriteString(fmt.Sprintf("        @bottom-center { content: %s; }\n", pageMarginContent(foot)))
+		}
+		css.WriteString("    }\n")
+	}
+
+	pageRule("", types.PageTemplates{Header: hf.HeaderTemplate, Footer: hf.FooterTemplate}, false)
+	if hf.OddPage != nil {
+		pageRule(" :right", *hf.OddPage, true)
+	}
+	if hf.EvenPage != nil {
+		pageRule(" :left", *hf.EvenPage, true)
+	}
+	if hf.SuppressChapterStart {
+		css.WriteString("    \n")
+		css.WriteString("    section.level1 {\n")
+		css.WriteString("        page: chapter;\n")
+		css.WriteString("    }\n")
+		pageRule(" chapter:first", types.PageTemplates{}, true)
+		pageRule(" :nth(1 of chapter)", types.PageTemplates{}, true)
+	}
+	// The first page rule comes last so that it wins over chapter rules
+	if hf.FirstPage != nil {
+		pageRule(" :first", *hf.FirstPage, true)
+	}
+
+	return css.String()
+}
+
+// pageMarginContent returns the content value of a page margin box
+func pageMarginContent(text string) string {
+	if text == "" {
+		return "none"
+	}
+	return "'" + strings.NewReplacer(`\`, `\\`, "'", `\'`).Replace(text) + "'"
+}
+
+// DOCX parts and relationships added for the headers and footers of a style
+const (
+	headerFooterPartPrefix = "word/docgen-"
+	headerFooterIDPrefix   = "rIdDocgenHF"
+)
+
+var (
+	// templateFieldPattern matches the template variables that become Word fields
+	templateFieldPattern = regexp.MustCompile(`\{(page|total_pages|chapter_title|section_title)\}`)
+	// headerFooterRefPattern matches any header or footer reference of a section
+	headerFooterRefPattern = regexp.MustCompile(`<w:(header|footer)Reference\b[^>]*/>`)
+	// headerFooterRefSubmatchPattern captures the kind and type of a header or footer reference
+	headerFooterRefSubmatchPattern = regexp.MustCompile(`<w:(header|footer)Reference\b[^>]*w:type="(default|first|even)"[^>]*/>`)
+	titlePgPattern                 = regexp.MustCompile(`<w:titlePg\b[^>]*/>`)
+	// Elements that follow w:titlePg in a section's properties
+	afterTitlePgPattern = regexp.MustCompile(`<w:(textDirection|bidi|rtlGutter|docGrid|printerSettings|sectPrChange)\b`)
+	evenAndOddPattern   = regexp.MustCompile(`<w:evenAndOddHeaders\b[^>]*/>`)
+	// Elements that follow w:evenAndOddHeaders in the document settings
+	afterEvenAndOddPattern = regexp.MustCompile(`<w:(bookFold\w*|drawingGrid\w*|display\w*DrawingGridEvery|doNotUseMarginsForDrawingGridOrigin|doNotShadeFormData|noPunctuationKerning|characterSpacingControl|printTwoOnOne|strictFirstAndLastChars|noLineBreaks\w*|savePreviewPicture|updateFields|hdrShapeDefaults|footnotePr|endnotePr|compat|docVars|rsids|mathPr|attachedSchema|themeFontLang|clrSchemeMapping|shapeDefaults|decimalSymbol|listSeparator)\b`)
+)
+
+// docxHeaderFooter is a header or footer part of a DOCX reference document
+type docxHeaderFooter struct {
+	kind     string // "header" or "footer"
+	pageType string // "default", "first" or "even"
+	template string
+}
+
+// headerFooterReferenceDoc writes a copy of a DOCX reference document, or
+// pandoc's default one when base is empty, whose last section uses a style's
+// headers and footers: default ones for odd pages, and first page and even page
+// ones as Word's "different first page" and "different odd and even pages"
+// settings. Page numbers, the page count and chapter and section titles are
+// Word fields. Chapter opening pages can't be told apart in a one-section
+// document, so they aren't suppressed.
+func headerFooterReferenceDoc(pandocPath, base, outputPath string, hf types.HeaderFooter, vars TemplateVariables) error {
+	data, err := readReferenceDoc(pandocPath, base)
+	if err != nil {
+		return err
+	}
+
+	// The reference document keeps its own default header and footer unless
+	// the style replaces them
+	var parts []docxHeaderFooter
+	odd := hf.Odd()
+	if odd.Header != "" || hf.OddPage != nil {
+		parts = append(parts, docxHeaderFooter{"header", "default", odd.Header})
+	}
+	if odd.Footer != "" || hf.OddPage != nil {
+		parts = append(parts, docxHeaderFooter{"footer", "default", odd.Footer})
+	}
+	if hf.FirstPage != nil {
+		parts = append(parts, docxHeaderFooter{"header", "first", hf.FirstPage.Header}, docxHeaderFooter{"footer", "first", hf.FirstPage.Footer})
+	}
+	evenPages := hf.EvenPage != nil
+	if evenPages {
+		parts = append(parts, docxHeaderFooter{"header", "even", hf.EvenPage.Header}, docxHeaderFooter{"footer", "even", hf.EvenPage.Footer})
+	}
+
+	contents := map[string]string{}
+	references := map[string]string{}
+	var overrides, relationships strings.Builder
+	for _, part := range parts {
+		name := fmt.Sprintf("%s%s-%s.xml", headerFooterPartPrefix, part.kind, part.pageType)
+		id := headerFooterIDPrefix + part.kind + "-" + part.pageType
+		contents[name] = headerFooterXML(part.kind, part.template, vars)
+		overrides.WriteString(fmt.Sprintf(`<Override PartName="/%s" ContentType="application/vnd.openxmlformats-officedocument.wordprocessingml.%s+xml"/>`, name, part.kind))
+		relationships.WriteString(fmt.Sprintf(`<Relationship Id="%s" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/%s" Target="%s"/>`, id, part.kind, strings.TrimPrefix(name, "word/")))
+		references[part.kind+" "+part.pageType] = fmt.Sprintf(`<w:%sReference w:type="%s" r:id="%s"/>`, part.kind, part.pageType, id)
+	}
+
+	return rewriteReferenceDoc(data, outputPath, func(name string, content []byte) []byte {
+		switch name {
+		case "[Content_Types].xml":
+			return []byte(strings.Replace(string(content), "</Types>", overrides.String()+"</Types>", 1))
+		case "word/_rels/document.xml.rels":
+			return []byte(strings.Replace(string(content), "</Relationships>", relationships.String()+"</Relationships>", 1))
+		case "word/document.xml":
+			return editLastSectPr(content, func(body string) string {
+				body = replaceHeaderFooterRefs(body, references)
+				body = titlePgPattern.ReplaceAllString(body, "")
+				if hf.FirstPage != nil {
+					body = insertBefore(body, afterTitlePgPattern, "<w:titlePg/>")
+				}
+				return body
+			})
+		case "word/settings.xml":
+			settings := evenAndOddPattern.ReplaceAllString(string(content), "")
+			if evenPages {
+				if loc := afterEvenAndOddPattern.FindStringIndex(settings); loc != nil {
+					settings = settings[:loc[0]] + "<w:evenAndOddHeaders/>" + settings[loc[0]:]
+				} else {
+					settings = strings.Replace(settings, "</w:settings>", "<w:evenAndOddHeaders/></w:settings>", 1)
+				}
+			}
+			return []byte(settings)
+		}
+		return content
+	}, contents)
+}
+
+// replaceHeaderFooterRefs replaces the header and footer references of a
+// section's properties with those given by kind and type, such as "footer
+// first", keeping the others. Header references come before footer references.
+func replaceHeaderFooterRefs(body string, references map[string]string) string {
+	kept := map[string]string{}
+	for _, match := range headerFooterRefSubmatchPattern.FindAllStringSubmatch(body, -1) {
+		kept[match[1]+" "+match[2]] = match[0]
+	}
+	for key, reference := range references {
+		kept[key] = reference
+	}
+
+	var refs strings.Builder
+	for _, kind := range []string{"header", "footer"} {
+		for _, pageType := range []string{"default", "first", "even"} {
+			refs.WriteString(kept[kind+" "+pageType])
+		}
+	}
+	return refs.String() + headerFooterRefPattern.ReplaceAllString(body, "")
+}
+
+// headerFooterXML returns a header or footer part with a template as a
+// centered line, its page and title variables as Word fields
+func headerFooterXML(kind, template string, vars TemplateVariables) string {
+	// Document variables are filled in; the others become fields
+	text := template
+	for placeholder, value := range map[string]string{
+		"{document_title}": vars.DocumentTitle,
+		"{author}":         vars.Author,
+		"{date}":           vars.Date,
+		"{chapter_number}": "",
+	} {
+		text = strings.ReplaceAll(text, placeholder, value)
+	}
+
+	var runs strings.Builder
+	writeText := func(t string) {
+		if t != "" {
+			runs.WriteString(`<w:r><w:t xml:space="preserve">` + xmlEscape(t) + `</w:t></w:r>`)
+		}
+	}
+	last := 0
+	for _, match := range templateFieldPattern.FindAllStringSubmatchIndex(text, -1) {
+		writeText(text[last:match[0]])
+		var instr string
+		switch text[match[2]:match[3]] {
+		case "page":
+			instr = "PAGE"
+		case "total_pages":
+			instr = "NUMPAGES"
+		case "chapter_title":
+			instr = `STYLEREF "Heading 1"`
+		case "section_title":
+			instr = `STYLEREF "Heading 2"`
+		}
+		runs.WriteString(`<w:fldSimple w:instr=" ` + xmlEscape(instr) + ` "><w:r><w:t>1</w:t></w:r></w:fldSimple>`)
+		last = match[1]
+	}
+	writeText(text[last:])
+
+	element, style := "w:hdr", "Header"
+	if kind == "footer" {
+		element, style = "w:ftr", "Footer"
+	}
+	return `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<` + element + ` ` + wordNamespaces + `><w:p><w:pPr><w:pStyle w:val="` + style + `"/><w:jc w:val="center"/></w:pPr>` +
+		runs.String() + `</w:p></` + element + `>`
+}
+
+// insertBefore inserts element before the first match of pattern, or at the end
+func insertBefore(body string, pattern *regexp.Regexp, element string) string {
+	if loc := pattern.FindStringIndex(body); loc != nil {
+		return body[:loc[0]] + element + body[loc[0]:]
+	}
+	return body + element
+}
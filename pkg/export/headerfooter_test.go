@@ -0,0 +1,123 @@
+package export
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/gomcpgo/docgen/pkg/types"
+)
+
+func TestGenerateHeaderFooterHeader(t *testing.T) {
+	vars := TemplateVariables{DocumentTitle: "Field Guide"}
+	if header := generateHeaderFooterHeader(types.HeaderFooter{}, vars); header != "" {
+		t.Errorf("Expected no header without templates, got %q", header)
+	}
+
+	header := generateHeaderFooterHeader(types.HeaderFooter{
+		HeaderTemplate: "{document_title}",
+		FooterTemplate: "{page}",
+		FirstPage:      &types.PageTemplates{},
+		EvenPage:       &types.PageTemplates{Header: "{chapter_title}", Footer: "{page}"},
+	}, vars)
+	for _, want := range []string{
+		"\\AddToHook{shipout/after}{\\global\\docgenfirstpagefalse}",
+		"\\fancyhead[C]{\\ifdocgenfirstpage \\else \\ifodd\\value{page}Field Guide\\else \\leftmark\\fi\\fi}",
+		"\\fancyfoot[C]{\\ifdocgenfirstpage \\else \\thepage\\fi}",
+		// The title page and chapter openings use the plain style
+		"\\fancyfoot[C]{\\ifdocgenfirstpage \\else \\thepage\\fi}\n}",
+	} {
+		if !strings.Contains(header, want) {
+			t.Errorf("Expected %q in the header:\n%s", want, header)
+		}
+	}
+
+	// Suppressing chapter openings alone keeps the page numbers elsewhere
+	header = generateHeaderFooterHeader(types.HeaderFooter{SuppressChapterStart: true}, vars)
+	if !strings.Contains(header, "\\fancyfoot[C]{\\thepage}") {
+		t.Errorf("Expected page numbers on other pages:\n%s", header)
+	}
+	plain := header[strings.Index(header, "\\fancypagestyle{plain}"):]
+	if strings.Contains(plain, "\\fancyfoot") || strings.Contains(plain, "\\fancyhead") {
+		t.Errorf("Expected empty chapter opening pages:\n%s", header)
+	}
+}
+
+func TestGenerateHeaderFooterCSS(t *testing.T) {
+	css := generateHeaderFooterCSS(types.HeaderFooter{
+		HeaderTemplate:       "{document_title}",
+		FirstPage:            &types.PageTemplates{Footer: "Draft"},
+		OddPage:              &types.PageTemplates{Header: "Reader's copy"},
+		SuppressChapterStart: true,
+	}, TemplateVariables{DocumentTitle: "Field Guide"})
+
+	for _, want := range []string{
+		"@page {\n        @top-center { content: 'Field Guide'; }\n    }",
+		"@page :right {\n        @top-center { content: 'Reader\\'s copy'; }\n        @bottom-center { content: none; }",
+		"section.level1 {\n        page: chapter;",
+		"@page chapter:first {\n        @top-center { content: none; }",
+		"@page :first {\n        @top-center { content: none; }\n        @bottom-center { content: 'Draft'; }",
+	} {
+		if !strings.Contains(css, want) {
+			t.Errorf("Expected %q in the CSS:\n%s", want, css)
+		}
+	}
+	if strings.Contains(css, ":left") {
+		t.Errorf("Did not expect an even page rule:\n%s", css)
+	}
+}
+
+func TestHeaderFooterReferenceDoc(t *testing.T) {
+	tempDir := t.TempDir()
+
+	// A minimal reference document with its own default header and footer
+	base := filepath.Join(tempDir, "base.docx")
+	writeZip(t, base, map[string]string{
+		"[Content_Types].xml":          `<Types></Types>`,
+		"word/_rels/document.xml.rels": `<Relationships><Relationship Id="rId8" Target="header1.xml"/><Relationship Id="rId9" Target="footer1.xml"/></Relationships>`,
+		"word/document.xml":            `<w:document><w:body><w:p/><w:sectPr><w:headerReference w:type="default" r:id="rId8"/><w:footerReference w:type="default" r:id="rId9"/><w:pgSz w:w="12240"/><w:docGrid w:linePitch="360"/></w:sectPr></w:body></w:document>`,
+		"word/settings.xml":            `<w:settings><w:zoom w:percent="100"/><w:defaultTabStop w:val="720"/><w:compat/></w:settings>`,
+	})
+
+	output := filepath.Join(tempDir, "headers.docx")
+	hf := types.HeaderFooter{
+		FooterTemplate: "Page {page} of {total_pages}",
+		FirstPage:      &types.PageTemplates{Header: "{document_title} & more"},
+		EvenPage:       &types.PageTemplates{Header: "{chapter_title}"},
+	}
+	if err := headerFooterReferenceDoc("", base, output, hf, TemplateVariables{DocumentTitle: "Field Guide"}); err != nil {
+		t.Fatalf("headerFooterReferenceDoc() error = %v", err)
+	}
+	parts := readZip(t, output)
+
+	// The reference document keeps its default header; everything else is the style's
+	want := `<w:sectPr><w:headerReference w:type="default" r:id="rId8"/>` +
+		`<w:headerReference w:type="first" r:id="rIdDocgenHFheader-first"/>` +
+		`<w:headerReference w:type="even" r:id="rIdDocgenHFheader-even"/>` +
+		`<w:footerReference w:type="default" r:id="rIdDocgenHFfooter-default"/>` +
+		`<w:footerReference w:type="first" r:id="rIdDocgenHFfooter-first"/>` +
+		`<w:footerReference w:type="even" r:id="rIdDocgenHFfooter-even"/>` +
+		`<w:pgSz w:w="12240"/><w:titlePg/><w:docGrid w:linePitch="360"/></w:sectPr>`
+	if !strings.Contains(parts["word/document.xml"], want) {
+		t.Errorf("Unexpected section properties:\n%s", parts["word/document.xml"])
+	}
+	if !strings.Contains(parts["word/settings.xml"], `<w:defaultTabStop w:val="720"/><w:evenAndOddHeaders/><w:compat/>`) {
+		t.Errorf("Expected different odd and even pages, got %s", parts["word/settings.xml"])
+	}
+	if !strings.Contains(parts["word/_rels/document.xml.rels"], `Target="docgen-footer-default.xml"`) {
+		t.Errorf("Expected the footer relationship, got %s", parts["word/_rels/document.xml.rels"])
+	}
+
+	footer := parts["word/docgen-footer-default.xml"]
+	for _, want := range []string{"<w:ftr ", `<w:t xml:space="preserve">Page </w:t>`, `<w:fldSimple w:instr=" PAGE ">`, `<w:fldSimple w:instr=" NUMPAGES ">`} {
+		if !strings.Contains(footer, want) {
+			t.Errorf("Expected %q in the footer part:\n%s", want, footer)
+		}
+	}
+	if !strings.Contains(parts["word/docgen-header-first.xml"], "Field Guide &amp; more") {
+		t.Errorf("Expected the escaped title in the first page header:\n%s", parts["word/docgen-header-first.xml"])
+	}
+	if !strings.Contains(parts["word/docgen-header-even.xml"], `STYLEREF &#34;Heading 1&#34;`) {
+		t.Errorf("Expected a chapter title field in the even page header:\n%s", parts["word/docgen-header-even.xml"])
+	}
+}
@@ -0,0 +1,107 @@
+package export
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"os"
+	"os/exec"
+	"strings"
+
+	"github.com/gomcpgo/docgen/pkg/types"
+)
+
+// runHooks runs an export's hooks for an event in order, stopping at the
+// first that fails
+func (e *Exporter) runHooks(ctx context.Context, hooks []types.Hook, hookContext types.HookContext) error {
+	for _, hook := range hooks {
+		if hook.Event != hookContext.Event || !hook.RunsFor(hookContext.Format) {
+			continue
+		}
+		log.Printf("[DOCGEN HOOKS] Running %s hook %s for %s", hook.Event, hook.Label(), hookContext.DocumentID)
+		var err error
+		if hook.URL != "" {
+			err = e.callWebhook(ctx, hook, hookContext)
+		} else {
+			err = e.runHookCommand(ctx, hook, hookContext)
+		}
+		if err != nil {
+			return fmt.Errorf("%s hook %s failed: %w", hook.Event, hook.Label(), err)
+		}
+	}
+	return nil
+}
+
+// runHookCommand runs a hook's program in the document's directory with the
+// export timeout, passing the export's details in the environment and as JSON
+// on standard input
+func (e *Exporter) runHookCommand(ctx context.Context, hook types.Hook, hookContext types.HookContext) error {
+	payload, err := json.Marshal(hookContext)
+	if err != nil {
+		return err
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, e.config.ExportTimeout)
+	defer cancel()
+
+	cmd := exec.CommandContext(ctx, hook.Command[0], hook.Command[1:]...)
+	cmd.Dir = hookContext.DocumentPath
+	cmd.Stdin = bytes.NewReader(payload)
+	cmd.Env = append(os.Environ(),
+		"DOCGEN_HOOK_EVENT="+string(hookContext.Event),
+		"DOCGEN_DOCUMENT_ID="+string(hookContext.DocumentID),
+		"DOCGEN_FORMAT="+string(hookContext.Format),
+		"DOCGEN_DOCUMENT_PATH="+hookContext.DocumentPath,
+		"DOCGEN_MARKDOWN_PATH="+hookContext.MarkdownPath,
+		"DOCGEN_OUTPUT_PATH="+hookContext.OutputPath,
+	)
+
+	output, err := cmd.CombinedOutput()
+	if ctx.Err() == context.DeadlineExceeded {
+		return fmt.Errorf("timed out after %v", e.config.ExportTimeout)
+	}
+	if err != nil {
+		if message := strings.TrimSpace(string(output)); message != "" {
+			return fmt.Errorf("%w: %s", err, message)
+		}
+		return err
+	}
+	return nil
+}
+
+// callWebhook posts the export's details to a hook's URL as JSON. Any answer
+// but 2xx fails the hook.
+func (e *Exporter) callWebhook(ctx context.Context, hook types.Hook, hookContext types.HookContext) error {
+	payload, err := json.Marshal(hookContext)
+	if err != nil {
+		return err
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, e.config.ExportTimeout)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, hook.URL, bytes.NewReader(payload))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		if message := strings.TrimSpace(string(body)); message != "" {
+			return fmt.Errorf("webhook answered %s: %s", resp.Status, message)
+		}
+		return fmt.Errorf("webhook answered %s", resp.Status)
+	}
+	return nil
+}
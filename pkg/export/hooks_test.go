@@ -0,0 +1,99 @@
+package export
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/gomcpgo/docgen/pkg/types"
+)
+
+func TestExporter_ExportHooks(t *testing.T) {
+	exporter, tempDir := setupTestExporter(t)
+	defer os.RemoveAll(tempDir)
+
+	exporter.config.TempDir = filepath.Join(tempDir, "tmp")
+	exporter.config.PandocPath = filepath.Join(tempDir, "pandoc")
+	if err := os.WriteFile(exporter.config.PandocPath, []byte(fakePandoc), 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	doc, manifest, style, pandocConfig := createTestDocument(t, tempDir)
+	for _, chapter := range doc.Chapters {
+		chapterPath := filepath.Join(tempDir, "test-doc", "chapters", fmt.Sprintf("%02d", chapter.Number))
+		os.MkdirAll(chapterPath, 0755)
+		os.WriteFile(filepath.Join(chapterPath, "chapter.md"), []byte(chapter.Content), 0644)
+	}
+	os.WriteFile(filepath.Join(tempDir, "test-doc", "manifest.yaml"), []byte("document: {}\n"), 0644)
+
+	record := filepath.Join(tempDir, "hooks.log")
+	options := &types.ExportOptions{
+		Format: types.ExportFormatHTML,
+		Hooks: []types.Hook{
+			{Event: types.HookPostMarkdown, Command: []string{"sh", "-c", `echo "Added by a filter" >> "$DOCGEN_MARKDOWN_PATH"`}},
+			{Event: types.HookPostExport, Command: []string{"sh", "-c", `echo "$DOCGEN_HOOK_EVENT $DOCGEN_DOCUMENT_ID $DOCGEN_FORMAT $DOCGEN_OUTPUT_PATH $(pwd)" >> ` + record}},
+			{Event: types.HookPostExport, Command: []string{"sh", "-c", "exit 1"}, Formats: []types.ExportFormat{types.ExportFormatPDF}},
+		},
+	}
+	outputPath, err := exporter.ExportDocument(context.Background(), "test-doc", manifest, style, pandocConfig, options, nil)
+	if err != nil {
+		t.Fatalf("ExportDocument() error = %v", err)
+	}
+
+	logged, _ := os.ReadFile(record)
+	documentPath := exporter.config.DocumentPath("test-doc")
+	if want := fmt.Sprintf("post_export test-doc html %s %s\n", outputPath, documentPath); string(logged) != want {
+		t.Errorf("post_export hook logged %q, want %q", logged, want)
+	}
+	exportLog, err := exporter.LatestExportLog("test-doc")
+	if err != nil {
+		t.Fatalf("LatestExportLog() error = %v", err)
+	}
+	input, _ := os.ReadFile(filepath.Join(exportLog.Dir, exportLog.Files[0]))
+	if !strings.HasSuffix(string(input), "Added by a filter\n") {
+		t.Errorf("Expected pandoc to read the markdown the hook rewrote, got %q", input)
+	}
+
+	// A failing hook aborts the export before pandoc runs
+	options.Hooks = []types.Hook{{Name: "spellcheck", Event: types.HookPreMarkdown, Command: []string{"sh", "-c", "echo 3 misspellings; exit 2"}}}
+	_, err = exporter.ExportDocument(context.Background(), "test-doc", manifest, style, pandocConfig, options, nil)
+	if err == nil || !strings.Contains(err.Error(), "pre_markdown hook spellcheck failed") || !strings.Contains(err.Error(), "3 misspellings") {
+		t.Errorf("ExportDocument() error = %v, want the failing hook", err)
+	}
+}
+
+func TestExporter_Webhook(t *testing.T) {
+	exporter, tempDir := setupTestExporter(t)
+	defer os.RemoveAll(tempDir)
+
+	var received types.HookContext
+	status := http.StatusNoContent
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		json.NewDecoder(r.Body).Decode(&received)
+		w.WriteHeader(status)
+		if status != http.StatusNoContent {
+			fmt.Fprint(w, "upload rejected")
+		}
+	}))
+	defer server.Close()
+
+	hooks := []types.Hook{{Event: types.HookPostExport, URL: server.URL}}
+	hookContext := types.HookContext{Event: types.HookPostExport, DocumentID: "test-doc", Format: types.ExportFormatPDF, OutputPath: "/exports/test-doc.pdf"}
+	if err := exporter.runHooks(context.Background(), hooks, hookContext); err != nil {
+		t.Fatalf("runHooks() error = %v", err)
+	}
+	if received != hookContext {
+		t.Errorf("Webhook received %+v, want %+v", received, hookContext)
+	}
+
+	status = http.StatusBadGateway
+	if err := exporter.runHooks(context.Background(), hooks, hookContext); err == nil || !strings.Contains(err.Error(), "502 Bad Gateway: upload rejected") {
+		t.Errorf("runHooks() error = %v, want the webhook's answer", err)
+	}
+}
@@ -0,0 +1,122 @@
+package export
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/gomcpgo/docgen/pkg/types"
+)
+
+// Dark theme colors used where a style leaves them out
+const (
+	defaultDarkBackground   = "#1b1e23"
+	defaultDarkTextColor    = "#d8dee9"
+	defaultDarkHeadingColor = "#eceff4"
+	defaultDarkLinkColor    = "#88c0d0"
+)
+
+// sidebarBreakpoint is the narrowest screen the table of contents is pinned
+// beside the text on; narrower screens keep it above the text
+const sidebarBreakpoint = "1100px"
+
+// generateHTMLThemeCSS creates the CSS for a style's HTML layout and theme: the
+// text width, a table of contents pinned in a sidebar on wide screens, and a
+// dark theme following the reader's prefers-color-scheme setting. It is layered
+// over the document's own CSS, generated or custom, so it wins where both set
+// the same property.
+func generateHTMLThemeCSS(html types.HTMLStyle) string {
+	if !html.IsSet() {
+		return ""
+	}
+
+	var css strings.Builder
+	css.WriteString("/* HTML theme */\n")
+	if html.DarkMode {
+		css.WriteString(":root {\n    color-scheme: light dark;\n}\n\n")
+	}
+	if html.Background != "" {
+		css.WriteString(fmt.Sprintf("html, body {\n    background: %s;\n}\n\n", html.Background))
+	}
+	if html.MaxWidth != "" {
+		css.WriteString(fmt.Sprintf("body {\n    max-width: %s;\n}\n\n", html.MaxWidth))
+	}
+
+	if html.SidebarTOC {
+		css.WriteString(fmt.Sprintf("@media screen and (min-width: %s) {\n", sidebarBreakpoint))
+		css.WriteString("    body {\n")
+		css.WriteString("        margin-left: 20rem;\n")
+		css.WriteString("        margin-right: auto;\n")
+		css.WriteString("    }\n")
+		css.WriteString("    \n")
+		css.WriteString("    #TOC {\n")
+		css.WriteString("        position: fixed;\n")
+		css.WriteString("        top: 0;\n")
+		css.WriteString("        bottom: 0;\n")
+		css.WriteString("        left: 0;\n")
+		css.WriteString("        width: 17rem;\n")
+		css.WriteString("        margin: 0;\n")
+		css.WriteString("        overflow-y: auto;\n")
+		css.WriteString("        border-radius: 0;\n")
+		css.WriteString("        box-sizing: border-box;\n")
+		css.WriteString("    }\n")
+		css.WriteString("}\n\n")
+	}
+
+	if html.DarkMode {
+		background := colorOr(html.DarkBackground, defaultDarkBackground)
+		text := colorOr(html.DarkTextColor, defaultDarkTextColor)
+		heading := colorOr(html.DarkHeadingColor, defaultDarkHeadingColor)
+		link := colorOr(html.DarkLinkColor, defaultDarkLinkColor)
+
+		// Screen only, so printing keeps dark text on white paper
+		css.WriteString("@media screen and (prefers-color-scheme: dark) {\n")
+		css.WriteString("    html, body {\n")
+		css.WriteString(fmt.Sprintf("        background: %s;\n", background))
+		css.WriteString(fmt.Sprintf("        color: %s;\n", text))
+		css.WriteString("    }\n")
+		css.WriteString("    \n")
+		css.WriteString("    body {\n")
+		css.WriteString("        box-shadow: none;\n")
+		css.WriteString("    }\n")
+		css.WriteString("    \n")
+		css.WriteString("    h1, h2, h3, h4, h5, h6, th {\n")
+		css.WriteString(fmt.Sprintf("        color: %s;\n", heading))
+		css.WriteString("    }\n")
+		css.WriteString("    \n")
+		css.WriteString("    h1 {\n")
+		css.WriteString(fmt.Sprintf("        border-top-color: %s;\n", heading))
+		css.WriteString("    }\n")
+		css.WriteString("    \n")
+		css.WriteString("    a {\n")
+		css.WriteString(fmt.Sprintf("        color: %s;\n", link))
+		css.WriteString("    }\n")
+		css.WriteString("    \n")
+		css.WriteString("    code, pre, .code {\n")
+		css.WriteString(fmt.Sprintf("        color: %s;\n", text))
+		css.WriteString("    }\n")
+		css.WriteString("    \n")
+		css.WriteString("    #TOC {\n")
+		css.WriteString("        background: rgba(255, 255, 255, 0.05);\n")
+		css.WriteString(fmt.Sprintf("        border-left-color: %s;\n", link))
+		css.WriteString("    }\n")
+		css.WriteString("    \n")
+		css.WriteString("    th {\n")
+		css.WriteString("        background-color: rgba(255, 255, 255, 0.08);\n")
+		css.WriteString("    }\n")
+		css.WriteString("    \n")
+		css.WriteString("    th, td {\n")
+		css.WriteString("        border-color: rgba(255, 255, 255, 0.2);\n")
+		css.WriteString("    }\n")
+		css.WriteString("}\n")
+	}
+
+	return css.String()
+}
+
+// colorOr returns color, or fallback when it is empty
+func colorOr(color, fallback string) string {
+	if color == "" {
+		return fallback
+	}
+	return color
+}
@@ -0,0 +1,81 @@
+package export
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/gomcpgo/docgen/pkg/types"
+)
+
+func TestGenerateHTMLThemeCSS(t *testing.T) {
+	if css := generateHTMLThemeCSS(types.HTMLStyle{}); css != "" {
+		t.Errorf("Expected no theme CSS without HTML options, got %q", css)
+	}
+
+	css := generateHTMLThemeCSS(types.HTMLStyle{
+		MaxWidth:      "45rem",
+		SidebarTOC:    true,
+		DarkMode:      true,
+		DarkLinkColor: "#ffcc00",
+	})
+	for _, want := range []string{
+		"color-scheme: light dark;",
+		"body {\n    max-width: 45rem;\n}",
+		"@media screen and (min-width: 1100px) {",
+		"position: fixed;",
+		"@media screen and (prefers-color-scheme: dark) {",
+		"background: " + defaultDarkBackground + ";",
+		"color: #ffcc00;",
+		"border-left-color: #ffcc00;",
+	} {
+		if !strings.Contains(css, want) {
+			t.Errorf("Expected %q in the theme CSS:\n%s", want, css)
+		}
+	}
+
+	// Without dark mode the page keeps a single light theme
+	css = generateHTMLThemeCSS(types.HTMLStyle{Background: "#fdfaf3"})
+	if !strings.Contains(css, "background: #fdfaf3;") {
+		t.Errorf("Expected the light background:\n%s", css)
+	}
+	if strings.Contains(css, "prefers-color-scheme") || strings.Contains(css, "#TOC") {
+		t.Errorf("Did not expect dark mode or a sidebar:\n%s", css)
+	}
+}
+
+func TestExporter_GeneratePandocCommand_HTMLTheme(t *testing.T) {
+	exporter, tempDir := setupTestExporter(t)
+	defer os.RemoveAll(tempDir)
+
+	_, manifest, style, pandocConfig := createTestDocument(t, tempDir)
+	workDir := t.TempDir()
+	inputFile := filepath.Join(workDir, "test-doc-input.md")
+	options := &types.ExportOptions{Format: types.ExportFormatHTML}
+	pandocConfig.TOC = false
+
+	args := strings.Join(exporter.GeneratePandocCommand(context.Background(), "test-doc", inputFile, "out.html", manifest, style, pandocConfig, options, "").Args, " ")
+	if strings.Contains(args, "-theme.css") || strings.Contains(args, "--toc") {
+		t.Errorf("Expected no theme CSS or table of contents by default: %s", args)
+	}
+
+	// The sidebar needs a table of contents to hold
+	style.HTML = types.HTMLStyle{SidebarTOC: true, DarkMode: true}
+	args = strings.Join(exporter.GeneratePandocCommand(context.Background(), "test-doc", inputFile, "out.html", manifest, style, pandocConfig, options, "").Args, " ")
+	themeFile := filepath.Join(workDir, "test-doc-theme.css")
+	if !strings.Contains(args, "--css "+themeFile) || !strings.Contains(args, "--toc") {
+		t.Errorf("Expected the theme CSS and a table of contents: %s", args)
+	}
+	if theme, err := os.ReadFile(themeFile); err != nil || !strings.Contains(string(theme), "prefers-color-scheme: dark") {
+		t.Errorf("Expected the theme CSS next to the input file, got error %v", err)
+	}
+
+	// Other formats have no sidebar
+	options.Format = types.ExportFormatDOCX
+	args = strings.Join(exporter.GeneratePandocCommand(context.Background(), "test-doc", inputFile, "out.docx", manifest, style, pandocConfig, options, "").Args, " ")
+	if strings.Contains(args, "--toc") {
+		t.Errorf("Did not expect a table of contents for DOCX: %s", args)
+	}
+}
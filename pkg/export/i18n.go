@@ -0,0 +1,85 @@
+package export
+
+import (
+	"fmt"
+	"strings"
+)
+
+// languageScript describes how a non-Latin script is typeset
+type languageScript struct {
+	RTL bool // Right-to-left script (Arabic, Hebrew, ...)
+	CJK bool // Chinese, Japanese or Korean
+
+	// FontCommand is the polyglossia font family command for RTL scripts
+	FontCommand string
+	// FontOptions are the fontspec options used with FontCommand
+	FontOptions string
+	// Font is the fallback font used when the style does not provide one
+	Font string
+}
+
+// languageScripts maps primary BCP-47 language subtags to their script settings.
+// Languages not listed here are typeset as Latin text with the default engine.
+var languageScripts = map[string]languageScript{
+	"ar": {RTL: true, FontCommand: "arabicfont", FontOptions: "Script=Arabic", Font: "Noto Naskh Arabic"},
+	"fa": {RTL: true, FontCommand: "persianfont", FontOptions: "Script=Arabic", Font: "Noto Naskh Arabic"},
+	"ur": {RTL: true, FontCommand: "urdufont", FontOptions: "Script=Arabic", Font: "Noto Nastaliq Urdu"},
+	"he": {RTL: true, FontCommand: "hebrewfont", FontOptions: "Script=Hebrew", Font: "Noto Serif Hebrew"},
+	"yi": {RTL: true, FontCommand: "hebrewfont", FontOptions: "Script=Hebrew", Font: "Noto Serif Hebrew"},
+	"zh": {CJK: true, Font: "Noto Serif CJK SC"},
+	"ja": {CJK: true, Font: "Noto Serif CJK JP"},
+	"ko": {CJK: true, Font: "Noto Serif CJK KR"},
+}
+
+// lookupLanguageScript returns the script settings for a language tag, or nil for Latin scripts
+func lookupLanguageScript(language string) *languageScript {
+	if language == "" {
+		return nil
+	}
+	primary := strings.ToLower(strings.SplitN(language, "-", 2)[0])
+	script, ok := languageScripts[primary]
+	if !ok {
+		return nil
+	}
+
+	// Traditional Chinese needs a different font than the simplified default
+	if primary == "zh" {
+		lower := strings.ToLower(language)
+		if strings.Contains(lower, "hant") || strings.HasSuffix(lower, "-tw") || strings.HasSuffix(lower, "-hk") {
+			script.Font = "Noto Serif CJK TC"
+		}
+	}
+	return &script
+}
+
+// isRTLLanguage reports whether a language is written right-to-left
+func isRTLLanguage(language string) bool {
+	script := lookupLanguageScript(language)
+	return script != nil && script.RTL
+}
+
+// generateLanguageHeader creates the LaTeX preamble for RTL and CJK languages.
+// For RTL scripts pandoc's template loads polyglossia (from lang) and bidi (from
+// dir: rtl); this header supplies the script font polyglossia expects. CJK text is
+// set with xeCJK.
+func generateLanguageHeader(language string) string {
+	script := lookupLanguageScript(language)
+	if script == nil {
+		return ""
+	}
+
+	var header strings.Builder
+	switch {
+	case script.RTL:
+		header.WriteString("% Right-to-left script support (requires XeLaTeX or LuaLaTeX)\n")
+		header.WriteString("\\usepackage{fontspec}\n")
+		header.WriteString(fmt.Sprintf("\\newfontfamily\\%s[%s]{%s}\n", script.FontCommand, script.FontOptions, script.Font))
+	case script.CJK:
+		header.WriteString("% CJK script support (requires XeLaTeX)\n")
+		header.WriteString("\\usepackage{xeCJK}\n")
+		header.WriteString(fmt.Sprintf("\\setCJKmainfont{%s}\n", script.Font))
+		header.WriteString(fmt.Sprintf("\\setCJKsansfont{%s}\n", strings.Replace(script.Font, "Serif", "Sans", 1)))
+	}
+
+	return header.String()
+}
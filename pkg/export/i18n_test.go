@@ -0,0 +1,68 @@
+package export
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/gomcpgo/docgen/pkg/types"
+)
+
+func TestDeterminePDFEngine_Language(t *testing.T) {
+	tests := []struct {
+		name     string
+		language string
+		engine   string
+		want     string
+	}{
+		{"latin default", "en-US", "", "pdflatex"},
+		{"latin user override", "de", "lualatex", "lualatex"},
+		{"arabic", "ar", "", "xelatex"},
+		{"hebrew with pdflatex override", "he-IL", "pdflatex", "xelatex"},
+		{"hebrew with lualatex", "he", "lualatex", "lualatex"},
+		{"japanese", "ja", "", "xelatex"},
+		{"chinese with lualatex", "zh-CN", "lualatex", "xelatex"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := determinePDFEngine(nil, &types.PandocConfig{PDFEngine: tt.engine}, tt.language)
+			if got != tt.want {
+				t.Errorf("determinePDFEngine() = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestGenerateLanguageHeader(t *testing.T) {
+	if header := generateLanguageHeader("en"); header != "" {
+		t.Errorf("Latin languages should not need a language header, got %q", header)
+	}
+
+	arabic := generateLanguageHeader("ar-EG")
+	if !strings.Contains(arabic, `\newfontfamily\arabicfont[Script=Arabic]{Noto Naskh Arabic}`) {
+		t.Errorf("Arabic header should define the polyglossia script font, got:\n%s", arabic)
+	}
+
+	chinese := generateLanguageHeader("zh-Hant-TW")
+	if !strings.Contains(chinese, `\usepackage{xeCJK}`) || !strings.Contains(chinese, `\setCJKmainfont{Noto Serif CJK TC}`) {
+		t.Errorf("Traditional Chinese header should load xeCJK with a TC font, got:\n%s", chinese)
+	}
+}
+
+func TestGenerateYAMLMetadata_RTL(t *testing.T) {
+	doc := &types.Document{
+		Title:            "Test Document",
+		Type:             types.DocumentTypeArticle,
+		DocumentMetadata: types.DocumentMetadata{Language: "he"},
+	}
+
+	yaml := generateYAMLMetadata(doc, nil)
+	if !strings.Contains(yaml, "dir: rtl\n") {
+		t.Errorf("YAML for RTL languages should set dir, got:\n%s", yaml)
+	}
+
+	doc.Language = "fr"
+	if strings.Contains(generateYAMLMetadata(doc, nil), "dir:") {
+		t.Errorf("YAML for LTR languages should not set dir")
+	}
+}
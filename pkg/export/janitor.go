@@ -0,0 +1,144 @@
+package export
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/gomcpgo/docgen/pkg/config"
+)
+
+// janitorInterval is how often the janitor looks for stale temporary files
+const janitorInterval = 10 * time.Minute
+
+// minStaleAge is the least time a temporary file is kept before the janitor removes it
+const minStaleAge = time.Hour
+
+// workDirPrefix starts the names of export working directories. The janitor
+// only removes entries named with it, since the temporary directory may be
+// shared with other programs.
+const workDirPrefix = "docgen-export-"
+
+// newWorkDir creates a uniquely named working directory for one export's
+// intermediate files. The caller removes it when the export completes.
+func (e *Exporter) newWorkDir(documentID string) (string, error) {
+	if err := os.MkdirAll(e.config.TempPath(), 0755); err != nil {
+		return "", fmt.Errorf("failed to create temporary directory: %w", err)
+	}
+	dir, err := os.MkdirTemp(e.config.TempPath(), workDirPrefix+documentID+"-*")
+	if err != nil {
+		return "", fmt.Errorf("failed to create export working directory: %w", err)
+	}
+	return dir, nil
+}
+
+// CleanTempDir removes the export working directories in dir last modified
+// before the cutoff, such as those of exports interrupted by a crash. Entries
+// docgen didn't create are left alone. It returns the number of entries
+// removed.
+func CleanTempDir(dir string, cutoff time.Time) (int, error) {
+	entries, err := os.ReadDir(dir)
+	if os.IsNotExist(err) {
+		return 0, nil
+	}
+	if err != nil {
+		return 0, fmt.Errorf("failed to read temporary directory: %w", err)
+	}
+
+	removed := 0
+	for _, entry := range entries {
+		if !strings.HasPrefix(entry.Name(), workDirPrefix) {
+			continue
+		}
+		info, err := entry.Info()
+		if err != nil || !info.ModTime().Before(cutoff) {
+			continue
+		}
+		if err := os.RemoveAll(filepath.Join(dir, entry.Name())); err != nil {
+			log.Printf("[DOCGEN JANITOR] Failed to remove %s: %v", entry.Name(), err)
+			continue
+		}
+		removed++
+	}
+	return removed, nil
+}
+
+// Janitor periodically removes stale files from the temporary directory. Files
+// are stale once they are older than any export could still be running.
+type Janitor struct {
+	config *config.Config
+
+	// tasks run on every pass after the temporary directory is cleaned
+	tasks []func(now time.Time)
+
+	stop chan struct{}
+	done chan struct{}
+}
+
+// NewJanitor creates a janitor for the configured temporary directory
+func NewJanitor(cfg *config.Config) *Janitor {
+	return &Janitor{config: cfg}
+}
+
+// AddTask adds housekeeping to run on every pass, such as removing expired
+// documents. Tasks must be added before Start.
+func (j *Janitor) AddTask(task func(now time.Time)) {
+	j.tasks = append(j.tasks, task)
+}
+
+// Start cleans the temporary directory now and then periodically in the background
+func (j *Janitor) Start() {
+	j.stop = make(chan struct{})
+	j.done = make(chan struct{})
+	j.clean(time.Now())
+
+	go func() {
+		defer close(j.done)
+		ticker := time.NewTicker(janitorInterval)
+		defer ticker.Stop()
+		for {
+			select {
+			case now := <-ticker.C:
+				j.clean(now)
+			case <-j.stop:
+				return
+			}
+		}
+	}()
+}
+
+// Stop ends periodic cleaning and waits for the current pass to finish
+func (j *Janitor) Stop() {
+	if j.stop == nil {
+		return
+	}
+	close(j.stop)
+	<-j.done
+	j.stop = nil
+}
+
+// clean removes temporary files too old to belong to a running export and
+// runs the added tasks
+func (j *Janitor) clean(now time.Time) {
+	defer func() {
+		for _, task := range j.tasks {
+			task(now)
+		}
+	}()
+
+	maxAge := 2 * j.config.ExportTimeout
+	if maxAge < minStaleAge {
+		maxAge = minStaleAge
+	}
+	removed, err := CleanTempDir(j.config.TempPath(), now.Add(-maxAge))
+	if err != nil {
+		log.Printf("[DOCGEN JANITOR] %v", err)
+		return
+	}
+	if removed > 0 {
+		log.Printf("[DOCGEN JANITOR] Removed %d stale temporary file(s) from %s", removed, j.config.TempPath())
+	}
+}
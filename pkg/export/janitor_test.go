@@ -0,0 +1,61 @@
+package export
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/gomcpgo/docgen/pkg/config"
+)
+
+func TestCleanTempDir(t *testing.T) {
+	dir := t.TempDir()
+	stale := filepath.Join(dir, workDirPrefix+"doc-123")
+	fresh := filepath.Join(dir, workDirPrefix+"doc-456")
+	// Files of other programs sharing the directory are never removed
+	foreign := filepath.Join(dir, "other-program")
+	for _, path := range []string{stale, fresh, foreign} {
+		if err := os.MkdirAll(path, 0755); err != nil {
+			t.Fatal(err)
+		}
+		os.WriteFile(filepath.Join(path, "doc-input.md"), []byte("# Draft"), 0644)
+	}
+	old := time.Now().Add(-3 * time.Hour)
+	os.Chtimes(stale, old, old)
+	os.Chtimes(foreign, old, old)
+
+	removed, err := CleanTempDir(dir, time.Now().Add(-time.Hour))
+	if err != nil {
+		t.Fatalf("CleanTempDir() error = %v", err)
+	}
+	if removed != 1 {
+		t.Errorf("Expected 1 entry removed, got %d", removed)
+	}
+	if _, err := os.Stat(stale); !os.IsNotExist(err) {
+		t.Errorf("Expected the stale directory to be removed")
+	}
+	if _, err := os.Stat(fresh); err != nil {
+		t.Errorf("Expected the fresh directory to be kept: %v", err)
+	}
+	if _, err := os.Stat(foreign); err != nil {
+		t.Errorf("Expected another program's directory to be kept: %v", err)
+	}
+
+	if removed, err := CleanTempDir(filepath.Join(dir, "missing"), time.Now()); err != nil || removed != 0 {
+		t.Errorf("Expected a missing directory to be ignored, got %d, %v", removed, err)
+	}
+}
+
+func TestJanitor_RunsTasks(t *testing.T) {
+	tempDir := t.TempDir()
+	janitor := NewJanitor(&config.Config{TempDir: tempDir, ExportTimeout: time.Minute})
+	var ran []time.Time
+	janitor.AddTask(func(now time.Time) { ran = append(ran, now) })
+
+	now := time.Now()
+	janitor.clean(now)
+	if len(ran) != 1 || !ran[0].Equal(now) {
+		t.Errorf("Expected the task to run once with the pass time, ran %v", ran)
+	}
+}
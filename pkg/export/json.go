@@ -0,0 +1,69 @@
+package export
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/gomcpgo/docgen/pkg/types"
+)
+
+// exportJSON writes the document given in the options as JSON, keeping only
+// the chapters the export covers, and with ApprovedOnly only their approved
+// content
+func (e *Exporter) exportJSON(documentID string, options *types.ExportOptions) (string, error) {
+	if options.DocumentJSON == nil {
+		return "", fmt.Errorf("json export needs the document's content")
+	}
+
+	doc := *options.DocumentJSON
+	if len(options.Chapters) > 0 {
+		included := make(map[types.ChapterNumber]bool)
+		for _, chapterNum := range options.Chapters {
+			included[chapterNum] = true
+		}
+		doc.Chapters = []types.ChapterJSON{}
+		for _, chapter := range options.DocumentJSON.Chapters {
+			if included[chapter.Number] {
+				doc.Chapters = append(doc.Chapters, chapter)
+			}
+		}
+	}
+
+	if options.ApprovedOnly {
+		approved := []types.ChapterJSON{}
+		for _, chapter := range doc.Chapters {
+			sections := []types.SectionJSON{}
+			for _, section := range chapter.Sections {
+				if section.Status.Approved() {
+					sections = append(sections, section)
+				}
+			}
+			if !chapter.Status.Approved() && len(sections) == 0 {
+				continue
+			}
+			if !chapter.Status.Approved() {
+				chapter.Intro = ""
+			}
+			chapter.Sections = sections
+			approved = append(approved, chapter)
+		}
+		doc.Chapters = approved
+	}
+
+	data, err := json.MarshalIndent(doc, "", "  ")
+	if err != nil {
+		return "", fmt.Errorf("failed to encode document: %w", err)
+	}
+
+	outputFile := e.config.ExportPath(documentID, string(options.Format))
+	if err := os.MkdirAll(filepath.Dir(outputFile), 0755); err != nil {
+		return "", fmt.Errorf("failed to create output directory: %w", err)
+	}
+	if err := os.WriteFile(outputFile, append(data, '\n'), 0644); err != nil {
+		return "", fmt.Errorf("failed to write output file: %w", err)
+	}
+
+	return outputFile, nil
+}
@@ -0,0 +1,55 @@
+package export
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/gomcpgo/docgen/pkg/types"
+)
+
+func TestExporter_ExportJSON(t *testing.T) {
+	exporter, tempDir := setupTestExporter(t)
+	defer os.RemoveAll(tempDir)
+
+	_, manifest, _, _ := createTestDocument(t, tempDir)
+	doc := &types.DocumentJSON{
+		SchemaVersion: types.DocumentJSONSchemaVersion,
+		ID:            "test-doc",
+		Title:         "Test Document",
+		Chapters: []types.ChapterJSON{
+			{Number: 1, Title: "One", Sections: []types.SectionJSON{{Number: "1.1", Title: "Start", Level: 1, Content: "Hello."}}},
+			{Number: 2, Title: "Two", Sections: []types.SectionJSON{}},
+		},
+	}
+
+	options := &types.ExportOptions{Format: types.ExportFormatJSON, Chapters: []types.ChapterNumber{1}, DocumentJSON: doc}
+	outputFile, err := exporter.ExportDocument(context.Background(), "test-doc", manifest, nil, nil, options, nil)
+	if err != nil {
+		t.Fatalf("ExportDocument() error = %v", err)
+	}
+	if !strings.HasSuffix(outputFile, ".json") {
+		t.Errorf("Expected a .json export, got %s", outputFile)
+	}
+
+	data, err := os.ReadFile(outputFile)
+	if err != nil {
+		t.Fatalf("Failed to read output: %v", err)
+	}
+	var exported types.DocumentJSON
+	if err := json.Unmarshal(data, &exported); err != nil {
+		t.Fatalf("Export is not valid JSON: %v", err)
+	}
+	if exported.SchemaVersion != types.DocumentJSONSchemaVersion || len(exported.Chapters) != 1 || exported.Chapters[0].Sections[0].Content != "Hello." {
+		t.Errorf("Unexpected export %+v", exported)
+	}
+	if len(doc.Chapters) != 2 {
+		t.Error("Exporting some chapters should leave the given document alone")
+	}
+
+	if _, err := exporter.ExportDocument(context.Background(), "test-doc", manifest, nil, nil, &types.ExportOptions{Format: types.ExportFormatJSON}, nil); err == nil {
+		t.Error("Expected an error without the document's content")
+	}
+}
@@ -0,0 +1,238 @@
+package export
+
+import (
+	"fmt"
+	"html"
+	"regexp"
+	"strings"
+
+	"github.com/gomcpgo/docgen/pkg/types"
+)
+
+var (
+	// attributedFencePattern matches the opening fence of a code block with
+	// an attribute list, capturing the attributes
+	attributedFencePattern = regexp.MustCompile("^\\s{0,3}(?:`{3,}|~{3,})\\s*\\{([^}=][^}]*)\\}\\s*$")
+	// listingAnchorPattern matches a listing ID in a code block's attributes
+	listingAnchorPattern = regexp.MustCompile(`(?:^|\s)#(lst-\d+\.\d+)(?:\s|$)`)
+	// listingReferencePattern matches a cross-reference to a listing: @lst-1.2
+	listingReferencePattern = regexp.MustCompile(`@(lst-\d+\.\d+)\b`)
+)
+
+// ListingLabel opens a code listing's caption and cross-references to it in
+// documents without a locale
+const ListingLabel = "Listing"
+
+// listingsLanguages maps the languages pandoc highlights to the names of the
+// languages the LaTeX listings package knows. Listings in other languages are
+// set without highlighting, since listings stops on a language it doesn't know.
+var listingsLanguages = map[string]string{
+	"bash": "bash", "sh": "sh", "shell": "bash", "zsh": "bash",
+	"c": "C", "cpp": "C++", "c++": "C++", "csharp": "[Sharp]C", "cs": "[Sharp]C",
+	"fortran": "Fortran", "haskell": "Haskell", "html": "HTML", "java": "Java",
+	"latex": "[LaTeX]TeX", "tex": "TeX", "lisp": "Lisp", "lua": "[5.2]Lua",
+	"matlab": "Matlab", "ocaml": "[Objective]Caml", "pascal": "Pascal", "perl": "Perl",
+	"php": "PHP", "python": "Python", "r": "R", "ruby": "Ruby", "scala": "Scala",
+	"sql": "SQL", "tcl": "tcl", "xml": "XML", "make": "make", "makefile": "make",
+}
+
+// formatListings numbers and captions the code listings placed in a chapter:
+// listings environments in PDF exports, a figure with a figcaption in HTML
+// and EPUB exports, and a "Listing Caption" paragraph before the code in DOCX
+// and other formats. Cross-references to listings anywhere in the document
+// become "Listing 1.2", linked to the listing. Code blocks carrying an ID the
+// document doesn't register are left alone.
+func formatListings(manifest *types.Manifest, chapterNum types.ChapterNumber, content string, format types.ExportFormat, numbering *types.NumberingStyle) string {
+	if numbering == nil {
+		numbering = &types.NumberingStyle{}
+	}
+	listings := make(map[string]types.Listing)
+	for _, chapter := range manifest.Document.Chapters {
+		for _, listing := range chapter.Listings {
+			listings[string(listing.ID)] = listing
+		}
+	}
+	if len(listings) == 0 {
+		return content
+	}
+	label := exportLocale(&manifest.Document).Listing
+
+	// Code blocks are found line by line, since a listing's closing fence
+	// must match its opening one
+	lines := strings.Split(content, "\n")
+	var out []string
+	for i := 0; i < len(lines); i++ {
+		opening := codeFencePattern.FindStringSubmatch(lines[i])
+		if opening == nil {
+			out = append(out, lines[i])
+			continue
+		}
+		closing := -1
+		for j := i + 1; j < len(lines); j++ {
+			if isClosingFence(lines[j], opening[1]) {
+				closing = j
+				break
+			}
+		}
+		if closing < 0 {
+			out = append(out, lines[i:]...)
+			break
+		}
+		block := lines[i : closing+1]
+		i = closing
+
+		attributed := attributedFencePattern.FindStringSubmatch(block[0])
+		if attributed == nil {
+			out = append(out, block...)
+			continue
+		}
+		anchor := listingAnchorPattern.FindStringSubmatch(attributed[1])
+		if anchor == nil {
+			out = append(out, block...)
+			continue
+		}
+		listing, ok := listings[anchor[1]]
+		if !ok || listing.Chapter != chapterNum {
+			out = append(out, block...)
+			continue
+		}
+
+		code := strings.Join(block[1:len(block)-1], "\n")
+		attributes := strings.Join(strings.Fields(listingAnchorPattern.ReplaceAllString(attributed[1], " ")), " ")
+		number := listingNumber(listing, numbering)
+		switch format {
+		case types.ExportFormatPDF:
+			out = append(out, latexListing(listing, number, code))
+		case types.ExportFormatHTML, types.ExportFormatEPUB, types.ExportFormatRevealJS:
+			out = append(out, htmlListing(listing, label, number, code, attributes))
+		default:
+			out = append(out, markdownListing(listing, label, number, code, attributes))
+		}
+	}
+	content = strings.Join(out, "\n")
+
+	return listingReferencePattern.ReplaceAllStringFunc(content, func(match string) string {
+		listing, ok := listings[strings.TrimPrefix(match, "@")]
+		if !ok {
+			return match
+		}
+		if format == types.ExportFormatPDF {
+			return fmt.Sprintf("`%s~\\ref{%s}`{=latex}", label, listing.ID)
+		}
+		return fmt.Sprintf("[%s %s](#%s)", label, listingNumber(listing, numbering), listing.ID)
+	})
+}
+
+// listingNumber is a listing's number in the document's chapter numbering: 1.2 or II.2
+func listingNumber(listing types.Listing, numbering *types.NumberingStyle) string {
+	return fmt.Sprintf("%s.%d", numbering.ChapterLabel(listing.Chapter), listing.Sequence)
+}
+
+// latexListing writes a listing as a listings environment. The listing's
+// number is set for its caption and references, and its hyperref anchor is
+// named after its ID so anchors stay unique across chapters.
+func latexListing(listing types.Listing, number, code string) string {
+	options := []string{
+		fmt.Sprintf("caption={%s}", latexSpecialChars.Replace(listing.Caption)),
+		fmt.Sprintf("label={%s}", listing.ID),
+	}
+	if language, ok := listingsLanguages[strings.ToLower(listing.Language)]; ok {
+		options = append(options, "language="+language)
+	}
+
+	fence := types.CodeFence(code)
+	var latex strings.Builder
+	latex.WriteString(fence + "{=latex}\n")
+	latex.WriteString(fmt.Sprintf("\\renewcommand{\\thelstlisting}{%s}\\renewcommand{\\theHlstlisting}{%s}\n", number, listing.ID))
+	latex.WriteString(fmt.Sprintf("\\begin{lstlisting}[%s]\n", strings.Join(options, ",")))
+	latex.WriteString(code + "\n")
+	latex.WriteString("\\end{lstlisting}\n")
+	latex.WriteString(fence)
+	return latex.String()
+}
+
+// htmlListing writes a listing as a figure holding the code block, captioned
+// with a figcaption. The listing class keeps figure numbering from counting it.
+func htmlListing(listing types.Listing, label, number, code, attributes string) string {
+	var out strings.Builder
+	out.WriteString("```{=html}\n")
+	out.WriteString(fmt.Sprintf("<figure id=\"%s\" class=\"listing\">\n", listing.ID))
+	out.WriteString(fmt.Sprintf("<figcaption>%s %s: %s</figcaption>\n", label, number, html.EscapeString(listing.Caption)))
+	out.WriteString("```\n\n")
+	out.WriteString(codeBlock(code, attributes))
+	out.WriteString("\n\n```{=html}\n</figure>\n```")
+	return out.String()
+}
+
+// markdownListing writes a listing as its caption, in the Listing Caption
+// style for DOCX, followed by the code block. The caption carries the
+// listing's ID, so references link to it.
+func markdownListing(listing types.Listing, label, number, code, attributes string) string {
+	var out strings.Builder
+	out.WriteString("::: {custom-style=\"Listing Caption\"}\n")
+	out.WriteString(fmt.Sprintf("[%s %s: %s]{#%s}\n", label, number, listing.Caption, listing.ID))
+	out.WriteString(":::\n\n")
+	out.WriteString(codeBlock(code, attributes))
+	return out.String()
+}
+
+// codeBlock writes code as a fenced code block with attributes, which may be empty
+func codeBlock(code, attributes string) string {
+	fence := types.CodeFence(code)
+	if attributes != "" {
+		return fmt.Sprintf("%s{%s}\n%s\n%s", fence, attributes, code, fence)
+	}
+	return fmt.Sprintf("%s\n%s\n%s", fence, code, fence)
+}
+
+// hasListings reports whether any exported chapter has a code listing
+func hasListings(manifest *types.Manifest, chapters []types.ChapterNumber) bool {
+	for _, chapter := range exportedChapters(manifest, chapters) {
+		if len(chapter.Listings) > 0 {
+			return true
+		}
+	}
+	return false
+}
+
+// generateListingsHeader loads the listings package for the exported code
+// listings, set in the monospace font with long lines wrapped
+func generateListingsHeader(manifest *types.Manifest, chapters []types.ChapterNumber) string {
+	if !hasListings(manifest, chapters) {
+		return ""
+	}
+	locale := exportLocale(&manifest.Document)
+	var header strings.Builder
+	header.WriteString("\n% Code listings\n")
+	header.WriteString("\\usepackage{listings}\n")
+	header.WriteString("\\lstset{basicstyle=\\ttfamily\\small,breaklines=true,columns=fullflexible,keepspaces=true,frame=tb,captionpos=t,upquote=true}\n")
+	header.WriteString(fmt.Sprintf("\\renewcommand{\\lstlistingname}{%s}\n", latexSpecialChars.Replace(locale.Listing)))
+	header.WriteString(fmt.Sprintf("\\renewcommand{\\lstlistlistingname}{%s}\n", latexSpecialChars.Replace(locale.Listings)))
+	return header.String()
+}
+
+// listOfListings lists the exported chapters' code listings with their
+// numbers and captions, linked to the listings. PDF exports leave the list to
+// LaTeX.
+func listOfListings(manifest *types.Manifest, chapters []types.ChapterNumber, format types.ExportFormat, numbering *types.NumberingStyle) string {
+	if !hasListings(manifest, chapters) {
+		return ""
+	}
+	if format == types.ExportFormatPDF {
+		return "```{=latex}\n\\lstlistoflistings\n```\n\n"
+	}
+	if numbering == nil {
+		numbering = &types.NumberingStyle{}
+	}
+
+	locale := exportLocale(&manifest.Document)
+	var list strings.Builder
+	list.WriteString(fmt.Sprintf("# %s {.unnumbered}\n\n", locale.Listings))
+	for _, chapter := range exportedChapters(manifest, chapters) {
+		for _, listing := range chapter.Listings {
+			fmt.Fprintf(&list, "- [%s %s: %s](#%s)\n", locale.Listing, listingNumber(listing, numbering), listing.Caption, listing.ID)
+		}
+	}
+	list.WriteString("\n")
+	return list.String()
+}
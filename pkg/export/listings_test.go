@@ -0,0 +1,85 @@
+package export
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/gomcpgo/docgen/pkg/types"
+)
+
+func listingsManifest() *types.Manifest {
+	return &types.Manifest{
+		Document: types.Document{
+			Chapters: []types.Chapter{
+				{Number: 1, Listings: []types.Listing{{ID: "lst-1.1", Chapter: 1, Sequence: 1, Caption: "Hello & goodbye", Language: "python"}}},
+				{Number: 2, Listings: []types.Listing{{ID: "lst-2.1", Chapter: 2, Sequence: 1, Caption: "Build", Language: "zig"}}},
+			},
+		},
+	}
+}
+
+const listingsContent = "As @lst-1.1 and @lst-2.1 show, but not @lst-9.9:\n\n" +
+	"````{#lst-1.1 .python .numberLines}\nprint(\"hi\")\n```\nnot a fence\n````\n\n" +
+	"```\n{#lst-1.1}\n```\n\n" +
+	"```{#lst-2.1 .zig}\nconst x = 1;\n```"
+
+func TestFormatListings_PDF(t *testing.T) {
+	numbering := &types.NumberingStyle{ChapterFormat: types.NumberFormatRoman}
+	got := formatListings(listingsManifest(), 1, listingsContent, types.ExportFormatPDF, numbering)
+
+	for _, want := range []string{
+		"````{=latex}\n\\renewcommand{\\thelstlisting}{I.1}\\renewcommand{\\theHlstlisting}{lst-1.1}\n" +
+			"\\begin{lstlisting}[caption={Hello \\& goodbye},label={lst-1.1},language=Python]\n" +
+			"print(\"hi\")\n```\nnot a fence\n\\end{lstlisting}\n````",
+		"As `Listing~\\ref{lst-1.1}`{=latex} and `Listing~\\ref{lst-2.1}`{=latex} show, but not @lst-9.9:",
+		// Plain code blocks and listings of other chapters are left alone
+		"```\n{#lst-1.1}\n```",
+		"```{#lst-2.1 .zig}\nconst x = 1;\n```",
+	} {
+		if !strings.Contains(got, want) {
+			t.Errorf("Expected %q in:\n%s", want, got)
+		}
+	}
+
+	// Languages the listings package doesn't know are set without highlighting
+	got = formatListings(listingsManifest(), 2, listingsContent, types.ExportFormatPDF, nil)
+	if !strings.Contains(got, "\\begin{lstlisting}[caption={Build},label={lst-2.1}]") {
+		t.Errorf("Expected lst-2.1 without a language:\n%s", got)
+	}
+}
+
+func TestFormatListings_HTMLAndDOCX(t *testing.T) {
+	got := formatListings(listingsManifest(), 1, listingsContent, types.ExportFormatHTML, nil)
+	for _, want := range []string{
+		"<figure id=\"lst-1.1\" class=\"listing\">\n<figcaption>Listing 1.1: Hello &amp; goodbye</figcaption>\n```\n\n" +
+			"````{.python .numberLines}\nprint(\"hi\")\n```\nnot a fence\n````\n\n```{=html}\n</figure>\n```",
+		"As [Listing 1.1](#lst-1.1) and [Listing 2.1](#lst-2.1) show",
+	} {
+		if !strings.Contains(got, want) {
+			t.Errorf("Expected %q in:\n%s", want, got)
+		}
+	}
+
+	got = formatListings(listingsManifest(), 2, listingsContent, types.ExportFormatDOCX, nil)
+	want := "::: {custom-style=\"Listing Caption\"}\n[Listing 2.1: Build]{#lst-2.1}\n:::\n\n```{.zig}\nconst x = 1;\n```"
+	if !strings.Contains(got, want) {
+		t.Errorf("Expected %q in:\n%s", want, got)
+	}
+}
+
+func TestListOfListings(t *testing.T) {
+	manifest := listingsManifest()
+	got := listOfListings(manifest, []types.ChapterNumber{2}, types.ExportFormatHTML, nil)
+	if got != "# List of Listings {.unnumbered}\n\n- [Listing 2.1: Build](#lst-2.1)\n\n" {
+		t.Errorf("listOfListings() = %q", got)
+	}
+	if got := listOfListings(manifest, nil, types.ExportFormatPDF, nil); !strings.Contains(got, "\\lstlistoflistings") {
+		t.Errorf("Expected LaTeX's list of listings, got %q", got)
+	}
+	if got := listOfListings(&types.Manifest{}, nil, types.ExportFormatHTML, nil); got != "" {
+		t.Errorf("Expected no list without listings, got %q", got)
+	}
+	if header := generateListingsHeader(manifest, nil); !strings.Contains(header, "\\usepackage{listings}") {
+		t.Errorf("Expected the listings package in the header:\n%s", header)
+	}
+}
@@ -0,0 +1,190 @@
+package export
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gomcpgo/docgen/pkg/types"
+)
+
+// documentLocale holds the words and date format exports write in a
+// document's locale
+type documentLocale struct {
+	Months [12]string
+	// DateFormat lays out a date with {day}, {month} and {year}
+	DateFormat string
+	// FirstDay replaces the first day of the month, as in "1er janvier"
+	FirstDay string
+
+	Figure  string
+	Table   string
+	Listing string
+
+	Contents string
+	Figures  string
+	Tables   string
+	Listings string
+}
+
+// englishLocale is used when a document sets no locale, or one docgen has no
+// words for
+var englishLocale = documentLocale{
+	Months:     [12]string{"January", "February", "March", "April", "May", "June", "July", "August", "September", "October", "November", "December"},
+	DateFormat: "{month} {day}, {year}",
+	Figure:     "Figure",
+	Table:      "Table",
+	Listing:    ListingLabel,
+	Contents:   "Contents",
+	Figures:    "List of Figures",
+	Tables:     "List of Tables",
+	Listings:   "List of Listings",
+}
+
+// locales maps primary BCP-47 language subtags to their words
+var locales = map[string]documentLocale{
+	"en": englishLocale,
+	"fr": {
+		Months:     [12]string{"janvier", "février", "mars", "avril", "mai", "juin", "juillet", "août", "septembre", "octobre", "novembre", "décembre"},
+		DateFormat: "{day} {month} {year}",
+		FirstDay:   "1er",
+		Figure:     "Figure",
+		Table:      "Tableau",
+		Listing:    "Listing",
+		Contents:   "Table des matières",
+		Figures:    "Table des figures",
+		Tables:     "Liste des tableaux",
+		Listings:   "Liste des listings",
+	},
+	"de": {
+		Months:     [12]string{"Januar", "Februar", "März", "April", "Mai", "Juni", "Juli", "August", "September", "Oktober", "November", "Dezember"},
+		DateFormat: "{day}. {month} {year}",
+		Figure:     "Abbildung",
+		Table:      "Tabelle",
+		Listing:    "Listing",
+		Contents:   "Inhaltsverzeichnis",
+		Figures:    "Abbildungsverzeichnis",
+		Tables:     "Tabellenverzeichnis",
+		Listings:   "Verzeichnis der Listings",
+	},
+	"es": {
+		Months:     [12]string{"enero", "febrero", "marzo", "abril", "mayo", "junio", "julio", "agosto", "septiembre", "octubre", "noviembre", "diciembre"},
+		DateFormat: "{day} de {month} de {year}",
+		Figure:     "Figura",
+		Table:      "Tabla",
+		Listing:    "Listado",
+		Contents:   "Índice",
+		Figures:    "Índice de figuras",
+		Tables:     "Índice de tablas",
+		Listings:   "Índice de listados",
+	},
+	"it": {
+		Months:     [12]string{"gennaio", "febbraio", "marzo", "aprile", "maggio", "giugno", "luglio", "agosto", "settembre", "ottobre", "novembre", "dicembre"},
+		DateFormat: "{day} {month} {year}",
+		Figure:     "Figura",
+		Table:      "Tabella",
+		Listing:    "Listato",
+		Contents:   "Indice",
+		Figures:    "Elenco delle figure",
+		Tables:     "Elenco delle tabelle",
+		Listings:   "Elenco dei listati",
+	},
+	"pt": {
+		Months:     [12]string{"janeiro", "fevereiro", "março", "abril", "maio", "junho", "julho", "agosto", "setembro", "outubro", "novembro", "dezembro"},
+		DateFormat: "{day} de {month} de {year}",
+		Figure:     "Figura",
+		Table:      "Tabela",
+		Listing:    "Listagem",
+		Contents:   "Sumário",
+		Figures:    "Lista de figuras",
+		Tables:     "Lista de tabelas",
+		Listings:   "Lista de listagens",
+	},
+	"nl": {
+		Months:     [12]string{"januari", "februari", "maart", "april", "mei", "juni", "juli", "augustus", "september", "oktober", "november", "december"},
+		DateFormat: "{day} {month} {year}",
+		Figure:     "Figuur",
+		Table:      "Tabel",
+		Listing:    "Listing",
+		Contents:   "Inhoudsopgave",
+		Figures:    "Lijst van figuren",
+		Tables:     "Lijst van tabellen",
+		Listings:   "Lijst van listings",
+	},
+}
+
+// regionalDateFormats overrides the date format of a language in regions
+// that write dates differently
+var regionalDateFormats = map[string]string{
+	"en-gb": "{day} {month} {year}",
+	"en-au": "{day} {month} {year}",
+	"en-ie": "{day} {month} {year}",
+	"en-nz": "{day} {month} {year}",
+}
+
+// lookupLocale returns the words for a locale tag, or nil when the tag is
+// empty or docgen has no words for its language
+func lookupLocale(tag string) *documentLocale {
+	if tag == "" {
+		return nil
+	}
+	lower := strings.ToLower(tag)
+	locale, ok := locales[strings.SplitN(lower, "-", 2)[0]]
+	if !ok {
+		return nil
+	}
+	for region, format := range regionalDateFormats {
+		if lower == region || strings.HasPrefix(lower, region+"-") {
+			locale.DateFormat = format
+		}
+	}
+	return &locale
+}
+
+// exportLocale returns the words a document's exports are written with: its
+// locale's, or English ones
+func exportLocale(doc *types.Document) *documentLocale {
+	if locale := lookupLocale(doc.Locale); locale != nil {
+		return locale
+	}
+	return &englishLocale
+}
+
+// formatDate writes a date in the locale's format, with the month spelled out
+func (l *documentLocale) formatDate(date time.Time) string {
+	day := strconv.Itoa(date.Day())
+	if date.Day() == 1 && l.FirstDay != "" {
+		day = l.FirstDay
+	}
+	return strings.NewReplacer(
+		"{day}", day,
+		"{month}", l.Months[date.Month()-1],
+		"{year}", strconv.Itoa(date.Year()),
+	).Replace(l.DateFormat)
+}
+
+// generateLocaleHeader sets LaTeX's caption labels and list headings in the
+// document's locale. They are set when the document begins, after babel or
+// polyglossia have set their own for the document language.
+func generateLocaleHeader(doc *types.Document) string {
+	if lookupLocale(doc.Locale) == nil {
+		return ""
+	}
+	locale := exportLocale(doc)
+
+	var header strings.Builder
+	header.WriteString("\n% Caption labels and headings in the document locale\n")
+	header.WriteString("\\AtBeginDocument{%\n")
+	for _, name := range []struct{ command, text string }{
+		{"figurename", locale.Figure},
+		{"tablename", locale.Table},
+		{"contentsname", locale.Contents},
+		{"listfigurename", locale.Figures},
+		{"listtablename", locale.Tables},
+	} {
+		header.WriteString(fmt.Sprintf("  \\renewcommand{\\%s}{%s}%%\n", name.command, latexSpecialChars.Replace(name.text)))
+	}
+	header.WriteString("}\n")
+	return header.String()
+}
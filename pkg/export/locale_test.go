@@ -0,0 +1,88 @@
+package export
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gomcpgo/docgen/pkg/types"
+)
+
+func TestDocumentDate_Locale(t *testing.T) {
+	tests := []struct {
+		locale string
+		date   string
+		want   string
+	}{
+		{"", "2024-05-01", "2024-05-01"},
+		{"fr-FR", "2024-05-01", "1er mai 2024"},
+		{"de", "2024-03-15", "15. März 2024"},
+		{"es-MX", "2024-12-09", "9 de diciembre de 2024"},
+		{"en-US", "2024-05-01", "May 1, 2024"},
+		{"en-GB", "2024-05-01", "1 May 2024"},
+		{"fr", "Printemps 2024", "Printemps 2024"},
+		{"ja", "2024-05-01", "2024-05-01"},
+	}
+	for _, tt := range tests {
+		doc := &types.Document{DocumentMetadata: types.DocumentMetadata{Locale: tt.locale, Date: tt.date}}
+		if got := documentDate(doc); got != tt.want {
+			t.Errorf("documentDate(%q, %q) = %q, want %q", tt.locale, tt.date, got, tt.want)
+		}
+	}
+
+	// Without a date the export date is written in the locale
+	today := time.Now()
+	doc := &types.Document{DocumentMetadata: types.DocumentMetadata{Locale: "it"}}
+	if got := documentDate(doc); !strings.HasSuffix(got, locales["it"].Months[today.Month()-1]+" "+today.Format("2006")) {
+		t.Errorf("Expected today's date in Italian, got %q", got)
+	}
+}
+
+func TestGenerateLocale(t *testing.T) {
+	doc := &types.Document{Title: "Essai", DocumentMetadata: types.DocumentMetadata{Locale: "fr-CA", Date: "2024-06-02"}}
+
+	yaml := generateYAMLMetadata(doc, nil)
+	if !strings.Contains(yaml, `date: "2 juin 2024"`) || !strings.Contains(yaml, `toc-title: "Table des matières"`) {
+		t.Errorf("Expected the date and contents heading in French:\n%s", yaml)
+	}
+	if vars := CreateTemplateVariables(&types.Manifest{Document: *doc}); vars.Date != "2 juin 2024" {
+		t.Errorf("Expected {date} in French, got %q", vars.Date)
+	}
+
+	header := generateLocaleHeader(doc)
+	for _, want := range []string{`\renewcommand{\figurename}{Figure}`, `\renewcommand{\tablename}{Tableau}`, `\renewcommand{\listfigurename}{Table des figures}`} {
+		if !strings.Contains(header, want) {
+			t.Errorf("Expected %q in the header:\n%s", want, header)
+		}
+	}
+	css := generateNumberingCSS(&types.ExportOptions{Numbering: &types.NumberingStyle{}}, exportLocale(doc))
+	if !strings.Contains(css, `"Tableau " counter(docgen-chapter)`) {
+		t.Errorf("Expected French table labels:\n%s", css)
+	}
+
+	// Documents without a supported locale keep pandoc's and LaTeX's own
+	english := &types.Document{DocumentMetadata: types.DocumentMetadata{Language: "de", Locale: "ja"}}
+	if generateLocaleHeader(english) != "" || strings.Contains(generateYAMLMetadata(english, nil), "toc-title") {
+		t.Error("Expected no locale settings for an unsupported locale")
+	}
+	if exportLocale(english).Figure != "Figure" {
+		t.Error("Expected English labels for an unsupported locale")
+	}
+}
+
+func TestFormatListings_Locale(t *testing.T) {
+	manifest := listingsManifest()
+	manifest.Document.Locale = "es"
+	content := "```{#lst-2.1 .python}\nprint(1)\n```\n\nSee @lst-2.1."
+
+	got := formatListings(manifest, 2, content, types.ExportFormatHTML, nil)
+	if !strings.Contains(got, "<figcaption>Listado 2.1:") || !strings.Contains(got, "[Listado 2.1](#lst-2.1)") {
+		t.Errorf("Expected Spanish listing labels:\n%s", got)
+	}
+	if list := listOfListings(manifest, nil, types.ExportFormatHTML, nil); !strings.HasPrefix(list, "# Índice de listados {.unnumbered}") {
+		t.Errorf("Unexpected list of listings %q", list)
+	}
+	if header := generateListingsHeader(manifest, nil); !strings.Contains(header, `\renewcommand{\lstlistingname}{Listado}`) {
+		t.Errorf("Expected the LaTeX listing name in Spanish:\n%s", header)
+	}
+}
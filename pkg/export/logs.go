@@ -0,0 +1,182 @@
+package export
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/gomcpgo/docgen/pkg/types"
+)
+
+// exportLogsKept is the number of export logs kept per document
+const exportLogsKept = 10
+
+// exportLogTimestamp names log directories so that they sort by time
+const exportLogTimestamp = "20060102T150405.000Z"
+
+// exportLogInputFlags are the pandoc flags whose values are intermediate files
+var exportLogInputFlags = map[string]bool{"-H": true, "--css": true, "--reference-doc": true, "--lua-filter": true}
+
+// saveExportLog records a pandoc run under exports/logs/{doc}-{timestamp}/ with
+// its output and copies of the intermediate files it read. Failing to write the
+// log never fails the export.
+func (e *Exporter) saveExportLog(documentID string, format types.ExportFormat, cmd *exec.Cmd, inputFile string, started time.Time, stdout, stderr string, runErr error, outputFile string) {
+	dir := e.config.ExportLogPath(documentID, started.UTC().Format(exportLogTimestamp))
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		log.Printf("[DOCGEN EXPORT LOG] Failed to create log directory: %v", err)
+		return
+	}
+
+	record := types.ExportLog{
+		DocumentID: types.DocumentID(documentID),
+		Format:     format,
+		StartedAt:  started,
+		DurationMS: time.Since(started).Milliseconds(),
+		Command:    cmd.Args,
+		ExitCode:   -1,
+		Files:      []string{},
+		Dir:        dir,
+	}
+	if cmd.ProcessState != nil {
+		record.ExitCode = cmd.ProcessState.ExitCode()
+	}
+	if runErr != nil {
+		record.Error = runErr.Error()
+	} else {
+		record.OutputPath = outputFile
+	}
+
+	// Keep the combined markdown and the generated headers, stylesheets and reference documents
+	inputs := []string{inputFile}
+	for i := 1; i < len(cmd.Args); i++ {
+		if exportLogInputFlags[cmd.Args[i-1]] {
+			inputs = append(inputs, cmd.Args[i])
+		}
+	}
+	for _, input := range inputs {
+		// Only the export's own files; the user's templates stay where they are
+		if filepath.Dir(input) != filepath.Dir(inputFile) {
+			continue
+		}
+		name := filepath.Base(input)
+		if err := copyFile(input, filepath.Join(dir, name)); err != nil {
+			log.Printf("[DOCGEN EXPORT LOG] Failed to keep %s: %v", name, err)
+			continue
+		}
+		record.Files = append(record.Files, name)
+	}
+
+	if err := os.WriteFile(filepath.Join(dir, "stdout.txt"), []byte(stdout), 0644); err != nil {
+		log.Printf("[DOCGEN EXPORT LOG] Failed to write stdout: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "stderr.txt"), []byte(stderr), 0644); err != nil {
+		log.Printf("[DOCGEN EXPORT LOG] Failed to write stderr: %v", err)
+	}
+	data, err := json.MarshalIndent(record, "", "  ")
+	if err == nil {
+		err = os.WriteFile(filepath.Join(dir, "log.json"), data, 0644)
+	}
+	if err != nil {
+		log.Printf("[DOCGEN EXPORT LOG] Failed to write log: %v", err)
+	}
+
+	e.pruneExportLogs(documentID)
+}
+
+// LatestExportLog returns the log of a document's most recent export
+func (e *Exporter) LatestExportLog(documentID string) (*types.ExportLog, error) {
+	logs, err := e.exportLogDirs(documentID)
+	if err != nil {
+		return nil, err
+	}
+	if len(logs) == 0 {
+		return nil, fmt.Errorf("no export logs found for document %s", documentID)
+	}
+	return readExportLog(logs[len(logs)-1])
+}
+
+// exportLogDirs lists a document's log directories, oldest first
+func (e *Exporter) exportLogDirs(documentID string) ([]string, error) {
+	entries, err := os.ReadDir(e.config.ExportLogsPath())
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read export logs: %w", err)
+	}
+
+	var dirs []string
+	prefix := documentID + "-"
+	for _, entry := range entries {
+		name := entry.Name()
+		if !entry.IsDir() || !strings.HasPrefix(name, prefix) {
+			continue
+		}
+		// Another document's ID may start with this one's, so check the rest is a timestamp
+		if _, err := time.Parse(exportLogTimestamp, strings.TrimPrefix(name, prefix)); err != nil {
+			continue
+		}
+		dirs = append(dirs, filepath.Join(e.config.ExportLogsPath(), name))
+	}
+	sort.Strings(dirs)
+	return dirs, nil
+}
+
+// pruneExportLogs removes all but the most recent logs of a document
+func (e *Exporter) pruneExportLogs(documentID string) {
+	dirs, err := e.exportLogDirs(documentID)
+	if err != nil || len(dirs) <= exportLogsKept {
+		return
+	}
+	for _, dir := range dirs[:len(dirs)-exportLogsKept] {
+		if err := os.RemoveAll(dir); err != nil {
+			log.Printf("[DOCGEN EXPORT LOG] Failed to remove old log %s: %v", dir, err)
+		}
+	}
+}
+
+// readExportLog loads a log and pandoc's output from its directory
+func readExportLog(dir string) (*types.ExportLog, error) {
+	data, err := os.ReadFile(filepath.Join(dir, "log.json"))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read export log: %w", err)
+	}
+	var record types.ExportLog
+	if err := json.Unmarshal(data, &record); err != nil {
+		return nil, fmt.Errorf("invalid export log: %w", err)
+	}
+	record.Dir = dir
+
+	stdout, _ := os.ReadFile(filepath.Join(dir, "stdout.txt"))
+	stderr, _ := os.ReadFile(filepath.Join(dir, "stderr.txt"))
+	record.Stdout = string(stdout)
+	record.Stderr = string(stderr)
+	return &record, nil
+}
+
+// copyFile copies a file's contents to a new file
+func copyFile(source, destination string) error {
+	in, err := os.Open(source)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+
+	out, err := os.Create(destination)
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(out, in); err != nil {
+		out.Close()
+		return err
+	}
+	return out.Close()
+}
@@ -0,0 +1,117 @@
+package export
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/gomcpgo/docgen/pkg/types"
+)
+
+// fakePandoc is a pandoc stand-in that warns on stderr and creates its output file
+const fakePandoc = `#!/bin/sh
+echo "[WARNING] Could not convert TeX math" >&2
+while [ $# -gt 0 ]; do
+  if [ "$1" = "-o" ]; then touch "$2"; fi
+  shift
+done
+`
+
+func TestExporter_ExportLog(t *testing.T) {
+	exporter, tempDir := setupTestExporter(t)
+	defer os.RemoveAll(tempDir)
+
+	exporter.config.TempDir = filepath.Join(tempDir, "tmp")
+	exporter.config.PandocPath = filepath.Join(tempDir, "pandoc")
+	if err := os.WriteFile(exporter.config.PandocPath, []byte(fakePandoc), 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := exporter.LatestExportLog("test-doc"); err == nil {
+		t.Error("Expected an error before any export")
+	}
+
+	doc, manifest, style, pandocConfig := createTestDocument(t, tempDir)
+	for _, chapter := range doc.Chapters {
+		chapterPath := filepath.Join(tempDir, "test-doc", "chapters", fmt.Sprintf("%02d", chapter.Number))
+		os.MkdirAll(chapterPath, 0755)
+		os.WriteFile(filepath.Join(chapterPath, "chapter.md"), []byte(chapter.Content), 0644)
+	}
+	os.WriteFile(filepath.Join(tempDir, "test-doc", "manifest.yaml"), []byte("document: {}\n"), 0644)
+
+	outputPath, err := exporter.ExportDocument(context.Background(), "test-doc", manifest, style, pandocConfig, &types.ExportOptions{Format: types.ExportFormatHTML}, nil)
+	if err != nil {
+		t.Fatalf("ExportDocument() error = %v", err)
+	}
+
+	// The export's working directory is removed once the log has kept what it needs
+	if leftover, _ := os.ReadDir(exporter.config.TempDir); len(leftover) != 0 {
+		t.Errorf("Expected no temporary files after the export, found %d", len(leftover))
+	}
+
+	// A document whose ID extends this one's must not be mistaken for it
+	os.MkdirAll(exporter.config.ExportLogPath("test-doc-2", "20990101T000000.000Z"), 0755)
+
+	exportLog, err := exporter.LatestExportLog("test-doc")
+	if err != nil {
+		t.Fatalf("LatestExportLog() error = %v", err)
+	}
+	if exportLog.ExitCode != 0 || exportLog.Error != "" || exportLog.OutputPath != outputPath {
+		t.Errorf("Unexpected log %+v", exportLog)
+	}
+	if !strings.Contains(exportLog.Stderr, "[WARNING] Could not convert TeX math") {
+		t.Errorf("Expected pandoc's warning to be kept, got %q", exportLog.Stderr)
+	}
+	if len(exportLog.Files) == 0 || exportLog.Files[0] != "test-doc-input.md" {
+		t.Fatalf("Expected the combined markdown to be kept, got %v", exportLog.Files)
+	}
+	input, err := os.ReadFile(filepath.Join(exportLog.Dir, exportLog.Files[0]))
+	if err != nil || !strings.Contains(string(input), "This is the introduction chapter") {
+		t.Errorf("Unexpected kept input %q, %v", input, err)
+	}
+}
+
+func TestExporter_ExportDocument_Cancelled(t *testing.T) {
+	exporter, tempDir := setupTestExporter(t)
+	defer os.RemoveAll(tempDir)
+
+	exporter.config.TempDir = filepath.Join(tempDir, "tmp")
+	exporter.config.PandocPath = filepath.Join(tempDir, "pandoc")
+	if err := os.WriteFile(exporter.config.PandocPath, []byte(fakePandoc), 0755); err != nil {
+		t.Fatal(err)
+	}
+	doc, manifest, style, pandocConfig := createTestDocument(t, tempDir)
+	for _, chapter := range doc.Chapters {
+		chapterPath := filepath.Join(tempDir, "test-doc", "chapters", fmt.Sprintf("%02d", chapter.Number))
+		os.MkdirAll(chapterPath, 0755)
+		os.WriteFile(filepath.Join(chapterPath, "chapter.md"), []byte(chapter.Content), 0644)
+	}
+	os.WriteFile(filepath.Join(tempDir, "test-doc", "manifest.yaml"), []byte("document: {}\n"), 0644)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	// No chapter is rebuilt once the client has gone
+	rebuilt := 0
+	rebuild := func(types.DocumentID, types.ChapterNumber) error {
+		rebuilt++
+		return nil
+	}
+	options := &types.ExportOptions{Format: types.ExportFormatHTML}
+	if _, err := exporter.ExportDocument(ctx, "test-doc", manifest, style, pandocConfig, options, rebuild); !errors.Is(err, context.Canceled) {
+		t.Errorf("ExportDocument() error = %v, want a cancellation", err)
+	}
+	if rebuilt != 0 {
+		t.Errorf("Expected no chapters rebuilt, got %d", rebuilt)
+	}
+
+	// Nor is pandoc run
+	_, err := exporter.ExportDocument(ctx, "test-doc", manifest, style, pandocConfig, options, nil)
+	if err == nil || !strings.Contains(err.Error(), "cancelled") {
+		t.Errorf("ExportDocument() error = %v, want a cancellation", err)
+	}
+}
@@ -0,0 +1,348 @@
+package export
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/gomcpgo/docgen/pkg/types"
+)
+
+// hasMarkings reports whether an export asks for a watermark, banner or line numbers
+func hasMarkings(options *types.ExportOptions) bool {
+	return options.Watermark != "" || options.Banner != "" || options.LineNumbers
+}
+
+// generateMarkingsHeader creates the LaTeX preamble for the review markings of an export
+func generateMarkingsHeader(options *types.ExportOptions) string {
+	if !hasMarkings(options) {
+		return ""
+	}
+
+	var header strings.Builder
+	header.WriteString("\n% Review markings\n")
+	if options.Watermark != "" {
+		header.WriteString("\\usepackage{draftwatermark}\n")
+		header.WriteString(fmt.Sprintf("\\SetWatermarkText{%s}\n", latexSpecialChars.Replace(options.Watermark)))
+		header.WriteString("\\SetWatermarkScale{1}\n")
+		header.WriteString("\\SetWatermarkColor[gray]{0.85}\n")
+	}
+	if options.Banner != "" {
+		// Drawn over the page rather than in the footer so it coexists with fancyhdr footers
+		header.WriteString("\\usepackage{eso-pic}\n")
+		header.WriteString(fmt.Sprintf("\\AddToShipoutPictureFG{\\AtPageLowerLeft{\\makebox[\\paperwidth]{\\raisebox{1.5em}{\\footnotesize\\bfseries %s}}}}\n", latexSpecialChars.Replace(options.Banner)))
+	}
+	if options.LineNumbers {
+		header.WriteString("\\usepackage{lineno}\n")
+		header.WriteString("\\linenumbers\n")
+	}
+	return header.String()
+}
+
+// generateMarkingsCSS creates the stylesheet for the review markings of an HTML
+// export. Browsers wrap lines to the window, so paragraphs are numbered instead of lines.
+func generateMarkingsCSS(options *types.ExportOptions) string {
+	if !hasMarkings(options) {
+		return ""
+	}
+
+	var css strings.Builder
+	css.WriteString("/* Review markings */\n")
+	if options.Watermark != "" {
+		css.WriteString(fmt.Sprintf(`body::before {
+  content: "%s";
+  position: fixed;
+  top: 50%%;
+  left: 50%%;
+  transform: translate(-50%%, -50%%) rotate(-45deg);
+  font: bold 8em sans-serif;
+  color: rgba(0, 0, 0, 0.08);
+  white-space: nowrap;
+  pointer-events: none;
+  z-index: 1000;
+}
+`, cssString(options.Watermark)))
+	}
+	if options.Banner != "" {
+		css.WriteString(fmt.Sprintf(`body { padding-bottom: 3em; }
+body::after {
+  content: "%s";
+  position: fixed;
+  bottom: 0;
+  left: 0;
+  right: 0;
+  padding: 0.4em;
+  text-align: center;
+  font: bold 0.9em sans-serif;
+  color: #fff;
+  background: #b00020;
+  z-index: 1000;
+}
+@media print {
+  body::after { position: static; display: block; }
+}
+`, cssString(options.Banner)))
+	}
+	if options.LineNumbers {
+		css.WriteString(`body { counter-reset: docgen-paragraph; }
+p { position: relative; }
+p::before {
+  counter-increment: docgen-paragraph;
+  content: counter(docgen-paragraph);
+  position: absolute;
+  left: -3em;
+  width: 2.5em;
+  text-align: right;
+  font: 0.75em sans-serif;
+  color: #999;
+}
+`)
+	}
+	return css.String()
+}
+
+// cssString escapes text for a double-quoted CSS string
+func cssString(text string) string {
+	return strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\A `).Replace(text)
+}
+
+// DOCX parts and relationship added for the markings
+const (
+	markingsHeaderPart = "word/header-docgen.xml"
+	markingsFooterPart = "word/footer-docgen.xml"
+	markingsHeaderID   = "rIdDocgenHeader"
+	markingsFooterID   = "rIdDocgenFooter"
+)
+
+var (
+	sectPrPattern           = regexp.MustCompile(`(?s)<w:sectPr\b[^>]*?(/>|>.*?</w:sectPr>)`)
+	defaultHeaderRefPattern = regexp.MustCompile(`<w:headerReference\b[^>]*w:type="default"[^>]*/>`)
+	defaultFooterRefPattern = regexp.MustCompile(`<w:footerReference\b[^>]*w:type="default"[^>]*/>`)
+	lnNumTypePattern        = regexp.MustCompile(`<w:lnNumType\b[^>]*/>`)
+	// Elements that follow w:lnNumType in a section's properties
+	afterLnNumTypePattern = regexp.MustCompile(`<w:(pgNumType|cols|formProt|vAlign|noEndnote|titlePg|textDirection|bidi|rtlGutter|docGrid|printerSettings|sectPrChange)\b`)
+)
+
+// markedReferenceDoc writes a copy of a DOCX reference document, or pandoc's
+// default one when base is empty, whose page setup adds the watermark, banner
+// and line numbering of an export. pandoc takes headers, footers and section
+// properties from the reference document.
+func markedReferenceDoc(pandocPath, base, outputPath string, options *types.ExportOptions) error {
+	data, err := readReferenceDoc(pandocPath, base)
+	if err != nil {
+		return err
+	}
+
+	parts := map[string]string{}
+	if options.Watermark != "" {
+		parts[markingsHeaderPart] = watermarkHeaderXML(options.Watermark)
+	}
+	if options.Banner != "" {
+		parts[markingsFooterPart] = bannerFooterXML(options.Banner)
+	}
+
+	return rewriteReferenceDoc(data, outputPath, func(name string, content []byte) []byte {
+		switch name {
+		case "[Content_Types].xml":
+			return markContentTypes(content, options)
+		case "word/_rels/document.xml.rels":
+			return markRelationships(content, options)
+		case "word/document.xml":
+			return markSectionProperties(content, options)
+		}
+		return content
+	}, parts)
+}
+
+// readReferenceDoc reads a DOCX reference document, or pandoc's default one
+// when base is empty
+func readReferenceDoc(pandocPath, base string) ([]byte, error) {
+	var data []byte
+	var err error
+	if base != "" {
+		data, err = os.ReadFile(base)
+	} else {
+		data, err = exec.Command(pandocPath, "--print-default-data-file", "reference.docx").Output()
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read reference document: %w", err)
+	}
+	return data, nil
+}
+
+// rewriteReferenceDoc writes a copy of a reference document to outputPath,
+// passing each of its parts through edit and adding parts, which replace any
+// existing parts of the same name
+func rewriteReferenceDoc(data []byte, outputPath string, edit func(name string, content []byte) []byte, parts map[string]string) error {
+	reader, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		return fmt.Errorf("invalid reference document: %w", err)
+	}
+
+	var output bytes.Buffer
+	writer := zip.NewWriter(&output)
+	for _, file := range reader.File {
+		if _, replaced := parts[file.Name]; replaced {
+			continue
+		}
+		rc, err := file.Open()
+		if err != nil {
+			return fmt.Errorf("failed to read %s: %w", file.Name, err)
+		}
+		content, err := io.ReadAll(rc)
+		rc.Close()
+		if err != nil {
+			return fmt.Errorf("failed to read %s: %w", file.Name, err)
+		}
+
+		w, err := writer.Create(file.Name)
+		if err != nil {
+			return fmt.Errorf("failed to write %s: %w", file.Name, err)
+		}
+		if _, err := w.Write(edit(file.Name, content)); err != nil {
+			return fmt.Errorf("failed to write %s: %w", file.Name, err)
+		}
+	}
+
+	names := make([]string, 0, len(parts))
+	for name := range parts {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		w, err := writer.Create(name)
+		if err != nil {
+			return fmt.Errorf("failed to write %s: %w", name, err)
+		}
+		if _, err := io.WriteString(w, parts[name]); err != nil {
+			return fmt.Errorf("failed to write %s: %w", name, err)
+		}
+	}
+
+	if err := writer.Close(); err != nil {
+		return fmt.Errorf("failed to write reference document: %w", err)
+	}
+	return os.WriteFile(outputPath, output.Bytes(), 0644)
+}
+
+// markContentTypes registers the header and footer parts
+func markContentTypes(content []byte, options *types.ExportOptions) []byte {
+	var overrides strings.Builder
+	if options.Watermark != "" {
+		overrides.WriteString(`<Override PartName="/` + markingsHeaderPart + `" ContentType="application/vnd.openxmlformats-officedocument.wordprocessingml.header+xml"/>`)
+	}
+	if options.Banner != "" {
+		overrides.WriteString(`<Override PartName="/` + markingsFooterPart + `" ContentType="application/vnd.openxmlformats-officedocument.wordprocessingml.footer+xml"/>`)
+	}
+	return bytes.Replace(content, []byte("</Types>"), []byte(overrides.String()+"</Types>"), 1)
+}
+
+// markRelationships links the header and footer parts to the document
+func markRelationships(content []byte, options *types.ExportOptions) []byte {
+	var relationships strings.Builder
+	if options.Watermark != "" {
+		relationships.WriteString(`<Relationship Id="` + markingsHeaderID + `" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/header" Target="header-docgen.xml"/>`)
+	}
+	if options.Banner != "" {
+		relationships.WriteString(`<Relationship Id="` + markingsFooterID + `" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/footer" Target="footer-docgen.xml"/>`)
+	}
+	return bytes.Replace(content, []byte("</Relationships>"), []byte(relationships.String()+"</Relationships>"), 1)
+}
+
+// markSectionProperties points the document's last section at the marked header
+// and footer and turns on line numbering
+func markSectionProperties(content []byte, options *types.ExportOptions) []byte {
+	return editLastSectPr(content, func(body string) string {
+		return markSectPr(body, options)
+	})
+}
+
+// editLastSectPr passes the children of the document's last w:sectPr element,
+// the one pandoc takes from a reference document, through edit
+func editLastSectPr(content []byte, edit func(body string) string) []byte {
+	matches := sectPrPattern.FindAllIndex(content, -1)
+	if len(matches) == 0 {
+		// Without section properties pandoc uses its defaults, so add them
+		sectPr := "<w:sectPr>" + edit("") + "</w:sectPr>"
+		return bytes.Replace(content, []byte("</w:body>"), []byte(sectPr+"</w:body>"), 1)
+	}
+
+	last := matches[len(matches)-1]
+	sectPr := string(content[last[0]:last[1]])
+	if strings.HasSuffix(sectPr, "/>") {
+		sectPr = strings.TrimSuffix(sectPr, "/>") + "></w:sectPr>"
+	}
+	open := sectPr[:strings.Index(sectPr, ">")+1]
+	body := strings.TrimSuffix(sectPr[len(open):], "</w:sectPr>")
+
+	var result bytes.Buffer
+	result.Write(content[:last[0]])
+	result.WriteString(open + edit(body) + "</w:sectPr>")
+	result.Write(content[last[1]:])
+	return result.Bytes()
+}
+
+// markSectPr adds the markings to the children of a w:sectPr element, keeping
+// them in schema order
+func markSectPr(body string, options *types.ExportOptions) string {
+	var references strings.Builder
+	if options.Watermark != "" {
+		body = defaultHeaderRefPattern.ReplaceAllString(body, "")
+		references.WriteString(`<w:headerReference w:type="default" r:id="` + markingsHeaderID + `"/>`)
+	}
+	if options.Banner != "" {
+		body = defaultFooterRefPattern.ReplaceAllString(body, "")
+		references.WriteString(`<w:footerReference w:type="default" r:id="` + markingsFooterID + `"/>`)
+	}
+	// Header references come before footer references
+	if index := strings.Index(body, "<w:footerReference"); options.Watermark != "" && index >= 0 {
+		body = body[:index] + references.String() + body[index:]
+	} else {
+		body = references.String() + body
+	}
+
+	if options.LineNumbers {
+		body = lnNumTypePattern.ReplaceAllString(body, "")
+		body = insertBefore(body, afterLnNumTypePattern, `<w:lnNumType w:countBy="1" w:restart="newPage"/>`)
+	}
+
+	return body
+}
+
+// wordNamespaces are declared on the header and footer parts
+const wordNamespaces = `xmlns:w="http://schemas.openxmlformats.org/wordprocessingml/2006/main" xmlns:r="http://schemas.openxmlformats.org/officeDocument/2006/relationships" xmlns:v="urn:schemas-microsoft-com:vml" xmlns:o="urn:schemas-microsoft-com:office:office"`
+
+// watermarkHeaderXML returns a header part with a diagonal text watermark, the
+// VML shape Word itself uses for watermarks
+func watermarkHeaderXML(text string) string {
+	return `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<w:hdr ` + wordNamespaces + `><w:p><w:pPr><w:pStyle w:val="Header"/></w:pPr><w:r><w:pict>` +
+		`<v:shapetype id="_x0000_t136" coordsize="21600,21600" o:spt="136" adj="10800" path="m@7,l@8,m@5,21600l@6,21600e">` +
+		`<v:formulas><v:f eqn="sum #0 0 10800"/><v:f eqn="prod #0 2 1"/><v:f eqn="sum 21600 0 @1"/><v:f eqn="sum 0 0 @2"/><v:f eqn="sum 21600 0 @3"/><v:f eqn="if @0 @3 0"/><v:f eqn="if @0 21600 @1"/><v:f eqn="if @0 0 @2"/><v:f eqn="if @0 @4 21600"/><v:f eqn="mid @5 @6"/><v:f eqn="mid @8 @5"/><v:f eqn="mid @7 @8"/><v:f eqn="mid @6 @7"/><v:f eqn="sum @6 0 @5"/></v:formulas>` +
+		`<v:path textpathok="t" o:connecttype="custom" o:connectlocs="@9,0;@10,10800;@11,21600;@12,10800" o:connectangles="270,180,90,0"/>` +
+		`<v:textpath on="t" fitshape="t"/><o:lock v:ext="edit" text="t" shapetype="t"/></v:shapetype>` +
+		`<v:shape id="DocgenWatermark" o:spid="_x0000_s2049" type="#_x0000_t136" style="position:absolute;margin-left:0;margin-top:0;width:468pt;height:117pt;rotation:315;z-index:-251657216;mso-position-horizontal:center;mso-position-horizontal-relative:margin;mso-position-vertical:center;mso-position-vertical-relative:margin" o:allowincell="f" fillcolor="silver" stroked="f">` +
+		`<v:fill opacity=".5"/><v:textpath style="font-family:&quot;Calibri&quot;;font-size:1pt" string="` + xmlEscape(text) + `"/></v:shape>` +
+		`</w:pict></w:r></w:p></w:hdr>`
+}
+
+// bannerFooterXML returns a footer part with a centered banner line
+func bannerFooterXML(text string) string {
+	return `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<w:ftr ` + wordNamespaces + `><w:p><w:pPr><w:pStyle w:val="Footer"/><w:jc w:val="center"/></w:pPr>` +
+		`<w:r><w:rPr><w:b/><w:color w:val="B00020"/></w:rPr><w:t xml:space="preserve">` + xmlEscape(text) + `</w:t></w:r></w:p></w:ftr>`
+}
+
+// xmlEscape escapes text for XML content and attribute values
+func xmlEscape(text string) string {
+	var buf bytes.Buffer
+	xml.EscapeText(&buf, []byte(text))
+	return buf.String()
+}
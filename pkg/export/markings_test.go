@@ -0,0 +1,126 @@
+package export
+
+import (
+	"archive/zip"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/gomcpgo/docgen/pkg/types"
+)
+
+func TestGenerateMarkingsHeader(t *testing.T) {
+	if header := generateMarkingsHeader(&types.ExportOptions{Format: types.ExportFormatPDF}); header != "" {
+		t.Errorf("Expected no header without markings, got %q", header)
+	}
+
+	header := generateMarkingsHeader(&types.ExportOptions{
+		Format:      types.ExportFormatPDF,
+		Watermark:   "DRAFT",
+		Banner:      "R&D only",
+		LineNumbers: true,
+	})
+	for _, want := range []string{
+		"\\usepackage{draftwatermark}",
+		"\\SetWatermarkText{DRAFT}",
+		"R\\&D only",
+		"\\usepackage{lineno}",
+		"\\linenumbers",
+	} {
+		if !strings.Contains(header, want) {
+			t.Errorf("Expected %q in the header:\n%s", want, header)
+		}
+	}
+}
+
+func TestGenerateMarkingsCSS(t *testing.T) {
+	css := generateMarkingsCSS(&types.ExportOptions{Watermark: `Say "draft"`, LineNumbers: true})
+	if !strings.Contains(css, `content: "Say \"draft\""`) {
+		t.Errorf("Expected an escaped watermark, got:\n%s", css)
+	}
+	if !strings.Contains(css, "counter(docgen-paragraph)") {
+		t.Errorf("Expected paragraph numbering, got:\n%s", css)
+	}
+	if strings.Contains(css, "body::after") {
+		t.Errorf("Did not expect a banner")
+	}
+}
+
+func TestMarkedReferenceDoc(t *testing.T) {
+	tempDir := t.TempDir()
+
+	// A minimal reference document with its own default footer
+	base := filepath.Join(tempDir, "base.docx")
+	writeZip(t, base, map[string]string{
+		"[Content_Types].xml":          `<Types></Types>`,
+		"word/_rels/document.xml.rels": `<Relationships><Relationship Id="rId9" Target="footer1.xml"/></Relationships>`,
+		"word/document.xml":            `<w:document><w:body><w:p/><w:sectPr><w:footerReference w:type="default" r:id="rId9"/><w:pgSz w:w="12240"/><w:cols w:space="720"/></w:sectPr></w:body></w:document>`,
+	})
+
+	output := filepath.Join(tempDir, "marked.docx")
+	options := &types.ExportOptions{Watermark: "DRAFT <1>", LineNumbers: true}
+	if err := markedReferenceDoc("", base, output, options); err != nil {
+		t.Fatalf("markedReferenceDoc() error = %v", err)
+	}
+	parts := readZip(t, output)
+
+	document := parts["word/document.xml"]
+	want := `<w:sectPr><w:headerReference w:type="default" r:id="rIdDocgenHeader"/><w:footerReference w:type="default" r:id="rId9"/><w:pgSz w:w="12240"/><w:lnNumType w:countBy="1" w:restart="newPage"/><w:cols w:space="720"/></w:sectPr>`
+	if !strings.Contains(document, want) {
+		t.Errorf("Unexpected section properties:\n%s", document)
+	}
+	if !strings.Contains(parts["word/_rels/document.xml.rels"], `Id="rIdDocgenHeader"`) {
+		t.Errorf("Expected the header relationship, got %s", parts["word/_rels/document.xml.rels"])
+	}
+	if !strings.Contains(parts["[Content_Types].xml"], "/word/header-docgen.xml") {
+		t.Errorf("Expected the header content type, got %s", parts["[Content_Types].xml"])
+	}
+	if !strings.Contains(parts["word/header-docgen.xml"], `string="DRAFT &lt;1&gt;"`) {
+		t.Errorf("Expected the escaped watermark in the header part")
+	}
+	if _, ok := parts["word/footer-docgen.xml"]; ok {
+		t.Errorf("Did not expect a banner footer")
+	}
+}
+
+func writeZip(t *testing.T, path string, files map[string]string) {
+	t.Helper()
+	file, err := os.Create(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer file.Close()
+	writer := zip.NewWriter(file)
+	for name, content := range files {
+		w, err := writer.Create(name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		io.WriteString(w, content)
+	}
+	if err := writer.Close(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func readZip(t *testing.T, path string) map[string]string {
+	t.Helper()
+	reader, err := zip.OpenReader(path)
+	if err != nil {
+		t.Fatalf("Failed to open %s: %v", path, err)
+	}
+	defer reader.Close()
+	parts := make(map[string]string)
+	for _, file := range reader.File {
+		rc, err := file.Open()
+		if err != nil {
+			t.Fatal(err)
+		}
+		content, _ := io.ReadAll(rc)
+		rc.Close()
+		parts[file.Name] = string(content)
+	}
+	return parts
+}
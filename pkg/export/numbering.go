@@ -0,0 +1,134 @@
+package export
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/gomcpgo/docgen/pkg/types"
+)
+
+// cssCounterStyles maps chapter number formats to CSS counter styles
+var cssCounterStyles = map[types.NumberFormat]string{
+	types.NumberFormatRoman:   "upper-roman",
+	types.NumberFormatLetters: "upper-alpha",
+}
+
+// numberingMarker sets the counters LaTeX and CSS number figures and tables
+// with at the start of a chapter. Counters are set rather than stepped, so
+// exports of some of the chapters keep the numbers of the whole document. In a
+// volume they continue from the documents before.
+func numberingMarker(format types.ExportFormat, numbering *types.NumberingStyle, manifest *types.Manifest, chapterNum types.ChapterNumber, volume *types.VolumePlacement) string {
+	figures, tables := 0, 0
+	for _, chapter := range manifest.Document.Chapters {
+		if chapter.Number < chapterNum {
+			figures += len(chapter.Figures)
+			tables += len(chapter.Tables)
+		}
+	}
+	if volume != nil {
+		chapterNum += types.ChapterNumber(volume.ChapterOffset)
+		figures += volume.FigureOffset
+		tables += volume.TableOffset
+	}
+	if numbering.FigureNumbering != types.CounterContinuous {
+		figures = 0
+	}
+	if numbering.TableNumbering != types.CounterContinuous {
+		tables = 0
+	}
+
+	switch format {
+	case types.ExportFormatPDF:
+		return fmt.Sprintf("```{=latex}\n\\renewcommand{\\docgenchapter}{%s}\\setcounter{figure}{%d}\\setcounter{table}{%d}\n```\n\n",
+			numbering.ChapterLabel(chapterNum), figures, tables)
+	case types.ExportFormatHTML:
+		return fmt.Sprintf("```{=html}\n<div class=\"docgen-counters\" style=\"counter-set: docgen-chapter %d docgen-figure %d docgen-table %d\"></div>\n```\n\n",
+			chapterNum, figures, tables)
+	default:
+		return ""
+	}
+}
+
+// renumberHeadings rewrites a chapter's heading in a volume's chapter heading
+// style, and its numbered section headings, for the chapter number it has in
+// the volume
+func renumberHeadings(content string, chapter *types.Chapter, number types.ChapterNumber, numbering *types.NumberingStyle, heading types.ChapterHeadingStyle) string {
+	var style types.NumberingStyle
+	if numbering != nil {
+		style = *numbering
+	}
+
+	lines := strings.Split(content, "\n")
+	for i, index := range chapterHeadings(lines, chapter) {
+		if index < 0 {
+			lines[i] = "# " + heading.Heading(number, chapter.Title, style)
+			continue
+		}
+		section := chapter.Sections[index]
+		text := section.Title
+		if label := style.SectionLabel(append(types.SectionNumber{int(number)}, section.Number[1:]...)); label != "" {
+			text = label + " " + text
+		}
+		lines[i] = strings.Repeat("#", section.Level+1) + " " + text
+	}
+	return strings.Join(lines, "\n")
+}
+
+// generateNumberingHeader numbers LaTeX figures and tables as docgen does,
+// per chapter (II.3) or through the document. Chapters are unnumbered headings
+// to LaTeX, so the chapter part comes from each chapter's marker.
+func generateNumberingHeader(options *types.ExportOptions) string {
+	if options.Numbering == nil {
+		return ""
+	}
+
+	var header strings.Builder
+	header.WriteString("\n% Figure and table numbering\n")
+	header.WriteString("\\newcommand{\\docgenchapter}{}\n")
+	for _, counter := range []struct {
+		name  string
+		scope types.CounterScope
+	}{{"figure", options.Numbering.FigureNumbering}, {"table", options.Numbering.TableNumbering}} {
+		if counter.scope == types.CounterContinuous {
+			header.WriteString(fmt.Sprintf("\\renewcommand{\\the%s}{\\arabic{%s}}\n", counter.name, counter.name))
+		} else {
+			header.WriteString(fmt.Sprintf("\\renewcommand{\\the%s}{\\docgenchapter.\\arabic{%s}}\n", counter.name, counter.name))
+		}
+		// Keeps hyperref's link targets unique when the counters restart
+		header.WriteString(fmt.Sprintf("\\AtBeginDocument{\\providecommand{\\theH%s}{}\\renewcommand{\\theH%s}{\\the%s}}\n", counter.name, counter.name, counter.name))
+	}
+	return header.String()
+}
+
+// generateNumberingCSS numbers figure and table captions in HTML exports with
+// CSS counters, set by each chapter's marker, labelled in the document locale
+func generateNumberingCSS(options *types.ExportOptions, locale *documentLocale) string {
+	if options.Numbering == nil {
+		return ""
+	}
+	chapter := "counter(docgen-chapter)"
+	if style, ok := cssCounterStyles[options.Numbering.ChapterFormat]; ok {
+		chapter = fmt.Sprintf("counter(docgen-chapter, %s)", style)
+	}
+
+	var css strings.Builder
+	css.WriteString("/* Figure and table numbering */\n")
+	css.WriteString("body { counter-reset: docgen-chapter docgen-figure docgen-table; }\n")
+	for _, counter := range []struct {
+		selector string
+		label    string
+		name     string
+		scope    types.CounterScope
+	}{
+		{"figure:not(.listing) > figcaption", locale.Figure, "docgen-figure", options.Numbering.FigureNumbering},
+		{"table > caption", locale.Table, "docgen-table", options.Numbering.TableNumbering},
+	} {
+		number := fmt.Sprintf("counter(%s)", counter.name)
+		if counter.scope != types.CounterContinuous {
+			number = chapter + ` "." ` + number
+		}
+		css.WriteString(fmt.Sprintf("%s { counter-increment: %s; }\n", counter.selector, counter.name))
+		css.WriteString(fmt.Sprintf("%s::before { content: \"%s \" %s \": \"; font-weight: bold; }\n", counter.selector, counter.label, number))
+	}
+	return css.String()
+}
@@ -0,0 +1,69 @@
+package export
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/gomcpgo/docgen/pkg/types"
+)
+
+func TestNumberingMarker(t *testing.T) {
+	manifest := &types.Manifest{Document: types.Document{Chapters: []types.Chapter{
+		{Number: 1, Figures: make([]types.Figure, 2), Tables: make([]types.Table, 1)},
+		{Number: 2, Figures: make([]types.Figure, 3)},
+		{Number: 3},
+	}}}
+
+	perChapter := &types.NumberingStyle{ChapterFormat: types.NumberFormatRoman}
+	if marker := numberingMarker(types.ExportFormatPDF, perChapter, manifest, 3, nil); !strings.Contains(marker, `\renewcommand{\docgenchapter}{III}\setcounter{figure}{0}\setcounter{table}{0}`) {
+		t.Errorf("Per-chapter counters should restart: %q", marker)
+	}
+
+	// Continuous counters carry on from the chapters before, even in a partial export
+	continuous := &types.NumberingStyle{FigureNumbering: types.CounterContinuous, TableNumbering: types.CounterContinuous}
+	if marker := numberingMarker(types.ExportFormatPDF, continuous, manifest, 3, nil); !strings.Contains(marker, `\setcounter{figure}{5}\setcounter{table}{1}`) {
+		t.Errorf("Continuous counters should count earlier chapters: %q", marker)
+	}
+	if marker := numberingMarker(types.ExportFormatHTML, continuous, manifest, 2, nil); !strings.Contains(marker, "counter-set: docgen-chapter 2 docgen-figure 2 docgen-table 1") {
+		t.Errorf("HTML marker = %q", marker)
+	}
+	if marker := numberingMarker(types.ExportFormatDOCX, continuous, manifest, 2, nil); marker != "" {
+		t.Errorf("DOCX has no counters to set, got %q", marker)
+	}
+}
+
+func TestGenerateNumbering(t *testing.T) {
+	if generateNumberingHeader(&types.ExportOptions{}) != "" || generateNumberingCSS(&types.ExportOptions{}, &englishLocale) != "" {
+		t.Error("Expected no numbering setup without a numbering style")
+	}
+
+	options := &types.ExportOptions{Numbering: &types.NumberingStyle{ChapterFormat: types.NumberFormatLetters, TableNumbering: types.CounterContinuous}}
+	header := generateNumberingHeader(options)
+	if !strings.Contains(header, `\renewcommand{\thefigure}{\docgenchapter.\arabic{figure}}`) || !strings.Contains(header, `\renewcommand{\thetable}{\arabic{table}}`) {
+		t.Errorf("Unexpected LaTeX numbering header:\n%s", header)
+	}
+	css := generateNumberingCSS(options, &englishLocale)
+	if !strings.Contains(css, `"Figure " counter(docgen-chapter, upper-alpha) "." counter(docgen-figure)`) || !strings.Contains(css, `"Table " counter(docgen-table)`) {
+		t.Errorf("Unexpected numbering CSS:\n%s", css)
+	}
+}
+
+func TestGenerateMarkdown_NumberingMarkers(t *testing.T) {
+	exporter, tempDir := setupTestExporter(t)
+	defer os.RemoveAll(tempDir)
+
+	_, manifest, _, _ := createTestDocument(t, tempDir)
+	chapterPath := filepath.Join(tempDir, "test-doc", "chapters", "02")
+	os.MkdirAll(chapterPath, 0755)
+	os.WriteFile(filepath.Join(chapterPath, "chapter.md"), []byte("# Chapter 2: Methods\n"), 0644)
+	options := &types.ExportOptions{Format: types.ExportFormatPDF, Numbering: &types.NumberingStyle{}, Chapters: []types.ChapterNumber{2}}
+	markdown, err := exporter.GenerateMarkdown("test-doc", manifest, options)
+	if err != nil {
+		t.Fatalf("GenerateMarkdown() error = %v", err)
+	}
+	if strings.Count(markdown, `\renewcommand{\docgenchapter}`) != 1 || !strings.Contains(markdown, `\renewcommand{\docgenchapter}{2}`) {
+		t.Errorf("Expected a marker for chapter 2:\n%s", markdown)
+	}
+}
@@ -0,0 +1,108 @@
+package export
+
+import (
+	"regexp"
+	"strings"
+
+	"github.com/gomcpgo/docgen/pkg/types"
+)
+
+var (
+	divOpenPattern  = regexp.MustCompile(`^(:{3,})\s*([^:\s].*?)[\s:]*$`)
+	divClosePattern = regexp.MustCompile(`^:{3,}\s*$`)
+
+	// keepTogetherPattern matches the attributes of a div that keeps its
+	// content on one page, written as {.keep-together} or ::: keep-together
+	keepTogetherPattern = regexp.MustCompile(`^(?:\{[^}]*\.keep-together\b[^}]*\}|keep-together)$`)
+)
+
+const (
+	latexPageBreak   = "```{=latex}\n\\newpage\n```"
+	openXMLPageBreak = "```{=openxml}\n<w:p><w:r><w:br w:type=\"page\"/></w:r></w:p>\n```"
+	latexKeepStart   = "```{=latex}\n\\par\\noindent\\begin{minipage}{\\linewidth}\n```"
+	latexKeepEnd     = "```{=latex}\n\\end{minipage}\\par\n```"
+)
+
+// translateLayoutMarkers turns page break lines and keep-together divs into
+// what the format paginates with: raw LaTeX for PDF, a page break run and the
+// Keep Together paragraph style for DOCX. HTML and EPUB drop page breaks and
+// keep the div for stylesheets to style; plain text and SSML drop both.
+func translateLayoutMarkers(content string, format types.ExportFormat) string {
+	lines := strings.Split(content, "\n")
+	output := make([]string, 0, len(lines))
+	fence := ""
+	var divs []bool // whether each open div keeps together
+
+	for _, line := range lines {
+		trimmed := strings.TrimSpace(line)
+		if fence != "" {
+			if strings.HasPrefix(trimmed, fence) {
+				fence = ""
+			}
+			output = append(output, line)
+			continue
+		}
+		if strings.HasPrefix(trimmed, "```") || strings.HasPrefix(trimmed, "~~~") {
+			fence = trimmed[:3]
+			output = append(output, line)
+			continue
+		}
+
+		switch {
+		case trimmed == types.PageBreakMarker || trimmed == "\\newpage":
+			switch format {
+			case types.ExportFormatPDF:
+				output = append(output, "", latexPageBreak, "")
+			case types.ExportFormatDOCX:
+				output = append(output, "", openXMLPageBreak, "")
+			case types.ExportFormatRevealJS, types.ExportFormatPPTX:
+				output = append(output, "", slideBreak, "")
+			}
+
+		case divClosePattern.MatchString(trimmed):
+			if len(divs) == 0 {
+				output = append(output, line)
+				break
+			}
+			keep := divs[len(divs)-1]
+			divs = divs[:len(divs)-1]
+			if !keep {
+				output = append(output, line)
+				break
+			}
+			switch format {
+			case types.ExportFormatPDF:
+				output = append(output, line, "", latexKeepEnd, "")
+			case types.ExportFormatDOCX, types.ExportFormatHTML, types.ExportFormatEPUB, types.ExportFormatRevealJS, types.ExportFormatPPTX:
+				output = append(output, line)
+			}
+
+		case divOpenPattern.MatchString(trimmed):
+			match := divOpenPattern.FindStringSubmatch(trimmed)
+			keep := keepTogetherPattern.MatchString(match[2])
+			divs = append(divs, keep)
+			if !keep {
+				output = append(output, line)
+				break
+			}
+			switch format {
+			case types.ExportFormatPDF:
+				output = append(output, "", latexKeepStart, "", line)
+			case types.ExportFormatDOCX:
+				output = append(output, match[1]+" {custom-style=\"Keep Together\"}")
+			case types.ExportFormatHTML, types.ExportFormatEPUB, types.ExportFormatRevealJS, types.ExportFormatPPTX:
+				output = append(output, line)
+			}
+
+		default:
+			output = append(output, line)
+		}
+	}
+	return strings.Join(output, "\n")
+}
+
+// keepTogetherCSS keeps .keep-together divs in HTML exports from breaking
+// across printed pages
+func keepTogetherCSS() string {
+	return ".keep-together {\n    break-inside: avoid;\n    page-break-inside: avoid;\n}\n\n"
+}
@@ -0,0 +1,42 @@
+package export
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/gomcpgo/docgen/pkg/types"
+)
+
+func TestTranslateLayoutMarkers(t *testing.T) {
+	content := "Before.\n\n\\pagebreak\n\n::: {.keep-together}\nKept.\n\n::: {.note}\nNested.\n:::\n:::\n\n```\n\\pagebreak\n```"
+
+	tests := []struct {
+		format types.ExportFormat
+		want   string
+	}{
+		{types.ExportFormatPDF, "Before.\n\n\n```{=latex}\n\\newpage\n```\n\n\n\n```{=latex}\n\\par\\noindent\\begin{minipage}{\\linewidth}\n```\n\n" +
+			"::: {.keep-together}\nKept.\n\n::: {.note}\nNested.\n:::\n:::\n\n```{=latex}\n\\end{minipage}\\par\n```\n\n\n```\n\\pagebreak\n```"},
+		{types.ExportFormatDOCX, "Before.\n\n\n```{=openxml}\n<w:p><w:r><w:br w:type=\"page\"/></w:r></w:p>\n```\n\n\n" +
+			"::: {custom-style=\"Keep Together\"}\nKept.\n\n::: {.note}\nNested.\n:::\n:::\n\n```\n\\pagebreak\n```"},
+		{types.ExportFormatHTML, "Before.\n\n\n::: {.keep-together}\nKept.\n\n::: {.note}\nNested.\n:::\n:::\n\n```\n\\pagebreak\n```"},
+		{types.ExportFormatText, "Before.\n\n\nKept.\n\n::: {.note}\nNested.\n:::\n\n```\n\\pagebreak\n```"},
+	}
+	for _, tt := range tests {
+		if got := translateLayoutMarkers(content, tt.format); got != tt.want {
+			t.Errorf("translateLayoutMarkers(%s) =\n%s\nwant:\n%s", tt.format, got, tt.want)
+		}
+	}
+
+	if got := translateLayoutMarkers("::: keep-together :::\nKept.\n:::", types.ExportFormatDOCX); got != "::: {custom-style=\"Keep Together\"}\nKept.\n:::" {
+		t.Errorf("Expected a bare keep-together class to be recognized, got:\n%s", got)
+	}
+}
+
+func TestKeepTogetherStyles(t *testing.T) {
+	if css := generateHTMLCSS(&types.Style{}, &types.Manifest{}); !strings.Contains(css, ".keep-together {\n    break-inside: avoid;") {
+		t.Errorf("Expected keep-together rules in the CSS:\n%s", css)
+	}
+	if styles := docxStylesXML(&types.Style{}); !strings.Contains(styles, `w:styleId="KeepTogether"`) {
+		t.Errorf("Expected a Keep Together style in the DOCX styles")
+	}
+}
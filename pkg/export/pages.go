@@ -0,0 +1,321 @@
+package export
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/gomcpgo/docgen/pkg/types"
+)
+
+// Typesetting figures for page estimates. Lengths are in points.
+const (
+	defaultFontSize     = 10.0  // pandoc's LaTeX default
+	defaultPageMargin   = 108.0 // 1.5in, about what LaTeX's classes leave without geometry
+	baselineFactor      = 1.2   // LaTeX's baseline skip over the font size
+	averageCharWidth    = 0.5   // in ems, for a book face
+	chapterOpeningLines = 10    // a chapter heading with the space above and below it
+	sectionHeadingLines = 3
+	paragraphGapLines   = 0.5
+)
+
+// paperSizes are page widths and heights in points by LaTeX paper name
+var paperSizes = map[string][2]float64{
+	"letter":    {612, 792},
+	"legal":     {612, 1008},
+	"executive": {522, 756},
+	"a4":        {595.3, 841.9},
+	"a5":        {419.5, 595.3},
+	"b5":        {498.9, 708.7},
+}
+
+// pageMarkerPattern finds the markers precise counts write on each chapter's
+// first page
+var pageMarkerPattern = regexp.MustCompile(`DOCGEN-PAGE-(\d+)`)
+
+// pageLayout is the text area of a PDF page and the type set in it
+type pageLayout struct {
+	paper       string
+	textWidth   float64
+	textHeight  float64
+	fontSize    float64
+	lineSpacing float64
+}
+
+// pageLayoutFor reads the page layout from the style and pandoc config the way
+// PDF exports apply them: the margin is the style's top margin on every side,
+// and pandoc variables win over the style
+func pageLayoutFor(style *types.Style, pandocConfig *types.PandocConfig) pageLayout {
+	layout := pageLayout{paper: "letter", fontSize: defaultFontSize, lineSpacing: 1}
+	margin := defaultPageMargin
+
+	var fontSize string
+	if style != nil {
+		fontSize = style.Body.FontSize
+		if points, ok := lengthToPoints(style.Margins.Top); ok && points > 0 {
+			margin = points
+		}
+		if spacing, err := strconv.ParseFloat(style.LineSpacing, 64); err == nil && spacing > 0 {
+			layout.lineSpacing = spacing
+		}
+	}
+	if pandocConfig != nil {
+		for _, option := range pandocConfig.ClassOptions {
+			if paper := strings.TrimSuffix(option, "paper"); paper != option {
+				if _, ok := paperSizes[paper]; ok {
+					layout.paper = paper
+				}
+			}
+		}
+		if paper := strings.TrimSuffix(strings.ToLower(pandocConfig.Variables["papersize"]), "paper"); paper != "" {
+			if _, ok := paperSizes[paper]; ok {
+				layout.paper = paper
+			}
+		}
+		if value := pandocConfig.Variables["fontsize"]; value != "" {
+			fontSize = value
+		}
+	}
+	if points, ok := lengthToPoints(fontSize); ok && points > 0 {
+		layout.fontSize = points
+	}
+
+	size := paperSizes[layout.paper]
+	layout.textWidth = math.Max(size[0]-2*margin, size[0]/4)
+	layout.textHeight = math.Max(size[1]-2*margin, size[1]/4)
+	return layout
+}
+
+// linesPerPage is how many lines of body text fit on a page
+func (l pageLayout) linesPerPage() float64 {
+	return math.Max(1, math.Floor(l.textHeight/(l.fontSize*baselineFactor*l.lineSpacing)))
+}
+
+// wordsPerLine is how many average words fit on a line
+func (l pageLayout) wordsPerLine() float64 {
+	return math.Max(1, l.textWidth/(l.fontSize*averageCharWidth*bytesPerWord))
+}
+
+// chapterLines estimates the lines a chapter's markdown takes on the page:
+// wrapped paragraphs, code and table rows a line each, headings with their
+// spacing, figures a share of a page, and page breaks filling out the page
+func chapterLines(content string, layout pageLayout) float64 {
+	perPage := layout.linesPerPage()
+	var lines, words float64
+	flush := func() {
+		if words > 0 {
+			lines += math.Ceil(words/layout.wordsPerLine()) + paragraphGapLines
+			words = 0
+		}
+	}
+
+	fence, raw := "", false
+	for _, line := range strings.Split(content, "\n") {
+		trimmed := strings.TrimSpace(line)
+		if fence != "" {
+			if strings.HasPrefix(trimmed, fence) {
+				fence = ""
+			} else if !raw {
+				lines++
+			}
+			continue
+		}
+
+		switch {
+		case strings.HasPrefix(trimmed, "```") || strings.HasPrefix(trimmed, "~~~"):
+			flush()
+			fence, raw = trimmed[:3], strings.Contains(trimmed, "{=")
+			if !raw {
+				lines += paragraphGapLines
+			}
+		case trimmed == "":
+			flush()
+		case trimmed == types.PageBreakMarker || trimmed == "\\newpage":
+			flush()
+			lines = math.Ceil(lines/perPage) * perPage
+		case strings.HasPrefix(trimmed, "# "):
+			flush()
+			lines += chapterOpeningLines
+		case strings.HasPrefix(trimmed, "#"):
+			flush()
+			lines += sectionHeadingLines
+		case strings.HasPrefix(trimmed, "!["):
+			flush()
+			lines += pagesPerFigure * perPage
+		case strings.HasPrefix(trimmed, "|") || strings.HasPrefix(trimmed, "+-"):
+			flush()
+			lines++
+		case strings.HasPrefix(trimmed, ":::") || strings.HasPrefix(trimmed, "<!--"):
+		default:
+			words += float64(len(strings.Fields(trimmed)))
+		}
+	}
+	flush()
+	return lines
+}
+
+// EstimatePages predicts how many pages a PDF export of the document takes,
+// per chapter, from the style's page size, margins, font size and line
+// spacing, without running LaTeX. Every chapter starts on a new page, and the
+// title page and table of contents are counted in the total.
+func (e *Exporter) EstimatePages(documentID string, manifest *types.Manifest, style *types.Style, pandocConfig *types.PandocConfig, chapters []types.ChapterNumber, rebuildFunc ChapterRebuildFunc) (*types.PageEstimate, error) {
+	if err := rebuildChapters(documentID, manifest, chapters, rebuildFunc); err != nil {
+		return nil, err
+	}
+
+	layout := pageLayoutFor(style, pandocConfig)
+	perPage := layout.linesPerPage()
+	estimate := &types.PageEstimate{
+		Mode:         types.PageEstimateApproximate,
+		PageSize:     layout.paper,
+		WordsPerPage: int(layout.wordsPerLine() * perPage),
+		Chapters:     []types.ChapterPages{},
+	}
+
+	// The title page, and a contents line per chapter and section
+	if manifest.Document.Title != "" {
+		estimate.Pages++
+	}
+	tocEntries := 0
+	for _, chapter := range exportedChapters(manifest, chapters) {
+		tocEntries += 1 + len(chapter.Sections)
+		content, err := e.loadChapterContent(documentID, manifest, chapter.Number)
+		if err != nil {
+			estimate.Warnings = append(estimate.Warnings, fmt.Sprintf("Chapter %d has no content to estimate: %v", chapter.Number, err))
+			continue
+		}
+		content = stripRawBlocks(content, types.ExportFormatPDF)
+		pages := int(math.Max(1, math.Ceil(chapterLines(content, layout)/perPage)))
+		estimate.Chapters = append(estimate.Chapters, types.ChapterPages{Number: chapter.Number, Title: chapter.Title, Pages: pages})
+		estimate.Pages += pages
+	}
+	if pandocConfig != nil && pandocConfig.TOC {
+		estimate.Pages += int(math.Ceil(float64(tocEntries) / perPage))
+	}
+	return estimate, nil
+}
+
+// CountPages typesets the document as a draft PDF, with images left as boxes
+// of their size, and counts the pages each chapter takes with pdftotext. The
+// draft is thrown away; exports are left as they were.
+func (e *Exporter) CountPages(ctx context.Context, documentID string, manifest *types.Manifest, style *types.Style, pandocConfig *types.PandocConfig, chapters []types.ChapterNumber, rebuildFunc ChapterRebuildFunc) (*types.PageEstimate, error) {
+	pdfToText, err := exec.LookPath(e.config.PDFToTextPath)
+	if err != nil {
+		return nil, fmt.Errorf("pdftotext not found (%s); it is needed for precise page counts", e.config.PDFToTextPath)
+	}
+	if err := rebuildChapters(documentID, manifest, chapters, rebuildFunc); err != nil {
+		return nil, err
+	}
+	report := e.ValidateDocument(documentID, manifest)
+	if !report.Valid {
+		return nil, fmt.Errorf("document validation failed: %v", report.Errors)
+	}
+
+	options := &types.ExportOptions{Format: types.ExportFormatPDF, Chapters: chapters, PageMarkers: true}
+	if style != nil {
+		options.Numbering = &style.NumberingStyle
+		options.Typography = &style.Typography
+	}
+	markdown, err := e.GenerateMarkdown(documentID, manifest, options)
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate markdown: %w", err)
+	}
+	markdown, svgWarnings := e.convertSVGImages(ctx, documentID, markdown)
+	style, fontWarnings := e.prepareFonts(ctx, documentID, style, types.ExportFormatPDF)
+
+	workDir, err := e.newWorkDir(documentID)
+	if err != nil {
+		return nil, err
+	}
+	defer os.RemoveAll(workDir)
+	inputFile := filepath.Join(workDir, fmt.Sprintf("%s-input.md", documentID))
+	if err := os.WriteFile(inputFile, []byte(markdown), 0644); err != nil {
+		return nil, fmt.Errorf("failed to write temporary input file: %w", err)
+	}
+	outputFile := filepath.Join(workDir, fmt.Sprintf("%s-pages.pdf", documentID))
+
+	draftConfig := mergeChapterPandocOptions(pandocConfig, manifest, chapters)
+	draftConfig.ClassOptions = append(draftConfig.ClassOptions, "draft")
+	cmd := e.GeneratePandocCommand(ctx, documentID, inputFile, outputFile, manifest, style, draftConfig, options, "")
+	if _, err := e.runPandoc(ctx, documentID, types.ExportFormatPDF, cmd, inputFile, outputFile); err != nil {
+		return nil, err
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, e.config.ExportTimeout)
+	defer cancel()
+	text, err := exec.CommandContext(ctx, pdfToText, "-q", outputFile, "-").Output()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read the draft PDF with pdftotext: %w", err)
+	}
+
+	estimate := countChapterPages(string(text), exportedChapters(manifest, chapters))
+	estimate.PageSize = pageLayoutFor(style, pandocConfig).paper
+	estimate.Warnings = append(append(svgWarnings, fontWarnings...), estimate.Warnings...)
+	return estimate, nil
+}
+
+// countChapterPages finds where each chapter starts in the text of a draft
+// PDF, one page per form feed, from the markers on their first pages. A
+// chapter runs until the next one starts, the last to the end of the PDF.
+func countChapterPages(text string, chapters []types.Chapter) *types.PageEstimate {
+	pages := strings.Split(strings.TrimSuffix(text, "\f"), "\f")
+	estimate := &types.PageEstimate{Mode: types.PageEstimatePrecise, Pages: len(pages), Chapters: []types.ChapterPages{}}
+
+	starts := make(map[types.ChapterNumber]int)
+	for i, page := range pages {
+		for _, match := range pageMarkerPattern.FindAllStringSubmatch(page, -1) {
+			if number, err := strconv.Atoi(match[1]); err == nil {
+				if _, seen := starts[types.ChapterNumber(number)]; !seen {
+					starts[types.ChapterNumber(number)] = i + 1
+				}
+			}
+		}
+	}
+
+	for _, chapter := range chapters {
+		start, ok := starts[chapter.Number]
+		if !ok {
+			estimate.Warnings = append(estimate.Warnings, fmt.Sprintf("Chapter %d was not found in the draft PDF", chapter.Number))
+			continue
+		}
+		estimate.Chapters = append(estimate.Chapters, types.ChapterPages{Number: chapter.Number, Title: chapter.Title, StartPage: start})
+	}
+	for i := range estimate.Chapters {
+		end := estimate.Pages + 1
+		if i+1 < len(estimate.Chapters) {
+			end = estimate.Chapters[i+1].StartPage
+		}
+		estimate.Chapters[i].Pages = end - estimate.Chapters[i].StartPage
+	}
+	return estimate
+}
+
+// addPageMarker writes the chapter number under the chapter heading in a box
+// of no height, where pdftotext finds it without the layout changing
+func addPageMarker(content string, chapterNum types.ChapterNumber) string {
+	marker := fmt.Sprintf("```{=latex}\n\\par\\nointerlineskip\\vbox to 0pt{\\hbox{\\tiny DOCGEN-PAGE-%d}\\vss}\\nointerlineskip\n```\n\n", chapterNum)
+	heading, rest, found := strings.Cut(content, "\n")
+	if !found || !strings.HasPrefix(heading, "# ") {
+		return marker + content
+	}
+	return heading + "\n\n" + marker + strings.TrimLeft(rest, "\n")
+}
+
+// rebuildChapters brings the chapter files of a page count up to date
+func rebuildChapters(documentID string, manifest *types.Manifest, chapters []types.ChapterNumber, rebuildFunc ChapterRebuildFunc) error {
+	if rebuildFunc == nil {
+		return nil
+	}
+	for _, chapter := range exportedChapters(manifest, chapters) {
+		if err := rebuildFunc(types.DocumentID(documentID), chapter.Number); err != nil {
+			return fmt.Errorf("failed to rebuild chapter %d markdown: %w", chapter.Number, err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,147 @@
+package export
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/gomcpgo/docgen/pkg/types"
+)
+
+// draftPandoc stands in for pandoc: it copies its input next to the draft PDF
+// it writes
+const draftPandoc = `#!/bin/sh
+input="$1"
+while [ $# -gt 0 ]; do
+  if [ "$1" = "-o" ]; then out="$2"; fi
+  shift
+done
+cp "$input" "$(dirname "$out")/../draft-input.md"
+echo "%PDF" > "$out"
+`
+
+// draftPDFToText stands in for pdftotext: a title page, a contents page, two
+// pages of chapter 1 and one of chapter 2
+const draftPDFToText = `#!/bin/sh
+printf 'Test Document\fContents\n1 Introduction\fDOCGEN-PAGE-1\nIntroduction\fmore\fDOCGEN-PAGE-2\nMethods\f'
+`
+
+func TestPageLayoutFor(t *testing.T) {
+	layout := pageLayoutFor(&types.Style{Body: types.TextStyle{FontSize: "12pt"}, LineSpacing: "1.5"}, nil)
+	if layout.paper != "letter" || layout.linesPerPage() != 26 || layout.wordsPerLine() != 11 {
+		t.Errorf("Unexpected layout %+v: %v lines of %v words", layout, layout.linesPerPage(), layout.wordsPerLine())
+	}
+
+	style := &types.Style{Body: types.TextStyle{FontSize: "12pt"}, Margins: types.Margins{Top: "1in"}}
+	layout = pageLayoutFor(style, &types.PandocConfig{ClassOptions: []string{"a5paper"}, Variables: map[string]string{"fontsize": "10pt"}})
+	if layout.paper != "a5" || layout.fontSize != 10 || layout.textWidth != 419.5-144 {
+		t.Errorf("Expected pandoc's paper and font size over the style, got %+v", layout)
+	}
+	if layout := pageLayoutFor(nil, &types.PandocConfig{Variables: map[string]string{"papersize": "A4"}}); layout.paper != "a4" {
+		t.Errorf("Expected A4 paper, got %s", layout.paper)
+	}
+}
+
+func TestChapterLines(t *testing.T) {
+	layout := pageLayoutFor(&types.Style{Body: types.TextStyle{FontSize: "12pt"}, LineSpacing: "1.5"}, nil)
+	content := "# Introduction\n\n" + strings.Repeat("word ", 300) + "\n\n" + types.PageBreakMarker + "\n\n![A plot](plot.png)\n\n```{=latex}\n\\clearpage\n```\n\n```go\nx := 1\ny := 2\n```\n\n## Results\n\n| a | b |\n|---|---|\n| 1 | 2 |"
+	// 10 opening lines and 28 of text with a gap, filled out to 52 by the
+	// page break, half a page for the figure, and code, a heading and a table
+	if got, want := chapterLines(content, layout), 52.0+13+0.5+2+3+3; got != want {
+		t.Errorf("chapterLines() = %v, want %v", got, want)
+	}
+}
+
+func TestExporter_EstimatePages(t *testing.T) {
+	exporter, tempDir := setupTestExporter(t)
+	defer os.RemoveAll(tempDir)
+
+	_, manifest, style, pandocConfig := createTestDocument(t, tempDir)
+	chapterPath := filepath.Join(tempDir, "test-doc", "chapters", "01")
+	os.MkdirAll(chapterPath, 0755)
+	os.WriteFile(filepath.Join(chapterPath, "chapter.md"), []byte("# Introduction\n\n"+strings.Repeat("word ", 300)), 0644)
+
+	var rebuilt []types.ChapterNumber
+	rebuild := func(docID types.DocumentID, chapterNum types.ChapterNumber) error {
+		rebuilt = append(rebuilt, chapterNum)
+		return nil
+	}
+	estimate, err := exporter.EstimatePages("test-doc", manifest, style, pandocConfig, nil, rebuild)
+	if err != nil {
+		t.Fatalf("EstimatePages() error = %v", err)
+	}
+	if len(rebuilt) != 2 {
+		t.Errorf("Expected both chapters rebuilt, got %v", rebuilt)
+	}
+	if estimate.Mode != types.PageEstimateApproximate || estimate.WordsPerPage != 286 || estimate.PageSize != "letter" {
+		t.Errorf("Unexpected estimate %+v", estimate)
+	}
+	// The title page, two pages of chapter 1 and a contents page
+	if len(estimate.Chapters) != 1 || estimate.Chapters[0].Pages != 2 || estimate.Pages != 4 {
+		t.Errorf("Unexpected page counts %+v", estimate)
+	}
+	if len(estimate.Warnings) != 1 || !strings.Contains(estimate.Warnings[0], "Chapter 2") {
+		t.Errorf("Expected a warning for the chapter without content, got %v", estimate.Warnings)
+	}
+}
+
+func TestCountChapterPages(t *testing.T) {
+	chapters := []types.Chapter{{Number: 1, Title: "Introduction"}, {Number: 2, Title: "Methods"}, {Number: 3, Title: "Results"}}
+	estimate := countChapterPages("Title\fContents\fIntro DOCGEN-PAGE-1\fmore DOCGEN-PAGE-1\fDOCGEN-PAGE-2\fmore\fReferences\f", chapters)
+	if estimate.Pages != 7 || len(estimate.Chapters) != 2 {
+		t.Fatalf("Unexpected estimate %+v", estimate)
+	}
+	if got := estimate.Chapters[0]; got.StartPage != 3 || got.Pages != 2 {
+		t.Errorf("Unexpected pages for chapter 1: %+v", got)
+	}
+	if len(estimate.Warnings) != 1 || !strings.Contains(estimate.Warnings[0], "Chapter 3") {
+		t.Errorf("Expected a warning for the missing chapter, got %v", estimate.Warnings)
+	}
+}
+
+func TestExporter_CountPages(t *testing.T) {
+	exporter, tempDir := setupTestExporter(t)
+	defer os.RemoveAll(tempDir)
+
+	binDir := filepath.Join(tempDir, "bin")
+	os.MkdirAll(binDir, 0755)
+	exporter.config.PandocPath = filepath.Join(binDir, "pandoc")
+	exporter.config.PDFToTextPath = filepath.Join(binDir, "pdftotext")
+	os.WriteFile(exporter.config.PandocPath, []byte(draftPandoc), 0755)
+
+	doc, manifest, style, pandocConfig := createTestDocument(t, tempDir)
+	for _, chapter := range doc.Chapters {
+		chapterPath := filepath.Join(tempDir, "test-doc", "chapters", fmt.Sprintf("%02d", chapter.Number))
+		os.MkdirAll(chapterPath, 0755)
+		os.WriteFile(filepath.Join(chapterPath, "chapter.md"), []byte(chapter.Content), 0644)
+	}
+	os.WriteFile(filepath.Join(tempDir, "test-doc", "manifest.yaml"), []byte("document: {}\n"), 0644)
+
+	if _, err := exporter.CountPages(context.Background(), "test-doc", manifest, style, pandocConfig, nil, nil); err == nil || !strings.Contains(err.Error(), "pdftotext not found") {
+		t.Fatalf("Expected an error without pdftotext, got %v", err)
+	}
+	os.WriteFile(exporter.config.PDFToTextPath, []byte(draftPDFToText), 0755)
+
+	estimate, err := exporter.CountPages(context.Background(), "test-doc", manifest, style, pandocConfig, nil, nil)
+	if err != nil {
+		t.Fatalf("CountPages() error = %v", err)
+	}
+	if estimate.Mode != types.PageEstimatePrecise || estimate.Pages != 5 || len(estimate.Chapters) != 2 {
+		t.Fatalf("Unexpected estimate %+v", estimate)
+	}
+	if estimate.Chapters[0].StartPage != 3 || estimate.Chapters[0].Pages != 2 || estimate.Chapters[1].Pages != 1 {
+		t.Errorf("Unexpected chapter pages %+v", estimate.Chapters)
+	}
+
+	// The draft marks each chapter's first page and leaves the exports alone
+	input, _ := os.ReadFile(filepath.Join(exporter.config.TempPath(), "draft-input.md"))
+	if !strings.Contains(string(input), "# Introduction\n\n```{=latex}\n\\par\\nointerlineskip\\vbox to 0pt{\\hbox{\\tiny DOCGEN-PAGE-1}") {
+		t.Errorf("Expected a page marker under the chapter heading:\n%s", input)
+	}
+	if _, err := os.Stat(exporter.config.ExportPath("test-doc", "pdf")); err == nil {
+		t.Error("A page count should not write a PDF export")
+	}
+}
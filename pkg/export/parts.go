@@ -0,0 +1,32 @@
+package export
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/gomcpgo/docgen/pkg/types"
+)
+
+// latexSpecialChars escapes the characters LaTeX treats specially in text
+var latexSpecialChars = strings.NewReplacer(
+	`\`, `\textbackslash{}`,
+	`{`, `\{`,
+	`}`, `\}`,
+	`$`, `\$`,
+	`&`, `\&`,
+	`#`, `\#`,
+	`%`, `\%`,
+	`_`, `\_`,
+	`^`, `\textasciicircum{}`,
+	`~`, `\textasciitilde{}`,
+)
+
+// partHeading returns the heading that opens a part. PDF output uses LaTeX's
+// \part, which starts its own page and appears in the table of contents; other
+// formats get an unnumbered top-level heading on a new page.
+func partHeading(format types.ExportFormat, number int, title string) string {
+	if format == types.ExportFormatPDF {
+		return fmt.Sprintf("```{=latex}\n\\part{%s}\n```\n\n", latexSpecialChars.Replace(title))
+	}
+	return fmt.Sprintf("%s# Part %s: %s {.part .unnumbered}\n\n", chapterBreak(format), types.RomanNumeral(number), title)
+}
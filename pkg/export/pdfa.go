@@ -0,0 +1,140 @@
+package export
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+
+	"github.com/gomcpgo/docgen/pkg/types"
+)
+
+// builtinSRGBProfile is the sRGB color profile built into ghostscript
+const builtinSRGBProfile = "%rom%iccprofiles/srgb.icc"
+
+// pdfaDefinition is the PostScript that gives a PDF/A conversion its output
+// intent, embedding the ICC profile named by %s. It follows the PDFA_def.ps
+// that ships with ghostscript.
+const pdfaDefinition = `%%!
+/ICCProfile (%s) def
+[/_objdef {icc_PDFA} /type /stream /OBJ pdfmark
+[{icc_PDFA} << /N 3 >> /PUT pdfmark
+[{icc_PDFA} ICCProfile (r) file /PUT pdfmark
+[/_objdef {OutputIntent_PDFA} /type /dict /OBJ pdfmark
+[{OutputIntent_PDFA} <<
+  /Type /OutputIntent
+  /S /GTS_PDFA1
+  /DestOutputProfile {icc_PDFA}
+  /OutputConditionIdentifier (sRGB)
+>> /PUT pdfmark
+[{Catalog} << /OutputIntents [ {OutputIntent_PDFA} ] >> /PUT pdfmark
+`
+
+// convertPDFA rewrites a PDF export as PDF/A-2b with ghostscript, in place:
+// every font embedded, colors converted to RGB with an sRGB output intent, and
+// XMP metadata made from the document information pandoc wrote. The result is
+// then checked with veraPDF when it is installed.
+func (e *Exporter) convertPDFA(ctx context.Context, result *types.ExportResult) error {
+	gsPath, err := exec.LookPath(e.config.GhostscriptPath)
+	if err != nil {
+		return fmt.Errorf("ghostscript not found (%s); it is needed for PDF/A exports", e.config.GhostscriptPath)
+	}
+
+	profile := e.config.ICCProfilePath
+	if profile == "" {
+		profile = builtinSRGBProfile
+	}
+	definition := result.OutputPath + ".pdfa_def.ps"
+	if err := os.WriteFile(definition, []byte(fmt.Sprintf(pdfaDefinition, escapePostScript(profile))), 0644); err != nil {
+		return fmt.Errorf("failed to write PDF/A definition: %w", err)
+	}
+	defer os.Remove(definition)
+
+	converted := result.OutputPath + ".pdfa"
+	args := []string{
+		"-dPDFA=2", "-dBATCH", "-dNOPAUSE", "-dQUIET",
+		"-sDEVICE=pdfwrite",
+		"-sColorConversionStrategy=RGB",
+		"-dPDFACompatibilityPolicy=1",
+		"-dEmbedAllFonts=true",
+	}
+	if profile != builtinSRGBProfile {
+		args = append(args, "--permit-file-read="+profile)
+	}
+	args = append(args, "-sOutputFile="+converted, definition, result.OutputPath)
+
+	ctx, cancel := context.WithTimeout(ctx, e.config.ExportTimeout)
+	defer cancel()
+
+	output, err := exec.CommandContext(ctx, gsPath, args...).CombinedOutput()
+	if err != nil {
+		os.Remove(converted)
+		return fmt.Errorf("failed to convert PDF to PDF/A: %w: %s", err, strings.TrimSpace(string(output)))
+	}
+	if err := os.Rename(converted, result.OutputPath); err != nil {
+		os.Remove(converted)
+		return fmt.Errorf("failed to replace PDF with its PDF/A copy: %w", err)
+	}
+	result.PDFA = true
+
+	valid, problems, err := e.validatePDFA(ctx, result.OutputPath)
+	switch {
+	case err != nil:
+		result.Warnings = append(result.Warnings, err.Error())
+	case valid:
+		result.PDFAValidated = true
+	default:
+		result.Warnings = append(result.Warnings, "PDF/A-2b validation failed: "+strings.Join(problems, "; "))
+	}
+	return nil
+}
+
+// validatePDFA checks a PDF against PDF/A-2b with veraPDF, returning the rules
+// it breaks when it doesn't comply
+func (e *Exporter) validatePDFA(ctx context.Context, path string) (bool, []string, error) {
+	veraPDFPath, err := exec.LookPath(e.config.VeraPDFPath)
+	if err != nil {
+		return false, nil, fmt.Errorf("veraPDF not found (%s); the PDF/A export was not validated", e.config.VeraPDFPath)
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, e.config.ExportTimeout)
+	defer cancel()
+
+	// veraPDF exits with 1 when the file doesn't comply, so the output decides
+	output, _ := exec.CommandContext(ctx, veraPDFPath, "--flavour", "2b", "--format", "text", "--verbose", path).CombinedOutput()
+	return parseVeraPDFOutput(string(output))
+}
+
+// parseVeraPDFOutput reads veraPDF's text report: a PASS or FAIL line for the
+// file, followed in verbose mode by the failed rules
+func parseVeraPDFOutput(output string) (bool, []string, error) {
+	var problems []string
+	verdict := ""
+	for _, line := range strings.Split(output, "\n") {
+		// The failed rules are indented under the verdict
+		switch {
+		case strings.HasPrefix(line, "PASS "):
+			verdict = "PASS"
+		case strings.HasPrefix(line, "FAIL "):
+			verdict = "FAIL"
+		case verdict == "FAIL" && strings.TrimSpace(line) != "":
+			problems = append(problems, strings.TrimSpace(line))
+		}
+	}
+	switch verdict {
+	case "PASS":
+		return true, nil, nil
+	case "FAIL":
+		if len(problems) == 0 {
+			problems = []string{"veraPDF gave no details"}
+		}
+		return false, problems, nil
+	}
+	return false, nil, fmt.Errorf("veraPDF could not validate the PDF/A export: %s", strings.TrimSpace(output))
+}
+
+// escapePostScript escapes a string for use between parentheses in PostScript
+func escapePostScript(s string) string {
+	return strings.NewReplacer(`\`, `\\`, "(", `\(`, ")", `\)`).Replace(s)
+}
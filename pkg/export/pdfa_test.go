@@ -0,0 +1,93 @@
+package export
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/gomcpgo/docgen/pkg/types"
+)
+
+// fakeGhostscript copies its last argument to its -sOutputFile and keeps the
+// PDF/A definition it was given next to itself
+const fakeGhostscript = `#!/bin/sh
+for arg; do
+  case "$arg" in
+    -sOutputFile=*) output="${arg#-sOutputFile=}" ;;
+    *.ps) cp "$arg" "$0.def" ;;
+  esac
+  last="$arg"
+done
+cp "$last" "$output"
+echo pdfa >> "$output"
+`
+
+func TestParseVeraPDFOutput(t *testing.T) {
+	valid, problems, err := parseVeraPDFOutput("PASS /tmp/doc.pdf PDF/A-2B\n")
+	if err != nil || !valid || len(problems) != 0 {
+		t.Errorf("parseVeraPDFOutput(PASS) = %v, %v, %v", valid, problems, err)
+	}
+
+	valid, problems, err = parseVeraPDFOutput("FAIL /tmp/doc.pdf PDF/A-2B\n  FAIL 6.2.11.4.1-1 The font programs for all fonts used within a conforming file shall be embedded\n")
+	if err != nil || valid || len(problems) != 1 || !strings.Contains(problems[0], "6.2.11.4.1-1") {
+		t.Errorf("parseVeraPDFOutput(FAIL) = %v, %v, %v", valid, problems, err)
+	}
+
+	if _, _, err := parseVeraPDFOutput("Exception: could not open file"); err == nil {
+		t.Errorf("parseVeraPDFOutput() should fail without a verdict")
+	}
+}
+
+func TestExporter_FinishExportPDFA(t *testing.T) {
+	exporter, tempDir := setupTestExporter(t)
+	defer os.RemoveAll(tempDir)
+
+	output := filepath.Join(tempDir, "doc.pdf")
+	os.WriteFile(output, []byte("%PDF-1.7\n"), 0644)
+	options := &types.ExportOptions{Format: types.ExportFormatPDF, PDFA: true}
+
+	// Without ghostscript the export fails
+	exporter.config.GhostscriptPath = filepath.Join(tempDir, "missing", "gs")
+	if err := exporter.finishExport(context.Background(), &types.ExportResult{OutputPath: output}, options); err == nil || !strings.Contains(err.Error(), "ghostscript not found") {
+		t.Errorf("Expected a missing ghostscript error, got %v", err)
+	}
+
+	exporter.config.GhostscriptPath = filepath.Join(tempDir, "gs")
+	exporter.config.VeraPDFPath = filepath.Join(tempDir, "missing", "verapdf")
+	if err := os.WriteFile(exporter.config.GhostscriptPath, []byte(fakeGhostscript), 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	// Without veraPDF the PDF/A export is kept unvalidated, with a warning
+	result := &types.ExportResult{OutputPath: output}
+	if err := exporter.finishExport(context.Background(), result, options); err != nil {
+		t.Fatalf("finishExport() error = %v", err)
+	}
+	if data, _ := os.ReadFile(output); !result.PDFA || !strings.Contains(string(data), "pdfa") {
+		t.Errorf("Expected the PDF/A copy in place, got %+v and %q", result, data)
+	}
+	if result.PDFAValidated || len(result.Warnings) != 1 || !strings.Contains(result.Warnings[0], "veraPDF not found") {
+		t.Errorf("Expected a veraPDF warning, got %+v", result)
+	}
+	if definition, _ := os.ReadFile(exporter.config.GhostscriptPath + ".def"); !strings.Contains(string(definition), "(%rom%iccprofiles/srgb.icc)") {
+		t.Errorf("Expected the built-in sRGB profile in the definition, got:\n%s", definition)
+	}
+	if _, err := os.Stat(output + ".pdfa_def.ps"); !os.IsNotExist(err) {
+		t.Errorf("The PDF/A definition should be removed, stat error = %v", err)
+	}
+
+	// veraPDF's verdict is reported
+	exporter.config.VeraPDFPath = filepath.Join(tempDir, "verapdf")
+	if err := os.WriteFile(exporter.config.VeraPDFPath, []byte("#!/bin/sh\necho \"PASS $6 PDF/A-2B\"\n"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	result = &types.ExportResult{OutputPath: output}
+	if err := exporter.finishExport(context.Background(), result, options); err != nil {
+		t.Fatalf("finishExport() error = %v", err)
+	}
+	if !result.PDFAValidated || len(result.Warnings) != 0 {
+		t.Errorf("Expected a validated PDF/A export, got %+v", result)
+	}
+}
@@ -0,0 +1,78 @@
+package export
+
+import (
+	"math"
+	"os"
+	"path/filepath"
+
+	"github.com/gomcpgo/docgen/pkg/types"
+)
+
+// Rough figures for estimating an export from file sizes alone
+const (
+	bytesPerWord   = 6   // an average English word with its space and markup
+	wordsPerPage   = 350 // a typeset book page
+	pagesPerFigure = 0.5
+	pagesPerTable  = 0.3
+)
+
+// exportCost is the estimated time an export format takes: a fixed start-up
+// cost plus time per page and per megabyte of images
+type exportCost struct {
+	base, perPage, perImageMB float64
+}
+
+// exportCosts are rough per-format costs; LaTeX dominates PDF exports
+var exportCosts = map[types.ExportFormat]exportCost{
+	types.ExportFormatPDF:  {base: 5, perPage: 0.4, perImageMB: 1.5},
+	types.ExportFormatDOCX: {base: 2, perPage: 0.05, perImageMB: 0.3},
+	types.ExportFormatHTML: {base: 2, perPage: 0.05, perImageMB: 0.3},
+	types.ExportFormatEPUB: {base: 2, perPage: 0.05, perImageMB: 0.3},
+	types.ExportFormatText: {base: 1},
+	types.ExportFormatSSML: {base: 1},
+	types.ExportFormatJSON: {base: 1},
+
+	types.ExportFormatRevealJS: {base: 2, perPage: 0.05, perImageMB: 0.3},
+	types.ExportFormatPPTX:     {base: 3, perPage: 0.1, perImageMB: 0.5},
+}
+
+// Preflight summarizes an export without running it: the chapters it covers,
+// their estimated length and how long the export is likely to take, along with
+// the warnings and errors validation would report. Sizes come from the
+// compiled chapter files, so nothing is rebuilt or read in full.
+func (e *Exporter) Preflight(documentID string, manifest *types.Manifest, options *types.ExportOptions) *types.ExportPreflight {
+	preflight := &types.ExportPreflight{Format: options.Format}
+
+	var contentBytes int64
+	for _, chapter := range exportedChapters(manifest, options.Chapters) {
+		preflight.Chapters++
+		if contentPath, err := e.chapterContentPath(documentID, manifest, chapter.Number); err == nil {
+			if info, err := os.Stat(contentPath); err == nil {
+				contentBytes += info.Size()
+			}
+		}
+		preflight.Figures += len(chapter.Figures)
+		preflight.Tables += len(chapter.Tables)
+		for _, figure := range chapter.Figures {
+			for _, figureImage := range figure.ImagePaths() {
+				imagePath := filepath.Join(e.config.AssetsPath(documentID), filepath.Base(figureImage))
+				if info, err := os.Stat(imagePath); err == nil {
+					preflight.ImageBytes += info.Size()
+				}
+			}
+		}
+	}
+
+	preflight.Words = int(contentBytes / bytesPerWord)
+	pages := float64(preflight.Words)/wordsPerPage + float64(preflight.Figures)*pagesPerFigure + float64(preflight.Tables)*pagesPerTable
+	preflight.Pages = int(math.Max(1, math.Ceil(pages)))
+
+	cost := exportCosts[options.Format]
+	imageMB := float64(preflight.ImageBytes) / (1024 * 1024)
+	preflight.EstimatedSeconds = int(math.Ceil(cost.base + cost.perPage*float64(preflight.Pages) + cost.perImageMB*imageMB))
+
+	report := e.ValidateDocument(documentID, manifest)
+	preflight.Warnings = report.Warnings
+	preflight.Errors = report.Errors
+	return preflight
+}
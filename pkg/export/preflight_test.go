@@ -0,0 +1,52 @@
+package export
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/gomcpgo/docgen/pkg/types"
+)
+
+func TestExporter_Preflight(t *testing.T) {
+	exporter, tempDir := setupTestExporter(t)
+	defer os.RemoveAll(tempDir)
+
+	_, manifest, _, _ := createTestDocument(t, tempDir)
+	docDir := filepath.Join(tempDir, "test-doc")
+	for _, dir := range []string{"chapters/01", "chapters/02", "assets/images"} {
+		os.MkdirAll(filepath.Join(docDir, dir), 0755)
+	}
+	// About 3500 words in chapter 1, two pages of figures and tables in chapter 2
+	os.WriteFile(filepath.Join(docDir, "chapters", "01", "chapter.md"), []byte(strings.Repeat("words ", 3500)), 0644)
+	os.WriteFile(filepath.Join(docDir, "chapters", "02", "chapter.md"), []byte("# Methods\n"), 0644)
+	os.WriteFile(filepath.Join(docDir, "assets", "images", "plot.png"), make([]byte, 2*1024*1024), 0644)
+	manifest.Document.Chapters[1].Figures = []types.Figure{{ImagePath: "assets/images/plot.png"}, {ImagePath: "assets/images/missing.png"}}
+	manifest.Document.Chapters[1].Tables = []types.Table{{Caption: "Results"}}
+
+	preflight := exporter.Preflight("test-doc", manifest, &types.ExportOptions{Format: types.ExportFormatPDF})
+	if preflight.Chapters != 2 || preflight.Figures != 2 || preflight.Tables != 1 {
+		t.Errorf("Preflight() counts = %+v", preflight)
+	}
+	if preflight.Words != 3501 || preflight.ImageBytes != 2*1024*1024 {
+		t.Errorf("Preflight() words = %d, image bytes = %d", preflight.Words, preflight.ImageBytes)
+	}
+	// 10 pages of text, 1 of figures and 0.3 of tables
+	if preflight.Pages != 12 {
+		t.Errorf("Preflight() pages = %d, want 12", preflight.Pages)
+	}
+	// 5s to start, 0.4s a page and 1.5s per megabyte of images
+	if preflight.EstimatedSeconds != 13 {
+		t.Errorf("Preflight() seconds = %d, want 13", preflight.EstimatedSeconds)
+	}
+
+	// A selection of chapters, in a cheaper format
+	preflight = exporter.Preflight("test-doc", manifest, &types.ExportOptions{Format: types.ExportFormatHTML, Chapters: []types.ChapterNumber{2}})
+	if preflight.Chapters != 1 || preflight.Words != 1 || preflight.Pages != 2 {
+		t.Errorf("Preflight() of chapter 2 = %+v", preflight)
+	}
+	if preflight.EstimatedSeconds != 3 {
+		t.Errorf("Preflight() HTML seconds = %d, want 3", preflight.EstimatedSeconds)
+	}
+}
@@ -0,0 +1,130 @@
+package export
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+
+	"github.com/gomcpgo/docgen/pkg/types"
+)
+
+// protectExport encrypts a finished PDF or DOCX export with its protection
+// passwords, in place. Exports are only ever left behind protected: when
+// encryption fails, the unprotected export is removed.
+func (e *Exporter) protectExport(ctx context.Context, path string, options *types.ExportOptions) error {
+	var err error
+	switch options.Format {
+	case types.ExportFormatPDF:
+		err = e.encryptPDF(ctx, path, options.Protection, options.OptimizePDF)
+	case types.ExportFormatDOCX:
+		err = e.encryptDOCX(ctx, path, options.Protection)
+	default:
+		err = fmt.Errorf("password protection is only supported for PDF and DOCX exports")
+	}
+	if err != nil {
+		os.Remove(path)
+		return err
+	}
+	return nil
+}
+
+// encryptPDF encrypts a PDF with 256-bit AES using qpdf. Without an owner
+// password, a random one is used so the restrictions can't be lifted.
+// Encrypting rewrites the file, so an optimized PDF is linearized again.
+func (e *Exporter) encryptPDF(ctx context.Context, path string, protection *types.ExportProtection, linearize bool) error {
+	qpdfPath, err := exec.LookPath(e.config.QPDFPath)
+	if err != nil {
+		return fmt.Errorf("qpdf not found (%s); it is needed to password-protect PDF exports", e.config.QPDFPath)
+	}
+
+	ownerPassword := protection.OwnerPassword
+	if ownerPassword == "" {
+		random := make([]byte, 16)
+		if _, err := rand.Read(random); err != nil {
+			return fmt.Errorf("failed to generate owner password: %w", err)
+		}
+		ownerPassword = hex.EncodeToString(random)
+	}
+
+	// The passwords go to qpdf in an argument file only this user can read,
+	// so they don't show in the process list
+	workDir, err := e.newWorkDir("protect")
+	if err != nil {
+		return err
+	}
+	defer os.RemoveAll(workDir)
+
+	options := []string{"--encrypt", protection.UserPassword, ownerPassword, "256"}
+	if !protection.AllowPrint {
+		options = append(options, "--print=none")
+	}
+	if !protection.AllowCopy {
+		options = append(options, "--extract=n")
+	}
+	options = append(options, "--")
+	argFile := filepath.Join(workDir, "encrypt.args")
+	if err := os.WriteFile(argFile, []byte(strings.Join(options, "\n")+"\n"), 0600); err != nil {
+		return fmt.Errorf("failed to write qpdf arguments: %w", err)
+	}
+
+	args := []string{"@" + argFile}
+	if linearize {
+		args = append(args, "--linearize")
+	}
+
+	encrypted := path + ".encrypted"
+	if err := e.runEncryption(ctx, qpdfPath, append(args, path, encrypted), "", path, encrypted, qpdfWarningsExit); err != nil {
+		return fmt.Errorf("failed to encrypt PDF: %w", err)
+	}
+	return nil
+}
+
+// encryptDOCX encrypts a DOCX with msoffcrypto-tool, so that Word asks for the
+// user password to open it. The password is given on stdin rather than the
+// command line: -p without a value makes the tool prompt for it, which reads
+// stdin when there is no terminal, as for a server started by its client.
+func (e *Exporter) encryptDOCX(ctx context.Context, path string, protection *types.ExportProtection) error {
+	toolPath, err := exec.LookPath(e.config.MSOffCryptoPath)
+	if err != nil {
+		return fmt.Errorf("msoffcrypto-tool not found (%s); it is needed to password-protect DOCX exports", e.config.MSOffCryptoPath)
+	}
+
+	encrypted := path + ".encrypted"
+	args := []string{"-e", "-p", path, encrypted}
+	if err := e.runEncryption(ctx, toolPath, args, protection.UserPassword+"\n", path, encrypted, 0); err != nil {
+		return fmt.Errorf("failed to encrypt DOCX: %w", err)
+	}
+	return nil
+}
+
+// runEncryption runs an encryption tool that writes the encrypted copy of a
+// file to encrypted, then replaces the file with it. The tool reads stdin when
+// it is given. A nonzero warningsExit is an exit status that still counts as
+// success, as qpdf's warnings do.
+func (e *Exporter) runEncryption(ctx context.Context, tool string, args []string, stdin string, path, encrypted string, warningsExit int) error {
+	ctx, cancel := context.WithTimeout(ctx, e.config.ExportTimeout)
+	defer cancel()
+
+	cmd := exec.CommandContext(ctx, tool, args...)
+	if stdin != "" {
+		cmd.Stdin = strings.NewReader(stdin)
+	}
+	output, err := cmd.CombinedOutput()
+	if exitErr, ok := err.(*exec.ExitError); ok && warningsExit != 0 && exitErr.ExitCode() == warningsExit {
+		err = nil
+	}
+	if err != nil {
+		os.Remove(encrypted)
+		return fmt.Errorf("%w: %s", err, strings.TrimSpace(string(output)))
+	}
+	if err := os.Rename(encrypted, path); err != nil {
+		os.Remove(encrypted)
+		return fmt.Errorf("failed to replace the export with its encrypted copy: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,107 @@
+package export
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/gomcpgo/docgen/pkg/types"
+)
+
+// recordingEncrypter copies its second-to-last argument to its last, marks the
+// copy as encrypted and records its arguments, the contents of an @ argument
+// file and its stdin next to itself
+const recordingEncrypter = `#!/bin/sh
+echo "$@" > "$0.args"
+case "$1" in @*) cat "${1#@}" > "$0.argfile" ;; esac
+cat > "$0.stdin"
+for last; do :; done
+for arg; do [ "$arg" = "$last" ] || input="$arg"; done
+cp "$input" "$last"
+echo encrypted >> "$last"
+`
+
+func TestExporter_FinishExportProtectPDF(t *testing.T) {
+	exporter, tempDir := setupTestExporter(t)
+	defer os.RemoveAll(tempDir)
+
+	output := filepath.Join(tempDir, "doc.pdf")
+	options := &types.ExportOptions{
+		Format:     types.ExportFormatPDF,
+		Protection: &types.ExportProtection{UserPassword: "reader", AllowCopy: true},
+	}
+
+	// Without qpdf the export fails and the unprotected PDF is removed
+	os.WriteFile(output, []byte("%PDF-1.7\n"), 0644)
+	exporter.config.QPDFPath = filepath.Join(tempDir, "missing", "qpdf")
+	if err := exporter.finishExport(context.Background(), &types.ExportResult{OutputPath: output}, options); err == nil || !strings.Contains(err.Error(), "qpdf not found") {
+		t.Errorf("Expected a missing qpdf error, got %v", err)
+	}
+	if _, err := os.Stat(output); !os.IsNotExist(err) {
+		t.Errorf("The unprotected PDF should be removed, stat error = %v", err)
+	}
+
+	os.WriteFile(output, []byte("%PDF-1.7\n"), 0644)
+	exporter.config.QPDFPath = filepath.Join(tempDir, "qpdf")
+	if err := os.WriteFile(exporter.config.QPDFPath, []byte(recordingEncrypter), 0755); err != nil {
+		t.Fatal(err)
+	}
+	result := &types.ExportResult{OutputPath: output}
+	if err := exporter.finishExport(context.Background(), result, options); err != nil {
+		t.Fatalf("finishExport() error = %v", err)
+	}
+	if data, _ := os.ReadFile(output); !result.Protected || !strings.Contains(string(data), "encrypted") {
+		t.Errorf("Expected the encrypted PDF in place, got %+v and %q", result, data)
+	}
+
+	// The passwords are only in the argument file, which is removed
+	args, _ := os.ReadFile(exporter.config.QPDFPath + ".args")
+	if fields := strings.Fields(string(args)); len(fields) != 3 || !strings.HasPrefix(fields[0], "@") || strings.Contains(string(args), "reader") {
+		t.Errorf("Expected the passwords in an argument file, got %q", args)
+	}
+	if _, err := os.Stat(strings.TrimPrefix(strings.Fields(string(args))[0], "@")); !os.IsNotExist(err) {
+		t.Errorf("The argument file should be removed, stat error = %v", err)
+	}
+	argFile, _ := os.ReadFile(exporter.config.QPDFPath + ".argfile")
+	lines := strings.Split(strings.TrimSpace(string(argFile)), "\n")
+	if len(lines) < 4 || lines[0] != "--encrypt" || lines[1] != "reader" || lines[2] == "" || lines[3] != "256" {
+		t.Errorf("Expected 256-bit encryption with a random owner password, got %q", argFile)
+	}
+	if !strings.Contains(string(argFile), "--print=none") || strings.Contains(string(argFile), "--extract=n") {
+		t.Errorf("Expected printing restricted and copying allowed, got %q", argFile)
+	}
+}
+
+func TestExporter_FinishExportProtectDOCX(t *testing.T) {
+	exporter, tempDir := setupTestExporter(t)
+	defer os.RemoveAll(tempDir)
+
+	output := filepath.Join(tempDir, "doc.docx")
+	os.WriteFile(output, []byte("PK"), 0644)
+	exporter.config.MSOffCryptoPath = filepath.Join(tempDir, "msoffcrypto-tool")
+	if err := os.WriteFile(exporter.config.MSOffCryptoPath, []byte(recordingEncrypter), 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	options := &types.ExportOptions{
+		Format:     types.ExportFormatDOCX,
+		Protection: &types.ExportProtection{UserPassword: "reader", AllowPrint: true, AllowCopy: true},
+	}
+	result := &types.ExportResult{OutputPath: output}
+	if err := exporter.finishExport(context.Background(), result, options); err != nil {
+		t.Fatalf("finishExport() error = %v", err)
+	}
+	args, _ := os.ReadFile(exporter.config.MSOffCryptoPath + ".args")
+	stdin, _ := os.ReadFile(exporter.config.MSOffCryptoPath + ".stdin")
+	if !result.Protected || !strings.HasPrefix(string(args), "-e -p ") || string(stdin) != "reader\n" {
+		t.Errorf("Expected msoffcrypto-tool to encrypt with the password, got %+v, %q and %q", result, args, stdin)
+	}
+	if strings.Contains(string(args), "reader") {
+		t.Errorf("The password should not be on the command line, got %q", args)
+	}
+	if _, err := os.Stat(output + ".encrypted"); !os.IsNotExist(err) {
+		t.Errorf("The temporary encrypted file should be gone, stat error = %v", err)
+	}
+}
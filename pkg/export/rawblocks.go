@@ -0,0 +1,161 @@
+package export
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/gomcpgo/docgen/pkg/types"
+)
+
+var (
+	// rawFencePattern matches the opening fence of a raw block such as ```{=latex}
+	rawFencePattern = regexp.MustCompile("^\\s{0,3}(`{3,}|~{3,})\\s*\\{=([A-Za-z0-9_+-]+)\\}\\s*$")
+	// codeFencePattern matches the opening fence of an ordinary code block
+	codeFencePattern = regexp.MustCompile("^\\s{0,3}(`{3,}|~{3,})")
+	// rawInlinePattern matches raw inline content such as `\\hfill`{=latex}
+	rawInlinePattern = regexp.MustCompile("`([^`\\n]+)`\\{=([A-Za-z0-9_+-]+)\\}")
+)
+
+// rawFormats lists the raw block formats each export format passes through.
+// Raw content for any other format is stripped before pandoc runs.
+var rawFormats = map[types.ExportFormat][]string{
+	types.ExportFormatPDF:  {"latex", "tex"},
+	types.ExportFormatHTML: {"html", "html5"},
+	types.ExportFormatEPUB: {"html", "html5"},
+	types.ExportFormatDOCX: {"openxml"},
+
+	types.ExportFormatRevealJS: {"html", "html5", "revealjs"},
+	types.ExportFormatPPTX:     {"pptx"},
+}
+
+// rawBlock records a raw block found in chapter content
+type rawBlock struct {
+	Format string
+	Line   int // 1-based line of the opening fence
+}
+
+// acceptsRawFormat reports whether an export format passes raw content of the given format through
+func acceptsRawFormat(format types.ExportFormat, rawFormat string) bool {
+	return contains(rawFormats[format], strings.ToLower(rawFormat))
+}
+
+// findRawBlocks returns the fenced raw blocks in markdown, ignoring fences inside code blocks
+func findRawBlocks(markdown string) []rawBlock {
+	var blocks []rawBlock
+	scanRawBlocks(markdown, func(format string, line int, lines []string) []string {
+		blocks = append(blocks, rawBlock{Format: strings.ToLower(format), Line: line})
+		return lines
+	})
+	return blocks
+}
+
+// stripRawBlocks removes raw blocks and raw inlines that the export format cannot use,
+// so that, for example, LaTeX tweaks do not leak into DOCX or HTML output
+func stripRawBlocks(markdown string, format types.ExportFormat) string {
+	stripped := scanRawBlocks(markdown, func(rawFormat string, line int, lines []string) []string {
+		if acceptsRawFormat(format, rawFormat) {
+			return lines
+		}
+		return nil
+	})
+
+	return rawInlinePattern.ReplaceAllStringFunc(stripped, func(match string) string {
+		if acceptsRawFormat(format, rawInlinePattern.FindStringSubmatch(match)[2]) {
+			return match
+		}
+		return ""
+	})
+}
+
+// scanRawBlocks walks markdown line by line and passes each raw block, fences included,
+// to handle. The lines handle returns replace the block in the output. Ordinary code
+// blocks are copied unchanged so raw-block syntax shown in examples is left alone.
+func scanRawBlocks(markdown string, handle func(format string, line int, lines []string) []string) string {
+	lines := strings.Split(markdown, "\n")
+	output := make([]string, 0, len(lines))
+
+	for i := 0; i < len(lines); i++ {
+		fence := ""
+		rawFormat := ""
+		if match := rawFencePattern.FindStringSubmatch(lines[i]); match != nil {
+			fence, rawFormat = match[1], match[2]
+		} else if match := codeFencePattern.FindStringSubmatch(lines[i]); match != nil {
+			fence = match[1]
+		} else {
+			output = append(output, lines[i])
+			continue
+		}
+
+		// Collect the block up to and including its closing fence
+		start := i
+		for i+1 < len(lines) {
+			i++
+			if isClosingFence(lines[i], fence) {
+				break
+			}
+		}
+		block := lines[start : i+1]
+
+		if rawFormat == "" {
+			output = append(output, block...)
+		} else {
+			output = append(output, handle(rawFormat, start+1, block)...)
+		}
+	}
+
+	return strings.Join(output, "\n")
+}
+
+// isClosingFence reports whether line closes a block opened with fence
+func isClosingFence(line, fence string) bool {
+	trimmed := strings.TrimSpace(line)
+	return len(trimmed) >= len(fence) && strings.Trim(trimmed, fence[:1]) == ""
+}
+
+// checkRawBlocks adds a warning for each chapter that contains raw blocks, listing
+// the formats and the exports that will drop them
+func checkRawBlocks(chapterNum types.ChapterNumber, content string, report *types.ValidationReport) {
+	linesByFormat := make(map[string][]string)
+	for _, block := range findRawBlocks(content) {
+		linesByFormat[block.Format] = append(linesByFormat[block.Format], fmt.Sprintf("%d", block.Line))
+	}
+
+	formats := make([]string, 0, len(linesByFormat))
+	for format := range linesByFormat {
+		formats = append(formats, format)
+	}
+	sort.Strings(formats)
+
+	for _, rawFormat := range formats {
+		var exports []string
+		for _, format := range []types.ExportFormat{types.ExportFormatPDF, types.ExportFormatDOCX, types.ExportFormatHTML, types.ExportFormatEPUB, types.ExportFormatRevealJS, types.ExportFormatPPTX} {
+			if acceptsRawFormat(format, rawFormat) {
+				exports = append(exports, string(format))
+			}
+		}
+		target := "no export format"
+		if len(exports) > 0 {
+			target = exports[len(exports)-1] + " exports"
+			if len(exports) > 1 {
+				target = strings.Join(exports[:len(exports)-1], ", ") + " and " + target
+			}
+		}
+		report.Warnings = append(report.Warnings, fmt.Sprintf("Chapter %d contains raw %s block(s) at line(s) %s; included only in %s",
+			chapterNum, rawFormat, strings.Join(linesByFormat[rawFormat], ", "), target))
+	}
+}
+
+// chapterBreak returns the page break written before each chapter in the combined
+// markdown, as a raw block for the formats that support one
+func chapterBreak(format types.ExportFormat) string {
+	switch format {
+	case types.ExportFormatPDF:
+		return "```{=latex}\n\\newpage\n```\n\n"
+	case types.ExportFormatDOCX:
+		return "```{=openxml}\n<w:p><w:r><w:br w:type=\"page\"/></w:r></w:p>\n```\n\n"
+	default:
+		return ""
+	}
+}
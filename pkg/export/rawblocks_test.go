@@ -0,0 +1,78 @@
+package export
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/gomcpgo/docgen/pkg/types"
+)
+
+const rawBlockContent = "# Results\n\n" +
+	"```{=latex}\n\\begin{landscape}\n```\n\n" +
+	"Body text with `\\hfill`{=latex} inline.\n\n" +
+	"```{=html}\n<div class=\"note\">Note</div>\n```\n\n" +
+	"```markdown\n```{=latex}\n\\example\n```\n"
+
+func TestStripRawBlocks(t *testing.T) {
+	tests := []struct {
+		format  types.ExportFormat
+		keep    []string
+		removed []string
+	}{
+		{
+			format:  types.ExportFormatPDF,
+			keep:    []string{"\\begin{landscape}", "`\\hfill`{=latex}"},
+			removed: []string{"<div class"},
+		},
+		{
+			format:  types.ExportFormatHTML,
+			keep:    []string{"<div class"},
+			removed: []string{"\\begin{landscape}", "\\hfill"},
+		},
+		{
+			format:  types.ExportFormatDOCX,
+			removed: []string{"\\begin{landscape}", "\\hfill", "<div class"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(string(tt.format), func(t *testing.T) {
+			result := stripRawBlocks(rawBlockContent, tt.format)
+			for _, want := range tt.keep {
+				if !strings.Contains(result, want) {
+					t.Errorf("Expected %q to be kept, got:\n%s", want, result)
+				}
+			}
+			for _, unwanted := range tt.removed {
+				if strings.Contains(result, unwanted) {
+					t.Errorf("Expected %q to be stripped, got:\n%s", unwanted, result)
+				}
+			}
+			// Raw-block syntax inside an ordinary code block is example text
+			if !strings.Contains(result, "\\example") {
+				t.Errorf("Code block content should be left alone, got:\n%s", result)
+			}
+			if !strings.Contains(result, "Body text with") {
+				t.Errorf("Regular text should be kept")
+			}
+		})
+	}
+}
+
+func TestCheckRawBlocks(t *testing.T) {
+	report := &types.ValidationReport{Valid: true}
+	checkRawBlocks(2, rawBlockContent, report)
+
+	if len(report.Warnings) != 2 {
+		t.Fatalf("Expected one warning per raw format, got %v", report.Warnings)
+	}
+	if report.Warnings[0] != "Chapter 2 contains raw html block(s) at line(s) 9; included only in html, epub and revealjs exports" {
+		t.Errorf("Unexpected html warning: %s", report.Warnings[0])
+	}
+	if report.Warnings[1] != "Chapter 2 contains raw latex block(s) at line(s) 3; included only in pdf exports" {
+		t.Errorf("Unexpected latex warning: %s", report.Warnings[1])
+	}
+	if !report.Valid {
+		t.Errorf("Raw blocks should not make the document invalid")
+	}
+}
@@ -0,0 +1,309 @@
+package export
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/gomcpgo/docgen/pkg/types"
+)
+
+// readingBlockKind identifies the kind of a linearized document block
+type readingBlockKind int
+
+const (
+	readingHeading readingBlockKind = iota
+	readingParagraph
+	readingFigure
+	readingTable
+	readingCode
+)
+
+// readingBlock is a single unit of the linearized reading order
+type readingBlock struct {
+	kind readingBlockKind
+	text string
+}
+
+var (
+	readingHeadingPattern = regexp.MustCompile(`^(#{1,6})\s+(.*?)\s*#*\s*$`)
+	readingFigurePattern  = regexp.MustCompile(`^!\[([^\]]*)\]\(([^)]*)\)(\{#([^}\s]+)[^}]*\})?\s*$`)
+	readingListPattern    = regexp.MustCompile(`^\s*([-*+]|\d+[.)])\s+`)
+	readingLinkPattern    = regexp.MustCompile(`!?\[([^\]]*)\]\([^)]*\)`)
+	readingAttrPattern    = regexp.MustCompile(`\{[#.][^}]*\}`)
+	readingCommentPattern = regexp.MustCompile(`(?s)<!--.*?-->`)
+	readingTagPattern     = regexp.MustCompile(`</?[A-Za-z][^>]*>`)
+	readingEmphasis       = strings.NewReplacer("**", "", "__", "", "*", "", "`", "", "~~", "")
+	ssmlEscaper           = strings.NewReplacer("&", "&amp;", "<", "&lt;", ">", "&gt;", `"`, "&quot;", "'", "&apos;")
+
+	// readingTeXPattern matches a line of nothing but raw TeX commands, such as
+	// \newpage or \vspace{1em}
+	readingTeXPattern = regexp.MustCompile(`^(\\[A-Za-z]+\*?(\[[^\]]*\])?(\{[^{}]*\})*\s*)+$`)
+	// readingEscapePattern matches a backslash-escaped markdown character
+	readingEscapePattern = regexp.MustCompile("\\\\([!-/:-@\\[-`{-~])")
+)
+
+// exportReadingOrder writes a linearized plain-text or SSML rendition of the document
+// for text-to-speech pipelines. Figures are replaced by their alt text and tables are
+// summarized, so no pandoc run is needed.
+func (e *Exporter) exportReadingOrder(documentID string, manifest *types.Manifest, options *types.ExportOptions) (string, error) {
+	blocks := readingFrontMatter(&manifest.Document)
+
+	chaptersToInclude := options.Chapters
+	if len(chaptersToInclude) == 0 {
+		for _, chapter := range manifest.Document.Chapters {
+			chaptersToInclude = append(chaptersToInclude, chapter.Number)
+		}
+	}
+
+	numbers := figureNumbers(manifest, options.Numbering, nil)
+	for _, chapterNum := range chaptersToInclude {
+		chapterContent, err := e.loadChapterContent(documentID, manifest, chapterNum)
+		if err != nil {
+			return "", fmt.Errorf("failed to load chapter %d content: %w", chapterNum, err)
+		}
+		if chapter, err := manifest.Document.GetChapter(chapterNum); err == nil && options.ApprovedOnly {
+			var exported bool
+			if chapterContent, exported = filterApproved(chapterContent, chapter); !exported {
+				continue
+			}
+		}
+		chapterContent = e.expandFigureGrids(documentID, manifest, chapterNum, resolveFigureAnchors(manifest, stripRawBlocks(chapterContent, options.Format)), options.Format)
+		chapterContent = formatFigureReferences(manifest, chapterContent, options.Format, options.Numbering, nil)
+		blocks = append(blocks, linearizeMarkdown(translateLayoutMarkers(chapterContent, options.Format), numbers)...)
+	}
+
+	var output string
+	if options.Format == types.ExportFormatSSML {
+		output = renderSSML(blocks, manifest.Document.Language)
+	} else {
+		output = renderReadingText(blocks)
+	}
+
+	outputFile := e.config.ExportPath(documentID, string(options.Format))
+	if err := os.MkdirAll(filepath.Dir(outputFile), 0755); err != nil {
+		return "", fmt.Errorf("failed to create output directory: %w", err)
+	}
+	if err := os.WriteFile(outputFile, []byte(output), 0644); err != nil {
+		return "", fmt.Errorf("failed to write output file: %w", err)
+	}
+
+	return outputFile, nil
+}
+
+// readingFrontMatter announces the title, subtitle and authors
+func readingFrontMatter(doc *types.Document) []readingBlock {
+	blocks := []readingBlock{{kind: readingHeading, text: doc.Title}}
+	if doc.Subtitle != "" {
+		blocks = append(blocks, readingBlock{kind: readingParagraph, text: doc.Subtitle})
+	}
+	if len(doc.Authors) > 0 {
+		blocks = append(blocks, readingBlock{kind: readingParagraph, text: "By " + doc.Authors.String() + "."})
+	}
+	if doc.Abstract != "" {
+		blocks = append(blocks, readingBlock{kind: readingParagraph, text: "Abstract. " + cleanInline(doc.Abstract)})
+	}
+	return blocks
+}
+
+// linearizeMarkdown converts chapter markdown into reading-order blocks. Figures
+// are read with their numbers, by figure ID.
+func linearizeMarkdown(markdown string, numbers map[string]string) []readingBlock {
+	var blocks []readingBlock
+	var paragraph []string
+
+	flush := func() {
+		if len(paragraph) > 0 {
+			text := cleanInline(strings.Join(paragraph, " "))
+			if text != "" {
+				blocks = append(blocks, readingBlock{kind: readingParagraph, text: text})
+			}
+			paragraph = nil
+		}
+	}
+
+	markdown = readingCommentPattern.ReplaceAllString(markdown, "")
+	lines := strings.Split(markdown, "\n")
+
+	for i := 0; i < len(lines); i++ {
+		line := strings.TrimRight(lines[i], " \t\r")
+		trimmed := strings.TrimSpace(line)
+
+		switch {
+		case trimmed == "":
+			flush()
+
+		case strings.HasPrefix(trimmed, "```") || strings.HasPrefix(trimmed, "~~~"):
+			flush()
+			fence := trimmed[:3]
+			count := 0
+			for i+1 < len(lines) && !strings.HasPrefix(strings.TrimSpace(lines[i+1]), fence) {
+				i++
+				count++
+			}
+			i++ // skip closing fence
+			// Raw blocks such as ```{=latex} only affect layout
+			if !rawFencePattern.MatchString(trimmed) {
+				blocks = append(blocks, readingBlock{kind: readingCode, text: fmt.Sprintf("Code listing omitted, %d lines.", count)})
+			}
+
+		case readingTeXPattern.MatchString(trimmed):
+			// Raw LaTeX commands such as \newpage are not read aloud
+			flush()
+
+		case readingHeadingPattern.MatchString(trimmed):
+			flush()
+			heading := readingHeadingPattern.FindStringSubmatch(trimmed)[2]
+			blocks = append(blocks, readingBlock{kind: readingHeading, text: cleanInline(heading)})
+
+		case readingFigurePattern.MatchString(trimmed):
+			flush()
+			match := readingFigurePattern.FindStringSubmatch(trimmed)
+			number, ok := numbers[match[4]]
+			if !ok && types.FigureID(match[4]).IsNumbered() {
+				number = strings.TrimPrefix(match[4], "fig-")
+			}
+			blocks = append(blocks, readingBlock{kind: readingFigure, text: describeFigure(match[1], number)})
+
+		case strings.HasPrefix(trimmed, "|"):
+			flush()
+			var rows []string
+			for i < len(lines) && strings.HasPrefix(strings.TrimSpace(lines[i]), "|") {
+				rows = append(rows, strings.TrimSpace(lines[i]))
+				i++
+			}
+			i--
+			caption := ""
+			if i+2 < len(lines) && strings.TrimSpace(lines[i+1]) == "" && strings.HasPrefix(strings.TrimSpace(lines[i+2]), "Table:") {
+				caption = strings.TrimSpace(strings.TrimPrefix(strings.TrimSpace(lines[i+2]), "Table:"))
+				i += 2
+			}
+			blocks = append(blocks, readingBlock{kind: readingTable, text: summarizeTable(rows, caption)})
+
+		case strings.HasPrefix(trimmed, "Table:"):
+			// Caption written above its table
+			flush()
+			blocks = append(blocks, readingBlock{kind: readingTable, text: "Table: " + cleanInline(strings.TrimPrefix(trimmed, "Table:"))})
+
+		case readingListPattern.MatchString(line):
+			flush()
+			paragraph = append(paragraph, readingListPattern.ReplaceAllString(line, ""))
+			flush()
+
+		default:
+			paragraph = append(paragraph, strings.TrimPrefix(trimmed, "> "))
+		}
+	}
+	flush()
+
+	return blocks
+}
+
+// describeFigure produces the spoken replacement for an image, with its
+// figure number if it has one
+func describeFigure(alt, number string) string {
+	alt = cleanInline(alt)
+	label := "Figure"
+	if number != "" {
+		label = "Figure " + number
+	}
+	if alt == "" {
+		return label + ", no description provided."
+	}
+	return label + ": " + strings.TrimSuffix(alt, ".") + "."
+}
+
+// summarizeTable describes a markdown pipe table by its size and column headers
+func summarizeTable(rows []string, caption string) string {
+	var header []string
+	dataRows := 0
+	for i, row := range rows {
+		cells := strings.Split(strings.Trim(row, "|"), "|")
+		if i == 0 {
+			for _, cell := range cells {
+				if cell = cleanInline(cell); cell != "" {
+					header = append(header, cell)
+				}
+			}
+			continue
+		}
+		if strings.Trim(row, "|-: ") == "" {
+			// Alignment row
+			continue
+		}
+		dataRows++
+	}
+
+	var summary strings.Builder
+	summary.WriteString("Table")
+	if caption != "" {
+		summary.WriteString(": " + strings.TrimSuffix(cleanInline(caption), "."))
+	}
+	summary.WriteString(fmt.Sprintf(". %d rows and %d columns", dataRows, len(header)))
+	if len(header) > 0 {
+		summary.WriteString(". Columns: " + strings.Join(header, ", "))
+	}
+	summary.WriteString(".")
+	return summary.String()
+}
+
+// cleanInline strips inline markdown, raw content and HTML so the text reads
+// naturally. Escaped characters such as \* are read as themselves.
+func cleanInline(text string) string {
+	// Escaped characters are set aside in the private use area while the
+	// markup around them is stripped
+	text = readingEscapePattern.ReplaceAllStringFunc(text, func(escape string) string {
+		return string(rune(escapedCharBase + int(escape[1])))
+	})
+	text = rawInlinePattern.ReplaceAllString(text, "")
+	text = readingLinkPattern.ReplaceAllString(text, "$1")
+	text = readingAttrPattern.ReplaceAllString(text, "")
+	text = readingTagPattern.ReplaceAllString(text, "")
+	text = readingEmphasis.Replace(text)
+	text = strings.Map(func(r rune) rune {
+		if r >= escapedCharBase && r < escapedCharBase+128 {
+			return r - escapedCharBase
+		}
+		return r
+	}, text)
+	return strings.Join(strings.Fields(text), " ")
+}
+
+// escapedCharBase is where cleanInline sets escaped characters aside
+const escapedCharBase = 0xE000
+
+// renderReadingText renders blocks as plain text, one block per paragraph
+func renderReadingText(blocks []readingBlock) string {
+	var out strings.Builder
+	for _, block := range blocks {
+		out.WriteString(block.text)
+		out.WriteString("\n\n")
+	}
+	return out.String()
+}
+
+// renderSSML renders blocks as an SSML document with pauses around headings
+func renderSSML(blocks []readingBlock, language string) string {
+	if language == "" {
+		language = "en-US"
+	}
+
+	var out strings.Builder
+	out.WriteString(`<?xml version="1.0" encoding="UTF-8"?>` + "\n")
+	out.WriteString(fmt.Sprintf(`<speak version="1.1" xmlns="http://www.w3.org/2001/10/synthesis" xml:lang="%s">`+"\n", ssmlEscaper.Replace(language)))
+	for _, block := range blocks {
+		text := ssmlEscaper.Replace(block.text)
+		switch block.kind {
+		case readingHeading:
+			out.WriteString(fmt.Sprintf(`  <break time="750ms"/><p><emphasis level="strong">%s</emphasis></p><break time="500ms"/>`+"\n", text))
+		case readingFigure, readingTable, readingCode:
+			out.WriteString(fmt.Sprintf(`  <p><prosody rate="95%%">%s</prosody></p>`+"\n", text))
+		default:
+			out.WriteString(fmt.Sprintf("  <p>%s</p>\n", text))
+		}
+	}
+	out.WriteString("</speak>\n")
+	return out.String()
+}
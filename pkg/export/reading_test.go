@@ -0,0 +1,102 @@
+package export
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/gomcpgo/docgen/pkg/types"
+)
+
+func TestLinearizeMarkdown(t *testing.T) {
+	markdown := `# Chapter 1: Introduction
+
+## 1.1 Overview
+
+This is **bold** text with a [link](https://example.com).
+
+![A bar chart of sales](assets/images/fig-1.1.png){#fig-1.1}
+
+![A map](assets/images/map.png){#fig-3f9a2c7d width=50%}
+
+| Region | Sales |
+|--------|-------|
+| North  | 10    |
+| South  | 20    |
+
+Table: Sales by region
+
+` + "```go\nfmt.Println(1)\nfmt.Println(2)\n```" + `
+
+\newpage
+
+` + "```{=latex}\n\\vspace{1em}\n```" + `
+
+\*Not emphasis\* costs \$5 \[sic\]
+\# and ` + "`\\hfill`{=latex}" + ` stays.
+
+- First item
+- Second item
+`
+
+	blocks := linearizeMarkdown(markdown, map[string]string{"fig-3f9a2c7d": "1.2"})
+	var texts []string
+	for _, block := range blocks {
+		texts = append(texts, block.text)
+	}
+
+	expected := []string{
+		"Chapter 1: Introduction",
+		"1.1 Overview",
+		"This is bold text with a link.",
+		"Figure 1.1: A bar chart of sales.",
+		"Figure 1.2: A map.",
+		"Table: Sales by region. 2 rows and 2 columns. Columns: Region, Sales.",
+		"Code listing omitted, 2 lines.",
+		"*Not emphasis* costs $5 [sic] # and stays.",
+		"First item",
+		"Second item",
+	}
+	if strings.Join(texts, "\n") != strings.Join(expected, "\n") {
+		t.Errorf("linearizeMarkdown() =\n%s\nwant\n%s", strings.Join(texts, "\n"), strings.Join(expected, "\n"))
+	}
+}
+
+func TestExporter_ExportReadingOrder_SSML(t *testing.T) {
+	exporter, tempDir := setupTestExporter(t)
+	defer os.RemoveAll(tempDir)
+
+	doc, manifest, _, _ := createTestDocument(t, tempDir)
+	manifest.Document.Language = "en-GB"
+
+	// Create chapter content files
+	for _, chapter := range doc.Chapters {
+		chapterPath := filepath.Join(tempDir, "test-doc", "chapters", fmt.Sprintf("%02d", chapter.Number))
+		os.MkdirAll(chapterPath, 0755)
+		os.WriteFile(filepath.Join(chapterPath, "chapter.md"), []byte(chapter.Content), 0644)
+	}
+
+	outputFile, err := exporter.ExportDocument(context.Background(), "test-doc", manifest, nil, nil, &types.ExportOptions{Format: types.ExportFormatSSML}, nil)
+	if err != nil {
+		t.Fatalf("ExportDocument() error = %v", err)
+	}
+
+	data, err := os.ReadFile(outputFile)
+	if err != nil {
+		t.Fatalf("Failed to read output: %v", err)
+	}
+	ssml := string(data)
+
+	if !strings.Contains(ssml, `xml:lang="en-GB"`) {
+		t.Errorf("SSML should declare the document language")
+	}
+	if !strings.Contains(ssml, "<emphasis level=\"strong\">Test Document</emphasis>") {
+		t.Errorf("SSML should announce the document title, got:\n%s", ssml)
+	}
+	if !strings.HasSuffix(ssml, "</speak>\n") {
+		t.Errorf("SSML should be closed")
+	}
+}
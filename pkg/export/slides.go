@@ -0,0 +1,99 @@
+package export
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/gomcpgo/docgen/pkg/types"
+)
+
+// slideBreak starts a new slide where a page break is written; pandoc starts
+// one at every horizontal rule
+const slideBreak = "* * *"
+
+// slideArgs returns the pandoc arguments for a slide deck: the heading level
+// slides start at, incremental lists, and the reveal.js theme or PowerPoint
+// reference of the style. Generated files are written next to inputFile.
+func (e *Exporter) slideArgs(documentID, inputFile string, style *types.Style, options *types.ExportOptions) []string {
+	args := []string{"--to", string(options.Format), "--slide-level", fmt.Sprintf("%d", options.Slides.Level())}
+	if options.Slides != nil && options.Slides.Incremental {
+		args = append(args, "--incremental")
+	}
+
+	var slides types.SlideStyle
+	if style != nil {
+		slides = style.Slides
+	}
+
+	switch options.Format {
+	case types.ExportFormatRevealJS:
+		// The deck loads reveal.js itself, so only the page is written
+		args = append(args, "--standalone")
+		if slides.Theme != "" {
+			args = append(args, "-V", fmt.Sprintf("theme=%s", slides.Theme))
+		}
+		if slides.Transition != "" {
+			args = append(args, "-V", fmt.Sprintf("transition=%s", slides.Transition))
+		}
+		if slides.SlideNumbers {
+			args = append(args, "-V", "slideNumber=true")
+		}
+		if width, height, ok := slides.Size(); ok {
+			args = append(args, "-V", fmt.Sprintf("width=%d", width), "-V", fmt.Sprintf("height=%d", height))
+		}
+
+		// The style's fonts and colors are layered over the theme
+		if css := generateSlidesCSS(style); css != "" {
+			headerFile := filepath.Join(filepath.Dir(inputFile), fmt.Sprintf("%s-slides.html", documentID))
+			if err := os.WriteFile(headerFile, []byte("<style>\n"+css+"</style>\n"), 0644); err == nil {
+				args = append(args, "--include-in-header", headerFile)
+			}
+		}
+
+	case types.ExportFormatPPTX:
+		// Resolve the reference presentation relative to the document directory
+		if slides.ReferencePPTX != "" {
+			referencePPTX, err := e.config.ResolvePath(slides.ReferencePPTX, e.config.DocumentPath(documentID))
+			if err != nil {
+				log.Printf("[DOCGEN PPTX] Ignoring reference presentation: %v", err)
+			} else if _, err := os.Stat(referencePPTX); err != nil {
+				log.Printf("[DOCGEN PPTX] Reference presentation not found: %s", referencePPTX)
+			} else {
+				args = append(args, "--reference-doc", referencePPTX)
+			}
+		}
+	}
+	return args
+}
+
+// generateSlidesCSS sets reveal.js's theme variables from the style's body,
+// heading, code and link settings
+func generateSlidesCSS(style *types.Style) string {
+	if style == nil {
+		return ""
+	}
+
+	var variables []string
+	set := func(name, value, fallback string) {
+		if value == "" {
+			return
+		}
+		if fallback != "" {
+			value = fmt.Sprintf("'%s', %s", value, fallback)
+		}
+		variables = append(variables, fmt.Sprintf("    --r-%s: %s;\n", name, value))
+	}
+	set("main-font", style.Body.FontFamily, "serif")
+	set("main-color", style.Body.Color, "")
+	set("heading-font", style.Heading.FontFamily, "sans-serif")
+	set("heading-color", style.Heading.Color, "")
+	set("code-font", style.Monospace.FontFamily, "monospace")
+	set("link-color", style.LinkColor, "")
+	if len(variables) == 0 {
+		return ""
+	}
+	return ":root {\n" + strings.Join(variables, "") + "}\n"
+}
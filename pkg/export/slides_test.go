@@ -0,0 +1,70 @@
+package export
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/gomcpgo/docgen/pkg/types"
+)
+
+func TestExporter_SlideArgs(t *testing.T) {
+	exporter, tempDir := setupTestExporter(t)
+	defer os.RemoveAll(tempDir)
+
+	_, manifest, style, pandocConfig := createTestDocument(t, tempDir)
+	style.Heading.Color = "#003366"
+	style.LinkColor = "#0066cc"
+	style.Slides = types.SlideStyle{Theme: "serif", Transition: "fade", AspectRatio: "16:9", SlideNumbers: true, ReferencePPTX: "templates/deck.pptx"}
+	inputFile := filepath.Join(tempDir, "input.md")
+
+	options := &types.ExportOptions{Format: types.ExportFormatRevealJS, Slides: &types.SlideOptions{Split: types.SlideSplitChapter, Incremental: true}}
+	args := strings.Join(exporter.GeneratePandocCommand(context.Background(), "test-doc", inputFile, "out.slides.html", manifest, style, pandocConfig, options, "").Args, " ")
+	for _, want := range []string{"--to revealjs --slide-level 1 --incremental --standalone", "-V theme=serif", "-V transition=fade", "-V slideNumber=true", "-V width=1280 -V height=720", "--include-in-header"} {
+		if !strings.Contains(args, want) {
+			t.Errorf("Expected %q in the reveal.js arguments: %s", want, args)
+		}
+	}
+	if strings.Contains(args, "--reference-doc") || strings.Contains(args, "--embed-resources") {
+		t.Errorf("Unexpected reveal.js arguments: %s", args)
+	}
+	header, _ := os.ReadFile(filepath.Join(tempDir, "test-doc-slides.html"))
+	for _, want := range []string{"--r-main-font: 'Times New Roman', serif;", "--r-heading-color: #003366;", "--r-link-color: #0066cc;"} {
+		if !strings.Contains(string(header), want) {
+			t.Errorf("Expected %q in the slide styles:\n%s", want, header)
+		}
+	}
+
+	// The reference presentation is only used once it exists
+	options = &types.ExportOptions{Format: types.ExportFormatPPTX}
+	args = strings.Join(exporter.GeneratePandocCommand(context.Background(), "test-doc", inputFile, "out.pptx", manifest, style, pandocConfig, options, "").Args, " ")
+	if !strings.Contains(args, "--to pptx --slide-level 2") || strings.Contains(args, "--reference-doc") || strings.Contains(args, "theme=") {
+		t.Errorf("Unexpected pptx arguments: %s", args)
+	}
+	referencePPTX := filepath.Join(tempDir, "test-doc", "templates", "deck.pptx")
+	os.MkdirAll(filepath.Dir(referencePPTX), 0755)
+	os.WriteFile(referencePPTX, []byte("pptx"), 0644)
+	args = strings.Join(exporter.GeneratePandocCommand(context.Background(), "test-doc", inputFile, "out.pptx", manifest, style, pandocConfig, options, "").Args, " ")
+	if !strings.Contains(args, "--reference-doc "+referencePPTX) {
+		t.Errorf("Expected the reference presentation in the pptx arguments: %s", args)
+	}
+}
+
+func TestTranslateLayoutMarkers_Slides(t *testing.T) {
+	content := "First slide.\n\n" + types.PageBreakMarker + "\n\n::: {.keep-together}\nKept.\n:::"
+	got := translateLayoutMarkers(content, types.ExportFormatPPTX)
+	if !strings.Contains(got, "\n"+slideBreak+"\n") || !strings.Contains(got, "::: {.keep-together}\nKept.\n:::") {
+		t.Errorf("Expected a page break to start a new slide and divs kept:\n%s", got)
+	}
+}
+
+func TestGenerateSlidesCSS(t *testing.T) {
+	if css := generateSlidesCSS(&types.Style{}); css != "" {
+		t.Errorf("Expected no styles for an empty style, got:\n%s", css)
+	}
+	if css := generateSlidesCSS(nil); css != "" {
+		t.Errorf("Expected no styles without a style, got:\n%s", css)
+	}
+}
@@ -0,0 +1,91 @@
+package export
+
+import (
+	"archive/zip"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+)
+
+// sourceBundlePath returns where the source bundle of an export is written,
+// next to its combined markdown
+func sourceBundlePath(documentID, inputFile string) string {
+	return filepath.Join(filepath.Dir(inputFile), fmt.Sprintf("%s-source.zip", documentID))
+}
+
+// writeSourceBundle zips the combined markdown with the document's assets, so
+// that the exported PDF can carry everything needed to edit and rebuild it
+func (e *Exporter) writeSourceBundle(documentID, inputFile string) error {
+	file, err := os.Create(sourceBundlePath(documentID, inputFile))
+	if err != nil {
+		return fmt.Errorf("failed to create source bundle: %w", err)
+	}
+	defer file.Close()
+
+	writer := zip.NewWriter(file)
+	if err := addBundleFile(writer, inputFile, documentID+".md"); err != nil {
+		writer.Close()
+		return fmt.Errorf("failed to add markdown to source bundle: %w", err)
+	}
+
+	// Images are referenced as assets/images/..., relative to the markdown
+	assetsDir := e.config.AssetsPath(documentID)
+	err = filepath.WalkDir(assetsDir, func(filePath string, entry fs.DirEntry, err error) error {
+		if os.IsNotExist(err) {
+			return filepath.SkipDir
+		}
+		if err != nil || !entry.Type().IsRegular() {
+			return err
+		}
+		rel, err := filepath.Rel(e.config.DocumentPath(documentID), filePath)
+		if err != nil {
+			return err
+		}
+		return addBundleFile(writer, filePath, filepath.ToSlash(rel))
+	})
+	if err != nil {
+		writer.Close()
+		return fmt.Errorf("failed to add assets to source bundle: %w", err)
+	}
+
+	if err := writer.Close(); err != nil {
+		return fmt.Errorf("failed to write source bundle: %w", err)
+	}
+	return file.Close()
+}
+
+// addBundleFile copies a file into a zip under the given name
+func addBundleFile(writer *zip.Writer, source, name string) error {
+	in, err := os.Open(source)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+
+	w, err := writer.Create(path.Clean(name))
+	if err != nil {
+		return err
+	}
+	_, err = io.Copy(w, in)
+	return err
+}
+
+// generateSourceHeader creates the LaTeX preamble that attaches the source
+// bundle to the PDF, or "" when the export has no bundle
+func generateSourceHeader(documentID, inputFile string) string {
+	bundle := sourceBundlePath(documentID, inputFile)
+	if _, err := os.Stat(bundle); err != nil {
+		return ""
+	}
+
+	var header strings.Builder
+	header.WriteString("\n% Editable source\n")
+	header.WriteString("\\usepackage{embedfile}\n")
+	header.WriteString(fmt.Sprintf("\\embedfile[filespec={%s}, mimetype={application/zip}, desc={Source markdown and assets}]{%s}\n",
+		filepath.Base(bundle), filepath.ToSlash(bundle)))
+	return header.String()
+}
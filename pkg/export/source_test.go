@@ -0,0 +1,40 @@
+package export
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestExporter_SourceBundle(t *testing.T) {
+	exporter, tempDir := setupTestExporter(t)
+	defer os.RemoveAll(tempDir)
+
+	inputFile := filepath.Join(tempDir, "work", "test-doc-input.md")
+	os.MkdirAll(filepath.Dir(inputFile), 0755)
+	os.WriteFile(inputFile, []byte("# Report\n\n![Chart](assets/images/chart.png)\n"), 0644)
+
+	if header := generateSourceHeader("test-doc", inputFile); header != "" {
+		t.Errorf("Expected no header without a bundle, got %q", header)
+	}
+
+	os.MkdirAll(exporter.config.AssetsPath("test-doc"), 0755)
+	os.WriteFile(filepath.Join(exporter.config.AssetsPath("test-doc"), "chart.png"), []byte("PNG"), 0644)
+
+	if err := exporter.writeSourceBundle("test-doc", inputFile); err != nil {
+		t.Fatalf("writeSourceBundle() error = %v", err)
+	}
+	files := readZip(t, sourceBundlePath("test-doc", inputFile))
+	if !strings.Contains(files["test-doc.md"], "![Chart](assets/images/chart.png)") {
+		t.Errorf("Expected the combined markdown in the bundle, got %v", files)
+	}
+	if files["assets/images/chart.png"] != "PNG" {
+		t.Errorf("Expected the image at its referenced path, got %v", files)
+	}
+
+	header := generateSourceHeader("test-doc", inputFile)
+	if !strings.Contains(header, "\\usepackage{embedfile}") || !strings.Contains(header, "filespec={test-doc-source.zip}") {
+		t.Errorf("Unexpected header:\n%s", header)
+	}
+}
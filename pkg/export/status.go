@@ -0,0 +1,42 @@
+package export
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/gomcpgo/docgen/pkg/types"
+)
+
+// filterApproved drops the content of the chapter's sections that aren't
+// approved or final, and its introduction when the chapter isn't, keeping the
+// chapter heading. It reports whether anything of the chapter is left to
+// export: the chapter is approved or one of its sections is.
+func filterApproved(content string, chapter *types.Chapter) (string, bool) {
+	statuses := chapter.SectionStatuses()
+	lines := strings.Split(content, "\n")
+	headings := chapterHeadings(lines, chapter)
+	output := make([]string, 0, len(lines))
+
+	exported := chapter.Status.Approved()
+	keep := exported
+	for i, line := range lines {
+		if section, ok := headings[i]; ok {
+			if section < 0 {
+				output = append(output, line)
+				continue
+			}
+			keep = statuses[section].Approved()
+			exported = exported || keep
+		}
+		if keep {
+			output = append(output, line)
+		}
+	}
+	return strings.TrimRight(strings.Join(output, "\n"), "\n"), exported
+}
+
+// addStatusStamp writes the chapter's workflow status under its heading
+func addStatusStamp(content string, chapter *types.Chapter) string {
+	stamp := fmt.Sprintf("::: {.docgen-status .docgen-status-%s}\n*Status: %s*\n:::", chapter.Status.OrDraft(), chapter.Status.Label())
+	return insertUnderChapterHeading(content, chapter, stamp)
+}
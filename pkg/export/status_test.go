@@ -0,0 +1,49 @@
+package export
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/gomcpgo/docgen/pkg/types"
+)
+
+func TestFilterApproved(t *testing.T) {
+	chapter := &types.Chapter{
+		Number: 1,
+		Title:  "Basics",
+		Status: types.StatusInReview,
+		Sections: []types.Section{
+			{Number: types.SectionNumber{1, 1}, Title: "Start", Level: 1},
+			{Number: types.SectionNumber{1, 2}, Title: "Detail", Level: 1, Status: types.StatusApproved},
+			{Number: types.SectionNumber{1, 2, 1}, Title: "Fine print", Level: 2},
+			{Number: types.SectionNumber{1, 3}, Title: "End", Level: 1, Status: types.StatusFinal},
+		},
+	}
+	content := "# Chapter 1: Basics\n\nIntro.\n\n## 1.1 Start\n\nHello.\n\n## 1.2 Detail\n\nMore.\n\n```\n## 1.3 End\n```\n\n### 1.2.1 Fine print\n\nSmall.\n\n## 1.3 End\n\nBye."
+
+	got, exported := filterApproved(content, chapter)
+	want := "# Chapter 1: Basics\n## 1.2 Detail\n\nMore.\n\n```\n## 1.3 End\n```\n\n### 1.2.1 Fine print\n\nSmall.\n\n## 1.3 End\n\nBye."
+	if !exported || got != want {
+		t.Errorf("filterApproved() = %v,\n%s\nwant:\n%s", exported, got, want)
+	}
+
+	chapter.Sections[1].Status = ""
+	chapter.Sections[3].Status = types.StatusDraft
+	if _, exported := filterApproved(content, chapter); exported {
+		t.Error("Expected a chapter with nothing approved to be skipped")
+	}
+}
+
+func TestAddStatusStamp(t *testing.T) {
+	chapter := &types.Chapter{Number: 1, Title: "Basics", Status: types.StatusInReview}
+
+	got := addStatusStamp("# Chapter 1: Basics\n\nHello.", chapter)
+	want := "# Chapter 1: Basics\n\n::: {.docgen-status .docgen-status-in-review}\n*Status: In review*\n:::\n\nHello."
+	if got != want {
+		t.Errorf("addStatusStamp() =\n%s\nwant:\n%s", got, want)
+	}
+	chapter.Status = ""
+	if got := addStatusStamp("Hello.", chapter); !strings.HasPrefix(got, "::: {.docgen-status .docgen-status-draft}\n*Status: Draft*") {
+		t.Errorf("Expected a draft stamp before headless content, got:\n%s", got)
+	}
+}
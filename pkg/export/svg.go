@@ -0,0 +1,133 @@
+package export
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+var (
+	// markdownSVGPattern matches an inline image whose path is an SVG file,
+	// capturing what comes before the path and the path itself
+	markdownSVGPattern = regexp.MustCompile(`(?i)(!\[[^\]]*\]\(<?)([^)\s>]+\.svg)`)
+	// latexSVGPattern matches an SVG file included in raw LaTeX, as composite
+	// and placed figures are, capturing the command and the path
+	latexSVGPattern = regexp.MustCompile(`(?i)(\\includegraphics(?:\[[^\]]*\])?\{)([^}]+\.svg)`)
+)
+
+// convertSVGImages points the SVG images of a PDF export's markdown at PDF or
+// PNG copies, since LaTeX can't include SVG. Copies are made with rsvg-convert
+// or inkscape and kept in assets/derived, named by the SVG's content, so an
+// unchanged image is converted once. Images that can't be converted are left
+// as they are, with a warning saying why.
+func (e *Exporter) convertSVGImages(ctx context.Context, documentID, markdown string) (string, []string) {
+	var warnings []string
+	warned := make(map[string]bool)
+	converted := make(map[string]string)
+
+	convert := func(path string) string {
+		if strings.Contains(path, "://") {
+			return path
+		}
+		if derived, ok := converted[path]; ok {
+			return derived
+		}
+		derived, err := e.convertSVG(ctx, documentID, path)
+		if err != nil {
+			if !warned[path] {
+				warned[path] = true
+				warnings = append(warnings, fmt.Sprintf("SVG image %s can't be included in the PDF: %v", path, err))
+			}
+			derived = path
+		}
+		converted[path] = derived
+		return derived
+	}
+
+	replace := func(pattern *regexp.Regexp) {
+		markdown = pattern.ReplaceAllStringFunc(markdown, func(match string) string {
+			parts := pattern.FindStringSubmatch(match)
+			return parts[1] + convert(parts[2])
+		})
+	}
+	replace(markdownSVGPattern)
+	replace(latexSVGPattern)
+	return markdown, warnings
+}
+
+// convertSVG returns the converted copy of an SVG image, converting it unless
+// a copy of the same content is already in assets/derived
+func (e *Exporter) convertSVG(ctx context.Context, documentID, path string) (string, error) {
+	source, err := e.config.ResolvePath(path, e.config.DocumentPath(documentID))
+	if err != nil {
+		return "", err
+	}
+	data, err := os.ReadFile(source)
+	if err != nil {
+		return "", fmt.Errorf("failed to read image: %w", err)
+	}
+
+	format := e.config.SVGFormat
+	if format == "" {
+		format = "pdf"
+	}
+	sum := sha256.Sum256(data)
+	name := strings.TrimSuffix(filepath.Base(source), filepath.Ext(source))
+	derivedDir := e.config.DerivedAssetsPath(documentID)
+	derived := filepath.Join(derivedDir, fmt.Sprintf("%s-%s.%s", name, hex.EncodeToString(sum[:6]), format))
+	if _, err := os.Stat(derived); err == nil {
+		return derived, nil
+	}
+
+	converter, err := exec.LookPath(e.config.SVGConverterPath)
+	if err != nil {
+		return "", fmt.Errorf("%s not found; install rsvg-convert or inkscape and set DOCGEN_SVG_CONVERTER, convert the image to PNG, or export to HTML", e.config.SVGConverterPath)
+	}
+	if err := os.MkdirAll(derivedDir, 0755); err != nil {
+		return "", fmt.Errorf("failed to create %s: %w", derivedDir, err)
+	}
+
+	// Convert next to the copy and rename, so an interrupted conversion is never cached
+	partial := derived + ".partial"
+	ctx, cancel := context.WithTimeout(ctx, e.config.ExportTimeout)
+	defer cancel()
+	output, err := exec.CommandContext(ctx, converter, svgConverterArgs(converter, format, source, partial)...).CombinedOutput()
+	if err == nil {
+		if _, statErr := os.Stat(partial); statErr != nil {
+			err = fmt.Errorf("no output written")
+		}
+	}
+	if err != nil {
+		os.Remove(partial)
+		return "", fmt.Errorf("conversion with %s failed: %w: %s", filepath.Base(converter), err, strings.TrimSpace(string(output)))
+	}
+	if err := os.Rename(partial, derived); err != nil {
+		os.Remove(partial)
+		return "", fmt.Errorf("failed to save converted image: %w", err)
+	}
+	return derived, nil
+}
+
+// svgConverterArgs returns the arguments that convert an SVG file to format
+// with rsvg-convert or, when the converter is inkscape, Inkscape 1.x. PNG
+// copies are rendered at print resolution.
+func svgConverterArgs(converter, format, source, output string) []string {
+	if strings.Contains(strings.ToLower(filepath.Base(converter)), "inkscape") {
+		args := []string{"--export-type=" + format, "--export-filename=" + output}
+		if format == "png" {
+			args = append(args, "--export-dpi=300")
+		}
+		return append(args, source)
+	}
+	args := []string{"--format", format, "--output", output}
+	if format == "png" {
+		args = append(args, "--dpi-x", "300", "--dpi-y", "300")
+	}
+	return append(args, source)
+}
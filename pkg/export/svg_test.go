@@ -0,0 +1,89 @@
+package export
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// countingRSVG copies the SVG to the output as rsvg-convert would convert it,
+// counting its runs in a file next to itself
+const countingRSVG = `#!/bin/sh
+echo run >> "$0.runs"
+while [ $# -gt 1 ]; do
+  case "$1" in --output) out="$2"; shift ;; esac
+  shift
+done
+cp "$1" "$out"
+`
+
+func TestExporter_ConvertSVGImages(t *testing.T) {
+	exporter, tempDir := setupTestExporter(t)
+	defer os.RemoveAll(tempDir)
+
+	imagesDir := filepath.Join(tempDir, "test-doc", "assets", "images")
+	os.MkdirAll(imagesDir, 0755)
+	os.WriteFile(filepath.Join(imagesDir, "chart.svg"), []byte("<svg/>"), 0644)
+	markdown := "![A chart](assets/images/chart.svg){#fig-1.1}\n\n![Photo](assets/images/photo.png)\n\n" +
+		"```{=latex}\n\\includegraphics[width=\\linewidth]{" + filepath.Join(imagesDir, "chart.svg") + "}\n```\n\n" +
+		"![Remote](https://example.com/logo.svg)"
+
+	// Without a converter the images stay as they are, with a warning
+	exporter.config.SVGConverterPath = filepath.Join(tempDir, "missing-rsvg-convert")
+	converted, warnings := exporter.convertSVGImages(context.Background(), "test-doc", markdown)
+	if converted != markdown {
+		t.Errorf("Expected the markdown unchanged, got:\n%s", converted)
+	}
+	if len(warnings) != 2 || !strings.Contains(warnings[0], "assets/images/chart.svg can't be included in the PDF") || !strings.Contains(warnings[0], "not found") {
+		t.Errorf("Unexpected warnings %v", warnings)
+	}
+
+	exporter.config.SVGConverterPath = filepath.Join(tempDir, "rsvg-convert")
+	if err := os.WriteFile(exporter.config.SVGConverterPath, []byte(countingRSVG), 0755); err != nil {
+		t.Fatal(err)
+	}
+	converted, warnings = exporter.convertSVGImages(context.Background(), "test-doc", markdown)
+	if len(warnings) != 0 {
+		t.Fatalf("Unexpected warnings %v", warnings)
+	}
+	derived, _ := filepath.Glob(filepath.Join(tempDir, "test-doc", "assets", "derived", "chart-*.pdf"))
+	if len(derived) != 1 {
+		t.Fatalf("Expected one converted copy, got %v", derived)
+	}
+	for _, want := range []string{
+		"![A chart](" + derived[0] + "){#fig-1.1}",
+		"![Photo](assets/images/photo.png)",
+		"\\includegraphics[width=\\linewidth]{" + derived[0] + "}",
+		"![Remote](https://example.com/logo.svg)",
+	} {
+		if !strings.Contains(converted, want) {
+			t.Errorf("Expected %q in:\n%s", want, converted)
+		}
+	}
+
+	// The copy is reused until the SVG changes
+	exporter.convertSVGImages(context.Background(), "test-doc", markdown)
+	runs, _ := os.ReadFile(exporter.config.SVGConverterPath + ".runs")
+	if strings.Count(string(runs), "run") != 1 {
+		t.Errorf("Expected one conversion, got %d", strings.Count(string(runs), "run"))
+	}
+	os.WriteFile(filepath.Join(imagesDir, "chart.svg"), []byte("<svg width='10'/>"), 0644)
+	exporter.convertSVGImages(context.Background(), "test-doc", markdown)
+	runs, _ = os.ReadFile(exporter.config.SVGConverterPath + ".runs")
+	if strings.Count(string(runs), "run") != 2 {
+		t.Errorf("Expected the changed SVG converted again, got %d conversions", strings.Count(string(runs), "run"))
+	}
+}
+
+func TestSVGConverterArgs(t *testing.T) {
+	got := strings.Join(svgConverterArgs("/usr/bin/rsvg-convert", "png", "in.svg", "out.png"), " ")
+	if got != "--format png --output out.png --dpi-x 300 --dpi-y 300 in.svg" {
+		t.Errorf("rsvg-convert args = %q", got)
+	}
+	got = strings.Join(svgConverterArgs("/opt/Inkscape", "pdf", "in.svg", "out.pdf"), " ")
+	if got != "--export-type=pdf --export-filename=out.pdf in.svg" {
+		t.Errorf("inkscape args = %q", got)
+	}
+}
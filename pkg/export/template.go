@@ -3,7 +3,6 @@ package export
 import (
 	"fmt"
 	"strings"
-	"time"
 
 	"github.com/gomcpgo/docgen/pkg/types"
 )
@@ -53,8 +52,8 @@ func CreateTemplateVariables(manifest *types.Manifest) TemplateVariables {
 		ChapterTitle:  "",              // Context-sensitive, filled when processing specific chapters
 		ChapterNumber: "",              // Context-sensitive, filled when processing specific chapters
 		DocumentTitle: manifest.Document.Title,
-		Author:        manifest.Document.Author,
-		Date:          time.Now().Format("2006-01-02"),
+		Author:        manifest.Document.Authors.String(),
+		Date:          documentDate(&manifest.Document),
 		SectionTitle:  "",              // Context-sensitive, filled when processing specific sections
 	}
 }
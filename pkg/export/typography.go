@@ -0,0 +1,125 @@
+package export
+
+import (
+	"regexp"
+	"strings"
+
+	"github.com/gomcpgo/docgen/pkg/types"
+)
+
+var (
+	// typographyProtectedPattern matches what typography leaves as written:
+	// inline code, link and image targets, autolinks and HTML tags,
+	// attributes and bare URLs
+	typographyProtectedPattern = regexp.MustCompile("`+[^`]*`+|\\]\\([^)]*\\)|<[^>\\s][^>]*>|\\{[^}]*\\}|https?://\\S+")
+
+	// Dashes between words, spaced or closed up. Numbers are left alone, as a
+	// dash between them is a range or a minus sign.
+	spacedDashPattern   = regexp.MustCompile(`([\pL,.;:!?)'"’”*_]) +(?:-{1,3}|–|—) +([\pL('"‘“*_])`)
+	spacedHyphenPattern = regexp.MustCompile(`([\pL,.;:!?)'"’”*_]) +(?:-{1,3}|—) +([\pL('"‘“*_])`)
+	closedDashPattern   = regexp.MustCompile(`(\pL)(?:-{2,3}|—)(\pL)`)
+
+	ellipsisPattern = regexp.MustCompile(`\.\.\.`)
+
+	unitSpacePattern      = regexp.MustCompile(`(\d) (%|°[CF]|(?:kg|mg|g|km|cm|mm|m|ms|min|s|h|Hz|kHz|MHz|GHz|KB|MB|GB|TB|kW|W|V|mA|A|mL|ml|L|px|pt)\b)`)
+	referenceSpacePattern = regexp.MustCompile(`\b(Figures?|Fig\.|Tables?|Chapters?|Sections?|Listings?|Equations?|Eq\.|Appendix|Page|p\.|pp\.|No\.|Vol\.) (\d|@|\[)`)
+)
+
+// applyTypography rewrites the text of chapter content to the typography's
+// conventions, outside code blocks and the spans typographyProtectedPattern
+// matches
+func applyTypography(content string, typography *types.Typography) string {
+	if typography == nil || !typography.RewritesText() {
+		return content
+	}
+
+	lines := strings.Split(content, "\n")
+	fence := ""
+	for i, line := range lines {
+		trimmed := strings.TrimSpace(line)
+		if fence != "" {
+			if strings.HasPrefix(trimmed, fence) {
+				fence = ""
+			}
+			continue
+		}
+		if strings.HasPrefix(trimmed, "```") || strings.HasPrefix(trimmed, "~~~") {
+			fence = trimmed[:3]
+			continue
+		}
+
+		var out strings.Builder
+		last := 0
+		for _, span := range typographyProtectedPattern.FindAllStringIndex(line, -1) {
+			out.WriteString(typesetText(line[last:span[0]], typography))
+			out.WriteString(line[span[0]:span[1]])
+			last = span[1]
+		}
+		out.WriteString(typesetText(line[last:], typography))
+		lines[i] = out.String()
+	}
+	return strings.Join(lines, "\n")
+}
+
+// typesetText applies the typography to a run of plain text
+func typesetText(text string, typography *types.Typography) string {
+	switch typography.Dashes {
+	case types.DashEm:
+		text = replaceUntilStable(spacedDashPattern, text, "$1—$2")
+		text = closedDashPattern.ReplaceAllString(text, "$1—$2")
+	case types.DashSpacedEn:
+		text = replaceUntilStable(spacedHyphenPattern, text, "$1 – $2")
+		text = replaceUntilStable(closedDashPattern, text, "$1 – $2")
+	}
+	if typography.Ellipses {
+		text = ellipsisPattern.ReplaceAllString(text, "…")
+	}
+	if typography.NonBreakingSpaces {
+		text = unitSpacePattern.ReplaceAllString(text, "$1\u00a0$2")
+		text = referenceSpacePattern.ReplaceAllString(text, "$1\u00a0$2")
+	}
+	return text
+}
+
+// replaceUntilStable replaces matches until none are left, as matches that
+// share a character, such as the two dashes of "a - b - c", are found one pass
+// at a time
+func replaceUntilStable(pattern *regexp.Regexp, text, replacement string) string {
+	for {
+		replaced := pattern.ReplaceAllString(text, replacement)
+		if replaced == text {
+			return text
+		}
+		text = replaced
+	}
+}
+
+// readerFormat returns the pandoc markdown format for the profile, with the
+// style's smart punctuation setting taking precedence over the profile's
+func readerFormat(profile *types.MarkdownProfile, style *types.Style) string {
+	if style == nil || style.Typography.SmartPunctuation == nil {
+		return profile.PandocFormat()
+	}
+	merged := types.MarkdownProfile{}
+	if profile != nil {
+		merged = *profile
+	}
+	merged.Smart = style.Typography.SmartPunctuation
+	return merged.PandocFormat()
+}
+
+// generateTypographyHeader keeps paragraphs' first and last lines from being
+// left alone at the bottom or top of a page
+func generateTypographyHeader(typography types.Typography) string {
+	var header strings.Builder
+	if typography.PreventWidows {
+		header.WriteString("\\widowpenalty=10000\n\\displaywidowpenalty=10000\n")
+	}
+	if typography.PreventOrphans {
+		header.WriteString("\\clubpenalty=10000\n")
+	}
+	if header.Len() == 0 {
+		return ""
+	}
+	return "\n% Widow and orphan control\n" + header.String()
+}
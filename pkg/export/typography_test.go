@@ -0,0 +1,78 @@
+package export
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/gomcpgo/docgen/pkg/types"
+)
+
+func TestApplyTypography(t *testing.T) {
+	content := "# Chapter 1: Basics\n\nIt was late -- too late --- and dark - very dark...\n\n" +
+		"Pages 10 - 20 weigh 5 kg, see Figure 3 and p. 12.\n\n" +
+		"Run `a -- b...` or see [the docs -- here](https://example.com/a--b...) {#sec-a--b}\n\n" +
+		"```\nx -- y...\n```\n\n| a | b |\n|---|---|\n| - | well--known |"
+
+	tests := []struct {
+		name       string
+		typography types.Typography
+		want       []string
+	}{
+		{
+			name:       "em dashes and ellipses",
+			typography: types.Typography{Dashes: types.DashEm, Ellipses: true},
+			want: []string{
+				"It was late—too late—and dark—very dark…",
+				"Pages 10 - 20",
+				"Run `a -- b...` or see [the docs—here](https://example.com/a--b...) {#sec-a--b}",
+				"```\nx -- y...\n```",
+				"|---|---|\n| - | well—known |",
+			},
+		},
+		{
+			name:       "spaced en dashes",
+			typography: types.Typography{Dashes: types.DashSpacedEn},
+			want:       []string{"It was late – too late – and dark – very dark...", "| - | well – known |"},
+		},
+		{
+			name:       "non-breaking spaces",
+			typography: types.Typography{NonBreakingSpaces: true},
+			want:       []string{"# Chapter\u00a01: Basics", "weigh 5\u00a0kg, see Figure\u00a03 and p.\u00a012.", "late -- too"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := applyTypography(content, &tt.typography)
+			for _, want := range tt.want {
+				if !strings.Contains(got, want) {
+					t.Errorf("Expected %q in:\n%s", want, got)
+				}
+			}
+		})
+	}
+
+	if got := applyTypography(content, &types.Typography{PreventWidows: true}); got != content {
+		t.Errorf("Expected no text changes, got:\n%s", got)
+	}
+}
+
+func TestTypographyPandocSettings(t *testing.T) {
+	off := false
+	style := &types.Style{Typography: types.Typography{SmartPunctuation: &off, PreventWidows: true, PreventOrphans: true}}
+
+	if got := readerFormat(nil, style); got != "markdown-smart" {
+		t.Errorf("readerFormat() = %q, want markdown-smart", got)
+	}
+	on := true
+	if got := readerFormat(&types.MarkdownProfile{Smart: &on, Footnotes: &off}, style); got != "markdown-footnotes-smart" {
+		t.Errorf("readerFormat() = %q, want the style to override smart", got)
+	}
+	if got := readerFormat(&types.MarkdownProfile{Smart: &on}, &types.Style{}); got != "markdown+smart" {
+		t.Errorf("readerFormat() = %q, want the profile's setting", got)
+	}
+
+	header := generateLaTeXHeader(style, &types.Manifest{})
+	if !strings.Contains(header, "\\widowpenalty=10000") || !strings.Contains(header, "\\clubpenalty=10000") {
+		t.Errorf("Expected widow and orphan penalties in:\n%s", header)
+	}
+}
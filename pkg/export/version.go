@@ -0,0 +1,32 @@
+package export
+
+import (
+	"context"
+	"fmt"
+	"os/exec"
+	"strings"
+	"time"
+)
+
+// PandocVersion returns the version reported by the configured pandoc, such as
+// "pandoc 3.1.2"
+func (e *Exporter) PandocVersion() (string, error) {
+	pandocPath, err := findPandocPath(e.config.PandocPath)
+	if err != nil {
+		return "", err
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	output, err := exec.CommandContext(ctx, pandocPath, "--version").Output()
+	if err != nil {
+		return "", fmt.Errorf("failed to run pandoc --version: %w", err)
+	}
+
+	firstLine := strings.TrimSpace(strings.SplitN(string(output), "\n", 2)[0])
+	if firstLine == "" {
+		return "", fmt.Errorf("pandoc --version printed nothing")
+	}
+	return firstLine, nil
+}
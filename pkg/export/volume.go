@@ -0,0 +1,190 @@
+package export
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/gomcpgo/docgen/pkg/types"
+)
+
+var (
+	// Image paths in markdown, raw LaTeX and raw HTML, which volume exports
+	// point at the document the image belongs to
+	markdownImagePathPattern = regexp.MustCompile(`(!\[[^\]]*\]\(<?)([^)\s>]+)`)
+	latexImagePathPattern    = regexp.MustCompile(`(\\includegraphics(?:\[[^\]]*\])?\{)([^}]+)`)
+	htmlImagePathPattern     = regexp.MustCompile(`(<img\s[^>]*?src=")([^"]+)`)
+)
+
+// VolumeDocument is a document compiled into a volume, with its manifest
+type VolumeDocument struct {
+	ID       string
+	Manifest *types.Manifest
+}
+
+// CompileVolume exports documents as one volume: one title page and table of
+// contents, one style, and each document a part titled with the document's
+// title. Chapters are numbered through the volume or from 1 in each document,
+// and their headings are written in the style's chapter heading style. The
+// first document's pandoc config supplies the bibliography and other pandoc
+// settings.
+func (e *Exporter) CompileVolume(ctx context.Context, volume *types.Volume, documents []VolumeDocument, style *types.Style, pandocConfig *types.PandocConfig, options *types.ExportOptions, rebuildFunc ChapterRebuildFunc) (*types.ExportResult, error) {
+	switch options.Format {
+	case types.ExportFormatPDF, types.ExportFormatDOCX, types.ExportFormatHTML, types.ExportFormatEPUB:
+	default:
+		return nil, fmt.Errorf("volumes can only be compiled to pdf, docx, html or epub")
+	}
+	if len(documents) == 0 {
+		return nil, fmt.Errorf("a volume needs at least one document")
+	}
+
+	// The volume stands in for a document in the pandoc command and headers
+	combined := volumeManifest(volume, documents)
+	chapterOptions := *options
+	var heading types.ChapterHeadingStyle
+	if style != nil {
+		chapterOptions.Numbering = &style.NumberingStyle
+		chapterOptions.Typography = &style.Typography
+		heading = style.ChapterHeading
+	}
+
+	var content strings.Builder
+	content.WriteString("---\n")
+	content.WriteString(generateYAMLMetadata(&combined.Document, nil))
+	content.WriteString("---\n\n")
+
+	var warnings []string
+	placement := types.VolumePlacement{ChapterHeading: heading}
+	for i, document := range documents {
+		if err := ctx.Err(); err != nil {
+			return nil, fmt.Errorf("export cancelled: %w", err)
+		}
+		if rebuildFunc != nil {
+			for _, chapter := range document.Manifest.Document.Chapters {
+				if err := rebuildFunc(types.DocumentID(document.ID), chapter.Number); err != nil {
+					return nil, fmt.Errorf("failed to rebuild chapter %d of %s: %w", chapter.Number, document.ID, err)
+				}
+			}
+		}
+		if report := e.ValidateDocument(document.ID, document.Manifest); !report.Valid {
+			return nil, fmt.Errorf("document %s validation failed: %v", document.ID, report.Errors)
+		}
+
+		documentOptions := chapterOptions
+		documentPlacement := placement
+		documentOptions.Volume = &documentPlacement
+		markdown, err := e.GenerateMarkdown(document.ID, document.Manifest, &documentOptions)
+		if err != nil {
+			return nil, fmt.Errorf("failed to generate markdown for %s: %w", document.ID, err)
+		}
+		if options.Format == types.ExportFormatPDF {
+			var svgWarnings []string
+			markdown, svgWarnings = e.convertSVGImages(ctx, document.ID, markdown)
+			warnings = append(warnings, svgWarnings...)
+		}
+
+		content.WriteString(partHeading(options.Format, i+1, document.Manifest.Document.Title))
+		content.WriteString(absoluteImagePaths(markdown, e.config.DocumentPath(document.ID)))
+
+		// Counters that run through the volume continue in the next document
+		if volume.Numbering == types.VolumeNumberingContinuous {
+			for _, chapter := range document.Manifest.Document.Chapters {
+				placement.ChapterOffset++
+				placement.FigureOffset += len(chapter.Figures)
+				placement.TableOffset += len(chapter.Tables)
+			}
+		}
+	}
+
+	workDir, err := e.newWorkDir(volume.Name())
+	if err != nil {
+		return nil, err
+	}
+	defer os.RemoveAll(workDir)
+
+	inputFile := filepath.Join(workDir, fmt.Sprintf("%s-input.md", volume.Name()))
+	if err := os.WriteFile(inputFile, []byte(content.String()), 0644); err != nil {
+		return nil, fmt.Errorf("failed to write temporary input file: %w", err)
+	}
+
+	outputFile := e.config.ExportPath(volume.Name(), string(options.Format))
+	if err := os.MkdirAll(filepath.Dir(outputFile), 0755); err != nil {
+		return nil, fmt.Errorf("failed to create output directory: %w", err)
+	}
+
+	var cssFile string
+	if options.Format == types.ExportFormatHTML && style != nil {
+		cssFile = filepath.Join(workDir, fmt.Sprintf("%s-style.css", volume.Name()))
+		if err := os.WriteFile(cssFile, []byte(generateHTMLCSS(style, combined)), 0644); err != nil {
+			return nil, fmt.Errorf("failed to create temporary CSS file: %w", err)
+		}
+	}
+
+	// A volume always has a table of contents, covering every document
+	volumeConfig := types.PandocConfig{}
+	if pandocConfig != nil {
+		volumeConfig = *pandocConfig
+	}
+	volumeConfig.TOC = true
+	merged := mergeChapterPandocOptions(&volumeConfig, combined, nil)
+
+	cmd := e.GeneratePandocCommand(ctx, documents[0].ID, inputFile, outputFile, combined, style, merged, &chapterOptions, cssFile)
+	if _, err := e.runPandoc(ctx, volume.Name(), options.Format, cmd, inputFile, outputFile); err != nil {
+		return nil, err
+	}
+	if _, err := os.Stat(outputFile); os.IsNotExist(err) {
+		return nil, fmt.Errorf("output file was not created: %s", outputFile)
+	}
+	log.Printf("[DOCGEN VOLUME] Compiled %d document(s) into %s", len(documents), outputFile)
+
+	result := &types.ExportResult{OutputPath: outputFile, PDFEngine: pdfEngineArg(cmd.Args), Warnings: warnings}
+	if err := e.finishExport(ctx, result, options); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// volumeManifest is a manifest for a volume as a whole: the volume's title,
+// the documents' authors and every chapter, numbered through the volume
+func volumeManifest(volume *types.Volume, documents []VolumeDocument) *types.Manifest {
+	first := documents[0].Manifest.Document
+	combined := &types.Manifest{Document: types.Document{Title: volume.Title, Type: types.DocumentTypeBook}}
+	combined.Document.Language = first.Language
+	combined.Document.Locale = first.Locale
+
+	authors := make(map[string]bool)
+	for _, document := range documents {
+		for _, author := range document.Manifest.Document.Authors {
+			if !authors[author.Name] {
+				authors[author.Name] = true
+				combined.Document.Authors = append(combined.Document.Authors, author)
+			}
+		}
+		for _, chapter := range document.Manifest.Document.Chapters {
+			chapter.Number = types.ChapterNumber(len(combined.Document.Chapters) + 1)
+			combined.Document.Chapters = append(combined.Document.Chapters, chapter)
+		}
+	}
+	return combined
+}
+
+// absoluteImagePaths points the relative image paths of a document's markdown
+// at the document's directory, so images of documents in a volume don't get
+// mixed up
+func absoluteImagePaths(markdown, documentDir string) string {
+	for _, pattern := range []*regexp.Regexp{markdownImagePathPattern, latexImagePathPattern, htmlImagePathPattern} {
+		markdown = pattern.ReplaceAllStringFunc(markdown, func(match string) string {
+			parts := pattern.FindStringSubmatch(match)
+			path := parts[2]
+			if strings.Contains(path, ":") || filepath.IsAbs(path) || strings.HasPrefix(path, "#") {
+				return match
+			}
+			return parts[1] + filepath.ToSlash(filepath.Join(documentDir, path))
+		})
+	}
+	return markdown
+}
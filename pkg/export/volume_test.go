@@ -0,0 +1,73 @@
+package export
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/gomcpgo/docgen/pkg/types"
+)
+
+func TestGenerateMarkdown_Volume(t *testing.T) {
+	exporter, tempDir := setupTestExporter(t)
+	defer os.RemoveAll(tempDir)
+
+	_, manifest, _, _ := createTestDocument(t, tempDir)
+	manifest.Document.Chapters[1].Sections = []types.Section{{Number: types.SectionNumber{2, 1}, Title: "Setup", Level: 1}}
+	manifest.Document.Chapters[0].Figures = []types.Figure{{ID: "fig-1"}}
+	manifest.Document.Parts = []types.Part{{Title: "Basics", FirstChapter: 1, LastChapter: 2}}
+	chapterPath := filepath.Join(tempDir, "test-doc", "chapters", "02")
+	os.MkdirAll(chapterPath, 0755)
+	os.WriteFile(filepath.Join(chapterPath, "chapter.md"), []byte("# Chapter 2: Methods\n\n## 2.1 Setup\n\nText.\n"), 0644)
+
+	prefix := "%d."
+	options := &types.ExportOptions{
+		Format:    types.ExportFormatPDF,
+		Chapters:  []types.ChapterNumber{2},
+		Numbering: &types.NumberingStyle{FigureNumbering: types.CounterContinuous},
+		Volume:    &types.VolumePlacement{ChapterOffset: 3, FigureOffset: 4, ChapterHeading: types.ChapterHeadingStyle{Prefix: &prefix}},
+	}
+	markdown, err := exporter.GenerateMarkdown("test-doc", manifest, options)
+	if err != nil {
+		t.Fatalf("GenerateMarkdown() error = %v", err)
+	}
+	if strings.HasPrefix(markdown, "---") || strings.Contains(markdown, "\\part{Basics}") {
+		t.Errorf("Expected no metadata or parts of the document's own in a volume:\n%s", markdown)
+	}
+	for _, want := range []string{"# 5. Methods\n", "## 5.1 Setup\n", `\renewcommand{\docgenchapter}{5}\setcounter{figure}{5}`} {
+		if !strings.Contains(markdown, want) {
+			t.Errorf("Expected %q in the volume markdown:\n%s", want, markdown)
+		}
+	}
+}
+
+func TestAbsoluteImagePaths(t *testing.T) {
+	markdown := "![Plot](assets/images/plot.png){width=50%}\n\n![Logo](https://example.com/logo.png)\n\n" +
+		"\\includegraphics[width=\\linewidth]{assets/images/a.pdf}\n\n<img src=\"assets/images/b.png\" alt=\"B\">"
+	want := "![Plot](/docs/report/assets/images/plot.png){width=50%}\n\n![Logo](https://example.com/logo.png)\n\n" +
+		"\\includegraphics[width=\\linewidth]{/docs/report/assets/images/a.pdf}\n\n<img src=\"/docs/report/assets/images/b.png\" alt=\"B\">"
+	if got := absoluteImagePaths(markdown, "/docs/report"); got != want {
+		t.Errorf("absoluteImagePaths() =\n%s\nwant:\n%s", got, want)
+	}
+}
+
+func TestVolumeManifest(t *testing.T) {
+	first := &types.Manifest{Document: types.Document{Title: "Part One", Authors: types.AuthorList{{Name: "Ada"}}, Chapters: []types.Chapter{{Number: 1, Title: "A"}, {Number: 2, Title: "B"}}}}
+	second := &types.Manifest{Document: types.Document{Title: "Part Two", Authors: types.AuthorList{{Name: "Ada"}, {Name: "Grace"}}, Chapters: []types.Chapter{{Number: 1, Title: "C"}}}}
+	volume := &types.Volume{Title: "Collected Reports, 2026", Numbering: types.VolumeNumberingContinuous}
+
+	combined := volumeManifest(volume, []VolumeDocument{{ID: "one", Manifest: first}, {ID: "two", Manifest: second}})
+	if combined.Document.Title != volume.Title || len(combined.Document.Authors) != 2 {
+		t.Errorf("Unexpected volume document %+v", combined.Document)
+	}
+	if chapters := combined.Document.Chapters; len(chapters) != 3 || chapters[2].Number != 3 || chapters[2].Title != "C" {
+		t.Errorf("Expected the chapters numbered through the volume, got %+v", chapters)
+	}
+	if first.Document.Chapters[0].Number != 1 || second.Document.Chapters[0].Number != 1 {
+		t.Error("Expected the documents' own manifests unchanged")
+	}
+	if name := volume.Name(); name != "volume-collected-reports-2026" {
+		t.Errorf("Name() = %q", name)
+	}
+}
@@ -0,0 +1,156 @@
+// Package guard protects documents from runaway or adversarial clients: it
+// limits how often each client may call tools and delete, and keeps the tokens
+// that confirm deletions.
+package guard
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/gomcpgo/docgen/pkg/config"
+)
+
+// TokenTTL is how long a confirmation token stays valid
+const TokenTTL = 5 * time.Minute
+
+// pendingDeletion is a deletion waiting for its confirmation token
+type pendingDeletion struct {
+	clientID string
+	tool     string
+	target   string
+	expires  time.Time
+}
+
+// Guard holds each client's recent calls, deletes and confirmation tokens.
+// They are kept in memory, so a session lasts as long as the server runs.
+type Guard struct {
+	config *config.Config
+	mu     sync.Mutex
+
+	calls   map[string][]time.Time
+	deletes map[string]int
+	tokens  map[string]pendingDeletion
+
+	// now is the clock, replaced in tests
+	now func() time.Time
+}
+
+// NewGuard creates a new guard
+func NewGuard(cfg *config.Config) *Guard {
+	return &Guard{
+		config:  cfg,
+		calls:   make(map[string][]time.Time),
+		deletes: make(map[string]int),
+		tokens:  make(map[string]pendingDeletion),
+		now:     time.Now,
+	}
+}
+
+// CheckRate records a call to a tool and returns an error if the client has
+// already made as many calls as the tool's rate limit allows
+func (g *Guard) CheckRate(clientID, tool string) error {
+	limit, ok := g.config.RateLimits[tool]
+	if !ok {
+		limit, ok = g.config.RateLimits["*"]
+	}
+	if !ok {
+		return nil
+	}
+
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	now := g.now()
+	key := clientID + "\x00" + tool
+	var recent []time.Time
+	for _, call := range g.calls[key] {
+		if now.Sub(call) < limit.Per {
+			recent = append(recent, call)
+		}
+	}
+	if len(recent) >= limit.Calls {
+		g.calls[key] = recent
+		wait := recent[0].Add(limit.Per).Sub(now).Round(time.Second)
+		if wait < time.Second {
+			wait = time.Second
+		}
+		return fmt.Errorf("rate limit exceeded: %s allows %d calls per %v; try again in %v", tool, limit.Calls, limit.Per, wait)
+	}
+	g.calls[key] = append(recent, now)
+	return nil
+}
+
+// CheckDelete returns an error once the client has made as many delete calls
+// as allowed
+func (g *Guard) CheckDelete(clientID string) error {
+	if g.config.MaxDeletes <= 0 {
+		return nil
+	}
+
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	if g.deletes[clientID] >= g.config.MaxDeletes {
+		return fmt.Errorf("delete limit reached: client %s has made all %d deletes allowed this session", clientID, g.config.MaxDeletes)
+	}
+	return nil
+}
+
+// RecordDelete counts a delete call toward the client's limit
+func (g *Guard) RecordDelete(clientID string) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	g.deletes[clientID]++
+}
+
+// IssueToken returns a token that confirms the deletion of target by the
+// client with the tool. It can be used once, within TokenTTL.
+func (g *Guard) IssueToken(clientID, tool, target string) (string, error) {
+	random := make([]byte, 16)
+	if _, err := rand.Read(random); err != nil {
+		return "", fmt.Errorf("failed to generate confirmation token: %w", err)
+	}
+	token := hex.EncodeToString(random)
+
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	now := g.now()
+	for existing, pending := range g.tokens {
+		if now.After(pending.expires) {
+			delete(g.tokens, existing)
+		}
+	}
+	g.tokens[token] = pendingDeletion{
+		clientID: clientID,
+		tool:     tool,
+		target:   target,
+		expires:  now.Add(TokenTTL),
+	}
+	return token, nil
+}
+
+// RedeemToken uses up a confirmation token, returning an error unless it was
+// issued to the client for the same tool and target and hasn't expired
+func (g *Guard) RedeemToken(clientID, tool, target, token string) error {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	// Another client's token is as good as none
+	pending, ok := g.tokens[token]
+	if !ok || pending.clientID != clientID || g.now().After(pending.expires) {
+		if ok && pending.clientID == clientID {
+			delete(g.tokens, token)
+		}
+		return fmt.Errorf("confirmation token is invalid or expired; call %s without confirmation_token for a new one", tool)
+	}
+	if pending.tool != tool || pending.target != target {
+		return fmt.Errorf("confirmation token was issued to delete %s with %s; call %s without confirmation_token for a new one", pending.target, pending.tool, tool)
+	}
+	delete(g.tokens, token)
+	return nil
+}
@@ -0,0 +1,111 @@
+package guard
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gomcpgo/docgen/pkg/config"
+)
+
+func setupTestGuard() (*Guard, *time.Time) {
+	cfg := &config.Config{
+		MaxDeletes: 2,
+		RateLimits: map[string]config.RateLimit{
+			"export_document": {Calls: 2, Per: time.Minute},
+			"*":               {Calls: 3, Per: time.Second},
+		},
+	}
+	guard := NewGuard(cfg)
+	now := time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)
+	guard.now = func() time.Time { return now }
+	return guard, &now
+}
+
+func TestGuard_CheckRate(t *testing.T) {
+	guard, now := setupTestGuard()
+
+	for i := 0; i < 2; i++ {
+		if err := guard.CheckRate("alice", "export_document"); err != nil {
+			t.Fatalf("CheckRate() call %d error = %v", i+1, err)
+		}
+	}
+	err := guard.CheckRate("alice", "export_document")
+	if err == nil || !strings.Contains(err.Error(), "try again in 1m0s") {
+		t.Errorf("CheckRate() error = %v, want a rate limit", err)
+	}
+
+	// Limits are per client, and the "*" limit per tool
+	if err := guard.CheckRate("bob", "export_document"); err != nil {
+		t.Errorf("CheckRate() for another client error = %v", err)
+	}
+	for _, tool := range []string{"get_toc", "get_toc", "get_toc", "add_section"} {
+		if err := guard.CheckRate("alice", tool); err != nil {
+			t.Errorf("CheckRate(%s) error = %v", tool, err)
+		}
+	}
+	if err := guard.CheckRate("alice", "get_toc"); err == nil {
+		t.Error("Expected the default limit to apply to get_toc")
+	}
+
+	// Calls leave the window as time passes
+	*now = now.Add(30 * time.Second)
+	if err := guard.CheckRate("alice", "export_document"); err == nil || !strings.Contains(err.Error(), "try again in 30s") {
+		t.Errorf("CheckRate() error = %v, want 30s to wait", err)
+	}
+	*now = now.Add(30 * time.Second)
+	if err := guard.CheckRate("alice", "export_document"); err != nil {
+		t.Errorf("CheckRate() after the period error = %v", err)
+	}
+}
+
+func TestGuard_Deletes(t *testing.T) {
+	guard, _ := setupTestGuard()
+
+	for i := 0; i < 2; i++ {
+		if err := guard.CheckDelete("alice"); err != nil {
+			t.Fatalf("CheckDelete() error = %v", err)
+		}
+		guard.RecordDelete("alice")
+	}
+	if err := guard.CheckDelete("alice"); err == nil || !strings.Contains(err.Error(), "all 2 deletes") {
+		t.Errorf("CheckDelete() error = %v, want the delete limit", err)
+	}
+	if err := guard.CheckDelete("bob"); err != nil {
+		t.Errorf("CheckDelete() for another client error = %v", err)
+	}
+
+	guard.config.MaxDeletes = 0
+	if err := guard.CheckDelete("alice"); err != nil {
+		t.Errorf("CheckDelete() without a limit error = %v", err)
+	}
+}
+
+func TestGuard_Tokens(t *testing.T) {
+	guard, now := setupTestGuard()
+
+	token, err := guard.IssueToken("alice", "delete_document", "document a")
+	if err != nil || len(token) != 32 {
+		t.Fatalf("IssueToken() = %q, %v", token, err)
+	}
+
+	// Tokens are bound to their client, tool and target
+	if err := guard.RedeemToken("bob", "delete_document", "document a", token); err == nil || !strings.Contains(err.Error(), "invalid or expired") {
+		t.Errorf("RedeemToken() by another client error = %v", err)
+	}
+	if err := guard.RedeemToken("alice", "delete_document", "document b", token); err == nil || !strings.Contains(err.Error(), "issued to delete document a") {
+		t.Errorf("RedeemToken() for another target error = %v", err)
+	}
+	if err := guard.RedeemToken("alice", "delete_document", "document a", token); err != nil {
+		t.Fatalf("RedeemToken() error = %v", err)
+	}
+	if err := guard.RedeemToken("alice", "delete_document", "document a", token); err == nil {
+		t.Error("Expected a token to work only once")
+	}
+
+	token, _ = guard.IssueToken("alice", "delete_document", "document a")
+	*now = now.Add(TokenTTL + time.Second)
+	if err := guard.RedeemToken("alice", "delete_document", "document a", token); err == nil {
+		t.Error("Expected an expired token to be refused")
+	}
+}
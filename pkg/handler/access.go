@@ -0,0 +1,302 @@
+package handler
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/gomcpgo/docgen/pkg/types"
+	"github.com/gomcpgo/mcp/pkg/protocol"
+)
+
+// toolRoles is the role each tool requires when access control is enabled. Tools
+// that take a document_id are checked against the caller's role on that document;
+// the rest against the caller's global role. Tools not listed require admin.
+var toolRoles = map[string]types.Role{
+	// Reading and exporting
+	"list_documents":         types.RoleViewer,
+	"get_document_structure": types.RoleViewer,
+	"get_toc":                types.RoleViewer,
+	"summarize_structure":    types.RoleViewer,
+	"get_document_json":      types.RoleViewer,
+	"list_todos":             types.RoleViewer,
+	"get_editorial_report":   types.RoleViewer,
+	"check_house_style":      types.RoleViewer, // editor when fixing
+	"check_assets":           types.RoleViewer, // editor when pruning
+	"check_figures_tables":   types.RoleViewer,
+	"check_consistency":      types.RoleViewer, // editor when rebuilding
+	"writing_progress":       types.RoleViewer,
+	"get_document_size":      types.RoleViewer,
+	"archive_document":       types.RoleViewer,
+	"get_section_content":    types.RoleViewer,
+	"get_chapter_content":    types.RoleViewer,
+	"get_section_blocks":     types.RoleViewer,
+	"list_chapter_revisions": types.RoleViewer,
+	"preview_chapter":        types.RoleViewer,
+	"preview_chapter_diff":   types.RoleViewer,
+	"export_document":        types.RoleViewer,
+	"compile_volume":         types.RoleViewer,
+	"reimport_docx_feedback": types.RoleViewer,
+	"estimate_pages":         types.RoleViewer,
+	"validate_document":      types.RoleViewer,
+	"get_export_log":         types.RoleViewer,
+	"list_exports":           types.RoleViewer,
+	"resolve_style":          types.RoleViewer,
+	"list_fonts":             types.RoleViewer,
+	"verify_export":          types.RoleViewer,
+
+	// Changing content and settings
+	"create_document":         types.RoleEditor,
+	"create_sandbox_document": types.RoleEditor,
+	"restore_document":        types.RoleEditor,
+	"configure_document":      types.RoleEditor,
+	"rename_document":         types.RoleEditor, // admin when changing the ID
+	"tag_document":            types.RoleEditor,
+	"untag_document":          types.RoleEditor,
+	"add_author":              types.RoleEditor,
+	"remove_author":           types.RoleEditor,
+	"set_editorial_rules":     types.RoleEditor,
+	"set_writing_target":      types.RoleEditor,
+	"add_chapter":             types.RoleEditor,
+	"update_chapter_metadata": types.RoleEditor,
+	"configure_chapter":       types.RoleEditor,
+	"set_front_matter":        types.RoleEditor,
+	"set_status":              types.RoleEditor,
+	"set_epigraph":            types.RoleEditor,
+	"snapshot_chapter":        types.RoleEditor,
+	"include_file":            types.RoleEditor,
+	"build_outline":           types.RoleEditor,
+	"delete_chapter":          types.RoleEditor,
+	"move_chapter":            types.RoleEditor,
+	"merge_chapters":          types.RoleEditor,
+	"split_chapter":           types.RoleEditor,
+	"set_part":                types.RoleEditor,
+	"set_abbreviation":        types.RoleEditor,
+	"list_abbreviations":      types.RoleViewer,
+	"add_section":             types.RoleEditor,
+	"add_sections":            types.RoleEditor,
+	"update_section":          types.RoleEditor,
+	"append_to_section":       types.RoleEditor,
+	"insert_into_section":     types.RoleEditor,
+	"insert_page_break":       types.RoleEditor,
+	"delete_section":          types.RoleEditor,
+	"update_block":            types.RoleEditor,
+	"insert_block":            types.RoleEditor,
+	"delete_block":            types.RoleEditor,
+	"insert_citation":         types.RoleEditor,
+	"add_content":             types.RoleEditor,
+	"apply_section_template":  types.RoleEditor,
+	"add_image":               types.RoleEditor,
+	"add_figure_grid":         types.RoleEditor,
+	"add_listing":             types.RoleEditor,
+	"update_image_caption":    types.RoleEditor,
+	"update_image_properties": types.RoleEditor,
+	"set_figure_anchor":       types.RoleEditor,
+	"create_table_from_csv":   types.RoleEditor,
+	"update_table_data":       types.RoleEditor,
+	"delete_image":            types.RoleEditor,
+	"annotate_image":          types.RoleEditor,
+	"migrate_figure_ids":      types.RoleEditor,
+	"add_font":                types.RoleEditor, // admin for shared fonts
+	"delete_export":           types.RoleEditor,
+	"lock_document":           types.RoleEditor,
+	"unlock_document":         types.RoleEditor,
+
+	// Deleting documents, granting roles and shared resources
+	"delete_document":       types.RoleAdmin,
+	"set_document_role":     types.RoleAdmin,
+	"save_section_template": types.RoleAdmin,
+	"save_house_style":      types.RoleAdmin,
+	"get_usage_report":      types.RoleAdmin,
+	"get_storage_usage":     types.RoleAdmin,
+	"get_export_records":    types.RoleAdmin,
+}
+
+// authorize checks the caller's role for a tool call. It always succeeds when
+// access control is disabled.
+func (h *DocGenHandler) authorize(clientID string, req *protocol.CallToolRequest) error {
+	if !h.config.AccessControl {
+		return nil
+	}
+
+	required := requiredRole(req)
+
+	// Listing is filtered per document instead
+	if req.Name == "list_documents" {
+		return nil
+	}
+
+	documentID, _ := req.Arguments["document_id"].(string)
+
+	h.accessMu.Lock()
+	policy, err := h.storage.LoadAccessPolicy()
+	h.accessMu.Unlock()
+	if err != nil {
+		return fmt.Errorf("failed to load access policy: %w", err)
+	}
+
+	// A volume needs the role on each of its documents. Other tools are
+	// checked on document_id whatever else they are given.
+	if req.Name == "compile_volume" {
+		documentIDs, _ := req.Arguments["document_ids"].([]interface{})
+		if len(documentIDs) == 0 {
+			return fmt.Errorf("access denied: %s requires document_ids", req.Name)
+		}
+		for _, id := range documentIDs {
+			id, _ := id.(string)
+			if id == "" || !policy.RoleFor(clientID, id).Includes(required) {
+				return fmt.Errorf("access denied: %s requires the %s role on document %s", req.Name, required, id)
+			}
+		}
+		if documentID == "" {
+			return nil
+		}
+	}
+
+	role := policy.RoleFor(clientID, documentID)
+	if !role.Includes(required) {
+		if documentID != "" {
+			return fmt.Errorf("access denied: %s requires the %s role on document %s", req.Name, required, documentID)
+		}
+		return fmt.Errorf("access denied: %s requires the %s role", req.Name, required)
+	}
+	return nil
+}
+
+// requiredRole is the role a tool call requires
+func requiredRole(req *protocol.CallToolRequest) types.Role {
+	required, ok := toolRoles[req.Name]
+	if !ok {
+		required = types.RoleAdmin
+	}
+	if fix, _ := req.Arguments["fix"].(bool); req.Name == "check_house_style" && fix {
+		required = types.RoleEditor
+	}
+	if prune, _ := req.Arguments["prune"].(bool); req.Name == "check_assets" && prune {
+		required = types.RoleEditor
+	}
+	if rebuild, _ := req.Arguments["rebuild"].(bool); req.Name == "check_consistency" && rebuild {
+		required = types.RoleEditor
+	}
+	if req.Name == "rename_document" && changesDocumentID(req.Arguments) {
+		required = types.RoleAdmin
+	}
+	if _, ok := req.Arguments["document_id"]; req.Name == "add_font" && !ok {
+		required = types.RoleAdmin
+	}
+	return required
+}
+
+// lockExemptTools change a document's state without touching its content, so
+// they still work while it is locked
+var lockExemptTools = map[string]bool{
+	"lock_document":     true,
+	"unlock_document":   true,
+	"delete_export":     true,
+	"snapshot_chapter":  true,
+	"set_document_role": true,
+}
+
+// checkLock refuses calls that would change a locked document. Tools that
+// need the editor role change documents, as do deleting and renaming them.
+func (h *DocGenHandler) checkLock(req *protocol.CallToolRequest) error {
+	if lockExemptTools[req.Name] {
+		return nil
+	}
+	required := requiredRole(req)
+	if required != types.RoleEditor && req.Name != "delete_document" && req.Name != "rename_document" {
+		return nil
+	}
+	documentID, _ := req.Arguments["document_id"].(string)
+	if documentID == "" {
+		return nil
+	}
+	return h.manager.CheckUnlocked(types.DocumentID(documentID))
+}
+
+// changesDocumentID reports whether a rename_document call moves the document
+// to a new ID, which takes it away from the old one as deleting it would
+func changesDocumentID(params map[string]interface{}) bool {
+	newID, _ := params["new_document_id"].(string)
+	deriveID, _ := params["derive_id"].(bool)
+	return strings.TrimSpace(newID) != "" || deriveID
+}
+
+// canView reports whether the caller may see a document
+func (h *DocGenHandler) canView(policy *types.AccessPolicy, clientID, documentID string) bool {
+	return policy == nil || policy.RoleFor(clientID, documentID).Includes(types.RoleViewer)
+}
+
+// grantCreator makes the creator of a document its admin
+func (h *DocGenHandler) grantCreator(clientID string, docID types.DocumentID) error {
+	if !h.config.AccessControl {
+		return nil
+	}
+	return h.updateAccessPolicy(func(policy *types.AccessPolicy) {
+		policy.SetDocumentRole(string(docID), clientID, types.RoleAdmin)
+	})
+}
+
+// updateAccessPolicy applies a change to the access policy and saves it
+func (h *DocGenHandler) updateAccessPolicy(change func(policy *types.AccessPolicy)) error {
+	h.accessMu.Lock()
+	defer h.accessMu.Unlock()
+
+	policy, err := h.storage.LoadAccessPolicy()
+	if err != nil {
+		return fmt.Errorf("failed to load access policy: %w", err)
+	}
+	change(policy)
+	if err := h.storage.SaveAccessPolicy(policy); err != nil {
+		return fmt.Errorf("failed to save access policy: %w", err)
+	}
+	return nil
+}
+
+func (h *DocGenHandler) handleSetDocumentRole(params map[string]interface{}) (*protocol.CallToolResponse, error) {
+	// Get document ID
+	docID, err := h.getDocumentID(params)
+	if err != nil {
+		return h.errorResponse(fmt.Sprintf("Invalid document_id: %v", err))
+	}
+
+	// Get identity
+	identity, ok := params["identity"].(string)
+	if !ok || identity == "" {
+		return h.errorResponse("identity parameter is required")
+	}
+
+	// Get role (empty revokes)
+	roleStr, _ := params["role"].(string)
+	role := types.Role(roleStr)
+	if role != "" {
+		if err := role.Validate(); err != nil {
+			return h.errorResponse(err.Error())
+		}
+	}
+
+	exists, err := h.storage.DocumentExists(string(docID))
+	if err != nil {
+		return h.errorResponse(fmt.Sprintf("Failed to check document: %v", err))
+	}
+	if !exists {
+		return h.errorResponse(fmt.Sprintf("Document %s not found", docID))
+	}
+
+	if err := h.updateAccessPolicy(func(policy *types.AccessPolicy) {
+		policy.SetDocumentRole(string(docID), identity, role)
+	}); err != nil {
+		return h.errorResponse(fmt.Sprintf("Failed to set document role: %v", err))
+	}
+
+	message := fmt.Sprintf("Revoked %s's role on document %s", identity, docID)
+	if role != "" {
+		message = fmt.Sprintf("Granted %s the %s role on document %s", identity, role, docID)
+	}
+
+	return h.successResponse(map[string]interface{}{
+		"document_id": docID,
+		"identity":    identity,
+		"role":        role,
+		"message":     message,
+	})
+}
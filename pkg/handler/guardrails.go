@@ -0,0 +1,97 @@
+package handler
+
+import (
+	"fmt"
+
+	"github.com/gomcpgo/docgen/pkg/guard"
+	"github.com/gomcpgo/docgen/pkg/types"
+	"github.com/gomcpgo/mcp/pkg/protocol"
+)
+
+// deleteTools delete documents or their content, so they count toward the
+// per-session delete limit, as does check_assets when it prunes
+var deleteTools = map[string]bool{
+	"delete_document": true,
+	"delete_chapter":  true,
+	"delete_section":  true,
+	"delete_block":    true,
+	"delete_image":    true,
+	"delete_export":   true,
+}
+
+// confirmedTools delete whole documents or chapters, so with confirmation on
+// their first call only returns a token that must be passed back to delete
+var confirmedTools = map[string]bool{
+	"delete_document": true,
+	"delete_chapter":  true,
+}
+
+// checkGuardrails applies the rate limits, the delete limit and delete
+// confirmation to a tool call. It returns the response to send instead when
+// the call must not go ahead. Deletes are counted by CallTool once they
+// succeed.
+func (h *DocGenHandler) checkGuardrails(clientID string, req *protocol.CallToolRequest) *protocol.CallToolResponse {
+	if err := h.guard.CheckRate(clientID, req.Name); err != nil {
+		resp, _ := h.errorResponse(err.Error())
+		return resp
+	}
+	if !isDeleteCall(req) {
+		return nil
+	}
+	if err := h.guard.CheckDelete(clientID); err != nil {
+		resp, _ := h.errorResponse(err.Error())
+		return resp
+	}
+
+	if h.config.ConfirmDeletes && confirmedTools[req.Name] {
+		target := h.deleteTarget(req.Arguments)
+		token, _ := req.Arguments["confirmation_token"].(string)
+		if token == "" {
+			token, err := h.guard.IssueToken(clientID, req.Name, target)
+			if err != nil {
+				resp, _ := h.errorResponse(err.Error())
+				return resp
+			}
+			resp, _ := h.successResponse(map[string]interface{}{
+				"confirmation_required": true,
+				"confirmation_token":    token,
+				"expires_in_seconds":    int(guard.TokenTTL.Seconds()),
+				"message":               fmt.Sprintf("Nothing was deleted. To delete %s, call %s again with confirmation_token %s within %v.", target, req.Name, token, guard.TokenTTL),
+			})
+			return resp
+		}
+		if err := h.guard.RedeemToken(clientID, req.Name, target, token); err != nil {
+			resp, _ := h.errorResponse(err.Error())
+			return resp
+		}
+	}
+
+	return nil
+}
+
+// isDeleteCall reports whether a tool call deletes something
+func isDeleteCall(req *protocol.CallToolRequest) bool {
+	if deleteTools[req.Name] {
+		return true
+	}
+	prune, _ := req.Arguments["prune"].(bool)
+	return req.Name == "check_assets" && prune
+}
+
+// deleteTarget describes what a delete call would delete, binding its
+// confirmation token to it. Chapter numbers shift as chapters are added,
+// moved and deleted, so a chapter is identified by the directory it is kept
+// in, looked up again when the token is redeemed.
+func (h *DocGenHandler) deleteTarget(params map[string]interface{}) string {
+	documentID, _ := params["document_id"].(string)
+	chapterNum, ok := params["chapter_number"].(float64)
+	if !ok {
+		return fmt.Sprintf("document %s", documentID)
+	}
+	if manifest, err := h.storage.LoadManifest(documentID); err == nil {
+		if dir, ok := manifest.ChapterDir(types.ChapterNumber(chapterNum)); ok {
+			return fmt.Sprintf("chapter %s of document %s", dir, documentID)
+		}
+	}
+	return fmt.Sprintf("chapter %v of document %s", chapterNum, documentID)
+}
@@ -6,13 +6,17 @@ import (
 	"fmt"
 	"strconv"
 	"strings"
+	"sync"
 
 	"github.com/gomcpgo/mcp/pkg/protocol"
 	"github.com/gomcpgo/docgen/pkg/config"
 	"github.com/gomcpgo/docgen/pkg/document"
 	"github.com/gomcpgo/docgen/pkg/export"
+	"github.com/gomcpgo/docgen/pkg/guard"
+	"github.com/gomcpgo/docgen/pkg/notary"
 	"github.com/gomcpgo/docgen/pkg/storage"
 	"github.com/gomcpgo/docgen/pkg/types"
+	"github.com/gomcpgo/docgen/pkg/usage"
 )
 
 // DocGenHandler implements the MCP handler for document generation
@@ -21,11 +25,23 @@ type DocGenHandler struct {
 	manager  *document.Manager
 	exporter *export.Exporter
 	storage  storage.Storage
+	usage    *usage.Tracker
+	ledger   *notary.Ledger
+	guard    *guard.Guard
+
+	// version is the server version recorded in export records
+	version string
+
+	// accessMu serializes changes to the access policy
+	accessMu sync.Mutex
 }
 
 // NewDocGenHandler creates a new document generation handler
 func NewDocGenHandler(cfg *config.Config) (*DocGenHandler, error) {
-	stor := storage.NewFileSystemStorage(cfg)
+	stor, err := storage.New(cfg)
+	if err != nil {
+		return nil, err
+	}
 	manager := document.NewManager(cfg, stor)
 	exporter := export.NewExporter(cfg)
 
@@ -34,6 +50,10 @@ func NewDocGenHandler(cfg *config.Config) (*DocGenHandler, error) {
 		manager:  manager,
 		exporter: exporter,
 		storage:  stor,
+		usage:    usage.NewTracker(cfg, stor),
+		ledger:   notary.NewLedger(cfg),
+		guard:    guard.NewGuard(cfg),
+		version:  "dev",
 	}, nil
 }
 
@@ -50,54 +70,235 @@ func (h *DocGenHandler) GetStorage() storage.Storage {
 	return h.storage
 }
 
+// SetVersion sets the server version recorded in export records
+func (h *DocGenHandler) SetVersion(version string) {
+	h.version = version
+}
+
 // CallTool executes a tool
-func (h *DocGenHandler) CallTool(ctx context.Context, req *protocol.CallToolRequest) (*protocol.CallToolResponse, error) {
+func (h *DocGenHandler) CallTool(ctx context.Context, req *protocol.CallToolRequest) (resp *protocol.CallToolResponse, err error) {
+	// Identify the caller for usage accounting and access control
+	clientID := h.usage.ClientID(ctx)
+	if err := h.authorize(clientID, req); err != nil {
+		return h.errorResponse(err.Error())
+	}
+	if err := h.checkLock(req); err != nil {
+		return h.errorResponse(err.Error())
+	}
+	if resp := h.checkGuardrails(clientID, req); resp != nil {
+		return resp, nil
+	}
+	// A delete counts toward the limit once it has succeeded
+	if isDeleteCall(req) {
+		defer func() {
+			if err == nil && resp != nil && !resp.IsError {
+				h.guard.RecordDelete(clientID)
+			}
+		}()
+	}
+	// Today's word count is recorded once per call that changed a document
+	if docID, ok := changesContent(req); ok {
+		defer func() {
+			if err == nil && resp != nil && !resp.IsError {
+				h.recordWordCount(docID)
+			}
+		}()
+	}
+
 	switch req.Name {
 	// Document operations
 	case "list_documents":
-		return h.handleListDocuments(req.Arguments)
+		return h.handleListDocuments(ctx, clientID, req.Arguments)
 	case "create_document":
-		return h.handleCreateDocument(req.Arguments)
+		return h.handleCreateDocument(clientID, req.Arguments)
+	case "create_sandbox_document":
+		return h.handleCreateSandboxDocument(clientID, req.Arguments)
 	case "get_document_structure":
-		return h.handleGetDocumentStructure(req.Arguments)
+		return h.handleGetDocumentStructure(ctx, req.Arguments)
+	case "get_toc":
+		return h.handleGetTOC(req.Arguments)
+	case "summarize_structure":
+		return h.handleSummarizeStructure(req.Arguments)
+	case "get_document_json":
+		return h.handleGetDocumentJSON(ctx, req.Arguments)
 	case "delete_document":
 		return h.handleDeleteDocument(req.Arguments)
+	case "archive_document":
+		return h.handleArchiveDocument(ctx, req.Arguments)
+	case "restore_document":
+		return h.handleRestoreDocument(ctx, clientID, req.Arguments)
+	case "rename_document":
+		return h.handleRenameDocument(ctx, req.Arguments)
+	case "tag_document":
+		return h.handleTagDocument(req.Arguments, false)
+	case "untag_document":
+		return h.handleTagDocument(req.Arguments, true)
 	case "configure_document":
 		return h.handleConfigureDocument(req.Arguments)
+	case "lock_document":
+		return h.handleLockDocument(req.Arguments)
+	case "unlock_document":
+		return h.handleUnlockDocument(req.Arguments)
+	case "add_author":
+		return h.handleAddAuthor(req.Arguments)
+	case "remove_author":
+		return h.handleRemoveAuthor(req.Arguments)
+	case "list_todos":
+		return h.handleListTodos(req.Arguments)
+	case "set_editorial_rules":
+		return h.handleSetEditorialRules(req.Arguments)
+	case "get_editorial_report":
+		return h.handleGetEditorialReport(req.Arguments)
+	case "save_house_style":
+		return h.handleSaveHouseStyle(req.Arguments)
+	case "check_house_style":
+		return h.handleCheckHouseStyle(req.Arguments)
+	case "set_writing_target":
+		return h.handleSetWritingTarget(req.Arguments)
+	case "writing_progress":
+		return h.handleWritingProgress(req.Arguments)
+	case "get_document_size":
+		return h.handleGetDocumentSize(ctx, req.Arguments)
+	case "get_storage_usage":
+		return h.handleGetStorageUsage(ctx, req.Arguments)
+	case "get_usage_report":
+		return h.handleGetUsageReport(req.Arguments)
+	case "set_document_role":
+		return h.handleSetDocumentRole(req.Arguments)
+	case "get_export_records":
+		return h.handleGetExportRecords(req.Arguments)
+	case "verify_export":
+		return h.handleVerifyExport(req.Arguments)
 
 	// Chapter operations
 	case "add_chapter":
-		return h.handleAddChapter(req.Arguments)
+		return h.handleAddChapter(ctx, req.Arguments)
 	case "update_chapter_metadata":
 		return h.handleUpdateChapterMetadata(req.Arguments)
+	case "set_front_matter":
+		return h.handleSetFrontMatter(req.Arguments)
+	case "set_status":
+		return h.handleSetStatus(req.Arguments)
+	case "set_epigraph":
+		return h.handleSetEpigraph(req.Arguments)
+	case "configure_chapter":
+		return h.handleConfigureChapter(req.Arguments)
+	case "build_outline":
+		return h.handleBuildOutline(ctx, req.Arguments)
+	case "include_file":
+		return h.handleIncludeFile(req.Arguments)
 	case "delete_chapter":
 		return h.handleDeleteChapter(req.Arguments)
 	case "move_chapter":
-		return h.handleMoveChapter(req.Arguments)
+		return h.handleMoveChapter(ctx, req.Arguments)
+	case "merge_chapters":
+		return h.handleMergeChapters(ctx, req.Arguments)
+	case "split_chapter":
+		return h.handleSplitChapter(ctx, req.Arguments)
+	case "set_part":
+		return h.handleSetPart(req.Arguments)
+	case "set_abbreviation":
+		return h.handleSetAbbreviation(req.Arguments)
+	case "list_abbreviations":
+		return h.handleListAbbreviations(ctx, req.Arguments)
 
 	// Section operations
 	case "add_section":
-		return h.handleAddSection(req.Arguments)
+		return h.handleAddSection(ctx, req.Arguments)
+	case "add_sections":
+		return h.handleAddSections(ctx, req.Arguments)
 	case "update_section":
-		return h.handleUpdateSection(req.Arguments)
+		return h.handleUpdateSection(ctx, req.Arguments)
+	case "append_to_section":
+		return h.handleAddToSection(ctx, req.Arguments, false)
+	case "insert_into_section":
+		return h.handleAddToSection(ctx, req.Arguments, true)
+	case "insert_page_break":
+		return h.handleInsertPageBreak(ctx, req.Arguments)
 	case "delete_section":
 		return h.handleDeleteSection(req.Arguments)
+	case "insert_citation":
+		return h.handleInsertCitation(ctx, req.Arguments)
+	case "get_chapter_content":
+		return h.handleGetChapterContent(ctx, req.Arguments)
 	case "get_section_content":
 		return h.handleGetSectionContent(req.Arguments)
+	case "get_section_blocks":
+		return h.handleGetSectionBlocks(req.Arguments)
+	case "update_block":
+		return h.handleUpdateBlock(ctx, req.Arguments)
+	case "insert_block":
+		return h.handleInsertBlock(ctx, req.Arguments)
+	case "delete_block":
+		return h.handleDeleteBlock(ctx, req.Arguments)
+	case "snapshot_chapter":
+		return h.handleSnapshotChapter(req.Arguments)
+	case "list_chapter_revisions":
+		return h.handleListChapterRevisions(req.Arguments)
+	case "preview_chapter":
+		return h.handlePreviewChapter(ctx, req.Arguments)
+	case "preview_chapter_diff":
+		return h.handlePreviewChapterDiff(ctx, req.Arguments)
+	case "check_consistency":
+		return h.handleCheckConsistency(ctx, req.Arguments)
+	case "add_content":
+		return h.handleAddContent(ctx, req.Arguments)
+	case "save_section_template":
+		return h.handleSaveSectionTemplate(req.Arguments)
+	case "apply_section_template":
+		return h.handleApplySectionTemplate(ctx, req.Arguments)
 
 	// Image operations
 	case "add_image":
 		return h.handleAddImage(req.Arguments)
+	case "add_figure_grid":
+		return h.handleAddFigureGrid(req.Arguments)
+	case "add_listing":
+		return h.handleAddListing(req.Arguments)
 	case "update_image_caption":
 		return h.handleUpdateImageCaption(req.Arguments)
+	case "update_image_properties":
+		return h.handleUpdateImageProperties(req.Arguments)
+	case "set_figure_anchor":
+		return h.handleSetFigureAnchor(req.Arguments)
+	case "create_table_from_csv":
+		return h.handleCreateTableFromCSV(req.Arguments)
+	case "update_table_data":
+		return h.handleUpdateTableData(req.Arguments)
 	case "delete_image":
 		return h.handleDeleteImage(req.Arguments)
+	case "annotate_image":
+		return h.handleAnnotateImage(ctx, req.Arguments)
+	case "check_assets":
+		return h.handleCheckAssets(ctx, req.Arguments)
+	case "check_figures_tables":
+		return h.handleCheckFiguresTables(req.Arguments)
+	case "migrate_figure_ids":
+		return h.handleMigrateFigureIDs(ctx, req.Arguments)
 
 	// Export operations
 	case "export_document":
-		return h.handleExportDocument(req.Arguments)
+		return h.handleExportDocument(ctx, clientID, req.Arguments)
+	case "compile_volume":
+		return h.handleCompileVolume(ctx, clientID, req.Arguments)
+	case "estimate_pages":
+		return h.handleEstimatePages(ctx, req.Arguments)
+	case "reimport_docx_feedback":
+		return h.handleReimportDocxFeedback(ctx, req.Arguments)
 	case "validate_document":
-		return h.handleValidateDocument(req.Arguments)
+		return h.handleValidateDocument(ctx, req.Arguments)
+	case "get_export_log":
+		return h.handleGetExportLog(req.Arguments)
+	case "list_exports":
+		return h.handleListExports(req.Arguments)
+	case "delete_export":
+		return h.handleDeleteExport(req.Arguments)
+	case "resolve_style":
+		return h.handleResolveStyle(req.Arguments)
+	case "add_font":
+		return h.handleAddFont(ctx, req.Arguments)
+	case "list_fonts":
+		return h.handleListFonts(req.Arguments)
 
 	default:
 		return &protocol.CallToolResponse{
@@ -141,6 +342,14 @@ func (h *DocGenHandler) getChapterNumber(params map[string]interface{}) (types.C
 	return chapterNum, nil
 }
 
+func (h *DocGenHandler) getSectionNumber(params map[string]interface{}) (types.SectionNumber, error) {
+	sectionNumStr, ok := params["section_number"].(string)
+	if !ok || sectionNumStr == "" {
+		return nil, fmt.Errorf("section_number parameter is required")
+	}
+	return h.parseSectionNumber(sectionNumStr)
+}
+
 func (h *DocGenHandler) parseSectionNumber(sectionNumStr string) (types.SectionNumber, error) {
 	parts := strings.Split(sectionNumStr, ".")
 	if len(parts) < 2 {
@@ -186,5 +395,6 @@ func (h *DocGenHandler) errorResponse(message string) (*protocol.CallToolRespons
 				Text: fmt.Sprintf("Error: %s", message),
 			},
 		},
+		IsError: true,
 	}, nil
 }
\ No newline at end of file
@@ -1,15 +1,23 @@
 package handler
 
 import (
+	"bytes"
+	"context"
+	"encoding/json"
 	"fmt"
+	"log"
+	"strings"
+
+	"gopkg.in/yaml.v3"
 
 	"github.com/gomcpgo/mcp/pkg/protocol"
+	"github.com/gomcpgo/docgen/pkg/export"
 	"github.com/gomcpgo/docgen/pkg/types"
 )
 
 // Chapter operations
 
-func (h *DocGenHandler) handleAddChapter(params map[string]interface{}) (*protocol.CallToolResponse, error) {
+func (h *DocGenHandler) handleAddChapter(ctx context.Context, params map[string]interface{}) (*protocol.CallToolResponse, error) {
 	// Get document ID
 	docID, err := h.getDocumentID(params)
 	if err != nil {
@@ -30,15 +38,19 @@ func (h *DocGenHandler) handleAddChapter(params map[string]interface{}) (*protoc
 	}
 
 	// Add the chapter
-	chapterNum, err := h.manager.AddChapter(docID, title, position)
+	chapterNum, err := h.manager.AddChapter(ctx, docID, title, position)
 	if err != nil {
 		return h.errorResponse(fmt.Sprintf("Failed to add chapter: %v", err))
 	}
 
+	message := fmt.Sprintf("Chapter '%s' added as chapter %d", title, chapterNum)
+	if position != nil && *position == int(chapterNum) {
+		message += "; later chapters, their sections, figures and tables were renumbered"
+	}
 	return h.successResponse(map[string]interface{}{
 		"document_id":    docID,
 		"chapter_number": int(chapterNum),
-		"message":        fmt.Sprintf("Chapter '%s' added as chapter %d", title, chapterNum),
+		"message":        message,
 	})
 }
 
@@ -75,6 +87,297 @@ func (h *DocGenHandler) handleUpdateChapterMetadata(params map[string]interface{
 	})
 }
 
+func (h *DocGenHandler) handleSetFrontMatter(params map[string]interface{}) (*protocol.CallToolResponse, error) {
+	docID, err := h.getDocumentID(params)
+	if err != nil {
+		return h.errorResponse(fmt.Sprintf("Invalid document_id: %v", err))
+	}
+	chapterNum, err := h.getChapterNumber(params)
+	if err != nil {
+		return h.errorResponse(fmt.Sprintf("Invalid chapter_number: %v", err))
+	}
+
+	// Get section number (optional, the chapter's own front matter otherwise)
+	var sectionNum types.SectionNumber
+	target := fmt.Sprintf("chapter %d", chapterNum)
+	if sectionNumStr, ok := params["section_number"].(string); ok && sectionNumStr != "" {
+		sectionNum, err = h.parseSectionNumber(sectionNumStr)
+		if err != nil {
+			return h.errorResponse(fmt.Sprintf("Invalid section_number format: %v", err))
+		}
+		target = "section " + sectionNumStr
+	}
+
+	values, ok := params["front_matter"].(map[string]interface{})
+	if !ok {
+		return h.errorResponse("front_matter parameter is required")
+	}
+	replace, _ := params["replace"].(bool)
+
+	frontMatter, err := h.manager.SetFrontMatter(docID, chapterNum, sectionNum, values, replace)
+	if err != nil {
+		return h.errorResponse(fmt.Sprintf("Failed to set front matter: %v", err))
+	}
+
+	return h.successResponse(map[string]interface{}{
+		"document_id":    docID,
+		"chapter_number": chapterNum,
+		"front_matter":   frontMatter,
+		"message":        fmt.Sprintf("Front matter of %s updated", target),
+	})
+}
+
+func (h *DocGenHandler) handleSetStatus(params map[string]interface{}) (*protocol.CallToolResponse, error) {
+	docID, err := h.getDocumentID(params)
+	if err != nil {
+		return h.errorResponse(fmt.Sprintf("Invalid document_id: %v", err))
+	}
+	chapterNum, err := h.getChapterNumber(params)
+	if err != nil {
+		return h.errorResponse(fmt.Sprintf("Invalid chapter_number: %v", err))
+	}
+
+	// Get section number (optional, the chapter's own status otherwise)
+	var sectionNum types.SectionNumber
+	target := fmt.Sprintf("chapter %d", chapterNum)
+	if sectionNumStr, ok := params["section_number"].(string); ok && sectionNumStr != "" {
+		sectionNum, err = h.parseSectionNumber(sectionNumStr)
+		if err != nil {
+			return h.errorResponse(fmt.Sprintf("Invalid section_number format: %v", err))
+		}
+		target = "section " + sectionNumStr
+	}
+
+	statusStr, ok := params["status"].(string)
+	if !ok {
+		return h.errorResponse("status parameter is required")
+	}
+	status := types.WorkflowStatus(statusStr)
+
+	if err := h.manager.SetStatus(docID, chapterNum, sectionNum, status); err != nil {
+		return h.errorResponse(fmt.Sprintf("Failed to set status: %v", err))
+	}
+
+	message := fmt.Sprintf("Status of %s set to %s", target, status)
+	if status == "" {
+		message = fmt.Sprintf("Status of %s cleared", target)
+	}
+	return h.successResponse(map[string]interface{}{
+		"document_id":    docID,
+		"chapter_number": chapterNum,
+		"status":         status,
+		"message":        message,
+	})
+}
+
+func (h *DocGenHandler) handleSetEpigraph(params map[string]interface{}) (*protocol.CallToolResponse, error) {
+	docID, err := h.getDocumentID(params)
+	if err != nil {
+		return h.errorResponse(fmt.Sprintf("Invalid document_id: %v", err))
+	}
+	chapterNum, err := h.getChapterNumber(params)
+	if err != nil {
+		return h.errorResponse(fmt.Sprintf("Invalid chapter_number: %v", err))
+	}
+
+	// An empty or missing text removes the epigraph
+	text, _ := params["text"].(string)
+	attribution, _ := params["attribution"].(string)
+	epigraph := &types.Epigraph{Text: text, Attribution: attribution}
+
+	if err := h.manager.SetEpigraph(docID, chapterNum, epigraph); err != nil {
+		return h.errorResponse(fmt.Sprintf("Failed to set epigraph: %v", err))
+	}
+
+	message := fmt.Sprintf("Epigraph of chapter %d set", chapterNum)
+	if strings.TrimSpace(text) == "" {
+		message = fmt.Sprintf("Epigraph of chapter %d removed", chapterNum)
+	}
+	return h.successResponse(map[string]interface{}{
+		"document_id":    docID,
+		"chapter_number": chapterNum,
+		"message":        message,
+	})
+}
+
+func (h *DocGenHandler) handleConfigureChapter(params map[string]interface{}) (*protocol.CallToolResponse, error) {
+	// Get document ID
+	docID, err := h.getDocumentID(params)
+	if err != nil {
+		return h.errorResponse(fmt.Sprintf("Invalid document_id: %v", err))
+	}
+
+	// Get chapter number
+	chapterNum, err := h.getChapterNumber(params)
+	if err != nil {
+		return h.errorResponse(fmt.Sprintf("Invalid chapter_number: %v", err))
+	}
+
+	options := &types.ChapterPandocOptions{}
+
+	// Get variables (optional)
+	if variablesRaw, ok := params["variables"].(map[string]interface{}); ok {
+		options.Variables = make(map[string]string)
+		for key, value := range variablesRaw {
+			switch v := value.(type) {
+			case string:
+				options.Variables[key] = v
+			default:
+				options.Variables[key] = fmt.Sprintf("%v", v)
+			}
+		}
+	}
+
+	// Get class options (optional)
+	if classOptionsRaw, ok := params["classoptions"].([]interface{}); ok {
+		for _, optionRaw := range classOptionsRaw {
+			option, ok := optionRaw.(string)
+			if !ok || option == "" {
+				return h.errorResponse("classoptions must be an array of non-empty strings")
+			}
+			options.ClassOptions = append(options.ClassOptions, option)
+		}
+	}
+
+	// Get landscape (optional)
+	if landscape, ok := params["landscape"].(bool); ok {
+		options.Landscape = landscape
+	}
+
+	// Get markdown extensions (optional)
+	if markdownParams, ok := params["markdown"].(map[string]interface{}); ok && len(markdownParams) > 0 {
+		options.Markdown, err = h.markdownSettings(markdownParams, "markdown")
+		if err != nil {
+			return h.errorResponse(err.Error())
+		}
+	}
+
+	// An empty configuration clears the chapter options
+	if len(options.Variables) == 0 && len(options.ClassOptions) == 0 && !options.Landscape && len(options.Markdown) == 0 {
+		options = nil
+	}
+
+	err = h.manager.ConfigureChapter(docID, chapterNum, options)
+	if err != nil {
+		return h.errorResponse(fmt.Sprintf("Failed to configure chapter: %v", err))
+	}
+
+	return h.successResponse(map[string]interface{}{
+		"document_id":    docID,
+		"chapter_number": int(chapterNum),
+		"pandoc_options": options,
+		"message":        fmt.Sprintf("Chapter %d export options updated", chapterNum),
+	})
+}
+
+func (h *DocGenHandler) handleBuildOutline(ctx context.Context, params map[string]interface{}) (*protocol.CallToolResponse, error) {
+	docID, err := h.getDocumentID(params)
+	if err != nil {
+		return h.errorResponse(fmt.Sprintf("Invalid document_id: %v", err))
+	}
+	outline, err := parseOutline(params["outline"])
+	if err != nil {
+		return h.errorResponse(fmt.Sprintf("Invalid outline: %v", err))
+	}
+
+	chapters, sections, err := h.manager.BuildOutline(ctx, docID, outline)
+	if err != nil {
+		// Report what was created before the failure, so it can be finished or removed
+		return h.errorResponse(fmt.Sprintf("Failed to build outline after adding %d chapter(s) and %d section(s): %v", len(chapters), sections, err))
+	}
+
+	return h.successResponse(map[string]interface{}{
+		"document_id": docID,
+		"chapters":    chapters,
+		"sections":    sections,
+		"message":     fmt.Sprintf("Added %d chapter(s) and %d section(s); sections without content hold TODO placeholders", len(chapters), sections),
+	})
+}
+
+// parseOutline reads an outline given as an array of chapters or as JSON or
+// YAML text. Unknown fields are rejected so that misspelt keys aren't lost.
+func parseOutline(value interface{}) ([]types.OutlineChapter, error) {
+	var data []byte
+	switch v := value.(type) {
+	case nil:
+		return nil, fmt.Errorf("outline parameter is required")
+	case string:
+		data = []byte(v)
+	default:
+		encoded, err := json.Marshal(v)
+		if err != nil {
+			return nil, err
+		}
+		data = encoded
+	}
+
+	var outline []types.OutlineChapter
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.DisallowUnknownFields()
+	jsonErr := decoder.Decode(&outline)
+	if jsonErr == nil {
+		return outline, nil
+	}
+	if _, ok := value.(string); !ok {
+		return nil, jsonErr
+	}
+
+	outline = nil
+	yamlDecoder := yaml.NewDecoder(bytes.NewReader(data))
+	yamlDecoder.KnownFields(true)
+	if err := yamlDecoder.Decode(&outline); err != nil {
+		return nil, fmt.Errorf("not valid JSON or YAML: JSON error: %v, YAML error: %v", jsonErr, err)
+	}
+	return outline, nil
+}
+
+func (h *DocGenHandler) handleIncludeFile(params map[string]interface{}) (*protocol.CallToolResponse, error) {
+	docID, err := h.getDocumentID(params)
+	if err != nil {
+		return h.errorResponse(fmt.Sprintf("Invalid document_id: %v", err))
+	}
+	chapterNum, err := h.getChapterNumber(params)
+	if err != nil {
+		return h.errorResponse(fmt.Sprintf("Invalid chapter_number: %v", err))
+	}
+
+	// Without a section number the file is included in the chapter itself
+	var sectionNum types.SectionNumber
+	if sectionNumStr, ok := params["section_number"].(string); ok && sectionNumStr != "" {
+		sectionNum, err = h.parseSectionNumber(sectionNumStr)
+		if err != nil {
+			return h.errorResponse(fmt.Sprintf("Invalid section_number format: %v", err))
+		}
+	}
+
+	path, ok := params["path"].(string)
+	if !ok {
+		return h.errorResponse("path parameter is required (an empty path stops including a file)")
+	}
+	path = strings.TrimSpace(path)
+
+	resolved, err := h.manager.SetIncludeFile(docID, chapterNum, sectionNum, path)
+	if err != nil {
+		return h.errorResponse(fmt.Sprintf("Failed to include file: %v", err))
+	}
+
+	target := fmt.Sprintf("Chapter %d", chapterNum)
+	if len(sectionNum) > 0 {
+		target = fmt.Sprintf("Section %s", sectionNum.String())
+	}
+	if path == "" {
+		return h.successResponse(map[string]interface{}{
+			"document_id": docID,
+			"message":     fmt.Sprintf("%s no longer includes a file", target),
+		})
+	}
+	return h.successResponse(map[string]interface{}{
+		"document_id":   docID,
+		"included_file": resolved,
+		"message":       fmt.Sprintf("%s now includes %s; it is read again whenever the chapter is built or exported", target, resolved),
+	})
+}
+
 func (h *DocGenHandler) handleDeleteChapter(params map[string]interface{}) (*protocol.CallToolResponse, error) {
 	// Get document ID
 	docID, err := h.getDocumentID(params)
@@ -101,7 +404,7 @@ func (h *DocGenHandler) handleDeleteChapter(params map[string]interface{}) (*pro
 	})
 }
 
-func (h *DocGenHandler) handleMoveChapter(params map[string]interface{}) (*protocol.CallToolResponse, error) {
+func (h *DocGenHandler) handleMoveChapter(ctx context.Context, params map[string]interface{}) (*protocol.CallToolResponse, error) {
 	// Get document ID
 	docID, err := h.getDocumentID(params)
 	if err != nil {
@@ -133,17 +436,449 @@ func (h *DocGenHandler) handleMoveChapter(params map[string]interface{}) (*proto
 	}
 
 	// Move the chapter
-	err = h.manager.MoveChapter(docID, fromPos, toPos)
+	result, err := h.manager.MoveChapter(ctx, docID, fromPos, toPos)
 	if err != nil {
 		return h.errorResponse(fmt.Sprintf("Failed to move chapter: %v", err))
 	}
 
 	return h.successResponse(map[string]interface{}{
-		"document_id":    docID,
+		"document_id":   docID,
 		"from_position": int(fromPos),
 		"to_position":   int(toPos),
-		"message":       fmt.Sprintf("Chapter moved from position %d to %d successfully", fromPos, toPos),
+		"renamed_ids":   result.IDs,
+		"message":       fmt.Sprintf("Chapter moved from position %d to %d successfully; the chapters in between were renumbered", fromPos, toPos),
+	})
+}
+
+func (h *DocGenHandler) handleMergeChapters(ctx context.Context, params map[string]interface{}) (*protocol.CallToolResponse, error) {
+	// Get document ID
+	docID, err := h.getDocumentID(params)
+	if err != nil {
+		return h.errorResponse(fmt.Sprintf("Invalid document_id: %v", err))
+	}
+
+	// Get target chapter
+	intoFloat, ok := params["target_chapter"].(float64)
+	if !ok || intoFloat < 1 {
+		return h.errorResponse("target_chapter parameter is required and must be at least 1")
+	}
+	into := types.ChapterNumber(intoFloat)
+
+	// Get source chapter (defaults to the chapter after the target)
+	from := into + 1
+	if fromFloat, ok := params["source_chapter"].(float64); ok {
+		if fromFloat < 1 {
+			return h.errorResponse("source_chapter must be at least 1")
+		}
+		from = types.ChapterNumber(fromFloat)
+	}
+
+	if into == from {
+		return h.errorResponse("target_chapter and source_chapter cannot be the same")
+	}
+
+	// Merge the chapters
+	result, err := h.manager.MergeChapters(ctx, docID, into, from)
+	if err != nil {
+		return h.errorResponse(fmt.Sprintf("Failed to merge chapters: %v", err))
+	}
+
+	return h.successResponse(map[string]interface{}{
+		"document_id":         docID,
+		"chapter_number":      int(result.Chapter),
+		"renumbered_sections": result.Sections,
+		"renamed_ids":         result.IDs,
+		"message":             fmt.Sprintf("Chapter %d merged into chapter %d, now chapter %d", from, into, result.Chapter),
+	})
+}
+
+func (h *DocGenHandler) handleSplitChapter(ctx context.Context, params map[string]interface{}) (*protocol.CallToolResponse, error) {
+	// Get document ID
+	docID, err := h.getDocumentID(params)
+	if err != nil {
+		return h.errorResponse(fmt.Sprintf("Invalid document_id: %v", err))
+	}
+
+	// Get chapter number
+	chapterNum, err := h.getChapterNumber(params)
+	if err != nil {
+		return h.errorResponse(fmt.Sprintf("Invalid chapter_number: %v", err))
+	}
+
+	// Get section number (optional, defaults to the most even split)
+	var sectionNum types.SectionNumber
+	sectionNumStr, _ := params["section_number"].(string)
+	if sectionNumStr != "" {
+		sectionNum, err = h.parseSectionNumber(sectionNumStr)
+		if err != nil {
+			return h.errorResponse(fmt.Sprintf("Invalid section_number: %v", err))
+		}
+	} else {
+		sectionNum, err = h.manager.BalancedSplitPoint(docID, chapterNum)
+		if err != nil {
+			return h.errorResponse(fmt.Sprintf("Failed to split chapter: %v", err))
+		}
+		sectionNumStr = sectionNum.String()
+	}
+
+	// Get title (optional)
+	title, _ := params["title"].(string)
+
+	// Split the chapter
+	result, err := h.manager.SplitChapter(ctx, docID, chapterNum, sectionNum, title)
+	if err != nil {
+		return h.errorResponse(fmt.Sprintf("Failed to split chapter: %v", err))
+	}
+
+	return h.successResponse(map[string]interface{}{
+		"document_id":         docID,
+		"chapter_number":      int(result.Chapter),
+		"renumbered_sections": result.Sections,
+		"renamed_ids":         result.IDs,
+		"message":             fmt.Sprintf("Chapter %d split at section %s into new chapter %d", chapterNum, sectionNumStr, result.Chapter),
 	})
 }
 
+func (h *DocGenHandler) handleSetPart(params map[string]interface{}) (*protocol.CallToolResponse, error) {
+	// Get document ID
+	docID, err := h.getDocumentID(params)
+	if err != nil {
+		return h.errorResponse(fmt.Sprintf("Invalid document_id: %v", err))
+	}
 
+	// Get first chapter
+	firstFloat, ok := params["first_chapter"].(float64)
+	if !ok || firstFloat < 1 {
+		return h.errorResponse("first_chapter parameter is required and must be at least 1")
+	}
+	first := types.ChapterNumber(firstFloat)
+
+	// Get title (empty removes the part)
+	title, _ := params["title"].(string)
+	title = strings.TrimSpace(title)
+	if title == "" {
+		parts, err := h.manager.RemovePart(docID, first)
+		if err != nil {
+			return h.errorResponse(fmt.Sprintf("Failed to remove part: %v", err))
+		}
+		return h.successResponse(map[string]interface{}{
+			"document_id": docID,
+			"parts":       parts,
+			"message":     fmt.Sprintf("Removed the part starting at chapter %d", first),
+		})
+	}
+
+	// Get last chapter (defaults to the first)
+	last := first
+	if lastFloat, ok := params["last_chapter"].(float64); ok {
+		last = types.ChapterNumber(lastFloat)
+	}
+
+	parts, err := h.manager.SetPart(docID, types.Part{
+		Title:        title,
+		FirstChapter: first,
+		LastChapter:  last,
+	})
+	if err != nil {
+		return h.errorResponse(fmt.Sprintf("Failed to set part: %v", err))
+	}
+
+	return h.successResponse(map[string]interface{}{
+		"document_id": docID,
+		"parts":       parts,
+		"message":     fmt.Sprintf("Part %q now groups chapters %d-%d", title, first, last),
+	})
+}
+
+func (h *DocGenHandler) handleGetChapterContent(ctx context.Context, params map[string]interface{}) (*protocol.CallToolResponse, error) {
+	// Get document ID
+	docID, err := h.getDocumentID(params)
+	if err != nil {
+		return h.errorResponse(fmt.Sprintf("Invalid document_id: %v", err))
+	}
+
+	// Get chapter number
+	chapterNum, err := h.getChapterNumber(params)
+	if err != nil {
+		return h.errorResponse(fmt.Sprintf("Invalid chapter_number: %v", err))
+	}
+
+	// Get format (optional)
+	format := "markdown"
+	if formatStr, ok := params["format"].(string); ok && formatStr != "" {
+		format = formatStr
+	}
+	if format != "markdown" && format != "html" {
+		return h.errorResponse("format must be markdown or html")
+	}
+
+	chapter, err := h.manager.GetChapter(docID, chapterNum)
+	if err != nil {
+		return h.errorResponse(fmt.Sprintf("Failed to get chapter: %v", err))
+	}
+
+	result := map[string]interface{}{
+		"document_id":    docID,
+		"chapter_number": chapterNum,
+		"title":          chapter.Title,
+		"format":         format,
+	}
+
+	if format == "markdown" {
+		if err := h.manager.RebuildChapterMarkdown(docID, chapterNum); err != nil {
+			return h.errorResponse(fmt.Sprintf("Failed to rebuild chapter: %v", err))
+		}
+		content, err := h.storage.LoadChapterContent(string(docID), int(chapterNum))
+		if err != nil {
+			return h.errorResponse(fmt.Sprintf("Failed to load chapter content: %v", err))
+		}
+		result["content"] = content
+		return h.successResponse(result)
+	}
+
+	manifest, err := h.manager.GetDocumentStructure(docID)
+	if err != nil {
+		return h.errorResponse(fmt.Sprintf("Failed to load document: %v", err))
+	}
+	if err := h.manager.SyncDocument(ctx, docID); err != nil {
+		return h.errorResponse(fmt.Sprintf("Failed to load document: %v", err))
+	}
+	content, approximate, err := h.exporter.RenderChapterHTML(ctx, string(docID), manifest, chapterNum, h.markdownProfile(docID), h.manager.RebuildChapterMarkdown)
+	if err != nil {
+		return h.errorResponse(fmt.Sprintf("Failed to render chapter: %v", err))
+	}
+	result["content"] = content
+	result["approximate"] = approximate
+	if approximate {
+		result["note"] = export.ApproximateNotice
+	}
+
+	return h.successResponse(result)
+}
+
+func (h *DocGenHandler) handleSnapshotChapter(params map[string]interface{}) (*protocol.CallToolResponse, error) {
+	docID, err := h.getDocumentID(params)
+	if err != nil {
+		return h.errorResponse(fmt.Sprintf("Invalid document_id: %v", err))
+	}
+	chapterNum, err := h.getChapterNumber(params)
+	if err != nil {
+		return h.errorResponse(fmt.Sprintf("Invalid chapter_number: %v", err))
+	}
+	label, _ := params["label"].(string)
+
+	revision, created, err := h.manager.SnapshotChapter(docID, chapterNum, label)
+	if err != nil {
+		return h.errorResponse(fmt.Sprintf("Failed to snapshot chapter: %v", err))
+	}
+
+	message := fmt.Sprintf("Revision %d of chapter %d taken", revision.ID, chapterNum)
+	if !created {
+		message = fmt.Sprintf("Chapter %d is unchanged since revision %d", chapterNum, revision.ID)
+	}
+	return h.successResponse(map[string]interface{}{
+		"document_id":    docID,
+		"chapter_number": chapterNum,
+		"revision":       revision,
+		"message":        message,
+	})
+}
+
+func (h *DocGenHandler) handleListChapterRevisions(params map[string]interface{}) (*protocol.CallToolResponse, error) {
+	docID, err := h.getDocumentID(params)
+	if err != nil {
+		return h.errorResponse(fmt.Sprintf("Invalid document_id: %v", err))
+	}
+	chapterNum, err := h.getChapterNumber(params)
+	if err != nil {
+		return h.errorResponse(fmt.Sprintf("Invalid chapter_number: %v", err))
+	}
+
+	revisions, err := h.manager.ListChapterRevisions(docID, chapterNum)
+	if err != nil {
+		return h.errorResponse(fmt.Sprintf("Failed to list revisions: %v", err))
+	}
+	return h.successResponse(map[string]interface{}{
+		"document_id":    docID,
+		"chapter_number": chapterNum,
+		"revisions":      revisions,
+		"message":        fmt.Sprintf("Chapter %d has %d revision(s)", chapterNum, len(revisions)),
+	})
+}
+
+func (h *DocGenHandler) handlePreviewChapter(ctx context.Context, params map[string]interface{}) (*protocol.CallToolResponse, error) {
+	docID, err := h.getDocumentID(params)
+	if err != nil {
+		return h.errorResponse(fmt.Sprintf("Invalid document_id: %v", err))
+	}
+	chapterNum, err := h.getChapterNumber(params)
+	if err != nil {
+		return h.errorResponse(fmt.Sprintf("Invalid chapter_number: %v", err))
+	}
+	format := types.ExportFormatHTML
+	if val, ok := params["format"].(string); ok && val != "" {
+		format = types.ExportFormat(val)
+	}
+	if format != types.ExportFormatHTML && format != types.ExportFormatPDF {
+		return h.errorResponse("format must be html or pdf")
+	}
+	styleName, _ := params["style_name"].(string)
+	unstyled, _ := params["unstyled"].(bool)
+	if unstyled && strings.TrimSpace(styleName) != "" {
+		return h.errorResponse("style_name can't be combined with unstyled")
+	}
+
+	manifest, err := h.manager.GetDocumentStructure(docID)
+	if err != nil {
+		return h.errorResponse(fmt.Sprintf("Failed to load document: %v", err))
+	}
+	if _, ok := manifest.ChapterDir(chapterNum); !ok {
+		return h.errorResponse(fmt.Sprintf("Chapter %d not found", chapterNum))
+	}
+	if err := h.manager.SyncDocument(ctx, docID); err != nil {
+		return h.errorResponse(fmt.Sprintf("Failed to load document: %v", err))
+	}
+
+	// Previews are styled as exports are unless asked not to be
+	var style *types.Style
+	if !unstyled {
+		if err := h.storage.EnsureDefaultStyle(); err != nil {
+			log.Printf("[DOCGEN HANDLER] Warning: Failed to ensure default style: %v", err)
+		}
+		style, _, err = h.resolveStyle(strings.TrimSpace(styleName))
+		if err != nil {
+			return h.errorResponse(fmt.Sprintf("Failed to load style: %v", err))
+		}
+	}
+	pandocConfig, _ := h.storage.LoadPandocConfig(string(docID))
+
+	outputPath, err := h.exporter.PreviewChapter(ctx, string(docID), manifest, chapterNum, format, style, pandocConfig, h.manager.RebuildChapterMarkdown)
+	if err != nil {
+		return h.errorResponse(fmt.Sprintf("Failed to preview chapter: %v", err))
+	}
+	return h.successResponse(map[string]interface{}{
+		"document_id":    docID,
+		"chapter_number": chapterNum,
+		"format":         format,
+		"output_path":    outputPath,
+		"styled":         style != nil,
+		"message":        fmt.Sprintf("Chapter %d previewed at %s", chapterNum, outputPath),
+	})
+}
+
+func (h *DocGenHandler) handlePreviewChapterDiff(ctx context.Context, params map[string]interface{}) (*protocol.CallToolResponse, error) {
+	docID, err := h.getDocumentID(params)
+	if err != nil {
+		return h.errorResponse(fmt.Sprintf("Invalid document_id: %v", err))
+	}
+	chapterNum, err := h.getChapterNumber(params)
+	if err != nil {
+		return h.errorResponse(fmt.Sprintf("Invalid chapter_number: %v", err))
+	}
+
+	// The latest revision unless one is chosen
+	revisionID := 0
+	if val, ok := params["revision"].(float64); ok {
+		if val < 1 {
+			return h.errorResponse("revision must be at least 1")
+		}
+		revisionID = int(val)
+	}
+	mode := types.DiffModeInline
+	if val, ok := params["mode"].(string); ok && val != "" {
+		mode = types.DiffMode(val)
+	}
+	if err := mode.Validate(); err != nil {
+		return h.errorResponse(err.Error())
+	}
+
+	revision, err := h.manager.GetChapterRevision(docID, chapterNum, revisionID)
+	if err != nil {
+		return h.errorResponse(fmt.Sprintf("Failed to load revision: %v", err))
+	}
+	if err := h.manager.SyncDocument(ctx, docID); err != nil {
+		return h.errorResponse(fmt.Sprintf("Failed to load document: %v", err))
+	}
+	manifest, err := h.manager.GetDocumentStructure(docID)
+	if err != nil {
+		return h.errorResponse(fmt.Sprintf("Failed to load document: %v", err))
+	}
+
+	diff, err := h.exporter.DiffChapter(ctx, string(docID), manifest, chapterNum, revision, mode, h.markdownProfile(docID), h.manager.RebuildChapterMarkdown)
+	if err != nil {
+		return h.errorResponse(fmt.Sprintf("Failed to diff chapter: %v", err))
+	}
+
+	result := map[string]interface{}{
+		"document_id":    docID,
+		"chapter_number": chapterNum,
+		"output_path":    diff.OutputPath,
+		"mode":           diff.Mode,
+		"revision":       diff.Revision,
+		"added":          diff.Added,
+		"removed":        diff.Removed,
+		"unchanged":      diff.Unchanged,
+		"approximate":    diff.Approximate,
+		"message":        fmt.Sprintf("Chapter %d since revision %d: %d block(s) added, %d removed", chapterNum, diff.Revision, diff.Added, diff.Removed),
+	}
+	if diff.Approximate {
+		result["note"] = export.ApproximateNotice
+	}
+	return h.successResponse(result)
+}
+
+func (h *DocGenHandler) handleCheckConsistency(ctx context.Context, params map[string]interface{}) (*protocol.CallToolResponse, error) {
+	// Get document ID
+	docID, err := h.getDocumentID(params)
+	if err != nil {
+		return h.errorResponse(fmt.Sprintf("Invalid document_id: %v", err))
+	}
+
+	// Get chapter number (optional, 0 checks every chapter)
+	var chapterNum types.ChapterNumber
+	if _, ok := params["chapter_number"]; ok {
+		chapterNum, err = h.getChapterNumber(params)
+		if err != nil {
+			return h.errorResponse(fmt.Sprintf("Invalid chapter_number: %v", err))
+		}
+	}
+	rebuild, _ := params["rebuild"].(bool)
+
+	issues, err := h.manager.CheckConsistency(ctx, docID, chapterNum)
+	if err != nil {
+		return h.errorResponse(fmt.Sprintf("Failed to check consistency: %v", err))
+	}
+
+	message := "Chapter markdown matches the sections"
+	if len(issues) > 0 {
+		message = fmt.Sprintf("Found %d drift issue(s) between chapter markdown and the sections", len(issues))
+	}
+	result := map[string]interface{}{
+		"document_id": docID,
+		"issues":      issues,
+	}
+
+	// Rebuilding fixes chapter.md, not the section files themselves
+	if rebuild {
+		rebuilt := []types.ChapterNumber{}
+		for _, issue := range issues {
+			if issue.Problem == types.ConsistencyMissingSectionFile || issue.Problem == types.ConsistencyOrphanSectionFile {
+				continue
+			}
+			if len(rebuilt) > 0 && rebuilt[len(rebuilt)-1] == issue.Chapter {
+				continue
+			}
+			if err := h.manager.RebuildChapterMarkdown(docID, issue.Chapter); err != nil {
+				return h.errorResponse(fmt.Sprintf("Failed to rebuild chapter %d: %v", issue.Chapter, err))
+			}
+			rebuilt = append(rebuilt, issue.Chapter)
+		}
+		result["rebuilt_chapters"] = rebuilt
+		if len(rebuilt) > 0 {
+			message += fmt.Sprintf("; rebuilt chapter markdown for %d chapter(s)", len(rebuilt))
+		}
+	}
+
+	result["message"] = message
+	return h.successResponse(result)
+}
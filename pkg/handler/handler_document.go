@@ -1,15 +1,21 @@
 package handler
 
 import (
+	"context"
 	"fmt"
+	"log"
+	"sort"
+	"strings"
+	"time"
 
 	"github.com/gomcpgo/mcp/pkg/protocol"
+	"github.com/gomcpgo/docgen/pkg/document"
 	"github.com/gomcpgo/docgen/pkg/types"
 )
 
 // Document operations
 
-func (h *DocGenHandler) handleCreateDocument(params map[string]interface{}) (*protocol.CallToolResponse, error) {
+func (h *DocGenHandler) handleCreateDocument(clientID string, params map[string]interface{}) (*protocol.CallToolResponse, error) {
 	// Get title
 	title, ok := params["title"].(string)
 	if !ok || title == "" {
@@ -38,11 +44,30 @@ func (h *DocGenHandler) handleCreateDocument(params map[string]interface{}) (*pr
 
 	docType := types.DocumentType(docTypeStr)
 
+	// Get optional metadata (subtitle, keywords, abstract, language, date, locale)
+	updates, err := parseDocumentMetadata(params)
+	if err != nil {
+		return h.errorResponse(err.Error())
+	}
+	var metadata types.DocumentMetadata
+	metadata.Merge(*updates)
+
+	// Check the client's document quota
+	if err := h.usage.CheckDocument(clientID); err != nil {
+		return h.errorResponse(err.Error())
+	}
+
 	// Create the document
-	docID, err := h.manager.CreateDocument(title, author, docType)
+	docID, err := h.manager.CreateDocumentWithMetadata(title, author, docType, metadata)
 	if err != nil {
 		return h.errorResponse(fmt.Sprintf("Failed to create document: %v", err))
 	}
+	if err := h.usage.RecordDocumentCreated(clientID, string(docID)); err != nil {
+		log.Printf("[DOCGEN HANDLER] Failed to record usage: %v", err)
+	}
+	if err := h.grantCreator(clientID, docID); err != nil {
+		log.Printf("[DOCGEN HANDLER] Failed to grant creator access: %v", err)
+	}
 
 	return h.successResponse(map[string]interface{}{
 		"document_id": docID,
@@ -50,20 +75,259 @@ func (h *DocGenHandler) handleCreateDocument(params map[string]interface{}) (*pr
 	})
 }
 
-func (h *DocGenHandler) handleGetDocumentStructure(params map[string]interface{}) (*protocol.CallToolResponse, error) {
+func (h *DocGenHandler) handleCreateSandboxDocument(clientID string, params map[string]interface{}) (*protocol.CallToolResponse, error) {
+	title, _ := params["title"].(string)
+	if title == "" {
+		title = "Sandbox"
+	}
+	author, _ := params["author"].(string)
+	if author == "" {
+		author = "Sandbox"
+	}
+	docType := types.DocumentTypeReport
+	if val, ok := params["type"].(string); ok && val != "" {
+		switch types.DocumentType(val) {
+		case types.DocumentTypeBook, types.DocumentTypeReport, types.DocumentTypeArticle, types.DocumentTypeLetter:
+			docType = types.DocumentType(val)
+		default:
+			return h.errorResponse("type must be one of: book, report, article, letter")
+		}
+	}
+	var ttl time.Duration
+	if val, ok := params["ttl_minutes"].(float64); ok {
+		if val < 1 {
+			return h.errorResponse("ttl_minutes must be at least 1")
+		}
+		ttl = time.Duration(val) * time.Minute
+	}
+
+	// Expired sandboxes make room for new ones
+	h.SweepSandboxes(time.Now())
+
+	docID, expiresAt, err := h.manager.CreateSandboxDocument(title, author, docType, ttl)
+	if err != nil {
+		return h.errorResponse(fmt.Sprintf("Failed to create sandbox document: %v", err))
+	}
+	if err := h.grantCreator(clientID, docID); err != nil {
+		log.Printf("[DOCGEN HANDLER] Failed to grant creator access: %v", err)
+	}
+
+	return h.successResponse(map[string]interface{}{
+		"document_id": docID,
+		"expires_at":  expiresAt,
+		"message":     fmt.Sprintf("Sandbox document %s created; it will be deleted at %s", docID, expiresAt.Format(time.RFC3339)),
+	})
+}
+
+// SweepSandboxes deletes the sandbox documents expired at now, along with
+// their roles, and returns how many it deleted
+func (h *DocGenHandler) SweepSandboxes(now time.Time) int {
+	expired, err := h.manager.ExpiredSandboxes(now)
+	if err != nil {
+		log.Printf("[DOCGEN HANDLER] Failed to find expired sandbox documents: %v", err)
+		return 0
+	}
+	deleted := 0
+	for _, docID := range expired {
+		if err := h.manager.DeleteDocument(docID); err != nil {
+			log.Printf("[DOCGEN HANDLER] Failed to delete expired sandbox document %s: %v", docID, err)
+			continue
+		}
+		deleted++
+		if h.config.AccessControl {
+			if err := h.updateAccessPolicy(func(policy *types.AccessPolicy) {
+				delete(policy.Documents, string(docID))
+			}); err != nil {
+				log.Printf("[DOCGEN HANDLER] Failed to remove document roles: %v", err)
+			}
+		}
+	}
+	if deleted > 0 {
+		log.Printf("[DOCGEN HANDLER] Deleted %d expired sandbox document(s)", deleted)
+	}
+	return deleted
+}
+
+func (h *DocGenHandler) handleGetDocumentStructure(ctx context.Context, params map[string]interface{}) (*protocol.CallToolResponse, error) {
 	docID, err := h.getDocumentID(params)
 	if err != nil {
 		return h.errorResponse(fmt.Sprintf("Invalid document_id: %v", err))
 	}
 
+	// Everything is included unless include names what to list
+	options := types.StructureOptions{Sections: true, Figures: true, Tables: true, Listings: true}
+	if includeParam, ok := params["include"].([]interface{}); ok {
+		options.Sections, options.Figures, options.Tables, options.Listings = false, false, false, false
+		for _, item := range includeParam {
+			switch item {
+			case "sections":
+				options.Sections = true
+			case "figures":
+				options.Figures = true
+			case "tables":
+				options.Tables = true
+			case "listings":
+				options.Listings = true
+			default:
+				return h.errorResponse(fmt.Sprintf("Invalid include: %v (must be sections, figures, tables or listings)", item))
+			}
+		}
+	}
+	if val, ok := params["depth"].(float64); ok {
+		options.Depth = int(val)
+	}
+	if rangeParam, ok := params["chapter_range"].(string); ok && strings.TrimSpace(rangeParam) != "" {
+		options.Chapters, err = types.ParseChapterRange(rangeParam)
+		if err != nil {
+			return h.errorResponse(err.Error())
+		}
+	}
+	options.Compact, _ = params["compact"].(bool)
+	if filter, ok := params["front_matter"].(map[string]interface{}); ok && len(filter) > 0 {
+		options.FrontMatter = make(map[string]string)
+		for key, value := range filter {
+			options.FrontMatter[key] = fmt.Sprint(value)
+		}
+	}
+	if statuses, ok := params["status"].([]interface{}); ok {
+		for _, item := range statuses {
+			status := types.WorkflowStatus(fmt.Sprint(item))
+			if err := status.Validate(); err != nil {
+				return h.errorResponse(err.Error())
+			}
+			options.Status = append(options.Status, status)
+		}
+	}
+
 	manifest, err := h.manager.GetDocumentStructure(docID)
 	if err != nil {
 		return h.errorResponse(fmt.Sprintf("Failed to get document: %v", err))
 	}
+	manifest.Health, err = h.documentHealth(ctx, docID, manifest)
+	if err != nil {
+		return h.errorResponse(fmt.Sprintf("Failed to get document: %v", err))
+	}
+	manifest, err = document.ShapeStructure(manifest, options)
+	if err != nil {
+		return h.errorResponse(fmt.Sprintf("Failed to get document: %v", err))
+	}
 
+	if options.Compact {
+		compact, err := document.CompactStructure(manifest)
+		if err != nil {
+			return h.errorResponse(fmt.Sprintf("Failed to get document: %v", err))
+		}
+		return h.successResponse(compact)
+	}
 	return h.successResponse(manifest)
 }
 
+func (h *DocGenHandler) handleGetDocumentJSON(ctx context.Context, params map[string]interface{}) (*protocol.CallToolResponse, error) {
+	docID, err := h.getDocumentID(params)
+	if err != nil {
+		return h.errorResponse(fmt.Sprintf("Invalid document_id: %v", err))
+	}
+	var chapters []types.ChapterNumber
+	if rangeParam, ok := params["chapter_range"].(string); ok && strings.TrimSpace(rangeParam) != "" {
+		chapters, err = types.ParseChapterRange(rangeParam)
+		if err != nil {
+			return h.errorResponse(err.Error())
+		}
+	}
+
+	doc, err := h.manager.DocumentJSON(ctx, docID)
+	if err != nil {
+		return h.errorResponse(fmt.Sprintf("Failed to get document: %v", err))
+	}
+	if chapters != nil {
+		included := make(map[types.ChapterNumber]bool)
+		for _, chapterNum := range chapters {
+			included[chapterNum] = true
+		}
+		all := doc.Chapters
+		doc.Chapters = []types.ChapterJSON{}
+		for _, chapter := range all {
+			if included[chapter.Number] {
+				doc.Chapters = append(doc.Chapters, chapter)
+			}
+		}
+	}
+	return h.successResponse(doc)
+}
+
+// documentHealth validates a document loaded with GetDocumentStructure and
+// scores its health
+func (h *DocGenHandler) documentHealth(ctx context.Context, docID types.DocumentID, manifest *types.Manifest) (*types.DocumentHealth, error) {
+	if err := h.manager.SyncDocument(ctx, docID); err != nil {
+		return nil, err
+	}
+	report := h.exporter.ValidateDocument(string(docID), manifest)
+	return h.manager.Health(docID, len(report.Errors))
+}
+
+func (h *DocGenHandler) handleGetTOC(params map[string]interface{}) (*protocol.CallToolResponse, error) {
+	docID, err := h.getDocumentID(params)
+	if err != nil {
+		return h.errorResponse(fmt.Sprintf("Invalid document_id: %v", err))
+	}
+
+	depth := document.DefaultTOCDepth
+	if val, ok := params["depth"].(float64); ok {
+		depth = int(val)
+	}
+
+	format, _ := params["format"].(string)
+	if format != "" && format != "text" && format != "json" {
+		return h.errorResponse(fmt.Sprintf("Invalid format: %s (must be text or json)", format))
+	}
+
+	toc, err := h.manager.TableOfContents(docID, depth)
+	if err != nil {
+		return h.errorResponse(fmt.Sprintf("Failed to get table of contents: %v", err))
+	}
+
+	if format == "json" {
+		return h.successResponse(map[string]interface{}{
+			"document_id": docID,
+			"toc":         toc,
+		})
+	}
+	return h.successResponse(map[string]interface{}{
+		"document_id": docID,
+		"toc":         toc.Text(),
+	})
+}
+
+func (h *DocGenHandler) handleSummarizeStructure(params map[string]interface{}) (*protocol.CallToolResponse, error) {
+	docID, err := h.getDocumentID(params)
+	if err != nil {
+		return h.errorResponse(fmt.Sprintf("Invalid document_id: %v", err))
+	}
+
+	var options types.SummaryOptions
+	if val, ok := params["depth"].(float64); ok {
+		options.Depth = int(val)
+	}
+	if val, ok := params["excerpt_words"].(float64); ok {
+		options.ExcerptWords = int(val)
+	}
+	if rangeParam, ok := params["chapter_range"].(string); ok && strings.TrimSpace(rangeParam) != "" {
+		options.Chapters, err = types.ParseChapterRange(rangeParam)
+		if err != nil {
+			return h.errorResponse(err.Error())
+		}
+	}
+
+	summary, err := h.manager.SummarizeStructure(docID, options)
+	if err != nil {
+		return h.errorResponse(fmt.Sprintf("Failed to summarize document: %v", err))
+	}
+	return h.successResponse(map[string]interface{}{
+		"document_id": docID,
+		"summary":     summary,
+	})
+}
+
 func (h *DocGenHandler) handleDeleteDocument(params map[string]interface{}) (*protocol.CallToolResponse, error) {
 	docID, err := h.getDocumentID(params)
 	if err != nil {
@@ -74,6 +338,16 @@ func (h *DocGenHandler) handleDeleteDocument(params map[string]interface{}) (*pr
 	if err != nil {
 		return h.errorResponse(fmt.Sprintf("Failed to delete document: %v", err))
 	}
+	if err := h.usage.RecordDocumentDeleted(string(docID)); err != nil {
+		log.Printf("[DOCGEN HANDLER] Failed to record usage: %v", err)
+	}
+	if h.config.AccessControl {
+		if err := h.updateAccessPolicy(func(policy *types.AccessPolicy) {
+			delete(policy.Documents, string(docID))
+		}); err != nil {
+			log.Printf("[DOCGEN HANDLER] Failed to remove document roles: %v", err)
+		}
+	}
 
 	return h.successResponse(map[string]interface{}{
 		"document_id": docID,
@@ -81,6 +355,109 @@ func (h *DocGenHandler) handleDeleteDocument(params map[string]interface{}) (*pr
 	})
 }
 
+func (h *DocGenHandler) handleArchiveDocument(ctx context.Context, params map[string]interface{}) (*protocol.CallToolResponse, error) {
+	docID, err := h.getDocumentID(params)
+	if err != nil {
+		return h.errorResponse(fmt.Sprintf("Invalid document_id: %v", err))
+	}
+
+	archivePath, err := h.manager.ArchiveDocument(ctx, docID)
+	if err != nil {
+		return h.errorResponse(fmt.Sprintf("Failed to archive document: %v", err))
+	}
+
+	return h.successResponse(map[string]interface{}{
+		"document_id":  docID,
+		"archive_path": archivePath,
+		"message":      fmt.Sprintf("Document %s archived to %s", docID, archivePath),
+	})
+}
+
+func (h *DocGenHandler) handleRestoreDocument(ctx context.Context, clientID string, params map[string]interface{}) (*protocol.CallToolResponse, error) {
+	archivePath, ok := params["archive_path"].(string)
+	if !ok || archivePath == "" {
+		return h.errorResponse("archive_path parameter is required")
+	}
+	newID, _ := params["new_document_id"].(string)
+
+	// Check the client's document quota
+	if err := h.usage.CheckDocument(clientID); err != nil {
+		return h.errorResponse(err.Error())
+	}
+
+	docID, err := h.manager.RestoreDocument(ctx, archivePath, types.DocumentID(newID))
+	if err != nil {
+		return h.errorResponse(fmt.Sprintf("Failed to restore document: %v", err))
+	}
+	if err := h.usage.RecordDocumentCreated(clientID, string(docID)); err != nil {
+		log.Printf("[DOCGEN HANDLER] Failed to record usage: %v", err)
+	}
+	if err := h.grantCreator(clientID, docID); err != nil {
+		log.Printf("[DOCGEN HANDLER] Failed to grant creator access: %v", err)
+	}
+
+	return h.successResponse(map[string]interface{}{
+		"document_id": docID,
+		"message":     fmt.Sprintf("Document restored as %s", docID),
+	})
+}
+
+func (h *DocGenHandler) handleRenameDocument(ctx context.Context, params map[string]interface{}) (*protocol.CallToolResponse, error) {
+	docID, err := h.getDocumentID(params)
+	if err != nil {
+		return h.errorResponse(fmt.Sprintf("Invalid document_id: %v", err))
+	}
+
+	var options types.RenameOptions
+	if title, ok := params["title"].(string); ok {
+		options.Title = strings.TrimSpace(title)
+	}
+	if author, ok := params["author"].(string); ok {
+		options.Author = strings.TrimSpace(author)
+	}
+	if newID, ok := params["new_document_id"].(string); ok {
+		options.NewID = types.DocumentID(strings.TrimSpace(newID))
+	}
+	options.DeriveID, _ = params["derive_id"].(bool)
+
+	newID, err := h.manager.RenameDocument(ctx, docID, options)
+	if err != nil {
+		return h.errorResponse(fmt.Sprintf("Failed to rename document: %v", err))
+	}
+
+	manifest, err := h.manager.GetDocumentStructure(newID)
+	if err != nil {
+		return h.errorResponse(fmt.Sprintf("Failed to get document: %v", err))
+	}
+	result := map[string]interface{}{
+		"document_id": newID,
+		"title":       manifest.Document.Title,
+		"authors":     manifest.Document.Authors,
+		"message":     fmt.Sprintf("Document %s renamed to %q", newID, manifest.Document.Title),
+	}
+	if newID == docID {
+		return h.successResponse(result)
+	}
+
+	// Records kept outside the document follow it to its new ID
+	if err := h.usage.RecordDocumentRenamed(string(docID), string(newID)); err != nil {
+		log.Printf("[DOCGEN HANDLER] Failed to record usage: %v", err)
+	}
+	if h.config.AccessControl {
+		if err := h.updateAccessPolicy(func(policy *types.AccessPolicy) {
+			if roles, ok := policy.Documents[string(docID)]; ok {
+				policy.Documents[string(newID)] = roles
+				delete(policy.Documents, string(docID))
+			}
+		}); err != nil {
+			log.Printf("[DOCGEN HANDLER] Failed to move document roles: %v", err)
+		}
+	}
+	result["previous_document_id"] = docID
+	result["message"] = fmt.Sprintf("Document %s moved to %s; use the new ID from now on. Earlier exports keep the old name.", docID, newID)
+	return h.successResponse(result)
+}
+
 func (h *DocGenHandler) handleConfigureDocument(params map[string]interface{}) (*protocol.CallToolResponse, error) {
 	docID, err := h.getDocumentID(params)
 	if err != nil {
@@ -89,7 +466,11 @@ func (h *DocGenHandler) handleConfigureDocument(params map[string]interface{}) (
 
 	// Parse style options
 	var styleOptions *types.Style
-	if styleParams, ok := params["style"].(map[string]interface{}); ok {
+	styleParams, ok := params["style"].(map[string]interface{})
+	if !ok {
+		styleParams, ok = params["style_updates"].(map[string]interface{})
+	}
+	if ok {
 		style := &types.Style{}
 
 		// Parse body text style
@@ -137,6 +518,21 @@ func (h *DocGenHandler) handleConfigureDocument(params map[string]interface{}) (
 			style.Monospace = monospaceStyle
 		}
 
+		// Parse epigraph style
+		if epigraphParams, ok := styleParams["epigraph"].(map[string]interface{}); ok {
+			epigraphStyle := types.TextStyle{}
+			if fontFamily, ok := epigraphParams["font_family"].(string); ok {
+				epigraphStyle.FontFamily = fontFamily
+			}
+			if fontSize, ok := epigraphParams["font_size"].(string); ok {
+				epigraphStyle.FontSize = fontSize
+			}
+			if color, ok := epigraphParams["color"].(string); ok {
+				epigraphStyle.Color = color
+			}
+			style.Epigraph = epigraphStyle
+		}
+
 		// Parse global style options
 		if linkColor, ok := styleParams["link_color"].(string); ok {
 			style.LinkColor = linkColor
@@ -145,6 +541,30 @@ func (h *DocGenHandler) handleConfigureDocument(params map[string]interface{}) (
 			style.LineSpacing = lineSpacing
 		}
 
+		// Parse typography
+		if typographyParams, ok := styleParams["typography"].(map[string]interface{}); ok {
+			typography := types.Typography{}
+			if smart, ok := typographyParams["smart_punctuation"].(bool); ok {
+				typography.SmartPunctuation = &smart
+			}
+			if dashes, ok := typographyParams["dashes"].(string); ok {
+				typography.Dashes = types.DashStyle(dashes)
+			}
+			if ellipses, ok := typographyParams["ellipses"].(bool); ok {
+				typography.Ellipses = ellipses
+			}
+			if nonBreaking, ok := typographyParams["non_breaking_spaces"].(bool); ok {
+				typography.NonBreakingSpaces = nonBreaking
+			}
+			if widows, ok := typographyParams["prevent_widows"].(bool); ok {
+				typography.PreventWidows = widows
+			}
+			if orphans, ok := typographyParams["prevent_orphans"].(bool); ok {
+				typography.PreventOrphans = orphans
+			}
+			style.Typography = typography
+		}
+
 		// Parse margins
 		if marginParams, ok := styleParams["margins"].(map[string]interface{}); ok {
 			margins := types.Margins{}
@@ -172,6 +592,12 @@ func (h *DocGenHandler) handleConfigureDocument(params map[string]interface{}) (
 			if footerTemplate, ok := headerFooterParams["footer_template"].(string); ok {
 				headerFooter.FooterTemplate = footerTemplate
 			}
+			headerFooter.FirstPage = parsePageTemplates(headerFooterParams["first_page"])
+			headerFooter.OddPage = parsePageTemplates(headerFooterParams["odd_page"])
+			headerFooter.EvenPage = parsePageTemplates(headerFooterParams["even_page"])
+			if suppress, ok := headerFooterParams["suppress_chapter_start"].(bool); ok {
+				headerFooter.SuppressChapterStart = suppress
+			}
 			style.HeaderFooter = headerFooter
 		}
 
@@ -190,9 +616,91 @@ func (h *DocGenHandler) handleConfigureDocument(params map[string]interface{}) (
 			if tables, ok := numberingParams["tables"].(bool); ok {
 				numbering.Tables = tables
 			}
+			if chapterFormat, ok := numberingParams["chapter_format"].(string); ok {
+				numbering.ChapterFormat = types.NumberFormat(chapterFormat)
+			}
+			if figureNumbering, ok := numberingParams["figure_numbering"].(string); ok {
+				numbering.FigureNumbering = types.CounterScope(figureNumbering)
+			}
+			if tableNumbering, ok := numberingParams["table_numbering"].(string); ok {
+				numbering.TableNumbering = types.CounterScope(tableNumbering)
+			}
+			if sectionDepth, ok := numberingParams["section_depth"].(float64); ok {
+				numbering.SectionDepth = int(sectionDepth)
+			}
 			style.NumberingStyle = numbering
 		}
 
+		// Parse chapter heading format
+		if headingParams, ok := styleParams["chapter_heading"].(map[string]interface{}); ok {
+			heading := types.ChapterHeadingStyle{}
+			if prefix, ok := headingParams["prefix"].(string); ok {
+				heading.Prefix = &prefix
+			}
+			if unnumbered, ok := headingParams["unnumbered"].([]interface{}); ok {
+				for _, title := range unnumbered {
+					if title, ok := title.(string); ok && title != "" {
+						heading.Unnumbered = append(heading.Unnumbered, title)
+					}
+				}
+			}
+			if headingCase, ok := headingParams["case"].(string); ok {
+				heading.Case = types.HeadingCase(headingCase)
+			}
+			style.ChapterHeading = heading
+		}
+
+		// Parse HTML layout and theme
+		if htmlParams, ok := styleParams["html"].(map[string]interface{}); ok {
+			html := types.HTMLStyle{}
+			if maxWidth, ok := htmlParams["max_width"].(string); ok {
+				html.MaxWidth = maxWidth
+			}
+			if sidebarTOC, ok := htmlParams["sidebar_toc"].(bool); ok {
+				html.SidebarTOC = sidebarTOC
+			}
+			if background, ok := htmlParams["background"].(string); ok {
+				html.Background = background
+			}
+			if darkMode, ok := htmlParams["dark_mode"].(bool); ok {
+				html.DarkMode = darkMode
+			}
+			if darkBackground, ok := htmlParams["dark_background"].(string); ok {
+				html.DarkBackground = darkBackground
+			}
+			if darkTextColor, ok := htmlParams["dark_text_color"].(string); ok {
+				html.DarkTextColor = darkTextColor
+			}
+			if darkHeadingColor, ok := htmlParams["dark_heading_color"].(string); ok {
+				html.DarkHeadingColor = darkHeadingColor
+			}
+			if darkLinkColor, ok := htmlParams["dark_link_color"].(string); ok {
+				html.DarkLinkColor = darkLinkColor
+			}
+			style.HTML = html
+		}
+
+		// Parse slide deck theme and layout
+		if slideParams, ok := styleParams["slides"].(map[string]interface{}); ok {
+			slides := types.SlideStyle{}
+			if theme, ok := slideParams["theme"].(string); ok {
+				slides.Theme = theme
+			}
+			if transition, ok := slideParams["transition"].(string); ok {
+				slides.Transition = transition
+			}
+			if aspectRatio, ok := slideParams["aspect_ratio"].(string); ok {
+				slides.AspectRatio = aspectRatio
+			}
+			if slideNumbers, ok := slideParams["slide_numbers"].(bool); ok {
+				slides.SlideNumbers = slideNumbers
+			}
+			if referencePPTX, ok := slideParams["reference_pptx"].(string); ok {
+				slides.ReferencePPTX = referencePPTX
+			}
+			style.Slides = slides
+		}
+
 		// Parse output-specific templates
 		if referenceDocx, ok := styleParams["reference_docx"].(string); ok {
 			style.ReferenceDocx = referenceDocx
@@ -209,7 +717,11 @@ func (h *DocGenHandler) handleConfigureDocument(params map[string]interface{}) (
 
 	// Parse pandoc options
 	var pandocOptions *types.PandocConfig
-	if pandocParams, ok := params["export_settings"].(map[string]interface{}); ok {
+	pandocParams, ok := params["pandoc_options"].(map[string]interface{})
+	if !ok {
+		pandocParams, ok = params["export_settings"].(map[string]interface{})
+	}
+	if ok {
 		pandoc := &types.PandocConfig{}
 
 		if pdfEngine, ok := pandocParams["pdf_engine"].(string); ok {
@@ -224,39 +736,149 @@ func (h *DocGenHandler) handleConfigureDocument(params map[string]interface{}) (
 		if citationStyle, ok := pandocParams["citation_style"].(string); ok {
 			pandoc.CitationStyle = citationStyle
 		}
+		if bibliography, ok := pandocParams["bibliography"].(string); ok {
+			pandoc.Bibliography = bibliography
+		}
+		if scope, ok := pandocParams["bibliography_scope"].(string); ok {
+			switch types.BibliographyScope(scope) {
+			case types.BibliographyScopeDocument, types.BibliographyScopeChapter:
+				pandoc.BibliographyScope = types.BibliographyScope(scope)
+			default:
+				return h.errorResponse(fmt.Sprintf("Invalid bibliography_scope: %s (must be document or chapter)", scope))
+			}
+		}
 
 		pandocOptions = pandoc
 	}
 
+	// Parse metadata updates
+	var metadataOptions *types.DocumentMetadataUpdate
+	if metadataParams, ok := params["metadata"].(map[string]interface{}); ok {
+		metadataOptions, err = parseDocumentMetadata(metadataParams)
+		if err != nil {
+			return h.errorResponse(err.Error())
+		}
+	}
+
+	// Get markdown extensions (optional), checked against the installed pandoc
+	var markdownSettings map[string]bool
+	if markdownParams, ok := params["markdown"].(map[string]interface{}); ok {
+		markdownSettings, err = h.markdownSettings(markdownParams, "markdown")
+		if err != nil {
+			return h.errorResponse(err.Error())
+		}
+	}
+
 	// Update document configuration
-	err = h.manager.ConfigureDocument(docID, styleOptions, pandocOptions)
+	err = h.manager.ConfigureDocument(docID, styleOptions, pandocOptions, metadataOptions)
 	if err != nil {
 		return h.errorResponse(fmt.Sprintf("Failed to configure document: %v", err))
 	}
 
+	// Update the markdown profile
+	if markdownSettings != nil {
+		if _, err := h.manager.ConfigureMarkdown(docID, markdownSettings); err != nil {
+			return h.errorResponse(fmt.Sprintf("Failed to configure markdown: %v", err))
+		}
+	}
+
 	return h.successResponse(map[string]interface{}{
 		"document_id": docID,
 		"message":     "Document configuration updated successfully",
 	})
 }
 
-// handleListDocuments lists all available documents
-func (h *DocGenHandler) handleListDocuments(params map[string]interface{}) (*protocol.CallToolResponse, error) {
+// parsePageTemplates parses the header and footer of one kind of page, or
+// returns nil when they aren't given
+func parsePageTemplates(param interface{}) *types.PageTemplates {
+	params, ok := param.(map[string]interface{})
+	if !ok {
+		return nil
+	}
+	templates := &types.PageTemplates{}
+	templates.Header, _ = params["header"].(string)
+	templates.Footer, _ = params["footer"].(string)
+	return templates
+}
+
+// parseDocumentMetadata reads the optional metadata fields from tool
+// parameters. Only the fields given are set, so an empty string clears one.
+func parseDocumentMetadata(params map[string]interface{}) (*types.DocumentMetadataUpdate, error) {
+	metadata := &types.DocumentMetadataUpdate{}
+
+	if subtitle, ok := params["subtitle"].(string); ok {
+		metadata.Subtitle = &subtitle
+	}
+	if abstract, ok := params["abstract"].(string); ok {
+		metadata.Abstract = &abstract
+	}
+	if language, ok := params["language"].(string); ok {
+		metadata.Language = &language
+	}
+	if date, ok := params["date"].(string); ok {
+		metadata.Date = &date
+	}
+	if locale, ok := params["locale"].(string); ok {
+		metadata.Locale = &locale
+	}
+	if keywordsRaw, ok := params["keywords"].([]interface{}); ok {
+		metadata.Keywords = []string{}
+		for _, keywordRaw := range keywordsRaw {
+			keyword, ok := keywordRaw.(string)
+			if !ok {
+				return nil, fmt.Errorf("keywords must be an array of strings")
+			}
+			metadata.Keywords = append(metadata.Keywords, keyword)
+		}
+	}
+
+	return metadata, nil
+}
+
+// handleListDocuments lists the available documents, filtered, sorted and limited
+func (h *DocGenHandler) handleListDocuments(ctx context.Context, clientID string, params map[string]interface{}) (*protocol.CallToolResponse, error) {
 	// Get optional parameters with defaults
-	// sortBy := "updated_at"
-	// if val, ok := params["sort_by"].(string); ok {
-	// 	sortBy = val
-	// }
+	sortBy := "updated_at"
+	if val, ok := params["sort_by"].(string); ok && val != "" {
+		sortBy = val
+	}
+	switch sortBy {
+	case "created_at", "updated_at", "title", "word_count":
+	default:
+		return h.errorResponse("sort_by must be one of: created_at, updated_at, title, word_count")
+	}
 	
-	// sortOrder := "desc"
-	// if val, ok := params["sort_order"].(string); ok {
-	// 	sortOrder = val
-	// }
+	sortOrder := "desc"
+	if val, ok := params["sort_order"].(string); ok && val != "" {
+		sortOrder = val
+	}
+	if sortOrder != "asc" && sortOrder != "desc" {
+		return h.errorResponse("sort_order must be one of: asc, desc")
+	}
 	
 	limit := 50
 	if val, ok := params["limit"].(float64); ok {
 		limit = int(val)
 	}
+	if limit < 1 || limit > 100 {
+		return h.errorResponse("limit must be between 1 and 100")
+	}
+	
+	// Get filters (optional)
+	typeFilter, _ := params["type"].(string)
+	titleFilter, _ := params["title_contains"].(string)
+	titleFilter = strings.ToLower(strings.TrimSpace(titleFilter))
+	var tagFilter []string
+	if tagsParam, ok := params["tags"].([]interface{}); ok {
+		for _, item := range tagsParam {
+			name, _ := item.(string)
+			tag, err := types.NormalizeTag(name)
+			if err != nil {
+				return h.errorResponse(err.Error())
+			}
+			tagFilter = append(tagFilter, tag)
+		}
+	}
 	
 	// Get document IDs from storage
 	documentIDs, err := h.storage.ListDocuments()
@@ -264,48 +886,314 @@ func (h *DocGenHandler) handleListDocuments(params map[string]interface{}) (*pro
 		return h.errorResponse(fmt.Sprintf("Failed to list documents: %v", err))
 	}
 	
-	// Load metadata for each document
-	var documents []map[string]interface{}
+	// With access control, only documents the caller may view are listed
+	var policy *types.AccessPolicy
+	if h.config.AccessControl {
+		policy, err = h.storage.LoadAccessPolicy()
+		if err != nil {
+			return h.errorResponse(fmt.Sprintf("Failed to load access policy: %v", err))
+		}
+	}
+	
+	// Load metadata for each document. Tags are counted over every document
+	// the caller can view, so that the collections are known before filtering.
+	documents := []types.DocumentSummary{}
+	tagCounts := make(map[string]int)
 	for _, docID := range documentIDs {
+		if err := ctx.Err(); err != nil {
+			return h.errorResponse(fmt.Sprintf("Listing documents cancelled: %v", err))
+		}
+		if !h.canView(policy, clientID, docID) {
+			continue
+		}
+		
 		// Load manifest to get document details
 		manifest, err := h.storage.LoadManifest(docID)
 		if err != nil {
 			continue // Skip documents that can't be loaded
 		}
+		doc := manifest.Document
+		for _, tag := range doc.Tags {
+			tagCounts[tag]++
+		}
 		
-		// Calculate chapter count
-		chapterCount := len(manifest.Document.Chapters)
+		if !doc.HasTags(tagFilter) {
+			continue
+		}
+		if typeFilter != "" && string(doc.Type) != typeFilter {
+			continue
+		}
+		if titleFilter != "" && !strings.Contains(strings.ToLower(doc.Title), titleFilter) {
+			continue
+		}
 		
-		// Calculate approximate word count from sections
-		// In a real implementation, we might store this in the manifest
-		wordCount := 0
+		// Word count across all section files
+		wordCount, err := h.manager.WordCount(types.DocumentID(docID))
+		if err != nil {
+			log.Printf("[DOCGEN HANDLER] Failed to count words in %s: %v", docID, err)
+		}
 		
-		docInfo := map[string]interface{}{
-			"document_id":   docID,
-			"title":        manifest.Document.Title,
-			"author":       manifest.Document.Author,
-			"type":         manifest.Document.Type,
-			"created_at":   manifest.Document.CreatedAt,
-			"updated_at":   manifest.Document.UpdatedAt,
-			"chapter_count": chapterCount,
-			"word_count":   wordCount,
+		health, err := h.documentHealth(ctx, types.DocumentID(docID), manifest)
+		if err != nil {
+			log.Printf("[DOCGEN HANDLER] Failed to score health of %s: %v", docID, err)
 		}
 		
-		documents = append(documents, docInfo)
+		documents = append(documents, types.DocumentSummary{
+			DocumentID:   types.DocumentID(docID),
+			Title:        doc.Title,
+			Author:       doc.Authors.String(),
+			Authors:      doc.Authors.Names(),
+			Type:         doc.Type,
+			CreatedAt:    doc.CreatedAt,
+			UpdatedAt:    doc.UpdatedAt,
+			ChapterCount: len(doc.Chapters),
+			WordCount:    wordCount,
+			Tags:         doc.Tags,
+			Health:       health,
+			ExpiresAt:    doc.ExpiresAt,
+			Locked:       doc.Lock != nil,
+		})
 	}
 	
 	// Sort documents based on parameters
-	// Note: In a real implementation, you'd implement sorting logic here based on sortBy and sortOrder
-	// For now, we'll return them as-is
+	sort.SliceStable(documents, func(i, j int) bool {
+		a, b := documents[i], documents[j]
+		if sortOrder == "desc" {
+			a, b = b, a
+		}
+		switch sortBy {
+		case "created_at":
+			return a.CreatedAt.Before(b.CreatedAt)
+		case "title":
+			return strings.ToLower(a.Title) < strings.ToLower(b.Title)
+		case "word_count":
+			return a.WordCount < b.WordCount
+		default:
+			return a.UpdatedAt.Before(b.UpdatedAt)
+		}
+	})
 	
 	// Apply limit
-	if limit > 0 && len(documents) > limit {
+	total := len(documents)
+	if len(documents) > limit {
 		documents = documents[:limit]
 	}
 	
 	return h.successResponse(map[string]interface{}{
 		"documents": documents,
 		"count":     len(documents),
-		"total":     len(documentIDs),
+		"total":     total,
+		"tags":      tagCounts,
+	})
+}
+
+func (h *DocGenHandler) handleLockDocument(params map[string]interface{}) (*protocol.CallToolResponse, error) {
+	docID, err := h.getDocumentID(params)
+	if err != nil {
+		return h.errorResponse(fmt.Sprintf("Invalid document_id: %v", err))
+	}
+	reason, _ := params["reason"].(string)
+
+	lock, err := h.manager.LockDocument(docID, strings.TrimSpace(reason))
+	if err != nil {
+		return h.errorResponse(fmt.Sprintf("Failed to lock document: %v", err))
+	}
+
+	return h.successResponse(map[string]interface{}{
+		"document_id": docID,
+		"lock":        lock,
+		"message":     fmt.Sprintf("Document %s is locked and read-only until unlocked", docID),
+	})
+}
+
+func (h *DocGenHandler) handleUnlockDocument(params map[string]interface{}) (*protocol.CallToolResponse, error) {
+	docID, err := h.getDocumentID(params)
+	if err != nil {
+		return h.errorResponse(fmt.Sprintf("Invalid document_id: %v", err))
+	}
+
+	if err := h.manager.UnlockDocument(docID); err != nil {
+		return h.errorResponse(fmt.Sprintf("Failed to unlock document: %v", err))
+	}
+
+	return h.successResponse(map[string]interface{}{
+		"document_id": docID,
+		"message":     fmt.Sprintf("Document %s is unlocked", docID),
 	})
-}
\ No newline at end of file
+}
+
+func (h *DocGenHandler) handleTagDocument(params map[string]interface{}, remove bool) (*protocol.CallToolResponse, error) {
+	docID, err := h.getDocumentID(params)
+	if err != nil {
+		return h.errorResponse(fmt.Sprintf("Invalid document_id: %v", err))
+	}
+
+	var tags []string
+	if tagsParam, ok := params["tags"].([]interface{}); ok {
+		for _, item := range tagsParam {
+			if tag, ok := item.(string); ok {
+				tags = append(tags, tag)
+			}
+		}
+	}
+	if len(tags) == 0 {
+		return h.errorResponse("tags parameter is required")
+	}
+
+	if remove {
+		tags, err = h.manager.UntagDocument(docID, tags)
+		if err != nil {
+			return h.errorResponse(fmt.Sprintf("Failed to untag document: %v", err))
+		}
+	} else {
+		tags, err = h.manager.TagDocument(docID, tags)
+		if err != nil {
+			return h.errorResponse(fmt.Sprintf("Failed to tag document: %v", err))
+		}
+	}
+
+	return h.successResponse(map[string]interface{}{
+		"document_id": docID,
+		"tags":        tags,
+		"message":     fmt.Sprintf("Document %s has %d tag(s)", docID, len(tags)),
+	})
+}
+
+func (h *DocGenHandler) handleAddAuthor(params map[string]interface{}) (*protocol.CallToolResponse, error) {
+	// Get document ID
+	docID, err := h.getDocumentID(params)
+	if err != nil {
+		return h.errorResponse(fmt.Sprintf("Invalid document_id: %v", err))
+	}
+
+	// Get author name
+	name, ok := params["name"].(string)
+	if !ok || name == "" {
+		return h.errorResponse("name parameter is required")
+	}
+
+	// Get optional author details
+	affiliation, _ := params["affiliation"].(string)
+	email, _ := params["email"].(string)
+	orcid, _ := params["orcid"].(string)
+
+	authors, err := h.manager.AddAuthor(docID, types.Author{
+		Name:        name,
+		Affiliation: affiliation,
+		Email:       email,
+		ORCID:       orcid,
+	})
+	if err != nil {
+		return h.errorResponse(fmt.Sprintf("Failed to add author: %v", err))
+	}
+
+	return h.successResponse(map[string]interface{}{
+		"document_id": docID,
+		"authors":     authors,
+		"message":     fmt.Sprintf("Author '%s' added successfully", name),
+	})
+}
+
+func (h *DocGenHandler) handleRemoveAuthor(params map[string]interface{}) (*protocol.CallToolResponse, error) {
+	// Get document ID
+	docID, err := h.getDocumentID(params)
+	if err != nil {
+		return h.errorResponse(fmt.Sprintf("Invalid document_id: %v", err))
+	}
+
+	// Get author name
+	name, ok := params["name"].(string)
+	if !ok || name == "" {
+		return h.errorResponse("name parameter is required")
+	}
+
+	authors, err := h.manager.RemoveAuthor(docID, name)
+	if err != nil {
+		return h.errorResponse(fmt.Sprintf("Failed to remove author: %v", err))
+	}
+
+	return h.successResponse(map[string]interface{}{
+		"document_id": docID,
+		"authors":     authors,
+		"message":     fmt.Sprintf("Author '%s' removed successfully", name),
+	})
+}
+
+func (h *DocGenHandler) handleListTodos(params map[string]interface{}) (*protocol.CallToolResponse, error) {
+	docID, err := h.getDocumentID(params)
+	if err != nil {
+		return h.errorResponse(fmt.Sprintf("Invalid document_id: %v", err))
+	}
+
+	todos, err := h.manager.ListTodos(docID)
+	if err != nil {
+		return h.errorResponse(fmt.Sprintf("Failed to list todos: %v", err))
+	}
+
+	return h.successResponse(map[string]interface{}{
+		"document_id": docID,
+		"todos":       todos,
+		"count":       len(todos),
+	})
+}
+func (h *DocGenHandler) handleSetAbbreviation(params map[string]interface{}) (*protocol.CallToolResponse, error) {
+	docID, err := h.getDocumentID(params)
+	if err != nil {
+		return h.errorResponse(fmt.Sprintf("Invalid document_id: %v", err))
+	}
+
+	term, _ := params["term"].(string)
+	term = strings.TrimSpace(term)
+	if term == "" {
+		return h.errorResponse("term parameter is required")
+	}
+
+	// An empty definition removes the term
+	definition, _ := params["definition"].(string)
+	definition = strings.TrimSpace(definition)
+	if definition == "" {
+		abbreviations, err := h.manager.RemoveAbbreviation(docID, term)
+		if err != nil {
+			return h.errorResponse(fmt.Sprintf("Failed to remove abbreviation: %v", err))
+		}
+		return h.successResponse(map[string]interface{}{
+			"document_id":   docID,
+			"abbreviations": abbreviations,
+			"message":       fmt.Sprintf("Removed the definition of %s", term),
+		})
+	}
+
+	abbreviations, err := h.manager.SetAbbreviation(docID, types.Abbreviation{Term: term, Definition: definition})
+	if err != nil {
+		return h.errorResponse(fmt.Sprintf("Failed to set abbreviation: %v", err))
+	}
+
+	return h.successResponse(map[string]interface{}{
+		"document_id":   docID,
+		"abbreviations": abbreviations,
+		"message":       fmt.Sprintf("%s is defined as %q", term, definition),
+	})
+}
+
+func (h *DocGenHandler) handleListAbbreviations(ctx context.Context, params map[string]interface{}) (*protocol.CallToolResponse, error) {
+	docID, err := h.getDocumentID(params)
+	if err != nil {
+		return h.errorResponse(fmt.Sprintf("Invalid document_id: %v", err))
+	}
+
+	manifest, err := h.manager.GetDocumentStructure(docID)
+	if err != nil {
+		return h.errorResponse(fmt.Sprintf("Failed to load document: %v", err))
+	}
+	if err := h.manager.SyncDocument(ctx, docID); err != nil {
+		return h.errorResponse(fmt.Sprintf("Failed to load document: %v", err))
+	}
+
+	report := h.exporter.Abbreviations(string(docID), manifest)
+	return h.successResponse(map[string]interface{}{
+		"document_id":   docID,
+		"abbreviations": report.Abbreviations,
+		"undefined":     report.Undefined,
+		"message":       fmt.Sprintf("%d abbreviation(s) defined, %d used without a definition", len(report.Abbreviations), len(report.Undefined)),
+	})
+}
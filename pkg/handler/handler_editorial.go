@@ -0,0 +1,144 @@
+package handler
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/gomcpgo/docgen/pkg/types"
+	"github.com/gomcpgo/mcp/pkg/protocol"
+)
+
+// Editorial operations
+
+func (h *DocGenHandler) handleSetEditorialRules(params map[string]interface{}) (*protocol.CallToolResponse, error) {
+	// Get document ID
+	docID, err := h.getDocumentID(params)
+	if err != nil {
+		return h.errorResponse(fmt.Sprintf("Invalid document_id: %v", err))
+	}
+
+	rules := &types.EditorialRules{}
+	if spellOut, ok := params["spell_out_numbers"].(bool); ok {
+		rules.SpellOutNumbers = spellOut
+	}
+	if unitSpacing, ok := params["unit_spacing"].(bool); ok {
+		rules.UnitSpacing = unitSpacing
+	}
+	if percentStyle, ok := params["percent_style"].(string); ok {
+		rules.PercentStyle = types.PercentStyle(percentStyle)
+	}
+
+	// Switching every rule off removes the configuration
+	if !rules.Enabled() {
+		rules = nil
+	}
+
+	if err := h.manager.SetEditorialRules(docID, rules); err != nil {
+		return h.errorResponse(fmt.Sprintf("Failed to set editorial rules: %v", err))
+	}
+
+	message := "Editorial rules disabled"
+	if rules != nil {
+		message = "Editorial rules updated; they apply the next time chapters are rebuilt"
+	}
+
+	return h.successResponse(map[string]interface{}{
+		"document_id":     docID,
+		"editorial_rules": rules,
+		"message":         message,
+	})
+}
+
+func (h *DocGenHandler) handleGetEditorialReport(params map[string]interface{}) (*protocol.CallToolResponse, error) {
+	// Get document ID
+	docID, err := h.getDocumentID(params)
+	if err != nil {
+		return h.errorResponse(fmt.Sprintf("Invalid document_id: %v", err))
+	}
+
+	changes, err := h.manager.EditorialReport(docID)
+	if err != nil {
+		return h.errorResponse(fmt.Sprintf("Failed to build editorial report: %v", err))
+	}
+
+	return h.successResponse(map[string]interface{}{
+		"document_id": docID,
+		"changes":     changes,
+		"count":       len(changes),
+	})
+}
+
+func (h *DocGenHandler) handleSaveHouseStyle(params map[string]interface{}) (*protocol.CallToolResponse, error) {
+	// Get ruleset name
+	name, ok := params["name"].(string)
+	if !ok || name == "" {
+		return h.errorResponse("name parameter is required")
+	}
+
+	// Get rules, decoded through JSON into the rule definitions
+	rulesRaw, ok := params["rules"].([]interface{})
+	if !ok {
+		return h.errorResponse("rules parameter is required")
+	}
+	rulesJSON, err := json.Marshal(rulesRaw)
+	if err != nil {
+		return h.errorResponse(fmt.Sprintf("Invalid rules: %v", err))
+	}
+	var rules []types.HouseStyleRule
+	if err := json.Unmarshal(rulesJSON, &rules); err != nil {
+		return h.errorResponse(fmt.Sprintf("Invalid rules: %v", err))
+	}
+
+	description, _ := params["description"].(string)
+
+	style := &types.HouseStyle{
+		Name:        name,
+		Description: description,
+		Rules:       rules,
+	}
+	if err := h.manager.SaveHouseStyle(style); err != nil {
+		return h.errorResponse(fmt.Sprintf("Failed to save house style: %v", err))
+	}
+
+	return h.successResponse(map[string]interface{}{
+		"name":       name,
+		"rule_count": len(rules),
+		"message":    fmt.Sprintf("House style '%s' saved with %d rules", name, len(rules)),
+	})
+}
+
+func (h *DocGenHandler) handleCheckHouseStyle(params map[string]interface{}) (*protocol.CallToolResponse, error) {
+	// Get document ID
+	docID, err := h.getDocumentID(params)
+	if err != nil {
+		return h.errorResponse(fmt.Sprintf("Invalid document_id: %v", err))
+	}
+
+	// Get ruleset name (optional, defaults to DOCGEN_HOUSE_STYLE)
+	name, _ := params["house_style"].(string)
+	fix, _ := params["fix"].(bool)
+
+	findings, err := h.manager.CheckHouseStyle(docID, name, fix)
+	if err != nil {
+		return h.errorResponse(fmt.Sprintf("Failed to check house style: %v", err))
+	}
+
+	bySeverity := map[types.HouseStyleSeverity]int{}
+	fixed := 0
+	for _, finding := range findings {
+		bySeverity[finding.Severity]++
+		if finding.Fixed {
+			fixed++
+		}
+	}
+
+	return h.successResponse(map[string]interface{}{
+		"document_id": docID,
+		"findings":    findings,
+		"count":       len(findings),
+		"errors":      bySeverity[types.SeverityError],
+		"warnings":    bySeverity[types.SeverityWarning],
+		"info":        bySeverity[types.SeverityInfo],
+		"fixed":       fixed,
+	})
+}
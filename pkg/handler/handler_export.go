@@ -1,21 +1,25 @@
 package handler
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
 	"log"
 	"os"
 	"path/filepath"
 	"strings"
+	"time"
 
 	"github.com/gomcpgo/mcp/pkg/protocol"
+	"github.com/gomcpgo/docgen/pkg/export"
+	"github.com/gomcpgo/docgen/pkg/style"
 	"github.com/gomcpgo/docgen/pkg/types"
 	"gopkg.in/yaml.v3"
 )
 
 // Export operations
 
-func (h *DocGenHandler) handleExportDocument(params map[string]interface{}) (*protocol.CallToolResponse, error) {
+func (h *DocGenHandler) handleExportDocument(ctx context.Context, clientID string, params map[string]interface{}) (*protocol.CallToolResponse, error) {
 	docID, err := h.getDocumentID(params)
 	if err != nil {
 		return h.errorResponse(fmt.Sprintf("Invalid document_id: %v", err))
@@ -29,10 +33,10 @@ func (h *DocGenHandler) handleExportDocument(params map[string]interface{}) (*pr
 
 	// Validate format
 	validFormats := map[string]bool{
-		"pdf": true, "docx": true, "html": true,
+		"pdf": true, "docx": true, "html": true, "epub": true, "txt": true, "ssml": true, "json": true, "revealjs": true, "pptx": true,
 	}
 	if !validFormats[format] {
-		return h.errorResponse("format must be one of: pdf, docx, html")
+		return h.errorResponse("format must be one of: pdf, docx, html, epub, txt, ssml, json, revealjs, pptx")
 	}
 
 	exportFormat := types.ExportFormat(format)
@@ -53,21 +57,703 @@ func (h *DocGenHandler) handleExportDocument(params map[string]interface{}) (*pr
 		}
 	}
 
-	// Load document manifest
+	// Get chapter range (optional)
+	if rangeParam, ok := params["chapter_range"].(string); ok && strings.TrimSpace(rangeParam) != "" {
+		if len(chapters) > 0 {
+			return h.errorResponse("chapters and chapter_range cannot both be given")
+		}
+		chapters, err = types.ParseChapterRange(rangeParam)
+		if err != nil {
+			return h.errorResponse(err.Error())
+		}
+	}
+
+	// Create export options
+	options := &types.ExportOptions{
+		Format:   exportFormat,
+		Chapters: chapters,
+	}
+
+	// Get review markings (optional)
+	if watermark, ok := params["watermark"].(string); ok {
+		options.Watermark = strings.TrimSpace(watermark)
+	}
+	if banner, ok := params["banner"].(string); ok {
+		options.Banner = strings.TrimSpace(banner)
+	}
+	if lineNumbers, ok := params["line_numbers"].(bool); ok {
+		options.LineNumbers = lineNumbers
+	}
+
+	// Get source embedding (optional)
+	if embedSource, ok := params["embed_source"].(bool); ok && embedSource {
+		if exportFormat != types.ExportFormatPDF {
+			return h.errorResponse("embed_source is only supported for PDF exports")
+		}
+		options.EmbedSource = true
+	}
+
+	// Get accessible export mode (optional)
+	if accessible, ok := params["accessible"].(bool); ok && accessible {
+		if exportFormat != types.ExportFormatPDF && exportFormat != types.ExportFormatHTML {
+			return h.errorResponse("accessible is only supported for PDF and HTML exports")
+		}
+		options.Accessible = true
+	}
+
+	// Get HTML fragment mode (optional)
+	fragment, _ := params["fragment"].(bool)
+	confluence, _ := params["confluence"].(bool)
+	offset, offsetGiven := params["heading_offset"].(float64)
+	imageBaseURL, _ := params["image_base_url"].(string)
+	if fragment || confluence {
+		if exportFormat != types.ExportFormatHTML {
+			return h.errorResponse("fragment and confluence are only supported for HTML exports")
+		}
+		options.Fragment = &types.HTMLFragment{
+			HeadingOffset: int(offset),
+			ImageBaseURL:  strings.TrimSpace(imageBaseURL),
+			Confluence:    confluence,
+		}
+		if err := options.Fragment.Validate(); err != nil {
+			return h.errorResponse(err.Error())
+		}
+	} else if offsetGiven || strings.TrimSpace(imageBaseURL) != "" {
+		return h.errorResponse("heading_offset and image_base_url only apply to fragments; set fragment or confluence")
+	}
+
+	// Get slide deck options (optional)
+	split, _ := params["slide_split"].(string)
+	incremental, _ := params["incremental"].(bool)
+	if exportFormat.IsSlides() {
+		options.Slides = &types.SlideOptions{Split: types.SlideSplitSection, Incremental: incremental}
+		if split != "" {
+			options.Slides.Split = types.SlideSplit(split)
+			if err := options.Slides.Split.Validate(); err != nil {
+				return h.errorResponse(err.Error())
+			}
+		}
+	} else if split != "" || incremental {
+		return h.errorResponse("slide_split and incremental are only supported for revealjs and pptx exports")
+	}
+
+	// Get abbreviations table (optional)
+	if abbreviations, ok := params["abbreviations"].(bool); ok {
+		options.Abbreviations = abbreviations
+	}
+
+	// Get list of listings (optional)
+	if listOfListings, ok := params["list_of_listings"].(bool); ok {
+		options.ListOfListings = listOfListings
+	}
+
+	// Get front matter badges (optional, only HTML and EPUB show them)
+	if keys, ok := params["front_matter_badges"].([]interface{}); ok {
+		for _, key := range keys {
+			if name, ok := key.(string); ok && name != "" {
+				options.FrontMatterBadges = append(options.FrontMatterBadges, name)
+			}
+		}
+	}
+
+	// Get workflow status options (optional)
+	options.ApprovedOnly, _ = params["approved_only"].(bool)
+	options.StatusStamps, _ = params["status_stamps"].(bool)
+
+	// Get float placement (optional)
+	if placement, ok := params["float_placement"].(string); ok && placement != "" {
+		options.FloatPlacement = types.FloatPlacement(placement)
+		if err := options.FloatPlacement.Validate(); err != nil {
+			return h.errorResponse(err.Error())
+		}
+		if exportFormat != types.ExportFormatPDF {
+			return h.errorResponse("float_placement is only supported for PDF exports")
+		}
+	}
+
+	// Get compression (optional)
+	if compression, ok := params["compression"].(string); ok && compression != "" {
+		options.Compression = types.ExportCompression(compression)
+		if err := options.Compression.Validate(); err != nil {
+			return h.errorResponse(err.Error())
+		}
+	}
+	if optimizePDF, ok := params["optimize_pdf"].(bool); ok && optimizePDF {
+		if exportFormat != types.ExportFormatPDF {
+			return h.errorResponse("optimize_pdf is only supported for PDF exports")
+		}
+		options.OptimizePDF = true
+	}
+
+	// Get PDF/A archival mode (optional)
+	if pdfa, ok := params["pdfa"].(bool); ok && pdfa {
+		if exportFormat != types.ExportFormatPDF {
+			return h.errorResponse("pdfa is only supported for PDF exports")
+		}
+		options.PDFA = true
+	}
+
+	// Get password protection (optional)
+	userPassword, _ := params["user_password"].(string)
+	ownerPassword, _ := params["owner_password"].(string)
+	allowPrint, printGiven := params["allow_print"].(bool)
+	allowCopy, copyGiven := params["allow_copy"].(bool)
+	if userPassword != "" || ownerPassword != "" || printGiven || copyGiven {
+		options.Protection = &types.ExportProtection{
+			UserPassword:  userPassword,
+			OwnerPassword: ownerPassword,
+			AllowPrint:    allowPrint || !printGiven,
+			AllowCopy:     allowCopy || !copyGiven,
+		}
+		if err := options.Protection.Validate(exportFormat); err != nil {
+			return h.errorResponse(err.Error())
+		}
+		if options.PDFA {
+			return h.errorResponse("PDF/A exports cannot be password-protected")
+		}
+	}
+
+	// Long exports wait for confirmation, so that a vague request doesn't start
+	// a build of many minutes
+	preflightOnly, _ := params["preflight"].(bool)
+	confirm, _ := params["confirm"].(bool)
+	if preflightOnly || (!confirm && h.config.PreflightThreshold > 0) {
+		preflight, err := h.exportPreflight(ctx, docID, options)
+		if err != nil {
+			return h.errorResponse(err.Error())
+		}
+		estimate := time.Duration(preflight.EstimatedSeconds) * time.Second
+		summary := fmt.Sprintf("Exporting %d chapter(s), about %d pages, to %s is estimated to take %s", preflight.Chapters, preflight.Pages, format, estimate)
+		if preflightOnly {
+			return h.successResponse(map[string]interface{}{
+				"document_id": docID,
+				"preflight":   preflight,
+				"message":     summary,
+			})
+		}
+		if estimate >= h.config.PreflightThreshold {
+			return h.successResponse(map[string]interface{}{
+				"document_id":           docID,
+				"preflight":             preflight,
+				"confirmation_required": true,
+				"message":               summary + ". Nothing was exported; call export_document again with confirm=true to proceed.",
+			})
+		}
+	}
+
+	// Check the client's export quota
+	if err := h.usage.CheckExport(clientID); err != nil {
+		return h.errorResponse(err.Error())
+	}
+	if err := h.manager.CheckStorageQuota(ctx, docID, 0); err != nil {
+		return h.errorResponse(err.Error())
+	}
+
+	// Export the document
+	result, err := h.exportDocument(ctx, docID, styleName, options)
+	if err != nil {
+		return h.errorResponse(err.Error())
+	}
+	outputPath := result.OutputPath
+
+	var size int64
+	if info, err := os.Stat(outputPath); err == nil {
+		size = info.Size()
+	}
+	if err := h.usage.RecordExport(clientID, size); err != nil {
+		log.Printf("[DOCGEN HANDLER] Failed to record usage: %v", err)
+	}
+
+	response := map[string]interface{}{
+		"output_path": outputPath,
+		"format":      format,
+		"message":     fmt.Sprintf("Document exported successfully to %s", outputPath),
+	}
+	if result.PDFEngine != "" {
+		response["pdf_engine"] = result.PDFEngine
+	}
+	if result.Optimized {
+		response["optimized"] = true
+	}
+	if result.Protected {
+		response["protected"] = true
+	}
+	if result.PDFA {
+		response["pdfa"] = true
+		response["pdfa_validated"] = result.PDFAValidated
+	}
+	if result.CompressedPath != "" {
+		response["compressed_path"] = result.CompressedPath
+		response["compressed_bytes"] = result.CompressedBytes
+		response["bytes"] = size
+	}
+	if len(result.Warnings) > 0 {
+		response["warnings"] = result.Warnings
+	}
+	if result.FallbackFrom != "" {
+		response["fallback_from"] = result.FallbackFrom
+		response["fallback_reason"] = result.FallbackReason
+		response["message"] = fmt.Sprintf("Document exported successfully to %s using %s after %s failed (%s); set pdf_engine to %s with configure_document to use it directly", outputPath, result.PDFEngine, result.FallbackFrom, result.FallbackReason, result.PDFEngine)
+	}
+
+	return h.successResponse(response)
+}
+
+func (h *DocGenHandler) handleCompileVolume(ctx context.Context, clientID string, params map[string]interface{}) (*protocol.CallToolResponse, error) {
+	volume := &types.Volume{Numbering: types.VolumeNumberingContinuous}
+
+	idsParam, _ := params["document_ids"].([]interface{})
+	seen := make(map[types.DocumentID]bool)
+	for _, id := range idsParam {
+		idStr, _ := id.(string)
+		docID := types.DocumentID(strings.TrimSpace(idStr))
+		if err := docID.Validate(); err != nil {
+			return h.errorResponse(fmt.Sprintf("Invalid document_ids: %v", err))
+		}
+		if seen[docID] {
+			return h.errorResponse(fmt.Sprintf("Document %s appears more than once in document_ids", docID))
+		}
+		seen[docID] = true
+		volume.Documents = append(volume.Documents, docID)
+	}
+	if len(volume.Documents) < 2 {
+		return h.errorResponse("document_ids needs at least two documents; use export_document for one")
+	}
+
+	title, _ := params["title"].(string)
+	volume.Title = strings.TrimSpace(title)
+	if volume.Title == "" {
+		return h.errorResponse("title parameter is required")
+	}
+
+	format, _ := params["format"].(string)
+	switch types.ExportFormat(format) {
+	case types.ExportFormatPDF, types.ExportFormatDOCX, types.ExportFormatHTML, types.ExportFormatEPUB:
+	default:
+		return h.errorResponse("format must be one of: pdf, docx, html, epub")
+	}
+
+	if numbering, ok := params["numbering"].(string); ok && numbering != "" {
+		volume.Numbering = types.VolumeNumbering(numbering)
+		if err := volume.Numbering.Validate(); err != nil {
+			return h.errorResponse(err.Error())
+		}
+	}
+
+	var styleName string
+	if styleParam, ok := params["style_name"].(string); ok {
+		styleName = strings.TrimSpace(styleParam)
+	}
+
+	// Load every document before compiling any of them
+	documents := make([]export.VolumeDocument, 0, len(volume.Documents))
+	for _, docID := range volume.Documents {
+		manifest, err := h.manager.GetDocumentStructure(docID)
+		if err != nil {
+			return h.errorResponse(fmt.Sprintf("Failed to load document %s: %v", docID, err))
+		}
+		if err := h.manager.SyncDocument(ctx, docID); err != nil {
+			return h.errorResponse(fmt.Sprintf("Failed to load document %s: %v", docID, err))
+		}
+		documents = append(documents, export.VolumeDocument{ID: string(docID), Manifest: manifest})
+	}
+
+	if err := h.usage.CheckExport(clientID); err != nil {
+		return h.errorResponse(err.Error())
+	}
+	if err := h.storage.EnsureDefaultStyle(); err != nil {
+		log.Printf("[DOCGEN HANDLER] Warning: Failed to ensure default style: %v", err)
+	}
+	style, _, err := h.resolveStyle(styleName)
+	if err != nil {
+		return h.errorResponse(fmt.Sprintf("Failed to load style: %v", err))
+	}
+	pandocConfig, _ := h.storage.LoadPandocConfig(string(volume.Documents[0]))
+
+	result, err := h.exporter.CompileVolume(ctx, volume, documents, style, pandocConfig, &types.ExportOptions{Format: types.ExportFormat(format)}, h.manager.RebuildChapterMarkdown)
+	if err != nil {
+		return h.errorResponse(fmt.Sprintf("Failed to compile volume: %v", err))
+	}
+
+	var size int64
+	if info, err := os.Stat(result.OutputPath); err == nil {
+		size = info.Size()
+	}
+	if err := h.usage.RecordExport(clientID, size); err != nil {
+		log.Printf("[DOCGEN HANDLER] Failed to record usage: %v", err)
+	}
+
+	response := map[string]interface{}{
+		"output_path": result.OutputPath,
+		"format":      format,
+		"documents":   volume.Documents,
+		"numbering":   volume.Numbering,
+		"message":     fmt.Sprintf("%d documents compiled into %s", len(documents), result.OutputPath),
+	}
+	if len(result.Warnings) > 0 {
+		response["warnings"] = result.Warnings
+	}
+	return h.successResponse(response)
+}
+
+func (h *DocGenHandler) handleReimportDocxFeedback(ctx context.Context, params map[string]interface{}) (*protocol.CallToolResponse, error) {
+	docID, err := h.getDocumentID(params)
+	if err != nil {
+		return h.errorResponse(fmt.Sprintf("Invalid document_id: %v", err))
+	}
+
+	// The document's own DOCX export unless another file is given; relative
+	// paths are in the exports directory
+	docxPath := h.config.ExportPath(string(docID), "docx")
+	if pathParam, ok := params["path"].(string); ok && strings.TrimSpace(pathParam) != "" {
+		docxPath, err = h.config.ResolvePath(strings.TrimSpace(pathParam), h.config.ExportsDir)
+		if err != nil {
+			return h.errorResponse(fmt.Sprintf("Invalid path: %v", err))
+		}
+	}
+	if _, err := os.Stat(docxPath); err != nil {
+		return h.errorResponse(fmt.Sprintf("DOCX not found: %s", docxPath))
+	}
+
+	if err := h.manager.SyncDocument(ctx, docID); err != nil {
+		return h.errorResponse(fmt.Sprintf("Failed to load document: %v", err))
+	}
 	manifest, err := h.manager.GetDocumentStructure(docID)
 	if err != nil {
 		return h.errorResponse(fmt.Sprintf("Failed to load document: %v", err))
 	}
 
+	feedback, err := h.exporter.ReadDOCXFeedback(ctx, docxPath, manifest, h.markdownProfile(docID))
+	if err != nil {
+		return h.errorResponse(fmt.Sprintf("Failed to read feedback: %v", err))
+	}
+
+	counts := make(map[types.FeedbackKind]int)
+	for _, item := range feedback.Items {
+		counts[item.Kind]++
+	}
+	result := map[string]interface{}{
+		"document_id": docID,
+		"path":        feedback.Path,
+		"items":       feedback.Items,
+		"unmapped":    feedback.Unmapped,
+		"message": fmt.Sprintf("%d insertion(s), %d deletion(s) and %d comment(s) read; %d section update(s) proposed",
+			counts[types.FeedbackInsertion], counts[types.FeedbackDeletion], counts[types.FeedbackComment], len(feedback.Proposals)),
+	}
+	if len(feedback.Proposals) > 0 {
+		result["proposals"] = feedback.Proposals
+		result["note"] = "Proposals are the sections as they read with the changes accepted; review them and apply them with update_section"
+	}
+	if len(feedback.Warnings) > 0 {
+		result["warnings"] = feedback.Warnings
+	}
+	return h.successResponse(result)
+}
+
+func (h *DocGenHandler) handleEstimatePages(ctx context.Context, params map[string]interface{}) (*protocol.CallToolResponse, error) {
+	docID, err := h.getDocumentID(params)
+	if err != nil {
+		return h.errorResponse(fmt.Sprintf("Invalid document_id: %v", err))
+	}
+
+	mode := types.PageEstimateApproximate
+	if modeParam, ok := params["mode"].(string); ok && modeParam != "" {
+		mode = types.PageEstimateMode(modeParam)
+	}
+	if err := mode.Validate(); err != nil {
+		return h.errorResponse(err.Error())
+	}
+	var chapters []types.ChapterNumber
+	if rangeParam, ok := params["chapter_range"].(string); ok && strings.TrimSpace(rangeParam) != "" {
+		chapters, err = types.ParseChapterRange(rangeParam)
+		if err != nil {
+			return h.errorResponse(err.Error())
+		}
+	}
+	styleName, _ := params["style_name"].(string)
+
+	manifest, err := h.manager.GetDocumentStructure(docID)
+	if err != nil {
+		return h.errorResponse(fmt.Sprintf("Failed to load document: %v", err))
+	}
+	for _, number := range chapters {
+		if _, ok := manifest.ChapterDir(number); !ok {
+			return h.errorResponse(fmt.Sprintf("Chapter %d not found", number))
+		}
+	}
+	if err := h.manager.SyncDocument(ctx, docID); err != nil {
+		return h.errorResponse(fmt.Sprintf("Failed to load document: %v", err))
+	}
+	if err := h.storage.EnsureDefaultStyle(); err != nil {
+		log.Printf("[DOCGEN HANDLER] Warning: Failed to ensure default style: %v", err)
+	}
+	style, _, err := h.resolveStyle(strings.TrimSpace(styleName))
+	if err != nil {
+		return h.errorResponse(fmt.Sprintf("Failed to load style: %v", err))
+	}
+	pandocConfig, _ := h.storage.LoadPandocConfig(string(docID))
+
+	var estimate *types.PageEstimate
+	if mode == types.PageEstimatePrecise {
+		estimate, err = h.exporter.CountPages(ctx, string(docID), manifest, style, pandocConfig, chapters, h.manager.RebuildChapterMarkdown)
+	} else {
+		estimate, err = h.exporter.EstimatePages(string(docID), manifest, style, pandocConfig, chapters, h.manager.RebuildChapterMarkdown)
+	}
+	if err != nil {
+		return h.errorResponse(fmt.Sprintf("Failed to count pages: %v", err))
+	}
+	return h.successResponse(map[string]interface{}{
+		"document_id": docID,
+		"estimate":    estimate,
+		"message":     fmt.Sprintf("About %d page(s) in all", estimate.Pages),
+	})
+}
+
+func (h *DocGenHandler) handleGetExportLog(params map[string]interface{}) (*protocol.CallToolResponse, error) {
+	docID, err := h.getDocumentID(params)
+	if err != nil {
+		return h.errorResponse(fmt.Sprintf("Invalid document_id: %v", err))
+	}
+
+	exportLog, err := h.exporter.LatestExportLog(string(docID))
+	if err != nil {
+		return h.errorResponse(fmt.Sprintf("Failed to get export log: %v", err))
+	}
+
+	return h.successResponse(map[string]interface{}{
+		"log":    exportLog,
+		"stdout": exportLog.Stdout,
+		"stderr": exportLog.Stderr,
+	})
+}
+
+func (h *DocGenHandler) handleListExports(params map[string]interface{}) (*protocol.CallToolResponse, error) {
+	docID, err := h.getDocumentID(params)
+	if err != nil {
+		return h.errorResponse(fmt.Sprintf("Invalid document_id: %v", err))
+	}
+
+	format, _ := params["format"].(string)
+	limit := 20
+	if val, ok := params["limit"].(float64); ok {
+		limit = int(val)
+	}
+	if limit < 0 {
+		return h.errorResponse("limit must not be negative")
+	}
+
+	exports, err := h.manager.ListExports(docID)
+	if err != nil {
+		return h.errorResponse(fmt.Sprintf("Failed to list exports: %v", err))
+	}
+
+	listed := make([]types.ExportEntry, 0, len(exports))
+	for _, entry := range exports {
+		if format != "" && string(entry.Format) != format {
+			continue
+		}
+		if limit > 0 && len(listed) == limit {
+			break
+		}
+		listed = append(listed, entry)
+	}
+
+	return h.successResponse(map[string]interface{}{
+		"exports": listed,
+		"total":   len(exports),
+		"message": fmt.Sprintf("Listed %d of %d exports", len(listed), len(exports)),
+	})
+}
+
+func (h *DocGenHandler) handleDeleteExport(params map[string]interface{}) (*protocol.CallToolResponse, error) {
+	docID, err := h.getDocumentID(params)
+	if err != nil {
+		return h.errorResponse(fmt.Sprintf("Invalid document_id: %v", err))
+	}
+
+	var ids []int
+	if val, ok := params["export_id"].(float64); ok {
+		ids = append(ids, int(val))
+	}
+	var before time.Time
+	if val, ok := params["older_than_days"].(float64); ok {
+		if val < 0 {
+			return h.errorResponse("older_than_days must not be negative")
+		}
+		before = time.Now().Add(-time.Duration(val * float64(24*time.Hour)))
+	}
+	if len(ids) == 0 && before.IsZero() {
+		return h.errorResponse("export_id or older_than_days is required")
+	}
+
+	deleted, freed, err := h.manager.DeleteExports(docID, ids, before)
+	if err != nil {
+		return h.errorResponse(fmt.Sprintf("Failed to delete exports: %v", err))
+	}
+
+	return h.successResponse(map[string]interface{}{
+		"deleted":     deleted,
+		"bytes_freed": freed,
+		"message":     fmt.Sprintf("Deleted %d exports, freeing %d bytes", len(deleted), freed),
+	})
+}
+
+func (h *DocGenHandler) handleResolveStyle(params map[string]interface{}) (*protocol.CallToolResponse, error) {
+	styleName, _ := params["style_name"].(string)
+	styleName = strings.TrimSpace(styleName)
+
+	// Ensure default style exists
+	if err := h.storage.EnsureDefaultStyle(); err != nil {
+		log.Printf("[DOCGEN HANDLER] Warning: Failed to ensure default style: %v", err)
+	}
+
+	resolved, chain, err := h.resolveStyle(styleName)
+	if err != nil {
+		return h.errorResponse(fmt.Sprintf("Failed to resolve style: %v", err))
+	}
+
+	return h.successResponse(map[string]interface{}{
+		"style":   resolved,
+		"chain":   chain,
+		"message": fmt.Sprintf("Style %s resolved", strings.Join(chain, " -> ")),
+	})
+}
+
+func (h *DocGenHandler) handleAddFont(ctx context.Context, params map[string]interface{}) (*protocol.CallToolResponse, error) {
+	sourcePath, ok := params["source_path"].(string)
+	if !ok || sourcePath == "" {
+		return h.errorResponse("source_path parameter is required")
+	}
+
+	// Without a document the font is shared by all documents
+	var docID types.DocumentID
+	if _, ok := params["document_id"]; ok {
+		var err error
+		if docID, err = h.getDocumentID(params); err != nil {
+			return h.errorResponse(fmt.Sprintf("Invalid document_id: %v", err))
+		}
+	}
+
+	name, err := h.manager.AddFont(ctx, docID, sourcePath)
+	if err != nil {
+		return h.errorResponse(fmt.Sprintf("Failed to add font: %v", err))
+	}
+
+	message := fmt.Sprintf("Font %s added to the shared fonts; name it in a style's font_file", name)
+	if docID != "" {
+		message = fmt.Sprintf("Font %s added to document %s; name it in a style's font_file", name, docID)
+	}
+	return h.successResponse(map[string]interface{}{
+		"name":    name,
+		"shared":  docID == "",
+		"message": message,
+	})
+}
+
+func (h *DocGenHandler) handleListFonts(params map[string]interface{}) (*protocol.CallToolResponse, error) {
+	var docID types.DocumentID
+	if _, ok := params["document_id"]; ok {
+		var err error
+		if docID, err = h.getDocumentID(params); err != nil {
+			return h.errorResponse(fmt.Sprintf("Invalid document_id: %v", err))
+		}
+	}
+
+	fonts, err := h.manager.ListFonts(docID)
+	if err != nil {
+		return h.errorResponse(fmt.Sprintf("Failed to list fonts: %v", err))
+	}
+	return h.successResponse(map[string]interface{}{
+		"fonts":   fonts,
+		"message": fmt.Sprintf("Found %d fonts", len(fonts)),
+	})
+}
+
+// exportPreflight loads a document and summarizes an export of it
+func (h *DocGenHandler) exportPreflight(ctx context.Context, docID types.DocumentID, options *types.ExportOptions) (*types.ExportPreflight, error) {
+	manifest, err := h.manager.GetDocumentStructure(docID)
+	if err != nil {
+		return nil, fmt.Errorf("Failed to load document: %w", err)
+	}
+	if err := h.manager.SyncDocument(ctx, docID); err != nil {
+		return nil, fmt.Errorf("Failed to load document: %w", err)
+	}
+	return h.exporter.Preflight(string(docID), manifest, options), nil
+}
+
+// ExportDocument exports a whole document with its resolved style. It is used by
+// background exporters such as the watcher.
+func (h *DocGenHandler) ExportDocument(ctx context.Context, documentID string, format types.ExportFormat) (string, error) {
+	result, err := h.exportDocument(ctx, types.DocumentID(documentID), "", &types.ExportOptions{Format: format})
+	if err != nil {
+		return "", err
+	}
+	return result.OutputPath, nil
+}
+
+// RenderApproximateHTML renders a whole document as HTML without pandoc, for
+// previews on machines where pandoc is not installed
+func (h *DocGenHandler) RenderApproximateHTML(ctx context.Context, documentID string) ([]byte, error) {
+	manifest, err := h.manager.GetDocumentStructure(types.DocumentID(documentID))
+	if err != nil {
+		return nil, fmt.Errorf("Failed to load document: %w", err)
+	}
+	if err := h.manager.SyncDocument(ctx, types.DocumentID(documentID)); err != nil {
+		return nil, fmt.Errorf("Failed to load document: %w", err)
+	}
+	return h.exporter.RenderApproximateDocument(ctx, documentID, manifest, h.markdownProfile(types.DocumentID(documentID)), h.manager.RebuildChapterMarkdown)
+}
+
+// markdownSettings reads markdown extension switches given under param and
+// checks that the installed pandoc supports them
+func (h *DocGenHandler) markdownSettings(values map[string]interface{}, param string) (map[string]bool, error) {
+	settings := make(map[string]bool)
+	names := make([]string, 0, len(values))
+	for extension, value := range values {
+		enabled, ok := value.(bool)
+		if !ok {
+			return nil, fmt.Errorf("%s.%s must be true or false", param, extension)
+		}
+		settings[extension] = enabled
+		names = append(names, extension)
+	}
+	if err := h.exporter.CheckMarkdownExtensions(names); err != nil {
+		return nil, err
+	}
+	return settings, nil
+}
+
+// markdownProfile returns the document's markdown profile, or nil for pandoc's defaults
+func (h *DocGenHandler) markdownProfile(docID types.DocumentID) *types.MarkdownProfile {
+	pandocConfig, err := h.storage.LoadPandocConfig(string(docID))
+	if err != nil {
+		return nil
+	}
+	return pandocConfig.Markdown
+}
+
+// exportDocument loads a document's manifest, style and pandoc config and exports it
+func (h *DocGenHandler) exportDocument(ctx context.Context, docID types.DocumentID, styleName string, options *types.ExportOptions) (*types.ExportResult, error) {
+	// Load document manifest
+	manifest, err := h.manager.GetDocumentStructure(docID)
+	if err != nil {
+		return nil, fmt.Errorf("Failed to load document: %w", err)
+	}
+
+	// Exports read the document's local files
+	if err := h.manager.SyncDocument(ctx, docID); err != nil {
+		return nil, fmt.Errorf("Failed to load document: %w", err)
+	}
+
 	// Ensure default style exists
 	if err := h.storage.EnsureDefaultStyle(); err != nil {
 		log.Printf("[DOCGEN HANDLER] Warning: Failed to ensure default style: %v", err)
 	}
 
 	// Load style using enhanced resolution logic
-	style, err := h.resolveStyle(styleName)
+	style, chain, err := h.resolveStyle(styleName)
 	if err != nil {
-		return h.errorResponse(fmt.Sprintf("Failed to load style: %v", err))
+		return nil, fmt.Errorf("Failed to load style: %w", err)
 	}
 	
 	// Load pandoc config (can be nil)
@@ -85,27 +771,96 @@ func (h *DocGenHandler) handleExportDocument(params map[string]interface{}) (*pr
 		log.Printf("[DOCGEN HANDLER] No style loaded for document %s, using defaults", docID)
 	}
 
-	// Create export options
-	options := &types.ExportOptions{
-		Format:   exportFormat,
-		Chapters: chapters,
+	// Export hooks are kept on disk and only run when enabled
+	if h.config.HooksEnabled {
+		hooks, err := h.loadHooks(docID)
+		if err != nil {
+			return nil, fmt.Errorf("Failed to load hooks: %w", err)
+		}
+		hookedOptions := *options
+		hookedOptions.Hooks = hooks
+		options = &hookedOptions
+	}
+
+	// JSON exports write the document with its section contents
+	if options.Format == types.ExportFormatJSON {
+		doc, err := h.manager.DocumentJSON(ctx, docID)
+		if err != nil {
+			return nil, fmt.Errorf("Failed to load document: %w", err)
+		}
+		jsonOptions := *options
+		jsonOptions.DocumentJSON = doc
+		options = &jsonOptions
 	}
 
 	// Export the document
-	outputPath, err := h.exporter.ExportDocument(string(docID), manifest, style, pandocConfig, options, h.manager.RebuildChapterMarkdown)
+	started := time.Now()
+	result, err := h.exporter.ExportDocumentResult(ctx, string(docID), manifest, style, pandocConfig, options, h.manager.RebuildChapterMarkdown)
+	h.recordExport(docID, chain, options, started, result, err)
 	if err != nil {
-		return h.errorResponse(fmt.Sprintf("Failed to export document: %v", err))
+		return nil, fmt.Errorf("Failed to export document: %w", err)
 	}
 
-	return h.successResponse(map[string]interface{}{
-		"output_path": outputPath,
-		"format":      format,
-		"message":     fmt.Sprintf("Document exported successfully to %s", outputPath),
-	})
+	// Record the export in the ledger for regulated users
+	if h.config.Notarize {
+		if _, err := h.notarizeExport(docID, options.Format, result.OutputPath, style); err != nil {
+			return nil, fmt.Errorf("Document exported to %s but could not be notarized: %w", result.OutputPath, err)
+		}
+	}
+
+	return result, nil
+}
+
+// loadHooks returns the hooks run for every document followed by the
+// document's own, when the root hooks file opts the document in
+func (h *DocGenHandler) loadHooks(docID types.DocumentID) ([]types.Hook, error) {
+	global, err := h.storage.LoadHooks()
+	if err != nil {
+		return nil, err
+	}
+	document, err := h.storage.LoadDocumentHooks(string(docID))
+	if err != nil {
+		return nil, err
+	}
+	if !global.AllowsDocumentHooks(docID) {
+		if len(document.Hooks) > 0 {
+			log.Printf("[DOCGEN HOOKS] Ignoring the hooks of %s: it is not listed in document_hooks of %s", docID, h.config.HooksPath())
+		}
+		return global.Hooks, nil
+	}
+	return append(global.Hooks, document.Hooks...), nil
+}
+
+// recordExport adds an export to the document's export history. An export
+// that can't be recorded still succeeds.
+func (h *DocGenHandler) recordExport(docID types.DocumentID, chain []string, options *types.ExportOptions, started time.Time, result *types.ExportResult, exportErr error) {
+	entry := types.ExportEntry{
+		Time:       started,
+		Format:     options.Format,
+		Chapters:   options.Chapters,
+		DurationMS: time.Since(started).Milliseconds(),
+		Success:    exportErr == nil,
+	}
+	if len(chain) > 0 {
+		entry.Style = chain[0]
+	}
+	if exportErr != nil {
+		entry.Error = exportErr.Error()
+	}
+	if result != nil {
+		entry.OutputPath = result.OutputPath
+		entry.CompressedPath = result.CompressedPath
+		if info, err := os.Stat(result.OutputPath); err == nil {
+			entry.Bytes = info.Size()
+		}
+	}
+	if _, err := h.manager.RecordExport(docID, entry); err != nil {
+		log.Printf("[DOCGEN HANDLER] Warning: Failed to record export of %s: %v", docID, err)
+	}
 }
 
 
-func (h *DocGenHandler) handleValidateDocument(params map[string]interface{}) (*protocol.CallToolResponse, error) {
+func (h *DocGenHandler) handleValidateDocument(ctx context.Context, params map[string]interface{}) (*protocol.CallToolResponse, error) {
 	docID, err := h.getDocumentID(params)
 	if err != nil {
 		return h.errorResponse(fmt.Sprintf("Invalid document_id: %v", err))
@@ -116,9 +871,46 @@ func (h *DocGenHandler) handleValidateDocument(params map[string]interface{}) (*
 	if err != nil {
 		return h.errorResponse(fmt.Sprintf("Failed to load document: %v", err))
 	}
+	if err := h.manager.SyncDocument(ctx, docID); err != nil {
+		return h.errorResponse(fmt.Sprintf("Failed to load document: %v", err))
+	}
+
+	// Strict validation also fails on unresolved TODO items
+	strict, _ := params["strict"].(bool)
 
 	// Validate the document
-	report := h.exporter.ValidateDocument(string(docID), manifest)
+	var report *types.ValidationReport
+	if strict {
+		report = h.exporter.ValidateDocumentStrict(string(docID), manifest)
+	} else {
+		report = h.exporter.ValidateDocument(string(docID), manifest)
+	}
+
+	// Style checks run against the style exports would use
+	format, _ := params["format"].(string)
+	accessibility, _ := params["accessibility"].(bool)
+	var style *types.Style
+	if accessibility || format == string(types.ExportFormatPDF) {
+		styleName, _ := params["style_name"].(string)
+		style, _, err = h.resolveStyle(styleName)
+		if err != nil && styleName != "" {
+			return h.errorResponse(fmt.Sprintf("Failed to load style: %v", err))
+		}
+	}
+
+	// Format-specific checks (optional)
+	switch format {
+	case string(types.ExportFormatEPUB):
+		h.exporter.ValidateEPUB(ctx, string(docID), manifest, report)
+	case string(types.ExportFormatPDF):
+		h.exporter.ValidateFonts(ctx, string(docID), style, report)
+	}
+
+	// Accessibility checks (optional). Without a style to check, colors are
+	// left out of them.
+	if accessibility {
+		h.exporter.ValidateAccessibility(string(docID), manifest, style, report)
+	}
 
 	return h.successResponse(map[string]interface{}{
 		"validation_report": report,
@@ -126,16 +918,14 @@ func (h *DocGenHandler) handleValidateDocument(params map[string]interface{}) (*
 	})
 }
 
-// resolveStyle implements the enhanced style resolution logic
-func (h *DocGenHandler) resolveStyle(styleName string) (*types.Style, error) {
+// resolveStyle implements the enhanced style resolution logic. Styles are
+// flattened with the styles they extend; the chain of styles used is returned
+// along with the effective style.
+func (h *DocGenHandler) resolveStyle(styleName string) (*types.Style, []string, error) {
 	// Priority 1: If style_name parameter provided, use it
 	if styleName != "" {
 		log.Printf("[DOCGEN HANDLER] Using provided style name: %s", styleName)
-		style, err := h.storage.LoadStyleByName(styleName)
-		if err != nil {
-			return nil, fmt.Errorf("style '%s' not found: %w", styleName, err)
-		}
-		return style, nil
+		return style.Resolve(h.storage, styleName)
 	}
 	
 	// Priority 2: Check DOCGEN_CURRENT_STYLE environment variable
@@ -144,70 +934,81 @@ func (h *DocGenHandler) resolveStyle(styleName string) (*types.Style, error) {
 		log.Printf("[DOCGEN HANDLER] Found DOCGEN_CURRENT_STYLE: %s", currentStyle)
 		
 		// First try as style name (styles/{currentStyle}.yaml)
-		style, err := h.storage.LoadStyleByName(currentStyle)
-		if err == nil {
-			log.Printf("[DOCGEN HANDLER] Successfully loaded style by name: %s", currentStyle)
-			return style, nil
+		if _, err := h.storage.LoadStyleFields(currentStyle); err == nil {
+			log.Printf("[DOCGEN HANDLER] Loading style by name: %s", currentStyle)
+			return style.Resolve(h.storage, currentStyle)
 		}
 		
 		// If that fails, try as file path
 		if strings.Contains(currentStyle, "/") || strings.Contains(currentStyle, "\\") {
 			log.Printf("[DOCGEN HANDLER] Trying DOCGEN_CURRENT_STYLE as file path: %s", currentStyle)
-			style, err := h.loadStyleFromFile(currentStyle)
+			resolved, chain, err := h.loadStyleFromFile(currentStyle)
 			if err != nil {
-				return nil, fmt.Errorf("failed to load style from path '%s': %w", currentStyle, err)
+				return nil, nil, fmt.Errorf("failed to load style from path '%s': %w", currentStyle, err)
 			}
 			log.Printf("[DOCGEN HANDLER] Successfully loaded style from file path: %s", currentStyle)
-			return style, nil
+			return resolved, chain, nil
 		}
 		
 		// Neither worked
-		return nil, fmt.Errorf("style '%s' not found as name or file path", currentStyle)
+		return nil, nil, fmt.Errorf("style '%s' not found as name or file path", currentStyle)
 	}
 	
 	// Priority 3: Use default style (auto-created if needed)
 	log.Printf("[DOCGEN HANDLER] Using default style")
-	style, err := h.storage.LoadStyleByName("default")
+	resolved, chain, err := style.Resolve(h.storage, "default")
 	if err != nil {
-		return nil, fmt.Errorf("default style not found: %w", err)
+		return nil, nil, fmt.Errorf("default style not found: %w", err)
 	}
-	return style, nil
+	return resolved, chain, nil
 }
 
 // loadStyleFromFile loads a style from a file path (supports JSON and YAML)
-func (h *DocGenHandler) loadStyleFromFile(filePath string) (*types.Style, error) {
+// and flattens it with the named styles it extends
+func (h *DocGenHandler) loadStyleFromFile(filePath string) (*types.Style, []string, error) {
+	// Only style files inside the allowed directories may be read
+	filePath, err := h.config.ResolvePath(filePath, h.config.RootDir)
+	if err != nil {
+		return nil, nil, fmt.Errorf("invalid style file path: %w", err)
+	}
+
 	// Check if file exists
 	if _, err := os.Stat(filePath); os.IsNotExist(err) {
-		return nil, fmt.Errorf("style file not found: %s", filePath)
+		return nil, nil, fmt.Errorf("style file not found: %s", filePath)
 	}
 
 	// Read file content
 	content, err := os.ReadFile(filePath)
 	if err != nil {
-		return nil, fmt.Errorf("failed to read style file: %w", err)
+		return nil, nil, fmt.Errorf("failed to read style file: %w", err)
 	}
 
-	var style types.Style
+	// Read the fields the file sets, so those it leaves out are inherited
+	fields := make(map[string]interface{})
 	
 	// Determine format by file extension
 	ext := strings.ToLower(filepath.Ext(filePath))
 	switch ext {
 	case ".json":
-		if err := json.Unmarshal(content, &style); err != nil {
-			return nil, fmt.Errorf("failed to parse JSON style file: %w", err)
+		if err := json.Unmarshal(content, &fields); err != nil {
+			return nil, nil, fmt.Errorf("failed to parse JSON style file: %w", err)
 		}
 	case ".yaml", ".yml":
-		if err := yaml.Unmarshal(content, &style); err != nil {
-			return nil, fmt.Errorf("failed to parse YAML style file: %w", err)
+		if err := yaml.Unmarshal(content, &fields); err != nil {
+			return nil, nil, fmt.Errorf("failed to parse YAML style file: %w", err)
 		}
 	default:
 		// Try JSON first, then YAML
-		if err := json.Unmarshal(content, &style); err != nil {
-			if err2 := yaml.Unmarshal(content, &style); err2 != nil {
-				return nil, fmt.Errorf("failed to parse style file as JSON or YAML: JSON error: %v, YAML error: %v", err, err2)
+		if err := json.Unmarshal(content, &fields); err != nil {
+			if err2 := yaml.Unmarshal(content, &fields); err2 != nil {
+				return nil, nil, fmt.Errorf("failed to parse style file as JSON or YAML: JSON error: %v, YAML error: %v", err, err2)
 			}
 		}
 	}
 
-	return &style, nil
+	resolved, chain, err := style.ResolveFields(h.storage, fields)
+	if err != nil {
+		return nil, nil, err
+	}
+	return resolved, append([]string{filePath}, chain...), nil
 }
\ No newline at end of file
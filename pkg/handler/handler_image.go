@@ -1,9 +1,13 @@
 package handler
 
 import (
+	"context"
+	"encoding/json"
 	"fmt"
+	"strings"
 
 	"github.com/gomcpgo/mcp/pkg/protocol"
+	"github.com/gomcpgo/docgen/pkg/document"
 	"github.com/gomcpgo/docgen/pkg/types"
 )
 
@@ -28,28 +32,132 @@ func (h *DocGenHandler) handleAddImage(params map[string]interface{}) (*protocol
 		return h.errorResponse("image_path parameter is required")
 	}
 
+	// Get caption (optional, a placeholder flagged as TODO is used when omitted)
+	caption, _ := params["caption"].(string)
+
+	// Get position (optional, defaults to "here")
+	position := "here"
+	if pos, ok := params["position"].(string); ok && pos != "" {
+		position = pos
+	}
+
+	// Get width and alignment (optional, the image's natural size, centered)
+	width, _ := params["width"].(string)
+	alignment, _ := params["alignment"].(string)
+
+	// Add the image
+	figureID, err := h.manager.AddImage(docID, chapterNum, imagePath, caption, position, width, alignment)
+	if err != nil {
+		return h.errorResponse(fmt.Sprintf("Failed to add image: %v", err))
+	}
+
+	if caption == "" {
+		return h.successResponse(map[string]interface{}{
+			"document_id":  docID,
+			"figure_id":    figureID,
+			"caption_todo": true,
+			"message":      fmt.Sprintf("Image added successfully with ID %s using a placeholder caption; use update_image_caption to set it", figureID),
+		})
+	}
+
+	return h.successResponse(map[string]interface{}{
+		"document_id": docID,
+		"figure_id":   figureID,
+		"message":     fmt.Sprintf("Image added successfully with ID %s", figureID),
+	})
+}
+
+func (h *DocGenHandler) handleAddFigureGrid(params map[string]interface{}) (*protocol.CallToolResponse, error) {
+	// Get document ID
+	docID, err := h.getDocumentID(params)
+	if err != nil {
+		return h.errorResponse(fmt.Sprintf("Invalid document_id: %v", err))
+	}
+
+	// Get chapter number
+	chapterNum, err := h.getChapterNumber(params)
+	if err != nil {
+		return h.errorResponse(fmt.Sprintf("Invalid chapter_number: %v", err))
+	}
+
 	// Get caption
 	caption, ok := params["caption"].(string)
 	if !ok || caption == "" {
 		return h.errorResponse("caption parameter is required")
 	}
 
+	// Get images, decoded through JSON into the sub-figure definitions
+	imagesRaw, ok := params["images"].([]interface{})
+	if !ok {
+		return h.errorResponse("images parameter is required")
+	}
+	imagesJSON, err := json.Marshal(imagesRaw)
+	if err != nil {
+		return h.errorResponse(fmt.Sprintf("Invalid images: %v", err))
+	}
+	var subFigures []types.SubFigure
+	if err := json.Unmarshal(imagesJSON, &subFigures); err != nil {
+		return h.errorResponse(fmt.Sprintf("Invalid images: %v", err))
+	}
+
+	// Get columns (optional)
+	columns := 0
+	if val, ok := params["columns"].(float64); ok {
+		columns = int(val)
+	}
+
 	// Get position (optional, defaults to "here")
 	position := "here"
 	if pos, ok := params["position"].(string); ok && pos != "" {
 		position = pos
 	}
 
-	// Add the image
-	figureID, err := h.manager.AddImage(docID, chapterNum, imagePath, caption, position)
+	figureID, markup, err := h.manager.AddFigureGrid(docID, chapterNum, caption, subFigures, columns, position)
 	if err != nil {
-		return h.errorResponse(fmt.Sprintf("Failed to add image: %v", err))
+		return h.errorResponse(fmt.Sprintf("Failed to add figure grid: %v", err))
 	}
 
 	return h.successResponse(map[string]interface{}{
 		"document_id": docID,
 		"figure_id":   figureID,
-		"message":     fmt.Sprintf("Image added successfully with ID %s", figureID),
+		"markup":      markup,
+		"message":     fmt.Sprintf("Figure grid added with ID %s; put the markup in a section where the figure belongs", figureID),
+	})
+}
+
+func (h *DocGenHandler) handleAddListing(params map[string]interface{}) (*protocol.CallToolResponse, error) {
+	// Get document ID
+	docID, err := h.getDocumentID(params)
+	if err != nil {
+		return h.errorResponse(fmt.Sprintf("Invalid document_id: %v", err))
+	}
+
+	// Get chapter number
+	chapterNum, err := h.getChapterNumber(params)
+	if err != nil {
+		return h.errorResponse(fmt.Sprintf("Invalid chapter_number: %v", err))
+	}
+
+	// Get caption
+	caption, ok := params["caption"].(string)
+	if !ok || caption == "" {
+		return h.errorResponse("caption parameter is required")
+	}
+
+	// Get language and code (optional)
+	language, _ := params["language"].(string)
+	code, _ := params["code"].(string)
+
+	listingID, markup, err := h.manager.AddListing(docID, chapterNum, caption, language, code)
+	if err != nil {
+		return h.errorResponse(fmt.Sprintf("Failed to add listing: %v", err))
+	}
+
+	return h.successResponse(map[string]interface{}{
+		"document_id": docID,
+		"listing_id":  listingID,
+		"markup":      markup,
+		"message":     fmt.Sprintf("Listing added with ID %s; put the markup in a section where the listing belongs and refer to it as @%s", listingID, listingID),
 	})
 }
 
@@ -85,6 +193,223 @@ func (h *DocGenHandler) handleUpdateImageCaption(params map[string]interface{})
 	})
 }
 
+func (h *DocGenHandler) handleUpdateImageProperties(params map[string]interface{}) (*protocol.CallToolResponse, error) {
+	// Get document ID
+	docID, err := h.getDocumentID(params)
+	if err != nil {
+		return h.errorResponse(fmt.Sprintf("Invalid document_id: %v", err))
+	}
+
+	// Get figure ID
+	figureID, ok := params["figure_id"].(string)
+	if !ok || figureID == "" {
+		return h.errorResponse("figure_id parameter is required")
+	}
+
+	// Get the properties to change; those left out are kept
+	properties := types.ImageProperties{}
+	if width, ok := params["width"].(string); ok {
+		properties.Width = width
+	}
+	if alignment, ok := params["alignment"].(string); ok {
+		properties.Alignment = types.ImageAlignment(alignment)
+	}
+	if position, ok := params["position"].(string); ok {
+		properties.Position = types.ImagePosition(position)
+	}
+
+	figure, err := h.manager.UpdateImageProperties(docID, types.FigureID(figureID), properties)
+	if err != nil {
+		return h.errorResponse(fmt.Sprintf("Failed to update image properties: %v", err))
+	}
+
+	return h.successResponse(map[string]interface{}{
+		"document_id": docID,
+		"figure_id":   figureID,
+		"width":       figure.Width,
+		"alignment":   figure.Alignment,
+		"position":    figure.Position,
+		"message":     fmt.Sprintf("Image properties updated successfully for %s", figureID),
+	})
+}
+
+// handleSetFigureAnchor anchors a figure or table after a paragraph of a
+// section, or clears its anchor when no section_number is given
+func (h *DocGenHandler) handleSetFigureAnchor(params map[string]interface{}) (*protocol.CallToolResponse, error) {
+	docID, err := h.getDocumentID(params)
+	if err != nil {
+		return h.errorResponse(fmt.Sprintf("Invalid document_id: %v", err))
+	}
+	id, ok := params["id"].(string)
+	if !ok || id == "" {
+		return h.errorResponse("id parameter is required")
+	}
+
+	var anchor *types.FigureAnchor
+	sectionNumStr, _ := params["section_number"].(string)
+	if sectionNumStr != "" {
+		sectionNum, err := h.parseSectionNumber(sectionNumStr)
+		if err != nil {
+			return h.errorResponse(fmt.Sprintf("Invalid section_number format: %v", err))
+		}
+		afterParagraph, _ := params["after_paragraph"].(float64)
+		anchor = &types.FigureAnchor{Section: sectionNum, Paragraph: int(afterParagraph)}
+	}
+
+	placedByHand, err := h.manager.SetFigureAnchor(docID, id, anchor)
+	if err != nil {
+		return h.errorResponse(fmt.Sprintf("Failed to set figure anchor: %v", err))
+	}
+
+	result := map[string]interface{}{
+		"document_id": docID,
+		"id":          id,
+		"anchor":      anchor,
+		"message":     fmt.Sprintf("Anchor of %s cleared", id),
+	}
+	if anchor != nil {
+		result["message"] = fmt.Sprintf("%s anchored after paragraph %d of section %s", id, anchor.Paragraph, sectionNumStr)
+		if anchor.Paragraph == 0 {
+			result["message"] = fmt.Sprintf("%s anchored at the start of section %s", id, sectionNumStr)
+		}
+	}
+	if placedByHand {
+		result["placed_by_hand"] = true
+		result["message"] = fmt.Sprintf("%s; the content already places %s with {#%s}, which takes precedence until it is removed", result["message"], id, id)
+	}
+	return h.successResponse(result)
+}
+
+func (h *DocGenHandler) handleCreateTableFromCSV(params map[string]interface{}) (*protocol.CallToolResponse, error) {
+	docID, err := h.getDocumentID(params)
+	if err != nil {
+		return h.errorResponse(fmt.Sprintf("Invalid document_id: %v", err))
+	}
+	sectionNumStr, ok := params["section_number"].(string)
+	if !ok || sectionNumStr == "" {
+		return h.errorResponse("section_number parameter is required")
+	}
+	sectionNum, err := h.parseSectionNumber(sectionNumStr)
+	if err != nil {
+		return h.errorResponse(fmt.Sprintf("Invalid section_number format: %v", err))
+	}
+	caption, ok := params["caption"].(string)
+	if !ok || strings.TrimSpace(caption) == "" {
+		return h.errorResponse("caption parameter is required")
+	}
+	csvData, ok := params["data"].(string)
+	if !ok || strings.TrimSpace(csvData) == "" {
+		return h.errorResponse("data parameter is required")
+	}
+	delimiter, _ := params["delimiter"].(string)
+	format, _ := params["format"].(string)
+
+	// Without a position the table goes after the section's last paragraph
+	afterParagraph := -1
+	if value, ok := params["after_paragraph"].(float64); ok {
+		afterParagraph = int(value)
+	}
+
+	data, err := document.ParseDelimitedTable(csvData, delimiter)
+	if err != nil {
+		return h.errorResponse(err.Error())
+	}
+	if alignments, ok := params["alignments"].([]interface{}); ok {
+		for _, alignment := range alignments {
+			data.Alignments = append(data.Alignments, types.ColumnAlignment(fmt.Sprint(alignment)))
+		}
+	}
+
+	table, err := h.manager.AddTable(docID, sectionNum, afterParagraph, caption, format, data)
+	if err != nil {
+		return h.errorResponse(fmt.Sprintf("Failed to create table: %v", err))
+	}
+
+	return h.successResponse(map[string]interface{}{
+		"document_id": docID,
+		"table_id":    table.ID,
+		"columns":     data.Columns(),
+		"rows":        len(data.Rows),
+		"anchor":      table.Anchor,
+		"message":     fmt.Sprintf("Table %s with %d row(s) placed after paragraph %d of section %s; refer to it as @%s and change its cells with update_table_data", table.ID, len(data.Rows), table.Anchor.Paragraph, sectionNumStr, table.ID),
+	})
+}
+
+func (h *DocGenHandler) handleUpdateTableData(params map[string]interface{}) (*protocol.CallToolResponse, error) {
+	docID, err := h.getDocumentID(params)
+	if err != nil {
+		return h.errorResponse(fmt.Sprintf("Invalid document_id: %v", err))
+	}
+	tableID, ok := params["table_id"].(string)
+	if !ok || tableID == "" {
+		return h.errorResponse("table_id parameter is required")
+	}
+
+	// Cell values may come as numbers, which are written as they print
+	cellValue := func(value interface{}) string {
+		if value == nil {
+			return ""
+		}
+		return fmt.Sprint(value)
+	}
+	cellValues := func(values []interface{}) []string {
+		cells := make([]string, len(values))
+		for i, value := range values {
+			cells[i] = cellValue(value)
+		}
+		return cells
+	}
+
+	var update types.TableUpdate
+	if columns, ok := params["add_columns"].([]interface{}); ok {
+		for _, item := range columns {
+			column, ok := item.(map[string]interface{})
+			if !ok {
+				return h.errorResponse("add_columns must be an array of objects with header and values")
+			}
+			values, _ := column["values"].([]interface{})
+			update.AddColumns = append(update.AddColumns, types.TableColumn{Header: cellValue(column["header"]), Values: cellValues(values)})
+		}
+	}
+	if rows, ok := params["add_rows"].([]interface{}); ok {
+		for _, item := range rows {
+			row, ok := item.([]interface{})
+			if !ok {
+				return h.errorResponse("add_rows must be an array of arrays of cell values")
+			}
+			update.AddRows = append(update.AddRows, cellValues(row))
+		}
+	}
+	if cells, ok := params["cells"].([]interface{}); ok {
+		for _, item := range cells {
+			cell, ok := item.(map[string]interface{})
+			if !ok {
+				return h.errorResponse("cells must be an array of objects with row, column and value")
+			}
+			row, rowOK := cell["row"].(float64)
+			column, columnOK := cell["column"].(float64)
+			if !rowOK || !columnOK {
+				return h.errorResponse("each cell needs a row and a column")
+			}
+			update.Cells = append(update.Cells, types.TableCell{Row: int(row), Column: int(column), Value: cellValue(cell["value"])})
+		}
+	}
+
+	table, err := h.manager.UpdateTableData(docID, types.TableID(tableID), update)
+	if err != nil {
+		return h.errorResponse(fmt.Sprintf("Failed to update table: %v", err))
+	}
+
+	return h.successResponse(map[string]interface{}{
+		"document_id": docID,
+		"table_id":    table.ID,
+		"columns":     table.Data.Columns(),
+		"rows":        len(table.Data.Rows),
+		"content":     table.Content,
+		"message":     fmt.Sprintf("Table %s updated", table.ID),
+	})
+}
+
 func (h *DocGenHandler) handleDeleteImage(params map[string]interface{}) (*protocol.CallToolResponse, error) {
 	// Get document ID
 	docID, err := h.getDocumentID(params)
@@ -109,4 +434,134 @@ func (h *DocGenHandler) handleDeleteImage(params map[string]interface{}) (*proto
 		"figure_id":   figureID,
 		"message":     fmt.Sprintf("Image %s deleted successfully", figureID),
 	})
-}
\ No newline at end of file
+}
+func (h *DocGenHandler) handleAnnotateImage(ctx context.Context, params map[string]interface{}) (*protocol.CallToolResponse, error) {
+	// Get document ID
+	docID, err := h.getDocumentID(params)
+	if err != nil {
+		return h.errorResponse(fmt.Sprintf("Invalid document_id: %v", err))
+	}
+
+	// Get image
+	image, ok := params["image"].(string)
+	if !ok || image == "" {
+		return h.errorResponse("image parameter is required")
+	}
+
+	// Get annotations, decoded through JSON into the annotation definitions
+	annotationsRaw, ok := params["annotations"].([]interface{})
+	if !ok || len(annotationsRaw) == 0 {
+		return h.errorResponse("annotations parameter is required")
+	}
+	annotationsJSON, err := json.Marshal(annotationsRaw)
+	if err != nil {
+		return h.errorResponse(fmt.Sprintf("Invalid annotations: %v", err))
+	}
+	var annotations []types.Annotation
+	if err := json.Unmarshal(annotationsJSON, &annotations); err != nil {
+		return h.errorResponse(fmt.Sprintf("Invalid annotations: %v", err))
+	}
+
+	// Get output name (optional)
+	outputName, _ := params["output_name"].(string)
+
+	imagePath, err := h.manager.AnnotateImage(ctx, docID, image, annotations, outputName)
+	if err != nil {
+		return h.errorResponse(fmt.Sprintf("Failed to annotate image: %v", err))
+	}
+
+	return h.successResponse(map[string]interface{}{
+		"document_id": docID,
+		"image_path":  imagePath,
+		"annotations": len(annotations),
+		"message":     fmt.Sprintf("Annotated image saved as %s; use add_image to add it as a figure", imagePath),
+	})
+}
+
+func (h *DocGenHandler) handleCheckAssets(ctx context.Context, params map[string]interface{}) (*protocol.CallToolResponse, error) {
+	// Get document ID
+	docID, err := h.getDocumentID(params)
+	if err != nil {
+		return h.errorResponse(fmt.Sprintf("Invalid document_id: %v", err))
+	}
+
+	prune, _ := params["prune"].(bool)
+
+	report, err := h.manager.CheckAssets(ctx, docID, prune)
+	if err != nil {
+		return h.errorResponse(fmt.Sprintf("Failed to check assets: %v", err))
+	}
+
+	message := fmt.Sprintf("Found %d unused image(s) (%d bytes) and %d figure(s) with missing images", len(report.Orphaned), report.OrphanedBytes, len(report.MissingFigures))
+	if report.Pruned {
+		message = fmt.Sprintf("Removed %d unused image(s) (%d bytes); %d figure(s) have missing images", len(report.Orphaned), report.OrphanedBytes, len(report.MissingFigures))
+	}
+
+	return h.successResponse(map[string]interface{}{
+		"document_id":     docID,
+		"orphaned":        report.Orphaned,
+		"orphaned_bytes":  report.OrphanedBytes,
+		"missing_figures": report.MissingFigures,
+		"pruned":          report.Pruned,
+		"message":         message,
+	})
+}
+
+func (h *DocGenHandler) handleCheckFiguresTables(params map[string]interface{}) (*protocol.CallToolResponse, error) {
+	// Get document ID
+	docID, err := h.getDocumentID(params)
+	if err != nil {
+		return h.errorResponse(fmt.Sprintf("Invalid document_id: %v", err))
+	}
+
+	// Get chapter number (optional, 0 checks every chapter)
+	var chapterNum types.ChapterNumber
+	if _, ok := params["chapter_number"]; ok {
+		chapterNum, err = h.getChapterNumber(params)
+		if err != nil {
+			return h.errorResponse(fmt.Sprintf("Invalid chapter_number: %v", err))
+		}
+	}
+
+	issues, err := h.manager.LintFiguresAndTables(docID, chapterNum)
+	if err != nil {
+		return h.errorResponse(fmt.Sprintf("Failed to check figures and tables: %v", err))
+	}
+
+	message := "Figures and tables match the content"
+	if len(issues) > 0 {
+		message = fmt.Sprintf("Found %d mismatch(es) between registered figures and tables and the content", len(issues))
+	}
+
+	return h.successResponse(map[string]interface{}{
+		"document_id": docID,
+		"issues":      issues,
+		"message":     message,
+	})
+}
+
+// handleMigrateFigureIDs gives the figures still identified by their numbers
+// stable IDs
+func (h *DocGenHandler) handleMigrateFigureIDs(ctx context.Context, params map[string]interface{}) (*protocol.CallToolResponse, error) {
+	// Get document ID
+	docID, err := h.getDocumentID(params)
+	if err != nil {
+		return h.errorResponse(fmt.Sprintf("Invalid document_id: %v", err))
+	}
+
+	ids, err := h.manager.MigrateFigureIDs(ctx, docID)
+	if err != nil {
+		return h.errorResponse(fmt.Sprintf("Failed to migrate figure IDs: %v", err))
+	}
+
+	message := "Every figure already has a stable ID"
+	if len(ids) > 0 {
+		message = fmt.Sprintf("Gave %d figure(s) stable IDs; their anchors and references were updated", len(ids))
+	}
+
+	return h.successResponse(map[string]interface{}{
+		"document_id": docID,
+		"ids":         ids,
+		"message":     message,
+	})
+}
@@ -0,0 +1,125 @@
+package handler
+
+import (
+	"fmt"
+	"log"
+
+	"github.com/gomcpgo/docgen/pkg/notary"
+	"github.com/gomcpgo/docgen/pkg/types"
+	"github.com/gomcpgo/mcp/pkg/protocol"
+)
+
+// Export notarization operations
+
+// notarizeExport appends a record of an export to the export ledger
+func (h *DocGenHandler) notarizeExport(docID types.DocumentID, format types.ExportFormat, outputPath string, style *types.Style) (*types.ExportRecord, error) {
+	contentHash, err := notary.HashFile(outputPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to hash export: %w", err)
+	}
+	styleHash, err := notary.HashStyle(style)
+	if err != nil {
+		return nil, err
+	}
+
+	pandocVersion, err := h.exporter.PandocVersion()
+	if err != nil {
+		log.Printf("[DOCGEN HANDLER] Could not determine pandoc version: %v", err)
+		pandocVersion = "unknown"
+	}
+
+	return h.ledger.Append(types.ExportRecord{
+		DocumentID:      docID,
+		Format:          format,
+		OutputPath:      outputPath,
+		ContentHash:     contentHash,
+		StyleHash:       styleHash,
+		ExporterVersion: h.version,
+		PandocVersion:   pandocVersion,
+	})
+}
+
+func (h *DocGenHandler) handleGetExportRecords(params map[string]interface{}) (*protocol.CallToolResponse, error) {
+	// Get document ID (optional, all documents when omitted)
+	var documentID string
+	if _, ok := params["document_id"]; ok {
+		docID, err := h.getDocumentID(params)
+		if err != nil {
+			return h.errorResponse(fmt.Sprintf("Invalid document_id: %v", err))
+		}
+		documentID = string(docID)
+	}
+
+	records, err := h.ledger.Records(documentID)
+	if err != nil {
+		return h.errorResponse(fmt.Sprintf("Failed to read export ledger: %v", err))
+	}
+
+	// The whole chain is verified since every record depends on the ones before it
+	checked, verifyErr := h.ledger.Verify()
+	result := map[string]interface{}{
+		"records":         records,
+		"count":           len(records),
+		"ledger_valid":    verifyErr == nil,
+		"records_checked": checked,
+	}
+	if verifyErr != nil {
+		result["ledger_error"] = verifyErr.Error()
+	}
+
+	return h.successResponse(result)
+}
+
+func (h *DocGenHandler) handleVerifyExport(params map[string]interface{}) (*protocol.CallToolResponse, error) {
+	// Get document ID
+	docID, err := h.getDocumentID(params)
+	if err != nil {
+		return h.errorResponse(fmt.Sprintf("Invalid document_id: %v", err))
+	}
+
+	// Get format
+	format, ok := params["format"].(string)
+	if !ok || format == "" {
+		return h.errorResponse("format parameter is required")
+	}
+
+	records, err := h.ledger.Records(string(docID))
+	if err != nil {
+		return h.errorResponse(fmt.Sprintf("Failed to read export ledger: %v", err))
+	}
+
+	// The most recent record for this format describes the current export file
+	var latest *types.ExportRecord
+	for i := range records {
+		if string(records[i].Format) == format {
+			latest = &records[i]
+		}
+	}
+	if latest == nil {
+		return h.errorResponse(fmt.Sprintf("No export record found for document %s in format %s", docID, format))
+	}
+
+	contentHash, err := notary.HashFile(latest.OutputPath)
+	if err != nil {
+		return h.errorResponse(fmt.Sprintf("Failed to hash export file: %v", err))
+	}
+	_, ledgerErr := h.ledger.Verify()
+
+	matches := contentHash == latest.ContentHash
+	message := fmt.Sprintf("Export %s matches ledger record %d", latest.OutputPath, latest.Sequence)
+	if !matches {
+		message = fmt.Sprintf("Export %s has changed since ledger record %d", latest.OutputPath, latest.Sequence)
+	}
+	if ledgerErr != nil {
+		message += fmt.Sprintf("; the ledger itself fails verification: %v", ledgerErr)
+	}
+
+	return h.successResponse(map[string]interface{}{
+		"document_id":  docID,
+		"record":       latest,
+		"current_hash": contentHash,
+		"matches":      matches,
+		"ledger_valid": ledgerErr == nil,
+		"message":      message,
+	})
+}
@@ -0,0 +1,140 @@
+package handler
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"strings"
+
+	"github.com/gomcpgo/docgen/pkg/types"
+	"github.com/gomcpgo/mcp/pkg/protocol"
+)
+
+// Writing progress operations
+
+// changesContent reports whether a call may change a document's text, and
+// returns the document: tools that need the editor role change documents,
+// apart from those that leave their content alone while locked
+func changesContent(req *protocol.CallToolRequest) (types.DocumentID, bool) {
+	documentID, _ := req.Arguments["document_id"].(string)
+	if documentID == "" || lockExemptTools[req.Name] || requiredRole(req) != types.RoleEditor {
+		return "", false
+	}
+	return types.DocumentID(documentID), true
+}
+
+// recordWordCount keeps today's word count snapshot of a changed document
+// current for writing_progress
+func (h *DocGenHandler) recordWordCount(docID types.DocumentID) {
+	if err := h.manager.RecordWordCount(docID); err != nil {
+		log.Printf("[DOCGEN HANDLER] Failed to record the word count of %s: %v", docID, err)
+	}
+}
+
+func (h *DocGenHandler) handleSetWritingTarget(params map[string]interface{}) (*protocol.CallToolResponse, error) {
+	// Get document ID
+	docID, err := h.getDocumentID(params)
+	if err != nil {
+		return h.errorResponse(fmt.Sprintf("Invalid document_id: %v", err))
+	}
+
+	// A missing or zero target removes it
+	var target *types.WritingTarget
+	if words, ok := params["target_words"].(float64); ok && words > 0 {
+		target = &types.WritingTarget{Words: int(words)}
+		if deadline, ok := params["deadline"].(string); ok {
+			target.Deadline = deadline
+		}
+	}
+
+	if err := h.manager.SetWritingTarget(docID, target); err != nil {
+		return h.errorResponse(fmt.Sprintf("Failed to set writing target: %v", err))
+	}
+
+	message := "Writing target removed"
+	if target != nil {
+		message = fmt.Sprintf("Writing target set to %d words", target.Words)
+		if target.Deadline != "" {
+			message += fmt.Sprintf(" by %s", target.Deadline)
+		}
+	}
+
+	return h.successResponse(map[string]interface{}{
+		"document_id":    docID,
+		"writing_target": target,
+		"message":        message,
+	})
+}
+
+func (h *DocGenHandler) handleWritingProgress(params map[string]interface{}) (*protocol.CallToolResponse, error) {
+	// Get document ID
+	docID, err := h.getDocumentID(params)
+	if err != nil {
+		return h.errorResponse(fmt.Sprintf("Invalid document_id: %v", err))
+	}
+
+	days := 30
+	if val, ok := params["days"].(float64); ok {
+		days = int(val)
+	}
+
+	progress, err := h.manager.WritingProgress(docID, days)
+	if err != nil {
+		return h.errorResponse(fmt.Sprintf("Failed to get writing progress: %v", err))
+	}
+
+	return h.successResponse(map[string]interface{}{
+		"document_id": docID,
+		"progress":    progress,
+	})
+}
+
+func (h *DocGenHandler) handleGetDocumentSize(ctx context.Context, params map[string]interface{}) (*protocol.CallToolResponse, error) {
+	// Get document ID
+	docID, err := h.getDocumentID(params)
+	if err != nil {
+		return h.errorResponse(fmt.Sprintf("Invalid document_id: %v", err))
+	}
+
+	largest := 0
+	if val, ok := params["largest"].(float64); ok {
+		largest = int(val)
+	}
+
+	size, err := h.manager.DocumentSize(ctx, docID, largest)
+	if err != nil {
+		return h.errorResponse(fmt.Sprintf("Failed to get document size: %v", err))
+	}
+
+	return h.successResponse(map[string]interface{}{
+		"document_id": docID,
+		"size":        size,
+		"message":     fmt.Sprintf("Document uses %d bytes: %d in chapters, %d in assets, %d in exports and %d in snapshots", size.TotalBytes, size.ChaptersBytes, size.AssetsBytes, size.ExportsBytes, size.SnapshotsBytes),
+	})
+}
+
+func (h *DocGenHandler) handleGetStorageUsage(ctx context.Context, params map[string]interface{}) (*protocol.CallToolResponse, error) {
+	usage, err := h.manager.StorageUsage(ctx)
+	if err != nil {
+		return h.errorResponse(fmt.Sprintf("Failed to get storage usage: %v", err))
+	}
+
+	message := fmt.Sprintf("%d document(s) use %d bytes in total", len(usage.Documents), usage.TotalBytes)
+	if usage.MaxTotalBytes > 0 {
+		message += fmt.Sprintf(" of the %d byte quota", usage.MaxTotalBytes)
+	}
+	var over []string
+	for _, document := range usage.Documents {
+		if document.OverQuota {
+			over = append(over, string(document.DocumentID))
+		}
+	}
+	if len(over) > 0 {
+		message += fmt.Sprintf("; over the per-document quota: %s", strings.Join(over, ", "))
+	}
+
+	return h.successResponse(map[string]interface{}{
+		"usage":   usage,
+		"message": message,
+	})
+}
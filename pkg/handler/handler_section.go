@@ -1,14 +1,19 @@
 package handler
 
 import (
+	"context"
+	"encoding/json"
 	"fmt"
+	"strings"
 
 	"github.com/gomcpgo/mcp/pkg/protocol"
+	"github.com/gomcpgo/docgen/pkg/document"
+	"github.com/gomcpgo/docgen/pkg/types"
 )
 
 // Section operations
 
-func (h *DocGenHandler) handleAddSection(params map[string]interface{}) (*protocol.CallToolResponse, error) {
+func (h *DocGenHandler) handleAddSection(ctx context.Context, params map[string]interface{}) (*protocol.CallToolResponse, error) {
 	// Get document ID
 	docID, err := h.getDocumentID(params)
 	if err != nil {
@@ -42,20 +47,97 @@ func (h *DocGenHandler) handleAddSection(params map[string]interface{}) (*protoc
 		}
 	}
 
+	issues, rejected := h.checkSectionMarkdown(docID, level, content)
+	if rejected != nil {
+		return rejected, nil
+	}
+
 	// Add the section
-	sectionNum, err := h.manager.AddSection(docID, chapterNum, title, content, level)
+	sectionNum, err := h.manager.AddSection(ctx, docID, chapterNum, title, content, level)
 	if err != nil {
 		return h.errorResponse(fmt.Sprintf("Failed to add section: %v", err))
 	}
 
-	return h.successResponse(map[string]interface{}{
+	result := map[string]interface{}{
 		"document_id":    docID,
 		"section_number": sectionNum.String(),
 		"message":        fmt.Sprintf("Section '%s' added successfully to chapter %d", title, chapterNum),
-	})
+	}
+	addMarkdownWarnings(result, issues)
+	return h.successResponse(result)
 }
 
-func (h *DocGenHandler) handleUpdateSection(params map[string]interface{}) (*protocol.CallToolResponse, error) {
+// handleAddSections adds several sections to a chapter in one call
+func (h *DocGenHandler) handleAddSections(ctx context.Context, params map[string]interface{}) (*protocol.CallToolResponse, error) {
+	// Get document ID
+	docID, err := h.getDocumentID(params)
+	if err != nil {
+		return h.errorResponse(fmt.Sprintf("Invalid document_id: %v", err))
+	}
+
+	// Get chapter number
+	chapterNum, err := h.getChapterNumber(params)
+	if err != nil {
+		return h.errorResponse(fmt.Sprintf("Invalid chapter_number: %v", err))
+	}
+
+	// Get sections, decoded through JSON into the new sections
+	sectionsRaw, ok := params["sections"].([]interface{})
+	if !ok || len(sectionsRaw) == 0 {
+		return h.errorResponse("sections parameter is required")
+	}
+	sectionsJSON, err := json.Marshal(sectionsRaw)
+	if err != nil {
+		return h.errorResponse(fmt.Sprintf("Invalid sections: %v", err))
+	}
+	var sections []types.NewSection
+	if err := json.Unmarshal(sectionsJSON, &sections); err != nil {
+		return h.errorResponse(fmt.Sprintf("Invalid sections: %v", err))
+	}
+
+	// Every section's markdown is checked before any is added
+	issues := make([][]types.MarkdownIssue, len(sections))
+	for i := range sections {
+		if sections[i].Level == 0 {
+			sections[i].Level = 1
+		}
+		var rejected *protocol.CallToolResponse
+		issues[i], rejected = h.checkSectionMarkdown(docID, sections[i].Level, sections[i].Content)
+		if rejected != nil {
+			return rejected, nil
+		}
+	}
+
+	// Add the sections
+	numbers, err := h.manager.AddSections(ctx, docID, chapterNum, sections)
+	if err != nil {
+		return h.errorResponse(fmt.Sprintf("Failed to add sections: %v", err))
+	}
+
+	sectionNumbers := make([]string, len(numbers))
+	warnings := make(map[string][]types.MarkdownIssue)
+	count := 0
+	for i, sectionNum := range numbers {
+		sectionNumbers[i] = sectionNum.String()
+		if len(issues[i]) > 0 {
+			warnings[sectionNum.String()] = issues[i]
+			count += len(issues[i])
+		}
+	}
+
+	result := map[string]interface{}{
+		"document_id":     docID,
+		"section_numbers": sectionNumbers,
+		"message":         fmt.Sprintf("Added %d section(s) to chapter %d", len(numbers), chapterNum),
+	}
+	if count > 0 {
+		result["markdown_warnings"] = warnings
+		result["message"] = fmt.Sprintf("%s, with %d markdown warning(s) to fix", result["message"], count)
+	}
+	return h.successResponse(result)
+}
+
+func (h *DocGenHandler) handleUpdateSection(ctx context.Context, params map[string]interface{}) (*protocol.CallToolResponse, error) {
 	// Get document ID
 	docID, err := h.getDocumentID(params)
 	if err != nil {
@@ -86,16 +168,148 @@ func (h *DocGenHandler) handleUpdateSection(params map[string]interface{}) (*pro
 		return h.errorResponse("content parameter is required")
 	}
 
+	// Headings in the content are checked against the section's own level
+	level := 1
+	if chapter, err := h.storage.LoadChapterMetadata(string(docID), int(chapterNum)); err == nil {
+		for _, section := range chapter.Sections {
+			if section.Number.String() == sectionNum.String() {
+				level = section.Level
+			}
+		}
+	}
+	issues, rejected := h.checkSectionMarkdown(docID, level, content)
+	if rejected != nil {
+		return rejected, nil
+	}
+
 	// Update the section
-	err = h.manager.UpdateSection(docID, chapterNum, sectionNum, content)
+	err = h.manager.UpdateSection(ctx, docID, chapterNum, sectionNum, content)
 	if err != nil {
 		return h.errorResponse(fmt.Sprintf("Failed to update section: %v", err))
 	}
 
-	return h.successResponse(map[string]interface{}{
+	result := map[string]interface{}{
 		"document_id":    docID,
 		"section_number": sectionNumStr,
 		"message":        fmt.Sprintf("Section %s updated successfully", sectionNumStr),
+	}
+	addMarkdownWarnings(result, issues)
+	return h.successResponse(result)
+}
+
+// checkSectionMarkdown checks content meant for a section at level. With strict
+// markdown configured, content with problems is rejected with an error response.
+func (h *DocGenHandler) checkSectionMarkdown(docID types.DocumentID, level int, content string) ([]types.MarkdownIssue, *protocol.CallToolResponse) {
+	issues, err := h.manager.CheckSectionMarkdown(docID, level, content)
+	if err != nil {
+		resp, _ := h.errorResponse(fmt.Sprintf("Failed to check markdown: %v", err))
+		return nil, resp
+	}
+	if len(issues) == 0 || !h.config.StrictMarkdown {
+		return issues, nil
+	}
+
+	problems := make([]string, len(issues))
+	for i, issue := range issues {
+		problems[i] = fmt.Sprintf("line %d: %s", issue.Line, issue.Message)
+	}
+	resp, _ := h.errorResponse(fmt.Sprintf("Content not saved, its markdown has problems: %s", strings.Join(problems, "; ")))
+	return nil, resp
+}
+
+// addMarkdownWarnings adds the markdown problems found in saved content to a result
+func addMarkdownWarnings(result map[string]interface{}, issues []types.MarkdownIssue) {
+	if len(issues) == 0 {
+		return
+	}
+	result["markdown_warnings"] = issues
+	result["message"] = fmt.Sprintf("%s, with %d markdown warning(s) to fix", result["message"], len(issues))
+}
+
+// handleAddToSection appends content to a section or, with insert, inserts it
+// after a paragraph given by index or by text it contains
+func (h *DocGenHandler) handleAddToSection(ctx context.Context, params map[string]interface{}, insert bool) (*protocol.CallToolResponse, error) {
+	docID, err := h.getDocumentID(params)
+	if err != nil {
+		return h.errorResponse(fmt.Sprintf("Invalid document_id: %v", err))
+	}
+	chapterNum, err := h.getChapterNumber(params)
+	if err != nil {
+		return h.errorResponse(fmt.Sprintf("Invalid chapter_number: %v", err))
+	}
+	sectionNumStr, ok := params["section_number"].(string)
+	if !ok || sectionNumStr == "" {
+		return h.errorResponse("section_number parameter is required")
+	}
+	sectionNum, err := h.parseSectionNumber(sectionNumStr)
+	if err != nil {
+		return h.errorResponse(fmt.Sprintf("Invalid section_number format: %v", err))
+	}
+	content, ok := params["content"].(string)
+	if !ok || content == "" {
+		return h.errorResponse("content parameter is required")
+	}
+
+	if !insert {
+		if err := h.manager.AppendToSection(ctx, docID, chapterNum, sectionNum, content); err != nil {
+			return h.errorResponse(fmt.Sprintf("Failed to append to section: %v", err))
+		}
+		return h.successResponse(map[string]interface{}{
+			"document_id":    docID,
+			"section_number": sectionNumStr,
+			"message":        fmt.Sprintf("Content appended to section %s", sectionNumStr),
+		})
+	}
+
+	afterParagraph, hasParagraph := params["after_paragraph"].(float64)
+	afterText, _ := params["after_text"].(string)
+	if hasParagraph == (afterText != "") {
+		return h.errorResponse("give either after_paragraph or after_text")
+	}
+	if err := h.manager.InsertIntoSection(ctx, docID, chapterNum, sectionNum, content, int(afterParagraph), afterText); err != nil {
+		return h.errorResponse(fmt.Sprintf("Failed to insert into section: %v", err))
+	}
+	return h.successResponse(map[string]interface{}{
+		"document_id":    docID,
+		"section_number": sectionNumStr,
+		"message":        fmt.Sprintf("Content inserted into section %s", sectionNumStr),
+	})
+}
+
+func (h *DocGenHandler) handleInsertPageBreak(ctx context.Context, params map[string]interface{}) (*protocol.CallToolResponse, error) {
+	docID, err := h.getDocumentID(params)
+	if err != nil {
+		return h.errorResponse(fmt.Sprintf("Invalid document_id: %v", err))
+	}
+	chapterNum, err := h.getChapterNumber(params)
+	if err != nil {
+		return h.errorResponse(fmt.Sprintf("Invalid chapter_number: %v", err))
+	}
+	sectionNumStr, ok := params["section_number"].(string)
+	if !ok || sectionNumStr == "" {
+		return h.errorResponse("section_number parameter is required")
+	}
+	sectionNum, err := h.parseSectionNumber(sectionNumStr)
+	if err != nil {
+		return h.errorResponse(fmt.Sprintf("Invalid section_number format: %v", err))
+	}
+
+	// Without a position the page break goes at the end of the section
+	afterParagraph := -1
+	if value, ok := params["after_paragraph"].(float64); ok {
+		afterParagraph = int(value)
+	}
+	afterText, _ := params["after_text"].(string)
+	if afterParagraph >= 0 && afterText != "" {
+		return h.errorResponse("give either after_paragraph or after_text, not both")
+	}
+	if err := h.manager.InsertPageBreak(ctx, docID, chapterNum, sectionNum, afterParagraph, afterText); err != nil {
+		return h.errorResponse(fmt.Sprintf("Failed to insert page break: %v", err))
+	}
+	return h.successResponse(map[string]interface{}{
+		"document_id":    docID,
+		"section_number": sectionNumStr,
+		"message":        fmt.Sprintf("Page break inserted into section %s", sectionNumStr),
 	})
 }
 
@@ -137,6 +351,54 @@ func (h *DocGenHandler) handleDeleteSection(params map[string]interface{}) (*pro
 	})
 }
 
+func (h *DocGenHandler) handleInsertCitation(ctx context.Context, params map[string]interface{}) (*protocol.CallToolResponse, error) {
+	// Get document ID
+	docID, err := h.getDocumentID(params)
+	if err != nil {
+		return h.errorResponse(fmt.Sprintf("Invalid document_id: %v", err))
+	}
+
+	// Get chapter number
+	chapterNum, err := h.getChapterNumber(params)
+	if err != nil {
+		return h.errorResponse(fmt.Sprintf("Invalid chapter_number: %v", err))
+	}
+
+	// Get section number
+	sectionNumStr, ok := params["section_number"].(string)
+	if !ok || sectionNumStr == "" {
+		return h.errorResponse("section_number parameter is required")
+	}
+	sectionNum, err := h.parseSectionNumber(sectionNumStr)
+	if err != nil {
+		return h.errorResponse(fmt.Sprintf("Invalid section_number format: %v", err))
+	}
+
+	// Get the citation
+	key, ok := params["key"].(string)
+	if !ok || key == "" {
+		return h.errorResponse("key parameter is required")
+	}
+	citation := types.Citation{Key: strings.TrimPrefix(key, "@")}
+	citation.Prefix, _ = params["prefix"].(string)
+	citation.Locator, _ = params["locator"].(string)
+	citation.AuthorInText, _ = params["author_in_text"].(bool)
+	citation.SuppressAuthor, _ = params["suppress_author"].(bool)
+	after, _ := params["after_text"].(string)
+
+	markup, err := h.manager.InsertCitation(ctx, docID, chapterNum, sectionNum, citation, after)
+	if err != nil {
+		return h.errorResponse(fmt.Sprintf("Failed to insert citation: %v", err))
+	}
+
+	return h.successResponse(map[string]interface{}{
+		"document_id":    docID,
+		"section_number": sectionNumStr,
+		"citation":       markup,
+		"message":        fmt.Sprintf("Citation %s inserted in section %s", markup, sectionNumStr),
+	})
+}
+
 func (h *DocGenHandler) handleGetSectionContent(params map[string]interface{}) (*protocol.CallToolResponse, error) {
 	// Get document ID
 	docID, err := h.getDocumentID(params)
@@ -228,4 +490,187 @@ func (h *DocGenHandler) handleGetSectionContent(params map[string]interface{}) (
 		"document_id": docID,
 		"sections":    results,
 	})
-}
\ No newline at end of file
+}
+
+func (h *DocGenHandler) handleAddContent(ctx context.Context, params map[string]interface{}) (*protocol.CallToolResponse, error) {
+	// Get document ID
+	docID, err := h.getDocumentID(params)
+	if err != nil {
+		return h.errorResponse(fmt.Sprintf("Invalid document_id: %v", err))
+	}
+
+	// Get chapter number
+	chapterNum, err := h.getChapterNumber(params)
+	if err != nil {
+		return h.errorResponse(fmt.Sprintf("Invalid chapter_number: %v", err))
+	}
+
+	// Get section title
+	title, ok := params["title"].(string)
+	if !ok || title == "" {
+		return h.errorResponse("title parameter is required")
+	}
+
+	// Get markdown content
+	content, ok := params["content"].(string)
+	if !ok || content == "" {
+		return h.errorResponse("content parameter is required")
+	}
+
+	// Get level (optional, defaults to 1)
+	level := 1
+	if levelFloat, ok := params["level"].(float64); ok {
+		level = int(levelFloat)
+		if level < 1 || level > 6 {
+			return h.errorResponse("level must be between 1 and 6")
+		}
+	}
+
+	// Get attached images (optional)
+	var images []document.ContentImage
+	if imagesRaw, ok := params["images"].([]interface{}); ok {
+		for _, imageRaw := range imagesRaw {
+			image, ok := imageRaw.(map[string]interface{})
+			if !ok {
+				return h.errorResponse("images must be an array of objects")
+			}
+			name, _ := image["name"].(string)
+			data, _ := image["data"].(string)
+			if name == "" || data == "" {
+				return h.errorResponse("each image requires name and data")
+			}
+			mimeType, _ := image["mime_type"].(string)
+			caption, _ := image["caption"].(string)
+			images = append(images, document.ContentImage{
+				Name:     name,
+				Data:     data,
+				MimeType: mimeType,
+				Caption:  caption,
+			})
+		}
+	}
+
+	// Import the content
+	result, err := h.manager.AddContent(ctx, docID, chapterNum, title, content, level, images)
+	if err != nil {
+		return h.errorResponse(fmt.Sprintf("Failed to add content: %v", err))
+	}
+
+	return h.successResponse(map[string]interface{}{
+		"document_id":    docID,
+		"section_number": result.SectionNumber.String(),
+		"figures":        result.Figures,
+		"warnings":       result.Warnings,
+		"message":        fmt.Sprintf("Content '%s' added to chapter %d with %d image(s)", title, chapterNum, len(result.Figures)),
+	})
+}
+
+func (h *DocGenHandler) handleGetSectionBlocks(params map[string]interface{}) (*protocol.CallToolResponse, error) {
+	docID, chapterNum, sectionNum, resp := h.getBlockTarget(params)
+	if resp != nil {
+		return resp, nil
+	}
+
+	blocks, err := h.manager.GetSectionBlocks(docID, chapterNum, sectionNum)
+	if err != nil {
+		return h.errorResponse(fmt.Sprintf("Failed to get section blocks: %v", err))
+	}
+
+	return h.successResponse(map[string]interface{}{
+		"document_id":    docID,
+		"chapter_number": chapterNum,
+		"section_number": sectionNum.String(),
+		"blocks":         blocks,
+	})
+}
+
+func (h *DocGenHandler) handleUpdateBlock(ctx context.Context, params map[string]interface{}) (*protocol.CallToolResponse, error) {
+	docID, chapterNum, sectionNum, resp := h.getBlockTarget(params)
+	if resp != nil {
+		return resp, nil
+	}
+	block, ok := params["block_number"].(float64)
+	if !ok {
+		return h.errorResponse("block_number parameter is required")
+	}
+	content, ok := params["content"].(string)
+	if !ok || content == "" {
+		return h.errorResponse("content parameter is required")
+	}
+	expectedText, _ := params["expected_text"].(string)
+
+	if err := h.manager.UpdateBlock(ctx, docID, chapterNum, sectionNum, int(block), content, expectedText); err != nil {
+		return h.errorResponse(fmt.Sprintf("Failed to update block: %v", err))
+	}
+	return h.successResponse(map[string]interface{}{
+		"document_id":    docID,
+		"section_number": sectionNum.String(),
+		"message":        fmt.Sprintf("Block %d of section %s updated", int(block), sectionNum.String()),
+	})
+}
+
+func (h *DocGenHandler) handleInsertBlock(ctx context.Context, params map[string]interface{}) (*protocol.CallToolResponse, error) {
+	docID, chapterNum, sectionNum, resp := h.getBlockTarget(params)
+	if resp != nil {
+		return resp, nil
+	}
+	after, ok := params["after_block"].(float64)
+	if !ok {
+		return h.errorResponse("after_block parameter is required")
+	}
+	content, ok := params["content"].(string)
+	if !ok || content == "" {
+		return h.errorResponse("content parameter is required")
+	}
+
+	if err := h.manager.InsertBlock(ctx, docID, chapterNum, sectionNum, int(after), content); err != nil {
+		return h.errorResponse(fmt.Sprintf("Failed to insert block: %v", err))
+	}
+	return h.successResponse(map[string]interface{}{
+		"document_id":    docID,
+		"section_number": sectionNum.String(),
+		"message":        fmt.Sprintf("Block inserted after block %d of section %s", int(after), sectionNum.String()),
+	})
+}
+
+func (h *DocGenHandler) handleDeleteBlock(ctx context.Context, params map[string]interface{}) (*protocol.CallToolResponse, error) {
+	docID, chapterNum, sectionNum, resp := h.getBlockTarget(params)
+	if resp != nil {
+		return resp, nil
+	}
+	block, ok := params["block_number"].(float64)
+	if !ok {
+		return h.errorResponse("block_number parameter is required")
+	}
+	expectedText, _ := params["expected_text"].(string)
+
+	if err := h.manager.DeleteBlock(ctx, docID, chapterNum, sectionNum, int(block), expectedText); err != nil {
+		return h.errorResponse(fmt.Sprintf("Failed to delete block: %v", err))
+	}
+	return h.successResponse(map[string]interface{}{
+		"document_id":    docID,
+		"section_number": sectionNum.String(),
+		"message":        fmt.Sprintf("Block %d of section %s deleted", int(block), sectionNum.String()),
+	})
+}
+
+// getBlockTarget reads the section a block operation works on, returning the
+// error response to send when a parameter is invalid
+func (h *DocGenHandler) getBlockTarget(params map[string]interface{}) (types.DocumentID, types.ChapterNumber, types.SectionNumber, *protocol.CallToolResponse) {
+	docID, err := h.getDocumentID(params)
+	if err != nil {
+		resp, _ := h.errorResponse(fmt.Sprintf("Invalid document_id: %v", err))
+		return "", 0, nil, resp
+	}
+	chapterNum, err := h.getChapterNumber(params)
+	if err != nil {
+		resp, _ := h.errorResponse(fmt.Sprintf("Invalid chapter_number: %v", err))
+		return "", 0, nil, resp
+	}
+	sectionNum, err := h.getSectionNumber(params)
+	if err != nil {
+		resp, _ := h.errorResponse(fmt.Sprintf("Invalid section_number: %v", err))
+		return "", 0, nil, resp
+	}
+	return docID, chapterNum, sectionNum, nil
+}
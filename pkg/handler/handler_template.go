@@ -0,0 +1,106 @@
+package handler
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/gomcpgo/mcp/pkg/protocol"
+)
+
+// Section template operations
+
+func (h *DocGenHandler) handleSaveSectionTemplate(params map[string]interface{}) (*protocol.CallToolResponse, error) {
+	// Get template name
+	name, ok := params["name"].(string)
+	if !ok || name == "" {
+		return h.errorResponse("name parameter is required")
+	}
+
+	// Get section title template
+	title, ok := params["title"].(string)
+	if !ok || title == "" {
+		return h.errorResponse("title parameter is required")
+	}
+
+	// Get content template
+	content, ok := params["content"].(string)
+	if !ok || content == "" {
+		return h.errorResponse("content parameter is required")
+	}
+
+	// Get description (optional)
+	description, _ := params["description"].(string)
+
+	// Get level (optional, defaults to 1)
+	level := 1
+	if levelFloat, ok := params["level"].(float64); ok {
+		level = int(levelFloat)
+		if level < 1 || level > 6 {
+			return h.errorResponse("level must be between 1 and 6")
+		}
+	}
+
+	template, err := h.manager.SaveSectionTemplate(name, description, title, content, level)
+	if err != nil {
+		return h.errorResponse(fmt.Sprintf("Failed to save section template: %v", err))
+	}
+
+	return h.successResponse(map[string]interface{}{
+		"name":      template.Name,
+		"variables": template.Variables,
+		"message":   fmt.Sprintf("Section template '%s' saved successfully", template.Name),
+	})
+}
+
+func (h *DocGenHandler) handleApplySectionTemplate(ctx context.Context, params map[string]interface{}) (*protocol.CallToolResponse, error) {
+	// Get document ID
+	docID, err := h.getDocumentID(params)
+	if err != nil {
+		return h.errorResponse(fmt.Sprintf("Invalid document_id: %v", err))
+	}
+
+	// Get chapter number
+	chapterNum, err := h.getChapterNumber(params)
+	if err != nil {
+		return h.errorResponse(fmt.Sprintf("Invalid chapter_number: %v", err))
+	}
+
+	// Get template name
+	name, ok := params["template_name"].(string)
+	if !ok || name == "" {
+		return h.errorResponse("template_name parameter is required")
+	}
+
+	// Get variable values (optional for templates without variables)
+	values := make(map[string]string)
+	if valuesRaw, ok := params["values"].(map[string]interface{}); ok {
+		for key, value := range valuesRaw {
+			switch v := value.(type) {
+			case string:
+				values[key] = v
+			default:
+				values[key] = fmt.Sprintf("%v", v)
+			}
+		}
+	}
+
+	// Get level (optional, defaults to the template's level)
+	level := 0
+	if levelFloat, ok := params["level"].(float64); ok {
+		level = int(levelFloat)
+		if level < 1 || level > 6 {
+			return h.errorResponse("level must be between 1 and 6")
+		}
+	}
+
+	sectionNum, err := h.manager.ApplySectionTemplate(ctx, docID, chapterNum, name, values, level)
+	if err != nil {
+		return h.errorResponse(fmt.Sprintf("Failed to apply section template: %v", err))
+	}
+
+	return h.successResponse(map[string]interface{}{
+		"document_id":    docID,
+		"section_number": sectionNum.String(),
+		"message":        fmt.Sprintf("Section template '%s' applied to chapter %d", name, chapterNum),
+	})
+}
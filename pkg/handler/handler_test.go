@@ -1,16 +1,20 @@
 package handler
 
 import (
+	"archive/zip"
 	"context"
 	"encoding/json"
 	"os"
 	"path/filepath"
+	"strings"
 	"testing"
 	"time"
 
 	"github.com/gomcpgo/mcp/pkg/protocol"
 	"github.com/gomcpgo/docgen/pkg/config"
+	"github.com/gomcpgo/docgen/pkg/document"
 	"github.com/gomcpgo/docgen/pkg/types"
+	"github.com/gomcpgo/docgen/pkg/usage"
 )
 
 // setupTestHandler creates a test handler with temporary directory
@@ -407,6 +411,2142 @@ func TestDocGenHandler_HelperMethods(t *testing.T) {
 	})
 }
 
+func TestDocGenHandler_AccessControl(t *testing.T) {
+	handler, tempDir := setupTestHandler(t)
+	defer cleanupTestHandler(tempDir)
+
+	handler.config.AccessControl = true
+	policy := &types.AccessPolicy{Roles: map[string]types.Role{"alice": types.RoleEditor}}
+	if err := handler.storage.SaveAccessPolicy(policy); err != nil {
+		t.Fatalf("Failed to save access policy: %v", err)
+	}
+
+	call := func(identity, name string, args map[string]interface{}) *protocol.CallToolResponse {
+		ctx := usage.WithClientID(context.Background(), identity)
+		resp, err := handler.CallTool(ctx, &protocol.CallToolRequest{Name: name, Arguments: args})
+		if err != nil {
+			t.Fatalf("CallTool(%s) error = %v", name, err)
+		}
+		return resp
+	}
+
+	// Identities without a role cannot create documents
+	createArgs := map[string]interface{}{"title": "Team Report", "author": "Alice", "type": "report"}
+	expectError(t, call("bob", "create_document", createArgs), "access denied")
+
+	// The creator becomes the document's admin
+	docID := parseSuccessResponse(t, call("alice", "create_document", createArgs))["document_id"].(string)
+	docArgs := map[string]interface{}{"document_id": docID}
+	expectError(t, call("bob", "get_document_structure", docArgs), "access denied")
+
+	grant := map[string]interface{}{"document_id": docID, "identity": "bob", "role": "viewer"}
+	expectError(t, call("bob", "set_document_role", grant), "requires the admin role")
+	parseSuccessResponse(t, call("alice", "set_document_role", grant))
+
+	// Viewers can read but not change the document
+	if resp := call("bob", "get_document_structure", docArgs); resp.IsError {
+		t.Errorf("Viewer should read the document structure, got %s", resp.Content[0].Text)
+	}
+	expectError(t, call("bob", "add_chapter", map[string]interface{}{"document_id": docID, "title": "Notes"}), "requires the editor role")
+
+	// document_ids doesn't stand in for the role on document_id
+	bypass := map[string]interface{}{"document_id": docID, "document_ids": []interface{}{}}
+	expectError(t, call("carol", "get_document_structure", bypass), "access denied")
+	expectError(t, call("bob", "delete_document", bypass), "requires the admin role")
+	expectError(t, call("bob", "compile_volume", map[string]interface{}{"document_ids": []interface{}{}, "title": "Volume", "format": "pdf"}), "requires document_ids")
+	expectError(t, call("carol", "compile_volume", map[string]interface{}{"document_ids": []interface{}{docID}, "title": "Volume", "format": "pdf"}), "access denied")
+	if _, err := handler.manager.GetDocumentStructure(types.DocumentID(docID)); err != nil {
+		t.Fatalf("Expected the document to survive, got %v", err)
+	}
+
+	// Listing only shows documents the caller can view
+	listed := parseSuccessResponse(t, call("carol", "list_documents", map[string]interface{}{}))
+	if documents, _ := listed["documents"].([]interface{}); len(documents) != 0 {
+		t.Errorf("Expected no documents for an identity without access, got %v", documents)
+	}
+	listed = parseSuccessResponse(t, call("bob", "list_documents", map[string]interface{}{}))
+	if documents, _ := listed["documents"].([]interface{}); len(documents) != 1 {
+		t.Errorf("Expected the viewer to see one document, got %v", listed["documents"])
+	}
+}
+
+func TestDocGenHandler_ListDocuments(t *testing.T) {
+	handler, tempDir := setupTestHandler(t)
+	defer cleanupTestHandler(tempDir)
+
+	call := func(name string, args map[string]interface{}) map[string]interface{} {
+		resp, err := handler.CallTool(context.Background(), &protocol.CallToolRequest{Name: name, Arguments: args})
+		if err != nil {
+			t.Fatalf("CallTool(%s) error = %v", name, err)
+		}
+		return parseSuccessResponse(t, resp)
+	}
+
+	for _, doc := range []struct{ title, docType string }{
+		{"Beta Guide", "book"},
+		{"Alpha Report", "report"},
+		{"Gamma Notes", "book"},
+	} {
+		call("create_document", map[string]interface{}{"title": doc.title, "author": "Test Author", "type": doc.docType})
+	}
+
+	listed := call("list_documents", map[string]interface{}{"sort_by": "title", "sort_order": "asc"})
+	documents := listed["documents"].([]interface{})
+	if len(documents) != 3 {
+		t.Fatalf("Expected 3 documents, got %d", len(documents))
+	}
+	first := documents[0].(map[string]interface{})
+	if first["title"] != "Alpha Report" || first["author"] != "Test Author" {
+		t.Errorf("Expected Alpha Report by Test Author first, got %v", first)
+	}
+
+	// Word counts come from the section files
+	docID := first["document_id"].(string)
+	call("add_chapter", map[string]interface{}{"document_id": docID, "title": "Summary"})
+	call("add_section", map[string]interface{}{"document_id": docID, "chapter_number": float64(1), "title": "Overview", "content": "Four words of text."})
+
+	listed = call("list_documents", map[string]interface{}{"sort_by": "word_count", "limit": float64(1)})
+	documents = listed["documents"].([]interface{})
+	top := documents[0].(map[string]interface{})
+	if top["document_id"] != docID || top["word_count"].(float64) != 5 || top["chapter_count"].(float64) != 1 {
+		t.Errorf("Expected the longest document first with 5 words, got %v", top)
+	}
+	if listed["total"].(float64) != 3 || listed["count"].(float64) != 1 {
+		t.Errorf("Expected count 1 of total 3, got %v of %v", listed["count"], listed["total"])
+	}
+
+	// Filters
+	listed = call("list_documents", map[string]interface{}{"type": "book", "title_contains": "gamma"})
+	documents = listed["documents"].([]interface{})
+	if len(documents) != 1 || documents[0].(map[string]interface{})["title"] != "Gamma Notes" {
+		t.Errorf("Expected only Gamma Notes, got %v", documents)
+	}
+
+	resp, err := handler.CallTool(context.Background(), &protocol.CallToolRequest{
+		Name:      "list_documents",
+		Arguments: map[string]interface{}{"sort_by": "size"},
+	})
+	if err != nil {
+		t.Fatalf("CallTool() error = %v", err)
+	}
+	expectError(t, resp, "sort_by must be one of")
+}
+
+func TestDocGenHandler_VerifyExport(t *testing.T) {
+	handler, tempDir := setupTestHandler(t)
+	defer cleanupTestHandler(tempDir)
+
+	docID := createTestDocument(t, handler)
+	call := func(name string, args map[string]interface{}) *protocol.CallToolResponse {
+		resp, err := handler.CallTool(context.Background(), &protocol.CallToolRequest{Name: name, Arguments: args})
+		if err != nil {
+			t.Fatalf("CallTool(%s) error = %v", name, err)
+		}
+		return resp
+	}
+
+	expectError(t, call("verify_export", map[string]interface{}{"document_id": docID, "format": "pdf"}), "No export record found")
+
+	// Notarize an export file as exportDocument does
+	outputPath := filepath.Join(tempDir, "exports", docID+".pdf")
+	os.MkdirAll(filepath.Dir(outputPath), 0755)
+	if err := os.WriteFile(outputPath, []byte("%PDF-1.7 exported"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := handler.notarizeExport(types.DocumentID(docID), types.ExportFormatPDF, outputPath, nil); err != nil {
+		t.Fatalf("notarizeExport() error = %v", err)
+	}
+
+	result := parseSuccessResponse(t, call("verify_export", map[string]interface{}{"document_id": docID, "format": "pdf"}))
+	if result["matches"] != true || result["ledger_valid"] != true {
+		t.Errorf("Expected the export to match its record, got %v", result)
+	}
+
+	records := parseSuccessResponse(t, call("get_export_records", map[string]interface{}{"document_id": docID}))
+	if records["count"].(float64) != 1 || records["ledger_valid"] != true {
+		t.Errorf("Expected one valid record, got %v", records)
+	}
+
+	// A changed file no longer matches
+	if err := os.WriteFile(outputPath, []byte("%PDF-1.7 altered"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	result = parseSuccessResponse(t, call("verify_export", map[string]interface{}{"document_id": docID, "format": "pdf"}))
+	if result["matches"] != false {
+		t.Errorf("Expected the altered export not to match, got %v", result)
+	}
+}
+
+func TestDocGenHandler_ExportPreflight(t *testing.T) {
+	handler, tempDir := setupTestHandler(t)
+	defer cleanupTestHandler(tempDir)
+
+	docID := createTestDocument(t, handler)
+	createTestChapter(t, handler, docID)
+	call := func(args map[string]interface{}) *protocol.CallToolResponse {
+		resp, err := handler.CallTool(context.Background(), &protocol.CallToolRequest{Name: "export_document", Arguments: args})
+		if err != nil {
+			t.Fatalf("CallTool() error = %v", err)
+		}
+		return resp
+	}
+
+	// A preflight on request never exports
+	result := parseSuccessResponse(t, call(map[string]interface{}{"document_id": docID, "format": "html", "preflight": true}))
+	preflight, ok := result["preflight"].(map[string]interface{})
+	if !ok || preflight["chapters"].(float64) != 1 || preflight["estimated_pages"].(float64) < 1 {
+		t.Fatalf("Expected a preflight summary of one chapter, got %v", result)
+	}
+	if _, err := os.Stat(filepath.Join(tempDir, "exports", docID+".html")); !os.IsNotExist(err) {
+		t.Errorf("A preflight should not export, stat error = %v", err)
+	}
+
+	// Exports estimated to take longer than the threshold wait for confirmation
+	handler.config.PreflightThreshold = time.Nanosecond
+	result = parseSuccessResponse(t, call(map[string]interface{}{"document_id": docID, "format": "html"}))
+	if result["confirmation_required"] != true || !strings.Contains(result["message"].(string), "confirm=true") {
+		t.Errorf("Expected the export to wait for confirmation, got %v", result)
+	}
+	if _, err := os.Stat(filepath.Join(tempDir, "exports", docID+".html")); !os.IsNotExist(err) {
+		t.Errorf("An unconfirmed export should not run, stat error = %v", err)
+	}
+
+	// Confirmed exports go ahead, whether or not pandoc is installed
+	resp := call(map[string]interface{}{"document_id": docID, "format": "html", "confirm": true})
+	if text := resp.Content[0].Text; strings.Contains(text, "confirmation_required") {
+		t.Errorf("A confirmed export should run, got %s", text)
+	}
+
+	expectError(t, call(map[string]interface{}{"document_id": docID, "format": "pdf", "float_placement": "anywhere"}), "invalid float placement")
+	expectError(t, call(map[string]interface{}{"document_id": docID, "format": "html", "float_placement": "here"}), "only supported for PDF")
+	expectError(t, call(map[string]interface{}{"document_id": docID, "format": "html", "compression": "rar"}), "invalid compression")
+	expectError(t, call(map[string]interface{}{"document_id": docID, "format": "docx", "optimize_pdf": true}), "only supported for PDF")
+}
+
+func TestDocGenHandler_GetChapterContent(t *testing.T) {
+	handler, tempDir := setupTestHandler(t)
+	defer cleanupTestHandler(tempDir)
+
+	// Simulate a machine without pandoc
+	handler.config.PandocPath = filepath.Join(tempDir, "missing", "pandoc")
+
+	docID := createTestDocument(t, handler)
+	createTestChapter(t, handler, docID)
+	call := func(args map[string]interface{}) *protocol.CallToolResponse {
+		resp, err := handler.CallTool(context.Background(), &protocol.CallToolRequest{Name: "get_chapter_content", Arguments: args})
+		if err != nil {
+			t.Fatalf("CallTool() error = %v", err)
+		}
+		return resp
+	}
+
+	_, err := handler.CallTool(context.Background(), &protocol.CallToolRequest{
+		Name: "add_section",
+		Arguments: map[string]interface{}{
+			"document_id":    docID,
+			"chapter_number": float64(1),
+			"title":          "Overview",
+			"content":        "Some **bold** text.",
+		},
+	})
+	if err != nil {
+		t.Fatalf("Failed to add section: %v", err)
+	}
+
+	result := parseSuccessResponse(t, call(map[string]interface{}{"document_id": docID, "chapter_number": float64(1)}))
+	if !strings.Contains(result["content"].(string), "Some **bold** text.") {
+		t.Errorf("Expected the chapter markdown, got %v", result["content"])
+	}
+
+	result = parseSuccessResponse(t, call(map[string]interface{}{"document_id": docID, "chapter_number": float64(1), "format": "html"}))
+	if result["approximate"] != true || result["note"] == nil {
+		t.Errorf("Expected an approximate rendering marked as such, got %v", result)
+	}
+	if !strings.Contains(result["content"].(string), "<strong>bold</strong>") {
+		t.Errorf("Expected rendered HTML, got %v", result["content"])
+	}
+
+	expectError(t, call(map[string]interface{}{"document_id": docID, "chapter_number": float64(1), "format": "pdf"}), "format must be")
+}
+
+func TestDocGenHandler_SetPartAndChapterRange(t *testing.T) {
+	handler, tempDir := setupTestHandler(t)
+	defer cleanupTestHandler(tempDir)
+
+	docID := createTestDocument(t, handler)
+	createTestChapter(t, handler, docID)
+	createTestChapter(t, handler, docID)
+	call := func(name string, args map[string]interface{}) *protocol.CallToolResponse {
+		resp, err := handler.CallTool(context.Background(), &protocol.CallToolRequest{Name: name, Arguments: args})
+		if err != nil {
+			t.Fatalf("CallTool() error = %v", err)
+		}
+		return resp
+	}
+
+	result := parseSuccessResponse(t, call("set_part", map[string]interface{}{
+		"document_id":   docID,
+		"title":         "Foundations",
+		"first_chapter": float64(1),
+		"last_chapter":  float64(2),
+	}))
+	if parts, ok := result["parts"].([]interface{}); !ok || len(parts) != 1 {
+		t.Errorf("Expected one part, got %v", result["parts"])
+	}
+
+	expectError(t, call("set_part", map[string]interface{}{
+		"document_id":   docID,
+		"title":         "Overlap",
+		"first_chapter": float64(2),
+	}), "overlap")
+
+	// An empty title removes the part
+	result = parseSuccessResponse(t, call("set_part", map[string]interface{}{"document_id": docID, "first_chapter": float64(1)}))
+	if parts, _ := result["parts"].([]interface{}); len(parts) != 0 {
+		t.Errorf("Expected the part to be removed, got %v", result["parts"])
+	}
+
+	expectError(t, call("export_document", map[string]interface{}{
+		"document_id":   docID,
+		"format":        "html",
+		"chapters":      []interface{}{float64(1)},
+		"chapter_range": "1-2",
+	}), "cannot both be given")
+	expectError(t, call("export_document", map[string]interface{}{
+		"document_id":   docID,
+		"format":        "html",
+		"chapter_range": "2-1",
+	}), "invalid chapter range")
+}
+
+func TestDocGenHandler_MergeAdjacentChapters(t *testing.T) {
+	handler, tempDir := setupTestHandler(t)
+	defer cleanupTestHandler(tempDir)
+
+	docID := createTestDocument(t, handler)
+	createTestChapter(t, handler, docID)
+	createTestChapter(t, handler, docID)
+	call := func(args map[string]interface{}) *protocol.CallToolResponse {
+		resp, err := handler.CallTool(context.Background(), &protocol.CallToolRequest{Name: "merge_chapters", Arguments: args})
+		if err != nil {
+			t.Fatalf("CallTool() error = %v", err)
+		}
+		return resp
+	}
+
+	// Without a source the next chapter is merged
+	result := parseSuccessResponse(t, call(map[string]interface{}{"document_id": docID, "target_chapter": float64(1)}))
+	if result["chapter_number"] != float64(1) {
+		t.Errorf("Expected chapter 1 to survive, got %v", result["chapter_number"])
+	}
+
+	// The last chapter has nothing after it
+	expectError(t, call(map[string]interface{}{"document_id": docID, "target_chapter": float64(1)}), "chapter 2 not found")
+}
+
+func TestDocGenHandler_Abbreviations(t *testing.T) {
+	handler, tempDir := setupTestHandler(t)
+	defer cleanupTestHandler(tempDir)
+
+	docID := createTestDocument(t, handler)
+	createTestChapter(t, handler, docID)
+	call := func(name string, args map[string]interface{}) *protocol.CallToolResponse {
+		resp, err := handler.CallTool(context.Background(), &protocol.CallToolRequest{Name: name, Arguments: args})
+		if err != nil {
+			t.Fatalf("CallTool() error = %v", err)
+		}
+		return resp
+	}
+	call("add_section", map[string]interface{}{
+		"document_id":    docID,
+		"chapter_number": float64(1),
+		"title":          "Overview",
+		"content":        "Optical Character Recognition (OCR) reads each page through an API.",
+	})
+
+	result := parseSuccessResponse(t, call("list_abbreviations", map[string]interface{}{"document_id": docID}))
+	if undefined, _ := result["undefined"].([]interface{}); len(undefined) != 1 || undefined[0] != "API" {
+		t.Errorf("Expected API to be undefined, got %v", result["undefined"])
+	}
+
+	parseSuccessResponse(t, call("set_abbreviation", map[string]interface{}{
+		"document_id": docID,
+		"term":        "API",
+		"definition":  "Application Programming Interface",
+	}))
+	result = parseSuccessResponse(t, call("list_abbreviations", map[string]interface{}{"document_id": docID}))
+	abbreviations, _ := result["abbreviations"].([]interface{})
+	if len(abbreviations) != 2 || len(result["undefined"].([]interface{})) != 0 {
+		t.Errorf("Expected API and OCR to be defined, got %v and %v", result["abbreviations"], result["undefined"])
+	}
+
+	// An empty definition removes the term; removing it twice fails
+	parseSuccessResponse(t, call("set_abbreviation", map[string]interface{}{"document_id": docID, "term": "API"}))
+	expectError(t, call("set_abbreviation", map[string]interface{}{"document_id": docID, "term": "API"}), "not defined")
+}
+
+func TestDocGenHandler_ConfigureHeaderFooter(t *testing.T) {
+	handler, tempDir := setupTestHandler(t)
+	defer cleanupTestHandler(tempDir)
+
+	docID := createTestDocument(t, handler)
+	resp, err := handler.CallTool(context.Background(), &protocol.CallToolRequest{
+		Name: "configure_document",
+		Arguments: map[string]interface{}{
+			"document_id": docID,
+			"style_updates": map[string]interface{}{
+				"header_footer": map[string]interface{}{
+					"footer_template":        "{page}",
+					"first_page":             map[string]interface{}{"footer": "Confidential"},
+					"even_page":              map[string]interface{}{"header": "{document_title}"},
+					"suppress_chapter_start": true,
+				},
+			},
+		},
+	})
+	if err != nil {
+		t.Fatalf("CallTool() error = %v", err)
+	}
+	parseSuccessResponse(t, resp)
+
+	style, err := handler.storage.LoadStyle(docID)
+	if err != nil {
+		t.Fatalf("LoadStyle() error = %v", err)
+	}
+	hf := style.HeaderFooter
+	if hf.FooterTemplate != "{page}" || !hf.SuppressChapterStart || hf.OddPage != nil {
+		t.Errorf("Unexpected header and footer %+v", hf)
+	}
+	if hf.FirstPage == nil || hf.FirstPage.Footer != "Confidential" || hf.EvenPage == nil || hf.EvenPage.Header != "{document_title}" {
+		t.Errorf("Unexpected page templates %+v, %+v", hf.FirstPage, hf.EvenPage)
+	}
+}
+
+func TestDocGenHandler_ConfigureMetadata(t *testing.T) {
+	handler, tempDir := setupTestHandler(t)
+	defer cleanupTestHandler(tempDir)
+
+	docID := createTestDocument(t, handler)
+	configure := func(metadata map[string]interface{}) types.DocumentMetadata {
+		resp, err := handler.CallTool(context.Background(), &protocol.CallToolRequest{
+			Name:      "configure_document",
+			Arguments: map[string]interface{}{"document_id": docID, "metadata": metadata},
+		})
+		if err != nil {
+			t.Fatalf("CallTool() error = %v", err)
+		}
+		parseSuccessResponse(t, resp)
+		manifest, err := handler.storage.LoadManifest(docID)
+		if err != nil {
+			t.Fatalf("LoadManifest() error = %v", err)
+		}
+		return manifest.Document.DocumentMetadata
+	}
+
+	metadata := configure(map[string]interface{}{"subtitle": "A Study", "abstract": "Short.", "language": "en-GB", "date": "2024-05-01", "locale": "en-GB", "keywords": []interface{}{"go"}})
+	if metadata.Subtitle != "A Study" || metadata.Abstract != "Short." || metadata.Date != "2024-05-01" || len(metadata.Keywords) != 1 {
+		t.Fatalf("Unexpected metadata %+v", metadata)
+	}
+
+	// Fields not given are kept; empty ones are cleared
+	metadata = configure(map[string]interface{}{"subtitle": "", "abstract": "", "date": "", "keywords": []interface{}{}})
+	if metadata.Subtitle != "" || metadata.Abstract != "" || metadata.Date != "" || len(metadata.Keywords) != 0 || metadata.Language != "en-GB" || metadata.Locale != "en-GB" {
+		t.Errorf("Expected subtitle, abstract, date and keywords cleared, got %+v", metadata)
+	}
+	metadata = configure(map[string]interface{}{"language": "", "locale": ""})
+	if metadata.Language != "" || metadata.Locale != "" {
+		t.Errorf("Expected language and locale cleared, got %+v", metadata)
+	}
+}
+
+func TestDocGenHandler_ConfigureHTMLTheme(t *testing.T) {
+	handler, tempDir := setupTestHandler(t)
+	defer cleanupTestHandler(tempDir)
+
+	docID := createTestDocument(t, handler)
+	resp, err := handler.CallTool(context.Background(), &protocol.CallToolRequest{
+		Name: "configure_document",
+		Arguments: map[string]interface{}{
+			"document_id": docID,
+			"style_updates": map[string]interface{}{
+				"html": map[string]interface{}{
+					"max_width":       "45rem",
+					"sidebar_toc":     true,
+					"dark_mode":       true,
+					"dark_link_color": "#88c0d0",
+				},
+			},
+		},
+	})
+	if err != nil {
+		t.Fatalf("CallTool() error = %v", err)
+	}
+	parseSuccessResponse(t, resp)
+
+	style, err := handler.storage.LoadStyle(docID)
+	if err != nil {
+		t.Fatalf("LoadStyle() error = %v", err)
+	}
+	want := types.HTMLStyle{MaxWidth: "45rem", SidebarTOC: true, DarkMode: true, DarkLinkColor: "#88c0d0"}
+	if style.HTML != want {
+		t.Errorf("Unexpected HTML style %+v", style.HTML)
+	}
+}
+
+func TestDocGenHandler_SlideDecks(t *testing.T) {
+	handler, tempDir := setupTestHandler(t)
+	defer cleanupTestHandler(tempDir)
+
+	docID := createTestDocument(t, handler)
+	call := func(name string, args map[string]interface{}) *protocol.CallToolResponse {
+		args["document_id"] = docID
+		resp, err := handler.CallTool(context.Background(), &protocol.CallToolRequest{Name: name, Arguments: args})
+		if err != nil {
+			t.Fatalf("CallTool() error = %v", err)
+		}
+		return resp
+	}
+
+	parseSuccessResponse(t, call("configure_document", map[string]interface{}{
+		"style_updates": map[string]interface{}{
+			"slides": map[string]interface{}{"theme": "black", "transition": "zoom", "aspect_ratio": "4:3", "slide_numbers": true},
+		},
+	}))
+	style, err := handler.storage.LoadStyle(docID)
+	if err != nil {
+		t.Fatalf("LoadStyle() error = %v", err)
+	}
+	if want := (types.SlideStyle{Theme: "black", Transition: "zoom", AspectRatio: "4:3", SlideNumbers: true}); style.Slides != want {
+		t.Errorf("Unexpected slide style %+v", style.Slides)
+	}
+	expectError(t, call("configure_document", map[string]interface{}{
+		"style_updates": map[string]interface{}{"slides": map[string]interface{}{"transition": "spin"}},
+	}), "invalid slide transition")
+	expectError(t, call("configure_document", map[string]interface{}{
+		"style_updates": map[string]interface{}{"slides": map[string]interface{}{"aspect_ratio": "21:9"}},
+	}), "invalid slide aspect_ratio")
+
+	expectError(t, call("export_document", map[string]interface{}{"format": "pdf", "incremental": true, "preflight": true}), "only supported for revealjs and pptx")
+	expectError(t, call("export_document", map[string]interface{}{"format": "pptx", "slide_split": "paragraph", "preflight": true}), "invalid slide_split")
+	parseSuccessResponse(t, call("export_document", map[string]interface{}{"format": "revealjs", "slide_split": "chapter", "incremental": true, "preflight": true}))
+}
+
+func TestDocGenHandler_ConfigureBibliography(t *testing.T) {
+	handler, tempDir := setupTestHandler(t)
+	defer cleanupTestHandler(tempDir)
+
+	docID := createTestDocument(t, handler)
+	call := func(args map[string]interface{}) *protocol.CallToolResponse {
+		resp, err := handler.CallTool(context.Background(), &protocol.CallToolRequest{Name: "configure_document", Arguments: args})
+		if err != nil {
+			t.Fatalf("CallTool() error = %v", err)
+		}
+		return resp
+	}
+
+	parseSuccessResponse(t, call(map[string]interface{}{
+		"document_id": docID,
+		"pandoc_options": map[string]interface{}{
+			"toc":                true,
+			"bibliography":       "references.bib",
+			"bibliography_scope": "chapter",
+		},
+	}))
+	pandocConfig, err := handler.storage.LoadPandocConfig(docID)
+	if err != nil {
+		t.Fatalf("LoadPandocConfig() error = %v", err)
+	}
+	if pandocConfig.Bibliography != "references.bib" || pandocConfig.BibliographyScope != types.BibliographyScopeChapter {
+		t.Errorf("Expected a per-chapter bibliography, got %q (%q)", pandocConfig.Bibliography, pandocConfig.BibliographyScope)
+	}
+
+	expectError(t, call(map[string]interface{}{
+		"document_id":    docID,
+		"pandoc_options": map[string]interface{}{"bibliography_scope": "section"},
+	}), "Invalid bibliography_scope")
+}
+
+func TestDocGenHandler_InsertCitation(t *testing.T) {
+	handler, tempDir := setupTestHandler(t)
+	defer cleanupTestHandler(tempDir)
+
+	docID := createTestDocument(t, handler)
+	createTestChapter(t, handler, docID)
+	call := func(name string, args map[string]interface{}) *protocol.CallToolResponse {
+		resp, err := handler.CallTool(context.Background(), &protocol.CallToolRequest{Name: name, Arguments: args})
+		if err != nil {
+			t.Fatalf("CallTool() error = %v", err)
+		}
+		return resp
+	}
+	call("add_section", map[string]interface{}{
+		"document_id":    docID,
+		"chapter_number": float64(1),
+		"title":          "Macros",
+		"content":        "As the TeXbook explains, macros expand in the mouth.",
+	})
+	os.WriteFile(filepath.Join(tempDir, docID, "references.bib"), []byte("@book{knuth84, title={The TeXbook}}\n"), 0644)
+	call("configure_document", map[string]interface{}{
+		"document_id":    docID,
+		"pandoc_options": map[string]interface{}{"bibliography": "references.bib"},
+	})
+
+	result := parseSuccessResponse(t, call("insert_citation", map[string]interface{}{
+		"document_id":    docID,
+		"chapter_number": float64(1),
+		"section_number": "1.1",
+		"key":            "@knuth84",
+		"locator":        "p. 12",
+		"after_text":     "the TeXbook",
+	}))
+	if result["citation"] != "[@knuth84, p. 12]" {
+		t.Errorf("Expected [@knuth84, p. 12], got %v", result["citation"])
+	}
+	result = parseSuccessResponse(t, call("get_section_content", map[string]interface{}{
+		"document_id": docID,
+		"sections":    []interface{}{map[string]interface{}{"chapter_number": float64(1), "section_number": "1.1"}},
+	}))
+	if sections, _ := result["sections"].([]interface{}); len(sections) != 1 || !strings.Contains(sections[0].(map[string]interface{})["content"].(string), "the TeXbook [@knuth84, p. 12] explains") {
+		t.Errorf("Expected the citation after the hint, got %v", result["sections"])
+	}
+
+	expectError(t, call("insert_citation", map[string]interface{}{
+		"document_id":    docID,
+		"chapter_number": float64(1),
+		"section_number": "1.1",
+		"key":            "lamport94",
+	}), "not found in bibliography")
+}
+
+func TestDocGenHandler_ResolveStyle(t *testing.T) {
+	handler, tempDir := setupTestHandler(t)
+	defer cleanupTestHandler(tempDir)
+
+	call := func(args map[string]interface{}) *protocol.CallToolResponse {
+		resp, err := handler.CallTool(context.Background(), &protocol.CallToolRequest{Name: "resolve_style", Arguments: args})
+		if err != nil {
+			t.Fatalf("CallTool() error = %v", err)
+		}
+		return resp
+	}
+
+	// Without a name, the default style is resolved
+	result := parseSuccessResponse(t, call(map[string]interface{}{}))
+	if chain := result["chain"].([]interface{}); len(chain) != 1 || chain[0] != "default" {
+		t.Errorf("Expected the default style, got chain %v", chain)
+	}
+
+	// A style that extends the default only overrides what it sets
+	brand := "extends: default\nheading:\n  color: \"#C00000\"\nnumbering_style:\n  chapters: false\n"
+	if err := os.WriteFile(filepath.Join(tempDir, "styles", "brand.yaml"), []byte(brand), 0644); err != nil {
+		t.Fatalf("Failed to write style: %v", err)
+	}
+	result = parseSuccessResponse(t, call(map[string]interface{}{"style_name": "brand"}))
+	style := result["style"].(map[string]interface{})
+	heading := style["heading"].(map[string]interface{})
+	numbering := style["numbering_style"].(map[string]interface{})
+	if heading["color"] != "#C00000" || heading["font_family"] == "" || numbering["chapters"] != false || numbering["sections"] != true {
+		t.Errorf("Unexpected effective style: %v", style)
+	}
+	if _, ok := style["extends"]; ok {
+		t.Errorf("The effective style should be flattened: %v", style)
+	}
+
+	expectError(t, call(map[string]interface{}{"style_name": "missing"}), "not found")
+}
+
+func TestDocGenHandler_GetTOC(t *testing.T) {
+	handler, tempDir := setupTestHandler(t)
+	defer cleanupTestHandler(tempDir)
+
+	docID := createTestDocument(t, handler)
+	createTestChapter(t, handler, docID)
+	call := func(args map[string]interface{}) *protocol.CallToolResponse {
+		resp, err := handler.CallTool(context.Background(), &protocol.CallToolRequest{Name: "get_toc", Arguments: args})
+		if err != nil {
+			t.Fatalf("CallTool() error = %v", err)
+		}
+		return resp
+	}
+
+	result := parseSuccessResponse(t, call(map[string]interface{}{"document_id": docID}))
+	if toc, ok := result["toc"].(string); !ok || !strings.Contains(toc, "\n1 ") {
+		t.Errorf("Expected a text outline with chapter 1, got %v", result["toc"])
+	}
+
+	result = parseSuccessResponse(t, call(map[string]interface{}{"document_id": docID, "format": "json", "depth": float64(1)}))
+	toc := result["toc"].(map[string]interface{})
+	if chapters := toc["chapters"].([]interface{}); len(chapters) != 1 {
+		t.Errorf("Expected one chapter, got %v", chapters)
+	}
+
+	expectError(t, call(map[string]interface{}{"document_id": docID, "format": "xml"}), "Invalid format")
+}
+
+func TestDocGenHandler_GetDocumentStructureOptions(t *testing.T) {
+	handler, tempDir := setupTestHandler(t)
+	defer cleanupTestHandler(tempDir)
+
+	docID := createTestDocument(t, handler)
+	createTestChapter(t, handler, docID)
+	call := func(args map[string]interface{}) *protocol.CallToolResponse {
+		args["document_id"] = docID
+		resp, err := handler.CallTool(context.Background(), &protocol.CallToolRequest{Name: "get_document_structure", Arguments: args})
+		if err != nil {
+			t.Fatalf("CallTool() error = %v", err)
+		}
+		return resp
+	}
+
+	result := parseSuccessResponse(t, call(map[string]interface{}{"compact": true, "include": []interface{}{}}))
+	if _, ok := result["created_at"]; ok {
+		t.Errorf("Compact structure should leave out timestamps, got %v", result)
+	}
+	if _, ok := result["document"].(map[string]interface{}); !ok {
+		t.Errorf("Expected the document, got %v", result)
+	}
+
+	result = parseSuccessResponse(t, call(map[string]interface{}{"chapter_range": "1", "depth": float64(1)}))
+	chapters := result["document"].(map[string]interface{})["chapters"].([]interface{})
+	if len(chapters) != 1 {
+		t.Errorf("Expected chapter 1 only, got %v", chapters)
+	}
+
+	expectError(t, call(map[string]interface{}{"include": []interface{}{"pages"}}), "Invalid include")
+	expectError(t, call(map[string]interface{}{"chapter_range": "7"}), "chapter 7 not found")
+}
+
+func TestDocGenHandler_DocumentHealth(t *testing.T) {
+	handler, tempDir := setupTestHandler(t)
+	defer cleanupTestHandler(tempDir)
+
+	docID := createTestDocument(t, handler)
+	createTestChapter(t, handler, docID)
+
+	resp, err := handler.CallTool(context.Background(), &protocol.CallToolRequest{
+		Name:      "get_document_structure",
+		Arguments: map[string]interface{}{"document_id": docID},
+	})
+	if err != nil {
+		t.Fatalf("CallTool() error = %v", err)
+	}
+	health, ok := parseSuccessResponse(t, resp)["health"].(map[string]interface{})
+	if !ok {
+		t.Fatalf("Expected a health report in the structure")
+	}
+	// The test chapter has no sections yet
+	if score := health["score"].(float64); score >= 100 || len(health["empty_chapters"].([]interface{})) != 1 {
+		t.Errorf("Unexpected health: %v", health)
+	}
+
+	resp, err = handler.CallTool(context.Background(), &protocol.CallToolRequest{
+		Name:      "list_documents",
+		Arguments: map[string]interface{}{},
+	})
+	if err != nil {
+		t.Fatalf("CallTool() error = %v", err)
+	}
+	documents := parseSuccessResponse(t, resp)["documents"].([]interface{})
+	summary := documents[0].(map[string]interface{})
+	if listed, ok := summary["health"].(map[string]interface{}); !ok || listed["score"] != health["score"] {
+		t.Errorf("Listed health %v should match the structure's %v", summary["health"], health)
+	}
+}
+
+func TestDocGenHandler_RenameDocument(t *testing.T) {
+	handler, tempDir := setupTestHandler(t)
+	defer cleanupTestHandler(tempDir)
+
+	handler.config.AccessControl = true
+	policy := &types.AccessPolicy{Roles: map[string]types.Role{"alice": types.RoleEditor}}
+	if err := handler.storage.SaveAccessPolicy(policy); err != nil {
+		t.Fatalf("Failed to save access policy: %v", err)
+	}
+	call := func(identity string, args map[string]interface{}) *protocol.CallToolResponse {
+		ctx := usage.WithClientID(context.Background(), identity)
+		resp, err := handler.CallTool(ctx, &protocol.CallToolRequest{Name: "rename_document", Arguments: args})
+		if err != nil {
+			t.Fatalf("CallTool() error = %v", err)
+		}
+		return resp
+	}
+
+	ctx := usage.WithClientID(context.Background(), "alice")
+	resp, _ := handler.CallTool(ctx, &protocol.CallToolRequest{Name: "create_document", Arguments: map[string]interface{}{"title": "Working Title", "author": "Alice", "type": "report"}})
+	docID := parseSuccessResponse(t, resp)["document_id"].(string)
+	handler.updateAccessPolicy(func(policy *types.AccessPolicy) {
+		policy.SetDocumentRole(docID, "bob", types.RoleEditor)
+	})
+
+	// Editors can retitle but only admins can move a document
+	result := parseSuccessResponse(t, call("bob", map[string]interface{}{"document_id": docID, "title": "Field Study"}))
+	if result["document_id"] != docID || result["title"] != "Field Study" {
+		t.Errorf("Unexpected rename result %v", result)
+	}
+	expectError(t, call("bob", map[string]interface{}{"document_id": docID, "new_document_id": "field-study"}), "requires the admin role")
+
+	result = parseSuccessResponse(t, call("alice", map[string]interface{}{"document_id": docID, "new_document_id": "field-study"}))
+	if result["document_id"] != "field-study" || result["previous_document_id"] != docID {
+		t.Errorf("Unexpected move result %v", result)
+	}
+
+	// Roles follow the document
+	policy, _ = handler.storage.LoadAccessPolicy()
+	if _, ok := policy.Documents[docID]; ok || policy.Documents["field-study"]["bob"] != types.RoleEditor {
+		t.Errorf("Expected roles to move to field-study, got %v", policy.Documents)
+	}
+	expectError(t, call("alice", map[string]interface{}{"document_id": "field-study"}), "nothing to change")
+}
+
+func TestDocGenHandler_TagDocuments(t *testing.T) {
+	handler, tempDir := setupTestHandler(t)
+	defer cleanupTestHandler(tempDir)
+
+	call := func(name string, args map[string]interface{}) *protocol.CallToolResponse {
+		resp, err := handler.CallTool(context.Background(), &protocol.CallToolRequest{Name: name, Arguments: args})
+		if err != nil {
+			t.Fatalf("CallTool(%s) error = %v", name, err)
+		}
+		return resp
+	}
+
+	acme := createTestDocument(t, handler)
+	other := parseSuccessResponse(t, call("create_document", map[string]interface{}{"title": "Other Notes", "author": "Test Author", "type": "report"}))["document_id"].(string)
+	parseSuccessResponse(t, call("tag_document", map[string]interface{}{"document_id": acme, "tags": []interface{}{"client:acme", "draft"}}))
+	parseSuccessResponse(t, call("tag_document", map[string]interface{}{"document_id": other, "tags": []interface{}{"draft"}}))
+
+	listed := parseSuccessResponse(t, call("list_documents", map[string]interface{}{"tags": []interface{}{"Client:Acme"}}))
+	documents := listed["documents"].([]interface{})
+	if len(documents) != 1 || documents[0].(map[string]interface{})["document_id"] != acme {
+		t.Errorf("Expected only the acme document, got %v", documents)
+	}
+	if counts := listed["tags"].(map[string]interface{}); counts["draft"].(float64) != 2 || counts["client:acme"].(float64) != 1 {
+		t.Errorf("Unexpected tag counts %v", counts)
+	}
+
+	result := parseSuccessResponse(t, call("untag_document", map[string]interface{}{"document_id": acme, "tags": []interface{}{"draft"}}))
+	if tags := result["tags"].([]interface{}); len(tags) != 1 || tags[0] != "client:acme" {
+		t.Errorf("Expected client:acme to remain, got %v", tags)
+	}
+
+	expectError(t, call("tag_document", map[string]interface{}{"document_id": acme}), "tags parameter is required")
+	expectError(t, call("list_documents", map[string]interface{}{"tags": []interface{}{"not a tag"}}), "invalid tag")
+}
+
+func TestDocGenHandler_GetStorageUsage(t *testing.T) {
+	handler, tempDir := setupTestHandler(t)
+	defer cleanupTestHandler(tempDir)
+
+	docID := createTestDocument(t, handler)
+	createTestChapter(t, handler, docID)
+	call := func(name string, args map[string]interface{}) *protocol.CallToolResponse {
+		resp, err := handler.CallTool(context.Background(), &protocol.CallToolRequest{Name: name, Arguments: args})
+		if err != nil {
+			t.Fatalf("CallTool(%s) error = %v", name, err)
+		}
+		return resp
+	}
+
+	result := parseSuccessResponse(t, call("get_storage_usage", map[string]interface{}{}))
+	usage := result["usage"].(map[string]interface{})
+	documents := usage["documents"].([]interface{})
+	if len(documents) != 1 || documents[0].(map[string]interface{})["document_id"] != docID || usage["total_bytes"].(float64) == 0 {
+		t.Errorf("Unexpected storage usage %v", usage)
+	}
+
+	// Writes and exports past the quota fail
+	handler.config.MaxDocumentSize = 1
+	expectError(t, call("add_section", map[string]interface{}{"document_id": docID, "chapter_number": float64(1), "title": "More", "content": "Too much."}), "storage quota exceeded")
+	expectError(t, call("export_document", map[string]interface{}{"document_id": docID, "format": "html", "confirm": true}), "storage quota exceeded")
+	result = parseSuccessResponse(t, call("get_storage_usage", map[string]interface{}{}))
+	if !strings.Contains(result["message"].(string), "over the per-document quota: "+docID) {
+		t.Errorf("Expected the document to be flagged, got %v", result["message"])
+	}
+}
+
+func TestDocGenHandler_SandboxDocuments(t *testing.T) {
+	handler, tempDir := setupTestHandler(t)
+	defer cleanupTestHandler(tempDir)
+
+	call := func(name string, args map[string]interface{}) *protocol.CallToolResponse {
+		resp, err := handler.CallTool(context.Background(), &protocol.CallToolRequest{Name: name, Arguments: args})
+		if err != nil {
+			t.Fatalf("CallTool() error = %v", err)
+		}
+		return resp
+	}
+
+	result := parseSuccessResponse(t, call("create_sandbox_document", map[string]interface{}{"ttl_minutes": float64(5)}))
+	docID, _ := result["document_id"].(string)
+	if !strings.HasPrefix(docID, document.SandboxIDPrefix) || result["expires_at"] == nil {
+		t.Fatalf("Unexpected sandbox: %v", result)
+	}
+	parseSuccessResponse(t, call("add_chapter", map[string]interface{}{"document_id": docID, "title": "Experiment"}))
+
+	listed := parseSuccessResponse(t, call("list_documents", map[string]interface{}{}))["documents"].([]interface{})
+	if len(listed) != 1 || listed[0].(map[string]interface{})["expires_at"] == nil {
+		t.Errorf("Expected the sandbox listed with its expiry, got %v", listed)
+	}
+
+	expectError(t, call("create_sandbox_document", map[string]interface{}{"type": "poem"}), "type must be one of")
+	expectError(t, call("create_sandbox_document", map[string]interface{}{"ttl_minutes": float64(2000)}), "at most 24 hours")
+
+	// Expired sandboxes are swept away
+	if deleted := handler.SweepSandboxes(time.Now().Add(10 * time.Minute)); deleted != 1 {
+		t.Errorf("SweepSandboxes() = %d, want 1", deleted)
+	}
+	expectError(t, call("get_document_structure", map[string]interface{}{"document_id": docID}), "not found")
+}
+
+func TestDocGenHandler_AppendAndInsertIntoSection(t *testing.T) {
+	handler, tempDir := setupTestHandler(t)
+	defer cleanupTestHandler(tempDir)
+
+	docID := createTestDocument(t, handler)
+	createTestChapter(t, handler, docID)
+	call := func(name string, args map[string]interface{}) *protocol.CallToolResponse {
+		args["document_id"] = docID
+		args["chapter_number"] = float64(1)
+		args["section_number"] = "1.1"
+		resp, err := handler.CallTool(context.Background(), &protocol.CallToolRequest{Name: name, Arguments: args})
+		if err != nil {
+			t.Fatalf("CallTool() error = %v", err)
+		}
+		return resp
+	}
+	if _, err := handler.manager.AddSection(context.Background(), types.DocumentID(docID), 1, "Intro", "One.", 1); err != nil {
+		t.Fatalf("AddSection() error = %v", err)
+	}
+
+	parseSuccessResponse(t, call("append_to_section", map[string]interface{}{"content": "Three."}))
+	parseSuccessResponse(t, call("insert_into_section", map[string]interface{}{"content": "Two.", "after_text": "One"}))
+	content, _ := handler.manager.GetSectionContent(types.DocumentID(docID), 1, types.SectionNumber{1, 1})
+	if content != "One.\n\nTwo.\n\nThree." {
+		t.Errorf("Unexpected content %q", content)
+	}
+
+	expectError(t, call("insert_into_section", map[string]interface{}{"content": "Four."}), "either after_paragraph or after_text")
+	expectError(t, call("insert_into_section", map[string]interface{}{"content": "Four.", "after_paragraph": float64(7)}), "paragraph 7 not found")
+	expectError(t, call("append_to_section", map[string]interface{}{}), "content parameter is required")
+}
+
+func TestDocGenHandler_MarkdownExtensions(t *testing.T) {
+	handler, tempDir := setupTestHandler(t)
+	defer cleanupTestHandler(tempDir)
+
+	// A pandoc stand-in that only lists its markdown extensions
+	handler.config.PandocPath = filepath.Join(tempDir, "pandoc")
+	script := "#!/bin/sh\nprintf '+definition_lists\\n-mark\\n+smart\\n'\n"
+	if err := os.WriteFile(handler.config.PandocPath, []byte(script), 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	docID := createTestDocument(t, handler)
+	createTestChapter(t, handler, docID)
+	call := func(name string, args map[string]interface{}) *protocol.CallToolResponse {
+		args["document_id"] = docID
+		resp, err := handler.CallTool(context.Background(), &protocol.CallToolRequest{Name: name, Arguments: args})
+		if err != nil {
+			t.Fatalf("CallTool() error = %v", err)
+		}
+		return resp
+	}
+
+	parseSuccessResponse(t, call("configure_document", map[string]interface{}{"markdown": map[string]interface{}{"definition_lists": true, "smart": false}}))
+	pandocConfig, _ := handler.storage.LoadPandocConfig(docID)
+	if got := pandocConfig.Markdown.PandocFormat(); got != "markdown-smart+definition_lists" {
+		t.Errorf("PandocFormat() = %q", got)
+	}
+
+	parseSuccessResponse(t, call("configure_chapter", map[string]interface{}{"chapter_number": float64(1), "markdown": map[string]interface{}{"mark": true}}))
+	chapter, _ := handler.storage.LoadChapterMetadata(docID, 1)
+	if chapter.PandocOptions == nil || !chapter.PandocOptions.Markdown["mark"] {
+		t.Errorf("Expected the chapter to turn on mark, got %+v", chapter.PandocOptions)
+	}
+
+	expectError(t, call("configure_document", map[string]interface{}{"markdown": map[string]interface{}{"wikilinks": true}}), "does not support wikilinks")
+	expectError(t, call("configure_chapter", map[string]interface{}{"chapter_number": float64(1), "markdown": map[string]interface{}{"mark": "yes"}}), "markdown.mark must be true or false")
+}
+
+func TestDocGenHandler_IncludeFile(t *testing.T) {
+	handler, tempDir := setupTestHandler(t)
+	defer cleanupTestHandler(tempDir)
+
+	docID := createTestDocument(t, handler)
+	createTestChapter(t, handler, docID)
+	call := func(args map[string]interface{}) *protocol.CallToolResponse {
+		args["document_id"] = docID
+		args["chapter_number"] = float64(1)
+		resp, err := handler.CallTool(context.Background(), &protocol.CallToolRequest{Name: "include_file", Arguments: args})
+		if err != nil {
+			t.Fatalf("CallTool() error = %v", err)
+		}
+		return resp
+	}
+	if _, err := handler.manager.AddSection(context.Background(), types.DocumentID(docID), 1, "Setup", "Local text.", 1); err != nil {
+		t.Fatalf("AddSection() error = %v", err)
+	}
+	shared := filepath.Join(tempDir, "shared.md")
+	os.WriteFile(shared, []byte("Shared text."), 0644)
+
+	result := parseSuccessResponse(t, call(map[string]interface{}{"section_number": "1.1", "path": shared}))
+	if result["included_file"] == nil {
+		t.Errorf("Expected included_file in %v", result)
+	}
+	chapter, _ := handler.manager.GetChapter(types.DocumentID(docID), 1)
+	if !strings.Contains(chapter.Content, "Shared text.") || strings.Contains(chapter.Content, "Local text.") {
+		t.Errorf("Section should show the included file:\n%s", chapter.Content)
+	}
+
+	expectError(t, call(map[string]interface{}{}), "path parameter is required")
+	expectError(t, call(map[string]interface{}{"path": "/etc/hostname"}), "Failed to include file")
+
+	parseSuccessResponse(t, call(map[string]interface{}{"section_number": "1.1", "path": ""}))
+	chapter, _ = handler.manager.GetChapter(types.DocumentID(docID), 1)
+	if !strings.Contains(chapter.Content, "Local text.") {
+		t.Errorf("Section should show its own text again:\n%s", chapter.Content)
+	}
+}
+
+func TestDocGenHandler_BuildOutline(t *testing.T) {
+	handler, tempDir := setupTestHandler(t)
+	defer cleanupTestHandler(tempDir)
+
+	docID := createTestDocument(t, handler)
+	call := func(outline interface{}) *protocol.CallToolResponse {
+		resp, err := handler.CallTool(context.Background(), &protocol.CallToolRequest{
+			Name:      "build_outline",
+			Arguments: map[string]interface{}{"document_id": docID, "outline": outline},
+		})
+		if err != nil {
+			t.Fatalf("CallTool() error = %v", err)
+		}
+		return resp
+	}
+
+	result := parseSuccessResponse(t, call([]interface{}{
+		map[string]interface{}{
+			"title": "Introduction",
+			"sections": []interface{}{
+				map[string]interface{}{"title": "Background", "content": "Draft."},
+			},
+		},
+	}))
+	if result["sections"] != float64(1) {
+		t.Errorf("Expected 1 section, got %v", result["sections"])
+	}
+
+	// YAML text works as well
+	result = parseSuccessResponse(t, call("- title: Methods\n  sections:\n    - title: Data\n      sections:\n        - title: Sources\n"))
+	if result["sections"] != float64(2) {
+		t.Errorf("Expected 2 sections, got %v", result["sections"])
+	}
+	chapter, _ := handler.manager.GetChapter(types.DocumentID(docID), 2)
+	if len(chapter.Sections) != 2 || chapter.Sections[1].Number.String() != "2.1.1" {
+		t.Errorf("Unexpected sections %+v", chapter.Sections)
+	}
+
+	expectError(t, call(nil), "outline parameter is required")
+	expectError(t, call([]interface{}{map[string]interface{}{"title": "X", "subsections": []interface{}{}}}), "unknown field")
+}
+
+func TestDocGenHandler_SectionMarkdownChecks(t *testing.T) {
+	handler, tempDir := setupTestHandler(t)
+	defer cleanupTestHandler(tempDir)
+
+	docID := createTestDocument(t, handler)
+	createTestChapter(t, handler, docID)
+	call := func(name string, args map[string]interface{}) *protocol.CallToolResponse {
+		args["document_id"] = docID
+		args["chapter_number"] = float64(1)
+		resp, err := handler.CallTool(context.Background(), &protocol.CallToolRequest{Name: name, Arguments: args})
+		if err != nil {
+			t.Fatalf("CallTool() error = %v", err)
+		}
+		return resp
+	}
+
+	// Without strict markdown, content is saved with warnings
+	result := parseSuccessResponse(t, call("add_section", map[string]interface{}{"title": "Code", "content": "```\nunclosed"}))
+	warnings, ok := result["markdown_warnings"].([]interface{})
+	if !ok || len(warnings) != 1 || warnings[0].(map[string]interface{})["problem"] != "unclosed_code_fence" {
+		t.Errorf("Expected an unclosed fence warning, got %v", result["markdown_warnings"])
+	}
+	result = parseSuccessResponse(t, call("update_section", map[string]interface{}{"section_number": "1.1", "content": "```\nclosed\n```"}))
+	if result["markdown_warnings"] != nil {
+		t.Errorf("Expected no warnings, got %v", result["markdown_warnings"])
+	}
+
+	// With strict markdown, it is rejected and the section keeps its content
+	handler.config.StrictMarkdown = true
+	expectError(t, call("update_section", map[string]interface{}{"section_number": "1.1", "content": "# Title\n\n#### Deep"}), "heading level jumps")
+	content, _ := handler.manager.GetSectionContent(types.DocumentID(docID), 1, types.SectionNumber{1, 1})
+	if content != "```\nclosed\n```" {
+		t.Errorf("Rejected content should not be saved, got %q", content)
+	}
+	expectError(t, call("add_section", map[string]interface{}{"title": "Image", "content": "![Chart]()"}), "image has no path")
+}
+
+func TestDocGenHandler_Accessibility(t *testing.T) {
+	handler, tempDir := setupTestHandler(t)
+	defer cleanupTestHandler(tempDir)
+
+	docID := createTestDocument(t, handler)
+	createTestChapter(t, handler, docID)
+	call := func(name string, args map[string]interface{}) *protocol.CallToolResponse {
+		args["document_id"] = docID
+		resp, err := handler.CallTool(context.Background(), &protocol.CallToolRequest{Name: name, Arguments: args})
+		if err != nil {
+			t.Fatalf("CallTool() error = %v", err)
+		}
+		return resp
+	}
+
+	parseSuccessResponse(t, call("add_section", map[string]interface{}{
+		"chapter_number": float64(1),
+		"title":          "Results",
+		"content":        "![](chart.png)",
+	}))
+
+	result := parseSuccessResponse(t, call("validate_document", map[string]interface{}{"accessibility": true}))
+	report := result["validation_report"].(map[string]interface{})
+	warnings, _ := report["warnings"].([]interface{})
+	found := false
+	for _, warning := range warnings {
+		if strings.Contains(warning.(string), "image chart.png has no alt text") {
+			found = true
+		}
+	}
+	if !found {
+		t.Errorf("Expected a missing alt text warning, got %v", warnings)
+	}
+
+	expectError(t, call("validate_document", map[string]interface{}{"accessibility": true, "style_name": "missing"}), "Failed to load style")
+	expectError(t, call("export_document", map[string]interface{}{"format": "docx", "accessible": true}), "only supported for PDF and HTML")
+}
+
+func TestDocGenHandler_ImageProperties(t *testing.T) {
+	handler, tempDir := setupTestHandler(t)
+	defer cleanupTestHandler(tempDir)
+
+	docID := createTestDocument(t, handler)
+	createTestChapter(t, handler, docID)
+	call := func(name string, args map[string]interface{}) *protocol.CallToolResponse {
+		args["document_id"] = docID
+		resp, err := handler.CallTool(context.Background(), &protocol.CallToolRequest{Name: name, Arguments: args})
+		if err != nil {
+			t.Fatalf("CallTool() error = %v", err)
+		}
+		return resp
+	}
+
+	parseSuccessResponse(t, call("add_image", map[string]interface{}{
+		"chapter_number": float64(1),
+		"image_path":     "assets/images/chart.png",
+		"caption":        "A chart",
+		"width":          "50%",
+		"alignment":      "right",
+	}))
+	result := parseSuccessResponse(t, call("update_image_properties", map[string]interface{}{
+		"figure_id": "fig-1.1",
+		"position":  "top",
+	}))
+	if result["width"] != "50%" || result["alignment"] != "right" || result["position"] != "top" {
+		t.Errorf("Unexpected properties %v", result)
+	}
+
+	expectError(t, call("add_image", map[string]interface{}{"chapter_number": float64(1), "image_path": "assets/images/chart.png", "width": "huge"}), "invalid width")
+	expectError(t, call("update_image_properties", map[string]interface{}{"figure_id": "fig-1.1"}), "at least one of")
+	expectError(t, call("update_image_properties", map[string]interface{}{"figure_id": "fig-1.9", "alignment": "left"}), "not found")
+}
+
+func TestDocGenHandler_SetFigureAnchor(t *testing.T) {
+	handler, tempDir := setupTestHandler(t)
+	defer cleanupTestHandler(tempDir)
+
+	docID := createTestDocument(t, handler)
+	createTestChapter(t, handler, docID)
+	call := func(name string, args map[string]interface{}) *protocol.CallToolResponse {
+		args["document_id"] = docID
+		resp, err := handler.CallTool(context.Background(), &protocol.CallToolRequest{Name: name, Arguments: args})
+		if err != nil {
+			t.Fatalf("CallTool() error = %v", err)
+		}
+		return resp
+	}
+
+	parseSuccessResponse(t, call("add_section", map[string]interface{}{"chapter_number": float64(1), "title": "Results", "content": "First.\n\nSecond."}))
+	image := parseSuccessResponse(t, call("add_image", map[string]interface{}{"chapter_number": float64(1), "image_path": "assets/images/chart.png", "caption": "A chart"}))
+	figureID, _ := image["figure_id"].(string)
+
+	// The figure is named by its display number
+	result := parseSuccessResponse(t, call("set_figure_anchor", map[string]interface{}{"id": "fig-1.1", "section_number": "1.1", "after_paragraph": float64(1)}))
+	if result["message"] != "fig-1.1 anchored after paragraph 1 of section 1.1" || result["placed_by_hand"] != nil {
+		t.Errorf("Unexpected result %v", result)
+	}
+	chapter := parseSuccessResponse(t, call("get_chapter_content", map[string]interface{}{"chapter_number": float64(1)}))
+	if content, _ := chapter["content"].(string); !strings.Contains(content, "First.\n\n![A chart](assets/images/chart.png){#"+figureID+"}\n\nSecond.") {
+		t.Errorf("Expected the figure between the paragraphs, got %v", chapter)
+	}
+
+	result = parseSuccessResponse(t, call("set_figure_anchor", map[string]interface{}{"id": "fig-1.1"}))
+	if result["message"] != "Anchor of fig-1.1 cleared" {
+		t.Errorf("Unexpected result %v", result)
+	}
+	expectError(t, call("set_figure_anchor", map[string]interface{}{"id": "fig-1.1", "section_number": "1"}), "Invalid section_number")
+	expectError(t, call("set_figure_anchor", map[string]interface{}{"id": "fig-1.4", "section_number": "1.1"}), "not found")
+}
+
+func TestDocGenHandler_SetFrontMatter(t *testing.T) {
+	handler, tempDir := setupTestHandler(t)
+	defer cleanupTestHandler(tempDir)
+
+	docID := createTestDocument(t, handler)
+	createTestChapter(t, handler, docID)
+	call := func(name string, args map[string]interface{}) *protocol.CallToolResponse {
+		args["document_id"] = docID
+		resp, err := handler.CallTool(context.Background(), &protocol.CallToolRequest{Name: name, Arguments: args})
+		if err != nil {
+			t.Fatalf("CallTool() error = %v", err)
+		}
+		return resp
+	}
+	parseSuccessResponse(t, call("add_section", map[string]interface{}{"chapter_number": float64(1), "title": "Results", "content": "Found it."}))
+	parseSuccessResponse(t, call("add_section", map[string]interface{}{"chapter_number": float64(1), "title": "Notes", "content": "Later."}))
+
+	result := parseSuccessResponse(t, call("set_front_matter", map[string]interface{}{
+		"chapter_number": float64(1), "section_number": "1.1", "front_matter": map[string]interface{}{"status": "draft", "due": float64(3)},
+	}))
+	if result["message"] != "Front matter of section 1.1 updated" {
+		t.Errorf("Unexpected result %v", result)
+	}
+
+	result = parseSuccessResponse(t, call("get_document_structure", map[string]interface{}{"front_matter": map[string]interface{}{"status": "draft", "due": float64(3)}}))
+	chapters := result["document"].(map[string]interface{})["chapters"].([]interface{})
+	sections := chapters[0].(map[string]interface{})["sections"].([]interface{})
+	if len(sections) != 1 || sections[0].(map[string]interface{})["front_matter"].(map[string]interface{})["status"] != "draft" {
+		t.Errorf("Expected only the draft section, got %v", sections)
+	}
+
+	expectError(t, call("set_front_matter", map[string]interface{}{"chapter_number": float64(1)}), "front_matter parameter is required")
+	expectError(t, call("set_front_matter", map[string]interface{}{"chapter_number": float64(1), "front_matter": map[string]interface{}{"9": "x"}}), "invalid front matter key")
+}
+
+func TestDocGenHandler_SetStatus(t *testing.T) {
+	handler, tempDir := setupTestHandler(t)
+	defer cleanupTestHandler(tempDir)
+
+	docID := createTestDocument(t, handler)
+	createTestChapter(t, handler, docID)
+	call := func(name string, args map[string]interface{}) *protocol.CallToolResponse {
+		args["document_id"] = docID
+		resp, err := handler.CallTool(context.Background(), &protocol.CallToolRequest{Name: name, Arguments: args})
+		if err != nil {
+			t.Fatalf("CallTool() error = %v", err)
+		}
+		return resp
+	}
+	parseSuccessResponse(t, call("add_section", map[string]interface{}{"chapter_number": float64(1), "title": "Results", "content": "Found it."}))
+	parseSuccessResponse(t, call("add_section", map[string]interface{}{"chapter_number": float64(1), "title": "Notes", "content": "Later."}))
+
+	result := parseSuccessResponse(t, call("set_status", map[string]interface{}{"chapter_number": float64(1), "section_number": "1.1", "status": "approved"}))
+	if result["message"] != "Status of section 1.1 set to approved" {
+		t.Errorf("Unexpected result %v", result)
+	}
+
+	result = parseSuccessResponse(t, call("get_document_structure", map[string]interface{}{"status": []interface{}{"approved", "final"}}))
+	chapters := result["document"].(map[string]interface{})["chapters"].([]interface{})
+	sections := chapters[0].(map[string]interface{})["sections"].([]interface{})
+	if len(sections) != 1 || sections[0].(map[string]interface{})["status"] != "approved" {
+		t.Errorf("Expected only the approved section, got %v", sections)
+	}
+
+	expectError(t, call("set_status", map[string]interface{}{"chapter_number": float64(1)}), "status parameter is required")
+	expectError(t, call("set_status", map[string]interface{}{"chapter_number": float64(1), "status": "done"}), "invalid status")
+	expectError(t, call("get_document_structure", map[string]interface{}{"status": []interface{}{"done"}}), "invalid status")
+}
+
+func TestDocGenHandler_SetEpigraph(t *testing.T) {
+	handler, tempDir := setupTestHandler(t)
+	defer cleanupTestHandler(tempDir)
+
+	docID := createTestDocument(t, handler)
+	createTestChapter(t, handler, docID)
+	call := func(name string, args map[string]interface{}) *protocol.CallToolResponse {
+		args["document_id"] = docID
+		resp, err := handler.CallTool(context.Background(), &protocol.CallToolRequest{Name: name, Arguments: args})
+		if err != nil {
+			t.Fatalf("CallTool() error = %v", err)
+		}
+		return resp
+	}
+
+	result := parseSuccessResponse(t, call("set_epigraph", map[string]interface{}{"chapter_number": float64(1), "text": "Call me Ishmael.", "attribution": "Herman Melville"}))
+	if result["message"] != "Epigraph of chapter 1 set" {
+		t.Errorf("Unexpected result %v", result)
+	}
+	result = parseSuccessResponse(t, call("get_document_structure", map[string]interface{}{}))
+	chapters := result["document"].(map[string]interface{})["chapters"].([]interface{})
+	epigraph, _ := chapters[0].(map[string]interface{})["epigraph"].(map[string]interface{})
+	if epigraph["text"] != "Call me Ishmael." || epigraph["attribution"] != "Herman Melville" {
+		t.Errorf("Expected the epigraph in the structure, got %v", chapters[0])
+	}
+
+	expectError(t, call("set_epigraph", map[string]interface{}{"chapter_number": float64(1), "attribution": "Herman Melville"}), "needs its text")
+	result = parseSuccessResponse(t, call("set_epigraph", map[string]interface{}{"chapter_number": float64(1)}))
+	if result["message"] != "Epigraph of chapter 1 removed" {
+		t.Errorf("Unexpected result %v", result)
+	}
+}
+
+func TestDocGenHandler_InsertPageBreak(t *testing.T) {
+	handler, tempDir := setupTestHandler(t)
+	defer cleanupTestHandler(tempDir)
+
+	docID := createTestDocument(t, handler)
+	createTestChapter(t, handler, docID)
+	call := func(name string, args map[string]interface{}) *protocol.CallToolResponse {
+		args["document_id"] = docID
+		resp, err := handler.CallTool(context.Background(), &protocol.CallToolRequest{Name: name, Arguments: args})
+		if err != nil {
+			t.Fatalf("CallTool() error = %v", err)
+		}
+		return resp
+	}
+	call("add_section", map[string]interface{}{"chapter_number": float64(1), "title": "Results", "content": "First.\n\nSecond."})
+
+	result := parseSuccessResponse(t, call("insert_page_break", map[string]interface{}{"chapter_number": float64(1), "section_number": "1.1", "after_text": "First"}))
+	if result["message"] != "Page break inserted into section 1.1" {
+		t.Errorf("Unexpected result %v", result)
+	}
+	result = parseSuccessResponse(t, call("get_section_content", map[string]interface{}{
+		"sections": []interface{}{map[string]interface{}{"chapter_number": float64(1), "section_number": "1.1"}},
+	}))
+	if sections, _ := result["sections"].([]interface{}); len(sections) != 1 || !strings.Contains(sections[0].(map[string]interface{})["content"].(string), "First.\n\n\\pagebreak\n\nSecond.") {
+		t.Errorf("Expected the page break after the first paragraph, got %v", result["sections"])
+	}
+
+	expectError(t, call("insert_page_break", map[string]interface{}{"chapter_number": float64(1), "section_number": "1.1", "after_paragraph": float64(1), "after_text": "First"}), "not both")
+	expectError(t, call("insert_page_break", map[string]interface{}{"chapter_number": float64(1), "section_number": "1.1", "after_paragraph": float64(7)}), "paragraph 7 not found")
+}
+func TestDocGenHandler_CompileVolume(t *testing.T) {
+	handler, tempDir := setupTestHandler(t)
+	defer cleanupTestHandler(tempDir)
+
+	firstID := createTestDocument(t, handler)
+	createTestChapter(t, handler, firstID)
+	resp, err := handler.CallTool(context.Background(), &protocol.CallToolRequest{
+		Name:      "create_document",
+		Arguments: map[string]interface{}{"title": "Second Report", "author": "Test Author", "type": "book"},
+	})
+	if err != nil {
+		t.Fatalf("CallTool() error = %v", err)
+	}
+	secondID := parseSuccessResponse(t, resp)["document_id"].(string)
+	createTestChapter(t, handler, secondID)
+
+	call := func(args map[string]interface{}) *protocol.CallToolResponse {
+		resp, err := handler.CallTool(context.Background(), &protocol.CallToolRequest{Name: "compile_volume", Arguments: args})
+		if err != nil {
+			t.Fatalf("CallTool() error = %v", err)
+		}
+		return resp
+	}
+	both := []interface{}{firstID, secondID}
+
+	expectError(t, call(map[string]interface{}{"document_ids": []interface{}{firstID}, "title": "Collected", "format": "pdf"}), "at least two documents")
+	expectError(t, call(map[string]interface{}{"document_ids": []interface{}{firstID, firstID}, "title": "Collected", "format": "pdf"}), "more than once")
+	expectError(t, call(map[string]interface{}{"document_ids": both, "format": "pdf"}), "title parameter is required")
+	expectError(t, call(map[string]interface{}{"document_ids": both, "title": "Collected", "format": "txt"}), "format must be one of")
+	expectError(t, call(map[string]interface{}{"document_ids": both, "title": "Collected", "format": "pdf", "numbering": "weird"}), "invalid numbering")
+	expectError(t, call(map[string]interface{}{"document_ids": []interface{}{firstID, "missing-doc"}, "title": "Collected", "format": "pdf"}), "Failed to load document missing-doc")
+
+	// Pandoc isn't available to the tests, so a valid volume gets as far as the export
+	expectError(t, call(map[string]interface{}{"document_ids": both, "title": "Collected", "format": "html", "numbering": "per-document"}), "Failed to compile volume")
+}
+func TestDocGenHandler_PreviewChapterDiff(t *testing.T) {
+	handler, tempDir := setupTestHandler(t)
+	defer cleanupTestHandler(tempDir)
+
+	docID := createTestDocument(t, handler)
+	createTestChapter(t, handler, docID)
+	call := func(name string, args map[string]interface{}) *protocol.CallToolResponse {
+		args["document_id"] = docID
+		resp, err := handler.CallTool(context.Background(), &protocol.CallToolRequest{Name: name, Arguments: args})
+		if err != nil {
+			t.Fatalf("CallTool() error = %v", err)
+		}
+		return resp
+	}
+	call("add_section", map[string]interface{}{"chapter_number": float64(1), "title": "Results", "content": "First draft."})
+
+	expectError(t, call("preview_chapter_diff", map[string]interface{}{"chapter_number": float64(1)}), "no revisions")
+
+	result := parseSuccessResponse(t, call("snapshot_chapter", map[string]interface{}{"chapter_number": float64(1), "label": "before edit"}))
+	if result["message"] != "Revision 1 of chapter 1 taken" {
+		t.Errorf("Unexpected result %v", result)
+	}
+	result = parseSuccessResponse(t, call("snapshot_chapter", map[string]interface{}{"chapter_number": float64(1)}))
+	if result["message"] != "Chapter 1 is unchanged since revision 1" {
+		t.Errorf("Unexpected result %v", result)
+	}
+	call("update_section", map[string]interface{}{"chapter_number": float64(1), "section_number": "1.1", "content": "Second draft."})
+
+	result = parseSuccessResponse(t, call("list_chapter_revisions", map[string]interface{}{"chapter_number": float64(1)}))
+	revisions, _ := result["revisions"].([]interface{})
+	if len(revisions) != 1 || revisions[0].(map[string]interface{})["label"] != "before edit" || revisions[0].(map[string]interface{})["content"] != nil {
+		t.Errorf("Expected one revision listed without its content, got %v", result["revisions"])
+	}
+
+	result = parseSuccessResponse(t, call("preview_chapter_diff", map[string]interface{}{"chapter_number": float64(1), "mode": "side-by-side"}))
+	if result["revision"] != float64(1) || result["mode"] != "side-by-side" {
+		t.Errorf("Unexpected result %v", result)
+	}
+	if _, err := os.Stat(result["output_path"].(string)); err != nil {
+		t.Errorf("Expected the diff page written: %v", err)
+	}
+
+	expectError(t, call("preview_chapter_diff", map[string]interface{}{"chapter_number": float64(1), "mode": "split"}), "invalid mode")
+	expectError(t, call("preview_chapter_diff", map[string]interface{}{"chapter_number": float64(1), "revision": float64(4)}), "revision 4 of chapter 1 not found")
+}
+func TestDocGenHandler_CreateTableFromCSV(t *testing.T) {
+	handler, tempDir := setupTestHandler(t)
+	defer cleanupTestHandler(tempDir)
+
+	docID := createTestDocument(t, handler)
+	createTestChapter(t, handler, docID)
+	call := func(name string, args map[string]interface{}) *protocol.CallToolResponse {
+		args["document_id"] = docID
+		resp, err := handler.CallTool(context.Background(), &protocol.CallToolRequest{Name: name, Arguments: args})
+		if err != nil {
+			t.Fatalf("CallTool() error = %v", err)
+		}
+		return resp
+	}
+	call("add_section", map[string]interface{}{"chapter_number": float64(1), "title": "Results", "content": "The scores follow."})
+
+	result := parseSuccessResponse(t, call("create_table_from_csv", map[string]interface{}{
+		"section_number": "1.1",
+		"caption":        "Scores",
+		"data":           "Name\tScore\nAda\t3",
+		"alignments":     []interface{}{"left", "right"},
+	}))
+	if result["table_id"] != "table-1.1" || result["rows"] != float64(1) || result["columns"] != float64(2) {
+		t.Errorf("Unexpected result %v", result)
+	}
+
+	result = parseSuccessResponse(t, call("update_table_data", map[string]interface{}{
+		"table_id": "table-1.1",
+		"add_rows": []interface{}{[]interface{}{"Grace", float64(5)}},
+		"cells":    []interface{}{map[string]interface{}{"row": float64(1), "column": float64(2), "value": "4"}},
+	}))
+	if want := "| Name | Score |\n|:---|---:|\n| Ada | 4 |\n| Grace | 5 |"; result["content"] != want {
+		t.Errorf("content = %q, want %q", result["content"], want)
+	}
+
+	expectError(t, call("create_table_from_csv", map[string]interface{}{"section_number": "1.1", "data": "a,b"}), "caption parameter is required")
+	expectError(t, call("create_table_from_csv", map[string]interface{}{"section_number": "1.1", "caption": "Bad", "data": "a,b", "format": "html"}), "invalid table format")
+	expectError(t, call("create_table_from_csv", map[string]interface{}{"section_number": "1.1", "caption": "Bad", "data": "a,b", "alignments": []interface{}{"middle"}}), "invalid alignment")
+	expectError(t, call("update_table_data", map[string]interface{}{"table_id": "table-1.1"}), "nothing to update")
+	expectError(t, call("update_table_data", map[string]interface{}{"table_id": "table-1.1", "cells": []interface{}{map[string]interface{}{"row": float64(1), "column": float64(3), "value": "x"}}}), "column 3 out of range")
+}
+
+func TestDocGenHandler_ReimportDocxFeedback(t *testing.T) {
+	handler, tempDir := setupTestHandler(t)
+	defer cleanupTestHandler(tempDir)
+
+	docID := createTestDocument(t, handler)
+	createTestChapter(t, handler, docID)
+	call := func(args map[string]interface{}) *protocol.CallToolResponse {
+		args["document_id"] = docID
+		resp, err := handler.CallTool(context.Background(), &protocol.CallToolRequest{Name: "reimport_docx_feedback", Arguments: args})
+		if err != nil {
+			t.Fatalf("CallTool() error = %v", err)
+		}
+		return resp
+	}
+	handler.CallTool(context.Background(), &protocol.CallToolRequest{Name: "add_section", Arguments: map[string]interface{}{
+		"document_id": docID, "chapter_number": float64(1), "title": "Results", "content": "The results are clear.",
+	}})
+
+	expectError(t, call(map[string]interface{}{}), "DOCX not found")
+	expectError(t, call(map[string]interface{}{"path": "/etc/passwd"}), "Invalid path")
+
+	// The document's DOCX export, with a reviewer's insertion and comment
+	docxPath := handler.config.ExportPath(docID, "docx")
+	os.MkdirAll(filepath.Dir(docxPath), 0755)
+	file, err := os.Create(docxPath)
+	if err != nil {
+		t.Fatalf("Failed to create DOCX: %v", err)
+	}
+	writer := zip.NewWriter(file)
+	part, _ := writer.Create("word/document.xml")
+	part.Write([]byte(`<w:document xmlns:w="http://schemas.openxmlformats.org/wordprocessingml/2006/main"><w:body>` +
+		`<w:p><w:pPr><w:pStyle w:val="Heading1"/></w:pPr><w:r><w:t>Chapter 1: Test Chapter</w:t></w:r></w:p>` +
+		`<w:p><w:pPr><w:pStyle w:val="Heading2"/></w:pPr><w:r><w:t>1.1 Results</w:t></w:r></w:p>` +
+		`<w:p><w:commentRangeStart w:id="0"/><w:r><w:t xml:space="preserve">The results are </w:t></w:r><w:commentRangeEnd w:id="0"/>` +
+		`<w:ins w:id="1" w:author="Reviewer"><w:r><w:t xml:space="preserve">very </w:t></w:r></w:ins><w:r><w:t>clear.</w:t></w:r></w:p>` +
+		`</w:body></w:document>`))
+	part, _ = writer.Create("word/comments.xml")
+	part.Write([]byte(`<w:comments xmlns:w="http://schemas.openxmlformats.org/wordprocessingml/2006/main"><w:comment w:id="0" w:author="Reviewer"><w:p><w:r><w:t>Which results?</w:t></w:r></w:p></w:comment></w:comments>`))
+	writer.Close()
+	file.Close()
+
+	result := parseSuccessResponse(t, call(map[string]interface{}{}))
+	if result["message"] != "1 insertion(s), 0 deletion(s) and 1 comment(s) read; 0 section update(s) proposed" {
+		t.Errorf("Unexpected result %v", result)
+	}
+	items, _ := result["items"].([]interface{})
+	if len(items) != 2 {
+		t.Fatalf("Expected 2 items, got %v", result["items"])
+	}
+	for _, item := range items {
+		fields := item.(map[string]interface{})
+		if fields["chapter"] != float64(1) || fields["section"] != "1.1" || fields["author"] != "Reviewer" {
+			t.Errorf("Expected the feedback placed in section 1.1, got %v", fields)
+		}
+	}
+	if comment := items[1].(map[string]interface{}); comment["comment"] != "Which results?" || comment["text"] != "The results are" {
+		t.Errorf("Unexpected comment %v", comment)
+	}
+
+	result = parseSuccessResponse(t, call(map[string]interface{}{"path": filepath.Base(docxPath)}))
+	if result["unmapped"] != float64(0) {
+		t.Errorf("Expected a path relative to the exports directory to read the same DOCX, got %v", result)
+	}
+}
+
+func TestDocGenHandler_ExportFragmentOptions(t *testing.T) {
+	handler, tempDir := setupTestHandler(t)
+	defer cleanupTestHandler(tempDir)
+
+	docID := createTestDocument(t, handler)
+	call := func(args map[string]interface{}) *protocol.CallToolResponse {
+		args["document_id"] = docID
+		args["preflight"] = true
+		resp, err := handler.CallTool(context.Background(), &protocol.CallToolRequest{Name: "export_document", Arguments: args})
+		if err != nil {
+			t.Fatalf("CallTool() error = %v", err)
+		}
+		return resp
+	}
+
+	expectError(t, call(map[string]interface{}{"format": "pdf", "fragment": true}), "only supported for HTML exports")
+	expectError(t, call(map[string]interface{}{"format": "html", "heading_offset": float64(1)}), "set fragment or confluence")
+	expectError(t, call(map[string]interface{}{"format": "html", "fragment": true, "heading_offset": float64(7)}), "heading_offset must be between 0 and 5")
+	expectError(t, call(map[string]interface{}{"format": "html", "confluence": true, "image_base_url": "https://cdn.example.com/my docs"}), "invalid image_base_url")
+	parseSuccessResponse(t, call(map[string]interface{}{"format": "html", "confluence": true, "heading_offset": float64(1), "image_base_url": "https://cdn.example.com/docs"}))
+}
+
+func TestDocGenHandler_SummarizeStructure(t *testing.T) {
+	handler, tempDir := setupTestHandler(t)
+	defer cleanupTestHandler(tempDir)
+
+	docID := createTestDocument(t, handler)
+	createTestChapter(t, handler, docID)
+	_, err := handler.CallTool(context.Background(), &protocol.CallToolRequest{
+		Name: "add_section",
+		Arguments: map[string]interface{}{
+			"document_id": docID, "chapter_number": float64(1), "title": "Background", "content": "The first paragraph.\n\nThe second one.", "level": float64(1),
+		},
+	})
+	if err != nil {
+		t.Fatalf("add_section error = %v", err)
+	}
+
+	call := func(args map[string]interface{}) *protocol.CallToolResponse {
+		args["document_id"] = docID
+		resp, err := handler.CallTool(context.Background(), &protocol.CallToolRequest{Name: "summarize_structure", Arguments: args})
+		if err != nil {
+			t.Fatalf("CallTool() error = %v", err)
+		}
+		return resp
+	}
+
+	resp := call(map[string]interface{}{"excerpt_words": float64(3)})
+	parseSuccessResponse(t, resp)
+	var result struct {
+		Summary types.StructureSummary `json:"summary"`
+	}
+	if err := json.Unmarshal([]byte(resp.Content[0].Text), &result); err != nil {
+		t.Fatalf("Failed to parse summary: %v", err)
+	}
+	if len(result.Summary.Chapters) != 1 || result.Summary.Chapters[0].Excerpt != "The first paragraph.…" || len(result.Summary.Chapters[0].Sections) != 1 {
+		t.Errorf("Unexpected summary %+v", result.Summary)
+	}
+
+	expectError(t, call(map[string]interface{}{"chapter_range": "4"}), "chapter 4 not found")
+	expectError(t, call(map[string]interface{}{"excerpt_words": float64(-2)}), "cannot be negative")
+}
+
+func TestDocGenHandler_EstimatePages(t *testing.T) {
+	handler, tempDir := setupTestHandler(t)
+	defer cleanupTestHandler(tempDir)
+
+	docID := createTestDocument(t, handler)
+	createTestChapter(t, handler, docID)
+	call := func(args map[string]interface{}) *protocol.CallToolResponse {
+		args["document_id"] = docID
+		resp, err := handler.CallTool(context.Background(), &protocol.CallToolRequest{Name: "estimate_pages", Arguments: args})
+		if err != nil {
+			t.Fatalf("CallTool() error = %v", err)
+		}
+		return resp
+	}
+
+	resp := call(map[string]interface{}{})
+	parseSuccessResponse(t, resp)
+	var result struct {
+		Estimate types.PageEstimate `json:"estimate"`
+	}
+	if err := json.Unmarshal([]byte(resp.Content[0].Text), &result); err != nil {
+		t.Fatalf("Failed to parse estimate: %v", err)
+	}
+	if result.Estimate.Mode != types.PageEstimateApproximate || len(result.Estimate.Chapters) != 1 || result.Estimate.Chapters[0].Pages != 1 {
+		t.Errorf("Unexpected estimate %+v", result.Estimate)
+	}
+
+	expectError(t, call(map[string]interface{}{"mode": "exact"}), "invalid mode")
+	expectError(t, call(map[string]interface{}{"chapter_range": "3"}), "Chapter 3 not found")
+	handler.config.PDFToTextPath = filepath.Join(tempDir, "missing", "pdftotext")
+	expectError(t, call(map[string]interface{}{"mode": "precise"}), "pdftotext not found")
+}
+
+func TestDocGenHandler_PreviewChapter(t *testing.T) {
+	handler, tempDir := setupTestHandler(t)
+	defer cleanupTestHandler(tempDir)
+
+	// A pandoc that records its arguments and writes the preview
+	handler.config.PandocPath = filepath.Join(tempDir, "bin", "pandoc")
+	os.MkdirAll(filepath.Dir(handler.config.PandocPath), 0755)
+	os.WriteFile(handler.config.PandocPath, []byte("#!/bin/sh\necho \"$@\" > \"$(dirname \"$0\")/args\"\nwhile [ $# -gt 0 ]; do\n  if [ \"$1\" = \"-o\" ]; then out=\"$2\"; fi\n  shift\ndone\necho preview > \"$out\"\n"), 0755)
+
+	docID := createTestDocument(t, handler)
+	createTestChapter(t, handler, docID)
+	call := func(args map[string]interface{}) *protocol.CallToolResponse {
+		args["document_id"] = docID
+		resp, err := handler.CallTool(context.Background(), &protocol.CallToolRequest{Name: "preview_chapter", Arguments: args})
+		if err != nil {
+			t.Fatalf("CallTool() error = %v", err)
+		}
+		return resp
+	}
+
+	result := parseSuccessResponse(t, call(map[string]interface{}{"chapter_number": float64(1)}))
+	if result["styled"] != true || !strings.HasSuffix(result["output_path"].(string), "-chapter-1-preview.html") {
+		t.Errorf("Unexpected preview %v", result)
+	}
+	args, _ := os.ReadFile(filepath.Join(tempDir, "bin", "args"))
+	if !strings.Contains(string(args), "--css") {
+		t.Errorf("Expected the document style in the preview: %s", args)
+	}
+
+	result = parseSuccessResponse(t, call(map[string]interface{}{"chapter_number": float64(1), "unstyled": true}))
+	args, _ = os.ReadFile(filepath.Join(tempDir, "bin", "args"))
+	if result["styled"] != false || strings.Contains(string(args), "--css") {
+		t.Errorf("Expected an unstyled preview: %v %s", result, args)
+	}
+
+	expectError(t, call(map[string]interface{}{"chapter_number": float64(1), "format": "docx"}), "format must be html or pdf")
+	expectError(t, call(map[string]interface{}{"chapter_number": float64(1), "unstyled": true, "style_name": "default"}), "can't be combined")
+	expectError(t, call(map[string]interface{}{"chapter_number": float64(4)}), "Chapter 4 not found")
+}
+
+func TestDocGenHandler_ValidateFonts(t *testing.T) {
+	handler, tempDir := setupTestHandler(t)
+	defer cleanupTestHandler(tempDir)
+
+	docID := createTestDocument(t, handler)
+	call := func(args map[string]interface{}) *protocol.CallToolResponse {
+		args["document_id"] = docID
+		resp, err := handler.CallTool(context.Background(), &protocol.CallToolRequest{Name: "validate_document", Arguments: args})
+		if err != nil {
+			t.Fatalf("CallTool() error = %v", err)
+		}
+		return resp
+	}
+
+	handler.config.FCListPath = filepath.Join(tempDir, "fc-list")
+	if err := os.WriteFile(handler.config.FCListPath, []byte("#!/bin/sh\nprintf 'DejaVu Serif\\nDejaVu Sans Mono\\n'\n"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	handler.storage.EnsureDefaultStyle()
+	brand := "extends: default\nbody:\n  font_family: Minion Pro\nfont_fallbacks:\n  Minion Pro: [DejaVu Serif]\nmonospace:\n  font_family: Menlo\n"
+	if err := os.WriteFile(filepath.Join(tempDir, "styles", "brand.yaml"), []byte(brand), 0644); err != nil {
+		t.Fatalf("Failed to write style: %v", err)
+	}
+
+	result := parseSuccessResponse(t, call(map[string]interface{}{"format": "pdf", "style_name": "brand"}))
+	warnings, _ := result["validation_report"].(map[string]interface{})["warnings"].([]interface{})
+	var fonts []string
+	for _, warning := range warnings {
+		if strings.HasPrefix(warning.(string), "Font ") {
+			fonts = append(fonts, warning.(string))
+		}
+	}
+	// The default style's Times New Roman headings are missing too
+	if len(fonts) != 3 || !strings.Contains(fonts[0], `using "DejaVu Serif" instead`) || !strings.Contains(fonts[2], "Installed fonts like it: DejaVu Sans Mono") {
+		t.Errorf("Unexpected font warnings %v", fonts)
+	}
+
+	expectError(t, call(map[string]interface{}{"format": "pdf", "style_name": "missing"}), "Failed to load style")
+}
+
+func TestDocGenHandler_Fonts(t *testing.T) {
+	handler, tempDir := setupTestHandler(t)
+	defer cleanupTestHandler(tempDir)
+
+	docID := createTestDocument(t, handler)
+	call := func(name string, args map[string]interface{}) *protocol.CallToolResponse {
+		resp, err := handler.CallTool(context.Background(), &protocol.CallToolRequest{Name: name, Arguments: args})
+		if err != nil {
+			t.Fatalf("CallTool() error = %v", err)
+		}
+		return resp
+	}
+
+	fontPath := filepath.Join(tempDir, "Serif-Regular.otf")
+	os.WriteFile(fontPath, []byte("font"), 0644)
+	result := parseSuccessResponse(t, call("add_font", map[string]interface{}{"document_id": docID, "source_path": fontPath}))
+	if result["name"] != "Serif-Regular.otf" || result["shared"] != false {
+		t.Errorf("Unexpected result %v", result)
+	}
+	result = parseSuccessResponse(t, call("add_font", map[string]interface{}{"source_path": fontPath}))
+	if result["shared"] != true {
+		t.Errorf("Expected a shared font, got %v", result)
+	}
+
+	result = parseSuccessResponse(t, call("list_fonts", map[string]interface{}{"document_id": docID}))
+	if fonts := result["fonts"].([]interface{}); len(fonts) != 1 || fonts[0].(map[string]interface{})["shared"] != false {
+		t.Errorf("Expected the document's font over the shared one, got %v", fonts)
+	}
+
+	expectError(t, call("add_font", map[string]interface{}{}), "source_path parameter is required")
+	expectError(t, call("add_font", map[string]interface{}{"document_id": docID, "source_path": filepath.Join(tempDir, "missing.otf")}), "Failed to add font")
+
+	// Shared fonts are for admins to add
+	if role := requiredRole(&protocol.CallToolRequest{Name: "add_font", Arguments: map[string]interface{}{"source_path": fontPath}}); role != types.RoleAdmin {
+		t.Errorf("Expected shared fonts to need admin, got %s", role)
+	}
+}
+
+func TestDocGenHandler_MigrateFigureIDs(t *testing.T) {
+	handler, tempDir := setupTestHandler(t)
+	defer cleanupTestHandler(tempDir)
+
+	docID := createTestDocument(t, handler)
+	createTestChapter(t, handler, docID)
+	call := func(name string, args map[string]interface{}) *protocol.CallToolResponse {
+		args["document_id"] = docID
+		resp, err := handler.CallTool(context.Background(), &protocol.CallToolRequest{Name: name, Arguments: args})
+		if err != nil {
+			t.Fatalf("CallTool() error = %v", err)
+		}
+		return resp
+	}
+
+	parseSuccessResponse(t, call("add_image", map[string]interface{}{"chapter_number": float64(1), "image_path": "assets/images/chart.png", "caption": "A chart"}))
+	result := parseSuccessResponse(t, call("migrate_figure_ids", map[string]interface{}{}))
+	if ids, _ := result["ids"].(map[string]interface{}); len(ids) != 0 || result["message"] != "Every figure already has a stable ID" {
+		t.Errorf("Unexpected result %v", result)
+	}
+
+	resp, _ := handler.CallTool(context.Background(), &protocol.CallToolRequest{Name: "migrate_figure_ids", Arguments: map[string]interface{}{"document_id": "missing-doc"}})
+	expectError(t, resp, "Failed to migrate figure IDs")
+}
+
+func TestDocGenHandler_AddSections(t *testing.T) {
+	handler, tempDir := setupTestHandler(t)
+	defer cleanupTestHandler(tempDir)
+
+	docID := createTestDocument(t, handler)
+	createTestChapter(t, handler, docID)
+	call := func(name string, args map[string]interface{}) *protocol.CallToolResponse {
+		args["document_id"] = docID
+		args["chapter_number"] = float64(1)
+		resp, err := handler.CallTool(context.Background(), &protocol.CallToolRequest{Name: name, Arguments: args})
+		if err != nil {
+			t.Fatalf("CallTool() error = %v", err)
+		}
+		return resp
+	}
+
+	result := parseSuccessResponse(t, call("add_sections", map[string]interface{}{"sections": []interface{}{
+		map[string]interface{}{"title": "Setup", "content": "Install it."},
+		map[string]interface{}{"title": "Requirements", "content": "```go\nunclosed", "level": float64(2)},
+		map[string]interface{}{"title": "Usage", "content": "Run it.", "level": float64(1)},
+	}}))
+	numbers, _ := result["section_numbers"].([]interface{})
+	if len(numbers) != 3 || numbers[0] != "1.1" || numbers[1] != "1.1.1" || numbers[2] != "1.2" {
+		t.Errorf("Unexpected section numbers %v", result["section_numbers"])
+	}
+	warnings, _ := result["markdown_warnings"].(map[string]interface{})
+	if len(warnings) != 1 || warnings["1.1.1"] == nil || !strings.HasPrefix(result["message"].(string), "Added 3 section(s) to chapter 1, with") {
+		t.Errorf("Expected a markdown warning for section 1.1.1, got %v", result)
+	}
+
+	expectError(t, call("add_sections", map[string]interface{}{"sections": []interface{}{}}), "sections parameter is required")
+	expectError(t, call("add_sections", map[string]interface{}{"sections": []interface{}{map[string]interface{}{"title": "Deep", "content": "Text.", "level": float64(9)}}}), "section level must be between 1 and 6")
+	expectError(t, call("add_sections", map[string]interface{}{"sections": []interface{}{"Setup"}}), "Invalid sections")
+}
+
+func TestDocGenHandler_DocumentHooksOptIn(t *testing.T) {
+	handler, tempDir := setupTestHandler(t)
+	defer cleanupTestHandler(tempDir)
+
+	docID := createTestDocument(t, handler)
+	os.WriteFile(handler.config.HooksPath(), []byte("hooks:\n  - url: https://chat.example.com/hook\n    event: post_export\n"), 0644)
+	os.WriteFile(handler.config.DocumentHooksPath(docID), []byte("hooks:\n  - event: pre_markdown\n    command: [./run.sh]\n"), 0644)
+
+	// A document's own hooks run only once the operator lists it
+	hooks, err := handler.loadHooks(types.DocumentID(docID))
+	if err != nil || len(hooks) != 1 || hooks[0].URL == "" {
+		t.Fatalf("Expected only the root hook, got %+v, %v", hooks, err)
+	}
+	os.WriteFile(handler.config.HooksPath(), []byte("document_hooks: ["+docID+"]\nhooks:\n  - url: https://chat.example.com/hook\n    event: post_export\n"), 0644)
+	hooks, err = handler.loadHooks(types.DocumentID(docID))
+	if err != nil || len(hooks) != 2 || hooks[1].Command[0] != "./run.sh" {
+		t.Errorf("Expected the document's hook after the root one, got %+v, %v", hooks, err)
+	}
+}
+
+func TestDocGenHandler_WritingProgressRecording(t *testing.T) {
+	handler, tempDir := setupTestHandler(t)
+	defer cleanupTestHandler(tempDir)
+
+	docID := createTestDocument(t, handler)
+	createTestChapter(t, handler, docID)
+	call := func(name string, args map[string]interface{}) *protocol.CallToolResponse {
+		args["document_id"] = docID
+		resp, err := handler.CallTool(context.Background(), &protocol.CallToolRequest{Name: name, Arguments: args})
+		if err != nil {
+			t.Fatalf("CallTool(%s) error = %v", name, err)
+		}
+		return resp
+	}
+
+	// A call that changes content records today's word count once
+	parseSuccessResponse(t, call("add_section", map[string]interface{}{"chapter_number": float64(1), "title": "Scene", "content": "It was a dark and stormy night."}))
+	stats, err := handler.storage.LoadWritingStats(docID)
+	if err != nil || len(stats.Snapshots) != 1 || stats.Snapshots[0].Words != 8 {
+		t.Fatalf("Expected a snapshot of 8 words, got %+v, %v", stats, err)
+	}
+
+	// Reading progress doesn't write
+	os.Remove(handler.config.StatsPath(docID))
+	result := parseSuccessResponse(t, call("writing_progress", map[string]interface{}{}))
+	if progress, _ := result["progress"].(map[string]interface{}); progress["current_words"] != float64(8) {
+		t.Errorf("Unexpected progress %v", result)
+	}
+	if _, err := os.Stat(handler.config.StatsPath(docID)); !os.IsNotExist(err) {
+		t.Errorf("writing_progress should not record a snapshot, stat error = %v", err)
+	}
+}
+
+func TestDocGenHandler_GetDocumentJSON(t *testing.T) {
+	handler, tempDir := setupTestHandler(t)
+	defer cleanupTestHandler(tempDir)
+
+	docID := createTestDocument(t, handler)
+	createTestChapter(t, handler, docID)
+	createTestChapter(t, handler, docID)
+	call := func(name string, args map[string]interface{}) *protocol.CallToolResponse {
+		args["document_id"] = docID
+		resp, err := handler.CallTool(context.Background(), &protocol.CallToolRequest{Name: name, Arguments: args})
+		if err != nil {
+			t.Fatalf("CallTool() error = %v", err)
+		}
+		return resp
+	}
+	parseSuccessResponse(t, call("add_section", map[string]interface{}{"chapter_number": float64(2), "title": "Results", "content": "Found it."}))
+
+	result := parseSuccessResponse(t, call("get_document_json", map[string]interface{}{"chapter_range": "2"}))
+	chapters, _ := result["chapters"].([]interface{})
+	if result["schema_version"] != float64(1) || len(chapters) != 1 {
+		t.Fatalf("Unexpected result %v", result)
+	}
+	sections, _ := chapters[0].(map[string]interface{})["sections"].([]interface{})
+	if len(sections) != 1 || sections[0].(map[string]interface{})["content"] != "Found it." {
+		t.Errorf("Unexpected sections %v", sections)
+	}
+	expectError(t, call("get_document_json", map[string]interface{}{"chapter_range": "x"}), "")
+
+	result = parseSuccessResponse(t, call("export_document", map[string]interface{}{"format": "json"}))
+	path, _ := result["output_path"].(string)
+	data, err := os.ReadFile(path)
+	if err != nil || !strings.Contains(string(data), `"content": "Found it."`) {
+		t.Errorf("Expected the section content in the JSON export, got %v %s", err, data)
+	}
+}
+
+func TestDocGenHandler_BlockEdits(t *testing.T) {
+	handler, tempDir := setupTestHandler(t)
+	defer cleanupTestHandler(tempDir)
+
+	docID := createTestDocument(t, handler)
+	createTestChapter(t, handler, docID)
+	call := func(name string, args map[string]interface{}) *protocol.CallToolResponse {
+		args["document_id"] = docID
+		args["chapter_number"] = float64(1)
+		args["section_number"] = "1.1"
+		resp, err := handler.CallTool(context.Background(), &protocol.CallToolRequest{Name: name, Arguments: args})
+		if err != nil {
+			t.Fatalf("CallTool() error = %v", err)
+		}
+		return resp
+	}
+
+	parseSuccessResponse(t, call("add_section", map[string]interface{}{"title": "Results", "content": "First.\n\n- a\n- b"}))
+	result := parseSuccessResponse(t, call("get_section_blocks", map[string]interface{}{}))
+	blocks, _ := result["blocks"].([]interface{})
+	if len(blocks) != 2 {
+		t.Fatalf("Expected 2 blocks, got %v", result)
+	}
+	if block, _ := blocks[1].(map[string]interface{}); block["kind"] != "list" || block["number"] != float64(2) {
+		t.Errorf("Unexpected block %v", block)
+	}
+
+	parseSuccessResponse(t, call("update_block", map[string]interface{}{"block_number": float64(1), "content": "Changed.", "expected_text": "First"}))
+	parseSuccessResponse(t, call("insert_block", map[string]interface{}{"after_block": float64(0), "content": "Opening."}))
+	result = parseSuccessResponse(t, call("delete_block", map[string]interface{}{"block_number": float64(3)}))
+	if result["message"] != "Block 3 of section 1.1 deleted" {
+		t.Errorf("Unexpected result %v", result)
+	}
+	content, _ := handler.manager.GetSectionContent(types.DocumentID(docID), 1, types.SectionNumber{1, 1})
+	if content != "Opening.\n\nChanged." {
+		t.Errorf("Section content = %q", content)
+	}
+
+	expectError(t, call("update_block", map[string]interface{}{"block_number": float64(1), "content": "x", "expected_text": "First"}), "doesn't contain")
+	expectError(t, call("delete_block", map[string]interface{}{}), "block_number parameter is required")
+	expectError(t, call("insert_block", map[string]interface{}{"after_block": float64(5), "content": "x"}), "block 5 not found")
+}
+
+func TestDocGenHandler_CheckConsistency(t *testing.T) {
+	handler, tempDir := setupTestHandler(t)
+	defer cleanupTestHandler(tempDir)
+
+	docID := createTestDocument(t, handler)
+	createTestChapter(t, handler, docID)
+	call := func(name string, args map[string]interface{}) *protocol.CallToolResponse {
+		args["document_id"] = docID
+		resp, err := handler.CallTool(context.Background(), &protocol.CallToolRequest{Name: name, Arguments: args})
+		if err != nil {
+			t.Fatalf("CallTool() error = %v", err)
+		}
+		return resp
+	}
+
+	parseSuccessResponse(t, call("add_section", map[string]interface{}{"chapter_number": float64(1), "title": "Results", "content": "First."}))
+	result := parseSuccessResponse(t, call("check_consistency", map[string]interface{}{}))
+	if result["message"] != "Chapter markdown matches the sections" {
+		t.Errorf("Unexpected result %v", result)
+	}
+
+	content, _ := handler.storage.LoadChapterContent(docID, 1)
+	handler.storage.SaveChapterContent(docID, 1, content+"Added by hand.\n")
+	result = parseSuccessResponse(t, call("check_consistency", map[string]interface{}{"chapter_number": float64(1)}))
+	if issues, _ := result["issues"].([]interface{}); len(issues) != 1 || result["rebuilt_chapters"] != nil {
+		t.Errorf("Expected one issue and nothing rebuilt, got %v", result)
+	}
+
+	result = parseSuccessResponse(t, call("check_consistency", map[string]interface{}{"rebuild": true}))
+	if rebuilt, _ := result["rebuilt_chapters"].([]interface{}); len(rebuilt) != 1 {
+		t.Errorf("Expected chapter 1 rebuilt, got %v", result)
+	}
+	result = parseSuccessResponse(t, call("check_consistency", map[string]interface{}{}))
+	if issues, _ := result["issues"].([]interface{}); len(issues) != 0 {
+		t.Errorf("Expected no drift after rebuilding, got %v", result)
+	}
+	expectError(t, call("check_consistency", map[string]interface{}{"chapter_number": float64(4)}), "chapter 4 not found")
+}
+
+func TestDocGenHandler_AddListing(t *testing.T) {
+	handler, tempDir := setupTestHandler(t)
+	defer cleanupTestHandler(tempDir)
+
+	docID := createTestDocument(t, handler)
+	createTestChapter(t, handler, docID)
+	call := func(args map[string]interface{}) *protocol.CallToolResponse {
+		args["document_id"] = docID
+		resp, err := handler.CallTool(context.Background(), &protocol.CallToolRequest{Name: "add_listing", Arguments: args})
+		if err != nil {
+			t.Fatalf("CallTool() error = %v", err)
+		}
+		return resp
+	}
+
+	result := parseSuccessResponse(t, call(map[string]interface{}{
+		"chapter_number": float64(1),
+		"caption":        "Hello world",
+		"language":       "go",
+		"code":           "fmt.Println(\"hello\")",
+	}))
+	if result["listing_id"] != "lst-1.1" || result["markup"] != "```{#lst-1.1 .go}\nfmt.Println(\"hello\")\n```" {
+		t.Errorf("Unexpected result %v", result)
+	}
+
+	expectError(t, call(map[string]interface{}{"chapter_number": float64(1)}), "caption parameter is required")
+	expectError(t, call(map[string]interface{}{"chapter_number": float64(1), "caption": "Bad", "language": "two words"}), "invalid listing language")
+}
+
+func TestDocGenHandler_ExportProtectionParams(t *testing.T) {
+	handler, tempDir := setupTestHandler(t)
+	defer cleanupTestHandler(tempDir)
+
+	docID := createTestDocument(t, handler)
+	call := func(args map[string]interface{}) *protocol.CallToolResponse {
+		args["document_id"] = docID
+		resp, err := handler.CallTool(context.Background(), &protocol.CallToolRequest{Name: "export_document", Arguments: args})
+		if err != nil {
+			t.Fatalf("CallTool() error = %v", err)
+		}
+		return resp
+	}
+
+	expectError(t, call(map[string]interface{}{"format": "html", "user_password": "secret"}), "only supported for PDF and DOCX")
+	expectError(t, call(map[string]interface{}{"format": "docx", "user_password": "secret", "allow_copy": false}), "only supported for PDF exports")
+	expectError(t, call(map[string]interface{}{"format": "pdf", "allow_print": true}), "needs a password or a restriction")
+	expectError(t, call(map[string]interface{}{"format": "docx", "pdfa": true}), "pdfa is only supported for PDF")
+	expectError(t, call(map[string]interface{}{"format": "pdf", "pdfa": true, "user_password": "secret"}), "cannot be password-protected")
+}
+
+func TestDocGenHandler_ExportHistory(t *testing.T) {
+	handler, tempDir := setupTestHandler(t)
+	defer cleanupTestHandler(tempDir)
+
+	docID := createTestDocument(t, handler)
+	call := func(name string, args map[string]interface{}) *protocol.CallToolResponse {
+		args["document_id"] = docID
+		resp, err := handler.CallTool(context.Background(), &protocol.CallToolRequest{Name: name, Arguments: args})
+		if err != nil {
+			t.Fatalf("CallTool(%s) error = %v", name, err)
+		}
+		return resp
+	}
+
+	// Exports are recorded whether or not they succeed
+	handler.exportDocument(context.Background(), types.DocumentID(docID), "", &types.ExportOptions{Format: types.ExportFormatHTML})
+	handler.exportDocument(context.Background(), types.DocumentID(docID), "", &types.ExportOptions{Format: types.ExportFormatDOCX, Chapters: []types.ChapterNumber{1}})
+
+	result := parseSuccessResponse(t, call("list_exports", map[string]interface{}{}))
+	exports := result["exports"].([]interface{})
+	if result["total"].(float64) != 2 || len(exports) != 2 {
+		t.Fatalf("Expected two exports, got %v", result)
+	}
+	latest := exports[0].(map[string]interface{})
+	if latest["id"].(float64) != 2 || latest["format"] != "docx" || latest["style"] != "default" {
+		t.Errorf("Unexpected latest export %v", latest)
+	}
+
+	result = parseSuccessResponse(t, call("list_exports", map[string]interface{}{"format": "html"}))
+	if exports := result["exports"].([]interface{}); len(exports) != 1 || exports[0].(map[string]interface{})["id"].(float64) != 1 {
+		t.Errorf("Expected only the HTML export, got %v", result)
+	}
+
+	expectError(t, call("delete_export", map[string]interface{}{}), "export_id or older_than_days is required")
+	expectError(t, call("delete_export", map[string]interface{}{"export_id": float64(7)}), "export 7 not found")
+	result = parseSuccessResponse(t, call("delete_export", map[string]interface{}{"export_id": float64(1)}))
+	if deleted := result["deleted"].([]interface{}); len(deleted) != 1 {
+		t.Errorf("Expected one deleted export, got %v", result)
+	}
+	result = parseSuccessResponse(t, call("list_exports", map[string]interface{}{}))
+	if result["total"].(float64) != 1 {
+		t.Errorf("Expected one export left, got %v", result)
+	}
+}
+
+func TestDocGenHandler_LockDocument(t *testing.T) {
+	handler, tempDir := setupTestHandler(t)
+	defer cleanupTestHandler(tempDir)
+
+	docID := createTestDocument(t, handler)
+	createTestChapter(t, handler, docID)
+	call := func(name string, args map[string]interface{}) *protocol.CallToolResponse {
+		args["document_id"] = docID
+		resp, err := handler.CallTool(context.Background(), &protocol.CallToolRequest{Name: name, Arguments: args})
+		if err != nil {
+			t.Fatalf("CallTool(%s) error = %v", name, err)
+		}
+		return resp
+	}
+
+	parseSuccessResponse(t, call("lock_document", map[string]interface{}{"reason": "final"}))
+
+	// Changes are refused, reading still works
+	expectError(t, call("add_chapter", map[string]interface{}{"title": "Late Addition"}), "is locked (final) and read-only")
+	expectError(t, call("tag_document", map[string]interface{}{"tags": []interface{}{"done"}}), "is locked")
+	expectError(t, call("delete_document", map[string]interface{}{}), "is locked")
+	structure := parseSuccessResponse(t, call("get_document_structure", map[string]interface{}{}))
+	if structure["document"].(map[string]interface{})["lock"] == nil {
+		t.Errorf("Expected the lock in the document structure, got %v", structure["document"])
+	}
+
+	parseSuccessResponse(t, call("unlock_document", map[string]interface{}{}))
+	parseSuccessResponse(t, call("add_chapter", map[string]interface{}{"title": "Late Addition"}))
+	expectError(t, call("unlock_document", map[string]interface{}{}), "is not locked")
+}
+
+func TestDocGenHandler_Guardrails(t *testing.T) {
+	handler, tempDir := setupTestHandler(t)
+	defer cleanupTestHandler(tempDir)
+
+	handler.config.ConfirmDeletes = true
+	handler.config.MaxDeletes = 4
+	handler.config.RateLimits = map[string]config.RateLimit{"get_toc": {Calls: 2, Per: time.Hour}}
+
+	docID := createTestDocument(t, handler)
+	createTestChapter(t, handler, docID)
+	createTestChapter(t, handler, docID)
+	call := func(name string, args map[string]interface{}) *protocol.CallToolResponse {
+		args["document_id"] = docID
+		resp, err := handler.CallTool(context.Background(), &protocol.CallToolRequest{Name: name, Arguments: args})
+		if err != nil {
+			t.Fatalf("CallTool(%s) error = %v", name, err)
+		}
+		return resp
+	}
+
+	// Rate limits apply per tool
+	parseSuccessResponse(t, call("get_toc", map[string]interface{}{}))
+	parseSuccessResponse(t, call("get_toc", map[string]interface{}{}))
+	expectError(t, call("get_toc", map[string]interface{}{}), "rate limit exceeded: get_toc allows 2 calls per 1h0m0s")
+
+	// Deleting a chapter takes a token, which only confirms that deletion
+	result := parseSuccessResponse(t, call("delete_chapter", map[string]interface{}{"chapter_number": float64(1)}))
+	token, _ := result["confirmation_token"].(string)
+	if result["confirmation_required"] != true || token == "" {
+		t.Fatalf("Expected a confirmation token, got %v", result)
+	}
+	structure := parseSuccessResponse(t, call("get_document_structure", map[string]interface{}{}))
+	if chapters := structure["document"].(map[string]interface{})["chapters"].([]interface{}); len(chapters) != 2 {
+		t.Fatalf("Expected nothing deleted before confirmation, got %d chapters", len(chapters))
+	}
+
+	// The token follows the chapter when chapters are renumbered
+	manifest, _ := handler.storage.LoadManifest(docID)
+	confirmed, _ := manifest.ChapterDir(1)
+	parseSuccessResponse(t, call("move_chapter", map[string]interface{}{"from_position": float64(1), "to_position": float64(2)}))
+	expectError(t, call("delete_chapter", map[string]interface{}{"chapter_number": float64(1), "confirmation_token": token}), "issued to delete chapter "+confirmed)
+	parseSuccessResponse(t, call("delete_chapter", map[string]interface{}{"chapter_number": float64(2), "confirmation_token": token}))
+	manifest, _ = handler.storage.LoadManifest(docID)
+	if len(manifest.ChapterOrder) != 1 || manifest.ChapterOrder[0] == confirmed {
+		t.Errorf("Expected the confirmed chapter %s deleted, chapters left in %v", confirmed, manifest.ChapterOrder)
+	}
+	expectError(t, call("delete_chapter", map[string]interface{}{"chapter_number": float64(1), "confirmation_token": token}), "invalid or expired")
+
+	// A delete that fails doesn't count toward the limit
+	expectError(t, call("delete_section", map[string]interface{}{"chapter_number": float64(1), "section_number": "9.9"}), "")
+
+	// Deletes beyond the limit are refused
+	parseSuccessResponse(t, call("add_section", map[string]interface{}{"chapter_number": float64(1), "title": "Overview", "content": "Text.\n\nMore text."}))
+	parseSuccessResponse(t, call("delete_block", map[string]interface{}{"chapter_number": float64(1), "section_number": "1.1", "block_number": float64(2)}))
+	parseSuccessResponse(t, call("delete_section", map[string]interface{}{"chapter_number": float64(1), "section_number": "1.1"}))
+	parseSuccessResponse(t, call("check_assets", map[string]interface{}{}))
+	parseSuccessResponse(t, call("check_assets", map[string]interface{}{"prune": true}))
+	expectError(t, call("delete_export", map[string]interface{}{"export_id": float64(1)}), "delete limit reached")
+}
+
 // Helper functions for tests
 
 func createTestDocument(t *testing.T, handler *DocGenHandler) string {
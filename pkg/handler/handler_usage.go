@@ -0,0 +1,24 @@
+package handler
+
+import (
+	"fmt"
+
+	"github.com/gomcpgo/mcp/pkg/protocol"
+)
+
+// Usage accounting operations
+
+func (h *DocGenHandler) handleGetUsageReport(params map[string]interface{}) (*protocol.CallToolResponse, error) {
+	// Get client ID (optional, all clients when omitted)
+	clientID, _ := params["client_id"].(string)
+
+	report, err := h.usage.Report(clientID)
+	if err != nil {
+		return h.errorResponse(fmt.Sprintf("Failed to get usage report: %v", err))
+	}
+
+	return h.successResponse(map[string]interface{}{
+		"clients": report,
+		"count":   len(report),
+	})
+}
@@ -12,13 +12,13 @@ func (h *DocGenHandler) ListTools(ctx context.Context) (*protocol.ListToolsRespo
 	tools := []protocol.Tool{
 		{
 			Name:        "list_documents",
-			Description: "List all available documents with their metadata. Returns document IDs, titles, authors, types, creation dates, and basic statistics. Use this to see what documents exist before performing operations.",
+			Description: "List all available documents with their metadata. Returns document IDs, titles, authors, types, creation and update dates, chapter counts, word counts and a health score. Results can be filtered by type, title and tags, sorted and limited, and the response counts the documents under each tag. Use this to see what documents exist before performing operations.",
 			InputSchema: json.RawMessage(`{
 				"type": "object",
 				"properties": {
 					"sort_by": {
 						"type": "string",
-						"enum": ["created_at", "updated_at", "title"],
+						"enum": ["created_at", "updated_at", "title", "word_count"],
 						"default": "updated_at",
 						"description": "Field to sort by"
 					},
@@ -34,6 +34,20 @@ func (h *DocGenHandler) ListTools(ctx context.Context) (*protocol.ListToolsRespo
 						"minimum": 1,
 						"maximum": 100,
 						"description": "Maximum number of documents to return"
+					},
+					"type": {
+						"type": "string",
+						"enum": ["book", "report", "article", "letter"],
+						"description": "Only list documents of this type"
+					},
+					"title_contains": {
+						"type": "string",
+						"description": "Only list documents whose title contains this text (case-insensitive)"
+					},
+					"tags": {
+						"type": "array",
+						"items": {"type": "string"},
+						"description": "Only list documents with all of these tags (e.g., ['client:acme', 'q3'])"
 					}
 				}
 			}`),
@@ -56,42 +70,109 @@ func (h *DocGenHandler) ListTools(ctx context.Context) (*protocol.ListToolsRespo
 						"type": "string",
 						"enum": ["book", "report", "article", "letter"],
 						"description": "Document type: 'book' for multi-chapter works, 'report' for structured reports, 'article' for papers, 'letter' for formal letters"
+					},
+					"subtitle": {
+						"type": "string",
+						"description": "Document subtitle (optional)"
+					},
+					"keywords": {
+						"type": "array",
+						"items": {"type": "string"},
+						"description": "Keywords for the document properties (optional)"
+					},
+					"abstract": {
+						"type": "string",
+						"description": "Document abstract (optional)"
+					},
+					"language": {
+						"type": "string",
+						"description": "Document language as a BCP-47 tag, e.g. 'en-US', 'de', 'fr-CA' (optional). Controls hyphenation and language selection in PDF exports."
+					},
+					"date": {
+						"type": "string",
+						"description": "Explicit document date, e.g. '2024-05-01' or 'Spring 2024' (optional). Defaults to the export date."
+					},
+					"locale": {
+						"type": "string",
+						"description": "Locale as a BCP-47 tag, e.g. 'fr-FR' (optional). Writes ISO dates with the month spelled out, caption labels ('Figura', 'Tableau') and contents headings for the locale in PDF, HTML and DOCX exports."
 					}
 				},
 				"required": ["title", "author", "type"]
 			}`),
 		},
 		{
-			Name:        "get_document_structure",
-			Description: "Get the complete overview of a document's current structure - shows all chapters, sections, figures, and tables with their numbers and titles. Use this to check the current state of the document before making changes or to understand the document organization. Essential for knowing what chapters exist before adding content.",
+			Name:        "create_sandbox_document",
+			Description: "Create a throwaway sandbox document to try out styles, structures or export settings without touching real documents. Sandboxes don't count toward the document limit and are deleted automatically once they expire (after an hour unless ttl_minutes says otherwise). Each call gets a fresh document_id, so several sandboxes can be used at once.",
 			InputSchema: json.RawMessage(`{
 				"type": "object",
 				"properties": {
-					"document_id": {
+					"title": {
 						"type": "string",
-						"description": "Document ID returned from create_document (e.g., 'technical-manual-1234567890')"
+						"description": "Document title (optional, defaults to 'Sandbox')"
+					},
+					"author": {
+						"type": "string",
+						"description": "Document author (optional, defaults to 'Sandbox')"
+					},
+					"type": {
+						"type": "string",
+						"enum": ["book", "report", "article", "letter"],
+						"description": "Document type (optional, defaults to 'report')"
+					},
+					"ttl_minutes": {
+						"type": "integer",
+						"minimum": 1,
+						"maximum": 1440,
+						"description": "Minutes until the sandbox is deleted (optional, defaults to the server's sandbox lifetime)"
 					}
-				},
-				"required": ["document_id"]
+				}
 			}`),
 		},
 		{
-			Name:        "delete_document",
-			Description: "Permanently delete a document and all its contents including chapters, sections, figures, and exported files. This action cannot be undone. Use only when the user explicitly requests document deletion.",
+			Name:        "get_document_structure",
+			Description: "Get the complete overview of a document's current structure - shows all chapters, sections, figures, and tables with their numbers and titles. Use this to check the current state of the document before making changes or to understand the document organization. Essential for knowing what chapters exist before adding content. For large documents, narrow it with include, depth, chapter_range and compact, or use get_toc. The health field scores the document from 0 to 100 by validation errors, TODOs, empty sections and stale chapters, with suggestions for what to clean up before exporting.",
 			InputSchema: json.RawMessage(`{
 				"type": "object",
 				"properties": {
 					"document_id": {
 						"type": "string",
-						"description": "Document ID of the document to permanently delete"
+						"description": "Document ID returned from create_document (e.g., 'technical-manual-1234567890')"
+					},
+					"include": {
+						"type": "array",
+						"items": {"type": "string", "enum": ["sections", "figures", "tables", "listings"]},
+						"description": "Lists to include for each chapter (default: all four); an empty list gives chapters only"
+					},
+					"depth": {
+						"type": "integer",
+						"minimum": 1,
+						"description": "Heading levels to include: 1 for chapters only, 2 adds top-level sections (default: all levels)"
+					},
+					"chapter_range": {
+						"type": "string",
+						"description": "Only these chapters, as a number or range such as '3-7' (default: all)"
+					},
+					"compact": {
+						"type": "boolean",
+						"description": "Leave out timestamps, chapter counts and empty fields to keep the response small (default: false)"
+					},
+					"front_matter": {
+						"type": "object",
+						"additionalProperties": {"type": "string"},
+						"description": "Only chapters and sections whose front matter has these values, e.g. {\"status\": \"draft\"}. A matching chapter keeps all its sections; other chapters keep only their matching sections."
+					},
+					"status": {
+						"type": "array",
+						"items": {"type": "string", "enum": ["draft", "in-review", "approved", "final"]},
+						"description": "Only sections with one of these workflow statuses, set with set_status; a section without its own takes its parent section's or chapter's. Chapters are kept when their own status is listed or any of their sections are."
 					}
 				},
 				"required": ["document_id"]
 			}`),
 		},
 		{
-			Name:        "configure_document",
-			Description: "Update document styling (fonts, margins, spacing) and export settings (PDF engine, table of contents). Use this to customize the appearance and formatting of the final exported document. Changes apply to future exports, not existing ones.",
+			Name:        "get_document_json",
+			Description: "Get a whole document as JSON for other tools, such as static site generators, CI pipelines or analytics scripts: its metadata, and each chapter with its sections' markdown content, figures, tables and listings. The schema is stable: schema_version only changes when a field is removed or changes meaning. Export with format 'json' to write the same JSON to a file.",
 			InputSchema: json.RawMessage(`{
 				"type": "object",
 				"properties": {
@@ -99,41 +180,17 @@ func (h *DocGenHandler) ListTools(ctx context.Context) (*protocol.ListToolsRespo
 						"type": "string",
 						"description": "Document ID returned from create_document (e.g., 'technical-manual-1234567890')"
 					},
-					"style_updates": {
-						"type": "object",
-						"properties": {
-							"font_family": {"type": "string"},
-							"font_size": {"type": "string"},
-							"line_spacing": {"type": "string"},
-							"margins": {
-								"type": "object",
-								"properties": {
-									"top": {"type": "string"},
-									"bottom": {"type": "string"},
-									"left": {"type": "string"},
-									"right": {"type": "string"}
-								}
-							}
-						},
-						"description": "Style updates: font_family, font_size, line_spacing, margins with top/bottom/left/right"
-					},
-					"pandoc_options": {
-						"type": "object",
-						"properties": {
-							"pdf_engine": {"type": "string"},
-							"toc": {"type": "boolean"},
-							"toc_depth": {"type": "integer"},
-							"citation_style": {"type": "string"}
-						},
-						"description": "Export settings: pdf_engine, toc (table of contents), toc_depth, citation_style"
+					"chapter_range": {
+						"type": "string",
+						"description": "Only these chapters, as a number or range such as '3-7' (default: all)"
 					}
 				},
 				"required": ["document_id"]
 			}`),
 		},
 		{
-			Name:        "add_chapter",
-			Description: "Add a new chapter to a document. Creates chapter structure but not content - use add_section to add actual content. Chapters are automatically numbered sequentially (1, 2, 3...). Returns the assigned chapter number. Use this before adding any content to a chapter.",
+			Name:        "get_toc",
+			Description: "Get a compact table of contents: chapter and section numbers and titles, parts, and figure and table counts per chapter, without the timestamps and settings get_document_structure returns. Use this to orient yourself in a large document cheaply.",
 			InputSchema: json.RawMessage(`{
 				"type": "object",
 				"properties": {
@@ -141,22 +198,23 @@ func (h *DocGenHandler) ListTools(ctx context.Context) (*protocol.ListToolsRespo
 						"type": "string",
 						"description": "Document ID returned from create_document (e.g., 'technical-manual-1234567890')"
 					},
-					"title": {
-						"type": "string",
-						"description": "Chapter title"
-					},
-					"position": {
+					"depth": {
 						"type": "integer",
-						"description": "Position to insert chapter (optional). If specified, existing chapters are renumbered. If omitted, chapter is added at end.",
-						"minimum": 1
+						"minimum": 1,
+						"description": "Heading levels to include: 1 for chapters only, 2 adds top-level sections (default: 3)"
+					},
+					"format": {
+						"type": "string",
+						"enum": ["text", "json"],
+						"description": "'text' (default) returns an indented outline, one heading per line; 'json' returns the same entries as structured data"
 					}
 				},
-				"required": ["document_id", "title"]
+				"required": ["document_id"]
 			}`),
 		},
 		{
-			Name:        "update_chapter_metadata",
-			Description: "Update chapter information like title. Use this to rename chapters or update chapter-level information. Does not affect chapter content - use update_section for content changes.",
+			Name:        "summarize_structure",
+			Description: "Get a cheap, deterministic summary of a document: per chapter its title, word count, section headings and an excerpt of its opening (the first paragraph, or the first excerpt_words words). Use this to decide where to edit without fetching full chapter contents.",
 			InputSchema: json.RawMessage(`{
 				"type": "object",
 				"properties": {
@@ -164,248 +222,179 @@ func (h *DocGenHandler) ListTools(ctx context.Context) (*protocol.ListToolsRespo
 						"type": "string",
 						"description": "Document ID returned from create_document (e.g., 'technical-manual-1234567890')"
 					},
-					"chapter_number": {
+					"chapter_range": {
+						"type": "string",
+						"description": "Summarize only these chapters, such as '3-7' or '4' (default: all chapters)"
+					},
+					"depth": {
 						"type": "integer",
-						"description": "Chapter number (1-based, sequential). Use get_document_structure to see available chapter numbers.",
-						"minimum": 1
+						"minimum": 1,
+						"description": "Heading levels to include: 1 for chapters only, 2 adds top-level sections (default: every level)"
 					},
-					"title": {
-						"type": "string",
-						"description": "New chapter title"
+					"excerpt_words": {
+						"type": "integer",
+						"minimum": 1,
+						"description": "Take each chapter's excerpt from its first this many words, across paragraphs, instead of its first paragraph"
 					}
 				},
-				"required": ["document_id", "chapter_number", "title"]
+				"required": ["document_id"]
 			}`),
 		},
 		{
-			Name:        "delete_chapter",
-			Description: "Delete a chapter and all its content permanently. Automatically renumbers subsequent chapters (chapter 3 becomes 2, chapter 4 becomes 3, etc.). All sections, figures, and tables in the chapter are also deleted. Use only when user explicitly requests chapter deletion.",
+			Name:        "delete_document",
+			Description: "Permanently delete a document and all its contents including chapters, sections, figures, and exported files. This action cannot be undone. Use only when the user explicitly requests document deletion.",
 			InputSchema: json.RawMessage(`{
 				"type": "object",
 				"properties": {
 					"document_id": {
 						"type": "string",
-						"description": "Document ID returned from create_document (e.g., 'technical-manual-1234567890')"
+						"description": "Document ID of the document to permanently delete"
 					},
-					"chapter_number": {
-						"type": "integer",
-						"description": "Chapter number to permanently delete (warning: this removes all chapter content)",
-						"minimum": 1
+					"confirmation_token": {
+						"type": "string",
+						"description": "Token returned by a first delete_document call when the server requires confirmation. Pass it back to actually delete."
 					}
 				},
-				"required": ["document_id", "chapter_number"]
+				"required": ["document_id"]
 			}`),
 		},
 		{
-			Name:        "move_chapter",
-			Description: "Reorder chapters by moving a chapter from one position to another. Automatically renumbers all chapters and updates cross-references. For example, moving chapter 3 to position 1 makes it chapter 1, and chapters 1-2 become 2-3. Use get_document_structure first to see current chapter order.",
+			Name:        "archive_document",
+			Description: "Package an entire document (manifest, chapters, sections, assets, style and pandoc config) into a single .zip in the archives/ directory, for backup or to move it to another machine. Returns the archive's path. Use restore_document to bring it back.",
 			InputSchema: json.RawMessage(`{
 				"type": "object",
 				"properties": {
 					"document_id": {
 						"type": "string",
 						"description": "Document ID returned from create_document (e.g., 'technical-manual-1234567890')"
-					},
-					"from_number": {
-						"type": "integer",
-						"description": "Current chapter number",
-						"minimum": 1
-					},
-					"to_number": {
-						"type": "integer",
-						"description": "Target chapter number",
-						"minimum": 1
 					}
 				},
-				"required": ["document_id", "from_number", "to_number"]
+				"required": ["document_id"]
 			}`),
 		},
 		{
-			Name:        "add_section",
-			Description: "Add actual content to a chapter by creating a section. This is where you put the real text, paragraphs, lists, and formatting. Sections are automatically numbered (1.1, 1.2, 2.1, etc.). The chapter must exist first - use add_chapter if needed. Supports full markdown formatting.",
+			Name:        "restore_document",
+			Description: "Restore a document from a .zip made by archive_document. The archive's structure is checked before anything is written. The document keeps its original ID unless a document with that ID already exists, in which case it is given a new one; the ID used is returned.",
 			InputSchema: json.RawMessage(`{
 				"type": "object",
 				"properties": {
-					"document_id": {
-						"type": "string",
-						"description": "Document ID returned from create_document (e.g., 'technical-manual-1234567890')"
-					},
-					"chapter_number": {
-						"type": "integer",
-						"description": "Chapter number (1-based, sequential). Use get_document_structure to see available chapter numbers.",
-						"minimum": 1
-					},
-					"title": {
+					"archive_path": {
 						"type": "string",
-						"description": "Section title"
+						"description": "Path of the archive; relative paths are resolved against the archives/ directory"
 					},
-					"content": {
+					"new_document_id": {
 						"type": "string",
-						"description": "Section content in markdown format. Supports headings, paragraphs, lists, code blocks, emphasis, links, etc."
-					},
-					"level": {
-						"type": "integer",
-						"description": "Section hierarchy level: 1=main section (1.1), 2=subsection (1.1.1), 3=sub-subsection (1.1.1.1), etc.",
-						"minimum": 1,
-						"maximum": 6,
-						"default": 1
+						"description": "ID to restore the document under (default: its archived ID)"
 					}
 				},
-				"required": ["document_id", "chapter_number", "title", "content"]
+				"required": ["archive_path"]
 			}`),
 		},
 		{
-			Name:        "update_section",
-			Description: "Modify the content of an existing section within a chapter. Use this to edit, revise, or replace section text while preserving the document structure. Find the section number using get_document_structure or get_chapter first.",
+			Name:        "rename_document",
+			Description: "Change a document's title or first author, and optionally move it to a new document ID. A move renames the document's directory, updates paths inside it and carries its roles and usage records over; the response gives the new ID, which replaces the old one in every later call. Earlier exports keep their old file names.",
 			InputSchema: json.RawMessage(`{
 				"type": "object",
 				"properties": {
 					"document_id": {
 						"type": "string",
-						"description": "Document ID returned from create_document (e.g., 'technical-manual-1234567890')"
+						"description": "Document ID to rename"
 					},
-					"chapter_number": {
-						"type": "integer",
-						"description": "Chapter number (1-based, sequential). Use get_document_structure to see available chapter numbers.",
-						"minimum": 1
+					"title": {
+						"type": "string",
+						"description": "New document title (optional)"
 					},
-					"section_number": {
+					"author": {
 						"type": "string",
-						"description": "Section number (e.g., '1.1', '1.2.1')"
+						"description": "New name for the first author, keeping their affiliation and contact details (optional); use add_author and remove_author for other changes"
 					},
-					"content": {
+					"new_document_id": {
 						"type": "string",
-						"description": "New section content"
+						"description": "Move the document to this ID (letters, numbers, hyphens and underscores; optional)"
+					},
+					"derive_id": {
+						"type": "boolean",
+						"description": "Move the document to an ID made from its title, as create_document does (default: false)"
 					}
 				},
-				"required": ["document_id", "chapter_number", "section_number", "content"]
+				"required": ["document_id"]
 			}`),
 		},
 		{
-			Name:        "delete_section",
-			Description: "Permanently remove a section from a chapter and automatically renumber subsequent sections. This removes the section content and adjusts section numbering (1.2 becomes 1.1, 1.3 becomes 1.2, etc.). Use only when user explicitly requests section deletion.",
+			Name:        "lock_document",
+			Description: "Make a document read-only, once a manuscript is finalized or while someone edits its files directly on disk. Tools that change a locked document refuse with an error until unlock_document is called; reading, checking and exporting it still work.",
 			InputSchema: json.RawMessage(`{
 				"type": "object",
 				"properties": {
 					"document_id": {
 						"type": "string",
-						"description": "Document ID returned from create_document (e.g., 'technical-manual-1234567890')"
-					},
-					"chapter_number": {
-						"type": "integer",
-						"description": "Chapter number (1-based, sequential). Use get_document_structure to see available chapter numbers.",
-						"minimum": 1
+						"description": "Document ID to lock"
 					},
-					"section_number": {
+					"reason": {
 						"type": "string",
-						"description": "Section number to delete (e.g., '1.1', '1.2.1')"
+						"description": "Why the document is locked, included in the errors of refused changes (optional)"
 					}
 				},
-				"required": ["document_id", "chapter_number", "section_number"]
+				"required": ["document_id"]
 			}`),
 		},
 		{
-			Name:        "get_section_content",
-			Description: "Get the content of one or more sections. This is useful for progressively loading section content as needed. Can retrieve a single section or multiple sections at once.",
+			Name:        "unlock_document",
+			Description: "Make a locked document editable again",
 			InputSchema: json.RawMessage(`{
 				"type": "object",
 				"properties": {
 					"document_id": {
 						"type": "string",
-						"description": "Document ID returned from create_document"
-					},
-					"sections": {
-						"type": "array",
-						"description": "Array of sections to retrieve",
-						"items": {
-							"type": "object",
-							"properties": {
-								"chapter_number": {
-									"type": "integer",
-									"description": "Chapter number",
-									"minimum": 1
-								},
-								"section_number": {
-									"type": "string",
-									"description": "Section number (e.g., '1.1', '1.2.1')"
-								}
-							},
-							"required": ["chapter_number", "section_number"]
-						},
-						"minItems": 1
+						"description": "Document ID to unlock"
 					}
 				},
-				"required": ["document_id", "sections"]
+				"required": ["document_id"]
 			}`),
 		},
 		{
-			Name:        "add_image",
-			Description: "Add an image/figure to a chapter with automatic numbering (fig-1.1, fig-1.2, etc.). Images are automatically numbered within each chapter and include captions. Supports positioning, sizing, and alignment options. The image file must exist at the specified path.",
+			Name:        "tag_document",
+			Description: "Add tags to a document to organize documents into collections, such as one per client or project. Tags are lowercased words of letters, numbers and - _ . : / (e.g., 'client:acme', 'q3-report'). Filter list_documents by tags to find a collection.",
 			InputSchema: json.RawMessage(`{
 				"type": "object",
 				"properties": {
 					"document_id": {
 						"type": "string",
-						"description": "Document ID returned from create_document (e.g., 'technical-manual-1234567890')"
-					},
-					"chapter_number": {
-						"type": "integer",
-						"description": "Chapter number (1-based, sequential). Use get_document_structure to see available chapter numbers.",
-						"minimum": 1
-					},
-					"image_path": {
-						"type": "string",
-						"description": "Path to the image file"
-					},
-					"caption": {
-						"type": "string",
-						"description": "Image caption"
+						"description": "Document ID to tag"
 					},
-					"position": {
-						"type": "string",
-						"enum": ["here", "top", "bottom", "page", "float"],
-						"description": "Image position (default: here)",
-						"default": "here"
-					},
-					"width": {
-						"type": "string",
-						"description": "Image width (e.g., '50%', '10cm')"
-					},
-					"alignment": {
-						"type": "string",
-						"enum": ["left", "center", "right"],
-						"description": "Image alignment (default: center)",
-						"default": "center"
+					"tags": {
+						"type": "array",
+						"items": {"type": "string"},
+						"minItems": 1,
+						"description": "Tags to add; tags the document already has are ignored"
 					}
 				},
-				"required": ["document_id", "chapter_number", "image_path", "caption"]
+				"required": ["document_id", "tags"]
 			}`),
 		},
 		{
-			Name:        "update_image_caption",
-			Description: "Change the caption text of an existing figure while preserving the image and its position. Use the figure_id (like 'fig-1.1') to identify which image to update. Find figure IDs using get_document_structure or get_chapter.",
+			Name:        "untag_document",
+			Description: "Remove tags from a document",
 			InputSchema: json.RawMessage(`{
 				"type": "object",
 				"properties": {
 					"document_id": {
 						"type": "string",
-						"description": "Document ID returned from create_document (e.g., 'technical-manual-1234567890')"
-					},
-					"figure_id": {
-						"type": "string",
-						"description": "Figure ID (e.g., 'fig-1.1')"
+						"description": "Document ID to untag"
 					},
-					"new_caption": {
-						"type": "string",
-						"description": "New caption text"
+					"tags": {
+						"type": "array",
+						"items": {"type": "string"},
+						"minItems": 1,
+						"description": "Tags to remove"
 					}
 				},
-				"required": ["document_id", "figure_id", "new_caption"]
+				"required": ["document_id", "tags"]
 			}`),
 		},
 		{
-			Name:        "delete_image",
-			Description: "Permanently remove an image/figure from a chapter and automatically renumber remaining figures (fig-1.2 becomes fig-1.1, fig-1.3 becomes fig-1.2, etc.). This removes both the image reference and its caption. Use only when user explicitly requests image deletion.",
+			Name:        "configure_document",
+			Description: "Update document styling (fonts, margins, spacing), export settings (PDF engine, table of contents) and the markdown flavor. Use this to customize the appearance and formatting of the final exported document. Changes apply to future exports, not existing ones.",
 			InputSchema: json.RawMessage(`{
 				"type": "object",
 				"properties": {
@@ -413,13 +402,1898 @@ func (h *DocGenHandler) ListTools(ctx context.Context) (*protocol.ListToolsRespo
 						"type": "string",
 						"description": "Document ID returned from create_document (e.g., 'technical-manual-1234567890')"
 					},
-					"figure_id": {
-						"type": "string",
-						"description": "Figure ID to delete (e.g., 'fig-1.1')"
-					}
-				},
-				"required": ["document_id", "figure_id"]
-			}`),
+					"style_updates": {
+						"type": "object",
+						"properties": {
+							"font_family": {"type": "string"},
+							"font_size": {"type": "string"},
+							"line_spacing": {"type": "string"},
+							"margins": {
+								"type": "object",
+								"properties": {
+									"top": {"type": "string"},
+									"bottom": {"type": "string"},
+									"left": {"type": "string"},
+									"right": {"type": "string"}
+								}
+							},
+							"header_footer": {
+								"type": "object",
+								"properties": {
+									"header_template": {"type": "string"},
+									"footer_template": {"type": "string"},
+									"first_page": {"type": "object", "properties": {"header": {"type": "string"}, "footer": {"type": "string"}}},
+									"odd_page": {"type": "object", "properties": {"header": {"type": "string"}, "footer": {"type": "string"}}},
+									"even_page": {"type": "object", "properties": {"header": {"type": "string"}, "footer": {"type": "string"}}},
+									"suppress_chapter_start": {"type": "boolean"}
+								}
+							},
+							"numbering_style": {
+								"type": "object",
+								"properties": {
+									"chapter_format": {"type": "string", "enum": ["arabic", "roman", "letters"]},
+									"figure_numbering": {"type": "string", "enum": ["chapter", "continuous"]},
+									"table_numbering": {"type": "string", "enum": ["chapter", "continuous"]},
+									"section_depth": {"type": "integer", "minimum": 0, "maximum": 5}
+								}
+							},
+							"chapter_heading": {
+								"type": "object",
+								"properties": {
+									"prefix": {"type": "string"},
+									"unnumbered": {"type": "array", "items": {"type": "string"}},
+									"case": {"type": "string", "enum": ["upper", "lower", "title"]}
+								}
+							},
+							"html": {
+								"type": "object",
+								"properties": {
+									"max_width": {"type": "string"},
+									"sidebar_toc": {"type": "boolean"},
+									"background": {"type": "string"},
+									"dark_mode": {"type": "boolean"},
+									"dark_background": {"type": "string"},
+									"dark_text_color": {"type": "string"},
+									"dark_heading_color": {"type": "string"},
+									"dark_link_color": {"type": "string"}
+								}
+							},
+							"slides": {
+								"type": "object",
+								"properties": {
+									"theme": {"type": "string"},
+									"transition": {"type": "string", "enum": ["none", "fade", "slide", "convex", "concave", "zoom"]},
+									"aspect_ratio": {"type": "string", "enum": ["16:9", "4:3"]},
+									"slide_numbers": {"type": "boolean"},
+									"reference_pptx": {"type": "string"}
+								}
+							}
+						},
+						"description": "Style updates: font_family, font_size, line_spacing, epigraph (font_family, font_size and color of chapter epigraphs set with set_epigraph), margins with top/bottom/left/right, numbering_style (chapter_format: arabic 1, roman I or letters A; figure_numbering and table_numbering: chapter for 1.1, 1.2 or continuous for 1, 2, 3; section_depth: deepest numbered section level, 0 for all), chapter_heading (prefix before chapter titles with %d for the chapter number, such as 'Chapter %d:' (the default) or '%d.', or '' for titles alone; unnumbered: titles of chapters such as Introduction written without the prefix; case: 'upper', 'lower' or 'title' to recapitalize chapter titles), header_footer (header_template and footer_template with {page}, {total_pages}, {document_title}, {author}, {date}, {chapter_title} and {section_title}; first_page, odd_page and even_page each replace them with their own header and footer, blank where left out; suppress_chapter_start leaves chapter opening pages without them in PDF and HTML), typography (smart_punctuation turns curly quotes, dashes and ellipses from -- , --- and ... on or off; dashes rewrites dashes between words as 'em' for word—word or 'spaced-en' for word – word; ellipses writes ... as …; non_breaking_spaces keeps numbers with their units and 'Figure 3' or 'p. 12' together; prevent_widows and prevent_orphans stop PDF pages from starting with a paragraph's last line or ending with its first), html (HTML exports only: max_width of the text column such as 45rem; sidebar_toc pins the table of contents beside the text on wide screens and turns it on; background color; dark_mode follows the reader's dark mode setting, with dark_background, dark_text_color, dark_heading_color and dark_link_color), slides (slide decks only, which take the body, heading and link fonts and colors and nothing else of the style: reveal.js theme such as white, black or serif, transition, aspect_ratio 16:9 or 4:3 and slide_numbers; reference_pptx: a PowerPoint file relative to the document whose layouts and theme pptx decks use)"
+					},
+					"pandoc_options": {
+						"type": "object",
+						"properties": {
+							"pdf_engine": {"type": "string"},
+							"toc": {"type": "boolean"},
+							"toc_depth": {"type": "integer"},
+							"citation_style": {"type": "string"},
+							"bibliography": {"type": "string"},
+							"bibliography_scope": {"type": "string", "enum": ["document", "chapter"]}
+						},
+						"description": "Export settings: pdf_engine, toc (table of contents), toc_depth, citation_style (a .csl file selects the citation style), bibliography (.bib/.json/.yaml file relative to the document, enables citations), bibliography_scope (document: one reference list at the end; chapter: a reference list after each chapter)"
+					},
+					"markdown": {
+						"type": "object",
+						"properties": {
+							"footnotes": {"type": "boolean"},
+							"pipe_tables": {"type": "boolean"},
+							"task_lists": {"type": "boolean"},
+							"raw_html": {"type": "boolean"},
+							"smart": {"type": "boolean"}
+						},
+						"additionalProperties": {"type": "boolean"},
+						"description": "Markdown flavor: turn pandoc reader extensions on or off (the five listed are on by default). Applied to every export and to previews, including the built-in renderer. Any other extension the installed pandoc supports can be given by name too, such as definition_lists, raw_tex or mark; these apply to exports only. Only provided extensions are changed."
+					},
+					"metadata": {
+						"type": "object",
+						"properties": {
+							"subtitle": {"type": "string"},
+							"keywords": {"type": "array", "items": {"type": "string"}},
+							"abstract": {"type": "string"},
+							"language": {"type": "string"},
+							"date": {"type": "string"},
+							"locale": {"type": "string"}
+						},
+						"description": "Document metadata: subtitle, keywords, abstract, language (BCP-47 tag), date, locale (BCP-47 tag for dates, caption labels and contents headings). Only provided fields are changed; an empty string or keyword list clears a field."
+					}
+				},
+				"required": ["document_id"]
+			}`),
+		},
+		{
+			Name:        "add_author",
+			Description: "Add an author to a document. Documents support multiple authors, each with optional affiliation, email, and ORCID. Authors appear in the exported title page and document properties in the order they were added.",
+			InputSchema: json.RawMessage(`{
+				"type": "object",
+				"properties": {
+					"document_id": {
+						"type": "string",
+						"description": "Document ID returned from create_document (e.g., 'technical-manual-1234567890')"
+					},
+					"name": {
+						"type": "string",
+						"description": "Author's full name"
+					},
+					"affiliation": {
+						"type": "string",
+						"description": "Institution or organization (optional)"
+					},
+					"email": {
+						"type": "string",
+						"description": "Contact email address (optional)"
+					},
+					"orcid": {
+						"type": "string",
+						"description": "ORCID identifier in the form 0000-0000-0000-0000 (optional)"
+					}
+				},
+				"required": ["document_id", "name"]
+			}`),
+		},
+		{
+			Name:        "remove_author",
+			Description: "Remove an author from a document by name. A document must always keep at least one author.",
+			InputSchema: json.RawMessage(`{
+				"type": "object",
+				"properties": {
+					"document_id": {
+						"type": "string",
+						"description": "Document ID returned from create_document (e.g., 'technical-manual-1234567890')"
+					},
+					"name": {
+						"type": "string",
+						"description": "Name of the author to remove"
+					}
+				},
+				"required": ["document_id", "name"]
+			}`),
+		},
+		{
+			Name:        "list_todos",
+			Description: "List outstanding drafting items in a document, such as images added without a caption that still carry a placeholder. Use this to find what needs finishing before a strict validation or final export.",
+			InputSchema: json.RawMessage(`{
+				"type": "object",
+				"properties": {
+					"document_id": {
+						"type": "string",
+						"description": "Document ID returned from create_document (e.g., 'technical-manual-1234567890')"
+					}
+				},
+				"required": ["document_id"]
+			}`),
+		},
+		{
+			Name:        "set_editorial_rules",
+			Description: "Configure house-style rules applied automatically when chapters are rebuilt: spell out whole numbers under ten, put a narrow no-break space between numbers and units, and write percentages consistently. Section files are not modified; only the compiled chapter output is normalized. Call with all rules off to disable. Use get_editorial_report to preview the changes.",
+			InputSchema: json.RawMessage(`{
+				"type": "object",
+				"properties": {
+					"document_id": {
+						"type": "string",
+						"description": "Document ID returned from create_document (e.g., 'technical-manual-1234567890')"
+					},
+					"spell_out_numbers": {
+						"type": "boolean",
+						"description": "Write standalone whole numbers under ten as words (3 -> three). Numbers with units, percentages, and labels like 'Figure 3' are kept."
+					},
+					"unit_spacing": {
+						"type": "boolean",
+						"description": "Separate numbers and unit symbols with a narrow no-break space (5kg -> 5 kg)"
+					},
+					"percent_style": {
+						"type": "string",
+						"enum": ["symbol", "word"],
+						"description": "Write percentages as '25%' (symbol) or '25 percent' (word)"
+					}
+				},
+				"required": ["document_id"]
+			}`),
+		},
+		{
+			Name:        "get_editorial_report",
+			Description: "List every change the document's editorial rules make during rebuild, with chapter, section, line, rule, and the original and replacement text. Use this to review house-style normalization before exporting.",
+			InputSchema: json.RawMessage(`{
+				"type": "object",
+				"properties": {
+					"document_id": {
+						"type": "string",
+						"description": "Document ID returned from create_document (e.g., 'technical-manual-1234567890')"
+					}
+				},
+				"required": ["document_id"]
+			}`),
+		},
+		{
+			Name:        "save_house_style",
+			Description: "Save an organization's house style ruleset for use with check_house_style. Each rule either matches a regular expression (optionally with a replacement) or runs a built-in check: spell_out_numbers, unit_spacing, percent_style (option style: symbol|word), repeated_words, max_sentence_words (option max), heading_punctuation. Rules marked fix: true are corrected automatically when checking with fix. Saving under an existing name replaces the ruleset.",
+			InputSchema: json.RawMessage(`{
+				"type": "object",
+				"properties": {
+					"name": {
+						"type": "string",
+						"description": "Ruleset name (letters, numbers, hyphens, underscores; e.g., 'acme-style')"
+					},
+					"description": {
+						"type": "string",
+						"description": "What the ruleset is for"
+					},
+					"rules": {
+						"type": "array",
+						"description": "Rules applied in order",
+						"items": {
+							"type": "object",
+							"properties": {
+								"id": {"type": "string", "description": "Unique rule identifier"},
+								"message": {"type": "string", "description": "Explanation shown with each finding"},
+								"severity": {"type": "string", "enum": ["error", "warning", "info"], "description": "Defaults to warning"},
+								"pattern": {"type": "string", "description": "Regular expression (RE2 syntax) to flag"},
+								"ignore_case": {"type": "boolean"},
+								"replacement": {"type": "string", "description": "Replacement for pattern matches; $1 refers to capture groups"},
+								"builtin": {"type": "string", "enum": ["spell_out_numbers", "unit_spacing", "percent_style", "repeated_words", "max_sentence_words", "heading_punctuation"]},
+								"options": {"type": "object", "additionalProperties": {"type": "string"}, "description": "Built-in check options"},
+								"blocks": {"type": "array", "items": {"type": "string", "enum": ["heading", "paragraph", "list_item", "blockquote"]}, "description": "Limit the rule to these block kinds"},
+								"fix": {"type": "boolean", "description": "The rule's correction is safe to apply automatically"}
+							},
+							"required": ["id"]
+						}
+					}
+				},
+				"required": ["name", "rules"]
+			}`),
+		},
+		{
+			Name:        "check_house_style",
+			Description: "Check every section of a document against a house style ruleset and report findings with severity, location, and suggested corrections. With fix=true, corrections from rules marked safe to fix are written to the section files and the affected chapters are rebuilt. Code blocks, tables, inline code, and links are never changed.",
+			InputSchema: json.RawMessage(`{
+				"type": "object",
+				"properties": {
+					"document_id": {
+						"type": "string",
+						"description": "Document ID returned from create_document (e.g., 'technical-manual-1234567890')"
+					},
+					"house_style": {
+						"type": "string",
+						"description": "Ruleset name saved with save_house_style. Defaults to the DOCGEN_HOUSE_STYLE ruleset."
+					},
+					"fix": {
+						"type": "boolean",
+						"description": "Apply safe corrections to the document (default: false, report only)"
+					}
+				},
+				"required": ["document_id"]
+			}`),
+		},
+		{
+			Name:        "set_writing_target",
+			Description: "Set a word count goal for a document, with an optional deadline, used by writing_progress to project when the draft will be finished. Call without target_words to remove the target.",
+			InputSchema: json.RawMessage(`{
+				"type": "object",
+				"properties": {
+					"document_id": {
+						"type": "string",
+						"description": "Document ID returned from create_document (e.g., 'technical-manual-1234567890')"
+					},
+					"target_words": {
+						"type": "integer",
+						"description": "Total words the finished document should have (e.g., 50000)",
+						"minimum": 1
+					},
+					"deadline": {
+						"type": "string",
+						"description": "Date the target should be reached, YYYY-MM-DD (e.g., '2024-11-30')"
+					}
+				},
+				"required": ["document_id"]
+			}`),
+		},
+		{
+			Name:        "writing_progress",
+			Description: "Show daily writing progress for a document: words added per day from the daily word count snapshots recorded whenever content changes, the average pace over the last seven days, and, when a writing target is set, the projected completion date and the daily words needed to meet the deadline.",
+			InputSchema: json.RawMessage(`{
+				"type": "object",
+				"properties": {
+					"document_id": {
+						"type": "string",
+						"description": "Document ID returned from create_document (e.g., 'technical-manual-1234567890')"
+					},
+					"days": {
+						"type": "integer",
+						"description": "Number of most recent days to list (default: 30, 0 for all)",
+						"minimum": 0
+					}
+				},
+				"required": ["document_id"]
+			}`),
+		},
+		{
+			Name:        "get_document_size",
+			Description: "Report how much disk space a document uses, broken down into chapters (with a total per chapter), image assets, exports (exported files, previews and export logs) and word count snapshots, plus the largest files. Use it to see what is taking up space before hitting a storage or export quota.",
+			InputSchema: json.RawMessage(`{
+				"type": "object",
+				"properties": {
+					"document_id": {
+						"type": "string",
+						"description": "Document ID returned from create_document (e.g., 'technical-manual-1234567890')"
+					},
+					"largest": {
+						"type": "integer",
+						"description": "Number of largest files to list (default: 10)",
+						"minimum": 1
+					}
+				},
+				"required": ["document_id"]
+			}`),
+		},
+		{
+			Name:        "get_storage_usage",
+			Description: "Report the disk space every document uses, split into content, image assets and exports, largest first, with the total for the root directory and the storage quotas set by DOCGEN_MAX_TOTAL_SIZE and DOCGEN_MAX_DOCUMENT_SIZE. Documents over their quota are flagged; writes that would exceed a quota fail, so use this to decide what to prune.",
+			InputSchema: json.RawMessage(`{
+				"type": "object",
+				"properties": {}
+			}`),
+		},
+		{
+			Name:        "get_usage_report",
+			Description: "Report per-client usage on a shared server: documents held and created, exports made, and bytes exported, with the quota that applies to each client. Clients are identified by the token or identity the transport supplies, or DOCGEN_CLIENT_ID for a single local client.",
+			InputSchema: json.RawMessage(`{
+				"type": "object",
+				"properties": {
+					"client_id": {
+						"type": "string",
+						"description": "Report only this client (default: all clients)"
+					}
+				}
+			}`),
+		},
+		{
+			Name:        "set_document_role",
+			Description: "Grant a client identity a role on a document when access control is enabled: viewer (read and export), editor (change content and settings) or admin (delete the document and grant roles). Omit the role to revoke the identity's document-level grant. Requires the admin role on the document.",
+			InputSchema: json.RawMessage(`{
+				"type": "object",
+				"properties": {
+					"document_id": {
+						"type": "string",
+						"description": "Document ID returned from create_document (e.g., 'technical-manual-1234567890')"
+					},
+					"identity": {
+						"type": "string",
+						"description": "Client identity (token or user) supplied by the transport"
+					},
+					"role": {
+						"type": "string",
+						"enum": ["viewer", "editor", "admin"],
+						"description": "Role to grant; omit to revoke"
+					}
+				},
+				"required": ["document_id", "identity"]
+			}`),
+		},
+		{
+			Name:        "get_export_records",
+			Description: "List the notarized export records kept when DOCGEN_NOTARIZE is enabled: content hash, style hash, exporter and pandoc versions, timestamp and the optional RFC 3161 timestamp token of each export. The hash chain of the whole ledger is verified and reported.",
+			InputSchema: json.RawMessage(`{
+				"type": "object",
+				"properties": {
+					"document_id": {
+						"type": "string",
+						"description": "Only list records for this document (default: all documents)"
+					}
+				}
+			}`),
+		},
+		{
+			Name:        "verify_export",
+			Description: "Check that a document's current export file is byte-for-byte the one recorded in the export ledger, and that the ledger has not been altered.",
+			InputSchema: json.RawMessage(`{
+				"type": "object",
+				"properties": {
+					"document_id": {
+						"type": "string",
+						"description": "Document ID returned from create_document (e.g., 'technical-manual-1234567890')"
+					},
+					"format": {
+						"type": "string",
+						"enum": ["pdf", "docx", "html", "epub", "txt", "ssml", "json", "revealjs", "pptx"],
+						"description": "Export format to verify"
+					}
+				},
+				"required": ["document_id", "format"]
+			}`),
+		},
+		{
+			Name:        "add_chapter",
+			Description: "Add a new chapter to a document. Creates chapter structure but not content - use add_section to add actual content. Chapters are automatically numbered sequentially (1, 2, 3...). Returns the assigned chapter number. Use this before adding any content to a chapter.",
+			InputSchema: json.RawMessage(`{
+				"type": "object",
+				"properties": {
+					"document_id": {
+						"type": "string",
+						"description": "Document ID returned from create_document (e.g., 'technical-manual-1234567890')"
+					},
+					"title": {
+						"type": "string",
+						"description": "Chapter title"
+					},
+					"position": {
+						"type": "integer",
+						"description": "Position to insert chapter (optional). If specified, existing chapters from that position on are renumbered, along with their section numbers and figure and table IDs, and references to those IDs are updated. If omitted, chapter is added at end.",
+						"minimum": 1
+					}
+				},
+				"required": ["document_id", "title"]
+			}`),
+		},
+		{
+			Name:        "update_chapter_metadata",
+			Description: "Update chapter information like title. Use this to rename chapters or update chapter-level information. Does not affect chapter content - use update_section for content changes.",
+			InputSchema: json.RawMessage(`{
+				"type": "object",
+				"properties": {
+					"document_id": {
+						"type": "string",
+						"description": "Document ID returned from create_document (e.g., 'technical-manual-1234567890')"
+					},
+					"chapter_number": {
+						"type": "integer",
+						"description": "Chapter number (1-based, sequential). Use get_document_structure to see available chapter numbers.",
+						"minimum": 1
+					},
+					"title": {
+						"type": "string",
+						"description": "New chapter title"
+					}
+				},
+				"required": ["document_id", "chapter_number", "title"]
+			}`),
+		},
+		{
+			Name:        "set_front_matter",
+			Description: "Attach your own metadata to a chapter, or to a section with section_number, such as status: draft, a reviewer or a due date. It is kept with the chapter's metadata, shown by get_document_structure and get_document_json, can filter get_document_structure with its front_matter parameter, and export_document's front_matter_badges shows chosen keys as badges in HTML. Keys given are added or replaced and a null value removes its key; replace swaps the whole front matter for the one given.",
+			InputSchema: json.RawMessage(`{
+				"type": "object",
+				"properties": {
+					"document_id": {
+						"type": "string",
+						"description": "Document ID returned from create_document (e.g., 'technical-manual-1234567890')"
+					},
+					"chapter_number": {
+						"type": "integer",
+						"description": "Chapter number (1-based, sequential). Use get_document_structure to see available chapter numbers.",
+						"minimum": 1
+					},
+					"section_number": {
+						"type": "string",
+						"description": "Section number (e.g., '1.1', '1.2.1'); leave out to set the chapter's front matter"
+					},
+					"front_matter": {
+						"type": "object",
+						"description": "Keys and values to set, e.g. {\"status\": \"draft\", \"reviewer\": \"Sam\"}; null removes a key. Keys use letters, digits, - and _."
+					},
+					"replace": {
+						"type": "boolean",
+						"description": "Replace the whole front matter instead of merging into it (default: false)"
+					}
+				},
+				"required": ["document_id", "chapter_number", "front_matter"]
+			}`),
+		},
+		{
+			Name:        "set_status",
+			Description: "Set where a chapter, or a section with section_number, is in the review workflow: draft, in-review, approved or final. Chapters are draft until set; a section without its own status takes the status of the section it is nested in, or of its chapter. get_document_structure shows statuses and filters by them, get_document_json gives each section's effective status, and export_document can export only approved content or stamp chapters with their status. An empty status clears a section's own status.",
+			InputSchema: json.RawMessage(`{
+				"type": "object",
+				"properties": {
+					"document_id": {
+						"type": "string",
+						"description": "Document ID returned from create_document (e.g., 'technical-manual-1234567890')"
+					},
+					"chapter_number": {
+						"type": "integer",
+						"description": "Chapter number (1-based, sequential). Use get_document_structure to see available chapter numbers.",
+						"minimum": 1
+					},
+					"section_number": {
+						"type": "string",
+						"description": "Section number (e.g., '1.1', '1.2.1'); leave out to set the chapter's status"
+					},
+					"status": {
+						"type": "string",
+						"enum": ["draft", "in-review", "approved", "final", ""],
+						"description": "Workflow status; '' clears a section's own status so it takes its parent's again"
+					}
+				},
+				"required": ["document_id", "chapter_number", "status"]
+			}`),
+		},
+		{
+			Name:        "set_epigraph",
+			Description: "Open a chapter with an epigraph: a quotation and who it is by, set under the chapter heading in a narrow right-aligned column. PDF, DOCX (Epigraph and Epigraph Attribution paragraph styles) and HTML (.epigraph and .epigraph-attribution) exports each style it properly, in italics or the style's epigraph font_family, font_size and color. Leave text empty to remove the epigraph.",
+			InputSchema: json.RawMessage(`{
+				"type": "object",
+				"properties": {
+					"document_id": {
+						"type": "string",
+						"description": "Document ID returned from create_document (e.g., 'technical-manual-1234567890')"
+					},
+					"chapter_number": {
+						"type": "integer",
+						"description": "Chapter number (1-based, sequential). Use get_document_structure to see available chapter numbers.",
+						"minimum": 1
+					},
+					"text": {
+						"type": "string",
+						"description": "The quotation, in markdown; empty removes the epigraph"
+					},
+					"attribution": {
+						"type": "string",
+						"description": "Who or what the quotation is from, e.g. 'Ursula K. Le Guin, *The Dispossessed*'; shown after an em dash"
+					}
+				},
+				"required": ["document_id", "chapter_number"]
+			}`),
+		},
+		{
+			Name:        "configure_chapter",
+			Description: "Set chapter-specific export options such as pandoc variables, document class options, or landscape orientation. Useful for appendices with wide tables. Options are merged with the document-level configuration at export; document-level variables take precedence. Calling with no options clears them.",
+			InputSchema: json.RawMessage(`{
+				"type": "object",
+				"properties": {
+					"document_id": {
+						"type": "string",
+						"description": "Document ID returned from create_document (e.g., 'technical-manual-1234567890')"
+					},
+					"chapter_number": {
+						"type": "integer",
+						"description": "Chapter number (1-based, sequential). Use get_document_structure to see available chapter numbers.",
+						"minimum": 1
+					},
+					"variables": {
+						"type": "object",
+						"description": "Pandoc variables to set when this chapter is exported (e.g., {\"geometry\": \"margin=0.5in\"})",
+						"additionalProperties": {"type": "string"}
+					},
+					"classoptions": {
+						"type": "array",
+						"items": {"type": "string"},
+						"description": "LaTeX document class options to add (e.g., [\"openany\"])"
+					},
+					"landscape": {
+						"type": "boolean",
+						"description": "Set this chapter's pages in landscape orientation (PDF only)"
+					},
+					"markdown": {
+						"type": "object",
+						"additionalProperties": {"type": "boolean"},
+						"description": "Pandoc markdown extensions to turn on or off, by name (e.g. {\"definition_lists\": true}), in exports that include this chapter. The whole export is read with one markdown flavor, so the document's markdown settings take precedence and the first chapter to set an extension wins."
+					}
+				},
+				"required": ["document_id", "chapter_number"]
+			}`),
+		},
+		{
+			Name:        "build_outline",
+			Description: "Scaffold a whole document in one call: add the chapters of an outline, each with its nested sections, to the end of the document instead of calling add_chapter and add_section once per item. Sections nested inside a section become its subsections (up to 6 levels). Sections given without content get a 'TODO: write <title>' placeholder to fill in later with update_section. The outline is checked before anything is created.",
+			InputSchema: json.RawMessage(`{
+				"type": "object",
+				"properties": {
+					"document_id": {
+						"type": "string",
+						"description": "Document ID returned from create_document (e.g., 'technical-manual-1234567890')"
+					},
+					"outline": {
+						"description": "The chapters to add, as an array or as JSON or YAML text of the same shape. Example: [{\"title\": \"Introduction\", \"sections\": [{\"title\": \"Background\", \"sections\": [{\"title\": \"History\", \"content\": \"Draft text.\"}]}]}]",
+						"oneOf": [
+							{
+								"type": "array",
+								"items": {
+									"type": "object",
+									"properties": {
+										"title": {"type": "string", "description": "Chapter title"},
+										"sections": {
+											"type": "array",
+											"description": "Sections of the chapter; each has a title, optional placeholder content and optional nested sections",
+											"items": {"type": "object"}
+										}
+									},
+									"required": ["title"]
+								}
+							},
+							{"type": "string"}
+						]
+					}
+				},
+				"required": ["document_id", "outline"]
+			}`),
+		},
+		{
+			Name:        "include_file",
+			Description: "Make a chapter or section include a markdown file kept outside the document, such as one checked out from another repository, instead of copying its text in. The file is read again whenever the chapter is built or exported, so changes made to it elsewhere are picked up. A chapter's included file comes right after the chapter heading; a section's replaces the section's own content. validate_document reports included files that have gone missing. Pass an empty path to stop including a file.",
+			InputSchema: json.RawMessage(`{
+				"type": "object",
+				"properties": {
+					"document_id": {
+						"type": "string",
+						"description": "Document ID returned from create_document (e.g., 'technical-manual-1234567890')"
+					},
+					"chapter_number": {
+						"type": "integer",
+						"description": "Chapter number (1-based, sequential). Use get_document_structure to see available chapter numbers.",
+						"minimum": 1
+					},
+					"section_number": {
+						"type": "string",
+						"description": "Section number (e.g., '1.1') to include the file in; omit to include it in the chapter (optional)"
+					},
+					"path": {
+						"type": "string",
+						"description": "Path of the markdown file, absolute or relative to the document directory. It must lie within the allowed directories. An empty path stops including a file."
+					}
+				},
+				"required": ["document_id", "chapter_number", "path"]
+			}`),
+		},
+		{
+			Name:        "delete_chapter",
+			Description: "Delete a chapter and all its content permanently. Automatically renumbers subsequent chapters (chapter 3 becomes 2, chapter 4 becomes 3, etc.). All sections, figures, and tables in the chapter are also deleted. Use only when user explicitly requests chapter deletion.",
+			InputSchema: json.RawMessage(`{
+				"type": "object",
+				"properties": {
+					"document_id": {
+						"type": "string",
+						"description": "Document ID returned from create_document (e.g., 'technical-manual-1234567890')"
+					},
+					"chapter_number": {
+						"type": "integer",
+						"description": "Chapter number to permanently delete (warning: this removes all chapter content)",
+						"minimum": 1
+					},
+					"confirmation_token": {
+						"type": "string",
+						"description": "Token returned by a first delete_chapter call when the server requires confirmation. Pass it back to actually delete."
+					}
+				},
+				"required": ["document_id", "chapter_number"]
+			}`),
+		},
+		{
+			Name:        "move_chapter",
+			Description: "Reorder chapters by moving a chapter from one position to another. Automatically renumbers all chapters and updates cross-references. For example, moving chapter 3 to position 1 makes it chapter 1, and chapters 1-2 become 2-3. Use get_document_structure first to see current chapter order.",
+			InputSchema: json.RawMessage(`{
+				"type": "object",
+				"properties": {
+					"document_id": {
+						"type": "string",
+						"description": "Document ID returned from create_document (e.g., 'technical-manual-1234567890')"
+					},
+					"from_number": {
+						"type": "integer",
+						"description": "Current chapter number",
+						"minimum": 1
+					},
+					"to_number": {
+						"type": "integer",
+						"description": "Target chapter number",
+						"minimum": 1
+					}
+				},
+				"required": ["document_id", "from_number", "to_number"]
+			}`),
+		},
+		{
+			Name:        "merge_chapters",
+			Description: "Merge one chapter into another: the source chapter's sections are appended to the target chapter and numbered after its own, its figures and tables are renumbered into the target's sequence, and the source chapter is removed. Later chapters are renumbered and figure and table references in all sections are updated. Returns the old-to-new section numbers and IDs.",
+			InputSchema: json.RawMessage(`{
+				"type": "object",
+				"properties": {
+					"document_id": {
+						"type": "string",
+						"description": "Document ID returned from create_document (e.g., 'technical-manual-1234567890')"
+					},
+					"target_chapter": {
+						"type": "integer",
+						"description": "Chapter that receives the content",
+						"minimum": 1
+					},
+					"source_chapter": {
+						"type": "integer",
+						"description": "Chapter whose content is appended to the target and which is then removed (default: the chapter after the target)",
+						"minimum": 1
+					}
+				},
+				"required": ["document_id", "target_chapter"]
+			}`),
+		},
+		{
+			Name:        "split_chapter",
+			Description: "Split a chapter at a top-level section: that section and every section after it move into a new chapter inserted directly after the original. Without section_number the chapter is split at the section that divides its words most evenly, which is useful when a draft chapter has grown too long. Figures and tables referenced only by the moved sections move with them. Later chapters are renumbered and figure and table references in all sections are updated. Returns the old-to-new section numbers and IDs.",
+			InputSchema: json.RawMessage(`{
+				"type": "object",
+				"properties": {
+					"document_id": {
+						"type": "string",
+						"description": "Document ID returned from create_document (e.g., 'technical-manual-1234567890')"
+					},
+					"chapter_number": {
+						"type": "integer",
+						"description": "Chapter to split",
+						"minimum": 1
+					},
+					"section_number": {
+						"type": "string",
+						"description": "Top-level section that starts the new chapter (e.g., '3.4'); it cannot be the chapter's first section (default: the section that splits the chapter's words most evenly)"
+					},
+					"title": {
+						"type": "string",
+						"description": "Title of the new chapter (default: the title of the section it starts with)"
+					}
+				},
+				"required": ["document_id", "chapter_number"]
+			}`),
+		},
+		{
+			Name:        "set_part",
+			Description: "Group a run of consecutive chapters into a named part (e.g., 'Part II: Methods'). Parts are numbered in chapter order and open with \\part{} in PDF and a part heading in DOCX and HTML. Setting a part that starts at the same chapter as an existing one replaces it; an empty title removes it. Parts may not overlap and follow their chapters when chapters are added, deleted, merged or split.",
+			InputSchema: json.RawMessage(`{
+				"type": "object",
+				"properties": {
+					"document_id": {
+						"type": "string",
+						"description": "Document ID returned from create_document (e.g., 'technical-manual-1234567890')"
+					},
+					"title": {
+						"type": "string",
+						"description": "Part title (e.g., 'Foundations'); empty removes the part starting at first_chapter"
+					},
+					"first_chapter": {
+						"type": "integer",
+						"description": "First chapter in the part",
+						"minimum": 1
+					},
+					"last_chapter": {
+						"type": "integer",
+						"description": "Last chapter in the part (default: first_chapter)",
+						"minimum": 1
+					}
+				},
+				"required": ["document_id", "first_chapter"]
+			}`),
+		},
+		{
+			Name:        "set_abbreviation",
+			Description: "Define an abbreviation or acronym for the document's abbreviations table (e.g., 'API' = 'Application Programming Interface'). Abbreviations written out in the text at first use, as in 'Application Programming Interface (API)', are found automatically and need no definition; a definition set here takes precedence. An empty definition removes the term.",
+			InputSchema: json.RawMessage(`{
+				"type": "object",
+				"properties": {
+					"document_id": {
+						"type": "string",
+						"description": "Document ID returned from create_document (e.g., 'technical-manual-1234567890')"
+					},
+					"term": {
+						"type": "string",
+						"description": "The abbreviation as it appears in the text (e.g., 'API')"
+					},
+					"definition": {
+						"type": "string",
+						"description": "What the abbreviation stands for; empty removes the definition"
+					}
+				},
+				"required": ["document_id", "term"]
+			}`),
+		},
+		{
+			Name:        "list_abbreviations",
+			Description: "List the document's abbreviations as the abbreviations table would show them, sorted by term, and the abbreviations used in the text that are never defined.",
+			InputSchema: json.RawMessage(`{
+				"type": "object",
+				"properties": {
+					"document_id": {
+						"type": "string",
+						"description": "Document ID returned from create_document (e.g., 'technical-manual-1234567890')"
+					}
+				},
+				"required": ["document_id"]
+			}`),
+		},
+		{
+			Name:        "add_section",
+			Description: "Add actual content to a chapter by creating a section. This is where you put the real text, paragraphs, lists, and formatting. Sections are automatically numbered (1.1, 1.2, 2.1, etc.). The chapter must exist first - use add_chapter if needed. Supports full markdown formatting. The markdown is checked for unclosed code fences, malformed tables, heading level jumps and images with missing paths; problems come back as markdown_warnings, or reject the content when the server requires strict markdown.",
+			InputSchema: json.RawMessage(`{
+				"type": "object",
+				"properties": {
+					"document_id": {
+						"type": "string",
+						"description": "Document ID returned from create_document (e.g., 'technical-manual-1234567890')"
+					},
+					"chapter_number": {
+						"type": "integer",
+						"description": "Chapter number (1-based, sequential). Use get_document_structure to see available chapter numbers.",
+						"minimum": 1
+					},
+					"title": {
+						"type": "string",
+						"description": "Section title"
+					},
+					"content": {
+						"type": "string",
+						"description": "Section content in markdown format. Supports headings, paragraphs, lists, code blocks, emphasis, links, etc."
+					},
+					"level": {
+						"type": "integer",
+						"description": "Section hierarchy level: 1=main section (1.1), 2=subsection (1.1.1), 3=sub-subsection (1.1.1.1), etc.",
+						"minimum": 1,
+						"maximum": 6,
+						"default": 1
+					}
+				},
+				"required": ["document_id", "chapter_number", "title", "content"]
+			}`),
+		},
+		{
+			Name:        "add_sections",
+			Description: "Add several sections to the end of a chapter in one call, in the order given, instead of calling add_section once per section. Each section is numbered after the ones before it, so a level 2 section following a level 1 section becomes its subsection (1.3, 1.3.1). Every section is checked before any is added; if one has a problem, none are added. The markdown of each section is checked like add_section's, with problems returned as markdown_warnings by section number.",
+			InputSchema: json.RawMessage(`{
+				"type": "object",
+				"properties": {
+					"document_id": {
+						"type": "string",
+						"description": "Document ID returned from create_document (e.g., 'technical-manual-1234567890')"
+					},
+					"chapter_number": {
+						"type": "integer",
+						"description": "Chapter number (1-based, sequential). Use get_document_structure to see available chapter numbers.",
+						"minimum": 1
+					},
+					"sections": {
+						"type": "array",
+						"description": "The sections to add, in order",
+						"minItems": 1,
+						"items": {
+							"type": "object",
+							"properties": {
+								"title": {"type": "string", "description": "Section title"},
+								"content": {"type": "string", "description": "Section content in markdown format"},
+								"level": {
+									"type": "integer",
+									"description": "Section hierarchy level: 1=main section (1.1), 2=subsection (1.1.1), etc.",
+									"minimum": 1,
+									"maximum": 6,
+									"default": 1
+								}
+							},
+							"required": ["title", "content"]
+						}
+					}
+				},
+				"required": ["document_id", "chapter_number", "sections"]
+			}`),
+		},
+		{
+			Name:        "update_section",
+			Description: "Modify the content of an existing section within a chapter. Use this to edit, revise, or replace section text while preserving the document structure. Find the section number using get_document_structure or get_chapter first. The new content is checked like add_section's, with problems returned as markdown_warnings.",
+			InputSchema: json.RawMessage(`{
+				"type": "object",
+				"properties": {
+					"document_id": {
+						"type": "string",
+						"description": "Document ID returned from create_document (e.g., 'technical-manual-1234567890')"
+					},
+					"chapter_number": {
+						"type": "integer",
+						"description": "Chapter number (1-based, sequential). Use get_document_structure to see available chapter numbers.",
+						"minimum": 1
+					},
+					"section_number": {
+						"type": "string",
+						"description": "Section number (e.g., '1.1', '1.2.1')"
+					},
+					"content": {
+						"type": "string",
+						"description": "New section content"
+					}
+				},
+				"required": ["document_id", "chapter_number", "section_number", "content"]
+			}`),
+		},
+		{
+			Name:        "append_to_section",
+			Description: "Add content to the end of an existing section without re-sending the rest of it. Prefer this over update_section to extend a long section paragraph by paragraph: the existing text is left exactly as it is.",
+			InputSchema: json.RawMessage(`{
+				"type": "object",
+				"properties": {
+					"document_id": {
+						"type": "string",
+						"description": "Document ID returned from create_document (e.g., 'technical-manual-1234567890')"
+					},
+					"chapter_number": {
+						"type": "integer",
+						"description": "Chapter number (1-based, sequential). Use get_document_structure to see available chapter numbers.",
+						"minimum": 1
+					},
+					"section_number": {
+						"type": "string",
+						"description": "Section number (e.g., '1.1', '1.2.1')"
+					},
+					"content": {
+						"type": "string",
+						"description": "Markdown to add as one or more paragraphs of their own"
+					}
+				},
+				"required": ["document_id", "chapter_number", "section_number", "content"]
+			}`),
+		},
+		{
+			Name:        "insert_into_section",
+			Description: "Insert content into an existing section after one of its paragraphs, without re-sending the rest of it. Give after_paragraph (1 for after the first paragraph, 0 for the start of the section) or after_text, a piece of text that appears in exactly one paragraph. Paragraphs are separated by blank lines; a fenced code block counts as one paragraph.",
+			InputSchema: json.RawMessage(`{
+				"type": "object",
+				"properties": {
+					"document_id": {
+						"type": "string",
+						"description": "Document ID returned from create_document (e.g., 'technical-manual-1234567890')"
+					},
+					"chapter_number": {
+						"type": "integer",
+						"description": "Chapter number (1-based, sequential). Use get_document_structure to see available chapter numbers.",
+						"minimum": 1
+					},
+					"section_number": {
+						"type": "string",
+						"description": "Section number (e.g., '1.1', '1.2.1')"
+					},
+					"content": {
+						"type": "string",
+						"description": "Markdown to add as one or more paragraphs of their own"
+					},
+					"after_paragraph": {
+						"type": "integer",
+						"minimum": 0,
+						"description": "Number of the paragraph to insert after, counting from 1; 0 inserts at the start"
+					},
+					"after_text": {
+						"type": "string",
+						"description": "Text from the paragraph to insert after; it must appear in only one paragraph"
+					}
+				},
+				"required": ["document_id", "chapter_number", "section_number", "content"]
+			}`),
+		},
+		{
+			Name:        "insert_page_break",
+			Description: "Start a new page in PDF and DOCX exports at a point in a section, by adding a \\pagebreak line after one of its paragraphs, or at its end when no position is given. HTML and EPUB exports ignore page breaks. To keep a table or a few paragraphs on one page, wrap them in a ::: {.keep-together} div instead.",
+			InputSchema: json.RawMessage(`{
+				"type": "object",
+				"properties": {
+					"document_id": {
+						"type": "string",
+						"description": "Document ID returned from create_document (e.g., 'technical-manual-1234567890')"
+					},
+					"chapter_number": {
+						"type": "integer",
+						"description": "Chapter number (1-based, sequential). Use get_document_structure to see available chapter numbers.",
+						"minimum": 1
+					},
+					"section_number": {
+						"type": "string",
+						"description": "Section number (e.g., '1.1', '1.2.1')"
+					},
+					"after_paragraph": {
+						"type": "integer",
+						"minimum": 0,
+						"description": "Number of the paragraph to break after, counting from 1; 0 breaks before the first. Defaults to the end of the section."
+					},
+					"after_text": {
+						"type": "string",
+						"description": "Text from the paragraph to break after; it must appear in only one paragraph"
+					}
+				},
+				"required": ["document_id", "chapter_number", "section_number"]
+			}`),
+		},
+		{
+			Name:        "get_section_blocks",
+			Description: "Get a section's content split into numbered blocks: paragraphs, headings, lists, tables, quotes, figures and code fences, as separated by blank lines. Use the block numbers with update_block, insert_block and delete_block to change a small part of a section without re-sending all of it.",
+			InputSchema: json.RawMessage(`{
+				"type": "object",
+				"properties": {
+					"document_id": {
+						"type": "string",
+						"description": "Document ID returned from create_document (e.g., 'technical-manual-1234567890')"
+					},
+					"chapter_number": {
+						"type": "integer",
+						"description": "Chapter number (1-based, sequential). Use get_document_structure to see available chapter numbers.",
+						"minimum": 1
+					},
+					"section_number": {
+						"type": "string",
+						"description": "Section number (e.g., '1.1', '1.2.1')"
+					}
+				},
+				"required": ["document_id", "chapter_number", "section_number"]
+			}`),
+		},
+		{
+			Name:        "update_block",
+			Description: "Replace one block of a section, numbered as get_section_blocks returns them, with new content. The content may hold several blocks. Block numbers change as blocks are inserted and deleted, so call get_section_blocks again after structural edits, or pass expected_text.",
+			InputSchema: json.RawMessage(`{
+				"type": "object",
+				"properties": {
+					"document_id": {
+						"type": "string",
+						"description": "Document ID returned from create_document (e.g., 'technical-manual-1234567890')"
+					},
+					"chapter_number": {
+						"type": "integer",
+						"description": "Chapter number (1-based, sequential). Use get_document_structure to see available chapter numbers.",
+						"minimum": 1
+					},
+					"section_number": {
+						"type": "string",
+						"description": "Section number (e.g., '1.1', '1.2.1')"
+					},
+					"block_number": {
+						"type": "integer",
+						"minimum": 1,
+						"description": "Number of the block to replace, counting from 1"
+					},
+					"content": {
+						"type": "string",
+						"description": "Markdown to put in place of the block"
+					},
+					"expected_text": {
+						"type": "string",
+						"description": "Text the block must contain; the edit is refused if it doesn't, so it never lands on a block that has moved"
+					}
+				},
+				"required": ["document_id", "chapter_number", "section_number", "block_number", "content"]
+			}`),
+		},
+		{
+			Name:        "insert_block",
+			Description: "Insert content as a new block of a section after block after_block, numbered as get_section_blocks returns them. An after_block of 0 inserts at the start of the section.",
+			InputSchema: json.RawMessage(`{
+				"type": "object",
+				"properties": {
+					"document_id": {
+						"type": "string",
+						"description": "Document ID returned from create_document (e.g., 'technical-manual-1234567890')"
+					},
+					"chapter_number": {
+						"type": "integer",
+						"description": "Chapter number (1-based, sequential). Use get_document_structure to see available chapter numbers.",
+						"minimum": 1
+					},
+					"section_number": {
+						"type": "string",
+						"description": "Section number (e.g., '1.1', '1.2.1')"
+					},
+					"after_block": {
+						"type": "integer",
+						"minimum": 0,
+						"description": "Number of the block to insert after, counting from 1; 0 inserts at the start"
+					},
+					"content": {
+						"type": "string",
+						"description": "Markdown to add as one or more blocks of their own"
+					}
+				},
+				"required": ["document_id", "chapter_number", "section_number", "after_block", "content"]
+			}`),
+		},
+		{
+			Name:        "delete_block",
+			Description: "Delete one block of a section, numbered as get_section_blocks returns them. The blocks after it move up by one. A section's only block can't be deleted; use delete_section to remove the section.",
+			InputSchema: json.RawMessage(`{
+				"type": "object",
+				"properties": {
+					"document_id": {
+						"type": "string",
+						"description": "Document ID returned from create_document (e.g., 'technical-manual-1234567890')"
+					},
+					"chapter_number": {
+						"type": "integer",
+						"description": "Chapter number (1-based, sequential). Use get_document_structure to see available chapter numbers.",
+						"minimum": 1
+					},
+					"section_number": {
+						"type": "string",
+						"description": "Section number (e.g., '1.1', '1.2.1')"
+					},
+					"block_number": {
+						"type": "integer",
+						"minimum": 1,
+						"description": "Number of the block to delete, counting from 1"
+					},
+					"expected_text": {
+						"type": "string",
+						"description": "Text the block must contain; the edit is refused if it doesn't, so it never lands on a block that has moved"
+					}
+				},
+				"required": ["document_id", "chapter_number", "section_number", "block_number"]
+			}`),
+		},
+		{
+			Name:        "delete_section",
+			Description: "Permanently remove a section from a chapter and automatically renumber subsequent sections. This removes the section content and adjusts section numbering (1.2 becomes 1.1, 1.3 becomes 1.2, etc.). Use only when user explicitly requests section deletion.",
+			InputSchema: json.RawMessage(`{
+				"type": "object",
+				"properties": {
+					"document_id": {
+						"type": "string",
+						"description": "Document ID returned from create_document (e.g., 'technical-manual-1234567890')"
+					},
+					"chapter_number": {
+						"type": "integer",
+						"description": "Chapter number (1-based, sequential). Use get_document_structure to see available chapter numbers.",
+						"minimum": 1
+					},
+					"section_number": {
+						"type": "string",
+						"description": "Section number to delete (e.g., '1.1', '1.2.1')"
+					}
+				},
+				"required": ["document_id", "chapter_number", "section_number"]
+			}`),
+		},
+		{
+			Name:        "insert_citation",
+			Description: "Cite a bibliography entry in a section, writing pandoc's citation syntax for you ([@key, p. 12], [see @key], @key [p. 12]). The key is checked against the document's bibliography (set with configure_document's pandoc_options.bibliography), so use this instead of typing citation markup by hand. The citation is placed after after_text, which must appear exactly once in the section, or at the end of the section; at the end of a sentence it goes before the full stop.",
+			InputSchema: json.RawMessage(`{
+				"type": "object",
+				"properties": {
+					"document_id": {
+						"type": "string",
+						"description": "Document ID returned from create_document (e.g., 'technical-manual-1234567890')"
+					},
+					"chapter_number": {
+						"type": "integer",
+						"description": "Chapter number (1-based, sequential). Use get_document_structure to see available chapter numbers.",
+						"minimum": 1
+					},
+					"section_number": {
+						"type": "string",
+						"description": "Section number (e.g., '1.1', '1.2.1')"
+					},
+					"key": {
+						"type": "string",
+						"description": "Bibliography key of the cited work (e.g., 'knuth84')"
+					},
+					"locator": {
+						"type": "string",
+						"description": "Where in the work (e.g., 'p. 12', 'pp. 33-35', 'chap. 3')"
+					},
+					"prefix": {
+						"type": "string",
+						"description": "Text before the citation inside the brackets (e.g., 'see', 'cf.')"
+					},
+					"author_in_text": {
+						"type": "boolean",
+						"description": "Name the author as part of the sentence: @key [p. 12] renders as Knuth (1984, 12)"
+					},
+					"suppress_author": {
+						"type": "boolean",
+						"description": "Leave out the author when the sentence already names them: [-@key]"
+					},
+					"after_text": {
+						"type": "string",
+						"description": "Existing text of the section to place the citation after. Must appear exactly once. If omitted, the citation goes at the end of the section."
+					}
+				},
+				"required": ["document_id", "chapter_number", "section_number", "key"]
+			}`),
+		},
+		{
+			Name:        "get_section_content",
+			Description: "Get the content of one or more sections. This is useful for progressively loading section content as needed. Can retrieve a single section or multiple sections at once.",
+			InputSchema: json.RawMessage(`{
+				"type": "object",
+				"properties": {
+					"document_id": {
+						"type": "string",
+						"description": "Document ID returned from create_document"
+					},
+					"sections": {
+						"type": "array",
+						"description": "Array of sections to retrieve",
+						"items": {
+							"type": "object",
+							"properties": {
+								"chapter_number": {
+									"type": "integer",
+									"description": "Chapter number",
+									"minimum": 1
+								},
+								"section_number": {
+									"type": "string",
+									"description": "Section number (e.g., '1.1', '1.2.1')"
+								}
+							},
+							"required": ["chapter_number", "section_number"]
+						},
+						"minItems": 1
+					}
+				},
+				"required": ["document_id", "sections"]
+			}`),
+		},
+		{
+			Name:        "get_chapter_content",
+			Description: "Get a chapter's compiled content as markdown, or rendered as HTML for review. HTML is rendered with pandoc; on machines without pandoc a built-in renderer is used and the result is marked approximate, since citations, cross-references, math and pandoc-specific markdown are not rendered as in exports.",
+			InputSchema: json.RawMessage(`{
+				"type": "object",
+				"properties": {
+					"document_id": {
+						"type": "string",
+						"description": "Document ID returned from create_document"
+					},
+					"chapter_number": {
+						"type": "integer",
+						"description": "Chapter number (1-based, sequential). Use get_document_structure to see available chapter numbers.",
+						"minimum": 1
+					},
+					"format": {
+						"type": "string",
+						"enum": ["markdown", "html"],
+						"description": "Content format (default: markdown)"
+					}
+				},
+				"required": ["document_id", "chapter_number"]
+			}`),
+		},
+		{
+			Name:        "snapshot_chapter",
+			Description: "Save a chapter's compiled content as a numbered revision, to compare later edits against with preview_chapter_diff; take one before handing a chapter over for revision. A chapter unchanged since its latest revision keeps it instead of taking another. The last 20 revisions of each chapter are kept.",
+			InputSchema: json.RawMessage(`{
+				"type": "object",
+				"properties": {
+					"document_id": {
+						"type": "string",
+						"description": "Document ID returned from create_document"
+					},
+					"chapter_number": {
+						"type": "integer",
+						"description": "Chapter number (1-based, sequential). Use get_document_structure to see available chapter numbers.",
+						"minimum": 1
+					},
+					"label": {
+						"type": "string",
+						"description": "Optional note on the revision, such as 'before copy edit'"
+					}
+				},
+				"required": ["document_id", "chapter_number"]
+			}`),
+		},
+		{
+			Name:        "list_chapter_revisions",
+			Description: "List a chapter's revisions taken with snapshot_chapter, newest first: ID, label, time and word count.",
+			InputSchema: json.RawMessage(`{
+				"type": "object",
+				"properties": {
+					"document_id": {
+						"type": "string",
+						"description": "Document ID returned from create_document"
+					},
+					"chapter_number": {
+						"type": "integer",
+						"description": "Chapter number (1-based, sequential). Use get_document_structure to see available chapter numbers.",
+						"minimum": 1
+					}
+				},
+				"required": ["document_id", "chapter_number"]
+			}`),
+		},
+		{
+			Name:        "preview_chapter",
+			Description: "Render a single chapter as a standalone HTML page or PDF for review, the way exports render it: citations are resolved against the document's bibliography with a reference list after the chapter, footnotes are set, and the document's style is applied. Returns the path of the preview, written to the exports directory.",
+			InputSchema: json.RawMessage(`{
+				"type": "object",
+				"properties": {
+					"document_id": {
+						"type": "string",
+						"description": "Document ID returned from create_document"
+					},
+					"chapter_number": {
+						"type": "integer",
+						"description": "Chapter number (1-based, sequential). Use get_document_structure to see available chapter numbers.",
+						"minimum": 1
+					},
+					"format": {
+						"type": "string",
+						"enum": ["html", "pdf"],
+						"description": "Preview format (default: html)"
+					},
+					"style_name": {
+						"type": "string",
+						"description": "Style to preview with, as for export_document (optional)"
+					},
+					"unstyled": {
+						"type": "boolean",
+						"description": "Preview without any style, with pandoc's defaults (default: false)"
+					}
+				},
+				"required": ["document_id", "chapter_number"]
+			}`),
+		},
+		{
+			Name:        "preview_chapter_diff",
+			Description: "Render a chapter's current content and one of its revisions to HTML and write an HTML page showing the paragraphs, headings, lists and tables added and removed since, for reviewing edits before accepting them. Inline diffs mark the changes in one column; side-by-side diffs show the revision next to the current content. Rendered with pandoc, or approximately with a built-in renderer on machines without it.",
+			InputSchema: json.RawMessage(`{
+				"type": "object",
+				"properties": {
+					"document_id": {
+						"type": "string",
+						"description": "Document ID returned from create_document"
+					},
+					"chapter_number": {
+						"type": "integer",
+						"description": "Chapter number (1-based, sequential). Use get_document_structure to see available chapter numbers.",
+						"minimum": 1
+					},
+					"revision": {
+						"type": "integer",
+						"description": "Revision ID from snapshot_chapter or list_chapter_revisions (default: the latest)",
+						"minimum": 1
+					},
+					"mode": {
+						"type": "string",
+						"enum": ["inline", "side-by-side"],
+						"description": "Diff layout (default: inline)"
+					}
+				},
+				"required": ["document_id", "chapter_number"]
+			}`),
+		},
+		{
+			Name:        "add_content",
+			Description: "Add a section from pasted content that mixes markdown with images, the way chat clients hand over text with screenshots. Reference attached images inline as ![alt](attachment:name) or embed data URIs directly; each image is saved to the document assets, registered as a numbered figure, and its reference is rewritten to the stored file.",
+			InputSchema: json.RawMessage(`{
+				"type": "object",
+				"properties": {
+					"document_id": {
+						"type": "string",
+						"description": "Document ID returned from create_document (e.g., 'technical-manual-1234567890')"
+					},
+					"chapter_number": {
+						"type": "integer",
+						"description": "Chapter number (1-based, sequential). Use get_document_structure to see available chapter numbers.",
+						"minimum": 1
+					},
+					"title": {
+						"type": "string",
+						"description": "Section title"
+					},
+					"content": {
+						"type": "string",
+						"description": "Section content in markdown. Reference images as ![alt](attachment:name) or ![alt](data:image/png;base64,...)"
+					},
+					"level": {
+						"type": "integer",
+						"description": "Section hierarchy level: 1=main section (1.1), 2=subsection (1.1.1), etc.",
+						"minimum": 1,
+						"maximum": 6,
+						"default": 1
+					},
+					"images": {
+						"type": "array",
+						"description": "Images referenced from content via attachment:name",
+						"items": {
+							"type": "object",
+							"properties": {
+								"name": {"type": "string", "description": "Attachment name used in the content reference"},
+								"data": {"type": "string", "description": "Base64 encoded image data"},
+								"mime_type": {"type": "string", "description": "Image MIME type (e.g., 'image/png')"},
+								"caption": {"type": "string", "description": "Figure caption (defaults to the alt text)"}
+							},
+							"required": ["name", "data"]
+						}
+					}
+				},
+				"required": ["document_id", "chapter_number", "title", "content"]
+			}`),
+		},
+		{
+			Name:        "save_section_template",
+			Description: "Save a reusable section template (e.g., 'Executive Summary', 'Methodology') with {{variable}} placeholders in the title and content. Templates are shared across all documents. Saving with an existing name replaces that template. Returns the variables the template expects.",
+			InputSchema: json.RawMessage(`{
+				"type": "object",
+				"properties": {
+					"name": {
+						"type": "string",
+						"description": "Template name (letters, numbers, hyphens, underscores), e.g. 'executive-summary'"
+					},
+					"title": {
+						"type": "string",
+						"description": "Section title, may contain placeholders, e.g. 'Executive Summary: {{project}}'"
+					},
+					"content": {
+						"type": "string",
+						"description": "Markdown content with {{variable}} placeholders"
+					},
+					"description": {
+						"type": "string",
+						"description": "What the template is for (optional)"
+					},
+					"level": {
+						"type": "integer",
+						"description": "Default section level (1-6, default: 1)",
+						"minimum": 1,
+						"maximum": 6
+					}
+				},
+				"required": ["name", "title", "content"]
+			}`),
+		},
+		{
+			Name:        "apply_section_template",
+			Description: "Create a new section in a chapter from a saved section template, replacing each {{variable}} placeholder with the supplied value. All variables used by the template must be provided.",
+			InputSchema: json.RawMessage(`{
+				"type": "object",
+				"properties": {
+					"document_id": {
+						"type": "string",
+						"description": "Document ID returned from create_document (e.g., 'technical-manual-1234567890')"
+					},
+					"chapter_number": {
+						"type": "integer",
+						"description": "Chapter number to add the section to",
+						"minimum": 1
+					},
+					"template_name": {
+						"type": "string",
+						"description": "Name of the saved section template"
+					},
+					"values": {
+						"type": "object",
+						"additionalProperties": {"type": "string"},
+						"description": "Values for the template variables, e.g. {\"project\": \"Apollo\"}"
+					},
+					"level": {
+						"type": "integer",
+						"description": "Section level (1-6). Defaults to the template's level.",
+						"minimum": 1,
+						"maximum": 6
+					}
+				},
+				"required": ["document_id", "chapter_number", "template_name"]
+			}`),
+		},
+		{
+			Name:        "add_image",
+			Description: "Add an image/figure to a chapter with automatic numbering. The figure gets a stable ID (like 'fig-3f9a2c7d') that never changes; exports number it within its chapter (Figure 1.2), and the display number fig-1.2 also names it in other tools. Refer to it in content as @fig-3f9a2c7d. Supports positioning, sizing, and alignment options. The image file must exist at the specified path.",
+			InputSchema: json.RawMessage(`{
+				"type": "object",
+				"properties": {
+					"document_id": {
+						"type": "string",
+						"description": "Document ID returned from create_document (e.g., 'technical-manual-1234567890')"
+					},
+					"chapter_number": {
+						"type": "integer",
+						"description": "Chapter number (1-based, sequential). Use get_document_structure to see available chapter numbers.",
+						"minimum": 1
+					},
+					"image_path": {
+						"type": "string",
+						"description": "Path to the image file, relative to the document directory or absolute within the allowed directories (DOCGEN_ROOT_DIR by default)"
+					},
+					"caption": {
+						"type": "string",
+						"description": "Image caption (optional). If omitted, a placeholder caption is used and flagged as a TODO until update_image_caption is called."
+					},
+					"position": {
+						"type": "string",
+						"enum": ["here", "top", "bottom", "page", "float"],
+						"description": "Image position (default: here)",
+						"default": "here"
+					},
+					"width": {
+						"type": "string",
+						"description": "Image width: a share of the text width (e.g., '50%') or a length (e.g., '10cm'). Omit to keep the image's natural size."
+					},
+					"alignment": {
+						"type": "string",
+						"enum": ["left", "center", "right"],
+						"description": "Image alignment in PDF and HTML exports (default: center)",
+						"default": "center"
+					}
+				},
+				"required": ["document_id", "chapter_number", "image_path"]
+			}`),
+		},
+		{
+			Name:        "add_figure_grid",
+			Description: "Add a composite figure to a chapter: several images laid out in a grid (2x2 by default), each with a sub-caption labeled (a), (b), (c), ..., under one numbered caption. PDF exports use LaTeX subfigures and HTML exports a CSS grid. Returns the figure ID and the markup to put in a section where the figure belongs.",
+			InputSchema: json.RawMessage(`{
+				"type": "object",
+				"properties": {
+					"document_id": {
+						"type": "string",
+						"description": "Document ID returned from create_document (e.g., 'technical-manual-1234567890')"
+					},
+					"chapter_number": {
+						"type": "integer",
+						"description": "Chapter number to add the figure to"
+					},
+					"caption": {
+						"type": "string",
+						"description": "Caption of the whole figure"
+					},
+					"images": {
+						"type": "array",
+						"minItems": 2,
+						"maxItems": 12,
+						"description": "Sub-figures in reading order, filling the grid row by row",
+						"items": {
+							"type": "object",
+							"properties": {
+								"image_path": {"type": "string", "description": "Image path, relative to the document (e.g., 'assets/images/wild-type.png')"},
+								"caption": {"type": "string", "description": "Sub-caption, labeled (a), (b), ... in order"}
+							},
+							"required": ["image_path"]
+						}
+					},
+					"columns": {
+						"type": "integer",
+						"minimum": 1,
+						"maximum": 4,
+						"description": "Images per row (default: 2)"
+					},
+					"position": {
+						"type": "string",
+						"enum": ["here", "top", "bottom", "page", "float"],
+						"description": "Figure placement (default: here)"
+					}
+				},
+				"required": ["document_id", "chapter_number", "caption", "images"]
+			}`),
+		},
+		{
+			Name:        "add_listing",
+			Description: "Add a numbered, captioned code listing (Listing 1.2) to a chapter. Returns the listing ID and a fenced code block carrying it ({#lst-1.2 .python}) to put in a section where the listing belongs; the code can be edited there like any other content. Refer to the listing as @lst-1.2 for a linked 'Listing 1.2'. PDF exports use the LaTeX listings package, HTML a figure with a figcaption and DOCX a Listing Caption paragraph; export_document's list_of_listings adds a list of them.",
+			InputSchema: json.RawMessage(`{
+				"type": "object",
+				"properties": {
+					"document_id": {
+						"type": "string",
+						"description": "Document ID returned from create_document (e.g., 'technical-manual-1234567890')"
+					},
+					"chapter_number": {
+						"type": "integer",
+						"description": "Chapter number to add the listing to"
+					},
+					"caption": {
+						"type": "string",
+						"description": "Caption of the listing"
+					},
+					"language": {
+						"type": "string",
+						"description": "Language the code is highlighted as (e.g., 'python', 'go', 'bash')"
+					},
+					"code": {
+						"type": "string",
+						"description": "Code to put in the returned block (default: an empty block to fill in)"
+					}
+				},
+				"required": ["document_id", "chapter_number", "caption"]
+			}`),
+		},
+		{
+			Name:        "update_image_caption",
+			Description: "Change the caption text of an existing figure while preserving the image and its position. Use the figure_id (like 'fig-3f9a2c7d', or its display number 'fig-1.1') to identify which image to update. Find figure IDs using get_document_structure or get_chapter.",
+			InputSchema: json.RawMessage(`{
+				"type": "object",
+				"properties": {
+					"document_id": {
+						"type": "string",
+						"description": "Document ID returned from create_document (e.g., 'technical-manual-1234567890')"
+					},
+					"figure_id": {
+						"type": "string",
+						"description": "Figure ID or display number (e.g., 'fig-3f9a2c7d' or 'fig-1.1')"
+					},
+					"new_caption": {
+						"type": "string",
+						"description": "New caption text"
+					}
+				},
+				"required": ["document_id", "figure_id", "new_caption"]
+			}`),
+		},
+		{
+			Name:        "update_image_properties",
+			Description: "Change the width, alignment or position of an existing figure; properties left out are kept. Width sets the image size in PDF, HTML and DOCX exports, alignment places it left, centered or right in PDF and HTML, and position is the LaTeX float placement in PDF. Only the position of a figure grid can be changed.",
+			InputSchema: json.RawMessage(`{
+				"type": "object",
+				"properties": {
+					"document_id": {
+						"type": "string",
+						"description": "Document ID returned from create_document (e.g., 'technical-manual-1234567890')"
+					},
+					"figure_id": {
+						"type": "string",
+						"description": "Figure ID or display number (e.g., 'fig-3f9a2c7d' or 'fig-1.1')"
+					},
+					"width": {
+						"type": "string",
+						"description": "Image width: a share of the text width (e.g., '50%') or a length (e.g., '10cm'); 'auto' returns to the image's natural size"
+					},
+					"alignment": {
+						"type": "string",
+						"enum": ["left", "center", "right"],
+						"description": "Image alignment"
+					},
+					"position": {
+						"type": "string",
+						"enum": ["here", "top", "bottom", "page", "float"],
+						"description": "Image position"
+					}
+				},
+				"required": ["document_id", "figure_id"]
+			}`),
+		},
+		{
+			Name:        "set_figure_anchor",
+			Description: "Anchor a figure or table to a place in its chapter's text, so it lands there in every export without editing the section content: after a given paragraph of a section, or at its start. Give only id to clear the anchor. A figure or table the content already places with its {#id} anchor stays where the content puts it. Anchors follow their sections when chapters are merged or split and sections deleted.",
+			InputSchema: json.RawMessage(`{
+				"type": "object",
+				"properties": {
+					"document_id": {
+						"type": "string",
+						"description": "Document ID returned from create_document (e.g., 'technical-manual-1234567890')"
+					},
+					"id": {
+						"type": "string",
+						"description": "Figure or table ID (e.g., 'fig-3f9a2c7d', 'fig-1.2' or 'table-1.1')"
+					},
+					"section_number": {
+						"type": "string",
+						"description": "Section of the figure's chapter to anchor it in (e.g., '1.2'); leave out to clear the anchor"
+					},
+					"after_paragraph": {
+						"type": "integer",
+						"description": "Paragraph of the section to place it after, counting from 1; 0 places it at the start of the section. Past the last paragraph places it at the end.",
+						"minimum": 0
+					}
+				},
+				"required": ["document_id", "id"]
+			}`),
+		},
+		{
+			Name:        "create_table_from_csv",
+			Description: "Create a numbered, captioned table (Table 1.2) from CSV or TSV data instead of writing a markdown table by hand. The first row is the header. The table is anchored after a paragraph of a section, like set_figure_anchor, so the section content stays free of table markup; change its cells later with update_table_data and refer to it as @table-1.2.",
+			InputSchema: json.RawMessage(`{
+				"type": "object",
+				"properties": {
+					"document_id": {
+						"type": "string",
+						"description": "Document ID returned from create_document (e.g., 'technical-manual-1234567890')"
+					},
+					"section_number": {
+						"type": "string",
+						"description": "Section to place the table in (e.g., '1.2'); the table belongs to the section's chapter"
+					},
+					"caption": {
+						"type": "string",
+						"description": "Caption of the table"
+					},
+					"data": {
+						"type": "string",
+						"description": "CSV or TSV text, header row first; quote cells holding the delimiter"
+					},
+					"delimiter": {
+						"type": "string",
+						"description": "Cell delimiter: a single character such as ',' or ';', or 'tab' (default: detected from the first line)"
+					},
+					"format": {
+						"type": "string",
+						"enum": ["markdown", "grid"],
+						"description": "Table markup: 'markdown' pipe tables keep cells on one line, 'grid' tables allow cells with several lines (default: markdown)"
+					},
+					"alignments": {
+						"type": "array",
+						"items": {"type": "string", "enum": ["left", "right", "center", "default"]},
+						"description": "Alignment of each column, in order"
+					},
+					"after_paragraph": {
+						"type": "integer",
+						"description": "Paragraph of the section to place the table after, counting from 1; 0 places it at the start of the section (default: after the last paragraph)",
+						"minimum": 0
+					}
+				},
+				"required": ["document_id", "section_number", "caption", "data"]
+			}`),
+		},
+		{
+			Name:        "update_table_data",
+			Description: "Change the cells of a table made with create_table_from_csv without rewriting it: add columns and rows, then set cell values by row and column. Row 0 is the header; rows and columns count from 1. The table's markdown is rewritten and its chapter rebuilt.",
+			InputSchema: json.RawMessage(`{
+				"type": "object",
+				"properties": {
+					"document_id": {
+						"type": "string",
+						"description": "Document ID returned from create_document (e.g., 'technical-manual-1234567890')"
+					},
+					"table_id": {
+						"type": "string",
+						"description": "Table ID (e.g., 'table-1.2')"
+					},
+					"cells": {
+						"type": "array",
+						"items": {
+							"type": "object",
+							"properties": {
+								"row": {"type": "integer", "minimum": 0, "description": "Row, 0 for the header"},
+								"column": {"type": "integer", "minimum": 1, "description": "Column, counting from 1"},
+								"value": {"type": "string", "description": "New cell value"}
+							},
+							"required": ["row", "column", "value"]
+						},
+						"description": "Cell values to set"
+					},
+					"add_rows": {
+						"type": "array",
+						"items": {"type": "array", "items": {"type": "string"}},
+						"description": "Rows to append, each a list of cell values; short rows are padded with empty cells"
+					},
+					"add_columns": {
+						"type": "array",
+						"items": {
+							"type": "object",
+							"properties": {
+								"header": {"type": "string", "description": "Header of the column"},
+								"values": {"type": "array", "items": {"type": "string"}, "description": "Values for the existing rows, in order; missing values are left empty"}
+							},
+							"required": ["header"]
+						},
+						"description": "Columns to append"
+					}
+				},
+				"required": ["document_id", "table_id"]
+			}`),
+		},
+		{
+			Name:        "delete_image",
+			Description: "Permanently remove an image/figure from a chapter and automatically renumber remaining figures (Figure 1.2 becomes Figure 1.1, etc.). Stable figure IDs don't change; figures still identified by their numbers are renamed (fig-1.2 becomes fig-1.1). This removes both the image reference and its caption. Use only when user explicitly requests image deletion.",
+			InputSchema: json.RawMessage(`{
+				"type": "object",
+				"properties": {
+					"document_id": {
+						"type": "string",
+						"description": "Document ID returned from create_document (e.g., 'technical-manual-1234567890')"
+					},
+					"figure_id": {
+						"type": "string",
+						"description": "Figure ID or display number to delete (e.g., 'fig-3f9a2c7d' or 'fig-1.1')"
+					}
+				},
+				"required": ["document_id", "figure_id"]
+			}`),
+		},
+		{
+			Name:        "annotate_image",
+			Description: "Draw callouts on an image in the document's assets - arrows, boxes and numbered step markers, as used to document software screens - and save the result as a new PNG alongside it. The original image is not changed. Returns the new image's path; pass it to add_image to use it as a figure.",
+			InputSchema: json.RawMessage(`{
+				"type": "object",
+				"properties": {
+					"document_id": {
+						"type": "string",
+						"description": "Document ID returned from create_document (e.g., 'technical-manual-1234567890')"
+					},
+					"image": {
+						"type": "string",
+						"description": "PNG, JPEG or GIF file name in assets/images (e.g., 'fig-1.1.png') or a path relative to the document"
+					},
+					"annotations": {
+						"type": "array",
+						"description": "Callouts drawn in order. Coordinates are pixels from the image's top-left corner.",
+						"items": {
+							"type": "object",
+							"properties": {
+								"kind": {"type": "string", "enum": ["arrow", "box", "step"], "description": "arrow: from (x, y) pointing at (to_x, to_y); box: outline with top-left corner (x, y); step: numbered disc centered on (x, y)"},
+								"x": {"type": "integer"},
+								"y": {"type": "integer"},
+								"to_x": {"type": "integer", "description": "Arrow tip"},
+								"to_y": {"type": "integer", "description": "Arrow tip"},
+								"width": {"type": "integer", "description": "Box width"},
+								"height": {"type": "integer", "description": "Box height"},
+								"number": {"type": "integer", "description": "Step number (default: one more than the previous step)"},
+								"color": {"type": "string", "description": "#RRGGBB color (default: red)"}
+							},
+							"required": ["kind", "x", "y"]
+						}
+					},
+					"output_name": {
+						"type": "string",
+						"description": "File name for the annotated PNG (default: the image's name with -annotated.png)"
+					}
+				},
+				"required": ["document_id", "image", "annotations"]
+			}`),
+		},
+		{
+			Name:        "check_assets",
+			Description: "Find images in the document's assets/images folder that no figure or section uses, and figures whose image file is missing. With prune, the unused images are deleted to reclaim space; figures are never changed. Run without prune first to review what would be removed.",
+			InputSchema: json.RawMessage(`{
+				"type": "object",
+				"properties": {
+					"document_id": {
+						"type": "string",
+						"description": "Document ID returned from create_document (e.g., 'technical-manual-1234567890')"
+					},
+					"prune": {
+						"type": "boolean",
+						"description": "Delete the unused images (default: false)"
+					}
+				},
+				"required": ["document_id"]
+			}`),
+		},
+		{
+			Name:        "check_figures_tables",
+			Description: "Check that every figure, table and code listing registered in a chapter appears in its content (as a {#fig-3f9a2c7d} or {#lst-1.2} anchor or the image or table itself), and that every anchor and @fig-/@table-/@lst- cross-reference in the content has a registered figure, table or listing behind it. Figures named by their display number (fig-1.2) rather than their stable ID are reported, since the number changes when figures are deleted or chapters move. Each mismatch comes with a suggested fix. Nothing is changed.",
+			InputSchema: json.RawMessage(`{
+				"type": "object",
+				"properties": {
+					"document_id": {
+						"type": "string",
+						"description": "Document ID returned from create_document (e.g., 'technical-manual-1234567890')"
+					},
+					"chapter_number": {
+						"type": "integer",
+						"description": "Chapter to check (default: all chapters)",
+						"minimum": 1
+					}
+				},
+				"required": ["document_id"]
+			}`),
+		},
+		{
+			Name:        "migrate_figure_ids",
+			Description: "Give every figure still identified by its number (fig-1.2, from documents created before stable IDs) a stable ID like 'fig-3f9a2c7d', so deleting figures and moving chapters no longer renames it. Images imported under the old ID are renamed, and anchors and @fig- references in every section are updated. Figures keep their display numbers. Returns the old and new IDs.",
+			InputSchema: json.RawMessage(`{
+				"type": "object",
+				"properties": {
+					"document_id": {
+						"type": "string",
+						"description": "Document ID returned from create_document (e.g., 'technical-manual-1234567890')"
+					}
+				},
+				"required": ["document_id"]
+			}`),
+		},
+		{
+			Name:        "check_consistency",
+			Description: "Check that each chapter's compiled chapter.md matches its section files and metadata. Reports content in chapter.md that no section holds, sections missing from chapter.md or present in an older version, sections without a file and section files the chapter doesn't list, each with a suggested fix. Exports use the sections, so drift in chapter.md is lost on the next rebuild. Nothing is changed unless rebuild is set.",
+			InputSchema: json.RawMessage(`{
+				"type": "object",
+				"properties": {
+					"document_id": {
+						"type": "string",
+						"description": "Document ID returned from create_document (e.g., 'technical-manual-1234567890')"
+					},
+					"chapter_number": {
+						"type": "integer",
+						"description": "Chapter to check (default: all chapters)",
+						"minimum": 1
+					},
+					"rebuild": {
+						"type": "boolean",
+						"description": "Rewrite chapter.md from the sections in chapters that drifted, discarding content only chapter.md holds (default: false)"
+					}
+				},
+				"required": ["document_id"]
+			}`),
 		},
 		{
 			Name:        "export_document",
@@ -433,8 +2307,8 @@ func (h *DocGenHandler) ListTools(ctx context.Context) (*protocol.ListToolsRespo
 					},
 					"format": {
 						"type": "string",
-						"enum": ["pdf", "docx", "html"],
-						"description": "Export format"
+						"enum": ["pdf", "docx", "html", "epub", "txt", "ssml", "json", "revealjs", "pptx"],
+						"description": "Export format. 'epub' produces an EPUB3 e-book with accessibility metadata and a navigable table of contents. 'txt' and 'ssml' produce a linearized reading-order version for screen readers and text-to-speech (figures replaced by their alt text, tables summarized). 'json' writes the document and its section contents in the get_document_json schema for other tools. 'revealjs' and 'pptx' turn the document into a slide deck: a reveal.js page, which loads reveal.js from its CDN, or a PowerPoint file, split and built up with slide_split and incremental and themed by the style's slides settings."
 					},
 					"chapters": {
 						"type": "array",
@@ -443,11 +2317,204 @@ func (h *DocGenHandler) ListTools(ctx context.Context) (*protocol.ListToolsRespo
 							"minimum": 1
 						},
 						"description": "Specific chapters to export (optional, defaults to all)"
+					},
+					"chapter_range": {
+						"type": "string",
+						"description": "Range of chapters to export instead of chapters (e.g., '3-7')"
+					},
+					"watermark": {
+						"type": "string",
+						"description": "Text shown diagonally across every page of this export, e.g. 'DRAFT' (PDF, DOCX, HTML)"
+					},
+					"banner": {
+						"type": "string",
+						"description": "Confidentiality line shown at the foot of every page of this export, e.g. 'CONFIDENTIAL - DO NOT DISTRIBUTE' (PDF, DOCX, HTML)"
+					},
+					"line_numbers": {
+						"type": "boolean",
+						"description": "Number lines for legal or review copies (PDF and DOCX number lines, HTML numbers paragraphs)"
+					},
+					"embed_source": {
+						"type": "boolean",
+						"description": "Attach a zip of the combined markdown and image assets inside the PDF, so the file carries its editable source (PDF only, default: false)"
+					},
+					"accessible": {
+						"type": "boolean",
+						"description": "Accessible export: a tagged PDF for screen readers (needs LaTeX's tagpdf package), or HTML with a main landmark and a skip link to it. The response warns about what still keeps the document from being accessible, as validate_document's accessibility checks do (PDF and HTML only, default: false)"
+					},
+					"slide_split": {
+						"type": "string",
+						"enum": ["chapter", "section"],
+						"description": "Where slides start: 'section' (the default) gives each chapter a title slide and each section a slide of its own, 'chapter' puts each chapter with its sections on one slide (revealjs and pptx only)"
+					},
+					"incremental": {
+						"type": "boolean",
+						"description": "Reveal list items one at a time (revealjs and pptx only, default: false)"
+					},
+					"fragment": {
+						"type": "boolean",
+						"description": "Export body-only HTML, without <html>, <head> or stylesheets, for pasting or pushing into a CMS or wiki page; written to <document>.fragment.html (HTML only, default: false)"
+					},
+					"heading_offset": {
+						"type": "integer",
+						"minimum": 0,
+						"maximum": 5,
+						"description": "Shift the fragment's headings down by this many levels, e.g. 1 to make chapters <h2> under the page's own title (fragments only, default: 0)"
+					},
+					"image_base_url": {
+						"type": "string",
+						"description": "URL or path the fragment's images will be served from; assets/images/plot.png becomes <image_base_url>/plot.png, so upload the images there (fragments only)"
+					},
+					"confluence": {
+						"type": "boolean",
+						"description": "Write Confluence storage format instead of HTML, to <document>.confluence.xml: images become image macros, attachments of the page by file name unless image_base_url is given, and code blocks code macros (HTML only, implies fragment, default: false)"
+					},
+					"abbreviations": {
+						"type": "boolean",
+						"description": "Open the document with a sorted table of the abbreviations defined with set_abbreviation or written out in the exported chapters (default: false)"
+					},
+					"list_of_listings": {
+						"type": "boolean",
+						"description": "Open the document with a list of the exported chapters' code listings, linked to each listing (default: false)"
+					},
+					"front_matter_badges": {
+						"type": "array",
+						"items": {"type": "string"},
+						"description": "Front matter keys set with set_front_matter, such as 'status', to show as badges under chapter and section headings (HTML and EPUB only; other formats ignore them)"
+					},
+					"approved_only": {
+						"type": "boolean",
+						"description": "Export only approved and final content, as set with set_status: sections that aren't are left out, and chapters with nothing approved are skipped (default: false)"
+					},
+					"status_stamps": {
+						"type": "boolean",
+						"description": "Write each chapter's workflow status, such as 'Status: In review', under its heading (default: false)"
+					},
+					"compression": {
+						"type": "string",
+						"enum": ["gzip", "zip"],
+						"description": "Also write a compressed copy of the export next to it for delivery: 'gzip' (<file>.gz, e.g. for serving HTML) or 'zip' (<file>.zip); the response gives its path and size"
+					},
+					"optimize_pdf": {
+						"type": "boolean",
+						"description": "Linearize and recompress the PDF with qpdf, if installed, so it is smaller and its first page shows while the rest downloads (PDF only, default: false)"
+					},
+					"pdfa": {
+						"type": "boolean",
+						"description": "Convert the PDF to PDF/A-2b with ghostscript for institutional repositories and legal archives: fonts embedded, an sRGB color profile and XMP metadata. It is validated with veraPDF when installed; pdfa_validated in the response says whether it passed (PDF only, not with passwords, default: false)"
+					},
+					"user_password": {
+						"type": "string",
+						"description": "Encrypt the export so this password is needed to open it: a PDF with qpdf, a DOCX with msoffcrypto-tool (PDF and DOCX only). The export fails rather than being left unprotected when the tool is missing."
+					},
+					"owner_password": {
+						"type": "string",
+						"description": "Password that lifts a PDF's restrictions on printing and copying; a random one is used when it is left out, so they can't be lifted (PDF only)"
+					},
+					"allow_print": {
+						"type": "boolean",
+						"description": "Let readers of an encrypted PDF print it (PDF only, default: true)"
+					},
+					"allow_copy": {
+						"type": "boolean",
+						"description": "Let readers of an encrypted PDF copy its text and images (PDF only, default: true)"
+					},
+					"float_placement": {
+						"type": "string",
+						"enum": ["balanced", "here"],
+						"description": "How figures and tables float in a PDF: 'balanced' relaxes LaTeX's float limits so floats fill pages instead of leaving large gaps, 'here' keeps every float where it is written (PDF only, default: LaTeX's own placement)"
+					},
+					"preflight": {
+						"type": "boolean",
+						"description": "Only summarize the export (chapters, estimated pages and time, validation warnings) without running it (default: false)"
+					},
+					"confirm": {
+						"type": "boolean",
+						"description": "Run an export estimated to take long; without it such exports return a preflight summary instead (default: false)"
 					}
 				},
 				"required": ["document_id", "format"]
 			}`),
 		},
+		{
+			Name:        "compile_volume",
+			Description: "Compile several documents into one export, such as the parts of a book kept as separate documents or an anthology of reports, when the user asks for it. The volume has one title page and table of contents and one style; each document becomes a part titled with its title. Returns the path of the volume in the exports/ directory.",
+			InputSchema: json.RawMessage(`{
+				"type": "object",
+				"properties": {
+					"document_ids": {
+						"type": "array",
+						"items": {"type": "string"},
+						"minItems": 2,
+						"description": "Documents to compile, in the order they appear in the volume"
+					},
+					"title": {
+						"type": "string",
+						"description": "Title of the volume, for its title page and file name"
+					},
+					"format": {
+						"type": "string",
+						"enum": ["pdf", "docx", "html", "epub"],
+						"description": "Export format"
+					},
+					"numbering": {
+						"type": "string",
+						"enum": ["continuous", "per-document"],
+						"description": "Number chapters, figures and tables through the volume (continuous, the default) or from 1 in each document (per-document)"
+					},
+					"style_name": {
+						"type": "string",
+						"description": "Style for the whole volume, as for export_document (optional)"
+					}
+				},
+				"required": ["document_ids", "title", "format"]
+			}`),
+		},
+		{
+			Name:        "reimport_docx_feedback",
+			Description: "Read a reviewer's feedback back from a DOCX export of the document that was edited in Word with tracked changes and comments. Returns each insertion, deletion and comment with its author, the chapter and section it is in and the paragraph around it, and, when pandoc is installed, each changed section as it reads with the changes accepted. Nothing in the document changes: go through the feedback with the user and apply what they accept with update_section.",
+			InputSchema: json.RawMessage(`{
+				"type": "object",
+				"properties": {
+					"document_id": {
+						"type": "string",
+						"description": "Document the DOCX was exported from"
+					},
+					"path": {
+						"type": "string",
+						"description": "Path of the edited DOCX, relative paths being in the exports/ directory (optional, defaults to the document's DOCX export)"
+					}
+				},
+				"required": ["document_id"]
+			}`),
+		},
+		{
+			Name:        "estimate_pages",
+			Description: "Tell how many pages the document's PDF takes, in all and per chapter, without exporting it. 'estimate' (the default) predicts pages from the style's page size, margins, font size and line spacing in an instant; 'precise' typesets a draft PDF, with images as placeholder boxes, and counts its pages with pdftotext, which takes about as long as a PDF export.",
+			InputSchema: json.RawMessage(`{
+				"type": "object",
+				"properties": {
+					"document_id": {
+						"type": "string",
+						"description": "Document ID returned from create_document (e.g., 'technical-manual-1234567890')"
+					},
+					"mode": {
+						"type": "string",
+						"enum": ["estimate", "precise"],
+						"description": "'estimate' (default) predicts pages without running LaTeX; 'precise' counts the pages of a draft PDF"
+					},
+					"chapter_range": {
+						"type": "string",
+						"description": "Count only these chapters, such as '3-7' or '4' (default: all chapters)"
+					},
+					"style_name": {
+						"type": "string",
+						"description": "Style to lay the pages out with, as for export_document (optional)"
+					}
+				},
+				"required": ["document_id"]
+			}`),
+		},
 		{
 			Name:        "validate_document",
 			Description: "Check document integrity and identify potential issues before export. Validates document structure, verifies all referenced files exist, checks for missing content, and ensures proper numbering. Run this before export_document to catch problems early.",
@@ -457,11 +2524,134 @@ func (h *DocGenHandler) ListTools(ctx context.Context) (*protocol.ListToolsRespo
 					"document_id": {
 						"type": "string",
 						"description": "Document ID to validate"
+					},
+					"strict": {
+						"type": "boolean",
+						"description": "Treat unresolved TODO items (e.g., placeholder captions) as errors (default: false)"
+					},
+					"format": {
+						"type": "string",
+						"enum": ["epub", "pdf"],
+						"description": "Also run format-specific checks (optional). 'epub' checks accessibility prerequisites and runs epubcheck on the last EPUB export if epubcheck is installed. 'pdf' checks that the style's fonts are installed, warning about each font a PDF export would replace and suggesting installed alternatives."
+					},
+					"accessibility": {
+						"type": "boolean",
+						"description": "Also warn about accessibility problems: figures without captions, images without alt text, headings that skip a level, text colors with too little contrast with the page, and a missing document language (default: false)"
+					},
+					"style_name": {
+						"type": "string",
+						"description": "Style whose colors the accessibility checks and whose fonts the 'pdf' checks use (optional, defaults to the style exports use)"
+					}
+				},
+				"required": ["document_id"]
+			}`),
+		},
+		{
+			Name:        "get_export_log",
+			Description: "Get the log of a document's most recent pandoc export: the command line, exit code, duration, pandoc's stdout and stderr (including warnings from successful exports) and the intermediate files kept alongside it. Use it to diagnose a failed export or unexpected output.",
+			InputSchema: json.RawMessage(`{
+				"type": "object",
+				"properties": {
+					"document_id": {
+						"type": "string",
+						"description": "Document ID returned from create_document (e.g., 'technical-manual-1234567890')"
 					}
 				},
 				"required": ["document_id"]
 			}`),
 		},
+		{
+			Name:        "list_exports",
+			Description: "List a document's export history, newest first: each export's ID, time, format, style, chapters, output path, file size, duration and whether it succeeded, with the error of failed exports. An export is available while its output file exists and no later export has overwritten it.",
+			InputSchema: json.RawMessage(`{
+				"type": "object",
+				"properties": {
+					"document_id": {
+						"type": "string",
+						"description": "Document ID returned from create_document (e.g., 'technical-manual-1234567890')"
+					},
+					"format": {
+						"type": "string",
+						"enum": ["pdf", "docx", "html", "epub", "txt", "ssml", "json", "revealjs", "pptx"],
+						"description": "Only list exports in this format"
+					},
+					"limit": {
+						"type": "integer",
+						"default": 20,
+						"minimum": 0,
+						"description": "Maximum number of exports to return (0 for all)"
+					}
+				},
+				"required": ["document_id"]
+			}`),
+		},
+		{
+			Name:        "delete_export",
+			Description: "Delete exports from a document's export history, by ID from list_exports or all those older than a number of days. The output files of available exports are deleted with their compressed copies; files outside the exports directory are left alone. Returns the deleted exports and the bytes freed.",
+			InputSchema: json.RawMessage(`{
+				"type": "object",
+				"properties": {
+					"document_id": {
+						"type": "string",
+						"description": "Document ID returned from create_document (e.g., 'technical-manual-1234567890')"
+					},
+					"export_id": {
+						"type": "integer",
+						"description": "ID of the export to delete, from list_exports"
+					},
+					"older_than_days": {
+						"type": "number",
+						"minimum": 0,
+						"description": "Delete every export made more than this many days ago"
+					}
+				},
+				"required": ["document_id"]
+			}`),
+		},
+		{
+			Name:        "resolve_style",
+			Description: "Show the effective style an export would use. A style in the styles/ folder can set extends: <name> and override only the fields it changes; this flattens the chain and returns the merged style along with the styles it was built from.",
+			InputSchema: json.RawMessage(`{
+				"type": "object",
+				"properties": {
+					"style_name": {
+						"type": "string",
+						"description": "Style name in the styles/ folder (optional, defaults to the style export_document would use: DOCGEN_CURRENT_STYLE or 'default')"
+					}
+				}
+			}`),
+		},
+		{
+			Name:        "add_font",
+			Description: "Bundle a font file (.otf or .ttf) so exports don't depend on the fonts installed where they run. The file is copied into the document's fonts folder, or without a document_id into the fonts folder shared by all documents. Name it in a style's font_file (body, heading, monospace or epigraph): PDF exports load it with fontspec, HTML exports embed it with @font-face and EPUB exports embed it in the book. Bold and italic faces are the files named like it, such as Name-Bold.otf, Name-Italic.otf and Name-BoldItalic.otf beside Name-Regular.otf.",
+			InputSchema: json.RawMessage(`{
+				"type": "object",
+				"properties": {
+					"source_path": {
+						"type": "string",
+						"description": "Path to the font file, within the allowed directories"
+					},
+					"document_id": {
+						"type": "string",
+						"description": "Document to add the font to (optional, shares the font with all documents when omitted)"
+					}
+				},
+				"required": ["source_path"]
+			}`),
+		},
+		{
+			Name:        "list_fonts",
+			Description: "List the bundled font files styles can name in font_file: a document's own fonts, then the shared fonts it doesn't have a font of the same name for.",
+			InputSchema: json.RawMessage(`{
+				"type": "object",
+				"properties": {
+					"document_id": {
+						"type": "string",
+						"description": "Document whose fonts to list (optional, lists only the shared fonts when omitted)"
+					}
+				}
+			}`),
+		},
 	}
 
 	return &protocol.ListToolsResponse{Tools: tools}, nil
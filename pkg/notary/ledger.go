@@ -0,0 +1,222 @@
+// Package notary keeps an append-only, hash-chained ledger of exports so that
+// regulated users can later prove what was exported, when and with which tools.
+package notary
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/gomcpgo/docgen/pkg/config"
+	"github.com/gomcpgo/docgen/pkg/types"
+	"gopkg.in/yaml.v3"
+)
+
+// Ledger appends export records to the ledger file, one JSON object per line
+type Ledger struct {
+	config *config.Config
+	mu     sync.Mutex
+}
+
+// NewLedger creates a new export ledger
+func NewLedger(cfg *config.Config) *Ledger {
+	return &Ledger{
+		config: cfg,
+	}
+}
+
+// Append completes a record with its sequence number, timestamp and hashes,
+// anchors it with the configured timestamp authority and appends it to the
+// ledger. A failing timestamp authority is noted on the record rather than
+// failing the export.
+func (l *Ledger) Append(record types.ExportRecord) (*types.ExportRecord, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	records, err := l.load()
+	if err != nil {
+		return nil, err
+	}
+
+	record.Sequence = 1
+	record.PreviousHash = ""
+	if len(records) > 0 {
+		last := records[len(records)-1]
+		record.Sequence = last.Sequence + 1
+		record.PreviousHash = last.RecordHash
+	}
+	record.Timestamp = time.Now().UTC()
+	record.RecordHash, err = recordHash(record)
+	if err != nil {
+		return nil, err
+	}
+
+	if l.config.TimestampURL != "" {
+		record.TimestampAuthority = l.config.TimestampURL
+		digest, _ := hex.DecodeString(record.RecordHash)
+		token, err := requestTimestamp(l.config.TimestampURL, digest)
+		if err != nil {
+			log.Printf("[DOCGEN NOTARY] Timestamp request failed: %v", err)
+			record.TimestampError = err.Error()
+		} else {
+			record.TimestampToken = token
+		}
+	}
+
+	line, err := json.Marshal(record)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode export record: %w", err)
+	}
+
+	file, err := os.OpenFile(l.config.ExportLedgerPath(), os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open export ledger: %w", err)
+	}
+	defer file.Close()
+
+	if _, err := file.Write(append(line, '\n')); err != nil {
+		return nil, fmt.Errorf("failed to write export record: %w", err)
+	}
+	if err := file.Sync(); err != nil {
+		return nil, fmt.Errorf("failed to sync export ledger: %w", err)
+	}
+
+	return &record, nil
+}
+
+// Records returns the ledger records for a document, or every record when
+// documentID is empty
+func (l *Ledger) Records(documentID string) ([]types.ExportRecord, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	records, err := l.load()
+	if err != nil {
+		return nil, err
+	}
+
+	result := []types.ExportRecord{}
+	for _, record := range records {
+		if documentID == "" || string(record.DocumentID) == documentID {
+			result = append(result, record)
+		}
+	}
+	return result, nil
+}
+
+// Verify checks that every record's hash matches its contents and that each
+// record is chained to the one before it. It returns the number of records checked.
+func (l *Ledger) Verify() (int, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	records, err := l.load()
+	if err != nil {
+		return 0, err
+	}
+
+	previous := ""
+	for i, record := range records {
+		if record.Sequence != i+1 {
+			return i, fmt.Errorf("record %d has sequence %d", i+1, record.Sequence)
+		}
+		if record.PreviousHash != previous {
+			return i, fmt.Errorf("record %d is not chained to the record before it", record.Sequence)
+		}
+		hash, err := recordHash(record)
+		if err != nil {
+			return i, err
+		}
+		if hash != record.RecordHash {
+			return i, fmt.Errorf("record %d has been modified", record.Sequence)
+		}
+		previous = record.RecordHash
+	}
+	return len(records), nil
+}
+
+// load reads every record in the ledger
+func (l *Ledger) load() ([]types.ExportRecord, error) {
+	file, err := os.Open(l.config.ExportLedgerPath())
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to open export ledger: %w", err)
+	}
+	defer file.Close()
+
+	var records []types.ExportRecord
+	reader := bufio.NewReader(file)
+	for lineNumber := 1; ; lineNumber++ {
+		line, err := reader.ReadBytes('\n')
+		if len(bytes.TrimSpace(line)) > 0 {
+			var record types.ExportRecord
+			if jsonErr := json.Unmarshal(line, &record); jsonErr != nil {
+				return nil, fmt.Errorf("export ledger line %d is corrupt: %w", lineNumber, jsonErr)
+			}
+			records = append(records, record)
+		}
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to read export ledger: %w", err)
+		}
+	}
+	return records, nil
+}
+
+// recordHash hashes a record without its own hash and timestamp anchoring, which
+// are derived from it
+func recordHash(record types.ExportRecord) (string, error) {
+	record.RecordHash = ""
+	record.TimestampAuthority = ""
+	record.TimestampToken = nil
+	record.TimestampError = ""
+
+	data, err := json.Marshal(record)
+	if err != nil {
+		return "", fmt.Errorf("failed to encode export record: %w", err)
+	}
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:]), nil
+}
+
+// HashFile returns the hex SHA-256 of a file's contents
+func HashFile(path string) (string, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return "", err
+	}
+	defer file.Close()
+
+	hash := sha256.New()
+	if _, err := io.Copy(hash, file); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(hash.Sum(nil)), nil
+}
+
+// HashStyle returns the hex SHA-256 of a style's YAML form. A nil style hashes
+// as empty.
+func HashStyle(style *types.Style) (string, error) {
+	var data []byte
+	if style != nil {
+		var err error
+		data, err = yaml.Marshal(style)
+		if err != nil {
+			return "", fmt.Errorf("failed to encode style: %w", err)
+		}
+	}
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:]), nil
+}
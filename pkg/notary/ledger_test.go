@@ -0,0 +1,164 @@
+package notary
+
+import (
+	"encoding/asn1"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/gomcpgo/docgen/pkg/config"
+	"github.com/gomcpgo/docgen/pkg/types"
+)
+
+func setupTestLedger(t *testing.T) (*Ledger, *config.Config) {
+	cfg := &config.Config{RootDir: t.TempDir()}
+	return NewLedger(cfg), cfg
+}
+
+func appendRecord(t *testing.T, ledger *Ledger, docID string) *types.ExportRecord {
+	record, err := ledger.Append(types.ExportRecord{
+		DocumentID:      types.DocumentID(docID),
+		Format:          types.ExportFormatPDF,
+		OutputPath:      "/exports/" + docID + ".pdf",
+		ContentHash:     "abc",
+		StyleHash:       "def",
+		ExporterVersion: "1.0.0",
+		PandocVersion:   "pandoc 3.1",
+	})
+	if err != nil {
+		t.Fatalf("Append() error = %v", err)
+	}
+	return record
+}
+
+func TestLedger_AppendChainsRecords(t *testing.T) {
+	ledger, _ := setupTestLedger(t)
+
+	first := appendRecord(t, ledger, "doc-a")
+	second := appendRecord(t, ledger, "doc-b")
+	appendRecord(t, ledger, "doc-a")
+
+	if first.Sequence != 1 || first.PreviousHash != "" {
+		t.Errorf("first record = sequence %d, previous %q", first.Sequence, first.PreviousHash)
+	}
+	if second.Sequence != 2 || second.PreviousHash != first.RecordHash {
+		t.Errorf("second record = sequence %d, previous %q, want 2 and %q", second.Sequence, second.PreviousHash, first.RecordHash)
+	}
+
+	records, err := ledger.Records("doc-a")
+	if err != nil {
+		t.Fatalf("Records() error = %v", err)
+	}
+	if len(records) != 2 {
+		t.Errorf("Records(doc-a) returned %d records, want 2", len(records))
+	}
+
+	checked, err := ledger.Verify()
+	if err != nil || checked != 3 {
+		t.Errorf("Verify() = %d, %v, want 3, nil", checked, err)
+	}
+}
+
+func TestLedger_VerifyDetectsTampering(t *testing.T) {
+	ledger, cfg := setupTestLedger(t)
+	appendRecord(t, ledger, "doc-a")
+	appendRecord(t, ledger, "doc-a")
+
+	data, err := os.ReadFile(cfg.ExportLedgerPath())
+	if err != nil {
+		t.Fatal(err)
+	}
+	tampered := strings.Replace(string(data), `"content_hash":"abc"`, `"content_hash":"xyz"`, 1)
+	if err := os.WriteFile(cfg.ExportLedgerPath(), []byte(tampered), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := ledger.Verify(); err == nil || !strings.Contains(err.Error(), "record 1 has been modified") {
+		t.Errorf("Verify() error = %v, want record 1 modified", err)
+	}
+
+	// Dropping the first record breaks the chain
+	lines := strings.SplitAfter(string(data), "\n")
+	if err := os.WriteFile(cfg.ExportLedgerPath(), []byte(strings.Join(lines[1:], "")), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := ledger.Verify(); err == nil {
+		t.Error("Verify() succeeded after a record was removed")
+	}
+}
+
+func TestLedger_Timestamp(t *testing.T) {
+	token := asn1.RawValue{Class: asn1.ClassUniversal, Tag: asn1.TagSequence, IsCompound: true, Bytes: []byte{0x02, 0x01, 0x07}}
+	var received timeStampReq
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Content-Type") != "application/timestamp-query" {
+			http.Error(w, "bad content type", http.StatusBadRequest)
+			return
+		}
+		body, _ := io.ReadAll(r.Body)
+		if _, err := asn1.Unmarshal(body, &received); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		response, _ := asn1.Marshal(timeStampResp{Status: pkiStatusInfo{Status: 0}, TimeStampToken: token})
+		w.Header().Set("Content-Type", "application/timestamp-reply")
+		w.Write(response)
+	}))
+	defer server.Close()
+
+	ledger, cfg := setupTestLedger(t)
+	cfg.TimestampURL = server.URL
+
+	record := appendRecord(t, ledger, "doc-a")
+	if record.TimestampError != "" {
+		t.Fatalf("TimestampError = %q", record.TimestampError)
+	}
+	if len(record.TimestampToken) == 0 {
+		t.Fatal("record has no timestamp token")
+	}
+	if !received.MessageImprint.HashAlgorithm.Algorithm.Equal(oidSHA256) || len(received.MessageImprint.HashedMessage) != 32 {
+		t.Errorf("unexpected message imprint %+v", received.MessageImprint)
+	}
+
+	// The token is stored with the record and does not affect verification
+	if _, err := ledger.Verify(); err != nil {
+		t.Errorf("Verify() error = %v", err)
+	}
+}
+
+func TestLedger_TimestampFailureKeepsRecord(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		response, _ := asn1.Marshal(timeStampResp{Status: pkiStatusInfo{Status: 2}})
+		w.Write(response)
+	}))
+	defer server.Close()
+
+	ledger, cfg := setupTestLedger(t)
+	cfg.TimestampURL = server.URL
+
+	record := appendRecord(t, ledger, "doc-a")
+	if !strings.Contains(record.TimestampError, "rejected") {
+		t.Errorf("TimestampError = %q, want rejection", record.TimestampError)
+	}
+	if records, _ := ledger.Records(""); len(records) != 1 {
+		t.Errorf("ledger has %d records, want 1", len(records))
+	}
+}
+
+func TestHashFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "out.txt")
+	if err := os.WriteFile(path, []byte("hello"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	hash, err := HashFile(path)
+	if err != nil {
+		t.Fatalf("HashFile() error = %v", err)
+	}
+	if hash != "2cf24dba5fb0a30e26e83b2ac5b9e29e1b161e5c1fa7425e73043362938b9824" {
+		t.Errorf("HashFile() = %s", hash)
+	}
+}
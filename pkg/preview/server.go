@@ -0,0 +1,295 @@
+// Package preview serves HTML exports of documents over HTTP with live reload.
+package preview
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"html/template"
+	"log"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/gomcpgo/docgen/pkg/config"
+	"github.com/gomcpgo/docgen/pkg/storage"
+	"github.com/gomcpgo/docgen/pkg/types"
+)
+
+// ExportFunc exports a whole document and returns the path of the exported
+// file, giving up when the context is cancelled
+type ExportFunc func(ctx context.Context, documentID string, format types.ExportFormat) (string, error)
+
+// RenderFunc renders a whole document as an approximate HTML page without pandoc
+type RenderFunc func(ctx context.Context, documentID string) ([]byte, error)
+
+// liveReloadScript polls the document's version endpoint and reloads the page
+// when a newer HTML export appears
+const liveReloadScript = `<script>
+(function () {
+  var version = null;
+  setInterval(function () {
+    fetch("version", {cache: "no-store"}).then(function (r) { return r.text(); }).then(function (v) {
+      if (version === null) { version = v; } else if (v !== version) { location.reload(); }
+    }).catch(function () {});
+  }, 1000);
+})();
+</script>
+`
+
+var indexTemplate = template.Must(template.New("index").Parse(`<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<title>Document previews</title>
+<style>
+body { font-family: sans-serif; margin: 2em auto; max-width: 50em; color: #222; }
+li { margin: 0.5em 0; }
+.meta { color: #666; font-size: 0.9em; }
+</style>
+</head>
+<body>
+<h1>Document previews</h1>
+{{if .}}<ul>
+{{range .}}<li><a href="/documents/{{.ID}}/">{{.Title}}</a>
+<div class="meta">{{.ID}}{{if .Authors}} &middot; {{.Authors}}{{end}} &middot; {{if .Exported}}exported {{.Exported.Format "2006-01-02 15:04"}}{{else}}not exported yet{{end}}</div></li>
+{{end}}</ul>{{else}}<p>No documents found.</p>{{end}}
+</body>
+</html>
+`))
+
+// indexEntry is a document listed on the index page
+type indexEntry struct {
+	ID       string
+	Title    string
+	Authors  string
+	Exported *time.Time
+}
+
+// Server serves the document index, HTML previews and document assets
+type Server struct {
+	config  *config.Config
+	storage storage.Storage
+	export  ExportFunc
+	render  RenderFunc
+	// pandocAvailable reports whether an export can produce HTML at all
+	pandocAvailable func() bool
+}
+
+// NewServer creates a preview server. When export is not nil, documents without an
+// HTML export are exported the first time they are previewed.
+func NewServer(cfg *config.Config, stor storage.Storage, export ExportFunc) *Server {
+	return &Server{
+		config:  cfg,
+		storage: stor,
+		export:  export,
+	}
+}
+
+// SetApproximateRenderer sets the renderer used when a document has no HTML
+// export and pandoc cannot produce one. When pandocAvailable is not nil and
+// reports pandoc missing, the renderer is used without trying to export.
+func (s *Server) SetApproximateRenderer(render RenderFunc, pandocAvailable func() bool) {
+	s.render = render
+	s.pandocAvailable = pandocAvailable
+}
+
+// ListenAndServe serves previews on addr until the server fails
+func (s *Server) ListenAndServe(addr string) error {
+	log.Printf("[DOCGEN PREVIEW] Serving document previews on http://%s/", displayAddr(addr))
+	server := &http.Server{
+		Addr:              addr,
+		Handler:           s.Handler(),
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+	return server.ListenAndServe()
+}
+
+// Handler returns the HTTP handler for the preview routes:
+//
+//	/                                 document index
+//	/documents/{id}/                  HTML preview with live reload
+//	/documents/{id}/version           current export version, polled by the preview
+//	/documents/{id}/assets/...        files from the document's assets directory
+func (s *Server) Handler() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/", s.handleIndex)
+	mux.HandleFunc("/documents/", s.handleDocument)
+	return mux
+}
+
+func (s *Server) handleIndex(w http.ResponseWriter, r *http.Request) {
+	if r.URL.Path != "/" {
+		http.NotFound(w, r)
+		return
+	}
+
+	documentIDs, err := s.storage.ListDocuments()
+	if err != nil {
+		http.Error(w, fmt.Sprintf("failed to list documents: %v", err), http.StatusInternalServerError)
+		return
+	}
+
+	entries := make([]indexEntry, 0, len(documentIDs))
+	for _, documentID := range documentIDs {
+		entry := indexEntry{ID: documentID, Title: documentID}
+		if manifest, err := s.storage.LoadManifest(documentID); err == nil && manifest != nil {
+			if manifest.Document.Title != "" {
+				entry.Title = manifest.Document.Title
+			}
+			entry.Authors = manifest.Document.Authors.String()
+		}
+		if _, modTime, ok := s.htmlExport(documentID); ok {
+			entry.Exported = &modTime
+		}
+		entries = append(entries, entry)
+	}
+	sort.Slice(entries, func(i, j int) bool {
+		return strings.ToLower(entries[i].Title) < strings.ToLower(entries[j].Title)
+	})
+
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	if err := indexTemplate.Execute(w, entries); err != nil {
+		log.Printf("[DOCGEN PREVIEW] Failed to render index: %v", err)
+	}
+}
+
+func (s *Server) handleDocument(w http.ResponseWriter, r *http.Request) {
+	rest := strings.TrimPrefix(r.URL.Path, "/documents/")
+	documentID, subPath, hasSlash := strings.Cut(rest, "/")
+
+	if err := types.DocumentID(documentID).Validate(); err != nil {
+		http.NotFound(w, r)
+		return
+	}
+	if exists, err := s.storage.DocumentExists(documentID); err != nil || !exists {
+		http.NotFound(w, r)
+		return
+	}
+	if !hasSlash {
+		http.Redirect(w, r, "/documents/"+documentID+"/", http.StatusMovedPermanently)
+		return
+	}
+
+	switch {
+	case subPath == "":
+		s.servePreview(w, r, documentID)
+	case subPath == "version":
+		s.serveVersion(w, documentID)
+	case strings.HasPrefix(subPath, "assets/"):
+		// http.Dir rejects paths that escape the assets directory
+		assets := http.Dir(filepath.Join(s.config.DocumentPath(documentID), "assets"))
+		http.StripPrefix("/documents/"+documentID+"/assets", http.FileServer(assets)).ServeHTTP(w, r)
+	default:
+		http.NotFound(w, r)
+	}
+}
+
+// servePreview serves the document's HTML export with the live reload script injected
+func (s *Server) servePreview(w http.ResponseWriter, r *http.Request, documentID string) {
+	path, _, ok := s.htmlExport(documentID)
+	if !ok && s.render != nil && s.pandocAvailable != nil && !s.pandocAvailable() {
+		log.Printf("[DOCGEN PREVIEW] Showing approximate preview of %s: pandoc is not installed", documentID)
+		s.serveApproximate(w, r, documentID)
+		return
+	}
+	if !ok {
+		exportErr := fmt.Errorf("document %s has no HTML export yet; export it as html first", documentID)
+		status := http.StatusNotFound
+		if s.export != nil {
+			path, exportErr = s.export(r.Context(), documentID, types.ExportFormatHTML)
+			if exportErr != nil {
+				exportErr = fmt.Errorf("failed to export document: %w", exportErr)
+				status = http.StatusInternalServerError
+			}
+		}
+		if exportErr != nil {
+			if s.render == nil {
+				http.Error(w, exportErr.Error(), status)
+				return
+			}
+			log.Printf("[DOCGEN PREVIEW] Showing approximate preview of %s: %v", documentID, exportErr)
+			s.serveApproximate(w, r, documentID)
+			return
+		}
+	}
+
+	content, err := os.ReadFile(path)
+	if err != nil {
+		http.Error(w, fmt.Sprintf("failed to read HTML export: %v", err), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	w.Header().Set("Cache-Control", "no-store")
+	w.Write(injectLiveReload(content))
+}
+
+// serveApproximate serves the document rendered without pandoc
+func (s *Server) serveApproximate(w http.ResponseWriter, r *http.Request, documentID string) {
+	content, err := s.render(r.Context(), documentID)
+	if err != nil {
+		http.Error(w, fmt.Sprintf("failed to render document: %v", err), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	w.Header().Set("Cache-Control", "no-store")
+	w.Write(content)
+}
+
+// serveVersion reports the modification time of the current HTML export
+func (s *Server) serveVersion(w http.ResponseWriter, documentID string) {
+	w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+	w.Header().Set("Cache-Control", "no-store")
+	if _, modTime, ok := s.htmlExport(documentID); ok {
+		fmt.Fprintf(w, "%d", modTime.UnixNano())
+		return
+	}
+	fmt.Fprint(w, "0")
+}
+
+// htmlExport returns the newest HTML export of a document: either the last
+// export_document result or the watcher's latest export
+func (s *Server) htmlExport(documentID string) (string, time.Time, bool) {
+	var newestPath string
+	var newest time.Time
+	for _, path := range []string{
+		s.config.ExportPath(documentID, string(types.ExportFormatHTML)),
+		s.config.LatestExportPath(documentID, string(types.ExportFormatHTML)),
+	} {
+		info, err := os.Stat(path)
+		if err != nil {
+			continue
+		}
+		if newestPath == "" || info.ModTime().After(newest) {
+			newestPath, newest = path, info.ModTime()
+		}
+	}
+	return newestPath, newest, newestPath != ""
+}
+
+// injectLiveReload inserts the live reload script before </body>, or appends it
+func injectLiveReload(content []byte) []byte {
+	index := bytes.LastIndex(bytes.ToLower(content), []byte("</body>"))
+	if index == -1 {
+		return append(content, []byte(liveReloadScript)...)
+	}
+
+	result := make([]byte, 0, len(content)+len(liveReloadScript))
+	result = append(result, content[:index]...)
+	result = append(result, liveReloadScript...)
+	result = append(result, content[index:]...)
+	return result
+}
+
+// displayAddr turns a listen address such as ":8080" into a browsable host:port
+func displayAddr(addr string) string {
+	if strings.HasPrefix(addr, ":") {
+		return "localhost" + addr
+	}
+	return addr
+}
@@ -0,0 +1,165 @@
+package preview
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gomcpgo/docgen/pkg/config"
+	"github.com/gomcpgo/docgen/pkg/storage"
+	"github.com/gomcpgo/docgen/pkg/types"
+)
+
+func setupTestServer(t *testing.T, export ExportFunc) (*Server, *config.Config) {
+	tempDir := t.TempDir()
+	cfg := &config.Config{
+		RootDir:       tempDir,
+		ExportsDir:    filepath.Join(tempDir, "exports"),
+		PandocPath:    "pandoc",
+		MaxDocuments:  100,
+		MaxFileSize:   10 * 1024 * 1024,
+		ExportTimeout: 30 * time.Second,
+	}
+	stor := storage.NewFileSystemStorage(cfg)
+
+	doc := &types.Document{ID: "report-1", Title: "Quarterly Report", Authors: types.AuthorList{{Name: "Ann Author"}}, Type: types.DocumentTypeReport}
+	if err := stor.CreateDocumentStructure(doc); err != nil {
+		t.Fatalf("Failed to create document: %v", err)
+	}
+	if err := stor.SaveManifest("report-1", &types.Manifest{Document: *doc}); err != nil {
+		t.Fatalf("Failed to save manifest: %v", err)
+	}
+
+	return NewServer(cfg, stor, export), cfg
+}
+
+func get(t *testing.T, handler http.Handler, path string) *httptest.ResponseRecorder {
+	recorder := httptest.NewRecorder()
+	handler.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, path, nil))
+	return recorder
+}
+
+func TestServer_Index(t *testing.T) {
+	server, _ := setupTestServer(t, nil)
+
+	response := get(t, server.Handler(), "/")
+	if response.Code != http.StatusOK {
+		t.Fatalf("Expected 200, got %d", response.Code)
+	}
+	body := response.Body.String()
+	if !strings.Contains(body, `<a href="/documents/report-1/">Quarterly Report</a>`) {
+		t.Errorf("Index should link to the document, got:\n%s", body)
+	}
+	if !strings.Contains(body, "not exported yet") {
+		t.Errorf("Index should show the export status")
+	}
+}
+
+func TestServer_Preview(t *testing.T) {
+	server, cfg := setupTestServer(t, nil)
+	handler := server.Handler()
+
+	// Without an export and without an export function there is nothing to show
+	if response := get(t, handler, "/documents/report-1/"); response.Code != http.StatusNotFound {
+		t.Errorf("Expected 404 without an HTML export, got %d", response.Code)
+	}
+
+	os.MkdirAll(cfg.ExportsDir, 0755)
+	os.WriteFile(cfg.ExportPath("report-1", "html"), []byte("<html><body><p>Hello</p></body></html>"), 0644)
+
+	response := get(t, handler, "/documents/report-1/")
+	if response.Code != http.StatusOK {
+		t.Fatalf("Expected 200, got %d", response.Code)
+	}
+	body := response.Body.String()
+	if !strings.Contains(body, "<p>Hello</p><script>") || !strings.HasSuffix(body, "</script>\n</body></html>") {
+		t.Errorf("Live reload script should be injected before </body>, got:\n%s", body)
+	}
+
+	if response := get(t, handler, "/documents/report-1"); response.Code != http.StatusMovedPermanently {
+		t.Errorf("Expected redirect to the trailing-slash URL, got %d", response.Code)
+	}
+
+	version := get(t, handler, "/documents/report-1/version").Body.String()
+	if version == "0" || version == "" {
+		t.Errorf("Expected the export modification time as version, got %q", version)
+	}
+
+	if response := get(t, handler, "/documents/missing-doc/"); response.Code != http.StatusNotFound {
+		t.Errorf("Expected 404 for an unknown document, got %d", response.Code)
+	}
+}
+
+func TestServer_PreviewExportsOnDemand(t *testing.T) {
+	var exported []string
+	var server *Server
+	var cfg *config.Config
+	server, cfg = setupTestServer(t, func(ctx context.Context, documentID string, format types.ExportFormat) (string, error) {
+		exported = append(exported, documentID+"."+string(format))
+		path := cfg.ExportPath(documentID, string(format))
+		os.MkdirAll(filepath.Dir(path), 0755)
+		return path, os.WriteFile(path, []byte("<p>Generated</p>"), 0644)
+	})
+
+	response := get(t, server.Handler(), "/documents/report-1/")
+	if response.Code != http.StatusOK || !strings.Contains(response.Body.String(), "Generated") {
+		t.Fatalf("Expected an on-demand export, got %d: %s", response.Code, response.Body.String())
+	}
+	if len(exported) != 1 || exported[0] != "report-1.html" {
+		t.Errorf("Expected one HTML export, got %v", exported)
+	}
+}
+
+func TestServer_PreviewFallsBackToApproximateRenderer(t *testing.T) {
+	server, _ := setupTestServer(t, func(ctx context.Context, documentID string, format types.ExportFormat) (string, error) {
+		return "", fmt.Errorf("pandoc not found in PATH")
+	})
+	handler := server.Handler()
+
+	if response := get(t, handler, "/documents/report-1/"); response.Code != http.StatusInternalServerError {
+		t.Errorf("Expected 500 without a fallback renderer, got %d", response.Code)
+	}
+
+	render := func(ctx context.Context, documentID string) ([]byte, error) {
+		return []byte("<p>Approximate " + documentID + "</p>"), nil
+	}
+	server.SetApproximateRenderer(render, nil)
+	response := get(t, handler, "/documents/report-1/")
+	if response.Code != http.StatusOK || response.Body.String() != "<p>Approximate report-1</p>" {
+		t.Errorf("Expected the approximate rendering, got %d: %s", response.Code, response.Body.String())
+	}
+
+	// Without pandoc the export isn't tried at all
+	server.export = func(ctx context.Context, documentID string, format types.ExportFormat) (string, error) {
+		t.Error("Expected no export without pandoc")
+		return "", fmt.Errorf("pandoc not found in PATH")
+	}
+	server.SetApproximateRenderer(render, func() bool { return false })
+	response = get(t, handler, "/documents/report-1/")
+	if response.Code != http.StatusOK || response.Body.String() != "<p>Approximate report-1</p>" {
+		t.Errorf("Expected the approximate rendering, got %d: %s", response.Code, response.Body.String())
+	}
+}
+
+func TestServer_Assets(t *testing.T) {
+	server, cfg := setupTestServer(t, nil)
+	handler := server.Handler()
+
+	os.MkdirAll(cfg.AssetsPath("report-1"), 0755)
+	os.WriteFile(filepath.Join(cfg.AssetsPath("report-1"), "chart.png"), []byte("PNGDATA"), 0644)
+
+	response := get(t, handler, "/documents/report-1/assets/images/chart.png")
+	if response.Code != http.StatusOK || response.Body.String() != "PNGDATA" {
+		t.Errorf("Expected asset content, got %d: %s", response.Code, response.Body.String())
+	}
+
+	if response := get(t, handler, "/documents/report-1/assets/../manifest.yaml"); response.Code == http.StatusOK && strings.Contains(response.Body.String(), "document") {
+		t.Errorf("Asset serving must not escape the assets directory")
+	}
+}
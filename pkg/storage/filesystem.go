@@ -1,6 +1,7 @@
 package storage
 
 import (
+	"errors"
 	"fmt"
 	"os"
 	"path/filepath"
@@ -34,12 +35,21 @@ type Storage interface {
 	// Style operations (by name in styles folder)
 	SaveStyleByName(styleName string, style *types.Style) error
 	LoadStyleByName(styleName string) (*types.Style, error)
+	LoadStyleFields(styleName string) (map[string]interface{}, error)
 	EnsureDefaultStyle() error
 
 	// Pandoc config operations
 	SavePandocConfig(documentID string, config *types.PandocConfig) error
 	LoadPandocConfig(documentID string) (*types.PandocConfig, error)
 
+	// Writing statistics operations
+	SaveWritingStats(documentID string, stats *types.WritingStats) error
+	LoadWritingStats(documentID string) (*types.WritingStats, error)
+
+	// Export history operations
+	SaveExportHistory(documentID string, history *types.ExportHistory) error
+	LoadExportHistory(documentID string) (*types.ExportHistory, error)
+
 	// Chapter content operations
 	SaveChapterContent(documentID string, chapterNumber int, content string) error
 	LoadChapterContent(documentID string, chapterNumber int) (string, error)
@@ -47,14 +57,50 @@ type Storage interface {
 	// Chapter metadata operations
 	SaveChapterMetadata(documentID string, chapter *types.Chapter) error
 	LoadChapterMetadata(documentID string, chapterNumber int) (*types.Chapter, error)
+
+	// Chapter revision operations
+	SaveChapterRevisions(documentID string, chapterNumber int, revisions *types.ChapterRevisions) error
+	LoadChapterRevisions(documentID string, chapterNumber int) (*types.ChapterRevisions, error)
 	
 	// Section file operations
 	SaveSectionContent(documentID string, chapterNumber int, sectionNumber types.SectionNumber, content string) error
 	LoadSectionContent(documentID string, chapterNumber int, sectionNumber types.SectionNumber) (string, error)
 	DeleteSectionFile(documentID string, chapterNumber int, sectionNumber types.SectionNumber) error
 	CreateSectionsDirectory(documentID string, chapterNumber int) error
+	LoadLegacySectionContent(documentID string, chapterNumber int) (map[string]string, error)
+
+	// Asset operations
+	SaveAsset(documentID string, fileName string, data []byte) (string, error)
+
+	// Font operations: a document's fonts folder, or with an empty document ID
+	// the one shared by all documents
+	SaveFont(documentID string, fileName string, data []byte) (string, error)
+	ListFonts(documentID string) ([]string, error)
+
+	// Section template operations (by name in templates folder)
+	SaveSectionTemplate(template *types.SectionTemplate) error
+	LoadSectionTemplate(templateName string) (*types.SectionTemplate, error)
+
+	// House style operations (by name in house-styles folder)
+	SaveHouseStyle(style *types.HouseStyle) error
+	LoadHouseStyle(name string) (*types.HouseStyle, error)
+
+	// Usage ledger operations (shared by all documents)
+	SaveUsageLedger(ledger *types.UsageLedger) error
+	LoadUsageLedger() (*types.UsageLedger, error)
+
+	// Access policy operations (shared by all documents)
+	SaveAccessPolicy(policy *types.AccessPolicy) error
+	LoadAccessPolicy() (*types.AccessPolicy, error)
+
+	// Export hook operations: hooks are edited on disk, never through tools
+	LoadHooks() (*types.HookConfig, error)
+	LoadDocumentHooks(documentID string) (*types.HookConfig, error)
 }
 
+// errChapterNotFound reports a chapter number the chapter order has no directory for
+var errChapterNotFound = errors.New("not in the chapter order")
+
 // FileSystemStorage implements Storage using the local filesystem
 type FileSystemStorage struct {
 	config *config.Config
@@ -78,7 +124,7 @@ func (fs *FileSystemStorage) CreateDocumentStructure(doc *types.Document) error
 	}
 
 	// Create chapters directory
-	chaptersPath := filepath.Join(docPath, "chapters")
+	chaptersPath := fs.config.ChaptersPath(docID)
 	if err := os.MkdirAll(chaptersPath, 0755); err != nil {
 		return fmt.Errorf("failed to create chapters directory: %w", err)
 	}
@@ -160,9 +206,14 @@ func (fs *FileSystemStorage) ListDocuments() ([]string, error) {
 	return documents, nil
 }
 
-// CreateChapterStructure creates the directory structure for a new chapter
+// CreateChapterStructure creates the directory structure for a new chapter, in
+// the directory the chapter order gives its number
 func (fs *FileSystemStorage) CreateChapterStructure(documentID string, chapter *types.Chapter) error {
-	chapterPath := fs.config.ChapterPath(documentID, int(chapter.Number))
+	dir, err := fs.chapterDir(documentID, int(chapter.Number))
+	if err != nil {
+		return err
+	}
+	chapterPath := fs.config.ChapterPath(documentID, dir)
 
 	// Create chapter directory
 	if err := os.MkdirAll(chapterPath, 0755); err != nil {
@@ -189,8 +240,14 @@ func (fs *FileSystemStorage) CreateChapterStructure(documentID string, chapter *
 
 // ChapterExists checks if a chapter exists
 func (fs *FileSystemStorage) ChapterExists(documentID string, chapterNumber int) (bool, error) {
-	chapterPath := fs.config.ChapterPath(documentID, chapterNumber)
-	_, err := os.Stat(chapterPath)
+	dir, err := fs.chapterDir(documentID, chapterNumber)
+	if errors.Is(err, errChapterNotFound) || errors.Is(err, os.ErrNotExist) {
+		return false, nil
+	}
+	if err != nil {
+		return false, fmt.Errorf("failed to check chapter existence: %w", err)
+	}
+	_, err = os.Stat(fs.config.ChapterPath(documentID, dir))
 	if os.IsNotExist(err) {
 		return false, nil
 	}
@@ -202,13 +259,30 @@ func (fs *FileSystemStorage) ChapterExists(documentID string, chapterNumber int)
 
 // DeleteChapter removes a chapter directory and all its contents
 func (fs *FileSystemStorage) DeleteChapter(documentID string, chapterNumber int) error {
-	chapterPath := fs.config.ChapterPath(documentID, chapterNumber)
-	if err := os.RemoveAll(chapterPath); err != nil {
+	dir, err := fs.chapterDir(documentID, chapterNumber)
+	if err != nil {
+		return err
+	}
+	if err := os.RemoveAll(fs.config.ChapterPath(documentID, dir)); err != nil {
 		return fmt.Errorf("failed to delete chapter: %w", err)
 	}
 	return nil
 }
 
+// chapterDir returns the directory that holds a chapter, looked up in the
+// document's chapter order
+func (fs *FileSystemStorage) chapterDir(documentID string, chapterNumber int) (string, error) {
+	manifest, err := fs.LoadManifest(documentID)
+	if err != nil {
+		return "", fmt.Errorf("failed to load manifest: %w", err)
+	}
+	dir, ok := manifest.ChapterDir(types.ChapterNumber(chapterNumber))
+	if !ok {
+		return "", fmt.Errorf("chapter %d of document %s: %w", chapterNumber, documentID, errChapterNotFound)
+	}
+	return dir, nil
+}
+
 // SaveManifest saves the document manifest
 func (fs *FileSystemStorage) SaveManifest(documentID string, manifest *types.Manifest) error {
 	manifestPath := fs.config.ManifestPath(documentID)
@@ -222,6 +296,8 @@ func (fs *FileSystemStorage) LoadManifest(documentID string) (*types.Manifest, e
 	if err := fs.loadYAMLFile(manifestPath, &manifest); err != nil {
 		return nil, err
 	}
+	// Older manifests store a single author string
+	manifest.Document.MigrateLegacyAuthor()
 	return &manifest, nil
 }
 
@@ -257,10 +333,51 @@ func (fs *FileSystemStorage) LoadPandocConfig(documentID string) (*types.PandocC
 	return &pandocConfig, nil
 }
 
+// SaveWritingStats saves the word count history
+func (fs *FileSystemStorage) SaveWritingStats(documentID string, stats *types.WritingStats) error {
+	statsPath := fs.config.StatsPath(documentID)
+	return fs.saveYAMLFile(statsPath, stats)
+}
+
+// LoadWritingStats loads the word count history, which is empty until the first snapshot
+func (fs *FileSystemStorage) LoadWritingStats(documentID string) (*types.WritingStats, error) {
+	statsPath := fs.config.StatsPath(documentID)
+	var stats types.WritingStats
+	if _, err := os.Stat(statsPath); os.IsNotExist(err) {
+		return &stats, nil
+	}
+	if err := fs.loadYAMLFile(statsPath, &stats); err != nil {
+		return nil, err
+	}
+	return &stats, nil
+}
+
+// SaveExportHistory saves the registry of a document's exports
+func (fs *FileSystemStorage) SaveExportHistory(documentID string, history *types.ExportHistory) error {
+	return fs.saveYAMLFile(fs.config.ExportHistoryPath(documentID), history)
+}
+
+// LoadExportHistory loads the registry of a document's exports, which is empty
+// until the first export
+func (fs *FileSystemStorage) LoadExportHistory(documentID string) (*types.ExportHistory, error) {
+	historyPath := fs.config.ExportHistoryPath(documentID)
+	var history types.ExportHistory
+	if _, err := os.Stat(historyPath); os.IsNotExist(err) {
+		return &history, nil
+	}
+	if err := fs.loadYAMLFile(historyPath, &history); err != nil {
+		return nil, err
+	}
+	return &history, nil
+}
+
 // SaveChapterContent saves chapter content to the chapter.md file
 func (fs *FileSystemStorage) SaveChapterContent(documentID string, chapterNumber int, content string) error {
-	contentPath := fs.config.ChapterContentPath(documentID, chapterNumber)
-	if err := os.WriteFile(contentPath, []byte(content), 0644); err != nil {
+	dir, err := fs.chapterDir(documentID, chapterNumber)
+	if err != nil {
+		return err
+	}
+	if err := os.WriteFile(fs.config.ChapterContentPath(documentID, dir), []byte(content), 0644); err != nil {
 		return fmt.Errorf("failed to save chapter content: %w", err)
 	}
 	return nil
@@ -268,8 +385,11 @@ func (fs *FileSystemStorage) SaveChapterContent(documentID string, chapterNumber
 
 // LoadChapterContent loads chapter content from the chapter.md file
 func (fs *FileSystemStorage) LoadChapterContent(documentID string, chapterNumber int) (string, error) {
-	contentPath := fs.config.ChapterContentPath(documentID, chapterNumber)
-	content, err := os.ReadFile(contentPath)
+	dir, err := fs.chapterDir(documentID, chapterNumber)
+	if err != nil {
+		return "", err
+	}
+	content, err := os.ReadFile(fs.config.ChapterContentPath(documentID, dir))
 	if err != nil {
 		return "", fmt.Errorf("failed to load chapter content: %w", err)
 	}
@@ -278,20 +398,53 @@ func (fs *FileSystemStorage) LoadChapterContent(documentID string, chapterNumber
 
 // SaveChapterMetadata saves chapter metadata to the metadata.yaml file
 func (fs *FileSystemStorage) SaveChapterMetadata(documentID string, chapter *types.Chapter) error {
-	metadataPath := fs.config.ChapterMetadataPath(documentID, int(chapter.Number))
-	return fs.saveYAMLFile(metadataPath, chapter)
+	dir, err := fs.chapterDir(documentID, int(chapter.Number))
+	if err != nil {
+		return err
+	}
+	return fs.saveYAMLFile(fs.config.ChapterMetadataPath(documentID, dir), chapter)
 }
 
 // LoadChapterMetadata loads chapter metadata from the metadata.yaml file
 func (fs *FileSystemStorage) LoadChapterMetadata(documentID string, chapterNumber int) (*types.Chapter, error) {
-	metadataPath := fs.config.ChapterMetadataPath(documentID, chapterNumber)
+	dir, err := fs.chapterDir(documentID, chapterNumber)
+	if err != nil {
+		return nil, err
+	}
 	var chapter types.Chapter
-	if err := fs.loadYAMLFile(metadataPath, &chapter); err != nil {
+	if err := fs.loadYAMLFile(fs.config.ChapterMetadataPath(documentID, dir), &chapter); err != nil {
 		return nil, err
 	}
 	return &chapter, nil
 }
 
+// SaveChapterRevisions saves the registry of a chapter's revisions
+func (fs *FileSystemStorage) SaveChapterRevisions(documentID string, chapterNumber int, revisions *types.ChapterRevisions) error {
+	dir, err := fs.chapterDir(documentID, chapterNumber)
+	if err != nil {
+		return err
+	}
+	return fs.saveYAMLFile(fs.config.ChapterRevisionsPath(documentID, dir), revisions)
+}
+
+// LoadChapterRevisions loads the registry of a chapter's revisions, which is
+// empty until the first snapshot
+func (fs *FileSystemStorage) LoadChapterRevisions(documentID string, chapterNumber int) (*types.ChapterRevisions, error) {
+	dir, err := fs.chapterDir(documentID, chapterNumber)
+	if err != nil {
+		return nil, err
+	}
+	revisionsPath := fs.config.ChapterRevisionsPath(documentID, dir)
+	var revisions types.ChapterRevisions
+	if _, err := os.Stat(revisionsPath); os.IsNotExist(err) {
+		return &revisions, nil
+	}
+	if err := fs.loadYAMLFile(revisionsPath, &revisions); err != nil {
+		return nil, err
+	}
+	return &revisions, nil
+}
+
 // saveYAMLFile saves data to a YAML file
 func (fs *FileSystemStorage) saveYAMLFile(filePath string, data interface{}) error {
 	// Ensure directory exists
@@ -334,14 +487,17 @@ func (fs *FileSystemStorage) loadYAMLFile(filePath string, data interface{}) err
 
 // SaveSectionContent saves section content to individual section file
 func (fs *FileSystemStorage) SaveSectionContent(documentID string, chapterNumber int, sectionNumber types.SectionNumber, content string) error {
-	sectionPath := fs.config.SectionPath(documentID, chapterNumber, sectionNumber.String())
+	dir, err := fs.chapterDir(documentID, chapterNumber)
+	if err != nil {
+		return err
+	}
 	
 	// Ensure sections directory exists
-	if err := fs.CreateSectionsDirectory(documentID, chapterNumber); err != nil {
+	if err := os.MkdirAll(fs.config.SectionsPath(documentID, dir), 0755); err != nil {
 		return fmt.Errorf("failed to create sections directory: %w", err)
 	}
 	
-	if err := os.WriteFile(sectionPath, []byte(content), 0644); err != nil {
+	if err := os.WriteFile(fs.config.SectionPath(documentID, dir, sectionNumber.String()), []byte(content), 0644); err != nil {
 		return fmt.Errorf("failed to save section content: %w", err)
 	}
 	return nil
@@ -349,8 +505,11 @@ func (fs *FileSystemStorage) SaveSectionContent(documentID string, chapterNumber
 
 // LoadSectionContent loads section content from individual section file
 func (fs *FileSystemStorage) LoadSectionContent(documentID string, chapterNumber int, sectionNumber types.SectionNumber) (string, error) {
-	sectionPath := fs.config.SectionPath(documentID, chapterNumber, sectionNumber.String())
-	content, err := os.ReadFile(sectionPath)
+	dir, err := fs.chapterDir(documentID, chapterNumber)
+	if err != nil {
+		return "", err
+	}
+	content, err := os.ReadFile(fs.config.SectionPath(documentID, dir, sectionNumber.String()))
 	if err != nil {
 		return "", fmt.Errorf("failed to load section content: %w", err)
 	}
@@ -359,17 +518,49 @@ func (fs *FileSystemStorage) LoadSectionContent(documentID string, chapterNumber
 
 // DeleteSectionFile removes a section file
 func (fs *FileSystemStorage) DeleteSectionFile(documentID string, chapterNumber int, sectionNumber types.SectionNumber) error {
-	sectionPath := fs.config.SectionPath(documentID, chapterNumber, sectionNumber.String())
-	if err := os.Remove(sectionPath); err != nil && !os.IsNotExist(err) {
+	dir, err := fs.chapterDir(documentID, chapterNumber)
+	if err != nil {
+		return err
+	}
+	if err := os.Remove(fs.config.SectionPath(documentID, dir, sectionNumber.String())); err != nil && !os.IsNotExist(err) {
 		return fmt.Errorf("failed to delete section file: %w", err)
 	}
 	return nil
 }
 
+// LoadLegacySectionContent returns section bodies that older versions stored in
+// the chapter's metadata.yaml, keyed by section number
+func (fs *FileSystemStorage) LoadLegacySectionContent(documentID string, chapterNumber int) (map[string]string, error) {
+	var legacy struct {
+		Sections []struct {
+			Number  types.SectionNumber `yaml:"number"`
+			Content string              `yaml:"content"`
+		} `yaml:"sections"`
+	}
+	dir, err := fs.chapterDir(documentID, chapterNumber)
+	if err != nil {
+		return nil, err
+	}
+	if err := fs.loadYAMLFile(fs.config.ChapterMetadataPath(documentID, dir), &legacy); err != nil {
+		return nil, err
+	}
+
+	content := make(map[string]string)
+	for _, section := range legacy.Sections {
+		if section.Content != "" {
+			content[section.Number.String()] = section.Content
+		}
+	}
+	return content, nil
+}
+
 // CreateSectionsDirectory creates the sections subdirectory for a chapter
 func (fs *FileSystemStorage) CreateSectionsDirectory(documentID string, chapterNumber int) error {
-	sectionsDir := fs.config.SectionsPath(documentID, chapterNumber)
-	if err := os.MkdirAll(sectionsDir, 0755); err != nil {
+	dir, err := fs.chapterDir(documentID, chapterNumber)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(fs.config.SectionsPath(documentID, dir), 0755); err != nil {
 		return fmt.Errorf("failed to create sections directory: %w", err)
 	}
 	return nil
@@ -391,6 +582,17 @@ func (fs *FileSystemStorage) LoadStyleByName(styleName string) (*types.Style, er
 	return &style, nil
 }
 
+// LoadStyleFields loads a style by name as the fields its file sets, so a style
+// that extends another can tell the fields it overrides from those it leaves out
+func (fs *FileSystemStorage) LoadStyleFields(styleName string) (map[string]interface{}, error) {
+	stylePath := fs.config.StyleByNamePath(styleName)
+	fields := make(map[string]interface{})
+	if err := fs.loadYAMLFile(stylePath, &fields); err != nil {
+		return nil, err
+	}
+	return fields, nil
+}
+
 // EnsureDefaultStyle creates the default style if it doesn't exist
 func (fs *FileSystemStorage) EnsureDefaultStyle() error {
 	defaultStylePath := fs.config.StyleByNamePath("default")
@@ -413,4 +615,178 @@ func (fs *FileSystemStorage) EnsureDefaultStyle() error {
 	}
 	
 	return nil
-}
\ No newline at end of file
+}
+
+// SaveAsset writes binary asset data into the document's assets/images directory
+// and returns the full path of the written file
+func (fs *FileSystemStorage) SaveAsset(documentID string, fileName string, data []byte) (string, error) {
+	if fileName == "" || fileName != filepath.Base(fileName) {
+		return "", fmt.Errorf("invalid asset file name: %q", fileName)
+	}
+
+	assetsDir := fs.config.AssetsPath(documentID)
+	if err := os.MkdirAll(assetsDir, 0755); err != nil {
+		return "", fmt.Errorf("failed to create assets directory: %w", err)
+	}
+
+	assetPath := filepath.Join(assetsDir, fileName)
+	if err := os.WriteFile(assetPath, data, 0644); err != nil {
+		return "", fmt.Errorf("failed to save asset: %w", err)
+	}
+	return assetPath, nil
+}
+
+// fontsPath returns a document's fonts folder, or the shared one
+func (fs *FileSystemStorage) fontsPath(documentID string) string {
+	if documentID == "" {
+		return fs.config.FontsPath()
+	}
+	return fs.config.DocumentFontsPath(documentID)
+}
+
+// SaveFont writes a font file to a document's fonts folder or the shared one
+// and returns its path
+func (fs *FileSystemStorage) SaveFont(documentID string, fileName string, data []byte) (string, error) {
+	if err := types.ValidateFontFileName(fileName); err != nil {
+		return "", err
+	}
+
+	fontsDir := fs.fontsPath(documentID)
+	if err := os.MkdirAll(fontsDir, 0755); err != nil {
+		return "", fmt.Errorf("failed to create fonts directory: %w", err)
+	}
+
+	fontPath := filepath.Join(fontsDir, fileName)
+	if err := os.WriteFile(fontPath, data, 0644); err != nil {
+		return "", fmt.Errorf("failed to save font: %w", err)
+	}
+	return fontPath, nil
+}
+
+// ListFonts returns the names of the font files in a document's fonts folder
+// or the shared one, sorted
+func (fs *FileSystemStorage) ListFonts(documentID string) ([]string, error) {
+	entries, err := os.ReadDir(fs.fontsPath(documentID))
+	if os.IsNotExist(err) {
+		return []string{}, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to list fonts: %w", err)
+	}
+
+	fonts := []string{}
+	for _, entry := range entries {
+		if entry.Type().IsRegular() && types.ValidateFontFileName(entry.Name()) == nil {
+			fonts = append(fonts, entry.Name())
+		}
+	}
+	return fonts, nil
+}
+
+// SaveSectionTemplate saves a section template by name to the templates folder
+func (fs *FileSystemStorage) SaveSectionTemplate(template *types.SectionTemplate) error {
+	templatePath := fs.config.SectionTemplatePath(template.Name)
+	return fs.saveYAMLFile(templatePath, template)
+}
+
+// LoadSectionTemplate loads a section template by name from the templates folder
+func (fs *FileSystemStorage) LoadSectionTemplate(templateName string) (*types.SectionTemplate, error) {
+	templatePath := fs.config.SectionTemplatePath(templateName)
+	if _, err := os.Stat(templatePath); os.IsNotExist(err) {
+		return nil, fmt.Errorf("section template %s not found", templateName)
+	}
+	var template types.SectionTemplate
+	if err := fs.loadYAMLFile(templatePath, &template); err != nil {
+		return nil, err
+	}
+	return &template, nil
+}
+
+// SaveHouseStyle saves a house style ruleset by name to the house-styles folder
+func (fs *FileSystemStorage) SaveHouseStyle(style *types.HouseStyle) error {
+	stylePath := fs.config.HouseStylePath(style.Name)
+	return fs.saveYAMLFile(stylePath, style)
+}
+
+// LoadHouseStyle loads a house style ruleset by name from the house-styles folder
+func (fs *FileSystemStorage) LoadHouseStyle(name string) (*types.HouseStyle, error) {
+	stylePath := fs.config.HouseStylePath(name)
+	if _, err := os.Stat(stylePath); os.IsNotExist(err) {
+		return nil, fmt.Errorf("house style %s not found", name)
+	}
+	var style types.HouseStyle
+	if err := fs.loadYAMLFile(stylePath, &style); err != nil {
+		return nil, err
+	}
+	if style.Name == "" {
+		style.Name = name
+	}
+	return &style, nil
+}
+
+// SaveUsageLedger saves the per-client usage ledger
+func (fs *FileSystemStorage) SaveUsageLedger(ledger *types.UsageLedger) error {
+	return fs.saveYAMLFile(fs.config.UsagePath(), ledger)
+}
+
+// LoadUsageLedger loads the per-client usage ledger, which is empty until usage is recorded
+func (fs *FileSystemStorage) LoadUsageLedger() (*types.UsageLedger, error) {
+	ledger := types.UsageLedger{Clients: make(map[string]*types.ClientUsage)}
+	usagePath := fs.config.UsagePath()
+	if _, err := os.Stat(usagePath); os.IsNotExist(err) {
+		return &ledger, nil
+	}
+	if err := fs.loadYAMLFile(usagePath, &ledger); err != nil {
+		return nil, err
+	}
+	if ledger.Clients == nil {
+		ledger.Clients = make(map[string]*types.ClientUsage)
+	}
+	return &ledger, nil
+}
+
+// SaveAccessPolicy saves the role-based access policy
+func (fs *FileSystemStorage) SaveAccessPolicy(policy *types.AccessPolicy) error {
+	return fs.saveYAMLFile(fs.config.AccessPolicyPath(), policy)
+}
+
+// LoadAccessPolicy loads the role-based access policy. A missing policy grants no access.
+func (fs *FileSystemStorage) LoadAccessPolicy() (*types.AccessPolicy, error) {
+	var policy types.AccessPolicy
+	policyPath := fs.config.AccessPolicyPath()
+	if _, err := os.Stat(policyPath); os.IsNotExist(err) {
+		return &policy, nil
+	}
+	if err := fs.loadYAMLFile(policyPath, &policy); err != nil {
+		return nil, err
+	}
+	return &policy, nil
+}
+
+// LoadHooks loads the export hooks run for every document. There are none
+// without a hooks file.
+func (fs *FileSystemStorage) LoadHooks() (*types.HookConfig, error) {
+	return fs.loadHookConfig(fs.config.HooksPath())
+}
+
+// LoadDocumentHooks loads a document's own export hooks
+func (fs *FileSystemStorage) LoadDocumentHooks(documentID string) (*types.HookConfig, error) {
+	return fs.loadHookConfig(fs.config.DocumentHooksPath(documentID))
+}
+
+// loadHookConfig loads and validates a hooks file
+func (fs *FileSystemStorage) loadHookConfig(path string) (*types.HookConfig, error) {
+	var hooks types.HookConfig
+	if _, err := os.Stat(path); os.IsNotExist(err) {
+		return &hooks, nil
+	}
+	if err := fs.loadYAMLFile(path, &hooks); err != nil {
+		return nil, err
+	}
+	for _, hook := range hooks.Hooks {
+		if err := hook.Validate(); err != nil {
+			return nil, fmt.Errorf("invalid hook in %s: %w", path, err)
+		}
+	}
+	return &hooks, nil
+}
@@ -25,10 +25,10 @@ func TestFileSystemStorage_CreateDocumentStructure(t *testing.T) {
 
 	docID := types.DocumentID("test-doc")
 	doc := &types.Document{
-		ID:     docID,
-		Title:  "Test Document",
-		Author: "Test Author",
-		Type:   types.DocumentTypeBook,
+		ID:      docID,
+		Title:   "Test Document",
+		Authors: types.AuthorList{{Name: "Test Author"}},
+		Type:    types.DocumentTypeBook,
 	}
 
 	err = storage.CreateDocumentStructure(doc)
@@ -125,10 +125,10 @@ func TestFileSystemStorage_DeleteDocument(t *testing.T) {
 	// Create a document first
 	docID := "test-doc"
 	doc := &types.Document{
-		ID:     types.DocumentID(docID),
-		Title:  "Test Document",
-		Author: "Test Author",
-		Type:   types.DocumentTypeBook,
+		ID:      types.DocumentID(docID),
+		Title:   "Test Document",
+		Authors: types.AuthorList{{Name: "Test Author"}},
+		Type:    types.DocumentTypeBook,
 	}
 
 	err = storage.CreateDocumentStructure(doc)
@@ -177,10 +177,10 @@ func TestFileSystemStorage_CreateChapterStructure(t *testing.T) {
 	// Create document first
 	docID := "test-doc"
 	doc := &types.Document{
-		ID:     types.DocumentID(docID),
-		Title:  "Test Document",
-		Author: "Test Author",
-		Type:   types.DocumentTypeBook,
+		ID:      types.DocumentID(docID),
+		Title:   "Test Document",
+		Authors: types.AuthorList{{Name: "Test Author"}},
+		Type:    types.DocumentTypeBook,
 	}
 
 	err = storage.CreateDocumentStructure(doc)
@@ -201,20 +201,97 @@ func TestFileSystemStorage_CreateChapterStructure(t *testing.T) {
 	}
 
 	// Check if chapter directory exists
-	chapterPath := cfg.ChapterPath(docID, int(chapter.Number))
+	chapterPath := cfg.ChapterPath(docID, types.LegacyChapterDir(chapter.Number))
 	if _, err := os.Stat(chapterPath); os.IsNotExist(err) {
 		t.Errorf("Chapter directory not created: %s", chapterPath)
 	}
 
 	// Check if chapter.md file exists
-	contentPath := cfg.ChapterContentPath(docID, int(chapter.Number))
+	contentPath := cfg.ChapterContentPath(docID, types.LegacyChapterDir(chapter.Number))
 	if _, err := os.Stat(contentPath); os.IsNotExist(err) {
 		t.Errorf("Chapter content file not created: %s", contentPath)
 	}
 
 	// Check if metadata.yaml file exists
-	metadataPath := cfg.ChapterMetadataPath(docID, int(chapter.Number))
+	metadataPath := cfg.ChapterMetadataPath(docID, types.LegacyChapterDir(chapter.Number))
 	if _, err := os.Stat(metadataPath); os.IsNotExist(err) {
 		t.Errorf("Chapter metadata file not created: %s", metadataPath)
 	}
-}
\ No newline at end of file
+}
+func TestFileSystemStorage_LoadManifest_LegacyAuthor(t *testing.T) {
+	tempDir, err := os.MkdirTemp("", "docgen_test_")
+	if err != nil {
+		t.Fatalf("Failed to create temp dir: %v", err)
+	}
+	defer os.RemoveAll(tempDir)
+
+	cfg := &config.Config{
+		RootDir: tempDir,
+	}
+
+	storage := NewFileSystemStorage(cfg)
+
+	// Write a manifest in the pre-multi-author format
+	docID := "legacy-doc"
+	if err := os.MkdirAll(cfg.DocumentPath(docID), 0755); err != nil {
+		t.Fatalf("Failed to create document dir: %v", err)
+	}
+	legacy := "document:\n  id: legacy-doc\n  title: Legacy Document\n  author: Old Author\n  type: book\n"
+	if err := os.WriteFile(cfg.ManifestPath(docID), []byte(legacy), 0644); err != nil {
+		t.Fatalf("Failed to write manifest: %v", err)
+	}
+
+	manifest, err := storage.LoadManifest(docID)
+	if err != nil {
+		t.Fatalf("LoadManifest() error = %v", err)
+	}
+
+	if len(manifest.Document.Authors) != 1 || manifest.Document.Authors[0].Name != "Old Author" {
+		t.Errorf("Legacy author not migrated, got %+v", manifest.Document.Authors)
+	}
+	if manifest.Document.LegacyAuthor != "" {
+		t.Errorf("LegacyAuthor should be cleared after migration")
+	}
+}
+
+func TestFileSystemStorage_LoadHooks(t *testing.T) {
+	tempDir, err := os.MkdirTemp("", "docgen_test_")
+	if err != nil {
+		t.Fatalf("Failed to create temp dir: %v", err)
+	}
+	defer os.RemoveAll(tempDir)
+
+	cfg := &config.Config{
+		RootDir: tempDir,
+	}
+
+	storage := NewFileSystemStorage(cfg)
+
+	hooks, err := storage.LoadHooks()
+	if err != nil || len(hooks.Hooks) != 0 {
+		t.Fatalf("LoadHooks() = %+v, %v without a hooks file", hooks, err)
+	}
+
+	global := "hooks:\n  - name: notify\n    event: post_export\n    url: https://chat.example.com/hook\n    formats: [pdf]\n"
+	if err := os.WriteFile(cfg.HooksPath(), []byte(global), 0644); err != nil {
+		t.Fatal(err)
+	}
+	hooks, err = storage.LoadHooks()
+	if err != nil {
+		t.Fatalf("LoadHooks() error = %v", err)
+	}
+	if len(hooks.Hooks) != 1 || hooks.Hooks[0].URL != "https://chat.example.com/hook" || !hooks.Hooks[0].RunsFor(types.ExportFormatPDF) || hooks.Hooks[0].RunsFor(types.ExportFormatHTML) {
+		t.Errorf("Unexpected hooks %+v", hooks.Hooks)
+	}
+
+	// A hook with both a command and a URL is rejected
+	docID := "hooked-doc"
+	os.MkdirAll(cfg.DocumentPath(docID), 0755)
+	invalid := "hooks:\n  - event: post_markdown\n    command: [./filter.sh]\n    url: https://example.com\n"
+	if err := os.WriteFile(cfg.DocumentHooksPath(docID), []byte(invalid), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := storage.LoadDocumentHooks(docID); err == nil {
+		t.Error("Expected an error for a hook with a command and a URL")
+	}
+}
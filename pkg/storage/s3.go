@@ -0,0 +1,797 @@
+package storage
+
+import (
+	"context"
+	"crypto/md5"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"github.com/gomcpgo/docgen/pkg/config"
+	"github.com/gomcpgo/docgen/pkg/types"
+)
+
+// Syncer is implemented by storage that keeps documents somewhere other than
+// RootDir and caches them there. Code that reads or changes a document's files
+// directly, such as exports and chapter renumbering, syncs the cache first and
+// publishes its changes afterwards.
+type Syncer interface {
+	// SyncDocument makes the local copy of a document match the store,
+	// stopping when ctx is done
+	SyncDocument(ctx context.Context, documentID string) error
+	// PublishDocument makes the store match the local copy of a document
+	PublishDocument(documentID string) error
+}
+
+// New creates the storage configured by cfg.StorageBackend
+func New(cfg *config.Config) (Storage, error) {
+	switch cfg.StorageBackend {
+	case "", "filesystem":
+		return NewFileSystemStorage(cfg), nil
+	case "s3":
+		return NewS3Storage(cfg), nil
+	default:
+		return nil, fmt.Errorf("unsupported storage backend: %s", cfg.StorageBackend)
+	}
+}
+
+// S3Storage implements Storage on an S3-compatible object store, so that any
+// number of servers can share documents without shared disk. Objects are keyed
+// by their path under RootDir, which serves as a write-through cache: every
+// save goes to the store, every load reads from it, and the files exports are
+// built from are fetched by SyncDocument. Manifests are written with
+// conditional puts that must match the ETag the manifest was loaded with, so
+// a stale manifest can't overwrite a newer one.
+type S3Storage struct {
+	config *config.Config
+	local  *FileSystemStorage
+	client *s3Client
+}
+
+// NewS3Storage creates storage on the bucket in cfg.S3, cached under cfg.RootDir
+func NewS3Storage(cfg *config.Config) *S3Storage {
+	return &S3Storage{
+		config: cfg,
+		local:  &FileSystemStorage{config: cfg},
+		client: newS3Client(cfg.S3),
+	}
+}
+
+// key returns the object key for a path under RootDir
+func (s *S3Storage) key(localPath string) (string, error) {
+	rel, err := filepath.Rel(s.config.RootDir, localPath)
+	if err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		return "", fmt.Errorf("path %s is outside the root directory", localPath)
+	}
+	prefix := strings.Trim(s.config.S3.Prefix, "/")
+	if rel == "." {
+		return prefix, nil
+	}
+	return path.Join(prefix, filepath.ToSlash(rel)), nil
+}
+
+// localPath returns the path under RootDir for an object key
+func (s *S3Storage) localPath(key string) string {
+	rel := strings.TrimPrefix(key, strings.Trim(s.config.S3.Prefix, "/"))
+	return filepath.Join(s.config.RootDir, filepath.FromSlash(strings.TrimPrefix(rel, "/")))
+}
+
+// dirKey returns the key prefix of everything under a directory
+func (s *S3Storage) dirKey(dir string) (string, error) {
+	key, err := s.key(dir)
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimPrefix(key+"/", "/"), nil
+}
+
+// pull copies an object to its local path, removing the local file when the
+// store doesn't have it, and returns the object's ETag
+func (s *S3Storage) pull(localPath string) (string, error) {
+	key, err := s.key(localPath)
+	if err != nil {
+		return "", err
+	}
+	data, etag, err := s.client.GetObject(key)
+	if errors.Is(err, ErrObjectNotFound) {
+		if err := os.Remove(localPath); err != nil && !os.IsNotExist(err) {
+			return "", fmt.Errorf("failed to remove stale cache file: %w", err)
+		}
+		return "", nil
+	}
+	if err != nil {
+		return "", fmt.Errorf("failed to fetch %s: %w", key, err)
+	}
+	if err := writeCacheFile(localPath, data); err != nil {
+		return "", err
+	}
+	return etag, nil
+}
+
+// push copies a local file to the store and returns the object's ETag
+func (s *S3Storage) push(localPath, ifMatch string) (string, error) {
+	key, err := s.key(localPath)
+	if err != nil {
+		return "", err
+	}
+	data, err := os.ReadFile(localPath)
+	if err != nil {
+		return "", fmt.Errorf("failed to read %s: %w", localPath, err)
+	}
+	etag, err := s.client.PutObject(key, data, ifMatch)
+	if err != nil {
+		return "", fmt.Errorf("failed to store %s: %w", key, err)
+	}
+	return etag, nil
+}
+
+// remove deletes a file from the store and the cache
+func (s *S3Storage) remove(localPath string) error {
+	key, err := s.key(localPath)
+	if err != nil {
+		return err
+	}
+	if err := s.client.DeleteObject(key); err != nil {
+		return fmt.Errorf("failed to delete %s: %w", key, err)
+	}
+	if err := os.Remove(localPath); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	return nil
+}
+
+// removeTree deletes every object under a directory
+func (s *S3Storage) removeTree(dir string) error {
+	prefix, err := s.dirKey(dir)
+	if err != nil {
+		return err
+	}
+	objects, _, err := s.client.ListObjects(prefix, "", 0)
+	if err != nil {
+		return fmt.Errorf("failed to list %s: %w", prefix, err)
+	}
+	for _, object := range objects {
+		if err := s.client.DeleteObject(object.Key); err != nil {
+			return fmt.Errorf("failed to delete %s: %w", object.Key, err)
+		}
+	}
+	return nil
+}
+
+// hasObjects reports whether the store holds anything under a directory
+func (s *S3Storage) hasObjects(dir string) (bool, error) {
+	prefix, err := s.dirKey(dir)
+	if err != nil {
+		return false, err
+	}
+	objects, _, err := s.client.ListObjects(prefix, "", 1)
+	if err != nil {
+		return false, fmt.Errorf("failed to list %s: %w", prefix, err)
+	}
+	return len(objects) > 0, nil
+}
+
+// save writes a file through the local storage, then copies it to the store
+func (s *S3Storage) save(localPath string, write func() error) error {
+	if err := write(); err != nil {
+		return err
+	}
+	_, err := s.push(localPath, "")
+	return err
+}
+
+// SyncDocument downloads the objects of a document that differ from the cache
+// and removes cached files the store no longer holds. The shared fonts are
+// downloaded along with it.
+func (s *S3Storage) SyncDocument(ctx context.Context, documentID string) error {
+	docPath := s.config.DocumentPath(documentID)
+	prefix, err := s.dirKey(docPath)
+	if err != nil {
+		return err
+	}
+	objects, _, err := s.client.ListObjects(prefix, "", 0)
+	if err != nil {
+		return fmt.Errorf("failed to list document %s: %w", documentID, err)
+	}
+
+	remote := make(map[string]bool)
+	for _, object := range objects {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		localPath := s.localPath(object.Key)
+		remote[localPath] = true
+		if sameContent(localPath, object.ETag) {
+			continue
+		}
+		data, _, err := s.client.GetObject(object.Key)
+		if err != nil {
+			return fmt.Errorf("failed to fetch %s: %w", object.Key, err)
+		}
+		if err := writeCacheFile(localPath, data); err != nil {
+			return err
+		}
+	}
+
+	// Exports of the document may load the shared fonts
+	if _, err := s.ListFonts(""); err != nil {
+		return err
+	}
+
+	return walkLocalFiles(docPath, func(localPath string) error {
+		if remote[localPath] {
+			return nil
+		}
+		return os.Remove(localPath)
+	})
+}
+
+// PublishDocument uploads the cached files of a document that differ from the
+// store and deletes objects that are no longer cached
+func (s *S3Storage) PublishDocument(documentID string) error {
+	docPath := s.config.DocumentPath(documentID)
+	prefix, err := s.dirKey(docPath)
+	if err != nil {
+		return err
+	}
+	objects, _, err := s.client.ListObjects(prefix, "", 0)
+	if err != nil {
+		return fmt.Errorf("failed to list document %s: %w", documentID, err)
+	}
+	remote := make(map[string]string)
+	for _, object := range objects {
+		remote[s.localPath(object.Key)] = object.ETag
+	}
+
+	local := make(map[string]bool)
+	err = walkLocalFiles(docPath, func(localPath string) error {
+		local[localPath] = true
+		if etag, ok := remote[localPath]; ok && sameContent(localPath, etag) {
+			return nil
+		}
+		_, err := s.push(localPath, "")
+		return err
+	})
+	if err != nil {
+		return err
+	}
+
+	for _, object := range objects {
+		if local[s.localPath(object.Key)] {
+			continue
+		}
+		if err := s.client.DeleteObject(object.Key); err != nil {
+			return fmt.Errorf("failed to delete %s: %w", object.Key, err)
+		}
+	}
+	return nil
+}
+
+// CreateDocumentStructure creates a new document, failing if the store already has its manifest
+func (s *S3Storage) CreateDocumentStructure(doc *types.Document) error {
+	docID := string(doc.ID)
+	if err := s.local.CreateDocumentStructure(doc); err != nil {
+		return err
+	}
+	_, err := s.push(s.config.ManifestPath(docID), noObject)
+	if errors.Is(err, ErrPreconditionFailed) {
+		return fmt.Errorf("document %s already exists in the store", docID)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to create manifest: %w", err)
+	}
+	if _, err := s.push(s.config.PandocConfigPath(docID), ""); err != nil {
+		return fmt.Errorf("failed to create pandoc config: %w", err)
+	}
+	return nil
+}
+
+// DocumentExists checks if the store holds anything for a document
+func (s *S3Storage) DocumentExists(documentID string) (bool, error) {
+	exists, err := s.hasObjects(s.config.DocumentPath(documentID))
+	if err != nil {
+		return false, fmt.Errorf("failed to check document existence: %w", err)
+	}
+	return exists, nil
+}
+
+// DeleteDocument removes a document from the store and the cache
+func (s *S3Storage) DeleteDocument(documentID string) error {
+	if err := s.removeTree(s.config.DocumentPath(documentID)); err != nil {
+		return fmt.Errorf("failed to delete document: %w", err)
+	}
+	return s.local.DeleteDocument(documentID)
+}
+
+// ListDocuments returns the IDs of documents with a manifest in the store
+func (s *S3Storage) ListDocuments() ([]string, error) {
+	rootPrefix, err := s.dirKey(s.config.RootDir)
+	if err != nil {
+		return nil, err
+	}
+	_, prefixes, err := s.client.ListObjects(rootPrefix, "/", 0)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list documents: %w", err)
+	}
+
+	var documents []string
+	for _, prefix := range prefixes {
+		documentID := strings.TrimSuffix(strings.TrimPrefix(prefix, rootPrefix), "/")
+		manifestKey, err := s.key(s.config.ManifestPath(documentID))
+		if err != nil {
+			continue
+		}
+		objects, _, err := s.client.ListObjects(manifestKey, "", 1)
+		if err != nil {
+			return nil, fmt.Errorf("failed to list documents: %w", err)
+		}
+		if len(objects) > 0 && objects[0].Key == manifestKey {
+			documents = append(documents, documentID)
+		}
+	}
+	return documents, nil
+}
+
+// CreateChapterStructure creates a chapter's files and stores them
+func (s *S3Storage) CreateChapterStructure(documentID string, chapter *types.Chapter) error {
+	if err := s.local.CreateChapterStructure(documentID, chapter); err != nil {
+		return err
+	}
+	dir, err := s.chapterDir(documentID, int(chapter.Number))
+	if err != nil {
+		return err
+	}
+	return walkLocalFiles(s.config.ChapterPath(documentID, dir), func(localPath string) error {
+		_, err := s.push(localPath, "")
+		return err
+	})
+}
+
+// ChapterExists checks if the store holds anything for a chapter
+func (s *S3Storage) ChapterExists(documentID string, chapterNumber int) (bool, error) {
+	dir, err := s.chapterDir(documentID, chapterNumber)
+	if errors.Is(err, errChapterNotFound) || errors.Is(err, os.ErrNotExist) {
+		return false, nil
+	}
+	if err != nil {
+		return false, fmt.Errorf("failed to check chapter existence: %w", err)
+	}
+	exists, err := s.hasObjects(s.config.ChapterPath(documentID, dir))
+	if err != nil {
+		return false, fmt.Errorf("failed to check chapter existence: %w", err)
+	}
+	return exists, nil
+}
+
+// DeleteChapter removes a chapter from the store and the cache
+func (s *S3Storage) DeleteChapter(documentID string, chapterNumber int) error {
+	dir, err := s.chapterDir(documentID, chapterNumber)
+	if err != nil {
+		return err
+	}
+	if err := s.removeTree(s.config.ChapterPath(documentID, dir)); err != nil {
+		return fmt.Errorf("failed to delete chapter: %w", err)
+	}
+	return s.local.DeleteChapter(documentID, chapterNumber)
+}
+
+// chapterDir looks a chapter up in the cached manifest, fetching the manifest
+// first when this server has none
+func (s *S3Storage) chapterDir(documentID string, chapterNumber int) (string, error) {
+	manifestPath := s.config.ManifestPath(documentID)
+	if _, err := os.Stat(manifestPath); os.IsNotExist(err) {
+		if _, err := s.pull(manifestPath); err != nil {
+			return "", err
+		}
+	}
+	return s.local.chapterDir(documentID, chapterNumber)
+}
+
+// SaveManifest stores the manifest only if nobody has changed it since it was
+// loaded, and records the new ETag on it. A manifest that wasn't loaded from
+// the store can only create one.
+func (s *S3Storage) SaveManifest(documentID string, manifest *types.Manifest) error {
+	manifestPath := s.config.ManifestPath(documentID)
+	if err := s.local.SaveManifest(documentID, manifest); err != nil {
+		return err
+	}
+	ifMatch := manifest.ETag
+	if ifMatch == "" {
+		ifMatch = noObject
+	}
+	etag, err := s.push(manifestPath, ifMatch)
+	if errors.Is(err, ErrPreconditionFailed) {
+		// Drop the rejected copy so that the next load fetches the current one
+		os.Remove(manifestPath)
+		return fmt.Errorf("manifest of %s was changed since it was loaded; load it again and retry: %w", documentID, err)
+	}
+	if err != nil {
+		return err
+	}
+	manifest.ETag = etag
+	return nil
+}
+
+// LoadManifest fetches the manifest along with the ETag its save must match
+func (s *S3Storage) LoadManifest(documentID string) (*types.Manifest, error) {
+	etag, err := s.pull(s.config.ManifestPath(documentID))
+	if err != nil {
+		return nil, err
+	}
+	manifest, err := s.local.LoadManifest(documentID)
+	if err != nil {
+		return nil, err
+	}
+	manifest.ETag = etag
+	return manifest, nil
+}
+
+// SaveStyle stores a document-specific style
+func (s *S3Storage) SaveStyle(documentID string, style *types.Style) error {
+	return s.save(s.config.StylePath(documentID), func() error { return s.local.SaveStyle(documentID, style) })
+}
+
+// LoadStyle fetches a document-specific style
+func (s *S3Storage) LoadStyle(documentID string) (*types.Style, error) {
+	if _, err := s.pull(s.config.StylePath(documentID)); err != nil {
+		return nil, err
+	}
+	return s.local.LoadStyle(documentID)
+}
+
+// SaveStyleByName stores a style in the styles folder
+func (s *S3Storage) SaveStyleByName(styleName string, style *types.Style) error {
+	return s.save(s.config.StyleByNamePath(styleName), func() error { return s.local.SaveStyleByName(styleName, style) })
+}
+
+// LoadStyleByName fetches a style from the styles folder
+func (s *S3Storage) LoadStyleByName(styleName string) (*types.Style, error) {
+	if _, err := s.pull(s.config.StyleByNamePath(styleName)); err != nil {
+		return nil, err
+	}
+	return s.local.LoadStyleByName(styleName)
+}
+
+// LoadStyleFields fetches a style from the styles folder as the fields it sets
+func (s *S3Storage) LoadStyleFields(styleName string) (map[string]interface{}, error) {
+	if _, err := s.pull(s.config.StyleByNamePath(styleName)); err != nil {
+		return nil, err
+	}
+	return s.local.LoadStyleFields(styleName)
+}
+
+// EnsureDefaultStyle stores the default style if the store doesn't have one
+func (s *S3Storage) EnsureDefaultStyle() error {
+	defaultStylePath := s.config.StyleByNamePath("default")
+	if _, err := s.pull(defaultStylePath); err != nil {
+		return err
+	}
+	if _, err := os.Stat(defaultStylePath); err == nil {
+		return nil
+	}
+	return s.save(defaultStylePath, s.local.EnsureDefaultStyle)
+}
+
+// SavePandocConfig stores a document's pandoc config
+func (s *S3Storage) SavePandocConfig(documentID string, config *types.PandocConfig) error {
+	return s.save(s.config.PandocConfigPath(documentID), func() error { return s.local.SavePandocConfig(documentID, config) })
+}
+
+// LoadPandocConfig fetches a document's pandoc config
+func (s *S3Storage) LoadPandocConfig(documentID string) (*types.PandocConfig, error) {
+	if _, err := s.pull(s.config.PandocConfigPath(documentID)); err != nil {
+		return nil, err
+	}
+	return s.local.LoadPandocConfig(documentID)
+}
+
+// SaveWritingStats stores a document's writing statistics
+func (s *S3Storage) SaveWritingStats(documentID string, stats *types.WritingStats) error {
+	return s.save(s.config.StatsPath(documentID), func() error { return s.local.SaveWritingStats(documentID, stats) })
+}
+
+// LoadWritingStats fetches a document's writing statistics
+func (s *S3Storage) LoadWritingStats(documentID string) (*types.WritingStats, error) {
+	if _, err := s.pull(s.config.StatsPath(documentID)); err != nil {
+		return nil, err
+	}
+	return s.local.LoadWritingStats(documentID)
+}
+
+// SaveExportHistory stores the registry of a document's exports
+func (s *S3Storage) SaveExportHistory(documentID string, history *types.ExportHistory) error {
+	return s.save(s.config.ExportHistoryPath(documentID), func() error { return s.local.SaveExportHistory(documentID, history) })
+}
+
+// LoadExportHistory fetches the registry of a document's exports
+func (s *S3Storage) LoadExportHistory(documentID string) (*types.ExportHistory, error) {
+	if _, err := s.pull(s.config.ExportHistoryPath(documentID)); err != nil {
+		return nil, err
+	}
+	return s.local.LoadExportHistory(documentID)
+}
+
+// SaveChapterContent stores a chapter's assembled markdown
+func (s *S3Storage) SaveChapterContent(documentID string, chapterNumber int, content string) error {
+	dir, err := s.chapterDir(documentID, chapterNumber)
+	if err != nil {
+		return err
+	}
+	return s.save(s.config.ChapterContentPath(documentID, dir), func() error {
+		return s.local.SaveChapterContent(documentID, chapterNumber, content)
+	})
+}
+
+// LoadChapterContent fetches a chapter's assembled markdown
+func (s *S3Storage) LoadChapterContent(documentID string, chapterNumber int) (string, error) {
+	dir, err := s.chapterDir(documentID, chapterNumber)
+	if err != nil {
+		return "", err
+	}
+	if _, err := s.pull(s.config.ChapterContentPath(documentID, dir)); err != nil {
+		return "", err
+	}
+	return s.local.LoadChapterContent(documentID, chapterNumber)
+}
+
+// SaveChapterMetadata stores a chapter's metadata
+func (s *S3Storage) SaveChapterMetadata(documentID string, chapter *types.Chapter) error {
+	dir, err := s.chapterDir(documentID, int(chapter.Number))
+	if err != nil {
+		return err
+	}
+	return s.save(s.config.ChapterMetadataPath(documentID, dir), func() error {
+		return s.local.SaveChapterMetadata(documentID, chapter)
+	})
+}
+
+// LoadChapterMetadata fetches a chapter's metadata
+func (s *S3Storage) LoadChapterMetadata(documentID string, chapterNumber int) (*types.Chapter, error) {
+	dir, err := s.chapterDir(documentID, chapterNumber)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := s.pull(s.config.ChapterMetadataPath(documentID, dir)); err != nil {
+		return nil, err
+	}
+	return s.local.LoadChapterMetadata(documentID, chapterNumber)
+}
+
+// SaveChapterRevisions stores the registry of a chapter's revisions
+func (s *S3Storage) SaveChapterRevisions(documentID string, chapterNumber int, revisions *types.ChapterRevisions) error {
+	dir, err := s.chapterDir(documentID, chapterNumber)
+	if err != nil {
+		return err
+	}
+	return s.save(s.config.ChapterRevisionsPath(documentID, dir), func() error {
+		return s.local.SaveChapterRevisions(documentID, chapterNumber, revisions)
+	})
+}
+
+// LoadChapterRevisions fetches the registry of a chapter's revisions
+func (s *S3Storage) LoadChapterRevisions(documentID string, chapterNumber int) (*types.ChapterRevisions, error) {
+	dir, err := s.chapterDir(documentID, chapterNumber)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := s.pull(s.config.ChapterRevisionsPath(documentID, dir)); err != nil {
+		return nil, err
+	}
+	return s.local.LoadChapterRevisions(documentID, chapterNumber)
+}
+
+// SaveSectionContent stores a section file
+func (s *S3Storage) SaveSectionContent(documentID string, chapterNumber int, sectionNumber types.SectionNumber, content string) error {
+	dir, err := s.chapterDir(documentID, chapterNumber)
+	if err != nil {
+		return err
+	}
+	return s.save(s.config.SectionPath(documentID, dir, sectionNumber.String()), func() error {
+		return s.local.SaveSectionContent(documentID, chapterNumber, sectionNumber, content)
+	})
+}
+
+// LoadSectionContent fetches a section file
+func (s *S3Storage) LoadSectionContent(documentID string, chapterNumber int, sectionNumber types.SectionNumber) (string, error) {
+	dir, err := s.chapterDir(documentID, chapterNumber)
+	if err != nil {
+		return "", err
+	}
+	if _, err := s.pull(s.config.SectionPath(documentID, dir, sectionNumber.String())); err != nil {
+		return "", err
+	}
+	return s.local.LoadSectionContent(documentID, chapterNumber, sectionNumber)
+}
+
+// DeleteSectionFile removes a section file from the store and the cache
+func (s *S3Storage) DeleteSectionFile(documentID string, chapterNumber int, sectionNumber types.SectionNumber) error {
+	dir, err := s.chapterDir(documentID, chapterNumber)
+	if err != nil {
+		return err
+	}
+	if err := s.remove(s.config.SectionPath(documentID, dir, sectionNumber.String())); err != nil {
+		return fmt.Errorf("failed to delete section file: %w", err)
+	}
+	return nil
+}
+
+// CreateSectionsDirectory creates a chapter's sections directory in the cache;
+// the store has no directories
+func (s *S3Storage) CreateSectionsDirectory(documentID string, chapterNumber int) error {
+	return s.local.CreateSectionsDirectory(documentID, chapterNumber)
+}
+
+// LoadLegacySectionContent returns section bodies older versions kept in chapter metadata
+func (s *S3Storage) LoadLegacySectionContent(documentID string, chapterNumber int) (map[string]string, error) {
+	dir, err := s.chapterDir(documentID, chapterNumber)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := s.pull(s.config.ChapterMetadataPath(documentID, dir)); err != nil {
+		return nil, err
+	}
+	return s.local.LoadLegacySectionContent(documentID, chapterNumber)
+}
+
+// SaveAsset stores an asset and returns its cached path
+func (s *S3Storage) SaveAsset(documentID string, fileName string, data []byte) (string, error) {
+	assetPath, err := s.local.SaveAsset(documentID, fileName, data)
+	if err != nil {
+		return "", err
+	}
+	if _, err := s.push(assetPath, ""); err != nil {
+		return "", fmt.Errorf("failed to save asset: %w", err)
+	}
+	return assetPath, nil
+}
+
+// SaveFont stores a font file in a document's fonts folder or the shared one
+func (s *S3Storage) SaveFont(documentID string, fileName string, data []byte) (string, error) {
+	fontPath, err := s.local.SaveFont(documentID, fileName, data)
+	if err != nil {
+		return "", err
+	}
+	if _, err := s.push(fontPath, ""); err != nil {
+		return "", fmt.Errorf("failed to save font: %w", err)
+	}
+	return fontPath, nil
+}
+
+// ListFonts fetches the font files of a document's fonts folder or the shared
+// one that differ from the cache, so exports can load them, and lists them
+func (s *S3Storage) ListFonts(documentID string) ([]string, error) {
+	prefix, err := s.dirKey(s.local.fontsPath(documentID))
+	if err != nil {
+		return nil, err
+	}
+	objects, _, err := s.client.ListObjects(prefix, "", 0)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list fonts: %w", err)
+	}
+	for _, object := range objects {
+		localPath := s.localPath(object.Key)
+		if sameContent(localPath, object.ETag) {
+			continue
+		}
+		data, _, err := s.client.GetObject(object.Key)
+		if err != nil {
+			return nil, fmt.Errorf("failed to fetch %s: %w", object.Key, err)
+		}
+		if err := writeCacheFile(localPath, data); err != nil {
+			return nil, err
+		}
+	}
+	return s.local.ListFonts(documentID)
+}
+
+// SaveSectionTemplate stores a section template
+func (s *S3Storage) SaveSectionTemplate(template *types.SectionTemplate) error {
+	return s.save(s.config.SectionTemplatePath(template.Name), func() error { return s.local.SaveSectionTemplate(template) })
+}
+
+// LoadSectionTemplate fetches a section template
+func (s *S3Storage) LoadSectionTemplate(templateName string) (*types.SectionTemplate, error) {
+	if _, err := s.pull(s.config.SectionTemplatePath(templateName)); err != nil {
+		return nil, err
+	}
+	return s.local.LoadSectionTemplate(templateName)
+}
+
+// SaveHouseStyle stores a house style ruleset
+func (s *S3Storage) SaveHouseStyle(style *types.HouseStyle) error {
+	return s.save(s.config.HouseStylePath(style.Name), func() error { return s.local.SaveHouseStyle(style) })
+}
+
+// LoadHouseStyle fetches a house style ruleset
+func (s *S3Storage) LoadHouseStyle(name string) (*types.HouseStyle, error) {
+	if _, err := s.pull(s.config.HouseStylePath(name)); err != nil {
+		return nil, err
+	}
+	return s.local.LoadHouseStyle(name)
+}
+
+// SaveUsageLedger stores the usage ledger
+func (s *S3Storage) SaveUsageLedger(ledger *types.UsageLedger) error {
+	return s.save(s.config.UsagePath(), func() error { return s.local.SaveUsageLedger(ledger) })
+}
+
+// LoadUsageLedger fetches the usage ledger
+func (s *S3Storage) LoadUsageLedger() (*types.UsageLedger, error) {
+	if _, err := s.pull(s.config.UsagePath()); err != nil {
+		return nil, err
+	}
+	return s.local.LoadUsageLedger()
+}
+
+// SaveAccessPolicy stores the access policy
+func (s *S3Storage) SaveAccessPolicy(policy *types.AccessPolicy) error {
+	return s.save(s.config.AccessPolicyPath(), func() error { return s.local.SaveAccessPolicy(policy) })
+}
+
+// LoadAccessPolicy fetches the access policy
+func (s *S3Storage) LoadAccessPolicy() (*types.AccessPolicy, error) {
+	if _, err := s.pull(s.config.AccessPolicyPath()); err != nil {
+		return nil, err
+	}
+	return s.local.LoadAccessPolicy()
+}
+
+// LoadHooks fetches the export hooks run for every document
+func (s *S3Storage) LoadHooks() (*types.HookConfig, error) {
+	if _, err := s.pull(s.config.HooksPath()); err != nil {
+		return nil, err
+	}
+	return s.local.LoadHooks()
+}
+
+// LoadDocumentHooks fetches a document's own export hooks
+func (s *S3Storage) LoadDocumentHooks(documentID string) (*types.HookConfig, error) {
+	if _, err := s.pull(s.config.DocumentHooksPath(documentID)); err != nil {
+		return nil, err
+	}
+	return s.local.LoadDocumentHooks(documentID)
+}
+
+// writeCacheFile writes fetched data to its cache path
+func writeCacheFile(localPath string, data []byte) error {
+	if err := os.MkdirAll(filepath.Dir(localPath), 0755); err != nil {
+		return fmt.Errorf("failed to create cache directory: %w", err)
+	}
+	if err := os.WriteFile(localPath, data, 0644); err != nil {
+		return fmt.Errorf("failed to write cache file: %w", err)
+	}
+	return nil
+}
+
+// sameContent reports whether a local file matches an object's ETag, which
+// S3 sets to the MD5 of objects stored with a single put
+func sameContent(localPath, etag string) bool {
+	data, err := os.ReadFile(localPath)
+	if err != nil {
+		return false
+	}
+	sum := md5.Sum(data)
+	return strings.Trim(etag, `"`) == hex.EncodeToString(sum[:])
+}
+
+// walkLocalFiles calls visit for every regular file under dir, if it exists
+func walkLocalFiles(dir string, visit func(localPath string) error) error {
+	err := filepath.WalkDir(dir, func(localPath string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if !entry.Type().IsRegular() {
+			return nil
+		}
+		return visit(localPath)
+	})
+	if os.IsNotExist(err) {
+		return nil
+	}
+	return err
+}
@@ -0,0 +1,310 @@
+package storage
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/gomcpgo/docgen/pkg/config"
+)
+
+// ErrObjectNotFound is returned for keys the object store doesn't hold
+var ErrObjectNotFound = errors.New("object not found")
+
+// ErrPreconditionFailed is returned when a conditional put loses to another writer
+var ErrPreconditionFailed = errors.New("object was changed by another writer")
+
+// s3Timeout bounds a single object store request
+const s3Timeout = 60 * time.Second
+
+// s3Object is one entry of a bucket listing
+type s3Object struct {
+	Key  string `xml:"Key"`
+	ETag string `xml:"ETag"`
+	Size int64  `xml:"Size"`
+}
+
+// s3ListResult is the ListObjectsV2 response
+type s3ListResult struct {
+	Contents       []s3Object `xml:"Contents"`
+	CommonPrefixes []struct {
+		Prefix string `xml:"Prefix"`
+	} `xml:"CommonPrefixes"`
+	IsTruncated           bool   `xml:"IsTruncated"`
+	NextContinuationToken string `xml:"NextContinuationToken"`
+}
+
+// s3Client is a minimal client for S3-compatible object stores, signing
+// requests with AWS Signature Version 4
+type s3Client struct {
+	cfg    config.S3Config
+	client *http.Client
+	// now is replaced in tests to sign with a fixed time
+	now func() time.Time
+}
+
+// newS3Client creates a client for the configured bucket
+func newS3Client(cfg config.S3Config) *s3Client {
+	if cfg.Region == "" {
+		cfg.Region = "us-east-1"
+	}
+	return &s3Client{
+		cfg:    cfg,
+		client: &http.Client{Timeout: s3Timeout},
+		now:    time.Now,
+	}
+}
+
+// objectURL returns the URL of a key, or of the bucket when key is empty
+func (c *s3Client) objectURL(key string) (*url.URL, error) {
+	endpoint := c.cfg.Endpoint
+	if endpoint == "" {
+		endpoint = fmt.Sprintf("https://s3.%s.amazonaws.com", c.cfg.Region)
+	}
+	u, err := url.Parse(strings.TrimSuffix(endpoint, "/"))
+	if err != nil {
+		return nil, fmt.Errorf("invalid S3 endpoint: %w", err)
+	}
+	if c.cfg.PathStyle {
+		u.Path += "/" + c.cfg.Bucket
+	} else {
+		u.Host = c.cfg.Bucket + "." + u.Host
+	}
+	u.Path += "/" + key
+	return u, nil
+}
+
+// do signs and sends a request, returning the response with its body read
+func (c *s3Client) do(method, key string, query url.Values, header http.Header, body []byte) (*http.Response, []byte, error) {
+	u, err := c.objectURL(key)
+	if err != nil {
+		return nil, nil, err
+	}
+	u.RawQuery = query.Encode()
+
+	req, err := http.NewRequest(method, u.String(), bytes.NewReader(body))
+	if err != nil {
+		return nil, nil, err
+	}
+	for name, values := range header {
+		req.Header[name] = values
+	}
+	c.sign(req, body)
+
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return nil, nil, fmt.Errorf("%s %s: %w", method, key, err)
+	}
+	defer resp.Body.Close()
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, nil, fmt.Errorf("%s %s: %w", method, key, err)
+	}
+
+	switch {
+	case resp.StatusCode == http.StatusNotFound:
+		return resp, data, fmt.Errorf("%s: %w", key, ErrObjectNotFound)
+	case resp.StatusCode == http.StatusPreconditionFailed:
+		return resp, data, fmt.Errorf("%s: %w", key, ErrPreconditionFailed)
+	case resp.StatusCode >= 300:
+		return resp, data, fmt.Errorf("%s %s: %s: %s", method, key, resp.Status, strings.TrimSpace(string(data)))
+	}
+	return resp, data, nil
+}
+
+// GetObject returns an object's content and ETag
+func (c *s3Client) GetObject(key string) ([]byte, string, error) {
+	resp, data, err := c.do(http.MethodGet, key, nil, nil, nil)
+	if err != nil {
+		return nil, "", err
+	}
+	return data, resp.Header.Get("ETag"), nil
+}
+
+// noObject as the expected ETag makes a put succeed only when the key doesn't exist yet
+const noObject = "none"
+
+// PutObject stores an object and returns its new ETag. With ifMatch set, the
+// put only succeeds while the object still has that ETag, or with noObject,
+// while there is no object. A lost race returns ErrPreconditionFailed.
+func (c *s3Client) PutObject(key string, data []byte, ifMatch string) (string, error) {
+	header := http.Header{}
+	switch ifMatch {
+	case "":
+	case noObject:
+		header.Set("If-None-Match", "*")
+	default:
+		header.Set("If-Match", ifMatch)
+	}
+	resp, _, err := c.do(http.MethodPut, key, nil, header, data)
+	if err != nil {
+		return "", err
+	}
+	return resp.Header.Get("ETag"), nil
+}
+
+// DeleteObject removes an object; removing a missing key is not an error
+func (c *s3Client) DeleteObject(key string) error {
+	_, _, err := c.do(http.MethodDelete, key, nil, nil, nil)
+	if errors.Is(err, ErrObjectNotFound) {
+		return nil
+	}
+	return err
+}
+
+// ListObjects returns the objects under prefix, following continuation tokens.
+// With a delimiter, keys beyond it are grouped into the returned prefixes.
+func (c *s3Client) ListObjects(prefix, delimiter string, limit int) ([]s3Object, []string, error) {
+	var objects []s3Object
+	var prefixes []string
+	token := ""
+	for {
+		query := url.Values{"list-type": {"2"}, "prefix": {prefix}}
+		if delimiter != "" {
+			query.Set("delimiter", delimiter)
+		}
+		if limit > 0 {
+			query.Set("max-keys", fmt.Sprint(limit))
+		}
+		if token != "" {
+			query.Set("continuation-token", token)
+		}
+		_, data, err := c.do(http.MethodGet, "", query, nil, nil)
+		if err != nil {
+			return nil, nil, err
+		}
+		var result s3ListResult
+		if err := xml.Unmarshal(data, &result); err != nil {
+			return nil, nil, fmt.Errorf("failed to decode bucket listing: %w", err)
+		}
+		objects = append(objects, result.Contents...)
+		for _, common := range result.CommonPrefixes {
+			prefixes = append(prefixes, common.Prefix)
+		}
+		if !result.IsTruncated || result.NextContinuationToken == "" || (limit > 0 && len(objects) >= limit) {
+			return objects, prefixes, nil
+		}
+		token = result.NextContinuationToken
+	}
+}
+
+// sign adds AWS Signature Version 4 headers to a request. Every header already
+// on the request is signed along with the host.
+func (c *s3Client) sign(req *http.Request, body []byte) {
+	now := c.now().UTC()
+	amzDate := now.Format("20060102T150405Z")
+	date := now.Format("20060102")
+	payloadHash := sha256Hex(body)
+
+	req.Header.Set("X-Amz-Date", amzDate)
+	req.Header.Set("X-Amz-Content-Sha256", payloadHash)
+	if c.cfg.SessionToken != "" {
+		req.Header.Set("X-Amz-Security-Token", c.cfg.SessionToken)
+	}
+
+	headers := map[string]string{"host": req.URL.Host}
+	for name, values := range req.Header {
+		headers[strings.ToLower(name)] = strings.TrimSpace(strings.Join(values, ","))
+	}
+	names := make([]string, 0, len(headers))
+	for name := range headers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	var canonicalHeaders strings.Builder
+	for _, name := range names {
+		canonicalHeaders.WriteString(name + ":" + headers[name] + "\n")
+	}
+	signedHeaders := strings.Join(names, ";")
+
+	canonicalRequest := strings.Join([]string{
+		req.Method,
+		escapePath(req.URL.Path),
+		canonicalQuery(req.URL.Query()),
+		canonicalHeaders.String(),
+		signedHeaders,
+		payloadHash,
+	}, "\n")
+
+	scope := date + "/" + c.cfg.Region + "/s3/aws4_request"
+	stringToSign := strings.Join([]string{
+		"AWS4-HMAC-SHA256",
+		amzDate,
+		scope,
+		sha256Hex([]byte(canonicalRequest)),
+	}, "\n")
+
+	key := hmacSHA256([]byte("AWS4"+c.cfg.SecretAccessKey), date)
+	key = hmacSHA256(key, c.cfg.Region)
+	key = hmacSHA256(key, "s3")
+	key = hmacSHA256(key, "aws4_request")
+	signature := hex.EncodeToString(hmacSHA256(key, stringToSign))
+
+	req.Header.Set("Authorization", fmt.Sprintf("AWS4-HMAC-SHA256 Credential=%s/%s, SignedHeaders=%s, Signature=%s",
+		c.cfg.AccessKeyID, scope, signedHeaders, signature))
+}
+
+// canonicalQuery encodes query parameters sorted by name, as signing requires
+func canonicalQuery(query url.Values) string {
+	names := make([]string, 0, len(query))
+	for name := range query {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	var parts []string
+	for _, name := range names {
+		values := append([]string(nil), query[name]...)
+		sort.Strings(values)
+		for _, value := range values {
+			parts = append(parts, escape(name)+"="+escape(value))
+		}
+	}
+	return strings.Join(parts, "&")
+}
+
+// escapePath URI-encodes each segment of a path
+func escapePath(path string) string {
+	if path == "" {
+		return "/"
+	}
+	segments := strings.Split(path, "/")
+	for i, segment := range segments {
+		segments[i] = escape(segment)
+	}
+	return strings.Join(segments, "/")
+}
+
+// escape URI-encodes everything but unreserved characters
+func escape(s string) string {
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		ch := s[i]
+		if ch >= 'A' && ch <= 'Z' || ch >= 'a' && ch <= 'z' || ch >= '0' && ch <= '9' || ch == '-' || ch == '_' || ch == '.' || ch == '~' {
+			b.WriteByte(ch)
+		} else {
+			fmt.Fprintf(&b, "%%%02X", ch)
+		}
+	}
+	return b.String()
+}
+
+func sha256Hex(data []byte) string {
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
+
+func hmacSHA256(key []byte, data string) []byte {
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(data))
+	return mac.Sum(nil)
+}
@@ -0,0 +1,109 @@
+// Package style flattens styles that extend other styles into the effective
+// style an export uses.
+package style
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/gomcpgo/docgen/pkg/types"
+	"gopkg.in/yaml.v3"
+)
+
+// maxDepth limits how many styles an inheritance chain may pass through
+const maxDepth = 10
+
+// Loader loads a named style as the fields its file sets
+type Loader interface {
+	LoadStyleFields(styleName string) (map[string]interface{}, error)
+}
+
+// Resolve loads a named style and flattens it with the styles it extends. It
+// returns the effective style and the chain of styles it was built from, the
+// named style first.
+func Resolve(loader Loader, name string) (*types.Style, []string, error) {
+	fields, err := loader.LoadStyleFields(name)
+	if err != nil {
+		return nil, nil, fmt.Errorf("style '%s' not found: %w", name, err)
+	}
+	return resolve(loader, []string{name}, fields)
+}
+
+// ResolveFields flattens a style given as its fields, such as one read from a
+// file outside the styles folder, with the styles it extends
+func ResolveFields(loader Loader, fields map[string]interface{}) (*types.Style, []string, error) {
+	return resolve(loader, nil, fields)
+}
+
+// resolve follows the extends chain from fields, overlaying each style on the
+// one it extends
+func resolve(loader Loader, chain []string, fields map[string]interface{}) (*types.Style, []string, error) {
+	layers := []map[string]interface{}{fields}
+	for {
+		parent, _ := layers[len(layers)-1]["extends"].(string)
+		if parent == "" {
+			break
+		}
+		for _, name := range chain {
+			if name == parent {
+				return nil, nil, fmt.Errorf("style inheritance cycle: %s -> %s", strings.Join(chain, " -> "), parent)
+			}
+		}
+		if len(layers) > maxDepth {
+			return nil, nil, fmt.Errorf("style inheritance is deeper than %d styles: %s", maxDepth, strings.Join(chain, " -> "))
+		}
+		parentFields, err := loader.LoadStyleFields(parent)
+		if err != nil {
+			return nil, nil, fmt.Errorf("extended style '%s' not found: %w", parent, err)
+		}
+		chain = append(chain, parent)
+		layers = append(layers, parentFields)
+	}
+
+	// The base style goes first and each style that extends it on top
+	merged := make(map[string]interface{})
+	for i := len(layers) - 1; i >= 0; i-- {
+		merged = Merge(merged, layers[i])
+	}
+	delete(merged, "extends")
+
+	style, err := decode(merged)
+	if err != nil {
+		return nil, nil, err
+	}
+	return style, chain, nil
+}
+
+// Merge overlays the fields of override on base. Nested groups such as body or
+// margins are merged field by field; any other field set in override replaces
+// the one in base, including false, zero and empty values. Neither map is
+// modified.
+func Merge(base, override map[string]interface{}) map[string]interface{} {
+	merged := make(map[string]interface{}, len(base)+len(override))
+	for key, value := range base {
+		merged[key] = value
+	}
+	for key, value := range override {
+		overrideGroup, overrideIsGroup := value.(map[string]interface{})
+		baseGroup, baseIsGroup := merged[key].(map[string]interface{})
+		if overrideIsGroup && baseIsGroup {
+			merged[key] = Merge(baseGroup, overrideGroup)
+			continue
+		}
+		merged[key] = value
+	}
+	return merged
+}
+
+// decode turns merged style fields into a style
+func decode(fields map[string]interface{}) (*types.Style, error) {
+	data, err := yaml.Marshal(fields)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode style: %w", err)
+	}
+	var style types.Style
+	if err := yaml.Unmarshal(data, &style); err != nil {
+		return nil, fmt.Errorf("failed to decode style: %w", err)
+	}
+	return &style, nil
+}
@@ -0,0 +1,119 @@
+package style
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"gopkg.in/yaml.v3"
+)
+
+// mapLoader serves styles from YAML strings
+type mapLoader map[string]string
+
+func (l mapLoader) LoadStyleFields(styleName string) (map[string]interface{}, error) {
+	data, ok := l[styleName]
+	if !ok {
+		return nil, fmt.Errorf("no style %s", styleName)
+	}
+	fields := make(map[string]interface{})
+	if err := yaml.Unmarshal([]byte(data), &fields); err != nil {
+		return nil, err
+	}
+	return fields, nil
+}
+
+var testStyles = mapLoader{
+	"default": `
+body: {font_family: Times New Roman, font_size: 12pt, color: "#000000"}
+heading: {font_family: Arial, color: "#000000"}
+link_color: "#0000EE"
+margins: {top: 1in, bottom: 1in, left: 1in, right: 1in}
+numbering_style: {chapters: true, sections: true, figures: true, tables: true}
+`,
+	"brand": `
+extends: default
+heading: {color: "#C00000"}
+margins: {left: 1.25in}
+`,
+	"brand-draft": `
+extends: brand
+numbering_style: {sections: false}
+line_spacing: "2.0"
+`,
+	"loop-a": "extends: loop-b\n",
+	"loop-b": "extends: loop-a\n",
+	"orphan": "extends: missing\n",
+}
+
+func TestResolve(t *testing.T) {
+	style, chain, err := Resolve(testStyles, "brand-draft")
+	if err != nil {
+		t.Fatalf("Resolve() error = %v", err)
+	}
+	if strings.Join(chain, ",") != "brand-draft,brand,default" {
+		t.Errorf("Resolve() chain = %v", chain)
+	}
+	if style.Extends != "" {
+		t.Errorf("Resolved style should not extend anything, got %q", style.Extends)
+	}
+
+	// Overridden fields come from the nearest style that sets them
+	if style.Heading.Color != "#C00000" || style.Margins.Left != "1.25in" || style.LineSpacing != "2.0" {
+		t.Errorf("Overrides not applied: %+v", style)
+	}
+	// Fields left out, including siblings in a nested group, are inherited
+	if style.Heading.FontFamily != "Arial" || style.Body.FontSize != "12pt" || style.Margins.Right != "1in" || style.LinkColor != "#0000EE" {
+		t.Errorf("Inherited fields missing: %+v", style)
+	}
+	// A style can switch off what it inherits
+	if style.NumberingStyle.Sections || !style.NumberingStyle.Chapters {
+		t.Errorf("NumberingStyle = %+v, want sections off and chapters on", style.NumberingStyle)
+	}
+}
+
+func TestResolve_Errors(t *testing.T) {
+	tests := []struct {
+		name    string
+		wantErr string
+	}{
+		{"loop-a", "cycle: loop-a -> loop-b -> loop-a"},
+		{"orphan", "extended style 'missing' not found"},
+		{"unknown", "style 'unknown' not found"},
+	}
+	for _, tt := range tests {
+		if _, _, err := Resolve(testStyles, tt.name); err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+			t.Errorf("Resolve(%s) error = %v, want %q", tt.name, err, tt.wantErr)
+		}
+	}
+
+	deep := mapLoader{"s0": "line_spacing: \"1.0\"\n"}
+	for i := 1; i <= maxDepth+1; i++ {
+		deep[fmt.Sprintf("s%d", i)] = fmt.Sprintf("extends: s%d\n", i-1)
+	}
+	if _, _, err := Resolve(deep, fmt.Sprintf("s%d", maxDepth+1)); err == nil || !strings.Contains(err.Error(), "deeper than") {
+		t.Errorf("Resolve() of a long chain error = %v", err)
+	}
+}
+
+func TestResolveFields(t *testing.T) {
+	style, chain, err := ResolveFields(testStyles, map[string]interface{}{
+		"extends": "brand",
+		"body":    map[string]interface{}{"font_size": "11pt"},
+	})
+	if err != nil {
+		t.Fatalf("ResolveFields() error = %v", err)
+	}
+	if strings.Join(chain, ",") != "brand,default" {
+		t.Errorf("ResolveFields() chain = %v", chain)
+	}
+	if style.Body.FontSize != "11pt" || style.Body.FontFamily != "Times New Roman" || style.Heading.Color != "#C00000" {
+		t.Errorf("ResolveFields() = %+v", style)
+	}
+
+	// A style without extends is returned as is
+	style, chain, err = ResolveFields(testStyles, map[string]interface{}{"link_color": "#FF0000"})
+	if err != nil || len(chain) != 0 || style.LinkColor != "#FF0000" || style.Body.FontFamily != "" {
+		t.Errorf("ResolveFields() without extends = %+v, %v, %v", style, chain, err)
+	}
+}
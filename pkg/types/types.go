@@ -1,11 +1,18 @@
 package types
 
 import (
+	"crypto/rand"
+	"encoding/hex"
 	"fmt"
+	"path"
+	"path/filepath"
 	"regexp"
+	"sort"
 	"strconv"
 	"strings"
 	"time"
+	"unicode"
+	"unicode/utf8"
 )
 
 // DocumentID represents a unique document identifier
@@ -17,12 +24,21 @@ type ChapterNumber int
 // SectionNumber represents a section number (1.1, 1.2.1, etc.)
 type SectionNumber []int
 
-// FigureID represents a figure identifier (fig-1.1, fig-2.3...)
+// FigureID represents a figure identifier. Figures get a stable ID
+// (fig-3f9a2c7d) that stays the same when figures are deleted or chapters
+// move; figures registered before stable IDs carry their number (fig-1.2)
+// until migrate_figure_ids gives them one.
 type FigureID string
 
+// FigureIDPattern matches a figure ID, stable or numbered, in content
+const FigureIDPattern = `fig-(?:[0-9a-f]{8}|\d+\.\d+)`
+
 // TableID represents a table identifier (table-1.1, table-2.3...)
 type TableID string
 
+// ListingID represents a code listing identifier (lst-1.1, lst-2.3...)
+type ListingID string
+
 // DocumentType represents the type of document
 type DocumentType string
 
@@ -40,8 +56,29 @@ const (
 	ExportFormatPDF  ExportFormat = "pdf"
 	ExportFormatDOCX ExportFormat = "docx"
 	ExportFormatHTML ExportFormat = "html"
+	ExportFormatEPUB ExportFormat = "epub"
+	ExportFormatText ExportFormat = "txt"  // Linearized plain text for screen readers
+	ExportFormatSSML ExportFormat = "ssml" // Linearized SSML for text-to-speech
+	ExportFormatJSON ExportFormat = "json" // DocumentJSON, for tooling
+
+	ExportFormatRevealJS ExportFormat = "revealjs" // reveal.js slide deck, an HTML page
+	ExportFormatPPTX     ExportFormat = "pptx"     // PowerPoint slide deck
 )
 
+// IsSlides reports whether the format is a slide deck
+func (f ExportFormat) IsSlides() bool {
+	return f == ExportFormatRevealJS || f == ExportFormatPPTX
+}
+
+// Extension is the file extension of the format's exports; reveal.js decks
+// are HTML pages kept apart from the document's HTML export
+func (f ExportFormat) Extension() string {
+	if f == ExportFormatRevealJS {
+		return "slides.html"
+	}
+	return string(f)
+}
+
 // ImagePosition represents image positioning options
 type ImagePosition string
 
@@ -66,13 +103,71 @@ const (
 type Document struct {
 	ID          DocumentID    `yaml:"id" json:"id"`
 	Title       string        `yaml:"title" json:"title"`
-	Author      string        `yaml:"author" json:"author"`
+	Authors     AuthorList    `yaml:"authors" json:"authors"`
 	Type        DocumentType  `yaml:"type" json:"type"`
 	CreatedAt   time.Time     `yaml:"created_at" json:"created_at"`
 	UpdatedAt   time.Time     `yaml:"updated_at" json:"updated_at"`
 	Chapters    []Chapter     `yaml:"chapters" json:"chapters"`
+
+	DocumentMetadata `yaml:",inline"`
+
+	// EditorialRules are applied to section content when chapters are rebuilt
+	EditorialRules *EditorialRules `yaml:"editorial_rules,omitempty" json:"editorial_rules,omitempty"`
+
+	// WritingTarget is the author's word count goal used for progress projections
+	WritingTarget *WritingTarget `yaml:"writing_target,omitempty" json:"writing_target,omitempty"`
+
+	// Parts group consecutive chapters, ordered by their first chapter
+	Parts []Part `yaml:"parts,omitempty" json:"parts,omitempty"`
+
+	// Abbreviations are defined for the abbreviations table, sorted by term.
+	// Abbreviations written out in the text as "Long Form (LF)" need no entry.
+	Abbreviations []Abbreviation `yaml:"abbreviations,omitempty" json:"abbreviations,omitempty"`
+
+	// Tags organize documents into collections, such as a client or project.
+	// They are lowercase and sorted.
+	Tags []string `yaml:"tags,omitempty" json:"tags,omitempty"`
+
+	// ExpiresAt is set on sandbox documents, which are scratch space deleted once
+	// it passes. They don't count toward the document limit.
+	ExpiresAt *time.Time `yaml:"expires_at,omitempty" json:"expires_at,omitempty"`
+
+	// Lock is set while the document is read-only. Tools that change a locked
+	// document refuse until it is unlocked; reading and exporting still work.
+	Lock *DocumentLock `yaml:"lock,omitempty" json:"lock,omitempty"`
+
+	// LegacyAuthor holds the single author string used by older manifests.
+	// It is migrated into Authors when the manifest is loaded.
+	LegacyAuthor string `yaml:"author,omitempty" json:"-"`
+}
+
+// DocumentLock records when and why a document was made read-only
+type DocumentLock struct {
+	LockedAt time.Time `yaml:"locked_at" json:"locked_at"`
+	Reason   string    `yaml:"reason,omitempty" json:"reason,omitempty"`
+}
+
+// DocumentMetadata holds optional bibliographic metadata passed to pandoc
+type DocumentMetadata struct {
+	Subtitle string   `yaml:"subtitle,omitempty" json:"subtitle,omitempty"`
+	Keywords []string `yaml:"keywords,omitempty" json:"keywords,omitempty"`
+	Abstract string   `yaml:"abstract,omitempty" json:"abstract,omitempty"`
+	Language string   `yaml:"language,omitempty" json:"language,omitempty"` // BCP-47 tag, e.g. "en-US"
+	Date     string   `yaml:"date,omitempty" json:"date,omitempty"`         // Explicit document date; export date when empty
+	Locale   string   `yaml:"locale,omitempty" json:"locale,omitempty"`     // BCP-47 tag for dates, caption labels and contents headings; ISO dates and English when empty
+}
+
+// Author represents a document author with optional contact details
+type Author struct {
+	Name        string `yaml:"name" json:"name"`
+	Affiliation string `yaml:"affiliation,omitempty" json:"affiliation,omitempty"`
+	Email       string `yaml:"email,omitempty" json:"email,omitempty"`
+	ORCID       string `yaml:"orcid,omitempty" json:"orcid,omitempty"`
 }
 
+// AuthorList is the ordered list of document authors
+type AuthorList []Author
+
 // Chapter represents a document chapter
 type Chapter struct {
 	Number    ChapterNumber `yaml:"number" json:"number"`
@@ -81,18 +176,725 @@ type Chapter struct {
 	Sections  []Section     `yaml:"sections" json:"sections"`
 	Figures   []Figure      `yaml:"figures" json:"figures"`
 	Tables    []Table       `yaml:"tables" json:"tables"`
+	Listings  []Listing     `yaml:"listings,omitempty" json:"listings,omitempty"`
 	CreatedAt time.Time     `yaml:"created_at" json:"created_at"`
 	UpdatedAt time.Time     `yaml:"updated_at" json:"updated_at"`
+
+	// PandocOptions holds chapter-specific export settings
+	PandocOptions *ChapterPandocOptions `yaml:"pandoc_options,omitempty" json:"pandoc_options,omitempty"`
+
+	// IncludeFile is a markdown file kept outside the document, placed after the
+	// chapter heading and before the chapter's sections. It is read whenever the
+	// chapter is built, so exports pick up changes made to it elsewhere.
+	IncludeFile string `yaml:"include_file,omitempty" json:"include_file,omitempty"`
+
+	// FrontMatter is the author's own metadata, such as status or reviewer
+	FrontMatter FrontMatter `yaml:"front_matter,omitempty" json:"front_matter,omitempty"`
+
+	// Status is where the chapter is in the review workflow; draft when unset
+	Status WorkflowStatus `yaml:"status,omitempty" json:"status,omitempty"`
+
+	// Epigraph is a quotation set under the chapter heading
+	Epigraph *Epigraph `yaml:"epigraph,omitempty" json:"epigraph,omitempty"`
+}
+
+// Epigraph is a quotation opening a chapter, with who it is by
+type Epigraph struct {
+	Text        string `yaml:"text" json:"text"` // markdown
+	Attribution string `yaml:"attribution,omitempty" json:"attribution,omitempty"`
+}
+
+// PageBreakMarker is the line in section markdown that starts a new page.
+// \newpage is accepted too, and a {.keep-together} div keeps its content on
+// one page.
+const PageBreakMarker = "\\pagebreak"
+
+// SectionStatuses returns the workflow status of each section in order: its
+// own, or else that of the section it is nested in, or else the chapter's
+func (c Chapter) SectionStatuses() []WorkflowStatus {
+	statuses := make([]WorkflowStatus, len(c.Sections))
+	var parents []Section
+	for i, section := range c.Sections {
+		for len(parents) > 0 && parents[len(parents)-1].Level >= section.Level {
+			parents = parents[:len(parents)-1]
+		}
+		statuses[i] = section.Status
+		for j := len(parents) - 1; j >= 0 && statuses[i] == ""; j-- {
+			statuses[i] = parents[j].Status
+		}
+		if statuses[i] == "" {
+			statuses[i] = c.Status
+		}
+		parents = append(parents, section)
+	}
+	return statuses
+}
+
+// WorkflowStatus is where a chapter or section is in the review workflow
+type WorkflowStatus string
+
+const (
+	StatusDraft    WorkflowStatus = "draft"
+	StatusInReview WorkflowStatus = "in-review"
+	StatusApproved WorkflowStatus = "approved"
+	StatusFinal    WorkflowStatus = "final"
+)
+
+// Validate validates a workflow status
+func (s WorkflowStatus) Validate() error {
+	switch s {
+	case StatusDraft, StatusInReview, StatusApproved, StatusFinal:
+		return nil
+	}
+	return fmt.Errorf("invalid status %q: must be %q, %q, %q or %q", s, StatusDraft, StatusInReview, StatusApproved, StatusFinal)
+}
+
+// OrDraft returns the status, or draft when it is unset
+func (s WorkflowStatus) OrDraft() WorkflowStatus {
+	if s == "" {
+		return StatusDraft
+	}
+	return s
+}
+
+// Approved reports whether content with the status may go out: approved or final
+func (s WorkflowStatus) Approved() bool {
+	return s == StatusApproved || s == StatusFinal
+}
+
+// Label returns the status as written in exports, e.g. "In review"
+func (s WorkflowStatus) Label() string {
+	switch s.OrDraft() {
+	case StatusInReview:
+		return "In review"
+	case StatusApproved:
+		return "Approved"
+	case StatusFinal:
+		return "Final"
+	}
+	return "Draft"
+}
+
+// FrontMatter is free-form metadata authors attach to chapters and sections,
+// such as status: draft or a reviewer and due date. Docgen keeps it as given
+// and only reads it to filter structure and show it in HTML exports.
+type FrontMatter map[string]interface{}
+
+// Matches reports whether the front matter has every key in query, with the
+// value written the same way. A list value matches any of its items.
+func (f FrontMatter) Matches(query map[string]string) bool {
+	for key, want := range query {
+		value, ok := f[key]
+		if !ok {
+			return false
+		}
+		if items, isList := value.([]interface{}); isList {
+			found := false
+			for _, item := range items {
+				if fmt.Sprint(item) == want {
+					found = true
+					break
+				}
+			}
+			if !found {
+				return false
+			}
+		} else if fmt.Sprint(value) != want {
+			return false
+		}
+	}
+	return true
+}
+
+// Part groups a run of consecutive chapters under a heading such as "Part II: Methods"
+type Part struct {
+	Title        string        `yaml:"title" json:"title"`
+	FirstChapter ChapterNumber `yaml:"first_chapter" json:"first_chapter"`
+	LastChapter  ChapterNumber `yaml:"last_chapter" json:"last_chapter"`
+}
+
+// Contains reports whether the part includes a chapter
+func (p Part) Contains(chapter ChapterNumber) bool {
+	return chapter >= p.FirstChapter && chapter <= p.LastChapter
+}
+
+// ChapterPandocOptions holds export settings for a single chapter. Variables and
+// class options are merged into the document-level pandoc config when the chapter
+// is exported; Landscape rotates just this chapter's pages in PDF output.
+type ChapterPandocOptions struct {
+	Variables    map[string]string `yaml:"variables,omitempty" json:"variables,omitempty"`
+	ClassOptions []string          `yaml:"classoptions,omitempty" json:"classoptions,omitempty"`
+	Landscape    bool              `yaml:"landscape,omitempty" json:"landscape,omitempty"`
+
+	// Markdown turns pandoc markdown extensions on or off, by name, for exports
+	// that include the chapter. The document's own settings take precedence.
+	Markdown map[string]bool `yaml:"markdown,omitempty" json:"markdown,omitempty"`
+}
+
+// PercentStyle selects how percentages are written
+type PercentStyle string
+
+const (
+	PercentStyleSymbol PercentStyle = "symbol" // 25%
+	PercentStyleWord   PercentStyle = "word"   // 25 percent
+)
+
+// EditorialRules configures the house-style normalization applied to section
+// content when chapters are rebuilt. All rules are off by default.
+type EditorialRules struct {
+	SpellOutNumbers bool         `yaml:"spell_out_numbers,omitempty" json:"spell_out_numbers,omitempty"` // Write whole numbers under ten as words
+	UnitSpacing     bool         `yaml:"unit_spacing,omitempty" json:"unit_spacing,omitempty"`           // Narrow no-break space between a number and its unit
+	PercentStyle    PercentStyle `yaml:"percent_style,omitempty" json:"percent_style,omitempty"`         // Consistent percent style; empty leaves percentages alone
+}
+
+// Enabled reports whether any editorial rule is switched on
+func (r *EditorialRules) Enabled() bool {
+	return r != nil && (r.SpellOutNumbers || r.UnitSpacing || r.PercentStyle != "")
+}
+
+// Validate checks the editorial rule settings
+func (r *EditorialRules) Validate() error {
+	switch r.PercentStyle {
+	case "", PercentStyleSymbol, PercentStyleWord:
+		return nil
+	default:
+		return fmt.Errorf("invalid percent style %q: must be %q or %q", r.PercentStyle, PercentStyleSymbol, PercentStyleWord)
+	}
+}
+
+// EditorialChange records one edit made by the editorial normalization pass
+type EditorialChange struct {
+	Chapter     ChapterNumber `json:"chapter"`
+	Section     string        `json:"section"`
+	Line        int           `json:"line"`
+	Rule        string        `json:"rule"`
+	Original    string        `json:"original"`
+	Replacement string        `json:"replacement"`
+}
+
+// HouseStyleSeverity ranks house style findings
+type HouseStyleSeverity string
+
+const (
+	SeverityError   HouseStyleSeverity = "error"
+	SeverityWarning HouseStyleSeverity = "warning"
+	SeverityInfo    HouseStyleSeverity = "info"
+)
+
+// HouseStyle is an organization's ruleset, stored as YAML in the house-styles directory
+type HouseStyle struct {
+	Name        string           `yaml:"name" json:"name"`
+	Description string           `yaml:"description,omitempty" json:"description,omitempty"`
+	Rules       []HouseStyleRule `yaml:"rules" json:"rules"`
+}
+
+// HouseStyleRule is a single house style rule. A rule either matches a regular
+// expression (Pattern, optionally fixed with Replacement) or runs a built-in check
+// (Builtin, configured through Options). Blocks limits the rule to certain block
+// kinds: heading, paragraph, list_item and blockquote. Fix marks a rule whose
+// replacement is safe to apply automatically.
+type HouseStyleRule struct {
+	ID          string             `yaml:"id" json:"id"`
+	Message     string             `yaml:"message,omitempty" json:"message,omitempty"`
+	Severity    HouseStyleSeverity `yaml:"severity,omitempty" json:"severity,omitempty"`
+	Pattern     string             `yaml:"pattern,omitempty" json:"pattern,omitempty"`
+	IgnoreCase  bool               `yaml:"ignore_case,omitempty" json:"ignore_case,omitempty"`
+	Replacement *string            `yaml:"replacement,omitempty" json:"replacement,omitempty"`
+	Builtin     string             `yaml:"builtin,omitempty" json:"builtin,omitempty"`
+	Options     map[string]string  `yaml:"options,omitempty" json:"options,omitempty"`
+	Blocks      []string           `yaml:"blocks,omitempty" json:"blocks,omitempty"`
+	Fix         bool               `yaml:"fix,omitempty" json:"fix,omitempty"`
+}
+
+// HouseStyleFinding is a rule violation found in a section
+type HouseStyleFinding struct {
+	Rule       string             `json:"rule"`
+	Severity   HouseStyleSeverity `json:"severity"`
+	Message    string             `json:"message"`
+	Chapter    ChapterNumber      `json:"chapter"`
+	Section    string             `json:"section"`
+	Line       int                `json:"line"` // 0 for the section title
+	Match      string             `json:"match"`
+	Suggestion string             `json:"suggestion,omitempty"`
+	Fixed      bool               `json:"fixed"`
+}
+
+// AssetReport lists image files no figure or section uses and figures whose
+// image file is missing
+type AssetReport struct {
+	Orphaned       []OrphanedAsset `json:"orphaned"`
+	OrphanedBytes  int64           `json:"orphaned_bytes"`
+	MissingFigures []MissingFigure `json:"missing_figures"`
+	Pruned         bool            `json:"pruned"`
+}
+
+// OrphanedAsset is an image in assets/images that nothing refers to
+type OrphanedAsset struct {
+	File string `json:"file"`
+	Size int64  `json:"size"`
+}
+
+// MissingFigure is a figure whose image file cannot be found
+type MissingFigure struct {
+	FigureID  FigureID      `json:"figure_id"`
+	Chapter   ChapterNumber `json:"chapter"`
+	ImagePath string        `json:"image_path"`
+}
+
+// AnnotationKind is a callout drawn on an image by annotate_image
+type AnnotationKind string
+
+const (
+	AnnotationArrow AnnotationKind = "arrow" // a line from (x, y) with a head at (to_x, to_y)
+	AnnotationBox   AnnotationKind = "box"   // an outline with its top-left corner at (x, y)
+	AnnotationStep  AnnotationKind = "step"  // a numbered disc centered on (x, y)
+)
+
+// Annotation is one callout on an image. Coordinates are in pixels of the
+// source image, measured from its top-left corner.
+type Annotation struct {
+	Kind   AnnotationKind `json:"kind"`
+	X      int            `json:"x"`
+	Y      int            `json:"y"`
+	ToX    int            `json:"to_x,omitempty"`
+	ToY    int            `json:"to_y,omitempty"`
+	Width  int            `json:"width,omitempty"`
+	Height int            `json:"height,omitempty"`
+	Number int            `json:"number,omitempty"` // steps without one count on from the previous step
+	Color  string         `json:"color,omitempty"`  // #RRGGBB or #RGB; red by default
+}
+
+// ContentLintProblem is the kind of mismatch between a chapter's figures and
+// tables and its content
+type ContentLintProblem string
+
+const (
+	// LintNotInContent is a registered figure or table the content never shows
+	LintNotInContent ContentLintProblem = "not_in_content"
+	// LintNotRegistered is an anchor ({#fig-1.2}) with no figure or table behind it
+	LintNotRegistered ContentLintProblem = "not_registered"
+	// LintWrongChapter is an anchor for a figure or table registered in another chapter
+	LintWrongChapter ContentLintProblem = "wrong_chapter"
+	// LintUnknownReference is a cross-reference (@fig-1.2) to an ID nothing registers
+	LintUnknownReference ContentLintProblem = "unknown_reference"
+	// LintDisplayAlias is an anchor or cross-reference naming a figure by its
+	// display number (fig-1.2) rather than its stable ID
+	LintDisplayAlias ContentLintProblem = "display_alias"
+)
+
+// ContentLintIssue is one mismatch with a suggested fix
+type ContentLintIssue struct {
+	Chapter    ChapterNumber      `json:"chapter"`
+	Section    string             `json:"section,omitempty"` // where the ID appears in the content
+	ID         string             `json:"id"`
+	Problem    ContentLintProblem `json:"problem"`
+	Suggestion string             `json:"suggestion"`
+}
+
+// ConsistencyProblem is the kind of drift between a chapter's chapter.md and
+// its section files and metadata
+type ConsistencyProblem string
+
+const (
+	// ConsistencyExtraContent is content in chapter.md that no section holds
+	ConsistencyExtraContent ConsistencyProblem = "extra_content"
+	// ConsistencyMissingSection is a section chapter.md doesn't contain at all
+	ConsistencyMissingSection ConsistencyProblem = "missing_section"
+	// ConsistencyStaleSection is a section chapter.md holds an older version of
+	ConsistencyStaleSection ConsistencyProblem = "stale_section"
+	// ConsistencyMissingSectionFile is a section in the metadata with no file
+	ConsistencyMissingSectionFile ConsistencyProblem = "missing_section_file"
+	// ConsistencyOrphanSectionFile is a section file the metadata doesn't list
+	ConsistencyOrphanSectionFile ConsistencyProblem = "orphan_section_file"
+	// ConsistencyMissingChapterFile is a chapter without a chapter.md
+	ConsistencyMissingChapterFile ConsistencyProblem = "missing_chapter_file"
+)
+
+// ConsistencyIssue is one difference between chapter.md and what rebuilding
+// it from the sections would write
+type ConsistencyIssue struct {
+	Chapter    ChapterNumber      `json:"chapter"`
+	Section    string             `json:"section,omitempty"`
+	Line       int                `json:"line,omitempty"` // in chapter.md
+	Problem    ConsistencyProblem `json:"problem"`
+	Detail     string             `json:"detail"`
+	Suggestion string             `json:"suggestion"`
+}
+
+// BlockKind is what a block of section content holds
+type BlockKind string
+
+const (
+	BlockParagraph BlockKind = "paragraph"
+	BlockHeading   BlockKind = "heading"
+	BlockList      BlockKind = "list"
+	BlockCode      BlockKind = "code"
+	BlockTable     BlockKind = "table"
+	BlockQuote     BlockKind = "quote"
+	BlockFigure    BlockKind = "figure"
+)
+
+// SectionBlock is one block of a section's content: a paragraph, list, code
+// fence or other run of lines separated from the rest by blank lines
+type SectionBlock struct {
+	Number  int       `json:"number"` // counting from 1
+	Kind    BlockKind `json:"kind"`
+	Content string    `json:"content"`
+}
+
+// MarkdownProblem identifies a structural problem in section markdown
+type MarkdownProblem string
+
+const (
+	// MarkdownUnclosedFence is a code fence that is never closed, which turns the
+	// rest of the section into code
+	MarkdownUnclosedFence MarkdownProblem = "unclosed_code_fence"
+	// MarkdownMalformedTable is a pipe table without a delimiter row or with rows
+	// of a different width than its header
+	MarkdownMalformedTable MarkdownProblem = "malformed_table"
+	// MarkdownHeadingJump is a heading more than one level below the one before it
+	MarkdownHeadingJump MarkdownProblem = "heading_level_jump"
+	// MarkdownMissingImage is an image with no path or a path to no file
+	MarkdownMissingImage MarkdownProblem = "image_missing_path"
+)
+
+// MarkdownIssue is one structural problem found in section markdown
+type MarkdownIssue struct {
+	Line    int             `json:"line"`
+	Problem MarkdownProblem `json:"problem"`
+	Message string          `json:"message"`
+}
+
+// DocumentSize breaks down the disk space a document uses
+type DocumentSize struct {
+	TotalBytes     int64         `json:"total_bytes"`
+	ChaptersBytes  int64         `json:"chapters_bytes"`
+	AssetsBytes    int64         `json:"assets_bytes"`
+	ExportsBytes   int64         `json:"exports_bytes"`   // exported files, previews and export logs
+	SnapshotsBytes int64         `json:"snapshots_bytes"` // word count snapshots
+	OtherBytes     int64         `json:"other_bytes"`     // manifest and settings
+	Chapters       []ChapterSize `json:"chapters"`
+	LargestFiles   []FileSize    `json:"largest_files"`
+}
+
+// StorageUsage reports the disk space each document uses against the storage quotas
+type StorageUsage struct {
+	TotalBytes       int64           `json:"total_bytes"`  // everything under the root directory
+	SharedBytes      int64           `json:"shared_bytes"` // styles, templates, archives and ledgers
+	MaxTotalBytes    int64           `json:"max_total_bytes,omitempty"`
+	MaxDocumentBytes int64           `json:"max_document_bytes,omitempty"`
+	Documents        []DocumentUsage `json:"documents"` // largest first
 }
 
-// Section represents a document section
+// DocumentUsage is the disk space used by one document
+type DocumentUsage struct {
+	DocumentID   DocumentID `json:"document_id"`
+	Title        string     `json:"title"`
+	ContentBytes int64      `json:"content_bytes"` // chapters, manifest, settings and snapshots
+	AssetsBytes  int64      `json:"assets_bytes"`
+	ExportsBytes int64      `json:"exports_bytes"`
+	TotalBytes   int64      `json:"total_bytes"`
+	OverQuota    bool       `json:"over_quota,omitempty"`
+}
+
+// ChapterSize is the disk space used by one chapter's text and metadata
+type ChapterSize struct {
+	Chapter ChapterNumber `json:"chapter"`
+	Bytes   int64         `json:"bytes"`
+}
+
+// FileSize is a file and its size, with the path relative to the root directory
+type FileSize struct {
+	Path  string `json:"path"`
+	Bytes int64  `json:"bytes"`
+}
+
+// TableOfContents is a compact outline of a document: chapter and section
+// numbers and titles with figure and table counts, and nothing else
+type TableOfContents struct {
+	Title    string       `json:"title"`
+	Chapters []TOCChapter `json:"chapters"`
+}
+
+// TOCChapter is a chapter in a table of contents
+type TOCChapter struct {
+	Number   ChapterNumber `json:"number"`
+	Title    string        `json:"title"`
+	Part     string        `json:"part,omitempty"` // set on the first chapter of a part
+	Figures  int           `json:"figures,omitempty"`
+	Tables   int           `json:"tables,omitempty"`
+	Sections []TOCSection  `json:"sections,omitempty"`
+}
+
+// TOCSection is a section in a table of contents
+type TOCSection struct {
+	Number SectionNumber `json:"number"`
+	Title  string        `json:"title"`
+	Level  int           `json:"level"`
+}
+
+// Text writes the table of contents one heading per line, sections indented
+// by level and figure and table counts after their chapter
+func (t *TableOfContents) Text() string {
+	var text strings.Builder
+	text.WriteString(t.Title + "\n")
+	for _, chapter := range t.Chapters {
+		if chapter.Part != "" {
+			text.WriteString(chapter.Part + "\n")
+		}
+		text.WriteString(fmt.Sprintf("%d %s", chapter.Number, chapter.Title))
+		var counts []string
+		if chapter.Figures > 0 {
+			counts = append(counts, pluralize(chapter.Figures, "figure"))
+		}
+		if chapter.Tables > 0 {
+			counts = append(counts, pluralize(chapter.Tables, "table"))
+		}
+		if len(counts) > 0 {
+			text.WriteString(" (" + strings.Join(counts, ", ") + ")")
+		}
+		text.WriteString("\n")
+		for _, section := range chapter.Sections {
+			text.WriteString(fmt.Sprintf("%s%s %s\n", strings.Repeat("  ", section.Level), section.Number, section.Title))
+		}
+	}
+	return text.String()
+}
+
+// StructureSummary is a cheap outline of a document for deciding where to
+// edit: each chapter's opening and section headings, without its full content
+type StructureSummary struct {
+	Title    string           `json:"title"`
+	Chapters []ChapterSummary `json:"chapters"`
+}
+
+// ChapterSummary is a chapter in a structure summary
+type ChapterSummary struct {
+	Number   ChapterNumber `json:"number"`
+	Title    string        `json:"title"`
+	Words    int           `json:"words"`
+	Excerpt  string        `json:"excerpt,omitempty"` // the first paragraph, or the first words of the chapter
+	Sections []TOCSection  `json:"sections,omitempty"`
+}
+
+// SummaryOptions selects what a structure summary includes
+type SummaryOptions struct {
+	// Chapters limits the summary to these chapters; all when empty
+	Chapters []ChapterNumber
+	// Depth counts heading levels as toc_depth does. Zero includes every level.
+	Depth int
+	// ExcerptWords takes each chapter's excerpt from its first words, across
+	// paragraphs, instead of its first paragraph
+	ExcerptWords int
+}
+
+// pluralize writes a count with its noun, adding an s unless the count is one
+func pluralize(count int, noun string) string {
+	if count == 1 {
+		return fmt.Sprintf("1 %s", noun)
+	}
+	return fmt.Sprintf("%d %ss", count, noun)
+}
+
+// WritingTarget is a word count goal with an optional deadline
+type WritingTarget struct {
+	Words    int    `yaml:"words" json:"words"`
+	Deadline string `yaml:"deadline,omitempty" json:"deadline,omitempty"` // YYYY-MM-DD
+}
+
+// Validate validates a writing target
+func (t *WritingTarget) Validate() error {
+	if t.Words <= 0 {
+		return fmt.Errorf("target words must be positive")
+	}
+	if t.Deadline != "" {
+		if _, err := time.Parse(DateLayout, t.Deadline); err != nil {
+			return fmt.Errorf("invalid deadline %q: use YYYY-MM-DD", t.Deadline)
+		}
+	}
+	return nil
+}
+
+// DateLayout is the layout of calendar dates in stats and targets
+const DateLayout = "2006-01-02"
+
+// WordCountSnapshot is a document's word count at the end of a day
+type WordCountSnapshot struct {
+	Date  string `yaml:"date" json:"date"` // YYYY-MM-DD
+	Words int    `yaml:"words" json:"words"`
+}
+
+// WritingStats is the word count history of a document, one snapshot per day
+// in date order. It is stored in the document's stats.yaml.
+type WritingStats struct {
+	Snapshots []WordCountSnapshot `yaml:"snapshots" json:"snapshots"`
+}
+
+// Record sets the word count for a day, replacing that day's earlier snapshot
+func (s *WritingStats) Record(date string, words int) {
+	if n := len(s.Snapshots); n > 0 && s.Snapshots[n-1].Date == date {
+		s.Snapshots[n-1].Words = words
+		return
+	}
+	s.Snapshots = append(s.Snapshots, WordCountSnapshot{Date: date, Words: words})
+}
+
+// DailyProgress is the words written on a single day
+type DailyProgress struct {
+	Date  string `json:"date"`
+	Words int    `json:"words"` // Total at the end of the day
+	Added int    `json:"added"` // Negative when text was cut
+}
+
+// WritingProgress summarizes a document's word count history against its target
+type WritingProgress struct {
+	CurrentWords        int             `json:"current_words"`
+	Days                []DailyProgress `json:"days"`
+	AveragePerDay       float64         `json:"average_per_day"` // Over the last seven days
+	TargetWords         int             `json:"target_words,omitempty"`
+	RemainingWords      int             `json:"remaining_words,omitempty"`
+	PercentComplete     float64         `json:"percent_complete,omitempty"`
+	ProjectedCompletion string          `json:"projected_completion,omitempty"` // YYYY-MM-DD at the current pace
+	Deadline            string          `json:"deadline,omitempty"`
+	RequiredPerDay      int             `json:"required_per_day,omitempty"` // To finish by the deadline
+	OnTrack             *bool           `json:"on_track,omitempty"`
+}
+
+// UsageQuota limits what a single client may produce. Zero means unlimited.
+type UsageQuota struct {
+	Documents   int   `yaml:"documents,omitempty" json:"documents,omitempty"`
+	Exports     int   `yaml:"exports,omitempty" json:"exports,omitempty"`
+	ExportBytes int64 `yaml:"export_bytes,omitempty" json:"export_bytes,omitempty"`
+}
+
+// ClientUsage is the usage recorded for one client (token or identity)
+type ClientUsage struct {
+	ClientID         string      `yaml:"client_id" json:"client_id"`
+	Documents        []string    `yaml:"documents" json:"documents"` // Documents created and not yet deleted
+	DocumentsCreated int         `yaml:"documents_created" json:"documents_created"`
+	Exports          int         `yaml:"exports" json:"exports"`
+	ExportBytes      int64       `yaml:"export_bytes" json:"export_bytes"`
+	LastActivity     time.Time   `yaml:"last_activity" json:"last_activity"`
+	Quota            *UsageQuota `yaml:"quota,omitempty" json:"quota,omitempty"` // Overrides the configured default quota
+}
+
+// UsageLedger holds the usage of every client. It is stored in usage.yaml in the
+// root directory.
+type UsageLedger struct {
+	Clients map[string]*ClientUsage `yaml:"clients" json:"clients"`
+}
+
+// Role is a level of access to documents. Each role includes the ones below it.
+type Role string
+
+const (
+	RoleViewer Role = "viewer" // Read and export documents
+	RoleEditor Role = "editor" // Change document content and settings
+	RoleAdmin  Role = "admin"  // Delete documents, grant roles and manage shared resources
+)
+
+// rank orders roles; unknown roles rank below viewer
+func (r Role) rank() int {
+	switch r {
+	case RoleViewer:
+		return 1
+	case RoleEditor:
+		return 2
+	case RoleAdmin:
+		return 3
+	default:
+		return 0
+	}
+}
+
+// Includes reports whether the role grants at least the required role
+func (r Role) Includes(required Role) bool {
+	return r.rank() > 0 && r.rank() >= required.rank()
+}
+
+// Validate validates a role
+func (r Role) Validate() error {
+	if r.rank() == 0 {
+		return fmt.Errorf("invalid role %q: must be %q, %q or %q", r, RoleViewer, RoleEditor, RoleAdmin)
+	}
+	return nil
+}
+
+// ProjectAccess grants roles on every document whose ID matches a pattern
+type ProjectAccess struct {
+	Documents string          `yaml:"documents" json:"documents"` // Glob over document IDs, e.g. "acme-*"
+	Roles     map[string]Role `yaml:"roles" json:"roles"`         // Identity to role
+}
+
+// AccessPolicy maps client identities to roles. It is stored in access.yaml in the
+// root directory. Grants are additive: an identity has the highest role given to
+// it globally, by a matching project or on the document itself.
+type AccessPolicy struct {
+	DefaultRole Role                       `yaml:"default_role,omitempty" json:"default_role,omitempty"` // Role of unlisted identities; none when empty
+	Roles       map[string]Role            `yaml:"roles,omitempty" json:"roles,omitempty"`               // Roles on every document
+	Projects    []ProjectAccess            `yaml:"projects,omitempty" json:"projects,omitempty"`
+	Documents   map[string]map[string]Role `yaml:"documents,omitempty" json:"documents,omitempty"` // Document ID to identity to role
+}
+
+// RoleFor returns the identity's role on a document, or its global role when
+// documentID is empty. An empty role means no access.
+func (p *AccessPolicy) RoleFor(identity, documentID string) Role {
+	role := p.DefaultRole
+	grant := func(r Role) {
+		if r.rank() > role.rank() {
+			role = r
+		}
+	}
+
+	grant(p.Roles[identity])
+	if documentID != "" {
+		for _, project := range p.Projects {
+			if matched, err := path.Match(project.Documents, documentID); err == nil && matched {
+				grant(project.Roles[identity])
+			}
+		}
+		grant(p.Documents[documentID][identity])
+	}
+	return role
+}
+
+// SetDocumentRole grants an identity a role on a document; an empty role revokes it
+func (p *AccessPolicy) SetDocumentRole(documentID, identity string, role Role) {
+	if role == "" {
+		delete(p.Documents[documentID], identity)
+		if len(p.Documents[documentID]) == 0 {
+			delete(p.Documents, documentID)
+		}
+		return
+	}
+	if p.Documents == nil {
+		p.Documents = make(map[string]map[string]Role)
+	}
+	if p.Documents[documentID] == nil {
+		p.Documents[documentID] = make(map[string]Role)
+	}
+	p.Documents[documentID][identity] = role
+}
+
+// Section represents a document section. Its body lives in the section file
+// (chapters/NN/sections/{number}.md); metadata holds structure only.
 type Section struct {
 	Number    SectionNumber `yaml:"number" json:"number"`
 	Title     string        `yaml:"title" json:"title"`
-	Content   string        `yaml:"content" json:"content"`
 	Level     int           `yaml:"level" json:"level"` // 1, 2, 3 for different heading levels
 	CreatedAt time.Time     `yaml:"created_at" json:"created_at"`
 	UpdatedAt time.Time     `yaml:"updated_at" json:"updated_at"`
+
+	// IncludeFile is a markdown file kept outside the document whose content
+	// is used instead of the section's own whenever the chapter is built
+	IncludeFile string `yaml:"include_file,omitempty" json:"include_file,omitempty"`
+
+	// FrontMatter is the author's own metadata, such as status or reviewer
+	FrontMatter FrontMatter `yaml:"front_matter,omitempty" json:"front_matter,omitempty"`
+
+	// Status is where the section is in the review workflow; the status of
+	// the section it is nested in, or of its chapter, when unset
+	Status WorkflowStatus `yaml:"status,omitempty" json:"status,omitempty"`
 }
 
 // Figure represents an image figure
@@ -107,6 +909,118 @@ type Figure struct {
 	Alignment ImageAlignment `yaml:"alignment" json:"alignment"`
 	CreatedAt time.Time      `yaml:"created_at" json:"created_at"`
 	UpdatedAt time.Time      `yaml:"updated_at" json:"updated_at"`
+
+	// CaptionTODO marks a generated placeholder caption that still needs writing
+	CaptionTODO bool `yaml:"caption_todo,omitempty" json:"caption_todo,omitempty"`
+
+	// SubFigures make a composite figure: images laid out in a grid of Columns
+	// columns, each with its own sub-caption (a), (b), ... under the figure's
+	// caption. Composite figures have no ImagePath of their own.
+	SubFigures []SubFigure `yaml:"subfigures,omitempty" json:"subfigures,omitempty"`
+	Columns    int         `yaml:"columns,omitempty" json:"columns,omitempty"`
+
+	// Anchor places the figure in the text flow when the content doesn't
+	Anchor *FigureAnchor `yaml:"anchor,omitempty" json:"anchor,omitempty"`
+}
+
+// FigureAnchor places a figure or table in its chapter's text: after paragraph
+// Paragraph of section Section, counting from 1, or at the start of the section
+// for 0. Rebuilding the chapter inserts the markup there, unless the content
+// already places the figure or table by hand.
+type FigureAnchor struct {
+	Section   SectionNumber `yaml:"section" json:"section"`
+	Paragraph int           `yaml:"paragraph" json:"paragraph"`
+}
+
+// SubFigure is one image of a composite figure
+type SubFigure struct {
+	ImagePath string `yaml:"image_path" json:"image_path"`
+	Caption   string `yaml:"caption,omitempty" json:"caption,omitempty"`
+}
+
+// FigureGridClass marks the fenced div that places a composite figure in the
+// content; exports fill it in from the figure's metadata
+const FigureGridClass = "figure-grid"
+
+// IsGrid reports whether a figure is a composite of sub-figures
+func (f Figure) IsGrid() bool {
+	return len(f.SubFigures) > 0
+}
+
+// ImagePaths returns the figure's image, or every image of a composite figure
+func (f Figure) ImagePaths() []string {
+	if !f.IsGrid() {
+		return []string{f.ImagePath}
+	}
+	paths := make([]string, len(f.SubFigures))
+	for i, sub := range f.SubFigures {
+		paths[i] = sub.ImagePath
+	}
+	return paths
+}
+
+// Label returns the figure's display number as an ID, fig-1.2 for the second
+// figure of chapter 1. It is accepted wherever a figure ID is, but changes when
+// figures are deleted or chapters move.
+func (f Figure) Label() FigureID {
+	return GenerateFigureID(f.Chapter, f.Sequence)
+}
+
+// Markup returns the markdown that places the figure in the content: the
+// image with its anchor, or the fenced div a composite figure is rendered from
+func (f Figure) Markup() string {
+	if f.IsGrid() {
+		return fmt.Sprintf("::: {#%s .%s}\n:::", f.ID, FigureGridClass)
+	}
+	return fmt.Sprintf("![%s](%s){%s}", f.Caption, f.ImagePath, strings.Join(append([]string{"#" + string(f.ID)}, f.LayoutAttributes()...), " "))
+}
+
+// Figure alignment classes, set on the image of a figure that isn't centered
+const (
+	FigureAlignLeftClass  = "align-left"
+	FigureAlignRightClass = "align-right"
+)
+
+// LayoutAttributes returns the pandoc attributes that size and align the
+// figure's image: an alignment class when it isn't centered and its width
+func (f Figure) LayoutAttributes() []string {
+	var attributes []string
+	switch f.Alignment {
+	case AlignLeft:
+		attributes = append(attributes, "."+FigureAlignLeftClass)
+	case AlignRight:
+		attributes = append(attributes, "."+FigureAlignRightClass)
+	}
+	if f.Width != "" {
+		attributes = append(attributes, "width="+f.Width)
+	}
+	return attributes
+}
+
+// ImageProperties are the layout settings of an image figure. Empty fields
+// are left as they are when updating a figure.
+type ImageProperties struct {
+	// Width is a share of the text width such as 50%, or a length such as
+	// 10cm; "auto" clears it so the image keeps its natural size
+	Width     string         `json:"width,omitempty"`
+	Alignment ImageAlignment `json:"alignment,omitempty"`
+	Position  ImagePosition  `json:"position,omitempty"`
+}
+
+// imageWidthPattern matches an image width: a percentage or a length
+var imageWidthPattern = regexp.MustCompile(`^(\d+(\.\d+)?%|\d+(\.\d+)?(in|cm|mm|pt|px))$`)
+
+// ValidateImageWidth checks an image width such as 50% or 10cm
+func ValidateImageWidth(width string) error {
+	if !imageWidthPattern.MatchString(width) {
+		return fmt.Errorf("invalid width: %s (use a percentage such as 50%% or a length in in, cm, mm, pt or px)", width)
+	}
+	if strings.HasSuffix(width, "%") {
+		if percent, _ := strconv.ParseFloat(strings.TrimSuffix(width, "%"), 64); percent <= 0 || percent > 100 {
+			return fmt.Errorf("invalid width: %s (a percentage must be above 0%% and at most 100%%)", width)
+		}
+	}
+	return nil
 }
 
 // Table represents a document table
@@ -116,9 +1030,269 @@ type Table struct {
 	Sequence  int           `yaml:"sequence" json:"sequence"`
 	Caption   string        `yaml:"caption" json:"caption"`
 	Content   string        `yaml:"content" json:"content"` // Markdown table content
-	Format    string        `yaml:"format" json:"format"`  // "markdown" for MVP
+	Format    string        `yaml:"format" json:"format"`  // "markdown" for pipe tables, or "grid"
 	CreatedAt time.Time     `yaml:"created_at" json:"created_at"`
 	UpdatedAt time.Time     `yaml:"updated_at" json:"updated_at"`
+
+	// Anchor places the table in the text flow when the content doesn't
+	Anchor *FigureAnchor `yaml:"anchor,omitempty" json:"anchor,omitempty"`
+
+	// Data holds the cells of tables built from CSV, which Content is
+	// written from whenever they change
+	Data *TableData `yaml:"data,omitempty" json:"data,omitempty"`
+}
+
+// Table formats: pipe tables keep each cell on one line, grid tables allow
+// cells with several lines
+const (
+	TableFormatMarkdown = "markdown"
+	TableFormatGrid     = "grid"
+)
+
+// ColumnAlignment is how a table column's cells are aligned
+type ColumnAlignment string
+
+const (
+	ColumnDefault ColumnAlignment = "default"
+	ColumnLeft    ColumnAlignment = "left"
+	ColumnRight   ColumnAlignment = "right"
+	ColumnCenter  ColumnAlignment = "center"
+)
+
+// TableData is the cells of a table: a header row and body rows, each as wide
+// as the widest row
+type TableData struct {
+	Header     []string          `yaml:"header" json:"header"`
+	Rows       [][]string        `yaml:"rows" json:"rows"`
+	Alignments []ColumnAlignment `yaml:"alignments,omitempty" json:"alignments,omitempty"` // per column
+}
+
+// TableCell sets one cell of a table. Row 0 is the header and rows and columns
+// count from 1.
+type TableCell struct {
+	Row    int    `json:"row"`
+	Column int    `json:"column"`
+	Value  string `json:"value"`
+}
+
+// TableColumn is a column added to a table, with a value for each body row
+type TableColumn struct {
+	Header string   `json:"header"`
+	Values []string `json:"values"`
+}
+
+// TableUpdate changes a table's cells: columns and rows are added first, so
+// cells can set values in them
+type TableUpdate struct {
+	AddColumns []TableColumn `json:"add_columns,omitempty"`
+	AddRows    [][]string    `json:"add_rows,omitempty"`
+	Cells      []TableCell   `json:"cells,omitempty"`
+}
+
+// ValidateTableFormat checks a table format
+func ValidateTableFormat(format string) error {
+	switch format {
+	case TableFormatMarkdown, TableFormatGrid:
+		return nil
+	}
+	return fmt.Errorf("invalid table format %q: must be %q or %q", format, TableFormatMarkdown, TableFormatGrid)
+}
+
+// Columns returns the number of columns of the table
+func (d *TableData) Columns() int {
+	columns := len(d.Header)
+	for _, row := range d.Rows {
+		if len(row) > columns {
+			columns = len(row)
+		}
+	}
+	return columns
+}
+
+// Normalize pads the header and rows with empty cells to the table's width
+// and trims the spaces around cell values
+func (d *TableData) Normalize() {
+	columns := d.Columns()
+	pad := func(cells []string) []string {
+		for i := range cells {
+			cells[i] = strings.TrimSpace(cells[i])
+		}
+		for len(cells) < columns {
+			cells = append(cells, "")
+		}
+		return cells
+	}
+	d.Header = pad(d.Header)
+	for i := range d.Rows {
+		d.Rows[i] = pad(d.Rows[i])
+	}
+}
+
+// Validate validates table data
+func (d *TableData) Validate() error {
+	if d.Columns() == 0 {
+		return fmt.Errorf("a table needs at least one column")
+	}
+	if len(d.Alignments) > d.Columns() {
+		return fmt.Errorf("%d alignments given for %d columns", len(d.Alignments), d.Columns())
+	}
+	for _, alignment := range d.Alignments {
+		switch alignment {
+		case ColumnDefault, ColumnLeft, ColumnRight, ColumnCenter, "":
+		default:
+			return fmt.Errorf("invalid alignment %q: must be left, right, center or default", alignment)
+		}
+	}
+	return nil
+}
+
+// Markdown writes the table as a pipe table, or a grid table for the grid
+// format. Pipe tables can't hold line breaks, so they are written as spaces.
+func (d *TableData) Markdown(format string) string {
+	columns := d.Columns()
+	cell := func(cells []string, i int) string {
+		if i >= len(cells) {
+			return ""
+		}
+		return strings.ReplaceAll(cells[i], "|", "\\|")
+	}
+	alignment := func(i int) ColumnAlignment {
+		if i < len(d.Alignments) {
+			return d.Alignments[i]
+		}
+		return ColumnDefault
+	}
+
+	var table strings.Builder
+	if format != TableFormatGrid {
+		row := func(cells []string) {
+			for i := 0; i < columns; i++ {
+				table.WriteString("| " + strings.Join(strings.Fields(cell(cells, i)), " ") + " ")
+			}
+			table.WriteString("|\n")
+		}
+		row(d.Header)
+		for i := 0; i < columns; i++ {
+			switch alignment(i) {
+			case ColumnLeft:
+				table.WriteString("|:---")
+			case ColumnRight:
+				table.WriteString("|---:")
+			case ColumnCenter:
+				table.WriteString("|:---:")
+			default:
+				table.WriteString("|---")
+			}
+		}
+		table.WriteString("|\n")
+		for _, cells := range d.Rows {
+			row(cells)
+		}
+		return strings.TrimSuffix(table.String(), "\n")
+	}
+
+	// Grid columns are as wide as their widest line
+	widths := make([]int, columns)
+	measure := func(cells []string) {
+		for i := 0; i < columns; i++ {
+			for _, line := range strings.Split(cell(cells, i), "\n") {
+				if n := utf8.RuneCountInString(line); n > widths[i] {
+					widths[i] = n
+				}
+			}
+		}
+	}
+	measure(d.Header)
+	for _, cells := range d.Rows {
+		measure(cells)
+	}
+	border := func(fill string, aligned bool) {
+		for i, width := range widths {
+			line := strings.Repeat(fill, width+2)
+			if aligned {
+				switch alignment(i) {
+				case ColumnLeft:
+					line = ":" + line[1:]
+				case ColumnRight:
+					line = line[1:] + ":"
+				case ColumnCenter:
+					line = ":" + line[1:len(line)-1] + ":"
+				}
+			}
+			table.WriteString("+" + line)
+		}
+		table.WriteString("+\n")
+	}
+	row := func(cells []string) {
+		lines := make([][]string, columns)
+		height := 1
+		for i := range lines {
+			lines[i] = strings.Split(cell(cells, i), "\n")
+			if len(lines[i]) > height {
+				height = len(lines[i])
+			}
+		}
+		for l := 0; l < height; l++ {
+			for i, width := range widths {
+				text := ""
+				if l < len(lines[i]) {
+					text = lines[i][l]
+				}
+				table.WriteString("| " + text + strings.Repeat(" ", width-utf8.RuneCountInString(text)) + " ")
+			}
+			table.WriteString("|\n")
+		}
+	}
+	border("-", false)
+	row(d.Header)
+	border("=", true)
+	for _, cells := range d.Rows {
+		row(cells)
+		border("-", false)
+	}
+	return strings.TrimSuffix(table.String(), "\n")
+}
+
+// Markup returns the markdown that places the table in the content: the table
+// followed by its caption and anchor
+func (t Table) Markup() string {
+	return fmt.Sprintf("%s\n\nTable: %s {#%s}", strings.TrimSpace(t.Content), t.Caption, t.ID)
+}
+
+// Listing represents a captioned code listing. Its code lives in the section
+// content, as a fenced code block carrying the listing's ID: ```{#lst-1.2 .python}
+type Listing struct {
+	ID        ListingID     `yaml:"id" json:"id"`
+	Chapter   ChapterNumber `yaml:"chapter" json:"chapter"`
+	Sequence  int           `yaml:"sequence" json:"sequence"`
+	Caption   string        `yaml:"caption" json:"caption"`
+	Language  string        `yaml:"language,omitempty" json:"language,omitempty"`
+	CreatedAt time.Time     `yaml:"created_at" json:"created_at"`
+	UpdatedAt time.Time     `yaml:"updated_at" json:"updated_at"`
+}
+
+// Markup returns the fenced code block that places the listing in the content
+func (l Listing) Markup(code string) string {
+	fence := CodeFence(code)
+	attributes := "#" + string(l.ID)
+	if l.Language != "" {
+		attributes += " ." + l.Language
+	}
+	return fmt.Sprintf("%s{%s}\n%s\n%s", fence, attributes, strings.TrimRight(code, "\n"), fence)
+}
+
+// CodeFence returns a backtick fence for a code block holding code: longer
+// than any backtick run a line of the code starts with, so the code can't
+// close it
+func CodeFence(code string) string {
+	fence := "```"
+	for _, line := range strings.Split(code, "\n") {
+		trimmed := strings.TrimLeft(line, " ")
+		for strings.HasPrefix(trimmed, fence) {
+			fence += "`"
+		}
+	}
+	return fence
 }
 
 // ChapterCount tracks counts for a chapter
@@ -134,37 +1308,182 @@ type Manifest struct {
 	ChapterCounts map[ChapterNumber]ChapterCount `yaml:"chapter_counts" json:"chapter_counts"`
 	CreatedAt     time.Time                   `yaml:"created_at" json:"created_at"`
 	UpdatedAt     time.Time                   `yaml:"updated_at" json:"updated_at"`
+
+	// ChapterOrder lists the directory under chapters/ of every chapter in
+	// reading order: chapter N is kept in ChapterOrder[N-1]. Directories are
+	// named once, when a chapter is created, so reordering chapters only changes
+	// this list. Documents written before the list existed have none, and their
+	// chapters are kept in directories named after their number.
+	ChapterOrder []string `yaml:"chapter_order,omitempty" json:"chapter_order,omitempty"`
+
+	// Health is filled in for get_document_structure responses and never saved
+	Health *DocumentHealth `yaml:"-" json:"health,omitempty"`
+
+	// ETag is the version of the manifest in an object store as it was
+	// loaded or last saved; a save only succeeds while the stored manifest
+	// still has it. Storage that keeps no versions leaves it empty.
+	ETag string `yaml:"-" json:"-"`
 }
 
 // TextStyle represents font and color settings for text elements
 type TextStyle struct {
 	FontFamily string `yaml:"font_family" json:"font_family"`
+	// FontFile is a font file in the document's fonts folder or the shared one
+	// that exports load instead of an installed font, so they look the same
+	// on machines without it. Bold and italic faces are the files named like
+	// it, such as Name-Bold.otf and Name-Italic.otf beside Name-Regular.otf.
+	FontFile   string `yaml:"font_file,omitempty" json:"font_file,omitempty"`
 	FontSize   string `yaml:"font_size,omitempty" json:"font_size,omitempty"`
 	Color      string `yaml:"color,omitempty" json:"color,omitempty"`
 }
 
 // Style represents document styling configuration
 type Style struct {
+	// Extends names a style this one is based on. Only the fields a style
+	// sets override those of the style it extends.
+	Extends       string         `yaml:"extends,omitempty" json:"extends,omitempty"`
+
 	// Text styles
 	Body          TextStyle      `yaml:"body" json:"body"`
 	Heading       TextStyle      `yaml:"heading" json:"heading"`
 	Monospace     TextStyle      `yaml:"monospace,omitempty" json:"monospace,omitempty"`
+	Epigraph      TextStyle      `yaml:"epigraph,omitempty" json:"epigraph,omitempty"`
+
+	// FontFallbacks lists, for a font family, the fonts PDF exports use in
+	// its place when it isn't installed, in order of preference
+	FontFallbacks map[string][]string `yaml:"font_fallbacks,omitempty" json:"font_fallbacks,omitempty"`
 	
 	// Global styles
 	LinkColor     string         `yaml:"link_color,omitempty" json:"link_color,omitempty"`
 	Margins       Margins        `yaml:"margins" json:"margins"`
 	LineSpacing   string         `yaml:"line_spacing,omitempty" json:"line_spacing,omitempty"`
+	Typography    Typography     `yaml:"typography,omitempty" json:"typography,omitempty"`
 	
 	// Header/Footer with template support
 	HeaderFooter  HeaderFooter   `yaml:"header_footer" json:"header_footer"`
 	
 	// Other settings
 	NumberingStyle NumberingStyle `yaml:"numbering_style" json:"numbering_style"`
+	ChapterHeading ChapterHeadingStyle `yaml:"chapter_heading,omitempty" json:"chapter_heading,omitempty"`
 	
 	// Output-specific templates
 	ReferenceDocx string         `yaml:"reference_docx,omitempty" json:"reference_docx,omitempty"`
 	StyleCSS      string         `yaml:"style_css,omitempty" json:"style_css,omitempty"`
 	LaTeXHeader   string         `yaml:"latex_header,omitempty" json:"latex_header,omitempty"`
+
+	// HTML-only layout and theme
+	HTML          HTMLStyle      `yaml:"html,omitempty" json:"html,omitempty"`
+
+	// Slide deck theme and layout
+	Slides        SlideStyle     `yaml:"slides,omitempty" json:"slides,omitempty"`
+}
+
+// SlideStyle is the part of a style for slide decks. Decks take their fonts
+// and colors from the body, headings and links; the rest of the style is for
+// pages and doesn't apply.
+type SlideStyle struct {
+	// Theme is the reveal.js theme, such as white, black, simple or serif
+	Theme string `yaml:"theme,omitempty" json:"theme,omitempty"`
+	// Transition is how reveal.js moves between slides: none, fade, slide,
+	// convex, concave or zoom
+	Transition string `yaml:"transition,omitempty" json:"transition,omitempty"`
+	// AspectRatio is the reveal.js slide shape, 16:9 or 4:3
+	AspectRatio string `yaml:"aspect_ratio,omitempty" json:"aspect_ratio,omitempty"`
+	// SlideNumbers shows the number of each reveal.js slide
+	SlideNumbers bool `yaml:"slide_numbers,omitempty" json:"slide_numbers,omitempty"`
+	// ReferencePPTX is a PowerPoint file whose layouts and theme pptx decks
+	// use, relative to the document directory
+	ReferencePPTX string `yaml:"reference_pptx,omitempty" json:"reference_pptx,omitempty"`
+}
+
+// slideAspectRatios are the reveal.js slide sizes of each aspect ratio
+var slideAspectRatios = map[string][2]int{"16:9": {1280, 720}, "4:3": {1024, 768}}
+
+// Validate checks the transition and aspect ratio
+func (s SlideStyle) Validate() error {
+	switch s.Transition {
+	case "", "none", "fade", "slide", "convex", "concave", "zoom":
+	default:
+		return fmt.Errorf("invalid slide transition %q: must be one of none, fade, slide, convex, concave, zoom", s.Transition)
+	}
+	if _, ok := slideAspectRatios[s.AspectRatio]; s.AspectRatio != "" && !ok {
+		return fmt.Errorf("invalid slide aspect_ratio %q: must be 16:9 or 4:3", s.AspectRatio)
+	}
+	return nil
+}
+
+// Size returns the reveal.js slide width and height of the aspect ratio, or
+// false when none is set
+func (s SlideStyle) Size() (int, int, bool) {
+	size, ok := slideAspectRatios[s.AspectRatio]
+	return size[0], size[1], ok
+}
+
+// HTMLStyle controls the layout and colors of HTML exports, layered over the
+// document's own CSS
+type HTMLStyle struct {
+	// MaxWidth caps the width of the text column, such as 45rem or 800px
+	MaxWidth string `yaml:"max_width,omitempty" json:"max_width,omitempty"`
+	// SidebarTOC pins the table of contents beside the text on wide screens.
+	// It turns the table of contents on for HTML exports.
+	SidebarTOC bool `yaml:"sidebar_toc,omitempty" json:"sidebar_toc,omitempty"`
+	// Background is the page color in the light theme
+	Background string `yaml:"background,omitempty" json:"background,omitempty"`
+
+	// DarkMode follows the reader's prefers-color-scheme setting with a dark
+	// theme, in the colors below or dark defaults where they are left out
+	DarkMode         bool   `yaml:"dark_mode,omitempty" json:"dark_mode,omitempty"`
+	DarkBackground   string `yaml:"dark_background,omitempty" json:"dark_background,omitempty"`
+	DarkTextColor    string `yaml:"dark_text_color,omitempty" json:"dark_text_color,omitempty"`
+	DarkHeadingColor string `yaml:"dark_heading_color,omitempty" json:"dark_heading_color,omitempty"`
+	DarkLinkColor    string `yaml:"dark_link_color,omitempty" json:"dark_link_color,omitempty"`
+}
+
+// IsSet reports whether any HTML layout or theme option is set
+func (h HTMLStyle) IsSet() bool {
+	return h != HTMLStyle{}
+}
+
+// Typography holds a house's typographic conventions, applied to the text of
+// exports. Code, links and attributes are left as written.
+type Typography struct {
+	// SmartPunctuation turns pandoc's curly quotes, -- and --- as dashes and
+	// ... as an ellipsis on or off; unset keeps the markdown profile's setting
+	SmartPunctuation *bool `yaml:"smart_punctuation,omitempty" json:"smart_punctuation,omitempty"`
+	// Dashes rewrites dashes between words in one house style
+	Dashes DashStyle `yaml:"dashes,omitempty" json:"dashes,omitempty"`
+	// Ellipses writes three dots as an ellipsis character
+	Ellipses bool `yaml:"ellipses,omitempty" json:"ellipses,omitempty"`
+	// NonBreakingSpaces keeps numbers with their units (10 kg) and reference
+	// words with their numbers (Figure 3, p. 12) on one line
+	NonBreakingSpaces bool `yaml:"non_breaking_spaces,omitempty" json:"non_breaking_spaces,omitempty"`
+
+	// PreventWidows and PreventOrphans keep LaTeX from leaving a paragraph's
+	// last line alone at the top of a page, or its first at the bottom
+	PreventWidows  bool `yaml:"prevent_widows,omitempty" json:"prevent_widows,omitempty"`
+	PreventOrphans bool `yaml:"prevent_orphans,omitempty" json:"prevent_orphans,omitempty"`
+}
+
+// DashStyle is how dashes between words are written
+type DashStyle string
+
+const (
+	DashEm       DashStyle = "em"        // closed em dash: word—word
+	DashSpacedEn DashStyle = "spaced-en" // spaced en dash: word – word
+)
+
+// Validate validates the typography settings
+func (t Typography) Validate() error {
+	switch t.Dashes {
+	case "", DashEm, DashSpacedEn:
+		return nil
+	}
+	return fmt.Errorf("invalid dashes %q: must be %q or %q", t.Dashes, DashEm, DashSpacedEn)
+}
+
+// RewritesText reports whether the typography changes the text itself
+func (t Typography) RewritesText() bool {
+	return t.Dashes != "" || t.Ellipses || t.NonBreakingSpaces
 }
 
 // Margins represents document margins
@@ -179,6 +1498,68 @@ type Margins struct {
 type HeaderFooter struct {
 	HeaderTemplate string `yaml:"header_template,omitempty" json:"header_template,omitempty"`
 	FooterTemplate string `yaml:"footer_template,omitempty" json:"footer_template,omitempty"`
+
+	// FirstPage, OddPage and EvenPage replace the templates above on the first
+	// page and on odd (right-hand) and even (left-hand) pages. A page kind that
+	// is set shows only what it gives, so leaving its header empty blanks it.
+	FirstPage *PageTemplates `yaml:"first_page,omitempty" json:"first_page,omitempty"`
+	OddPage   *PageTemplates `yaml:"odd_page,omitempty" json:"odd_page,omitempty"`
+	EvenPage  *PageTemplates `yaml:"even_page,omitempty" json:"even_page,omitempty"`
+
+	// SuppressChapterStart leaves the opening page of each chapter without a
+	// header or footer (PDF and HTML)
+	SuppressChapterStart bool `yaml:"suppress_chapter_start,omitempty" json:"suppress_chapter_start,omitempty"`
+}
+
+// PageTemplates are the header and footer templates of one kind of page
+type PageTemplates struct {
+	Header string `yaml:"header,omitempty" json:"header,omitempty"`
+	Footer string `yaml:"footer,omitempty" json:"footer,omitempty"`
+}
+
+// IsSet reports whether any header or footer is configured
+func (hf HeaderFooter) IsSet() bool {
+	return hf.HeaderTemplate != "" || hf.FooterTemplate != "" || hf.FirstPage != nil || hf.OddPage != nil || hf.EvenPage != nil || hf.SuppressChapterStart
+}
+
+// Odd returns the templates of odd pages
+func (hf HeaderFooter) Odd() PageTemplates {
+	if hf.OddPage != nil {
+		return *hf.OddPage
+	}
+	return PageTemplates{Header: hf.HeaderTemplate, Footer: hf.FooterTemplate}
+}
+
+// Even returns the templates of even pages
+func (hf HeaderFooter) Even() PageTemplates {
+	if hf.EvenPage != nil {
+		return *hf.EvenPage
+	}
+	return PageTemplates{Header: hf.HeaderTemplate, Footer: hf.FooterTemplate}
+}
+
+// namedTemplates lists every template that is set, with the name validation
+// warnings use for it
+func (hf HeaderFooter) namedTemplates() [][2]string {
+	templates := [][2]string{{"Header template", hf.HeaderTemplate}, {"Footer template", hf.FooterTemplate}}
+	for _, page := range []struct {
+		name      string
+		templates *PageTemplates
+	}{{"First page", hf.FirstPage}, {"Odd page", hf.OddPage}, {"Even page", hf.EvenPage}} {
+		if page.templates != nil {
+			templates = append(templates,
+				[2]string{page.name + " header", page.templates.Header},
+				[2]string{page.name + " footer", page.templates.Footer})
+		}
+	}
+
+	var named [][2]string
+	for _, template := range templates {
+		if template[1] != "" {
+			named = append(named, template)
+		}
+	}
+	return named
 }
 
 // NumberingStyle represents numbering preferences
@@ -187,30 +1568,1042 @@ type NumberingStyle struct {
 	Sections bool `yaml:"sections" json:"sections"`
 	Figures  bool `yaml:"figures" json:"figures"`
 	Tables   bool `yaml:"tables" json:"tables"`
+
+	// ChapterFormat writes chapter numbers as 1, 2 (the default), I, II or A, B.
+	// Section, figure and table numbers start with the chapter number as written.
+	ChapterFormat NumberFormat `yaml:"chapter_format,omitempty" json:"chapter_format,omitempty"`
+	// FigureNumbering and TableNumbering restart in each chapter (1.1, 1.2; the
+	// default) or run through the document (1, 2, 3)
+	FigureNumbering CounterScope `yaml:"figure_numbering,omitempty" json:"figure_numbering,omitempty"`
+	TableNumbering  CounterScope `yaml:"table_numbering,omitempty" json:"table_numbering,omitempty"`
+	// SectionDepth is the deepest section level that is numbered: 1 numbers
+	// 1.1 but not 1.1.1. Zero numbers every level.
+	SectionDepth int `yaml:"section_depth,omitempty" json:"section_depth,omitempty"`
+}
+
+// ChapterHeadingStyle is how chapter headings are written in chapter markdown,
+// and so in every export
+type ChapterHeadingStyle struct {
+	// Prefix goes before the title, with %d replaced by the chapter number in
+	// the chapter format. Unset means "Chapter %d:"; empty means the title alone.
+	Prefix *string `yaml:"prefix,omitempty" json:"prefix,omitempty"`
+	// Unnumbered lists the titles of chapters written without the prefix, such
+	// as Introduction. Other chapters keep their numbers.
+	Unnumbered []string `yaml:"unnumbered,omitempty" json:"unnumbered,omitempty"`
+	// Case rewrites the title, leaving the prefix as written
+	Case HeadingCase `yaml:"case,omitempty" json:"case,omitempty"`
+}
+
+// HeadingCase is how heading text is capitalized
+type HeadingCase string
+
+const (
+	HeadingCaseUpper HeadingCase = "upper" // ALL CAPITALS
+	HeadingCaseLower HeadingCase = "lower" // all lower case
+	HeadingCaseTitle HeadingCase = "title" // Capitals for All but Minor Words
+)
+
+// DefaultChapterPrefix is the chapter heading prefix when a style sets none
+const DefaultChapterPrefix = "Chapter %d:"
+
+// NumberFormat is how a counter is written
+type NumberFormat string
+
+const (
+	NumberFormatArabic  NumberFormat = "arabic"  // 1, 2, 3
+	NumberFormatRoman   NumberFormat = "roman"   // I, II, III
+	NumberFormatLetters NumberFormat = "letters" // A, B, C
+)
+
+// CounterScope says whether a counter restarts with each chapter
+type CounterScope string
+
+const (
+	CounterPerChapter CounterScope = "chapter"    // 1.1, 1.2, 2.1
+	CounterContinuous CounterScope = "continuous" // 1, 2, 3
+)
+
+// FloatPlacement says how LaTeX places figures and tables in PDF exports
+type FloatPlacement string
+
+const (
+	FloatPlacementDefault  FloatPlacement = ""         // LaTeX's own float parameters
+	FloatPlacementBalanced FloatPlacement = "balanced" // relaxed float parameters, so floats fill pages instead of leaving gaps
+	FloatPlacementHere     FloatPlacement = "here"     // every float stays where it is written ([H])
+)
+
+// Validate validates a float placement
+func (p FloatPlacement) Validate() error {
+	switch p {
+	case FloatPlacementDefault, FloatPlacementBalanced, FloatPlacementHere:
+		return nil
+	}
+	return fmt.Errorf("invalid float placement %q: must be %q or %q", p, FloatPlacementBalanced, FloatPlacementHere)
+}
+
+// ExportCompression is the packaging of the compressed copy of an export
+type ExportCompression string
+
+const (
+	CompressionNone ExportCompression = ""
+	CompressionGzip ExportCompression = "gzip" // <export>.gz, e.g. for serving HTML with Content-Encoding: gzip
+	CompressionZip  ExportCompression = "zip"  // <export>.zip, for downloads
+)
+
+// Validate validates an export compression
+func (c ExportCompression) Validate() error {
+	switch c {
+	case CompressionNone, CompressionGzip, CompressionZip:
+		return nil
+	}
+	return fmt.Errorf("invalid compression %q: must be %q or %q", c, CompressionGzip, CompressionZip)
+}
+
+// PandocConfig represents pandoc-specific configuration
+type PandocConfig struct {
+	PDFEngine     string            `yaml:"pdf_engine" json:"pdf_engine"`
+	TOC           bool              `yaml:"toc" json:"toc"`
+	TOCDepth      int               `yaml:"toc_depth" json:"toc_depth"`
+	CitationStyle string            `yaml:"citation_style" json:"citation_style"`
+	Args          []string          `yaml:"args" json:"args"`
+	Variables     map[string]string `yaml:"variables" json:"variables"`
+	ClassOptions  []string          `yaml:"classoptions,omitempty" json:"classoptions,omitempty"`
+
+	// Bibliography is a .bib, .json or .yaml file of references, relative to
+	// the document directory. Citations are resolved with citeproc when set.
+	Bibliography string `yaml:"bibliography,omitempty" json:"bibliography,omitempty"`
+	// BibliographyScope places the reference list at the end of the book
+	// (the default) or at the end of each chapter
+	BibliographyScope BibliographyScope `yaml:"bibliography_scope,omitempty" json:"bibliography_scope,omitempty"`
+
+	// Markdown selects the markdown flavor used to read the document
+	Markdown *MarkdownProfile `yaml:"markdown,omitempty" json:"markdown,omitempty"`
+}
+
+// BibliographyScope says where reference lists are placed
+type BibliographyScope string
+
+const (
+	BibliographyScopeDocument BibliographyScope = "document" // one list at the end of the document
+	BibliographyScopeChapter  BibliographyScope = "chapter"  // a list of its own references after each chapter
+)
+
+// Citation is a reference to a bibliography entry, written in pandoc's
+// citation syntax: [see @knuth84, p. 12]
+type Citation struct {
+	Key     string `json:"key"`               // bibliography key, without the @
+	Prefix  string `json:"prefix,omitempty"`  // text before the key, e.g. "see"
+	Locator string `json:"locator,omitempty"` // e.g. "p. 12" or "chap. 3"
+
+	// AuthorInText names the author in the sentence: @knuth84 [p. 12]
+	AuthorInText bool `json:"author_in_text,omitempty"`
+	// SuppressAuthor leaves the author out when the text already names it: [-@knuth84]
+	SuppressAuthor bool `json:"suppress_author,omitempty"`
+}
+
+// MarkdownProfile turns pandoc markdown reader extensions on or off for a
+// document. Unset fields keep pandoc's default, which is on for all of them. The
+// same profile is used for exports and the built-in preview renderer.
+type MarkdownProfile struct {
+	Footnotes  *bool `yaml:"footnotes,omitempty" json:"footnotes,omitempty"`
+	PipeTables *bool `yaml:"pipe_tables,omitempty" json:"pipe_tables,omitempty"`
+	TaskLists  *bool `yaml:"task_lists,omitempty" json:"task_lists,omitempty"`
+	RawHTML    *bool `yaml:"raw_html,omitempty" json:"raw_html,omitempty"`
+	Smart      *bool `yaml:"smart,omitempty" json:"smart,omitempty"`
+
+	// Extensions turns other pandoc markdown extensions on or off by name, such
+	// as definition_lists or raw_tex. Only exports use them.
+	Extensions map[string]bool `yaml:"extensions,omitempty" json:"extensions,omitempty"`
+}
+
+// MarkdownExtensions lists the extensions a markdown profile has fields for, by
+// their pandoc names. The built-in preview renderer follows these.
+var MarkdownExtensions = []string{"footnotes", "pipe_tables", "task_lists", "raw_html", "smart"}
+
+// extensionNamePattern matches pandoc extension names
+var extensionNamePattern = regexp.MustCompile(`^[a-z][a-z0-9_]*$`)
+
+// field returns the setting for a pandoc extension name
+func (p *MarkdownProfile) field(extension string) **bool {
+	switch extension {
+	case "footnotes":
+		return &p.Footnotes
+	case "pipe_tables":
+		return &p.PipeTables
+	case "task_lists":
+		return &p.TaskLists
+	case "raw_html":
+		return &p.RawHTML
+	case "smart":
+		return &p.Smart
+	}
+	return nil
+}
+
+// Set turns an extension on or off. Whether pandoc knows the extension is
+// checked against the installed pandoc, not here.
+func (p *MarkdownProfile) Set(extension string, enabled bool) error {
+	if field := p.field(extension); field != nil {
+		*field = &enabled
+		return nil
+	}
+	if !extensionNamePattern.MatchString(extension) {
+		return fmt.Errorf("invalid markdown extension %q: use pandoc's name, such as definition_lists", extension)
+	}
+	if p.Extensions == nil {
+		p.Extensions = make(map[string]bool)
+	}
+	p.Extensions[extension] = enabled
+	return nil
+}
+
+// Setting returns whether an extension is on and whether the profile sets it
+// at all
+func (p *MarkdownProfile) Setting(extension string) (enabled, ok bool) {
+	if p == nil {
+		return false, false
+	}
+	if field := p.field(extension); field != nil {
+		if *field == nil {
+			return false, false
+		}
+		return **field, true
+	}
+	enabled, ok = p.Extensions[extension]
+	return enabled, ok
+}
+
+// Enabled reports whether an extension is on. A nil profile has every extension on.
+func (p *MarkdownProfile) Enabled(extension string) bool {
+	enabled, ok := p.Setting(extension)
+	return !ok || enabled
+}
+
+// PandocFormat returns the pandoc --from value for the profile, such as
+// "markdown-raw_html+smart". A nil profile reads plain pandoc markdown.
+func (p *MarkdownProfile) PandocFormat() string {
+	format := "markdown"
+	if p == nil {
+		return format
+	}
+	for _, extension := range MarkdownExtensions {
+		setting := *p.field(extension)
+		if setting == nil {
+			continue
+		}
+		if *setting {
+			format += "+" + extension
+		} else {
+			format += "-" + extension
+		}
+	}
+	extensions := make([]string, 0, len(p.Extensions))
+	for extension := range p.Extensions {
+		extensions = append(extensions, extension)
+	}
+	sort.Strings(extensions)
+	for _, extension := range extensions {
+		if p.Extensions[extension] {
+			format += "+" + extension
+		} else {
+			format += "-" + extension
+		}
+	}
+	return format
+}
+
+// SectionTemplate is a reusable, parameterized section scaffold.
+// Title and Content may contain {{variable}} placeholders.
+type SectionTemplate struct {
+	Name        string    `yaml:"name" json:"name"`
+	Description string    `yaml:"description,omitempty" json:"description,omitempty"`
+	Title       string    `yaml:"title" json:"title"`
+	Content     string    `yaml:"content" json:"content"`
+	Level       int       `yaml:"level" json:"level"`
+	Variables   []string  `yaml:"variables" json:"variables"`
+	CreatedAt   time.Time `yaml:"created_at" json:"created_at"`
+	UpdatedAt   time.Time `yaml:"updated_at" json:"updated_at"`
+}
+
+// OutlineChapter is a chapter of an outline that build_outline creates in one go
+type OutlineChapter struct {
+	Title    string           `yaml:"title" json:"title"`
+	Sections []OutlineSection `yaml:"sections,omitempty" json:"sections,omitempty"`
+}
+
+// OutlineSection is a section of an outline. Its own sections are nested one
+// level deeper. Sections without content get placeholder content.
+type OutlineSection struct {
+	Title    string           `yaml:"title" json:"title"`
+	Content  string           `yaml:"content,omitempty" json:"content,omitempty"`
+	Sections []OutlineSection `yaml:"sections,omitempty" json:"sections,omitempty"`
+}
+
+// NewSection is one of the sections add_sections adds to a chapter in order.
+// Its level places it the way add_section's does: 1 for 1.1, 2 for 1.1.1, with
+// 1 the default.
+type NewSection struct {
+	Title   string `json:"title"`
+	Content string `json:"content"`
+	Level   int    `json:"level,omitempty"`
+}
+
+// PlaceholderSectionContent returns the content given to an outline section
+// that has none yet
+func PlaceholderSectionContent(title string) string {
+	return fmt.Sprintf("TODO: write %s", title)
+}
+
+// TodoKind identifies the kind of outstanding drafting item
+type TodoKind string
+
+const (
+	TodoKindFigureCaption TodoKind = "figure_caption"
+)
+
+// TodoItem represents an outstanding drafting item, such as a placeholder caption
+type TodoItem struct {
+	Kind        TodoKind      `yaml:"kind" json:"kind"`
+	Chapter     ChapterNumber `yaml:"chapter" json:"chapter"`
+	ItemID      string        `yaml:"item_id" json:"item_id"`
+	Description string        `yaml:"description" json:"description"`
+}
+
+// PlaceholderCaption returns the placeholder caption used for an image added without one
+func PlaceholderCaption(imagePath string) string {
+	return fmt.Sprintf("TODO: caption for %s", filepath.Base(imagePath))
+}
+
+// Todos collects the outstanding drafting items in the document
+func (m *Manifest) Todos() []TodoItem {
+	todos := []TodoItem{}
+	for _, chapter := range m.Document.Chapters {
+		for _, figure := range chapter.Figures {
+			if !figure.CaptionTODO {
+				continue
+			}
+			todos = append(todos, TodoItem{
+				Kind:        TodoKindFigureCaption,
+				Chapter:     chapter.Number,
+				ItemID:      string(figure.ID),
+				Description: fmt.Sprintf("Figure %s needs a caption (image: %s)", figure.ID, figure.ImagePath),
+			})
+		}
+	}
+	return todos
+}
+
+// DocumentSummary is the listing entry for a document
+type DocumentSummary struct {
+	DocumentID   DocumentID      `json:"document_id"`
+	Title        string          `json:"title"`
+	Author       string          `json:"author"`
+	Authors      []string        `json:"authors"`
+	Type         DocumentType    `json:"type"`
+	CreatedAt    time.Time       `json:"created_at"`
+	UpdatedAt    time.Time       `json:"updated_at"`
+	ChapterCount int             `json:"chapter_count"`
+	WordCount    int             `json:"word_count"`
+	Tags         []string        `json:"tags,omitempty"`
+	Health       *DocumentHealth `json:"health,omitempty"`
+	ExpiresAt    *time.Time      `json:"expires_at,omitempty"` // sandbox documents only
+	Locked       bool            `json:"locked,omitempty"`
+}
+
+// ExportRecord notarizes a single export. Records are appended to the export
+// ledger, each chained to the previous one through PreviousHash so that any later
+// change to the ledger is detectable.
+type ExportRecord struct {
+	Sequence        int          `json:"sequence"`
+	DocumentID      DocumentID   `json:"document_id"`
+	Format          ExportFormat `json:"format"`
+	OutputPath      string       `json:"output_path"`
+	ContentHash     string       `json:"content_hash"` // SHA-256 of the exported file
+	StyleHash       string       `json:"style_hash"`   // SHA-256 of the style the export used
+	ExporterVersion string       `json:"exporter_version"`
+	PandocVersion   string       `json:"pandoc_version"`
+	Timestamp       time.Time    `json:"timestamp"`
+	PreviousHash    string       `json:"previous_hash"`
+	RecordHash      string       `json:"record_hash"` // SHA-256 over the fields above
+
+	// External anchoring of RecordHash by an RFC 3161 timestamp authority
+	TimestampAuthority string `json:"timestamp_authority,omitempty"`
+	TimestampToken     []byte `json:"timestamp_token,omitempty"`
+	TimestampError     string `json:"timestamp_error,omitempty"`
+}
+
+// ValidationReport represents document validation results
+type ValidationReport struct {
+	Valid    bool     `yaml:"valid" json:"valid"`
+	Errors   []string `yaml:"errors" json:"errors"`
+	Warnings []string `yaml:"warnings" json:"warnings"`
+}
+
+// DocumentHealth scores how ready a document is for export, from 100 for a
+// document with nothing to clean up down to 0. Suggestions say what to fix first.
+type DocumentHealth struct {
+	Score            int             `json:"score"`
+	ValidationErrors int             `json:"validation_errors"`
+	Todos            int             `json:"todos"`
+	EmptySections    []string        `json:"empty_sections,omitempty"` // section numbers such as "2.1"
+	EmptyChapters    []ChapterNumber `json:"empty_chapters,omitempty"`
+	StaleChapters    []ChapterNumber `json:"stale_chapters,omitempty"`
+	Suggestions      []string        `json:"suggestions,omitempty"`
+}
+
+// RenameOptions says what RenameDocument changes. Empty fields are left alone.
+type RenameOptions struct {
+	Title  string
+	Author string // Replaces the name of the first author, keeping their details
+	// NewID moves the document to this ID
+	NewID DocumentID
+	// DeriveID moves the document to an ID made from its (new) title, as
+	// create_document makes one
+	DeriveID bool
+}
+
+// StructureOptions selects what a document structure response includes
+type StructureOptions struct {
+	// Chapters limits the response to these chapters; all when empty
+	Chapters []ChapterNumber
+	// Depth counts heading levels as toc_depth does: 1 lists chapters without
+	// sections, 2 adds top-level sections and so on. Zero includes every level.
+	Depth int
+	// Sections, Figures, Tables and Listings include each chapter's list of them
+	Sections bool
+	Figures  bool
+	Tables   bool
+	Listings bool
+	// Compact leaves out timestamps, chapter counts, directories and empty fields
+	Compact bool
+	// FrontMatter keeps only chapters and sections whose front matter matches
+	FrontMatter map[string]string
+	// Status keeps only chapters and sections with one of these workflow statuses
+	Status []WorkflowStatus
+}
+
+// ExportOptions represents export configuration
+type ExportOptions struct {
+	Format   ExportFormat  `yaml:"format" json:"format"`
+	Chapters []ChapterNumber `yaml:"chapters,omitempty" json:"chapters,omitempty"`
+	Template string        `yaml:"template,omitempty" json:"template,omitempty"`
+
+	// Review markings apply to a single export and leave the saved style alone
+	Watermark   string `yaml:"watermark,omitempty" json:"watermark,omitempty"`       // e.g. "DRAFT", shown diagonally on every page
+	Banner      string `yaml:"banner,omitempty" json:"banner,omitempty"`             // e.g. "CONFIDENTIAL", shown at the foot of every page
+	LineNumbers bool   `yaml:"line_numbers,omitempty" json:"line_numbers,omitempty"` // number lines for review comments
+
+	// EmbedSource attaches the combined markdown and assets to a PDF export as a zip
+	EmbedSource bool `yaml:"embed_source,omitempty" json:"embed_source,omitempty"`
+
+	// Accessible tags a PDF export for screen readers, and gives an HTML export
+	// a main landmark and a skip link to it
+	Accessible bool `yaml:"accessible,omitempty" json:"accessible,omitempty"`
+
+	// Abbreviations adds a sorted table of abbreviations before the first chapter
+	Abbreviations bool `yaml:"abbreviations,omitempty" json:"abbreviations,omitempty"`
+	// ListOfListings adds a list of the exported chapters' code listings
+	// before the first chapter
+	ListOfListings bool `yaml:"list_of_listings,omitempty" json:"list_of_listings,omitempty"`
+
+	// FloatPlacement tunes how figures and tables float in PDF exports
+	FloatPlacement FloatPlacement `yaml:"float_placement,omitempty" json:"float_placement,omitempty"`
+
+	// Compression writes a compressed copy of the export next to it for delivery
+	Compression ExportCompression `yaml:"compression,omitempty" json:"compression,omitempty"`
+	// OptimizePDF linearizes and recompresses a PDF export with qpdf, so it
+	// is smaller and its first page shows while the rest downloads
+	OptimizePDF bool `yaml:"optimize_pdf,omitempty" json:"optimize_pdf,omitempty"`
+	// PDFA converts a PDF export to PDF/A-2b for archiving, with ghostscript
+	PDFA bool `yaml:"pdfa,omitempty" json:"pdfa,omitempty"`
+	// Protection password-protects a PDF or DOCX export. It is never saved.
+	Protection *ExportProtection `yaml:"-" json:"-"`
+
+	// ChapterBibliographies marks where each chapter ends, for the filter that
+	// gives every chapter its own reference list. Set from the pandoc config.
+	ChapterBibliographies bool `yaml:"-" json:"-"`
+	// Numbering sets the figure and table counters at the start of each
+	// chapter, for PDF and HTML. Set from the document style.
+	Numbering *NumberingStyle `yaml:"-" json:"-"`
+	// Typography rewrites dashes, ellipses and spaces in the chapters' text.
+	// Set from the document style.
+	Typography *Typography `yaml:"-" json:"-"`
+	// PageMarkers writes each chapter's number on its first page, so that
+	// precise page counts can find where chapters start in a draft PDF
+	PageMarkers bool `yaml:"-" json:"-"`
+	// FrontMatterBadges shows these front matter keys as badges under chapter
+	// and section headings in HTML and EPUB exports; other formats ignore them
+	FrontMatterBadges []string `yaml:"front_matter_badges,omitempty" json:"front_matter_badges,omitempty"`
+
+	// ApprovedOnly leaves out chapters and sections that aren't approved or final
+	ApprovedOnly bool `yaml:"approved_only,omitempty" json:"approved_only,omitempty"`
+	// StatusStamps writes each chapter's workflow status under its heading
+	StatusStamps bool `yaml:"status_stamps,omitempty" json:"status_stamps,omitempty"`
+
+	// Hooks run at points in the export, the global ones before the
+	// document's. Set from hooks.yaml when hooks are enabled.
+	Hooks []Hook `yaml:"-" json:"-"`
+	// DocumentJSON is what a json export writes. Set from the section files.
+	DocumentJSON *DocumentJSON `yaml:"-" json:"-"`
+	// Volume places the document's chapters in a volume compiled from several
+	// documents. Set by compile_volume.
+	Volume *VolumePlacement `yaml:"-" json:"-"`
+
+	// Fragment exports HTML as body-only markup for embedding in a wiki or CMS
+	Fragment *HTMLFragment `yaml:"fragment,omitempty" json:"fragment,omitempty"`
+
+	// Slides sets how slide decks are split and built up
+	Slides *SlideOptions `yaml:"slides,omitempty" json:"slides,omitempty"`
+}
+
+// SlideSplit is the heading level that starts a new slide
+type SlideSplit string
+
+const (
+	SlideSplitChapter SlideSplit = "chapter" // a slide per chapter, its sections on it
+	SlideSplitSection SlideSplit = "section" // a title slide per chapter, a slide per section
+)
+
+// Validate checks that the split is one of the known rules
+func (s SlideSplit) Validate() error {
+	switch s {
+	case SlideSplitChapter, SlideSplitSection:
+		return nil
+	}
+	return fmt.Errorf("invalid slide_split %q: must be %q or %q", s, SlideSplitChapter, SlideSplitSection)
+}
+
+// SlideOptions are the options of a slide deck export
+type SlideOptions struct {
+	Split SlideSplit `yaml:"split,omitempty" json:"split,omitempty"`
+	// Incremental reveals list items one at a time
+	Incremental bool `yaml:"incremental,omitempty" json:"incremental,omitempty"`
+}
+
+// Level is the heading level of the slides, for pandoc's --slide-level
+func (o *SlideOptions) Level() int {
+	if o != nil && o.Split == SlideSplitChapter {
+		return 1
+	}
+	return 2
+}
+
+// MaxHeadingOffset is the most heading levels a fragment can be shifted by
+const MaxHeadingOffset = 5
+
+// HTMLFragment is how HTML is exported for embedding: the body alone, without
+// <html> and <head>, styled by the page it is pushed into
+type HTMLFragment struct {
+	// HeadingOffset shifts every heading down, so that with 1 chapters are
+	// <h2> under the embedding page's own <h1>
+	HeadingOffset int `yaml:"heading_offset,omitempty" json:"heading_offset,omitempty"`
+	// ImageBaseURL is where the document's images are served from, so that
+	// assets/images/plot.png becomes <base>/plot.png
+	ImageBaseURL string `yaml:"image_base_url,omitempty" json:"image_base_url,omitempty"`
+	// Confluence writes Confluence's storage format instead of HTML, with
+	// images without a base URL attached to the page by file name
+	Confluence bool `yaml:"confluence,omitempty" json:"confluence,omitempty"`
+}
+
+// Validate checks the heading offset and base URL
+func (f *HTMLFragment) Validate() error {
+	if f.HeadingOffset < 0 || f.HeadingOffset > MaxHeadingOffset {
+		return fmt.Errorf("heading_offset must be between 0 and %d", MaxHeadingOffset)
+	}
+	if strings.ContainsAny(f.ImageBaseURL, "\"'<> \t\n") {
+		return fmt.Errorf("invalid image_base_url %q: it may not contain quotes, angle brackets or whitespace", f.ImageBaseURL)
+	}
+	return nil
+}
+
+// Extension is the export's file extension, which keeps fragments apart from
+// full HTML exports of the document
+func (f *HTMLFragment) Extension() string {
+	if f.Confluence {
+		return "confluence.xml"
+	}
+	return "fragment.html"
+}
+
+// Volume is several documents compiled into one export, such as the parts of
+// a book kept as separate documents or an anthology of reports
+type Volume struct {
+	Title     string
+	Documents []DocumentID
+	Numbering VolumeNumbering
+}
+
+// Name is the volume's export file name, without its extension
+func (v Volume) Name() string {
+	slug := strings.Trim(volumeSlugPattern.ReplaceAllString(strings.ToLower(v.Title), "-"), "-")
+	if slug == "" {
+		slug = "untitled"
+	}
+	return "volume-" + slug
+}
+
+var volumeSlugPattern = regexp.MustCompile(`[^a-z0-9]+`)
+
+// VolumeNumbering says how the chapters of a compiled volume are numbered
+type VolumeNumbering string
+
+const (
+	VolumeNumberingContinuous  VolumeNumbering = "continuous"   // 1, 2, 3 through the volume
+	VolumeNumberingPerDocument VolumeNumbering = "per-document" // each document from 1
+)
+
+// Validate validates a volume numbering
+func (n VolumeNumbering) Validate() error {
+	switch n {
+	case VolumeNumberingContinuous, VolumeNumberingPerDocument:
+		return nil
+	}
+	return fmt.Errorf("invalid numbering %q: must be %q or %q", n, VolumeNumberingContinuous, VolumeNumberingPerDocument)
+}
+
+// VolumePlacement is where one document's chapters go in a compiled volume
+type VolumePlacement struct {
+	// ChapterOffset is added to the document's chapter numbers
+	ChapterOffset int
+	// FigureOffset and TableOffset count the figures and tables of the
+	// documents before, for counters that run through the volume
+	FigureOffset int
+	TableOffset  int
+	// ChapterHeading is the volume's chapter heading style, which chapter
+	// headings are rewritten in
+	ChapterHeading ChapterHeadingStyle
+}
+
+// DocumentJSONSchemaVersion is the version of the DocumentJSON schema. It
+// changes only when a field is removed or changes meaning; new fields may be
+// added without it changing.
+const DocumentJSONSchemaVersion = 1
+
+// DocumentJSON is a whole document, section contents included, in the stable
+// form get_document_json and json exports give to external tools
+type DocumentJSON struct {
+	SchemaVersion int            `json:"schema_version"`
+	ID            DocumentID     `json:"id"`
+	Title         string         `json:"title"`
+	Subtitle      string         `json:"subtitle,omitempty"`
+	Authors       AuthorList     `json:"authors"`
+	Type          DocumentType   `json:"type"`
+	Language      string         `json:"language,omitempty"`
+	Locale        string         `json:"locale,omitempty"`
+	Date          string         `json:"date,omitempty"`
+	Abstract      string         `json:"abstract,omitempty"`
+	Keywords      []string       `json:"keywords,omitempty"`
+	Tags          []string       `json:"tags,omitempty"`
+	Parts         []Part         `json:"parts,omitempty"`
+	Abbreviations []Abbreviation `json:"abbreviations,omitempty"`
+	CreatedAt     time.Time      `json:"created_at"`
+	UpdatedAt     time.Time      `json:"updated_at"`
+	Chapters      []ChapterJSON  `json:"chapters"`
+}
+
+// ChapterJSON is a chapter of a DocumentJSON
+type ChapterJSON struct {
+	Number ChapterNumber `json:"number"`
+	Title  string        `json:"title"`
+	// Intro is the content of the chapter's included file, placed before its sections
+	Intro       string         `json:"intro,omitempty"`
+	Epigraph    *Epigraph      `json:"epigraph,omitempty"`
+	Status      WorkflowStatus `json:"status"`
+	FrontMatter FrontMatter    `json:"front_matter,omitempty"`
+	Sections    []SectionJSON  `json:"sections"`
+	Figures     []Figure       `json:"figures"`
+	Tables      []Table        `json:"tables"`
+	Listings    []Listing      `json:"listings"`
+	CreatedAt   time.Time      `json:"created_at"`
+	UpdatedAt   time.Time      `json:"updated_at"`
+}
+
+// SectionJSON is a section of a DocumentJSON, with its markdown content as
+// written, or the content of the file included in its place
+type SectionJSON struct {
+	Number      string         `json:"number"`
+	Title       string         `json:"title"`
+	Level       int            `json:"level"`
+	Content     string         `json:"content"`
+	Status      WorkflowStatus `json:"status"` // effective, as Chapter.SectionStatuses gives it
+	FrontMatter FrontMatter    `json:"front_matter,omitempty"`
+	UpdatedAt   time.Time      `json:"updated_at"`
+}
+
+// ExportProtection password-protects an export. PDF exports are encrypted with
+// qpdf and can restrict printing and copying; DOCX exports are encrypted with
+// msoffcrypto-tool and only take a password to open them.
+type ExportProtection struct {
+	UserPassword  string // needed to open the export
+	OwnerPassword string // lifts the PDF restrictions; a random one when empty
+	AllowPrint    bool
+	AllowCopy     bool
+}
+
+// Validate checks that an export protection can be applied to the format
+func (p *ExportProtection) Validate(format ExportFormat) error {
+	// The encryption tools read passwords one per line
+	if strings.ContainsAny(p.UserPassword+p.OwnerPassword, "\r\n") {
+		return fmt.Errorf("passwords can't contain line breaks")
+	}
+	switch format {
+	case ExportFormatPDF:
+		if p.UserPassword == "" && p.OwnerPassword == "" && p.AllowPrint && p.AllowCopy {
+			return fmt.Errorf("PDF protection needs a password or a restriction on printing or copying")
+		}
+	case ExportFormatDOCX:
+		if p.UserPassword == "" {
+			return fmt.Errorf("DOCX exports can only be protected with user_password")
+		}
+		if p.OwnerPassword != "" || !p.AllowPrint || !p.AllowCopy {
+			return fmt.Errorf("owner_password, allow_print and allow_copy are only supported for PDF exports")
+		}
+	default:
+		return fmt.Errorf("password protection is only supported for PDF and DOCX exports")
+	}
+	return nil
+}
+
+// Abbreviation is an acronym or abbreviation and what it stands for
+type Abbreviation struct {
+	Term       string `yaml:"term" json:"term"`
+	Definition string `yaml:"definition" json:"definition"`
+}
+
+// AbbreviationReport lists a document's abbreviations, as its abbreviations
+// table would, and those used in the text without a definition
+type AbbreviationReport struct {
+	Abbreviations []Abbreviation `json:"abbreviations"`
+	Undefined     []string       `json:"undefined"`
+}
+
+// HookEvent is a point in an export where hooks run
+type HookEvent string
+
+const (
+	// HookPreMarkdown runs after the chapters are rebuilt, before the combined
+	// markdown is generated
+	HookPreMarkdown HookEvent = "pre_markdown"
+	// HookPostMarkdown runs on the combined markdown before pandoc reads it,
+	// and may rewrite the file
+	HookPostMarkdown HookEvent = "post_markdown"
+	// HookPostExport runs once the export is written
+	HookPostExport HookEvent = "post_export"
+)
+
+// Hook runs a script or calls a webhook at a point in an export. Scripts get
+// the export's details in DOCGEN_* environment variables and as JSON on
+// standard input; webhooks get the JSON in a POST. A script that exits
+// nonzero or a webhook that doesn't answer 2xx aborts the export.
+type Hook struct {
+	Name    string         `yaml:"name,omitempty" json:"name,omitempty"`
+	Event   HookEvent      `yaml:"event" json:"event"`
+	Command []string       `yaml:"command,omitempty" json:"command,omitempty"` // program and arguments, run without a shell
+	URL     string         `yaml:"url,omitempty" json:"url,omitempty"`
+	Formats []ExportFormat `yaml:"formats,omitempty" json:"formats,omitempty"` // every format when empty
+}
+
+// Validate checks that a hook runs at a known event and does one thing
+func (h Hook) Validate() error {
+	switch h.Event {
+	case HookPreMarkdown, HookPostMarkdown, HookPostExport:
+	default:
+		return fmt.Errorf("invalid hook event %q: must be one of pre_markdown, post_markdown, post_export", h.Event)
+	}
+	if (len(h.Command) == 0) == (h.URL == "") {
+		return fmt.Errorf("hook %s must have either a command or a url", h.Label())
+	}
+	return nil
+}
+
+// Label names a hook in errors: its name, or its command or URL
+func (h Hook) Label() string {
+	switch {
+	case h.Name != "":
+		return h.Name
+	case len(h.Command) > 0:
+		return h.Command[0]
+	default:
+		return h.URL
+	}
+}
+
+// RunsFor reports whether a hook runs for exports in a format
+func (h Hook) RunsFor(format ExportFormat) bool {
+	if len(h.Formats) == 0 {
+		return true
+	}
+	for _, f := range h.Formats {
+		if f == format {
+			return true
+		}
+	}
+	return false
+}
+
+// HookConfig is a hooks.yaml file
+type HookConfig struct {
+	Hooks []Hook `yaml:"hooks" json:"hooks"`
+	// DocumentHooks lists the documents whose own hooks.yaml is run. Only
+	// the root hooks file sets it, so the operator opts each document in.
+	DocumentHooks []DocumentID `yaml:"document_hooks,omitempty" json:"document_hooks,omitempty"`
+}
+
+// AllowsDocumentHooks reports whether a document's own hooks are run
+func (c HookConfig) AllowsDocumentHooks(docID DocumentID) bool {
+	for _, id := range c.DocumentHooks {
+		if id == docID {
+			return true
+		}
+	}
+	return false
+}
+
+// HookContext describes the export a hook runs in
+type HookContext struct {
+	Event        HookEvent    `json:"event"`
+	DocumentID   DocumentID   `json:"document_id"`
+	Format       ExportFormat `json:"format"`
+	DocumentPath string       `json:"document_path"`
+	MarkdownPath string       `json:"markdown_path,omitempty"` // post_markdown only
+	OutputPath   string       `json:"output_path,omitempty"`   // post_export only
+}
+
+// ExportResult describes a finished export
+type ExportResult struct {
+	OutputPath string `json:"output_path"`
+	PDFEngine  string `json:"pdf_engine,omitempty"`
+
+	// Set when the first PDF engine failed and the export was retried with PDFEngine
+	FallbackFrom   string `json:"fallback_from,omitempty"`
+	FallbackReason string `json:"fallback_reason,omitempty"`
+
+	// Set when the export was optimized or given a compressed copy
+	Optimized       bool   `json:"optimized,omitempty"`
+	// Set when the export was encrypted with its protection passwords
+	Protected bool `json:"protected,omitempty"`
+	// Set when the export was converted to PDF/A-2b, and when veraPDF found
+	// that it complies
+	PDFA          bool `json:"pdfa,omitempty"`
+	PDFAValidated bool `json:"pdfa_validated,omitempty"`
+	CompressedPath  string `json:"compressed_path,omitempty"`
+	CompressedBytes int64  `json:"compressed_bytes,omitempty"`
+	// Warnings report optional steps that were skipped, such as optimizing
+	// a PDF without qpdf installed
+	Warnings []string `json:"warnings,omitempty"`
+}
+
+// ExportEntry is one export in a document's export history, failed or not
+type ExportEntry struct {
+	ID       int             `yaml:"id" json:"id"`
+	Time     time.Time       `yaml:"time" json:"time"`
+	Format   ExportFormat    `yaml:"format" json:"format"`
+	Style    string          `yaml:"style,omitempty" json:"style,omitempty"`
+	Chapters []ChapterNumber `yaml:"chapters,omitempty" json:"chapters,omitempty"` // every chapter when empty
+
+	OutputPath     string `yaml:"output_path,omitempty" json:"output_path,omitempty"`
+	CompressedPath string `yaml:"compressed_path,omitempty" json:"compressed_path,omitempty"`
+	Bytes          int64  `yaml:"bytes,omitempty" json:"bytes,omitempty"`
+	DurationMS     int64  `yaml:"duration_ms" json:"duration_ms"`
+	Success        bool   `yaml:"success" json:"success"`
+	Error          string `yaml:"error,omitempty" json:"error,omitempty"`
+
+	// Available reports that the output file still holds this export: it
+	// exists and no later export of the document has overwritten it
+	Available bool `yaml:"-" json:"available"`
+}
+
+// ExportHistory is the registry of a document's exports, oldest first. It is
+// stored in the document's exports.yaml.
+type ExportHistory struct {
+	LastID  int            `yaml:"last_id" json:"last_id"`
+	Exports []ExportEntry `yaml:"exports" json:"exports"`
+}
+
+// MaxChapterRevisions is the number of revisions kept for each chapter; taking
+// another drops the oldest
+const MaxChapterRevisions = 20
+
+// ChapterRevision is a snapshot of a chapter's compiled markdown, taken to
+// compare later edits against
+type ChapterRevision struct {
+	ID        int       `yaml:"id" json:"id"`
+	Label     string    `yaml:"label,omitempty" json:"label,omitempty"`
+	CreatedAt time.Time `yaml:"created_at" json:"created_at"`
+	Words     int       `yaml:"words" json:"words"`
+	Content   string    `yaml:"content" json:"-"`
+}
+
+// ChapterRevisions is the registry of a chapter's revisions, oldest first. It
+// is stored in the chapter's revisions.yaml.
+type ChapterRevisions struct {
+	LastID    int               `yaml:"last_id" json:"last_id"`
+	Revisions []ChapterRevision `yaml:"revisions" json:"revisions"`
+}
+
+// Find returns the revision with the given ID, or the latest one for ID 0
+func (r *ChapterRevisions) Find(id int) (*ChapterRevision, bool) {
+	if id == 0 && len(r.Revisions) > 0 {
+		return &r.Revisions[len(r.Revisions)-1], true
+	}
+	for i := range r.Revisions {
+		if r.Revisions[i].ID == id {
+			return &r.Revisions[i], true
+		}
+	}
+	return nil, false
+}
+
+// DiffMode is how a chapter diff lays out the two renderings
+type DiffMode string
+
+const (
+	DiffModeInline     DiffMode = "inline"       // one column, removed and added blocks marked
+	DiffModeSideBySide DiffMode = "side-by-side" // the revision on the left, the current content on the right
+)
+
+// Validate validates a diff mode
+func (m DiffMode) Validate() error {
+	switch m {
+	case DiffModeInline, DiffModeSideBySide:
+		return nil
+	}
+	return fmt.Errorf("invalid mode %q: must be %q or %q", m, DiffModeInline, DiffModeSideBySide)
+}
+
+// ChapterDiff is the result of comparing a chapter's rendering with one of
+// its revisions
+type ChapterDiff struct {
+	OutputPath string   `json:"output_path"`
+	Mode       DiffMode `json:"mode"`
+	Revision   int      `json:"revision"`
+	// Added, Removed and Unchanged count the rendered blocks, such as
+	// paragraphs, headings, lists and tables
+	Added     int `json:"added"`
+	Removed   int `json:"removed"`
+	Unchanged int `json:"unchanged"`
+	// Approximate reports that the chapter was rendered without pandoc
+	Approximate bool `json:"approximate"`
+}
+
+// FeedbackKind is the kind of reviewer feedback found in an edited DOCX
+type FeedbackKind string
+
+const (
+	FeedbackInsertion FeedbackKind = "insertion"
+	FeedbackDeletion  FeedbackKind = "deletion"
+	FeedbackComment   FeedbackKind = "comment"
+)
+
+// FeedbackItem is one tracked change or comment from an edited DOCX, placed
+// in the chapter and section whose heading it follows
+type FeedbackItem struct {
+	Kind   FeedbackKind `json:"kind"`
+	Author string       `json:"author,omitempty"`
+	Date   string       `json:"date,omitempty"`
+	// Chapter is 0 for feedback before the first chapter heading, or under a
+	// heading that matches no chapter
+	Chapter ChapterNumber `json:"chapter,omitempty"`
+	// Section is empty for feedback in a chapter's opening, before its first section
+	Section string `json:"section,omitempty"`
+	// Text is the inserted or deleted text, or the text a comment is on
+	Text    string `json:"text,omitempty"`
+	Comment string `json:"comment,omitempty"`
+	// Context is the paragraph the feedback is in, with changes accepted
+	Context string `json:"context,omitempty"`
+}
+
+// SectionProposal is a section's content as it reads in an edited DOCX with
+// its tracked changes accepted
+type SectionProposal struct {
+	Chapter ChapterNumber `json:"chapter"`
+	Section string        `json:"section"`
+	Title   string        `json:"title"`
+	Content string        `json:"content"`
+	// Changes counts the tracked insertions and deletions in the section
+	Changes int `json:"changes"`
+}
+
+// DocxFeedback is the reviewer feedback read back from an edited DOCX export
+type DocxFeedback struct {
+	Path      string            `json:"path"`
+	Items     []FeedbackItem    `json:"items"`
+	Proposals []SectionProposal `json:"proposals,omitempty"`
+	// Unmapped counts the items placed in no chapter
+	Unmapped int      `json:"unmapped"`
+	Warnings []string `json:"warnings,omitempty"`
+}
+
+// ExportPreflight summarizes an export before it runs, with rough estimates of
+// its length and duration taken from the sizes of the compiled chapters and images
+type ExportPreflight struct {
+	Format           ExportFormat `json:"format"`
+	Chapters         int          `json:"chapters"`
+	Words            int          `json:"estimated_words"`
+	Figures          int          `json:"figures"`
+	Tables           int          `json:"tables"`
+	ImageBytes       int64        `json:"image_bytes"`
+	Pages            int          `json:"estimated_pages"`
+	EstimatedSeconds int          `json:"estimated_seconds"`
+	Warnings         []string     `json:"warnings"`
+	Errors           []string     `json:"errors"`
+}
+
+// PageEstimateMode says how estimate_pages counts pages
+type PageEstimateMode string
+
+const (
+	// PageEstimateApproximate predicts pages from the style's page size,
+	// margins, font size and line spacing without running LaTeX
+	PageEstimateApproximate PageEstimateMode = "estimate"
+	// PageEstimatePrecise counts the pages of a draft PDF
+	PageEstimatePrecise PageEstimateMode = "precise"
+)
+
+// Validate checks that the mode is known
+func (m PageEstimateMode) Validate() error {
+	switch m {
+	case PageEstimateApproximate, PageEstimatePrecise:
+		return nil
+	}
+	return fmt.Errorf("invalid mode %q (must be estimate or precise)", m)
 }
 
-// PandocConfig represents pandoc-specific configuration
-type PandocConfig struct {
-	PDFEngine     string            `yaml:"pdf_engine" json:"pdf_engine"`
-	TOC           bool              `yaml:"toc" json:"toc"`
-	TOCDepth      int               `yaml:"toc_depth" json:"toc_depth"`
-	CitationStyle string            `yaml:"citation_style" json:"citation_style"`
-	Args          []string          `yaml:"args" json:"args"`
-	Variables     map[string]string `yaml:"variables" json:"variables"`
+// PageEstimate is how many pages a document's PDF takes, in all and per chapter
+type PageEstimate struct {
+	Mode         PageEstimateMode `json:"mode"`
+	Pages        int              `json:"pages"`
+	PageSize     string           `json:"page_size,omitempty"`
+	WordsPerPage int              `json:"words_per_page,omitempty"` // estimates only
+	Chapters     []ChapterPages   `json:"chapters"`
+	Warnings     []string         `json:"warnings,omitempty"`
 }
 
-// ValidationReport represents document validation results
-type ValidationReport struct {
-	Valid    bool     `yaml:"valid" json:"valid"`
-	Errors   []string `yaml:"errors" json:"errors"`
-	Warnings []string `yaml:"warnings" json:"warnings"`
+// ChapterPages is a chapter's share of a page estimate
+type ChapterPages struct {
+	Number    ChapterNumber `json:"number"`
+	Title     string        `json:"title"`
+	Pages     int           `json:"pages"`
+	StartPage int           `json:"start_page,omitempty"` // precise counts only
 }
 
-// ExportOptions represents export configuration
-type ExportOptions struct {
-	Format   ExportFormat  `yaml:"format" json:"format"`
-	Chapters []ChapterNumber `yaml:"chapters,omitempty" json:"chapters,omitempty"`
-	Template string        `yaml:"template,omitempty" json:"template,omitempty"`
+// ExportLog records one pandoc run so that failed exports, and warnings on
+// successful ones, can be diagnosed afterwards
+type ExportLog struct {
+	DocumentID DocumentID   `json:"document_id"`
+	Format     ExportFormat `json:"format"`
+	StartedAt  time.Time    `json:"started_at"`
+	DurationMS int64        `json:"duration_ms"`
+	Command    []string     `json:"command"`
+	ExitCode   int          `json:"exit_code"`
+	Error      string       `json:"error,omitempty"`
+	OutputPath string       `json:"output_path,omitempty"`
+	Files      []string     `json:"files"` // intermediate files kept with the log
+	Dir        string       `json:"dir"`
+
+	// pandoc's output is kept in stdout.txt and stderr.txt next to the log
+	Stdout string `json:"-"`
+	Stderr string `json:"-"`
 }
 
 // Validate validates a DocumentID
@@ -251,14 +2644,21 @@ func (s SectionNumber) String() string {
 
 // Validate validates a FigureID
 func (id FigureID) Validate() error {
-	// Expected format: fig-{chapter}.{sequence}
-	matched, _ := regexp.MatchString(`^fig-\d+\.\d+$`, string(id))
+	// Expected format: fig-{8 hex digits}, or fig-{chapter}.{sequence}
+	matched, _ := regexp.MatchString(`^`+FigureIDPattern+`$`, string(id))
 	if !matched {
-		return fmt.Errorf("invalid figure ID format (expected: fig-{chapter}.{sequence})")
+		return fmt.Errorf("invalid figure ID format (expected: fig-3f9a2c7d, or fig-{chapter}.{sequence} for a display number)")
 	}
 	return nil
 }
 
+// IsNumbered reports whether a figure ID is a chapter and sequence number
+// (fig-1.2) rather than a stable ID
+func (id FigureID) IsNumbered() bool {
+	matched, _ := regexp.MatchString(`^fig-\d+\.\d+$`, string(id))
+	return matched
+}
+
 // Validate validates a TableID
 func (id TableID) Validate() error {
 	// Expected format: table-{chapter}.{sequence}
@@ -269,6 +2669,431 @@ func (id TableID) Validate() error {
 	return nil
 }
 
+// Validate validates a ListingID
+func (id ListingID) Validate() error {
+	// Expected format: lst-{chapter}.{sequence}
+	matched, _ := regexp.MatchString(`^lst-\d+\.\d+$`, string(id))
+	if !matched {
+		return fmt.Errorf("invalid listing ID format (expected: lst-{chapter}.{sequence})")
+	}
+	return nil
+}
+
+// ValidateListingLanguage checks a listing's language, the class pandoc
+// highlights its code by (python, go, c++...)
+func ValidateListingLanguage(language string) error {
+	if matched, _ := regexp.MatchString(`^[A-Za-z0-9_+#-]+$`, language); !matched {
+		return fmt.Errorf("invalid listing language: %s (use a name such as python, go or c++)", language)
+	}
+	return nil
+}
+
+// ValidateTemplateName validates a section template name
+func ValidateTemplateName(name string) error {
+	if name == "" {
+		return fmt.Errorf("template name cannot be empty")
+	}
+	if len(name) > 50 {
+		return fmt.Errorf("template name too long (max 50 characters)")
+	}
+	matched, _ := regexp.MatchString(`^[a-zA-Z0-9_-]+$`, name)
+	if !matched {
+		return fmt.Errorf("template name can only contain letters, numbers, hyphens, and underscores")
+	}
+	return nil
+}
+
+// BundledFont is a font file kept in a fonts folder
+type BundledFont struct {
+	Name string `json:"name"`
+	// Shared fonts are in the root fonts folder, for every document to use
+	Shared bool `json:"shared"`
+}
+
+// ValidateFontFileName checks that a font file is named by its file name alone
+// and is an OpenType or TrueType font, which every export format can load
+func ValidateFontFileName(name string) error {
+	if name == "" {
+		return fmt.Errorf("font file name cannot be empty")
+	}
+	if name != filepath.Base(name) || strings.HasPrefix(name, ".") {
+		return fmt.Errorf("font file %q must be a file name in a fonts folder", name)
+	}
+	switch strings.ToLower(filepath.Ext(name)) {
+	case ".otf", ".ttf":
+		return nil
+	}
+	return fmt.Errorf("font file %q must be an .otf or .ttf font", name)
+}
+
+// ValidateHouseStyleName validates a house style ruleset name
+func ValidateHouseStyleName(name string) error {
+	if name == "" {
+		return fmt.Errorf("house style name cannot be empty")
+	}
+	if len(name) > 50 {
+		return fmt.Errorf("house style name too long (max 50 characters)")
+	}
+	matched, _ := regexp.MatchString(`^[a-zA-Z0-9_-]+$`, name)
+	if !matched {
+		return fmt.Errorf("house style name can only contain letters, numbers, hyphens, and underscores")
+	}
+	return nil
+}
+
+// NormalizeTag trims and lowercases a document tag and checks that it is one
+// word of letters, numbers and - _ . : /, such as "client:acme"
+func NormalizeTag(tag string) (string, error) {
+	tag = strings.ToLower(strings.TrimSpace(tag))
+	if tag == "" {
+		return "", fmt.Errorf("tag cannot be empty")
+	}
+	if len(tag) > 50 {
+		return "", fmt.Errorf("tag too long (max 50 characters): %s", tag)
+	}
+	matched, _ := regexp.MatchString(`^[a-z0-9][a-z0-9_.:/-]*$`, tag)
+	if !matched {
+		return "", fmt.Errorf("invalid tag %q (use letters, numbers and - _ . : /, starting with a letter or number)", tag)
+	}
+	return tag, nil
+}
+
+// IsSandbox reports whether a document is a sandbox document
+func (d *Document) IsSandbox() bool {
+	return d.ExpiresAt != nil
+}
+
+// Expired reports whether a sandbox document is due to be deleted
+func (d *Document) Expired(now time.Time) bool {
+	return d.ExpiresAt != nil && !now.Before(*d.ExpiresAt)
+}
+
+// HasTags reports whether the document has every one of the tags
+func (d *Document) HasTags(tags []string) bool {
+	for _, tag := range tags {
+		found := false
+		for _, own := range d.Tags {
+			if own == tag {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+	return true
+}
+
+// Validate validates DocumentMetadata
+func (dm DocumentMetadata) Validate() error {
+	if dm.Language != "" {
+		matched, _ := regexp.MatchString(`^[A-Za-z]{2,3}(-[A-Za-z0-9]{1,8})*$`, dm.Language)
+		if !matched {
+			return fmt.Errorf("invalid language tag (expected BCP-47, e.g. en-US): %s", dm.Language)
+		}
+	}
+	if dm.Locale != "" {
+		matched, _ := regexp.MatchString(`^[A-Za-z]{2,3}(-[A-Za-z0-9]{1,8})*$`, dm.Locale)
+		if !matched {
+			return fmt.Errorf("invalid locale tag (expected BCP-47, e.g. fr-FR): %s", dm.Locale)
+		}
+	}
+	for _, keyword := range dm.Keywords {
+		if strings.TrimSpace(keyword) == "" {
+			return fmt.Errorf("keywords cannot be empty")
+		}
+	}
+	return nil
+}
+
+// DocumentMetadataUpdate changes document metadata. Nil fields are left as
+// they are, and an empty string clears a field.
+type DocumentMetadataUpdate struct {
+	Subtitle *string
+	Keywords []string // replaced when not nil; an empty list clears them
+	Abstract *string
+	Language *string
+	Date     *string
+	Locale   *string
+}
+
+// Merge applies the fields given in updates to the metadata
+func (dm *DocumentMetadata) Merge(updates DocumentMetadataUpdate) {
+	if updates.Subtitle != nil {
+		dm.Subtitle = *updates.Subtitle
+	}
+	if updates.Keywords != nil {
+		dm.Keywords = updates.Keywords
+	}
+	if updates.Abstract != nil {
+		dm.Abstract = *updates.Abstract
+	}
+	if updates.Language != nil {
+		dm.Language = *updates.Language
+	}
+	if updates.Date != nil {
+		dm.Date = *updates.Date
+	}
+	if updates.Locale != nil {
+		dm.Locale = *updates.Locale
+	}
+}
+
+// Validate validates an Author
+func (a Author) Validate() error {
+	if strings.TrimSpace(a.Name) == "" {
+		return fmt.Errorf("author name is required")
+	}
+	if a.Email != "" {
+		matched, _ := regexp.MatchString(`^[^@\s]+@[^@\s]+\.[^@\s]+$`, a.Email)
+		if !matched {
+			return fmt.Errorf("invalid author email: %s", a.Email)
+		}
+	}
+	if a.ORCID != "" {
+		matched, _ := regexp.MatchString(`^\d{4}-\d{4}-\d{4}-\d{3}[\dX]$`, a.ORCID)
+		if !matched {
+			return fmt.Errorf("invalid ORCID (expected format: 0000-0000-0000-0000): %s", a.ORCID)
+		}
+	}
+	return nil
+}
+
+// Validate validates a NumberingStyle
+func (n NumberingStyle) Validate() error {
+	switch n.ChapterFormat {
+	case "", NumberFormatArabic, NumberFormatRoman, NumberFormatLetters:
+	default:
+		return fmt.Errorf("invalid chapter_format: %s (must be arabic, roman or letters)", n.ChapterFormat)
+	}
+	for i, scope := range []CounterScope{n.FigureNumbering, n.TableNumbering} {
+		switch scope {
+		case "", CounterPerChapter, CounterContinuous:
+		default:
+			return fmt.Errorf("invalid %s_numbering: %s (must be chapter or continuous)", []string{"figure", "table"}[i], scope)
+		}
+	}
+	if n.SectionDepth < 0 || n.SectionDepth > 5 {
+		return fmt.Errorf("section_depth must be between 0 and 5")
+	}
+	return nil
+}
+
+// ChapterLabel writes a chapter number in the chapter format
+func (n NumberingStyle) ChapterLabel(chapter ChapterNumber) string {
+	switch n.ChapterFormat {
+	case NumberFormatRoman:
+		return RomanNumeral(int(chapter))
+	case NumberFormatLetters:
+		return alphabeticNumeral(int(chapter))
+	default:
+		return chapter.String()
+	}
+}
+
+// SectionLabel writes a section number with its chapter in the chapter format
+// (II.3.1), or returns "" for sections deeper than the numbered depth
+func (n NumberingStyle) SectionLabel(section SectionNumber) string {
+	if len(section) < 2 || (n.SectionDepth > 0 && len(section)-1 > n.SectionDepth) {
+		return ""
+	}
+	return n.ChapterLabel(ChapterNumber(section[0])) + "." + section[1:].String()
+}
+
+// Validate validates the chapter heading style
+func (h ChapterHeadingStyle) Validate() error {
+	switch h.Case {
+	case "", HeadingCaseUpper, HeadingCaseLower, HeadingCaseTitle:
+	default:
+		return fmt.Errorf("invalid heading case %q: must be upper, lower or title", h.Case)
+	}
+	if h.Prefix != nil && strings.Count(*h.Prefix, "%d") > 1 {
+		return fmt.Errorf("chapter heading prefix %q has more than one %%d", *h.Prefix)
+	}
+	return nil
+}
+
+// Heading writes a chapter's heading text, without the leading #
+func (h ChapterHeadingStyle) Heading(chapter ChapterNumber, title string, numbering NumberingStyle) string {
+	prefix := DefaultChapterPrefix
+	if h.Prefix != nil {
+		prefix = strings.TrimSpace(*h.Prefix)
+	}
+	for _, unnumbered := range h.Unnumbered {
+		if strings.EqualFold(strings.TrimSpace(unnumbered), strings.TrimSpace(title)) {
+			prefix = ""
+		}
+	}
+
+	title = h.Case.Apply(title)
+	if prefix == "" {
+		return title
+	}
+	return strings.Replace(prefix, "%d", numbering.ChapterLabel(chapter), 1) + " " + title
+}
+
+// titleCaseMinorWords stay lower case inside title-cased headings
+var titleCaseMinorWords = map[string]bool{
+	"a": true, "an": true, "the": true, "and": true, "but": true, "or": true, "nor": true,
+	"for": true, "so": true, "yet": true, "as": true, "at": true, "by": true, "in": true,
+	"of": true, "on": true, "to": true, "up": true, "via": true, "with": true, "from": true,
+}
+
+// Apply capitalizes text in the heading case
+func (c HeadingCase) Apply(text string) string {
+	switch c {
+	case HeadingCaseUpper:
+		return strings.ToUpper(text)
+	case HeadingCaseLower:
+		return strings.ToLower(text)
+	case HeadingCaseTitle:
+		words := strings.Fields(text)
+		for i, word := range words {
+			if i > 0 && i < len(words)-1 && titleCaseMinorWords[strings.ToLower(word)] {
+				words[i] = strings.ToLower(word)
+				continue
+			}
+			first, size := utf8.DecodeRuneInString(word)
+			words[i] = string(unicode.ToUpper(first)) + word[size:]
+		}
+		return strings.Join(words, " ")
+	}
+	return text
+}
+
+// RomanNumeral formats a positive number as an upper-case Roman numeral
+func RomanNumeral(number int) string {
+	values := []int{1000, 900, 500, 400, 100, 90, 50, 40, 10, 9, 5, 4, 1}
+	symbols := []string{"M", "CM", "D", "CD", "C", "XC", "L", "XL", "X", "IX", "V", "IV", "I"}
+
+	var result strings.Builder
+	for i, value := range values {
+		for number >= value {
+			result.WriteString(symbols[i])
+			number -= value
+		}
+	}
+	return result.String()
+}
+
+// alphabeticNumeral formats a positive number as letters: A to Z, then AA, AB...
+func alphabeticNumeral(number int) string {
+	var letters []byte
+	for number > 0 {
+		number--
+		letters = append([]byte{byte('A' + number%26)}, letters...)
+		number /= 26
+	}
+	return string(letters)
+}
+
+// Validate validates a Citation
+func (c Citation) Validate() error {
+	// Pandoc citation keys start with a letter, digit or _ and may contain
+	// internal punctuation
+	matched, _ := regexp.MatchString(`^[\pL\pN_]([\pL\pN_:.#$%&+?<>~/-]*[\pL\pN_])?$`, c.Key)
+	if !matched {
+		return fmt.Errorf("invalid citation key: %q", c.Key)
+	}
+	if c.AuthorInText && (c.SuppressAuthor || c.Prefix != "") {
+		return fmt.Errorf("an author-in-text citation cannot suppress the author or take a prefix")
+	}
+	if strings.ContainsAny(c.Prefix+c.Locator, "[]@;\n") {
+		return fmt.Errorf("citation prefix and locator cannot contain brackets, @, ; or line breaks")
+	}
+	return nil
+}
+
+// Validate validates an Annotation. Coordinates are checked against the image
+// when it is drawn.
+func (a Annotation) Validate() error {
+	switch a.Kind {
+	case AnnotationArrow:
+		if a.X == a.ToX && a.Y == a.ToY {
+			return fmt.Errorf("arrow needs to_x and to_y different from its start")
+		}
+	case AnnotationBox:
+		if a.Width <= 0 || a.Height <= 0 {
+			return fmt.Errorf("box needs a positive width and height")
+		}
+	case AnnotationStep:
+		if a.Number < 0 || a.Number > 999 {
+			return fmt.Errorf("step number must be between 0 and 999")
+		}
+	default:
+		return fmt.Errorf("invalid annotation kind: %q (must be one of: arrow, box, step)", a.Kind)
+	}
+	if a.Color != "" {
+		matched, _ := regexp.MatchString(`^#([A-Fa-f0-9]{6}|[A-Fa-f0-9]{3})$`, a.Color)
+		if !matched {
+			return fmt.Errorf("invalid annotation color: %q (use #RRGGBB)", a.Color)
+		}
+	}
+	return nil
+}
+
+// Markup returns the citation in pandoc markdown
+func (c Citation) Markup() string {
+	key := "@" + c.Key
+	if c.AuthorInText {
+		if c.Locator != "" {
+			return fmt.Sprintf("%s [%s]", key, c.Locator)
+		}
+		return key
+	}
+	if c.SuppressAuthor {
+		key = "-" + key
+	}
+	if c.Prefix != "" {
+		key = c.Prefix + " " + key
+	}
+	if c.Locator != "" {
+		key += ", " + c.Locator
+	}
+	return "[" + key + "]"
+}
+
+// Names returns the author names in order
+func (l AuthorList) Names() []string {
+	names := make([]string, len(l))
+	for i, author := range l {
+		names[i] = author.Name
+	}
+	return names
+}
+
+// String returns the author names joined for display ("A", "A and B", "A, B and C")
+func (l AuthorList) String() string {
+	names := l.Names()
+	switch len(names) {
+	case 0:
+		return ""
+	case 1:
+		return names[0]
+	default:
+		return strings.Join(names[:len(names)-1], ", ") + " and " + names[len(names)-1]
+	}
+}
+
+// Index returns the position of the author with the given name, or -1
+func (l AuthorList) Index(name string) int {
+	for i, author := range l {
+		if strings.EqualFold(author.Name, name) {
+			return i
+		}
+	}
+	return -1
+}
+
+// MigrateLegacyAuthor moves a pre-multi-author "author" string into Authors
+func (d *Document) MigrateLegacyAuthor() {
+	if d.LegacyAuthor != "" && len(d.Authors) == 0 {
+		d.Authors = AuthorList{{Name: d.LegacyAuthor}}
+	}
+	d.LegacyAuthor = ""
+}
+
 // AddChapter adds a chapter to the document
 func (d *Document) AddChapter(chapter Chapter) {
 	d.Chapters = append(d.Chapters, chapter)
@@ -285,6 +3110,195 @@ func (d *Document) GetChapter(number ChapterNumber) (*Chapter, error) {
 	return nil, fmt.Errorf("chapter %d not found", number)
 }
 
+// SetPart adds a part, replacing the part that starts at the same chapter. Parts
+// may not overlap.
+func (d *Document) SetPart(part Part) error {
+	if strings.TrimSpace(part.Title) == "" {
+		return fmt.Errorf("part title is required")
+	}
+	if part.FirstChapter < 1 || part.LastChapter < part.FirstChapter {
+		return fmt.Errorf("invalid chapter range %d-%d", part.FirstChapter, part.LastChapter)
+	}
+
+	var parts []Part
+	for _, existing := range d.Parts {
+		if existing.FirstChapter == part.FirstChapter {
+			continue
+		}
+		if existing.FirstChapter <= part.LastChapter && part.FirstChapter <= existing.LastChapter {
+			return fmt.Errorf("chapters %d-%d overlap part %q (chapters %d-%d)", part.FirstChapter, part.LastChapter, existing.Title, existing.FirstChapter, existing.LastChapter)
+		}
+		parts = append(parts, existing)
+	}
+	parts = append(parts, part)
+	sort.Slice(parts, func(i, j int) bool {
+		return parts[i].FirstChapter < parts[j].FirstChapter
+	})
+	d.Parts = parts
+	return nil
+}
+
+// RemovePart removes the part that starts at a chapter and reports whether there was one
+func (d *Document) RemovePart(firstChapter ChapterNumber) bool {
+	for i, part := range d.Parts {
+		if part.FirstChapter == firstChapter {
+			d.Parts = append(d.Parts[:i], d.Parts[i+1:]...)
+			return true
+		}
+	}
+	return false
+}
+
+// SetAbbreviation defines an abbreviation, replacing any definition of the same term
+func (d *Document) SetAbbreviation(abbreviation Abbreviation) error {
+	abbreviation.Term = strings.TrimSpace(abbreviation.Term)
+	abbreviation.Definition = strings.TrimSpace(abbreviation.Definition)
+	if abbreviation.Term == "" {
+		return fmt.Errorf("abbreviation term is required")
+	}
+	if abbreviation.Definition == "" {
+		return fmt.Errorf("abbreviation definition is required")
+	}
+
+	d.RemoveAbbreviation(abbreviation.Term)
+	d.Abbreviations = append(d.Abbreviations, abbreviation)
+	sort.Slice(d.Abbreviations, func(i, j int) bool {
+		return strings.ToLower(d.Abbreviations[i].Term) < strings.ToLower(d.Abbreviations[j].Term)
+	})
+	return nil
+}
+
+// RemoveAbbreviation removes the definition of a term and reports whether there was one
+func (d *Document) RemoveAbbreviation(term string) bool {
+	for i, abbreviation := range d.Abbreviations {
+		if abbreviation.Term == strings.TrimSpace(term) {
+			d.Abbreviations = append(d.Abbreviations[:i], d.Abbreviations[i+1:]...)
+			return true
+		}
+	}
+	return false
+}
+
+// PartContaining returns the 1-based number and the part that includes a
+// chapter, or 0 and nil
+func (d *Document) PartContaining(chapter ChapterNumber) (int, *Part) {
+	for i := range d.Parts {
+		if d.Parts[i].Contains(chapter) {
+			return i + 1, &d.Parts[i]
+		}
+	}
+	return 0, nil
+}
+
+// InsertChapterInParts shifts the parts after a chapter inserted at the given
+// number. A chapter inserted inside a part joins it.
+func (d *Document) InsertChapterInParts(chapter ChapterNumber) {
+	for i := range d.Parts {
+		part := &d.Parts[i]
+		if part.FirstChapter >= chapter {
+			part.FirstChapter++
+		}
+		if part.LastChapter >= chapter {
+			part.LastChapter++
+		}
+	}
+}
+
+// RemoveChapterFromParts shifts the parts after a chapter is removed, dropping
+// parts left without chapters
+func (d *Document) RemoveChapterFromParts(chapter ChapterNumber) {
+	var parts []Part
+	for _, part := range d.Parts {
+		if part.FirstChapter > chapter {
+			part.FirstChapter--
+		}
+		if part.LastChapter >= chapter {
+			part.LastChapter--
+		}
+		if part.LastChapter >= part.FirstChapter {
+			parts = append(parts, part)
+		}
+	}
+	d.Parts = parts
+}
+
+// LegacyChapterDir names the directory of a chapter in documents without a
+// chapter order, where directories were renamed as chapters were renumbered
+func LegacyChapterDir(chapter ChapterNumber) string {
+	return fmt.Sprintf("%02d", chapter)
+}
+
+// IsLegacyChapterDir reports whether a chapter directory is named after a chapter number
+func IsLegacyChapterDir(dir string) bool {
+	return dir != "" && strings.Trim(dir, "0123456789") == ""
+}
+
+// NewChapterDir returns a new directory name for a chapter, which it keeps
+// wherever the chapter moves
+func NewChapterDir() string {
+	var id [4]byte
+	if _, err := rand.Read(id[:]); err != nil {
+		// The clock is unique enough within one document
+		return fmt.Sprintf("ch-%x", time.Now().UnixNano())
+	}
+	return "ch-" + hex.EncodeToString(id[:])
+}
+
+// ChapterDir returns the directory under chapters/ that holds a chapter, and
+// false when the chapter order has no directory for it
+func (m *Manifest) ChapterDir(chapter ChapterNumber) (string, bool) {
+	if m.ChapterOrder == nil {
+		return LegacyChapterDir(chapter), true
+	}
+	if chapter < 1 || int(chapter) > len(m.ChapterOrder) || m.ChapterOrder[chapter-1] == "" {
+		return "", false
+	}
+	return m.ChapterOrder[chapter-1], true
+}
+
+// EnsureChapterOrder records the directories of a document without a chapter
+// order: each chapter is in the directory named after its number
+func (m *Manifest) EnsureChapterOrder() {
+	if m.ChapterOrder != nil {
+		return
+	}
+	last := ChapterNumber(0)
+	for _, chapter := range m.Document.Chapters {
+		if chapter.Number > last {
+			last = chapter.Number
+		}
+	}
+	m.ChapterOrder = make([]string, 0, last)
+	for chapter := ChapterNumber(1); chapter <= last; chapter++ {
+		m.ChapterOrder = append(m.ChapterOrder, LegacyChapterDir(chapter))
+	}
+}
+
+// ParseChapterRange parses a chapter range such as "3-7" or a single chapter "4"
+func ParseChapterRange(value string) ([]ChapterNumber, error) {
+	first, last, isRange := strings.Cut(strings.TrimSpace(value), "-")
+	if !isRange {
+		last = first
+	}
+	start, err := strconv.Atoi(strings.TrimSpace(first))
+	if err != nil {
+		return nil, fmt.Errorf("invalid chapter range %q: expected a form like \"3-7\"", value)
+	}
+	end, err := strconv.Atoi(strings.TrimSpace(last))
+	if err != nil {
+		return nil, fmt.Errorf("invalid chapter range %q: expected a form like \"3-7\"", value)
+	}
+	if start < 1 || end < start {
+		return nil, fmt.Errorf("invalid chapter range %q: chapters must be at least 1 and in ascending order", value)
+	}
+
+	chapters := make([]ChapterNumber, 0, end-start+1)
+	for chapter := start; chapter <= end; chapter++ {
+		chapters = append(chapters, ChapterNumber(chapter))
+	}
+	return chapters, nil
+}
+
 // TotalSections returns the total number of sections across all chapters
 func (m *Manifest) TotalSections() int {
 	total := 0
@@ -317,11 +3331,27 @@ func GenerateFigureID(chapter ChapterNumber, sequence int) FigureID {
 	return FigureID(fmt.Sprintf("fig-%d.%d", chapter, sequence))
 }
 
+// NewFigureID returns a stable figure ID: fig- and eight random hex digits.
+// Unlike a number, it stays the same when figures are deleted or chapters move.
+func NewFigureID() FigureID {
+	var id [4]byte
+	if _, err := rand.Read(id[:]); err != nil {
+		// The clock is unique enough within one document
+		return FigureID(fmt.Sprintf("fig-%08x", uint32(time.Now().UnixNano())))
+	}
+	return FigureID("fig-" + hex.EncodeToString(id[:]))
+}
+
 // GenerateTableID generates a table ID for a chapter and sequence
 func GenerateTableID(chapter ChapterNumber, sequence int) TableID {
 	return TableID(fmt.Sprintf("table-%d.%d", chapter, sequence))
 }
 
+// GenerateListingID generates a code listing ID for a chapter and sequence
+func GenerateListingID(chapter ChapterNumber, sequence int) ListingID {
+	return ListingID(fmt.Sprintf("lst-%d.%d", chapter, sequence))
+}
+
 // NewSectionNumber creates a new section number
 func NewSectionNumber(parts ...int) SectionNumber {
 	return SectionNumber(parts)
@@ -414,12 +3444,29 @@ func ValidateStyle(style *Style) *StyleValidation {
 	validateColor(style.Heading.Color, "heading color", validation)
 	validateColor(style.Monospace.Color, "monospace color", validation)
 	validateColor(style.LinkColor, "link color", validation)
+	validateColor(style.HTML.Background, "HTML background", validation)
+	validateColor(style.HTML.DarkBackground, "HTML dark background", validation)
+	validateColor(style.HTML.DarkTextColor, "HTML dark text color", validation)
+	validateColor(style.HTML.DarkHeadingColor, "HTML dark heading color", validation)
+	validateColor(style.HTML.DarkLinkColor, "HTML dark link color", validation)
 
 	// Validate font sizes
 	validateFontSize(style.Body.FontSize, "body font size", validation)
 	validateFontSize(style.Heading.FontSize, "heading font size", validation)
 	validateFontSize(style.Monospace.FontSize, "monospace font size", validation)
 
+	// Validate font files
+	for _, text := range []struct {
+		name  string
+		style TextStyle
+	}{{"body", style.Body}, {"heading", style.Heading}, {"monospace", style.Monospace}, {"epigraph", style.Epigraph}} {
+		if text.style.FontFile != "" {
+			if err := ValidateFontFileName(text.style.FontFile); err != nil {
+				validation.AddWarning(fmt.Sprintf("Invalid %s font file: %v", text.name, err))
+			}
+		}
+	}
+
 	// Validate line spacing
 	if style.LineSpacing != "" {
 		if !isValidLineSpacing(style.LineSpacing) {
@@ -427,32 +3474,38 @@ func ValidateStyle(style *Style) *StyleValidation {
 		}
 	}
 
+	// Validate typography
+	if err := style.Typography.Validate(); err != nil {
+		validation.AddWarning(fmt.Sprintf("%v, leaving dashes as written", err))
+	}
+	if err := style.ChapterHeading.Validate(); err != nil {
+		validation.AddWarning(err.Error())
+	}
+
 	// Validate margins
 	validateMargin(style.Margins.Top, "top margin", validation)
 	validateMargin(style.Margins.Bottom, "bottom margin", validation)
 	validateMargin(style.Margins.Left, "left margin", validation)
 	validateMargin(style.Margins.Right, "right margin", validation)
 
-	// Validate template strings (using the template package functions)
-	if style.HeaderFooter.HeaderTemplate != "" {
-		if warnings := validateTemplateString(style.HeaderFooter.HeaderTemplate); len(warnings) > 0 {
-			for _, warning := range warnings {
-				validation.AddWarning(fmt.Sprintf("Header template: %s", warning))
-			}
-		}
+	// Validate the HTML text width, which may also be relative
+	if style.HTML.MaxWidth != "" && !htmlWidthPattern.MatchString(style.HTML.MaxWidth) {
+		validation.AddWarning(fmt.Sprintf("Invalid HTML max width '%s', should include unit (px, em, rem, ch, %%, in, cm, mm, pt)", style.HTML.MaxWidth))
 	}
 
-	if style.HeaderFooter.FooterTemplate != "" {
-		if warnings := validateTemplateString(style.HeaderFooter.FooterTemplate); len(warnings) > 0 {
-			for _, warning := range warnings {
-				validation.AddWarning(fmt.Sprintf("Footer template: %s", warning))
-			}
+	// Validate template strings (using the template package functions)
+	for _, template := range style.HeaderFooter.namedTemplates() {
+		for _, warning := range validateTemplateString(template[1]) {
+			validation.AddWarning(fmt.Sprintf("%s: %s", template[0], warning))
 		}
 	}
 
 	return validation
 }
 
+// htmlWidthPattern matches a CSS length usable as the HTML text width
+var htmlWidthPattern = regexp.MustCompile(`^\d+(\.\d+)?(px|em|rem|ch|%|in|cm|mm|pt)$`)
+
 // validateColor checks if a color format is valid
 func validateColor(color, fieldName string, validation *StyleValidation) {
 	if color == "" {
@@ -1,6 +1,7 @@
 package types
 
 import (
+	"strings"
 	"testing"
 )
 
@@ -127,4 +128,437 @@ func TestManifest_TotalSections(t *testing.T) {
 	if total != expected {
 		t.Errorf("Expected total sections %d, got %d", expected, total)
 	}
-}
\ No newline at end of file
+}
+
+func TestManifest_ChapterDir(t *testing.T) {
+	// Without a chapter order, directories are named after chapter numbers
+	manifest := &Manifest{Document: Document{Chapters: []Chapter{{Number: 1}, {Number: 2}}}}
+	if dir, ok := manifest.ChapterDir(2); !ok || dir != "02" {
+		t.Errorf("ChapterDir(2) = %q, %v, want 02", dir, ok)
+	}
+
+	manifest.EnsureChapterOrder()
+	if len(manifest.ChapterOrder) != 2 || manifest.ChapterOrder[0] != "01" {
+		t.Fatalf("EnsureChapterOrder() = %v", manifest.ChapterOrder)
+	}
+
+	manifest.ChapterOrder = []string{"ch-b", "", "ch-a"}
+	if dir, ok := manifest.ChapterDir(3); !ok || dir != "ch-a" {
+		t.Errorf("ChapterDir(3) = %q, %v, want ch-a", dir, ok)
+	}
+	for _, chapter := range []ChapterNumber{0, 2, 4} {
+		if dir, ok := manifest.ChapterDir(chapter); ok {
+			t.Errorf("ChapterDir(%d) = %q, want none", chapter, dir)
+		}
+	}
+
+	if dir := NewChapterDir(); !strings.HasPrefix(dir, "ch-") || IsLegacyChapterDir(dir) || dir == NewChapterDir() {
+		t.Errorf("NewChapterDir() = %q", dir)
+	}
+	if !IsLegacyChapterDir("07") {
+		t.Error("IsLegacyChapterDir(07) = false")
+	}
+}
+
+func TestDocumentMetadata_Validate(t *testing.T) {
+	tests := []struct {
+		name     string
+		metadata DocumentMetadata
+		wantErr  bool
+	}{
+		{"empty", DocumentMetadata{}, false},
+		{"language only", DocumentMetadata{Language: "de"}, false},
+		{"language with region", DocumentMetadata{Language: "en-US"}, false},
+		{"language with script", DocumentMetadata{Language: "zh-Hant-TW"}, false},
+		{"invalid language", DocumentMetadata{Language: "english"}, true},
+		{"locale", DocumentMetadata{Locale: "fr-CA"}, false},
+		{"invalid locale", DocumentMetadata{Locale: "fr_FR"}, true},
+		{"empty keyword", DocumentMetadata{Keywords: []string{"go", " "}}, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.metadata.Validate()
+			if (err != nil) != tt.wantErr {
+				t.Errorf("DocumentMetadata.Validate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestAccessPolicy_RoleFor(t *testing.T) {
+	policy := &AccessPolicy{
+		DefaultRole: RoleViewer,
+		Roles:       map[string]Role{"root": RoleAdmin},
+		Projects: []ProjectAccess{
+			{Documents: "acme-*", Roles: map[string]Role{"bob": RoleEditor}},
+		},
+		Documents: map[string]map[string]Role{
+			"acme-report-1": {"carol": RoleAdmin, "root": RoleViewer},
+		},
+	}
+
+	tests := []struct {
+		name       string
+		identity   string
+		documentID string
+		want       Role
+	}{
+		{"default role", "guest", "acme-report-1", RoleViewer},
+		{"global role", "root", "other-doc", RoleAdmin},
+		{"grants are additive", "root", "acme-report-1", RoleAdmin},
+		{"project role", "bob", "acme-plan-2", RoleEditor},
+		{"project does not match", "bob", "other-doc", RoleViewer},
+		{"document role", "carol", "acme-report-1", RoleAdmin},
+		{"global scope ignores documents", "carol", "", RoleViewer},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := policy.RoleFor(tt.identity, tt.documentID); got != tt.want {
+				t.Errorf("RoleFor(%q, %q) = %q, want %q", tt.identity, tt.documentID, got, tt.want)
+			}
+		})
+	}
+
+	if (&AccessPolicy{}).RoleFor("anyone", "doc").Includes(RoleViewer) {
+		t.Errorf("An empty policy should grant no access")
+	}
+	if !RoleAdmin.Includes(RoleEditor) || RoleViewer.Includes(RoleEditor) {
+		t.Errorf("Role.Includes() ordering is wrong")
+	}
+}
+
+func TestDocument_Parts(t *testing.T) {
+	doc := &Document{}
+	if err := doc.SetPart(Part{Title: "Methods", FirstChapter: 4, LastChapter: 6}); err != nil {
+		t.Fatalf("SetPart() error = %v", err)
+	}
+	if err := doc.SetPart(Part{Title: "Foundations", FirstChapter: 1, LastChapter: 3}); err != nil {
+		t.Fatalf("SetPart() error = %v", err)
+	}
+	if err := doc.SetPart(Part{Title: "Overlap", FirstChapter: 3, LastChapter: 5}); err == nil {
+		t.Errorf("SetPart() should reject overlapping parts")
+	}
+	if doc.Parts[0].Title != "Foundations" || doc.Parts[1].Title != "Methods" {
+		t.Fatalf("Parts should be ordered by first chapter, got %+v", doc.Parts)
+	}
+
+	if number, part := doc.PartContaining(5); number != 2 || part.Title != "Methods" {
+		t.Errorf("PartContaining(5) = %d, %+v", number, part)
+	}
+	if number, _ := doc.PartContaining(7); number != 0 {
+		t.Errorf("PartContaining(7) = %d, want 0", number)
+	}
+
+	// Inserting inside a part grows it and shifts the later ones
+	doc.InsertChapterInParts(2)
+	if doc.Parts[0].LastChapter != 4 || doc.Parts[1].FirstChapter != 5 || doc.Parts[1].LastChapter != 7 {
+		t.Errorf("InsertChapterInParts(2) = %+v", doc.Parts)
+	}
+
+	// Removing the only chapter of a part drops it
+	doc.SetPart(Part{Title: "Appendix", FirstChapter: 8, LastChapter: 8})
+	doc.RemoveChapterFromParts(8)
+	doc.RemoveChapterFromParts(1)
+	if len(doc.Parts) != 2 || doc.Parts[0].FirstChapter != 1 || doc.Parts[0].LastChapter != 3 || doc.Parts[1].FirstChapter != 4 {
+		t.Errorf("RemoveChapterFromParts() = %+v", doc.Parts)
+	}
+}
+
+func TestDocument_Abbreviations(t *testing.T) {
+	doc := &Document{}
+	doc.SetAbbreviation(Abbreviation{Term: "XML", Definition: "Extensible Markup Language"})
+	doc.SetAbbreviation(Abbreviation{Term: "api", Definition: "application programming interface"})
+	if err := doc.SetAbbreviation(Abbreviation{Term: " XML ", Definition: "eXtensible Markup Language"}); err != nil {
+		t.Fatalf("SetAbbreviation() error = %v", err)
+	}
+	if len(doc.Abbreviations) != 2 || doc.Abbreviations[0].Term != "api" || doc.Abbreviations[1].Definition != "eXtensible Markup Language" {
+		t.Errorf("Abbreviations should be sorted with redefinitions replaced, got %+v", doc.Abbreviations)
+	}
+	if err := doc.SetAbbreviation(Abbreviation{Term: "CSV"}); err == nil {
+		t.Errorf("SetAbbreviation() should require a definition")
+	}
+	if !doc.RemoveAbbreviation("XML") || doc.RemoveAbbreviation("XML") {
+		t.Errorf("RemoveAbbreviation() should report whether the term was defined")
+	}
+}
+
+func TestParseChapterRange(t *testing.T) {
+	chapters, err := ParseChapterRange("3-5")
+	if err != nil {
+		t.Fatalf("ParseChapterRange() error = %v", err)
+	}
+	if len(chapters) != 3 || chapters[0] != 3 || chapters[2] != 5 {
+		t.Errorf("ParseChapterRange(\"3-5\") = %v", chapters)
+	}
+
+	if chapters, err := ParseChapterRange("4"); err != nil || len(chapters) != 1 || chapters[0] != 4 {
+		t.Errorf("ParseChapterRange(\"4\") = %v, %v", chapters, err)
+	}
+
+	for _, invalid := range []string{"", "7-3", "0-2", "a-b", "3-"} {
+		if _, err := ParseChapterRange(invalid); err == nil {
+			t.Errorf("ParseChapterRange(%q) should fail", invalid)
+		}
+	}
+}
+
+func TestMarkdownProfile_PandocFormat(t *testing.T) {
+	var profile *MarkdownProfile
+	if got := profile.PandocFormat(); got != "markdown" {
+		t.Errorf("nil profile PandocFormat() = %q, want markdown", got)
+	}
+	if !profile.Enabled("footnotes") {
+		t.Errorf("Extensions should be on by default")
+	}
+
+	profile = &MarkdownProfile{}
+	if err := profile.Set("raw_html", false); err != nil {
+		t.Fatalf("Set() error = %v", err)
+	}
+	if err := profile.Set("smart", true); err != nil {
+		t.Fatalf("Set() error = %v", err)
+	}
+	if err := profile.Set("+emoji", true); err == nil {
+		t.Errorf("Set() should reject invalid extension names")
+	}
+	if got := profile.PandocFormat(); got != "markdown-raw_html+smart" {
+		t.Errorf("PandocFormat() = %q, want markdown-raw_html+smart", got)
+	}
+
+	// Other extensions follow the built-in ones in name order
+	profile.Set("emoji", true)
+	profile.Set("definition_lists", false)
+	if got := profile.PandocFormat(); got != "markdown-raw_html+smart-definition_lists+emoji" {
+		t.Errorf("PandocFormat() = %q, want markdown-raw_html+smart-definition_lists+emoji", got)
+	}
+	if enabled, ok := profile.Setting("emoji"); !enabled || !ok {
+		t.Errorf("Setting(emoji) = %v, %v", enabled, ok)
+	}
+	if _, ok := profile.Setting("footnotes"); ok {
+		t.Errorf("Unset extensions should not be reported as set")
+	}
+	if profile.Enabled("raw_html") || !profile.Enabled("pipe_tables") {
+		t.Errorf("Enabled() does not reflect the profile")
+	}
+}
+
+func TestFigure_Markup(t *testing.T) {
+	figure := Figure{ID: "fig-1.2", Caption: "A plot", ImagePath: "plot.png", Alignment: AlignCenter}
+	if got := figure.Markup(); got != "![A plot](plot.png){#fig-1.2}" {
+		t.Errorf("Markup() = %q", got)
+	}
+	figure.Alignment = AlignLeft
+	figure.Width = "50%"
+	if got := figure.Markup(); got != "![A plot](plot.png){#fig-1.2 .align-left width=50%}" {
+		t.Errorf("Markup() = %q", got)
+	}
+}
+
+func TestValidateImageWidth(t *testing.T) {
+	for _, width := range []string{"50%", "100%", "12.5%", "10cm", "3in", "400px"} {
+		if err := ValidateImageWidth(width); err != nil {
+			t.Errorf("ValidateImageWidth(%q) error = %v", width, err)
+		}
+	}
+	for _, width := range []string{"", "0%", "120%", "10", "wide", "50 %"} {
+		if err := ValidateImageWidth(width); err == nil {
+			t.Errorf("ValidateImageWidth(%q) should fail", width)
+		}
+	}
+}
+
+func TestCitation_Markup(t *testing.T) {
+	tests := []struct {
+		citation Citation
+		want     string
+	}{
+		{Citation{Key: "knuth84"}, "[@knuth84]"},
+		{Citation{Key: "knuth84", Prefix: "see", Locator: "p. 12"}, "[see @knuth84, p. 12]"},
+		{Citation{Key: "knuth84", SuppressAuthor: true}, "[-@knuth84]"},
+		{Citation{Key: "knuth84", AuthorInText: true, Locator: "chap. 3"}, "@knuth84 [chap. 3]"},
+	}
+	for _, tt := range tests {
+		if err := tt.citation.Validate(); err != nil {
+			t.Errorf("Validate(%+v) error = %v", tt.citation, err)
+		}
+		if got := tt.citation.Markup(); got != tt.want {
+			t.Errorf("Markup(%+v) = %q, want %q", tt.citation, got, tt.want)
+		}
+	}
+
+	for _, invalid := range []Citation{
+		{Key: ""},
+		{Key: "knuth 84"},
+		{Key: "knuth84."},
+		{Key: "knuth84", Locator: "p. 12]"},
+		{Key: "knuth84", AuthorInText: true, Prefix: "see"},
+	} {
+		if err := invalid.Validate(); err == nil {
+			t.Errorf("Validate(%+v) should fail", invalid)
+		}
+	}
+}
+
+func TestNumberingStyle_Labels(t *testing.T) {
+	arabic := NumberingStyle{}
+	roman := NumberingStyle{ChapterFormat: NumberFormatRoman, SectionDepth: 1}
+	letters := NumberingStyle{ChapterFormat: NumberFormatLetters}
+
+	if got := arabic.ChapterLabel(4); got != "4" {
+		t.Errorf("arabic ChapterLabel(4) = %q", got)
+	}
+	if got := roman.ChapterLabel(14); got != "XIV" {
+		t.Errorf("roman ChapterLabel(14) = %q", got)
+	}
+	for chapter, want := range map[ChapterNumber]string{1: "A", 26: "Z", 27: "AA", 53: "BA"} {
+		if got := letters.ChapterLabel(chapter); got != want {
+			t.Errorf("letters ChapterLabel(%d) = %q, want %q", chapter, got, want)
+		}
+	}
+
+	if got := roman.SectionLabel(SectionNumber{2, 3}); got != "II.3" {
+		t.Errorf("SectionLabel(2.3) = %q", got)
+	}
+	if got := roman.SectionLabel(SectionNumber{2, 3, 1}); got != "" {
+		t.Errorf("SectionLabel(2.3.1) beyond depth 1 = %q, want unnumbered", got)
+	}
+	if got := arabic.SectionLabel(SectionNumber{2, 3, 1}); got != "2.3.1" {
+		t.Errorf("SectionLabel(2.3.1) = %q", got)
+	}
+}
+
+func TestNumberingStyle_Validate(t *testing.T) {
+	valid := NumberingStyle{ChapterFormat: NumberFormatRoman, FigureNumbering: CounterContinuous, SectionDepth: 2}
+	if err := valid.Validate(); err != nil {
+		t.Errorf("Validate() error = %v", err)
+	}
+	for _, invalid := range []NumberingStyle{
+		{ChapterFormat: "greek"},
+		{TableNumbering: "section"},
+		{SectionDepth: -1},
+	} {
+		if err := invalid.Validate(); err == nil {
+			t.Errorf("Validate(%+v) should fail", invalid)
+		}
+	}
+}
+
+func TestChapterHeadingStyle_Heading(t *testing.T) {
+	roman := NumberingStyle{ChapterFormat: NumberFormatRoman}
+	dotted := "%d."
+	none := ""
+
+	tests := []struct {
+		heading ChapterHeadingStyle
+		title   string
+		want    string
+	}{
+		{ChapterHeadingStyle{}, "Methods", "Chapter III: Methods"},
+		{ChapterHeadingStyle{Prefix: &dotted}, "Methods", "III. Methods"},
+		{ChapterHeadingStyle{Prefix: &none}, "Methods", "Methods"},
+		{ChapterHeadingStyle{Unnumbered: []string{"introduction"}}, "Introduction", "Introduction"},
+		{ChapterHeadingStyle{Unnumbered: []string{"Introduction"}, Case: HeadingCaseUpper}, "Introduction", "INTRODUCTION"},
+		{ChapterHeadingStyle{Case: HeadingCaseTitle}, "the state of the art in NLP", "Chapter III: The State of the Art in NLP"},
+		{ChapterHeadingStyle{Case: HeadingCaseLower}, "Results", "Chapter III: results"},
+	}
+	for _, tt := range tests {
+		if got := tt.heading.Heading(3, tt.title, roman); got != tt.want {
+			t.Errorf("Heading(%q) with %+v = %q, want %q", tt.title, tt.heading, got, tt.want)
+		}
+	}
+
+	twice := "%d/%d"
+	for _, invalid := range []ChapterHeadingStyle{{Case: "small-caps"}, {Prefix: &twice}} {
+		if err := invalid.Validate(); err == nil {
+			t.Errorf("Validate(%+v) should fail", invalid)
+		}
+	}
+}
+
+func TestExportProtection_Validate(t *testing.T) {
+	valid := []struct {
+		format     ExportFormat
+		protection ExportProtection
+	}{
+		{ExportFormatPDF, ExportProtection{UserPassword: "secret", AllowPrint: true, AllowCopy: true}},
+		{ExportFormatPDF, ExportProtection{AllowPrint: true}},
+		{ExportFormatDOCX, ExportProtection{UserPassword: "secret", AllowPrint: true, AllowCopy: true}},
+	}
+	for _, tt := range valid {
+		if err := tt.protection.Validate(tt.format); err != nil {
+			t.Errorf("Validate(%s, %+v) error = %v", tt.format, tt.protection, err)
+		}
+	}
+
+	invalid := []struct {
+		format     ExportFormat
+		protection ExportProtection
+	}{
+		{ExportFormatPDF, ExportProtection{AllowPrint: true, AllowCopy: true}},
+		{ExportFormatDOCX, ExportProtection{AllowPrint: true, AllowCopy: true}},
+		{ExportFormatDOCX, ExportProtection{UserPassword: "secret", AllowCopy: true}},
+		{ExportFormatHTML, ExportProtection{UserPassword: "secret", AllowPrint: true, AllowCopy: true}},
+		{ExportFormatPDF, ExportProtection{UserPassword: "sec\nret", AllowPrint: true, AllowCopy: true}},
+	}
+	for _, tt := range invalid {
+		if err := tt.protection.Validate(tt.format); err == nil {
+			t.Errorf("Validate(%s, %+v) should fail", tt.format, tt.protection)
+		}
+	}
+}
+
+func TestValidateStyle_PageTemplates(t *testing.T) {
+	style := &Style{HeaderFooter: HeaderFooter{
+		HeaderTemplate: "{document_title}",
+		EvenPage:       &PageTemplates{Footer: "{pages}"},
+	}}
+	validation := ValidateStyle(style)
+	if len(validation.Warnings) != 1 || !strings.Contains(validation.Warnings[0], "Even page footer: Unknown template variable: {pages}") {
+		t.Errorf("ValidateStyle() warnings = %v", validation.Warnings)
+	}
+}
+
+func TestValidateStyle_HTML(t *testing.T) {
+	style := &Style{HTML: HTMLStyle{MaxWidth: "45rem", DarkMode: true, DarkLinkColor: "#88c0d0"}}
+	if validation := ValidateStyle(style); len(validation.Warnings) != 0 {
+		t.Errorf("ValidateStyle() warnings = %v", validation.Warnings)
+	}
+
+	style.HTML = HTMLStyle{MaxWidth: "wide", DarkBackground: "night"}
+	validation := ValidateStyle(style)
+	if len(validation.Warnings) != 2 {
+		t.Fatalf("ValidateStyle() warnings = %v", validation.Warnings)
+	}
+	if !strings.Contains(validation.Warnings[0], "HTML dark background") || !strings.Contains(validation.Warnings[1], "HTML max width 'wide'") {
+		t.Errorf("ValidateStyle() warnings = %v", validation.Warnings)
+	}
+}
+
+func TestTableData_Markdown(t *testing.T) {
+	data := &TableData{
+		Header:     []string{"Item", "Notes"},
+		Rows:       [][]string{{"a|b", "Two\nlines"}, {"Café", ""}},
+		Alignments: []ColumnAlignment{ColumnLeft, ColumnRight},
+	}
+	if got, want := data.Markdown(TableFormatMarkdown), "| Item | Notes |\n|:---|---:|\n| a\\|b | Two lines |\n| Café |  |"; got != want {
+		t.Errorf("Markdown(markdown) =\n%s\nwant:\n%s", got, want)
+	}
+
+	want := "+------+-------+\n" +
+		"| Item | Notes |\n" +
+		"+:=====+======:+\n" +
+		"| a\\|b | Two   |\n" +
+		"|      | lines |\n" +
+		"+------+-------+\n" +
+		"| Café |       |\n" +
+		"+------+-------+"
+	if got := data.Markdown(TableFormatGrid); got != want {
+		t.Errorf("Markdown(grid) =\n%s\nwant:\n%s", got, want)
+	}
+
+	if err := (&TableData{Header: []string{"A"}, Alignments: []ColumnAlignment{"middle"}}).Validate(); err == nil {
+		t.Error("Expected an error for an invalid alignment")
+	}
+}
@@ -0,0 +1,226 @@
+// Package usage records what each client of a shared server produces and enforces
+// per-client quotas on documents and exports.
+package usage
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/gomcpgo/docgen/pkg/config"
+	"github.com/gomcpgo/docgen/pkg/storage"
+	"github.com/gomcpgo/docgen/pkg/types"
+)
+
+// clientIDKey is the context key for the calling client's identity
+type clientIDKey struct{}
+
+// WithClientID returns a context carrying the calling client's identity. Transports
+// that serve several clients, such as HTTP or SSE, attach the token or user they
+// authenticated before calling the tool handler.
+func WithClientID(ctx context.Context, clientID string) context.Context {
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	return context.WithValue(ctx, clientIDKey{}, clientID)
+}
+
+// Tracker records per-client usage in the usage ledger
+type Tracker struct {
+	config  *config.Config
+	storage storage.Storage
+	mu      sync.Mutex
+}
+
+// NewTracker creates a new usage tracker
+func NewTracker(cfg *config.Config, stor storage.Storage) *Tracker {
+	return &Tracker{
+		config:  cfg,
+		storage: stor,
+	}
+}
+
+// ClientID returns the identity attached to the context, or the configured
+// client ID when the transport supplied none
+func (t *Tracker) ClientID(ctx context.Context) string {
+	if ctx != nil {
+		if clientID, ok := ctx.Value(clientIDKey{}).(string); ok && clientID != "" {
+			return clientID
+		}
+	}
+	if t.config.ClientID != "" {
+		return t.config.ClientID
+	}
+	return "local"
+}
+
+// CheckDocument returns an error if the client may not create another document
+func (t *Tracker) CheckDocument(clientID string) error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	client, quota, err := t.client(clientID)
+	if err != nil {
+		return err
+	}
+	if quota.Documents > 0 && len(client.Documents) >= quota.Documents {
+		return fmt.Errorf("document quota exceeded for client %s: %d of %d documents", clientID, len(client.Documents), quota.Documents)
+	}
+	return nil
+}
+
+// CheckExport returns an error if the client may not export again
+func (t *Tracker) CheckExport(clientID string) error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	client, quota, err := t.client(clientID)
+	if err != nil {
+		return err
+	}
+	if quota.Exports > 0 && client.Exports >= quota.Exports {
+		return fmt.Errorf("export quota exceeded for client %s: %d of %d exports", clientID, client.Exports, quota.Exports)
+	}
+	if quota.ExportBytes > 0 && client.ExportBytes >= quota.ExportBytes {
+		return fmt.Errorf("export size quota exceeded for client %s: %d of %d bytes", clientID, client.ExportBytes, quota.ExportBytes)
+	}
+	return nil
+}
+
+// RecordDocumentCreated records a document created by the client
+func (t *Tracker) RecordDocumentCreated(clientID, documentID string) error {
+	return t.update(func(ledger *types.UsageLedger) {
+		client := clientUsage(ledger, clientID)
+		client.Documents = append(client.Documents, documentID)
+		client.DocumentsCreated++
+		client.LastActivity = time.Now()
+	})
+}
+
+// RecordDocumentDeleted releases a deleted document from the client that created it
+func (t *Tracker) RecordDocumentDeleted(documentID string) error {
+	return t.update(func(ledger *types.UsageLedger) {
+		for _, client := range ledger.Clients {
+			for i, id := range client.Documents {
+				if id == documentID {
+					client.Documents = append(client.Documents[:i], client.Documents[i+1:]...)
+					return
+				}
+			}
+		}
+	})
+}
+
+// RecordDocumentRenamed moves a document to its new ID in the client that created it
+func (t *Tracker) RecordDocumentRenamed(documentID, newID string) error {
+	return t.update(func(ledger *types.UsageLedger) {
+		for _, client := range ledger.Clients {
+			for i, id := range client.Documents {
+				if id == documentID {
+					client.Documents[i] = newID
+					return
+				}
+			}
+		}
+	})
+}
+
+// RecordExport records an export of the given size made by the client
+func (t *Tracker) RecordExport(clientID string, bytes int64) error {
+	return t.update(func(ledger *types.UsageLedger) {
+		client := clientUsage(ledger, clientID)
+		client.Exports++
+		client.ExportBytes += bytes
+		client.LastActivity = time.Now()
+	})
+}
+
+// Report returns the usage of one client, or of every client ordered by ID when
+// clientID is empty. Each entry carries the quota that applies to it.
+func (t *Tracker) Report(clientID string) ([]types.ClientUsage, error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	ledger, err := t.storage.LoadUsageLedger()
+	if err != nil {
+		return nil, fmt.Errorf("failed to load usage ledger: %w", err)
+	}
+
+	report := []types.ClientUsage{}
+	for id, client := range ledger.Clients {
+		if clientID != "" && id != clientID {
+			continue
+		}
+		entry := *client
+		entry.ClientID = id
+		quota := t.quota(client)
+		entry.Quota = &quota
+		report = append(report, entry)
+	}
+	sort.Slice(report, func(i, j int) bool {
+		return report[i].ClientID < report[j].ClientID
+	})
+
+	// A client with no recorded usage still has a quota
+	if clientID != "" && len(report) == 0 {
+		quota := t.quota(nil)
+		report = append(report, types.ClientUsage{ClientID: clientID, Documents: []string{}, Quota: &quota})
+	}
+	return report, nil
+}
+
+// client loads a client's usage and the quota that applies to it
+func (t *Tracker) client(clientID string) (*types.ClientUsage, types.UsageQuota, error) {
+	ledger, err := t.storage.LoadUsageLedger()
+	if err != nil {
+		return nil, types.UsageQuota{}, fmt.Errorf("failed to load usage ledger: %w", err)
+	}
+	client := ledger.Clients[clientID]
+	if client == nil {
+		client = &types.ClientUsage{ClientID: clientID}
+	}
+	return client, t.quota(client), nil
+}
+
+// quota returns the client's own quota, falling back to the configured defaults
+func (t *Tracker) quota(client *types.ClientUsage) types.UsageQuota {
+	if client != nil && client.Quota != nil {
+		return *client.Quota
+	}
+	return types.UsageQuota{
+		Documents:   t.config.QuotaDocuments,
+		Exports:     t.config.QuotaExports,
+		ExportBytes: t.config.QuotaExportBytes,
+	}
+}
+
+// update applies a change to the ledger and saves it
+func (t *Tracker) update(change func(ledger *types.UsageLedger)) error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	ledger, err := t.storage.LoadUsageLedger()
+	if err != nil {
+		return fmt.Errorf("failed to load usage ledger: %w", err)
+	}
+	change(ledger)
+	if err := t.storage.SaveUsageLedger(ledger); err != nil {
+		return fmt.Errorf("failed to save usage ledger: %w", err)
+	}
+	return nil
+}
+
+// clientUsage returns the ledger entry for a client, creating it if needed
+func clientUsage(ledger *types.UsageLedger, clientID string) *types.ClientUsage {
+	if ledger.Clients == nil {
+		ledger.Clients = make(map[string]*types.ClientUsage)
+	}
+	client := ledger.Clients[clientID]
+	if client == nil {
+		client = &types.ClientUsage{ClientID: clientID, Documents: []string{}}
+		ledger.Clients[clientID] = client
+	}
+	return client
+}
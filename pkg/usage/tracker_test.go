@@ -0,0 +1,118 @@
+package usage
+
+import (
+	"context"
+	"testing"
+
+	"github.com/gomcpgo/docgen/pkg/config"
+	"github.com/gomcpgo/docgen/pkg/storage"
+	"github.com/gomcpgo/docgen/pkg/types"
+)
+
+func setupTestTracker(t *testing.T) (*Tracker, storage.Storage) {
+	cfg := &config.Config{
+		RootDir:        t.TempDir(),
+		ClientID:       "local",
+		QuotaDocuments: 2,
+		QuotaExports:   3,
+	}
+	stor := storage.NewFileSystemStorage(cfg)
+	return NewTracker(cfg, stor), stor
+}
+
+func TestTracker_ClientID(t *testing.T) {
+	tracker, _ := setupTestTracker(t)
+
+	if got := tracker.ClientID(context.Background()); got != "local" {
+		t.Errorf("ClientID() without identity = %q, want local", got)
+	}
+	if got := tracker.ClientID(nil); got != "local" {
+		t.Errorf("ClientID(nil) = %q, want local", got)
+	}
+	if got := tracker.ClientID(WithClientID(context.Background(), "team-a")); got != "team-a" {
+		t.Errorf("ClientID() = %q, want team-a", got)
+	}
+}
+
+func TestTracker_DocumentQuota(t *testing.T) {
+	tracker, _ := setupTestTracker(t)
+
+	for _, docID := range []string{"doc-1", "doc-2"} {
+		if err := tracker.CheckDocument("alice"); err != nil {
+			t.Fatalf("CheckDocument() error = %v", err)
+		}
+		if err := tracker.RecordDocumentCreated("alice", docID); err != nil {
+			t.Fatalf("RecordDocumentCreated() error = %v", err)
+		}
+	}
+	if err := tracker.CheckDocument("alice"); err == nil {
+		t.Errorf("CheckDocument() should fail once the quota is reached")
+	}
+
+	// Quotas are per client
+	if err := tracker.CheckDocument("bob"); err != nil {
+		t.Errorf("CheckDocument() for another client error = %v", err)
+	}
+
+	// Deleting a document frees quota but keeps the creation count
+	if err := tracker.RecordDocumentDeleted("doc-1"); err != nil {
+		t.Fatalf("RecordDocumentDeleted() error = %v", err)
+	}
+	if err := tracker.CheckDocument("alice"); err != nil {
+		t.Errorf("CheckDocument() after deletion error = %v", err)
+	}
+
+	report, err := tracker.Report("alice")
+	if err != nil {
+		t.Fatalf("Report() error = %v", err)
+	}
+	if len(report) != 1 || len(report[0].Documents) != 1 || report[0].DocumentsCreated != 2 {
+		t.Errorf("Unexpected report %+v", report)
+	}
+
+	// A renamed document stays with its creator
+	if err := tracker.RecordDocumentRenamed("doc-2", "doc-3"); err != nil {
+		t.Fatalf("RecordDocumentRenamed() error = %v", err)
+	}
+	report, _ = tracker.Report("alice")
+	if report[0].Documents[0] != "doc-3" {
+		t.Errorf("Expected doc-3 after the rename, got %v", report[0].Documents)
+	}
+}
+
+func TestTracker_ExportQuota(t *testing.T) {
+	tracker, stor := setupTestTracker(t)
+
+	for i := 0; i < 3; i++ {
+		if err := tracker.CheckExport("alice"); err != nil {
+			t.Fatalf("CheckExport() error = %v", err)
+		}
+		if err := tracker.RecordExport("alice", 1000); err != nil {
+			t.Fatalf("RecordExport() error = %v", err)
+		}
+	}
+	if err := tracker.CheckExport("alice"); err == nil {
+		t.Errorf("CheckExport() should fail once the quota is reached")
+	}
+
+	// A client's own quota overrides the default
+	ledger, err := stor.LoadUsageLedger()
+	if err != nil {
+		t.Fatalf("LoadUsageLedger() error = %v", err)
+	}
+	ledger.Clients["alice"].Quota = &types.UsageQuota{Exports: 10, ExportBytes: 3000}
+	if err := stor.SaveUsageLedger(ledger); err != nil {
+		t.Fatalf("SaveUsageLedger() error = %v", err)
+	}
+	if err := tracker.CheckExport("alice"); err == nil {
+		t.Errorf("CheckExport() should fail once the byte quota is reached")
+	}
+
+	report, err := tracker.Report("")
+	if err != nil {
+		t.Fatalf("Report() error = %v", err)
+	}
+	if len(report) != 1 || report[0].Exports != 3 || report[0].ExportBytes != 3000 || report[0].Quota.Exports != 10 {
+		t.Errorf("Unexpected report %+v", report)
+	}
+}
@@ -0,0 +1,229 @@
+// Package watch re-exports documents automatically when their content changes.
+package watch
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"io/fs"
+	"log"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/gomcpgo/docgen/pkg/config"
+	"github.com/gomcpgo/docgen/pkg/types"
+)
+
+// pollInterval is how often document directories are scanned for changes
+const pollInterval = time.Second
+
+// ExportFunc exports a whole document and returns the path of the exported
+// file, giving up when the context is cancelled
+type ExportFunc func(ctx context.Context, documentID string, format types.ExportFormat) (string, error)
+
+// fingerprint summarizes the state of a document's source files
+type fingerprint struct {
+	files   int
+	size    int64
+	modTime int64
+}
+
+// Watcher polls document directories and, once a document has been quiet for the
+// debounce interval after a change, exports it and copies the result to the
+// document's stable "latest" path
+type Watcher struct {
+	config *config.Config
+	export ExportFunc
+	format types.ExportFormat
+
+	mu           sync.Mutex
+	fingerprints map[string]fingerprint
+	pending      map[string]time.Time // document ID -> time the export is due
+
+	stop chan struct{}
+	done chan struct{}
+}
+
+// NewWatcher creates a watcher using the format and debounce from the configuration
+func NewWatcher(cfg *config.Config, export ExportFunc) *Watcher {
+	return &Watcher{
+		config:       cfg,
+		export:       export,
+		format:       types.ExportFormat(cfg.WatchFormat),
+		fingerprints: make(map[string]fingerprint),
+		pending:      make(map[string]time.Time),
+	}
+}
+
+// Start begins watching in the background. The current state of every document is
+// taken as the baseline, so nothing is exported until something changes.
+func (w *Watcher) Start() {
+	w.stop = make(chan struct{})
+	w.done = make(chan struct{})
+	w.poll(time.Now())
+
+	go func() {
+		defer close(w.done)
+		ticker := time.NewTicker(pollInterval)
+		defer ticker.Stop()
+		for {
+			select {
+			case now := <-ticker.C:
+				w.poll(now)
+			case <-w.stop:
+				return
+			}
+		}
+	}()
+
+	log.Printf("[DOCGEN WATCH] Watching %s, exporting %s after %v of inactivity", w.config.RootDir, w.format, w.config.WatchDebounce)
+}
+
+// Stop stops watching and waits for any export in progress to finish
+func (w *Watcher) Stop() {
+	if w.stop == nil {
+		return
+	}
+	close(w.stop)
+	<-w.done
+	w.stop = nil
+}
+
+// poll scans all documents, schedules exports for changed ones and runs exports
+// whose debounce interval has passed
+func (w *Watcher) poll(now time.Time) {
+	documentIDs, err := w.listDocuments()
+	if err != nil {
+		log.Printf("[DOCGEN WATCH] Failed to list documents: %v", err)
+		return
+	}
+
+	var due []string
+	w.mu.Lock()
+	for _, documentID := range documentIDs {
+		current, err := w.fingerprint(documentID)
+		if err != nil {
+			continue
+		}
+		previous, known := w.fingerprints[documentID]
+		w.fingerprints[documentID] = current
+		if known && current != previous {
+			// Each change pushes the export back by the debounce interval
+			w.pending[documentID] = now.Add(w.config.WatchDebounce)
+		}
+	}
+	for documentID, dueAt := range w.pending {
+		if !now.Before(dueAt) {
+			due = append(due, documentID)
+			delete(w.pending, documentID)
+		}
+	}
+	w.mu.Unlock()
+
+	for _, documentID := range due {
+		if err := w.exportLatest(documentID); err != nil {
+			log.Printf("[DOCGEN WATCH] Export of %s failed: %v", documentID, err)
+		}
+	}
+}
+
+// exportLatest exports a document and copies the result to its latest path
+func (w *Watcher) exportLatest(documentID string) error {
+	// Background exports aren't cancelled: Stop waits for them to finish
+	outputPath, err := w.export(context.Background(), documentID, w.format)
+	if err != nil {
+		return err
+	}
+
+	latestPath := w.config.LatestExportPath(documentID, string(w.format))
+	if err := copyFileAtomic(outputPath, latestPath); err != nil {
+		return fmt.Errorf("failed to update latest export: %w", err)
+	}
+
+	log.Printf("[DOCGEN WATCH] Updated %s", latestPath)
+	return nil
+}
+
+// listDocuments returns the IDs of all directories under the root that hold a manifest
+func (w *Watcher) listDocuments() ([]string, error) {
+	entries, err := os.ReadDir(w.config.RootDir)
+	if err != nil {
+		return nil, err
+	}
+
+	var documentIDs []string
+	for _, entry := range entries {
+		if !entry.IsDir() {
+			continue
+		}
+		if _, err := os.Stat(w.config.ManifestPath(entry.Name())); err == nil {
+			documentIDs = append(documentIDs, entry.Name())
+		}
+	}
+	return documentIDs, nil
+}
+
+// generatedFiles are written by the server itself whenever a document is
+// exported: compiled chapters, the word count stats and the export history
+var generatedFiles = map[string]bool{
+	"chapter.md":   true,
+	"stats.yaml":   true,
+	"exports.yaml": true,
+}
+
+// fingerprint summarizes a document's source files. Files the server writes on
+// every export, and the images it converts for exports, are ignored, as they
+// would otherwise retrigger the watcher after each of its own exports.
+func (w *Watcher) fingerprint(documentID string) (fingerprint, error) {
+	var result fingerprint
+	derivedDir := w.config.DerivedAssetsPath(documentID)
+	err := filepath.WalkDir(w.config.DocumentPath(documentID), func(path string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if entry.IsDir() && path == derivedDir {
+			return filepath.SkipDir
+		}
+		if entry.IsDir() || generatedFiles[entry.Name()] {
+			return nil
+		}
+		info, err := entry.Info()
+		if err != nil {
+			return err
+		}
+		result.files++
+		result.size += info.Size()
+		if modTime := info.ModTime().UnixNano(); modTime > result.modTime {
+			result.modTime = modTime
+		}
+		return nil
+	})
+	return result, err
+}
+
+// copyFileAtomic copies src to dst through a temporary file so viewers never see a
+// partially written export
+func copyFileAtomic(src, dst string) error {
+	in, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+
+	tmp, err := os.CreateTemp(filepath.Dir(dst), filepath.Base(dst)+".tmp-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := io.Copy(tmp, in); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), dst)
+}
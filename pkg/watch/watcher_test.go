@@ -0,0 +1,131 @@
+package watch
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gomcpgo/docgen/pkg/config"
+	"github.com/gomcpgo/docgen/pkg/types"
+)
+
+func setupTestWatcher(t *testing.T) (*Watcher, *config.Config, *[]string) {
+	tempDir := t.TempDir()
+	cfg := &config.Config{
+		RootDir:       tempDir,
+		ExportsDir:    filepath.Join(tempDir, "exports"),
+		WatchFormat:   "html",
+		WatchDebounce: 2 * time.Second,
+	}
+
+	// Create a document with one section file
+	sectionsDir := cfg.SectionsPath("doc-1", "01")
+	if err := os.MkdirAll(sectionsDir, 0755); err != nil {
+		t.Fatalf("Failed to create document: %v", err)
+	}
+	os.WriteFile(cfg.ManifestPath("doc-1"), []byte("document:\n  id: doc-1\n"), 0644)
+	os.WriteFile(filepath.Join(sectionsDir, "1.1.md"), []byte("First draft"), 0644)
+	os.MkdirAll(cfg.ExportsDir, 0755)
+
+	exported := &[]string{}
+	export := func(ctx context.Context, documentID string, format types.ExportFormat) (string, error) {
+		*exported = append(*exported, documentID)
+		outputPath := cfg.ExportPath(documentID, string(format))
+		os.WriteFile(outputPath, []byte("<html>export</html>"), 0644)
+		// Exports rebuild chapter.md, which must not retrigger the watcher
+		os.WriteFile(cfg.ChapterContentPath(documentID, "01"), []byte("# Rebuilt"), 0644)
+		return outputPath, nil
+	}
+
+	return NewWatcher(cfg, export), cfg, exported
+}
+
+func TestWatcher_DebouncedExport(t *testing.T) {
+	watcher, cfg, exported := setupTestWatcher(t)
+	start := time.Now()
+
+	// The first scan only records the baseline
+	watcher.poll(start)
+	if len(*exported) != 0 {
+		t.Fatalf("Baseline scan should not export, got %v", *exported)
+	}
+
+	// A change schedules an export after the debounce interval
+	sectionPath := cfg.SectionPath("doc-1", "01", "1.1")
+	os.WriteFile(sectionPath, []byte("Second draft with more text"), 0644)
+	watcher.poll(start.Add(1 * time.Second))
+	watcher.poll(start.Add(2 * time.Second))
+	if len(*exported) != 0 {
+		t.Fatalf("Export should wait for the debounce interval, got %v", *exported)
+	}
+
+	// Another edit pushes the export back
+	os.WriteFile(sectionPath, []byte("Third draft with even more text"), 0644)
+	watcher.poll(start.Add(2500 * time.Millisecond))
+	watcher.poll(start.Add(4 * time.Second))
+	if len(*exported) != 0 {
+		t.Fatalf("A new edit should restart the debounce interval, got %v", *exported)
+	}
+
+	watcher.poll(start.Add(5 * time.Second))
+	if len(*exported) != 1 || (*exported)[0] != "doc-1" {
+		t.Fatalf("Expected one export of doc-1, got %v", *exported)
+	}
+
+	latest, err := os.ReadFile(cfg.LatestExportPath("doc-1", "html"))
+	if err != nil {
+		t.Fatalf("Latest export not written: %v", err)
+	}
+	if string(latest) != "<html>export</html>" {
+		t.Errorf("Unexpected latest export content: %s", latest)
+	}
+
+	// The rebuilt chapter.md does not cause another export
+	watcher.poll(start.Add(6 * time.Second))
+	watcher.poll(start.Add(10 * time.Second))
+	if len(*exported) != 1 {
+		t.Errorf("Export output should not retrigger the watcher, got %v", *exported)
+	}
+}
+
+func TestWatcher_IgnoresNonDocuments(t *testing.T) {
+	watcher, cfg, _ := setupTestWatcher(t)
+	os.MkdirAll(filepath.Join(cfg.RootDir, "styles"), 0755)
+
+	documentIDs, err := watcher.listDocuments()
+	if err != nil {
+		t.Fatalf("listDocuments() error = %v", err)
+	}
+	if len(documentIDs) != 1 || documentIDs[0] != "doc-1" {
+		t.Errorf("Expected only doc-1, got %v", documentIDs)
+	}
+}
+
+func TestWatcher_OwnExportsDoNotRetrigger(t *testing.T) {
+	watcher, cfg, exported := setupTestWatcher(t)
+	// Exports also record themselves in the history, update the word count
+	// stats and convert images, whether they succeed or not
+	export := watcher.export
+	watcher.export = func(ctx context.Context, documentID string, format types.ExportFormat) (string, error) {
+		runs := len(*exported)
+		os.WriteFile(cfg.ExportHistoryPath(documentID), []byte(strings.Repeat("- export\n", runs+1)), 0644)
+		os.WriteFile(cfg.StatsPath(documentID), []byte(strings.Repeat("- words\n", runs+1)), 0644)
+		os.MkdirAll(cfg.DerivedAssetsPath(documentID), 0755)
+		os.WriteFile(filepath.Join(cfg.DerivedAssetsPath(documentID), fmt.Sprintf("chart-%d.png", runs)), []byte("PNG"), 0644)
+		return export(ctx, documentID, format)
+	}
+	start := time.Now()
+
+	watcher.poll(start)
+	os.WriteFile(cfg.SectionPath("doc-1", "01", "1.1"), []byte("Second draft"), 0644)
+	for i := 1; i <= 20; i++ {
+		watcher.poll(start.Add(time.Duration(i) * time.Second))
+	}
+	if len(*exported) != 1 {
+		t.Errorf("Expected one export, got %d", len(*exported))
+	}
+}